@@ -4,9 +4,11 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io/ioutil"
+	"mime"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 
 	"gitlab.com/NebulousLabs/fastrand"
@@ -99,6 +101,198 @@ func TUSMaxSize() (int64, bool) {
 	return maxSize, true
 }
 
+// SectorCacheSize returns the sectorCacheSize environment variable if set.
+func SectorCacheSize() (uint64, bool) {
+	sizeStr, ok := os.LookupEnv(sectorCacheSize)
+	if !ok {
+		return 0, false
+	}
+	var size uint64
+	_, err := fmt.Sscan(sizeStr, &size)
+	if err != nil {
+		Critical("failed to marshal SKYD_SECTOR_CACHE_SIZE environment variable")
+		return 0, false
+	}
+	return size, true
+}
+
+// RecentUploadCacheSize returns the recentUploadCacheSize environment variable
+// if set.
+func RecentUploadCacheSize() (uint64, bool) {
+	sizeStr, ok := os.LookupEnv(recentUploadCacheSize)
+	if !ok {
+		return 0, false
+	}
+	var size uint64
+	_, err := fmt.Sscan(sizeStr, &size)
+	if err != nil {
+		Critical("failed to marshal SKYD_RECENT_UPLOAD_CACHE_SIZE environment variable")
+		return 0, false
+	}
+	return size, true
+}
+
+// RegistryCacheSize returns the registryCacheSize environment variable if set.
+func RegistryCacheSize() (uint64, bool) {
+	sizeStr, ok := os.LookupEnv(registryCacheSize)
+	if !ok {
+		return 0, false
+	}
+	var size uint64
+	_, err := fmt.Sscan(sizeStr, &size)
+	if err != nil {
+		Critical("failed to marshal SKYD_REGISTRY_CACHE_SIZE environment variable")
+		return 0, false
+	}
+	return size, true
+}
+
+// ContractDefragWindow returns the parsed contractDefragWindow environment
+// variable if set. The window starts at the start hour and ends at the
+// beginning of the end hour. It wraps around midnight if end is smaller than
+// start.
+func ContractDefragWindow() (start, end int, ok bool) {
+	windowStr, ok := os.LookupEnv(contractDefragWindow)
+	if !ok {
+		return 0, 0, false
+	}
+	start, end, err := parseHourRange(windowStr)
+	if err != nil {
+		Critical(fmt.Sprintf("failed to parse SKYD_CONTRACT_DEFRAG_WINDOW environment variable: %v", err))
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// parseHourRange parses a range of hours like "2-5".
+func parseHourRange(hours string) (int, int, error) {
+	parts := strings.Split(hours, "-")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("range '%v' should have the format 'start-end'", hours)
+	}
+	start, err1 := strconv.Atoi(strings.TrimSpace(parts[0]))
+	end, err2 := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err1 != nil || err2 != nil {
+		return 0, 0, fmt.Errorf("range '%v' contains an invalid hour", hours)
+	}
+	if start < 0 || start > 23 || end < 0 || end > 23 {
+		return 0, 0, fmt.Errorf("range '%v' contains an hour outside of 0-23", hours)
+	}
+	if start == end {
+		return 0, 0, fmt.Errorf("range '%v' is empty", hours)
+	}
+	return start, end, nil
+}
+
+// HNSResolver returns the hnsResolver environment variable.
+func HNSResolver() string {
+	return os.Getenv(hnsResolver)
+}
+
+// HostAllowlist returns the hostAllowlist and hostAllowlistKey environment
+// variables.
+func HostAllowlist() (source, publicKey string) {
+	return os.Getenv(hostAllowlist), os.Getenv(hostAllowlistKey)
+}
+
+// UploadScanner returns the uploadScanner environment variable.
+func UploadScanner() string {
+	return os.Getenv(uploadScanner)
+}
+
+// PersistS3Settings are the settings of the S3 compatible object store used
+// for the renter's persistence.
+type PersistS3Settings struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	Prefix          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// PersistS3 returns the settings of the object store for the renter's
+// persistence from the environment. ok is false if no bucket is set, in which
+// case the renter persists to the local disk.
+func PersistS3() (PersistS3Settings, bool) {
+	bucket, ok := os.LookupEnv(persistS3Bucket)
+	if !ok || bucket == "" {
+		return PersistS3Settings{}, false
+	}
+	return PersistS3Settings{
+		Endpoint:        os.Getenv(persistS3Endpoint),
+		Region:          os.Getenv(persistS3Region),
+		Bucket:          bucket,
+		Prefix:          os.Getenv(persistS3Prefix),
+		AccessKeyID:     os.Getenv(persistS3AccessKeyID),
+		SecretAccessKey: os.Getenv(persistS3SecretAccessKey),
+	}, true
+}
+
+// ContentTypeOverrides returns the parsed contentTypeOverrides environment
+// variable if set. The returned map uses lower-case file extensions with a
+// leading dot as keys.
+func ContentTypeOverrides() (map[string]string, bool) {
+	overridesStr, ok := os.LookupEnv(contentTypeOverrides)
+	if !ok {
+		return nil, false
+	}
+	overrides := make(map[string]string)
+	for _, pair := range strings.Split(overridesStr, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		ext, contentType, err := parseContentTypeOverride(pair)
+		if err != nil {
+			Critical(fmt.Sprintf("failed to parse SKYD_CONTENT_TYPE_OVERRIDES environment variable: %v", err))
+			return nil, false
+		}
+		overrides[ext] = contentType
+	}
+	return overrides, true
+}
+
+// SkynetEarlyHints returns the parsed skynetEarlyHints environment variable if
+// set. The returned mode is either SkynetEarlyHintsLink or SkynetEarlyHints103.
+func SkynetEarlyHints() (string, bool) {
+	modeStr, ok := os.LookupEnv(skynetEarlyHints)
+	if !ok {
+		return "", false
+	}
+	mode := strings.ToLower(strings.TrimSpace(modeStr))
+	switch mode {
+	case "", "off":
+		return "", false
+	case SkynetEarlyHintsLink, SkynetEarlyHints103:
+		return mode, true
+	default:
+		Critical(fmt.Sprintf("failed to parse SKYD_SKYNET_EARLY_HINTS environment variable: unknown mode '%v'", modeStr))
+		return "", false
+	}
+}
+
+// parseContentTypeOverride parses a single ext=type pair of the
+// contentTypeOverrides environment variable.
+func parseContentTypeOverride(pair string) (string, string, error) {
+	i := strings.Index(pair, "=")
+	if i == -1 {
+		return "", "", fmt.Errorf("override '%v' is missing a '='", pair)
+	}
+	ext := strings.ToLower(strings.TrimSpace(pair[:i]))
+	contentType := strings.TrimSpace(pair[i+1:])
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	if ext == "." || strings.ContainsAny(ext[1:], "./") {
+		return "", "", fmt.Errorf("override '%v' has an invalid extension", pair)
+	}
+	if _, _, err := mime.ParseMediaType(contentType); err != nil {
+		return "", "", fmt.Errorf("override '%v' has an invalid content type: %v", pair, err)
+	}
+	return ext, contentType, nil
+}
+
 // apiPasswordFilePath returns the path to the API's password file. The password
 // file is stored in the Sia data directory.
 func apiPasswordFilePath() string {
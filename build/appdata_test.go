@@ -146,3 +146,123 @@ func TestSiaExchangeRate(t *testing.T) {
 		t.Errorf("Expected exchange rate to be %v but was %v", newRate, rate)
 	}
 }
+
+// TestContentTypeOverrides tests parsing the content type overrides.
+func TestContentTypeOverrides(t *testing.T) {
+	// Unset any defaults, this only affects in memory state. Any Env Vars will
+	// remain intact on disk
+	err := os.Unsetenv(contentTypeOverrides)
+	if err != nil {
+		t.Error(err)
+	}
+
+	// Test Default
+	if _, ok := ContentTypeOverrides(); ok {
+		t.Error("Expected no overrides")
+	}
+
+	// Test Env Variable
+	err = os.Setenv(contentTypeOverrides, " .WASM=application/wasm, md=text/markdown; charset=utf-8,")
+	if err != nil {
+		t.Error(err)
+	}
+	overrides, ok := ContentTypeOverrides()
+	if !ok {
+		t.Fatal("Expected overrides")
+	}
+	if len(overrides) != 2 || overrides[".wasm"] != "application/wasm" || overrides[".md"] != "text/markdown; charset=utf-8" {
+		t.Errorf("Unexpected overrides %v", overrides)
+	}
+	err = os.Unsetenv(contentTypeOverrides)
+	if err != nil {
+		t.Error(err)
+	}
+
+	// Test invalid pairs
+	for _, pair := range []string{"wasm", "=text/plain", "tar.gz=application/gzip", "txt=", "txt=text/"} {
+		if _, _, err := parseContentTypeOverride(pair); err == nil {
+			t.Errorf("Expected pair '%v' to be invalid", pair)
+		}
+	}
+}
+
+// TestContractDefragWindow probes the ContractDefragWindow function.
+func TestContractDefragWindow(t *testing.T) {
+	// Unset any defaults, this only affects in memory state. Any Env Vars will
+	// remain intact on disk
+	err := os.Unsetenv(contractDefragWindow)
+	if err != nil {
+		t.Error(err)
+	}
+
+	// Test Default
+	if _, _, ok := ContractDefragWindow(); ok {
+		t.Error("Expected no window")
+	}
+
+	// Test Env Variable
+	err = os.Setenv(contractDefragWindow, "22- 4")
+	if err != nil {
+		t.Error(err)
+	}
+	start, end, ok := ContractDefragWindow()
+	if !ok {
+		t.Fatal("Expected window")
+	}
+	if start != 22 || end != 4 {
+		t.Errorf("Unexpected window %v-%v", start, end)
+	}
+	err = os.Unsetenv(contractDefragWindow)
+	if err != nil {
+		t.Error(err)
+	}
+
+	// Test invalid ranges
+	for _, hours := range []string{"", "2", "2-5-7", "a-5", "2-24", "-1-5", "3-3"} {
+		if _, _, err := parseHourRange(hours); err == nil {
+			t.Errorf("Expected range '%v' to be invalid", hours)
+		}
+	}
+}
+
+// TestSkynetEarlyHints probes the SkynetEarlyHints function.
+func TestSkynetEarlyHints(t *testing.T) {
+	// Unset any defaults, this only affects in memory state. Any Env Vars will
+	// remain intact on disk
+	err := os.Unsetenv(skynetEarlyHints)
+	if err != nil {
+		t.Error(err)
+	}
+
+	// Test Default
+	if _, ok := SkynetEarlyHints(); ok {
+		t.Error("Expected early hints to be disabled")
+	}
+
+	// Test Env Variable
+	tests := []struct {
+		value string
+		mode  string
+		ok    bool
+	}{
+		{"off", "", false},
+		{"", "", false},
+		{"link", SkynetEarlyHintsLink, true},
+		{" LINK ", SkynetEarlyHintsLink, true},
+		{"103", SkynetEarlyHints103, true},
+	}
+	for _, test := range tests {
+		err = os.Setenv(skynetEarlyHints, test.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mode, ok := SkynetEarlyHints()
+		if mode != test.mode || ok != test.ok {
+			t.Errorf("%v: expected %v %v but got %v %v", test.value, test.mode, test.ok, mode, ok)
+		}
+	}
+	err = os.Unsetenv(skynetEarlyHints)
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -26,4 +26,68 @@ var (
 
 	// tusMaxSize determines the max size of an upload via the /tus endpoint.
 	tusMaxSize = "TUS_MAXSIZE"
+
+	// sectorCacheSize determines the max size of the renter's on-disk sector
+	// cache in bytes. The cache is disabled if not set.
+	sectorCacheSize = "SKYD_SECTOR_CACHE_SIZE"
+
+	// recentUploadCacheSize determines the max size of the renter's on-disk
+	// cache of recently uploaded base sectors in bytes. The cache is disabled
+	// if not set.
+	recentUploadCacheSize = "SKYD_RECENT_UPLOAD_CACHE_SIZE"
+
+	// registryCacheSize determines the max number of entries in the renter's
+	// in-memory registry cache. The cache is disabled if not set.
+	registryCacheSize = "SKYD_REGISTRY_CACHE_SIZE"
+
+	// contractDefragWindow is the daily window in which the renter removes
+	// unreferenced sectors from its contracts, given as a range of UTC hours
+	// like "2-5". Contracts are only defragged on demand if not set.
+	contractDefragWindow = "SKYD_CONTRACT_DEFRAG_WINDOW"
+
+	// hnsResolver is the address of a DNS server which resolves Handshake
+	// names. Handshake names can't be resolved if not set.
+	hnsResolver = "SKYD_HNS_RESOLVER"
+
+	// contentTypeOverrides is a comma-separated list of ext=type pairs which
+	// override the content types skyfiles are served with.
+	contentTypeOverrides = "SKYD_CONTENT_TYPE_OVERRIDES"
+
+	// skynetEarlyHints enables hinting browsers at the stylesheets and scripts
+	// of a skyfile when serving its HTML default path. It is either "link" to
+	// attach Link preload headers or "103" to also send them in a 103 Early
+	// Hints response. Disabled if not set.
+	skynetEarlyHints = "SKYD_SKYNET_EARLY_HINTS"
+
+	// hostAllowlist is the URL or path of a signed host allow-list which the
+	// renter's hostdb is pinned to. hostAllowlistKey is the ed25519 key the
+	// allow-list is signed with.
+	hostAllowlist    = "SKYD_HOST_ALLOWLIST"
+	hostAllowlistKey = "SKYD_HOST_ALLOWLIST_KEY"
+
+	// uploadScanner is the URL of an HTTP callback or the path of a local
+	// command which scans the content of uploaded skyfiles and can reject
+	// them. Uploads aren't scanned if not set.
+	uploadScanner = "SKYD_UPLOAD_SCANNER"
+
+	// persistS3Bucket enables storing the renter's persistence in the given
+	// S3 bucket instead of the local disk. The other persistS3 variables
+	// configure the object store.
+	persistS3Bucket          = "SKYD_PERSIST_S3_BUCKET"
+	persistS3Endpoint        = "SKYD_PERSIST_S3_ENDPOINT"
+	persistS3Region          = "SKYD_PERSIST_S3_REGION"
+	persistS3Prefix          = "SKYD_PERSIST_S3_PREFIX"
+	persistS3AccessKeyID     = "SKYD_PERSIST_S3_ACCESS_KEY_ID"
+	persistS3SecretAccessKey = "SKYD_PERSIST_S3_SECRET_ACCESS_KEY"
+)
+
+const (
+	// SkynetEarlyHintsLink is the skynetEarlyHints mode which attaches Link
+	// preload headers to the response.
+	SkynetEarlyHintsLink = "link"
+
+	// SkynetEarlyHints103 is the skynetEarlyHints mode which sends the Link
+	// preload headers in a 103 Early Hints response before the actual
+	// response.
+	SkynetEarlyHints103 = "103"
 )
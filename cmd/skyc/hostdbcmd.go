@@ -59,6 +59,7 @@ func printScoreBreakdown(info *api.HostdbHostsGET) {
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 	fmt.Fprintf(w, "\t\tAge:\t %.3f\n", info.ScoreBreakdown.AgeAdjustment)
 	fmt.Fprintf(w, "\t\tBase Price:\t %.3f\n", info.ScoreBreakdown.BasePriceAdjustment)
+	fmt.Fprintf(w, "\t\tBenchmark:\t %.3f\n", info.ScoreBreakdown.BenchmarkAdjustment)
 	fmt.Fprintf(w, "\t\tBurn:\t %.3f\n", info.ScoreBreakdown.BurnAdjustment)
 	fmt.Fprintf(w, "\t\tCollateral:\t %.3f\n", info.ScoreBreakdown.CollateralAdjustment/1e96)
 	fmt.Fprintf(w, "\t\tDuration:\t %.3f\n", info.ScoreBreakdown.DurationAdjustment)
@@ -447,5 +448,13 @@ func hostdbviewcmd(pubkey string) {
 	fmt.Println("  Recent Successful Interactions:   ", info.Entry.RecentSuccessfulInteractions)
 	fmt.Printf("  Overall Uptime:                    %.3f\n", uptimeRatio)
 
+	// Print the benchmark results if the host was benchmarked.
+	if bm := info.Entry.Benchmark; !bm.Timestamp.IsZero() {
+		fmt.Println("\n  Last Benchmark:                   ", bm.Timestamp)
+		fmt.Println("  Benchmark RTT:                    ", bm.RTT)
+		fmt.Println("  Benchmark Execution Latency:      ", bm.ExecutionLatency)
+		fmt.Println("  Benchmark Throughput:             ", modules.FilesizeUnits(uint64(bm.Throughput))+"/s")
+	}
+
 	fmt.Println()
 }
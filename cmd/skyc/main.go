@@ -72,13 +72,18 @@ var (
 	allowanceMaxSectorAccessPrice      string // max allowed price to access a sector on a host
 	allowanceMaxStoragePrice           string // max allowed price to store data on a host
 	allowanceMaxUploadBandwidthPrice   string // max allowed price to upload data to a host
+	allowanceMaxFeeBumpBudget          string // max budget for bumping the fees of contract txns
 
 	// Skykey Flags
-	skykeyID              string // ID used to identify a Skykey.
-	skykeyName            string // Name used to identify a Skykey.
-	skykeyRenameAs        string // Optional parameter to rename a Skykey while adding it.
-	skykeyShowPrivateKeys bool   // Set to true to show private key data.
-	skykeyType            string // Type used to create a new Skykey.
+	skykeyBackupAutoEntry   string // Registry entry name used for the automatic skykey backup.
+	skykeyBackupDisableAuto bool   // Set to true to disable the automatic skykey backup.
+	skykeyBackupFile        string // Local file used to export or import skykeys.
+	skykeyBackupStatus      bool   // Set to true to show the automatic skykey backup status.
+	skykeyID                string // ID used to identify a Skykey.
+	skykeyName              string // Name used to identify a Skykey.
+	skykeyRenameAs          string // Optional parameter to rename a Skykey while adding it.
+	skykeyShowPrivateKeys   bool   // Set to true to show private key data.
+	skykeyType              string // Type used to create a new Skykey.
 
 	// Skynet Flags
 	skynetBlocklistHash            bool   // Indicates if the input for the blocklist is already a hash.
@@ -377,6 +382,7 @@ func initCmds() *cobra.Command {
 	renterSetAllowanceCmd.Flags().StringVar(&allowanceMaxSectorAccessPrice, "max-sector-access-price", "", "the maximum price that the renter will pay to access a sector on a host")
 	renterSetAllowanceCmd.Flags().StringVar(&allowanceMaxStoragePrice, "max-storage-price", "", "the maximum price that the renter will pay to store data on a host")
 	renterSetAllowanceCmd.Flags().StringVar(&allowanceMaxUploadBandwidthPrice, "max-upload-bandwidth-price", "", "the maximum price that the renter will pay to upload data to a host")
+	renterSetAllowanceCmd.Flags().StringVar(&allowanceMaxFeeBumpBudget, "max-fee-bump-budget", "", "the maximum amount the renter will spend on bumping the fees of unconfirmed contract transactions")
 
 	renterFuseCmd.AddCommand(renterFuseMountCmd, renterFuseUnmountCmd)
 	renterFuseMountCmd.Flags().BoolVarP(&renterFuseMountAllowOther, "allow-other", "", false, "Allow users other than the user that mounted the fuse directory to access and use the fuse directory")
@@ -408,13 +414,18 @@ func initCmds() *cobra.Command {
 	skynetSkylinkCmd.AddCommand(skynetSkylinkCompareCmd, skynetSkylinkLayoutCmd, skynetSkylinkMetadataCmd)
 
 	root.AddCommand(skykeyCmd)
-	skykeyCmd.AddCommand(skykeyAddCmd, skykeyCreateCmd, skykeyDeleteCmd, skykeyGetCmd, skykeyGetIDCmd, skykeyListCmd)
+	skykeyCmd.AddCommand(skykeyAddCmd, skykeyBackupCmd, skykeyCreateCmd, skykeyDeleteCmd, skykeyGetCmd, skykeyGetIDCmd, skykeyListCmd, skykeyRestoreCmd)
+	skykeyBackupCmd.Flags().StringVar(&skykeyBackupAutoEntry, "auto-entry", "", "Enable the automatic backup using the registry entry with the given name")
+	skykeyBackupCmd.Flags().BoolVar(&skykeyBackupDisableAuto, "disable-auto", false, "Disable the automatic backup")
+	skykeyBackupCmd.Flags().StringVar(&skykeyBackupFile, "file", "", "Write the backup to the given file")
+	skykeyBackupCmd.Flags().BoolVar(&skykeyBackupStatus, "status", false, "Show the status of the automatic backup")
 	skykeyAddCmd.Flags().StringVar(&skykeyRenameAs, "rename-as", "", "The new name for the skykey being added")
 	skykeyCreateCmd.Flags().StringVar(&skykeyType, "type", "", "The type of the skykey")
 	skykeyDeleteCmd.AddCommand(skykeyDeleteNameCmd, skykeyDeleteIDCmd)
 	skykeyGetCmd.Flags().StringVar(&skykeyName, "name", "", "The name of the skykey")
 	skykeyGetCmd.Flags().StringVar(&skykeyID, "id", "", "The base-64 encoded skykey ID")
 	skykeyListCmd.Flags().BoolVar(&skykeyShowPrivateKeys, "show-priv-keys", false, "Show private key data.")
+	skykeyRestoreCmd.Flags().StringVar(&skykeyBackupFile, "file", "", "Restore the skykeys from the given file")
 
 	// Daemon Commands
 	root.AddCommand(alertsCmd, globalRatelimitCmd, profileCmd, stackCmd, stopCmd, versionCmd)
@@ -428,7 +439,7 @@ func initCmds() *cobra.Command {
 	root.AddCommand(utilsCmd)
 	utilsCmd.AddCommand(bashcomplCmd, mangenCmd, utilsBruteForceSeedCmd, utilsCheckSigCmd,
 		utilsDecodeRawTxnCmd, utilsDisplayAPIPasswordCmd, utilsEncodeRawTxnCmd, utilsHastingsCmd,
-		utilsSigHashCmd, utilsUploadedsizeCmd, utilsVerifySeedCmd)
+		utilsSigHashCmd, utilsUploadedsizeCmd, utilsVerifyProofCmd, utilsVerifySeedCmd)
 
 	utilsVerifySeedCmd.Flags().StringVarP(&dictionaryLanguage, "language", "l", "english", "which dictionary you want to use")
 
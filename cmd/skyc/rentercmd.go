@@ -609,6 +609,7 @@ Price Protections:
   MaxSectorAccessPrice:      %v per million accesses
   MaxStoragePrice:           %v per TB per Month
   MaxUploadBandwidthPrice:   %v per TB
+  MaxFeeBumpBudget:          %v
 `, currencyUnitsWithExchangeRate(allowance.Funds, rate), allowance.Period, allowance.RenewWindow,
 		allowance.Hosts, currencyUnitsWithExchangeRate(allowance.PaymentContractInitialFunding, rate),
 		modules.FilesizeUnits(allowance.ExpectedStorage),
@@ -620,7 +621,8 @@ Price Protections:
 		currencyUnits(allowance.MaxDownloadBandwidthPrice.Mul(modules.BytesPerTerabyte)),
 		currencyUnits(allowance.MaxSectorAccessPrice.Mul64(1e6)),
 		currencyUnits(allowance.MaxStoragePrice.Mul(modules.BlockBytesPerMonthTerabyte)),
-		currencyUnits(allowance.MaxUploadBandwidthPrice.Mul(modules.BytesPerTerabyte)))
+		currencyUnits(allowance.MaxUploadBandwidthPrice.Mul(modules.BytesPerTerabyte)),
+		currencyUnits(allowance.MaxFeeBumpBudget))
 
 	// Show detailed current Period spending metrics
 	renterallowancespending(rg)
@@ -874,6 +876,20 @@ func rentersetallowancecmd(_ *cobra.Command, _ []string) {
 		req = req.WithMaxUploadBandwidthPrice(price)
 		changedFields++
 	}
+	// parse maxfeebumpbudget
+	if allowanceMaxFeeBumpBudget != "" {
+		budgetStr, err := types.ParseCurrency(allowanceMaxFeeBumpBudget)
+		if err != nil {
+			die("Could not parse max fee bump budget:", err)
+		}
+		var budget types.Currency
+		_, err = fmt.Sscan(budgetStr, &budget)
+		if err != nil {
+			die("Could not read max fee bump budget:", err)
+		}
+		req = req.WithMaxFeeBumpBudget(budget)
+		changedFields++
+	}
 
 	// check if any fields were updated.
 	if changedFields == 0 {
@@ -1700,9 +1716,15 @@ func rentercontractrecoveryscanprogresscmd() {
 	if crpg.ScanInProgress {
 		fmt.Println("Scan in progress")
 		fmt.Println("Scanned height:\t", crpg.ScannedHeight)
+		fmt.Println("Paused:\t\t", crpg.Paused)
 	} else {
 		fmt.Println("No scan in progress")
 	}
+	fmt.Println("Blocks scanned:\t", crpg.BlocksScanned)
+	fmt.Println("Contracts found:", crpg.ContractsFound)
+	for _, c := range crpg.Contracts {
+		fmt.Printf("  %v: %v (attempts: %v) %v\n", c.ID, c.State, c.Attempts, c.Error)
+	}
 }
 
 // renterfileslistcmd is the handler for the command `skyc renter ls`. Lists
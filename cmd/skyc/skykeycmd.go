@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strings"
 	"text/tabwriter"
@@ -11,6 +12,7 @@ import (
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/SkynetLabs/skyd/node/api/client"
 	"gitlab.com/SkynetLabs/skyd/skykey"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
 )
 
 var (
@@ -30,6 +32,29 @@ var (
 		Run:   skykeycmd,
 	}
 
+	skykeyBackupCmd = &cobra.Command{
+		Use:   "backup",
+		Short: "Backup the skykeys with a password",
+		Long: `Create a password protected backup of all skykeys and upload it as a
+skyfile. Use --file to write the backup to a local file instead.
+
+Use --auto-entry to enable the automatic backup of the skykeys. Every time the
+skykeys change, a new backup is uploaded and the registry entry with the given
+name is updated to point to it. The skylink of the registry entry can be used
+with 'skyc skykey restore' to restore the latest backup. Use --disable-auto to
+disable the automatic backup and --status to show its status.`,
+		Run: wrap(skykeybackupcmd),
+	}
+
+	skykeyRestoreCmd = &cobra.Command{
+		Use:   "restore [skylink]",
+		Short: "Restore the skykeys from a backup",
+		Long: `Restore the skykeys from a password protected backup created by
+'skyc skykey backup'. Use --file to restore the skykeys from a local file
+instead of a skylink. Skykeys which exist already are skipped.`,
+		Run: skykeyrestorecmd,
+	}
+
 	skykeyAddCmd = &cobra.Command{
 		Use:   "add [skykey base64-encoded skykey]",
 		Short: "Add a base64-encoded skykey to the key manager.",
@@ -93,6 +118,111 @@ func skykeycmd(cmd *cobra.Command, args []string) {
 	os.Exit(exitCodeUsage)
 }
 
+// skykeybackupcmd creates a password protected backup of the skykeys or
+// manages the automatic skykey backup.
+func skykeybackupcmd() {
+	if skykeyBackupStatus {
+		status, err := httpClient.SkykeysAutoBackupGet()
+		if err != nil {
+			die("Failed to get automatic skykey backup status:", err)
+		}
+		printSkykeyAutoBackupStatus(status)
+		return
+	}
+	if skykeyBackupDisableAuto {
+		_, err := httpClient.SkykeysAutoBackupPost("", "")
+		if err != nil {
+			die("Failed to disable automatic skykey backup:", err)
+		}
+		fmt.Println("Automatic skykey backup disabled")
+		return
+	}
+
+	password, err := passwordPrompt("Backup password: ")
+	if err != nil {
+		die("Reading password failed:", err)
+	}
+	if err := confirmPassword(password); err != nil {
+		die(err)
+	}
+
+	switch {
+	case skykeyBackupAutoEntry != "":
+		status, err := httpClient.SkykeysAutoBackupPost(password, skykeyBackupAutoEntry)
+		if err != nil {
+			die("Failed to enable automatic skykey backup:", err)
+		}
+		fmt.Println("Automatic skykey backup enabled")
+		printSkykeyAutoBackupStatus(status)
+	case skykeyBackupFile != "":
+		bundle, err := httpClient.SkykeysExportPost(password)
+		if err != nil {
+			die("Failed to export skykeys:", err)
+		}
+		if err := ioutil.WriteFile(skykeyBackupFile, bundle, 0600); err != nil {
+			die("Failed to write skykey backup:", err)
+		}
+		fmt.Printf("Skykeys backed up to %v\n", skykeyBackupFile)
+	default:
+		sbp, err := httpClient.SkykeysBackupPost(password)
+		if err != nil {
+			die("Failed to backup skykeys:", err)
+		}
+		fmt.Printf("Skykeys backed up to skylink: %v\n", sbp.Skylink)
+	}
+}
+
+// printSkykeyAutoBackupStatus prints the status of the automatic skykey
+// backup.
+func printSkykeyAutoBackupStatus(status skymodules.SkykeyAutoBackupStatus) {
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  Enabled:\t%v\n", status.Enabled)
+	if status.Enabled {
+		fmt.Fprintf(w, "  Entry Name:\t%v\n", status.EntryName)
+		fmt.Fprintf(w, "  Skylink:\t%v\n", status.Skylink)
+		fmt.Fprintf(w, "  Last Backup:\t%v\n", status.LastBackup)
+		fmt.Fprintf(w, "  Last Backup Time:\t%v\n", status.LastBackupTime)
+	}
+	if status.RecentErr != "" {
+		fmt.Fprintf(w, "  Recent Error:\t%v\n", status.RecentErr)
+		fmt.Fprintf(w, "  Recent Error Time:\t%v\n", status.RecentErrTime)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// skykeyrestorecmd restores the skykeys from a password protected backup.
+func skykeyrestorecmd(cmd *cobra.Command, args []string) {
+	if (len(args) == 1) == (skykeyBackupFile != "") {
+		_ = cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+
+	password, err := passwordPrompt("Backup password: ")
+	if err != nil {
+		die("Reading password failed:", err)
+	}
+
+	var added []skykey.Skykey
+	if skykeyBackupFile != "" {
+		bundle, err := ioutil.ReadFile(skykeyBackupFile)
+		if err != nil {
+			die("Failed to read skykey backup:", err)
+		}
+		added, err = httpClient.SkykeysImportPost(bundle, password)
+	} else {
+		added, err = httpClient.SkykeysRestorePost(args[0], password)
+	}
+	if err != nil {
+		die("Failed to restore skykeys:", err)
+	}
+	fmt.Printf("Restored %v skykeys\n", len(added))
+	for _, sk := range added {
+		fmt.Printf("  %v (%v)\n", sk.Name, sk.ID().ToString())
+	}
+}
+
 // skykeycreatecmd is a wrapper for skykeyCreate used to handle skykey creation.
 func skykeycreatecmd(name string) {
 	skykeyStr, err := skykeyCreate(httpClient, name, skykeyType)
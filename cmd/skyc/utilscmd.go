@@ -102,6 +102,19 @@ Use sighash to calculate the hash of a transaction.
 		Run: wrap(utilschecksigcmd),
 	}
 
+	utilsVerifyProofCmd = &cobra.Command{
+		Use:   "verifyproof [proof] [skylink|root]",
+		Short: "verify an exported range proof",
+		Long: `Verify that the data of an exported range proof is part of a sector.
+
+The proof may be either the encoded proof or a file containing it. It is
+verified against either a v1 skylink or the hex-encoded merkle root of a
+sector. When verifying against a skylink, the proven data also needs to lie
+within the data the skylink points to.
+`,
+		Run: wrap(utilsverifyproofcmd),
+	}
+
 	utilsVerifySeedCmd = &cobra.Command{
 		Use:   "verify-seed",
 		Short: "verify seed is formatted correctly",
@@ -263,6 +276,34 @@ func utilschecksigcmd(base64Sig, hexHash, pkStr string) {
 	}
 }
 
+// utilsverifyproofcmd is the handler for the command `skyc utils
+// verifyproof`. It verifies an exported range proof offline.
+func utilsverifyproofcmd(proofStr, rootStr string) {
+	// Assume the proof is stored in a file if the argument is a path to an
+	// existing file.
+	if b, err := ioutil.ReadFile(proofStr); err == nil {
+		proofStr = string(b)
+	}
+	proof, err := skymodules.DecodeSectorRangeProof(strings.TrimSpace(proofStr))
+	if err != nil {
+		die("Couldn't parse proof:", err)
+	}
+
+	var skylink skymodules.Skylink
+	var root crypto.Hash
+	if skylink.LoadString(rootStr) == nil {
+		err = proof.VerifySkylink(skylink)
+	} else if root.LoadString(rootStr) == nil {
+		err = proof.Verify(root)
+	} else {
+		die("Couldn't parse skylink or root")
+	}
+	if err != nil {
+		die("Bad proof:", err)
+	}
+	fmt.Printf("Verified OK: %v bytes at offset %v\n", len(proof.Data), proof.Offset)
+}
+
 // utilsverifyseedcmd is the handler for the command `skyc utils verify-seed`.
 // verifies a seed matches the required formatting.  This can be used to help
 // troubleshot seeds that are not being accepted by siad.
@@ -9,8 +9,8 @@ require (
 	github.com/eventials/go-tus v0.0.0-20200718001131-45c7ec8f5d59
 	github.com/hanwen/go-fuse/v2 v2.1.0
 	github.com/julienschmidt/httprouter v1.3.0
-	github.com/klauspost/cpuid/v2 v2.0.6 // indirect
 	github.com/klauspost/reedsolomon v1.9.12
+	github.com/minio/minio-go/v7 v7.0.10
 	github.com/montanaflynn/stats v0.6.3
 	github.com/opentracing/opentracing-go v1.1.0
 	github.com/spf13/cobra v1.1.3
@@ -30,4 +30,5 @@ require (
 	go.sia.tech/siad v1.5.7
 	golang.org/x/crypto v0.0.0-20210322153248-0c34fe9e7dc2
 	golang.org/x/net v0.0.0-20210410081132-afb366fc7cd1
+	lukechampine.com/blake3 v1.1.7
 )
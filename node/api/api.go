@@ -146,6 +146,15 @@ type (
 
 		staticStartTime time.Time
 
+		// staticContentTypeOverrides override the content types skyfiles
+		// are served with based on their extension.
+		staticContentTypeOverrides skymodules.ContentTypeOverrides
+
+		// staticSkynetEarlyHints is the mode used to hint clients at the
+		// stylesheets and scripts of skyfiles when serving their default
+		// path. Disabled if empty.
+		staticSkynetEarlyHints string
+
 		staticDeps modules.Dependencies
 	}
 
@@ -236,6 +245,12 @@ func NewCustom(cfg *skymodules.SiadConfig, requiredUserAgent string, requiredPas
 		staticDeps:      deps,
 		staticStartTime: time.Now(),
 	}
+	if overrides, ok := build.ContentTypeOverrides(); ok {
+		api.staticContentTypeOverrides = overrides
+	}
+	if mode, ok := build.SkynetEarlyHints(); ok {
+		api.staticSkynetEarlyHints = mode
+	}
 
 	// Register API handlers
 	api.buildHTTPRoutes()
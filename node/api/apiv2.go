@@ -0,0 +1,328 @@
+package api
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/SkynetLabs/skyd/build"
+)
+
+// The v2 API is a routing layer on top of the v1 handlers. Requests to paths
+// prefixed with APIV2Prefix, or requests which accept the APIV2MediaType, are
+// served by the same handlers as v1 but their JSON responses and errors are
+// wrapped in a V2Response envelope. Responses to v1 requests carry headers
+// pointing clients at their v2 successor.
+
+const (
+	// APIV2Prefix is the path prefix of the v2 API. The remainder of the path
+	// is the path of the corresponding v1 endpoint.
+	APIV2Prefix = "/api/v2"
+
+	// APIV2MediaType is the media type of v2 responses. Clients can request
+	// it using the Accept header, which also selects the v2 API for
+	// unprefixed paths.
+	APIV2MediaType = "application/vnd.skyd.v2+json"
+
+	// RequestIDHeader is the header which holds the ID of a v2 request. If a
+	// client provides a valid ID in the request, it is reused for the
+	// response.
+	RequestIDHeader = "Skynet-Request-Id"
+
+	// maxRequestIDLen is the maximum length of a request ID provided by a
+	// client.
+	maxRequestIDLen = 64
+)
+
+type (
+	// V2Response is the envelope of all JSON responses of the v2 API. Data
+	// holds the v1 response on success and Error describes the failure
+	// otherwise.
+	V2Response struct {
+		RequestID string          `json:"requestid"`
+		Data      json.RawMessage `json:"data"`
+		Error     *V2Error        `json:"error,omitempty"`
+	}
+
+	// V2Error is the error returned by the v2 API. Code is a stable,
+	// machine-readable identifier of the type of error.
+	V2Error struct {
+		Code    string `json:"code"`
+		Status  int    `json:"status"`
+		Message string `json:"message"`
+	}
+
+	// v2ResponseWriter wraps the http.ResponseWriter of a v2 request. JSON
+	// responses and errors written by the v1 handlers are buffered to be
+	// wrapped in a V2Response when the handler returns. All other responses,
+	// e.g. file downloads, are passed through unmodified.
+	v2ResponseWriter struct {
+		http.ResponseWriter
+
+		buf         bytes.Buffer
+		buffered    bool
+		status      int
+		wroteHeader bool
+
+		staticContentType string
+		staticRequestID   string
+	}
+)
+
+// Error implements the error interface.
+func (err V2Error) Error() string {
+	return err.Message
+}
+
+// apiVersionHandler is middleware that serves the v2 API on top of the v1
+// handlers of h. v1 requests are passed on to h. Responses of v1 API routes
+// carry deprecation headers, content served from skylinks or streams doesn't.
+func apiVersionHandler(h http.Handler, router *httprouter.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		prefixed := req.URL.Path == APIV2Prefix || strings.HasPrefix(req.URL.Path, APIV2Prefix+"/")
+		if !prefixed && !acceptsMediaType(req.Header.Get("Accept"), APIV2MediaType) {
+			if handle, _, _ := router.Lookup(req.Method, req.URL.Path); handle != nil && !isUnrestricted(req) {
+				w.Header().Set("Deprecation", "true")
+				w.Header().Set("Link", fmt.Sprintf("<%s%s>; rel=\"successor-version\"", APIV2Prefix, req.URL.EscapedPath()))
+			}
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		// Assign the request an ID.
+		requestID := req.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = hex.EncodeToString(fastrand.Bytes(16))
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+		w.Header().Add("Vary", "Accept")
+
+		// Negotiate the content type of the response.
+		contentType, ok := negotiateV2ContentType(req.Header.Get("Accept"))
+		if !ok {
+			writeV2Response(w, "application/json; charset=utf-8", requestID, nil, &V2Error{
+				Message: fmt.Sprintf("the v2 API only serves application/json and %v", APIV2MediaType),
+			}, http.StatusNotAcceptable)
+			return
+		}
+
+		// Strip the prefix to route the request to the v1 handler.
+		if prefixed {
+			req.URL.Path = strings.TrimPrefix(req.URL.Path, APIV2Prefix)
+			if req.URL.Path == "" {
+				req.URL.Path = "/"
+			}
+			if req.URL.RawPath != "" {
+				req.URL.RawPath = strings.TrimPrefix(req.URL.RawPath, APIV2Prefix)
+			}
+		}
+		vw := &v2ResponseWriter{
+			ResponseWriter:    w,
+			staticContentType: contentType,
+			staticRequestID:   requestID,
+		}
+		h.ServeHTTP(vw, req)
+		vw.finish()
+	})
+}
+
+// WriteHeader implements http.ResponseWriter. Errors, empty responses and JSON
+// responses are buffered, everything else is passed through.
+func (vw *v2ResponseWriter) WriteHeader(status int) {
+	if vw.wroteHeader {
+		return
+	}
+	vw.wroteHeader = true
+	vw.status = status
+	vw.buffered = status >= 400 || status == http.StatusNoContent || isJSONContentType(vw.Header().Get("Content-Type"))
+	if !vw.buffered {
+		vw.ResponseWriter.WriteHeader(status)
+	}
+}
+
+// Write implements http.ResponseWriter.
+func (vw *v2ResponseWriter) Write(b []byte) (int, error) {
+	if !vw.wroteHeader {
+		vw.WriteHeader(http.StatusOK)
+	}
+	if vw.buffered {
+		return vw.buf.Write(b)
+	}
+	return vw.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher for responses which are passed through.
+func (vw *v2ResponseWriter) Flush() {
+	if vw.buffered {
+		return
+	}
+	if f, ok := vw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// finish writes the buffered response wrapped in a V2Response. It needs to be
+// called after the v1 handler returned.
+func (vw *v2ResponseWriter) finish() {
+	if !vw.wroteHeader {
+		vw.WriteHeader(http.StatusOK)
+	}
+	if !vw.buffered {
+		return
+	}
+	body := bytes.TrimSpace(vw.buf.Bytes())
+
+	// Wrap errors.
+	if vw.status >= 400 {
+		var apiErr Error
+		if err := json.Unmarshal(body, &apiErr); err != nil || apiErr.Message == "" {
+			apiErr.Message = string(body)
+		}
+		if apiErr.Message == "" {
+			apiErr.Message = http.StatusText(vw.status)
+		}
+		writeV2Response(vw.ResponseWriter, vw.staticContentType, vw.staticRequestID, nil, &V2Error{Message: apiErr.Message}, vw.status)
+		return
+	}
+
+	// Wrap the data. Responses without content are sent as null.
+	var data json.RawMessage
+	if len(body) > 0 && json.Valid(body) {
+		data = body
+	} else if len(body) > 0 {
+		var err error
+		data, err = json.Marshal(string(body))
+		if err != nil {
+			build.Critical("failed to encode v2 response data:", err)
+		}
+	}
+	status := vw.status
+	if status == http.StatusNoContent {
+		status = http.StatusOK
+	}
+	writeV2Response(vw.ResponseWriter, vw.staticContentType, vw.staticRequestID, data, nil, status)
+}
+
+// writeV2Response writes a V2Response to w. The code and status of the error
+// are set according to the http status code.
+func writeV2Response(w http.ResponseWriter, contentType, requestID string, data json.RawMessage, v2Err *V2Error, status int) {
+	if v2Err != nil {
+		v2Err.Code = v2ErrorCode(status)
+		v2Err.Status = status
+	}
+	w.Header().Del("Content-Length")
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(V2Response{
+		RequestID: requestID,
+		Data:      data,
+		Error:     v2Err,
+	})
+	if _, isJsonErr := err.(*json.SyntaxError); isJsonErr {
+		build.Critical("failed to encode v2 API response:", err)
+	}
+}
+
+// v2ErrorCode returns the error code of the v2 API for a http status code.
+func v2ErrorCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "bad_request"
+	case http.StatusUnauthorized:
+		return "unauthorized"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusMethodNotAllowed:
+		return "method_not_allowed"
+	case http.StatusNotAcceptable:
+		return "not_acceptable"
+	case http.StatusRequestTimeout:
+		return "timeout"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusGone:
+		return "gone"
+	case http.StatusRequestEntityTooLarge:
+		return "too_large"
+	case http.StatusTooManyRequests:
+		return "too_many_requests"
+	case http.StatusUnavailableForLegalReasons:
+		return "blocked"
+	case StatusModuleNotLoaded:
+		return "module_not_loaded"
+	case StatusModuleDisabled:
+		return "module_disabled"
+	case http.StatusServiceUnavailable:
+		return "unavailable"
+	case http.StatusGatewayTimeout:
+		return "timeout"
+	}
+	if status >= 500 {
+		return "internal_error"
+	}
+	return "request_failed"
+}
+
+// negotiateV2ContentType returns the content type of a v2 response for the
+// given Accept header. The v2 media type is used if the client explicitly
+// accepts it, plain JSON otherwise. If the client accepts neither, false is
+// returned.
+func negotiateV2ContentType(accept string) (string, bool) {
+	if acceptsMediaType(accept, APIV2MediaType) {
+		return APIV2MediaType, true
+	}
+	if accept == "" || acceptsMediaType(accept, "application/json") || acceptsMediaType(accept, "application/*") || acceptsMediaType(accept, "*/*") {
+		return "application/json; charset=utf-8", true
+	}
+	return "", false
+}
+
+// acceptsMediaType returns whether the Accept header explicitly lists the
+// media type with a non-zero quality.
+func acceptsMediaType(accept, mediaType string) bool {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil || mt != mediaType {
+			continue
+		}
+		if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q <= 0 {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// isJSONContentType returns whether a Content-Type header describes a JSON
+// response.
+func isJSONContentType(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+// validRequestID returns whether a request ID provided by a client can be
+// used. It needs to be short and may only contain alphanumeric characters,
+// dashes, underscores and dots.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// testV2Router returns a router with a few v1 style handlers for testing the
+// v2 API.
+func testV2Router() *httprouter.Router {
+	router := httprouter.New()
+	router.GET("/test/json", func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+		WriteJSON(w, map[string]int{"value": 42})
+	})
+	router.GET("/test/error", func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+		WriteError(w, Error{"something went wrong"}, http.StatusBadRequest)
+	})
+	router.POST("/test/success", func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+		WriteSuccess(w)
+	})
+	router.GET("/test/raw", func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write([]byte("raw data"))
+	})
+	return router
+}
+
+// serveV2 serves a request using the apiVersionHandler on top of the test
+// router and decodes the v2 envelope of the response if there is one.
+func serveV2(t *testing.T, method, path string, header http.Header) (*httptest.ResponseRecorder, V2Response) {
+	router := testV2Router()
+	req := httptest.NewRequest(method, path, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	apiVersionHandler(router, router).ServeHTTP(rec, req)
+	var resp V2Response
+	if isJSONContentType(rec.Header().Get("Content-Type")) {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rec, resp
+}
+
+// TestAPIVersionHandler is a unit test for the apiVersionHandler.
+func TestAPIVersionHandler(t *testing.T) {
+	t.Parallel()
+
+	// v1 requests are served as is with deprecation headers.
+	rec, _ := serveV2(t, "GET", "/test/json", nil)
+	if rec.Code != http.StatusOK || rec.Body.String() != "{\"value\":42}\n" {
+		t.Fatal("unexpected v1 response", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Deprecation") != "true" || rec.Header().Get("Link") != "</api/v2/test/json>; rel=\"successor-version\"" {
+		t.Fatal("missing deprecation headers", rec.Header())
+	}
+	if rec.Header().Get(RequestIDHeader) != "" {
+		t.Fatal("v1 responses shouldn't have a request id")
+	}
+
+	// v2 JSON responses are wrapped.
+	rec, resp := serveV2(t, "GET", APIV2Prefix+"/test/json", nil)
+	if rec.Code != http.StatusOK || resp.Error != nil || string(resp.Data) != "{\"value\":42}" {
+		t.Fatal("unexpected v2 response", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Content-Type") != "application/json; charset=utf-8" || rec.Header().Get("Deprecation") != "" {
+		t.Fatal("unexpected headers", rec.Header())
+	}
+	if len(resp.RequestID) != 32 || rec.Header().Get(RequestIDHeader) != resp.RequestID {
+		t.Fatal("unexpected request id", resp.RequestID, rec.Header().Get(RequestIDHeader))
+	}
+
+	// Errors are wrapped and get a code. A valid client provided request id
+	// is reused.
+	header := http.Header{RequestIDHeader: []string{"my-request.1"}}
+	rec, resp = serveV2(t, "GET", APIV2Prefix+"/test/error", header)
+	if rec.Code != http.StatusBadRequest || string(resp.Data) != "null" || resp.RequestID != "my-request.1" {
+		t.Fatal("unexpected v2 error response", rec.Code, rec.Body.String())
+	}
+	if *resp.Error != (V2Error{Code: "bad_request", Status: http.StatusBadRequest, Message: "something went wrong"}) {
+		t.Fatal("unexpected error", *resp.Error)
+	}
+
+	// Unknown routes and invalid request ids.
+	header = http.Header{RequestIDHeader: []string{"invalid id"}}
+	rec, resp = serveV2(t, "GET", APIV2Prefix+"/test/unknown", header)
+	if rec.Code != http.StatusNotFound || resp.Error == nil || resp.Error.Code != "not_found" || resp.RequestID == "invalid id" {
+		t.Fatal("unexpected v2 response for unknown route", rec.Code, rec.Body.String())
+	}
+
+	// Responses without content return null data.
+	rec, resp = serveV2(t, "POST", APIV2Prefix+"/test/success", nil)
+	if rec.Code != http.StatusOK || resp.Error != nil || string(resp.Data) != "null" {
+		t.Fatal("unexpected v2 success response", rec.Code, rec.Body.String())
+	}
+
+	// Non-JSON content is passed through.
+	rec, _ = serveV2(t, "GET", APIV2Prefix+"/test/raw", nil)
+	if rec.Code != http.StatusOK || rec.Body.String() != "raw data" || rec.Header().Get(RequestIDHeader) == "" {
+		t.Fatal("unexpected v2 raw response", rec.Code, rec.Body.String())
+	}
+
+	// Requesting the v2 media type selects v2 for unprefixed paths.
+	header = http.Header{"Accept": []string{APIV2MediaType}}
+	rec, resp = serveV2(t, "GET", "/test/json", header)
+	if rec.Header().Get("Content-Type") != APIV2MediaType || string(resp.Data) != "{\"value\":42}" {
+		t.Fatal("unexpected v2 response", rec.Header(), rec.Body.String())
+	}
+
+	// Requesting an unsupported media type fails.
+	header = http.Header{"Accept": []string{"text/html, application/json;q=0"}}
+	rec, resp = serveV2(t, "GET", APIV2Prefix+"/test/json", header)
+	if rec.Code != http.StatusNotAcceptable || resp.Error == nil || resp.Error.Code != "not_acceptable" {
+		t.Fatal("unexpected v2 response for unsupported media type", rec.Code, rec.Body.String())
+	}
+}
+
+// TestNegotiateV2ContentType is a unit test for negotiateV2ContentType.
+func TestNegotiateV2ContentType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		accept      string
+		contentType string
+		ok          bool
+	}{
+		{"", "application/json; charset=utf-8", true},
+		{"*/*", "application/json; charset=utf-8", true},
+		{"application/*", "application/json; charset=utf-8", true},
+		{"text/html, application/json;q=0.9", "application/json; charset=utf-8", true},
+		{"application/json, " + APIV2MediaType, APIV2MediaType, true},
+		{APIV2MediaType + ";q=0, application/json", "application/json; charset=utf-8", true},
+		{"text/html", "", false},
+		{"application/json;q=0.0", "", false},
+	}
+	for _, test := range tests {
+		contentType, ok := negotiateV2ContentType(test.accept)
+		if contentType != test.contentType || ok != test.ok {
+			t.Errorf("%q: expected %q %v but got %q %v", test.accept, test.contentType, test.ok, contentType, ok)
+		}
+	}
+}
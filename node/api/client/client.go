@@ -357,3 +357,96 @@ func (c *Client) post(resource string, data string, obj interface{}) error {
 	}
 	return nil
 }
+
+// put makes a PUT request to the resource at `resource`, using `data` as the
+// json encoded request body.
+func (c *Client) put(resource string, data []byte) error {
+	req, err := c.NewRequest("PUT", resource, bytes.NewReader(data))
+	if err != nil {
+		return errors.AddContext(err, "failed to construct PUT request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	httpClient := http.Client{CheckRedirect: c.CheckRedirect}
+	// nolint:bodyclose // body is closed by drainAndClose
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return errors.AddContext(err, "PUT request failed")
+	}
+	defer drainAndClose(res.Body)
+
+	// Add ErrAPICallNotRecognized if StatusCode is StatusModuleNotLoaded to allow for
+	// handling of modules that are not loaded
+	if res.StatusCode == api.StatusModuleNotLoaded || res.StatusCode == api.StatusModuleDisabled {
+		err = errors.Compose(readAPIError(res.Body), api.ErrAPICallNotRecognized)
+		return errors.AddContext(err, "unable to perform PUT on "+resource)
+	}
+
+	// If the status code is not 2xx, decode and return the accompanying
+	// api.Error.
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return errors.AddContext(readAPIError(res.Body), "PUT request error")
+	}
+	return nil
+}
+
+// V2Get makes a GET request to the v2 version of the resource at `resource`.
+// The data of the response, if provided, will be decoded into `obj`. The ID of
+// the request is returned.
+func (c *Client) V2Get(resource string, obj interface{}) (string, error) {
+	return c.v2Request("GET", resource, nil, obj)
+}
+
+// V2Post makes a POST request to the v2 version of the resource at `resource`,
+// using `data` as the request body. The data of the response, if provided,
+// will be decoded into `obj`. The ID of the request is returned.
+func (c *Client) V2Post(resource string, data string, obj interface{}) (string, error) {
+	return c.v2Request("POST", resource, strings.NewReader(data), obj)
+}
+
+// v2Request makes a request to the v2 version of the resource at `resource`
+// and decodes the data of the response envelope into `obj`. Errors of the
+// envelope are returned as an api.V2Error.
+func (c *Client) v2Request(method, resource string, body io.Reader, obj interface{}) (string, error) {
+	req, err := c.NewRequest(method, api.APIV2Prefix+resource, body)
+	if err != nil {
+		return "", errors.AddContext(err, "failed to construct v2 request")
+	}
+	req.Header.Set("Accept", api.APIV2MediaType)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	httpClient := http.Client{CheckRedirect: c.CheckRedirect}
+	// nolint:bodyclose // body is closed by drainAndClose
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return "", errors.AddContext(err, "v2 request failed")
+	}
+	defer drainAndClose(res.Body)
+
+	// Decode the envelope.
+	var resp api.V2Response
+	err = json.NewDecoder(res.Body).Decode(&resp)
+	if err != nil {
+		return res.Header.Get(api.RequestIDHeader), errors.AddContext(err, "could not read v2 response")
+	}
+	if resp.Error != nil {
+		err = *resp.Error
+		// Add ErrAPICallNotRecognized if StatusCode is StatusModuleNotLoaded
+		// to allow for handling of modules that are not loaded
+		if res.StatusCode == api.StatusModuleNotLoaded || res.StatusCode == api.StatusModuleDisabled {
+			err = errors.Compose(err, api.ErrAPICallNotRecognized)
+		}
+		return resp.RequestID, errors.AddContext(err, "v2 request error")
+	}
+	if obj == nil || len(resp.Data) == 0 || string(resp.Data) == "null" {
+		// No need to decode response
+		return resp.RequestID, nil
+	}
+	err = json.Unmarshal(resp.Data, obj)
+	if err != nil {
+		return resp.RequestID, errors.AddContext(err, "could not read v2 response data")
+	}
+	return resp.RequestID, nil
+}
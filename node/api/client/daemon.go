@@ -1,9 +1,12 @@
 package client
 
 import (
+	"encoding/json"
+	"net/http"
 	"net/url"
 	"strconv"
 
+	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/SkynetLabs/skyd/node/api"
 )
 
@@ -24,12 +27,59 @@ func (c *Client) DaemonAlertsGet() (dag api.DaemonAlertsGet, err error) {
 	return
 }
 
+// DaemonMaintenanceGet requests the /daemon/maintenance resource.
+func (c *Client) DaemonMaintenanceGet() (dmg api.DaemonMaintenanceGET, err error) {
+	err = c.get("/daemon/maintenance", &dmg)
+	return
+}
+
+// DaemonMaintenancePausePost uses the /daemon/maintenance/:name endpoint to
+// pause or resume a maintenance job.
+func (c *Client) DaemonMaintenancePausePost(name string, paused bool) (err error) {
+	values := url.Values{}
+	values.Set("paused", strconv.FormatBool(paused))
+	err = c.post("/daemon/maintenance/"+name, values.Encode(), nil)
+	return
+}
+
+// DaemonMaintenanceIntervalPost uses the /daemon/maintenance/:name endpoint to
+// change the interval and jitter of a maintenance job. The interval is
+// interpreted as seconds.
+func (c *Client) DaemonMaintenanceIntervalPost(name string, interval uint64, jitter float64) (err error) {
+	values := url.Values{}
+	values.Set("interval", strconv.FormatUint(interval, 10))
+	values.Set("jitter", strconv.FormatFloat(jitter, 'f', -1, 64))
+	err = c.post("/daemon/maintenance/"+name, values.Encode(), nil)
+	return
+}
+
 // DaemonReadyGet requests the /daemon/ready resource.
 func (c *Client) DaemonReadyGet() (dr api.DaemonReady, err error) {
 	err = c.get("/daemon/ready", &dr)
 	return
 }
 
+// DaemonReadyStrictGet requests the /daemon/ready resource with the strict
+// flag set. It returns the status code of the response which is 503 while the
+// daemon isn't ready.
+func (c *Client) DaemonReadyStrictGet() (code int, dr api.DaemonReady, err error) {
+	req, err := c.NewRequest("GET", "/daemon/ready?strict=true", nil)
+	if err != nil {
+		return 0, api.DaemonReady{}, errors.AddContext(err, "failed to construct GET request")
+	}
+	httpClient := http.Client{CheckRedirect: c.CheckRedirect}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return 0, api.DaemonReady{}, errors.AddContext(err, "GET request failed")
+	}
+	defer drainAndClose(res.Body)
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusServiceUnavailable {
+		return res.StatusCode, api.DaemonReady{}, errors.AddContext(readAPIError(res.Body), "GET request error")
+	}
+	err = json.NewDecoder(res.Body).Decode(&dr)
+	return res.StatusCode, dr, errors.AddContext(err, "failed to decode response")
+}
+
 // DaemonVersionGet requests the /daemon/version resource.
 func (c *Client) DaemonVersionGet() (dvg api.DaemonVersionGet, err error) {
 	err = c.get("/daemon/version", &dvg)
@@ -42,6 +92,17 @@ func (c *Client) DaemonSettingsGet() (dsg api.DaemonSettingsGet, err error) {
 	return
 }
 
+// DaemonSettingsPut uses the /daemon/settings endpoint to replace the daemon's
+// settings with the given settings document.
+func (c *Client) DaemonSettingsPut(settings api.DaemonSettingsPUT) (err error) {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return errors.AddContext(err, "failed to encode settings")
+	}
+	err = c.put("/daemon/settings", data)
+	return
+}
+
 // DaemonStartProfilePost requests the /daemon/startprofile api resource.
 func (c *Client) DaemonStartProfilePost(profileFlags, profileDir string) (err error) {
 	values := url.Values{}
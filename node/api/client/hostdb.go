@@ -2,6 +2,7 @@ package client
 
 import (
 	"encoding/json"
+	"net/url"
 
 	"gitlab.com/SkynetLabs/skyd/node/api"
 	"gitlab.com/SkynetLabs/skyd/skymodules"
@@ -53,3 +54,22 @@ func (c *Client) HostDbHostsGet(pk types.SiaPublicKey) (hhg api.HostdbHostsGET,
 	err = c.get("/hostdb/hosts/"+pk.String(), &hhg)
 	return
 }
+
+// HostDbAllowlistGet requests the /hostdb/allowlist GET endpoint
+func (c *Client) HostDbAllowlistGet() (hdag api.HostdbAllowlistGET, err error) {
+	err = c.get("/hostdb/allowlist", &hdag)
+	return
+}
+
+// HostDbAllowlistPost requests the /hostdb/allowlist POST endpoint to refresh
+// the host allow-list. If source is not empty, it replaces the configured
+// source and key.
+func (c *Client) HostDbAllowlistPost(source string, publicKey types.SiaPublicKey) (err error) {
+	values := url.Values{}
+	if source != "" {
+		values.Set("source", source)
+		values.Set("publickey", publicKey.String())
+	}
+	err = c.post("/hostdb/allowlist", values.Encode(), nil)
+	return
+}
@@ -1,6 +1,8 @@
 package client
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
@@ -131,6 +133,40 @@ func (a *AllowanceRequestPost) WithMaxUploadBandwidthPrice(price types.Currency)
 	return a
 }
 
+// WithMaxFeeBumpBudget adds the maxfeebumpbudget field to the request.
+func (a *AllowanceRequestPost) WithMaxFeeBumpBudget(budget types.Currency) *AllowanceRequestPost {
+	a.values.Set("maxfeebumpbudget", budget.String())
+	return a
+}
+
+// WithArchiveHosts adds the archivehosts field to the request.
+func (a *AllowanceRequestPost) WithArchiveHosts(hosts uint64) *AllowanceRequestPost {
+	a.values.Set("archivehosts", fmt.Sprint(hosts))
+	return a
+}
+
+// WithArchiveExpectedStorage adds the archiveexpectedstorage field to the
+// request.
+func (a *AllowanceRequestPost) WithArchiveExpectedStorage(expectedStorage uint64) *AllowanceRequestPost {
+	a.values.Set("archiveexpectedstorage", fmt.Sprint(expectedStorage))
+	return a
+}
+
+// WithArchiveMaxStoragePrice adds the archivemaxstorageprice field to the
+// request.
+func (a *AllowanceRequestPost) WithArchiveMaxStoragePrice(price types.Currency) *AllowanceRequestPost {
+	a.values.Set("archivemaxstorageprice", price.String())
+	return a
+}
+
+// WithProfiles adds the profiles field to the request.
+func (a *AllowanceRequestPost) WithProfiles(profiles map[string]skymodules.AllowanceProfile) *AllowanceRequestPost {
+	// Marshaling a map of plain structs can't fail.
+	b, _ := json.Marshal(profiles)
+	a.values.Set("profiles", string(b))
+	return a
+}
+
 // Send finalizes and sends the request.
 func (a *AllowanceRequestPost) Send() (err error) {
 	if a.sent {
@@ -178,6 +214,15 @@ func (c *Client) RenterContractCancelPost(id types.FileContractID) (err error) {
 	return
 }
 
+// RenterContractDefragPost uses the /renter/contract/defrag endpoint to delete
+// the sectors which are no longer referenced from a contract
+func (c *Client) RenterContractDefragPost(id types.FileContractID) (report skymodules.ContractDefragReport, err error) {
+	values := url.Values{}
+	values.Set("id", id.String())
+	err = c.post("/renter/contract/defrag", values.Encode(), &report)
+	return
+}
+
 // RenterAllContractsGet requests the /renter/contracts resource with all
 // options set to true
 func (c *Client) RenterAllContractsGet() (rc api.RenterContracts, err error) {
@@ -196,6 +241,43 @@ func (c *Client) RenterContractsGet() (rc api.RenterContracts, err error) {
 	return
 }
 
+// RenterContractsSnapshotGet requests the /renter/contracts/snapshot resource
+// and returns a signed snapshot of the renter's contracts.
+func (c *Client) RenterContractsSnapshotGet() (ss skymodules.SignedContractSetSnapshot, err error) {
+	err = c.get("/renter/contracts/snapshot", &ss)
+	return
+}
+
+// RenterContractsSnapshotCSVGet requests the /renter/contracts/snapshot
+// resource and returns the snapshot of the renter's contracts as CSV.
+func (c *Client) RenterContractsSnapshotCSVGet() ([]byte, error) {
+	_, csv, err := c.getRawResponse("/renter/contracts/snapshot?format=csv")
+	return csv, err
+}
+
+// RenterContractsSnapshotDiffPost uses the /renter/contracts/snapshot/diff
+// endpoint to compare the renter's contracts to a previous snapshot.
+func (c *Client) RenterContractsSnapshotDiffPost(previous skymodules.SignedContractSetSnapshot) (diff api.RenterContractsSnapshotDiffPOST, err error) {
+	data, err := json.Marshal(previous)
+	if err != nil {
+		return api.RenterContractsSnapshotDiffPOST{}, err
+	}
+	err = c.post("/renter/contracts/snapshot/diff", string(data), &diff)
+	return
+}
+
+// RenterContractsSnapshotDiffCSVPost uses the /renter/contracts/snapshot/diff
+// endpoint to compare the renter's contracts to a previous snapshot and
+// returns the diff as CSV.
+func (c *Client) RenterContractsSnapshotDiffCSVPost(previous skymodules.SignedContractSetSnapshot) ([]byte, error) {
+	data, err := json.Marshal(previous)
+	if err != nil {
+		return nil, err
+	}
+	_, csv, err := c.postRawResponse("/renter/contracts/snapshot/diff?format=csv", bytes.NewReader(data))
+	return csv, err
+}
+
 // RenterContractStatus requests the /watchdog/contractstatus resource and returns
 // the status of a contract.
 func (c *Client) RenterContractStatus(fcID types.FileContractID) (status skymodules.ContractWatchStatus, err error) {
@@ -205,6 +287,13 @@ func (c *Client) RenterContractStatus(fcID types.FileContractID) (status skymodu
 	return
 }
 
+// RenterContractFeeBumpsGet requests the /renter/contractfeebumps resource and
+// returns the pending fee bumps of unconfirmed contract transactions.
+func (c *Client) RenterContractFeeBumpsGet() (fb api.RenterContractFeeBumpsGET, err error) {
+	err = c.get("/renter/contractfeebumps", &fb)
+	return
+}
+
 // RenterDisabledContractsGet requests the /renter/contracts resource with the
 // disabled flag set to true
 func (c *Client) RenterDisabledContractsGet() (rc api.RenterContracts, err error) {
@@ -230,6 +319,31 @@ func (c *Client) RenterInitContractRecoveryScanPost() (err error) {
 	return
 }
 
+// RenterInitContractRecoveryScanRangePost initializes a contract recovery
+// scan of the blocks between start and end (inclusive) using the
+// /renter/recoveryscan endpoint.
+func (c *Client) RenterInitContractRecoveryScanRangePost(start, end types.BlockHeight) (err error) {
+	values := url.Values{}
+	values.Set("startheight", fmt.Sprint(start))
+	values.Set("endheight", fmt.Sprint(end))
+	err = c.post("/renter/recoveryscan", values.Encode(), nil)
+	return
+}
+
+// RenterContractRecoveryScanPausePost pauses the running contract recovery
+// scan using the /renter/recoveryscan/pause endpoint.
+func (c *Client) RenterContractRecoveryScanPausePost() (err error) {
+	err = c.post("/renter/recoveryscan/pause", "", nil)
+	return
+}
+
+// RenterContractRecoveryScanResumePost resumes a paused contract recovery
+// scan using the /renter/recoveryscan/resume endpoint.
+func (c *Client) RenterContractRecoveryScanResumePost() (err error) {
+	err = c.post("/renter/recoveryscan/resume", "", nil)
+	return
+}
+
 // RenterContractRecoveryProgressGet returns information about potentially
 // ongoing contract recovery scans.
 func (c *Client) RenterContractRecoveryProgressGet() (rrs api.RenterRecoveryStatusGET, err error) {
@@ -279,6 +393,14 @@ func (c *Client) RenterFileDeletePost(siaPath skymodules.SiaPath) (err error) {
 	return
 }
 
+// RenterArchiveRetrievePost uses the /renter/archive/retrieve endpoint to start
+// the retrieval of an archived file.
+func (c *Client) RenterArchiveRetrievePost(siaPath skymodules.SiaPath) (err error) {
+	sp := escapeSiaPath(siaPath)
+	err = c.post(fmt.Sprintf("/renter/archive/retrieve/%s", sp), "", nil)
+	return
+}
+
 // RenterDownloadGet uses the /renter/download endpoint to download a file to a
 // destination on disk.
 func (c *Client) RenterDownloadGet(siaPath skymodules.SiaPath, destination string, offset, length uint64, async bool, disableLocalFetch bool, root bool) (skymodules.DownloadID, error) {
@@ -414,6 +536,21 @@ func (c *Client) RenterDownloadsGet() (rdq api.RenterDownloadQueue, err error) {
 	return
 }
 
+// RenterPendingDownloadsGet requests the /renter/downloads/pending resource
+func (c *Client) RenterPendingDownloadsGet() (rpdg api.RenterPendingDownloadsGET, err error) {
+	err = c.get("/renter/downloads/pending", &rpdg)
+	return
+}
+
+// RenterPendingDownloadsCancelPost uses the /renter/downloads/pending/cancel
+// endpoint to cancel a pending download.
+func (c *Client) RenterPendingDownloadsCancelPost(id skymodules.DownloadID) (err error) {
+	values := url.Values{}
+	values.Set("id", string(id))
+	err = c.post("/renter/downloads/pending/cancel", values.Encode(), nil)
+	return
+}
+
 // RenterDownloadsRootGet requests the /renter/downloads resource with the root
 // flag set.
 func (c *Client) RenterDownloadsRootGet() (rdq api.RenterDownloadQueue, err error) {
@@ -465,6 +602,24 @@ func (c *Client) RenterGet() (rg api.RenterGET, err error) {
 	return
 }
 
+// RenterAutoFundGet requests the /renter/autofund endpoint's resources.
+func (c *Client) RenterAutoFundGet() (rafg api.RenterAutoFundGET, err error) {
+	err = c.get("/renter/autofund", &rafg)
+	return
+}
+
+// RenterAutoFundPost uses the /renter/autofund endpoint to update the
+// auto-funding settings.
+func (c *Client) RenterAutoFundPost(settings skymodules.AutoFundSettings) (err error) {
+	values := url.Values{}
+	values.Set("enabled", strconv.FormatBool(settings.Enabled))
+	values.Set("threshold", settings.Threshold.String())
+	values.Set("amount", settings.Amount.String())
+	values.Set("maxperperiod", settings.MaxPerPeriod.String())
+	err = c.post("/renter/autofund", values.Encode(), nil)
+	return
+}
+
 // RenterPostAllowance uses the /renter endpoint to change the renter's allowance
 func (c *Client) RenterPostAllowance(allowance skymodules.Allowance) error {
 	a := c.RenterPostPartialAllowance()
@@ -496,6 +651,13 @@ func (c *Client) RenterPricesGet(allowance skymodules.Allowance) (rpg api.Renter
 	return
 }
 
+// RenterSpendingForecastGet requests the /renter/spending/forecast endpoint's
+// resources.
+func (c *Client) RenterSpendingForecastGet() (rsfg api.RenterSpendingForecastGET, err error) {
+	err = c.get("/renter/spending/forecast", &rsfg)
+	return
+}
+
 // RenterRateLimitPost uses the /renter endpoint to change the renter's bandwidth rate
 // limit.
 func (c *Client) RenterRateLimitPost(readBPS, writeBPS int64) (err error) {
@@ -506,6 +668,77 @@ func (c *Client) RenterRateLimitPost(readBPS, writeBPS int64) (err error) {
 	return
 }
 
+// RenterOverdrivePost uses the /renter endpoint to set the renter's default
+// overdrive settings.
+func (c *Client) RenterOverdrivePost(ods skymodules.OverdriveSettings) (err error) {
+	values := url.Values{}
+	values.Set("overdrive", string(ods.Strategy))
+	values.Set("overdrivetarget", fmt.Sprint(ods.LatencyTarget.Milliseconds()))
+	values.Set("overdrivemaxcost", ods.MaxCost.String()+"H")
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
+// RenterTrafficSharesPost uses the /renter endpoint to set the shares of the
+// renter's bandwidth limits that the traffic classes are allowed to use.
+func (c *Client) RenterTrafficSharesPost(shares skymodules.TrafficShares) (err error) {
+	values := url.Values{}
+	values.Set("interactiveshare", fmt.Sprint(shares.Interactive))
+	values.Set("streamingshare", fmt.Sprint(shares.Streaming))
+	values.Set("repairshare", fmt.Sprint(shares.Repair))
+	values.Set("backupshare", fmt.Sprint(shares.Backup))
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
+// RenterSkyfileUploadLimitsPost uses the /renter endpoint to set the limits
+// imposed on skyfile uploads.
+func (c *Client) RenterSkyfileUploadLimitsPost(limits skymodules.SkyfileUploadLimits) (err error) {
+	values := url.Values{}
+	values.Set("maxskyfileuploadsize", fmt.Sprint(limits.MaxUploadSize))
+	values.Set("maxskyfilesubfiles", fmt.Sprint(limits.MaxSubfiles))
+	values.Set("maxskyfilemetadatasize", fmt.Sprint(limits.MaxMetadataSize))
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
+// RenterFanoutRedundancyPolicyPost uses the /renter endpoint to set the policy
+// which determines the redundancy of the fanout of uploaded skyfiles.
+func (c *Client) RenterFanoutRedundancyPolicyPost(policy skymodules.FanoutRedundancyPolicy) (err error) {
+	values := url.Values{}
+	values.Set("fanoutredundancypolicy", string(policy))
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
+// RenterPriceTableUpdateFractionPost uses the /renter endpoint to set the
+// fraction of a price table's validity after which the workers update it.
+func (c *Client) RenterPriceTableUpdateFractionPost(fraction float64) (err error) {
+	values := url.Values{}
+	values.Set("pricetableupdatefraction", fmt.Sprint(fraction))
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
+// RenterBaseSectorHedgingPost uses the /renter endpoint to set the settings of
+// the hedged base sector downloads.
+func (c *Client) RenterBaseSectorHedgingPost(hs skymodules.HedgingSettings) (err error) {
+	values := url.Values{}
+	values.Set("basesectorhedgingdisabled", fmt.Sprint(hs.Disabled))
+	values.Set("basesectorhedgingpercentile", fmt.Sprint(hs.Percentile))
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
+// RenterMemoryLimitPost uses the /renter endpoint to set the renter's soft
+// memory limit.
+func (c *Client) RenterMemoryLimitPost(limit uint64) (err error) {
+	values := url.Values{}
+	values.Set("memorylimit", fmt.Sprint(limit))
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
 // RenterRenamePost uses the /renter/rename/:siapath endpoint to rename a file.
 func (c *Client) RenterRenamePost(siaPathOld, siaPathNew skymodules.SiaPath, root bool) (err error) {
 	spo := escapeSiaPath(siaPathOld)
@@ -556,6 +789,18 @@ func (c *Client) RenterStreamPartialGet(siaPath skymodules.SiaPath, start, end u
 	return
 }
 
+// RenterDirDownloadGet uses the /renter/dir/download endpoint to download all
+// of the files within a directory as an archive of the given format.
+func (c *Client) RenterDirDownloadGet(siaPath skymodules.SiaPath, format skymodules.SkyfileFormat, disableLocalFetch, root bool) (resp []byte, err error) {
+	values := url.Values{}
+	values.Set("format", string(format))
+	values.Set("disablelocalfetch", fmt.Sprint(disableLocalFetch))
+	values.Set("root", fmt.Sprint(root))
+	sp := escapeSiaPath(siaPath)
+	_, resp, err = c.getRawResponse(fmt.Sprintf("/renter/dir/download/%s?%s", sp, values.Encode()))
+	return
+}
+
 // RenterSetRepairPathPost uses the /renter/tracking endpoint to set the repair
 // path of a file to a new location. The file at newPath must exists.
 func (c *Client) RenterSetRepairPathPost(siaPath skymodules.SiaPath, newPath string) (err error) {
@@ -595,6 +840,45 @@ func (c *Client) RenterUploadForcePost(path string, siaPath skymodules.SiaPath,
 	return
 }
 
+// RenterUploadArchivePost uses the /renter/upload endpoint to upload a file to
+// the renter's archive hosts.
+func (c *Client) RenterUploadArchivePost(path string, siaPath skymodules.SiaPath, dataPieces, parityPieces uint64) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("source", path)
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("archive", strconv.FormatBool(true))
+	err = c.post(fmt.Sprintf("/renter/upload/%s", sp), values.Encode(), nil)
+	return
+}
+
+// RenterUploadProfilePost uses the /renter/upload endpoint to upload a file to
+// the hosts of the allowance profile with the given name.
+func (c *Client) RenterUploadProfilePost(path string, siaPath skymodules.SiaPath, dataPieces, parityPieces uint64, profile string) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("source", path)
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("profile", profile)
+	err = c.post(fmt.Sprintf("/renter/upload/%s", sp), values.Encode(), nil)
+	return
+}
+
+// RenterUploadAllowedHostsPost uses the /renter/upload endpoint to upload a
+// file to the given subset of the renter's hosts.
+func (c *Client) RenterUploadAllowedHostsPost(path string, siaPath skymodules.SiaPath, dataPieces, parityPieces uint64, hosts []types.SiaPublicKey) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("source", path)
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("allowedhosts", allowedHostsString(hosts))
+	err = c.post(fmt.Sprintf("/renter/upload/%s", sp), values.Encode(), nil)
+	return
+}
+
 // RenterUploadDefaultPost uses the /renter/upload endpoint with default
 // redundancy settings to upload a file.
 func (c *Client) RenterUploadDefaultPost(path string, siaPath skymodules.SiaPath) (err error) {
@@ -787,6 +1071,109 @@ func (c *Client) RenterWorkersSortedGet(sorted bool) (wps skymodules.WorkerPoolS
 	return
 }
 
+// RenterWorkersAccountRefillPost uses the /renter/workers/accountrefill
+// endpoint to set the refill settings of the ephemeral account on a host.
+func (c *Client) RenterWorkersAccountRefillPost(hostKey types.SiaPublicKey, settings skymodules.WorkerAccountRefillSettings) (err error) {
+	values := url.Values{}
+	values.Set("hostkey", hostKey.String())
+	values.Set("target", settings.Target.String())
+	values.Set("threshold", settings.Threshold.String())
+	err = c.post("/renter/workers/accountrefill", values.Encode(), nil)
+	return
+}
+
+// RenterWorkersResetCooldownPost uses the /renter/workers/resetcooldown
+// endpoint to take the worker of a host off its cooldowns.
+func (c *Client) RenterWorkersResetCooldownPost(hostKey types.SiaPublicKey) (err error) {
+	values := url.Values{}
+	values.Set("hostkey", hostKey.String())
+	err = c.post("/renter/workers/resetcooldown", values.Encode(), nil)
+	return
+}
+
+// RenterWorkersDetailGet uses the /renter/workers/detail endpoint to get the
+// full internal state of the renter's workers.
+func (c *Client) RenterWorkersDetailGet() (wpd skymodules.WorkerPoolDetail, err error) {
+	err = c.get("/renter/workers/detail", &wpd)
+	return
+}
+
+// RenterWorkerDetailGet uses the /renter/workers/detail endpoint to get the
+// full internal state of the worker of a host.
+func (c *Client) RenterWorkerDetailGet(hostKey types.SiaPublicKey) (wd skymodules.WorkerDetail, err error) {
+	values := url.Values{}
+	values.Set("hostkey", hostKey.String())
+	var wpd skymodules.WorkerPoolDetail
+	err = c.get("/renter/workers/detail?"+values.Encode(), &wpd)
+	if err != nil {
+		return
+	}
+	if len(wpd.Workers) != 1 {
+		err = fmt.Errorf("expected 1 worker but got %v", len(wpd.Workers))
+		return
+	}
+	wd = wpd.Workers[0]
+	return
+}
+
+// RenterWorkersDiagnosePost uses the /renter/workers/diagnose endpoint to run
+// a live connectivity test against the host of a worker.
+func (c *Client) RenterWorkersDiagnosePost(hostKey types.SiaPublicKey) (report skymodules.WorkerConnectionReport, err error) {
+	values := url.Values{}
+	values.Set("hostkey", hostKey.String())
+	err = c.post("/renter/workers/diagnose", values.Encode(), &report)
+	return
+}
+
+// RenterWorkersFlushPost uses the /renter/workers/flush endpoint to discard
+// all jobs queued with the worker of a host.
+func (c *Client) RenterWorkersFlushPost(hostKey types.SiaPublicKey) (err error) {
+	values := url.Values{}
+	values.Set("hostkey", hostKey.String())
+	err = c.post("/renter/workers/flush", values.Encode(), nil)
+	return
+}
+
+// RenterWorkersPausePost uses the /renter/workers/pause endpoint to pause the
+// worker of a host.
+func (c *Client) RenterWorkersPausePost(hostKey types.SiaPublicKey) (err error) {
+	values := url.Values{}
+	values.Set("hostkey", hostKey.String())
+	err = c.post("/renter/workers/pause", values.Encode(), nil)
+	return
+}
+
+// RenterWorkersResumePost uses the /renter/workers/resume endpoint to resume
+// the paused worker of a host.
+func (c *Client) RenterWorkersResumePost(hostKey types.SiaPublicKey) (err error) {
+	values := url.Values{}
+	values.Set("hostkey", hostKey.String())
+	err = c.post("/renter/workers/resume", values.Encode(), nil)
+	return
+}
+
+// RenterWorkersBlocklistGet uses the /renter/workers/blocklist endpoint to get
+// the worker blocklist.
+func (c *Client) RenterWorkersBlocklistGet() (wbg api.RenterWorkersBlocklistGET, err error) {
+	err = c.get("/renter/workers/blocklist", &wbg)
+	return
+}
+
+// RenterWorkersBlocklistPost uses the /renter/workers/blocklist endpoint to
+// add and remove entries from the worker blocklist.
+func (c *Client) RenterWorkersBlocklistPost(additions []skymodules.WorkerBlocklistEntry, removals []string) (err error) {
+	wbp := api.RenterWorkersBlocklistPOST{
+		Add:    additions,
+		Remove: removals,
+	}
+	data, err := json.Marshal(wbp)
+	if err != nil {
+		return err
+	}
+	err = c.post("/renter/workers/blocklist", string(data), nil)
+	return
+}
+
 // RenterBubblePost uses the /renter/bubble endpoint to manually trigger an
 // update to the directories metadata.
 func (c *Client) RenterBubblePost(siaPath skymodules.SiaPath, recursive bool) (err error) {
@@ -799,3 +1186,13 @@ func (c *Client) RenterBubblePost(siaPath skymodules.SiaPath, recursive bool) (e
 	err = c.post("/renter/bubble", values.Encode(), nil)
 	return
 }
+
+// allowedHostsString turns a list of host keys into the comma separated format
+// expected by the allowedhosts parameter of the upload endpoints.
+func allowedHostsString(hosts []types.SiaPublicKey) string {
+	hostStrs := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		hostStrs = append(hostStrs, host.String())
+	}
+	return strings.Join(hostStrs, ",")
+}
@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"path/filepath"
@@ -228,6 +230,31 @@ func (c *Client) SkynetSkylinkGet(skylink string) ([]byte, error) {
 	return c.SkynetSkylinkGetWithTimeout(skylink, -1)
 }
 
+// SkynetSkylinkSubdomainGet downloads a skylink subdomain-style by setting the
+// Host header to the base32 encoded skylink followed by the given domain. The
+// path is the path of the request.
+func (c *Client) SkynetSkylinkSubdomainGet(skylink, domain, path string) ([]byte, error) {
+	var sl skymodules.Skylink
+	if err := sl.LoadString(skylink); err != nil {
+		return nil, errors.AddContext(err, "failed to load skylink")
+	}
+	req, err := c.NewRequest("GET", path, nil)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to construct GET request")
+	}
+	req.Host = strings.ToLower(sl.Base32EncodedString()) + "." + domain
+	httpClient := http.Client{CheckRedirect: c.CheckRedirect}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, errors.AddContext(err, "GET request failed")
+	}
+	defer drainAndClose(res.Body)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, errors.AddContext(readAPIError(res.Body), "GET request error")
+	}
+	return ioutil.ReadAll(res.Body)
+}
+
 // SkynetMetadataGet uses the /skynet/metadata endpoint to fetch a skylink's
 // metadata.
 func (c *Client) SkynetMetadataGet(skylink string) (_ http.Header, sm skymodules.SkyfileMetadata, _ error) {
@@ -246,6 +273,15 @@ func (c *Client) SkynetSkylinkRange(skylink string, from, to uint64) ([]byte, er
 	return c.getRawPartialResponse(getQuery, from, to)
 }
 
+// SkynetSkylinkFormatRange uses the /skynet/skylink endpoint to download a
+// range from a skylink file served as an archive of the given format.
+func (c *Client) SkynetSkylinkFormatRange(skylink string, format skymodules.SkyfileFormat, from, to uint64) ([]byte, error) {
+	values := url.Values{}
+	values.Set("format", string(format))
+	getQuery := skylinkQueryWithValues(skylink, values)
+	return c.getRawPartialResponse(getQuery, from, to)
+}
+
 // SkynetSkylinkRangeParams uses the /skynet/skylink endpoint to download a
 // range from a skylink file using the range params instead of the header.
 func (c *Client) SkynetSkylinkRangeParams(skylink string, start, end uint64) ([]byte, error) {
@@ -269,6 +305,16 @@ func (c *Client) SkynetSkylinkGetWithTimeout(skylink string, timeout int) ([]byt
 	return c.skynetSkylinkGetWithParameters(skylink, params)
 }
 
+// SkynetSkylinkNoFanoutGet uses the /skynet/skylink endpoint with the
+// 'nofanout' flag to fetch the headers of a skylink file without downloading
+// its content.
+func (c *Client) SkynetSkylinkNoFanoutGet(skylink string) (http.Header, []byte, error) {
+	values := url.Values{}
+	values.Set("nofanout", "true")
+	getQuery := skylinkQueryWithValues(skylink, values)
+	return c.getRawResponse(getQuery)
+}
+
 // SkynetSkylinkGetWithLayout uses the /skynet/skylink endpoint to download
 // a skylink file, specifying the given value for the 'include-layout'
 // parameter.
@@ -318,6 +364,86 @@ func (c *Client) skynetSkylinkGetWithParametersRaw(skylink string, params map[st
 	return header, fileData, errors.AddContext(err, "skynetSkylinkGetWithParametersRaw with parameters failed getRawResponse")
 }
 
+// SkynetSkylinkGetWithProvenance uses the /skynet/skylink endpoint to download
+// a skylink file. It sets the 'Skynet-Include-Provenance' header and returns
+// the provenance of the downloaded chunks from the response's trailer.
+func (c *Client) SkynetSkylinkGetWithProvenance(skylink string) ([]byte, []skymodules.ChunkProvenance, error) {
+	getQuery := skylinkQueryWithValues(skylink, url.Values{})
+	req, err := c.NewRequest("GET", getQuery, nil)
+	if err != nil {
+		return nil, nil, errors.AddContext(err, "failed to construct GET request")
+	}
+	req.Header.Set(api.SkynetIncludeProvenanceHeader, "true")
+
+	httpClient := http.Client{CheckRedirect: c.CheckRedirect}
+	// nolint:bodyclose // body is closed by drainAndClose
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, errors.AddContext(err, "GET request failed")
+	}
+	defer drainAndClose(res.Body)
+
+	// If the status code is not 2xx, decode and return the accompanying
+	// api.Error.
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, nil, errors.AddContext(readAPIError(res.Body), "GET request error")
+	}
+
+	// The trailer is only available after the body was read.
+	fileData, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, errors.AddContext(err, "failed to read all bytes from reader")
+	}
+	var provenance []skymodules.ChunkProvenance
+	err = json.Unmarshal([]byte(res.Trailer.Get(api.SkynetProvenanceHeader)), &provenance)
+	if err != nil {
+		return nil, nil, errors.AddContext(err, "unable to decode provenance trailer")
+	}
+	return fileData, provenance, nil
+}
+
+// SkynetSkylinkGetWithBandwidth uses the /skynet/skylink endpoint to download
+// a skylink file. It sets the 'Skynet-Include-Bandwidth' header and returns
+// the number of bytes that were sent to and received from hosts to serve the
+// download from the response's trailers.
+func (c *Client) SkynetSkylinkGetWithBandwidth(skylink string) (_ []byte, up, down uint64, _ error) {
+	getQuery := skylinkQueryWithValues(skylink, url.Values{})
+	req, err := c.NewRequest("GET", getQuery, nil)
+	if err != nil {
+		return nil, 0, 0, errors.AddContext(err, "failed to construct GET request")
+	}
+	req.Header.Set(api.SkynetIncludeBandwidthHeader, "true")
+
+	httpClient := http.Client{CheckRedirect: c.CheckRedirect}
+	// nolint:bodyclose // body is closed by drainAndClose
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, 0, 0, errors.AddContext(err, "GET request failed")
+	}
+	defer drainAndClose(res.Body)
+
+	// If the status code is not 2xx, decode and return the accompanying
+	// api.Error.
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, 0, 0, errors.AddContext(readAPIError(res.Body), "GET request error")
+	}
+
+	// The trailers are only available after the body was read.
+	fileData, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, 0, 0, errors.AddContext(err, "failed to read all bytes from reader")
+	}
+	up, err = strconv.ParseUint(res.Trailer.Get(api.SkynetBandwidthUpHeader), 10, 64)
+	if err != nil {
+		return nil, 0, 0, errors.AddContext(err, "unable to parse upload bandwidth trailer")
+	}
+	down, err = strconv.ParseUint(res.Trailer.Get(api.SkynetBandwidthDownHeader), 10, 64)
+	if err != nil {
+		return nil, 0, 0, errors.AddContext(err, "unable to parse download bandwidth trailer")
+	}
+	return fileData, up, down, nil
+}
+
 // SkynetSkylinkHead uses the /skynet/skylink endpoint to get the headers that
 // are returned if the skyfile were to be requested using the SkynetSkylinkGet
 // method.
@@ -512,6 +638,40 @@ func (c *Client) SkynetSkylinkRestorePost(backup io.Reader) (string, error) {
 	return srp.Skylink, nil
 }
 
+// SkynetFolderGet lists the skyfiles within the skynet folder at the given
+// siapath. If a filter is provided, only skyfiles with matching extra metadata
+// are returned.
+func (c *Client) SkynetFolderGet(siaPath skymodules.SiaPath, recursive bool, filter string) (sfg api.SkynetFolderGET, err error) {
+	values := url.Values{}
+	values.Set("recursive", strconv.FormatBool(recursive))
+	if filter != "" {
+		values.Set("extrametadata", filter)
+	}
+	err = c.get(fmt.Sprintf("/skynet/folder/%s?%s", siaPath.String(), values.Encode()), &sfg)
+	return
+}
+
+// SkynetSkylinksGet lists the skylinks known to the node. The values are
+// passed on as the filter of the request.
+func (c *Client) SkynetSkylinksGet(values url.Values) (ssg api.SkynetSkylinksGET, err error) {
+	err = c.get("/skynet/skylinks?"+values.Encode(), &ssg)
+	return
+}
+
+// SkynetImportPost uses the /skynet/import endpoint to import a skylink from
+// the given portal.
+func (c *Client) SkynetImportPost(skylink, portal string) (string, error) {
+	values := url.Values{}
+	values.Set("portal", portal)
+	query := fmt.Sprintf("/skynet/import/%s?%s", skylink, values.Encode())
+	var sip api.SkynetImportPOST
+	err := c.post(query, "", &sip)
+	if err != nil {
+		return "", errors.AddContext(err, "post call to "+query+" failed")
+	}
+	return sip.Skylink, nil
+}
+
 // SkynetSkylinkReaderGet uses the /skynet/skylink endpoint to fetch a reader of
 // the file data.
 func (c *Client) SkynetSkylinkReaderGet(skylink string) (io.ReadCloser, error) {
@@ -575,6 +735,22 @@ func (c *Client) SkynetSkylinkPinPostWithTimeout(skylink string, spp skymodules.
 	return nil
 }
 
+// SkynetSkylinkPinWithDependenciesPost uses the /skynet/pin endpoint to pin
+// the file at the given skylink together with the skylinks it references up to
+// the dependency depth of the parameters.
+func (c *Client) SkynetSkylinkPinWithDependenciesPost(skylink string, spp skymodules.SkyfilePinParameters) (spg api.SkynetPinPOST, err error) {
+	values := urlValuesFromSkyfilePinParameters(spp)
+	values.Set("timeout", fmt.Sprintf("%d", uint64(api.DefaultSkynetRequestTimeout.Seconds())))
+
+	query := fmt.Sprintf("/skynet/pin/%s?%s", skylink, values.Encode())
+	_, resp, err := c.postRawResponse(query, nil)
+	if err != nil {
+		return api.SkynetPinPOST{}, errors.AddContext(err, "post call to "+query+" failed")
+	}
+	err = json.Unmarshal(resp, &spg)
+	return
+}
+
 // SkynetSkyfilePost uses the /skynet/skyfile endpoint to upload a skyfile.  The
 // resulting skylink is returned along with an error.
 func (c *Client) SkynetSkyfilePost(sup skymodules.SkyfileUploadParameters) (string, api.SkynetSkyfileHandlerPOST, error) {
@@ -598,6 +774,31 @@ func (c *Client) SkynetSkyfilePost(sup skymodules.SkyfileUploadParameters) (stri
 	return rshp.Skylink, rshp, err
 }
 
+// SkynetSkyfileExtractPost uses the /skynet/skyfile endpoint to upload a tar,
+// tar.gz or zip archive which is extracted by the node. The archived files are
+// uploaded as the subfiles of a single skyfile. The resulting skylink is
+// returned along with an error.
+func (c *Client) SkynetSkyfileExtractPost(sup skymodules.SkyfileUploadParameters) (string, api.SkynetSkyfileHandlerPOST, error) {
+	values, err := urlValuesFromSkyfileUploadParameters(sup)
+	if err != nil {
+		return "", api.SkynetSkyfileHandlerPOST{}, errors.AddContext(err, "failed to get url values")
+	}
+	values.Set("extract", "true")
+	query := fmt.Sprintf("/skynet/skyfile/%s?%s", sup.SiaPath.String(), values.Encode())
+	_, resp, err := c.postRawResponse(query, sup.Reader)
+	if err != nil {
+		return "", api.SkynetSkyfileHandlerPOST{}, errors.AddContext(err, "post call to "+query+" failed")
+	}
+
+	// Parse the response to get the skylink.
+	var rshp api.SkynetSkyfileHandlerPOST
+	err = json.Unmarshal(resp, &rshp)
+	if err != nil {
+		return "", api.SkynetSkyfileHandlerPOST{}, errors.AddContext(err, "unable to parse the skylink upload response")
+	}
+	return rshp.Skylink, rshp, err
+}
+
 // SkynetSkyfilePostDisableForce uses the /skynet/skyfile endpoint to upload a
 // skyfile. This method allows to set the Disable-Force header. The resulting
 // skylink is returned along with an error.
@@ -689,6 +890,93 @@ func (c *Client) SkynetConvertSiafileToSkyfilePost(sup skymodules.SkyfileUploadP
 	return rshp, nil
 }
 
+// SkynetBatchDownloadPost uses the /skynet/batchdownload endpoint to download
+// multiple skylinks with a single request. The results are returned in the
+// order of the downloads.
+func (c *Client) SkynetBatchDownloadPost(downloads []api.SkynetBatchDownload) ([]api.SkynetBatchDownloadResult, error) {
+	_, resp, err := c.skynetBatchDownloadPost(downloads, api.SkynetBatchFormatNDJSON)
+	if err != nil {
+		return nil, err
+	}
+	var results []api.SkynetBatchDownloadResult
+	dec := json.NewDecoder(bytes.NewReader(resp))
+	for dec.More() {
+		var result api.SkynetBatchDownloadResult
+		if err := dec.Decode(&result); err != nil {
+			return nil, errors.AddContext(err, "failed to decode batch download result")
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// SkynetBatchDownloadMultipartPost uses the /skynet/batchdownload endpoint to
+// download multiple skylinks with a single multipart response. The results are
+// reconstructed from the headers of the parts.
+func (c *Client) SkynetBatchDownloadMultipartPost(downloads []api.SkynetBatchDownload) ([]api.SkynetBatchDownloadResult, error) {
+	header, resp, err := c.skynetBatchDownloadPost(downloads, api.SkynetBatchFormatMultipart)
+	if err != nil {
+		return nil, err
+	}
+	_, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to parse Content-Type")
+	}
+	var results []api.SkynetBatchDownloadResult
+	mr := multipart.NewReader(bytes.NewReader(resp), params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if errors.Contains(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to read part")
+		}
+		data, err := ioutil.ReadAll(part)
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to read part data")
+		}
+		index, err := strconv.Atoi(part.Header.Get(api.SkynetBatchIndexHeader))
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to parse part index")
+		}
+		result := api.SkynetBatchDownloadResult{
+			Index:            index,
+			Skylink:          part.Header.Get(api.SkynetSkylinkHeader),
+			RequestedSkylink: part.Header.Get(api.SkynetRequestedSkylinkHeader),
+			Error:            part.Header.Get(api.SkynetBatchErrorHeader),
+		}
+		if result.Error == "" {
+			result.ContentType = part.Header.Get("Content-Type")
+			result.Data = data
+			result.Length = uint64(len(data))
+			if len(data) > 0 {
+				var last uint64
+				_, err = fmt.Sscanf(part.Header.Get("Content-Range"), "bytes %d-%d/%d", &result.Offset, &last, &result.Size)
+			} else {
+				_, err = fmt.Sscanf(part.Header.Get("Content-Range"), "bytes */%d", &result.Size)
+			}
+			if err != nil {
+				return nil, errors.AddContext(err, "failed to parse Content-Range")
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// skynetBatchDownloadPost posts the downloads to the /skynet/batchdownload
+// endpoint and returns the raw response.
+func (c *Client) skynetBatchDownloadPost(downloads []api.SkynetBatchDownload, format string) (http.Header, []byte, error) {
+	reqBytes, err := json.Marshal(api.SkynetBatchDownloadRequestPOST{Downloads: downloads})
+	if err != nil {
+		return nil, nil, err
+	}
+	values := url.Values{}
+	values.Set("format", format)
+	return c.postRawResponse("/skynet/batchdownload?"+values.Encode(), bytes.NewReader(reqBytes))
+}
+
 // SkynetBlocklistGet requests the /skynet/blocklist Get endpoint
 func (c *Client) SkynetBlocklistGet() (blocklist api.SkynetBlocklistGET, err error) {
 	err = c.get("/skynet/blocklist", &blocklist)
@@ -716,6 +1004,20 @@ func (c *Client) SkynetBlocklistPost(additions, removals []string) (err error) {
 	return
 }
 
+// SkynetBlocklistCheckPost requests the /skynet/blocklist/check Post endpoint
+func (c *Client) SkynetBlocklistCheckPost(candidates []string, isHash bool) (resp api.SkynetBlocklistCheckPOST, err error) {
+	sbcp := api.SkynetBlocklistCheckRequestPOST{
+		Check:  candidates,
+		IsHash: isHash,
+	}
+	data, err := json.Marshal(sbcp)
+	if err != nil {
+		return api.SkynetBlocklistCheckPOST{}, err
+	}
+	err = c.post("/skynet/blocklist/check", string(data), &resp)
+	return
+}
+
 // SkynetPortalsGet requests the /skynet/portals Get endpoint.
 func (c *Client) SkynetPortalsGet() (portals api.SkynetPortalsGET, err error) {
 	err = c.get("/skynet/portals", &portals)
@@ -736,12 +1038,91 @@ func (c *Client) SkynetPortalsPost(additions []skymodules.SkynetPortal, removals
 	return
 }
 
+// SkynetQuotasGet requests the /skynet/quotas Get endpoint.
+func (c *Client) SkynetQuotasGet() (quotas api.SkynetQuotasGET, err error) {
+	err = c.get("/skynet/quotas", &quotas)
+	return
+}
+
+// SkynetQuotaGet requests the /skynet/quotas Get endpoint for a single
+// folder.
+func (c *Client) SkynetQuotaGet(folder string) (usage skymodules.SkynetFolderUsage, err error) {
+	values := url.Values{}
+	values.Set("folder", folder)
+	var quotas api.SkynetQuotasGET
+	err = c.get(fmt.Sprintf("/skynet/quotas?%s", values.Encode()), &quotas)
+	if err != nil {
+		return skymodules.SkynetFolderUsage{}, err
+	}
+	if len(quotas.Folders) != 1 {
+		return skymodules.SkynetFolderUsage{}, fmt.Errorf("expected 1 folder but got %v", len(quotas.Folders))
+	}
+	return quotas.Folders[0], nil
+}
+
+// SkynetQuotasPost requests the /skynet/quotas Post endpoint which sets the
+// quota of a top-level folder of the skynet folder.
+func (c *Client) SkynetQuotasPost(folder string, quota uint64) (err error) {
+	data, err := json.Marshal(api.SkynetQuotasPOST{
+		Folder: folder,
+		Quota:  quota,
+	})
+	if err != nil {
+		return err
+	}
+	err = c.post("/skynet/quotas", string(data), nil)
+	return
+}
+
 // SkynetStatsGet requests the /skynet/stats Get endpoint
 func (c *Client) SkynetStatsGet() (stats api.SkynetStatsGET, err error) {
 	err = c.get("/skynet/stats", &stats)
 	return
 }
 
+// SkynetTokensGet requests the /skynet/tokens Get endpoint.
+func (c *Client) SkynetTokensGet() (tokens api.SkynetTokensGET, err error) {
+	err = c.get("/skynet/tokens", &tokens)
+	return
+}
+
+// SkynetTokensPost requests the /skynet/tokens Post endpoint which creates a
+// new API token.
+func (c *Client) SkynetTokensPost(name string, scope skymodules.APITokenScope, quota skymodules.APITokenQuota) (token api.SkynetTokenPOST, err error) {
+	data, err := json.Marshal(api.SkynetTokensPOST{
+		Name:  name,
+		Scope: scope,
+		Quota: quota,
+	})
+	if err != nil {
+		return api.SkynetTokenPOST{}, err
+	}
+	err = c.post("/skynet/tokens", string(data), &token)
+	return
+}
+
+// SkynetTokenQuotaPost requests the /skynet/tokens/:id/quota Post endpoint.
+func (c *Client) SkynetTokenQuotaPost(id string, quota skymodules.APITokenQuota) (err error) {
+	data, err := json.Marshal(quota)
+	if err != nil {
+		return err
+	}
+	err = c.post(fmt.Sprintf("/skynet/tokens/%s/quota", id), string(data), nil)
+	return
+}
+
+// SkynetTokenUsageGet requests the /skynet/tokens/:id/usage Get endpoint.
+func (c *Client) SkynetTokenUsageGet(id string) (usage api.SkynetTokenUsageGET, err error) {
+	err = c.get(fmt.Sprintf("/skynet/tokens/%s/usage", id), &usage)
+	return
+}
+
+// SkynetTokenRevokePost requests the /skynet/tokens/:id/revoke Post endpoint.
+func (c *Client) SkynetTokenRevokePost(id string) (err error) {
+	err = c.post(fmt.Sprintf("/skynet/tokens/%s/revoke", id), "", nil)
+	return
+}
+
 // SkykeyGetByName requests the /skynet/skykey Get endpoint using the key name.
 func (c *Client) SkykeyGetByName(name string) (skykey.Skykey, error) {
 	values := url.Values{}
@@ -855,12 +1236,95 @@ func (c *Client) SkykeySkykeysGet() ([]skykey.Skykey, error) {
 	return res, nil
 }
 
+// SkykeysExportPost requests the /skynet/skykeys/export POST endpoint to
+// export a password protected bundle of the renter's skykeys.
+func (c *Client) SkykeysExportPost(password string) ([]byte, error) {
+	values := url.Values{}
+	values.Set("password", password)
+	var sep api.SkykeysExportPOST
+	err := c.post("/skynet/skykeys/export", values.Encode(), &sep)
+	if err != nil {
+		return nil, errors.AddContext(err, "skykeys export POST request failed")
+	}
+	return sep.Bundle, nil
+}
+
+// SkykeysImportPost requests the /skynet/skykeys/import POST endpoint to
+// import the skykeys of a password protected bundle.
+func (c *Client) SkykeysImportPost(bundle []byte, password string) ([]skykey.Skykey, error) {
+	values := url.Values{}
+	values.Set("bundle", base64.StdEncoding.EncodeToString(bundle))
+	values.Set("password", password)
+	var sip api.SkykeysImportPOST
+	err := c.post("/skynet/skykeys/import", values.Encode(), &sip)
+	if err != nil {
+		return nil, errors.AddContext(err, "skykeys import POST request failed")
+	}
+	return decodeImportedSkykeys(sip)
+}
+
+// SkykeysBackupPost requests the /skynet/skykeys/backup POST endpoint to
+// upload a password protected bundle of the renter's skykeys.
+func (c *Client) SkykeysBackupPost(password string) (sbp api.SkykeysBackupPOST, err error) {
+	values := url.Values{}
+	values.Set("password", password)
+	err = c.post("/skynet/skykeys/backup", values.Encode(), &sbp)
+	return
+}
+
+// SkykeysRestorePost requests the /skynet/skykeys/restore/:skylink POST
+// endpoint to import the skykeys of a skykey backup.
+func (c *Client) SkykeysRestorePost(skylink, password string) ([]skykey.Skykey, error) {
+	values := url.Values{}
+	values.Set("password", password)
+	var sip api.SkykeysImportPOST
+	err := c.post("/skynet/skykeys/restore/"+skylink, values.Encode(), &sip)
+	if err != nil {
+		return nil, errors.AddContext(err, "skykeys restore POST request failed")
+	}
+	return decodeImportedSkykeys(sip)
+}
+
+// SkykeysAutoBackupGet requests the /skynet/skykeys/autobackup GET endpoint.
+func (c *Client) SkykeysAutoBackupGet() (status skymodules.SkykeyAutoBackupStatus, err error) {
+	err = c.get("/skynet/skykeys/autobackup", &status)
+	return
+}
+
+// SkykeysAutoBackupPost requests the /skynet/skykeys/autobackup POST endpoint
+// to enable the automatic skykey backup. An empty password disables it.
+func (c *Client) SkykeysAutoBackupPost(password, entryName string) (status skymodules.SkykeyAutoBackupStatus, err error) {
+	values := url.Values{}
+	values.Set("password", password)
+	values.Set("entryname", entryName)
+	err = c.post("/skynet/skykeys/autobackup", values.Encode(), &status)
+	return
+}
+
+// decodeImportedSkykeys decodes the skykeys of an import response.
+func decodeImportedSkykeys(sip api.SkykeysImportPOST) ([]skykey.Skykey, error) {
+	res := make([]skykey.Skykey, len(sip.Imported))
+	for i, skGET := range sip.Imported {
+		err := res[i].FromString(skGET.Skykey)
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to decode skykey string")
+		}
+	}
+	return res, nil
+}
+
 // SkylinkHealthGET queries the /skynet/health/skylink/:skylink endpoint.
 func (c *Client) SkylinkHealthGET(sl skymodules.Skylink) (sh skymodules.SkylinkHealth, err error) {
 	err = c.get(fmt.Sprintf("/skynet/health/skylink/%s", sl.String()), &sh)
 	return
 }
 
+// SkynetVerifyGET queries the /skynet/verify/:skylink endpoint.
+func (c *Client) SkynetVerifyGET(sl skymodules.Skylink) (sv skymodules.SkylinkVerification, err error) {
+	err = c.get(fmt.Sprintf("/skynet/verify/%s", sl.String()), &sv)
+	return
+}
+
 // RegistryRead queries the /skynet/registry [GET] endpoint.
 func (c *Client) RegistryRead(spk types.SiaPublicKey, dataKey crypto.Hash) (modules.SignedRegistryValue, error) {
 	return c.RegistryReadWithTimeout(spk, dataKey, 0)
@@ -899,6 +1363,13 @@ func (c *Client) ResolveSkylinkV2Custom(skylink string, timeout time.Duration) (
 	return srg.Skylink, h, err
 }
 
+// ResolveSkynetName queries the /skynet/resolve/:skylink [GET] endpoint with a
+// human-readable name.
+func (c *Client) ResolveSkynetName(name string) (srg api.SkylinkResolveGET, err error) {
+	err = c.get(fmt.Sprintf("/skynet/resolve/%v", name), &srg)
+	return
+}
+
 // RegistryReadWithTimeout queries the /skynet/registry [GET] endpoint with the
 // specified timeout.
 func (c *Client) RegistryReadWithTimeout(spk types.SiaPublicKey, dataKey crypto.Hash, timeout time.Duration) (modules.SignedRegistryValue, error) {
@@ -943,6 +1414,35 @@ func (c *Client) RegistryReadWithTimeout(spk types.SiaPublicKey, dataKey crypto.
 	return srv, srv.Verify(spk.ToPublicKey())
 }
 
+// RegistryReadRaw queries the /skynet/registry [GET] endpoint and returns the
+// response without decoding it. Contrary to RegistryRead, the response
+// includes the agreement of the trusted registry hosts.
+func (c *Client) RegistryReadRaw(spk types.SiaPublicKey, dataKey crypto.Hash) (rhg api.RegistryHandlerGET, err error) {
+	values := url.Values{}
+	values.Set("publickey", spk.String())
+	values.Set("datakey", dataKey.String())
+	err = c.get(fmt.Sprintf("/skynet/registry?%v", values.Encode()), &rhg)
+	return
+}
+
+// SkynetRegistryTrustedHostsGet uses the /skynet/registry/trustedhosts
+// endpoint to get the trusted registry hosts.
+func (c *Client) SkynetRegistryTrustedHostsGet() (trh skymodules.TrustedRegistryHosts, err error) {
+	err = c.get("/skynet/registry/trustedhosts", &trh)
+	return
+}
+
+// SkynetRegistryTrustedHostsPost uses the /skynet/registry/trustedhosts
+// endpoint to replace the trusted registry hosts.
+func (c *Client) SkynetRegistryTrustedHostsPost(trh skymodules.TrustedRegistryHosts) (err error) {
+	data, err := json.Marshal(trh)
+	if err != nil {
+		return err
+	}
+	err = c.post("/skynet/registry/trustedhosts", string(data), nil)
+	return
+}
+
 // RegistryEntryHealth queries the /skynet/health/entry endpoint to get a
 // registry entry's health.
 func (c *Client) RegistryEntryHealth(spk types.SiaPublicKey, dataKey crypto.Hash) (reh skymodules.RegistryEntryHealth, err error) {
@@ -969,11 +1469,18 @@ func (c *Client) RegistryUpdate(spk types.SiaPublicKey, dataKey crypto.Hash, rev
 
 // RegistryUpdateMulti queries the /skynet/registrymulti [POST] endpoint.
 func (c *Client) RegistryUpdateMulti(srvs map[string]skymodules.RegistryEntry) error {
+	_, err := c.RegistryUpdateMultiWithProof(srvs)
+	return err
+}
+
+// RegistryUpdateMultiWithProof queries the /skynet/registrymulti [POST]
+// endpoint and returns the proofs of the hosts which accepted the update.
+func (c *Client) RegistryUpdateMultiWithProof(srvs map[string]skymodules.RegistryEntry) (rhp api.RegistryHandlerPOST, err error) {
 	req := make([]api.RegistryHandlerMultiRequestPOST, 0, len(srvs))
 	for hk, srv := range srvs {
 		var hpk types.SiaPublicKey
 		if err := hpk.LoadString(hk); err != nil {
-			return fmt.Errorf("invalid hostkey %v", hk)
+			return api.RegistryHandlerPOST{}, fmt.Errorf("invalid hostkey %v", hk)
 		}
 		req = append(req, api.RegistryHandlerMultiRequestPOST{
 			RegistryHandlerRequestPOST: api.RegistryHandlerRequestPOST{
@@ -989,13 +1496,21 @@ func (c *Client) RegistryUpdateMulti(srvs map[string]skymodules.RegistryEntry) e
 	}
 	reqBytes, err := json.Marshal(req)
 	if err != nil {
-		return err
+		return api.RegistryHandlerPOST{}, err
 	}
-	return c.post("/skynet/registrymulti", string(reqBytes), nil)
+	err = c.post("/skynet/registrymulti", string(reqBytes), &rhp)
+	return
 }
 
 // RegistryUpdateWithEntry queries the /skynet/registry [POST] endpoint.
 func (c *Client) RegistryUpdateWithEntry(spk types.SiaPublicKey, srv modules.SignedRegistryValue) error {
+	_, err := c.RegistryUpdateWithProof(spk, srv)
+	return err
+}
+
+// RegistryUpdateWithProof queries the /skynet/registry [POST] endpoint and
+// returns the proofs of the hosts which accepted the update.
+func (c *Client) RegistryUpdateWithProof(spk types.SiaPublicKey, srv modules.SignedRegistryValue) (rhp api.RegistryHandlerPOST, err error) {
 	req := api.RegistryHandlerRequestPOST{
 		PublicKey: spk,
 		DataKey:   srv.Tweak,
@@ -1006,9 +1521,10 @@ func (c *Client) RegistryUpdateWithEntry(spk types.SiaPublicKey, srv modules.Sig
 	}
 	reqBytes, err := json.Marshal(req)
 	if err != nil {
-		return err
+		return api.RegistryHandlerPOST{}, err
 	}
-	return c.post("/skynet/registry", string(reqBytes), nil)
+	err = c.post("/skynet/registry", string(reqBytes), &rhp)
+	return
 }
 
 // SkylinkFromTUSURL is a helper to fetch the skylink of a finished upload.
@@ -1138,6 +1654,19 @@ func urlValuesFromSkyfileMultipartUploadParameters(sup skymodules.SkyfileMultipa
 		return url.Values{}, err
 	}
 	values.Set("errorpages", string(b))
+	if len(sup.ExtraMetadata) > 0 {
+		values.Set("extrametadata", string(sup.ExtraMetadata))
+	}
+	if len(sup.Headers) > 0 {
+		b, err := json.Marshal(sup.Headers)
+		if err != nil {
+			return url.Values{}, err
+		}
+		values.Set("headers", string(b))
+	}
+	if sup.SessionID != "" {
+		values.Set("sessionid", sup.SessionID)
+	}
 
 	return values, nil
 }
@@ -1150,6 +1679,9 @@ func urlValuesFromSkyfilePinParameters(sup skymodules.SkyfilePinParameters) url.
 	values.Set("force", fmt.Sprintf("%t", sup.Force))
 	values.Set("root", fmt.Sprintf("%t", sup.Root))
 	values.Set("basechunkredundancy", fmt.Sprintf("%v", sup.BaseChunkRedundancy))
+	if sup.DependencyDepth > 0 {
+		values.Set("dependencydepth", fmt.Sprintf("%v", sup.DependencyDepth))
+	}
 	return values
 }
 
@@ -1166,6 +1698,18 @@ func urlValuesFromSkyfileUploadParameters(sup skymodules.SkyfileUploadParameters
 	values.Set("mode", fmt.Sprintf("%o", sup.Mode))
 	values.Set("defaultpath", sup.DefaultPath)
 	values.Set("disabledefaultpath", strconv.FormatBool(sup.DisableDefaultPath))
+	if sup.Archive {
+		values.Set("archive", strconv.FormatBool(sup.Archive))
+	}
+	if sup.Profile != "" {
+		values.Set("profile", sup.Profile)
+	}
+	if len(sup.AllowedHosts) > 0 {
+		values.Set("allowedhosts", allowedHostsString(sup.AllowedHosts))
+	}
+	if sup.ContentHashes {
+		values.Set("contenthashes", strconv.FormatBool(sup.ContentHashes))
+	}
 
 	b, err := json.Marshal(sup.TryFiles)
 	if err != nil {
@@ -1177,6 +1721,22 @@ func urlValuesFromSkyfileUploadParameters(sup skymodules.SkyfileUploadParameters
 		return url.Values{}, err
 	}
 	values.Set("errorpages", string(b))
+	if len(sup.ExtraMetadata) > 0 {
+		values.Set("extrametadata", string(sup.ExtraMetadata))
+	}
+	if len(sup.Headers) > 0 {
+		b, err := json.Marshal(sup.Headers)
+		if err != nil {
+			return url.Values{}, err
+		}
+		values.Set("headers", string(b))
+	}
+	if sup.SessionID != "" {
+		values.Set("sessionid", sup.SessionID)
+	}
+	if sup.TTL > 0 {
+		values.Set("ttl", fmt.Sprint(uint64(sup.TTL.Seconds())))
+	}
 
 	// encode encryption parameters
 	if sup.SkykeyName != "" {
@@ -1215,3 +1775,154 @@ func (c *Client) SkynetSkylinkUnpinCustomPost(skylink string, siaPath skymodules
 	_, _, err := c.postRawResponse(query, nil)
 	return err
 }
+
+// SkynetSignPost uses the /skynet/sign endpoint to create a signed URL for a
+// skylink that is valid for the given duration. Signing a skylink restricts
+// it to signed URLs.
+func (c *Client) SkynetSignPost(skylink string, validity time.Duration) (ssp api.SkynetSignPOST, err error) {
+	values := url.Values{}
+	values.Set("validity", fmt.Sprint(uint64(validity.Seconds())))
+	query := fmt.Sprintf("/skynet/sign/%s?%s", skylink, values.Encode())
+	_, resp, err := c.postRawResponse(query, nil)
+	if err != nil {
+		return api.SkynetSignPOST{}, err
+	}
+	err = json.Unmarshal(resp, &ssp)
+	return
+}
+
+// SkynetUnrestrictPost uses the /skynet/sign endpoint to lift the restriction
+// of a skylink to signed URLs.
+func (c *Client) SkynetUnrestrictPost(skylink string) error {
+	query := fmt.Sprintf("/skynet/sign/%s?unrestrict=true", skylink)
+	_, _, err := c.postRawResponse(query, nil)
+	return err
+}
+
+// SkynetSignedURLGet downloads a skylink using a signed URL returned by
+// SkynetSignPost.
+func (c *Client) SkynetSignedURLGet(signedURL string) ([]byte, error) {
+	_, data, err := c.getRawResponse(signedURL)
+	return data, err
+}
+
+// SkynetDirUploadPost uses the /skynet/dirupload [POST] endpoint to create a
+// new directory upload session.
+func (c *Client) SkynetDirUploadPost(sdup api.SkynetDirUploadPOST) (session skymodules.SkynetDirUploadSession, err error) {
+	reqBytes, err := json.Marshal(sdup)
+	if err != nil {
+		return skymodules.SkynetDirUploadSession{}, err
+	}
+	err = c.post("/skynet/dirupload", string(reqBytes), &session)
+	return
+}
+
+// SkynetDirUploadGet uses the /skynet/dirupload/:id [GET] endpoint to fetch a
+// directory upload session.
+func (c *Client) SkynetDirUploadGet(id string) (session skymodules.SkynetDirUploadSession, err error) {
+	err = c.get("/skynet/dirupload/"+id, &session)
+	return
+}
+
+// SkynetDirUploadFilePost uses the /skynet/dirupload/:id/file [POST] endpoint
+// to upload a file to a directory upload session.
+func (c *Client) SkynetDirUploadFilePost(id string, file skymodules.SkynetDirUploadFile, r io.Reader) error {
+	values := url.Values{}
+	values.Set("filename", file.Filename)
+	if file.Mode != 0 {
+		values.Set("mode", fmt.Sprintf("%o", file.Mode))
+	}
+	if file.ContentType != "" {
+		values.Set("contenttype", file.ContentType)
+	}
+	query := fmt.Sprintf("/skynet/dirupload/%s/file?%s", id, values.Encode())
+	_, _, err := c.postRawResponse(query, r)
+	return err
+}
+
+// SkynetDirUploadFinalizePost uses the /skynet/dirupload/:id/finalize [POST]
+// endpoint to upload the files of a directory upload session as a skyfile.
+func (c *Client) SkynetDirUploadFinalizePost(id string) (string, api.SkynetSkyfileHandlerPOST, error) {
+	var rshp api.SkynetSkyfileHandlerPOST
+	err := c.post(fmt.Sprintf("/skynet/dirupload/%s/finalize", id), "", &rshp)
+	if err != nil {
+		return "", api.SkynetSkyfileHandlerPOST{}, err
+	}
+	return rshp.Skylink, rshp, nil
+}
+
+// SkynetDirUploadAbortPost uses the /skynet/dirupload/:id/abort [POST]
+// endpoint to abort a directory upload session.
+func (c *Client) SkynetDirUploadAbortPost(id string) error {
+	return c.post(fmt.Sprintf("/skynet/dirupload/%s/abort", id), "", nil)
+}
+
+// SkynetConvertDirPost uses the /skynet/convertdir [POST] endpoint to start
+// converting the siafiles of a directory to skyfiles.
+func (c *Client) SkynetConvertDirPost(scdp api.SkynetConvertDirPOST) (job skymodules.SkynetConvertDirJob, err error) {
+	reqBytes, err := json.Marshal(scdp)
+	if err != nil {
+		return skymodules.SkynetConvertDirJob{}, err
+	}
+	err = c.post("/skynet/convertdir", string(reqBytes), &job)
+	return
+}
+
+// SkynetConvertDirGet uses the /skynet/convertdir/:id [GET] endpoint to fetch
+// the progress of a directory conversion.
+func (c *Client) SkynetConvertDirGet(id string) (job skymodules.SkynetConvertDirJob, err error) {
+	err = c.get("/skynet/convertdir/"+id, &job)
+	return
+}
+
+// SkynetDeletePost uses the /skynet/delete/:skylink [POST] endpoint to delete
+// a skylink from the node.
+func (c *Client) SkynetDeletePost(skylink string) (job skymodules.SkynetDeleteJob, err error) {
+	err = c.post("/skynet/delete/"+skylink, "", &job)
+	return
+}
+
+// SkynetDeleteGet uses the /skynet/delete/:id [GET] endpoint to fetch the
+// progress of a skylink deletion.
+func (c *Client) SkynetDeleteGet(id string) (job skymodules.SkynetDeleteJob, err error) {
+	err = c.get("/skynet/delete/"+id, &job)
+	return
+}
+
+// SkynetRepairPriorityGet uses the /skynet/repairpriority [GET] endpoint to
+// fetch the skylinks whose chunks are repaired first.
+func (c *Client) SkynetRepairPriorityGet() (srpg api.SkynetRepairPriorityGET, err error) {
+	err = c.get("/skynet/repairpriority", &srpg)
+	return
+}
+
+// SkynetRepairPriorityPost uses the /skynet/repairpriority/:skylink [POST]
+// endpoint to override the repair priority of a skylink.
+func (c *Client) SkynetRepairPriorityPost(skylink string, priority float64) (srp skymodules.SkylinkRepairPriority, err error) {
+	values := url.Values{}
+	values.Set("priority", strconv.FormatFloat(priority, 'f', -1, 64))
+	err = c.post("/skynet/repairpriority/"+skylink, values.Encode(), &srp)
+	return
+}
+
+// SkynetRepairPriorityResetPost uses the /skynet/repairpriority/:skylink
+// [POST] endpoint to reset the repair priority of a skylink.
+func (c *Client) SkynetRepairPriorityResetPost(skylink string) (srp skymodules.SkylinkRepairPriority, err error) {
+	values := url.Values{}
+	values.Set("reset", "true")
+	err = c.post("/skynet/repairpriority/"+skylink, values.Encode(), &srp)
+	return
+}
+
+// SkynetFolderBackupPost uses the /skynet/folderbackup [POST] endpoint to
+// upload an encrypted backup of the skynet folder.
+func (c *Client) SkynetFolderBackupPost() (sfbp api.SkynetFolderBackupPOST, err error) {
+	err = c.post("/skynet/folderbackup", "", &sfbp)
+	return
+}
+
+// SkynetFolderRestorePost uses the /skynet/folderrestore/:skylink [POST]
+// endpoint to restore the skynet folder from a backup.
+func (c *Client) SkynetFolderRestorePost(skylink string) error {
+	return c.post("/skynet/folderrestore/"+skylink, "", nil)
+}
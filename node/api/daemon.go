@@ -1,19 +1,24 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"net/http"
 	"os"
 	"reflect"
 	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
 
 	"gitlab.com/SkynetLabs/skyd/build"
 	"gitlab.com/SkynetLabs/skyd/profile"
 	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"gitlab.com/SkynetLabs/skyd/skymodules/renter/contractor"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
@@ -91,6 +96,24 @@ type (
 		MaxDownloadSpeed int64         `json:"maxdownloadspeed"`
 		MaxUploadSpeed   int64         `json:"maxuploadspeed"`
 		Modules          configModules `json:"modules"`
+
+		// Renter contains the renter's settings if the renter is loaded.
+		Renter *skymodules.RenterSettings `json:"renter,omitempty"`
+	}
+
+	// DaemonSettingsPUT is the settings document accepted by the PUT
+	// /daemon/settings endpoint. It replaces the daemon's settings as a whole.
+	// If Renter is nil, the renter's settings remain unchanged.
+	DaemonSettingsPUT struct {
+		MaxDownloadSpeed int64                      `json:"maxdownloadspeed"`
+		MaxUploadSpeed   int64                      `json:"maxuploadspeed"`
+		Renter           *skymodules.RenterSettings `json:"renter,omitempty"`
+	}
+
+	// DaemonMaintenanceGET is the response returned by the
+	// /daemon/maintenance endpoint.
+	DaemonMaintenanceGET struct {
+		Jobs []skymodules.MaintenanceJobStatus `json:"jobs"`
 	}
 
 	// DaemonVersion holds the version information for siad
@@ -100,42 +123,166 @@ type (
 		BuildTime   string `json:"buildtime"`
 	}
 
-	// DaemonReady is the response returned by /daemon/ready endpoint.
+	// DaemonReady is the response returned by /daemon/ready endpoint. The
+	// daemon is ready if consensus, gateway and renter as well as all other
+	// loaded modules are ready.
 	DaemonReady struct {
 		Ready     bool `json:"ready"`
 		Consensus bool `json:"consensus"`
 		Gateway   bool `json:"gateway"`
 		Renter    bool `json:"renter"`
+
+		Modules DaemonReadyModules `json:"modules"`
+	}
+
+	// DaemonReadyModules contains the readiness of the individual modules.
+	DaemonReadyModules struct {
+		Consensus DaemonReadyConsensus `json:"consensus"`
+		Gateway   DaemonReadyGateway   `json:"gateway"`
+		HostDB    DaemonReadyHostDB    `json:"hostdb"`
+		Renter    DaemonReadyRenter    `json:"renter"`
+		Wallet    DaemonReadyWallet    `json:"wallet"`
+	}
+
+	// DaemonReadyConsensus is the readiness of the consensus set. It's ready
+	// once it's synced.
+	DaemonReadyConsensus struct {
+		Loaded bool              `json:"loaded"`
+		Ready  bool              `json:"ready"`
+		Synced bool              `json:"synced"`
+		Height types.BlockHeight `json:"height"`
+	}
+
+	// DaemonReadyGateway is the readiness of the gateway. It's ready once it's
+	// online.
+	DaemonReadyGateway struct {
+		Loaded bool `json:"loaded"`
+		Ready  bool `json:"ready"`
+		Online bool `json:"online"`
+		Peers  int  `json:"peers"`
+	}
+
+	// DaemonReadyHostDB is the readiness of the renter's hostdb. It's ready
+	// once the initial scan of the hosts is complete.
+	DaemonReadyHostDB struct {
+		Loaded              bool `json:"loaded"`
+		Ready               bool `json:"ready"`
+		InitialScanComplete bool `json:"initialscancomplete"`
+	}
+
+	// DaemonReadyRenter is the readiness of the renter. It's ready once the
+	// hostdb is ready, an allowance is set and both the number of active
+	// contracts and the size of the worker pool reach MinContracts.
+	DaemonReadyRenter struct {
+		Loaded          bool   `json:"loaded"`
+		Ready           bool   `json:"ready"`
+		AllowanceHosts  uint64 `json:"allowancehosts"`
+		ActiveContracts int    `json:"activecontracts"`
+		WorkerPoolSize  int    `json:"workerpoolsize"`
+
+		// MinContracts is the number of contracts required for uploading
+		// and downloading. It is the greater of the default number of data
+		// pieces and 2/3 of the allowance's hosts.
+		MinContracts int `json:"mincontracts"`
+	}
+
+	// DaemonReadyWallet is the readiness of the wallet. It's ready once it's
+	// unlocked.
+	DaemonReadyWallet struct {
+		Loaded   bool `json:"loaded"`
+		Ready    bool `json:"ready"`
+		Unlocked bool `json:"unlocked"`
 	}
 )
 
-// daemonReadyGET is the handler for the /daemon/ready endpoint.
-func (api *API) daemonReadyGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+// daemonReadyGET is the handler for the /daemon/ready endpoint. If the
+// 'strict' parameter is set, the response has status code 503 while the daemon
+// isn't ready.
+func (api *API) daemonReadyGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var strict bool
+	if strictStr := req.FormValue("strict"); strictStr != "" {
+		var err error
+		strict, err = strconv.ParseBool(strictStr)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'strict' arg: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
 	resp := DaemonReady{}
+	dm := &resp.Modules
 
 	// Check consensus set.
 	if api.cs != nil {
-		resp.Consensus = api.cs.Synced()
+		dm.Consensus.Loaded = true
+		dm.Consensus.Synced = api.cs.Synced()
+		dm.Consensus.Height = api.cs.Height()
+		dm.Consensus.Ready = dm.Consensus.Synced
 	}
 	if api.gateway != nil {
-		resp.Gateway = api.gateway.Online()
+		dm.Gateway.Loaded = true
+		dm.Gateway.Online = api.gateway.Online()
+		dm.Gateway.Peers = len(api.gateway.Peers())
+		dm.Gateway.Ready = dm.Gateway.Online
 	}
 	if api.renter != nil {
+		isc, err := api.renter.InitialScanComplete()
+		if err != nil {
+			WriteError(w, Error{"failed to get the initial scan status of the hostdb"}, http.StatusInternalServerError)
+			return
+		}
+		dm.HostDB.Loaded = true
+		dm.HostDB.InitialScanComplete = isc
+		dm.HostDB.Ready = isc
+
 		settings, err := api.renter.Settings()
 		if err != nil {
 			WriteError(w, Error{"failed to retrieve renter settings"}, http.StatusInternalServerError)
 			return
 		}
+		wps, err := api.renter.WorkerPoolStatus()
+		if err != nil {
+			WriteError(w, Error{"failed to retrieve worker pool status"}, http.StatusInternalServerError)
+			return
+		}
+		dm.Renter.Loaded = true
+		dm.Renter.WorkerPoolSize = wps.NumWorkers
 		a := settings.Allowance
+		dm.Renter.AllowanceHosts = a.Hosts
 		if !reflect.DeepEqual(a, modules.Allowance{}) && a.Hosts > 0 {
 			contracts := api.parseRenterContracts(false, false, false)
-
-			// Ready if 2/3 of the contracts in our allowance are gfu.
-			expectedHosts := float64(a.Hosts) * 2.0 / 3.0
-			resp.Renter = float64(len(contracts.ActiveContracts)) >= expectedHosts
+			dm.Renter.ActiveContracts = len(contracts.ActiveContracts)
+
+			// Ready if 2/3 of the contracts in our allowance are gfu and
+			// there are enough contracts and workers to recover a chunk.
+			minContracts := int((2*a.Hosts + 2) / 3)
+			if minContracts < skymodules.RenterDefaultDataPieces {
+				minContracts = skymodules.RenterDefaultDataPieces
+			}
+			dm.Renter.MinContracts = minContracts
+			dm.Renter.Ready = dm.HostDB.Ready &&
+				dm.Renter.ActiveContracts >= minContracts &&
+				dm.Renter.WorkerPoolSize >= minContracts
 		}
 	}
-	resp.Ready = resp.Consensus && resp.Gateway && resp.Renter
+	if api.wallet != nil {
+		unlocked, err := api.wallet.Unlocked()
+		if err != nil {
+			WriteError(w, Error{"failed to get the wallet status"}, http.StatusInternalServerError)
+			return
+		}
+		dm.Wallet.Loaded = true
+		dm.Wallet.Unlocked = unlocked
+		dm.Wallet.Ready = unlocked
+	}
+	resp.Consensus = dm.Consensus.Ready
+	resp.Gateway = dm.Gateway.Ready
+	resp.Renter = dm.Renter.Ready
+	resp.Ready = resp.Consensus && resp.Gateway && resp.Renter &&
+		(!dm.Wallet.Loaded || dm.Wallet.Ready)
+	if strict && !resp.Ready {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
 	WriteJSON(w, resp)
 }
 
@@ -308,11 +455,137 @@ func (api *API) daemonStopHandler(w http.ResponseWriter, _ *http.Request, _ http
 // settings.
 func (api *API) daemonSettingsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	gmds, gmus, _ := skymodules.GlobalRateLimits.Limits()
-	WriteJSON(w, DaemonSettingsGet{
+	dsg := DaemonSettingsGet{
 		MaxDownloadSpeed: gmds,
 		MaxUploadSpeed:   gmus,
 		Modules:          api.staticConfigModules,
-	})
+	}
+	if api.renter != nil {
+		settings, err := api.renter.Settings()
+		if err != nil {
+			WriteError(w, Error{"unable to get renter settings: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		dsg.Renter = &settings
+	}
+	WriteJSON(w, dsg)
+}
+
+// daemonSettingsHandlerPUT handles the API call replacing the daemon's
+// settings with a full settings document. The document is validated as a
+// whole before any of it is applied. If applying it fails, the previous
+// settings are restored. All of the settings take effect immediately without
+// restarting the daemon.
+func (api *API) daemonSettingsHandlerPUT(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var settings DaemonSettingsPUT
+	if err := json.NewDecoder(req.Body).Decode(&settings); err != nil {
+		WriteError(w, Error{"unable to decode settings document: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if settings.Renter != nil && api.renter == nil {
+		WriteError(w, Error{"unable to set renter settings: renter module is not loaded"}, http.StatusBadRequest)
+		return
+	}
+	if err := validateDaemonSettings(settings); err != nil {
+		WriteError(w, Error{"invalid settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Remember the current settings to restore them on failure.
+	oldDownloadSpeed, oldUploadSpeed, _ := skymodules.GlobalRateLimits.Limits()
+	var oldRenterSettings skymodules.RenterSettings
+	if settings.Renter != nil {
+		var err error
+		oldRenterSettings, err = api.renter.Settings()
+		if err != nil {
+			WriteError(w, Error{"unable to get renter settings: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// Apply the settings.
+	err := api.siadConfig.SetRatelimit(settings.MaxDownloadSpeed, settings.MaxUploadSpeed)
+	if err == nil && settings.Renter != nil {
+		err = errors.AddContext(api.renter.SetSettings(*settings.Renter), "unable to set renter settings")
+	}
+	if err != nil {
+		// Restore the previous settings.
+		rollbackErr := api.siadConfig.SetRatelimit(oldDownloadSpeed, oldUploadSpeed)
+		if settings.Renter != nil {
+			rollbackErr = errors.Compose(rollbackErr, api.renter.SetSettings(oldRenterSettings))
+		}
+		if rollbackErr != nil {
+			err = errors.Compose(err, errors.AddContext(rollbackErr, "failed to restore previous settings"))
+		}
+		WriteError(w, Error{"unable to apply settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// validateDaemonSettings validates a settings document including the
+// constraints between its fields.
+func validateDaemonSettings(settings DaemonSettingsPUT) error {
+	if settings.MaxDownloadSpeed < 0 || settings.MaxUploadSpeed < 0 {
+		return errors.New("download/upload rate can't be below 0")
+	}
+	rs := settings.Renter
+	if rs == nil {
+		return nil
+	}
+	if rs.MaxDownloadSpeed < 0 || rs.MaxUploadSpeed < 0 {
+		return errors.New("renter bandwidth limits cannot be negative")
+	}
+	// A renter limit above the global limit would never be reached.
+	if settings.MaxDownloadSpeed > 0 && rs.MaxDownloadSpeed > settings.MaxDownloadSpeed {
+		return fmt.Errorf("renter maxdownloadspeed %v exceeds the global maxdownloadspeed %v", rs.MaxDownloadSpeed, settings.MaxDownloadSpeed)
+	}
+	if settings.MaxUploadSpeed > 0 && rs.MaxUploadSpeed > settings.MaxUploadSpeed {
+		return fmt.Errorf("renter maxuploadspeed %v exceeds the global maxuploadspeed %v", rs.MaxUploadSpeed, settings.MaxUploadSpeed)
+	}
+	if err := rs.Overdrive.Validate(); err != nil {
+		return errors.AddContext(err, "invalid overdrive settings")
+	}
+	if err := rs.TrafficShares.Validate(); err != nil {
+		return errors.AddContext(err, "invalid traffic shares")
+	}
+	return validateAllowance(rs.Allowance)
+}
+
+// validateAllowance validates a complete allowance. Unlike the /renter
+// endpoint, no defaults are filled in. The empty allowance is valid and
+// cancels the current allowance.
+func validateAllowance(a skymodules.Allowance) error {
+	if reflect.DeepEqual(a, skymodules.Allowance{}) {
+		return nil
+	}
+	switch {
+	case a.Funds.IsZero():
+		return ErrFundsNeedToBeSet
+	case a.Period == 0:
+		return ErrPeriodNeedToBeSet
+	case a.Hosts == 0:
+		return contractor.ErrAllowanceNoHosts
+	case a.Hosts < requiredHosts:
+		return fmt.Errorf("insufficient number of hosts, need at least %v but have %v", requiredHosts, a.Hosts)
+	case a.RenewWindow == 0:
+		return contractor.ErrAllowanceZeroWindow
+	case a.RenewWindow < requiredRenewWindow:
+		return fmt.Errorf("renew window is too small, must be at least %v blocks but have %v blocks", requiredRenewWindow, a.RenewWindow)
+	case a.ExpectedStorage == 0:
+		return contractor.ErrAllowanceZeroExpectedStorage
+	case a.ExpectedUpload == 0:
+		return contractor.ErrAllowanceZeroExpectedUpload
+	case a.ExpectedDownload == 0:
+		return contractor.ErrAllowanceZeroExpectedDownload
+	case a.ExpectedRedundancy == 0:
+		return contractor.ErrAllowanceZeroExpectedRedundancy
+	case a.MaxPeriodChurn == 0:
+		return contractor.ErrAllowanceZeroMaxPeriodChurn
+	case a.PaymentContractInitialFunding.Cmp(a.Funds) > 0:
+		return errors.New("paymentcontractinitialfunding can't exceed the allowance's funds")
+	}
+	return nil
 }
 
 // daemonSettingsHandlerPOST handles the API call changing daemon specific
@@ -344,3 +617,96 @@ func (api *API) daemonSettingsHandlerPOST(w http.ResponseWriter, req *http.Reque
 	}
 	WriteSuccess(w)
 }
+
+// daemonMaintenanceHandlerGET handles the API call that returns the status of
+// the maintenance jobs.
+func (api *API) daemonMaintenanceHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	jobs := []skymodules.MaintenanceJobStatus{}
+	if api.renter != nil {
+		renterJobs, err := api.renter.MaintenanceJobs()
+		if err != nil {
+			WriteError(w, Error{"unable to get maintenance jobs: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		jobs = append(jobs, renterJobs...)
+	}
+	WriteJSON(w, DaemonMaintenanceGET{
+		Jobs: jobs,
+	})
+}
+
+// daemonMaintenanceHandlerPOST handles the API call that pauses, resumes or
+// reschedules a maintenance job.
+func (api *API) daemonMaintenanceHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	if api.renter == nil {
+		WriteError(w, Error{"unable to update maintenance job: renter module is not loaded"}, http.StatusBadRequest)
+		return
+	}
+	name := ps.ByName("name")
+	jobs, err := api.renter.MaintenanceJobs()
+	if err != nil {
+		WriteError(w, Error{"unable to get maintenance jobs: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	var job *skymodules.MaintenanceJobStatus
+	for i := range jobs {
+		if jobs[i].Name == name {
+			job = &jobs[i]
+			break
+		}
+	}
+	if job == nil {
+		WriteError(w, Error{skymodules.ErrMaintenanceJobNotFound.Error() + ": " + name}, http.StatusNotFound)
+		return
+	}
+
+	// Parse the parameters. (optional parameters)
+	interval, jitter := job.Interval, job.Jitter
+	if i := req.FormValue("interval"); i != "" {
+		seconds, err := strconv.ParseUint(i, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse interval: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		interval = time.Duration(seconds) * time.Second
+	}
+	if j := req.FormValue("jitter"); j != "" {
+		jitter, err = strconv.ParseFloat(j, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse jitter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if err := skymodules.ValidateMaintenanceInterval(interval, jitter); err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var paused *bool
+	if p := req.FormValue("paused"); p != "" {
+		pause, err := strconv.ParseBool(p)
+		if err != nil {
+			WriteError(w, Error{"unable to parse paused: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		paused = &pause
+	}
+
+	// Apply the changes.
+	if interval != job.Interval || jitter != job.Jitter {
+		err = api.renter.SetMaintenanceJobInterval(name, interval, jitter)
+		if err != nil {
+			WriteError(w, Error{"unable to set maintenance job interval: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+	}
+	if paused != nil && *paused {
+		err = api.renter.PauseMaintenanceJob(name)
+	} else if paused != nil {
+		err = api.renter.ResumeMaintenanceJob(name)
+	}
+	if err != nil {
+		WriteError(w, Error{"unable to update maintenance job: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteSuccess(w)
+}
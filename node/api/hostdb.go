@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
 
 	"gitlab.com/SkynetLabs/skyd/skymodules"
 	"go.sia.tech/siad/types"
@@ -34,6 +35,7 @@ type (
 	// by pubkey.
 	HostdbHostsGET struct {
 		Entry          ExtendedHostDBEntry           `json:"entry"`
+		GougingReport  skymodules.GougingReport      `json:"gougingreport"`
 		ScoreBreakdown skymodules.HostScoreBreakdown `json:"scorebreakdown"`
 	}
 
@@ -55,6 +57,12 @@ type (
 		FilterMode string               `json:"filtermode"`
 		Hosts      []types.SiaPublicKey `json:"hosts"`
 	}
+
+	// HostdbAllowlistGET contains the status of the renter's host allow-list
+	// feed.
+	HostdbAllowlistGET struct {
+		skymodules.HostAllowlistStatus
+	}
 )
 
 // hostdbHandler handles the API call asking for the list of active
@@ -158,8 +166,17 @@ func (api *API) hostdbHostsHandler(w http.ResponseWriter, _ *http.Request, ps ht
 		HostDBEntry:     entry,
 		PublicKeyString: entry.PublicKey.String(),
 	}
+	// Check the host's prices against the allowance.
+	settings, err := api.renter.Settings()
+	if err != nil {
+		WriteError(w, Error{"unable to get renter settings: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	report := skymodules.NewGougingChecker(settings.Allowance).CheckHostSettings(entry.HostExternalSettings)
+
 	WriteJSON(w, HostdbHostsGET{
 		Entry:          extendedEntry,
+		GougingReport:  report,
 		ScoreBreakdown: breakdown,
 	})
 }
@@ -208,3 +225,41 @@ func (api *API) hostdbFilterModeHandlerPOST(w http.ResponseWriter, req *http.Req
 	}
 	WriteSuccess(w)
 }
+
+// hostdbAllowlistHandlerGET handles the API call to get the status of the host
+// allow-list feed.
+func (api *API) hostdbAllowlistHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	status, err := api.renter.HostAllowlist()
+	if err != nil {
+		WriteError(w, Error{"unable to get host allow-list: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, HostdbAllowlistGET{status})
+}
+
+// hostdbAllowlistHandlerPOST handles the API call to refresh the host
+// allow-list, optionally from a new source.
+func (api *API) hostdbAllowlistHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	source := req.FormValue("source")
+	var pk types.SiaPublicKey
+	if source != "" {
+		if err := pk.LoadString(req.FormValue("publickey")); err != nil {
+			WriteError(w, Error{"unable to parse publickey: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	err := api.renter.RefreshHostAllowlist(source, pk)
+	if errors.Contains(err, skymodules.ErrHostAllowlistNotConfigured) ||
+		errors.Contains(err, skymodules.ErrHostAllowlistInvalidSignature) ||
+		errors.Contains(err, skymodules.ErrHostAllowlistNoHosts) ||
+		errors.Contains(err, skymodules.ErrHostAllowlistOutdated) {
+		WriteError(w, Error{"failed to refresh host allow-list: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"failed to refresh host allow-list: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteSuccess(w)
+}
@@ -65,6 +65,11 @@ func (ls *limitStreamer) Read(p []byte) (n int, err error) {
 	return
 }
 
+// Bandwidth implements the skymodules.SkyfileStreamer interface.
+func (ls *limitStreamer) Bandwidth() (up, down uint64) {
+	return ls.stream.Bandwidth()
+}
+
 // Layout implements the skymodules.SkyfileStreamer interface.
 func (ls *limitStreamer) Layout() skymodules.SkyfileLayout {
 	return ls.staticLayout
@@ -75,6 +80,11 @@ func (ls *limitStreamer) Metadata() skymodules.SkyfileMetadata {
 	return ls.staticMD
 }
 
+// Provenance implements the skymodules.SkyfileStreamer interface.
+func (ls *limitStreamer) Provenance() []skymodules.ChunkProvenance {
+	return ls.stream.Provenance()
+}
+
 // RawMetadata implements the skymodules.SkyfileStreamer interface.
 func (ls *limitStreamer) RawMetadata() []byte {
 	return ls.staticRawMD
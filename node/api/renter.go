@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -22,6 +23,7 @@ import (
 	"gitlab.com/SkynetLabs/skyd/skymodules"
 	"gitlab.com/SkynetLabs/skyd/skymodules/renter"
 	"gitlab.com/SkynetLabs/skyd/skymodules/renter/contractor"
+	"gitlab.com/SkynetLabs/skyd/skymodules/renter/filesystem"
 	"gitlab.com/SkynetLabs/skyd/skymodules/renter/filesystem/siafile"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
@@ -120,6 +122,10 @@ type (
 		// Size of the file contract, which is typically equal to the number of
 		// bytes that have been uploaded to the host.
 		Size uint64 `json:"size"`
+		// Breakdown of the contract's spending by category. Download, upload
+		// and registry spending include the money spent from the ephemeral
+		// account on the host.
+		SpendingBreakdown skymodules.ContractSpendingBreakdown `json:"spendingbreakdown"`
 		// Block height that the file contract began on.
 		StartHeight types.BlockHeight `json:"startheight"`
 		// Amount of contract funds that have been spent on storage.
@@ -141,6 +147,17 @@ type (
 		GoodForRenew bool `json:"goodforrenew"`
 		// Signals if a contract has been marked as bad
 		BadContract bool `json:"badcontract"`
+		// Profile is the name of the allowance profile the host of the
+		// contract belongs to. Empty for the regular hosts.
+		Profile string `json:"profile"`
+	}
+
+	// RenterContractFeeBumpsGET contains the pending fee bumps of the renter's
+	// unconfirmed contract transactions.
+	RenterContractFeeBumpsGET struct {
+		Budget   types.Currency               `json:"budget"`
+		Pending  types.Currency               `json:"pending"`
+		FeeBumps []skymodules.ContractFeeBump `json:"feebumps"`
 	}
 
 	// RenterContracts contains the renter's contracts.
@@ -171,6 +188,12 @@ type (
 		Downloads []DownloadInfo `json:"downloads"`
 	}
 
+	// RenterPendingDownloadsGET contains the chunks of classic downloads
+	// which are waiting in the download heap.
+	RenterPendingDownloadsGET struct {
+		Chunks []skymodules.PendingDownloadChunk `json:"chunks"`
+	}
+
 	// RenterFile lists the file queried.
 	RenterFile struct {
 		File skymodules.FileInfo `json:"file"`
@@ -197,12 +220,45 @@ type (
 		skymodules.RenterPriceEstimation
 		skymodules.Allowance
 	}
+	// RenterAutoFundGET contains the auto-funding settings and the audit log
+	// of automatic allowance top-ups.
+	RenterAutoFundGET struct {
+		skymodules.AutoFundStatus
+	}
+
+	// RenterSpendingForecastGET contains the projected spending of the
+	// renter for the remainder of the current period.
+	RenterSpendingForecastGET struct {
+		skymodules.SpendingForecast
+	}
 	// RenterRecoveryStatusGET returns information about potential contract
 	// recovery scans.
 	RenterRecoveryStatusGET struct {
 		ScanInProgress bool              `json:"scaninprogress"`
 		ScannedHeight  types.BlockHeight `json:"scannedheight"`
+
+		Paused         bool                                `json:"paused"`
+		StartHeight    types.BlockHeight                   `json:"startheight"`
+		EndHeight      types.BlockHeight                   `json:"endheight"`
+		BlocksScanned  uint64                              `json:"blocksscanned"`
+		ContractsFound uint64                              `json:"contractsfound"`
+		Contracts      []skymodules.ContractRecoveryStatus `json:"contracts"`
+	}
+	// RenterWorkersBlocklistGET contains the worker blocklist and the hosts
+	// which are currently blocked by it.
+	RenterWorkersBlocklistGET struct {
+		Entries          []skymodules.WorkerBlocklistEntry `json:"entries"`
+		BlocklistedHosts []types.SiaPublicKey              `json:"blocklistedhosts"`
 	}
+
+	// RenterWorkersBlocklistPOST contains the information needed for the
+	// /renter/workers/blocklist POST endpoint to be called. Removals are
+	// either subnets or ASNs of the form 'AS<number>'.
+	RenterWorkersBlocklistPOST struct {
+		Add    []skymodules.WorkerBlocklistEntry `json:"add"`
+		Remove []string                          `json:"remove"`
+	}
+
 	// RenterShareASCII contains an ASCII-encoded .sia file.
 	RenterShareASCII struct {
 		ASCIIsia string `json:"asciisia"`
@@ -614,6 +670,23 @@ func parseErasureCodingParameters(strDataPieces, strParityPieces string) (skymod
 	return skymodules.NewRSSubCode(dataPieces, parityPieces, crypto.SegmentSize)
 }
 
+// parseAllowedHosts parses a comma separated list of host public keys that an
+// upload is restricted to.
+func parseAllowedHosts(str string) ([]types.SiaPublicKey, error) {
+	if str == "" {
+		return nil, nil
+	}
+	var hosts []types.SiaPublicKey
+	for _, hostStr := range strings.Split(str, ",") {
+		var spk types.SiaPublicKey
+		if err := spk.LoadString(strings.TrimSpace(hostStr)); err != nil {
+			return nil, fmt.Errorf("unable to parse host key '%v': %v", hostStr, err)
+		}
+		hosts = append(hosts, spk)
+	}
+	return hosts, nil
+}
+
 // ParseDataAndParityPieces parse the numeric values for dataPieces and
 // parityPieces from the input strings
 func ParseDataAndParityPieces(strDataPieces, strParityPieces string) (dataPieces, parityPieces int, err error) {
@@ -832,6 +905,59 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 		}
 		settings.Allowance.MaxUploadBandwidthPrice = price
 	}
+	if str := req.FormValue("maxfeebumpbudget"); str != "" {
+		budget, ok := scanAmount(str)
+		if !ok {
+			WriteError(w, Error{"unable to parse maxfeebumpbudget"}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.MaxFeeBumpBudget = budget
+	}
+	if str := req.FormValue("profiles"); str != "" {
+		var profiles map[string]skymodules.AllowanceProfile
+		if err := json.Unmarshal([]byte(str), &profiles); err != nil {
+			WriteError(w, Error{"unable to parse profiles: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if _, exists := profiles[""]; exists {
+			WriteError(w, Error{"unable to parse profiles: profile name can't be empty"}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.Profiles = profiles
+	}
+	// The archive fields are a shorthand for the archive profile.
+	archiveProfile := settings.Allowance.ArchiveProfile()
+	setArchiveProfile := false
+	if str := req.FormValue("archivehosts"); str != "" {
+		var hosts uint64
+		if _, err := fmt.Sscan(str, &hosts); err != nil {
+			WriteError(w, Error{"unable to parse archivehosts: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		archiveProfile.Hosts = hosts
+		setArchiveProfile = true
+	}
+	if str := req.FormValue("archiveexpectedstorage"); str != "" {
+		var expectedStorage uint64
+		if _, err := fmt.Sscan(str, &expectedStorage); err != nil {
+			WriteError(w, Error{"unable to parse archiveexpectedstorage: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		archiveProfile.ExpectedStorage = expectedStorage
+		setArchiveProfile = true
+	}
+	if str := req.FormValue("archivemaxstorageprice"); str != "" {
+		price, ok := scanAmount(str)
+		if !ok {
+			WriteError(w, Error{"unable to parse archivemaxstorageprice"}, http.StatusBadRequest)
+			return
+		}
+		archiveProfile.MaxStoragePrice = price
+		setArchiveProfile = true
+	}
+	if setArchiveProfile {
+		settings.Allowance.SetArchiveProfile(archiveProfile)
+	}
 
 	// Validate any allowance changes. Funds and Period are the only required
 	// fields.
@@ -948,6 +1074,124 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 		settings.MaxUploadSpeed = uploadSpeed
 	}
 
+	// Scan the soft memory limit. (optional parameter)
+	if ml := req.FormValue("memorylimit"); ml != "" {
+		var memoryLimit uint64
+		if _, err := fmt.Sscan(ml, &memoryLimit); err != nil {
+			WriteError(w, Error{"unable to parse memorylimit: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.MemoryLimit = memoryLimit
+	}
+
+	// Scan the default overdrive strategy, latency target and max cost.
+	// (optional parameters)
+	if o := req.FormValue("overdrive"); o != "" {
+		strategy, err := skymodules.ParseOverdriveStrategy(o)
+		if err != nil {
+			WriteError(w, Error{"unable to parse overdrive: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Overdrive.Strategy = strategy
+	}
+	if ot := req.FormValue("overdrivetarget"); ot != "" {
+		var targetMS uint64
+		if _, err := fmt.Sscan(ot, &targetMS); err != nil {
+			WriteError(w, Error{"unable to parse overdrivetarget: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Overdrive.LatencyTarget = time.Duration(targetMS) * time.Millisecond
+	}
+	if omc := req.FormValue("overdrivemaxcost"); omc != "" {
+		maxCost, err := scanCurrency(omc)
+		if err != nil {
+			WriteError(w, Error{"unable to parse overdrivemaxcost: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Overdrive.MaxCost = maxCost
+	}
+
+	// Scan the base sector hedging settings. (optional parameters)
+	if hd := req.FormValue("basesectorhedgingdisabled"); hd != "" {
+		disabled, err := strconv.ParseBool(hd)
+		if err != nil {
+			WriteError(w, Error{"unable to parse basesectorhedgingdisabled: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.BaseSectorHedging.Disabled = disabled
+	}
+	if hp := req.FormValue("basesectorhedgingpercentile"); hp != "" {
+		percentile, err := strconv.ParseFloat(hp, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse basesectorhedgingpercentile: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.BaseSectorHedging.Percentile = percentile
+	}
+	if err := settings.BaseSectorHedging.Validate(); err != nil {
+		WriteError(w, Error{"invalid base sector hedging settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Scan the bandwidth shares of the traffic classes. (optional parameters)
+	shares := map[string]*uint64{
+		"interactiveshare": &settings.TrafficShares.Interactive,
+		"streamingshare":   &settings.TrafficShares.Streaming,
+		"repairshare":      &settings.TrafficShares.Repair,
+		"backupshare":      &settings.TrafficShares.Backup,
+	}
+	for param, share := range shares {
+		s := req.FormValue(param)
+		if s == "" {
+			continue
+		}
+		if _, err := fmt.Sscan(s, share); err != nil {
+			WriteError(w, Error{"unable to parse " + param + ": " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if err := settings.TrafficShares.Validate(); err != nil {
+		WriteError(w, Error{"invalid traffic shares: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Scan the skyfile upload limits. (optional parameters)
+	uploadLimits := map[string]*uint64{
+		"maxskyfileuploadsize":   &settings.SkyfileUploadLimits.MaxUploadSize,
+		"maxskyfilesubfiles":     &settings.SkyfileUploadLimits.MaxSubfiles,
+		"maxskyfilemetadatasize": &settings.SkyfileUploadLimits.MaxMetadataSize,
+	}
+	for param, limit := range uploadLimits {
+		l := req.FormValue(param)
+		if l == "" {
+			continue
+		}
+		if _, err := fmt.Sscan(l, limit); err != nil {
+			WriteError(w, Error{"unable to parse " + param + ": " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Scan the fanout redundancy policy. (optional parameter)
+	if p := req.FormValue("fanoutredundancypolicy"); p != "" {
+		policy, err := skymodules.ParseFanoutRedundancyPolicy(p)
+		if err != nil {
+			WriteError(w, Error{"unable to parse fanoutredundancypolicy: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.FanoutRedundancyPolicy = policy
+	}
+
+	// Scan the price table update fraction. (optional parameter)
+	if f := req.FormValue("pricetableupdatefraction"); f != "" {
+		var fraction float64
+		if _, err := fmt.Sscan(f, &fraction); err != nil {
+			WriteError(w, Error{"unable to parse pricetableupdatefraction: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.PriceTableUpdateFraction = fraction
+	}
+
 	// Scan the checkforipviolation flag.
 	if ipc := req.FormValue("checkforipviolation"); ipc != "" {
 		var ipviolationcheck bool
@@ -989,6 +1233,67 @@ func (api *API) renterAllowanceCancelHandlerPOST(w http.ResponseWriter, _ *http.
 	WriteSuccess(w)
 }
 
+// renterAutoFundHandlerGET handles the API call to /renter/autofund.
+func (api *API) renterAutoFundHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	status, err := api.renter.AutoFundStatus()
+	if err != nil {
+		WriteError(w, Error{"unable to get auto-fund status: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterAutoFundGET{
+		AutoFundStatus: status,
+	})
+}
+
+// renterAutoFundHandlerPOST handles the API call to update the auto-funding
+// settings. Every setting is optional and settings which are not provided
+// remain unchanged.
+func (api *API) renterAutoFundHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	status, err := api.renter.AutoFundStatus()
+	if err != nil {
+		WriteError(w, Error{"unable to get auto-fund status: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	settings := status.Settings
+
+	// Scan the enabled flag. (optional parameter)
+	if e := req.FormValue("enabled"); e != "" {
+		enabled, err := strconv.ParseBool(e)
+		if err != nil {
+			WriteError(w, Error{"unable to parse enabled: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Enabled = enabled
+	}
+	// Scan the currency values. (optional parameters)
+	for _, param := range []struct {
+		name  string
+		value *types.Currency
+	}{
+		{"threshold", &settings.Threshold},
+		{"amount", &settings.Amount},
+		{"maxperperiod", &settings.MaxPerPeriod},
+	} {
+		str := req.FormValue(param.name)
+		if str == "" {
+			continue
+		}
+		amount, ok := scanAmount(str)
+		if !ok {
+			WriteError(w, Error{"unable to parse " + param.name}, http.StatusBadRequest)
+			return
+		}
+		*param.value = amount
+	}
+
+	err = api.renter.SetAutoFundSettings(settings)
+	if err != nil {
+		WriteError(w, Error{"unable to set auto-fund settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // renterCleanHandlerPOST handles the API call to clean lost files from a Renter.
 func (api *API) renterCleanHandlerPOST(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	var deleteErrs error
@@ -1026,6 +1331,22 @@ func (api *API) renterContractCancelHandler(w http.ResponseWriter, req *http.Req
 	WriteSuccess(w)
 }
 
+// renterContractDefragHandler handles the API call to delete the sectors which
+// are no longer referenced from a specific Renter contract.
+func (api *API) renterContractDefragHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var fcid types.FileContractID
+	if err := fcid.LoadString(req.FormValue("id")); err != nil {
+		WriteError(w, Error{"unable to parse id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	report, err := api.renter.DefragContract(fcid)
+	if err != nil {
+		WriteError(w, Error{"unable to defrag contract: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, report)
+}
+
 // renterContractsHandler handles the API call to request the Renter's
 // contracts. Active and renewed contracts are returned by default
 //
@@ -1108,6 +1429,7 @@ func (api *API) renterContractsHandler(w http.ResponseWriter, req *http.Request,
 func (api *API) parseRenterContracts(disabled, inactive, expired bool) RenterContracts {
 	var rc RenterContracts
 	currentBlockHeight := api.cs.Height()
+	hostProfiles := api.renter.HostProfiles()
 	for _, c := range api.renter.Contracts() {
 		// Fetch host address
 		var netAddress modules.NetAddress
@@ -1131,8 +1453,10 @@ func (api *API) parseRenterContracts(disabled, inactive, expired bool) RenterCon
 			LastTransaction:           c.Transaction,
 			NetAddress:                netAddress,
 			MaintenanceSpending:       c.MaintenanceSpending,
+			Profile:                   hostProfiles[c.HostPublicKey.String()],
 			RenterFunds:               c.RenterFunds,
 			Size:                      c.Size(),
+			SpendingBreakdown:         c.SpendingBreakdown(),
 			StartHeight:               c.StartHeight,
 			StorageSpending:           c.StorageSpending,
 			StorageSpendingDeprecated: c.StorageSpending,
@@ -1197,8 +1521,10 @@ func (api *API) parseRenterContracts(disabled, inactive, expired bool) RenterCon
 			LastTransaction:           c.Transaction,
 			MaintenanceSpending:       c.MaintenanceSpending,
 			NetAddress:                netAddress,
+			Profile:                   hostProfiles[c.HostPublicKey.String()],
 			RenterFunds:               c.RenterFunds,
 			Size:                      size,
+			SpendingBreakdown:         c.SpendingBreakdown(),
 			StartHeight:               c.StartHeight,
 			StorageSpending:           c.StorageSpending,
 			StorageSpendingDeprecated: c.StorageSpending,
@@ -1270,6 +1596,35 @@ func (api *API) renterContractorChurnStatus(w http.ResponseWriter, _ *http.Reque
 	WriteJSON(w, api.renter.ContractorChurnStatus())
 }
 
+// renterPendingDownloadsHandlerGET handles the API call to request the chunks
+// of classic downloads which are waiting in the download heap.
+func (api *API) renterPendingDownloadsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	chunks, err := api.renter.PendingDownloadChunks()
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, RenterPendingDownloadsGET{
+		Chunks: chunks,
+	})
+}
+
+// renterPendingDownloadsCancelHandlerPOST handles the API call to cancel a
+// pending classic download.
+func (api *API) renterPendingDownloadsCancelHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	id := skymodules.DownloadID(req.FormValue("id"))
+	if id == "" {
+		WriteError(w, Error{"id not specified"}, http.StatusBadRequest)
+		return
+	}
+	err := api.renter.CancelPendingDownload(id)
+	if err != nil {
+		WriteError(w, Error{"unable to cancel download: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // renterDownloadsHandler handles the API call to request the download queue.
 func (api *API) renterDownloadsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	var downloads []DownloadInfo
@@ -1419,8 +1774,55 @@ func (api *API) renterFuseUnmountHandlerPOST(w http.ResponseWriter, req *http.Re
 }
 
 // renterRecoveryScanHandlerPOST handles the API call to /renter/recoveryscan.
-func (api *API) renterRecoveryScanHandlerPOST(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
-	if err := api.renter.InitRecoveryScan(); err != nil {
+func (api *API) renterRecoveryScanHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Scan the whole blockchain if no range is specified.
+	startStr, endStr := req.FormValue("startheight"), req.FormValue("endheight")
+	if startStr == "" && endStr == "" {
+		if err := api.renter.InitRecoveryScan(); err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+		WriteSuccess(w)
+		return
+	}
+
+	// Parse the range. It defaults to the genesis block and the current
+	// blockheight.
+	var start types.BlockHeight
+	if startStr != "" {
+		if _, err := fmt.Sscan(startStr, &start); err != nil {
+			WriteError(w, Error{"unable to parse 'startheight' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	end := api.cs.Height()
+	if endStr != "" {
+		if _, err := fmt.Sscan(endStr, &end); err != nil {
+			WriteError(w, Error{"unable to parse 'endheight' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if err := api.renter.InitRecoveryScanRange(start, end); err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterRecoveryScanPauseHandlerPOST handles the API call to
+// /renter/recoveryscan/pause.
+func (api *API) renterRecoveryScanPauseHandlerPOST(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	if err := api.renter.PauseRecoveryScan(); err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterRecoveryScanResumeHandlerPOST handles the API call to
+// /renter/recoveryscan/resume.
+func (api *API) renterRecoveryScanResumeHandlerPOST(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	if err := api.renter.ResumeRecoveryScan(); err != nil {
 		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
 		return
 	}
@@ -1430,9 +1832,17 @@ func (api *API) renterRecoveryScanHandlerPOST(w http.ResponseWriter, _ *http.Req
 // renterRecoveryScanHandlerGET handles the API call to /renter/recoveryscan.
 func (api *API) renterRecoveryScanHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	scanInProgress, height := api.renter.RecoveryScanStatus()
+	progress := api.renter.RecoveryScanProgress()
 	WriteJSON(w, RenterRecoveryStatusGET{
 		ScanInProgress: scanInProgress,
 		ScannedHeight:  height,
+
+		Paused:         progress.Paused,
+		StartHeight:    progress.StartHeight,
+		EndHeight:      progress.EndHeight,
+		BlocksScanned:  progress.BlocksScanned,
+		ContractsFound: progress.ContractsFound,
+		Contracts:      progress.Contracts,
 	})
 }
 
@@ -1604,6 +2014,19 @@ func (api *API) renterFilesHandler(w http.ResponseWriter, req *http.Request, _ h
 	})
 }
 
+// renterSpendingForecastHandlerGET handles the API call to
+// /renter/spending/forecast.
+func (api *API) renterSpendingForecastHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	forecast, err := api.renter.SpendingForecast()
+	if err != nil {
+		WriteError(w, Error{"unable to get spending forecast: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterSpendingForecastGET{
+		SpendingForecast: forecast,
+	})
+}
+
 // renterPricesHandler reports the expected costs of various actions given the
 // renter settings and the set of available hosts.
 func (api *API) renterPricesHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
@@ -1719,6 +2142,35 @@ func (api *API) renterDeleteHandler(w http.ResponseWriter, req *http.Request, ps
 	WriteSuccess(w)
 }
 
+// renterArchiveRetrieveHandlerPOST handles the API call to retrieve an archived
+// file.
+func (api *API) renterArchiveRetrieveHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := skymodules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	siaPath, err = rebaseInputSiaPath(siaPath)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = api.renter.RetrieveArchivedFile(siaPath)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		WriteError(w, Error{err.Error()}, http.StatusNotFound)
+		return
+	}
+	if errors.Contains(err, renter.ErrNotArchived) {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"unable to retrieve archived file: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // renterCancelDownloadHandler handles the API call to cancel a download.
 func (api *API) renterCancelDownloadHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	// Get the id.
@@ -1951,6 +2403,24 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 			return
 		}
 	}
+	// Check whether the file should be uploaded to the archive hosts.
+	archive := false
+	if a := req.FormValue("archive"); a != "" {
+		archive, err = strconv.ParseBool(a)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'archive' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	// Check whether the file should be uploaded to the hosts of an allowance
+	// profile.
+	profile := req.FormValue("profile")
+	// Check whether the upload is restricted to a set of hosts.
+	allowedHosts, err := parseAllowedHosts(req.FormValue("allowedhosts"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'allowedhosts' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
 	// Parse the erasure coder.
 	ec, err := parseErasureCodingParameters(req.FormValue("datapieces"), req.FormValue("paritypieces"))
 	if err != nil {
@@ -1970,10 +2440,13 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 		return
 	}
 	err = api.renter.Upload(skymodules.FileUploadParams{
-		Source:      source,
-		SiaPath:     siaPath,
-		ErasureCode: ec,
-		Force:       force,
+		Source:       source,
+		SiaPath:      siaPath,
+		ErasureCode:  ec,
+		Force:        force,
+		Archive:      archive,
+		Profile:      profile,
+		AllowedHosts: allowedHosts,
 
 		// NOTE: can make this an optional param.
 		CipherType: crypto.TypeDefaultRenter,
@@ -2087,6 +2560,16 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 		WriteError(w, Error{"can't provide erasure code settings when doing a repair"}, http.StatusBadRequest)
 		return
 	}
+	// Check whether the upload is restricted to a set of hosts.
+	allowedHosts, err := parseAllowedHosts(queryForm.Get("allowedhosts"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'allowedhosts' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if repair && len(allowedHosts) > 0 {
+		WriteError(w, Error{"can't provide allowed hosts when doing a repair"}, http.StatusBadRequest)
+		return
+	}
 
 	// Call the renter to upload the file.
 	siaPath, err := skymodules.NewSiaPath(ps.ByName("siapath"))
@@ -2100,10 +2583,11 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 		return
 	}
 	up := skymodules.FileUploadParams{
-		SiaPath:     siaPath,
-		ErasureCode: ec,
-		Force:       force,
-		Repair:      repair,
+		SiaPath:      siaPath,
+		ErasureCode:  ec,
+		Force:        force,
+		Repair:       repair,
+		AllowedHosts: allowedHosts,
 
 		// NOTE: can make this an optional param.
 		CipherType: crypto.TypeDefaultRenter,
@@ -2301,6 +2785,29 @@ func (api *API) renterContractStatusHandler(w http.ResponseWriter, req *http.Req
 	WriteJSON(w, contractStatus)
 }
 
+// renterContractFeeBumpsHandlerGET handles the API call to list the pending
+// fee bumps of the renter's unconfirmed contract transactions.
+func (api *API) renterContractFeeBumpsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	settings, err := api.renter.Settings()
+	if err != nil {
+		WriteError(w, Error{"unable to get renter settings: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	feeBumps := api.renter.ContractFeeBumps()
+	var pending types.Currency
+	for _, fb := range feeBumps {
+		pending = pending.Add(fb.Fee)
+	}
+	if feeBumps == nil {
+		feeBumps = []skymodules.ContractFeeBump{}
+	}
+	WriteJSON(w, RenterContractFeeBumpsGET{
+		Budget:   settings.Allowance.MaxFeeBumpBudget,
+		Pending:  pending,
+		FeeBumps: feeBumps,
+	})
+}
+
 // renterWorkersHandler handles the API call to check the status of the renter's
 // workers
 func (api *API) renterWorkersHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
@@ -2312,3 +2819,209 @@ func (api *API) renterWorkersHandler(w http.ResponseWriter, _ *http.Request, _ h
 
 	WriteJSON(w, workerPoolStatus)
 }
+
+// renterWorkersAccountRefillHandlerPOST handles the API call to set the refill
+// settings of the ephemeral account on a host.
+func (api *API) renterWorkersAccountRefillHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Scan the host key. (required parameter)
+	var hostKey types.SiaPublicKey
+	hostKey.LoadString(req.FormValue("hostkey"))
+	if hostKey.Key == nil {
+		WriteError(w, Error{"invalid host public key"}, http.StatusBadRequest)
+		return
+	}
+	// Scan the currency values. (optional parameters)
+	var settings skymodules.WorkerAccountRefillSettings
+	for _, param := range []struct {
+		name  string
+		value *types.Currency
+	}{
+		{"target", &settings.Target},
+		{"threshold", &settings.Threshold},
+	} {
+		str := req.FormValue(param.name)
+		if str == "" {
+			continue
+		}
+		amount, ok := scanAmount(str)
+		if !ok {
+			WriteError(w, Error{"unable to parse " + param.name}, http.StatusBadRequest)
+			return
+		}
+		*param.value = amount
+	}
+
+	err := api.renter.SetWorkerAccountRefillSettings(hostKey, settings)
+	if err != nil {
+		WriteError(w, Error{"unable to set account refill settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterWorkersResetCooldownHandlerPOST handles the API call to take a worker
+// off its cooldowns.
+func (api *API) renterWorkersResetCooldownHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Scan the host key. (required parameter)
+	var hostKey types.SiaPublicKey
+	hostKey.LoadString(req.FormValue("hostkey"))
+	if hostKey.Key == nil {
+		WriteError(w, Error{"invalid host public key"}, http.StatusBadRequest)
+		return
+	}
+
+	err := api.renter.ResetWorkerCooldown(hostKey)
+	if err != nil {
+		WriteError(w, Error{"unable to reset worker cooldown: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterWorkersDetailHandlerGET handles the API call to get the full internal
+// state of the renter's workers. The optional hostkey parameter limits the
+// response to the worker of a single host.
+func (api *API) renterWorkersDetailHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	wpd, err := api.renter.WorkerPoolDetail()
+	if err != nil {
+		WriteError(w, Error{"unable to get worker details: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	// Scan the host key. (optional parameter)
+	hostKeyStr := req.FormValue("hostkey")
+	if hostKeyStr == "" {
+		WriteJSON(w, wpd)
+		return
+	}
+	var hostKey types.SiaPublicKey
+	hostKey.LoadString(hostKeyStr)
+	if hostKey.Key == nil {
+		WriteError(w, Error{"invalid host public key"}, http.StatusBadRequest)
+		return
+	}
+	var workers []skymodules.WorkerDetail
+	for _, wd := range wpd.Workers {
+		if wd.HostPubKey.Equals(hostKey) {
+			workers = append(workers, wd)
+		}
+	}
+	if len(workers) == 0 {
+		WriteError(w, Error{"no worker found for host " + hostKeyStr}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, skymodules.WorkerPoolDetail{
+		NumWorkers: len(workers),
+		Workers:    workers,
+	})
+}
+
+// renterWorkersDiagnoseHandlerPOST handles the API call to run a live
+// connectivity test against the host of a worker.
+func (api *API) renterWorkersDiagnoseHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Scan the host key. (required parameter)
+	var hostKey types.SiaPublicKey
+	hostKey.LoadString(req.FormValue("hostkey"))
+	if hostKey.Key == nil {
+		WriteError(w, Error{"invalid host public key"}, http.StatusBadRequest)
+		return
+	}
+
+	report, err := api.renter.DiagnoseWorker(hostKey)
+	if err != nil {
+		WriteError(w, Error{"unable to diagnose worker: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, report)
+}
+
+// renterWorkersFlushHandlerPOST handles the API call to discard all jobs
+// queued with a worker.
+func (api *API) renterWorkersFlushHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Scan the host key. (required parameter)
+	var hostKey types.SiaPublicKey
+	hostKey.LoadString(req.FormValue("hostkey"))
+	if hostKey.Key == nil {
+		WriteError(w, Error{"invalid host public key"}, http.StatusBadRequest)
+		return
+	}
+
+	err := api.renter.FlushWorkerQueues(hostKey)
+	if err != nil {
+		WriteError(w, Error{"unable to flush worker queues: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterWorkersPauseHandlerPOST handles the API call to pause a worker.
+func (api *API) renterWorkersPauseHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Scan the host key. (required parameter)
+	var hostKey types.SiaPublicKey
+	hostKey.LoadString(req.FormValue("hostkey"))
+	if hostKey.Key == nil {
+		WriteError(w, Error{"invalid host public key"}, http.StatusBadRequest)
+		return
+	}
+
+	err := api.renter.PauseWorker(hostKey)
+	if err != nil {
+		WriteError(w, Error{"unable to pause worker: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterWorkersResumeHandlerPOST handles the API call to resume a paused
+// worker.
+func (api *API) renterWorkersResumeHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Scan the host key. (required parameter)
+	var hostKey types.SiaPublicKey
+	hostKey.LoadString(req.FormValue("hostkey"))
+	if hostKey.Key == nil {
+		WriteError(w, Error{"invalid host public key"}, http.StatusBadRequest)
+		return
+	}
+
+	err := api.renter.ResumeWorker(hostKey)
+	if err != nil {
+		WriteError(w, Error{"unable to resume worker: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterWorkersBlocklistHandlerGET handles the API call to get the worker
+// blocklist.
+func (api *API) renterWorkersBlocklistHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, RenterWorkersBlocklistGET{
+		Entries:          api.renter.WorkerBlocklist(),
+		BlocklistedHosts: api.renter.WorkerBlocklistedHosts(),
+	})
+}
+
+// renterWorkersBlocklistHandlerPOST handles the API call to add and remove
+// entries from the worker blocklist.
+func (api *API) renterWorkersBlocklistHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse parameters.
+	var params RenterWorkersBlocklistPOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Check for nil input.
+	if len(params.Add)+len(params.Remove) == 0 {
+		WriteError(w, Error{"no blocklist entries submitted"}, http.StatusBadRequest)
+		return
+	}
+
+	// Update the worker blocklist.
+	err = api.renter.UpdateWorkerBlocklist(params.Add, params.Remove)
+	if err != nil {
+		WriteError(w, Error{"unable to update the worker blocklist: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
@@ -1673,3 +1673,43 @@ func TestHealthLoop(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestParseAllowedHosts is a unit test for parseAllowedHosts.
+func TestParseAllowedHosts(t *testing.T) {
+	t.Parallel()
+
+	// No hosts.
+	hosts, err := parseAllowedHosts("")
+	if err != nil || hosts != nil {
+		t.Fatal("unexpected result", hosts, err)
+	}
+
+	// Multiple hosts.
+	var keys []types.SiaPublicKey
+	var strs []string
+	for i := 0; i < 3; i++ {
+		spk := types.SiaPublicKey{
+			Algorithm: types.SignatureEd25519,
+			Key:       fastrand.Bytes(32),
+		}
+		keys = append(keys, spk)
+		strs = append(strs, spk.String())
+	}
+	hosts, err = parseAllowedHosts(strings.Join(strs, ", "))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hosts) != len(keys) {
+		t.Fatal("wrong number of hosts", len(hosts))
+	}
+	for i := range hosts {
+		if !hosts[i].Equals(keys[i]) {
+			t.Fatal("wrong host", hosts[i], keys[i])
+		}
+	}
+
+	// Invalid hosts.
+	if _, err := parseAllowedHosts(strs[0] + ",invalid"); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+const (
+	// contractSnapshotFormatCSV exports contract set snapshots as CSV. CSV
+	// exports are meant for spreadsheets and are not signed.
+	contractSnapshotFormatCSV = "csv"
+
+	// contractSnapshotFormatJSON exports contract set snapshots as signed
+	// JSON documents. It is the default.
+	contractSnapshotFormatJSON = "json"
+)
+
+type (
+	// RenterContractsSnapshotDiffPOST is the response of
+	// /renter/contracts/snapshot/diff [POST]. It contains the current
+	// snapshot, which can be used for the next diff, and the changes since
+	// the submitted snapshot.
+	RenterContractsSnapshotDiffPOST struct {
+		Snapshot skymodules.SignedContractSetSnapshot `json:"snapshot"`
+		Diff     skymodules.ContractSetSnapshotDiff   `json:"diff"`
+	}
+)
+
+// parseContractSnapshotFormat parses the 'format' query parameter of the
+// contract snapshot endpoints.
+func parseContractSnapshotFormat(req *http.Request) (string, error) {
+	queryForm, err := url.ParseQuery(req.URL.RawQuery)
+	if err != nil {
+		return "", errors.New("failed to parse query params")
+	}
+	format := queryForm.Get("format")
+	switch format {
+	case "":
+		return contractSnapshotFormatJSON, nil
+	case contractSnapshotFormatCSV, contractSnapshotFormatJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unsupported format '%v'", format)
+	}
+}
+
+// writeCSV writes a CSV export as an attachment with the given filename.
+func writeCSV(w http.ResponseWriter, filename string, writeFn func(*bytes.Buffer) error) {
+	var buf bytes.Buffer
+	if err := writeFn(&buf); err != nil {
+		WriteError(w, Error{"failed to encode csv: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	_, _ = w.Write(buf.Bytes())
+}
+
+// renterContractsSnapshotHandlerGET handles the GET calls to
+// /renter/contracts/snapshot which export a snapshot of the renter's contracts.
+func (api *API) renterContractsSnapshotHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	format, err := parseContractSnapshotFormat(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	signed, err := api.renter.ContractSetSnapshot()
+	if err != nil {
+		WriteError(w, Error{"failed to create contract set snapshot: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	if format == contractSnapshotFormatJSON {
+		WriteJSON(w, signed)
+		return
+	}
+	snapshot, err := signed.Verify(signed.PublicKey)
+	if err != nil {
+		WriteError(w, Error{"failed to decode contract set snapshot: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	writeCSV(w, fmt.Sprintf("contracts-%v.csv", snapshot.BlockHeight), func(buf *bytes.Buffer) error {
+		return snapshot.WriteCSV(buf)
+	})
+}
+
+// renterContractsSnapshotDiffHandlerPOST handles the POST calls to
+// /renter/contracts/snapshot/diff which compare the renter's current contracts
+// to a previously exported snapshot.
+func (api *API) renterContractsSnapshotDiffHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	format, err := parseContractSnapshotFormat(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Decode the previous snapshot.
+	var previous skymodules.SignedContractSetSnapshot
+	err = json.NewDecoder(req.Body).Decode(&previous)
+	if err != nil {
+		WriteError(w, Error{"Failed to decode request: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Create the current snapshot and make sure that the previous one was
+	// signed by the renter as well.
+	signed, err := api.renter.ContractSetSnapshot()
+	if err != nil {
+		WriteError(w, Error{"failed to create contract set snapshot: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	old, err := previous.Verify(signed.PublicKey)
+	if err != nil {
+		WriteError(w, Error{"invalid previous snapshot: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	current, err := signed.Verify(signed.PublicKey)
+	if err != nil {
+		WriteError(w, Error{"failed to decode contract set snapshot: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	diff := current.Diff(old)
+
+	if format == contractSnapshotFormatJSON {
+		WriteJSON(w, RenterContractsSnapshotDiffPOST{
+			Snapshot: signed,
+			Diff:     diff,
+		})
+		return
+	}
+	writeCSV(w, fmt.Sprintf("contracts-%v-%v.csv", diff.FromBlockHeight, diff.ToBlockHeight), func(buf *bytes.Buffer) error {
+		return diff.WriteCSV(buf)
+	})
+}
@@ -0,0 +1,269 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+const (
+	// dirDownloadConcurrency is the number of files which are fetched
+	// concurrently when downloading a directory as an archive.
+	dirDownloadConcurrency = 4
+
+	// dirDownloadPrefetchSize is the number of bytes which are prefetched per
+	// file when downloading a directory as an archive. Files that are smaller
+	// are fetched completely. The memory used by a directory download is
+	// bounded by dirDownloadConcurrency * dirDownloadPrefetchSize.
+	dirDownloadPrefetchSize = 1 << 22 // 4 MiB
+)
+
+type (
+	// dirArchiveReader is an io.Reader which reads the concatenated contents
+	// of a list of files. While a file is read, the following files are
+	// prefetched concurrently. That way the latency of fetching a file is
+	// hidden for directories with many small files.
+	dirArchiveReader struct {
+		staticFiles []skymodules.FileInfo
+		staticOpen  func(skymodules.SiaPath) (skymodules.Streamer, error)
+
+		current io.Reader
+		closer  io.Closer
+		next    int
+		queue   []*dirArchivePrefetch
+	}
+
+	// dirArchivePrefetch is a file which is being prefetched by the
+	// dirArchiveReader. Once done is closed, head contains the first bytes of
+	// the file and streamer is positioned right after them. If the file was
+	// fetched completely, streamer is nil.
+	dirArchivePrefetch struct {
+		head     []byte
+		streamer skymodules.Streamer
+		err      error
+		done     chan struct{}
+	}
+)
+
+// newDirArchiveReader creates a reader for the concatenated contents of the
+// given files.
+func newDirArchiveReader(files []skymodules.FileInfo, open func(skymodules.SiaPath) (skymodules.Streamer, error)) *dirArchiveReader {
+	dr := &dirArchiveReader{
+		staticFiles: files,
+		staticOpen:  open,
+	}
+	dr.fillQueue()
+	return dr
+}
+
+// fillQueue starts prefetching files until dirDownloadConcurrency files are
+// being prefetched or there are no more files.
+func (dr *dirArchiveReader) fillQueue() {
+	for len(dr.queue) < dirDownloadConcurrency && dr.next < len(dr.staticFiles) {
+		fi := dr.staticFiles[dr.next]
+		dr.next++
+		pf := &dirArchivePrefetch{
+			done: make(chan struct{}),
+		}
+		dr.queue = append(dr.queue, pf)
+		go dr.threadedPrefetch(fi, pf)
+	}
+}
+
+// threadedPrefetch opens a file and prefetches its first bytes.
+func (dr *dirArchiveReader) threadedPrefetch(fi skymodules.FileInfo, pf *dirArchivePrefetch) {
+	defer close(pf.done)
+	streamer, err := dr.staticOpen(fi.SiaPath)
+	if err != nil {
+		pf.err = errors.AddContext(err, fmt.Sprintf("failed to open '%v'", fi.SiaPath))
+		return
+	}
+	headLen := fi.Filesize
+	if headLen > dirDownloadPrefetchSize {
+		headLen = dirDownloadPrefetchSize
+	}
+	pf.head = make([]byte, headLen)
+	_, err = io.ReadFull(streamer, pf.head)
+	if err != nil {
+		pf.err = errors.Compose(errors.AddContext(err, fmt.Sprintf("failed to read '%v'", fi.SiaPath)), streamer.Close())
+		return
+	}
+	// Close the streamer right away if the file was fetched completely.
+	if fi.Filesize <= dirDownloadPrefetchSize {
+		pf.err = streamer.Close()
+		return
+	}
+	pf.streamer = streamer
+}
+
+// Read implements the io.Reader interface.
+func (dr *dirArchiveReader) Read(b []byte) (int, error) {
+	for {
+		if dr.current == nil {
+			if len(dr.queue) == 0 {
+				return 0, io.EOF
+			}
+			pf := dr.queue[0]
+			dr.queue = dr.queue[1:]
+			dr.fillQueue()
+			<-pf.done
+			if pf.err != nil {
+				return 0, pf.err
+			}
+			dr.current = bytes.NewReader(pf.head)
+			if pf.streamer != nil {
+				dr.current = io.MultiReader(dr.current, pf.streamer)
+				dr.closer = pf.streamer
+			}
+		}
+		n, err := dr.current.Read(b)
+		if errors.Contains(err, io.EOF) {
+			err = dr.closeCurrent()
+			if n > 0 || err != nil {
+				return n, err
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+// closeCurrent closes the file which is currently being read.
+func (dr *dirArchiveReader) closeCurrent() (err error) {
+	if dr.closer != nil {
+		err = dr.closer.Close()
+	}
+	dr.current = nil
+	dr.closer = nil
+	return err
+}
+
+// Close closes the reader and all of the files which are being prefetched.
+func (dr *dirArchiveReader) Close() error {
+	err := dr.closeCurrent()
+	for _, pf := range dr.queue {
+		<-pf.done
+		if pf.streamer != nil {
+			err = errors.Compose(err, pf.streamer.Close())
+		}
+	}
+	dr.queue = nil
+	return err
+}
+
+// isDirDownloadRequest returns true if a request to /renter/dir/*siapath is a
+// request to download the directory rather than listing it.
+func isDirDownloadRequest(req *http.Request, ps httprouter.Params) bool {
+	str := ps.ByName("siapath")
+	isDownloadPath := str == "/download" || strings.HasPrefix(str, "/download/")
+	return isDownloadPath && req.FormValue("format") != ""
+}
+
+// renterDirDownloadHandlerGET handles the API call to download all of the
+// files within a directory as an archive.
+func (api *API) renterDirDownloadHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	// Parse the siapath.
+	var siaPath skymodules.SiaPath
+	var err error
+	str := strings.TrimPrefix(ps.ByName("siapath"), "/download")
+	if str == "" || str == "/" {
+		siaPath = skymodules.RootSiaPath()
+	} else {
+		siaPath, err = skymodules.NewSiaPath(str)
+	}
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if !root {
+		siaPath, err = rebaseInputSiaPath(siaPath)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Parse the format.
+	format := skymodules.SkyfileFormat(strings.ToLower(req.FormValue("format")))
+	if !format.IsArchive() {
+		WriteError(w, Error{fmt.Sprintf("unsupported archive format '%v'", format)}, http.StatusBadRequest)
+		return
+	}
+
+	// Parse the disablelocalfetch flag.
+	var disableLocalFetch bool
+	if str := req.FormValue("disablelocalfetch"); str != "" {
+		disableLocalFetch, err = scanBool(str)
+		if err != nil {
+			WriteError(w, Error{"error parsing the disablelocalfetch flag: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Get the files within the directory.
+	var files []skymodules.FileInfo
+	var mu sync.Mutex
+	err = api.renter.FileList(siaPath, true, true, func(fi skymodules.FileInfo) {
+		mu.Lock()
+		files = append(files, fi)
+		mu.Unlock()
+	})
+	if err != nil {
+		WriteError(w, Error{"failed to get file infos: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].SiaPath.String() < files[j].SiaPath.String()
+	})
+
+	// Name the files relative to the directory. The files are read one after
+	// another from the dir archive reader.
+	subfiles := make([]skymodules.SkyfileSubfileMetadata, 0, len(files))
+	var offset uint64
+	for _, fi := range files {
+		relPath, err := fi.SiaPath.Rebase(siaPath, skymodules.RootSiaPath())
+		if err != nil {
+			WriteError(w, Error{"failed to name file within archive: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		subfiles = append(subfiles, skymodules.SkyfileSubfileMetadata{
+			FileMode: fi.FileMode,
+			Filename: relPath.String(),
+			Offset:   offset,
+			Len:      fi.Filesize,
+		})
+		offset += fi.Filesize
+	}
+
+	// Stream the archive.
+	name := siaPath.Name()
+	if siaPath.IsRoot() {
+		name = "root"
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", strconv.Quote(name+format.Extension())))
+	dr := newDirArchiveReader(files, func(sp skymodules.SiaPath) (skymodules.Streamer, error) {
+		_, streamer, err := api.renter.Streamer(sp, disableLocalFetch)
+		return streamer, err
+	})
+	err = serveArchiveFiles(w, dr, format, subfiles)
+	err = errors.Compose(err, dr.Close())
+	if err != nil {
+		// Once the archive is being streamed, the error can't be returned to
+		// the client anymore. The archive will be truncated.
+		WriteError(w, Error{fmt.Sprintf("failed to serve directory as %v archive: %v", format, err)}, http.StatusInternalServerError)
+	}
+}
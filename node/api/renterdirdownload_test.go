@@ -0,0 +1,140 @@
+package api
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+// testDirStreamer is a skymodules.Streamer which tracks whether it was closed.
+type testDirStreamer struct {
+	*bytes.Reader
+	closed *sync.WaitGroup
+}
+
+// Close implements io.Closer.
+func (s *testDirStreamer) Close() error {
+	s.closed.Done()
+	return nil
+}
+
+// TestDirArchiveReader tests the dirArchiveReader.
+func TestDirArchiveReader(t *testing.T) {
+	t.Parallel()
+
+	// Create some files of different sizes. One of them is larger than the
+	// prefetch size.
+	sizes := []uint64{0, 1, 100, dirDownloadPrefetchSize, dirDownloadPrefetchSize + 1, 10}
+	contents := make(map[skymodules.SiaPath][]byte)
+	var files []skymodules.FileInfo
+	var expected []byte
+	for i, size := range sizes {
+		sp, err := skymodules.NewSiaPath("dir/" + string(rune('a'+i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents[sp] = fastrand.Bytes(int(size))
+		expected = append(expected, contents[sp]...)
+		files = append(files, skymodules.FileInfo{
+			SiaPath:  sp,
+			Filesize: size,
+			FileMode: 0644,
+		})
+	}
+	var closed sync.WaitGroup
+	var failPath skymodules.SiaPath
+	open := func(sp skymodules.SiaPath) (skymodules.Streamer, error) {
+		if sp == failPath {
+			return nil, errors.New("failed to open")
+		}
+		closed.Add(1)
+		return &testDirStreamer{Reader: bytes.NewReader(contents[sp]), closed: &closed}, nil
+	}
+
+	// Read the concatenated files. All streamers should be closed.
+	dr := newDirArchiveReader(files, open)
+	data, err := ioutil.ReadAll(dr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, expected) {
+		t.Fatal("wrong data")
+	}
+	if err := dr.Close(); err != nil {
+		t.Fatal(err)
+	}
+	closed.Wait()
+
+	// Closing the reader early should close all prefetched files.
+	dr = newDirArchiveReader(files, open)
+	if _, err := io.ReadFull(dr, make([]byte, 101)); err != nil {
+		t.Fatal(err)
+	}
+	if err := dr.Close(); err != nil {
+		t.Fatal(err)
+	}
+	closed.Wait()
+
+	// An error opening a file is returned when it is reached.
+	failPath = files[2].SiaPath
+	dr = newDirArchiveReader(files, open)
+	if _, err := ioutil.ReadAll(dr); err == nil || !strings.Contains(err.Error(), "failed to open") {
+		t.Fatal("expected error", err)
+	}
+	if err := dr.Close(); err != nil {
+		t.Fatal(err)
+	}
+	closed.Wait()
+
+	// Serve the files as a tar archive and check its contents.
+	failPath = skymodules.SiaPath{}
+	var subfiles []skymodules.SkyfileSubfileMetadata
+	var offset uint64
+	for _, fi := range files {
+		subfiles = append(subfiles, skymodules.SkyfileSubfileMetadata{
+			FileMode: fi.FileMode,
+			Filename: fi.SiaPath.String(),
+			Offset:   offset,
+			Len:      fi.Filesize,
+		})
+		offset += fi.Filesize
+	}
+	dr = newDirArchiveReader(files, open)
+	rec := httptest.NewRecorder()
+	if err := serveArchiveFiles(rec, dr, skymodules.SkyfileFormatTar, subfiles); err != nil {
+		t.Fatal(err)
+	}
+	if err := dr.Close(); err != nil {
+		t.Fatal(err)
+	}
+	closed.Wait()
+	tr := tar.NewReader(rec.Body)
+	for _, fi := range files {
+		header, err := tr.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Name != fi.SiaPath.String() || header.Size != int64(fi.Filesize) {
+			t.Fatal("wrong header", header.Name, header.Size)
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, contents[fi.SiaPath]) {
+			t.Fatal("wrong content", fi.SiaPath)
+		}
+	}
+	if _, err := tr.Next(); err != io.EOF {
+		t.Fatal("expected end of archive", err)
+	}
+}
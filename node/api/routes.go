@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
 	"time"
@@ -11,8 +12,10 @@ import (
 	"github.com/tus/tusd/pkg/handler"
 	siaapi "go.sia.tech/siad/node/api"
 
+	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/log"
 	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
 	"gitlab.com/SkynetLabs/skyd/skymodules/renter"
 )
 
@@ -45,9 +48,12 @@ func (api *API) buildHTTPRoutes() {
 	// Daemon API Calls
 	router.GET("/daemon/alerts", api.daemonAlertsHandlerGET)
 	router.GET("/daemon/constants", api.daemonConstantsHandler)
+	router.GET("/daemon/maintenance", api.daemonMaintenanceHandlerGET)
+	router.POST("/daemon/maintenance/:name", api.requireScope(api.daemonMaintenanceHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
 	router.GET("/daemon/ready", api.daemonReadyGET)
 	router.GET("/daemon/settings", api.daemonSettingsHandlerGET)
 	router.POST("/daemon/settings", api.daemonSettingsHandlerPOST)
+	router.PUT("/daemon/settings", api.requireScope(api.daemonSettingsHandlerPUT, requiredPassword, skymodules.APITokenScopeAdmin))
 	router.GET("/daemon/stack", api.daemonStackHandlerGET)
 	router.POST("/daemon/startprofile", api.daemonStartProfileHandlerPOST)
 	router.GET("/daemon/stop", RequirePassword(api.daemonStopHandler, requiredPassword))
@@ -87,72 +93,127 @@ func (api *API) buildHTTPRoutes() {
 	// Renter API Calls
 	if api.renter != nil {
 		router.GET("/renter", api.renterHandlerGET)
-		router.POST("/renter", RequirePassword(api.renterHandlerPOST, requiredPassword))
-		router.POST("/renter/allowance/cancel", RequirePassword(api.renterAllowanceCancelHandlerPOST, requiredPassword))
+		router.POST("/renter", api.requireScope(api.renterHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/renter/allowance/cancel", api.requireScope(api.renterAllowanceCancelHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.GET("/renter/autofund", api.renterAutoFundHandlerGET)
+		router.POST("/renter/autofund", api.requireScope(api.renterAutoFundHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
 		router.POST("/renter/bubble", api.renterBubbleHandlerPOST)
-		router.GET("/renter/backups", RequirePassword(api.renterBackupsHandlerGET, requiredPassword))
-		router.POST("/renter/backups/create", RequirePassword(api.renterBackupsCreateHandlerPOST, requiredPassword))
-		router.POST("/renter/backups/restore", RequirePassword(api.renterBackupsRestoreHandlerGET, requiredPassword))
-		router.POST("/renter/clean", RequirePassword(api.renterCleanHandlerPOST, requiredPassword))
-		router.POST("/renter/contract/cancel", RequirePassword(api.renterContractCancelHandler, requiredPassword))
+		router.GET("/renter/backups", api.requireScope(api.renterBackupsHandlerGET, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/renter/backups/create", api.requireScope(api.renterBackupsCreateHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/renter/backups/restore", api.requireScope(api.renterBackupsRestoreHandlerGET, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/renter/clean", api.requireScope(api.renterCleanHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/renter/contract/cancel", api.requireScope(api.renterContractCancelHandler, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/renter/contract/defrag", api.requireScope(api.renterContractDefragHandler, requiredPassword, skymodules.APITokenScopeAdmin))
 		router.GET("/renter/contracts", api.renterContractsHandler)
+		router.GET("/renter/contracts/snapshot", api.requireScope(api.renterContractsSnapshotHandlerGET, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/renter/contracts/snapshot/diff", api.requireScope(api.renterContractsSnapshotDiffHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
 		router.GET("/renter/contractorchurnstatus", api.renterContractorChurnStatus)
 		router.GET("/renter/downloadinfo/*uid", api.renterDownloadByUIDHandlerGET)
 		router.GET("/renter/downloads", api.renterDownloadsHandler)
-		router.POST("/renter/downloads/clear", RequirePassword(api.renterClearDownloadsHandler, requiredPassword))
+		router.POST("/renter/downloads/clear", api.requireScope(api.renterClearDownloadsHandler, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.GET("/renter/downloads/pending", api.renterPendingDownloadsHandlerGET)
+		router.POST("/renter/downloads/pending/cancel", api.requireScope(api.renterPendingDownloadsCancelHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
 		router.GET("/renter/files", api.renterFilesHandler)
 		router.GET("/renter/file/*siapath", api.renterFileHandlerGET)
-		router.POST("/renter/file/*siapath", RequirePassword(api.renterFileHandlerPOST, requiredPassword))
+		router.POST("/renter/file/*siapath", api.requireScope(api.renterFileHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
 		router.GET("/renter/prices", api.renterPricesHandler)
-		router.POST("/renter/recoveryscan", RequirePassword(api.renterRecoveryScanHandlerPOST, requiredPassword))
+		router.GET("/renter/spending/forecast", api.renterSpendingForecastHandlerGET)
+		router.POST("/renter/recoveryscan", api.requireScope(api.renterRecoveryScanHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
 		router.GET("/renter/recoveryscan", api.renterRecoveryScanHandlerGET)
+		router.POST("/renter/recoveryscan/pause", api.requireScope(api.renterRecoveryScanPauseHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/renter/recoveryscan/resume", api.requireScope(api.renterRecoveryScanResumeHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
 		router.GET("/renter/fuse", api.renterFuseHandlerGET)
-		router.POST("/renter/fuse/mount", RequirePassword(api.renterFuseMountHandlerPOST, requiredPassword))
-		router.POST("/renter/fuse/unmount", RequirePassword(api.renterFuseUnmountHandlerPOST, requiredPassword))
-
-		router.POST("/renter/delete/*siapath", RequirePassword(api.renterDeleteHandler, requiredPassword))
-		router.GET("/renter/download/*siapath", RequirePassword(api.renterDownloadHandler, requiredPassword))
-		router.POST("/renter/download/cancel", RequirePassword(api.renterCancelDownloadHandler, requiredPassword))
-		router.GET("/renter/downloadasync/*siapath", RequirePassword(api.renterDownloadAsyncHandler, requiredPassword))
-		router.POST("/renter/rename/*siapath", RequirePassword(api.renterRenameHandler, requiredPassword))
+		router.POST("/renter/fuse/mount", api.requireScope(api.renterFuseMountHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/renter/fuse/unmount", api.requireScope(api.renterFuseUnmountHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+
+		router.POST("/renter/archive/retrieve/*siapath", api.requireScope(api.renterArchiveRetrieveHandlerPOST, requiredPassword, skymodules.APITokenScopeDownload))
+		router.POST("/renter/delete/*siapath", api.requireScope(api.renterDeleteHandler, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.GET("/renter/download/*siapath", api.requireScope(api.renterDownloadHandler, requiredPassword, skymodules.APITokenScopeDownload))
+		router.POST("/renter/download/cancel", api.requireScope(api.renterCancelDownloadHandler, requiredPassword, skymodules.APITokenScopeDownload))
+		router.GET("/renter/downloadasync/*siapath", api.requireScope(api.renterDownloadAsyncHandler, requiredPassword, skymodules.APITokenScopeDownload))
+		router.POST("/renter/rename/*siapath", api.requireScope(api.renterRenameHandler, requiredPassword, skymodules.APITokenScopeAdmin))
 		router.GET("/renter/stream/*siapath", api.renterStreamHandler)
-		router.POST("/renter/upload/*siapath", RequirePassword(api.renterUploadHandler, requiredPassword))
+		router.POST("/renter/upload/*siapath", api.requireScope(api.renterUploadHandler, requiredPassword, skymodules.APITokenScopeUpload))
 		router.GET("/renter/uploadready", api.renterUploadReadyHandler)
-		router.POST("/renter/uploads/pause", RequirePassword(api.renterUploadsPauseHandler, requiredPassword))
-		router.POST("/renter/uploads/resume", RequirePassword(api.renterUploadsResumeHandler, requiredPassword))
-		router.POST("/renter/uploadstream/*siapath", RequirePassword(api.renterUploadStreamHandler, requiredPassword))
-		router.POST("/renter/validatesiapath/*siapath", RequirePassword(api.renterValidateSiaPathHandler, requiredPassword))
+		router.POST("/renter/uploads/pause", api.requireScope(api.renterUploadsPauseHandler, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/renter/uploads/resume", api.requireScope(api.renterUploadsResumeHandler, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/renter/uploadstream/*siapath", api.requireScope(api.renterUploadStreamHandler, requiredPassword, skymodules.APITokenScopeUpload))
+		router.POST("/renter/validatesiapath/*siapath", api.requireScope(api.renterValidateSiaPathHandler, requiredPassword, skymodules.APITokenScopeAdmin))
 		router.GET("/renter/workers", api.renterWorkersHandler)
+		router.POST("/renter/workers/accountrefill", api.requireScope(api.renterWorkersAccountRefillHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.GET("/renter/workers/blocklist", api.renterWorkersBlocklistHandlerGET)
+		router.POST("/renter/workers/blocklist", api.requireScope(api.renterWorkersBlocklistHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.GET("/renter/workers/detail", api.renterWorkersDetailHandlerGET)
+		router.POST("/renter/workers/diagnose", api.requireScope(api.renterWorkersDiagnoseHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/renter/workers/flush", api.requireScope(api.renterWorkersFlushHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/renter/workers/pause", api.requireScope(api.renterWorkersPauseHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/renter/workers/resetcooldown", api.requireScope(api.renterWorkersResetCooldownHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/renter/workers/resume", api.requireScope(api.renterWorkersResumeHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
 
 		// Skynet endpoints
 		router.GET("/skynet/basesector/*skylink", api.skynetBaseSectorHandlerGET)
+		router.POST("/skynet/batchdownload", api.skynetBatchDownloadHandlerPOST)
 		router.GET("/skynet/blocklist", api.skynetBlocklistHandlerGET)
-		router.POST("/skynet/blocklist", RequirePassword(api.skynetBlocklistHandlerPOST, requiredPassword))
+		router.POST("/skynet/convertdir", api.requireScope(api.skynetConvertDirHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.GET("/skynet/convertdir/:id", api.skynetConvertDirHandlerGET)
+		router.POST("/skynet/delete/:skylink", api.requireScope(api.skynetDeleteHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.GET("/skynet/delete/:id", api.skynetDeleteHandlerGET)
+		router.POST("/skynet/dirupload", api.requireScope(api.skynetDirUploadHandlerPOST, requiredPassword, skymodules.APITokenScopeUpload))
+		router.GET("/skynet/dirupload/:id", api.skynetDirUploadHandlerGET)
+		router.POST("/skynet/dirupload/:id/abort", api.requireScope(api.skynetDirUploadAbortHandlerPOST, requiredPassword, skymodules.APITokenScopeUpload))
+		router.POST("/skynet/dirupload/:id/file", api.requireScope(api.skynetDirUploadFileHandlerPOST, requiredPassword, skymodules.APITokenScopeUpload))
+		router.POST("/skynet/dirupload/:id/finalize", api.requireScope(api.skynetDirUploadFinalizeHandlerPOST, requiredPassword, skymodules.APITokenScopeUpload))
+		router.POST("/skynet/blocklist", api.requireScope(api.skynetBlocklistHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/skynet/blocklist/check", api.requireScope(api.skynetBlocklistCheckHandlerPOST, requiredPassword, skymodules.APITokenScopeUpload))
+		router.GET("/skynet/folder/*siapath", api.requireScope(api.skynetFolderHandlerGET, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/skynet/folderbackup", api.requireScope(api.skynetFolderBackupHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/skynet/folderrestore/:skylink", api.requireScope(api.skynetFolderRestoreHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
 		router.GET("/skynet/health/entry", api.registryEntryHealthHandlerGET)
+		router.POST("/skynet/import/:skylink", api.requireScope(api.skynetImportHandlerPOST, requiredPassword, skymodules.APITokenScopeUpload))
 		router.GET("/skynet/metadata/:skylink", api.skynetMetadataHandlerGET)
-		router.POST("/skynet/pin/:skylink", RequirePassword(api.skynetSkylinkPinHandlerPOST, requiredPassword))
+		router.POST("/skynet/pin/:skylink", api.requireScope(api.skynetSkylinkPinHandlerPOST, requiredPassword, skymodules.APITokenScopeUpload))
 		router.GET("/skynet/portals", api.skynetPortalsHandlerGET)
-		router.POST("/skynet/portals", RequirePassword(api.skynetPortalsHandlerPOST, requiredPassword))
-		router.POST("/skynet/registry", RequirePassword(api.registryHandlerPOST, requiredPassword))
-		router.POST("/skynet/registrymulti", RequirePassword(api.registryMultiHandlerPOST, requiredPassword))
+		router.POST("/skynet/portals", api.requireScope(api.skynetPortalsHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.GET("/skynet/quotas", api.requireScope(api.skynetQuotasHandlerGET, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/skynet/quotas", api.requireScope(api.skynetQuotasHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.GET("/skynet/repairpriority", api.requireScope(api.skynetRepairPriorityHandlerGET, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/skynet/repairpriority/:skylink", api.requireScope(api.skynetRepairPriorityHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/skynet/registry", api.requireScope(api.registryHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/skynet/registrymulti", api.requireScope(api.registryMultiHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
 		router.GET("/skynet/registry", api.registryHandlerGET)
 		router.GET("/skynet/registry/hosts", api.skynetHostsForRegistryUpdateGET)
+		router.GET("/skynet/registry/trustedhosts", api.skynetRegistryTrustedHostsHandlerGET)
+		router.POST("/skynet/registry/trustedhosts", api.requireScope(api.skynetRegistryTrustedHostsHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
 		router.GET("/skynet/resolve/:skylink", api.skylinkResolveGET)
-		router.POST("/skynet/restore", RequirePassword(api.skynetRestoreHandlerPOST, requiredPassword))
-		router.GET("/skynet/root", api.skynetRootHandlerGET)
+		router.POST("/skynet/restore", api.requireScope(api.skynetRestoreHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.GET("/skynet/root", api.requireScope(api.skynetRootHandlerGET, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/skynet/sign/:skylink", api.requireScope(api.skynetSignHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
 		router.GET("/skynet/skylink/*skylink", api.skynetSkylinkHandlerGET)
 		router.HEAD("/skynet/skylink/*skylink", api.skynetSkylinkHandlerGET)
-		router.POST("/skynet/skyfile/*siapath", RequirePassword(api.skynetSkyfileHandlerPOST, requiredPassword))
+		router.GET("/skynet/skylinks", api.requireScope(api.skynetSkylinksHandlerGET, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/skynet/skyfile/*siapath", api.requireScope(api.skynetSkyfileHandlerPOST, requiredPassword, skymodules.APITokenScopeUpload))
 		router.GET("/skynet/stats", api.skynetStatsHandlerGET)
-		router.POST("/skynet/unpin/:skylink", RequirePassword(api.skynetSkylinkUnpinHandlerPOST, requiredPassword))
+		router.GET("/skynet/tokens", api.requireScope(api.skynetTokensHandlerGET, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/skynet/tokens", api.requireScope(api.skynetTokensHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/skynet/tokens/:id/quota", api.requireScope(api.skynetTokenQuotaHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/skynet/tokens/:id/revoke", api.requireScope(api.skynetTokenRevokeHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.GET("/skynet/tokens/:id/usage", api.requireScope(api.skynetTokenUsageHandlerGET, requiredPassword, ""))
+		router.POST("/skynet/unpin/:skylink", api.requireScope(api.skynetSkylinkUnpinHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
 		router.GET("/skynet/health/skylink/:skylink", api.skynetSkylinkHealthGET)
+		router.GET("/skynet/verify/:skylink", api.requireScope(api.skynetSkylinkVerifyHandlerGET, requiredPassword, skymodules.APITokenScopeAdmin))
 
 		// Skykey endpoints
-		router.GET("/skynet/skykey", RequirePassword(api.skykeyHandlerGET, requiredPassword))
-		router.POST("/skynet/addskykey", RequirePassword(api.skykeyAddKeyHandlerPOST, requiredPassword))
-		router.POST("/skynet/createskykey", RequirePassword(api.skykeyCreateKeyHandlerPOST, requiredPassword))
-		router.POST("/skynet/deleteskykey", RequirePassword(api.skykeyDeleteHandlerPOST, requiredPassword))
-		router.GET("/skynet/skykeys", RequirePassword(api.skykeysHandlerGET, requiredPassword))
+		router.GET("/skynet/skykey", api.requireScope(api.skykeyHandlerGET, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/skynet/addskykey", api.requireScope(api.skykeyAddKeyHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/skynet/createskykey", api.requireScope(api.skykeyCreateKeyHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/skynet/deleteskykey", api.requireScope(api.skykeyDeleteHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.GET("/skynet/skykeys", api.requireScope(api.skykeysHandlerGET, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.GET("/skynet/skykeys/autobackup", api.requireScope(api.skykeysAutoBackupHandlerGET, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/skynet/skykeys/autobackup", api.requireScope(api.skykeysAutoBackupHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/skynet/skykeys/backup", api.requireScope(api.skykeysBackupHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/skynet/skykeys/export", api.requireScope(api.skykeysExportHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/skynet/skykeys/import", api.requireScope(api.skykeysImportHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/skynet/skykeys/restore/:skylink", api.requireScope(api.skykeysRestoreHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
 
 		// Create the store composer.
 		storeComposer := handler.NewStoreComposer()
@@ -200,8 +261,18 @@ func (api *API) buildHTTPRoutes() {
 		router.GET("/skynet/upload/tus/:id", api.skynetTUSUploadSkylinkGET)
 
 		// Directory endpoints
-		router.POST("/renter/dir/*siapath", RequirePassword(api.renterDirHandlerPOST, requiredPassword))
-		router.GET("/renter/dir/*siapath", api.renterDirHandlerGET)
+		router.POST("/renter/dir/*siapath", api.requireScope(api.renterDirHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		// Directory downloads share the catch-all route with the directory
+		// listing. They are recognized by the "download" prefix and the
+		// format parameter.
+		dirDownloadHandler := api.requireScope(api.renterDirDownloadHandlerGET, requiredPassword, skymodules.APITokenScopeDownload)
+		router.GET("/renter/dir/*siapath", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+			if isDirDownloadRequest(req, ps) {
+				dirDownloadHandler(w, req, ps)
+				return
+			}
+			api.renterDirHandlerGET(w, req, ps)
+		})
 
 		// HostDB endpoints.
 		router.GET("/hostdb", api.hostdbHandler)
@@ -209,16 +280,19 @@ func (api *API) buildHTTPRoutes() {
 		router.GET("/hostdb/all", api.hostdbAllHandler)
 		router.GET("/hostdb/hosts/:pubkey", api.hostdbHostsHandler)
 		router.GET("/hostdb/filtermode", api.hostdbFilterModeHandlerGET)
-		router.POST("/hostdb/filtermode", RequirePassword(api.hostdbFilterModeHandlerPOST, requiredPassword))
+		router.POST("/hostdb/filtermode", api.requireScope(api.hostdbFilterModeHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.GET("/hostdb/allowlist", api.hostdbAllowlistHandlerGET)
+		router.POST("/hostdb/allowlist", api.requireScope(api.hostdbAllowlistHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
 
 		// Renter watchdog endpoints.
 		router.GET("/renter/contractstatus", api.renterContractStatusHandler)
+		router.GET("/renter/contractfeebumps", api.renterContractFeeBumpsHandlerGET)
 
 		// Deprecated endpoints.
-		router.POST("/renter/backup", RequirePassword(api.renterBackupHandlerPOST, requiredPassword))
-		router.POST("/renter/recoverbackup", RequirePassword(api.renterLoadBackupHandlerPOST, requiredPassword))
+		router.POST("/renter/backup", api.requireScope(api.renterBackupHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
+		router.POST("/renter/recoverbackup", api.requireScope(api.renterLoadBackupHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
 		router.GET("/skynet/blacklist", api.skynetBlocklistHandlerGET)
-		router.POST("/skynet/blacklist", RequirePassword(api.skynetBlocklistHandlerPOST, requiredPassword))
+		router.POST("/skynet/blacklist", api.requireScope(api.skynetBlocklistHandlerPOST, requiredPassword, skymodules.APITokenScopeAdmin))
 	}
 
 	// Transaction pool API Calls
@@ -231,9 +305,10 @@ func (api *API) buildHTTPRoutes() {
 		siaapi.RegisterRoutesWallet(router, api.wallet, requiredPassword)
 	}
 
-	// Apply UserAgent middleware and return the Router
+	// Apply UserAgent middleware, serve the v2 API on top of the v1 routes
+	// and return the Router
 	api.routerMu.Lock()
-	api.router = TimeoutHandler(RequireUserAgent(router, requiredUserAgent), httpServerTimeout)
+	api.router = TimeoutHandler(apiVersionHandler(api.skynetSubdomainHandler(RequireUserAgent(api.skynetNameHostHandler(router, router), requiredUserAgent), router), router), httpServerTimeout)
 	api.routerMu.Unlock()
 	return
 }
@@ -263,6 +338,72 @@ func RequireUserAgent(h http.Handler, ua string) http.Handler {
 	})
 }
 
+// isUnroutedGET returns whether the request is a GET or HEAD request for a
+// path without a route.
+func isUnroutedGET(req *http.Request, router *httprouter.Router) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	handle, _, _ := router.Lookup(req.Method, req.URL.Path)
+	return handle == nil
+}
+
+// skynetSubdomainHandler is middleware that serves GET and HEAD requests for
+// paths without a route from the skylink in the leftmost label of the
+// request's Host header. Just like /skynet/skylink, these requests don't
+// require the user agent. All other requests are passed on to h.
+func (api *API) skynetSubdomainHandler(h http.Handler, router *httprouter.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if api.renter == nil || !isUnroutedGET(req, router) {
+			h.ServeHTTP(w, req)
+			return
+		}
+		// The skylink is parsed from the Host header by the handler.
+		if _, ok := skylinkFromHost(req.Host); ok {
+			api.skynetSkylinkHandlerGET(w, req, nil)
+			return
+		}
+		h.ServeHTTP(w, req)
+	})
+}
+
+// skynetNameHostHandler is middleware that serves GET and HEAD requests for
+// paths without a route from the skylink the skynet name in the request's Host
+// header resolves to. It expects to be wrapped by RequireUserAgent, so only
+// requests with the user agent cause name lookups. All other requests are
+// passed on to h.
+func (api *API) skynetNameHostHandler(h http.Handler, router *httprouter.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if api.renter == nil || !isUnroutedGET(req, router) {
+			h.ServeHTTP(w, req)
+			return
+		}
+		host := req.Host
+		if hostname, _, err := net.SplitHostPort(host); err == nil {
+			host = hostname
+		}
+		if _, _, err := skymodules.ParseSkynetName(host); err != nil {
+			h.ServeHTTP(w, req)
+			return
+		}
+		resolution, err := api.renter.ResolveSkynetName(req.Context(), host)
+		if errors.Contains(err, skymodules.ErrSkynetNameLookupsLimited) {
+			handleSkynetError(w, "failed to resolve name", err)
+			return
+		}
+		if err != nil {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		// Serve the path from the resolved skylink.
+		prefix := "/skynet/skylink/" + resolution.Skylink.String()
+		req.URL.RawPath = prefix + req.URL.EscapedPath()
+		req.URL.Path = prefix + req.URL.Path
+		api.skynetSkylinkHandlerGET(w, req, nil)
+	})
+}
+
 // RequirePassword is middleware that requires a request to authenticate with a
 // password using HTTP basic auth. Usernames are ignored. Empty passwords
 // indicate no authentication is required.
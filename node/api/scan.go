@@ -19,6 +19,20 @@ func scanAmount(amount string) (types.Currency, bool) {
 	return types.NewCurrency(i), true
 }
 
+// scanCurrency scans a types.Currency from a string with units, e.g. "1SC" or
+// "500mS".
+func scanCurrency(amount string) (types.Currency, error) {
+	hastings, err := types.ParseCurrency(amount)
+	if err != nil {
+		return types.Currency{}, err
+	}
+	c, ok := scanAmount(hastings)
+	if !ok {
+		return types.Currency{}, errors.New("could not scan currency amount")
+	}
+	return c, nil
+}
+
 // scanBool converts "true" and "false" strings to their respective
 // boolean value and returns an error if conversion is not possible.
 func scanBool(param string) (bool, error) {
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/SkynetLabs/skyd/skykey"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+const (
+	// maxSkykeyBundleSize is the maximum size of a skykey bundle that is
+	// downloaded when restoring skykeys from a skylink.
+	maxSkykeyBundleSize = 1 << 22 // 4 MiB
+)
+
+type (
+	// SkykeysExportPOST is the response returned by the /skynet/skykeys/export
+	// endpoint.
+	SkykeysExportPOST struct {
+		Bundle []byte `json:"bundle"` // base64 encoded bundle
+	}
+
+	// SkykeysImportPOST is the response returned by the
+	// /skynet/skykeys/import and /skynet/skykeys/restore/:skylink endpoints.
+	SkykeysImportPOST struct {
+		Imported []SkykeyGET `json:"imported"`
+	}
+
+	// SkykeysBackupPOST is the response returned by the
+	// /skynet/skykeys/backup endpoint.
+	SkykeysBackupPOST struct {
+		Skylink string `json:"skylink"`
+	}
+)
+
+// skykeysImportResponse converts the imported skykeys into the response of
+// the import endpoints.
+func skykeysImportResponse(keys []skykey.Skykey) (SkykeysImportPOST, error) {
+	res := SkykeysImportPOST{
+		Imported: make([]SkykeyGET, 0, len(keys)),
+	}
+	for _, sk := range keys {
+		skStr, err := sk.ToString()
+		if err != nil {
+			return SkykeysImportPOST{}, err
+		}
+		res.Imported = append(res.Imported, SkykeyGET{
+			Skykey: skStr,
+			Name:   sk.Name,
+			ID:     sk.ID().ToString(),
+			Type:   sk.Type.ToString(),
+		})
+	}
+	return res, nil
+}
+
+// writeSkykeysImported writes the response of the import endpoints. Keys that
+// failed to import because of a name conflict result in an error even though
+// the other keys were imported.
+func writeSkykeysImported(w http.ResponseWriter, added []skykey.Skykey, importErr error) {
+	if importErr != nil {
+		WriteError(w, Error{fmt.Sprintf("failed to import %v: imported %v skykeys", importErr, len(added))}, http.StatusBadRequest)
+		return
+	}
+	res, err := skykeysImportResponse(added)
+	if err != nil {
+		WriteError(w, Error{"failed to encode skykey: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, res)
+}
+
+// skykeysExportHandlerPOST handles the POST calls to /skynet/skykeys/export
+// which return a password protected bundle of the renter's skykeys.
+func (api *API) skykeysExportHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	bundle, err := api.renter.ExportSkykeys(req.FormValue("password"))
+	if err != nil {
+		WriteError(w, Error{"failed to export skykeys: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, SkykeysExportPOST{
+		Bundle: bundle,
+	})
+}
+
+// skykeysImportHandlerPOST handles the POST calls to /skynet/skykeys/import
+// which import the skykeys of a password protected bundle.
+func (api *API) skykeysImportHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	bundle, err := base64.StdEncoding.DecodeString(req.FormValue("bundle"))
+	if err != nil {
+		WriteError(w, Error{"failed to decode bundle: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	added, err := api.renter.ImportSkykeys(bundle, req.FormValue("password"))
+	writeSkykeysImported(w, added, err)
+}
+
+// skykeysBackupHandlerPOST handles the POST calls to /skynet/skykeys/backup
+// which upload a password protected bundle of the renter's skykeys.
+func (api *API) skykeysBackupHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	skylink, err := api.renter.CreateSkykeyBackup(req.Context(), req.FormValue("password"))
+	if err != nil {
+		handleSkynetError(w, "failed to create skykey backup", err)
+		return
+	}
+	WriteJSON(w, SkykeysBackupPOST{
+		Skylink: skylink.String(),
+	})
+}
+
+// skykeysRestoreHandlerPOST handles the POST calls to
+// /skynet/skykeys/restore/:skylink which import the skykeys of a backup
+// created by /skynet/skykeys/backup or the automatic skykey backup.
+func (api *API) skykeysRestoreHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse the skylink from the raw URL of the request.
+	skylink, _, _, err := parseSkylinkURL(req.Host, req.URL.String(), "/skynet/skykeys/restore/")
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("error parsing skylink: %v", err)}, http.StatusBadRequest)
+		return
+	}
+
+	// Parse the query params.
+	queryForm, err := url.ParseQuery(req.URL.RawQuery)
+	if err != nil {
+		WriteError(w, Error{"failed to parse query params"}, http.StatusBadRequest)
+		return
+	}
+
+	// Parse the timeout.
+	timeout, err := parseTimeout(queryForm)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Parse pricePerMS.
+	pricePerMS := DefaultSkynetPricePerMS
+	pricePerMSStr := queryForm.Get("priceperms")
+	if pricePerMSStr != "" {
+		_, err = fmt.Sscan(pricePerMSStr, &pricePerMS)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'pricePerMS' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Fetch the backup.
+	streamer, _, err := api.renter.DownloadSkylink(skylink, timeout, pricePerMS, skymodules.OverdriveSettings{}, nil)
+	if err != nil {
+		handleSkynetError(w, "failed to fetch skykey backup", err)
+		return
+	}
+	defer func() {
+		_ = streamer.Close()
+	}()
+	bundle, err := ioutil.ReadAll(io.LimitReader(streamer, maxSkykeyBundleSize+1))
+	if err != nil {
+		WriteError(w, Error{"failed to read skykey backup: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	if len(bundle) > maxSkykeyBundleSize {
+		WriteError(w, Error{"skykey backup exceeds the maximum size"}, http.StatusBadRequest)
+		return
+	}
+
+	// Import it.
+	added, err := api.renter.ImportSkykeys(bundle, req.FormValue("password"))
+	writeSkykeysImported(w, added, err)
+}
+
+// skykeysAutoBackupHandlerGET handles the GET calls to
+// /skynet/skykeys/autobackup.
+func (api *API) skykeysAutoBackupHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	status, err := api.renter.SkykeyAutoBackupStatus()
+	if err != nil {
+		WriteError(w, Error{"failed to get skykey backup status: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, status)
+}
+
+// skykeysAutoBackupHandlerPOST handles the POST calls to
+// /skynet/skykeys/autobackup which enable or disable the automatic skykey
+// backup.
+func (api *API) skykeysAutoBackupHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	status, err := api.renter.SetSkykeyAutoBackup(req.Context(), req.FormValue("password"), req.FormValue("entryname"))
+	if err != nil {
+		handleSkynetError(w, "failed to set skykey backup", err)
+		return
+	}
+	WriteJSON(w, status)
+}
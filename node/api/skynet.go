@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/SkynetLabs/skyd/build"
 	"gitlab.com/SkynetLabs/skyd/skykey"
 	"gitlab.com/SkynetLabs/skyd/skymodules"
@@ -28,6 +29,10 @@ import (
 )
 
 const (
+	// ArchiveContentTypePAXRecord is the PAX record which holds the content
+	// type of a file within a tar archive.
+	ArchiveContentTypePAXRecord = "SKYNET.contenttype"
+
 	// DefaultSkynetRequestTimeout is the default request timeout for routes
 	// that have a timeout query string parameter. If the request can not be
 	// resolved within the given amount of time, it times out. This is used for
@@ -35,12 +40,28 @@ const (
 	// does not finish in due time.
 	DefaultSkynetRequestTimeout = 30 * time.Second
 
+	// DefaultSkynetSignedURLValidity is the default duration for which a
+	// signed skylink URL is valid.
+	DefaultSkynetSignedURLValidity = 24 * time.Hour
+
 	// MaxSkynetRequestTimeout is the maximum a user is allowed to set as
 	// request timeout. This to prevent an attack vector where the attacker
 	// could cause a go-routine leak by creating a bunch of requests with very
 	// high timeouts.
 	MaxSkynetRequestTimeout = 15 * time.Minute
 
+	// SkynetBandwidthDownHeader is the trailer which holds the number of
+	// bytes that were received from hosts to serve a download if requested.
+	SkynetBandwidthDownHeader = "Skynet-Bandwidth-Down"
+
+	// SkynetBandwidthUpHeader is the trailer which holds the number of bytes
+	// that were sent to hosts to serve a download if requested.
+	SkynetBandwidthUpHeader = "Skynet-Bandwidth-Up"
+
+	// SkynetContentHashHeader is the trailer which holds the hash of the
+	// served content if requested.
+	SkynetContentHashHeader = "Skynet-Content-Hash"
+
 	// SkynetDisableForceHeader allows disabling the force-update feature.
 	SkynetDisableForceHeader = "Skynet-Disable-Force"
 
@@ -52,10 +73,35 @@ const (
 	// requested.
 	SkynetFileMetadataHeader = "Skynet-File-Metadata"
 
+	// SkynetHealthHeader holds the cached health of the served skylink if the
+	// skylink is pinned by the node. See CachedSkylinkHealth.
+	SkynetHealthHeader = "Skynet-Health"
+
+	// SkynetIncludeBandwidthHeader is the request header which enables the
+	// bandwidth trailers of a download.
+	SkynetIncludeBandwidthHeader = "Skynet-Include-Bandwidth"
+
+	// SkynetIncludeProvenanceHeader is the request header which enables the
+	// provenance trailer of a download.
+	SkynetIncludeProvenanceHeader = "Skynet-Include-Provenance"
+
 	// SkynetProofHeader holds an encoded JSON object with the registry proofs
 	// for this skylink.
 	SkynetProofHeader = "Skynet-Proof"
 
+	// SkynetProvenanceHeader is the trailer which holds an encoded JSON
+	// array with the hosts which served the pieces of the downloaded chunks
+	// if requested.
+	SkynetProvenanceHeader = "Skynet-Provenance"
+
+	// SkynetSkyfileBLAKE3Header holds the hex encoded BLAKE3 hash of the
+	// skyfile's content if it was computed during the upload.
+	SkynetSkyfileBLAKE3Header = "Skynet-Skyfile-Blake3"
+
+	// SkynetSkyfileSHA256Header holds the hex encoded SHA-256 hash of the
+	// skyfile's content if it was computed during the upload.
+	SkynetSkyfileSHA256Header = "Skynet-Skyfile-Sha256"
+
 	// SkynetSkylinkHeader is a string representation of the base64 encoded
 	// v1 Skylink that was served.
 	SkynetSkylinkHeader = "Skynet-Skylink"
@@ -63,6 +109,10 @@ const (
 	// SkynetRequestedSkylinkHeader is a string representation of the base64 encoded
 	// Skylink that was requested.
 	SkynetRequestedSkylinkHeader = "Skynet-Requested-Skylink"
+
+	// memoryLimitRetryAfter is the time clients are asked to wait before
+	// retrying a request that was rejected due to the soft memory limit.
+	memoryLimitRetryAfter = 5 * time.Second
 )
 
 var (
@@ -70,6 +120,15 @@ var (
 	// is able to spend on faster workers when downloading a Skyfile. By default
 	// this is a sane default of 100 nS.
 	DefaultSkynetPricePerMS = types.SiacoinPrecision.MulFloat(1e-7) // 100 nS
+
+	// MaxSkylinkDependencyDepth is the maximum depth of dependencies which
+	// can be pinned together with a skylink.
+	MaxSkylinkDependencyDepth = uint64(5)
+
+	// skynetRequestAdmissionMemory is the estimated amount of memory a skynet
+	// download or upload needs upfront. Requests are only admitted if that
+	// amount fits into the renter's soft memory limit.
+	skynetRequestAdmissionMemory = modules.SectorSize
 )
 
 type (
@@ -87,6 +146,13 @@ type (
 		Bitfield   uint16      `json:"bitfield"`
 	}
 
+	// SkynetPinPOST is the response that the api returns after the
+	// /skynet/pin POST endpoint has been used with a dependency depth.
+	SkynetPinPOST struct {
+		Skylink      string                         `json:"skylink"`
+		Dependencies []skymodules.SkylinkDependency `json:"dependencies"`
+	}
+
 	// SkynetBlocklistGET contains the information queried for the
 	// /skynet/blocklist GET endpoint
 	//
@@ -96,6 +162,11 @@ type (
 	SkynetBlocklistGET struct {
 		Blacklist []crypto.Hash `json:"blacklist"` // Deprecated, kept for backwards compatibility
 		Blocklist []crypto.Hash `json:"blocklist"`
+
+		// Reasons maps the hashes of the blocklist to the reasons why they
+		// were blocked if known, e.g. when the upload scanner rejected a
+		// skyfile.
+		Reasons map[string]string `json:"reasons"`
 	}
 
 	// SkynetBlocklistPOST contains the information needed for the
@@ -109,6 +180,23 @@ type (
 		IsHash bool `json:"ishash"`
 	}
 
+	// SkynetBlocklistCheckRequestPOST contains the candidates which are
+	// checked by the /skynet/blocklist/check POST endpoint.
+	SkynetBlocklistCheckRequestPOST struct {
+		Check []string `json:"check"`
+
+		// IsHash indicates if the supplied strings are already hashes of
+		// Skylinks
+		IsHash bool `json:"ishash"`
+	}
+
+	// SkynetBlocklistCheckPOST is the response of the /skynet/blocklist/check
+	// POST endpoint. It maps the checked candidates to whether they are
+	// blocked.
+	SkynetBlocklistCheckPOST struct {
+		Blocked map[string]bool `json:"blocked"`
+	}
+
 	// SkynetPortalsGET contains the information queried for the /skynet/portals
 	// GET endpoint.
 	SkynetPortalsGET struct {
@@ -122,6 +210,15 @@ type (
 		Remove []modules.NetAddress      `json:"remove"`
 	}
 
+	// SkynetSignPOST is the response that the api returns after the
+	// /skynet/sign POST endpoint has been used.
+	SkynetSignPOST struct {
+		Skylink   string `json:"skylink"`
+		Expires   int64  `json:"expires"`
+		Signature string `json:"signature"`
+		URL       string `json:"url"`
+	}
+
 	// SkynetRestorePOST is the response that the api returns after the
 	// /skynet/restore POST endpoint has been used.
 	SkynetRestorePOST struct {
@@ -137,6 +234,14 @@ type (
 		BaseSectorUpload15mP999ms     float64 `json:"basesectorupload15mp999ms"`
 		BaseSectorUpload15mP9999ms    float64 `json:"basesectorupload15mp9999ms"`
 
+		// Base Sector Download Stats
+		BaseSectorDownload15mDataPoints float64 `json:"basesectordownload15mdatapoints"`
+		BaseSectorDownload15mP99ms      float64 `json:"basesectordownload15mp99ms"`
+		BaseSectorDownload15mP999ms     float64 `json:"basesectordownload15mp999ms"`
+		BaseSectorDownload15mP9999ms    float64 `json:"basesectordownload15mp9999ms"`
+		BaseSectorHedges                uint64  `json:"basesectorhedges"`
+		BaseSectorHedgesWon             uint64  `json:"basesectorhedgeswon"`
+
 		// Sector Download Stats
 		BaseSectorOverdrivePct   float64 `json:"basesectoroverdrivepct"`
 		BaseSectorOverdriveAvg   float64 `json:"basesectoroverdriveavg"`
@@ -161,11 +266,18 @@ type (
 		RegistryWrite15mP999ms     float64 `json:"registrywrite15mp999ms"`
 		RegistryWrite15mP9999ms    float64 `json:"registrywrite15mp9999ms"`
 
+		// Registry cache stats.
+		RegistryCacheEntries       uint64 `json:"registrycacheentries"`
+		RegistryCacheHits          uint64 `json:"registrycachehits"`
+		RegistryCacheMisses        uint64 `json:"registrycachemisses"`
+		RegistryCacheInvalidations uint64 `json:"registrycacheinvalidations"`
+
 		// Stream Buffer Download Stats
 		StreamBufferRead15mDataPoints float64 `json:"streambufferread15mdatapoints"`
 		StreamBufferRead15mP99ms      float64 `json:"streambufferread15mp99ms"`
 		StreamBufferRead15mP999ms     float64 `json:"streambufferread15mp999ms"`
 		StreamBufferRead15mP9999ms    float64 `json:"streambufferread15mp9999ms"`
+		StreamBufferCancelledBytes    uint64  `json:"streambuffercancelledbytes"`
 
 		// The amount of computational time that it takes the health loop to
 		// scan the entire filesystem. Unit is given in hours.
@@ -216,6 +328,24 @@ type (
 		PublicKey types.SiaPublicKey        `json:"publickey"`
 		Signature string                    `json:"signature"`
 		Type      modules.RegistryEntryType `json:"type"`
+
+		// TrustedHostAgreement contains the responses of the trusted
+		// registry hosts if any are configured.
+		TrustedHostAgreement []skymodules.RegistryHostAgreement `json:"trustedhostagreement,omitempty"`
+	}
+
+	// RegistryHandlerPOST is the response returned by the /skynet/registry
+	// and /skynet/registrymulti [POST] endpoints.
+	RegistryHandlerPOST struct {
+		// Proof contains the entries accepted by the hosts which confirmed
+		// the update before the request returned.
+		Proof []RegistryHostProof `json:"proof"`
+	}
+
+	// RegistryHostProof is the proof that a host accepted a registry update.
+	RegistryHostProof struct {
+		RegistryHandlerGET
+		HostKey types.SiaPublicKey `json:"hostkey"`
 	}
 
 	// RegistryHandlerRequestPOST is the expected format of the json request for
@@ -237,9 +367,11 @@ type (
 	}
 
 	// SkylinkResolveGET is the response returned by the /skylink/resolve
-	// endpoint.
+	// endpoint. If a name was resolved, Name and Source are set.
 	SkylinkResolveGET struct {
 		Skylink string `json:"skylink"`
+		Name    string `json:"name,omitempty"`
+		Source  string `json:"source,omitempty"`
 	}
 
 	// archiveFunc is a function that serves subfiles from src to dst and
@@ -253,7 +385,7 @@ func (api *API) skynetBaseSectorHandlerGET(w http.ResponseWriter, req *http.Requ
 	// Parse the skylink from the raw URL of the request. Any special characters
 	// in the raw URL are encoded, allowing us to differentiate e.g. the '?'
 	// that begins query parameters from the encoded version '%3F'.
-	skylink, _, _, err := parseSkylinkURL(req.URL.String(), "/skynet/basesector/")
+	skylink, _, _, err := parseSkylinkURL(req.Host, req.URL.String(), "/skynet/basesector/")
 	if err != nil {
 		WriteError(w, Error{fmt.Sprintf("error parsing skylink: %v", err)}, http.StatusBadRequest)
 		return
@@ -284,8 +416,22 @@ func (api *API) skynetBaseSectorHandlerGET(w http.ResponseWriter, req *http.Requ
 		}
 	}
 
+	// Parse the overdrive settings.
+	overdrive, err := parseOverdriveSettings(queryForm)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Parse the signature of a signed URL.
+	access, err := parseSkylinkAccess(queryForm)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
 	// Fetch the skyfile's streamer to serve the basesector of the file
-	streamer, srvs, _, err := api.renter.DownloadSkylinkBaseSector(skylink, timeout, pricePerMS)
+	streamer, srvs, _, err := api.renter.DownloadSkylinkBaseSector(skylink, timeout, pricePerMS, overdrive, &access)
 	if err != nil {
 		handleSkynetError(w, "failed to fetch base sector", err)
 		return
@@ -318,8 +464,19 @@ func (api *API) skynetBlocklistHandlerGET(w http.ResponseWriter, _ *http.Request
 		return
 	}
 
+	reasons, err := api.renter.BlocklistReasons()
+	if err != nil {
+		WriteError(w, Error{"unable to get the blocklist reasons: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	reasonsByHash := make(map[string]string, len(reasons))
+	for hash, reason := range reasons {
+		reasonsByHash[hash.String()] = reason
+	}
+
 	WriteJSON(w, SkynetBlocklistGET{
 		Blocklist: blocklist,
+		Reasons:   reasonsByHash,
 	})
 }
 
@@ -367,6 +524,54 @@ func (api *API) skynetBlocklistHandlerPOST(w http.ResponseWriter, req *http.Requ
 	WriteSuccess(w)
 }
 
+// skynetBlocklistCheckHandlerPOST handles the API call to check whether
+// skylinks or hashes are blocked without adding them to the blocklist.
+func (api *API) skynetBlocklistCheckHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse the query params.
+	queryForm, err := url.ParseQuery(req.URL.RawQuery)
+	if err != nil {
+		WriteError(w, Error{"failed to parse query params"}, http.StatusBadRequest)
+		return
+	}
+
+	// Parse parameters
+	var params SkynetBlocklistCheckRequestPOST
+	err = json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if len(params.Check) == 0 {
+		WriteError(w, Error{"no skylinks submitted"}, http.StatusBadRequest)
+		return
+	}
+
+	// Parse the timeout.
+	timeout, err := parseTimeout(queryForm)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Generate context
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	defer cancel()
+
+	// Check the candidates.
+	blocked, err := api.renter.CheckSkynetBlocklist(ctx, params.Check, params.IsHash)
+	if err != nil {
+		WriteError(w, Error{"unable to check the skynet blocklist: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	resp := SkynetBlocklistCheckPOST{
+		Blocked: make(map[string]bool, len(blocked)),
+	}
+	for i, candidate := range params.Check {
+		resp.Blocked[candidate] = blocked[i]
+	}
+	WriteJSON(w, resp)
+}
+
 // skynetPortalsHandlerGET handles the API call to get the list of known skynet
 // portals.
 func (api *API) skynetPortalsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
@@ -473,8 +678,15 @@ func (api *API) skynetRootHandlerGET(w http.ResponseWriter, req *http.Request, _
 		}
 	}
 
+	// Parse the overdrive settings.
+	overdrive, err := parseOverdriveSettings(queryForm)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
 	// Fetch the skyfile's  streamer to serve the basesector of the file
-	sector, err := api.renter.DownloadByRoot(root, offset, length, timeout, pricePerMS)
+	sector, err := api.renter.DownloadByRoot(root, offset, length, timeout, pricePerMS, overdrive)
 	if err != nil {
 		handleSkynetError(w, "failed to fetch root", err)
 		return
@@ -504,8 +716,24 @@ func (api *API) skynetSkylinkHandlerGET(w http.ResponseWriter, req *http.Request
 	path := params.path
 	format := params.format
 
+	// Make sure the download fits into the soft memory limit.
+	if err := api.renter.AdmitRequest(skynetRequestAdmissionMemory); err != nil {
+		handleSkynetError(w, "failed to fetch skylink", err)
+		return
+	}
+
+	// HEAD requests and requests with the 'nofanout' flag only serve the
+	// headers. Those only require the skyfile's base sector.
+	headersOnly := req.Method == http.MethodHead || params.noFanout
+
 	// Fetch the skyfile's metadata and a streamer to download the file
-	streamer, srvs, err := api.renter.DownloadSkylink(params.skylink, params.timeout, params.pricePerMS)
+	var streamer skymodules.SkyfileStreamer
+	var srvs []skymodules.RegistryEntry
+	if headersOnly {
+		streamer, srvs, err = api.renter.DownloadSkylinkMetadata(params.skylink, params.timeout, params.pricePerMS, params.overdrive, &params.access)
+	} else {
+		streamer, srvs, err = api.renter.DownloadSkylink(params.skylink, params.timeout, params.pricePerMS, params.overdrive, &params.access)
+	}
 	if err != nil {
 		handleSkynetError(w, "failed to fetch skylink", err)
 		return
@@ -517,7 +745,12 @@ func (api *API) skynetSkylinkHandlerGET(w http.ResponseWriter, req *http.Request
 	}()
 
 	metadata := streamer.Metadata()
-	ew := newCustomErrorWriter(metadata, streamer)
+	errorMetadata := metadata
+	if headersOnly && streamer.Layout().FanoutSize > 0 {
+		// Custom error pages can't be served without the fanout.
+		errorMetadata.ErrorPages = nil
+	}
+	ew := newCustomErrorWriter(errorMetadata, streamer, api.staticContentTypeOverrides)
 
 	// Attach proof.
 	err = attachRegistryEntryProof(w, srvs)
@@ -530,9 +763,12 @@ func (api *API) skynetSkylinkHandlerGET(w http.ResponseWriter, req *http.Request
 	// this way the file can still be downloaded should it have been uploaded
 	// with incorrect metadata, which is possible seeing as it may have been
 	// uploaded by a private portal.
+	skyfileMetadata := metadata
+	var servesDefaultPath bool
 	if format == skymodules.SkyfileFormatNotSpecified {
 		// The path we actually want to serve based on defaultpath and tryfiles.
 		servePath := metadata.ServePath(path)
+		servesDefaultPath = path == "/" && servePath != path
 		isMulti := len(metadata.Subfiles) > 1
 		// If we don't have a subPath and the skylink doesn't end with a
 		// trailing slash we need to redirect in order to add the trailing
@@ -592,6 +828,7 @@ func (api *API) skynetSkylinkHandlerGET(w http.ResponseWriter, req *http.Request
 	}
 	w.Header().Set(SkynetSkylinkHeader, streamer.Skylink().String())
 	w.Header().Set(SkynetRequestedSkylinkHeader, params.skylink.String())
+	api.setSkynetHealthHeader(w, streamer.Skylink())
 
 	// Set the ETag response header
 	//
@@ -620,21 +857,108 @@ func (api *API) skynetSkylinkHandlerGET(w http.ResponseWriter, req *http.Request
 	}
 	w.Header().Set("Content-Disposition", cdh)
 
+	// Set the custom response headers of the skyfile.
+	for name, value := range metadata.AllowedHeaders() {
+		w.Header().Set(name, value)
+	}
+
+	// Set the content hashes of the skyfile. They are only available if the
+	// path covers the skyfile's whole content.
+	if metadata.Hashes != nil && !format.IsArchive() {
+		w.Header().Set(SkynetSkyfileBLAKE3Header, metadata.Hashes.BLAKE3)
+		w.Header().Set(SkynetSkyfileSHA256Header, metadata.Hashes.SHA256)
+	}
+
+	// Hint the client at the stylesheets and scripts of the skyfile when
+	// serving its HTML default path.
+	if api.staticSkynetEarlyHints != "" && servesDefaultPath && isSubfile && !params.attachment {
+		contentType := api.staticContentTypeOverrides.MetadataContentType(metadata)
+		writeSkynetEarlyHints(w, req, api.staticSkynetEarlyHints, skyfileMetadata, path, contentType, api.staticContentTypeOverrides)
+	}
+
+	// Serve only the headers if the content isn't requested.
+	if headersOnly {
+		err = serveSkyfileHeaders(w, req, streamer, metadata, format, api.staticContentTypeOverrides)
+		if err != nil {
+			ew.WriteError(w, Error{"failed to serve skyfile headers: " + err.Error()}, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Prepare the verification of the content if requested. Only the full
+	// skyfile can be verified.
+	var verifier *skymodules.SkyfileVerifier
+	if params.verify && req.Method == http.MethodGet {
+		if path != "/" || format != skymodules.SkyfileFormatNotSpecified || req.Header.Get("Range") != "" {
+			ew.WriteError(w, Error{"'verify' is only supported when downloading the full skyfile"}, http.StatusBadRequest)
+			return
+		}
+		// The access was already checked when fetching the streamer.
+		baseSector, _, _, err := api.renter.DownloadSkylinkBaseSector(streamer.Skylink(), params.timeout, params.pricePerMS, params.overdrive, nil)
+		if err != nil {
+			handleSkynetError(w, "failed to fetch base sector for verification", err)
+			return
+		}
+		baseSectorBytes, err := ioutil.ReadAll(baseSector)
+		_ = baseSector.Close()
+		if err != nil {
+			ew.WriteError(w, Error{"failed to read base sector for verification: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		verifier, err = skymodules.NewSkyfileVerifier(baseSectorBytes)
+		if err != nil {
+			ew.WriteError(w, Error{"failed to prepare verification: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Wrap the writer to compute the content hash and verify the content.
+	var cw *skynetContentWriter
+	if req.Method == http.MethodGet && (params.hash != "" || verifier != nil) {
+		cw, err = newSkynetContentWriter(w, params.hash, verifier)
+		if err != nil {
+			ew.WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+		w = cw
+		defer func() {
+			if err := cw.Finish(); err != nil {
+				// The response was already partially sent, abort it to
+				// make sure the client notices the failure.
+				panic(http.ErrAbortHandler)
+			}
+		}()
+	}
+
+	// Wrap the writer to attach the provenance of the served chunks.
+	if req.Method == http.MethodGet && params.includeProvenance {
+		pw := newSkynetProvenanceWriter(w, streamer)
+		w = pw
+		defer pw.Finish()
+	}
+
+	// Wrap the writer to attach the bandwidth used to serve the download.
+	if req.Method == http.MethodGet && params.includeBandwidth {
+		bw := newSkynetBandwidthWriter(w, streamer)
+		w = bw
+		defer bw.Finish()
+	}
+
 	// If requested, serve the content as a tar archive, compressed tar
 	// archive or zip archive.
 	if format.IsArchive() {
-		err = serveArchive(w, streamer, format, metadata)
+		err = serveArchive(w, req, streamer, format, metadata, api.staticContentTypeOverrides)
 		if err != nil {
 			ew.WriteError(w, Error{fmt.Sprintf("failed to serve skyfile as %v archive: %v", format, err)}, http.StatusInternalServerError)
 		}
 		return
 	}
 
-	// Only set the Content-Type header when the metadata defines one, if we
-	// were to set the header to an empty string, it would prevent the http
-	// library from sniffing the file's content type.
-	if metadata.ContentType() != "" {
-		w.Header().Set("Content-Type", metadata.ContentType())
+	// Only set the Content-Type header when the metadata or the overrides
+	// define one, if we were to set the header to an empty string, it would
+	// prevent the http library from sniffing the file's content type.
+	if contentType := api.staticContentTypeOverrides.MetadataContentType(metadata); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
 	}
 	http.ServeContent(w, req, metadata.Filename, time.Time{}, streamer)
 }
@@ -738,6 +1062,19 @@ func (api *API) skynetSkylinkPinHandlerPOST(w http.ResponseWriter, req *http.Req
 		}
 	}
 
+	// Check whether the dependencies should be pinned as well.
+	var depth uint64
+	if dStr := queryForm.Get("dependencydepth"); dStr != "" {
+		if _, err := fmt.Sscan(dStr, &depth); err != nil {
+			WriteError(w, Error{"unable to parse dependencydepth: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if depth > MaxSkylinkDependencyDepth {
+			WriteError(w, Error{fmt.Sprintf("dependencydepth can't be greater than %v", MaxSkylinkDependencyDepth)}, http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Create the upload parameters. Notably, the fanout redundancy, the file
 	// metadata and the filename are not included. Changing those would change
 	// the skylink, which is not the goal.
@@ -747,13 +1084,87 @@ func (api *API) skynetSkylinkPinHandlerPOST(w http.ResponseWriter, req *http.Req
 		BaseChunkRedundancy: redundancy,
 	}
 
-	err = api.renter.PinSkylink(skylink, lup, timeout, pricePerMS)
+	// Enforce the quota of the API token the request authenticated with and
+	// the quota of the top-level skynet folder the skylink is pinned to. The
+	// folder quota only counts the size of the skylink itself while the
+	// dependencies are added to the token's usage as they are pinned.
+	token, hasToken := apiTokenFromContext(req.Context())
+	folderLimit, folderLimitErr, err := api.managedSkynetFolderUploadLimit(siaPath, -1)
+	if err != nil {
+		handleSkynetError(w, "pin rejected", err)
+		return
+	}
+	var size uint64
+	if hasToken || folderLimitErr != nil {
+		streamer, _, err := api.renter.DownloadSkylink(skylink, timeout, pricePerMS, skymodules.OverdriveSettings{}, &skymodules.SkylinkAccess{})
+		if err != nil {
+			handleSkynetError(w, "failed to fetch skylink metadata", err)
+			return
+		}
+		size = streamer.Metadata().Length
+		if err := streamer.Close(); err != nil {
+			WriteError(w, Error{"failed to close streamer: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		if size > folderLimit {
+			handleSkynetError(w, "pin rejected", folderLimitErr)
+			return
+		}
+	}
+	var reservation *apiTokenReservation
+	if hasToken {
+		_, _, err = api.managedAPITokenUploadLimit(token.ID, int64(size))
+		if err != nil {
+			handleSkynetError(w, "pin rejected", err)
+			return
+		}
+		reservation = api.newAPITokenReservation(token.ID)
+		defer reservation.release()
+		err = reservation.reserve(size)
+		if err != nil {
+			handleSkynetError(w, "pin rejected", err)
+			return
+		}
+	}
+
+	// Without a depth only the skylink itself is pinned.
+	if depth == 0 {
+		err = api.renter.PinSkylink(skylink, lup, timeout, pricePerMS)
+		if err != nil {
+			handleSkynetError(w, "failed to pin file to skynet", err)
+			return
+		}
+		if reservation != nil {
+			err = reservation.commit(skylink, size)
+			if err != nil {
+				handleSkynetError(w, "failed to update api token usage", err)
+				return
+			}
+		}
+		w.Header().Set(SkynetSkylinkHeader, skylink.String())
+		WriteSuccess(w)
+		return
+	}
+
+	// The dependencies are added to the token's usage by the renter as they
+	// are pinned.
+	deps, err := api.renter.PinSkylinkWithDependencies(skylink, lup, timeout, pricePerMS, depth, token.ID)
 	if err != nil {
 		handleSkynetError(w, "failed to pin file to skynet", err)
 		return
 	}
+	if reservation != nil {
+		err = reservation.commit(skylink, size)
+		if err != nil {
+			handleSkynetError(w, "failed to update api token usage", err)
+			return
+		}
+	}
 	w.Header().Set(SkynetSkylinkHeader, skylink.String())
-	WriteSuccess(w)
+	WriteJSON(w, SkynetPinPOST{
+		Skylink:      skylink.String(),
+		Dependencies: deps,
+	})
 }
 
 // skynetTUSUploadSkylinkGET is the handler for the /skynet/tus/skylink/:id
@@ -791,7 +1202,12 @@ func (api *API) skynetTUSUploadSkylinkGET(w http.ResponseWriter, _ *http.Request
 // returns a skylink.
 func (api *API) skynetSkyfileHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	// parse the request headers and parameters
-	headers, params, err := parseUploadHeadersAndRequestParameters(req, ps)
+	limits := api.renter.SkyfileUploadLimits()
+	headers, params, err := parseUploadHeadersAndRequestParameters(req, ps, limits)
+	if errors.Contains(err, skymodules.ErrSkyfileUploadTooLarge) {
+		WriteError(w, Error{err.Error()}, http.StatusRequestEntityTooLarge)
+		return
+	}
 	if err != nil {
 		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
 		return
@@ -799,10 +1215,16 @@ func (api *API) skynetSkyfileHandlerPOST(w http.ResponseWriter, req *http.Reques
 
 	// build the upload parameters
 	sup := skymodules.SkyfileUploadParameters{
+		AllowedHosts:        params.allowedHosts,
+		Archive:             params.archive,
 		BaseChunkRedundancy: params.baseChunkRedundancy,
+		ContentHashes:       params.contentHashes,
 		DryRun:              params.dryRun,
 		Force:               params.force,
+		Profile:             params.profile,
+		SessionID:           params.sessionID,
 		SiaPath:             params.siaPath,
+		TTL:                 params.ttl,
 
 		// Set filename and mode
 		Filename: params.filename,
@@ -816,13 +1238,31 @@ func (api *API) skynetSkyfileHandlerPOST(w http.ResponseWriter, req *http.Reques
 		SkykeyName: params.skyKeyName,
 		SkykeyID:   params.skyKeyID,
 
-		TryFiles:   params.tryFiles,
-		ErrorPages: params.errorPages,
+		TryFiles:      params.tryFiles,
+		ErrorPages:    params.errorPages,
+		ExtraMetadata: params.extraMetadata,
+		Headers:       params.headers,
+	}
+
+	// make sure the upload fits into the soft memory limit
+	if err := api.renter.AdmitRequest(skynetRequestAdmissionMemory); err != nil {
+		handleSkynetError(w, "upload rejected", err)
+		return
 	}
 
 	// set the reader
 	var reader skymodules.SkyfileUploadReader
-	if isMultipartRequest(headers.mediaType) {
+	if params.extract {
+		er, err := skymodules.NewSkyfileExtractReader(req.Body, sup)
+		if err != nil {
+			WriteError(w, Error{fmt.Sprintf("unable to extract archive: %v", err)}, http.StatusBadRequest)
+			return
+		}
+		defer func() {
+			_ = er.Close()
+		}()
+		reader = er
+	} else if isMultipartRequest(headers.mediaType) {
 		reader, err = skymodules.NewSkyfileMultipartReaderFromRequest(req, sup)
 	} else {
 		reader = skymodules.NewSkyfileReader(req.Body, sup)
@@ -832,15 +1272,89 @@ func (api *API) skynetSkyfileHandlerPOST(w http.ResponseWriter, req *http.Reques
 		return
 	}
 
+	// Enforce the upload limits of the portal.
+	ulr := skymodules.NewSkyfileUploadLimitReader(reader, limits)
+	reader = ulr
+
+	// Enforce the quota of the API token the request authenticated with. The
+	// size of multipart uploads and extracted archives is unknown upfront.
+	size := req.ContentLength
+	if isMultipartRequest(headers.mediaType) || params.extract {
+		size = -1
+	}
+	token, hasToken := apiTokenFromContext(req.Context())
+	var qr *quotaReader
+	if hasToken && params.convertPath == "" {
+		limit, limitErr, err := api.managedAPITokenUploadLimit(token.ID, size)
+		if err != nil {
+			handleSkynetError(w, "upload rejected", err)
+			return
+		}
+		reservation := api.newAPITokenReservation(token.ID)
+		defer reservation.release()
+		if size >= 0 {
+			err = reservation.reserve(uint64(size))
+			if err != nil {
+				handleSkynetError(w, "upload rejected", err)
+				return
+			}
+		}
+		qr = &quotaReader{
+			SkyfileUploadReader: reader,
+			limit:               limit,
+			limitErr:            limitErr,
+			reservation:         reservation,
+		}
+		reader = qr
+	}
+
+	// Enforce the quota of the top-level skynet folder the skyfile is stored
+	// in.
+	var fqr *quotaReader
+	if params.convertPath == "" && !sup.DryRun {
+		limit, limitErr, err := api.managedSkynetFolderUploadLimit(sup.SiaPath, size)
+		if err != nil {
+			handleSkynetError(w, "upload rejected", err)
+			return
+		}
+		if limitErr != nil {
+			fqr = &quotaReader{
+				SkyfileUploadReader: reader,
+				limit:               limit,
+				limitErr:            limitErr,
+			}
+			reader = fqr
+		}
+	}
+
 	// Check whether this is a streaming upload or a siafile conversion. If no
 	// convert path is provided, assume that the req.Body will be used as a
 	// streaming upload.
 	if params.convertPath == "" {
 		skylink, err := api.renter.UploadSkyfile(req.Context(), sup, reader)
+		if ulr.Err() != nil {
+			handleSkynetError(w, "failed to upload file to skynet", ulr.Err())
+			return
+		}
+		if qr != nil && qr.err != nil {
+			handleSkynetError(w, "failed to upload file to skynet", qr.err)
+			return
+		}
+		if fqr != nil && fqr.err != nil {
+			handleSkynetError(w, "failed to upload file to skynet", fqr.err)
+			return
+		}
 		if err != nil {
 			handleSkynetError(w, "failed to upload file to skynet", err)
 			return
 		}
+		if qr != nil && !sup.DryRun {
+			err = qr.reservation.commit(skylink, qr.n)
+			if err != nil {
+				handleSkynetError(w, "failed to update api token usage", err)
+				return
+			}
+		}
 
 		// Determine whether the file is large or not, and update the
 		// appropriate bucket.
@@ -1028,6 +1542,13 @@ func (api *API) skynetStatsHandlerGET(w http.ResponseWriter, _ *http.Request, _
 		BaseSectorUpload15mP999ms:     float64(renterPerf.BaseSectorUploadStats.Nines[0][2]) / float64(time.Millisecond),
 		BaseSectorUpload15mP9999ms:    float64(renterPerf.BaseSectorUploadStats.Nines[0][3]) / float64(time.Millisecond),
 
+		BaseSectorDownload15mDataPoints: renterPerf.BaseSectorDownloadStats.DataPoints[0],
+		BaseSectorDownload15mP99ms:      float64(renterPerf.BaseSectorDownloadStats.Nines[0][1]) / float64(time.Millisecond),
+		BaseSectorDownload15mP999ms:     float64(renterPerf.BaseSectorDownloadStats.Nines[0][2]) / float64(time.Millisecond),
+		BaseSectorDownload15mP9999ms:    float64(renterPerf.BaseSectorDownloadStats.Nines[0][3]) / float64(time.Millisecond),
+		BaseSectorHedges:                renterPerf.BaseSectorHedges,
+		BaseSectorHedgesWon:             renterPerf.BaseSectorHedgesWon,
+
 		BaseSectorOverdrivePct:   baseSectorStats.OverdrivePct(),
 		BaseSectorOverdriveAvg:   baseSectorStats.NumOverdriveWorkersAvg(),
 		FanoutSectorOverdrivePct: fanoutSectorStats.OverdrivePct(),
@@ -1048,10 +1569,16 @@ func (api *API) skynetStatsHandlerGET(w http.ResponseWriter, _ *http.Request, _
 		RegistryWrite15mP999ms:     float64(renterPerf.RegistryWriteStats.Nines[0][2]) / float64(time.Millisecond),
 		RegistryWrite15mP9999ms:    float64(renterPerf.RegistryWriteStats.Nines[0][3]) / float64(time.Millisecond),
 
+		RegistryCacheEntries:       renterPerf.RegistryCacheStats.Entries,
+		RegistryCacheHits:          renterPerf.RegistryCacheStats.Hits,
+		RegistryCacheMisses:        renterPerf.RegistryCacheStats.Misses,
+		RegistryCacheInvalidations: renterPerf.RegistryCacheStats.Invalidations,
+
 		StreamBufferRead15mDataPoints: renterPerf.StreamBufferReadStats.DataPoints[0],
 		StreamBufferRead15mP99ms:      float64(renterPerf.StreamBufferReadStats.Nines[0][1]) / float64(time.Millisecond),
 		StreamBufferRead15mP999ms:     float64(renterPerf.StreamBufferReadStats.Nines[0][2]) / float64(time.Millisecond),
 		StreamBufferRead15mP9999ms:    float64(renterPerf.StreamBufferReadStats.Nines[0][3]) / float64(time.Millisecond),
+		StreamBufferCancelledBytes:    renterPerf.StreamBufferCancelledBytes,
 
 		SystemHealthScanDurationHours: float64(renterPerf.SystemHealthScanDuration) / float64(time.Hour),
 
@@ -1285,12 +1812,12 @@ func (api *API) registryHandlerPOST(w http.ResponseWriter, req *http.Request, _
 
 	// Update the registry.
 	srv := modules.NewSignedRegistryValue(rhp.DataKey, rhp.Data, rhp.Revision, rhp.Signature, rhp.Type)
-	err = api.renter.UpdateRegistry(ctx, rhp.PublicKey, srv)
+	proofs, err := api.renter.UpdateRegistry(ctx, rhp.PublicKey, srv)
 	if err != nil {
 		handleSkynetError(w, "Unable to update the registry", err)
 		return
 	}
-	WriteSuccess(w)
+	WriteJSON(w, newRegistryHandlerPOST(proofs))
 }
 
 // registryMultiHandlerPOST handles the POST calls to /skynet/registrymulti.
@@ -1335,12 +1862,12 @@ func (api *API) registryMultiHandlerPOST(w http.ResponseWriter, req *http.Reques
 	defer cancel()
 
 	// Update the registry.
-	err = api.renter.UpdateRegistryMulti(ctx, srvs)
+	proofs, err := api.renter.UpdateRegistryMulti(ctx, srvs)
 	if err != nil {
 		handleSkynetError(w, "Unable to update the registry", err)
 		return
 	}
-	WriteSuccess(w)
+	WriteJSON(w, newRegistryHandlerPOST(proofs))
 }
 
 // registryHandlerGET handles the GET calls to /skynet/registry.
@@ -1384,14 +1911,7 @@ func (api *API) registryHandlerGET(w http.ResponseWriter, req *http.Request, _ h
 	}
 
 	// Send response.
-	WriteJSON(w, RegistryHandlerGET{
-		Data:      hex.EncodeToString(srv.Data),
-		DataKey:   srv.Tweak,
-		Revision:  srv.Revision,
-		PublicKey: srv.PubKey,
-		Signature: hex.EncodeToString(srv.Signature[:]),
-		Type:      srv.Type,
-	})
+	WriteJSON(w, newRegistryHandlerGET(srv))
 }
 
 // registryEntryHealthHandlerGET is the handler for the /skynet/registry/health
@@ -1458,7 +1978,9 @@ func (api *API) registryEntryHealthHandlerGET(w http.ResponseWriter, req *http.R
 	WriteJSON(w, reh)
 }
 
-// skylinkResolveGET handles the GET calls to /skylink/resolve/:skylink.
+// skylinkResolveGET handles the GET calls to /skylink/resolve/:skylink. If
+// the parameter is not a skylink, it is resolved as a human-readable name
+// first.
 func (api *API) skylinkResolveGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	// Parse the query params.
 	queryForm, err := url.ParseQuery(req.URL.RawQuery)
@@ -1467,30 +1989,52 @@ func (api *API) skylinkResolveGET(w http.ResponseWriter, req *http.Request, ps h
 		return
 	}
 
-	// Parse Skylink
-	var sl skymodules.Skylink
-	err = sl.LoadString(ps.ByName("skylink"))
+	// Parse the timeout.
+	timeout, err := parseTimeout(queryForm)
 	if err != nil {
-		WriteError(w, Error{"Unable to parse skylink" + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
 		return
 	}
-	if !sl.IsSkylinkV2() {
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	defer cancel()
+
+	// Parse Skylink
+	var sl skymodules.Skylink
+	var resolution skymodules.SkynetNameResolution
+	param := ps.ByName("skylink")
+	err = sl.LoadString(param)
+	if err != nil {
+		// Check if the parameter is a name instead.
+		if _, _, nameErr := skymodules.ParseSkynetName(param); nameErr != nil {
+			WriteError(w, Error{"Unable to parse skylink" + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		resolution, err = api.renter.ResolveSkynetName(ctx, param)
+		if err != nil {
+			handleSkynetError(w, "Failed to resolve name", err)
+			return
+		}
+		sl = resolution.Skylink
+	} else if !sl.IsSkylinkV2() {
 		if err != nil {
 			WriteError(w, Error{"Can only resolve v2 skylinks" + err.Error()}, http.StatusBadRequest)
 			return
 		}
 	}
 
-	// Parse the timeout.
-	timeout, err := parseTimeout(queryForm)
-	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+	// A name might point to a V1 skylink which doesn't need to be resolved
+	// any further.
+	if resolution.Name != "" && sl.IsSkylinkV1() {
+		w.Header().Set(SkynetSkylinkHeader, sl.String())
+		WriteJSON(w, SkylinkResolveGET{
+			Skylink: sl.String(),
+			Name:    resolution.Name,
+			Source:  resolution.Source,
+		})
 		return
 	}
 
 	// Resolve skylink.
-	ctx, cancel := context.WithTimeout(req.Context(), timeout)
-	defer cancel()
 	slV1, srv, err := api.renter.ResolveSkylinkV2(ctx, sl)
 	if err != nil {
 		handleSkynetError(w, "Failed to resolve skylink", err)
@@ -1518,6 +2062,8 @@ func (api *API) skylinkResolveGET(w http.ResponseWriter, req *http.Request, ps h
 	// Send response.
 	WriteJSON(w, SkylinkResolveGET{
 		Skylink: slV1.String(),
+		Name:    resolution.Name,
+		Source:  resolution.Source,
 	})
 }
 
@@ -1540,7 +2086,7 @@ func (api *API) skynetMetadataHandlerGET(w http.ResponseWriter, req *http.Reques
 	// Parse the skylink from the raw URL of the request. Any special characters
 	// in the raw URL are encoded, allowing us to differentiate e.g. the '?'
 	// that begins query parameters from the encoded version '%3F'.
-	skylink, _, _, err := parseSkylinkURL(req.URL.String(), "/skynet/metadata/")
+	skylink, _, _, err := parseSkylinkURL(req.Host, req.URL.String(), "/skynet/metadata/")
 	if err != nil {
 		WriteError(w, Error{fmt.Sprintf("error parsing skylink: %v", err)}, http.StatusBadRequest)
 		return
@@ -1571,8 +2117,15 @@ func (api *API) skynetMetadataHandlerGET(w http.ResponseWriter, req *http.Reques
 		}
 	}
 
+	// Parse the signature of a signed URL.
+	access, err := parseSkylinkAccess(queryForm)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
 	// Fetch the skyfile's streamer to serve the basesector of the file
-	streamer, srvs, resolvedLink, err := api.renter.DownloadSkylinkBaseSector(skylink, timeout, pricePerMS)
+	streamer, srvs, resolvedLink, err := api.renter.DownloadSkylinkBaseSector(skylink, timeout, pricePerMS, skymodules.OverdriveSettings{}, &access)
 	if err != nil {
 		handleSkynetError(w, "failed to fetch base sector", err)
 		return
@@ -1589,6 +2142,7 @@ func (api *API) skynetMetadataHandlerGET(w http.ResponseWriter, req *http.Reques
 	}
 	w.Header().Set(SkynetSkylinkHeader, resolvedLink.String())
 	w.Header().Set(SkynetRequestedSkylinkHeader, skylink.String())
+	api.setSkynetHealthHeader(w, resolvedLink)
 
 	// Attach proof.
 	err = attachRegistryEntryProof(w, srvs)
@@ -1660,6 +2214,112 @@ func (api *API) skynetSkylinkHealthGET(w http.ResponseWriter, req *http.Request,
 	WriteJSON(w, sh)
 }
 
+// skynetSkylinkVerifyHandlerGET is the handler for the /skynet/verify/:skylink
+// endpoint. It reports which of the contracted hosts store the pieces of every
+// chunk of the skyfile.
+func (api *API) skynetSkylinkVerifyHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	strLink := ps.ByName("skylink")
+	var skylink skymodules.Skylink
+	err := skylink.LoadString(strLink)
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("error parsing skylink: %v", err)}, http.StatusBadRequest)
+		return
+	}
+
+	// Parse the query params.
+	queryForm, err := url.ParseQuery(req.URL.RawQuery)
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("failed to parse query params: %v", err)}, http.StatusBadRequest)
+		return
+	}
+
+	// Parse timeout.
+	timeout, err := parseTimeout(queryForm)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	defer cancel()
+
+	// Verify the skylink.
+	sv, err := api.renter.VerifySkylink(ctx, skylink, DefaultSkynetPricePerMS)
+	if err != nil {
+		handleSkynetError(w, "failed to verify skylink", err)
+		return
+	}
+	WriteJSON(w, sv)
+}
+
+// skynetSignHandlerPOST handles the API call to create a signed URL for a
+// skylink. Signing a skylink restricts it to signed URLs.
+func (api *API) skynetSignHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	strLink := ps.ByName("skylink")
+	var skylink skymodules.Skylink
+	err := skylink.LoadString(strLink)
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("error parsing skylink: %v", err)}, http.StatusBadRequest)
+		return
+	}
+
+	// Parse the query params.
+	queryForm, err := url.ParseQuery(req.URL.RawQuery)
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("failed to parse query params: %v", err)}, http.StatusBadRequest)
+		return
+	}
+
+	// If the unrestrict param is set, the restriction is lifted instead.
+	if unrestrictStr := queryForm.Get("unrestrict"); unrestrictStr != "" {
+		unrestrict, err := strconv.ParseBool(unrestrictStr)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'unrestrict' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if unrestrict {
+			err = api.renter.UnrestrictSkylink(skylink)
+			if err != nil {
+				WriteError(w, Error{"failed to unrestrict skylink: " + err.Error()}, http.StatusInternalServerError)
+				return
+			}
+			WriteSuccess(w)
+			return
+		}
+	}
+
+	// Parse the validity of the signed URL.
+	validity := DefaultSkynetSignedURLValidity
+	if validityStr := queryForm.Get("validity"); validityStr != "" {
+		validitySecs, err := strconv.ParseUint(validityStr, 10, 32)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'validity' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if validitySecs == 0 {
+			WriteError(w, Error{"'validity' parameter must be greater than zero"}, http.StatusBadRequest)
+			return
+		}
+		validity = time.Duration(validitySecs) * time.Second
+	}
+
+	// Sign the skylink.
+	expires := time.Now().Add(validity).Truncate(time.Second)
+	signature, err := api.renter.SignSkylink(skylink, expires)
+	if err != nil {
+		WriteError(w, Error{"failed to sign skylink: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	values := url.Values{}
+	values.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	values.Set("signature", hex.EncodeToString(signature))
+	WriteJSON(w, SkynetSignPOST{
+		Skylink:   skylink.String(),
+		Expires:   expires.Unix(),
+		Signature: hex.EncodeToString(signature),
+		URL:       fmt.Sprintf("/skynet/skylink/%s?%s", skylink.String(), values.Encode()),
+	})
+}
+
 // skynetSkylinkUnpinHandlerPOST will unpin a skylink from this Sia node.
 func (api *API) skynetSkylinkUnpinHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	strLink := ps.ByName("skylink")
@@ -1730,3 +2390,26 @@ func (api *API) skynetHostsForRegistryUpdateGET(w http.ResponseWriter, req *http
 		Hosts: hosts,
 	})
 }
+
+// skynetRegistryTrustedHostsHandlerGET handles the GET calls to
+// /skynet/registry/trustedhosts.
+func (api *API) skynetRegistryTrustedHostsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, api.renter.TrustedRegistryHosts())
+}
+
+// skynetRegistryTrustedHostsHandlerPOST handles the POST calls to
+// /skynet/registry/trustedhosts which replace the trusted registry hosts.
+func (api *API) skynetRegistryTrustedHostsHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var settings skymodules.TrustedRegistryHosts
+	err := json.NewDecoder(req.Body).Decode(&settings)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = api.renter.SetTrustedRegistryHosts(settings)
+	if err != nil {
+		WriteError(w, Error{"unable to set trusted registry hosts: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
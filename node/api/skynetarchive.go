@@ -0,0 +1,555 @@
+package api
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/binary"
+	"hash"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+// The tar and zip archives of skyfiles are deterministic. Their layout only
+// depends on the metadata of the archived files, which allows for computing
+// the offset of every header and every file within the archive without
+// reading the files. An archiveReader uses that layout to serve any range of
+// an archive by only reading the files which overlap with the range.
+//
+// The layout consists of segments. A segment is either a static part of the
+// archive like a tar header, the content of a file or a part of the archive
+// which depends on the checksums of the files like the central directory of a
+// zip. Files within zip archives are stored uncompressed and the checksums
+// are recorded in data descriptors after the files. The checksums are
+// computed while the files are read and files which are skipped are only read
+// if their checksums are needed.
+
+const (
+	// tarBlockSize is the size of the blocks a tar archive is made of.
+	tarBlockSize = 512
+
+	// zip format constants.
+	zipLocalHeaderSignature    = 0x04034b50
+	zipCentralHeaderSignature  = 0x02014b50
+	zipDataDescriptorSignature = 0x08074b50
+	zipEndSignature            = 0x06054b50
+	zip64EndSignature          = 0x06064b50
+	zip64EndLocatorSignature   = 0x07064b50
+	zipExtTimeExtraID          = 0x5455
+	zip64ExtraID               = 0x0001
+	zipFlagDataDescriptor      = 0x8
+	zipFlagUTF8                = 0x800
+	zipMethodStore             = 0
+	zipVersion20               = 20
+	zipVersion45               = 45
+	zipLocalHeaderLen          = 30
+	zipCentralHeaderLen        = 46
+	zipEndLen                  = 22
+	zip64EndLen                = 56
+	zip64EndLocatorLen         = 20
+	zipDataDescriptorLen       = 16
+	zip64DataDescriptorLen     = 24
+	zipExtTimeExtraLen         = 9
+	zipUint16Max               = 1<<16 - 1
+	zipUint32Max               = 1<<32 - 1
+)
+
+var (
+	// errArchiveSourceNotSeekable is returned if an archive reader needs to
+	// read a part of a file which was already read from a source which can't
+	// seek.
+	errArchiveSourceNotSeekable = errors.New("archive source can't seek backwards")
+
+	// errArchiveSeekNegative is returned when seeking to a negative offset.
+	errArchiveSeekNegative = errors.New("can't seek to a negative offset")
+)
+
+type (
+	// archiveReader is an io.ReadSeeker which reads the files from src and
+	// serves them as a tar or zip archive.
+	archiveReader struct {
+		files    []skymodules.SkyfileSubfileMetadata
+		segments []archiveSegment
+		size     int64
+
+		// crcs are the checksums of the files. They are only computed for
+		// zip archives.
+		crcs []archiveFileCRC
+
+		// offset is the offset of the reader within the archive and
+		// srcOffset the offset within src.
+		offset    int64
+		src       io.Reader
+		srcOffset int64
+	}
+
+	// archiveSegment is a part of an archive.
+	archiveSegment struct {
+		offset int64
+		size   int64
+
+		// file is the index of the file if the segment is the content of a
+		// file and -1 otherwise.
+		file int
+
+		// data is the static content of the segment. If gen is set, data
+		// is generated when the segment is read for the first time.
+		data []byte
+		gen  func() ([]byte, error)
+	}
+
+	// archiveFileCRC is the checksum of the first n bytes of a file.
+	archiveFileCRC struct {
+		hash hash.Hash32
+		n    int64
+	}
+)
+
+// newArchiveReader creates a reader which serves the given files as an
+// archive of the given format. Only tar and zip archives are supported. The
+// contents of the files are read from src at their offsets. If src is not an
+// io.Seeker, the archive needs to be read sequentially and the offsets of the
+// files need to be ascending.
+func newArchiveReader(src io.Reader, format skymodules.SkyfileFormat, files []skymodules.SkyfileSubfileMetadata) (*archiveReader, error) {
+	ar := &archiveReader{
+		files: files,
+		src:   src,
+	}
+	var err error
+	switch format {
+	case skymodules.SkyfileFormatTar:
+		err = ar.buildTarLayout()
+	case skymodules.SkyfileFormatZip:
+		err = ar.buildZipLayout()
+	default:
+		err = errors.New("archive format doesn't support random access")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ar, nil
+}
+
+// appendData appends a segment with static content to the layout.
+func (ar *archiveReader) appendData(data []byte) {
+	ar.appendSegment(archiveSegment{size: int64(len(data)), file: -1, data: data})
+}
+
+// appendSegment appends a segment to the layout. Empty segments are skipped.
+func (ar *archiveReader) appendSegment(seg archiveSegment) {
+	if seg.size == 0 {
+		return
+	}
+	seg.offset = ar.size
+	ar.segments = append(ar.segments, seg)
+	ar.size += seg.size
+}
+
+// buildTarLayout computes the layout of a tar archive. Files without a
+// modification time are archived with the unix epoch as their modification
+// time.
+func (ar *archiveReader) buildTarLayout() error {
+	for i, file := range ar.files {
+		// Create header.
+		header, err := tar.FileInfoHeader(file, file.Name())
+		if err != nil {
+			return err
+		}
+		// Modify name to match path within skyfile.
+		header.Name = file.Filename
+		// Record the content type.
+		if file.ContentType != "" {
+			header.PAXRecords = map[string]string{
+				ArchiveContentTypePAXRecord: file.ContentType,
+			}
+		}
+		// The writer writes the header right away, the content of the file
+		// is read from src.
+		var buf bytes.Buffer
+		if err := tar.NewWriter(&buf).WriteHeader(header); err != nil {
+			return err
+		}
+		ar.appendData(buf.Bytes())
+		ar.appendSegment(archiveSegment{size: int64(file.Len), file: i})
+		if padding := -int64(file.Len) & (tarBlockSize - 1); padding > 0 {
+			ar.appendData(make([]byte, padding))
+		}
+	}
+	// The archive ends with two empty blocks.
+	ar.appendData(make([]byte, 2*tarBlockSize))
+	return nil
+}
+
+// buildZipLayout computes the layout of a zip archive. Files are stored
+// without compression. Files larger than 4 GiB are stored using the zip64
+// extensions. Files without a modification time are archived with the
+// earliest time a zip can represent.
+func (ar *archiveReader) buildZipLayout() error {
+	ar.crcs = make([]archiveFileCRC, len(ar.files))
+	headerOffsets := make([]int64, len(ar.files))
+	for i, file := range ar.files {
+		ar.crcs[i].hash = crc32.NewIEEE()
+		headerOffsets[i] = ar.size
+
+		// The local header doesn't contain the checksum and the size of the
+		// file, they are recorded in the data descriptor after the file.
+		ar.appendData(zipLocalHeader(file))
+		ar.appendSegment(archiveSegment{size: int64(file.Len), file: i})
+
+		idx := i
+		ar.appendSegment(archiveSegment{
+			size: zipDataDescriptorSize(file),
+			file: -1,
+			gen: func() ([]byte, error) {
+				crc, err := ar.crc(idx)
+				if err != nil {
+					return nil, err
+				}
+				return zipDataDescriptor(ar.files[idx], crc), nil
+			},
+		})
+	}
+
+	// The central directory contains the checksums of all files.
+	cdOffset := ar.size
+	cdSize := int64(0)
+	for i, file := range ar.files {
+		cdSize += zipCentralHeaderSize(file, headerOffsets[i])
+	}
+	ar.appendSegment(archiveSegment{
+		size: cdSize,
+		file: -1,
+		gen: func() ([]byte, error) {
+			var buf bytes.Buffer
+			for i, file := range ar.files {
+				crc, err := ar.crc(i)
+				if err != nil {
+					return nil, err
+				}
+				buf.Write(zipCentralHeader(file, crc, headerOffsets[i]))
+			}
+			return buf.Bytes(), nil
+		},
+	})
+	ar.appendData(zipEnd(len(ar.files), cdOffset, cdSize))
+	return nil
+}
+
+// crc returns the checksum of the file with the given index. If the
+// file wasn't read completely yet, the rest of it is read from src.
+func (ar *archiveReader) crc(i int) (uint32, error) {
+	file := ar.files[i]
+	c := &ar.crcs[i]
+	size := int64(file.Len)
+	if c.n < size {
+		if err := ar.seekSrc(int64(file.Offset) + c.n); err != nil {
+			return 0, err
+		}
+		n, err := io.CopyN(c.hash, ar.src, size-c.n)
+		c.n += n
+		ar.srcOffset += n
+		if err != nil {
+			return 0, errors.AddContext(err, "failed to read file to compute checksum")
+		}
+	}
+	return c.hash.Sum32(), nil
+}
+
+// seekSrc moves src to the given offset.
+func (ar *archiveReader) seekSrc(offset int64) error {
+	if offset == ar.srcOffset {
+		return nil
+	}
+	if s, ok := ar.src.(io.Seeker); ok {
+		_, err := s.Seek(offset, io.SeekStart)
+		if err != nil {
+			return err
+		}
+		ar.srcOffset = offset
+		return nil
+	}
+	if offset < ar.srcOffset {
+		return errArchiveSourceNotSeekable
+	}
+	n, err := io.CopyN(ioutil.Discard, ar.src, offset-ar.srcOffset)
+	ar.srcOffset += n
+	return err
+}
+
+// readFile reads the content of the file with the given index starting at the
+// given offset within the file into p.
+func (ar *archiveReader) readFile(i int, offset int64, p []byte) (int, error) {
+	if err := ar.seekSrc(int64(ar.files[i].Offset) + offset); err != nil {
+		return 0, err
+	}
+	n, err := ar.src.Read(p)
+	ar.srcOffset += int64(n)
+	// Update the checksum if the data continues where it left off.
+	if ar.crcs != nil && ar.crcs[i].n == offset {
+		_, _ = ar.crcs[i].hash.Write(p[:n])
+		ar.crcs[i].n += int64(n)
+	}
+	if errors.Contains(err, io.EOF) {
+		if n > 0 {
+			return n, nil
+		}
+		return 0, io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// Read implements io.Reader.
+func (ar *archiveReader) Read(p []byte) (int, error) {
+	if ar.offset >= ar.size {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	// Find the segment at the current offset.
+	i := sort.Search(len(ar.segments), func(i int) bool {
+		return ar.segments[i].offset+ar.segments[i].size > ar.offset
+	})
+	seg := &ar.segments[i]
+	segOffset := ar.offset - seg.offset
+	if remaining := seg.size - segOffset; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+
+	var n int
+	var err error
+	if seg.file >= 0 {
+		n, err = ar.readFile(seg.file, segOffset, p)
+	} else {
+		if seg.data == nil && seg.gen != nil {
+			data, err := seg.gen()
+			if err != nil {
+				return 0, err
+			}
+			if int64(len(data)) != seg.size {
+				return 0, errors.New("generated archive segment has the wrong size")
+			}
+			seg.data = data
+		}
+		n = copy(p, seg.data[segOffset:])
+	}
+	ar.offset += int64(n)
+	return n, err
+}
+
+// Seek implements io.Seeker.
+func (ar *archiveReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += ar.offset
+	case io.SeekEnd:
+		offset += ar.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errArchiveSeekNegative
+	}
+	ar.offset = offset
+	return offset, nil
+}
+
+// Size returns the size of the archive.
+func (ar *archiveReader) Size() int64 {
+	return ar.size
+}
+
+// zipIsZip64 returns whether the file needs the zip64 extensions to record
+// its size.
+func zipIsZip64(file skymodules.SkyfileSubfileMetadata) bool {
+	return file.Len >= zipUint32Max
+}
+
+// zipModTime returns the modification time of a file within a zip archive.
+func zipModTime(file skymodules.SkyfileSubfileMetadata) time.Time {
+	modTime := file.ModTime()
+	if modTime.Before(zipEpoch) {
+		modTime = zipEpoch
+	}
+	return modTime
+}
+
+// zipMSDOSTime returns the MS-DOS date and time of the given time.
+func zipMSDOSTime(t time.Time) (date, tm uint16) {
+	date = uint16(t.Day() + int(t.Month())<<5 + (t.Year()-1980)<<9)
+	tm = uint16(t.Second()/2 + t.Minute()<<5 + t.Hour()<<11)
+	return
+}
+
+// zipExtTimeExtra returns the extended timestamp extra field which records
+// the modification time as a unix timestamp.
+func zipExtTimeExtra(modTime time.Time) []byte {
+	b := make([]byte, zipExtTimeExtraLen)
+	binary.LittleEndian.PutUint16(b[0:], zipExtTimeExtraID)
+	binary.LittleEndian.PutUint16(b[2:], zipExtTimeExtraLen-4)
+	b[4] = 1 // only the modification time is set
+	unix := modTime.Unix()
+	if unix > zipUint32Max {
+		unix = zipUint32Max
+	}
+	binary.LittleEndian.PutUint32(b[5:], uint32(unix))
+	return b
+}
+
+// zipVersionNeeded returns the zip version needed to extract the file.
+func zipVersionNeeded(zip64 bool) uint16 {
+	if zip64 {
+		return zipVersion45
+	}
+	return zipVersion20
+}
+
+// zipLocalHeader returns the local header of a file.
+func zipLocalHeader(file skymodules.SkyfileSubfileMetadata) []byte {
+	modTime := zipModTime(file)
+	date, tm := zipMSDOSTime(modTime)
+	extra := zipExtTimeExtra(modTime)
+	b := make([]byte, zipLocalHeaderLen, zipLocalHeaderLen+len(file.Filename)+len(extra))
+	binary.LittleEndian.PutUint32(b[0:], zipLocalHeaderSignature)
+	binary.LittleEndian.PutUint16(b[4:], zipVersionNeeded(zipIsZip64(file)))
+	binary.LittleEndian.PutUint16(b[6:], zipFlagDataDescriptor|zipFlagUTF8)
+	binary.LittleEndian.PutUint16(b[8:], zipMethodStore)
+	binary.LittleEndian.PutUint16(b[10:], tm)
+	binary.LittleEndian.PutUint16(b[12:], date)
+	// The checksum and the sizes are recorded in the data descriptor.
+	binary.LittleEndian.PutUint16(b[26:], uint16(len(file.Filename)))
+	binary.LittleEndian.PutUint16(b[28:], uint16(len(extra)))
+	b = append(b, file.Filename...)
+	return append(b, extra...)
+}
+
+// zipDataDescriptorSize returns the size of the data descriptor of a file.
+func zipDataDescriptorSize(file skymodules.SkyfileSubfileMetadata) int64 {
+	if zipIsZip64(file) {
+		return zip64DataDescriptorLen
+	}
+	return zipDataDescriptorLen
+}
+
+// zipDataDescriptor returns the data descriptor of a file.
+func zipDataDescriptor(file skymodules.SkyfileSubfileMetadata, crc uint32) []byte {
+	b := make([]byte, zipDataDescriptorSize(file))
+	binary.LittleEndian.PutUint32(b[0:], zipDataDescriptorSignature)
+	binary.LittleEndian.PutUint32(b[4:], crc)
+	if zipIsZip64(file) {
+		binary.LittleEndian.PutUint64(b[8:], file.Len)
+		binary.LittleEndian.PutUint64(b[16:], file.Len)
+	} else {
+		binary.LittleEndian.PutUint32(b[8:], uint32(file.Len))
+		binary.LittleEndian.PutUint32(b[12:], uint32(file.Len))
+	}
+	return b
+}
+
+// zipCentralExtra returns the extra fields of the central directory header of
+// a file.
+func zipCentralExtra(file skymodules.SkyfileSubfileMetadata, headerOffset int64) []byte {
+	var zip64Values []uint64
+	if zipIsZip64(file) {
+		zip64Values = append(zip64Values, file.Len, file.Len)
+	}
+	if headerOffset >= zipUint32Max {
+		zip64Values = append(zip64Values, uint64(headerOffset))
+	}
+	var extra []byte
+	if len(zip64Values) > 0 {
+		b := make([]byte, 4+8*len(zip64Values))
+		binary.LittleEndian.PutUint16(b[0:], zip64ExtraID)
+		binary.LittleEndian.PutUint16(b[2:], uint16(8*len(zip64Values)))
+		for i, v := range zip64Values {
+			binary.LittleEndian.PutUint64(b[4+8*i:], v)
+		}
+		extra = append(extra, b...)
+	}
+	return append(extra, zipExtTimeExtra(zipModTime(file))...)
+}
+
+// zipCentralHeaderSize returns the size of the central directory header of a
+// file.
+func zipCentralHeaderSize(file skymodules.SkyfileSubfileMetadata, headerOffset int64) int64 {
+	return int64(zipCentralHeaderLen + len(file.Filename) + len(zipCentralExtra(file, headerOffset)) + len(file.ContentType))
+}
+
+// zipCentralHeader returns the central directory header of a file. The
+// content type of the file is recorded in the file's comment.
+func zipCentralHeader(file skymodules.SkyfileSubfileMetadata, crc uint32, headerOffset int64) []byte {
+	modTime := zipModTime(file)
+	date, tm := zipMSDOSTime(modTime)
+	extra := zipCentralExtra(file, headerOffset)
+	zip64 := zipIsZip64(file) || headerOffset >= zipUint32Max
+	b := make([]byte, zipCentralHeaderLen, zipCentralHeaderLen+len(file.Filename)+len(extra)+len(file.ContentType))
+	binary.LittleEndian.PutUint32(b[0:], zipCentralHeaderSignature)
+	binary.LittleEndian.PutUint16(b[4:], zipVersion20)
+	binary.LittleEndian.PutUint16(b[6:], zipVersionNeeded(zip64))
+	binary.LittleEndian.PutUint16(b[8:], zipFlagDataDescriptor|zipFlagUTF8)
+	binary.LittleEndian.PutUint16(b[10:], zipMethodStore)
+	binary.LittleEndian.PutUint16(b[12:], tm)
+	binary.LittleEndian.PutUint16(b[14:], date)
+	binary.LittleEndian.PutUint32(b[16:], crc)
+	if zipIsZip64(file) {
+		binary.LittleEndian.PutUint32(b[20:], zipUint32Max)
+		binary.LittleEndian.PutUint32(b[24:], zipUint32Max)
+	} else {
+		binary.LittleEndian.PutUint32(b[20:], uint32(file.Len))
+		binary.LittleEndian.PutUint32(b[24:], uint32(file.Len))
+	}
+	binary.LittleEndian.PutUint16(b[28:], uint16(len(file.Filename)))
+	binary.LittleEndian.PutUint16(b[30:], uint16(len(extra)))
+	binary.LittleEndian.PutUint16(b[32:], uint16(len(file.ContentType)))
+	// The disk number and the file attributes are zero.
+	if headerOffset >= zipUint32Max {
+		binary.LittleEndian.PutUint32(b[42:], zipUint32Max)
+	} else {
+		binary.LittleEndian.PutUint32(b[42:], uint32(headerOffset))
+	}
+	b = append(b, file.Filename...)
+	b = append(b, extra...)
+	return append(b, file.ContentType...)
+}
+
+// zipEnd returns the end of central directory record. If the number of
+// records, the size or the offset of the central directory don't fit the
+// record, it is preceded by a zip64 end of central directory record and its
+// locator.
+func zipEnd(records int, cdOffset, cdSize int64) []byte {
+	var b []byte
+	if records >= zipUint16Max || cdSize >= zipUint32Max || cdOffset >= zipUint32Max {
+		end64 := make([]byte, zip64EndLen+zip64EndLocatorLen)
+		binary.LittleEndian.PutUint32(end64[0:], zip64EndSignature)
+		binary.LittleEndian.PutUint64(end64[4:], zip64EndLen-12)
+		binary.LittleEndian.PutUint16(end64[12:], zipVersion45)
+		binary.LittleEndian.PutUint16(end64[14:], zipVersion45)
+		binary.LittleEndian.PutUint64(end64[24:], uint64(records))
+		binary.LittleEndian.PutUint64(end64[32:], uint64(records))
+		binary.LittleEndian.PutUint64(end64[40:], uint64(cdSize))
+		binary.LittleEndian.PutUint64(end64[48:], uint64(cdOffset))
+
+		locator := end64[zip64EndLen:]
+		binary.LittleEndian.PutUint32(locator[0:], zip64EndLocatorSignature)
+		binary.LittleEndian.PutUint64(locator[8:], uint64(cdOffset+cdSize))
+		binary.LittleEndian.PutUint32(locator[16:], 1)
+		b = end64
+
+		records = zipUint16Max
+		cdSize = zipUint32Max
+		cdOffset = zipUint32Max
+	}
+	end := make([]byte, zipEndLen)
+	binary.LittleEndian.PutUint32(end[0:], zipEndSignature)
+	binary.LittleEndian.PutUint16(end[8:], uint16(records))
+	binary.LittleEndian.PutUint16(end[10:], uint16(records))
+	binary.LittleEndian.PutUint32(end[12:], uint32(cdSize))
+	binary.LittleEndian.PutUint32(end[16:], uint32(cdOffset))
+	return append(b, end...)
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+// TestArchiveReader verifies that an archiveReader serves valid archives and
+// that any range of an archive can be read without reading the whole archive.
+func TestArchiveReader(t *testing.T) {
+	t.Parallel()
+
+	// Create some files with a gap between them. The gap must not end up in
+	// the archive.
+	contents := [][]byte{fastrand.Bytes(1000), {}, fastrand.Bytes(1), fastrand.Bytes(5000)}
+	var data []byte
+	var files []skymodules.SkyfileSubfileMetadata
+	for i, c := range contents {
+		files = append(files, skymodules.SkyfileSubfileMetadata{
+			Filename:    fmt.Sprintf("dir/file%v", i),
+			ContentType: "application/octet-stream",
+			FileMode:    0644,
+			Offset:      uint64(len(data)),
+			Len:         uint64(len(c)),
+			Modified:    int64(1614834367 + i),
+		})
+		data = append(data, c...)
+		data = append(data, fastrand.Bytes(10)...)
+	}
+
+	for _, format := range []skymodules.SkyfileFormat{skymodules.SkyfileFormatTar, skymodules.SkyfileFormatZip} {
+		// Read the full archive.
+		ar, err := newArchiveReader(bytes.NewReader(data), format, files)
+		if err != nil {
+			t.Fatal(err)
+		}
+		archive, err := ioutil.ReadAll(ar)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if int64(len(archive)) != ar.Size() {
+			t.Fatal(format, "wrong size", len(archive), ar.Size())
+		}
+
+		// Check the contents of the archive.
+		var names []string
+		var archived [][]byte
+		if format == skymodules.SkyfileFormatZip {
+			zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, f := range zr.File {
+				r, err := f.Open()
+				if err != nil {
+					t.Fatal(err)
+				}
+				// Reading the whole file verifies the checksum.
+				b, err := ioutil.ReadAll(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				names = append(names, f.Name)
+				archived = append(archived, b)
+			}
+		} else {
+			tr := tar.NewReader(bytes.NewReader(archive))
+			for {
+				header, err := tr.Next()
+				if err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatal(err)
+				}
+				b, err := ioutil.ReadAll(tr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				names = append(names, header.Name)
+				archived = append(archived, b)
+			}
+		}
+		if len(names) != len(files) {
+			t.Fatal(format, "wrong number of files", len(names))
+		}
+		for i := range files {
+			if names[i] != files[i].Filename || !bytes.Equal(archived[i], contents[i]) {
+				t.Fatal(format, "wrong file", i, names[i])
+			}
+		}
+
+		// Read random ranges from new readers. They must match the full
+		// archive.
+		for i := 0; i < 100; i++ {
+			start := fastrand.Intn(len(archive))
+			end := start + fastrand.Intn(len(archive)-start) + 1
+			ar, err := newArchiveReader(bytes.NewReader(data), format, files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ar.Seek(int64(start), io.SeekStart); err != nil {
+				t.Fatal(err)
+			}
+			b := make([]byte, end-start)
+			if _, err := io.ReadFull(ar, b); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(b, archive[start:end]) {
+				t.Fatal(format, "wrong range", start, end)
+			}
+		}
+
+		// Reading an archive sequentially doesn't require seeking.
+		ar, err = newArchiveReader(io.MultiReader(bytes.NewReader(data)), format, files)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadAll(ar)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b, archive) {
+			t.Fatal(format, "sequential read doesn't match")
+		}
+
+		// Serve a range request.
+		md := skymodules.SkyfileMetadata{Filename: "dir", Subfiles: make(skymodules.SkyfileSubfiles)}
+		for _, file := range files {
+			md.Subfiles[file.Filename] = file
+		}
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Range", "bytes=100-")
+		w := httptest.NewRecorder()
+		if err := serveArchive(w, req, bytes.NewReader(data), format, md, nil); err != nil {
+			t.Fatal(err)
+		}
+		if w.Code != http.StatusPartialContent {
+			t.Fatal(format, "unexpected status", w.Code)
+		}
+		if !bytes.Equal(w.Body.Bytes(), archive[100:]) {
+			t.Fatal(format, "wrong partial content")
+		}
+	}
+
+	// Compressed archives don't support random access.
+	if _, err := newArchiveReader(bytes.NewReader(data), skymodules.SkyfileFormatTarGz, files); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/crypto"
+)
+
+type (
+	// SkynetFolderBackupPOST is the response that the api returns after the
+	// /skynet/folderbackup POST endpoint has been used.
+	SkynetFolderBackupPOST struct {
+		Skylink string `json:"skylink"`
+	}
+)
+
+// skynetBackupSecret derives the secret used to encrypt skynet folder backups
+// from the wallet's primary seed. The caller is responsible for wiping the
+// secret once it's no longer needed.
+func (api *API) skynetBackupSecret() (crypto.Hash, error) {
+	// Get the wallet seed.
+	ws, _, err := api.wallet.PrimarySeed()
+	if err != nil {
+		return crypto.Hash{}, err
+	}
+	// Derive the renter seed and wipe the memory once we are done using it.
+	rs := skymodules.DeriveRenterSeed(ws)
+	defer fastrand.Read(rs[:])
+	return crypto.HashAll(rs, skymodules.BackupKeySpecifier), nil
+}
+
+// skynetFolderBackupHandlerPOST handles the POST calls to /skynet/folderbackup
+// which upload an encrypted backup of the skynet folder.
+func (api *API) skynetFolderBackupHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	secret, err := api.skynetBackupSecret()
+	if err != nil {
+		WriteError(w, Error{"failed to get wallet's primary seed"}, http.StatusInternalServerError)
+		return
+	}
+	defer fastrand.Read(secret[:])
+
+	skylink, err := api.renter.CreateSkynetBackup(req.Context(), secret[:32])
+	if err != nil {
+		handleSkynetError(w, "failed to create skynet folder backup", err)
+		return
+	}
+	WriteJSON(w, SkynetFolderBackupPOST{
+		Skylink: skylink.String(),
+	})
+}
+
+// skynetFolderRestoreHandlerPOST handles the POST calls to
+// /skynet/folderrestore/:skylink which restore the skynet folder from a backup.
+func (api *API) skynetFolderRestoreHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse the skylink from the raw URL of the request.
+	skylink, _, _, err := parseSkylinkURL(req.Host, req.URL.String(), "/skynet/folderrestore/")
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("error parsing skylink: %v", err)}, http.StatusBadRequest)
+		return
+	}
+
+	// Parse the query params.
+	queryForm, err := url.ParseQuery(req.URL.RawQuery)
+	if err != nil {
+		WriteError(w, Error{"failed to parse query params"}, http.StatusBadRequest)
+		return
+	}
+
+	// Parse the timeout.
+	timeout, err := parseTimeout(queryForm)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Parse pricePerMS.
+	pricePerMS := DefaultSkynetPricePerMS
+	pricePerMSStr := queryForm.Get("priceperms")
+	if pricePerMSStr != "" {
+		_, err = fmt.Sscan(pricePerMSStr, &pricePerMS)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'pricePerMS' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	secret, err := api.skynetBackupSecret()
+	if err != nil {
+		WriteError(w, Error{"failed to get wallet's primary seed"}, http.StatusInternalServerError)
+		return
+	}
+	defer fastrand.Read(secret[:])
+
+	// Fetch the backup.
+	streamer, _, err := api.renter.DownloadSkylink(skylink, timeout, pricePerMS, skymodules.OverdriveSettings{}, nil)
+	if err != nil {
+		handleSkynetError(w, "failed to fetch skynet folder backup", err)
+		return
+	}
+	defer func() {
+		_ = streamer.Close()
+	}()
+
+	// Restore it.
+	if err := api.renter.RestoreSkynetBackup(streamer, secret[:32]); err != nil {
+		WriteError(w, Error{"failed to restore skynet folder backup: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
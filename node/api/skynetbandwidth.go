@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+// skynetBandwidthWriter wraps a http.ResponseWriter to attach the number of
+// bytes that were sent to and received from hosts to serve a download as
+// trailers of the response. Since the download is streamed, the bandwidth is
+// only known once the whole response was written.
+type skynetBandwidthWriter struct {
+	http.ResponseWriter
+
+	staticStreamer skymodules.SkyfileStreamer
+
+	wroteHeader bool
+}
+
+// newSkynetBandwidthWriter creates a new bandwidth writer and declares the
+// bandwidth trailers of the response.
+func newSkynetBandwidthWriter(w http.ResponseWriter, streamer skymodules.SkyfileStreamer) *skynetBandwidthWriter {
+	w.Header().Add("Trailer", SkynetBandwidthUpHeader)
+	w.Header().Add("Trailer", SkynetBandwidthDownHeader)
+	return &skynetBandwidthWriter{
+		ResponseWriter: w,
+		staticStreamer: streamer,
+	}
+}
+
+// WriteHeader implements http.ResponseWriter. The Content-Length header is
+// removed to force a chunked response which supports trailers.
+func (bw *skynetBandwidthWriter) WriteHeader(status int) {
+	if bw.wroteHeader {
+		return
+	}
+	bw.wroteHeader = true
+	bw.Header().Del("Content-Length")
+	bw.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter.
+func (bw *skynetBandwidthWriter) Write(b []byte) (int, error) {
+	if !bw.wroteHeader {
+		bw.WriteHeader(http.StatusOK)
+	}
+	return bw.ResponseWriter.Write(b)
+}
+
+// Finish sets the bandwidth trailers to the bandwidth that was used to fetch
+// the data which was read from the streamer.
+func (bw *skynetBandwidthWriter) Finish() {
+	up, down := bw.staticStreamer.Bandwidth()
+	bw.Header().Set(SkynetBandwidthUpHeader, strconv.FormatUint(up, 10))
+	bw.Header().Set(SkynetBandwidthDownHeader, strconv.FormatUint(down, 10))
+}
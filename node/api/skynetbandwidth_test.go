@@ -0,0 +1,55 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+// mockBandwidthStreamer is a SkyfileStreamer which only implements
+// Bandwidth.
+type mockBandwidthStreamer struct {
+	skymodules.SkyfileStreamer
+	up, down uint64
+}
+
+// Bandwidth implements the skymodules.SkyfileStreamer interface.
+func (m *mockBandwidthStreamer) Bandwidth() (uint64, uint64) {
+	return m.up, m.down
+}
+
+// TestSkynetBandwidthWriter is a unit test for the skynetBandwidthWriter.
+func TestSkynetBandwidthWriter(t *testing.T) {
+	t.Parallel()
+
+	streamer := &mockBandwidthStreamer{}
+	rec := httptest.NewRecorder()
+	bw := newSkynetBandwidthWriter(rec, streamer)
+	bw.Header().Set("Content-Length", "100")
+	data := fastrand.Bytes(100)
+	if _, err := bw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+
+	// The trailers should be declared and the content length removed.
+	trailers := rec.Header().Values("Trailer")
+	if len(trailers) != 2 || trailers[0] != SkynetBandwidthUpHeader || trailers[1] != SkynetBandwidthDownHeader {
+		t.Fatal("trailers not declared", trailers)
+	}
+	if rec.Header().Get("Content-Length") != "" {
+		t.Fatal("content length should have been removed")
+	}
+
+	// The bandwidth is read from the streamer when finishing.
+	streamer.up = 1 << 15
+	streamer.down = 1 << 22
+	bw.Finish()
+	if up := rec.Header().Get(SkynetBandwidthUpHeader); up != "32768" {
+		t.Fatal("unexpected upload bandwidth", up)
+	}
+	if down := rec.Header().Get(SkynetBandwidthDownHeader); down != "4194304" {
+		t.Fatal("unexpected download bandwidth", down)
+	}
+}
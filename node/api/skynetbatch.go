@@ -0,0 +1,442 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+const (
+	// SkynetBatchFormatMultipart is the batch download format which returns
+	// the downloads as the parts of a multipart/mixed response.
+	SkynetBatchFormatMultipart = "multipart"
+
+	// SkynetBatchFormatNDJSON is the batch download format which returns the
+	// downloads as newline delimited JSON objects. It is the default.
+	SkynetBatchFormatNDJSON = "ndjson"
+
+	// SkynetBatchErrorHeader is the header of a multipart batch download part
+	// which holds the reason why the download failed.
+	SkynetBatchErrorHeader = "Skynet-Batch-Error"
+
+	// SkynetBatchIndexHeader is the header of a multipart batch download part
+	// which holds the index of the download within the request.
+	SkynetBatchIndexHeader = "Skynet-Batch-Index"
+)
+
+var (
+	// skynetBatchDownloadMaxItems is the maximum number of downloads within
+	// a single batch.
+	skynetBatchDownloadMaxItems = 256
+
+	// skynetBatchDownloadMaxSize is the maximum number of bytes a batch
+	// download returns. The data of the downloads is buffered in memory
+	// before it is sent to preserve the order of the downloads. Downloads
+	// which would exceed the limit fail.
+	skynetBatchDownloadMaxSize = uint64(1 << 24) // 16 MiB
+
+	// skynetBatchDownloadParallelism is the number of skylinks of a batch
+	// which are downloaded concurrently.
+	skynetBatchDownloadParallelism = 16
+)
+
+var (
+	// errBatchSizeExceeded is returned for downloads which would exceed the
+	// maximum size of a batch download.
+	errBatchSizeExceeded = fmt.Errorf("batch download exceeds the maximum size of %v bytes", skynetBatchDownloadMaxSize)
+)
+
+type (
+	// SkynetBatchDownloadRequestPOST is the expected format of the json
+	// request for /skynet/batchdownload [POST].
+	SkynetBatchDownloadRequestPOST struct {
+		Downloads []SkynetBatchDownload `json:"downloads"`
+	}
+
+	// SkynetBatchDownload describes a single download of a batch. The path
+	// selects a subfile or directory of the skyfile the same way the path of
+	// a /skynet/skylink request does. Offset and length select a range of
+	// the served content. A length of 0 selects the content until its end.
+	// Expires and Signature are required for skylinks restricted to signed
+	// URLs.
+	SkynetBatchDownload struct {
+		Skylink   string `json:"skylink"`
+		Path      string `json:"path"`
+		Offset    uint64 `json:"offset"`
+		Length    uint64 `json:"length"`
+		Expires   int64  `json:"expires,omitempty"`
+		Signature string `json:"signature,omitempty"`
+	}
+
+	// SkynetBatchDownloadResult is the result of a single download of a
+	// batch. In the ndjson format each line holds one result.
+	SkynetBatchDownloadResult struct {
+		Index            int    `json:"index"`
+		Skylink          string `json:"skylink"`
+		RequestedSkylink string `json:"requestedskylink"`
+		Path             string `json:"path"`
+		ContentType      string `json:"contenttype"`
+		Offset           uint64 `json:"offset"`
+		Length           uint64 `json:"length"`
+		Size             uint64 `json:"size"`
+		Data             []byte `json:"data"`
+		Error            string `json:"error,omitempty"`
+	}
+
+	// skynetBatchDownload is a parsed download of a batch.
+	skynetBatchDownload struct {
+		skylink skymodules.Skylink
+		path    string
+		offset  uint64
+		length  uint64
+		access  skymodules.SkylinkAccess
+	}
+
+	// skynetBatchDownloader downloads the skylinks of a batch concurrently
+	// and makes the results available in the order of the request.
+	skynetBatchDownloader struct {
+		downloads []skynetBatchDownload
+		results   []SkynetBatchDownloadResult
+		done      []chan struct{}
+
+		// remaining is the number of bytes that can still be added to the
+		// batch's results.
+		remaining uint64
+		mu        sync.Mutex
+
+		staticAPI       *API
+		staticOverdrive skymodules.OverdriveSettings
+		staticTimeout   time.Duration
+	}
+)
+
+// parseBatchDownloads parses and validates the downloads of a batch download
+// request.
+func parseBatchDownloads(sbdr SkynetBatchDownloadRequestPOST) ([]skynetBatchDownload, error) {
+	if len(sbdr.Downloads) == 0 {
+		return nil, errors.New("no downloads were requested")
+	}
+	if len(sbdr.Downloads) > skynetBatchDownloadMaxItems {
+		return nil, fmt.Errorf("too many downloads, a batch may hold at most %v downloads", skynetBatchDownloadMaxItems)
+	}
+	downloads := make([]skynetBatchDownload, 0, len(sbdr.Downloads))
+	for i, d := range sbdr.Downloads {
+		var sl skymodules.Skylink
+		if err := sl.LoadString(d.Skylink); err != nil {
+			return nil, errors.AddContext(err, fmt.Sprintf("unable to parse skylink of download %v", i))
+		}
+		var access skymodules.SkylinkAccess
+		if d.Expires != 0 && d.Signature != "" {
+			signature, err := hex.DecodeString(d.Signature)
+			if err != nil {
+				return nil, errors.AddContext(err, fmt.Sprintf("unable to parse signature of download %v", i))
+			}
+			access = skymodules.SkylinkAccess{
+				Expires:   time.Unix(d.Expires, 0),
+				Signature: signature,
+			}
+		} else if d.Expires != 0 || d.Signature != "" {
+			return nil, errors.AddContext(errIncompleteSignedURL, fmt.Sprintf("download %v", i))
+		}
+		downloads = append(downloads, skynetBatchDownload{
+			skylink: sl,
+			path:    skymodules.EnsurePrefix(d.Path, "/"),
+			offset:  d.Offset,
+			length:  d.Length,
+			access:  access,
+		})
+	}
+	return downloads, nil
+}
+
+// newSkynetBatchDownloader creates a downloader for the given downloads.
+func (api *API) newSkynetBatchDownloader(downloads []skynetBatchDownload, timeout time.Duration, overdrive skymodules.OverdriveSettings) *skynetBatchDownloader {
+	bd := &skynetBatchDownloader{
+		downloads:       downloads,
+		results:         make([]SkynetBatchDownloadResult, len(downloads)),
+		done:            make([]chan struct{}, len(downloads)),
+		remaining:       skynetBatchDownloadMaxSize,
+		staticAPI:       api,
+		staticOverdrive: overdrive,
+		staticTimeout:   timeout,
+	}
+	for i, d := range downloads {
+		bd.done[i] = make(chan struct{})
+		bd.results[i] = SkynetBatchDownloadResult{
+			Index:            i,
+			RequestedSkylink: d.skylink.String(),
+			Path:             d.path,
+		}
+	}
+	return bd
+}
+
+// threadedDownload downloads all skylinks of the batch. Downloads of the same
+// skylink share a single streamer and thereby the worker sets of the
+// skylink's chunks. Different skylinks are downloaded concurrently.
+func (bd *skynetBatchDownloader) threadedDownload() {
+	// Group the downloads by skylink and the signature they present. The
+	// access is checked when fetching the skylink, so downloads with
+	// different signatures can't share the fetch.
+	var skylinks []string
+	bySkylink := make(map[string][]int)
+	for i, d := range bd.downloads {
+		sl := fmt.Sprintf("%v_%v_%x", d.skylink, d.access.Expires.Unix(), d.access.Signature)
+		if _, exists := bySkylink[sl]; !exists {
+			skylinks = append(skylinks, sl)
+		}
+		bySkylink[sl] = append(bySkylink[sl], i)
+	}
+
+	// Download the skylinks.
+	sem := make(chan struct{}, skynetBatchDownloadParallelism)
+	for _, sl := range skylinks {
+		sem <- struct{}{}
+		go func(indices []int) {
+			defer func() { <-sem }()
+			bd.managedDownloadSkylink(indices)
+		}(bySkylink[sl])
+	}
+}
+
+// managedDownloadSkylink performs the downloads with the given indices which
+// all share the same skylink and signature.
+func (bd *skynetBatchDownloader) managedDownloadSkylink(indices []int) {
+	defer func() {
+		for _, i := range indices {
+			close(bd.done[i])
+		}
+	}()
+
+	// Fetch the skylink.
+	api := bd.staticAPI
+	d := bd.downloads[indices[0]]
+	streamer, _, err := api.renter.DownloadSkylink(d.skylink, bd.staticTimeout, DefaultSkynetPricePerMS, bd.staticOverdrive, &d.access)
+	if err != nil {
+		for _, i := range indices {
+			bd.results[i].Error = errors.AddContext(err, "failed to fetch skylink").Error()
+		}
+		return
+	}
+	defer func() {
+		_ = streamer.Close()
+	}()
+
+	for _, i := range indices {
+		err := bd.managedDownload(streamer, i)
+		if err != nil {
+			bd.results[i].Error = err.Error()
+		}
+	}
+}
+
+// managedDownload performs the download with the given index using the
+// streamer of its skylink.
+func (bd *skynetBatchDownloader) managedDownload(streamer skymodules.SkyfileStreamer, i int) error {
+	d := bd.downloads[i]
+	res := &bd.results[i]
+	res.Skylink = streamer.Skylink().String()
+
+	// Determine the content to serve for the path.
+	metadata := streamer.Metadata()
+	path := metadata.ServePath(d.path)
+	var offset, size uint64
+	if path == "/" {
+		end, err := streamer.Seek(0, io.SeekEnd)
+		if err != nil {
+			return errors.AddContext(err, "failed to determine the size of the skyfile")
+		}
+		size = uint64(end)
+	} else {
+		var mdForPath skymodules.SkyfileMetadata
+		mdForPath, _, offset, size = metadata.ForPath(path)
+		if len(mdForPath.Subfiles) == 0 {
+			return fmt.Errorf("failed to download contents for path: %v", path)
+		}
+		metadata = mdForPath
+	}
+	res.Path = path
+	res.ContentType = bd.staticAPI.staticContentTypeOverrides.MetadataContentType(metadata)
+	res.Size = size
+
+	// Determine the range to serve.
+	if d.offset > size {
+		return fmt.Errorf("offset %v is out of bounds for content of size %v", d.offset, size)
+	}
+	length := size - d.offset
+	if d.length > 0 && d.length < length {
+		length = d.length
+	}
+	res.Offset = d.offset
+	res.Length = length
+
+	// Reserve the memory for the data.
+	bd.mu.Lock()
+	if length > bd.remaining {
+		bd.mu.Unlock()
+		return errBatchSizeExceeded
+	}
+	bd.remaining -= length
+	bd.mu.Unlock()
+
+	// Read the data.
+	_, err := streamer.Seek(int64(offset+d.offset), io.SeekStart)
+	if err != nil {
+		return errors.AddContext(err, "failed to seek to the requested offset")
+	}
+	data := make([]byte, length)
+	_, err = io.ReadFull(streamer, data)
+	if err != nil {
+		return errors.AddContext(err, "failed to read the requested range")
+	}
+	res.Data = data
+	return nil
+}
+
+// managedResult waits for the download with the given index to finish and
+// returns its result.
+func (bd *skynetBatchDownloader) managedResult(i int) SkynetBatchDownloadResult {
+	<-bd.done[i]
+	return bd.results[i]
+}
+
+// skynetBatchDownloadHandlerPOST handles the POST calls to
+// /skynet/batchdownload which download multiple skylinks with a single
+// request.
+func (api *API) skynetBatchDownloadHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse the query params.
+	queryForm, err := url.ParseQuery(req.URL.RawQuery)
+	if err != nil {
+		WriteError(w, Error{"failed to parse query params"}, http.StatusBadRequest)
+		return
+	}
+	format := queryForm.Get("format")
+	if format == "" {
+		format = SkynetBatchFormatNDJSON
+	}
+	if format != SkynetBatchFormatNDJSON && format != SkynetBatchFormatMultipart {
+		WriteError(w, Error{fmt.Sprintf("unsupported batch format '%v'", format)}, http.StatusBadRequest)
+		return
+	}
+	timeout, err := parseTimeout(queryForm)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	overdrive, err := parseOverdriveSettings(queryForm)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Decode request.
+	var sbdr SkynetBatchDownloadRequestPOST
+	err = json.NewDecoder(req.Body).Decode(&sbdr)
+	if err != nil {
+		WriteError(w, Error{"Failed to decode request: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	downloads, err := parseBatchDownloads(sbdr)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Make sure the download fits into the soft memory limit.
+	if err := api.renter.AdmitRequest(skynetRequestAdmissionMemory); err != nil {
+		handleSkynetError(w, "failed to start batch download", err)
+		return
+	}
+
+	// Start the downloads and write the results in order as they become
+	// available.
+	bd := api.newSkynetBatchDownloader(downloads, timeout, overdrive)
+	go bd.threadedDownload()
+	if format == SkynetBatchFormatMultipart {
+		err = writeBatchMultipart(w, bd)
+	} else {
+		err = writeBatchNDJSON(w, bd)
+	}
+	if err != nil {
+		// The response was already partially sent, abort it to make sure
+		// the client notices the failure.
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// writeBatchNDJSON writes the results of a batch download as newline delimited
+// JSON objects.
+func writeBatchNDJSON(w http.ResponseWriter, bd *skynetBatchDownloader) error {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	for i := range bd.downloads {
+		if err := enc.Encode(bd.managedResult(i)); err != nil {
+			return err
+		}
+		flushResponse(w)
+	}
+	return nil
+}
+
+// writeBatchMultipart writes the results of a batch download as the parts of
+// a multipart/mixed response.
+func writeBatchMultipart(w http.ResponseWriter, bd *skynetBatchDownloader) error {
+	mw := multipart.NewWriter(w)
+	w.Header().Set("Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+	for i := range bd.downloads {
+		res := bd.managedResult(i)
+		header := make(textproto.MIMEHeader)
+		header.Set(SkynetBatchIndexHeader, strconv.Itoa(res.Index))
+		header.Set(SkynetRequestedSkylinkHeader, res.RequestedSkylink)
+		if res.Skylink != "" {
+			header.Set(SkynetSkylinkHeader, res.Skylink)
+		}
+		if res.Error != "" {
+			header.Set(SkynetBatchErrorHeader, res.Error)
+		} else {
+			contentType := res.ContentType
+			if contentType == "" {
+				contentType = "application/octet-stream"
+			}
+			header.Set("Content-Type", contentType)
+			header.Set("Content-Range", batchContentRange(res))
+		}
+		pw, err := mw.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		if _, err := pw.Write(res.Data); err != nil {
+			return err
+		}
+		flushResponse(w)
+	}
+	return mw.Close()
+}
+
+// batchContentRange returns the Content-Range of a successful batch download.
+func batchContentRange(res SkynetBatchDownloadResult) string {
+	if res.Length == 0 {
+		return fmt.Sprintf("bytes */%v", res.Size)
+	}
+	return fmt.Sprintf("bytes %v-%v/%v", res.Offset, res.Offset+res.Length-1, res.Size)
+}
+
+// flushResponse flushes the data written to the response so far if the
+// response writer supports it.
+func flushResponse(w http.ResponseWriter) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+// TestParseBatchDownloads is a unit test for parseBatchDownloads.
+func TestParseBatchDownloads(t *testing.T) {
+	t.Parallel()
+
+	var sl skymodules.Skylink
+	err := sl.LoadString("AABEKWZ_wc2R9qlhYkzbG8mImFVi08kBu1nsvvwPLBtpEg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Valid batch.
+	downloads, err := parseBatchDownloads(SkynetBatchDownloadRequestPOST{
+		Downloads: []SkynetBatchDownload{
+			{Skylink: sl.String()},
+			{Skylink: sl.String(), Path: "dir/file", Offset: 1, Length: 2},
+			{Skylink: sl.String(), Expires: 1, Signature: "abcd"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(downloads) != 3 {
+		t.Fatal("wrong number of downloads", len(downloads))
+	}
+	if downloads[0].path != "/" || downloads[1].path != "/dir/file" {
+		t.Fatal("paths weren't prefixed", downloads[0].path, downloads[1].path)
+	}
+	if downloads[1].offset != 1 || downloads[1].length != 2 {
+		t.Fatal("wrong range", downloads[1].offset, downloads[1].length)
+	}
+	if downloads[2].access.Expires.Unix() != 1 || len(downloads[2].access.Signature) != 2 {
+		t.Fatal("wrong signature", downloads[2].access)
+	}
+
+	// Empty batch.
+	_, err = parseBatchDownloads(SkynetBatchDownloadRequestPOST{})
+	if err == nil {
+		t.Fatal("empty batch should fail")
+	}
+
+	// Too many downloads.
+	tooMany := make([]SkynetBatchDownload, skynetBatchDownloadMaxItems+1)
+	for i := range tooMany {
+		tooMany[i].Skylink = sl.String()
+	}
+	_, err = parseBatchDownloads(SkynetBatchDownloadRequestPOST{Downloads: tooMany})
+	if err == nil {
+		t.Fatal("batch with too many downloads should fail")
+	}
+
+	// Incomplete signature.
+	_, err = parseBatchDownloads(SkynetBatchDownloadRequestPOST{
+		Downloads: []SkynetBatchDownload{{Skylink: sl.String(), Expires: 1}},
+	})
+	if !errors.Contains(err, errIncompleteSignedURL) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// Invalid skylink.
+	_, err = parseBatchDownloads(SkynetBatchDownloadRequestPOST{
+		Downloads: []SkynetBatchDownload{{Skylink: "invalid"}},
+	})
+	if err == nil {
+		t.Fatal("invalid skylink should fail")
+	}
+}
+
+// TestBatchContentRange is a unit test for batchContentRange.
+func TestBatchContentRange(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		res      SkynetBatchDownloadResult
+		expected string
+	}{
+		{SkynetBatchDownloadResult{Offset: 0, Length: 10, Size: 10}, "bytes 0-9/10"},
+		{SkynetBatchDownloadResult{Offset: 5, Length: 1, Size: 10}, "bytes 5-5/10"},
+		{SkynetBatchDownloadResult{Offset: 10, Length: 0, Size: 10}, "bytes */10"},
+	}
+	for _, test := range tests {
+		if cr := batchContentRange(test.res); cr != test.expected {
+			t.Fatalf("expected %v but got %v", test.expected, cr)
+		}
+	}
+}
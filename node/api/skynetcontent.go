@@ -0,0 +1,140 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// SkynetContentHashBlake2b is the value of the 'hash' query string
+	// parameter to request a blake2b-256 hash of the served content.
+	SkynetContentHashBlake2b = "blake2b"
+
+	// SkynetContentHashSHA256 is the value of the 'hash' query string
+	// parameter to request a sha256 hash of the served content.
+	SkynetContentHashSHA256 = "sha256"
+)
+
+var (
+	// errUnknownContentHash is returned if an unknown hash algorithm is
+	// requested.
+	errUnknownContentHash = fmt.Errorf("unable to parse 'hash' parameter, allowed values are: '%v' and '%v'", SkynetContentHashBlake2b, SkynetContentHashSHA256)
+)
+
+// skynetContentWriter wraps a http.ResponseWriter to compute a hash of the
+// served content and to verify it against the skyfile's base sector. When
+// verifying, the last write is held back until the whole content was verified.
+type skynetContentWriter struct {
+	http.ResponseWriter
+
+	staticHashAlg  string
+	staticHasher   hash.Hash
+	staticVerifier *skymodules.SkyfileVerifier
+
+	pending   []byte
+	status    int
+	verifyErr error
+}
+
+// newContentHasher returns the hasher for the given algorithm.
+func newContentHasher(alg string) (hash.Hash, error) {
+	switch alg {
+	case SkynetContentHashBlake2b:
+		return blake2b.New256(nil)
+	case SkynetContentHashSHA256:
+		return sha256.New(), nil
+	default:
+		return nil, errUnknownContentHash
+	}
+}
+
+// newSkynetContentWriter creates a new content writer. If hashAlg is set, the
+// hash is declared as trailer of the response.
+func newSkynetContentWriter(w http.ResponseWriter, hashAlg string, verifier *skymodules.SkyfileVerifier) (*skynetContentWriter, error) {
+	cw := &skynetContentWriter{
+		ResponseWriter: w,
+		staticHashAlg:  hashAlg,
+		staticVerifier: verifier,
+	}
+	if hashAlg != "" {
+		hasher, err := newContentHasher(hashAlg)
+		if err != nil {
+			return nil, err
+		}
+		cw.staticHasher = hasher
+		w.Header().Add("Trailer", SkynetContentHashHeader)
+	}
+	return cw, nil
+}
+
+// WriteHeader implements http.ResponseWriter. If a hash is computed, the
+// Content-Length header is removed to force a chunked response which supports
+// trailers.
+func (cw *skynetContentWriter) WriteHeader(status int) {
+	if cw.status != 0 {
+		return
+	}
+	cw.status = status
+	if cw.staticHasher != nil {
+		cw.Header().Del("Content-Length")
+	}
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter.
+func (cw *skynetContentWriter) Write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.staticHasher != nil {
+		_, _ = cw.staticHasher.Write(b)
+	}
+	if cw.staticVerifier == nil {
+		return cw.ResponseWriter.Write(b)
+	}
+	// Verify the data and stop serving as soon as verification fails.
+	if _, err := cw.staticVerifier.Write(b); err != nil {
+		cw.verifyErr = err
+		return 0, err
+	}
+	// Send the previously held back data and hold back the new data.
+	if len(cw.pending) > 0 {
+		if _, err := cw.ResponseWriter.Write(cw.pending); err != nil {
+			return 0, err
+		}
+	}
+	cw.pending = append(cw.pending[:0], b...)
+	return len(b), nil
+}
+
+// Finish verifies the served content, sends the held back data and sets the
+// hash trailer. If verification fails, the held back data is not sent and an
+// error is returned.
+func (cw *skynetContentWriter) Finish() error {
+	if cw.staticVerifier != nil && cw.status == http.StatusOK {
+		err := cw.verifyErr
+		if err == nil {
+			err = cw.staticVerifier.Verify()
+		}
+		if err != nil {
+			return errors.AddContext(err, "failed to verify skyfile content")
+		}
+	}
+	if len(cw.pending) > 0 {
+		if _, err := cw.ResponseWriter.Write(cw.pending); err != nil {
+			return err
+		}
+		cw.pending = nil
+	}
+	if cw.staticHasher != nil {
+		cw.Header().Set(SkynetContentHashHeader, fmt.Sprintf("%v:%v", cw.staticHashAlg, hex.EncodeToString(cw.staticHasher.Sum(nil))))
+	}
+	return nil
+}
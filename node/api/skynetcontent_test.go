@@ -0,0 +1,114 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"golang.org/x/crypto/blake2b"
+)
+
+// TestSkynetContentWriter is a unit test for the skynetContentWriter.
+func TestSkynetContentWriter(t *testing.T) {
+	t.Parallel()
+
+	data := fastrand.Bytes(1000)
+
+	// Create a base sector for the data.
+	smBytes, err := skymodules.SkyfileMetadataBytes(skymodules.SkyfileMetadata{
+		Filename: "file",
+		Length:   uint64(len(data)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sl := skymodules.SkyfileLayout{
+		Version:      skymodules.SkyfileVersion,
+		Filesize:     uint64(len(data)),
+		MetadataSize: uint64(len(smBytes)),
+	}
+	baseSector, _ := skymodules.BuildBaseSector(sl.Encode(), nil, smBytes, data)
+
+	// serve is a helper that serves data in multiple writes.
+	serve := func(hashAlg string, verify bool, data []byte) (*httptest.ResponseRecorder, error) {
+		rec := httptest.NewRecorder()
+		var verifier *skymodules.SkyfileVerifier
+		if verify {
+			verifier, err = skymodules.NewSkyfileVerifier(baseSector)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		cw, err := newSkynetContentWriter(rec, hashAlg, verifier)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cw.Header().Set("Content-Length", "1000")
+		for i := 0; i < len(data); i += 100 {
+			if _, err := cw.Write(data[i : i+100]); err != nil {
+				return rec, err
+			}
+		}
+		return rec, cw.Finish()
+	}
+
+	// Hash the content with both algorithms.
+	b2b := blake2b.Sum256(data)
+	sha := sha256.Sum256(data)
+	for alg, expected := range map[string][]byte{
+		SkynetContentHashBlake2b: b2b[:],
+		SkynetContentHashSHA256:  sha[:],
+	} {
+		rec, err := serve(alg, false, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(rec.Body.Bytes(), data) {
+			t.Fatal("wrong data served")
+		}
+		if rec.Header().Get("Trailer") != SkynetContentHashHeader {
+			t.Fatal("trailer not declared")
+		}
+		if rec.Header().Get("Content-Length") != "" {
+			t.Fatal("content length should have been removed")
+		}
+		if hash := rec.Header().Get(SkynetContentHashHeader); hash != alg+":"+hex.EncodeToString(expected) {
+			t.Fatal("wrong hash", hash)
+		}
+	}
+
+	// Unknown algorithm.
+	_, err = newSkynetContentWriter(httptest.NewRecorder(), "md5", nil)
+	if !errors.Contains(err, errUnknownContentHash) {
+		t.Fatal("wrong error", err)
+	}
+
+	// Verify valid data.
+	rec, err := serve("", true, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(rec.Body.Bytes(), data) {
+		t.Fatal("wrong data served")
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatal("wrong status", rec.Code)
+	}
+
+	// Verify tampered data. The last write should be held back.
+	tampered := append([]byte{}, data...)
+	tampered[0]++
+	rec, err = serve("", true, tampered)
+	if !errors.Contains(err, skymodules.ErrSkyfileVerificationFailed) {
+		t.Fatal("wrong error", err)
+	}
+	if !bytes.Equal(rec.Body.Bytes(), tampered[:len(tampered)-100]) {
+		t.Fatal("held back data was served", rec.Body.Len())
+	}
+}
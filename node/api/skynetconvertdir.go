@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"gitlab.com/SkynetLabs/skyd/skymodules/renter/filesystem"
+)
+
+type (
+	// SkynetConvertDirPOST is the expected format of the json request for
+	// /skynet/convertdir [POST].
+	SkynetConvertDirPOST struct {
+		// SiaPath is the siapath of the directory which contains the
+		// siafiles to convert. It's relative to the user folder unless Root
+		// is set.
+		SiaPath skymodules.SiaPath `json:"siapath"`
+
+		// DestSiaPath is the siapath of the directory which will contain the
+		// base sectors of the converted files. It's relative to the skynet
+		// folder unless Root is set. If left empty, a random siapath is
+		// chosen.
+		DestSiaPath skymodules.SiaPath `json:"destsiapath"`
+		Root        bool               `json:"root"`
+
+		Concurrency int  `json:"concurrency"`
+		Recursive   bool `json:"recursive"`
+	}
+)
+
+// skynetConvertDirHandlerPOST handles the POST calls to /skynet/convertdir
+// which start converting the siafiles of a directory to skyfiles.
+func (api *API) skynetConvertDirHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Decode request.
+	var scdp SkynetConvertDirPOST
+	err := json.NewDecoder(req.Body).Decode(&scdp)
+	if err != nil {
+		WriteError(w, Error{"Failed to decode request: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if scdp.Concurrency < 0 || scdp.Concurrency > skymodules.MaxSkynetConvertDirConcurrency {
+		WriteError(w, Error{fmt.Sprintf("concurrency must be between 0 and %v", skymodules.MaxSkynetConvertDirConcurrency)}, http.StatusBadRequest)
+		return
+	}
+
+	// Rebase the siapaths.
+	siaPath, destSiaPath := scdp.SiaPath, scdp.DestSiaPath
+	if !scdp.Root {
+		siaPath, err = rebaseInputSiaPath(siaPath)
+		if err != nil {
+			WriteError(w, Error{"invalid siapath provided: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if !destSiaPath.IsEmpty() {
+			destSiaPath, err = skymodules.SkynetFolder.Join(destSiaPath.String())
+			if err != nil {
+				WriteError(w, Error{"invalid destsiapath provided: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	job, err := api.renter.SkynetConvertDir(skymodules.SkynetConvertDirJob{
+		SiaPath:     siaPath,
+		DestSiaPath: destSiaPath,
+		Recursive:   scdp.Recursive,
+		Concurrency: scdp.Concurrency,
+	})
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		WriteError(w, Error{"failed to start directory conversion: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		handleSkynetError(w, "failed to start directory conversion", err)
+		return
+	}
+	WriteJSON(w, job)
+}
+
+// skynetConvertDirHandlerGET handles the GET calls to /skynet/convertdir/:id.
+func (api *API) skynetConvertDirHandlerGET(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	job, err := api.renter.SkynetConvertDirJob(ps.ByName("id"))
+	if err != nil {
+		handleSkynetError(w, "failed to fetch directory conversion", err)
+		return
+	}
+	WriteJSON(w, job)
+}
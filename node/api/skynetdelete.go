@@ -0,0 +1,37 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+// skynetDeleteHandlerPOST handles the POST calls to /skynet/delete/:skylink
+// which tombstone a skylink and start removing its siafiles and cached data.
+func (api *API) skynetDeleteHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	var skylink skymodules.Skylink
+	err := skylink.LoadString(ps.ByName("skylink"))
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("error parsing skylink: %v", err)}, http.StatusBadRequest)
+		return
+	}
+
+	job, err := api.renter.DeleteSkylink(req.Context(), skylink)
+	if err != nil {
+		handleSkynetError(w, "failed to start skylink deletion", err)
+		return
+	}
+	WriteJSON(w, job)
+}
+
+// skynetDeleteHandlerGET handles the GET calls to /skynet/delete/:id.
+func (api *API) skynetDeleteHandlerGET(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	job, err := api.renter.SkynetDeleteJob(ps.ByName("id"))
+	if err != nil {
+		handleSkynetError(w, "failed to fetch skylink deletion", err)
+		return
+	}
+	WriteJSON(w, job)
+}
@@ -0,0 +1,237 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+type (
+	// SkynetDirUploadPOST is the expected format of the json request for
+	// /skynet/dirupload [POST].
+	SkynetDirUploadPOST struct {
+		// SiaPath is the siapath of the resulting skyfile. It's relative to
+		// the skynet folder unless Root is set. If left empty, a random
+		// siapath is chosen.
+		SiaPath skymodules.SiaPath `json:"siapath"`
+		Root    bool               `json:"root"`
+
+		BaseChunkRedundancy uint8          `json:"basechunkredundancy"`
+		DefaultPath         string         `json:"defaultpath"`
+		DisableDefaultPath  bool           `json:"disabledefaultpath"`
+		ErrorPages          map[int]string `json:"errorpages"`
+		Filename            string         `json:"filename"`
+		Force               bool           `json:"force"`
+		TryFiles            []string       `json:"tryfiles"`
+
+		// Files is an optional manifest of the files that are going to be
+		// uploaded to the session.
+		Files []skymodules.SkynetDirUploadFile `json:"files"`
+	}
+)
+
+// skynetDirUploadHandlerPOST handles the POST calls to /skynet/dirupload which
+// create a new directory upload session.
+func (api *API) skynetDirUploadHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Decode request.
+	var sdup SkynetDirUploadPOST
+	err := json.NewDecoder(req.Body).Decode(&sdup)
+	if err != nil {
+		WriteError(w, Error{"Failed to decode request: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Validate the default path params.
+	if sdup.DisableDefaultPath && sdup.DefaultPath != "" {
+		WriteError(w, Error{"DefaultPath and DisableDefaultPath are mutually exclusive and cannot be set together"}, http.StatusBadRequest)
+		return
+	}
+	if sdup.DefaultPath != "" {
+		sdup.DefaultPath = skymodules.EnsurePrefix(sdup.DefaultPath, "/")
+	}
+	if sdup.TryFiles == nil && sdup.DefaultPath == "" && !sdup.DisableDefaultPath {
+		sdup.TryFiles = skymodules.DefaultTryFilesValue
+	}
+	if len(sdup.TryFiles) > 0 && (sdup.DefaultPath != "" || sdup.DisableDefaultPath) {
+		WriteError(w, Error{"defaultpath and disabledefaultpath are not compatible with tryfiles"}, http.StatusBadRequest)
+		return
+	}
+
+	// Rebase the siapath.
+	siaPath := sdup.SiaPath
+	if !sdup.Root && !siaPath.IsEmpty() {
+		siaPath, err = skymodules.SkynetFolder.Join(siaPath.String())
+		if err != nil {
+			WriteError(w, Error{"invalid siapath provided: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	session, err := api.renter.SkynetDirUploadCreate(skymodules.SkynetDirUploadSession{
+		SiaPath:             siaPath,
+		BaseChunkRedundancy: sdup.BaseChunkRedundancy,
+		DefaultPath:         sdup.DefaultPath,
+		DisableDefaultPath:  sdup.DisableDefaultPath,
+		ErrorPages:          sdup.ErrorPages,
+		Filename:            sdup.Filename,
+		Force:               sdup.Force,
+		TryFiles:            sdup.TryFiles,
+	}, sdup.Files)
+	if err != nil {
+		handleSkynetError(w, "failed to create directory upload session", err)
+		return
+	}
+	WriteJSON(w, session)
+}
+
+// skynetDirUploadHandlerGET handles the GET calls to /skynet/dirupload/:id.
+func (api *API) skynetDirUploadHandlerGET(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	session, err := api.renter.SkynetDirUploadSession(ps.ByName("id"))
+	if err != nil {
+		handleSkynetError(w, "failed to fetch directory upload session", err)
+		return
+	}
+	WriteJSON(w, session)
+}
+
+// skynetDirUploadFileHandlerPOST handles the POST calls to
+// /skynet/dirupload/:id/file which upload a single file of a directory upload
+// session. The request body contains the file's data.
+func (api *API) skynetDirUploadFileHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	queryForm := req.URL.Query()
+
+	// parse 'filename' query parameter
+	filename := queryForm.Get("filename")
+	if filename == "" {
+		WriteError(w, Error{"'filename' parameter is required"}, http.StatusBadRequest)
+		return
+	}
+
+	// parse 'mode' query parameter
+	var mode os.FileMode
+	if modeStr := queryForm.Get("mode"); modeStr != "" {
+		_, err := fmt.Sscanf(modeStr, "%o", &mode)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'mode' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// parse 'contenttype' query parameter and fall back to the request's
+	// header
+	contentType := queryForm.Get("contenttype")
+	if contentType == "" {
+		contentType = req.Header.Get("Content-Type")
+	}
+
+	file := skymodules.SkynetDirUploadFile{
+		Filename:    filename,
+		ContentType: contentType,
+		Mode:        mode,
+	}
+
+	// Enforce the quota of the API token the request authenticated with. The
+	// file's data is only reserved while it's uploaded. The quota is
+	// committed when the session is finalized.
+	var body io.Reader = req.Body
+	var rr *reservationReader
+	token, hasToken := apiTokenFromContext(req.Context())
+	if hasToken {
+		_, _, err := api.managedAPITokenUploadLimit(token.ID, req.ContentLength)
+		if err != nil {
+			handleSkynetError(w, "upload rejected", err)
+			return
+		}
+		reservation := api.newAPITokenReservation(token.ID)
+		defer reservation.release()
+		rr = &reservationReader{
+			Reader:      body,
+			reservation: reservation,
+		}
+		body = rr
+	}
+
+	err := api.renter.SkynetDirUploadAddFile(ps.ByName("id"), file, body)
+	if rr != nil && rr.err != nil {
+		handleSkynetError(w, "failed to upload file to directory upload session", rr.err)
+		return
+	}
+	if err != nil {
+		handleSkynetError(w, "failed to upload file to directory upload session", err)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// skynetDirUploadFinalizeHandlerPOST handles the POST calls to
+// /skynet/dirupload/:id/finalize which upload the files of a session as a
+// single skyfile.
+func (api *API) skynetDirUploadFinalizeHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	id := ps.ByName("id")
+
+	// Reserve the size of the skyfile from the quota of the API token the
+	// request authenticated with.
+	var reservation *apiTokenReservation
+	var size uint64
+	token, hasToken := apiTokenFromContext(req.Context())
+	if hasToken {
+		session, err := api.renter.SkynetDirUploadSession(id)
+		if err != nil {
+			handleSkynetError(w, "failed to fetch directory upload session", err)
+			return
+		}
+		for _, file := range session.Files {
+			size += file.Len
+		}
+		_, _, err = api.managedAPITokenUploadLimit(token.ID, int64(size))
+		if err != nil {
+			handleSkynetError(w, "upload rejected", err)
+			return
+		}
+		reservation = api.newAPITokenReservation(token.ID)
+		defer reservation.release()
+		err = reservation.reserve(size)
+		if err != nil {
+			handleSkynetError(w, "upload rejected", err)
+			return
+		}
+	}
+
+	skylink, err := api.renter.SkynetDirUploadFinalize(req.Context(), id)
+	if err != nil {
+		handleSkynetError(w, "failed to finalize directory upload session", err)
+		return
+	}
+	if reservation != nil {
+		err = reservation.commit(skylink, size)
+		if err != nil {
+			handleSkynetError(w, "failed to update api token usage", err)
+			return
+		}
+	}
+
+	// Set the Skylink response header
+	w.Header().Set(SkynetSkylinkHeader, skylink.String())
+
+	WriteJSON(w, SkynetSkyfileHandlerPOST{
+		Skylink:    skylink.String(),
+		MerkleRoot: skylink.MerkleRoot(),
+		Bitfield:   skylink.Bitfield(),
+	})
+}
+
+// skynetDirUploadAbortHandlerPOST handles the POST calls to
+// /skynet/dirupload/:id/abort.
+func (api *API) skynetDirUploadAbortHandlerPOST(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	err := api.renter.SkynetDirUploadAbort(ps.ByName("id"))
+	if err != nil {
+		handleSkynetError(w, "failed to abort directory upload session", err)
+		return
+	}
+	WriteSuccess(w)
+}
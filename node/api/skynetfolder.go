@@ -0,0 +1,173 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/types"
+)
+
+type (
+	// SkynetFolderGET is the response that the api returns after the
+	// /skynet/folder GET endpoint has been used.
+	SkynetFolderGET struct {
+		Skyfiles []SkynetFolderSkyfile `json:"skyfiles"`
+	}
+
+	// SkynetFolderSkyfile describes a skyfile within a skynet folder. If the
+	// metadata of the skyfile couldn't be fetched, Error is set.
+	SkynetFolderSkyfile struct {
+		SiaPath       skymodules.SiaPath `json:"siapath"`
+		Skylink       string             `json:"skylink"`
+		Filename      string             `json:"filename"`
+		Length        uint64             `json:"length"`
+		ExtraMetadata json.RawMessage    `json:"extrametadata,omitempty"`
+		Error         string             `json:"error,omitempty"`
+	}
+)
+
+// skynetFolderHandlerGET handles the GET calls to /skynet/folder/*siapath
+// which list the skyfiles within a folder together with their extra metadata.
+// The skyfiles can be filtered by their extra metadata.
+func (api *API) skynetFolderHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	// Parse the query params.
+	queryForm, err := url.ParseQuery(req.URL.RawQuery)
+	if err != nil {
+		WriteError(w, Error{"failed to parse query params"}, http.StatusBadRequest)
+		return
+	}
+
+	// Parse the 'root' and 'recursive' query params.
+	var root, recursive bool
+	if rootStr := queryForm.Get("root"); rootStr != "" {
+		root, err = strconv.ParseBool(rootStr)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'root' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if recursiveStr := queryForm.Get("recursive"); recursiveStr != "" {
+		recursive, err = strconv.ParseBool(recursiveStr)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'recursive' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Parse the siapath.
+	siaPath := skymodules.SkynetFolder
+	if root {
+		siaPath = skymodules.RootSiaPath()
+	}
+	if siaPathStr := strings.Trim(ps.ByName("siapath"), "/"); siaPathStr != "" {
+		siaPath, err = siaPath.Join(siaPathStr)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'siapath' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Parse the 'extrametadata' filter.
+	var filter map[string]interface{}
+	if filterStr := queryForm.Get("extrametadata"); filterStr != "" {
+		filter, err = skymodules.ParseExtraMetadataFilter([]byte(filterStr))
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'extrametadata' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Parse the timeout.
+	timeout, err := parseTimeout(queryForm)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Parse pricePerMS.
+	pricePerMS := DefaultSkynetPricePerMS
+	pricePerMSStr := queryForm.Get("priceperms")
+	if pricePerMSStr != "" {
+		_, err = fmt.Sscan(pricePerMSStr, &pricePerMS)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'pricePerMS' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Collect the skylinks of the files within the folder. The extended
+	// files of large skyfiles are skipped since their skylinks are the same
+	// as the ones of the skyfiles they belong to.
+	var skyfiles []SkynetFolderSkyfile
+	var mu sync.Mutex
+	err = api.renter.FileList(siaPath, recursive, true, func(fi skymodules.FileInfo) {
+		if strings.HasSuffix(fi.SiaPath.String(), skymodules.ExtendedSuffix) {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, skylink := range fi.Skylinks {
+			skyfiles = append(skyfiles, SkynetFolderSkyfile{
+				SiaPath: fi.SiaPath,
+				Skylink: skylink,
+			})
+		}
+	})
+	if err != nil {
+		WriteError(w, Error{"failed to get file infos: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	sort.Slice(skyfiles, func(i, j int) bool {
+		if skyfiles[i].SiaPath.Equals(skyfiles[j].SiaPath) {
+			return skyfiles[i].Skylink < skyfiles[j].Skylink
+		}
+		return skyfiles[i].SiaPath.String() < skyfiles[j].SiaPath.String()
+	})
+
+	// Fetch the metadata of the skyfiles and apply the filter. Skyfiles for
+	// which the metadata can't be fetched are only returned without a filter.
+	filtered := make([]SkynetFolderSkyfile, 0, len(skyfiles))
+	for _, sf := range skyfiles {
+		sm, err := skyfileMetadata(api.renter, sf.Skylink, timeout, pricePerMS)
+		if err != nil {
+			if len(filter) == 0 {
+				sf.Error = err.Error()
+				filtered = append(filtered, sf)
+			}
+			continue
+		}
+		if !sm.MatchesExtraMetadata(filter) {
+			continue
+		}
+		sf.Filename = sm.Filename
+		sf.Length = sm.Length
+		sf.ExtraMetadata = sm.ExtraMetadata
+		filtered = append(filtered, sf)
+	}
+	WriteJSON(w, SkynetFolderGET{
+		Skyfiles: filtered,
+	})
+}
+
+// skyfileMetadata fetches the metadata of the skyfile with the given skylink.
+func skyfileMetadata(r skymodules.Renter, skylinkStr string, timeout time.Duration, pricePerMS types.Currency) (skymodules.SkyfileMetadata, error) {
+	var skylink skymodules.Skylink
+	if err := skylink.LoadString(skylinkStr); err != nil {
+		return skymodules.SkyfileMetadata{}, err
+	}
+	streamer, _, err := r.DownloadSkylinkMetadata(skylink, timeout, pricePerMS, skymodules.OverdriveSettings{}, nil)
+	if err != nil {
+		return skymodules.SkyfileMetadata{}, err
+	}
+	sm := streamer.Metadata()
+	return sm, streamer.Close()
+}
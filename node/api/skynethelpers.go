@@ -1,17 +1,18 @@
 package api
 
 import (
-	"archive/tar"
-	"archive/zip"
+	"bytes"
 	"compress/gzip"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"mime"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
@@ -24,6 +25,7 @@ import (
 	"gitlab.com/SkynetLabs/skyd/skymodules"
 	"gitlab.com/SkynetLabs/skyd/skymodules/renter"
 	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
 
@@ -45,6 +47,17 @@ var (
 
 	// errZeroTimeout is returned if the timeout is explicitly set to 0.
 	errZeroTimeout = errors.New("can't specify a zero timeout")
+
+	// errIncompleteSignedURL is returned when only one of the 'expires' and
+	// 'signature' params of a signed URL is set.
+	errIncompleteSignedURL = errors.New("signed url requires both the 'expires' and 'signature' params")
+)
+
+var (
+	// zipEpoch is the earliest modification time a zip archive can record in
+	// its MS-DOS timestamps. It is used for files without a modification
+	// time to keep zip archives reproducible.
+	zipEpoch = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
 )
 
 type (
@@ -59,25 +72,58 @@ type (
 		skylink              skymodules.Skylink
 		skylinkStringNoQuery string
 		timeout              time.Duration
+
+		// access is set if the skylink is downloaded using a signed URL.
+		access skymodules.SkylinkAccess
+
+		// hash is the algorithm used for the content hash trailer and verify
+		// indicates whether the content is verified before it's fully
+		// served.
+		hash   string
+		verify bool
+
+		// includeProvenance indicates whether the hosts which served the
+		// pieces of the downloaded chunks are attached as trailer.
+		includeProvenance bool
+
+		// includeBandwidth indicates whether the bandwidth used to download
+		// the chunks is attached as trailers.
+		includeBandwidth bool
+
+		// overdrive are the overdrive settings of the download.
+		overdrive skymodules.OverdriveSettings
+
+		// noFanout indicates that only the headers should be served, which
+		// can be done without fetching the fanout of the skyfile.
+		noFanout bool
 	}
 
 	// skyfileUploadParams is a helper struct that contains all of the query
 	// string parameters on upload
 	skyfileUploadParams struct {
+		allowedHosts        []types.SiaPublicKey
+		archive             bool
 		baseChunkRedundancy uint8
+		contentHashes       bool
 		defaultPath         string
 		convertPath         string
 		disableDefaultPath  bool
 		tryFiles            []string
 		errorPages          map[int]string
 		dryRun              bool
+		extract             bool
+		extraMetadata       json.RawMessage
+		headers             map[string]string
 		filename            string
 		force               bool
 		mode                os.FileMode
+		profile             string
 		root                bool
+		sessionID           string
 		siaPath             skymodules.SiaPath
 		skyKeyID            skykey.SkykeyID
 		skyKeyName          string
+		ttl                 time.Duration
 	}
 
 	// skyfileUploadHeaders is a helper struct that contains all of the request
@@ -122,20 +168,22 @@ func (rw *monetizedResponseWriter) Write(b []byte) (int, error) {
 }
 
 // newCustomErrorWriter creates a new customErrorWriter.
-func newCustomErrorWriter(meta skymodules.SkyfileMetadata, streamer io.ReadSeeker) *customErrorWriter {
+func newCustomErrorWriter(meta skymodules.SkyfileMetadata, streamer io.ReadSeeker, cto skymodules.ContentTypeOverrides) *customErrorWriter {
 	if meta.ErrorPages == nil {
 		meta.ErrorPages = make(map[int]string)
 	}
 	return &customErrorWriter{
-		staticMetadata: meta,
-		staticStreamer: streamer,
+		staticContentTypeOverrides: cto,
+		staticMetadata:             meta,
+		staticStreamer:             streamer,
 	}
 }
 
 // customErrorWriter responds to errors with custom content.
 type customErrorWriter struct {
-	staticMetadata skymodules.SkyfileMetadata
-	staticStreamer io.ReadSeeker
+	staticContentTypeOverrides skymodules.ContentTypeOverrides
+	staticMetadata             skymodules.SkyfileMetadata
+	staticStreamer             io.ReadSeeker
 }
 
 // WriteError checks whether there's custom content configured for the
@@ -179,7 +227,7 @@ func (ew *customErrorWriter) customContent(status int) (io.Reader, string, error
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to serve custom contents for status code %d, invalid offset, error '%s'", status, err.Error())
 	}
-	return io.LimitReader(ew.staticStreamer, int64(size)), metadataForPath.ContentType(), nil
+	return io.LimitReader(ew.staticStreamer, int64(size)), ew.staticContentTypeOverrides.MetadataContentType(metadataForPath), nil
 }
 
 // buildETag is a helper function that returns an ETag.
@@ -203,26 +251,68 @@ func isMultipartRequest(mediaType string) bool {
 // a path. The input skylink URL should not have been URL-decoded. The path is
 // URL-decoded before returning as it is for us to parse and use, while the
 // other components remain encoded for the skapp.
-func parseSkylinkURL(skylinkURL, apiRoute string) (skylink skymodules.Skylink, skylinkStringNoQuery, path string, err error) {
-	s := strings.TrimPrefix(skylinkURL, apiRoute)
-	s = strings.TrimPrefix(s, "/")
+//
+// Path-style URLs carry the skylink as the first path segment after the API
+// route. Subdomain-style URLs, which don't start with the API route, carry the
+// skylink in base32 as the leftmost DNS label of the host instead. In that case
+// the whole URL path is the path within the skyfile and the string
+// representation of the skylink is the path itself.
+func parseSkylinkURL(host, skylinkURL, apiRoute string) (skylink skymodules.Skylink, skylinkStringNoQuery, path string, err error) {
+	s := skylinkURL
+	subdomainSkylink, subdomain := skylinkFromHost(host)
+	if strings.HasPrefix(s, apiRoute) || !subdomain {
+		s = strings.TrimPrefix(s, apiRoute)
+		s = strings.TrimPrefix(s, "/")
+	}
+
+	// Split off the query.
+	skylinkStringNoQuery = strings.SplitN(s, "?", 2)[0]
+
 	// Parse out optional path to a subfile
 	path = "/" // default to root
-	splits := strings.SplitN(s, "?", 2)
-	skylinkStringNoQuery = splits[0]
-	splits = strings.SplitN(skylinkStringNoQuery, "/", 2)
-	// Check if a path is passed.
-	if len(splits) > 1 && len(splits[1]) > 0 {
-		path = skymodules.EnsurePrefix(splits[1], "/")
+	if subdomain && !strings.HasPrefix(skylinkURL, apiRoute) {
+		skylink = subdomainSkylink
+		if skylinkStringNoQuery != "" {
+			path = skymodules.EnsurePrefix(skylinkStringNoQuery, "/")
+		}
+	} else {
+		splits := strings.SplitN(skylinkStringNoQuery, "/", 2)
+		// Check if a path is passed.
+		if len(splits) > 1 && len(splits[1]) > 0 {
+			path = skymodules.EnsurePrefix(splits[1], "/")
+		}
+		// Parse skylink
+		err = skylink.LoadString(splits[0])
+		if err != nil {
+			return skymodules.Skylink{}, "", "", err
+		}
 	}
+
 	// Decode the path as it may contain URL-encoded characters.
 	path, err = url.QueryUnescape(path)
 	if err != nil {
-		return
+		return skymodules.Skylink{}, "", "", err
+	}
+	return skylink, skylinkStringNoQuery, path, nil
+}
+
+// skylinkFromHost returns the skylink of a subdomain-style request. The
+// skylink is the leftmost DNS label of the host and encoded in base32, the
+// only encoding of skylinks which is a valid, case-insensitive DNS label.
+func skylinkFromHost(host string) (skymodules.Skylink, bool) {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
 	}
-	// Parse skylink
-	err = skylink.LoadString(s)
-	return
+	label := strings.SplitN(host, ".", 2)[0]
+	var skylink skymodules.Skylink
+	if err := skylink.LoadString(label); err != nil {
+		return skymodules.Skylink{}, false
+	}
+	// Ignore base64 encoded skylinks.
+	if !strings.EqualFold(skylink.Base32EncodedString(), label) {
+		return skymodules.Skylink{}, false
+	}
+	return skylink, true
 }
 
 // parseTimeout tries to parse the timeout from the query string and validate
@@ -269,13 +359,44 @@ func parseRegistryTimeout(queryForm url.Values) (time.Duration, error) {
 	return time.Duration(timeoutInt) * time.Second, nil
 }
 
+// parseOverdriveSettings tries to parse the overdrive settings from the query
+// string. The 'overdrivetarget' is specified in milliseconds.
+func parseOverdriveSettings(queryForm url.Values) (skymodules.OverdriveSettings, error) {
+	var ods skymodules.OverdriveSettings
+	strategy, err := skymodules.ParseOverdriveStrategy(queryForm.Get("overdrive"))
+	if err != nil {
+		return skymodules.OverdriveSettings{}, errors.AddContext(err, "unable to parse 'overdrive'")
+	}
+	ods.Strategy = strategy
+
+	targetStr := queryForm.Get("overdrivetarget")
+	if targetStr != "" {
+		var targetMS uint64
+		_, err := fmt.Sscan(targetStr, &targetMS)
+		if err != nil {
+			return skymodules.OverdriveSettings{}, errors.AddContext(err, "unable to parse 'overdrivetarget'")
+		}
+		ods.LatencyTarget = time.Duration(targetMS) * time.Millisecond
+	}
+
+	maxCostStr := queryForm.Get("maxcost")
+	if maxCostStr != "" {
+		maxCost, err := scanCurrency(maxCostStr)
+		if err != nil {
+			return skymodules.OverdriveSettings{}, errors.AddContext(err, "unable to parse 'maxcost'")
+		}
+		ods.MaxCost = maxCost
+	}
+	return ods, nil
+}
+
 // parseDownloadRequestParameters is a helper function that parses all of the
 // query parameters from a download request
 func parseDownloadRequestParameters(req *http.Request) (*skyfileDownloadParams, error) {
 	// Parse the skylink from the raw URL of the request. Any special characters
 	// in the raw URL are encoded, allowing us to differentiate e.g. the '?'
 	// that begins query parameters from the encoded version '%3F'.
-	skylink, skylinkStringNoQuery, path, err := parseSkylinkURL(req.URL.String(), "/skynet/skylink/")
+	skylink, skylinkStringNoQuery, path, err := parseSkylinkURL(req.Host, req.URL.String(), "/skynet/skylink/")
 	if err != nil {
 		return nil, fmt.Errorf("error parsing skylink: %v", err)
 	}
@@ -368,8 +489,73 @@ func parseDownloadRequestParameters(req *http.Request) (*skyfileDownloadParams,
 		return nil, errIncompleteRangeRequest
 	}
 
+	// Parse the overdrive settings.
+	overdrive, err := parseOverdriveSettings(queryForm)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse the 'hash' query string parameter.
+	hashAlg := strings.ToLower(queryForm.Get("hash"))
+	if hashAlg != "" && hashAlg != SkynetContentHashBlake2b && hashAlg != SkynetContentHashSHA256 {
+		return nil, errUnknownContentHash
+	}
+
+	// Parse the 'verify' query string parameter.
+	var verify bool
+	verifyStr := queryForm.Get("verify")
+	if verifyStr != "" {
+		verify, err = strconv.ParseBool(verifyStr)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse 'verify' parameter: %v", err)
+		}
+	}
+
+	// Parse the 'nofanout' query string parameter.
+	var noFanout bool
+	noFanoutStr := queryForm.Get("nofanout")
+	if noFanoutStr != "" {
+		noFanout, err = strconv.ParseBool(noFanoutStr)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse 'nofanout' parameter: %v", err)
+		}
+	}
+
+	// Parse the 'Skynet-Include-Provenance' request header.
+	var includeProvenance bool
+	includeProvenanceStr := req.Header.Get(SkynetIncludeProvenanceHeader)
+	if includeProvenanceStr != "" {
+		includeProvenance, err = strconv.ParseBool(includeProvenanceStr)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse '%v' header: %v", SkynetIncludeProvenanceHeader, err)
+		}
+	}
+
+	// Parse the 'Skynet-Include-Bandwidth' request header.
+	var includeBandwidth bool
+	includeBandwidthStr := req.Header.Get(SkynetIncludeBandwidthHeader)
+	if includeBandwidthStr != "" {
+		includeBandwidth, err = strconv.ParseBool(includeBandwidthStr)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse '%v' header: %v", SkynetIncludeBandwidthHeader, err)
+		}
+	}
+
+	// Parse the signature of a signed URL.
+	access, err := parseSkylinkAccess(queryForm)
+	if err != nil {
+		return nil, err
+	}
+
 	return &skyfileDownloadParams{
+		access:               access,
 		attachment:           attachment,
+		hash:                 hashAlg,
+		includeBandwidth:     includeBandwidth,
+		includeProvenance:    includeProvenance,
+		noFanout:             noFanout,
+		overdrive:            overdrive,
+		verify:               verify,
 		format:               format,
 		includeLayout:        includeLayout,
 		path:                 path,
@@ -380,11 +566,43 @@ func parseDownloadRequestParameters(req *http.Request) (*skyfileDownloadParams,
 	}, nil
 }
 
+// parseSkylinkAccess parses the 'expires' and 'signature' params of a signed
+// URL. Both are empty if the URL isn't signed.
+func parseSkylinkAccess(queryForm url.Values) (skymodules.SkylinkAccess, error) {
+	expiresStr := queryForm.Get("expires")
+	signatureStr := queryForm.Get("signature")
+	if expiresStr == "" && signatureStr == "" {
+		return skymodules.SkylinkAccess{}, nil
+	}
+	if expiresStr == "" || signatureStr == "" {
+		return skymodules.SkylinkAccess{}, errIncompleteSignedURL
+	}
+	expiresUnix, err := strconv.ParseInt(expiresStr, 10, 64)
+	if err != nil {
+		return skymodules.SkylinkAccess{}, errors.AddContext(err, "unable to parse 'expires' parameter")
+	}
+	signature, err := hex.DecodeString(signatureStr)
+	if err != nil {
+		return skymodules.SkylinkAccess{}, errors.AddContext(err, "unable to parse 'signature' parameter")
+	}
+	return skymodules.SkylinkAccess{
+		Expires:   time.Unix(expiresUnix, 0),
+		Signature: signature,
+	}, nil
+}
+
 // parseUploadHeadersAndRequestParameters is a helper function that parses all
 // the query parameters and headers from an upload request
-func parseUploadHeadersAndRequestParameters(req *http.Request, ps httprouter.Params) (*skyfileUploadHeaders, *skyfileUploadParams, error) {
+func parseUploadHeadersAndRequestParameters(req *http.Request, ps httprouter.Params, limits skymodules.SkyfileUploadLimits) (*skyfileUploadHeaders, *skyfileUploadParams, error) {
 	var err error
 
+	// check the size of the upload before parsing anything else
+	if req.ContentLength > 0 {
+		if err := limits.CheckUploadSize(uint64(req.ContentLength)); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	// parse 'Skynet-Disable-Force' request header
 	var disableForce bool
 	strDisableForce := req.Header.Get(SkynetDisableForceHeader)
@@ -472,9 +690,68 @@ func parseUploadHeadersAndRequestParameters(req *http.Request, ps httprouter.Par
 		}
 	}
 
+	// parse 'archive' query parameter
+	var archive bool
+	archiveStr := queryForm.Get("archive")
+	if archiveStr != "" {
+		archive, err = strconv.ParseBool(archiveStr)
+		if err != nil {
+			return nil, nil, errors.AddContext(err, "unable to parse 'archive' parameter")
+		}
+	}
+
+	// parse 'profile' query parameter
+	profile := queryForm.Get("profile")
+
+	// parse 'allowedhosts' query parameter
+	allowedHosts, err := parseAllowedHosts(queryForm.Get("allowedhosts"))
+	if err != nil {
+		return nil, nil, errors.AddContext(err, "unable to parse 'allowedhosts' parameter")
+	}
+
+	// parse 'contenthashes' query parameter
+	var contentHashes bool
+	contentHashesStr := queryForm.Get("contenthashes")
+	if contentHashesStr != "" {
+		contentHashes, err = strconv.ParseBool(contentHashesStr)
+		if err != nil {
+			return nil, nil, errors.AddContext(err, "unable to parse 'contenthashes' parameter")
+		}
+	}
+
+	// parse 'extract' query parameter
+	var extract bool
+	extractStr := queryForm.Get("extract")
+	if extractStr != "" {
+		extract, err = strconv.ParseBool(extractStr)
+		if err != nil {
+			return nil, nil, errors.AddContext(err, "unable to parse 'extract' parameter")
+		}
+	}
+
+	// parse 'extrametadata' query parameter
+	var extraMetadata json.RawMessage
+	extraMetadataStr := queryForm.Get("extrametadata")
+	if extraMetadataStr != "" {
+		extraMetadata, err = skymodules.ParseExtraMetadata([]byte(extraMetadataStr))
+		if err != nil {
+			return nil, nil, errors.AddContext(err, "unable to parse 'extrametadata' parameter")
+		}
+	}
+
 	// parse 'filename' query parameter
 	filename := queryForm.Get("filename")
 
+	// parse 'headers' query parameter
+	var customHeaders map[string]string
+	headersStr := queryForm.Get("headers")
+	if headersStr != "" {
+		customHeaders, err = skymodules.ParseSkyfileHeaders([]byte(headersStr))
+		if err != nil {
+			return nil, nil, errors.AddContext(err, "unable to parse 'headers' parameter")
+		}
+	}
+
 	// parse 'force' query parameter
 	var force bool
 	strForce := queryForm.Get("force")
@@ -505,6 +782,24 @@ func parseUploadHeadersAndRequestParameters(req *http.Request, ps httprouter.Par
 		}
 	}
 
+	// parse 'sessionid' query parameter
+	sessionID := queryForm.Get("sessionid")
+	if len(sessionID) > skymodules.MaxUploadSessionIDLength {
+		return nil, nil, skymodules.ErrUploadSessionIDTooLong
+	}
+
+	// parse 'ttl' query parameter
+	var ttl time.Duration
+	ttlStr := queryForm.Get("ttl")
+	if ttlStr != "" {
+		var ttlSecs uint64
+		_, err = fmt.Sscan(ttlStr, &ttlSecs)
+		if err != nil {
+			return nil, nil, errors.AddContext(err, "unable to parse 'ttl' parameter")
+		}
+		ttl = time.Duration(ttlSecs) * time.Second
+	}
+
 	// parse 'siapath' query parameter
 	var siaPath skymodules.SiaPath
 	siaPathStr := ps.ByName("siapath")
@@ -548,8 +843,17 @@ func parseUploadHeadersAndRequestParameters(req *http.Request, ps httprouter.Par
 	}
 
 	// verify default path params are not set if it's not a multipart upload
-	if !isMultipartRequest(mediaType) && (disableDefaultPath || defaultPath != "") {
-		return nil, nil, errors.New("DefaultPath and DisableDefaultPath can only be set on multipart uploads")
+	// or an extracted archive
+	if !isMultipartRequest(mediaType) && !extract && (disableDefaultPath || defaultPath != "") {
+		return nil, nil, errors.New("DefaultPath and DisableDefaultPath can only be set on multipart uploads and extracted archives")
+	}
+
+	// verify extract is not combined with a multipart upload or a convertpath
+	if extract && isMultipartRequest(mediaType) {
+		return nil, nil, errors.New("'extract' can't be set on multipart uploads")
+	}
+	if extract && convertPath != "" {
+		return nil, nil, errors.New("cannot set both a 'convertpath' and 'extract'")
 	}
 
 	// verify convertpath and filename are not combined
@@ -568,57 +872,69 @@ func parseUploadHeadersAndRequestParameters(req *http.Request, ps httprouter.Par
 		mediaType:    mediaType,
 	}
 	params := &skyfileUploadParams{
+		allowedHosts:        allowedHosts,
+		archive:             archive,
 		baseChunkRedundancy: baseChunkRedundancy,
+		contentHashes:       contentHashes,
 		convertPath:         convertPath,
 		defaultPath:         defaultPath,
 		disableDefaultPath:  disableDefaultPath,
 		dryRun:              dryRun,
 		errorPages:          errPages,
+		extract:             extract,
+		extraMetadata:       extraMetadata,
+		headers:             customHeaders,
 		filename:            filename,
 		force:               force,
 		mode:                mode,
+		profile:             profile,
 		root:                root,
+		sessionID:           sessionID,
 		siaPath:             siaPath,
 		skyKeyID:            skykeyID,
 		skyKeyName:          skykeyName,
 		tryFiles:            tryFiles,
+		ttl:                 ttl,
 	}
 	return headers, params, nil
 }
 
-// serveArchive serves skyfiles as an archive by reading them from r and writing
-// the archive to dst using the given archiveFunc.
-func serveArchive(w http.ResponseWriter, src io.ReadSeeker, format skymodules.SkyfileFormat, md skymodules.SkyfileMetadata) (err error) {
-	// Based upon the given format, set the Content-Type header, wrap the writer
-	// and select an archive function.
-	var dst io.Writer
-	var archiveFunc archiveFunc
-	switch format {
-	case skymodules.SkyfileFormatTar:
-		archiveFunc = serveTar
-		w.Header().Set("Content-Type", "application/x-tar")
-		dst = w
-	case skymodules.SkyfileFormatTarGz:
-		archiveFunc = serveTar
-		w.Header().Set("Content-Type", "application/gzip")
-		gzw := gzip.NewWriter(w)
-		defer func() {
-			err = errors.Compose(err, gzw.Close())
-		}()
-		dst = gzw
-	case skymodules.SkyfileFormatZip:
-		archiveFunc = serveZip
-		w.Header().Set("Content-Type", "application/zip")
-		dst = w
+// serveArchive serves skyfiles as an archive by reading them from src. The
+// content types of the archived files are recorded within the archive after
+// applying the given overrides. Tar and zip archives support range requests,
+// compressed tar archives are always served in full.
+func serveArchive(w http.ResponseWriter, req *http.Request, src io.ReadSeeker, format skymodules.SkyfileFormat, md skymodules.SkyfileMetadata, cto skymodules.ContentTypeOverrides) error {
+	files, err := archiveFiles(src, md, cto)
+	if err != nil {
+		return err
+	}
+	if format == skymodules.SkyfileFormatTarGz {
+		return serveArchiveFiles(w, src, format, files)
 	}
+	ar, err := newArchiveReader(src, format, files)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", archiveContentType(format))
+	http.ServeContent(w, req, "", time.Time{}, ar)
+	return nil
+}
 
+// archiveFiles returns the files of a skyfile in the order they are archived.
+// The content types of the files are set after applying the given overrides.
+func archiveFiles(src io.Seeker, md skymodules.SkyfileMetadata, cto skymodules.ContentTypeOverrides) ([]skymodules.SkyfileSubfileMetadata, error) {
 	// Get the files to archive.
 	var files []skymodules.SkyfileSubfileMetadata
 	for _, file := range md.Subfiles {
 		files = append(files, file)
 	}
+	// Sort the files by offset and break ties between empty files by name to
+	// produce reproducible archives.
 	sort.Slice(files, func(i, j int) bool {
-		return files[i].Offset < files[j].Offset
+		if files[i].Offset != files[j].Offset {
+			return files[i].Offset < files[j].Offset
+		}
+		return files[i].Filename < files[j].Filename
 	})
 	// If there are no files, it's a single file download. Manually construct a
 	// SkyfileSubfileMetadata from the SkyfileMetadata.
@@ -629,7 +945,7 @@ func serveArchive(w http.ResponseWriter, src io.ReadSeeker, format skymodules.Sk
 			// to the start.
 			seekLen, err := src.Seek(0, io.SeekEnd)
 			if err != nil {
-				return errors.AddContext(err, "serveArchive: failed to seek to end of skyfile")
+				return nil, errors.AddContext(err, "failed to seek to end of skyfile")
 			}
 
 			// v150Compat a missing length is fine for legacy links but new
@@ -641,7 +957,7 @@ func serveArchive(w http.ResponseWriter, src io.ReadSeeker, format skymodules.Sk
 			// Seek back to the start
 			_, err = src.Seek(0, io.SeekStart)
 			if err != nil {
-				return errors.AddContext(err, "serveArchive: failed to seek to start of skyfile")
+				return nil, errors.AddContext(err, "failed to seek to start of skyfile")
 			}
 			length = uint64(seekLen)
 		}
@@ -653,51 +969,143 @@ func serveArchive(w http.ResponseWriter, src io.ReadSeeker, format skymodules.Sk
 			Len:      length,
 		})
 	}
-	err = archiveFunc(dst, src, files)
-	return err
+	for i := range files {
+		files[i].ContentType = cto.ContentType(files[i].Filename, files[i].ContentType)
+	}
+	return files, nil
 }
 
-// serveTar is an archiveFunc that implements serving the files from src to dst
-// as a tar.
-func serveTar(dst io.Writer, src io.Reader, files []skymodules.SkyfileSubfileMetadata) error {
-	tw := tar.NewWriter(dst)
-	for _, file := range files {
-		// Create header.
-		header, err := tar.FileInfoHeader(file, file.Name())
+// serveArchiveFiles serves the given files as an archive of the given format.
+// The contents of the files are read from src in the order of the files.
+func serveArchiveFiles(w http.ResponseWriter, src io.Reader, format skymodules.SkyfileFormat, files []skymodules.SkyfileSubfileMetadata) (err error) {
+	// Based upon the given format, set the Content-Type header, wrap the writer
+	// and select an archive function.
+	var dst io.Writer
+	var archiveFunc archiveFunc
+	switch format {
+	case skymodules.SkyfileFormatTar:
+		archiveFunc = serveTar
+		dst = w
+	case skymodules.SkyfileFormatTarGz:
+		archiveFunc = serveTar
+		gzw := gzip.NewWriter(w)
+		defer func() {
+			err = errors.Compose(err, gzw.Close())
+		}()
+		dst = gzw
+	case skymodules.SkyfileFormatZip:
+		archiveFunc = serveZip
+		dst = w
+	default:
+		return fmt.Errorf("unsupported archive format '%v'", format)
+	}
+	w.Header().Set("Content-Type", archiveContentType(format))
+	return archiveFunc(dst, src, files)
+}
+
+// archiveContentType returns the content type of an archive of the given
+// format.
+func archiveContentType(format skymodules.SkyfileFormat) string {
+	switch format {
+	case skymodules.SkyfileFormatTar:
+		return "application/x-tar"
+	case skymodules.SkyfileFormatTarGz:
+		return "application/gzip"
+	case skymodules.SkyfileFormatZip:
+		return "application/zip"
+	default:
+		return ""
+	}
+}
+
+// serveSkyfileHeaders serves the headers of a skyfile download without its
+// content, which doesn't require the fanout of the skyfile. Apart from the
+// Skynet-File-Metadata header, the headers match the ones of a regular
+// download. The Content-Length is only set for HEAD requests, since GET
+// requests are answered with an empty body. If the Content-Type can't be
+// determined from the metadata or the filename and the content isn't
+// available for sniffing, no Content-Type is set.
+func serveSkyfileHeaders(w http.ResponseWriter, req *http.Request, streamer skymodules.SkyfileStreamer, metadata skymodules.SkyfileMetadata, format skymodules.SkyfileFormat, cto skymodules.ContentTypeOverrides) error {
+	var md bytes.Buffer
+	err := json.Compact(&md, streamer.RawMetadata())
+	if err != nil {
+		return errors.AddContext(err, "failed to encode metadata")
+	}
+	w.Header().Set(SkynetFileMetadataHeader, md.String())
+
+	// The size of a tar or zip archive is known from the metadata. The size
+	// of a compressed archive is unknown without building it.
+	if format.IsArchive() {
+		w.Header().Set("Content-Type", archiveContentType(format))
+		if req.Method != http.MethodHead || format == skymodules.SkyfileFormatTarGz {
+			w.WriteHeader(http.StatusOK)
+			return nil
+		}
+		files, err := archiveFiles(streamer, metadata, cto)
 		if err != nil {
 			return err
 		}
-		// Modify name to match path within skyfile.
-		header.Name = file.Filename
-		// Write header.
-		if err := tw.WriteHeader(header); err != nil {
+		ar, err := newArchiveReader(streamer, format, files)
+		if err != nil {
 			return err
 		}
-		// Write file content.
-		if _, err := io.CopyN(tw, src, header.Size); err != nil {
-			return err
+		http.ServeContent(w, req, "", time.Time{}, ar)
+		return nil
+	}
+
+	contentType := cto.MetadataContentType(metadata)
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(metadata.Filename))
+	}
+	if contentType == "" && streamer.Layout().FanoutSize == 0 {
+		// The content is in the base sector, sniff it the same way
+		// http.ServeContent does.
+		buf := make([]byte, skymodules.ContentTypeSniffLen)
+		n, _ := io.ReadFull(streamer, buf)
+		contentType = http.DetectContentType(buf[:n])
+		_, err = streamer.Seek(0, io.SeekStart)
+		if err != nil {
+			return errors.AddContext(err, "failed to seek to start of skyfile")
 		}
 	}
-	return tw.Close()
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	} else {
+		// Setting the header to nil prevents http.ServeContent from sniffing
+		// the content.
+		w.Header()["Content-Type"] = nil
+	}
+	if req.Method != http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return nil
+	}
+	http.ServeContent(w, req, metadata.Filename, time.Time{}, streamer)
+	return nil
+}
+
+// serveTar is an archiveFunc that implements serving the files from src to dst
+// as a tar. Files without a modification time are archived with the unix epoch
+// as their modification time.
+func serveTar(dst io.Writer, src io.Reader, files []skymodules.SkyfileSubfileMetadata) error {
+	ar, err := newArchiveReader(src, skymodules.SkyfileFormatTar, files)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, ar)
+	return err
 }
 
 // serveZip is an archiveFunc that implements serving the files from src to dst
-// as a zip.
+// as a zip. The files are stored without compression. Files larger than 4 GiB
+// are stored using the zip64 extensions. Files without a modification time are
+// archived with the earliest time a zip can represent.
 func serveZip(dst io.Writer, src io.Reader, files []skymodules.SkyfileSubfileMetadata) error {
-	zw := zip.NewWriter(dst)
-	for _, file := range files {
-		f, err := zw.Create(file.Filename)
-		if err != nil {
-			return errors.AddContext(err, "serveZip: failed to add the file to the zip")
-		}
-
-		// Write file content.
-		_, err = io.CopyN(f, src, int64(file.Len))
-		if err != nil {
-			return errors.AddContext(err, "serveZip: failed to write file contents to the zip")
-		}
+	ar, err := newArchiveReader(src, skymodules.SkyfileFormatZip, files)
+	if err != nil {
+		return errors.AddContext(err, "serveZip: failed to compute the layout of the zip")
 	}
-	return zw.Close()
+	_, err = io.Copy(dst, ar)
+	return errors.AddContext(err, "serveZip: failed to write the zip")
 }
 
 // handleSkynetError is a handler that returns the correct status code for a
@@ -709,6 +1117,16 @@ func handleSkynetError(w http.ResponseWriter, prefix string, err error) {
 		WriteError(w, httpErr, http.StatusUnavailableForLegalReasons)
 		return
 	}
+	if errors.Contains(err, renter.ErrSkyfileRejected) {
+		WriteError(w, httpErr, http.StatusUnavailableForLegalReasons)
+		return
+	}
+	if errors.Contains(err, skymodules.ErrSkylinkSignatureRequired) ||
+		errors.Contains(err, skymodules.ErrSkylinkSignatureInvalid) ||
+		errors.Contains(err, skymodules.ErrSkylinkSignatureExpired) {
+		WriteError(w, httpErr, http.StatusForbidden)
+		return
+	}
 	if errors.Contains(err, renter.ErrRootNotFound) {
 		WriteError(w, httpErr, http.StatusNotFound)
 		return
@@ -725,6 +1143,75 @@ func handleSkynetError(w http.ResponseWriter, prefix string, err error) {
 		WriteError(w, httpErr, http.StatusNotFound)
 		return
 	}
+	if errors.Contains(err, skymodules.ErrMemoryLimitExceeded) {
+		w.Header().Set("Retry-After", fmt.Sprint(int(memoryLimitRetryAfter.Seconds())))
+		WriteError(w, httpErr, http.StatusServiceUnavailable)
+		return
+	}
+	if errors.Contains(err, skymodules.ErrAPITokenQuotaExceeded) {
+		WriteError(w, httpErr, http.StatusTooManyRequests)
+		return
+	}
+	if errors.Contains(err, skymodules.ErrSkynetFolderQuotaExceeded) {
+		WriteError(w, httpErr, http.StatusTooManyRequests)
+		return
+	}
+	if errors.Contains(err, skymodules.ErrAPITokenSkyfileTooLarge) {
+		WriteError(w, httpErr, http.StatusRequestEntityTooLarge)
+		return
+	}
+	if errors.Contains(err, skymodules.ErrSkynetNameNotFound) {
+		WriteError(w, httpErr, http.StatusNotFound)
+		return
+	}
+	if errors.Contains(err, skymodules.ErrSkynetNameLookupsLimited) {
+		WriteError(w, httpErr, http.StatusTooManyRequests)
+		return
+	}
+	if errors.Contains(err, skymodules.ErrSkylinkDeleted) {
+		WriteError(w, httpErr, http.StatusGone)
+		return
+	}
+	if errors.Contains(err, renter.ErrSkyfileImportVerificationFailed) {
+		WriteError(w, httpErr, http.StatusBadGateway)
+		return
+	}
+	if errors.Contains(err, skymodules.ErrDirUploadSessionNotFound) || errors.Contains(err, skymodules.ErrConvertDirJobNotFound) || errors.Contains(err, skymodules.ErrSkynetDeleteJobNotFound) {
+		WriteError(w, httpErr, http.StatusNotFound)
+		return
+	}
+	if errors.Contains(err, skymodules.ErrDirUploadSessionFinalizing) {
+		WriteError(w, httpErr, http.StatusConflict)
+		return
+	}
+	if errors.Contains(err, skymodules.ErrDirUploadFileNotInManifest) || errors.Contains(err, skymodules.ErrDirUploadIncomplete) {
+		WriteError(w, httpErr, http.StatusBadRequest)
+		return
+	}
+	if errors.Contains(err, skymodules.ErrUploadSessionEncrypted) || errors.Contains(err, skymodules.ErrUploadSessionSiaPathMismatch) || errors.Contains(err, skymodules.ErrUploadSessionIDTooLong) {
+		WriteError(w, httpErr, http.StatusBadRequest)
+		return
+	}
+	if errors.Contains(err, skymodules.ErrUploadSessionContentMismatch) {
+		WriteError(w, httpErr, http.StatusConflict)
+		return
+	}
+	if errors.Contains(err, skymodules.ErrDownloadCostCapExceeded) {
+		WriteError(w, httpErr, http.StatusPaymentRequired)
+		return
+	}
+	if errors.Contains(err, skymodules.ErrSkyfileUploadTooLarge) || errors.Contains(err, skymodules.ErrSkyfileTooManySubfiles) || errors.Contains(err, skymodules.ErrSkyfileMetadataTooLarge) {
+		WriteError(w, httpErr, http.StatusRequestEntityTooLarge)
+		return
+	}
+	if errors.Contains(err, skymodules.ErrExtractTooLarge) || errors.Contains(err, skymodules.ErrExtractTooManyEntries) {
+		WriteError(w, httpErr, http.StatusRequestEntityTooLarge)
+		return
+	}
+	if errors.Contains(err, skymodules.ErrExtractIllegalPath) || errors.Contains(err, skymodules.ErrExtractDuplicatePath) {
+		WriteError(w, httpErr, http.StatusBadRequest)
+		return
+	}
 	if errors.Contains(err, skymodules.ErrMalformedSkylink) {
 		WriteError(w, httpErr, http.StatusBadRequest)
 		return
@@ -745,14 +1232,7 @@ func handleSkynetError(w http.ResponseWriter, prefix string, err error) {
 func attachRegistryEntryProof(w http.ResponseWriter, srvs []skymodules.RegistryEntry) error {
 	proofChain := make([]RegistryHandlerGET, 0, len(srvs))
 	for _, srv := range srvs {
-		proofChain = append(proofChain, RegistryHandlerGET{
-			Data:      hex.EncodeToString(srv.Data),
-			DataKey:   srv.Tweak,
-			Revision:  srv.Revision,
-			PublicKey: srv.PubKey,
-			Signature: hex.EncodeToString(srv.Signature[:]),
-			Type:      srv.Type,
-		})
+		proofChain = append(proofChain, newRegistryHandlerGET(srv))
 	}
 	// If the proof is empty, don't set the header.
 	if len(proofChain) == 0 {
@@ -767,6 +1247,66 @@ func attachRegistryEntryProof(w http.ResponseWriter, srvs []skymodules.RegistryE
 	return nil
 }
 
+// setSkynetHealthHeader sets the health header to the cached health of the
+// given skylink. The header is omitted if the health isn't known, e.g. because
+// the skylink isn't pinned by the node.
+func (api *API) setSkynetHealthHeader(w http.ResponseWriter, skylink skymodules.Skylink) {
+	health, ok := api.renter.CachedSkylinkHealth(skylink)
+	if !ok {
+		return
+	}
+	w.Header().Set(SkynetHealthHeader, strconv.FormatFloat(health.Health, 'f', -1, 64))
+}
+
+// newRegistryHandlerGET converts a registry entry into its API representation.
+func newRegistryHandlerGET(srv skymodules.RegistryEntry) RegistryHandlerGET {
+	return RegistryHandlerGET{
+		Data:      hex.EncodeToString(srv.Data),
+		DataKey:   srv.Tweak,
+		Revision:  srv.Revision,
+		PublicKey: srv.PubKey,
+		Signature: hex.EncodeToString(srv.Signature[:]),
+		Type:      srv.Type,
+
+		TrustedHostAgreement: srv.TrustedHostAgreement,
+	}
+}
+
+// newRegistryHandlerPOST converts the proofs of a registry update into the
+// response of the registry update endpoints.
+func newRegistryHandlerPOST(proofs []skymodules.RegistryUpdateProof) RegistryHandlerPOST {
+	rhp := RegistryHandlerPOST{
+		Proof: make([]RegistryHostProof, 0, len(proofs)),
+	}
+	for _, proof := range proofs {
+		rhp.Proof = append(rhp.Proof, RegistryHostProof{
+			RegistryHandlerGET: newRegistryHandlerGET(proof.RegistryEntry),
+			HostKey:            proof.HostKey,
+		})
+	}
+	return rhp
+}
+
+// Verify verifies that the entry accepted by the host was signed by the
+// entry's public key.
+func (p RegistryHostProof) Verify() error {
+	data, err := hex.DecodeString(p.Data)
+	if err != nil {
+		return errors.AddContext(err, "failed to decode data")
+	}
+	sigBytes, err := hex.DecodeString(p.Signature)
+	if err != nil {
+		return errors.AddContext(err, "failed to decode signature")
+	}
+	var sig crypto.Signature
+	if len(sigBytes) != len(sig) {
+		return errors.New("invalid signature length")
+	}
+	copy(sig[:], sigBytes)
+	srv := modules.NewSignedRegistryValue(p.DataKey, data, p.Revision, sig, p.Type)
+	return srv.Verify(p.PublicKey.ToPublicKey())
+}
+
 // UnmarshalErrorPages unmarshals an errorpages string into an map[int]string.
 func UnmarshalErrorPages(s string) (map[int]string, error) {
 	errPages := make(map[int]string)
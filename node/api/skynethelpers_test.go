@@ -1,17 +1,24 @@
 package api
 
 import (
+	"archive/tar"
+	"archive/zip"
 	"bytes"
+	"compress/gzip"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"reflect"
 	"strconv"
 	"strings"
 	"testing"
+	"testing/quick"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
@@ -21,6 +28,7 @@ import (
 	"gitlab.com/SkynetLabs/skyd/skykey"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
 
 	"gitlab.com/SkynetLabs/skyd/skymodules"
 	"gitlab.com/SkynetLabs/skyd/skymodules/renter"
@@ -73,6 +81,10 @@ func TestHandleSkynetError(t *testing.T) {
 			err:        renter.ErrSkylinkBlocked,
 			statusCode: http.StatusUnavailableForLegalReasons,
 		},
+		{
+			err:        renter.ErrSkyfileRejected,
+			statusCode: http.StatusUnavailableForLegalReasons,
+		},
 		{
 			err:        renter.ErrRootNotFound,
 			statusCode: http.StatusNotFound,
@@ -97,6 +109,14 @@ func TestHandleSkynetError(t *testing.T) {
 			err:        renter.ErrRegistryUpdateTimeout,
 			statusCode: http.StatusRequestTimeout,
 		},
+		{
+			err:        skymodules.ErrMemoryLimitExceeded,
+			statusCode: http.StatusServiceUnavailable,
+		},
+		{
+			err:        skymodules.ErrDownloadCostCapExceeded,
+			statusCode: http.StatusPaymentRequired,
+		},
 		{
 			err:        errors.New("other"),
 			statusCode: http.StatusInternalServerError,
@@ -125,6 +145,12 @@ func TestHandleSkynetError(t *testing.T) {
 			t.Fatal("wrong status", test.statusCode, tw.statusCode)
 		}
 	}
+
+	// Rejections due to the memory limit should tell the client when to
+	// retry.
+	if retryAfter := tw.Header().Get("Retry-After"); retryAfter != "5" {
+		t.Fatal("unexpected Retry-After header", retryAfter)
+	}
 }
 
 // TestSkynetHelpers is a convenience function that wraps all of the Skynet
@@ -133,6 +159,9 @@ func TestHandleSkynetError(t *testing.T) {
 func TestSkynetHelpers(t *testing.T) {
 	t.Run("BuildETag", testBuildETag)
 	t.Run("ParseSkylinkURL", testParseSkylinkURL)
+	t.Run("ParseSkylinkURLInvariants", testParseSkylinkURLInvariants)
+	t.Run("ParseSkylinkURLStyles", testParseSkylinkURLStyles)
+	t.Run("ParseSkylinkURLProperties", testParseSkylinkURLProperties)
 	t.Run("ParseUploadRequestParameters", testParseUploadRequestParameters)
 	t.Run("ParseDownloadRequestParameters", testParseDownloadRequestParameters)
 }
@@ -182,8 +211,10 @@ func testBuildETag(t *testing.T) {
 
 // testParseSkylinkURL is a table test for the parseSkylinkUrl function.
 func testParseSkylinkURL(t *testing.T) {
+	b32 := "400bk2i89lheb6d8olltc2grqgfaqfge1im134ed6q1ro0g0fbnk1to"
 	tests := []struct {
 		name                 string
+		host                 string
 		strToParse           string
 		skylink              string
 		skylinkStringNoQuery string
@@ -279,11 +310,65 @@ func testParseSkylinkURL(t *testing.T) {
 			path:                 "",
 			errMsg:               skymodules.ErrSkylinkIncorrectSize.Error(),
 		},
+		{
+			name:                 "subdomain no path",
+			host:                 b32 + ".siasky.net",
+			strToParse:           "/",
+			skylink:              "IAC6CkhNYuWZqMVr1gob1B6tPg4MrBGRzTaDvAIAeu9A9w",
+			skylinkStringNoQuery: "/",
+			path:                 "/",
+			errMsg:               "",
+		},
+		{
+			name:                 "subdomain empty path",
+			host:                 b32 + ".siasky.net",
+			strToParse:           "",
+			skylink:              "IAC6CkhNYuWZqMVr1gob1B6tPg4MrBGRzTaDvAIAeu9A9w",
+			skylinkStringNoQuery: "",
+			path:                 "/",
+			errMsg:               "",
+		},
+		{
+			name:                 "subdomain with port, upper case and path to file with query",
+			host:                 strings.ToUpper(b32) + ".siasky.net:9980",
+			strToParse:           "/foo/bar%3F.baz?foobar=nope",
+			skylink:              "IAC6CkhNYuWZqMVr1gob1B6tPg4MrBGRzTaDvAIAeu9A9w",
+			skylinkStringNoQuery: "/foo/bar%3F.baz",
+			path:                 "/foo/bar?.baz",
+			errMsg:               "",
+		},
+		{
+			name:                 "subdomain with api route",
+			host:                 b32 + ".siasky.net",
+			strToParse:           "/skynet/skylink/AABEKWZ_wc2R9qlhYkzbG8mImFVi08kBu1nsvvwPLBtpEg/foo",
+			skylink:              "AABEKWZ_wc2R9qlhYkzbG8mImFVi08kBu1nsvvwPLBtpEg",
+			skylinkStringNoQuery: "AABEKWZ_wc2R9qlhYkzbG8mImFVi08kBu1nsvvwPLBtpEg/foo",
+			path:                 "/foo",
+			errMsg:               "",
+		},
+		{
+			name:                 "base64 subdomain",
+			host:                 "IAC6CkhNYuWZqMVr1gob1B6tPg4MrBGRzTaDvAIAeu9A9w.siasky.net",
+			strToParse:           "/foo",
+			skylink:              "",
+			skylinkStringNoQuery: "",
+			path:                 "",
+			errMsg:               skymodules.ErrSkylinkIncorrectSize.Error(),
+		},
+		{
+			name:                 "invalid subdomain",
+			host:                 "siasky.net",
+			strToParse:           "/foo",
+			skylink:              "",
+			skylinkStringNoQuery: "",
+			path:                 "",
+			errMsg:               skymodules.ErrSkylinkIncorrectSize.Error(),
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			skylink, skylinkStringNoQuery, path, err := parseSkylinkURL(tt.strToParse, "/skynet/skylink/")
+			skylink, skylinkStringNoQuery, path, err := parseSkylinkURL(tt.host, tt.strToParse, "/skynet/skylink/")
 			// Is there an actual or expected error?
 			if err != nil || tt.errMsg != "" {
 				// Actual err should contain expected err.
@@ -307,6 +392,168 @@ func testParseSkylinkURL(t *testing.T) {
 	}
 }
 
+// testParseSkylinkURLInvariants checks the invariants of the components
+// parsed by parseSkylinkURL for a range of valid and malformed hosts and URLs.
+func testParseSkylinkURLInvariants(t *testing.T) {
+	t.Parallel()
+
+	b32 := "400bk2i89lheb6d8olltc2grqgfaqfge1im134ed6q1ro0g0fbnk1to"
+	tests := []struct {
+		host       string
+		skylinkURL string
+	}{
+		{"", "/skynet/skylink/IAC6CkhNYuWZqMVr1gob1B6tPg4MrBGRzTaDvAIAeu9A9w/foo%3Fbar?foobar=nope"},
+		{"", "IAC6CkhNYuWZqMVr1gob1B6tPg4MrBGRzTaDvAIAeu9A9w/"},
+		{"", "/skynet/skylink/IAC6CkhNYuWZqMVr1gob1B6tPg4MrBGRzTaDvAIAeu9A9w?"},
+		{"", "/skynet/skylink/IAC6CkhNYuWZqMVr1gob1B6tPg4MrBGRzTaDvAIAeu9A9w//foo//"},
+		{"", "/skynet/skylink/IAC6CkhNYuWZqMVr1gob1B6tPg4MrBGRzTaDvAIAeu9A9w/%zz"},
+		{"", "/skynet/skylink/notaskylink/foo"},
+		{"", ""},
+		{"", "?"},
+		{b32 + ".siasky.net", "/foo/bar.baz?foo=bar"},
+		{b32 + ".siasky.net:443", ""},
+		{b32 + ".siasky.net", "/%zz"},
+		{strings.ToUpper(b32) + ".siasky.net", "/foo"},
+		{b32[1:] + ".siasky.net", "/foo"},
+		{"siasky.net", "/%zz"},
+		{"siasky.net:443", "/foo?bar"},
+		{"000000000000000000000\r000000000000000000000000000000000", "0"},
+	}
+	for _, test := range tests {
+		if err := checkParseSkylinkURLInvariants(test.host, test.skylinkURL); err != nil {
+			t.Fatal(err, test)
+		}
+	}
+}
+
+// checkParseSkylinkURLInvariants parses the given host and URL and checks the
+// invariants of the parsed components.
+func checkParseSkylinkURLInvariants(host, skylinkURL string) error {
+	skylink, skylinkStringNoQuery, path, err := parseSkylinkURL(host, skylinkURL, "/skynet/skylink/")
+	if err != nil {
+		if skylink != (skymodules.Skylink{}) || skylinkStringNoQuery != "" || path != "" {
+			return errors.New("components should be empty on error")
+		}
+		return nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("path should start with a slash %q", path)
+	}
+	if strings.Contains(skylinkStringNoQuery, "?") {
+		return fmt.Errorf("skylink string shouldn't contain a query %q", skylinkStringNoQuery)
+	}
+	if !strings.Contains(skylinkURL, skylinkStringNoQuery) {
+		return fmt.Errorf("skylink string should be part of the URL %q", skylinkStringNoQuery)
+	}
+	// The skylink can be loaded from its string representation.
+	var sl skymodules.Skylink
+	if err := sl.LoadString(skylink.String()); err != nil || sl != skylink {
+		return errors.AddContext(err, "skylink doesn't round trip")
+	}
+	return nil
+}
+
+// testParseSkylinkURLStyles checks that parseSkylinkURL parses equivalent
+// path-style and subdomain-style URLs the same way.
+func testParseSkylinkURLStyles(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		rawSkylink []byte
+		subpath    string
+	}{
+		{append([]byte{1, 0}, make([]byte, 32)...), ""},
+		{append([]byte{1, 0}, make([]byte, 32)...), "foo/bar.baz"},
+		{append([]byte{1, 0}, bytes.Repeat([]byte{7}, 32)...), "foo?bar/ +%"},
+		{append([]byte{1, 0}, fastrand.Bytes(32)...), "dir/index.html"},
+		{append([]byte{1, 0}, fastrand.Bytes(32)...), "ünïcödé/#frag"},
+	}
+	for _, test := range tests {
+		var skylink skymodules.Skylink
+		if err := skylink.LoadBytes(test.rawSkylink); err != nil {
+			t.Fatal(err)
+		}
+		host := strings.ToLower(skylink.Base32EncodedString()) + ".siasky.net"
+		if err := checkParseSkylinkURLStyles(skylink, host, test.subpath); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// checkParseSkylinkURLStyles checks that parseSkylinkURL parses the path-style
+// URL of the given skylink and subpath the same way as the subdomain-style URL
+// with the given host.
+func checkParseSkylinkURLStyles(skylink skymodules.Skylink, host, subpath string) error {
+	expectedPath := "/"
+	escapedPath := ""
+	if subpath != "" {
+		expectedPath += subpath
+		escapedPath = "/" + url.QueryEscape(subpath)
+	}
+
+	// Path-style.
+	sl, _, path, err := parseSkylinkURL("localhost:9980", "/skynet/skylink/"+skylink.String()+escapedPath+"?foo=bar", "/skynet/skylink/")
+	if err != nil {
+		return err
+	}
+	if sl != skylink || path != expectedPath {
+		return fmt.Errorf("wrong path-style result %v %q, expected %v %q", sl, path, skylink, expectedPath)
+	}
+
+	// Subdomain-style.
+	sl, skylinkStringNoQuery, path, err := parseSkylinkURL(host, escapedPath+"?foo=bar", "/skynet/skylink/")
+	if err != nil {
+		return err
+	}
+	if sl != skylink || path != expectedPath || skylinkStringNoQuery != escapedPath {
+		return fmt.Errorf("wrong subdomain-style result %v %q %q, expected %v %q", sl, path, skylinkStringNoQuery, skylink, expectedPath)
+	}
+	return nil
+}
+
+// testParseSkylinkURLProperties checks the invariants of parseSkylinkURL for
+// random inputs. The module targets go 1.13 which doesn't support native fuzz
+// tests, so testing/quick generates the inputs instead.
+func testParseSkylinkURLProperties(t *testing.T) {
+	t.Parallel()
+
+	// Arbitrary hosts and URLs.
+	arbitrary := func(host, skylinkURL string) bool {
+		return checkParseSkylinkURLInvariants(host, skylinkURL) == nil
+	}
+	if err := quick.Check(arbitrary, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Random base32 labels with random casing, ports and paths. The
+	// subdomain-style URL must be parsed like the path-style URL.
+	styles := func(rawSkylink [32]byte, caseMask []bool, port uint16, subpath string) bool {
+		var skylink skymodules.Skylink
+		if err := skylink.LoadBytes(append([]byte{1, 0}, rawSkylink[:]...)); err != nil {
+			return false
+		}
+		label := []byte(strings.ToLower(skylink.Base32EncodedString()))
+		for i := range label {
+			if i < len(caseMask) && caseMask[i] {
+				label[i] = strings.ToUpper(string(label[i]))[0]
+			}
+		}
+		host := string(label) + ".siasky.net"
+		if port > 0 {
+			host += ":" + strconv.Itoa(int(port))
+		}
+		if err := checkParseSkylinkURLStyles(skylink, host, subpath); err != nil {
+			t.Log(err)
+			return false
+		}
+		return checkParseSkylinkURLInvariants(host, subpath) == nil &&
+			checkParseSkylinkURLInvariants("", "/skynet/skylink/"+skylink.String()+"/"+subpath) == nil
+	}
+	if err := quick.Check(styles, &quick.Config{MaxCount: 1000}); err != nil {
+		t.Fatal(err)
+	}
+}
+
 // testParseUploadRequestParameters verifies the functionality of
 // 'parseUploadHeadersAndRequestParameters'.
 func testParseUploadRequestParameters(t *testing.T) {
@@ -340,7 +587,7 @@ func testParseUploadRequestParameters(t *testing.T) {
 		if req.Header.Get("Content-Type") == "" {
 			req.Header.Set("Content-Type", "application/octet-stream")
 		}
-		headers, params, err := parseUploadHeadersAndRequestParameters(req, ps)
+		headers, params, err := parseUploadHeadersAndRequestParameters(req, ps, skymodules.SkyfileUploadLimits{})
 		if err != nil {
 			return nil, nil, err
 		}
@@ -367,7 +614,7 @@ func testParseUploadRequestParameters(t *testing.T) {
 
 	// verify 'Skynet-Disable-Force' - combo with 'force'
 	req = buildRequest(url.Values{"force": trueStr}, hdrs)
-	_, _, err = parseUploadHeadersAndRequestParameters(req, defaultParams)
+	_, _, err = parseUploadHeadersAndRequestParameters(req, defaultParams, skymodules.SkyfileUploadLimits{})
 	if err == nil {
 		t.Fatal("Unexpected")
 	}
@@ -404,7 +651,7 @@ func testParseUploadRequestParameters(t *testing.T) {
 
 	// verify 'convertpath' - combo with 'filename
 	req = buildRequest(url.Values{"convertpath": []string{"/foo/bar"}, "filename": []string{"foo.txt"}}, http.Header{})
-	_, _, err = parseUploadHeadersAndRequestParameters(req, defaultParams)
+	_, _, err = parseUploadHeadersAndRequestParameters(req, defaultParams, skymodules.SkyfileUploadLimits{})
 	if err == nil {
 		t.Fatal("Unexpected")
 	}
@@ -421,7 +668,7 @@ func testParseUploadRequestParameters(t *testing.T) {
 
 	// verify 'defaultpath' - combo with a non-multipart request
 	req = buildRequest(url.Values{"defaultpath": []string{"/foo/bar.txt"}}, http.Header{"Content-Type": []string{"text/html"}})
-	_, _, err = parseUploadHeadersAndRequestParameters(req, defaultParams)
+	_, _, err = parseUploadHeadersAndRequestParameters(req, defaultParams, skymodules.SkyfileUploadLimits{})
 	if err == nil {
 		t.Fatal("Unexpected")
 	}
@@ -438,14 +685,14 @@ func testParseUploadRequestParameters(t *testing.T) {
 
 	// verify 'disabledefaultpath' - combo with 'defaultpath'
 	req = buildRequest(url.Values{"defaultpath": []string{"/foo/bar.txt"}, "disabledefaultpath": trueStr}, http.Header{"Content-Type": contentTypeStr})
-	_, _, err = parseUploadHeadersAndRequestParameters(req, defaultParams)
+	_, _, err = parseUploadHeadersAndRequestParameters(req, defaultParams, skymodules.SkyfileUploadLimits{})
 	if err == nil {
 		t.Fatal("Unexpected")
 	}
 
 	// verify 'disabledefaultpath' - combo with a non-multipart request
 	req = buildRequest(url.Values{"disabledefaultpath": trueStr}, http.Header{"Content-Type": []string{"text/html"}})
-	_, _, err = parseUploadHeadersAndRequestParameters(req, defaultParams)
+	_, _, err = parseUploadHeadersAndRequestParameters(req, defaultParams, skymodules.SkyfileUploadLimits{})
 	if err == nil {
 		t.Fatal("Unexpected")
 	}
@@ -502,12 +749,12 @@ func testParseUploadRequestParameters(t *testing.T) {
 	// verify that 'tryfiles' cannot be combined with 'defaultpath' or
 	// 'disabledefaultpath'
 	req = buildRequest(url.Values{"tryfiles": []string{"[\"index.html\"]"}, "defaultpath": skymodules.DefaultTryFilesValue}, http.Header{"Content-type": []string{"text/html"}})
-	_, params, err = parseUploadHeadersAndRequestParameters(req, defaultParams)
+	_, params, err = parseUploadHeadersAndRequestParameters(req, defaultParams, skymodules.SkyfileUploadLimits{})
 	if err == nil || !strings.Contains(err.Error(), "defaultpath and disabledefaultpath are not compatible with tryfiles") {
 		t.Fatal("Unexpected", err)
 	}
 	req = buildRequest(url.Values{"tryfiles": []string{"[\"index.html\"]"}, "disabledefaultpath": trueStr}, http.Header{"Content-type": []string{"text/html"}})
-	_, params, err = parseUploadHeadersAndRequestParameters(req, defaultParams)
+	_, params, err = parseUploadHeadersAndRequestParameters(req, defaultParams, skymodules.SkyfileUploadLimits{})
 	if err == nil || !strings.Contains(err.Error(), "defaultpath and disabledefaultpath are not compatible with tryfiles") {
 		t.Fatal("Unexpected", err)
 	}
@@ -544,7 +791,7 @@ func testParseUploadRequestParameters(t *testing.T) {
 
 	// verify 'force' - combo with 'dryrun
 	req = buildRequest(url.Values{"force": trueStr, "dryrun": trueStr}, http.Header{"Content-type": []string{"text/html"}})
-	_, _, err = parseUploadHeadersAndRequestParameters(req, defaultParams)
+	_, _, err = parseUploadHeadersAndRequestParameters(req, defaultParams, skymodules.SkyfileUploadLimits{})
 	if err == nil {
 		t.Fatal("Unexpected")
 	}
@@ -623,10 +870,24 @@ func testParseUploadRequestParameters(t *testing.T) {
 
 	// verify 'skykeyid' - combo with 'skykeyname'
 	req = buildRequest(url.Values{"skykeyname": []string{key.Name}, "skykeyid": []string{key.ID().ToString()}}, http.Header{"Content-type": []string{"text/html"}})
-	_, _, err = parseUploadHeadersAndRequestParameters(req, defaultParams)
+	_, _, err = parseUploadHeadersAndRequestParameters(req, defaultParams, skymodules.SkyfileUploadLimits{})
 	if err == nil {
 		t.Fatal("Unexpected")
 	}
+
+	// verify the max upload size
+	limits := skymodules.SkyfileUploadLimits{MaxUploadSize: 100}
+	req = buildRequest(url.Values{}, http.Header{"Content-type": []string{"text/html"}})
+	req.ContentLength = 100
+	_, _, err = parseUploadHeadersAndRequestParameters(req, defaultParams, limits)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	req.ContentLength = 101
+	_, _, err = parseUploadHeadersAndRequestParameters(req, defaultParams, limits)
+	if !errors.Contains(err, skymodules.ErrSkyfileUploadTooLarge) {
+		t.Fatal("Unexpected error", err)
+	}
 }
 
 // testParseDownloadRequestParameters verifies the functionality of
@@ -788,6 +1049,94 @@ func testParseDownloadRequestParameters(t *testing.T) {
 		t.Fatal("unexpected")
 	}
 
+	// Test maxcost
+	req, err = buildRequest(url.Values{"maxcost": []string{"2SC"}}, http.Header{"Content-type": []string{"text/html"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sdp, err = parseDownloadRequestParameters(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = baseParams()
+	expected.overdrive.MaxCost = types.SiacoinPrecision.Mul64(2)
+	if !reflect.DeepEqual(sdp, expected) {
+		t.Log("skyfileDownloadParams", sdp)
+		t.Log("expected", expected)
+		t.Fatal("unexpected")
+	}
+	req, err = buildRequest(url.Values{"maxcost": []string{"2"}}, http.Header{"Content-type": []string{"text/html"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parseDownloadRequestParameters(req)
+	if err == nil || !strings.Contains(err.Error(), "maxcost") {
+		t.Fatal("expected maxcost without units to fail", err)
+	}
+
+	// Test signed URL params
+	signature := fastrand.Bytes(32)
+	req, err = buildRequest(url.Values{
+		"expires":   []string{"1234567890"},
+		"signature": []string{hex.EncodeToString(signature)},
+	}, http.Header{"Content-type": []string{"text/html"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sdp, err = parseDownloadRequestParameters(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = baseParams()
+	expected.access = skymodules.SkylinkAccess{
+		Expires:   time.Unix(1234567890, 0),
+		Signature: signature,
+	}
+	if !reflect.DeepEqual(sdp, expected) {
+		t.Log("skyfileDownloadParams", sdp)
+		t.Log("expected", expected)
+		t.Fatal("unexpected")
+	}
+
+	// Test hash and verify params
+	req, err = buildRequest(url.Values{
+		"hash":   []string{"SHA256"},
+		"verify": trueStr,
+	}, http.Header{"Content-type": []string{"text/html"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sdp, err = parseDownloadRequestParameters(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = baseParams()
+	expected.hash = SkynetContentHashSHA256
+	expected.verify = true
+	if !reflect.DeepEqual(sdp, expected) {
+		t.Log("skyfileDownloadParams", sdp)
+		t.Log("expected", expected)
+		t.Fatal("unexpected")
+	}
+	req, err = buildRequest(url.Values{"hash": []string{"md5"}}, http.Header{"Content-type": []string{"text/html"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parseDownloadRequestParameters(req)
+	if err != errUnknownContentHash {
+		t.Fatalf("Expected error '%v' but got '%v'", errUnknownContentHash, err)
+	}
+
+	// Test incomplete signed URL params
+	req, err = buildRequest(url.Values{"expires": []string{"1234567890"}}, http.Header{"Content-type": []string{"text/html"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parseDownloadRequestParameters(req)
+	if err != errIncompleteSignedURL {
+		t.Fatalf("Expected error '%v' but got '%v'", errIncompleteSignedURL, err)
+	}
+
 	// Test range params
 	var rangeTests = []struct {
 		start     string
@@ -1072,7 +1421,7 @@ func TestCustomErrorWriter(t *testing.T) {
 	}
 	streamer := renter.SkylinkStreamerFromSlice(data, meta, rawMD, skymodules.Skylink{}, skymodules.SkyfileLayout{})
 
-	ew := newCustomErrorWriter(meta, streamer)
+	ew := newCustomErrorWriter(meta, streamer, nil)
 	w := newTestHTTPWriter()
 
 	// test all errorpage codes
@@ -1192,3 +1541,251 @@ func TestParseTimeout(t *testing.T) {
 		})
 	}
 }
+
+// TestServeArchiveContentTypes verifies that serveArchive records the content
+// types of the archived files after applying the overrides.
+func TestServeArchiveContentTypes(t *testing.T) {
+	t.Parallel()
+
+	data := []byte("<html></html>binary")
+	md := skymodules.SkyfileMetadata{
+		Filename: t.Name(),
+		Length:   uint64(len(data)),
+		Subfiles: skymodules.SkyfileSubfiles{
+			"index.html": skymodules.SkyfileSubfileMetadata{Filename: "index.html", ContentType: "text/html", Offset: 0, Len: 13, FileMode: 0644},
+			"app.wasm":   skymodules.SkyfileSubfileMetadata{Filename: "app.wasm", ContentType: "application/octet-stream", Offset: 13, Len: 6, FileMode: 0644},
+		},
+	}
+	cto := skymodules.ContentTypeOverrides{".wasm": "application/wasm"}
+	expected := map[string]string{
+		"index.html": "text/html",
+		"app.wasm":   "application/wasm",
+	}
+
+	// Check the tar archive.
+	w := httptest.NewRecorder()
+	err := serveArchive(w, httptest.NewRequest(http.MethodGet, "/", nil), bytes.NewReader(data), skymodules.SkyfileFormatTar, md, cto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(w.Body)
+	for {
+		header, err := tr.Next()
+		if errors.Contains(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if ct := header.PAXRecords[ArchiveContentTypePAXRecord]; ct != expected[header.Name] {
+			t.Fatalf("expected content type %v for %v but got %v", expected[header.Name], header.Name, ct)
+		}
+	}
+
+	// Check the zip archive.
+	w = httptest.NewRecorder()
+	err = serveArchive(w, httptest.NewRequest(http.MethodGet, "/", nil), bytes.NewReader(data), skymodules.SkyfileFormatZip, md, cto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zr.File) != len(expected) {
+		t.Fatal("unexpected number of files", len(zr.File))
+	}
+	for _, f := range zr.File {
+		if f.Comment != expected[f.Name] {
+			t.Fatalf("expected content type %v for %v but got %v", expected[f.Name], f.Name, f.Comment)
+		}
+	}
+}
+
+// TestServeArchiveReproducible verifies that serveArchive produces identical
+// archives for the same skyfile and records the modification times of the
+// archived files.
+func TestServeArchiveReproducible(t *testing.T) {
+	t.Parallel()
+
+	modified := time.Date(2021, time.March, 4, 5, 6, 7, 0, time.UTC)
+	data := []byte("<html></html>binary")
+	md := skymodules.SkyfileMetadata{
+		Filename: t.Name(),
+		Length:   uint64(len(data)),
+		Subfiles: skymodules.SkyfileSubfiles{
+			"index.html": skymodules.SkyfileSubfileMetadata{Filename: "index.html", ContentType: "text/html", Offset: 0, Len: 13, FileMode: 0644, Modified: modified.Unix()},
+			"app.wasm":   skymodules.SkyfileSubfileMetadata{Filename: "app.wasm", Offset: 13, Len: 6, FileMode: 0644},
+			"b.empty":    skymodules.SkyfileSubfileMetadata{Filename: "b.empty", Offset: 19, FileMode: 0644},
+			"a.empty":    skymodules.SkyfileSubfileMetadata{Filename: "a.empty", Offset: 19, FileMode: 0644},
+		},
+	}
+	expectedOrder := []string{"index.html", "app.wasm", "a.empty", "b.empty"}
+
+	for _, format := range []skymodules.SkyfileFormat{skymodules.SkyfileFormatTar, skymodules.SkyfileFormatTarGz, skymodules.SkyfileFormatZip} {
+		// Serve the archive twice, the archives must be identical.
+		var archives [][]byte
+		for i := 0; i < 2; i++ {
+			w := httptest.NewRecorder()
+			err := serveArchive(w, httptest.NewRequest(http.MethodGet, "/", nil), bytes.NewReader(data), format, md, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			archives = append(archives, w.Body.Bytes())
+		}
+		if !bytes.Equal(archives[0], archives[1]) {
+			t.Fatal(format, "archives are not identical")
+		}
+
+		// Check the order and the modification times of the files.
+		var names []string
+		modTimes := make(map[string]time.Time)
+		if format == skymodules.SkyfileFormatZip {
+			zr, err := zip.NewReader(bytes.NewReader(archives[0]), int64(len(archives[0])))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, f := range zr.File {
+				names = append(names, f.Name)
+				modTimes[f.Name] = f.Modified
+			}
+		} else {
+			var r io.Reader = bytes.NewReader(archives[0])
+			if format == skymodules.SkyfileFormatTarGz {
+				gzr, err := gzip.NewReader(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				r = gzr
+			}
+			tr := tar.NewReader(r)
+			for {
+				header, err := tr.Next()
+				if errors.Contains(err, io.EOF) {
+					break
+				} else if err != nil {
+					t.Fatal(err)
+				}
+				names = append(names, header.Name)
+				modTimes[header.Name] = header.ModTime
+			}
+		}
+		if !reflect.DeepEqual(names, expectedOrder) {
+			t.Fatal(format, "unexpected order", names)
+		}
+		if !modTimes["index.html"].Equal(modified) {
+			t.Fatal(format, "unexpected modification time", modTimes["index.html"])
+		}
+		epoch := time.Unix(0, 0)
+		if format == skymodules.SkyfileFormatZip {
+			epoch = zipEpoch
+		}
+		if !modTimes["app.wasm"].Equal(epoch) {
+			t.Fatal(format, "unexpected modification time", modTimes["app.wasm"])
+		}
+	}
+}
+
+// TestServeZip64 verifies that serveZip can archive files larger than 4 GiB.
+func TestServeZip64(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Archive a file of zeros which doesn't fit the 32 bit size fields of a
+	// zip. Only the beginning and the end of the archive are kept.
+	size := uint64(1<<32 + 10)
+	files := []skymodules.SkyfileSubfileMetadata{
+		{Filename: "small", Len: 10},
+		{Filename: "large", Offset: 10, Len: size},
+	}
+	src := io.LimitReader(zeroReader{}, int64(size+10))
+	dst := &headTailWriter{}
+	err := serveZip(dst, src, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The central directory must record the full size of the large file.
+	zr, err := zip.NewReader(dst, dst.n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zr.File) != 2 {
+		t.Fatal("unexpected number of files", len(zr.File))
+	}
+	if zr.File[0].UncompressedSize64 != 10 {
+		t.Fatal("unexpected size", zr.File[0].UncompressedSize64)
+	}
+	if zr.File[1].Name != "large" || zr.File[1].UncompressedSize64 != size {
+		t.Fatal("unexpected file", zr.File[1].Name, zr.File[1].UncompressedSize64)
+	}
+	if zr.File[1].UncompressedSize != math.MaxUint32 {
+		t.Fatal("large file isn't using zip64", zr.File[1].UncompressedSize)
+	}
+}
+
+// zeroReader is an io.Reader which reads zeros.
+type zeroReader struct{}
+
+// Read implements io.Reader.
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+// headTailWriter is an io.Writer which only keeps the beginning and the end of
+// the data written to it. It implements io.ReaderAt by returning zeros for the
+// data in between.
+type headTailWriter struct {
+	head []byte
+	tail []byte
+	n    int64
+}
+
+// headTailSize is the number of bytes a headTailWriter keeps at the beginning
+// and the end.
+const headTailSize = 1 << 16
+
+// Write implements io.Writer.
+func (w *headTailWriter) Write(p []byte) (int, error) {
+	if missing := headTailSize - len(w.head); missing > 0 {
+		if missing > len(p) {
+			missing = len(p)
+		}
+		w.head = append(w.head, p[:missing]...)
+	}
+	w.tail = append(w.tail, p...)
+	if len(w.tail) > 2*headTailSize {
+		w.tail = append([]byte{}, w.tail[len(w.tail)-headTailSize:]...)
+	}
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// ReadAt implements io.ReaderAt.
+func (w *headTailWriter) ReadAt(p []byte, off int64) (int, error) {
+	if off >= w.n {
+		return 0, io.EOF
+	}
+	tailStart := w.n - int64(len(w.tail))
+	n := 0
+	for n < len(p) && off < w.n {
+		switch {
+		case off < int64(len(w.head)):
+			p[n] = w.head[off]
+		case off >= tailStart:
+			p[n] = w.tail[off-tailStart]
+		default:
+			p[n] = 0
+		}
+		n++
+		off++
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
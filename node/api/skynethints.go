@@ -0,0 +1,107 @@
+package api
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+const (
+	// maxSkynetEarlyHints is the max number of subfiles which are hinted at
+	// when serving the default path of a skyfile.
+	maxSkynetEarlyHints = 16
+)
+
+// skynetEarlyHintsLinks returns the Link header values which preload the
+// stylesheets and scripts among the subfiles of a skyfile. The served subfile
+// is excluded. The links are relative to the root of the skyfile.
+func skynetEarlyHintsLinks(md skymodules.SkyfileMetadata, servedPath string, cto skymodules.ContentTypeOverrides) []string {
+	// Sort the subfiles to hint at the same subfiles on every request.
+	filenames := make([]string, 0, len(md.Subfiles))
+	for filename := range md.Subfiles {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	servedPath = strings.TrimPrefix(servedPath, "/")
+	var links []string
+	for _, filename := range filenames {
+		sf := md.Subfiles[filename]
+		if sf.Filename == servedPath {
+			continue
+		}
+		dest := skynetPreloadDestination(sf.Filename, cto.ContentType(sf.Filename, sf.ContentType))
+		if dest == "" {
+			continue
+		}
+		links = append(links, fmt.Sprintf("<./%s>; rel=preload; as=%s", escapeSkyfilePath(sf.Filename), dest))
+		if len(links) == maxSkynetEarlyHints {
+			break
+		}
+	}
+	return links
+}
+
+// skynetPreloadDestination returns the preload destination of a subfile with
+// the given name and content type or an empty string if it shouldn't be
+// preloaded. The content type is guessed from the name's extension if the
+// uploader didn't provide a specific one.
+func skynetPreloadDestination(filename, contentType string) string {
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = mime.TypeByExtension(path.Ext(filename))
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	switch mediaType {
+	case "text/css":
+		return "style"
+	case "text/javascript", "application/javascript", "application/x-javascript", "application/ecmascript":
+		return "script"
+	default:
+		return ""
+	}
+}
+
+// escapeSkyfilePath escapes every segment of the given subfile path for use
+// in a URL.
+func escapeSkyfilePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+	return strings.Join(segments, "/")
+}
+
+// writeSkynetEarlyHints hints the client at the stylesheets and scripts of a
+// skyfile whose HTML default path is served. The hints are attached as Link
+// headers and, depending on the mode, sent ahead of the response in a 103
+// Early Hints response.
+func writeSkynetEarlyHints(w http.ResponseWriter, req *http.Request, mode string, md skymodules.SkyfileMetadata, servedPath string, servedContentType string, cto skymodules.ContentTypeOverrides) {
+	if servedContentType == "" {
+		servedContentType = mime.TypeByExtension(path.Ext(servedPath))
+	}
+	mediaType, _, err := mime.ParseMediaType(servedContentType)
+	if err != nil || mediaType != "text/html" {
+		return
+	}
+	links := skynetEarlyHintsLinks(md, servedPath, cto)
+	if len(links) == 0 {
+		return
+	}
+	for _, link := range links {
+		w.Header().Add("Link", link)
+	}
+	// Only GET requests receive a body which is worth waiting for.
+	if mode == build.SkynetEarlyHints103 && req.Method == http.MethodGet {
+		w.WriteHeader(http.StatusEarlyHints)
+	}
+}
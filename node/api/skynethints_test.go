@@ -0,0 +1,119 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
+	"reflect"
+	"testing"
+
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+// TestSkynetEarlyHintsLinks is a unit test for skynetEarlyHintsLinks.
+func TestSkynetEarlyHintsLinks(t *testing.T) {
+	t.Parallel()
+
+	md := skymodules.SkyfileMetadata{
+		Filename: "skapp",
+		Subfiles: skymodules.SkyfileSubfiles{
+			"index.html":          {Filename: "index.html", ContentType: "text/html"},
+			"main.js":             {Filename: "main.js", ContentType: "application/octet-stream"},
+			"css/style.css":       {Filename: "css/style.css", ContentType: "text/css; charset=utf-8"},
+			"css/other style.css": {Filename: "css/other style.css"},
+			"image.png":           {Filename: "image.png", ContentType: "image/png"},
+			"module.mjs":          {Filename: "module.mjs", ContentType: "text/plain"},
+		},
+	}
+	expected := []string{
+		"<./css/other%20style.css>; rel=preload; as=style",
+		"<./css/style.css>; rel=preload; as=style",
+		"<./main.js>; rel=preload; as=script",
+	}
+	links := skynetEarlyHintsLinks(md, "/index.html", nil)
+	if !reflect.DeepEqual(links, expected) {
+		t.Fatalf("expected %v but got %v", expected, links)
+	}
+
+	// Content type overrides should be respected.
+	cto := skymodules.ContentTypeOverrides{".mjs": "text/javascript"}
+	links = skynetEarlyHintsLinks(md, "/index.html", cto)
+	if len(links) != 4 || links[3] != "<./module.mjs>; rel=preload; as=script" {
+		t.Fatal("override wasn't applied", links)
+	}
+
+	// The number of hints is limited.
+	md.Subfiles = make(skymodules.SkyfileSubfiles)
+	for i := 0; i < 2*maxSkynetEarlyHints; i++ {
+		name := fmt.Sprintf("%02d.css", i)
+		md.Subfiles[name] = skymodules.SkyfileSubfileMetadata{Filename: name}
+	}
+	links = skynetEarlyHintsLinks(md, "/index.html", nil)
+	if len(links) != maxSkynetEarlyHints || links[0] != "<./00.css>; rel=preload; as=style" {
+		t.Fatal("unexpected links", links)
+	}
+}
+
+// TestWriteSkynetEarlyHints verifies that the hints are sent in a 103 Early
+// Hints response and attached to the final response.
+func TestWriteSkynetEarlyHints(t *testing.T) {
+	t.Parallel()
+
+	md := skymodules.SkyfileMetadata{
+		Subfiles: skymodules.SkyfileSubfiles{
+			"index.html": {Filename: "index.html"},
+			"style.css":  {Filename: "style.css"},
+		},
+	}
+	expected := []string{"<./style.css>; rel=preload; as=style"}
+
+	for _, mode := range []string{build.SkynetEarlyHintsLink, build.SkynetEarlyHints103} {
+		mode := mode
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			writeSkynetEarlyHints(w, req, mode, md, "/index.html", "", nil)
+			_, _ = w.Write([]byte("<html></html>"))
+		}))
+
+		var hints [][]string
+		trace := &httptrace.ClientTrace{
+			Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+				if code == http.StatusEarlyHints {
+					hints = append(hints, header["Link"])
+				}
+				return nil
+			},
+		}
+		req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		srv.Close()
+
+		if !reflect.DeepEqual(resp.Header["Link"], expected) {
+			t.Fatalf("%v: expected %v but got %v", mode, expected, resp.Header["Link"])
+		}
+		if mode == build.SkynetEarlyHintsLink && len(hints) != 0 {
+			t.Fatal("no early hints should be sent", hints)
+		}
+		if mode == build.SkynetEarlyHints103 && (len(hints) != 1 || !reflect.DeepEqual(hints[0], expected)) {
+			t.Fatal("expected a single early hints response", hints)
+		}
+	}
+
+	// Non-HTML files don't receive any hints.
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	writeSkynetEarlyHints(rec, req, build.SkynetEarlyHints103, md, "/style.css", "", nil)
+	if len(rec.Header()["Link"]) != 0 {
+		t.Fatal("non-HTML file shouldn't receive hints")
+	}
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/modules"
+)
+
+type (
+	// SkynetImportPOST is the response that the api returns after the
+	// /skynet/import POST endpoint has been used.
+	SkynetImportPOST struct {
+		Skylink string `json:"skylink"`
+	}
+)
+
+// skynetImportHandlerPOST handles the POST calls to /skynet/import/:skylink
+// which fetch a skyfile from another portal, verify it against the skylink
+// and upload and pin it using the renter's contracts.
+func (api *API) skynetImportHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	var skylink skymodules.Skylink
+	err := skylink.LoadString(ps.ByName("skylink"))
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("error parsing skylink: %v", err)}, http.StatusBadRequest)
+		return
+	}
+
+	// Parse the portal.
+	portalStr := req.FormValue("portal")
+	if portalStr == "" {
+		WriteError(w, Error{"'portal' parameter is required"}, http.StatusBadRequest)
+		return
+	}
+	portal, err := url.Parse(portalStr)
+	if err != nil || (portal.Scheme != "http" && portal.Scheme != "https") || portal.Host == "" {
+		WriteError(w, Error{fmt.Sprintf("invalid 'portal' parameter '%v', expected an http(s) url", portalStr)}, http.StatusBadRequest)
+		return
+	}
+	portalURL := strings.TrimSuffix(portal.String(), "/")
+
+	// Resolve V2 skylinks locally. The import preserves the V1 skylink.
+	ctx := req.Context()
+	if skylink.IsSkylinkV2() {
+		skylink, _, err = api.renter.ResolveSkylinkV2(ctx, skylink)
+		if err != nil {
+			handleSkynetError(w, "failed to resolve skylink", err)
+			return
+		}
+	}
+
+	// Fetch the base sector from the portal.
+	body, err := fetchFromPortal(ctx, fmt.Sprintf("%v/skynet/basesector/%v", portalURL, skylink.String()))
+	if err != nil {
+		WriteError(w, Error{"failed to fetch base sector: " + err.Error()}, http.StatusBadGateway)
+		return
+	}
+	baseSector, err := ioutil.ReadAll(io.LimitReader(body, int64(modules.SectorSize)+1))
+	err = errors.Compose(err, body.Close())
+	if err != nil {
+		WriteError(w, Error{"failed to read base sector: " + err.Error()}, http.StatusBadGateway)
+		return
+	}
+
+	// Fetch the content if the skyfile has a fanout. Encrypted base sectors
+	// can't be parsed here so their content is always fetched and their size
+	// is unknown.
+	var content io.Reader
+	size := int64(-1)
+	fetchContent := skymodules.IsEncryptedBaseSector(baseSector)
+	if !fetchContent {
+		sl, _, sm, _, _, err := skymodules.ParseSkyfileMetadata(baseSector)
+		if err != nil {
+			WriteError(w, Error{"failed to parse base sector: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		fetchContent = sl.FanoutSize > 0
+		size = int64(sm.Length)
+	}
+
+	// Enforce the quota of the API token the request authenticated with.
+	var reservation *apiTokenReservation
+	token, hasToken := apiTokenFromContext(ctx)
+	if hasToken {
+		_, _, err = api.managedAPITokenUploadLimit(token.ID, size)
+		if err != nil {
+			handleSkynetError(w, "import rejected", err)
+			return
+		}
+		reservation = api.newAPITokenReservation(token.ID)
+		defer reservation.release()
+		if size >= 0 {
+			err = reservation.reserve(uint64(size))
+			if err != nil {
+				handleSkynetError(w, "import rejected", err)
+				return
+			}
+		}
+	}
+	if fetchContent {
+		body, err := fetchFromPortal(ctx, fmt.Sprintf("%v/skynet/skylink/%v?format=%v", portalURL, skylink.String(), skymodules.SkyfileFormatConcat))
+		if err != nil {
+			WriteError(w, Error{"failed to fetch skyfile content: " + err.Error()}, http.StatusBadGateway)
+			return
+		}
+		defer func() {
+			_ = body.Close()
+		}()
+		content = body
+	}
+
+	// Reserve the content as it's read if the size is unknown.
+	var rr *reservationReader
+	if reservation != nil && size < 0 && content != nil {
+		rr = &reservationReader{
+			Reader:      content,
+			reservation: reservation,
+		}
+		content = rr
+	}
+
+	// Import the skyfile.
+	skylink, err = api.renter.ImportSkyfile(skylink, baseSector, content)
+	if rr != nil && rr.err != nil {
+		handleSkynetError(w, "failed to import skyfile", rr.err)
+		return
+	}
+	if err != nil {
+		handleSkynetError(w, "failed to import skyfile", err)
+		return
+	}
+	if reservation != nil {
+		imported := uint64(size)
+		if rr != nil {
+			imported = rr.n
+		}
+		err = reservation.commit(skylink, imported)
+		if err != nil {
+			handleSkynetError(w, "failed to update api token usage", err)
+			return
+		}
+	}
+	WriteJSON(w, SkynetImportPOST{
+		Skylink: skylink.String(),
+	})
+}
+
+// fetchFromPortal performs a GET request against a portal and returns the
+// body of the response. A non-2xx response returns an error.
+func fetchFromPortal(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Sia-Agent")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		err = fmt.Errorf("portal responded with status %v: %v", resp.StatusCode, strings.TrimSpace(string(msg)))
+		return nil, errors.Compose(err, resp.Body.Close())
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+// skynetProvenanceWriter wraps a http.ResponseWriter to attach the provenance
+// of the served chunks as trailer of the response.
+type skynetProvenanceWriter struct {
+	http.ResponseWriter
+
+	staticStreamer skymodules.SkyfileStreamer
+
+	wroteHeader bool
+}
+
+// newSkynetProvenanceWriter creates a new provenance writer and declares the
+// provenance trailer of the response.
+func newSkynetProvenanceWriter(w http.ResponseWriter, streamer skymodules.SkyfileStreamer) *skynetProvenanceWriter {
+	w.Header().Add("Trailer", SkynetProvenanceHeader)
+	return &skynetProvenanceWriter{
+		ResponseWriter: w,
+		staticStreamer: streamer,
+	}
+}
+
+// WriteHeader implements http.ResponseWriter. The Content-Length header is
+// removed to force a chunked response which supports trailers.
+func (pw *skynetProvenanceWriter) WriteHeader(status int) {
+	if pw.wroteHeader {
+		return
+	}
+	pw.wroteHeader = true
+	pw.Header().Del("Content-Length")
+	pw.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter.
+func (pw *skynetProvenanceWriter) Write(b []byte) (int, error) {
+	if !pw.wroteHeader {
+		pw.WriteHeader(http.StatusOK)
+	}
+	return pw.ResponseWriter.Write(b)
+}
+
+// Finish sets the provenance trailer to the provenance of the chunks which
+// were read from the streamer.
+func (pw *skynetProvenanceWriter) Finish() {
+	provenance := pw.staticStreamer.Provenance()
+	if provenance == nil {
+		provenance = []skymodules.ChunkProvenance{}
+	}
+	b, err := json.Marshal(provenance)
+	if err != nil {
+		return
+	}
+	pw.Header().Set(SkynetProvenanceHeader, string(b))
+}
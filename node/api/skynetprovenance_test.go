@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/types"
+)
+
+// mockProvenanceStreamer is a SkyfileStreamer which only implements
+// Provenance.
+type mockProvenanceStreamer struct {
+	skymodules.SkyfileStreamer
+	provenance []skymodules.ChunkProvenance
+}
+
+// Provenance implements the skymodules.SkyfileStreamer interface.
+func (m *mockProvenanceStreamer) Provenance() []skymodules.ChunkProvenance {
+	return m.provenance
+}
+
+// TestSkynetProvenanceWriter is a unit test for the skynetProvenanceWriter.
+func TestSkynetProvenanceWriter(t *testing.T) {
+	t.Parallel()
+
+	streamer := &mockProvenanceStreamer{}
+	rec := httptest.NewRecorder()
+	pw := newSkynetProvenanceWriter(rec, streamer)
+	pw.Header().Set("Content-Length", "100")
+	data := fastrand.Bytes(100)
+	if _, err := pw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+
+	// The trailer should be declared and the content length removed.
+	if rec.Header().Get("Trailer") != SkynetProvenanceHeader {
+		t.Fatal("trailer not declared")
+	}
+	if rec.Header().Get("Content-Length") != "" {
+		t.Fatal("content length should have been removed")
+	}
+
+	// The provenance is read from the streamer when finishing.
+	streamer.provenance = []skymodules.ChunkProvenance{
+		{
+			ChunkIndex: 1,
+			Pieces: []skymodules.PieceProvenance{
+				{
+					PieceIndex: 2,
+					HostKey:    types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: fastrand.Bytes(32)},
+				},
+			},
+		},
+	}
+	pw.Finish()
+	var provenance []skymodules.ChunkProvenance
+	if err := json.Unmarshal([]byte(rec.Header().Get(SkynetProvenanceHeader)), &provenance); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(provenance, streamer.provenance) {
+		t.Fatal("unexpected provenance", provenance)
+	}
+
+	// Without provenance an empty array is attached.
+	rec = httptest.NewRecorder()
+	pw = newSkynetProvenanceWriter(rec, &mockProvenanceStreamer{})
+	pw.Finish()
+	if p := rec.Header().Get(SkynetProvenanceHeader); p != "[]" {
+		t.Fatal("unexpected provenance", p)
+	}
+}
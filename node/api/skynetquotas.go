@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+type (
+	// SkynetQuotasGET is the response returned by /skynet/quotas [GET].
+	SkynetQuotasGET struct {
+		Folders []skymodules.SkynetFolderUsage `json:"folders"`
+	}
+
+	// SkynetQuotasPOST is the expected format of the json request for
+	// /skynet/quotas [POST].
+	SkynetQuotasPOST struct {
+		Folder string `json:"folder"`
+		Quota  uint64 `json:"quota"`
+	}
+)
+
+// skynetQuotasHandlerGET handles the GET calls to /skynet/quotas. If the
+// 'folder' query parameter is set, only the usage of that folder is returned.
+func (api *API) skynetQuotasHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	if folder := req.FormValue("folder"); folder != "" {
+		usage, err := api.renter.SkynetFolderUsage(folder)
+		if errors.Contains(err, skymodules.ErrInvalidSkynetFolder) {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			WriteError(w, Error{"unable to get the skynet folder usage: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		WriteJSON(w, SkynetQuotasGET{
+			Folders: []skymodules.SkynetFolderUsage{usage},
+		})
+		return
+	}
+
+	usages, err := api.renter.SkynetFolderUsages()
+	if err != nil {
+		WriteError(w, Error{"unable to get the skynet folder usages: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, SkynetQuotasGET{
+		Folders: usages,
+	})
+}
+
+// skynetQuotasHandlerPOST handles the POST calls to /skynet/quotas which set
+// the quota of a top-level folder of the skynet folder.
+func (api *API) skynetQuotasHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Decode request.
+	var params SkynetQuotasPOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"Failed to decode request: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := skymodules.ValidateSkynetFolder(params.Folder); err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	err = api.renter.SetSkynetFolderQuota(params.Folder, params.Quota)
+	if err != nil {
+		WriteError(w, Error{"unable to set skynet folder quota: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// managedSkynetFolderUploadLimit returns the number of bytes which may still
+// be stored under the top-level skynet folder of the given siapath and the
+// error that applies once the limit is exceeded. If an upload of the given
+// size already exceeds the limit, the error is returned right away. A
+// negative size is unknown. Siapaths outside of a top-level skynet folder
+// aren't limited.
+func (api *API) managedSkynetFolderUploadLimit(siaPath skymodules.SiaPath, size int64) (uint64, error, error) {
+	folder, ok := skymodules.SkynetTopLevelFolder(siaPath)
+	if !ok {
+		return math.MaxUint64, nil, nil
+	}
+	usage, err := api.renter.SkynetFolderUsage(folder)
+	if err != nil {
+		return 0, nil, errors.AddContext(err, "unable to get skynet folder usage")
+	}
+	limit, limitErr := usage.UploadLimit()
+	if limitErr != nil && (limit == 0 || (size >= 0 && uint64(size) > limit)) {
+		return 0, nil, limitErr
+	}
+	return limit, limitErr, nil
+}
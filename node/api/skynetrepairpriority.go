@@ -0,0 +1,81 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+type (
+	// SkynetRepairPriorityGET contains the information queried for the
+	// /skynet/repairpriority GET endpoint.
+	SkynetRepairPriorityGET struct {
+		Skylinks []skymodules.SkylinkRepairPriority `json:"skylinks"`
+	}
+)
+
+// skynetRepairPriorityHandlerGET handles the GET calls to
+// /skynet/repairpriority which return the skylinks whose chunks are repaired
+// first.
+func (api *API) skynetRepairPriorityHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	priorities, err := api.renter.SkynetRepairPriorities()
+	if err != nil {
+		WriteError(w, Error{"unable to get repair priorities: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, SkynetRepairPriorityGET{
+		Skylinks: priorities,
+	})
+}
+
+// skynetRepairPriorityHandlerPOST handles the POST calls to
+// /skynet/repairpriority/:skylink which override the repair priority of a
+// skylink or reset it.
+func (api *API) skynetRepairPriorityHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	var skylink skymodules.Skylink
+	err := skylink.LoadString(ps.ByName("skylink"))
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("error parsing skylink: %v", err)}, http.StatusBadRequest)
+		return
+	}
+
+	// Parse the parameters.
+	var reset bool
+	if resetStr := req.FormValue("reset"); resetStr != "" {
+		reset, err = strconv.ParseBool(resetStr)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'reset' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	priorityStr := req.FormValue("priority")
+	if reset && priorityStr != "" {
+		WriteError(w, Error{"'priority' and 'reset' can't be combined"}, http.StatusBadRequest)
+		return
+	}
+	if !reset && priorityStr == "" {
+		WriteError(w, Error{"either 'priority' or 'reset' needs to be specified"}, http.StatusBadRequest)
+		return
+	}
+
+	var priority skymodules.SkylinkRepairPriority
+	if reset {
+		priority, err = api.renter.ResetSkylinkRepairPriority(skylink)
+	} else {
+		var p float64
+		p, err = strconv.ParseFloat(priorityStr, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'priority' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		priority, err = api.renter.SetSkylinkRepairPriority(skylink, p)
+	}
+	if err != nil {
+		WriteError(w, Error{"unable to update repair priority: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, priority)
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+type (
+	// SkynetSkylinksGET is the response that the api returns after the
+	// /skynet/skylinks GET endpoint has been used.
+	SkynetSkylinksGET struct {
+		Skylinks []skymodules.SkylinkInfo `json:"skylinks"`
+		Total    uint64                   `json:"total"`
+	}
+)
+
+// skynetSkylinksHandlerGET handles the GET calls to /skynet/skylinks which
+// list the skylinks known to the node.
+func (api *API) skynetSkylinksHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	queryForm, err := url.ParseQuery(req.URL.RawQuery)
+	if err != nil {
+		WriteError(w, Error{"failed to parse query params"}, http.StatusBadRequest)
+		return
+	}
+	filter, err := parseSkylinksFilter(queryForm)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	skylinks, total, err := api.renter.Skylinks(filter)
+	if errors.Contains(err, skymodules.ErrSkylinksLimitTooHigh) {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"failed to list skylinks: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, SkynetSkylinksGET{
+		Skylinks: skylinks,
+		Total:    total,
+	})
+}
+
+// parseSkylinksFilter parses the filter of the /skynet/skylinks endpoint from
+// the query string.
+func parseSkylinksFilter(queryForm url.Values) (skymodules.SkylinksFilter, error) {
+	var filter skymodules.SkylinksFilter
+
+	// Parse the integer parameters.
+	uints := []struct {
+		name  string
+		value *uint64
+	}{
+		{name: "offset", value: &filter.Offset},
+		{name: "limit", value: &filter.Limit},
+		{name: "minsize", value: &filter.MinSize},
+		{name: "maxsize", value: &filter.MaxSize},
+	}
+	for _, param := range uints {
+		str := queryForm.Get(param.name)
+		if str == "" {
+			continue
+		}
+		v, err := strconv.ParseUint(str, 10, 64)
+		if err != nil {
+			return skymodules.SkylinksFilter{}, fmt.Errorf("unable to parse '%v' parameter: %v", param.name, err)
+		}
+		*param.value = v
+	}
+
+	// Parse the upload time parameters.
+	times := []struct {
+		name  string
+		value *time.Time
+	}{
+		{name: "uploadedafter", value: &filter.UploadedAfter},
+		{name: "uploadedbefore", value: &filter.UploadedBefore},
+	}
+	for _, param := range times {
+		str := queryForm.Get(param.name)
+		if str == "" {
+			continue
+		}
+		unix, err := strconv.ParseInt(str, 10, 64)
+		if err != nil {
+			return skymodules.SkylinksFilter{}, fmt.Errorf("unable to parse '%v' parameter: %v", param.name, err)
+		}
+		*param.value = time.Unix(unix, 0)
+	}
+
+	// Parse the boolean parameters.
+	bools := []struct {
+		name  string
+		value **bool
+	}{
+		{name: "pinned", value: &filter.Pinned},
+		{name: "encrypted", value: &filter.Encrypted},
+	}
+	for _, param := range bools {
+		str := queryForm.Get(param.name)
+		if str == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return skymodules.SkylinksFilter{}, fmt.Errorf("unable to parse '%v' parameter: %v", param.name, err)
+		}
+		*param.value = &b
+	}
+
+	filter.Search = queryForm.Get("search")
+	return filter, nil
+}
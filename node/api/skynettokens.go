@@ -0,0 +1,320 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+type (
+	// SkynetTokensGET is the response returned by /skynet/tokens [GET].
+	SkynetTokensGET struct {
+		Tokens []skymodules.APIToken `json:"tokens"`
+	}
+
+	// SkynetTokensPOST is the expected format of the json request for
+	// /skynet/tokens [POST].
+	SkynetTokensPOST struct {
+		Name  string                   `json:"name"`
+		Scope skymodules.APITokenScope `json:"scope"`
+		Quota skymodules.APITokenQuota `json:"quota"`
+	}
+
+	// SkynetTokenPOST is the response returned by /skynet/tokens [POST]. The
+	// token is only returned once.
+	SkynetTokenPOST struct {
+		skymodules.APIToken
+		Token string `json:"token"`
+	}
+
+	// SkynetTokenUsageGET is the response returned by
+	// /skynet/tokens/:id/usage [GET].
+	SkynetTokenUsageGET struct {
+		Quota skymodules.APITokenQuota `json:"quota"`
+		Usage skymodules.APITokenUsage `json:"usage"`
+	}
+
+	// apiTokenContextKey is the key of the API token a request authenticated
+	// with in the request's context.
+	apiTokenContextKey struct{}
+
+	// apiTokenReservation tracks the bytes an upload or pin reserved of the
+	// quota of an API token. Once the upload succeeded, the bytes are
+	// committed. Otherwise they are released.
+	apiTokenReservation struct {
+		reserved     uint64
+		staticID     string
+		staticRenter skymodules.Renter
+	}
+
+	// quotaReader wraps the reader of an upload and fails the upload once it
+	// exceeds the upload limit of an API token or skynet folder. If a
+	// reservation is set, the data is reserved from the API token's quota as
+	// it is read.
+	quotaReader struct {
+		skymodules.SkyfileUploadReader
+		n           uint64
+		limit       uint64
+		limitErr    error
+		err         error
+		reservation *apiTokenReservation
+	}
+
+	// reservationReader wraps a reader and reserves the data from the quota
+	// of an API token as it is read.
+	reservationReader struct {
+		io.Reader
+		n           uint64
+		err         error
+		reservation *apiTokenReservation
+	}
+)
+
+// newAPITokenReservation creates a new, empty reservation for the API token
+// with the given ID.
+func (api *API) newAPITokenReservation(id string) *apiTokenReservation {
+	return &apiTokenReservation{
+		staticID:     id,
+		staticRenter: api.renter,
+	}
+}
+
+// reserve makes sure that at least the given number of bytes are reserved.
+func (r *apiTokenReservation) reserve(size uint64) error {
+	if size <= r.reserved {
+		return nil
+	}
+	err := r.staticRenter.ReserveAPITokenQuota(r.staticID, size-r.reserved)
+	if err != nil {
+		return err
+	}
+	r.reserved = size
+	return nil
+}
+
+// commit adds the given number of bytes of the reservation to the API token's
+// usage and attributes them to the skylink. Any remaining bytes are released.
+func (r *apiTokenReservation) commit(skylink skymodules.Skylink, size uint64) error {
+	if err := r.reserve(size); err != nil {
+		return err
+	}
+	err := r.staticRenter.CommitAPITokenQuota(r.staticID, skylink, size)
+	r.reserved -= size
+	r.release()
+	return err
+}
+
+// release releases all reserved bytes.
+func (r *apiTokenReservation) release() {
+	if r.reserved == 0 {
+		return
+	}
+	r.staticRenter.ReleaseAPITokenQuota(r.staticID, r.reserved)
+	r.reserved = 0
+}
+
+// Read implements io.Reader.
+func (rr *reservationReader) Read(b []byte) (int, error) {
+	n, err := rr.Reader.Read(b)
+	rr.n += uint64(n)
+	if rerr := rr.reservation.reserve(rr.n); rerr != nil {
+		rr.err = rerr
+		return n, rerr
+	}
+	return n, err
+}
+
+// Read implements io.Reader.
+func (qr *quotaReader) Read(b []byte) (int, error) {
+	n, err := qr.SkyfileUploadReader.Read(b)
+	qr.n += uint64(n)
+	if qr.n > qr.limit {
+		qr.err = qr.limitErr
+		return n, qr.err
+	}
+	if qr.reservation != nil {
+		if rerr := qr.reservation.reserve(qr.n); rerr != nil {
+			qr.err = rerr
+			return n, rerr
+		}
+	}
+	return n, err
+}
+
+// SetReadBuffer implements skymodules.SkyfileUploadReader. The data of the
+// buffer is read again, so it's not counted twice.
+func (qr *quotaReader) SetReadBuffer(data []byte) {
+	qr.n -= uint64(len(data))
+	qr.SkyfileUploadReader.SetReadBuffer(data)
+}
+
+// apiTokenFromContext returns the API token a request authenticated with.
+func apiTokenFromContext(ctx context.Context) (skymodules.APIToken, bool) {
+	token, ok := ctx.Value(apiTokenContextKey{}).(skymodules.APIToken)
+	return token, ok
+}
+
+// apiTokenFromRequest returns the credential of a request. It's either a
+// bearer token or the password of HTTP basic auth.
+func apiTokenFromRequest(req *http.Request) (string, bool) {
+	if auth := req.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer "), true
+	}
+	_, pass, ok := req.BasicAuth()
+	return pass, ok
+}
+
+// requireScope is middleware that requires a request to authenticate with
+// either the password or an API token that grants the given scope. An empty
+// scope is granted by all tokens. The token is attached to the request's
+// context. Empty passwords indicate no authentication is required.
+func (api *API) requireScope(h httprouter.Handle, password string, scope skymodules.APITokenScope) httprouter.Handle {
+	// An empty password is equivalent to no password.
+	if password == "" {
+		return h
+	}
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		token, ok := apiTokenFromRequest(req)
+		if ok && subtle.ConstantTimeCompare([]byte(token), []byte(password)) == 1 {
+			h(w, req, ps)
+			return
+		}
+		if ok && api.renter != nil {
+			t, err := api.renter.ValidateAPIToken(token)
+			if err == nil && (scope == "" || t.Scope.Allows(scope)) {
+				h(w, req.WithContext(context.WithValue(req.Context(), apiTokenContextKey{}, t)), ps)
+				return
+			}
+			if err == nil {
+				WriteError(w, Error{"API token doesn't grant the required scope: " + string(scope)}, http.StatusForbidden)
+				return
+			}
+		}
+		w.Header().Set("WWW-Authenticate", "Basic realm=\"SiaAPI\"")
+		WriteError(w, Error{"API authentication failed."}, http.StatusUnauthorized)
+	}
+}
+
+// skynetTokensHandlerGET handles the GET calls to /skynet/tokens.
+func (api *API) skynetTokensHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	tokens, err := api.renter.APITokens()
+	if err != nil {
+		WriteError(w, Error{"unable to get the api tokens: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, SkynetTokensGET{
+		Tokens: tokens,
+	})
+}
+
+// skynetTokensHandlerPOST handles the POST calls to /skynet/tokens which
+// create a new API token.
+func (api *API) skynetTokensHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Decode request.
+	var params SkynetTokensPOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"Failed to decode request: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := params.Scope.Validate(); err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	token, info, err := api.renter.CreateAPIToken(params.Name, params.Scope, params.Quota)
+	if err != nil {
+		WriteError(w, Error{"unable to create api token: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, SkynetTokenPOST{
+		APIToken: info,
+		Token:    token,
+	})
+}
+
+// skynetTokenRevokeHandlerPOST handles the POST calls to
+// /skynet/tokens/:id/revoke.
+func (api *API) skynetTokenRevokeHandlerPOST(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	err := api.renter.RevokeAPIToken(ps.ByName("id"))
+	if errors.Contains(err, skymodules.ErrAPITokenNotFound) {
+		WriteError(w, Error{err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"unable to revoke api token: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// skynetTokenQuotaHandlerPOST handles the POST calls to
+// /skynet/tokens/:id/quota.
+func (api *API) skynetTokenQuotaHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	// Decode request.
+	var quota skymodules.APITokenQuota
+	err := json.NewDecoder(req.Body).Decode(&quota)
+	if err != nil {
+		WriteError(w, Error{"Failed to decode request: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	err = api.renter.SetAPITokenQuota(ps.ByName("id"), quota)
+	if errors.Contains(err, skymodules.ErrAPITokenNotFound) {
+		WriteError(w, Error{err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"unable to set api token quota: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// skynetTokenUsageHandlerGET handles the GET calls to
+// /skynet/tokens/:id/usage. Tokens may query their own usage, the usage of
+// other tokens requires the admin scope.
+func (api *API) skynetTokenUsageHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	id := ps.ByName("id")
+	if token, ok := apiTokenFromContext(req.Context()); ok && token.ID != id && !token.Scope.Allows(skymodules.APITokenScopeAdmin) {
+		WriteError(w, Error{"API token doesn't grant the required scope: " + string(skymodules.APITokenScopeAdmin)}, http.StatusForbidden)
+		return
+	}
+
+	info, usage, err := api.renter.APITokenUsage(id)
+	if errors.Contains(err, skymodules.ErrAPITokenNotFound) {
+		WriteError(w, Error{err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"unable to get api token usage: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, SkynetTokenUsageGET{
+		Quota: info.Quota,
+		Usage: usage,
+	})
+}
+
+// managedAPITokenUploadLimit returns the number of bytes the API token with
+// the given ID may upload in a single skyfile and the error that applies once
+// the limit is exceeded. If an upload of the given size already exceeds the
+// limit, the error is returned right away. A negative size is unknown.
+func (api *API) managedAPITokenUploadLimit(id string, size int64) (uint64, error, error) {
+	info, usage, err := api.renter.APITokenUsage(id)
+	if err != nil {
+		return 0, nil, errors.AddContext(err, "unable to get api token usage")
+	}
+	limit, limitErr := info.Quota.UploadLimit(usage)
+	if limitErr != nil && (limit == 0 || (size >= 0 && uint64(size) > limit)) {
+		return 0, nil, limitErr
+	}
+	return limit, limitErr, nil
+}
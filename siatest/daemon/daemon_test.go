@@ -3,8 +3,10 @@ package daemon
 import (
 	"encoding/hex"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -14,12 +16,15 @@ import (
 
 	"gitlab.com/SkynetLabs/skyd/build"
 	"gitlab.com/SkynetLabs/skyd/node"
+	"gitlab.com/SkynetLabs/skyd/node/api"
 	"gitlab.com/SkynetLabs/skyd/node/api/client"
 	"gitlab.com/SkynetLabs/skyd/profile"
 	"gitlab.com/SkynetLabs/skyd/siatest"
 	"gitlab.com/SkynetLabs/skyd/siatest/dependencies"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
 )
 
 // TestDaemonAPIPassword makes sure that the daemon rejects requests with the
@@ -117,6 +122,105 @@ func TestDaemonRatelimit(t *testing.T) {
 	}
 }
 
+// TestDaemonSettingsPut makes sure that the daemon's settings can be replaced
+// as a whole, that invalid documents don't change anything and that the
+// settings are persisted.
+func TestDaemonSettingsPut(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := daemonTestDir(t.Name())
+
+	// Create a new server with a renter.
+	testNode, err := siatest.NewCleanNode(node.Renter(testDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// The settings should contain the renter's settings.
+	dsg, err := testNode.DaemonSettingsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dsg.Renter == nil {
+		t.Fatal("renter settings are missing")
+	}
+
+	// Prepare a new settings document.
+	renterSettings := *dsg.Renter
+	renterSettings.MaxDownloadSpeed = 1 << 30
+	renterSettings.MaxUploadSpeed = 1 << 31
+	renterSettings.IPViolationCheck = !renterSettings.IPViolationCheck
+	settings := api.DaemonSettingsPUT{
+		MaxDownloadSpeed: 1 << 32,
+		MaxUploadSpeed:   1 << 33,
+		Renter:           &renterSettings,
+	}
+
+	// A renter limit above the global limit is rejected.
+	invalid := settings
+	invalidRenterSettings := renterSettings
+	invalidRenterSettings.MaxUploadSpeed = 1 << 34
+	invalid.Renter = &invalidRenterSettings
+	if err := testNode.DaemonSettingsPut(invalid); err == nil || !strings.Contains(err.Error(), "exceeds the global maxuploadspeed") {
+		t.Fatal("expected error", err)
+	}
+
+	// An incomplete allowance is rejected.
+	invalidRenterSettings = renterSettings
+	invalidRenterSettings.Allowance = skymodules.Allowance{Funds: types.SiacoinPrecision}
+	if err := testNode.DaemonSettingsPut(invalid); err == nil || !strings.Contains(err.Error(), api.ErrPeriodNeedToBeSet.Error()) {
+		t.Fatal("expected error", err)
+	}
+
+	// Nothing should have changed.
+	dsg2, err := testNode.DaemonSettingsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dsg, dsg2) {
+		t.Fatalf("settings changed\n%+v\n%+v", dsg, dsg2)
+	}
+
+	// Apply the valid document.
+	if err := testNode.DaemonSettingsPut(settings); err != nil {
+		t.Fatal(err)
+	}
+	assertSettings := func() error {
+		dsg, err := testNode.DaemonSettingsGet()
+		if err != nil {
+			return err
+		}
+		if dsg.MaxDownloadSpeed != settings.MaxDownloadSpeed || dsg.MaxUploadSpeed != settings.MaxUploadSpeed {
+			return fmt.Errorf("wrong global limits %v/%v", dsg.MaxDownloadSpeed, dsg.MaxUploadSpeed)
+		}
+		rs := dsg.Renter
+		if rs.MaxDownloadSpeed != renterSettings.MaxDownloadSpeed || rs.MaxUploadSpeed != renterSettings.MaxUploadSpeed {
+			return fmt.Errorf("wrong renter limits %v/%v", rs.MaxDownloadSpeed, rs.MaxUploadSpeed)
+		}
+		if rs.IPViolationCheck != renterSettings.IPViolationCheck {
+			return errors.New("wrong ip violation check")
+		}
+		return nil
+	}
+	if err := assertSettings(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The settings should be persisted.
+	if err := testNode.RestartNode(); err != nil {
+		t.Fatal(err)
+	}
+	if err := assertSettings(); err != nil {
+		t.Fatal(err)
+	}
+}
+
 // TestGlobalRatelimitRenter makes sure that if multiple ratelimits are set, the
 // lower one is respected.
 func TestGlobalRatelimitRenter(t *testing.T) {
@@ -497,6 +601,22 @@ func TestDaemonReady(t *testing.T) {
 		if dr.Renter != r {
 			err = errors.Compose(err, fmt.Errorf("Renter: %v != %v", dr.Renter, r))
 		}
+		if dr.Modules.Consensus.Ready != cs || dr.Modules.Gateway.Ready != g || dr.Modules.Renter.Ready != r {
+			err = errors.Compose(err, fmt.Errorf("module readiness doesn't match: %+v", dr.Modules))
+		}
+
+		// Strict requests fail while the daemon isn't ready.
+		expectedCode := http.StatusOK
+		if !ready {
+			expectedCode = http.StatusServiceUnavailable
+		}
+		code, sdr, strictErr := renter.DaemonReadyStrictGet()
+		if strictErr != nil {
+			t.Fatal(strictErr)
+		}
+		if code != expectedCode || sdr.Ready != ready {
+			err = errors.Compose(err, fmt.Errorf("strict: %v %v != %v %v", code, sdr.Ready, expectedCode, ready))
+		}
 		return err
 	}
 
@@ -557,4 +677,157 @@ func TestDaemonReady(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+
+	// Check the details of the modules.
+	dr, err := renter.DaemonReadyGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dm := dr.Modules
+	if !dm.Consensus.Loaded || !dm.Consensus.Synced || dm.Consensus.Height == 0 {
+		t.Fatal("unexpected consensus readiness", dm.Consensus)
+	}
+	if !dm.Gateway.Loaded || !dm.Gateway.Online || dm.Gateway.Peers == 0 {
+		t.Fatal("unexpected gateway readiness", dm.Gateway)
+	}
+	if !dm.HostDB.Loaded || !dm.HostDB.Ready || !dm.HostDB.InitialScanComplete {
+		t.Fatal("unexpected hostdb readiness", dm.HostDB)
+	}
+	if !dm.Wallet.Loaded || !dm.Wallet.Ready || !dm.Wallet.Unlocked {
+		t.Fatal("unexpected wallet readiness", dm.Wallet)
+	}
+	if dm.Renter.AllowanceHosts != a.Hosts || dm.Renter.MinContracts != 2 || dm.Renter.ActiveContracts < 2 || dm.Renter.WorkerPoolSize < 2 {
+		t.Fatal("unexpected renter readiness", dm.Renter)
+	}
+}
+
+// TestDaemonMaintenance tests pausing, resuming and rescheduling the
+// maintenance jobs of a node.
+func TestDaemonMaintenance(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := daemonTestDir(t.Name())
+
+	// Create a new server with a renter.
+	testNode, err := siatest.NewCleanNode(node.Renter(testDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// The renter's jobs should be listed and run.
+	job := func(name string) (skymodules.MaintenanceJobStatus, error) {
+		dmg, err := testNode.DaemonMaintenanceGet()
+		if err != nil {
+			return skymodules.MaintenanceJobStatus{}, err
+		}
+		for _, job := range dmg.Jobs {
+			if job.Name == name {
+				return job, nil
+			}
+		}
+		return skymodules.MaintenanceJobStatus{}, fmt.Errorf("job %v not found in %v", name, dmg.Jobs)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		status, err := job("dirupdatebatch")
+		if err != nil {
+			return err
+		}
+		if status.Runs == 0 || status.LastRun.IsZero() || status.NextRun.IsZero() {
+			return fmt.Errorf("job didn't run %+v", status)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pause the job.
+	if err := testNode.DaemonMaintenancePausePost("dirupdatebatch", true); err != nil {
+		t.Fatal(err)
+	}
+	status, err := job("dirupdatebatch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Paused || !status.NextRun.IsZero() {
+		t.Fatalf("job should be paused %+v", status)
+	}
+
+	// Reschedule and resume it.
+	if err := testNode.DaemonMaintenanceIntervalPost("dirupdatebatch", 60, 0.2); err != nil {
+		t.Fatal(err)
+	}
+	if err := testNode.DaemonMaintenancePausePost("dirupdatebatch", false); err != nil {
+		t.Fatal(err)
+	}
+	status, err = job("dirupdatebatch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Paused || status.Interval != time.Minute || status.Jitter != 0.2 || time.Until(status.NextRun) > 72*time.Second {
+		t.Fatalf("unexpected status %+v", status)
+	}
+
+	// Invalid requests are rejected.
+	if err := testNode.DaemonMaintenancePausePost("unknown", true); err == nil || !strings.Contains(err.Error(), skymodules.ErrMaintenanceJobNotFound.Error()) {
+		t.Fatal("expected job not found error", err)
+	}
+	if err := testNode.DaemonMaintenanceIntervalPost("dirupdatebatch", 0, 0); err == nil || !strings.Contains(err.Error(), skymodules.ErrInvalidMaintenanceInterval.Error()) {
+		t.Fatal("expected invalid interval error", err)
+	}
+}
+
+// TestDaemonAPIV2 tests requesting v1 endpoints through the v2 API.
+func TestDaemonAPIV2(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := daemonTestDir(t.Name())
+
+	// Create a new server
+	testNode, err := siatest.NewCleanNode(node.Gateway(testDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err = testNode.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// The v2 response should contain the same data as the v1 response.
+	dvg, err := testNode.DaemonVersionGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dvg2 api.DaemonVersionGet
+	requestID, err := testNode.V2Get("/daemon/version", &dvg2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requestID == "" {
+		t.Fatal("missing request id")
+	}
+	if !reflect.DeepEqual(dvg, dvg2) {
+		t.Fatal("v2 response doesn't match v1 response", dvg, dvg2)
+	}
+
+	// Errors of the handlers are returned.
+	_, err = testNode.V2Post("/daemon/maintenance/unknown", "paused=true", nil)
+	if err == nil || !strings.Contains(err.Error(), "renter module is not loaded") {
+		t.Fatal("unexpected error", err)
+	}
+
+	// Modules that aren't loaded return ErrAPICallNotRecognized.
+	_, err = testNode.V2Get("/renter", nil)
+	if !errors.Contains(err, api.ErrAPICallNotRecognized) {
+		t.Fatal("expected ErrAPICallNotRecognized", err)
+	}
 }
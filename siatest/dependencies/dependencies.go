@@ -275,6 +275,12 @@ func NewDependencySkyfileUploadFail() *DependencyWithDisableAndEnable {
 	return newDependencywithDisableAndEnable("SkyfileUploadFail")
 }
 
+// NewDependencyInterruptUploadSession creates a new dependency that
+// interrupts the upload of a large skyfile after its first chunk.
+func NewDependencyInterruptUploadSession() *DependencyWithDisableAndEnable {
+	return newDependencywithDisableAndEnable("InterruptUploadSession")
+}
+
 // NewDependencyCustomResolver creates a dependency from a given lookupIP
 // method which returns a custom resolver that uses the specified lookupIP
 // method to resolve hostnames.
@@ -970,6 +970,18 @@ func TestRenterContractInitRecoveryScan(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	// The contracts should be reported as found and recovered.
+	if rrs.ContractsFound != uint64(len(oldContracts)) || rrs.BlocksScanned == 0 {
+		t.Fatalf("unexpected progress: %+v", rrs)
+	}
+	for _, c := range rrs.Contracts {
+		if _, exists := oldContracts[c.ID]; !exists {
+			t.Fatal("unknown contract", c.ID)
+		}
+		if c.State != skymodules.ContractRecoveryStateRecovered || c.Attempts == 0 {
+			t.Fatalf("unexpected contract status: %+v", c)
+		}
+	}
 }
 
 // TestRenterContractRecovery tests that recovering a node from a seed that has
@@ -1921,6 +1933,11 @@ func TestContractorChurnLimiter(t *testing.T) {
 			return fmt.Errorf("expected %v disabled contracts but got %v", 1, len(rc.DisabledContracts))
 		}
 
+		// The churn of the other bad scoring hosts should be deferred.
+		if len(churnStatus.DeferredContracts) == 0 || churnStatus.DeferredChurn == 0 {
+			return errors.New("expected the churn of the remaining contracts to be deferred")
+		}
+
 		// Check that a *different* host (i.e. not the offline host) was churned
 		// this time.
 		churnedHostKey := rc.DisabledContracts[0].HostPublicKey
@@ -2958,3 +2975,127 @@ func TestLargeRenewWindow(t *testing.T) {
 		t.Errorf("Expected NextPeriod to be %v but was %v", originalNextPeriod+allowance.Period, rg.NextPeriod)
 	}
 }
+
+// TestAllowanceProfiles tests that the renter forms contracts for the
+// allowance profiles with the profile's period and that files uploaded to a
+// profile are only stored on the profile's hosts.
+func TestAllowanceProfiles(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create Group
+	groupParams := siatest.GroupParams{
+		Hosts:  3,
+		Miners: 1,
+	}
+	groupDir := contractorTestDir(t.Name())
+	tg, err := siatest.NewGroupFromTemplate(groupDir, groupParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create and Add Renter with an allowance which leaves one host for the
+	// profile.
+	renterParams := node.Renter(filepath.Join(groupDir, "renter"))
+	renterParams.Allowance = siatest.DefaultAllowance
+	renterParams.Allowance.Hosts = 2
+	nodes, err := tg.AddNodes(renterParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	renter := nodes[0]
+
+	// Add a profile with a longer period.
+	profilePeriod := renterParams.Allowance.Period * 2
+	err = renter.RenterPostPartialAllowance().WithProfiles(map[string]skymodules.AllowanceProfile{
+		"cold": {
+			Hosts:  1,
+			Period: profilePeriod,
+		},
+	}).Send()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Wait for the contract of the profile.
+	var profileContract api.RenterContract
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		rc, err := renter.RenterContractsGet()
+		if err != nil {
+			return err
+		}
+		if len(rc.ActiveContracts) != 3 {
+			return fmt.Errorf("expected 3 active contracts but got %v", len(rc.ActiveContracts))
+		}
+		var profileContracts int
+		for _, c := range rc.ActiveContracts {
+			if c.Profile == "cold" {
+				profileContract = c
+				profileContracts++
+			} else if c.Profile != "" {
+				return fmt.Errorf("unexpected profile %v", c.Profile)
+			}
+		}
+		if profileContracts != 1 {
+			return fmt.Errorf("expected 1 profile contract but got %v", profileContracts)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The contract of the profile should use the profile's period.
+	if profileContract.EndHeight < profileContract.StartHeight+profilePeriod {
+		t.Fatalf("profile contract ends too early: %v < %v", profileContract.EndHeight, profileContract.StartHeight+profilePeriod)
+	}
+
+	// Upload a file to the profile.
+	lf, err := renter.FilesDir().NewFile(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	siaPath, err := skymodules.NewSiaPath(lf.FileName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = renter.RenterUploadProfilePost(lf.Path(), siaPath, 1, 1, "cold")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rf, err := renter.RenterFileGet(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rf.File.Profile != "cold" {
+		t.Fatal("wrong profile", rf.File.Profile)
+	}
+
+	// The file should only be uploaded to the host of the profile, even
+	// though that leaves the parity piece without a host.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		rc, err := renter.RenterContractsGet()
+		if err != nil {
+			return err
+		}
+		for _, c := range rc.ActiveContracts {
+			if c.Profile == "cold" && c.Size == 0 {
+				return errors.New("file wasn't uploaded to the profile's host")
+			}
+			if c.Profile == "" && c.Size != 0 {
+				return errors.New("file was uploaded to a regular host")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
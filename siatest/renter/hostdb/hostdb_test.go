@@ -1,10 +1,14 @@
 package hostdb
 
 import (
+	"encoding/json"
 	"fmt"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,6 +20,7 @@ import (
 	"gitlab.com/SkynetLabs/skyd/siatest"
 	"gitlab.com/SkynetLabs/skyd/siatest/dependencies"
 	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
@@ -969,3 +974,85 @@ func testFilterMode(t *testing.T, tg *siatest.TestGroup, renter *siatest.TestNod
 		t.Fatal(err)
 	}
 }
+
+// TestHostAllowlist tests pinning the hostdb to a signed host allow-list
+// served by a URL.
+func TestHostAllowlist(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a group for testing
+	groupParams := siatest.GroupParams{
+		Hosts:   2,
+		Miners:  1,
+		Renters: 1,
+	}
+	testDir := hostdbTestDir(t.Name())
+	tg, err := siatest.NewGroupFromTemplate(testDir, groupParams)
+	if err != nil {
+		t.Fatal(errors.AddContext(err, "failed to create group"))
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	renter := tg.Renters()[0]
+
+	// No feed is configured.
+	err = renter.HostDbAllowlistPost("", types.SiaPublicKey{})
+	if err == nil || !strings.Contains(err.Error(), skymodules.ErrHostAllowlistNotConfigured.Error()) {
+		t.Fatal("expected refresh to fail", err)
+	}
+
+	// Serve an allow-list with the first host.
+	hostPK, err := tg.Hosts()[0].HostPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	al := skymodules.HostAllowlist{
+		Revision: 1,
+		Hosts: []skymodules.HostAllowlistEntry{{
+			PublicKey: hostPK,
+			Metadata:  map[string]string{"name": "host"},
+		}},
+	}
+	sk, pk := crypto.GenerateKeyPair()
+	sal, err := skymodules.SignHostAllowlist(al, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(sal)
+	}))
+	defer server.Close()
+
+	// Using the wrong key fails.
+	_, wrongPK := crypto.GenerateKeyPair()
+	err = renter.HostDbAllowlistPost(server.URL, types.Ed25519PublicKey(wrongPK))
+	if err == nil || !strings.Contains(err.Error(), skymodules.ErrHostAllowlistInvalidSignature.Error()) {
+		t.Fatal("expected refresh to fail", err)
+	}
+
+	// Apply the allow-list.
+	err = renter.HostDbAllowlistPost(server.URL, types.Ed25519PublicKey(pk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hdfmg, err := renter.HostDbFilterModeGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hdfmg.FilterMode != skymodules.HostDBActiveWhitelist.String() || len(hdfmg.Hosts) != 1 || hdfmg.Hosts[0] != hostPK.String() {
+		t.Fatal("allow-list not applied", hdfmg)
+	}
+	hdag, err := renter.HostDbAllowlistGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hdag.Source != server.URL || !hdag.PublicKey.Equals(types.Ed25519PublicKey(pk)) || hdag.Revision != 1 || len(hdag.Hosts) != 1 || hdag.Hosts[0].Metadata["name"] != "host" || hdag.LastError != "" {
+		t.Fatal("wrong status", hdag)
+	}
+}
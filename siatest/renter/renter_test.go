@@ -1,8 +1,11 @@
 package renter
 
 import (
+	"archive/tar"
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math"
 	"net/url"
@@ -92,6 +95,8 @@ func TestRenterTwo(t *testing.T) {
 		{Name: "TestSiaFileTimestamps", Test: testSiafileTimestamps},
 		{Name: "TestZeroByteFile", Test: testZeroByteFile},
 		{Name: "TestUploadWithAndWithoutForceParameter", Test: testUploadWithAndWithoutForceParameter},
+		{Name: "TestContractsSnapshot", Test: testContractsSnapshot},
+		{Name: "TestPendingDownloads", Test: testPendingDownloads},
 	}
 
 	// Run tests
@@ -308,6 +313,8 @@ func TestRenterFour(t *testing.T) {
 		{Name: "TestNextPeriod", Test: testNextPeriod},
 		{Name: "TestPauseAndResumeRepairAndUploads", Test: testPauseAndResumeRepairAndUploads},
 		{Name: "TestDownloadServedFromDisk", Test: testDownloadServedFromDisk},
+		{Name: "TestRetrieveArchivedFile", Test: testRetrieveArchivedFile},
+		{Name: "TestDirDownload", Test: testDirDownload},
 		{Name: "TestEscapeSiaPath", Test: testEscapeSiaPath}, // Runs last because it uploads many files
 	}
 
@@ -1614,6 +1621,115 @@ func testUploadWithAndWithoutForceParameter(t *testing.T, tg *siatest.TestGroup)
 	}
 }
 
+// testPendingDownloads tests listing the pending chunks of the download heap
+// and cancelling pending downloads.
+func testPendingDownloads(t *testing.T, tg *siatest.TestGroup) {
+	renter := tg.Renters()[0]
+
+	// Upload and download a file. Once the download is done, no chunks should
+	// be pending.
+	_, rf, err := renter.UploadNewFileBlocking(int(modules.SectorSize), 1, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = renter.DownloadToDisk(rf, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rpdg, err := renter.RenterPendingDownloadsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rpdg.Chunks) != 0 {
+		t.Fatal("expected no pending chunks", rpdg.Chunks)
+	}
+
+	// Cancelling an unknown download should fail.
+	err = renter.RenterPendingDownloadsCancelPost("unknown")
+	if err == nil || !strings.Contains(err.Error(), "download not found") {
+		t.Fatal("expected cancelling an unknown download to fail", err)
+	}
+	err = renter.RenterPendingDownloadsCancelPost("")
+	if err == nil {
+		t.Fatal("expected cancelling without an id to fail")
+	}
+}
+
+// testContractsSnapshot tests exporting signed snapshots of the renter's
+// contracts and diffing them.
+func testContractsSnapshot(t *testing.T, tg *siatest.TestGroup) {
+	renter := tg.Renters()[0]
+
+	// Export a snapshot and verify it with its own key.
+	signed, err := renter.RenterContractsSnapshotGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := signed.Verify(signed.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, err := renter.RenterContractsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot.Contracts) != len(rc.Contracts) || len(snapshot.Contracts) == 0 {
+		t.Fatalf("expected %v contracts but got %v", len(rc.Contracts), len(snapshot.Contracts))
+	}
+
+	// The CSV export contains a header and a row per contract.
+	csv, err := renter.RenterContractsSnapshotCSVGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(csv), "\n"); lines != len(snapshot.Contracts)+1 {
+		t.Fatalf("expected %v lines but got %v", len(snapshot.Contracts)+1, lines)
+	}
+
+	// Upload a file to change the contracts.
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	_, _, err = renter.UploadNewFileBlocking(100+siatest.Fuzz(), dataPieces, parityPieces, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The diff should contain the changed contracts.
+	diff, err := renter.RenterContractsSnapshotDiffPost(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Diff.Added) != 0 || len(diff.Diff.Removed) != 0 {
+		t.Fatal("unexpected added or removed contracts", diff.Diff.Added, diff.Diff.Removed)
+	}
+	if len(diff.Diff.Changed) == 0 {
+		t.Fatal("expected changed contracts")
+	}
+	for _, c := range diff.Diff.Changed {
+		if c.New.Size <= c.Old.Size {
+			t.Fatal("expected contract size to grow", c.Old.Size, c.New.Size)
+		}
+	}
+	if _, err := diff.Snapshot.Verify(signed.PublicKey); err != nil {
+		t.Fatal(err)
+	}
+	csv, err = renter.RenterContractsSnapshotDiffCSVPost(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(csv), "change,id,") || !strings.Contains(string(csv), "\nchanged,") {
+		t.Fatal("unexpected csv diff", string(csv))
+	}
+
+	// Tampered snapshots are rejected.
+	tampered := signed
+	tampered.Snapshot = bytes.Replace(signed.Snapshot, []byte(`"timestamp":`), []byte(`"timestamp":1`), 1)
+	_, err = renter.RenterContractsSnapshotDiffPost(tampered)
+	if err == nil || !strings.Contains(err.Error(), skymodules.ErrContractSnapshotInvalidSignature.Error()) {
+		t.Fatal("expected invalid signature error", err)
+	}
+}
+
 // TestRenterInterrupt executes a number of subtests using the same TestGroup to
 // save time on initialization
 func TestRenterInterrupt(t *testing.T) {
@@ -4755,6 +4871,167 @@ func testDownloadServedFromDisk(t *testing.T, tg *siatest.TestGroup) {
 	}
 }
 
+// testRetrieveArchivedFile tests the delayed retrieval of archived files.
+func testRetrieveArchivedFile(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload an archived file. There are no archive hosts so the regular
+	// hosts are used.
+	lf, err := r.FilesDir().NewFile(1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := lf.Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+	siaPath, err := skymodules.NewSiaPath(lf.FileName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.RenterUploadArchivePost(lf.Path(), siaPath, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		rf, err := r.RenterFileGet(siaPath)
+		if err != nil {
+			return err
+		}
+		if !rf.File.Available {
+			return errors.New("file not available yet")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Delete the local copy.
+	err = lf.Delete()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Files which aren't archived can't be retrieved.
+	_, rf, err := r.UploadNewFileBlocking(100, 1, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.RenterArchiveRetrievePost(rf.SiaPath())
+	if err == nil || !strings.Contains(err.Error(), renter.ErrNotArchived.Error()) {
+		t.Fatal("expected retrieval to fail", err)
+	}
+
+	// Retrieve the archived file and wait for the retrieved copy.
+	err = r.RenterArchiveRetrievePost(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var localPath string
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		rf, err := r.RenterFileGet(siaPath)
+		if err != nil {
+			return err
+		}
+		if !rf.File.OnDisk || rf.File.LocalPath == lf.Path() {
+			return errors.New("file not retrieved yet")
+		}
+		localPath = rf.File.LocalPath
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Retrieving the file again is a no-op.
+	err = r.RenterArchiveRetrievePost(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The retrieved copy contains the file's data.
+	retrieved, err := ioutil.ReadFile(localPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(retrieved, data) {
+		t.Fatal("retrieved copy doesn't match the file")
+	}
+
+	// Deleting the file removes the retrieved copy.
+	err = r.RenterFileDeletePost(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(localPath); !os.IsNotExist(err) {
+		t.Fatal("retrieved copy wasn't removed", err)
+	}
+}
+
+// testDirDownload tests downloading a directory as an archive.
+func testDirDownload(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload a few files into a directory and one of its subdirectories.
+	dir := skymodules.RandomSiaPath()
+	names := []string{"a", "b", "sub/c"}
+	expected := make(map[string][]byte)
+	for i, name := range names {
+		lf, err := r.FilesDir().NewFile(100 * (i + 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		siaPath, err := dir.Join(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rf, err := r.Upload(lf, siaPath, 1, 1, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.WaitForUploadHealth(rf); err != nil {
+			t.Fatal(err)
+		}
+		expected[name], err = lf.Data()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Download the directory as a tar.
+	archive, err := r.RenterDirDownloadGet(dir, skymodules.SkyfileFormatTar, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(bytes.NewReader(archive))
+	for _, name := range names {
+		header, err := tr.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Name != name {
+			t.Fatalf("expected file %v but got %v", name, header.Name)
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, expected[name]) {
+			t.Fatal("wrong content", name)
+		}
+	}
+	if _, err := tr.Next(); err != io.EOF {
+		t.Fatal("expected end of archive", err)
+	}
+
+	// Unsupported formats are rejected.
+	_, err = r.RenterDirDownloadGet(dir, skymodules.SkyfileFormat("rar"), false, false)
+	if err == nil || !strings.Contains(err.Error(), "unsupported archive format") {
+		t.Fatal("expected error", err)
+	}
+}
+
 // TestWorkerStatus probes the WorkerPoolStatus
 func TestWorkerStatus(t *testing.T) {
 	if testing.Short() {
@@ -50,6 +50,7 @@ func TestSkynetDownloads(t *testing.T) {
 		{Name: "ContentDisposition", Test: testDownloadContentDisposition},
 		{Name: "SkynetSkylinkHeader", Test: testSkynetSkylinkHeader},
 		{Name: "ETag", Test: testETag},
+		{Name: "HeadersOnly", Test: testDownloadHeadersOnly},
 	}
 
 	// Run tests
@@ -749,6 +750,80 @@ func testETag(t *testing.T, tg *siatest.TestGroup) {
 	}
 }
 
+// testDownloadHeadersOnly verifies that HEAD requests and GET requests with the
+// 'nofanout' flag serve the same headers as a regular download.
+func testDownloadHeadersOnly(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload a small file which fits into the base sector and a large file
+	// with a fanout.
+	for _, size := range []uint64{100, 2 * modules.SectorSize} {
+		skylink, _, _, err := r.UploadNewSkyfileBlocking(fmt.Sprintf("%v-%v", t.Name(), size), size, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, metadata, err := r.SkynetMetadataGet(skylink)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Fetch the headers with a HEAD request.
+		status, header, err := r.SkynetSkylinkHead(skylink)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status != http.StatusOK {
+			t.Fatal("unexpected status", status)
+		}
+		if cl := header.Get("Content-Length"); cl != fmt.Sprint(size) {
+			t.Fatalf("unexpected Content-Length %v != %v", cl, size)
+		}
+		var headerMetadata skymodules.SkyfileMetadata
+		err = json.Unmarshal([]byte(header.Get(api.SkynetFileMetadataHeader)), &headerMetadata)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(headerMetadata, metadata) {
+			t.Fatal("metadata mismatch", headerMetadata, metadata)
+		}
+
+		// Fetch the headers with a GET request and the 'nofanout' flag. No
+		// content should be returned.
+		noFanoutHeader, data, err := r.SkynetSkylinkNoFanoutGet(skylink)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) != 0 {
+			t.Fatal("expected no content", len(data))
+		}
+		if noFanoutHeader.Get(api.SkynetFileMetadataHeader) != header.Get(api.SkynetFileMetadataHeader) {
+			t.Fatal("metadata mismatch")
+		}
+
+		// The headers should match the ones of a regular download.
+		getHeader, reader, err := r.SkynetSkylinkFormatGet(skylink, skymodules.SkyfileFormatNotSpecified)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := reader.Close(); err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range []string{"ETag", "Content-Disposition", "Content-Type", api.SkynetSkylinkHeader} {
+			// The content type of a file without extension and content type
+			// can't be sniffed without the fanout.
+			if key == "Content-Type" && size > modules.SectorSize {
+				if header.Get(key) != "" || noFanoutHeader.Get(key) != "" {
+					t.Fatal("unexpected content type", header.Get(key), noFanoutHeader.Get(key))
+				}
+				continue
+			}
+			if getHeader.Get(key) != header.Get(key) || getHeader.Get(key) != noFanoutHeader.Get(key) {
+				t.Fatalf("header %v mismatch: %v %v %v", key, getHeader.Get(key), header.Get(key), noFanoutHeader.Get(key))
+			}
+		}
+	}
+}
+
 // testSkynetSkylinkHeader tests that the 'Skynet-Skylink' is set both on the
 // Skynet upload - and download route.
 func testSkynetSkylinkHeader(t *testing.T, tg *siatest.TestGroup) {
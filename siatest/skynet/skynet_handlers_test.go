@@ -1,6 +1,7 @@
 package skynet
 
 import (
+	"bytes"
 	"net/http"
 	"regexp"
 	"testing"
@@ -209,6 +210,66 @@ func TestSkynetSkylinkHandlerGET(t *testing.T) {
 			}
 		}
 	}
+
+	// Subdomain-style requests should return the same data as path-style
+	// requests.
+	r.Client.CheckRedirect = nil
+	subdomainTests := []struct {
+		skylink string
+		path    string
+	}{
+		{"_A6d-2CpM2OQ-7m5NPAYW830NdzC3wGydFzzd-KnHXhwJA", "/"},
+		{"4CCcCO73xMbehYaK7bjDGCtW0GwOL6Swl-lNY52Pb_APzA", "/dir/index.html"},
+		{"4CCcCO73xMbehYaK7bjDGCtW0GwOL6Swl-lNY52Pb_APzA", "/test%3Fencoding"},
+	}
+	for _, test := range subdomainTests {
+		expected, err := r.SkynetSkylinkGet(test.skylink + test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := r.SkynetSkylinkSubdomainGet(test.skylink, "siasky.net", test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, expected) {
+			t.Fatalf("subdomain-style download of %v%v returned wrong data", test.skylink, test.path)
+		}
+	}
+
+	// A path that doesn't exist in the skyfile.
+	_, err = r.SkynetSkylinkSubdomainGet("4CCcCO73xMbehYaK7bjDGCtW0GwOL6Swl-lNY52Pb_APzA", "siasky.net", "/di")
+	if err == nil || !strings.Contains(err.Error(), "failed to download contents for path: /di") {
+		t.Fatal("unexpected error", err)
+	}
+
+	// Subdomain-style requests don't require the user agent, requests for
+	// names do since they cause lookups.
+	browserGet := func(host string) int {
+		req, err := http.NewRequest(http.MethodGet, "http://"+r.Address+"/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Host = host
+		req.Header.Set("User-Agent", "Mozilla/5.0")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := resp.Body.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+	var sl skymodules.Skylink
+	if err := sl.LoadString("_A6d-2CpM2OQ-7m5NPAYW830NdzC3wGydFzzd-KnHXhwJA"); err != nil {
+		t.Fatal(err)
+	}
+	if code := browserGet(strings.ToLower(sl.Base32EncodedString()) + ".siasky.net"); code != http.StatusOK {
+		t.Fatal("unexpected status code", code)
+	}
+	if code := browserGet("example.com"); code != http.StatusBadRequest {
+		t.Fatal("unexpected status code", code)
+	}
 }
 
 // TestSkynetSkylinkPinHandlerPOST ensures various aspects of the correct
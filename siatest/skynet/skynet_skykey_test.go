@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"fmt"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/SkynetLabs/skyd/build"
 	"gitlab.com/SkynetLabs/skyd/node/api"
 	"gitlab.com/SkynetLabs/skyd/node/api/client"
 	"gitlab.com/SkynetLabs/skyd/siatest"
@@ -41,6 +44,7 @@ func TestSkykey(t *testing.T) {
 		{Name: "LargeFilePrivateID", Test: testSkynetEncryptionLargeFileWithType(skykey.TypePrivateID)},
 		{Name: "LargeFilePublicID", Test: testSkynetEncryptionLargeFileWithType(skykey.TypePublicID)},
 		{Name: "UnsafeClient", Test: testUnsafeClient},
+		{Name: "SkykeyBackup", Test: testSkykeyBackup},
 	}
 
 	// Run tests
@@ -668,3 +672,108 @@ func testSkynetEncryptionLargeFile(t *testing.T, tg *siatest.TestGroup, skykeyTy
 		t.Fatal("skylink mismatch")
 	}
 }
+
+// testSkykeyBackup tests exporting, importing, backing up and restoring the
+// skykeys as well as the automatic skykey backup.
+func testSkykeyBackup(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Create a skykey to back up.
+	sk, err := r.SkykeyCreateKeyPost("backupkey", skykey.TypePrivateID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// deleteAndCheckRestored deletes the skykey, calls restore and checks
+	// that the skykey was restored.
+	deleteAndCheckRestored := func(restore func() ([]skykey.Skykey, error)) {
+		t.Helper()
+		if err := r.SkykeyDeleteByNamePost(sk.Name); err != nil {
+			t.Fatal(err)
+		}
+		added, err := restore()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(added) != 1 || added[0].ID() != sk.ID() {
+			t.Fatal("wrong skykeys restored", added)
+		}
+		restored, err := r.SkykeyGetByName(sk.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if restored.ID() != sk.ID() {
+			t.Fatal("wrong skykey restored")
+		}
+	}
+
+	// Export and import the skykeys.
+	if _, err := r.SkykeysExportPost(""); err == nil {
+		t.Fatal("exporting without a password should fail")
+	}
+	bundle, err := r.SkykeysExportPost("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.SkykeysImportPost(bundle, "wrong"); err == nil || !strings.Contains(err.Error(), skykey.ErrInvalidBundlePassword.Error()) {
+		t.Fatal("expected invalid password error", err)
+	}
+	deleteAndCheckRestored(func() ([]skykey.Skykey, error) {
+		return r.SkykeysImportPost(bundle, "password")
+	})
+
+	// Back up the skykeys to a skylink and restore them.
+	sbp, err := r.SkykeysBackupPost("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	deleteAndCheckRestored(func() ([]skykey.Skykey, error) {
+		return r.SkykeysRestorePost(sbp.Skylink, "password")
+	})
+
+	// Enable the automatic backup.
+	status, err := r.SkykeysAutoBackupPost("password", "skykeybackup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Enabled || status.EntryName != "skykeybackup" || status.Skylink == "" || status.LastBackup == "" {
+		t.Fatal("unexpected status", status)
+	}
+
+	// Creating a skykey should trigger a new backup.
+	sk2, err := r.SkykeyCreateKeyPost("backupkey2", skykey.TypePrivateID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		newStatus, err := r.SkykeysAutoBackupGet()
+		if err != nil {
+			return err
+		}
+		if newStatus.LastBackup == status.LastBackup {
+			return fmt.Errorf("no new backup yet, recent err: %v", newStatus.RecentErr)
+		}
+		status = newStatus
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Disable the automatic backup.
+	skylinkV2 := status.Skylink
+	status, err = r.SkykeysAutoBackupPost("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Enabled || status.Skylink != "" {
+		t.Fatal("backup should be disabled", status)
+	}
+
+	// Restoring from the V2 skylink should restore the latest backup which
+	// contains the new skykey.
+	sk = sk2
+	deleteAndCheckRestored(func() ([]skykey.Skykey, error) {
+		return r.SkykeysRestorePost(skylinkV2, "password")
+	})
+}
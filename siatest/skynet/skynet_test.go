@@ -5,6 +5,7 @@ import (
 	"bufio"
 	"bytes"
 	"compress/gzip"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -16,11 +17,13 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/eventials/go-tus"
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
 	"gitlab.com/SkynetLabs/skyd/build"
@@ -37,6 +40,7 @@ import (
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/persist"
 	"go.sia.tech/siad/types"
+	"lukechampine.com/blake3"
 )
 
 // TestSkynetSuiteOne verifies the functionality of Skynet, a decentralized CDN
@@ -66,12 +70,30 @@ func TestSkynetSuiteOne(t *testing.T) {
 		{Name: "SubDirDownload", Test: testSkynetSubDirDownload},
 		{Name: "DisableForce", Test: testSkynetDisableForce},
 		{Name: "Portals", Test: testSkynetPortals},
+		{Name: "Tokens", Test: testSkynetTokens},
+		{Name: "TokenQuotas", Test: testSkynetTokenQuotas},
+		{Name: "FolderQuotas", Test: testSkynetFolderQuotas},
+		{Name: "UploadLimits", Test: testSkynetUploadLimits},
+		{Name: "FanoutRedundancyPolicy", Test: testSkynetFanoutRedundancyPolicy},
+		{Name: "ExtraMetadata", Test: testSkynetExtraMetadata},
+		{Name: "CustomHeaders", Test: testSkynetCustomHeaders},
+		{Name: "Skylinks", Test: testSkynetSkylinks},
+		{Name: "Import", Test: testSkynetImport},
+		{Name: "Delete", Test: testSkynetDelete},
+		{Name: "SignedURLs", Test: testSkynetSignedURLs},
+		{Name: "ExtractUpload", Test: testSkynetExtractUpload},
+		{Name: "ContentHashes", Test: testSkynetContentHashes},
+		{Name: "SharedChunks", Test: testSkynetSharedChunks},
+		{Name: "RepairPriority", Test: testSkynetRepairPriority},
+		{Name: "HealthHeader", Test: testSkynetHealthHeader},
 		{Name: "IncludeLayout", Test: testSkynetIncludeLayout},
 		{Name: "RequestTimeout", Test: testSkynetRequestTimeout},
 		{Name: "DryRunUpload", Test: testSkynetDryRunUpload},
+		{Name: "AllowedHosts", Test: testSkynetAllowedHosts},
 		{Name: "RegressionTimeoutPanic", Test: testRegressionTimeoutPanic},
 		{Name: "RenameSiaPath", Test: testRenameSiaPath},
 		{Name: "NoWorkers", Test: testSkynetNoWorkers},
+		{Name: "PinDependencies", Test: testSkynetPinDependencies},
 	}
 
 	// Run tests
@@ -108,10 +130,16 @@ func TestSkynetSuiteTwo(t *testing.T) {
 		{Name: "DownloadBaseSectorEncrypted", Test: testSkynetDownloadBaseSectorEncrypted},
 		{Name: "FanoutRegression", Test: testSkynetFanoutRegression},
 		{Name: "DownloadRange", Test: testSkynetDownloadRange},
+		{Name: "DownloadArchiveRange", Test: testSkynetDownloadArchiveRange},
+		{Name: "BatchDownload", Test: testSkynetBatchDownload},
 		{Name: "DownloadRangeEncrypted", Test: testSkynetDownloadRangeEncrypted},
+		{Name: "DownloadProvenance", Test: testSkynetDownloadProvenance},
+		{Name: "DownloadBandwidth", Test: testSkynetDownloadBandwidth},
+		{Name: "MemoryLimit", Test: testSkynetMemoryLimit},
 		{Name: "Registry", Test: testSkynetRegistryReadWrite},
 		{Name: "Stats", Test: testSkynetStats},
 		{Name: "RegistryUpdateMulti", Test: testUpdateRegistryMulti},
+		{Name: "RegistryTrustedHosts", Test: testRegistryTrustedHosts},
 		{Name: "HostsForRegistryUpdate", Test: testHostsForRegistryUpdate},
 	}
 
@@ -830,7 +858,7 @@ func testSkynetMultipartUpload(t *testing.T, tg *siatest.TestGroup) {
 				rootFile.Name: {
 					FileMode:    os.FileMode(0644),
 					Filename:    rootFile.Name,
-					ContentType: "application/octet-stream",
+					ContentType: "text/plain; charset=utf-8",
 					Offset:      0,
 					Len:         uint64(len(rootFile.Data)),
 				},
@@ -1709,6 +1737,158 @@ func testSkynetDownloadRange(t *testing.T, tg *siatest.TestGroup) {
 	})
 }
 
+// testSkynetDownloadArchiveRange verifies that ranges of skyfiles downloaded as
+// tar and zip archives match the full archives.
+func testSkynetDownloadArchiveRange(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload a directory with a fanout.
+	files := []siatest.TestFile{
+		{Name: "a/small.txt", Data: fastrand.Bytes(100)},
+		{Name: "b/large.bin", Data: fastrand.Bytes(int(2*modules.SectorSize) + siatest.Fuzz())},
+		{Name: "c/small.txt", Data: fastrand.Bytes(10)},
+	}
+	skylink, _, _, err := r.UploadNewMultipartSkyfileBlocking(t.Name(), files, "", true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []skymodules.SkyfileFormat{skymodules.SkyfileFormatTar, skymodules.SkyfileFormatZip} {
+		// Download the full archive.
+		header, reader, err := r.SkynetSkylinkFormatGet(skylink, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		archive, err := ioutil.ReadAll(reader)
+		err = errors.Compose(err, reader.Close())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Get("Accept-Ranges") != "bytes" {
+			t.Fatal(format, "ranges aren't accepted")
+		}
+
+		// The size of the archive is known without downloading it.
+		_, header, err = r.SkynetSkylinkHeadWithFormat(skylink, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Get("Content-Length") != strconv.Itoa(len(archive)) {
+			t.Fatal(format, "wrong content length", header.Get("Content-Length"), len(archive))
+		}
+
+		// Download the beginning, the middle and the end of the archive.
+		size := uint64(len(archive))
+		for _, rng := range [][2]uint64{{0, 100}, {size / 3, 2 * size / 3}, {size - 1000, size}} {
+			data, err := r.SkynetSkylinkFormatRange(skylink, format, rng[0], rng[1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, archive[rng[0]:rng[1]]) {
+				t.Fatal(format, "wrong range", rng)
+			}
+		}
+	}
+}
+
+// testSkynetBatchDownload verifies that multiple skylinks can be downloaded
+// with a single request to /skynet/batchdownload.
+func testSkynetBatchDownload(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload a small skyfile and a directory.
+	small := fastrand.Bytes(100)
+	smallSkylink, _, _, err := r.UploadNewSkyfileWithDataBlocking(t.Name()+"-small", small, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []siatest.TestFile{
+		{Name: "a.json", Data: []byte(`{"a":1}`)},
+		{Name: "b.json", Data: []byte(`{"b":2}`)},
+	}
+	dirSkylink, _, _, err := r.UploadNewMultipartSkyfileBlocking(t.Name()+"-dir", files, "", true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Request the whole small file, a range of it, both files of the
+	// directory and two downloads which fail.
+	downloads := []api.SkynetBatchDownload{
+		{Skylink: smallSkylink},
+		{Skylink: smallSkylink, Offset: 10, Length: 20},
+		{Skylink: dirSkylink, Path: "b.json"},
+		{Skylink: dirSkylink, Path: "/a.json"},
+		{Skylink: dirSkylink, Path: "missing.json"},
+		{Skylink: smallSkylink, Offset: 101},
+	}
+	expected := []struct {
+		data []byte
+		size uint64
+		err  bool
+	}{
+		{data: small, size: 100},
+		{data: small[10:30], size: 100},
+		{data: files[1].Data, size: uint64(len(files[1].Data))},
+		{data: files[0].Data, size: uint64(len(files[0].Data))},
+		{err: true},
+		{err: true},
+	}
+
+	// Both formats should return the same results.
+	ndjsonResults, err := r.SkynetBatchDownloadPost(downloads)
+	if err != nil {
+		t.Fatal(err)
+	}
+	multipartResults, err := r.SkynetBatchDownloadMultipartPost(downloads)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, results := range [][]api.SkynetBatchDownloadResult{ndjsonResults, multipartResults} {
+		if len(results) != len(downloads) {
+			t.Fatalf("expected %v results but got %v", len(downloads), len(results))
+		}
+		for i, res := range results {
+			if res.Index != i {
+				t.Fatal("wrong index", res.Index, i)
+			}
+			if res.RequestedSkylink != downloads[i].Skylink {
+				t.Fatal("wrong skylink", res.RequestedSkylink, downloads[i].Skylink)
+			}
+			if expected[i].err {
+				if res.Error == "" {
+					t.Fatal("expected error for download", i)
+				}
+				continue
+			}
+			if res.Error != "" {
+				t.Fatal(i, res.Error)
+			}
+			if !bytes.Equal(res.Data, expected[i].data) {
+				t.Fatal("wrong data for download", i)
+			}
+			if res.Size != expected[i].size {
+				t.Fatal("wrong size", i, res.Size, expected[i].size)
+			}
+			if res.Offset != downloads[i].Offset {
+				t.Fatal("wrong offset", i, res.Offset, downloads[i].Offset)
+			}
+		}
+	}
+	if ct := ndjsonResults[2].ContentType; ct != "application/json" {
+		t.Fatal("unexpected content type", ct)
+	}
+
+	// Invalid requests should be rejected.
+	_, err = r.SkynetBatchDownloadPost(nil)
+	if err == nil || !strings.Contains(err.Error(), "no downloads were requested") {
+		t.Fatal("expected error for empty batch", err)
+	}
+	_, err = r.SkynetBatchDownloadPost([]api.SkynetBatchDownload{{Skylink: "invalid"}})
+	if err == nil || !strings.Contains(err.Error(), "unable to parse skylink of download 0") {
+		t.Fatal("expected error for invalid skylink", err)
+	}
+}
+
 // skynetDownloadRangeTest verifies different conditions of skynet downloads
 // with range requests.
 func skynetDownloadRangeTest(t *testing.T, tg *siatest.TestGroup, skykeyName string, largeFile bool) {
@@ -2468,6 +2648,92 @@ func TestSkynetBlocklist(t *testing.T) {
 	t.Run("BlocklistUpgrade", func(t *testing.T) {
 		testSkynetBlocklistUpgrade(t, tg)
 	})
+	t.Run("BlocklistCheck", func(t *testing.T) {
+		testSkynetBlocklistCheck(t, tg)
+	})
+}
+
+// testSkynetBlocklistCheck verifies that candidates can be checked against the
+// blocklist and that uploads of blocked content are rejected.
+func testSkynetBlocklistCheck(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Compute the skylink of a small skyfile with a dry run.
+	data := fastrand.Bytes(100)
+	sup := skymodules.SkyfileUploadParameters{
+		SiaPath:  skymodules.RandomSiaPath(),
+		Filename: "blocked",
+		Mode:     0640,
+		Reader:   bytes.NewReader(data),
+		DryRun:   true,
+	}
+	skylink, sshp, err := r.SkynetSkyfilePost(sup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := crypto.HashObject(sshp.MerkleRoot)
+	other, err := skymodules.NewSkylinkV1(crypto.Hash{1}, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing is blocked yet.
+	sbcp, err := r.SkynetBlocklistCheckPost([]string{skylink, other.String()}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sbcp.Blocked[skylink] || sbcp.Blocked[other.String()] || len(sbcp.Blocked) != 2 {
+		t.Fatal("unexpected result", sbcp.Blocked)
+	}
+
+	// Block the skylink.
+	err = r.SkynetBlocklistPost([]string{skylink}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.SkynetBlocklistPost(nil, []string{skylink}); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// The skylink and its hash should be reported as blocked.
+	sbcp, err = r.SkynetBlocklistCheckPost([]string{skylink, other.String()}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sbcp.Blocked[skylink] || sbcp.Blocked[other.String()] {
+		t.Fatal("unexpected result", sbcp.Blocked)
+	}
+	sbcp, err = r.SkynetBlocklistCheckPost([]string{hash.String()}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sbcp.Blocked[hash.String()] {
+		t.Fatal("unexpected result", sbcp.Blocked)
+	}
+
+	// Invalid candidates are rejected.
+	_, err = r.SkynetBlocklistCheckPost([]string{"invalid"}, false)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	// Uploading the blocked content fails and doesn't leave a file behind.
+	sup.DryRun = false
+	sup.Reader = bytes.NewReader(data)
+	_, _, err = r.SkynetSkyfilePost(sup)
+	if err == nil || !strings.Contains(err.Error(), renter.ErrSkylinkBlocked.Error()) {
+		t.Fatalf("Expected error %v but got %v", renter.ErrSkylinkBlocked, err)
+	}
+	sp, err := skymodules.SkynetFolder.Join(sup.SiaPath.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.RenterFileRootGet(sp)
+	if err == nil || !strings.Contains(err.Error(), filesystem.ErrNotExist.Error()) {
+		t.Fatalf("Expected error %v but got %v", filesystem.ErrNotExist, err)
+	}
 }
 
 // testSkynetBlocklistHash tests the skynet blocklist module when submitting
@@ -2962,181 +3228,1409 @@ func testSkynetBlocklistUpgrade(t *testing.T, tg *siatest.TestGroup) {
 	}
 }
 
-// testSkynetPortals tests the skynet portals module.
-func testSkynetPortals(t *testing.T, tg *siatest.TestGroup) {
+// testSkynetTokens tests creating and revoking scoped API tokens and that the
+// tokens only grant access to the routes of their scope.
+func testSkynetTokens(t *testing.T, tg *siatest.TestGroup) {
 	r := tg.Renters()[0]
 
-	portal1 := skymodules.SkynetPortal{
-		Address: modules.NetAddress("siasky.net:9980"),
-		Public:  true,
-	}
-	// loopback address
-	portal2 := skymodules.SkynetPortal{
-		Address: "localhost:9980",
-		Public:  true,
-	}
-	// address without a port
-	portal3 := skymodules.SkynetPortal{
-		Address: modules.NetAddress("siasky.net"),
-		Public:  true,
+	// Invalid scopes are rejected.
+	_, err := r.SkynetTokensPost("invalid", "superuser", skymodules.APITokenQuota{})
+	if err == nil || !strings.Contains(err.Error(), skymodules.ErrInvalidAPITokenScope.Error()) {
+		t.Fatal("expected invalid scope error", err)
 	}
 
-	// Add portal.
-	add := []skymodules.SkynetPortal{portal1}
-	remove := []modules.NetAddress{}
-	err := r.SkynetPortalsPost(add, remove)
+	// Create a token for every scope.
+	upload, err := r.SkynetTokensPost("uploader", skymodules.APITokenScopeUpload, skymodules.APITokenQuota{})
 	if err != nil {
 		t.Fatal(err)
 	}
-
-	// Confirm that the portal has been added.
-	spg, err := r.SkynetPortalsGet()
+	download, err := r.SkynetTokensPost("downloader", skymodules.APITokenScopeDownload, skymodules.APITokenQuota{})
 	if err != nil {
 		t.Fatal(err)
 	}
-	if len(spg.Portals) != 1 {
-		t.Fatalf("Incorrect number of portals, expected %v got %v", 1, len(spg.Portals))
-	}
-	if !reflect.DeepEqual(spg.Portals[0], portal1) {
-		t.Fatalf("Portals don't match, expected %v got %v", portal1, spg.Portals[0])
-	}
-
-	// Remove the portal.
-	add = []skymodules.SkynetPortal{}
-	remove = []modules.NetAddress{portal1.Address}
-	err = r.SkynetPortalsPost(add, remove)
+	admin, err := r.SkynetTokensPost("admin", skymodules.APITokenScopeAdmin, skymodules.APITokenQuota{})
 	if err != nil {
 		t.Fatal(err)
 	}
-
-	// Confirm that the portal has been removed.
-	spg, err = r.SkynetPortalsGet()
+	tokens, err := r.SkynetTokensGet()
 	if err != nil {
 		t.Fatal(err)
 	}
-	if len(spg.Portals) != 0 {
-		t.Fatalf("Incorrect number of portals, expected %v got %v", 0, len(spg.Portals))
+	found := make(map[string]bool)
+	for _, token := range tokens.Tokens {
+		found[token.ID] = true
 	}
-
-	// Try removing a portal that's not there.
-	add = []skymodules.SkynetPortal{}
-	remove = []modules.NetAddress{portal1.Address}
-	err = r.SkynetPortalsPost(add, remove)
-	if err == nil || !strings.Contains(err.Error(), "address "+string(portal1.Address)+" not already present in list of portals or being added") {
-		t.Fatal("portal should fail to be removed")
+	if !found[upload.ID] || !found[download.ID] || !found[admin.ID] {
+		t.Fatal("tokens are missing", tokens.Tokens)
 	}
 
-	// Try to add and remove a portal at the same time.
-	add = []skymodules.SkynetPortal{portal2}
-	remove = []modules.NetAddress{portal2.Address}
-	err = r.SkynetPortalsPost(add, remove)
-	if err != nil {
-		t.Fatal(err)
+	// tokenClient returns a client that authenticates with the given token.
+	tokenClient := func(token string) *client.Client {
+		return client.New(client.Options{
+			Address:   r.Address,
+			Password:  token,
+			UserAgent: r.UserAgent,
+		})
+	}
+	uploadFile := func(c *client.Client) error {
+		_, _, err := c.SkynetSkyfilePost(skymodules.SkyfileUploadParameters{
+			SiaPath:  skymodules.RandomSiaPath(),
+			Filename: "token",
+			Reader:   bytes.NewReader(fastrand.Bytes(100)),
+		})
+		return err
 	}
 
-	// Verify that the portal was not added.
-	spg, err = r.SkynetPortalsGet()
-	if err != nil {
+	// The upload token may upload but not manage tokens.
+	uc := tokenClient(upload.Token)
+	if err := uploadFile(uc); err != nil {
 		t.Fatal(err)
 	}
-	if len(spg.Portals) != 0 {
-		t.Fatalf("Incorrect number of portals, expected %v got %v", 0, len(spg.Portals))
+	if _, err := uc.SkynetTokensGet(); err == nil || !strings.Contains(err.Error(), "required scope") {
+		t.Fatal("upload token shouldn't be able to list tokens", err)
 	}
 
-	// Test updating a portal's public status.
-	portal1.Public = false
-	add = []skymodules.SkynetPortal{portal1}
-	remove = []modules.NetAddress{}
-	err = r.SkynetPortalsPost(add, remove)
-	if err != nil {
-		t.Fatal(err)
+	// The download token may not upload.
+	if err := uploadFile(tokenClient(download.Token)); err == nil || !strings.Contains(err.Error(), "required scope") {
+		t.Fatal("download token shouldn't be able to upload", err)
 	}
 
-	spg, err = r.SkynetPortalsGet()
-	if err != nil {
+	// The admin token may do both.
+	ac := tokenClient(admin.Token)
+	if err := uploadFile(ac); err != nil {
 		t.Fatal(err)
 	}
-	if len(spg.Portals) != 1 {
-		t.Fatalf("Incorrect number of portals, expected %v got %v", 1, len(spg.Portals))
-	}
-	if !reflect.DeepEqual(spg.Portals[0], portal1) {
-		t.Fatalf("Portals don't match, expected %v got %v", portal1, spg.Portals[0])
+	if _, err := ac.SkynetTokensGet(); err != nil {
+		t.Fatal(err)
 	}
 
-	portal1.Public = true
-	add = []skymodules.SkynetPortal{portal1}
-	remove = []modules.NetAddress{}
-	err = r.SkynetPortalsPost(add, remove)
-	if err != nil {
-		t.Fatal(err)
+	// Unknown tokens are rejected.
+	if err := uploadFile(tokenClient("notatoken")); err == nil || !strings.Contains(err.Error(), "authentication failed") {
+		t.Fatal("unknown token shouldn't be able to upload", err)
 	}
 
-	spg, err = r.SkynetPortalsGet()
-	if err != nil {
+	// Revoke the upload token.
+	if err := r.SkynetTokenRevokePost(upload.ID); err != nil {
 		t.Fatal(err)
 	}
-	if len(spg.Portals) != 1 {
-		t.Fatalf("Incorrect number of portals, expected %v got %v", 1, len(spg.Portals))
+	if err := r.SkynetTokenRevokePost(upload.ID); err == nil || !strings.Contains(err.Error(), skymodules.ErrAPITokenNotFound.Error()) {
+		t.Fatal("expected token not found error", err)
 	}
-	if !reflect.DeepEqual(spg.Portals[0], portal1) {
-		t.Fatalf("Portals don't match, expected %v got %v", portal1, spg.Portals[0])
+	if err := uploadFile(uc); err == nil || !strings.Contains(err.Error(), "authentication failed") {
+		t.Fatal("revoked token shouldn't be able to upload", err)
 	}
 
-	// Test an invalid network address.
-	add = []skymodules.SkynetPortal{portal3}
-	remove = []modules.NetAddress{}
-	err = r.SkynetPortalsPost(add, remove)
-	if err == nil || !strings.Contains(err.Error(), "missing port in address") {
-		t.Fatal("expected 'missing port' error")
+	// Clean up the remaining tokens.
+	for _, id := range []string{download.ID, admin.ID} {
+		if err := r.SkynetTokenRevokePost(id); err != nil {
+			t.Fatal(err)
+		}
 	}
+}
 
-	// Test adding an existing portal with an uppercase address.
-	portalUpper := portal1
-	portalUpper.Address = modules.NetAddress(strings.ToUpper(string(portalUpper.Address)))
-	add = []skymodules.SkynetPortal{portalUpper}
-	remove = []modules.NetAddress{}
-	err = r.SkynetPortalsPost(add, remove)
-	// This does not currently return an error.
+// testSkynetTokenQuotas tests that the quotas of API tokens are enforced when
+// uploading and pinning.
+func testSkynetTokenQuotas(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Create an upload token with a quota.
+	quota := skymodules.APITokenQuota{
+		MaxSkyfileSize:       100,
+		MaxUploadBytesPerDay: 250,
+	}
+	upload, err := r.SkynetTokensPost("uploader", skymodules.APITokenScopeUpload, quota)
 	if err != nil {
 		t.Fatal(err)
 	}
-
-	spg, err = r.SkynetPortalsGet()
+	download, err := r.SkynetTokensPost("downloader", skymodules.APITokenScopeDownload, skymodules.APITokenQuota{})
 	if err != nil {
 		t.Fatal(err)
 	}
-	if len(spg.Portals) != 2 {
-		t.Fatalf("Incorrect number of portals, expected %v got %v", 2, len(spg.Portals))
+	uc := client.New(client.Options{
+		Address:   r.Address,
+		Password:  upload.Token,
+		UserAgent: r.UserAgent,
+	})
+	uploadFile := func(size int) (string, error) {
+		skylink, _, err := uc.SkynetSkyfilePost(skymodules.SkyfileUploadParameters{
+			SiaPath:  skymodules.RandomSiaPath(),
+			Filename: "quota",
+			Reader:   bytes.NewReader(fastrand.Bytes(size)),
+		})
+		return skylink, err
 	}
-}
 
-// testSkynetIncludeLayout verifies the functionality of sending
-// a 'include-layout' query string parameter to the skylink GET route.
-func testSkynetIncludeLayout(t *testing.T, tg *siatest.TestGroup) {
-	r := tg.Renters()[0]
+	// Files larger than the max skyfile size are rejected.
+	if _, err := uploadFile(150); err == nil || !strings.Contains(err.Error(), skymodules.ErrAPITokenSkyfileTooLarge.Error()) {
+		t.Fatal("expected skyfile too large error", err)
+	}
 
-	// Upload a skyfile
-	skylink, _, _, err := r.UploadNewSkyfileBlocking(t.Name(), 100, false)
+	// Upload two files within the quota.
+	skylink, err := uploadFile(100)
 	if err != nil {
 		t.Fatal(err)
 	}
-
-	// GET without specifying the 'include-layout' query string parameter
-	_, layout, err := r.SkynetSkylinkGetWithLayout(skylink, false)
-	if err != nil {
+	if _, err := uploadFile(100); err != nil {
 		t.Fatal(err)
 	}
-	if !reflect.DeepEqual(layout, skymodules.SkyfileLayout{}) {
-		t.Fatal("unexpected")
+
+	// The next file exceeds the daily quota.
+	if _, err := uploadFile(60); err == nil || !strings.Contains(err.Error(), skymodules.ErrAPITokenQuotaExceeded.Error()) {
+		t.Fatal("expected quota exceeded error", err)
 	}
 
-	// GET with specifying the 'include-layout' query string parameter
-	_, layout, err = r.SkynetSkylinkGetWithLayout(skylink, true)
+	// The size of multipart uploads is only known while uploading.
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	_, err = skymodules.AddMultipartFile(writer, fastrand.Bytes(60), "files[]", "quota", 0600, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if reflect.DeepEqual(layout, skymodules.SkyfileLayout{}) {
-		t.Fatal("unexpected")
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = uc.SkynetSkyfileMultiPartPost(skymodules.SkyfileMultipartUploadParameters{
+		SiaPath:     skymodules.RandomSiaPath(),
+		Reader:      body,
+		ContentType: writer.FormDataContentType(),
+		Filename:    "quota",
+	})
+	if err == nil || !strings.Contains(err.Error(), skymodules.ErrAPITokenQuotaExceeded.Error()) {
+		t.Fatal("expected quota exceeded error", err)
+	}
+
+	// Check the usage. The token may query its own usage but not the usage of
+	// other tokens.
+	usage, err := uc.SkynetTokenUsageGet(upload.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Quota != quota || usage.Usage.UploadedBytesToday != 200 || usage.Usage.PinnedBytes != 200 {
+		t.Fatal("unexpected usage", usage)
+	}
+	if _, err := uc.SkynetTokenUsageGet(download.ID); err == nil || !strings.Contains(err.Error(), "required scope") {
+		t.Fatal("upload token shouldn't be able to query other tokens", err)
+	}
+
+	// Raise the daily quota and pin the first file again. Since it is
+	// already pinned using the token, it isn't charged again.
+	quota.MaxUploadBytesPerDay = 1000
+	if err := r.SkynetTokenQuotaPost(upload.ID, quota); err != nil {
+		t.Fatal(err)
+	}
+	err = uc.SkynetSkylinkPinPost(skylink, skymodules.SkyfilePinParameters{
+		SiaPath: skymodules.RandomSiaPath(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	usage, err = r.SkynetTokenUsageGet(upload.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Usage.UploadedBytesToday != 200 || usage.Usage.PinnedBytes != 200 {
+		t.Fatal("unexpected usage", usage)
+	}
+
+	// Unpinning the skylink frees its bytes.
+	if err := r.SkynetSkylinkUnpinPost(skylink); err != nil {
+		t.Fatal(err)
+	}
+	usage, err = r.SkynetTokenUsageGet(upload.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Usage.UploadedBytesToday != 200 || usage.Usage.PinnedBytes != 100 {
+		t.Fatal("unexpected usage", usage)
+	}
+
+	// Directory uploads count towards the quota as well. Files which exceed
+	// the quota are rejected while they are uploaded.
+	quota.MaxSkyfileSize = 0
+	quota.MaxUploadBytesPerDay = 300
+	if err := r.SkynetTokenQuotaPost(upload.ID, quota); err != nil {
+		t.Fatal(err)
+	}
+	session, err := uc.SkynetDirUploadPost(api.SkynetDirUploadPOST{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = uc.SkynetDirUploadFilePost(session.ID, skymodules.SkynetDirUploadFile{Filename: "big"}, bytes.NewReader(fastrand.Bytes(150)))
+	if err == nil || !strings.Contains(err.Error(), skymodules.ErrAPITokenQuotaExceeded.Error()) {
+		t.Fatal("expected quota exceeded error", err)
+	}
+	err = uc.SkynetDirUploadFilePost(session.ID, skymodules.SkynetDirUploadFile{Filename: "small"}, bytes.NewReader(fastrand.Bytes(50)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := uc.SkynetDirUploadFinalizePost(session.ID); err != nil {
+		t.Fatal(err)
+	}
+	usage, err = r.SkynetTokenUsageGet(upload.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Usage.UploadedBytesToday != 250 || usage.Usage.PinnedBytes != 150 {
+		t.Fatal("unexpected usage", usage)
+	}
+
+	// Clean up the tokens.
+	for _, id := range []string{upload.ID, download.ID} {
+		if err := r.SkynetTokenRevokePost(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// testSkynetFolderQuotas tests that the quotas of top-level skynet folders are
+// enforced when uploading and pinning.
+func testSkynetFolderQuotas(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	tenant := hex.EncodeToString(fastrand.Bytes(8))
+	other := hex.EncodeToString(fastrand.Bytes(8))
+	uploadFile := func(folder string, size int) (string, error) {
+		siaPath, err := skymodules.NewSiaPath(folder + "/" + hex.EncodeToString(fastrand.Bytes(8)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		skylink, _, err := r.SkynetSkyfilePost(skymodules.SkyfileUploadParameters{
+			SiaPath:  siaPath,
+			Filename: "quota",
+			Reader:   bytes.NewReader(fastrand.Bytes(size)),
+		})
+		return skylink, err
+	}
+
+	// Invalid folders are rejected.
+	if err := r.SkynetQuotasPost("a/b", 100); err == nil || !strings.Contains(err.Error(), skymodules.ErrInvalidSkynetFolder.Error()) {
+		t.Fatal("expected invalid folder error", err)
+	}
+
+	// Set a quota for a folder that doesn't exist yet.
+	quota := 10 * modules.SectorSize
+	if err := r.SkynetQuotasPost(tenant, quota); err != nil {
+		t.Fatal(err)
+	}
+	usage, err := r.SkynetQuotaGet(tenant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Folder != tenant || usage.Size != 0 || usage.Quota != quota {
+		t.Fatal("unexpected usage", usage)
+	}
+
+	// Upload a file to the folder and wait for its usage to be updated.
+	skylink, err := uploadFile(tenant, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		usage, err = r.SkynetQuotaGet(tenant)
+		if err != nil {
+			return err
+		}
+		if usage.NumFiles != 1 || usage.Size == 0 {
+			return fmt.Errorf("usage wasn't updated %+v", usage)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Lower the quota such that the next file doesn't fit anymore.
+	if err := r.SkynetQuotasPost(tenant, usage.Size+50); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uploadFile(tenant, 100); err == nil || !strings.Contains(err.Error(), skymodules.ErrSkynetFolderQuotaExceeded.Error()) {
+		t.Fatal("expected quota exceeded error", err)
+	}
+	pinPath, err := skymodules.NewSiaPath(tenant + "/pin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.SkynetSkylinkPinPost(skylink, skymodules.SkyfilePinParameters{
+		SiaPath: pinPath,
+	})
+	if err == nil || !strings.Contains(err.Error(), skymodules.ErrSkynetFolderQuotaExceeded.Error()) {
+		t.Fatal("expected quota exceeded error", err)
+	}
+
+	// Other folders aren't affected.
+	if _, err := uploadFile(other, 100); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both folders are listed.
+	quotas, err := r.SkynetQuotasGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := 0
+	for _, u := range quotas.Folders {
+		if u.Folder == tenant && u.Quota == usage.Size+50 {
+			found++
+		}
+		if u.Folder == other && u.Quota == 0 {
+			found++
+		}
+	}
+	if found != 2 {
+		t.Fatal("folders weren't listed", quotas.Folders)
+	}
+
+	// Removing the quota allows uploads again.
+	if err := r.SkynetQuotasPost(tenant, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uploadFile(tenant, 100); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testSkynetSkylinks verifies that the skylinks of a node can be listed,
+// filtered and searched.
+func testSkynetSkylinks(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload two files with a unique filename prefix.
+	prefix := hex.EncodeToString(fastrand.Bytes(8))
+	upload := func(name string, size int) string {
+		skylink, _, err := r.SkynetSkyfilePost(skymodules.SkyfileUploadParameters{
+			SiaPath:  skymodules.RandomSiaPath(),
+			Filename: prefix + name,
+			Reader:   bytes.NewReader(fastrand.Bytes(size)),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return skylink
+	}
+	skylinkSmall := upload("small.txt", 10)
+	skylinkLarge := upload("large.txt", 100)
+
+	// list returns the skylinks matching the filename prefix and the values.
+	list := func(values url.Values) map[string]skymodules.SkylinkInfo {
+		t.Helper()
+		values.Set("search", prefix)
+		ssg, err := r.SkynetSkylinksGet(values)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ssg.Total != uint64(len(ssg.Skylinks)) {
+			t.Fatal("wrong total", ssg.Total, len(ssg.Skylinks))
+		}
+		infos := make(map[string]skymodules.SkylinkInfo)
+		for _, info := range ssg.Skylinks {
+			infos[info.Skylink] = info
+		}
+		return infos
+	}
+
+	// Both skylinks are found by searching for the prefix.
+	infos := list(url.Values{})
+	if len(infos) != 2 {
+		t.Fatal("wrong number of skylinks", len(infos))
+	}
+	info := infos[skylinkSmall]
+	if info.Filename != prefix+"small.txt" || info.Size != 10 || !info.Pinned || info.Encrypted {
+		t.Fatal("wrong info", info)
+	}
+
+	// Filter by size and search the filename.
+	if infos := list(url.Values{"minsize": []string{"50"}}); len(infos) != 1 || infos[skylinkLarge].Skylink == "" {
+		t.Fatal("wrong skylinks", infos)
+	}
+	values := url.Values{}
+	values.Set("search", prefix+"SMALL")
+	ssg, err := r.SkynetSkylinksGet(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ssg.Skylinks) != 1 || ssg.Skylinks[0].Skylink != skylinkSmall {
+		t.Fatal("wrong skylinks", ssg.Skylinks)
+	}
+
+	// Invalid parameters are rejected.
+	_, err = r.SkynetSkylinksGet(url.Values{"pinned": []string{"maybe"}})
+	if err == nil {
+		t.Fatal("expected invalid pinned parameter to be rejected")
+	}
+	_, err = r.SkynetSkylinksGet(url.Values{"limit": []string{fmt.Sprint(skymodules.MaxSkylinksLimit + 1)}})
+	if err == nil || !strings.Contains(err.Error(), skymodules.ErrSkylinksLimitTooHigh.Error()) {
+		t.Fatal("expected limit to be rejected", err)
+	}
+
+	// Unpin the small skylink. It's still listed but no longer pinned. Unpinning
+	// happens in the background so retry.
+	if err := r.SkynetSkylinkUnpinPost(skylinkSmall); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		infos := list(url.Values{"pinned": []string{"false"}})
+		if len(infos) != 1 || infos[skylinkSmall].Skylink == "" {
+			return fmt.Errorf("wrong skylinks %v", infos)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Delete the large skylink. It's no longer listed.
+	job, err := r.SkynetDeletePost(skylinkLarge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		job, err = r.SkynetDeleteGet(job.ID)
+		if err != nil {
+			return err
+		}
+		if job.Status != skymodules.SkynetDeleteStatusComplete {
+			return fmt.Errorf("job not complete: %v %v", job.Status, job.Error)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if infos := list(url.Values{}); len(infos) != 1 || infos[skylinkLarge].Skylink != "" {
+		t.Fatal("wrong skylinks", infos)
+	}
+}
+
+// testSkynetImport tests importing skylinks from a portal with /skynet/import.
+func testSkynetImport(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+	portal := "http://" + r.Client.Address
+
+	// importAndVerify unpins the skylink, imports it from the portal and
+	// checks that it is pinned again and downloads the expected data.
+	importAndVerify := func(skylink string, expected []byte) {
+		t.Helper()
+		if err := r.SkynetSkylinkUnpinPost(skylink); err != nil {
+			t.Fatal(err)
+		}
+		// Unpinning happens in the background so wait for the siafiles to
+		// be deleted.
+		err := build.Retry(100, 100*time.Millisecond, func() error {
+			rd, err := r.RenterDirRootGet(skymodules.SkynetFolder)
+			if err != nil {
+				return err
+			}
+			for _, f := range rd.Files {
+				for _, sl := range f.Skylinks {
+					if sl == skylink {
+						return fmt.Errorf("siafile %v wasn't deleted yet", f.SiaPath)
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		imported, err := r.SkynetImportPost(skylink, portal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if imported != skylink {
+			t.Fatalf("skylink changed during import %v != %v", imported, skylink)
+		}
+		siaPath, err := skymodules.SkynetFolder.Join(skylink)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.RenterFileRootGet(siaPath); err != nil {
+			t.Fatal("imported skylink isn't pinned", err)
+		}
+		data, err := r.SkynetSkylinkConcatGet(skylink)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, expected) {
+			t.Fatal("wrong data")
+		}
+	}
+
+	// Import a small and a large skyfile.
+	for _, size := range []uint64{100, 2*modules.SectorSize + 1} {
+		data := fastrand.Bytes(int(size))
+		skylink, _, _, err := r.UploadNewSkyfileWithDataBlocking(persist.RandomSuffix(), data, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		importAndVerify(skylink, data)
+	}
+
+	// Import a large multipart skyfile.
+	files := []siatest.TestFile{
+		{Name: "a", Data: fastrand.Bytes(int(modules.SectorSize))},
+		{Name: "b", Data: fastrand.Bytes(int(modules.SectorSize))},
+	}
+	skylink, _, _, err := r.UploadNewMultipartSkyfileBlocking(persist.RandomSuffix(), files, "", true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	importAndVerify(skylink, append(files[0].Data, files[1].Data...))
+
+	// Importing a pinned skylink fails since the siafile already exists.
+	if _, err := r.SkynetImportPost(skylink, portal); err == nil {
+		t.Fatal("expected import of pinned skylink to fail")
+	}
+
+	// Importing from an invalid portal fails.
+	if _, err := r.SkynetImportPost(skylink, "localhost"); err == nil || !strings.Contains(err.Error(), "invalid 'portal' parameter") {
+		t.Fatal("unexpected error", err)
+	}
+}
+
+// testSkynetFanoutRedundancyPolicy verifies that the dynamic fanout redundancy
+// policy chooses the redundancy of a skyfile's fanout based on the available
+// hosts and records it in the skyfile's layout.
+func testSkynetFanoutRedundancyPolicy(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// uploadLayout uploads a large skyfile, checks that it can be downloaded
+	// and returns its layout.
+	uploadLayout := func() skymodules.SkyfileLayout {
+		t.Helper()
+		data := fastrand.Bytes(int(2 * modules.SectorSize))
+		skylink, _, _, err := r.UploadNewSkyfileWithDataBlocking("redundancy", data, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		downloaded, err := r.SkynetSkylinkGet(skylink)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(downloaded, data) {
+			t.Fatal("data mismatch")
+		}
+		baseSectorReader, err := r.SkynetBaseSectorGet(skylink)
+		if err != nil {
+			t.Fatal(err)
+		}
+		baseSector, err := ioutil.ReadAll(baseSectorReader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var layout skymodules.SkyfileLayout
+		layout.Decode(baseSector)
+		return layout
+	}
+
+	// The static policy is the default.
+	rg, err := r.RenterGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rg.Settings.FanoutRedundancyPolicy != skymodules.FanoutRedundancyPolicyStatic {
+		t.Fatal("unexpected policy", rg.Settings.FanoutRedundancyPolicy)
+	}
+	layout := uploadLayout()
+	if int(layout.FanoutDataPieces) != skymodules.RenterDefaultDataPieces || int(layout.FanoutParityPieces) != skymodules.RenterDefaultParityPieces {
+		t.Fatal("unexpected redundancy", layout.FanoutDataPieces, layout.FanoutParityPieces)
+	}
+
+	// Unknown policies are rejected.
+	err = r.RenterFanoutRedundancyPolicyPost("random")
+	if err == nil || !strings.Contains(err.Error(), skymodules.ErrUnknownFanoutRedundancyPolicy.Error()) {
+		t.Fatal("expected policy to be rejected", err)
+	}
+
+	// Enable the dynamic policy.
+	err = r.RenterFanoutRedundancyPolicyPost(skymodules.FanoutRedundancyPolicyDynamic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.RenterFanoutRedundancyPolicyPost(skymodules.FanoutRedundancyPolicyStatic); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// The redundancy should match the number of hosts.
+	dataPieces, parityPieces := skymodules.FanoutRedundancyPolicyDynamic.Redundancy(len(tg.Hosts()))
+	layout = uploadLayout()
+	if int(layout.FanoutDataPieces) != dataPieces || int(layout.FanoutParityPieces) != parityPieces {
+		t.Fatal("unexpected redundancy", layout.FanoutDataPieces, layout.FanoutParityPieces)
+	}
+}
+
+// testSkynetUploadLimits verifies that the skyfile upload limits of the
+// portal are enforced.
+func testSkynetUploadLimits(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// setLimits sets the limits and checks that they were applied.
+	setLimits := func(limits skymodules.SkyfileUploadLimits) {
+		t.Helper()
+		if err := r.RenterSkyfileUploadLimitsPost(limits); err != nil {
+			t.Fatal(err)
+		}
+		rg, err := r.RenterGet()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rg.Settings.SkyfileUploadLimits != limits {
+			t.Fatal("limits weren't applied", rg.Settings.SkyfileUploadLimits)
+		}
+	}
+	defer setLimits(skymodules.SkyfileUploadLimits{})
+	uploadFile := func(size int) error {
+		_, _, err := r.SkynetSkyfilePost(skymodules.SkyfileUploadParameters{
+			SiaPath:  skymodules.RandomSiaPath(),
+			Filename: "limits",
+			Reader:   bytes.NewReader(fastrand.Bytes(size)),
+		})
+		return err
+	}
+	uploadMultipart := func(numFiles int) error {
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		for i := 0; i < numFiles; i++ {
+			_, err := skymodules.AddMultipartFile(writer, fastrand.Bytes(10), "files[]", fmt.Sprint(i), 0600, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+		_, _, err := r.SkynetSkyfileMultiPartPost(skymodules.SkyfileMultipartUploadParameters{
+			SiaPath:     skymodules.RandomSiaPath(),
+			Reader:      body,
+			ContentType: writer.FormDataContentType(),
+			Filename:    "limits",
+		})
+		return err
+	}
+
+	// Uploads larger than the max upload size are rejected.
+	setLimits(skymodules.SkyfileUploadLimits{MaxUploadSize: 100})
+	if err := uploadFile(100); err != nil {
+		t.Fatal(err)
+	}
+	if err := uploadFile(101); err == nil || !strings.Contains(err.Error(), skymodules.ErrSkyfileUploadTooLarge.Error()) {
+		t.Fatal("expected upload too large error", err)
+	}
+
+	// Uploads with too many subfiles are rejected.
+	setLimits(skymodules.SkyfileUploadLimits{MaxSubfiles: 2})
+	if err := uploadMultipart(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := uploadMultipart(3); err == nil || !strings.Contains(err.Error(), skymodules.ErrSkyfileTooManySubfiles.Error()) {
+		t.Fatal("expected too many subfiles error", err)
+	}
+
+	// Uploads with too much metadata are rejected.
+	setLimits(skymodules.SkyfileUploadLimits{MaxMetadataSize: 10})
+	if err := uploadFile(10); err == nil || !strings.Contains(err.Error(), skymodules.ErrSkyfileMetadataTooLarge.Error()) {
+		t.Fatal("expected metadata too large error", err)
+	}
+
+	// TUS uploads are subject to the same limits.
+	chunkSize := int64(skymodules.ChunkSize(crypto.TypePlain, uint64(skymodules.RenterDefaultDataPieces)))
+	setLimits(skymodules.SkyfileUploadLimits{MaxUploadSize: 100})
+	if _, err := r.SkynetTUSUploadFromBytes(fastrand.Bytes(100), chunkSize, "limits", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.SkynetTUSUploadFromBytes(fastrand.Bytes(101), chunkSize, "limits", ""); !errors.Contains(err, tus.ErrLargeUpload) {
+		t.Fatal("expected upload too large error", err)
+	}
+	setLimits(skymodules.SkyfileUploadLimits{MaxMetadataSize: 10})
+	if _, err := r.SkynetTUSUploadFromBytes(fastrand.Bytes(10), chunkSize, "limits", ""); !errors.Contains(err, tus.ErrLargeUpload) {
+		t.Fatal("expected metadata too large error", err)
+	}
+
+	// So are directory uploads. Files which exceed the limits are rejected
+	// right away.
+	setLimits(skymodules.SkyfileUploadLimits{MaxUploadSize: 100, MaxSubfiles: 2})
+	session, err := r.SkynetDirUploadPost(api.SkynetDirUploadPOST{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, size := range []int{60, 40} {
+		err = r.SkynetDirUploadFilePost(session.ID, skymodules.SkynetDirUploadFile{Filename: fmt.Sprint(i)}, bytes.NewReader(fastrand.Bytes(size)))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = r.SkynetDirUploadFilePost(session.ID, skymodules.SkynetDirUploadFile{Filename: "1"}, bytes.NewReader(fastrand.Bytes(41)))
+	if err == nil || !strings.Contains(err.Error(), skymodules.ErrSkyfileUploadTooLarge.Error()) {
+		t.Fatal("expected upload too large error", err)
+	}
+	err = r.SkynetDirUploadFilePost(session.ID, skymodules.SkynetDirUploadFile{Filename: "2"}, bytes.NewReader(fastrand.Bytes(0)))
+	if err == nil || !strings.Contains(err.Error(), skymodules.ErrSkyfileTooManySubfiles.Error()) {
+		t.Fatal("expected too many subfiles error", err)
+	}
+	_, err = r.SkynetDirUploadPost(api.SkynetDirUploadPOST{
+		Files: []skymodules.SkynetDirUploadFile{{Filename: "a", Len: 60}, {Filename: "b", Len: 41}},
+	})
+	if err == nil || !strings.Contains(err.Error(), skymodules.ErrSkyfileUploadTooLarge.Error()) {
+		t.Fatal("expected upload too large error", err)
+	}
+
+	// The metadata is checked when the session is finalized.
+	setLimits(skymodules.SkyfileUploadLimits{MaxMetadataSize: 10})
+	_, _, err = r.SkynetDirUploadFinalizePost(session.ID)
+	if err == nil || !strings.Contains(err.Error(), skymodules.ErrSkyfileMetadataTooLarge.Error()) {
+		t.Fatal("expected metadata too large error", err)
+	}
+	setLimits(skymodules.SkyfileUploadLimits{})
+	if _, _, err = r.SkynetDirUploadFinalizePost(session.ID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testSkynetCustomHeaders verifies that custom response headers can be
+// attached to skyfiles and are returned when the skyfile is downloaded.
+func testSkynetCustomHeaders(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload a file with custom headers.
+	upload := func(headers map[string]string) (string, error) {
+		skylink, _, err := r.SkynetSkyfilePost(skymodules.SkyfileUploadParameters{
+			SiaPath:  skymodules.RandomSiaPath(),
+			Filename: "file",
+			Reader:   bytes.NewReader(fastrand.Bytes(10)),
+			Headers:  headers,
+		})
+		return skylink, err
+	}
+	skylink, err := upload(map[string]string{
+		"cache-control":               "public, max-age=31536000",
+		"Access-Control-Allow-Origin": "*",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Headers which aren't allowed are rejected.
+	_, err = upload(map[string]string{"Content-Type": "text/html"})
+	if err == nil || !strings.Contains(err.Error(), skymodules.ErrSkyfileHeaderNotAllowed.Error()) {
+		t.Fatal("expected header not allowed error", err)
+	}
+
+	// The headers are stored canonicalized in the metadata.
+	_, sm, err := r.SkynetMetadataGet(skylink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sm.Headers["Cache-Control"] != "public, max-age=31536000" || sm.Headers["Access-Control-Allow-Origin"] != "*" {
+		t.Fatal("wrong headers in metadata", sm.Headers)
+	}
+
+	// The headers are set on downloads.
+	_, header, err := r.SkynetSkylinkHead(skylink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("Cache-Control") != "public, max-age=31536000" {
+		t.Fatal("wrong Cache-Control header", header.Get("Cache-Control"))
+	}
+	if header.Get("Access-Control-Allow-Origin") != "*" {
+		t.Fatal("wrong Access-Control-Allow-Origin header", header.Get("Access-Control-Allow-Origin"))
+	}
+
+	// The headers of a multipart upload apply to all of its subfiles.
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	var offset uint64
+	for _, name := range []string{"index.html", "about.html"} {
+		_, err = skymodules.AddMultipartFile(writer, []byte(name+"_contents"), "files[]", name, skymodules.DefaultFilePerm, &offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	skylink, _, err = r.SkynetSkyfileMultiPartPost(skymodules.SkyfileMultipartUploadParameters{
+		SiaPath:     skymodules.RandomSiaPath(),
+		Filename:    "site",
+		Reader:      body,
+		ContentType: writer.FormDataContentType(),
+		TryFiles:    skymodules.DefaultTryFilesValue,
+		Headers:     map[string]string{"Cache-Control": "no-cache"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, header, err = r.SkynetSkylinkHead(skylink + "/about.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("Cache-Control") != "no-cache" {
+		t.Fatal("wrong Cache-Control header", header.Get("Cache-Control"))
+	}
+}
+
+// testSkynetExtraMetadata verifies that extra metadata can be attached to
+// skyfiles and that the skyfiles of a folder can be filtered by it.
+func testSkynetExtraMetadata(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload a few files with different extra metadata into a folder.
+	dir := skymodules.RandomSiaPath()
+	upload := func(name, extraMetadata string) (string, error) {
+		siaPath, err := dir.Join(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		skylink, _, err := r.SkynetSkyfilePost(skymodules.SkyfileUploadParameters{
+			SiaPath:       siaPath,
+			Filename:      name,
+			Reader:        bytes.NewReader(fastrand.Bytes(10)),
+			ExtraMetadata: json.RawMessage(extraMetadata),
+		})
+		return skylink, err
+	}
+	skylinkA, err := upload("a", `{"app": "foo", "tags": ["x", "y"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skylinkB, err := upload("b", `{"app": "bar"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skylinkC, err := upload("c", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid or too large extra metadata is rejected.
+	if _, err := upload("d", `["app"]`); err == nil || !strings.Contains(err.Error(), skymodules.ErrInvalidExtraMetadata.Error()) {
+		t.Fatal("expected invalid extra metadata error", err)
+	}
+	tooLarge := fmt.Sprintf(`{"data": "%v"}`, strings.Repeat("a", skymodules.SkyfileMaxExtraMetadataSize))
+	if _, err := upload("d", tooLarge); err == nil || !strings.Contains(err.Error(), skymodules.ErrSkyfileExtraMetadataTooLarge.Error()) {
+		t.Fatal("expected extra metadata too large error", err)
+	}
+
+	// The extra metadata is returned in the skyfile's metadata.
+	_, sm, err := r.SkynetMetadataGet(skylinkA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(sm.ExtraMetadata) != `{"app":"foo","tags":["x","y"]}` {
+		t.Fatal("wrong extra metadata", string(sm.ExtraMetadata))
+	}
+	_, header, err := r.SkynetSkylinkHead(skylinkA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var headerMetadata skymodules.SkyfileMetadata
+	err = json.Unmarshal([]byte(header.Get(api.SkynetFileMetadataHeader)), &headerMetadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(headerMetadata.ExtraMetadata, sm.ExtraMetadata) {
+		t.Fatal("wrong extra metadata in header", string(headerMetadata.ExtraMetadata))
+	}
+
+	// listFolder lists the folder and returns the skylinks of the result.
+	listFolder := func(filter string) []string {
+		t.Helper()
+		sfg, err := r.SkynetFolderGet(dir, false, filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var skylinks []string
+		for _, sf := range sfg.Skyfiles {
+			if sf.Error != "" {
+				t.Fatal(sf.Error)
+			}
+			skylinks = append(skylinks, sf.Skylink)
+		}
+		return skylinks
+	}
+
+	// Without a filter all skyfiles are listed.
+	if skylinks := listFolder(""); !reflect.DeepEqual(skylinks, []string{skylinkA, skylinkB, skylinkC}) {
+		t.Fatal("wrong skyfiles", skylinks)
+	}
+	// Filter by a single key.
+	if skylinks := listFolder(`{"app": "foo"}`); !reflect.DeepEqual(skylinks, []string{skylinkA}) {
+		t.Fatal("wrong skyfiles", skylinks)
+	}
+	// Filter by a nested value.
+	if skylinks := listFolder(`{"app": "foo", "tags": ["x", "y"]}`); !reflect.DeepEqual(skylinks, []string{skylinkA}) {
+		t.Fatal("wrong skyfiles", skylinks)
+	}
+	// Filter without a match.
+	if skylinks := listFolder(`{"app": "baz"}`); len(skylinks) != 0 {
+		t.Fatal("wrong skyfiles", skylinks)
+	}
+	// Invalid filters are rejected.
+	if _, err := r.SkynetFolderGet(dir, false, "app"); err == nil {
+		t.Fatal("expected invalid filter to be rejected")
+	}
+}
+
+// testSkynetContentHashes verifies that the content hashes of skyfiles
+// uploaded with the 'contenthashes' parameter are stored in their metadata and
+// returned in the headers of their downloads.
+func testSkynetContentHashes(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// checkHashes uploads the data and checks the hashes of the skyfile.
+	checkHashes := func(data []byte, contentHashes bool) {
+		sup := skymodules.SkyfileUploadParameters{
+			SiaPath:       skymodules.RandomSiaPath(),
+			Filename:      "file",
+			Reader:        bytes.NewReader(data),
+			ContentHashes: contentHashes,
+		}
+		skylink, _, err := r.SkynetSkyfilePost(sup)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, md, err := r.SkynetMetadataGet(skylink)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, header, err := r.SkynetSkylinkHead(skylink)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !contentHashes {
+			if md.Hashes != nil || header.Get(api.SkynetSkyfileSHA256Header) != "" {
+				t.Fatal("hashes shouldn't be computed", md.Hashes)
+			}
+			return
+		}
+		sha256Hash := sha256.Sum256(data)
+		blake3Hash := blake3.Sum256(data)
+		expected := skymodules.SkyfileHashes{
+			BLAKE3: hex.EncodeToString(blake3Hash[:]),
+			SHA256: hex.EncodeToString(sha256Hash[:]),
+		}
+		if md.Hashes == nil || *md.Hashes != expected {
+			t.Fatal("unexpected hashes", md.Hashes, expected)
+		}
+		if header.Get(api.SkynetSkyfileBLAKE3Header) != expected.BLAKE3 {
+			t.Fatal("unexpected blake3 header", header.Get(api.SkynetSkyfileBLAKE3Header))
+		}
+		if header.Get(api.SkynetSkyfileSHA256Header) != expected.SHA256 {
+			t.Fatal("unexpected sha256 header", header.Get(api.SkynetSkyfileSHA256Header))
+		}
+	}
+
+	// Check a small and a large skyfile.
+	checkHashes(fastrand.Bytes(100), true)
+	checkHashes(fastrand.Bytes(int(modules.SectorSize)+siatest.Fuzz()), true)
+
+	// Without the parameter no hashes are computed.
+	checkHashes(fastrand.Bytes(100), false)
+}
+
+// testSkynetExtractUpload verifies that archives uploaded with the 'extract'
+// parameter are uploaded as a skyfile with a subfile per archived file.
+func testSkynetExtractUpload(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Create a tar archive of a small website.
+	index := []byte("<html><body>hello</body></html>")
+	script := []byte("console.log('hello');")
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	for _, f := range []struct {
+		name string
+		data []byte
+	}{{"index.html", index}, {"js/app.js", script}} {
+		err := tw.WriteHeader(&tar.Header{Name: f.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(f.data))})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Upload the archive.
+	sup := skymodules.SkyfileUploadParameters{
+		SiaPath:     skymodules.RandomSiaPath(),
+		Filename:    "website",
+		DefaultPath: "/index.html",
+		Reader:      bytes.NewReader(buf.Bytes()),
+	}
+	skylink, _, err := r.SkynetSkyfileExtractPost(sup)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The default path should be served and the subfiles should be available.
+	data, err := r.SkynetSkylinkGet(skylink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, index) {
+		t.Fatal("unexpected data", string(data))
+	}
+	data, err = r.SkynetSkylinkGet(skylink + "/js/app.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, script) {
+		t.Fatal("unexpected data", string(data))
+	}
+	_, md, err := r.SkynetMetadataGet(skylink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(md.Subfiles) != 2 || md.Subfiles["js/app.js"].Offset != uint64(len(index)) {
+		t.Fatal("unexpected metadata", md)
+	}
+
+	// Archives with paths escaping the skyfile are rejected.
+	buf = new(bytes.Buffer)
+	tw = tar.NewWriter(buf)
+	err = tw.WriteHeader(&tar.Header{Name: "../evil", Typeflag: tar.TypeReg, Mode: 0644, Size: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sup.SiaPath = skymodules.RandomSiaPath()
+	sup.Reader = bytes.NewReader(buf.Bytes())
+	_, _, err = r.SkynetSkyfileExtractPost(sup)
+	if err == nil || !strings.Contains(err.Error(), skymodules.ErrExtractIllegalPath.Error()) {
+		t.Fatal("expected upload to fail", err)
+	}
+
+	// Data which isn't an archive is rejected.
+	sup.Reader = bytes.NewReader(fastrand.Bytes(1000))
+	_, _, err = r.SkynetSkyfileExtractPost(sup)
+	if err == nil || !strings.Contains(err.Error(), skymodules.ErrExtractUnsupportedFormat.Error()) {
+		t.Fatal("expected upload to fail", err)
+	}
+}
+
+// testSkynetSignedURLs tests that skylinks restricted to signed URLs can't be
+// accessed through any of the download endpoints without a signature.
+func testSkynetSignedURLs(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload a skyfile and restrict it.
+	skylink, _, _, err := r.UploadNewSkyfileBlocking("signedurls", 100, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ssp, err := r.SkynetSignPost(skylink, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// None of the endpoints should serve the skyfile without a signature.
+	_, err = r.SkynetSkylinkGet(skylink)
+	if err == nil || !strings.Contains(err.Error(), skymodules.ErrSkylinkSignatureRequired.Error()) {
+		t.Fatal("expected skylink download to fail", err)
+	}
+	_, _, err = r.SkynetMetadataGet(skylink)
+	if err == nil || !strings.Contains(err.Error(), skymodules.ErrSkylinkSignatureRequired.Error()) {
+		t.Fatal("expected metadata download to fail", err)
+	}
+	reader, err := r.SkynetBaseSectorGet(skylink)
+	if err == nil {
+		_ = reader.Close()
+	}
+	if err == nil || !strings.Contains(err.Error(), skymodules.ErrSkylinkSignatureRequired.Error()) {
+		t.Fatal("expected base sector download to fail", err)
+	}
+
+	// With the signature they should.
+	_, err = r.SkynetSignedURLGet(ssp.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := fmt.Sprintf("expires=%v&signature=%v", ssp.Expires, ssp.Signature)
+	_, err = r.SkynetSignedURLGet(fmt.Sprintf("/skynet/metadata/%v?%v", skylink, query))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.SkynetSignedURLGet(fmt.Sprintf("/skynet/basesector/%v?%v", skylink, query))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The base sector can't be fetched by its root without the API password.
+	var sl skymodules.Skylink
+	if err := sl.LoadString(skylink); err != nil {
+		t.Fatal(err)
+	}
+	offset, fetchSize, err := sl.OffsetAndFetchSize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := r.Client
+	c.Password = ""
+	reader, err = c.SkynetDownloadByRootGet(sl.MerkleRoot(), offset, fetchSize, -1)
+	if err == nil {
+		_ = reader.Close()
+	}
+	if err == nil || !strings.Contains(err.Error(), "API authentication failed") {
+		t.Fatal("expected root download to fail", err)
+	}
+}
+
+// testSkynetDelete tests deleting a skylink.
+func testSkynetDelete(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload a skyfile and pin it to a second siapath.
+	skylink, sup, _, err := r.UploadNewSkyfileBlocking("delete", 100, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinSiaPath := skymodules.RandomSiaPath()
+	err = r.SkynetSkylinkPinPost(skylink, skymodules.SkyfilePinParameters{
+		SiaPath: pinSiaPath,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Delete the skylink and wait for the job to complete.
+	job, err := r.SkynetDeletePost(skylink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Skylink != skylink {
+		t.Fatal("wrong skylink", job.Skylink)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		job, err = r.SkynetDeleteGet(job.ID)
+		if err != nil {
+			return err
+		}
+		if job.Status != skymodules.SkynetDeleteStatusComplete {
+			return fmt.Errorf("job not complete: %v %v", job.Status, job.Error)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Both siafiles should be gone.
+	if len(job.DeletedFiles) != 2 {
+		t.Fatal("expected 2 deleted files", job.DeletedFiles)
+	}
+	for _, siaPath := range []skymodules.SiaPath{sup.SiaPath, pinSiaPath} {
+		fullSiaPath, err := skymodules.SkynetFolder.Join(siaPath.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = r.RenterFileRootGet(fullSiaPath)
+		if err == nil || !strings.Contains(err.Error(), filesystem.ErrNotExist.Error()) {
+			t.Fatal("skyfile still present after deletion", err)
+		}
+	}
+
+	// The skylink can't be downloaded or pinned anymore.
+	if _, err := r.SkynetSkylinkGet(skylink); err == nil || !strings.Contains(err.Error(), skymodules.ErrSkylinkDeleted.Error()) {
+		t.Fatal("expected skylink deleted error", err)
+	}
+	err = r.SkynetSkylinkPinPost(skylink, skymodules.SkyfilePinParameters{
+		SiaPath: skymodules.RandomSiaPath(),
+	})
+	if err == nil || !strings.Contains(err.Error(), skymodules.ErrSkylinkDeleted.Error()) {
+		t.Fatal("expected skylink deleted error", err)
+	}
+
+	// Unknown jobs can't be found.
+	if _, err := r.SkynetDeleteGet("unknown"); err == nil || !strings.Contains(err.Error(), skymodules.ErrSkynetDeleteJobNotFound.Error()) {
+		t.Fatal("expected job not found error", err)
+	}
+}
+
+// testSkynetPortals tests the skynet portals module.
+func testSkynetPortals(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	portal1 := skymodules.SkynetPortal{
+		Address: modules.NetAddress("siasky.net:9980"),
+		Public:  true,
+	}
+	// loopback address
+	portal2 := skymodules.SkynetPortal{
+		Address: "localhost:9980",
+		Public:  true,
+	}
+	// address without a port
+	portal3 := skymodules.SkynetPortal{
+		Address: modules.NetAddress("siasky.net"),
+		Public:  true,
+	}
+
+	// Add portal.
+	add := []skymodules.SkynetPortal{portal1}
+	remove := []modules.NetAddress{}
+	err := r.SkynetPortalsPost(add, remove)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Confirm that the portal has been added.
+	spg, err := r.SkynetPortalsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spg.Portals) != 1 {
+		t.Fatalf("Incorrect number of portals, expected %v got %v", 1, len(spg.Portals))
+	}
+	if !reflect.DeepEqual(spg.Portals[0], portal1) {
+		t.Fatalf("Portals don't match, expected %v got %v", portal1, spg.Portals[0])
+	}
+
+	// Remove the portal.
+	add = []skymodules.SkynetPortal{}
+	remove = []modules.NetAddress{portal1.Address}
+	err = r.SkynetPortalsPost(add, remove)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Confirm that the portal has been removed.
+	spg, err = r.SkynetPortalsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spg.Portals) != 0 {
+		t.Fatalf("Incorrect number of portals, expected %v got %v", 0, len(spg.Portals))
+	}
+
+	// Try removing a portal that's not there.
+	add = []skymodules.SkynetPortal{}
+	remove = []modules.NetAddress{portal1.Address}
+	err = r.SkynetPortalsPost(add, remove)
+	if err == nil || !strings.Contains(err.Error(), "address "+string(portal1.Address)+" not already present in list of portals or being added") {
+		t.Fatal("portal should fail to be removed")
+	}
+
+	// Try to add and remove a portal at the same time.
+	add = []skymodules.SkynetPortal{portal2}
+	remove = []modules.NetAddress{portal2.Address}
+	err = r.SkynetPortalsPost(add, remove)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Verify that the portal was not added.
+	spg, err = r.SkynetPortalsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spg.Portals) != 0 {
+		t.Fatalf("Incorrect number of portals, expected %v got %v", 0, len(spg.Portals))
+	}
+
+	// Test updating a portal's public status.
+	portal1.Public = false
+	add = []skymodules.SkynetPortal{portal1}
+	remove = []modules.NetAddress{}
+	err = r.SkynetPortalsPost(add, remove)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spg, err = r.SkynetPortalsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spg.Portals) != 1 {
+		t.Fatalf("Incorrect number of portals, expected %v got %v", 1, len(spg.Portals))
+	}
+	if !reflect.DeepEqual(spg.Portals[0], portal1) {
+		t.Fatalf("Portals don't match, expected %v got %v", portal1, spg.Portals[0])
+	}
+
+	portal1.Public = true
+	add = []skymodules.SkynetPortal{portal1}
+	remove = []modules.NetAddress{}
+	err = r.SkynetPortalsPost(add, remove)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spg, err = r.SkynetPortalsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spg.Portals) != 1 {
+		t.Fatalf("Incorrect number of portals, expected %v got %v", 1, len(spg.Portals))
+	}
+	if !reflect.DeepEqual(spg.Portals[0], portal1) {
+		t.Fatalf("Portals don't match, expected %v got %v", portal1, spg.Portals[0])
+	}
+
+	// Test an invalid network address.
+	add = []skymodules.SkynetPortal{portal3}
+	remove = []modules.NetAddress{}
+	err = r.SkynetPortalsPost(add, remove)
+	if err == nil || !strings.Contains(err.Error(), "missing port in address") {
+		t.Fatal("expected 'missing port' error")
+	}
+
+	// Test adding an existing portal with an uppercase address.
+	portalUpper := portal1
+	portalUpper.Address = modules.NetAddress(strings.ToUpper(string(portalUpper.Address)))
+	add = []skymodules.SkynetPortal{portalUpper}
+	remove = []modules.NetAddress{}
+	err = r.SkynetPortalsPost(add, remove)
+	// This does not currently return an error.
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spg, err = r.SkynetPortalsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spg.Portals) != 2 {
+		t.Fatalf("Incorrect number of portals, expected %v got %v", 2, len(spg.Portals))
+	}
+}
+
+// testSkynetIncludeLayout verifies the functionality of sending
+// a 'include-layout' query string parameter to the skylink GET route.
+func testSkynetIncludeLayout(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload a skyfile
+	skylink, _, _, err := r.UploadNewSkyfileBlocking(t.Name(), 100, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// GET without specifying the 'include-layout' query string parameter
+	_, layout, err := r.SkynetSkylinkGetWithLayout(skylink, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(layout, skymodules.SkyfileLayout{}) {
+		t.Fatal("unexpected")
+	}
+
+	// GET with specifying the 'include-layout' query string parameter
+	_, layout, err = r.SkynetSkylinkGetWithLayout(skylink, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reflect.DeepEqual(layout, skymodules.SkyfileLayout{}) {
+		t.Fatal("unexpected")
 	}
 
 	// Perform a HEAD call to verify the same thing in the headers directly
@@ -3197,6 +4691,149 @@ func testSkynetNoWorkers(t *testing.T, tg *siatest.TestGroup) {
 	}
 }
 
+// testSkynetPinDependencies tests pinning a skylink together with the
+// skylinks referenced by its content.
+func testSkynetPinDependencies(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload a dependency and a html file which references it.
+	depLink, _, _, err := r.UploadSkyfileBlockingCustom("pindependencies.bin", fastrand.Bytes(100), "", 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := fmt.Sprintf(`<html><script src="sia://%v"></script></html>`, depLink)
+	rootLink, _, _, err := r.UploadSkyfileBlockingCustom("pindependencies.html", []byte(html), "", 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pin the html file with its dependencies.
+	pinSiaPath, err := skymodules.NewSiaPath("pindependencies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spg, err := r.SkynetSkylinkPinWithDependenciesPost(rootLink, skymodules.SkyfilePinParameters{
+		SiaPath:         pinSiaPath,
+		DependencyDepth: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check the dependency graph.
+	if spg.Skylink != rootLink {
+		t.Fatal("wrong skylink", spg.Skylink)
+	}
+	if len(spg.Dependencies) != 2 {
+		t.Fatal("wrong number of nodes", len(spg.Dependencies))
+	}
+	root, dep := spg.Dependencies[0], spg.Dependencies[1]
+	if root.Skylink != rootLink || !root.Pinned || len(root.Dependencies) != 1 || root.Dependencies[0] != depLink {
+		t.Fatal("wrong root node", root)
+	}
+	if dep.Skylink != depLink || !dep.Pinned || dep.Depth != 1 || dep.Error != "" {
+		t.Fatal("wrong dependency node", dep)
+	}
+
+	// The dependency should be pinned next to the html file.
+	depSiaPath, err := skymodules.SkynetFolder.Join("pindependencies-dependencies/" + depLink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rf, err := r.RenterFileRootGet(depSiaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rf.File.Skylinks) != 1 || rf.File.Skylinks[0] != depLink {
+		t.Fatal("wrong skylinks", rf.File.Skylinks)
+	}
+}
+
+// testSkynetAllowedHosts verifies that skyfiles can be restricted to a set of
+// hosts.
+func testSkynetAllowedHosts(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Restrict the upload to two of the hosts.
+	var allowedHosts []types.SiaPublicKey
+	for _, h := range tg.Hosts()[:2] {
+		hpk, err := h.HostPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		allowedHosts = append(allowedHosts, hpk)
+	}
+	allowed := make(map[string]struct{})
+	for _, hpk := range allowedHosts {
+		allowed[hpk.String()] = struct{}{}
+	}
+
+	// Uploading at a higher redundancy than the allowed hosts support fails
+	// right away.
+	siaPath, err := skymodules.NewSiaPath(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sup := skymodules.SkyfileUploadParameters{
+		SiaPath:             siaPath,
+		BaseChunkRedundancy: uint8(len(allowedHosts) + 1),
+		Filename:            "testSkynetAllowedHosts",
+		Mode:                0640,
+		Reader:              bytes.NewReader(fastrand.Bytes(100)),
+		AllowedHosts:        allowedHosts,
+	}
+	_, _, err = r.SkynetSkyfilePost(sup)
+	if err == nil || !strings.Contains(err.Error(), renter.ErrNotEnoughAllowedHosts.Error()) {
+		t.Fatal("expected upload to fail", err)
+	}
+
+	// Upload the skyfile at a supported redundancy.
+	sup.BaseChunkRedundancy = uint8(len(allowedHosts))
+	sup.Reader = bytes.NewReader(fastrand.Bytes(100))
+	skylink, _, err := r.SkynetSkyfilePost(sup)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The constraint is recorded in the siafile.
+	rf, err := r.RenterSkyfileGet(siaPath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rf.File.AllowedHosts) != len(allowedHosts) {
+		t.Fatal("wrong number of allowed hosts", len(rf.File.AllowedHosts))
+	}
+	for _, hpk := range rf.File.AllowedHosts {
+		if _, exists := allowed[hpk.String()]; !exists {
+			t.Fatal("unexpected allowed host", hpk)
+		}
+	}
+
+	// The base sector is only stored on the allowed hosts.
+	var sl skymodules.Skylink
+	if err := sl.LoadString(skylink); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		sv, err := r.SkynetVerifyGET(sl)
+		if err != nil {
+			return err
+		}
+		if len(sv.BaseSectorHosts) != len(allowedHosts) {
+			return fmt.Errorf("wrong number of base sector hosts %v != %v", len(sv.BaseSectorHosts), len(allowedHosts))
+		}
+		for _, hpk := range sv.BaseSectorHosts {
+			if _, exists := allowed[hpk.String()]; !exists {
+				return fmt.Errorf("base sector stored on host %v which isn't allowed", hpk)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 // testSkynetDryRunUpload verifies the --dry-run flag when uploading a Skyfile.
 func testSkynetDryRunUpload(t *testing.T, tg *siatest.TestGroup) {
 	r := tg.Renters()[0]
@@ -3745,11 +5382,37 @@ func testSkynetRegistryReadWrite(t *testing.T, tg *siatest.TestGroup) {
 	}
 
 	// Update the regisry.
-	err = r.RegistryUpdate(spk, dataKey, srv1.Revision, srv1.Signature, skylink1)
+	rhp, err := r.RegistryUpdateWithProof(spk, modules.NewSignedRegistryValue(dataKey, data1, srv1.Revision, srv1.Signature, modules.RegistryTypeWithoutPubkey))
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	// The response should contain a valid proof for every host that
+	// accepted the update.
+	if len(rhp.Proof) < renter.MinUpdateRegistrySuccesses {
+		t.Fatalf("expected at least %v proofs but got %v", renter.MinUpdateRegistrySuccesses, len(rhp.Proof))
+	}
+	proofHosts := make(map[string]struct{})
+	for _, proof := range rhp.Proof {
+		if err := proof.Verify(); err != nil {
+			t.Fatal(err)
+		}
+		if proof.Revision != srv1.Revision || proof.DataKey != dataKey || !proof.PublicKey.Equals(spk) {
+			t.Fatal("unexpected proof", proof)
+		}
+		proofHosts[proof.HostKey.String()] = struct{}{}
+	}
+	if len(proofHosts) != len(rhp.Proof) {
+		t.Fatal("proofs should be from different hosts")
+	}
+
+	// A tampered proof shouldn't verify.
+	tampered := rhp.Proof[0]
+	tampered.Revision++
+	if err := tampered.Verify(); err == nil {
+		t.Fatal("tampered proof shouldn't verify")
+	}
+
 	// Read it.
 	readSRV, err := r.RegistryRead(spk, dataKey)
 	if err != nil {
@@ -4768,6 +6431,135 @@ func TestSkynetCleanupOnError(t *testing.T) {
 	}
 }
 
+// TestSkynetUploadSession verifies that an interrupted upload of a large
+// skyfile can be resumed after a restart using its upload session.
+func TestSkynetUploadSession(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a testgroup.
+	groupParams := siatest.GroupParams{
+		Hosts:  3,
+		Miners: 1,
+	}
+	testDir := skynetTestDir(t.Name())
+	tg, err := siatest.NewGroupFromTemplate(testDir, groupParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Add a renter with a dependency that interrupts upload sessions.
+	deps := dependencies.NewDependencyInterruptUploadSession()
+	rt := node.RenterTemplate
+	rt.Allowance = siatest.DefaultAllowance
+	rt.Allowance.PaymentContractInitialFunding = siatest.DefaultPaymentContractInitialFunding
+	rt.RenterDeps = deps
+	nodes, err := tg.AddNodes(rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := nodes[0]
+
+	// upload uploads the data as a skyfile to the siapath.
+	siaPath := skymodules.RandomSiaPath()
+	sessionID := hex.EncodeToString(fastrand.Bytes(16))
+	upload := func(data []byte, siaPath skymodules.SiaPath, sessionID string, dryRun bool) (string, error) {
+		skylink, _, err := r.SkynetSkyfilePost(skymodules.SkyfileUploadParameters{
+			SiaPath:   siaPath,
+			Filename:  "file",
+			Reader:    bytes.NewReader(data),
+			SessionID: sessionID,
+			DryRun:    dryRun,
+		})
+		return skylink, err
+	}
+
+	// Determine the expected skylink with a dry run.
+	data := fastrand.Bytes(int(3 * modules.SectorSize))
+	expected, err := upload(data, skymodules.RandomSiaPath(), "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Upload the file with a session. The upload is interrupted after the
+	// first chunk but the extended siafile is kept.
+	_, err = upload(data, siaPath, sessionID, false)
+	if err == nil || !strings.Contains(err.Error(), "upload session interrupted") {
+		t.Fatal("expected upload to be interrupted", err)
+	}
+	skynetPath, err := skymodules.SkynetFolder.Join(siaPath.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	extendedPath, err := skynetPath.AddSuffixStr(skymodules.ExtendedSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.RenterFileRootGet(extendedPath); err != nil {
+		t.Fatal("extended siafile should be kept", err)
+	}
+
+	// Restart the renter and disable the dependency. Idle connections to the
+	// renter's API are closed first since they can't be reused after the
+	// restart.
+	http.DefaultTransport.(*http.Transport).CloseIdleConnections()
+	if err := r.RestartNode(); err != nil {
+		t.Fatal(err)
+	}
+	deps.Disable()
+
+	// Resuming the session with different content or a different siapath
+	// fails.
+	_, err = upload(fastrand.Bytes(len(data)), siaPath, sessionID, false)
+	if err == nil || !strings.Contains(err.Error(), skymodules.ErrUploadSessionContentMismatch.Error()) {
+		t.Fatal("expected content mismatch", err)
+	}
+	_, err = upload(data, skymodules.RandomSiaPath(), sessionID, false)
+	if err == nil || !strings.Contains(err.Error(), skymodules.ErrUploadSessionSiaPathMismatch.Error()) {
+		t.Fatal("expected siapath mismatch", err)
+	}
+
+	// Resume the session with the same content. This creates the expected
+	// skylink.
+	skylink, err := upload(data, siaPath, sessionID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skylink != expected {
+		t.Fatal("wrong skylink", skylink, expected)
+	}
+	downloaded, err := r.SkynetSkylinkGet(skylink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(downloaded, data) {
+		t.Fatal("wrong data")
+	}
+
+	// Encrypted skyfiles can't be uploaded with a session.
+	sk, err := r.SkykeyCreateKeyPost("uploadsession", skykey.TypePrivateID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = r.SkynetSkyfilePost(skymodules.SkyfileUploadParameters{
+		SiaPath:    skymodules.RandomSiaPath(),
+		Filename:   "file",
+		Reader:     bytes.NewReader(data),
+		SessionID:  sessionID,
+		SkykeyName: sk.Name,
+	})
+	if err == nil || !strings.Contains(err.Error(), skymodules.ErrUploadSessionEncrypted.Error()) {
+		t.Fatal("expected encrypted upload to fail", err)
+	}
+}
+
 // TestReadUnknownRegistryEntry makes sure that reading an unknown entry takes
 // the appropriate amount of time.
 func TestReadUnknownRegistryEntry(t *testing.T) {
@@ -5353,7 +7145,8 @@ func testSkylinkV2Download(t *testing.T, tg *siatest.TestGroup) {
 	}
 }
 
-// TestSkynetSkylinkHealth tests the /skynet/health/skylink endpoint.
+// TestSkynetSkylinkHealth tests the /skynet/health/skylink and /skynet/verify
+// endpoints.
 func TestSkynetSkylinkHealth(t *testing.T) {
 	if testing.Short() {
 		t.SkipNow()
@@ -5397,17 +7190,54 @@ func TestSkynetSkylinkHealth(t *testing.T) {
 		if sh.FanoutEffectiveRedundancy != fanoutHealth {
 			return fmt.Errorf("fanout not healthy %v != %v", sh.FanoutEffectiveRedundancy, fanoutHealth)
 		}
-		dataPieces := uint8(skymodules.RenterDefaultDataPieces)
-		parityPieces := uint8(skymodules.RenterDefaultParityPieces)
-		if sh.FanoutDataPieces != dataPieces {
-			return fmt.Errorf("invalid datapieces %v != %v", sh.FanoutDataPieces, dataPieces)
+		dataPieces := uint8(skymodules.RenterDefaultDataPieces)
+		parityPieces := uint8(skymodules.RenterDefaultParityPieces)
+		if sh.FanoutDataPieces != dataPieces {
+			return fmt.Errorf("invalid datapieces %v != %v", sh.FanoutDataPieces, dataPieces)
+		}
+		if sh.FanoutParityPieces != parityPieces {
+			return fmt.Errorf("invalid paritypieces %v != %v", sh.FanoutParityPieces, parityPieces)
+		}
+		for i, health := range sh.FanoutRedundancy {
+			if health != fanoutHealth {
+				return fmt.Errorf("chunk %v not healthy %v != %v", i, health, fanoutHealth)
+			}
+		}
+		return nil
+	}
+
+	// helper function for asserting the verification report
+	assertVerify := func(skylink string, baseSectorHosts int, effectiveRedundancy float64, missingPieces int) error {
+		var sl skymodules.Skylink
+		if err := sl.LoadString(skylink); err != nil {
+			t.Fatal(err)
+		}
+		sv, err := r.SkynetVerifyGET(sl)
+		if err != nil {
+			return err
+		}
+		if len(sv.BaseSectorHosts) != baseSectorHosts {
+			return fmt.Errorf("wrong number of base sector hosts %v != %v", len(sv.BaseSectorHosts), baseSectorHosts)
+		}
+		advertised := float64(skymodules.RenterDefaultDataPieces+skymodules.RenterDefaultParityPieces) / float64(skymodules.RenterDefaultDataPieces)
+		if sv.AdvertisedRedundancy != advertised {
+			return fmt.Errorf("wrong advertised redundancy %v != %v", sv.AdvertisedRedundancy, advertised)
+		}
+		if sv.EffectiveRedundancy != effectiveRedundancy {
+			return fmt.Errorf("wrong effective redundancy %v != %v", sv.EffectiveRedundancy, effectiveRedundancy)
+		}
+		if verified := effectiveRedundancy == advertised && baseSectorHosts > 0; sv.Verified != verified {
+			return fmt.Errorf("verified should be %v", verified)
 		}
-		if sh.FanoutParityPieces != parityPieces {
-			return fmt.Errorf("invalid paritypieces %v != %v", sh.FanoutParityPieces, parityPieces)
+		if len(sv.Chunks) != 3 {
+			return fmt.Errorf("expected 3 chunks but got %v", len(sv.Chunks))
 		}
-		for i, health := range sh.FanoutRedundancy {
-			if health != fanoutHealth {
-				return fmt.Errorf("chunk %v not healthy %v != %v", i, health, fanoutHealth)
+		for _, cv := range sv.Chunks {
+			if cv.Redundancy != effectiveRedundancy {
+				return fmt.Errorf("chunk %v has wrong redundancy %v", cv.ChunkIndex, cv.Redundancy)
+			}
+			if len(cv.MissingPieces) != missingPieces {
+				return fmt.Errorf("chunk %v has %v missing pieces instead of %v", cv.ChunkIndex, len(cv.MissingPieces), missingPieces)
 			}
 		}
 		return nil
@@ -5428,6 +7258,22 @@ func TestSkynetSkylinkHealth(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	err = assertVerify(skylink, siatest.DefaulTestingBaseChunkRedundancy, 5, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Verifying a skylink requires the API password.
+	var sl skymodules.Skylink
+	if err := sl.LoadString(skylink); err != nil {
+		t.Fatal(err)
+	}
+	c := r.Client
+	c.Password = ""
+	_, err = c.SkynetVerifyGET(sl)
+	if err == nil || !strings.Contains(err.Error(), "API authentication failed") {
+		t.Fatal("expected authentication error", err)
+	}
 
 	// Upload another file but encrypted. This makes sure we test encrypted
 	// skylinks as well as fanouts that can't be compressed.
@@ -5446,6 +7292,10 @@ func TestSkynetSkylinkHealth(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	err = assertVerify(skylink2, int(skylink2BaseSectorRedundancy), 5, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	// Take two hosts offline.
 	hosts := tg.Hosts()
@@ -5472,6 +7322,11 @@ func TestSkynetSkylinkHealth(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	// The verification report lists the 2 missing pieces of every chunk.
+	err = assertVerify(skylink2, int(skylink2BaseSectorRedundancy)-2, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
 }
 
 // TestHostLosingRegistryEntry tests the edge case where a host forgets about a
@@ -5527,6 +7382,89 @@ func TestHostLosingRegistryEntry(t *testing.T) {
 	}
 }
 
+// testRegistryTrustedHosts tests that registry reads only accept values
+// confirmed by a trusted host when trusted registry hosts are required.
+func testRegistryTrustedHosts(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+	hosts := tg.Hosts()
+	if len(hosts) < 3 {
+		t.Fatal("not enough hosts for test")
+	}
+	var hostKeys []types.SiaPublicKey
+	for _, h := range hosts {
+		hpk, err := h.HostPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		hostKeys = append(hostKeys, hpk)
+	}
+
+	// Invalid settings are rejected.
+	err := r.SkynetRegistryTrustedHostsPost(skymodules.TrustedRegistryHosts{
+		Hosts: hostKeys[:1],
+		Mode:  "unknown",
+	})
+	if err == nil || !strings.Contains(err.Error(), "unknown trusted registry hosts mode") {
+		t.Fatal("expected error", err)
+	}
+
+	// Trust the first host.
+	trusted := skymodules.TrustedRegistryHosts{
+		Hosts: hostKeys[:1],
+		Mode:  skymodules.TrustedRegistryHostsModeRequired,
+	}
+	if err := r.SkynetRegistryTrustedHostsPost(trusted); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.SkynetRegistryTrustedHostsPost(skymodules.TrustedRegistryHosts{}); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	trh, err := r.SkynetRegistryTrustedHostsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(trh, trusted) {
+		t.Fatal("wrong trusted hosts", trh)
+	}
+
+	// Set revision 1 on the trusted host and a higher revision on the other
+	// hosts.
+	sk, pk := crypto.GenerateKeyPair()
+	spk := types.Ed25519PublicKey(pk)
+	var dataKey crypto.Hash
+	fastrand.Read(dataKey[:])
+	srvs := make(map[string]skymodules.RegistryEntry)
+	for i, hpk := range hostKeys {
+		revision := uint64(2)
+		if i == 0 {
+			revision = 1
+		}
+		srv := modules.NewRegistryValue(dataKey, fastrand.Bytes(10), revision, modules.RegistryTypeWithoutPubkey).Sign(sk)
+		srvs[hpk.String()] = skymodules.NewRegistryEntry(spk, srv)
+	}
+	if err := r.RegistryUpdateMulti(srvs); err != nil {
+		t.Fatal(err)
+	}
+
+	// The read should return the trusted host's revision and its agreement.
+	rhg, err := r.RegistryReadRaw(spk, dataKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rhg.Revision != 1 {
+		t.Fatal("expected revision of the trusted host", rhg.Revision)
+	}
+	if len(rhg.TrustedHostAgreement) != 1 {
+		t.Fatal("wrong agreement", rhg.TrustedHostAgreement)
+	}
+	agreement := rhg.TrustedHostAgreement[0]
+	if !agreement.HostKey.Equals(hostKeys[0]) || !agreement.Responded || !agreement.Agrees || agreement.Revision != 1 {
+		t.Fatal("wrong agreement", agreement)
+	}
+}
+
 // testUpdateRegistryMulti tests the endpoint for updating multiple host with
 // different entries.
 func testUpdateRegistryMulti(t *testing.T, tg *siatest.TestGroup) {
@@ -5567,10 +7505,19 @@ func testUpdateRegistryMulti(t *testing.T, tg *siatest.TestGroup) {
 		}
 		srvs[hostKey.String()] = entry
 	}
-	err = r.RegistryUpdateMulti(srvs)
+	rhp, err := r.RegistryUpdateMultiWithProof(srvs)
 	if err != nil {
 		t.Fatal(err)
 	}
+	// Every proof should belong to one of the updated hosts.
+	for _, proof := range rhp.Proof {
+		if _, exists := srvs[proof.HostKey.String()]; !exists {
+			t.Fatal("proof from unexpected host", proof.HostKey)
+		}
+		if err := proof.Verify(); err != nil {
+			t.Fatal(err)
+		}
+	}
 
 	// Check the health. We should find the entry on every host but one and
 	// one host should be considered a primary entry.
@@ -5703,3 +7650,338 @@ func TestRegistryReadRepair(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// testSkynetSharedChunks verifies that skyfiles with the same data share their
+// fanout chunks and that unpinning one of them doesn't affect the other.
+func testSkynetSharedChunks(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload the same large file twice.
+	data := fastrand.Bytes(int(3*modules.SectorSize) + siatest.Fuzz())
+	skylink1, sup, _, err := r.UploadNewSkyfileWithDataBlocking("shared1", data, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skylink2, _, _, err := r.UploadNewSkyfileWithDataBlocking("shared2", data, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skylink1 == skylink2 {
+		t.Fatal("skylinks should be different")
+	}
+
+	// Unpin the first skyfile and wait for its fanout to be deleted.
+	if err := r.SkynetSkylinkUnpinPost(skylink1); err != nil {
+		t.Fatal(err)
+	}
+	extendedPath, err := skymodules.SkynetFolder.Join(sup.SiaPath.String() + skymodules.ExtendedSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		_, err := r.RenterFileRootGet(extendedPath)
+		if err == nil {
+			return errors.New("fanout of unpinned skyfile still exists")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second skyfile should still be downloadable.
+	downloaded, err := r.SkynetSkylinkGet(skylink2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(downloaded, data) {
+		t.Fatal("unexpected data")
+	}
+}
+
+// testSkynetRepairPriority verifies that downloads increase the repair
+// priority of a skylink and that the priority can be overridden.
+func testSkynetRepairPriority(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload a file and download it twice.
+	skylink, _, _, err := r.UploadNewSkyfileBlocking("repairpriority", 100, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := r.SkynetSkylinkGet(skylink); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// findPriority returns the repair priority of the skylink.
+	findPriority := func() (skymodules.SkylinkRepairPriority, bool) {
+		srpg, err := r.SkynetRepairPriorityGet()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range srpg.Skylinks {
+			if p.Skylink == skylink {
+				return p, true
+			}
+		}
+		return skymodules.SkylinkRepairPriority{}, false
+	}
+	p, found := findPriority()
+	if !found || p.Override || p.Popularity < 1 || p.Priority != p.Popularity {
+		t.Fatal("unexpected priority", found, p)
+	}
+
+	// Override the priority.
+	p, err = r.SkynetRepairPriorityPost(skylink, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Override || p.Priority != 1000 {
+		t.Fatal("unexpected priority", p)
+	}
+	if p, _ = findPriority(); !p.Override || p.Priority != 1000 {
+		t.Fatal("unexpected priority", p)
+	}
+
+	// Negative priorities are rejected.
+	_, err = r.SkynetRepairPriorityPost(skylink, -1)
+	if err == nil || !strings.Contains(err.Error(), skymodules.ErrInvalidRepairPriority.Error()) {
+		t.Fatal("expected override to fail", err)
+	}
+
+	// Reset the priority.
+	p, err = r.SkynetRepairPriorityResetPost(skylink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Override || p.Priority != p.Popularity {
+		t.Fatal("unexpected priority", p)
+	}
+}
+
+// testSkynetHealthHeader verifies that the cached health of a pinned skylink
+// is returned in the health header.
+func testSkynetHealthHeader(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload a file with a fanout.
+	data := fastrand.Bytes(int(2*modules.SectorSize) + siatest.Fuzz())
+	skylink, _, _, err := r.UploadNewSkyfileWithDataBlocking("healthheader", data, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// checkHeader checks that the header contains a valid health.
+	checkHeader := func(header http.Header) error {
+		str := header.Get(api.SkynetHealthHeader)
+		if str == "" {
+			return errors.New("health header not set")
+		}
+		health, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return err
+		}
+		if health < 0 {
+			return fmt.Errorf("invalid health %v", health)
+		}
+		return nil
+	}
+
+	// The header is set once the health loop updated the skylink's siafiles.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		_, header, err := r.SkynetSkylinkHead(skylink)
+		if err != nil {
+			return err
+		}
+		return checkHeader(header)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, _, err := r.SkynetMetadataGet(skylink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkHeader(header); err != nil {
+		t.Fatal(err)
+	}
+
+	// Skylinks which aren't pinned by the node don't have a health header.
+	sl, err := skymodules.NewSkylinkV1(crypto.Hash{1}, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, header, _ = r.SkynetSkylinkHead(sl.String())
+	if header.Get(api.SkynetHealthHeader) != "" {
+		t.Fatal("health header shouldn't be set")
+	}
+}
+
+// testSkynetDownloadProvenance verifies that the hosts which served the pieces
+// of the downloaded chunks are returned if requested.
+func testSkynetDownloadProvenance(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Collect the host keys.
+	hostKeys := make(map[string]struct{})
+	for _, h := range tg.Hosts() {
+		hpk, err := h.HostPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		hostKeys[hpk.String()] = struct{}{}
+	}
+
+	// Upload a file with a fanout and download it with provenance.
+	data := fastrand.Bytes(int(2*modules.SectorSize) + siatest.Fuzz())
+	skylink, _, _, err := r.UploadNewSkyfileWithDataBlocking("provenance", data, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	downloaded, provenance, err := r.SkynetSkylinkGetWithProvenance(skylink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(downloaded, data) {
+		t.Fatal("unexpected data")
+	}
+
+	// Every chunk of the fanout should be listed once with enough pieces to
+	// recover it, all served by known hosts.
+	_, layout, err := r.SkynetSkylinkGetWithLayout(skylink, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunkSize := skymodules.ChunkSize(layout.CipherType, uint64(layout.FanoutDataPieces))
+	numChunks := (uint64(len(data)) + chunkSize - 1) / chunkSize
+	if uint64(len(provenance)) != numChunks {
+		t.Fatalf("expected provenance of %v chunks but got %v", numChunks, len(provenance))
+	}
+	for i, cp := range provenance {
+		if cp.ChunkIndex != uint64(i) {
+			t.Fatal("unexpected chunk index", cp.ChunkIndex, i)
+		}
+		if len(cp.Pieces) < int(layout.FanoutDataPieces) {
+			t.Fatal("not enough pieces", len(cp.Pieces))
+		}
+		for _, pp := range cp.Pieces {
+			if _, exists := hostKeys[pp.HostKey.String()]; !exists {
+				t.Fatal("piece served by unknown host", pp.HostKey)
+			}
+			if pp.PieceIndex >= uint64(layout.FanoutDataPieces)+uint64(layout.FanoutParityPieces) {
+				t.Fatal("invalid piece index", pp.PieceIndex)
+			}
+		}
+	}
+
+	// Small files don't have a fanout.
+	skylink, _, _, err = r.UploadNewSkyfileBlocking("provenancesmall", 100, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, provenance, err = r.SkynetSkylinkGetWithProvenance(skylink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(provenance) != 0 {
+		t.Fatal("expected no provenance", provenance)
+	}
+}
+
+// testSkynetDownloadBandwidth verifies that the bandwidth used to serve a
+// download is returned if requested.
+func testSkynetDownloadBandwidth(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload a file with a fanout and download it with bandwidth.
+	data := fastrand.Bytes(int(2*modules.SectorSize) + siatest.Fuzz())
+	skylink, _, _, err := r.UploadNewSkyfileWithDataBlocking("bandwidth", data, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	downloaded, up, down, err := r.SkynetSkylinkGetWithBandwidth(skylink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(downloaded, data) {
+		t.Fatal("unexpected data")
+	}
+
+	// At least the fanout data must have been downloaded from the hosts and
+	// every read job sends a request.
+	if down < uint64(len(data)) {
+		t.Fatalf("expected at least %v bytes to be downloaded but got %v", len(data), down)
+	}
+	if up == 0 {
+		t.Fatal("expected upload bandwidth")
+	}
+
+	// Small files are served from the base sector.
+	skylink, _, _, err = r.UploadNewSkyfileBlocking("bandwidthsmall", 100, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, up, down, err = r.SkynetSkylinkGetWithBandwidth(skylink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if up != 0 || down != 0 {
+		t.Fatal("expected no bandwidth", up, down)
+	}
+}
+
+// testSkynetMemoryLimit tests that skynet requests are rejected once the soft
+// memory limit would be exceeded.
+func testSkynetMemoryLimit(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload a file before setting the limit.
+	skylink, _, _, err := r.UploadNewSkyfileBlocking("memorylimit", 100, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Set a limit that is too small for any request.
+	if err := r.RenterMemoryLimitPost(1); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := r.RenterMemoryLimitPost(0); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	rg, err := r.RenterGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rg.Settings.MemoryLimit != 1 || rg.MemoryStatus.Budget.Limit != 1 {
+		t.Fatal("memory limit wasn't set", rg.Settings.MemoryLimit, rg.MemoryStatus.Budget)
+	}
+
+	// Downloads and uploads should be rejected.
+	_, err = r.SkynetSkylinkGet(skylink)
+	if err == nil || !strings.Contains(err.Error(), skymodules.ErrMemoryLimitExceeded.Error()) {
+		t.Fatal("expected download to be rejected", err)
+	}
+	_, _, _, err = r.UploadNewSkyfileBlocking("memorylimit2", 100, false)
+	if err == nil || !strings.Contains(err.Error(), skymodules.ErrMemoryLimitExceeded.Error()) {
+		t.Fatal("expected upload to be rejected", err)
+	}
+	rg, err = r.RenterGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rg.MemoryStatus.Budget.Rejected < 2 {
+		t.Fatal("rejections weren't counted", rg.MemoryStatus.Budget)
+	}
+
+	// Remove the limit again, the download should succeed.
+	if err := r.RenterMemoryLimitPost(0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = r.SkynetSkylinkGet(skylink); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,226 @@
+package skykey
+
+import (
+	"bytes"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	// bundleSaltLen is the length of the salt used to derive the encryption
+	// key of a bundle from its password.
+	bundleSaltLen = 32
+
+	// bundleHeaderLen is the length of the unencrypted bundle header. It
+	// consists of the magic, the version and the salt.
+	bundleHeaderLen = types.SpecifierLen + types.SpecifierLen + bundleSaltLen
+
+	// maxBundleKeys is the maximum number of skykeys in a bundle. It protects
+	// against allocating huge amounts of memory when importing a malformed
+	// bundle.
+	maxBundleKeys = 1 << 16
+)
+
+// Argon2 parameters used to derive the encryption key of a bundle from its
+// password.
+const (
+	bundleArgon2Time    = 1
+	bundleArgon2Memory  = 64 * 1024 // 64 MiB
+	bundleArgon2Threads = 4
+)
+
+var (
+	// SkykeyBundleMagic is the first piece of data found in a skykey bundle.
+	SkykeyBundleMagic = types.NewSpecifier("SkykeyBundle")
+
+	// bundleVersion is the current version of the skykey bundle format.
+	bundleVersion = types.NewSpecifier("1.0")
+)
+
+var (
+	// ErrEmptyBundlePassword is returned when trying to export or import a
+	// bundle without a password.
+	ErrEmptyBundlePassword = errors.New("skykey bundle password can't be empty")
+
+	// ErrInvalidBundlePassword is returned when a bundle can't be decrypted
+	// with the given password.
+	ErrInvalidBundlePassword = errors.New("invalid skykey bundle password")
+
+	// errInvalidBundle is returned when a bundle is malformed.
+	errInvalidBundle = errors.New("invalid skykey bundle")
+)
+
+// BundleKey is the encryption key of a skykey bundle. It is derived from a
+// password and a random salt. Storing a BundleKey allows for creating new
+// bundles which can be opened with the same password without having to keep
+// the password itself around.
+type BundleKey struct {
+	Salt [bundleSaltLen]byte `json:"salt"`
+	Key  [32]byte            `json:"key"`
+}
+
+// NewBundleKey derives a new BundleKey from the password using a random salt.
+func NewBundleKey(password string) (BundleKey, error) {
+	var salt [bundleSaltLen]byte
+	fastrand.Read(salt[:])
+	return deriveBundleKey(password, salt)
+}
+
+// deriveBundleKey derives a BundleKey from the password and salt.
+func deriveBundleKey(password string, salt [bundleSaltLen]byte) (BundleKey, error) {
+	if password == "" {
+		return BundleKey{}, ErrEmptyBundlePassword
+	}
+	bk := BundleKey{Salt: salt}
+	key := argon2.IDKey([]byte(password), salt[:], bundleArgon2Time, bundleArgon2Memory, bundleArgon2Threads, uint32(len(bk.Key)))
+	copy(bk.Key[:], key)
+	return bk, nil
+}
+
+// cipherKey returns the cipher key used to encrypt the bundle.
+func (bk BundleKey) cipherKey() (crypto.CipherKey, error) {
+	return crypto.NewSiaKey(crypto.TypeTwofish, bk.Key[:])
+}
+
+// ExportBundle encrypts the given skykeys into a password protected bundle.
+func ExportBundle(keys []Skykey, password string) ([]byte, error) {
+	bk, err := NewBundleKey(password)
+	if err != nil {
+		return nil, err
+	}
+	return ExportBundleWithKey(keys, bk)
+}
+
+// ExportBundleWithKey encrypts the given skykeys into a bundle using a
+// previously derived BundleKey.
+func ExportBundleWithKey(keys []Skykey, bk BundleKey) ([]byte, error) {
+	// Marshal the keys.
+	var plaintext bytes.Buffer
+	e := encoding.NewEncoder(&plaintext)
+	e.WriteUint64(uint64(len(keys)))
+	if err := e.Err(); err != nil {
+		return nil, err
+	}
+	for _, sk := range keys {
+		if err := sk.marshalSia(&plaintext); err != nil {
+			return nil, errors.AddContext(err, "failed to marshal skykey")
+		}
+	}
+
+	// Encrypt them.
+	ck, err := bk.cipherKey()
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := ck.EncryptBytes(plaintext.Bytes())
+
+	// Prepend the header.
+	bundle := bytes.NewBuffer(make([]byte, 0, bundleHeaderLen+len(ciphertext)))
+	e = encoding.NewEncoder(bundle)
+	e.Encode(SkykeyBundleMagic)
+	e.Encode(bundleVersion)
+	e.Write(bk.Salt[:])
+	e.Write(ciphertext)
+	if err := e.Err(); err != nil {
+		return nil, errors.AddContext(err, "failed to encode skykey bundle")
+	}
+	return bundle.Bytes(), nil
+}
+
+// ImportBundle decrypts a bundle created by ExportBundle and returns the
+// contained skykeys.
+func ImportBundle(bundle []byte, password string) ([]Skykey, error) {
+	if len(bundle) < bundleHeaderLen {
+		return nil, errInvalidBundle
+	}
+
+	// Decode the header.
+	var magic, version types.Specifier
+	var salt [bundleSaltLen]byte
+	d := encoding.NewDecoder(bytes.NewReader(bundle[:bundleHeaderLen]), encoding.DefaultAllocLimit)
+	d.Decode(&magic)
+	d.Decode(&version)
+	d.ReadFull(salt[:])
+	if err := d.Err(); err != nil {
+		return nil, errors.Compose(errInvalidBundle, err)
+	}
+	if magic != SkykeyBundleMagic {
+		return nil, errors.AddContext(errInvalidBundle, "expected skykey bundle magic")
+	}
+	if version != bundleVersion {
+		return nil, errors.AddContext(errInvalidBundle, "unknown skykey bundle version")
+	}
+
+	// Decrypt the keys.
+	bk, err := deriveBundleKey(password, salt)
+	if err != nil {
+		return nil, err
+	}
+	ck, err := bk.cipherKey()
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := ck.DecryptBytes(bundle[bundleHeaderLen:])
+	if err != nil {
+		return nil, ErrInvalidBundlePassword
+	}
+
+	// Unmarshal them.
+	r := bytes.NewReader(plaintext)
+	d = encoding.NewDecoder(r, encoding.DefaultAllocLimit)
+	numKeys := d.NextUint64()
+	if err := d.Err(); err != nil {
+		return nil, errors.Compose(errInvalidBundle, err)
+	}
+	if numKeys > maxBundleKeys {
+		return nil, errors.AddContext(errInvalidBundle, "too many skykeys")
+	}
+	keys := make([]Skykey, 0, numKeys)
+	for i := uint64(0); i < numKeys; i++ {
+		var sk Skykey
+		if err := sk.unmarshalSia(r); err != nil {
+			return nil, errors.AddContext(err, "failed to unmarshal skykey")
+		}
+		keys = append(keys, sk)
+	}
+	if r.Len() != 0 {
+		return nil, errors.AddContext(errInvalidBundle, "unexpected data after skykeys")
+	}
+	return keys, nil
+}
+
+// ExportBundle exports all skykeys of the manager into a password protected
+// bundle.
+func (sm *SkykeyManager) ExportBundle(password string) ([]byte, error) {
+	return ExportBundle(sm.Skykeys(), password)
+}
+
+// ImportBundle adds the skykeys of a bundle to the manager and returns the
+// keys that were added. Keys which are already stored by the manager are
+// skipped. Keys whose name is already used by a different key are skipped as
+// well and reported in the returned error.
+func (sm *SkykeyManager) ImportBundle(bundle []byte, password string) ([]Skykey, error) {
+	keys, err := ImportBundle(bundle, password)
+	if err != nil {
+		return nil, err
+	}
+	var added []Skykey
+	var errs error
+	for _, sk := range keys {
+		err := sm.AddKey(sk)
+		if errors.Contains(err, ErrSkykeyWithIDAlreadyExists) {
+			continue
+		}
+		if err != nil {
+			errs = errors.Compose(errs, errors.AddContext(err, sk.Name))
+			continue
+		}
+		added = append(added, sk)
+	}
+	return added, errs
+}
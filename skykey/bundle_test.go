@@ -0,0 +1,119 @@
+package skykey
+
+import (
+	"bytes"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/build"
+)
+
+// TestSkykeyBundle tests exporting and importing skykey bundles.
+func TestSkykeyBundle(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a key manager with a few keys.
+	keyMan, err := NewSkykeyManager(build.TempDir("skykey", t.Name(), "src"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk1, err := keyMan.CreateKey("key1", TypePublicID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk2, err := keyMan.CreateKey("key2", TypePrivateID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Exporting without a password is not allowed.
+	_, err = keyMan.ExportBundle("")
+	if !errors.Contains(err, ErrEmptyBundlePassword) {
+		t.Fatal("unexpected error", err)
+	}
+	bundle, err := keyMan.ExportBundle("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The keys shouldn't appear in plaintext.
+	for _, sk := range []Skykey{sk1, sk2} {
+		if bytes.Contains(bundle, sk.Entropy) {
+			t.Fatal("bundle contains plaintext key")
+		}
+	}
+
+	// Importing with the wrong password fails.
+	_, err = ImportBundle(bundle, "wrong")
+	if !errors.Contains(err, ErrInvalidBundlePassword) {
+		t.Fatal("unexpected error", err)
+	}
+	// Importing a corrupted bundle fails.
+	corrupted := append([]byte{}, bundle...)
+	corrupted[0]++
+	_, err = ImportBundle(corrupted, "password")
+	if !errors.Contains(err, errInvalidBundle) {
+		t.Fatal("unexpected error", err)
+	}
+	_, err = ImportBundle(bundle[:bundleHeaderLen-1], "password")
+	if !errors.Contains(err, errInvalidBundle) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// Import the bundle into a new manager which already has one of the keys
+	// and another key using the name of the other one.
+	keyMan2, err := NewSkykeyManager(build.TempDir("skykey", t.Name(), "dst"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keyMan2.AddKey(sk1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keyMan2.CreateKey("key2", TypePrivateID); err != nil {
+		t.Fatal(err)
+	}
+	added, err := keyMan2.ImportBundle(bundle, "password")
+	if !errors.Contains(err, ErrSkykeyWithNameAlreadyExists) {
+		t.Fatal("expected name conflict", err)
+	}
+	if len(added) != 0 {
+		t.Fatal("no keys should have been added", added)
+	}
+
+	// Delete the conflicting key and try again.
+	if err := keyMan2.DeleteKeyByName("key2"); err != nil {
+		t.Fatal(err)
+	}
+	added, err = keyMan2.ImportBundle(bundle, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 1 || !added[0].equals(sk2) {
+		t.Fatal("wrong keys added", added)
+	}
+	sk, err := keyMan2.KeyByName("key2")
+	if err != nil || !sk.equals(sk2) {
+		t.Fatal("key wasn't imported", err)
+	}
+
+	// Bundles created with the same BundleKey can be opened with the original
+	// password.
+	bk, err := NewBundleKey("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle, err = ExportBundleWithKey([]Skykey{sk2}, bk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := ImportBundle(bundle, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || !keys[0].equals(sk2) {
+		t.Fatal("wrong keys", keys)
+	}
+}
@@ -0,0 +1,113 @@
+package skymodules
+
+import (
+	"math"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+const (
+	// APITokenScopeAdmin grants access to all password protected routes.
+	APITokenScopeAdmin APITokenScope = "admin"
+
+	// APITokenScopeDownload grants access to the password protected download
+	// routes.
+	APITokenScopeDownload APITokenScope = "download"
+
+	// APITokenScopeUpload grants access to the password protected upload and
+	// pin routes.
+	APITokenScopeUpload APITokenScope = "upload"
+)
+
+var (
+	// ErrAPITokenNotFound is returned if a token doesn't exist or was revoked.
+	ErrAPITokenNotFound = errors.New("api token not found")
+
+	// ErrInvalidAPITokenScope is returned if a token scope is unknown.
+	ErrInvalidAPITokenScope = errors.New("invalid api token scope")
+
+	// ErrAPITokenQuotaExceeded is returned if an upload or pin would exceed
+	// the daily upload or pinned bytes quota of a token.
+	ErrAPITokenQuotaExceeded = errors.New("api token quota exceeded")
+
+	// ErrAPITokenSkyfileTooLarge is returned if a skyfile exceeds the max
+	// skyfile size of a token.
+	ErrAPITokenSkyfileTooLarge = errors.New("skyfile exceeds the max skyfile size of the api token")
+)
+
+type (
+	// APITokenScope describes which routes an API token grants access to.
+	APITokenScope string
+
+	// APIToken contains the information about an API token. The token itself
+	// is only returned once when it is created.
+	APIToken struct {
+		ID        string        `json:"id"`
+		Name      string        `json:"name"`
+		Scope     APITokenScope `json:"scope"`
+		Quota     APITokenQuota `json:"quota"`
+		CreatedAt time.Time     `json:"createdat"`
+	}
+
+	// APITokenQuota limits the data a token may upload and pin. A value of 0
+	// means unlimited.
+	APITokenQuota struct {
+		MaxUploadBytesPerDay uint64 `json:"maxuploadbytesperday"`
+		MaxSkyfileSize       uint64 `json:"maxskyfilesize"`
+		MaxPinnedBytes       uint64 `json:"maxpinnedbytes"`
+	}
+
+	// APITokenUsage is the usage of a token's quota. Both uploads and pins
+	// count towards the uploaded and pinned bytes.
+	APITokenUsage struct {
+		// Day is the start of the UTC day UploadedBytesToday refers to.
+		Day                time.Time `json:"day"`
+		UploadedBytesToday uint64    `json:"uploadedbytestoday"`
+		PinnedBytes        uint64    `json:"pinnedbytes"`
+	}
+)
+
+// Validate returns an error if the scope is unknown.
+func (s APITokenScope) Validate() error {
+	switch s {
+	case APITokenScopeAdmin, APITokenScopeDownload, APITokenScopeUpload:
+		return nil
+	}
+	return errors.AddContext(ErrInvalidAPITokenScope, string(s))
+}
+
+// Allows returns whether a token with the scope may access a route which
+// requires the given scope. Admin tokens may access all routes.
+func (s APITokenScope) Allows(required APITokenScope) bool {
+	return s == APITokenScopeAdmin || s == required
+}
+
+// UploadLimit returns the number of bytes a token with the quota and usage may
+// upload in a single skyfile and the error that applies once the limit is
+// exceeded. Without a quota the limit is math.MaxUint64.
+func (q APITokenQuota) UploadLimit(u APITokenUsage) (uint64, error) {
+	limit, limitErr := uint64(math.MaxUint64), error(nil)
+	if q.MaxSkyfileSize > 0 {
+		limit, limitErr = q.MaxSkyfileSize, ErrAPITokenSkyfileTooLarge
+	}
+	if q.MaxUploadBytesPerDay > 0 {
+		remaining := uint64(0)
+		if u.UploadedBytesToday < q.MaxUploadBytesPerDay {
+			remaining = q.MaxUploadBytesPerDay - u.UploadedBytesToday
+		}
+		if remaining < limit {
+			limit, limitErr = remaining, ErrAPITokenQuotaExceeded
+		}
+	}
+	if q.MaxPinnedBytes > 0 {
+		remaining := uint64(0)
+		if u.PinnedBytes < q.MaxPinnedBytes {
+			remaining = q.MaxPinnedBytes - u.PinnedBytes
+		}
+		if remaining < limit {
+			limit, limitErr = remaining, ErrAPITokenQuotaExceeded
+		}
+	}
+	return limit, limitErr
+}
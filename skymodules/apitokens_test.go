@@ -0,0 +1,50 @@
+package skymodules
+
+import (
+	"math"
+	"testing"
+)
+
+// TestAPITokenQuotaUploadLimit is a unit test for UploadLimit.
+func TestAPITokenQuotaUploadLimit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		quota APITokenQuota
+		usage APITokenUsage
+		limit uint64
+		err   error
+	}{
+		// No quota.
+		{limit: math.MaxUint64},
+		// Only a max skyfile size.
+		{quota: APITokenQuota{MaxSkyfileSize: 100}, limit: 100, err: ErrAPITokenSkyfileTooLarge},
+		// The remaining daily quota is smaller than the max skyfile size.
+		{
+			quota: APITokenQuota{MaxSkyfileSize: 100, MaxUploadBytesPerDay: 200},
+			usage: APITokenUsage{UploadedBytesToday: 150},
+			limit: 50,
+			err:   ErrAPITokenQuotaExceeded,
+		},
+		// The daily quota is used up.
+		{
+			quota: APITokenQuota{MaxUploadBytesPerDay: 200},
+			usage: APITokenUsage{UploadedBytesToday: 250},
+			limit: 0,
+			err:   ErrAPITokenQuotaExceeded,
+		},
+		// The pinned bytes quota is the smallest.
+		{
+			quota: APITokenQuota{MaxSkyfileSize: 100, MaxUploadBytesPerDay: 200, MaxPinnedBytes: 1000},
+			usage: APITokenUsage{PinnedBytes: 990},
+			limit: 10,
+			err:   ErrAPITokenQuotaExceeded,
+		},
+	}
+	for i, test := range tests {
+		limit, err := test.quota.UploadLimit(test.usage)
+		if limit != test.limit || err != test.err {
+			t.Errorf("%v: expected %v %v but got %v %v", i, test.limit, test.err, limit, err)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package skymodules
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// A contract set snapshot is a signed, machine-readable export of the renter's
+// contracts and their spending. It allows auditing the spending of a renter
+// without access to the node. Snapshots are signed with an ed25519 key of the
+// renter so that a later snapshot can be diffed against an earlier one without
+// trusting the party that stored it.
+
+var (
+	// ErrContractSnapshotInvalidSignature is returned if the signature of a
+	// contract set snapshot doesn't match its content and the expected key.
+	ErrContractSnapshotInvalidSignature = errors.New("contract set snapshot has an invalid signature")
+)
+
+var (
+	// contractSnapshotCSVHeader is the header row of contract set snapshots
+	// exported as CSV.
+	contractSnapshotCSVHeader = []string{
+		"id",
+		"hostpublickey",
+		"netaddress",
+		"size",
+		"startheight",
+		"endheight",
+		"totalcost",
+		"fees",
+		"renterfunds",
+		"downloadspending",
+		"fundaccountspending",
+		"maintenancespending",
+		"storagespending",
+		"uploadspending",
+		"goodforupload",
+		"goodforrenew",
+	}
+)
+
+type (
+	// ContractSetSnapshot is the state of the renter's contracts at a given
+	// block height. The contracts are sorted by their ID.
+	ContractSetSnapshot struct {
+		BlockHeight types.BlockHeight  `json:"blockheight"`
+		Timestamp   int64              `json:"timestamp"`
+		Contracts   []ContractSnapshot `json:"contracts"`
+	}
+
+	// ContractSnapshot is the state of a single contract within a contract set
+	// snapshot. All currency values are in hastings.
+	ContractSnapshot struct {
+		ID            types.FileContractID `json:"id"`
+		HostPublicKey types.SiaPublicKey   `json:"hostpublickey"`
+		NetAddress    modules.NetAddress   `json:"netaddress"`
+		Size          uint64               `json:"size"`
+		StartHeight   types.BlockHeight    `json:"startheight"`
+		EndHeight     types.BlockHeight    `json:"endheight"`
+
+		TotalCost           types.Currency `json:"totalcost"`
+		Fees                types.Currency `json:"fees"`
+		RenterFunds         types.Currency `json:"renterfunds"`
+		DownloadSpending    types.Currency `json:"downloadspending"`
+		FundAccountSpending types.Currency `json:"fundaccountspending"`
+		MaintenanceSpending types.Currency `json:"maintenancespending"`
+		StorageSpending     types.Currency `json:"storagespending"`
+		UploadSpending      types.Currency `json:"uploadspending"`
+
+		GoodForUpload bool `json:"goodforupload"`
+		GoodForRenew  bool `json:"goodforrenew"`
+	}
+
+	// SignedContractSetSnapshot is the exported document of a contract set
+	// snapshot. The signature is the hex encoded ed25519 signature of the
+	// hash of the raw snapshot.
+	SignedContractSetSnapshot struct {
+		Snapshot  json.RawMessage    `json:"snapshot"`
+		PublicKey types.SiaPublicKey `json:"publickey"`
+		Signature string             `json:"signature"`
+	}
+
+	// ContractSetSnapshotDiff describes how the contract set changed between
+	// two snapshots.
+	ContractSetSnapshotDiff struct {
+		FromBlockHeight types.BlockHeight `json:"fromblockheight"`
+		ToBlockHeight   types.BlockHeight `json:"toblockheight"`
+		FromTimestamp   int64             `json:"fromtimestamp"`
+		ToTimestamp     int64             `json:"totimestamp"`
+
+		// Added are the contracts which are only in the newer snapshot and
+		// Removed the ones which are only in the older snapshot.
+		Added   []ContractSnapshot `json:"added"`
+		Removed []ContractSnapshot `json:"removed"`
+
+		// Changed are the contracts which are in both snapshots but
+		// differ.
+		Changed []ContractSnapshotChange `json:"changed"`
+	}
+
+	// ContractSnapshotChange is a contract whose state changed between two
+	// snapshots.
+	ContractSnapshotChange struct {
+		Old ContractSnapshot `json:"old"`
+		New ContractSnapshot `json:"new"`
+	}
+)
+
+// SignContractSetSnapshot creates the signed document of a contract set
+// snapshot.
+func SignContractSetSnapshot(s ContractSetSnapshot, sk crypto.SecretKey) (SignedContractSetSnapshot, error) {
+	sBytes, err := json.Marshal(s)
+	if err != nil {
+		return SignedContractSetSnapshot{}, errors.AddContext(err, "failed to marshal contract set snapshot")
+	}
+	sig := crypto.SignHash(crypto.HashBytes(sBytes), sk)
+	return SignedContractSetSnapshot{
+		Snapshot:  sBytes,
+		PublicKey: types.Ed25519PublicKey(sk.PublicKey()),
+		Signature: hex.EncodeToString(sig[:]),
+	}, nil
+}
+
+// Verify verifies the signature of the document using the given key and
+// returns the contract set snapshot.
+func (ss SignedContractSetSnapshot) Verify(pk types.SiaPublicKey) (ContractSetSnapshot, error) {
+	if pk.Algorithm != types.SignatureEd25519 || len(pk.Key) != crypto.PublicKeySize {
+		return ContractSetSnapshot{}, errors.New("contract set snapshot key must be an ed25519 key")
+	}
+	sigBytes, err := hex.DecodeString(ss.Signature)
+	if err != nil || len(sigBytes) != crypto.SignatureSize {
+		return ContractSetSnapshot{}, ErrContractSnapshotInvalidSignature
+	}
+	var sig crypto.Signature
+	copy(sig[:], sigBytes)
+	var cpk crypto.PublicKey
+	copy(cpk[:], pk.Key)
+	if crypto.VerifyHash(crypto.HashBytes(ss.Snapshot), cpk, sig) != nil {
+		return ContractSetSnapshot{}, ErrContractSnapshotInvalidSignature
+	}
+
+	var s ContractSetSnapshot
+	err = json.Unmarshal(ss.Snapshot, &s)
+	if err != nil {
+		return ContractSetSnapshot{}, errors.AddContext(err, "failed to unmarshal contract set snapshot")
+	}
+	return s, nil
+}
+
+// Diff returns the changes of the contract set since the given older
+// snapshot.
+func (s ContractSetSnapshot) Diff(old ContractSetSnapshot) ContractSetSnapshotDiff {
+	diff := ContractSetSnapshotDiff{
+		FromBlockHeight: old.BlockHeight,
+		ToBlockHeight:   s.BlockHeight,
+		FromTimestamp:   old.Timestamp,
+		ToTimestamp:     s.Timestamp,
+	}
+	oldContracts := make(map[types.FileContractID]ContractSnapshot, len(old.Contracts))
+	for _, c := range old.Contracts {
+		oldContracts[c.ID] = c
+	}
+	for _, c := range s.Contracts {
+		oc, exists := oldContracts[c.ID]
+		if !exists {
+			diff.Added = append(diff.Added, c)
+			continue
+		}
+		delete(oldContracts, c.ID)
+		if !oc.equals(c) {
+			diff.Changed = append(diff.Changed, ContractSnapshotChange{Old: oc, New: c})
+		}
+	}
+	for _, c := range oldContracts {
+		diff.Removed = append(diff.Removed, c)
+	}
+	sort.Slice(diff.Removed, func(i, j int) bool {
+		return diff.Removed[i].ID.String() < diff.Removed[j].ID.String()
+	})
+	return diff
+}
+
+// WriteCSV writes the contracts of the snapshot as CSV.
+func (s ContractSetSnapshot) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(contractSnapshotCSVHeader); err != nil {
+		return err
+	}
+	for _, c := range s.Contracts {
+		if err := cw.Write(c.csvRecord()); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteCSV writes the diff as CSV. Every added, removed or changed contract is
+// written as a single row which starts with the kind of the change. Changed
+// contracts are written with their new state.
+func (d ContractSetSnapshotDiff) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append([]string{"change"}, contractSnapshotCSVHeader...)); err != nil {
+		return err
+	}
+	write := func(change string, c ContractSnapshot) error {
+		return cw.Write(append([]string{change}, c.csvRecord()...))
+	}
+	for _, c := range d.Added {
+		if err := write("added", c); err != nil {
+			return err
+		}
+	}
+	for _, c := range d.Removed {
+		if err := write("removed", c); err != nil {
+			return err
+		}
+	}
+	for _, c := range d.Changed {
+		if err := write("changed", c.New); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvRecord returns the CSV record of the contract in the order of
+// contractSnapshotCSVHeader.
+func (c ContractSnapshot) csvRecord() []string {
+	return []string{
+		c.ID.String(),
+		c.HostPublicKey.String(),
+		string(c.NetAddress),
+		strconv.FormatUint(c.Size, 10),
+		strconv.FormatUint(uint64(c.StartHeight), 10),
+		strconv.FormatUint(uint64(c.EndHeight), 10),
+		c.TotalCost.String(),
+		c.Fees.String(),
+		c.RenterFunds.String(),
+		c.DownloadSpending.String(),
+		c.FundAccountSpending.String(),
+		c.MaintenanceSpending.String(),
+		c.StorageSpending.String(),
+		c.UploadSpending.String(),
+		strconv.FormatBool(c.GoodForUpload),
+		strconv.FormatBool(c.GoodForRenew),
+	}
+}
+
+// equals returns true if both contracts are in the same state.
+func (c ContractSnapshot) equals(other ContractSnapshot) bool {
+	return c.ID == other.ID &&
+		c.HostPublicKey.Equals(other.HostPublicKey) &&
+		c.NetAddress == other.NetAddress &&
+		c.Size == other.Size &&
+		c.StartHeight == other.StartHeight &&
+		c.EndHeight == other.EndHeight &&
+		c.TotalCost.Equals(other.TotalCost) &&
+		c.Fees.Equals(other.Fees) &&
+		c.RenterFunds.Equals(other.RenterFunds) &&
+		c.DownloadSpending.Equals(other.DownloadSpending) &&
+		c.FundAccountSpending.Equals(other.FundAccountSpending) &&
+		c.MaintenanceSpending.Equals(other.MaintenanceSpending) &&
+		c.StorageSpending.Equals(other.StorageSpending) &&
+		c.UploadSpending.Equals(other.UploadSpending) &&
+		c.GoodForUpload == other.GoodForUpload &&
+		c.GoodForRenew == other.GoodForRenew
+}
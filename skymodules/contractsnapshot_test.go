@@ -0,0 +1,167 @@
+package skymodules
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// randomContractSnapshot creates a contract snapshot with a random ID and host.
+func randomContractSnapshot() ContractSnapshot {
+	var id types.FileContractID
+	fastrand.Read(id[:])
+	_, hostPK := crypto.GenerateKeyPair()
+	return ContractSnapshot{
+		ID:             id,
+		HostPublicKey:  types.Ed25519PublicKey(hostPK),
+		NetAddress:     "127.0.0.1:9982",
+		Size:           fastrand.Uint64n(1 << 30),
+		StartHeight:    10,
+		EndHeight:      100,
+		TotalCost:      types.SiacoinPrecision,
+		UploadSpending: types.NewCurrency64(fastrand.Uint64n(1000)),
+		GoodForUpload:  true,
+		GoodForRenew:   true,
+	}
+}
+
+// TestSignedContractSetSnapshot is a unit test for SignContractSetSnapshot and
+// Verify.
+func TestSignedContractSetSnapshot(t *testing.T) {
+	t.Parallel()
+
+	sk, pk := crypto.GenerateKeyPair()
+	spk := types.Ed25519PublicKey(pk)
+	snapshot := ContractSetSnapshot{
+		BlockHeight: 42,
+		Timestamp:   1234,
+		Contracts:   []ContractSnapshot{randomContractSnapshot(), randomContractSnapshot()},
+	}
+
+	// Sign and verify the snapshot.
+	ss, err := SignContractSetSnapshot(snapshot, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ss.PublicKey.Equals(spk) {
+		t.Fatal("wrong public key", ss.PublicKey)
+	}
+	verified, err := ss.Verify(spk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(verified, snapshot) {
+		t.Fatal("wrong snapshot", verified)
+	}
+
+	// Different key.
+	_, pk2 := crypto.GenerateKeyPair()
+	_, err = ss.Verify(types.Ed25519PublicKey(pk2))
+	if !errors.Contains(err, ErrContractSnapshotInvalidSignature) {
+		t.Fatal("wrong error", err)
+	}
+
+	// Tampered snapshot.
+	tampered := ss
+	tampered.Snapshot = bytes.Replace(ss.Snapshot, []byte(`"blockheight":42`), []byte(`"blockheight":43`), 1)
+	_, err = tampered.Verify(spk)
+	if !errors.Contains(err, ErrContractSnapshotInvalidSignature) {
+		t.Fatal("wrong error", err)
+	}
+
+	// Invalid signature.
+	tampered = ss
+	tampered.Signature = "abcd"
+	_, err = tampered.Verify(spk)
+	if !errors.Contains(err, ErrContractSnapshotInvalidSignature) {
+		t.Fatal("wrong error", err)
+	}
+}
+
+// TestContractSetSnapshotDiff is a unit test for ContractSetSnapshot.Diff.
+func TestContractSetSnapshotDiff(t *testing.T) {
+	t.Parallel()
+
+	unchanged := randomContractSnapshot()
+	changed := randomContractSnapshot()
+	removed := randomContractSnapshot()
+	added := randomContractSnapshot()
+	old := ContractSetSnapshot{
+		BlockHeight: 1,
+		Timestamp:   10,
+		Contracts:   []ContractSnapshot{unchanged, changed, removed},
+	}
+	changedNew := changed
+	changedNew.Size++
+	changedNew.UploadSpending = changedNew.UploadSpending.Add64(1)
+	current := ContractSetSnapshot{
+		BlockHeight: 2,
+		Timestamp:   20,
+		Contracts:   []ContractSnapshot{unchanged, changedNew, added},
+	}
+
+	diff := current.Diff(old)
+	if diff.FromBlockHeight != 1 || diff.ToBlockHeight != 2 || diff.FromTimestamp != 10 || diff.ToTimestamp != 20 {
+		t.Fatal("wrong range", diff)
+	}
+	if len(diff.Added) != 1 || diff.Added[0].ID != added.ID {
+		t.Fatal("wrong added contracts", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].ID != removed.ID {
+		t.Fatal("wrong removed contracts", diff.Removed)
+	}
+	if len(diff.Changed) != 1 || !reflect.DeepEqual(diff.Changed[0], ContractSnapshotChange{Old: changed, New: changedNew}) {
+		t.Fatal("wrong changed contracts", diff.Changed)
+	}
+
+	// A snapshot doesn't differ from itself.
+	diff = current.Diff(current)
+	if len(diff.Added)+len(diff.Removed)+len(diff.Changed) != 0 {
+		t.Fatal("unexpected diff", diff)
+	}
+}
+
+// TestContractSetSnapshotCSV is a unit test for the CSV encoding of contract
+// set snapshots and their diffs.
+func TestContractSetSnapshotCSV(t *testing.T) {
+	t.Parallel()
+
+	c1, c2 := randomContractSnapshot(), randomContractSnapshot()
+	snapshot := ContractSetSnapshot{Contracts: []ContractSnapshot{c1, c2}}
+	var buf bytes.Buffer
+	if err := snapshot.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatal("wrong number of records", len(records))
+	}
+	if !reflect.DeepEqual(records[0], contractSnapshotCSVHeader) {
+		t.Fatal("wrong header", records[0])
+	}
+	if records[1][0] != c1.ID.String() || records[2][1] != c2.HostPublicKey.String() {
+		t.Fatal("wrong records", records)
+	}
+
+	diff := ContractSetSnapshot{Contracts: []ContractSnapshot{c2}}.Diff(snapshot)
+	buf.Reset()
+	if err := diff.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	records, err = csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0][0] != "change" || records[1][0] != "removed" || records[1][1] != c1.ID.String() {
+		t.Fatal("wrong diff records", records)
+	}
+}
@@ -107,8 +107,13 @@ type (
 	// of operations over a set of time ranges. Each time range corresponds to a
 	// different half life. A common choice is to track the half lives for {15
 	// minutes, 24 hours, Lifetime}.
+	//
+	// Alongside the decayed distributions, the tracker keeps a set of
+	// windowed distributions which only contain the recent data points. These
+	// are not persisted.
 	DistributionTracker struct {
 		distributions []*Distribution
+		windows       []*WindowedDistribution
 
 		mu sync.Mutex
 	}
@@ -119,6 +124,9 @@ type (
 	DistributionTrackerStats struct {
 		Nines      [][]time.Duration
 		DataPoints []float64
+
+		WindowedNines      [][]time.Duration
+		WindowedDataPoints []float64
 	}
 
 	// PersistedDistribution contains the information about a distribution
@@ -430,6 +438,9 @@ func (dt *DistributionTracker) AddDataPoint(dur time.Duration) {
 	for _, tr := range dt.distributions {
 		tr.AddDataPoint(dur)
 	}
+	for _, w := range dt.windows {
+		w.AddDataPoint(dur)
+	}
 }
 
 // Load loads the buckets of a PersistedDistributionTracker into the tracker
@@ -458,13 +469,31 @@ func (dt *DistributionTracker) Percentiles() [][]time.Duration {
 	dt.mu.Lock()
 	defer dt.mu.Unlock()
 
-	timings := make([][]time.Duration, len(dt.distributions))
+	return percentiles(dt.distributions)
+}
+
+// WindowedPercentiles returns the same percentiles as Percentiles for each
+// windowed distribution in the tracker.
+func (dt *DistributionTracker) WindowedPercentiles() [][]time.Duration {
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+
+	distributions := make([]*Distribution, 0, len(dt.windows))
+	for _, w := range dt.windows {
+		distributions = append(distributions, w.Distribution())
+	}
+	return percentiles(distributions)
+}
+
+// percentiles returns the p90, p99, p999 and p9999 of the given distributions.
+func percentiles(distributions []*Distribution) [][]time.Duration {
+	timings := make([][]time.Duration, len(distributions))
 	for i := 0; i < len(timings); i++ {
 		timings[i] = make([]time.Duration, 4)
-		timings[i][0] = dt.distributions[i].PStat(.9)
-		timings[i][1] = dt.distributions[i].PStat(.99)
-		timings[i][2] = dt.distributions[i].PStat(.999)
-		timings[i][3] = dt.distributions[i].PStat(.9999)
+		timings[i][0] = distributions[i].PStat(.9)
+		timings[i][1] = distributions[i].PStat(.99)
+		timings[i][2] = distributions[i].PStat(.999)
+		timings[i][3] = distributions[i].PStat(.9999)
 	}
 	return timings
 }
@@ -482,6 +511,19 @@ func (dt *DistributionTracker) DataPoints() []float64 {
 	return totals
 }
 
+// WindowedDataPoints returns the weighted number of items within each windowed
+// distribution.
+func (dt *DistributionTracker) WindowedDataPoints() []float64 {
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+
+	var totals []float64
+	for _, w := range dt.windows {
+		totals = append(totals, w.DataPoints())
+	}
+	return totals
+}
+
 // Distribution returns the distribution at the requested index. If the given
 // index is not within bounds it returns nil.
 func (dt *DistributionTracker) Distribution(index int) *Distribution {
@@ -495,12 +537,29 @@ func (dt *DistributionTracker) Distribution(index int) *Distribution {
 	return dt.distributions[index].Clone()
 }
 
+// WindowedDistribution returns the windowed distribution at the requested
+// index as a distribution without decay. If the given index is not within
+// bounds it returns nil.
+func (dt *DistributionTracker) WindowedDistribution(index int) *Distribution {
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+
+	if index < 0 || index >= len(dt.windows) {
+		build.Critical("unexpected windowed distribution index")
+		return nil
+	}
+	return dt.windows[index].Distribution()
+}
+
 // Stats returns a full suite of statistics about the distributions in the
 // tracker.
 func (dt *DistributionTracker) Stats() *DistributionTrackerStats {
 	return &DistributionTrackerStats{
 		Nines:      dt.Percentiles(),
 		DataPoints: dt.DataPoints(),
+
+		WindowedNines:      dt.WindowedPercentiles(),
+		WindowedDataPoints: dt.WindowedDataPoints(),
 	}
 }
 
@@ -513,7 +572,8 @@ func NewDistribution(halfLife time.Duration) *Distribution {
 
 // NewDistributionTrackerStandard returns a standard distribution tracker, which
 // tracks data points over distributions with half lives of 15 minutes, 24
-// hours, and 30 days.
+// hours, and 30 days as well as over sliding windows of 15 minutes, 1 hour and
+// 24 hours.
 func NewDistributionTrackerStandard() *DistributionTracker {
 	return &DistributionTracker{
 		distributions: []*Distribution{
@@ -521,5 +581,10 @@ func NewDistributionTrackerStandard() *DistributionTracker {
 			NewDistribution(24 * time.Hour),
 			NewDistribution(30 * 24 * time.Hour),
 		},
+		windows: []*WindowedDistribution{
+			NewWindowedDistribution(15 * time.Minute),
+			NewWindowedDistribution(time.Hour),
+			NewWindowedDistribution(24 * time.Hour),
+		},
 	}
 }
@@ -26,6 +26,8 @@ func TestDistributionTracker(t *testing.T) {
 	t.Run("Helpers", testDistributionHelpers)
 	t.Run("MergeWith", testDistributionMergeWith)
 	t.Run("Shift", testDistributionShift)
+	t.Run("Windowed", testDistributionWindowed)
+	t.Run("WindowedTracker", testDistributionTrackerWindowed)
 }
 
 // testDistributionBucketing will check that the distribution is placing timings
@@ -975,3 +977,114 @@ func testDistributionHelpers(t *testing.T) {
 		t.Error("bad", index, fraction)
 	}
 }
+
+// testDistributionWindowed is a unit test for the windowed distribution.
+func testDistributionWindowed(t *testing.T) {
+	t.Parallel()
+
+	window := 6 * time.Minute
+	slot := window / distributionWindowNumSlots
+	wd := NewWindowedDistribution(window)
+	if wd.Window() != window {
+		t.Fatal("wrong window", wd.Window())
+	}
+
+	// An empty window has no data points.
+	start := time.Now()
+	if dp := wd.distribution(start).DataPoints(); dp != 0 {
+		t.Fatal("expected no data points", dp)
+	}
+
+	// Add slow data points to the first slot. The expected duration is the
+	// start of the bucket the data points end up in.
+	slowIndex, _ := indexForDuration(time.Second)
+	slow := DistributionDurationForBucketIndex(slowIndex)
+	for i := 0; i < 10; i++ {
+		wd.addDataPoint(time.Second, start)
+	}
+	d := wd.distribution(start)
+	if dp := d.DataPoints(); dp != 10 {
+		t.Fatal("wrong number of data points", dp)
+	}
+	if d.ExpectedDuration() != slow {
+		t.Fatal("wrong expected duration", d.ExpectedDuration())
+	}
+
+	// Data points within the current slot don't decay.
+	now := start.Add(slot - 1)
+	if dp := wd.distribution(now).DataPoints(); dp != 10 {
+		t.Fatal("wrong number of data points", dp)
+	}
+
+	// Half a window later the slow data points are worth half as much.
+	now = start.Add(window / distributionWindowHalfLifeDivisor)
+	if dp := wd.distribution(now).DataPoints(); math.Abs(dp-5) > 1e-9 {
+		t.Fatal("wrong number of data points", dp)
+	}
+
+	// Add fast data points in the last slot of the window. The slow ones
+	// still count.
+	now = start.Add(window - slot)
+	for i := 0; i < 10; i++ {
+		wd.addDataPoint(100*time.Millisecond, now)
+	}
+	d = wd.distribution(now)
+	if ed := d.ExpectedDuration(); ed <= 100*time.Millisecond || ed >= slow {
+		t.Fatal("expected duration should be between the fast and slow ones", ed)
+	}
+
+	// Once the first slot leaves the window, only the fast data points are
+	// left.
+	now = start.Add(window)
+	d = wd.distribution(now)
+	if d.ExpectedDuration() != 100*time.Millisecond {
+		t.Fatal("wrong expected duration", d.ExpectedDuration())
+	}
+	if d.PStat(0.9) != 104*time.Millisecond {
+		t.Fatal("wrong p90", d.PStat(0.9))
+	}
+
+	// After a full window without data points the window is empty.
+	now = now.Add(window)
+	if dp := wd.distribution(now).DataPoints(); dp != 0 {
+		t.Fatal("expected no data points", dp)
+	}
+}
+
+// testDistributionTrackerWindowed verifies that the standard distribution
+// tracker tracks data points in its windows.
+func testDistributionTrackerWindowed(t *testing.T) {
+	t.Parallel()
+
+	dt := NewDistributionTrackerStandard()
+	for i := 0; i < 100; i++ {
+		dt.AddDataPoint(time.Duration(i+1) * 10 * time.Millisecond)
+	}
+
+	stats := dt.Stats()
+	if len(stats.WindowedNines) != 3 || len(stats.WindowedDataPoints) != 3 {
+		t.Fatal("expected 3 windows", stats.WindowedNines, stats.WindowedDataPoints)
+	}
+	for i := range stats.WindowedNines {
+		if stats.WindowedDataPoints[i] < 99 || stats.WindowedDataPoints[i] > 100 {
+			t.Fatal("wrong number of data points", stats.WindowedDataPoints[i])
+		}
+		// The windows contain the same data points as the decayed
+		// distributions.
+		for j := range stats.WindowedNines[i] {
+			if stats.WindowedNines[i][j] != stats.Nines[i][j] {
+				t.Fatal("windowed percentiles don't match", stats.WindowedNines[i], stats.Nines[i])
+			}
+		}
+	}
+	windowed := dt.WindowedDistribution(0).ExpectedDuration()
+	decayed := dt.Distribution(0).ExpectedDuration()
+	if diff := windowed - decayed; diff > time.Millisecond || diff < -time.Millisecond {
+		t.Fatal("expected durations don't match", windowed, decayed)
+	}
+
+	// The windows aren't persisted.
+	if len(dt.Persist().Distributions) != 3 {
+		t.Fatal("wrong number of persisted distributions")
+	}
+}
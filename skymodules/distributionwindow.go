@@ -0,0 +1,164 @@
+package skymodules
+
+// distributionwindow.go implements a distribution over a sliding time window.
+// The decayed distributions of the DistributionTracker never forget a data
+// point, they only weigh it less over time. That means a burst of slow
+// measurements can dominate the long half lives for days. A windowed
+// distribution drops data points entirely once they fall out of its window,
+// which makes it a better fit for estimates that should adapt to the current
+// network conditions.
+//
+// The window is split into a fixed number of slots. New data points are added
+// to the most recent slot and the oldest slot is recycled once it falls out of
+// the window. Within the window, older slots are weighted less using a half
+// life of half the window size, which smooths out the jumps that would
+// otherwise happen whenever a slot is recycled.
+
+import (
+	"math"
+	"time"
+
+	"gitlab.com/SkynetLabs/skyd/build"
+)
+
+const (
+	// distributionWindowNumSlots is the number of slots a window is split
+	// into. More slots make the window slide more smoothly at the cost of
+	// memory, every slot holds a full set of buckets.
+	distributionWindowNumSlots = 6
+
+	// distributionWindowHalfLifeDivisor determines the half life that is
+	// applied to the slots within the window. A divisor of 2 means that the
+	// data points at the end of the window are worth a quarter of the most
+	// recent ones.
+	distributionWindowHalfLifeDivisor = 2
+)
+
+type (
+	// WindowedDistribution tracks the distribution of durations within a
+	// sliding time window.
+	//
+	// NOTE: This struct is not thread safe, thread safety is derived from the
+	// parent object.
+	WindowedDistribution struct {
+		staticWindow time.Duration
+
+		// slots is a ring buffer of slots, current is the index of the most
+		// recent one.
+		slots   [distributionWindowNumSlots]distributionWindowSlot
+		current int
+	}
+
+	// distributionWindowSlot contains the data points that were added within
+	// a single slot of a window.
+	distributionWindowSlot struct {
+		start   time.Time
+		timings [numBuckets]float64
+	}
+)
+
+// NewWindowedDistribution creates a distribution over a sliding window of the
+// given size.
+func NewWindowedDistribution(window time.Duration) *WindowedDistribution {
+	return &WindowedDistribution{
+		staticWindow: window,
+	}
+}
+
+// slotDuration returns the duration covered by a single slot.
+func (wd *WindowedDistribution) slotDuration() time.Duration {
+	return wd.staticWindow / distributionWindowNumSlots
+}
+
+// advance moves the window forward to the given time, recycling the slots
+// that fell out of the window.
+func (wd *WindowedDistribution) advance(now time.Time) {
+	slotDuration := wd.slotDuration()
+	current := &wd.slots[wd.current]
+	if current.start.IsZero() {
+		current.start = now
+		return
+	}
+
+	// If the whole window expired, start over.
+	if now.Sub(current.start) >= wd.staticWindow {
+		wd.slots = [distributionWindowNumSlots]distributionWindowSlot{}
+		wd.current = 0
+		wd.slots[0].start = now
+		return
+	}
+
+	// Otherwise recycle the oldest slots until the current one covers now.
+	for now.Sub(wd.slots[wd.current].start) >= slotDuration {
+		start := wd.slots[wd.current].start.Add(slotDuration)
+		wd.current = (wd.current + 1) % distributionWindowNumSlots
+		wd.slots[wd.current] = distributionWindowSlot{start: start}
+	}
+}
+
+// AddDataPoint adds a sampled time to the current slot of the window.
+func (wd *WindowedDistribution) AddDataPoint(dur time.Duration) {
+	if dur < 0 {
+		build.Critical("cannot call AddDataPoint with negative duration")
+		return
+	}
+	wd.addDataPoint(dur, time.Now())
+}
+
+// addDataPoint adds a sampled time at the given time.
+func (wd *WindowedDistribution) addDataPoint(dur time.Duration, now time.Time) {
+	wd.advance(now)
+	index, _ := indexForDuration(dur)
+	wd.slots[wd.current].timings[index]++
+}
+
+// Distribution returns the weighted sum of the slots within the window as a
+// distribution without decay.
+func (wd *WindowedDistribution) Distribution() *Distribution {
+	return wd.distribution(time.Now())
+}
+
+// distribution returns the weighted sum of the slots within the window at the
+// given time.
+func (wd *WindowedDistribution) distribution(now time.Time) *Distribution {
+	wd.advance(now)
+
+	// The decay is applied per slot relative to the current slot. That way
+	// the data points of the current slot always have their full weight.
+	d := NewDistribution(0)
+	halfLife := wd.staticWindow / distributionWindowHalfLifeDivisor
+	currentStart := wd.slots[wd.current].start
+	for i := range wd.slots {
+		slot := &wd.slots[i]
+		if slot.start.IsZero() {
+			continue
+		}
+		weight := math.Pow(0.5, float64(currentStart.Sub(slot.start))/float64(halfLife))
+		for j, b := range slot.timings {
+			d.timings[j] += b * weight
+		}
+	}
+	return d
+}
+
+// DataPoints returns the weighted number of data points within the window.
+func (wd *WindowedDistribution) DataPoints() float64 {
+	return wd.Distribution().DataPoints()
+}
+
+// ExpectedDuration returns the estimated duration based on the data points
+// within the window.
+func (wd *WindowedDistribution) ExpectedDuration() time.Duration {
+	return wd.Distribution().ExpectedDuration()
+}
+
+// PStat returns the timing at which the percentage of requests within the
+// window is lower than the provided p.
+func (wd *WindowedDistribution) PStat(p float64) time.Duration {
+	return wd.Distribution().PStat(p)
+}
+
+// Window returns the size of the window.
+func (wd *WindowedDistribution) Window() time.Duration {
+	return wd.staticWindow
+}
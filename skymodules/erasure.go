@@ -15,6 +15,12 @@ import (
 	"go.sia.tech/siad/crypto"
 )
 
+var (
+	// ErrRecoverRangeOutOfBounds is returned by RecoverRange if the requested
+	// range exceeds the data contained in the pieces.
+	ErrRecoverRangeOutOfBounds = errors.New("range to recover exceeds the data in the pieces")
+)
+
 var (
 	// RenterDefaultDataPieces is the number of data pieces per erasure-coded
 	// chunk used in the renter.
@@ -32,6 +38,24 @@ var (
 		Testing:  4,
 	}).(int)
 
+	// RenterArchiveDataPieces is the number of data pieces per erasure-coded
+	// chunk used for files uploaded in archive mode.
+	RenterArchiveDataPieces = build.Select(build.Var{
+		Dev:      1,
+		Standard: 10,
+		Testing:  1,
+	}).(int)
+
+	// RenterArchiveParityPieces is the number of parity pieces per
+	// erasure-coded chunk used for files uploaded in archive mode. Archive
+	// hosts are less reliable than regular hosts which is why the redundancy
+	// is higher.
+	RenterArchiveParityPieces = build.Select(build.Var{
+		Dev:      2,
+		Standard: 40,
+		Testing:  5,
+	}).(int)
+
 	// RenterDefaultNumPieces is the sum of the renter's default data and parity
 	// pieces.
 	RenterDefaultNumPieces = RenterDefaultDataPieces + RenterDefaultParityPieces
@@ -78,6 +102,11 @@ type (
 		// sharded input.
 		EncodeShards(data [][]byte) ([][]byte, error)
 
+		// EncodeDataShards returns the data shards EncodeShards would return
+		// for the same input without computing the parity shards. The input
+		// is not modified.
+		EncodeDataShards(data [][]byte) ([][]byte, error)
+
 		// Reconstruct recovers the full set of encoded shards from the provided
 		// pieces, of which at least MinPieces must be non-nil.
 		Reconstruct(pieces [][]byte) error
@@ -89,6 +118,12 @@ type (
 		// pieces may have been padded with zeros during encoding.
 		Recover(pieces [][]byte, n uint64, w io.Writer) error
 
+		// RecoverRange recovers length bytes of the original data, starting
+		// at offset, from pieces and writes them to w. pieces should be
+		// provided the same way as for Recover. Only the parts of the pieces
+		// which contain the requested range are decoded.
+		RecoverRange(pieces [][]byte, offset, length uint64, w io.Writer) error
+
 		// SupportsPartialEncoding returns true if partial encoding is
 		// supported. The piece segment size will be returned. Otherwise the
 		// numerical return value is set to zero.
@@ -213,6 +248,17 @@ func (rs *RSCode) EncodeShards(pieces [][]byte) ([][]byte, error) {
 	return pieces, nil
 }
 
+// EncodeDataShards returns the data shards EncodeShards would return for the
+// same input without computing the parity shards. For the RSCode those are the
+// input shards themselves.
+func (rs *RSCode) EncodeDataShards(pieces [][]byte) ([][]byte, error) {
+	// Check that the caller provided the minimum amount of pieces.
+	if len(pieces) < rs.MinPieces() {
+		return nil, fmt.Errorf("invalid number of pieces given %v < %v", len(pieces), rs.MinPieces())
+	}
+	return pieces[:rs.MinPieces()], nil
+}
+
 // Identifier returns an identifier for an erasure coder which can be used to
 // identify erasure coders of the same type, dataPieces and parityPieces.
 func (rs *RSCode) Identifier() ErasureCoderIdentifier {
@@ -240,6 +286,52 @@ func (rs *RSCode) Recover(pieces [][]byte, n uint64, w io.Writer) error {
 	return rs.enc.Join(w, pieces, int(n))
 }
 
+// RecoverRange recovers length bytes of the original data, starting at offset,
+// from pieces and writes them to w. Missing pieces are only reconstructed if
+// any of the data pieces containing the range is missing.
+func (rs *RSCode) RecoverRange(pieces [][]byte, offset, length uint64, w io.Writer) error {
+	if len(pieces) != rs.NumPieces() {
+		return fmt.Errorf("expected pieces to have len %v but was %v", rs.NumPieces(), len(pieces))
+	}
+	if length == 0 {
+		return nil
+	}
+	pieceSize := maxPieceSize(pieces)
+	if pieceSize == 0 {
+		return errors.New("no pieces to recover the range from")
+	}
+	firstPiece := offset / pieceSize
+	lastPiece := (offset + length - 1) / pieceSize
+	if lastPiece >= uint64(rs.MinPieces()) {
+		return ErrRecoverRangeOutOfBounds
+	}
+
+	// Reconstruct the data pieces if any of the pieces we need is missing.
+	for i := firstPiece; i <= lastPiece; i++ {
+		if uint64(len(pieces[i])) != pieceSize {
+			if err := rs.enc.ReconstructData(pieces); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	// Write the range.
+	off := offset - firstPiece*pieceSize
+	for i := firstPiece; length > 0; i++ {
+		data := pieces[i][off:]
+		if uint64(len(data)) > length {
+			data = data[:length]
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		length -= uint64(len(data))
+		off = 0
+	}
+	return nil
+}
+
 // SupportsPartialEncoding returns false for the basic reed-solomon encoder and
 // a size of 0.
 func (rs *RSCode) SupportsPartialEncoding() (uint64, bool) {
@@ -278,24 +370,9 @@ func (rs *RSSubCode) Encode(data []byte) ([][]byte, error) {
 // EncodeShards encodes data in a way that every segmentSize bytes of the
 // encoded data can be decoded independently.
 func (rs *RSSubCode) EncodeShards(pieces [][]byte) ([][]byte, error) {
-	// Check that there are enough pieces.
-	if len(pieces) != rs.MinPieces() {
-		return nil, fmt.Errorf("not enough segments expected %v but was %v",
-			rs.MinPieces(), len(pieces))
-	}
-	// Since all the pieces should have the same length, get the pieceSize from
-	// the first one.
-	pieceSize := uint64(len(pieces[0]))
-	// pieceSize must be divisible by segmentSize
-	if pieceSize%rs.staticSegmentSize != 0 {
-		return nil, errors.New("pieceSize not divisible by segmentSize")
-	}
-	// Each piece should have pieceSize bytes.
-	for _, piece := range pieces {
-		if uint64(len(piece)) != pieceSize {
-			return nil, fmt.Errorf("pieces don't have right size expected %v but was %v",
-				pieceSize, len(piece))
-		}
+	pieceSize, err := rs.checkShards(pieces)
+	if err != nil {
+		return nil, err
 	}
 	// Flatten the pieces into a byte slice.
 	data := make([]byte, uint64(len(pieces))*pieceSize)
@@ -331,6 +408,56 @@ func (rs *RSSubCode) EncodeShards(pieces [][]byte) ([][]byte, error) {
 	return pieces, nil
 }
 
+// EncodeDataShards returns the data shards EncodeShards would return for the
+// same input without computing the parity shards.
+func (rs *RSSubCode) EncodeDataShards(pieces [][]byte) ([][]byte, error) {
+	pieceSize, err := rs.checkShards(pieces)
+	if err != nil {
+		return nil, err
+	}
+	// EncodeShards splits the flattened input into segments and distributes
+	// them across the shards in a round-robin fashion. Since pieceSize is
+	// divisible by the segment size, a segment never spans multiple pieces.
+	shards := make([][]byte, len(pieces))
+	for i := range shards {
+		shards[i] = make([]byte, 0, pieceSize)
+	}
+	numSegments := uint64(len(pieces)) * pieceSize / rs.staticSegmentSize
+	for i := uint64(0); i < numSegments; i++ {
+		offset := i * rs.staticSegmentSize
+		piece := pieces[offset/pieceSize]
+		segment := piece[offset%pieceSize:][:rs.staticSegmentSize]
+		shard := i % uint64(len(pieces))
+		shards[shard] = append(shards[shard], segment...)
+	}
+	return shards, nil
+}
+
+// checkShards checks that the provided data shards can be encoded and returns
+// their size.
+func (rs *RSSubCode) checkShards(pieces [][]byte) (uint64, error) {
+	// Check that there are enough pieces.
+	if len(pieces) != rs.MinPieces() {
+		return 0, fmt.Errorf("not enough segments expected %v but was %v",
+			rs.MinPieces(), len(pieces))
+	}
+	// Since all the pieces should have the same length, get the pieceSize from
+	// the first one.
+	pieceSize := uint64(len(pieces[0]))
+	// pieceSize must be divisible by segmentSize
+	if pieceSize%rs.staticSegmentSize != 0 {
+		return 0, errors.New("pieceSize not divisible by segmentSize")
+	}
+	// Each piece should have pieceSize bytes.
+	for _, piece := range pieces {
+		if uint64(len(piece)) != pieceSize {
+			return 0, fmt.Errorf("pieces don't have right size expected %v but was %v",
+				pieceSize, len(piece))
+		}
+	}
+	return pieceSize, nil
+}
+
 // Identifier returns an identifier for an erasure coder which can be used to
 // identify erasure coders of the same type, dataPieces and parityPieces.
 func (rs *RSSubCode) Identifier() ErasureCoderIdentifier {
@@ -443,6 +570,57 @@ func (rs *RSSubCode) Recover(pieces [][]byte, n uint64, w io.Writer) error {
 	return nil
 }
 
+// RecoverRange recovers length bytes of the original data, starting at offset,
+// from pieces and writes them to w. Only the segments which contain the range
+// are decoded.
+func (rs *RSSubCode) RecoverRange(pieces [][]byte, offset, length uint64, w io.Writer) error {
+	if len(pieces) != rs.NumPieces() {
+		return fmt.Errorf("expected pieces to have len %v but was %v", rs.NumPieces(), len(pieces))
+	}
+	pieceSize := maxPieceSize(pieces)
+	if pieceSize%rs.staticSegmentSize != 0 {
+		return errors.New("pieceSize not divisible by segmentSize")
+	}
+
+	// Determine the first segment containing the range and the offset of the
+	// range within it.
+	decodedSegmentSize := rs.staticSegmentSize * uint64(rs.MinPieces())
+	segmentIndex := offset / decodedSegmentSize
+	skip := offset % decodedSegmentSize
+
+	// Decode the segments one by one until the range is recovered.
+	numSegments := pieceSize / rs.staticSegmentSize
+	segment := make([][]byte, len(pieces))
+	buf := bytes.NewBuffer(make([]byte, 0, decodedSegmentSize))
+	for ; length > 0; segmentIndex++ {
+		if segmentIndex >= numSegments {
+			return ErrRecoverRangeOutOfBounds
+		}
+		off := segmentIndex * rs.staticSegmentSize
+		for i, piece := range pieces {
+			if uint64(len(piece)) >= off+rs.staticSegmentSize {
+				segment[i] = append(segment[i][:0], piece[off:off+rs.staticSegmentSize]...)
+			} else {
+				segment[i] = segment[i][:0]
+			}
+		}
+		n := skip + length
+		if n > decodedSegmentSize {
+			n = decodedSegmentSize
+		}
+		buf.Reset()
+		if err := rs.RSCode.Recover(segment, n, buf); err != nil {
+			return err
+		}
+		if _, err := w.Write(buf.Bytes()[skip:]); err != nil {
+			return err
+		}
+		length -= n - skip
+		skip = 0
+	}
+	return nil
+}
+
 // SupportsPartialEncoding returns true for the custom reed-solomon encoder and
 // returns the segment size.
 func (rs *RSSubCode) SupportsPartialEncoding() (uint64, bool) {
@@ -454,6 +632,18 @@ func (rs *RSSubCode) Type() ErasureCoderType {
 	return rs.staticType
 }
 
+// maxPieceSize returns the size of the largest piece. All pieces that are
+// present should have the same size.
+func maxPieceSize(pieces [][]byte) uint64 {
+	var pieceSize uint64
+	for _, piece := range pieces {
+		if uint64(len(piece)) > pieceSize {
+			pieceSize = uint64(len(piece))
+		}
+	}
+	return pieceSize
+}
+
 // ExtractSegment is a convenience method that extracts the data of the segment
 // at segmentIndex from pieces.
 func ExtractSegment(pieces [][]byte, segmentIndex int, segmentSize uint64) [][]byte {
@@ -498,6 +688,13 @@ func (pec *PassthroughErasureCoder) EncodeShards(pieces [][]byte) ([][]byte, err
 	return pieces, nil
 }
 
+// EncodeDataShards returns the data shards EncodeShards would return for the
+// same input without computing the parity shards. For the passthrough this is a
+// no-op.
+func (pec *PassthroughErasureCoder) EncodeDataShards(pieces [][]byte) ([][]byte, error) {
+	return pieces, nil
+}
+
 // Reconstruct recovers the full set of encoded shards from the provided pieces,
 // of which at least MinPieces must be non-nil. For the passthrough this is a
 // no-op.
@@ -515,6 +712,17 @@ func (pec *PassthroughErasureCoder) Recover(pieces [][]byte, n uint64, w io.Writ
 	return err
 }
 
+// RecoverRange recovers length bytes of the original data, starting at offset,
+// from pieces and writes them to w. For the passthrough this writes the range
+// of the only piece.
+func (pec *PassthroughErasureCoder) RecoverRange(pieces [][]byte, offset, length uint64, w io.Writer) error {
+	if offset+length > uint64(len(pieces[0])) {
+		return ErrRecoverRangeOutOfBounds
+	}
+	_, err := w.Write(pieces[0][offset : offset+length])
+	return err
+}
+
 // SupportsPartialEncoding returns true if partial encoding is supported. The
 // piece segment size will be returned. Otherwise the numerical return value is
 // set to zero.
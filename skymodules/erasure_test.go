@@ -18,6 +18,133 @@ func TestErasureCode(t *testing.T) {
 	t.Run("Passthrough", testPassthrough)
 	t.Run("UniqueIdentifier", testUniqueIdentifier)
 	t.Run("DefaultConstructors", testDefaultConstructors)
+	t.Run("RecoverRange", testRecoverRange)
+	t.Run("EncodeDataShards", testEncodeDataShards)
+}
+
+// testEncodeDataShards tests that EncodeDataShards returns the same data shards
+// as EncodeShards.
+func testEncodeDataShards(t *testing.T) {
+	rsc, err := NewRSCode(10, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rssc, err := NewRSSubCode(10, 20, crypto.SegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ec := range []ErasureCoder{rsc, rssc, NewPassthroughErasureCoder()} {
+		dataShards := make([][]byte, ec.MinPieces())
+		for i := range dataShards {
+			dataShards[i] = fastrand.Bytes(int(crypto.SegmentSize) * 16)
+		}
+		shards, err := ec.EncodeDataShards(dataShards)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(shards) != ec.MinPieces() {
+			t.Fatal("wrong number of shards", len(shards))
+		}
+		copies := make([][]byte, len(shards))
+		for i := range shards {
+			copies[i] = append([]byte{}, shards[i]...)
+		}
+		encoded, err := ec.EncodeShards(dataShards)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range copies {
+			if !bytes.Equal(copies[i], encoded[i]) {
+				t.Fatalf("%v: shard %v doesn't match", ec.Type(), i)
+			}
+		}
+	}
+}
+
+// testRecoverRange tests RecoverRange for all erasure coders by comparing it
+// to the output of Recover.
+func testRecoverRange(t *testing.T) {
+	rsc, err := NewRSCode(10, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rssc, err := NewRSSubCode(10, 20, crypto.SegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ec := range []ErasureCoder{rsc, rssc, NewPassthroughErasureCoder()} {
+		data := fastrand.Bytes(ec.MinPieces() * int(crypto.SegmentSize) * 16)
+		pieces, err := ec.Encode(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Drop as many data pieces as possible.
+		for i := 0; i < len(pieces)-ec.MinPieces() && i < ec.MinPieces(); i++ {
+			pieces[i] = nil
+		}
+		recoverRange := func(offset, length uint64) ([]byte, error) {
+			buf := bytes.NewBuffer(nil)
+			err := ec.RecoverRange(append([][]byte{}, pieces...), offset, length, buf)
+			return buf.Bytes(), err
+		}
+
+		// Get the expected output.
+		buf := bytes.NewBuffer(nil)
+		err = ec.Recover(append([][]byte{}, pieces...), uint64(len(data)), buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := buf.Bytes()
+
+		// Check some edge cases and random ranges.
+		ranges := [][2]uint64{
+			{0, 0},
+			{0, 1},
+			{0, uint64(len(data))},
+			{uint64(len(data)) - 1, 1},
+			{crypto.SegmentSize - 1, 2},
+		}
+		for i := 0; i < 100; i++ {
+			offset := fastrand.Uint64n(uint64(len(data)))
+			length := fastrand.Uint64n(uint64(len(data))-offset) + 1
+			ranges = append(ranges, [2]uint64{offset, length})
+		}
+		for _, r := range ranges {
+			offset, length := r[0], r[1]
+			actual, err := recoverRange(offset, length)
+			if err != nil {
+				t.Fatal(ec.Type(), offset, length, err)
+			}
+			if !bytes.Equal(actual, expected[offset:offset+length]) {
+				t.Fatal(ec.Type(), "wrong data", offset, length)
+			}
+		}
+
+		// Ranges exceeding the data should fail.
+		_, err = recoverRange(uint64(len(data)), 1)
+		if !errors.Contains(err, ErrRecoverRangeOutOfBounds) {
+			t.Fatal(ec.Type(), "expected out of bounds error", err)
+		}
+	}
+
+	// The RSCode doesn't need to decode if the pieces containing the range are
+	// present. Only provide the first piece which isn't enough for decoding.
+	data := fastrand.Bytes(10 * 64)
+	pieces, err := rsc.Encode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(pieces); i++ {
+		pieces[i] = nil
+	}
+	buf := bytes.NewBuffer(nil)
+	err = rsc.RecoverRange(pieces, 10, 20, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), data[10:30]) {
+		t.Fatal("wrong data")
+	}
 }
 
 // testRSCode tests the RSCode EC.
@@ -356,3 +483,76 @@ func BenchmarkRSSubCodeRecover(b *testing.B) {
 		}
 	}
 }
+
+// BenchmarkErasureCoders compares the throughput of the erasure coder
+// implementations for encoding and recovering a chunk with the default skynet
+// redundancy. The SIMD backend of the RS codes is selected at runtime based on
+// the CPU's features. Running the benchmark with the 'noasm' build tag
+// compares it to the generic backend.
+func BenchmarkErasureCoders(b *testing.B) {
+	const dataPieces, parityPieces = 10, 20
+	const dataSize = dataPieces << 16 // 64 KiB pieces
+
+	rsc, err := NewRSCode(dataPieces, parityPieces)
+	if err != nil {
+		b.Fatal(err)
+	}
+	rssc, err := NewRSSubCode(dataPieces, parityPieces, crypto.SegmentSize)
+	if err != nil {
+		b.Fatal(err)
+	}
+	coders := []struct {
+		name string
+		ec   ErasureCoder
+	}{
+		{"RSCode", rsc},
+		{"RSSubCode", rssc},
+		{"Passthrough", NewPassthroughErasureCoder()},
+	}
+
+	for _, coder := range coders {
+		ec := coder.ec
+		data := fastrand.Bytes(dataSize)
+		b.Run(coder.name+"/Encode", func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(dataSize)
+			for i := 0; i < b.N; i++ {
+				_, err := ec.Encode(data)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(coder.name+"/Recover", func(b *testing.B) {
+			pieces, err := ec.Encode(data)
+			if err != nil {
+				b.Fatal(err)
+			}
+			// Drop as many data pieces as possible to force decoding.
+			available := make([][]byte, len(pieces))
+			copy(available, pieces)
+			for i := 0; i < len(available)-ec.MinPieces() && i < ec.MinPieces(); i++ {
+				available[i] = nil
+			}
+			buf := bytes.NewBuffer(make([]byte, 0, dataSize))
+
+			b.ReportAllocs()
+			b.SetBytes(dataSize)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				buf.Reset()
+				pieces := append([][]byte{}, available...)
+				err := ec.Recover(pieces, dataSize, buf)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+			// NOTE: the RSSubCode recovers the data segment by segment
+			// so only the length is compared.
+			b.StopTimer()
+			if buf.Len() != dataSize {
+				b.Fatal("recovered data has wrong length", buf.Len())
+			}
+		})
+	}
+}
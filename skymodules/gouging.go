@@ -0,0 +1,156 @@
+package skymodules
+
+import (
+	"fmt"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// The following fields are checked by the GougingChecker. Fields of the host's
+// settings and price table which correspond to the same allowance limit share
+// the same field.
+const (
+	GougingFieldBaseRPCPrice           = GougingField("baserpcprice")
+	GougingFieldContractPrice          = GougingField("contractprice")
+	GougingFieldDownloadBandwidthPrice = GougingField("downloadbandwidthprice")
+	GougingFieldMemoryTimeCost         = GougingField("memorytimecost")
+	GougingFieldReadBaseCost           = GougingField("readbasecost")
+	GougingFieldSectorAccessPrice      = GougingField("sectoraccessprice")
+	GougingFieldStoragePrice           = GougingField("storageprice")
+	GougingFieldUploadBandwidthPrice   = GougingField("uploadbandwidthprice")
+	GougingFieldWriteLengthCost        = GougingField("writelengthcost")
+)
+
+var (
+	// ErrPriceGouging is returned by GougingReport.Err if a host's prices
+	// exceed the limits of the allowance.
+	ErrPriceGouging = errors.New("price gouging protection enabled")
+
+	// gougingFieldDescriptions are the human readable descriptions of the
+	// fields used within errors.
+	gougingFieldDescriptions = map[GougingField]string{
+		GougingFieldBaseRPCPrice:           "rpc price",
+		GougingFieldContractPrice:          "contract price",
+		GougingFieldDownloadBandwidthPrice: "download bandwidth price",
+		GougingFieldMemoryTimeCost:         "memory time cost",
+		GougingFieldReadBaseCost:           "read base cost",
+		GougingFieldSectorAccessPrice:      "sector access price",
+		GougingFieldStoragePrice:           "storage price",
+		GougingFieldUploadBandwidthPrice:   "upload bandwidth price",
+		GougingFieldWriteLengthCost:        "write length cost",
+	}
+
+	// maxUnusedPriceTableCost is the limit for price table fields which are
+	// unused by hosts and expected to be set to 1H.
+	maxUnusedPriceTableCost = types.NewCurrency64(1)
+)
+
+type (
+	// GougingField is the name of a host price which is checked for price
+	// gouging.
+	GougingField string
+
+	// GougingChecker checks a host's prices against the limits of an
+	// allowance.
+	GougingChecker struct {
+		staticAllowance Allowance
+	}
+
+	// GougingViolation describes a single host price which exceeds its limit.
+	GougingViolation struct {
+		Field  GougingField   `json:"field"`
+		Price  types.Currency `json:"price"`
+		Limit  types.Currency `json:"limit"`
+		Excess types.Currency `json:"excess"`
+	}
+
+	// GougingReport is the result of checking a host's prices for price
+	// gouging. It contains all prices which exceed their limits.
+	GougingReport struct {
+		Violations []GougingViolation `json:"violations"`
+	}
+)
+
+// NewGougingChecker creates a new checker for the given allowance.
+func NewGougingChecker(allowance Allowance) GougingChecker {
+	return GougingChecker{
+		staticAllowance: allowance,
+	}
+}
+
+// CheckHostSettings checks the prices of a host's external settings.
+func (gc GougingChecker) CheckHostSettings(hes modules.HostExternalSettings) GougingReport {
+	a := gc.staticAllowance
+	var report GougingReport
+	report.check(GougingFieldBaseRPCPrice, hes.BaseRPCPrice, a.MaxRPCPrice)
+	report.check(GougingFieldContractPrice, hes.ContractPrice, a.MaxContractPrice)
+	report.check(GougingFieldSectorAccessPrice, hes.SectorAccessPrice, a.MaxSectorAccessPrice)
+	report.check(GougingFieldStoragePrice, hes.StoragePrice, a.MaxStoragePrice)
+	report.check(GougingFieldUploadBandwidthPrice, hes.UploadBandwidthPrice, a.MaxUploadBandwidthPrice)
+	report.check(GougingFieldDownloadBandwidthPrice, hes.DownloadBandwidthPrice, a.MaxDownloadBandwidthPrice)
+	return report
+}
+
+// CheckPriceTable checks the prices of a host's price table.
+func (gc GougingChecker) CheckPriceTable(pt modules.RPCPriceTable) GougingReport {
+	a := gc.staticAllowance
+	var report GougingReport
+	report.check(GougingFieldBaseRPCPrice, pt.InitBaseCost, a.MaxRPCPrice)
+	report.check(GougingFieldContractPrice, pt.ContractPrice, a.MaxContractPrice)
+	report.check(GougingFieldSectorAccessPrice, pt.WriteBaseCost, a.MaxSectorAccessPrice)
+	report.check(GougingFieldReadBaseCost, pt.ReadBaseCost, a.MaxSectorAccessPrice)
+	report.check(GougingFieldStoragePrice, pt.WriteStoreCost, a.MaxStoragePrice)
+	report.check(GougingFieldUploadBandwidthPrice, pt.UploadBandwidthCost, a.MaxUploadBandwidthPrice)
+	report.check(GougingFieldDownloadBandwidthPrice, pt.DownloadBandwidthCost, a.MaxDownloadBandwidthPrice)
+	report.check(GougingFieldMemoryTimeCost, pt.MemoryTimeCost, maxUnusedPriceTableCost)
+	report.check(GougingFieldWriteLengthCost, pt.WriteLengthCost, maxUnusedPriceTableCost)
+	return report
+}
+
+// Err returns an error describing the violations of the given fields. If no
+// fields are provided, all violations are considered. If none of the
+// considered prices exceed their limits, nil is returned.
+func (gr GougingReport) Err(fields ...GougingField) error {
+	var err error
+	for _, v := range gr.Violations {
+		if len(fields) > 0 && !containsGougingField(fields, v.Field) {
+			continue
+		}
+		err = errors.Compose(err, fmt.Errorf("%v of host is %v, which is above the maximum allowed by the allowance: %v", gougingFieldDescriptions[v.Field], v.Price, v.Limit))
+	}
+	if err != nil {
+		return errors.Compose(err, ErrPriceGouging)
+	}
+	return nil
+}
+
+// Gouging returns true if any of the host's prices exceed their limits.
+func (gr GougingReport) Gouging() bool {
+	return len(gr.Violations) > 0
+}
+
+// check adds a violation to the report if the price exceeds the limit. A zero
+// limit means that there is no limit.
+func (gr *GougingReport) check(field GougingField, price, limit types.Currency) {
+	if limit.IsZero() || price.Cmp(limit) <= 0 {
+		return
+	}
+	gr.Violations = append(gr.Violations, GougingViolation{
+		Field:  field,
+		Price:  price,
+		Limit:  limit,
+		Excess: price.Sub(limit),
+	})
+}
+
+// containsGougingField returns true if the field is in the slice of fields.
+func containsGougingField(fields []GougingField, field GougingField) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
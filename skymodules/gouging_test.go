@@ -0,0 +1,65 @@
+package skymodules
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestGougingChecker is a unit test for the GougingChecker.
+func TestGougingChecker(t *testing.T) {
+	t.Parallel()
+
+	allowance := Allowance{
+		MaxRPCPrice:               types.NewCurrency64(10),
+		MaxStoragePrice:           types.NewCurrency64(10),
+		MaxDownloadBandwidthPrice: types.NewCurrency64(10),
+	}
+	gc := NewGougingChecker(allowance)
+
+	// Host settings within the limits. Prices without limit are ignored.
+	hes := modules.HostExternalSettings{
+		BaseRPCPrice:           types.NewCurrency64(10),
+		StoragePrice:           types.NewCurrency64(1),
+		DownloadBandwidthPrice: types.NewCurrency64(10),
+		UploadBandwidthPrice:   types.NewCurrency64(1000),
+	}
+	report := gc.CheckHostSettings(hes)
+	if report.Gouging() || report.Err() != nil {
+		t.Fatal("unexpected violations", report)
+	}
+
+	// Exceed two of the limits.
+	hes.BaseRPCPrice = types.NewCurrency64(11)
+	hes.StoragePrice = types.NewCurrency64(15)
+	report = gc.CheckHostSettings(hes)
+	if len(report.Violations) != 2 {
+		t.Fatal("wrong number of violations", report)
+	}
+	v := report.Violations[1]
+	if v.Field != GougingFieldStoragePrice || !v.Price.Equals64(15) || !v.Limit.Equals64(10) || !v.Excess.Equals64(5) {
+		t.Fatal("unexpected violation", v)
+	}
+	if err := report.Err(); !errors.Contains(err, ErrPriceGouging) {
+		t.Fatal("expected gouging error", err)
+	}
+	if err := report.Err(GougingFieldStoragePrice); !errors.Contains(err, ErrPriceGouging) {
+		t.Fatal("expected gouging error", err)
+	}
+	if err := report.Err(GougingFieldDownloadBandwidthPrice); err != nil {
+		t.Fatal("unexpected error", err)
+	}
+
+	// Check a price table. The unused costs are limited to 1H even without an
+	// allowance.
+	pt := modules.RPCPriceTable{
+		InitBaseCost:   types.NewCurrency64(1),
+		MemoryTimeCost: types.NewCurrency64(2),
+	}
+	report = NewGougingChecker(Allowance{}).CheckPriceTable(pt)
+	if len(report.Violations) != 1 || report.Violations[0].Field != GougingFieldMemoryTimeCost {
+		t.Fatal("unexpected violations", report)
+	}
+}
@@ -0,0 +1,42 @@
+package skymodules
+
+import (
+	"fmt"
+)
+
+const (
+	// DefaultHedgingPercentile is the percentile of the base sector download
+	// latency after which a hedged download is launched if none was
+	// specified.
+	DefaultHedgingPercentile = 0.9
+)
+
+type (
+	// HedgingSettings control the hedged fetching of base sectors. If a base
+	// sector download didn't return after the given percentile of the recent
+	// base sector download latencies, or if it failed, a second download is
+	// launched from a different set of workers. The download which finishes
+	// first is used and the other one is cancelled. A Percentile of zero
+	// means that the DefaultHedgingPercentile is used.
+	HedgingSettings struct {
+		Disabled   bool    `json:"disabled"`
+		Percentile float64 `json:"percentile"`
+	}
+)
+
+// PercentileOrDefault returns the percentile of the settings or the
+// DefaultHedgingPercentile if none was specified.
+func (hs HedgingSettings) PercentileOrDefault() float64 {
+	if hs.Percentile == 0 {
+		return DefaultHedgingPercentile
+	}
+	return hs.Percentile
+}
+
+// Validate checks the hedging settings for validity.
+func (hs HedgingSettings) Validate() error {
+	if hs.Percentile < 0 || hs.Percentile >= 1 {
+		return fmt.Errorf("hedging percentile must be within [0, 1), got %v", hs.Percentile)
+	}
+	return nil
+}
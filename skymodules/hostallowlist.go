@@ -0,0 +1,121 @@
+package skymodules
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// A host allow-list is a curated list of hosts published by the operator of a
+// private portal. The list is signed with an ed25519 key and the renter only
+// applies it as the whitelist of the hostdb if the signature matches the key
+// it was configured with.
+
+var (
+	// ErrHostAllowlistInvalidSignature is returned if the signature of a host
+	// allow-list doesn't match its content and the configured key.
+	ErrHostAllowlistInvalidSignature = errors.New("host allow-list has an invalid signature")
+
+	// ErrHostAllowlistNoHosts is returned if a host allow-list doesn't contain
+	// any hosts.
+	ErrHostAllowlistNoHosts = errors.New("host allow-list doesn't contain any hosts")
+
+	// ErrHostAllowlistNotConfigured is returned when refreshing the host
+	// allow-list without a configured source.
+	ErrHostAllowlistNotConfigured = errors.New("no host allow-list source configured")
+
+	// ErrHostAllowlistOutdated is returned if a host allow-list has a lower
+	// revision than the one that was applied before.
+	ErrHostAllowlistOutdated = errors.New("host allow-list revision is lower than the applied revision")
+)
+
+type (
+	// HostAllowlist is a list of hosts the renter is pinned to. The revision
+	// prevents an older list from replacing a newer one.
+	HostAllowlist struct {
+		Revision uint64               `json:"revision"`
+		Hosts    []HostAllowlistEntry `json:"hosts"`
+	}
+
+	// HostAllowlistEntry is a host of a host allow-list with optional
+	// metadata, e.g. the name or location of the host, which is not
+	// interpreted by the renter.
+	HostAllowlistEntry struct {
+		PublicKey types.SiaPublicKey `json:"publickey"`
+		Metadata  map[string]string  `json:"metadata,omitempty"`
+	}
+
+	// SignedHostAllowlist is the document of a host allow-list feed. The
+	// signature is the hex encoded ed25519 signature of the hash of the raw
+	// allow-list.
+	SignedHostAllowlist struct {
+		Allowlist json.RawMessage `json:"allowlist"`
+		Signature string          `json:"signature"`
+	}
+
+	// HostAllowlistStatus describes the host allow-list feed of the renter and
+	// the allow-list that was last applied.
+	HostAllowlistStatus struct {
+		Source     string               `json:"source"`
+		PublicKey  types.SiaPublicKey   `json:"publickey"`
+		Revision   uint64               `json:"revision"`
+		Hosts      []HostAllowlistEntry `json:"hosts"`
+		LastUpdate time.Time            `json:"lastupdate"`
+		LastError  string               `json:"lasterror,omitempty"`
+	}
+)
+
+// SignHostAllowlist creates the signed document of a host allow-list.
+func SignHostAllowlist(al HostAllowlist, sk crypto.SecretKey) (SignedHostAllowlist, error) {
+	alBytes, err := json.Marshal(al)
+	if err != nil {
+		return SignedHostAllowlist{}, errors.AddContext(err, "failed to marshal host allow-list")
+	}
+	sig := crypto.SignHash(crypto.HashBytes(alBytes), sk)
+	return SignedHostAllowlist{
+		Allowlist: alBytes,
+		Signature: hex.EncodeToString(sig[:]),
+	}, nil
+}
+
+// Verify verifies the signature of the document using the given key and
+// returns the host allow-list.
+func (sal SignedHostAllowlist) Verify(pk types.SiaPublicKey) (HostAllowlist, error) {
+	if pk.Algorithm != types.SignatureEd25519 || len(pk.Key) != crypto.PublicKeySize {
+		return HostAllowlist{}, errors.New("host allow-list key must be an ed25519 key")
+	}
+	sigBytes, err := hex.DecodeString(sal.Signature)
+	if err != nil || len(sigBytes) != crypto.SignatureSize {
+		return HostAllowlist{}, ErrHostAllowlistInvalidSignature
+	}
+	var sig crypto.Signature
+	copy(sig[:], sigBytes)
+	var cpk crypto.PublicKey
+	copy(cpk[:], pk.Key)
+	if crypto.VerifyHash(crypto.HashBytes(sal.Allowlist), cpk, sig) != nil {
+		return HostAllowlist{}, ErrHostAllowlistInvalidSignature
+	}
+
+	var al HostAllowlist
+	err = json.Unmarshal(sal.Allowlist, &al)
+	if err != nil {
+		return HostAllowlist{}, errors.AddContext(err, "failed to unmarshal host allow-list")
+	}
+	if len(al.Hosts) == 0 {
+		return HostAllowlist{}, ErrHostAllowlistNoHosts
+	}
+	return al, nil
+}
+
+// PublicKeys returns the public keys of the hosts of the allow-list.
+func (al HostAllowlist) PublicKeys() []types.SiaPublicKey {
+	pks := make([]types.SiaPublicKey, 0, len(al.Hosts))
+	for _, host := range al.Hosts {
+		pks = append(pks, host.PublicKey)
+	}
+	return pks
+}
@@ -0,0 +1,85 @@
+package skymodules
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// TestSignedHostAllowlist is a unit test for SignHostAllowlist and Verify.
+func TestSignedHostAllowlist(t *testing.T) {
+	t.Parallel()
+
+	sk, pk := crypto.GenerateKeyPair()
+	spk := types.Ed25519PublicKey(pk)
+	_, hostPK := crypto.GenerateKeyPair()
+	al := HostAllowlist{
+		Revision: 2,
+		Hosts: []HostAllowlistEntry{{
+			PublicKey: types.Ed25519PublicKey(hostPK),
+			Metadata:  map[string]string{"name": "host1"},
+		}},
+	}
+
+	// Sign and verify the list, also after a round trip through JSON.
+	sal, err := SignHostAllowlist(al, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	salBytes, err := json.Marshal(sal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sal2 SignedHostAllowlist
+	if err := json.Unmarshal(salBytes, &sal2); err != nil {
+		t.Fatal(err)
+	}
+	verified, err := sal2.Verify(spk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(verified, al) {
+		t.Fatal("wrong allow-list", verified)
+	}
+	if pks := verified.PublicKeys(); len(pks) != 1 || !pks[0].Equals(al.Hosts[0].PublicKey) {
+		t.Fatal("wrong public keys", pks)
+	}
+
+	// Different key.
+	_, pk2 := crypto.GenerateKeyPair()
+	_, err = sal.Verify(types.Ed25519PublicKey(pk2))
+	if !errors.Contains(err, ErrHostAllowlistInvalidSignature) {
+		t.Fatal("wrong error", err)
+	}
+
+	// Tampered list.
+	tampered := sal
+	tampered.Allowlist = append(json.RawMessage{}, sal.Allowlist...)
+	tampered.Allowlist[len(tampered.Allowlist)-2]++
+	_, err = tampered.Verify(spk)
+	if !errors.Contains(err, ErrHostAllowlistInvalidSignature) {
+		t.Fatal("wrong error", err)
+	}
+
+	// Malformed signature.
+	tampered = sal
+	tampered.Signature = "abc"
+	_, err = tampered.Verify(spk)
+	if !errors.Contains(err, ErrHostAllowlistInvalidSignature) {
+		t.Fatal("wrong error", err)
+	}
+
+	// Empty list.
+	sal, err = SignHostAllowlist(HostAllowlist{Revision: 3}, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = sal.Verify(spk)
+	if !errors.Contains(err, ErrHostAllowlistNoHosts) {
+		t.Fatal("wrong error", err)
+	}
+}
@@ -0,0 +1,49 @@
+package skymodules
+
+import (
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+var (
+	// ErrMaintenanceJobNotFound is returned if a maintenance job with the
+	// given name doesn't exist.
+	ErrMaintenanceJobNotFound = errors.New("maintenance job not found")
+
+	// ErrInvalidMaintenanceInterval is returned if the interval or jitter of
+	// a maintenance job is invalid.
+	ErrInvalidMaintenanceInterval = errors.New("invalid maintenance interval")
+)
+
+// MaintenanceJobStatus contains the status of a maintenance job which runs
+// periodically in the background.
+type MaintenanceJobStatus struct {
+	Name string `json:"name"`
+
+	// Interval is the time between two runs of the job. Jitter is the
+	// fraction of the interval by which a run is randomly moved forward or
+	// backward.
+	Interval time.Duration `json:"interval"`
+	Jitter   float64       `json:"jitter"`
+
+	Paused  bool `json:"paused"`
+	Running bool `json:"running"`
+
+	LastRun         time.Time     `json:"lastrun"`
+	LastRunDuration time.Duration `json:"lastrunduration"`
+	NextRun         time.Time     `json:"nextrun"`
+	Runs            uint64        `json:"runs"`
+}
+
+// ValidateMaintenanceInterval returns an error if the given interval and
+// jitter can't be used for a maintenance job.
+func ValidateMaintenanceInterval(interval time.Duration, jitter float64) error {
+	if interval <= 0 {
+		return errors.AddContext(ErrInvalidMaintenanceInterval, "interval must be positive")
+	}
+	if jitter < 0 || jitter >= 1 {
+		return errors.AddContext(ErrInvalidMaintenanceInterval, "jitter must be in the range [0, 1)")
+	}
+	return nil
+}
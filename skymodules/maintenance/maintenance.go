@@ -0,0 +1,254 @@
+package maintenance
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/NebulousLabs/threadgroup"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+var (
+	// errJobExists is returned when a job is registered twice.
+	errJobExists = errors.New("maintenance job already exists")
+)
+
+type (
+	// Scheduler runs named maintenance jobs periodically. The interval of a
+	// job can be changed and a job can be paused and resumed at runtime.
+	Scheduler struct {
+		jobs map[string]*job
+		mu   sync.Mutex
+		tg   threadgroup.ThreadGroup
+	}
+
+	// Job describes a maintenance job which is registered with the
+	// scheduler.
+	Job struct {
+		// Name is the unique name of the job.
+		Name string
+
+		// Interval is the time between two runs of the job. Jitter is the
+		// fraction of the interval by which a run is randomly moved forward
+		// or backward to avoid jobs running in lockstep.
+		Interval time.Duration
+		Jitter   float64
+
+		// Immediate indicates whether the job should run right after it was
+		// registered instead of after its first interval.
+		Immediate bool
+
+		// Fn is the function which is called on every run.
+		Fn func()
+	}
+
+	// job is the state of a registered job.
+	job struct {
+		staticName string
+		staticFn   func()
+
+		interval time.Duration
+		jitter   float64
+		paused   bool
+		running  bool
+
+		lastRun         time.Time
+		lastRunDuration time.Duration
+		nextRun         time.Time
+		runs            uint64
+
+		// wakeChan is closed to make the job's thread reconsider its next
+		// run after the job was paused, resumed or rescheduled.
+		wakeChan chan struct{}
+	}
+)
+
+// New creates a new scheduler.
+func New() *Scheduler {
+	return &Scheduler{
+		jobs: make(map[string]*job),
+	}
+}
+
+// Close stops the scheduler and waits for running jobs to finish.
+func (s *Scheduler) Close() error {
+	return s.tg.Stop()
+}
+
+// Register registers a job with the scheduler and starts running it.
+func (s *Scheduler) Register(j Job) error {
+	if err := skymodules.ValidateMaintenanceInterval(j.Interval, j.Jitter); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.jobs[j.Name]; exists {
+		return errors.AddContext(errJobExists, j.Name)
+	}
+	sj := &job{
+		staticName: j.Name,
+		staticFn:   j.Fn,
+		interval:   j.Interval,
+		jitter:     j.Jitter,
+		wakeChan:   make(chan struct{}),
+	}
+	sj.nextRun = time.Now()
+	if !j.Immediate {
+		sj.nextRun = sj.nextRun.Add(jitteredInterval(j.Interval, j.Jitter))
+	}
+	err := s.tg.Launch(func() {
+		s.threadedRunJob(sj)
+	})
+	if err != nil {
+		return err
+	}
+	s.jobs[j.Name] = sj
+	return nil
+}
+
+// Pause pauses the job with the given name. A running job finishes its current
+// run.
+func (s *Scheduler) Pause(name string) error {
+	return s.managedUpdate(name, func(j *job) {
+		j.paused = true
+	})
+}
+
+// Resume resumes the job with the given name. The next run is scheduled one
+// interval after the job was resumed.
+func (s *Scheduler) Resume(name string) error {
+	return s.managedUpdate(name, func(j *job) {
+		if !j.paused {
+			return
+		}
+		j.paused = false
+		j.nextRun = time.Now().Add(jitteredInterval(j.interval, j.jitter))
+	})
+}
+
+// SetInterval changes the interval and jitter of the job with the given name.
+// The next run is rescheduled relative to the last run.
+func (s *Scheduler) SetInterval(name string, interval time.Duration, jitter float64) error {
+	if err := skymodules.ValidateMaintenanceInterval(interval, jitter); err != nil {
+		return err
+	}
+	return s.managedUpdate(name, func(j *job) {
+		j.interval = interval
+		j.jitter = jitter
+		last := j.lastRun
+		if last.IsZero() {
+			last = time.Now()
+		}
+		j.nextRun = last.Add(jitteredInterval(interval, jitter))
+	})
+}
+
+// Status returns the status of all jobs sorted by name.
+func (s *Scheduler) Status() []skymodules.MaintenanceJobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make([]skymodules.MaintenanceJobStatus, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		status := skymodules.MaintenanceJobStatus{
+			Name:            j.staticName,
+			Interval:        j.interval,
+			Jitter:          j.jitter,
+			Paused:          j.paused,
+			Running:         j.running,
+			LastRun:         j.lastRun,
+			LastRunDuration: j.lastRunDuration,
+			Runs:            j.runs,
+		}
+		if !j.paused {
+			status.NextRun = j.nextRun
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, k int) bool {
+		return statuses[i].Name < statuses[k].Name
+	})
+	return statuses
+}
+
+// managedUpdate applies the given update to the job with the given name and
+// wakes up the job's thread.
+func (s *Scheduler) managedUpdate(name string, update func(*job)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, exists := s.jobs[name]
+	if !exists {
+		return errors.AddContext(skymodules.ErrMaintenanceJobNotFound, name)
+	}
+	update(j)
+	close(j.wakeChan)
+	j.wakeChan = make(chan struct{})
+	return nil
+}
+
+// threadedRunJob runs the given job whenever it is due until the scheduler is
+// stopped.
+func (s *Scheduler) threadedRunJob(j *job) {
+	for {
+		s.mu.Lock()
+		paused := j.paused
+		nextRun := j.nextRun
+		wakeChan := j.wakeChan
+		s.mu.Unlock()
+
+		// Wait until the job is due. Paused jobs wait to be resumed.
+		var timer *time.Timer
+		var timerChan <-chan time.Time
+		if !paused {
+			timer = time.NewTimer(time.Until(nextRun))
+			timerChan = timer.C
+		}
+		due := false
+		select {
+		case <-s.tg.StopChan():
+		case <-wakeChan:
+		case <-timerChan:
+			due = true
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		select {
+		case <-s.tg.StopChan():
+			return
+		default:
+		}
+		if !due {
+			continue
+		}
+
+		s.mu.Lock()
+		j.running = true
+		s.mu.Unlock()
+
+		start := time.Now()
+		j.staticFn()
+		end := time.Now()
+
+		s.mu.Lock()
+		j.running = false
+		j.lastRun = start
+		j.lastRunDuration = end.Sub(start)
+		j.nextRun = end.Add(jitteredInterval(j.interval, j.jitter))
+		j.runs++
+		s.mu.Unlock()
+	}
+}
+
+// jitteredInterval returns the given interval randomly moved forward or
+// backward by up to the given fraction of it.
+func jitteredInterval(interval time.Duration, jitter float64) time.Duration {
+	maxJitter := uint64(float64(interval) * jitter)
+	if maxJitter == 0 {
+		return interval
+	}
+	offset := time.Duration(fastrand.Uint64n(2*maxJitter+1)) - time.Duration(maxJitter)
+	return interval + offset
+}
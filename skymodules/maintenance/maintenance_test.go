@@ -0,0 +1,123 @@
+package maintenance
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+// TestJitteredInterval is a unit test for jitteredInterval.
+func TestJitteredInterval(t *testing.T) {
+	t.Parallel()
+
+	if d := jitteredInterval(time.Second, 0); d != time.Second {
+		t.Fatal("interval without jitter should be unchanged", d)
+	}
+	for i := 0; i < 1000; i++ {
+		d := jitteredInterval(time.Second, 0.1)
+		if d < 900*time.Millisecond || d > 1100*time.Millisecond {
+			t.Fatal("jittered interval out of bounds", d)
+		}
+	}
+}
+
+// TestScheduler tests running, pausing, resuming and rescheduling jobs.
+func TestScheduler(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	s := New()
+	defer func() {
+		if err := s.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	var runs uint64
+	job := Job{
+		Name:      "test",
+		Interval:  10 * time.Millisecond,
+		Jitter:    0.1,
+		Immediate: true,
+		Fn: func() {
+			atomic.AddUint64(&runs, 1)
+		},
+	}
+	if err := s.Register(job); err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid and duplicate jobs are rejected.
+	if err := s.Register(job); !errors.Contains(err, errJobExists) {
+		t.Fatal("expected duplicate job error", err)
+	}
+	invalid := job
+	invalid.Name = "invalid"
+	invalid.Interval = 0
+	if err := s.Register(invalid); !errors.Contains(err, skymodules.ErrInvalidMaintenanceInterval) {
+		t.Fatal("expected invalid interval error", err)
+	}
+
+	// The job should run repeatedly.
+	err := build.Retry(100, 10*time.Millisecond, func() error {
+		if n := atomic.LoadUint64(&runs); n < 3 {
+			return fmt.Errorf("job ran %v times", n)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pause the job. Once a potential run finished, the job shouldn't run
+	// anymore.
+	if err := s.Pause("test"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	paused := atomic.LoadUint64(&runs)
+	time.Sleep(50 * time.Millisecond)
+	if n := atomic.LoadUint64(&runs); n != paused {
+		t.Fatal("paused job ran", n, paused)
+	}
+	status := s.Status()
+	if len(status) != 1 || !status[0].Paused || !status[0].NextRun.IsZero() || status[0].Runs != paused || status[0].LastRun.IsZero() {
+		t.Fatalf("unexpected status %+v", status)
+	}
+
+	// Change the interval while paused and resume the job.
+	if err := s.SetInterval("test", 20*time.Millisecond, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Resume("test"); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 10*time.Millisecond, func() error {
+		if n := atomic.LoadUint64(&runs); n < paused+2 {
+			return fmt.Errorf("job ran %v times", n)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status = s.Status()
+	if status[0].Paused || status[0].Interval != 20*time.Millisecond || status[0].Jitter != 0 || status[0].NextRun.IsZero() {
+		t.Fatalf("unexpected status %+v", status)
+	}
+
+	// Unknown jobs return an error.
+	if err := s.Pause("unknown"); !errors.Contains(err, skymodules.ErrMaintenanceJobNotFound) {
+		t.Fatal("expected job not found error", err)
+	}
+	if err := s.SetInterval("test", time.Second, 1); !errors.Contains(err, skymodules.ErrInvalidMaintenanceInterval) {
+		t.Fatal("expected invalid interval error", err)
+	}
+}
@@ -0,0 +1,111 @@
+package skymodules
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// OverdriveStrategyNotSpecified indicates that no strategy was specified
+	// and the renter's default should be used.
+	OverdriveStrategyNotSpecified = OverdriveStrategy("")
+	// OverdriveStrategyConservative only launches as many workers as needed to
+	// complete the download and waits a bit longer than the slowest worker is
+	// expected to take before launching overdrive workers.
+	OverdriveStrategyConservative = OverdriveStrategy("conservative")
+	// OverdriveStrategyBalanced launches 20% more workers than needed and
+	// launches an overdrive worker as soon as the slowest worker is late.
+	OverdriveStrategyBalanced = OverdriveStrategy("balanced")
+	// OverdriveStrategyAggressive immediately launches a fixed number of
+	// overdrive workers on top of the workers needed to complete the download.
+	OverdriveStrategyAggressive = OverdriveStrategy("aggressive")
+	// OverdriveStrategyLatencyTarget behaves like the balanced strategy but
+	// also launches an overdrive worker as soon as the download is expected to
+	// exceed the latency target.
+	OverdriveStrategyLatencyTarget = OverdriveStrategy("latency-target")
+)
+
+const (
+	// DefaultOverdriveLatencyTarget is the latency target used by the
+	// latency-target strategy if none was specified.
+	DefaultOverdriveLatencyTarget = 500 * time.Millisecond
+)
+
+var (
+	// ErrUnknownOverdriveStrategy is returned when an unknown overdrive
+	// strategy is specified.
+	ErrUnknownOverdriveStrategy = fmt.Errorf("unknown overdrive strategy, allowed values are: '%v', '%v', '%v' and '%v'", OverdriveStrategyConservative, OverdriveStrategyBalanced, OverdriveStrategyAggressive, OverdriveStrategyLatencyTarget)
+
+	// ErrDownloadCostCapExceeded is returned when a download can't complete
+	// without launching overdrive workers that would exceed its max cost.
+	ErrDownloadCostCapExceeded = errors.New("download would exceed its max cost")
+)
+
+type (
+	// OverdriveStrategy describes how eagerly the download code launches
+	// overdrive workers to avoid slow hosts holding back a download.
+	OverdriveStrategy string
+
+	// OverdriveSettings are the settings of the overdrive code which can be set
+	// globally through the renter settings or per download. MaxCost caps the
+	// expected cost of the workers launched for a chunk, no overdrive workers
+	// are launched that would exceed it. A MaxCost of zero means no cap.
+	OverdriveSettings struct {
+		Strategy      OverdriveStrategy `json:"strategy"`
+		LatencyTarget time.Duration     `json:"latencytarget"`
+		MaxCost       types.Currency    `json:"maxcost"`
+	}
+)
+
+// ParseOverdriveStrategy parses an overdrive strategy from a string. An empty
+// string results in OverdriveStrategyNotSpecified.
+func ParseOverdriveStrategy(s string) (OverdriveStrategy, error) {
+	strategy := OverdriveStrategy(strings.ToLower(s))
+	switch strategy {
+	case OverdriveStrategyNotSpecified:
+	case OverdriveStrategyConservative:
+	case OverdriveStrategyBalanced:
+	case OverdriveStrategyAggressive:
+	case OverdriveStrategyLatencyTarget:
+	default:
+		return "", ErrUnknownOverdriveStrategy
+	}
+	return strategy, nil
+}
+
+// Validate checks the overdrive settings for validity.
+func (ods OverdriveSettings) Validate() error {
+	if _, err := ParseOverdriveStrategy(string(ods.Strategy)); err != nil {
+		return err
+	}
+	if ods.LatencyTarget < 0 {
+		return errors.New("overdrive latency target can't be negative")
+	}
+	return nil
+}
+
+// Merge returns the settings with all unspecified fields replaced by the
+// fields of the defaults. If neither specify a strategy, the balanced strategy
+// is used.
+func (ods OverdriveSettings) Merge(defaults OverdriveSettings) OverdriveSettings {
+	if ods.Strategy == OverdriveStrategyNotSpecified {
+		ods.Strategy = defaults.Strategy
+	}
+	if ods.Strategy == OverdriveStrategyNotSpecified {
+		ods.Strategy = OverdriveStrategyBalanced
+	}
+	if ods.LatencyTarget == 0 {
+		ods.LatencyTarget = defaults.LatencyTarget
+	}
+	if ods.LatencyTarget == 0 {
+		ods.LatencyTarget = DefaultOverdriveLatencyTarget
+	}
+	if ods.MaxCost.IsZero() {
+		ods.MaxCost = defaults.MaxCost
+	}
+	return ods
+}
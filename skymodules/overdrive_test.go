@@ -0,0 +1,55 @@
+package skymodules
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/types"
+)
+
+// TestOverdriveSettings is a unit test for the OverdriveSettings.
+func TestOverdriveSettings(t *testing.T) {
+	t.Parallel()
+
+	// Parse the strategies.
+	for _, s := range []string{"", "conservative", "Balanced", "AGGRESSIVE", "latency-target"} {
+		if _, err := ParseOverdriveStrategy(s); err != nil {
+			t.Fatal(s, err)
+		}
+	}
+	if _, err := ParseOverdriveStrategy("reckless"); err != ErrUnknownOverdriveStrategy {
+		t.Fatal("wrong error", err)
+	}
+
+	// Validate the settings.
+	if err := (OverdriveSettings{Strategy: "reckless"}).Validate(); err == nil {
+		t.Fatal("should fail")
+	}
+	if err := (OverdriveSettings{LatencyTarget: -time.Second}).Validate(); err == nil {
+		t.Fatal("should fail")
+	}
+
+	// Merge the settings.
+	ods := OverdriveSettings{}.Merge(OverdriveSettings{})
+	if ods.Strategy != OverdriveStrategyBalanced || ods.LatencyTarget != DefaultOverdriveLatencyTarget {
+		t.Fatal("wrong defaults", ods)
+	}
+	defaults := OverdriveSettings{
+		Strategy:      OverdriveStrategyAggressive,
+		LatencyTarget: time.Second,
+		MaxCost:       types.SiacoinPrecision,
+	}
+	ods = OverdriveSettings{}.Merge(defaults)
+	if !reflect.DeepEqual(ods, defaults) {
+		t.Fatal("wrong settings", ods)
+	}
+	ods = OverdriveSettings{Strategy: OverdriveStrategyLatencyTarget}.Merge(defaults)
+	if ods.Strategy != OverdriveStrategyLatencyTarget || ods.LatencyTarget != time.Second {
+		t.Fatal("wrong settings", ods)
+	}
+	ods = OverdriveSettings{MaxCost: types.SiacoinPrecision.Mul64(2)}.Merge(defaults)
+	if !ods.MaxCost.Equals(types.SiacoinPrecision.Mul64(2)) {
+		t.Fatal("wrong max cost", ods.MaxCost)
+	}
+}
@@ -0,0 +1,145 @@
+package skymodules
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/persist"
+)
+
+const (
+	// PersistTempSuffix is the suffix of the temporary copy of a persisted
+	// object. It matches the suffix used by the persist package to remain
+	// compatible with objects written by persist.SaveJSON.
+	PersistTempSuffix = "_temp"
+)
+
+var (
+	// ErrPersistObjectNotFound is returned by a PersistBackend if the
+	// requested object doesn't exist.
+	ErrPersistObjectNotFound = errors.New("persisted object not found")
+)
+
+type (
+	// PersistBackend is a storage backend for persisted objects. It allows for
+	// storing the renter's persistence somewhere else than the local disk,
+	// e.g. in an object store for portals whose local disk is ephemeral.
+	// Object names are slash separated paths relative to the root of the
+	// backend.
+	PersistBackend interface {
+		// DeleteObject deletes the object with the given name. Deleting an
+		// object which doesn't exist is not an error.
+		DeleteObject(name string) error
+
+		// ListObjects returns the names of all objects with the given prefix.
+		ListObjects(prefix string) ([]string, error)
+
+		// ReadObject returns the data of the object with the given name or
+		// ErrPersistObjectNotFound if it doesn't exist.
+		ReadObject(name string) ([]byte, error)
+
+		// WriteObject atomically replaces the object with the given name.
+		WriteObject(name string, data []byte) error
+	}
+)
+
+// SavePersistJSON saves a json object to the backend. The format matches the
+// one of persist.SaveJSON, so objects can be moved between the local disk and
+// other backends.
+func SavePersistJSON(b PersistBackend, meta persist.Metadata, object interface{}, name string) error {
+	data, err := MarshalPersistJSON(meta, object)
+	if err != nil {
+		return err
+	}
+	return b.WriteObject(name, data)
+}
+
+// MarshalPersistJSON encodes a json object in the format of SavePersistJSON.
+// It allows for taking a snapshot of an object while holding a lock and
+// writing it to a backend after releasing it.
+func MarshalPersistJSON(meta persist.Metadata, object interface{}) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	if err := enc.Encode(meta.Header); err != nil {
+		return nil, errors.AddContext(err, "unable to encode metadata header")
+	}
+	if err := enc.Encode(meta.Version); err != nil {
+		return nil, errors.AddContext(err, "unable to encode metadata version")
+	}
+	objBytes, err := json.MarshalIndent(object, "", "\t")
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to marshal the provided object")
+	}
+	if err := enc.Encode(crypto.HashBytes(objBytes)); err != nil {
+		return nil, errors.AddContext(err, "unable to encode checksum")
+	}
+	buf.Write(objBytes)
+	return buf.Bytes(), nil
+}
+
+// LoadPersistJSON loads a json object saved by SavePersistJSON or
+// persist.SaveJSON from the backend. If the object is corrupted, its temporary
+// copy is loaded instead. ErrPersistObjectNotFound is returned if the object
+// doesn't exist.
+func LoadPersistJSON(b PersistBackend, meta persist.Metadata, object interface{}, name string) error {
+	err := loadPersistJSON(b, meta, object, name)
+	if err == nil || errors.Contains(err, ErrPersistObjectNotFound) || errors.Contains(err, persist.ErrBadHeader) || errors.Contains(err, persist.ErrBadVersion) {
+		return err
+	}
+	tempErr := loadPersistJSON(b, meta, object, name+PersistTempSuffix)
+	if tempErr != nil {
+		return errors.AddContext(errors.Compose(err, tempErr), "unable to read persisted json object")
+	}
+	return nil
+}
+
+// loadPersistJSON loads a single json object from the backend and verifies
+// its metadata and checksum.
+func loadPersistJSON(b PersistBackend, meta persist.Metadata, object interface{}, name string) error {
+	data, err := b.ReadObject(name)
+	if err != nil {
+		return err
+	}
+
+	// Read the metadata.
+	var header, version string
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(&header); err != nil {
+		return errors.AddContext(err, "unable to read header from persisted json object")
+	}
+	if header != meta.Header {
+		return persist.ErrBadHeader
+	}
+	if err := dec.Decode(&version); err != nil {
+		return errors.AddContext(err, "unable to read version from persisted json object")
+	}
+	if version != meta.Version {
+		return persist.ErrBadVersion
+	}
+	remainingBytes := data[dec.InputOffset():]
+
+	// Verify the checksum. Like persist.LoadJSON, a manual checksum and no
+	// checksum at all are accepted as well. The remaining bytes start with the
+	// newline following the version.
+	if len(remainingBytes) >= 67 {
+		var checksum crypto.Hash
+		if json.Unmarshal(remainingBytes[:67], &checksum) == nil {
+			if checksum != crypto.HashBytes(remainingBytes[68:]) {
+				return errors.New("loading an object with a bad checksum")
+			}
+			return json.Unmarshal(remainingBytes[68:], object)
+		}
+	}
+	if len(remainingBytes) >= 9 {
+		var manualChecksum string
+		if json.Unmarshal(remainingBytes[:9], &manualChecksum) == nil {
+			if manualChecksum != "manual" {
+				return errors.New("loading an object with a bad checksum")
+			}
+			return json.Unmarshal(remainingBytes[10:], object)
+		}
+	}
+	return json.Unmarshal(remainingBytes, object)
+}
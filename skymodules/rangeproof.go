@@ -0,0 +1,148 @@
+package skymodules
+
+import (
+	"encoding/base64"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// sectorRangeProofVersion is the version of the encoding of a
+	// SectorRangeProof.
+	sectorRangeProofVersion = 1
+)
+
+var (
+	// ErrInvalidRangeProof is returned if a range proof doesn't prove that its
+	// data is part of the sector it claims to be part of.
+	ErrInvalidRangeProof = errors.New("invalid range proof")
+
+	// ErrRangeProofRootMismatch is returned if a range proof is verified
+	// against a different root than the one it was created for.
+	ErrRangeProofRootMismatch = errors.New("range proof was created for a different sector root")
+
+	// ErrRangeProofOutsideSkylink is returned if a range proof is verified
+	// against a skylink but the proven range lies outside of the data that
+	// the skylink points to.
+	ErrRangeProofOutsideSkylink = errors.New("range proof covers data outside of the skylink")
+)
+
+// SectorRangeProof is a portable proof that a segment aligned range of data is
+// part of a sector. It contains everything that is needed to verify the data
+// against the sector's merkle root without having to contact a host or a
+// renter, which allows third parties to audit served data offline.
+type SectorRangeProof struct {
+	// Root is the merkle root of the sector which contains the data.
+	Root crypto.Hash `json:"root"`
+
+	// Offset is the offset of the data within the sector.
+	Offset uint64 `json:"offset"`
+
+	// Data is the proven data.
+	Data []byte `json:"data"`
+
+	// Proof contains the hashes which are required to compute the root of
+	// the sector from the data.
+	Proof []crypto.Hash `json:"proof"`
+}
+
+// sectorRangeProofBlob is the versioned encoding of a SectorRangeProof.
+type sectorRangeProofBlob struct {
+	Version uint8
+	Proof   SectorRangeProof
+}
+
+// NewSectorRangeProof creates a range proof for the given range of a sector.
+// Both the offset and length need to be segment aligned.
+func NewSectorRangeProof(sector []byte, offset, length uint64) (SectorRangeProof, error) {
+	if uint64(len(sector)) != modules.SectorSize {
+		return SectorRangeProof{}, errors.New("sector has the wrong size")
+	}
+	if err := checkRangeProofRange(offset, length); err != nil {
+		return SectorRangeProof{}, err
+	}
+	start, end := offset/crypto.SegmentSize, (offset+length)/crypto.SegmentSize
+	return SectorRangeProof{
+		Root:   crypto.MerkleRoot(sector),
+		Offset: offset,
+		Data:   append([]byte{}, sector[offset:offset+length]...),
+		Proof:  crypto.MerkleRangeProof(sector, int(start), int(end)),
+	}, nil
+}
+
+// DecodeSectorRangeProof decodes a range proof which was encoded using
+// EncodeString.
+func DecodeSectorRangeProof(s string) (SectorRangeProof, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return SectorRangeProof{}, errors.AddContext(err, "failed to decode base64")
+	}
+	var blob sectorRangeProofBlob
+	if err := encoding.Unmarshal(b, &blob); err != nil {
+		return SectorRangeProof{}, errors.AddContext(err, "failed to unmarshal range proof")
+	}
+	if blob.Version != sectorRangeProofVersion {
+		return SectorRangeProof{}, errors.New("unknown range proof version")
+	}
+	return blob.Proof, nil
+}
+
+// EncodeString encodes the range proof into a portable string.
+func (p SectorRangeProof) EncodeString() string {
+	b := encoding.Marshal(sectorRangeProofBlob{
+		Version: sectorRangeProofVersion,
+		Proof:   p,
+	})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Verify verifies that the proven data is part of the sector with the given
+// root.
+func (p SectorRangeProof) Verify(root crypto.Hash) error {
+	if p.Root != root {
+		return ErrRangeProofRootMismatch
+	}
+	length := uint64(len(p.Data))
+	if err := checkRangeProofRange(p.Offset, length); err != nil {
+		return errors.Compose(err, ErrInvalidRangeProof)
+	}
+	start, end := p.Offset/crypto.SegmentSize, (p.Offset+length)/crypto.SegmentSize
+	if !crypto.VerifyRangeProof(p.Data, p.Proof, int(start), int(end), root) {
+		return ErrInvalidRangeProof
+	}
+	return nil
+}
+
+// VerifySkylink verifies that the proven data is part of the base sector of
+// the given v1 skylink and that it lies within the data the skylink points to.
+func (p SectorRangeProof) VerifySkylink(sl Skylink) error {
+	if !sl.IsSkylinkV1() {
+		return errors.New("range proofs can only be verified against v1 skylinks")
+	}
+	offset, fetchSize, err := sl.OffsetAndFetchSize()
+	if err != nil {
+		return errors.AddContext(err, "failed to parse skylink")
+	}
+	if p.Offset < offset || p.Offset+uint64(len(p.Data)) > offset+fetchSize {
+		return ErrRangeProofOutsideSkylink
+	}
+	return p.Verify(sl.MerkleRoot())
+}
+
+// checkRangeProofRange checks that a range is a valid, non-empty and segment
+// aligned range within a sector.
+func checkRangeProofRange(offset, length uint64) error {
+	if length == 0 {
+		return errors.New("range is empty")
+	}
+	if offset%crypto.SegmentSize != 0 || length%crypto.SegmentSize != 0 {
+		return errors.New("range is not segment aligned")
+	}
+	if offset+length < offset || offset+length > modules.SectorSize {
+		return errors.New("range is out of bounds")
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package skymodules
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestSectorRangeProof is a unit test for creating, encoding and verifying
+// sector range proofs.
+func TestSectorRangeProof(t *testing.T) {
+	t.Parallel()
+
+	sector := fastrand.Bytes(int(modules.SectorSize))
+	root := crypto.MerkleRoot(sector)
+
+	// Create a proof and verify it after encoding and decoding it.
+	offset, length := uint64(crypto.SegmentSize*10), uint64(crypto.SegmentSize*5)
+	proof, err := NewSectorRangeProof(sector, offset, length)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeSectorRangeProof(proof.EncodeString())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := decoded.Verify(root); err != nil {
+		t.Fatal(err)
+	}
+
+	// Verify against a different root.
+	if err := decoded.Verify(crypto.Hash{1}); !errors.Contains(err, ErrRangeProofRootMismatch) {
+		t.Fatal("wrong error", err)
+	}
+
+	// Tamper with the data.
+	tampered := decoded
+	tampered.Data = append([]byte{}, decoded.Data...)
+	tampered.Data[0]++
+	if err := tampered.Verify(root); !errors.Contains(err, ErrInvalidRangeProof) {
+		t.Fatal("wrong error", err)
+	}
+
+	// Claim a different offset.
+	tampered = decoded
+	tampered.Offset += crypto.SegmentSize
+	if err := tampered.Verify(root); !errors.Contains(err, ErrInvalidRangeProof) {
+		t.Fatal("wrong error", err)
+	}
+
+	// Unaligned data.
+	tampered = decoded
+	tampered.Data = decoded.Data[1:]
+	if err := tampered.Verify(root); !errors.Contains(err, ErrInvalidRangeProof) {
+		t.Fatal("wrong error", err)
+	}
+
+	// Unaligned and out of bounds ranges can't be proven.
+	if _, err := NewSectorRangeProof(sector, 1, crypto.SegmentSize); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := NewSectorRangeProof(sector, modules.SectorSize, crypto.SegmentSize); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// Invalid blobs can't be decoded.
+	if _, err := DecodeSectorRangeProof("invalid"); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// TestSectorRangeProofVerifySkylink is a unit test for
+// SectorRangeProof.VerifySkylink.
+func TestSectorRangeProofVerifySkylink(t *testing.T) {
+	t.Parallel()
+
+	sector := fastrand.Bytes(int(modules.SectorSize))
+	root := crypto.MerkleRoot(sector)
+	skylink, err := NewSkylinkV1(root, 0, 1<<12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A proof within the skylink's data is valid.
+	proof, err := NewSectorRangeProof(sector, crypto.SegmentSize, crypto.SegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := proof.VerifySkylink(skylink); err != nil {
+		t.Fatal(err)
+	}
+
+	// A proof for data outside of the skylink's data is not.
+	outside := proof
+	outside.Offset = 1 << 12
+	if err := outside.VerifySkylink(skylink); !errors.Contains(err, ErrRangeProofOutsideSkylink) {
+		t.Fatal("wrong error", err)
+	}
+
+	// V2 skylinks are not supported.
+	if err := proof.VerifySkylink(NewSkylinkV2(types.SiaPublicKey{}, crypto.Hash{})); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"sync"
 	"time"
 
@@ -63,10 +64,22 @@ var (
 	// ErrHostFault indicates if an error is the host's fault.
 	ErrHostFault = errors.New("host has returned an error")
 
+	// ErrLocalFault indicates that an error was caused by the renter itself,
+	// e.g. by failing to sign a transaction, and not by the host.
+	ErrLocalFault = errors.New("renter encountered a local error")
+
+	// ErrNetworkFault indicates that an error was caused by the connection to
+	// the host rather than by the host violating the protocol.
+	ErrNetworkFault = errors.New("network error while communicating with host")
+
 	// ErrDownloadCancelled is the error set when a download was cancelled
 	// manually by the user.
 	ErrDownloadCancelled = errors.New("download was cancelled")
 
+	// ErrMemoryLimitExceeded is returned if a request isn't admitted because
+	// the renter's soft memory limit would be exceeded.
+	ErrMemoryLimitExceeded = errors.New("soft memory limit exceeded, try again later")
+
 	// ErrNotEnoughWorkersInWorkerPool is an error that is returned whenever an
 	// operation expects a certain number of workers but there aren't that many
 	// available.
@@ -112,11 +125,33 @@ type RenterPerformance struct {
 	BaseSectorDownloadOverdriveStats   *DownloadOverdriveStats
 	FanoutSectorDownloadOverdriveStats *DownloadOverdriveStats
 
-	BaseSectorUploadStats *DistributionTrackerStats
-	ChunkUploadStats      *DistributionTrackerStats
-	RegistryReadStats     *DistributionTrackerStats
-	RegistryWriteStats    *DistributionTrackerStats
-	StreamBufferReadStats *DistributionTrackerStats
+	BaseSectorDownloadStats *DistributionTrackerStats
+	BaseSectorUploadStats   *DistributionTrackerStats
+	ChunkUploadStats        *DistributionTrackerStats
+	RegistryReadStats       *DistributionTrackerStats
+	RegistryWriteStats      *DistributionTrackerStats
+	StreamBufferReadStats   *DistributionTrackerStats
+
+	// StreamBufferCancelledBytes is the number of bytes the stream buffer
+	// stopped fetching because streams seeked away from them.
+	StreamBufferCancelledBytes uint64
+
+	// BaseSectorHedges is the number of hedged base sector downloads that
+	// were launched and BaseSectorHedgesWon is the number of them which
+	// finished before the download they were hedging.
+	BaseSectorHedges    uint64
+	BaseSectorHedgesWon uint64
+
+	RegistryCacheStats RegistryCacheStats
+}
+
+// RegistryCacheStats contains information about the renter's local cache of
+// recently read registry entries.
+type RegistryCacheStats struct {
+	Entries       uint64
+	Hits          uint64
+	Misses        uint64
+	Invalidations uint64
 }
 
 // DownloadOverdriveStats is a helper struct that contains information about the
@@ -231,6 +266,10 @@ const (
 	HostDBActiveWhitelist
 )
 
+// ArchiveProfileName is the name of the allowance profile which files that are
+// uploaded in archive mode are stored on.
+const ArchiveProfileName = "archive"
+
 // Filesystem related consts.
 const (
 	// DefaultDirPerm defines the default permissions used for a new dir if no
@@ -281,6 +320,17 @@ func IsHostsFault(err error) bool {
 	return errors.Contains(err, ErrHostFault)
 }
 
+// IsLocalFault indicates if a returned error was caused by the renter itself.
+func IsLocalFault(err error) bool {
+	return errors.Contains(err, ErrLocalFault)
+}
+
+// IsNetworkFault indicates if a returned error was caused by the connection to
+// the host.
+func IsNetworkFault(err error) bool {
+	return errors.Contains(err, ErrNetworkFault)
+}
+
 const (
 	// RenterDir is the name of the directory that is used to store the
 	// renter's persistent data.
@@ -390,6 +440,112 @@ type Allowance struct {
 	MaxSectorAccessPrice      types.Currency `json:"maxsectoraccessprice"`
 	MaxStoragePrice           types.Currency `json:"maxstorageprice"`
 	MaxUploadBandwidthPrice   types.Currency `json:"maxuploadbandwidthprice"`
+
+	// MaxFeeBumpBudget is the maximum amount of money the contractor spends on
+	// bumping the fees of unconfirmed contract transactions at any time. A
+	// value of zero disables fee bumping.
+	MaxFeeBumpBudget types.Currency `json:"maxfeebumpbudget"`
+
+	// Profiles are named sets of hosts which the renter forms contracts with
+	// in addition to the regular hosts. Every profile has its own host
+	// count, price caps and period. Files can be uploaded to the hosts of a
+	// specific profile, which allows for tiered storage within one renter.
+	Profiles map[string]AllowanceProfile `json:"profiles"`
+}
+
+// AllowanceProfile configures a named set of hosts. The hosts of a profile
+// share the funds of the allowance with the regular hosts. Zero values of the
+// period, renew window and the price caps fall back to the values of the
+// allowance.
+type AllowanceProfile struct {
+	// Hosts is the number of hosts the renter forms contracts with for the
+	// profile. A value of zero disables the profile.
+	Hosts uint64 `json:"hosts"`
+
+	// Period and RenewWindow are the length of the contracts of the profile
+	// and the number of blocks before their end at which they are renewed.
+	Period      types.BlockHeight `json:"period"`
+	RenewWindow types.BlockHeight `json:"renewwindow"`
+
+	// ExpectedStorage, ExpectedUpload and ExpectedDownload are the expected
+	// usage of the profile's hosts.
+	ExpectedStorage  uint64 `json:"expectedstorage"`
+	ExpectedUpload   uint64 `json:"expectedupload"`
+	ExpectedDownload uint64 `json:"expecteddownload"`
+
+	// The price caps of the profile. Hosts above any of the caps are not
+	// used for the profile.
+	MaxContractPrice          types.Currency `json:"maxcontractprice"`
+	MaxDownloadBandwidthPrice types.Currency `json:"maxdownloadbandwidthprice"`
+	MaxStoragePrice           types.Currency `json:"maxstorageprice"`
+	MaxUploadBandwidthPrice   types.Currency `json:"maxuploadbandwidthprice"`
+}
+
+// ProfileAllowance returns the allowance used to select, fund and renew the
+// hosts of the profile with the given name. The boolean is false if the
+// allowance doesn't contain such a profile.
+func (a Allowance) ProfileAllowance(name string) (Allowance, bool) {
+	p, exists := a.Profiles[name]
+	if !exists {
+		return Allowance{}, false
+	}
+	profile := a
+	profile.Profiles = nil
+	profile.Hosts = p.Hosts
+	if p.Period != 0 {
+		profile.Period = p.Period
+	}
+	if p.RenewWindow != 0 {
+		profile.RenewWindow = p.RenewWindow
+	}
+	profile.ExpectedStorage = p.ExpectedStorage
+	profile.ExpectedUpload = p.ExpectedUpload
+	profile.ExpectedDownload = p.ExpectedDownload
+	if !p.MaxContractPrice.IsZero() {
+		profile.MaxContractPrice = p.MaxContractPrice
+	}
+	if !p.MaxDownloadBandwidthPrice.IsZero() {
+		profile.MaxDownloadBandwidthPrice = p.MaxDownloadBandwidthPrice
+	}
+	if !p.MaxStoragePrice.IsZero() {
+		profile.MaxStoragePrice = p.MaxStoragePrice
+	}
+	if !p.MaxUploadBandwidthPrice.IsZero() {
+		profile.MaxUploadBandwidthPrice = p.MaxUploadBandwidthPrice
+	}
+	return profile, true
+}
+
+// ProfileNames returns the names of the allowance's profiles in a
+// deterministic order.
+func (a Allowance) ProfileNames() []string {
+	names := make([]string, 0, len(a.Profiles))
+	for name := range a.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ArchiveProfile returns the archive profile of the allowance. The archive
+// profile is only concerned with storage since archive hosts are neither
+// expected to serve many downloads nor to be fast.
+func (a Allowance) ArchiveProfile() AllowanceProfile {
+	return a.Profiles[ArchiveProfileName]
+}
+
+// SetArchiveProfile sets the archive profile of the allowance. The expected
+// upload of the profile is derived from its expected storage.
+func (a *Allowance) SetArchiveProfile(p AllowanceProfile) {
+	p.ExpectedUpload = 0
+	if a.Period > 0 {
+		p.ExpectedUpload = p.ExpectedStorage / uint64(a.Period)
+	}
+	p.ExpectedDownload = 0
+	if a.Profiles == nil {
+		a.Profiles = make(map[string]AllowanceProfile)
+	}
+	a.Profiles[ArchiveProfileName] = p
 }
 
 // Active returns true if and only if this allowance has been set in the
@@ -449,6 +605,24 @@ type ContractWatchStatus struct {
 	DoubleSpendHeight         types.BlockHeight `json:"doublespendheight"`
 	WindowStart               types.BlockHeight `json:"windowstart"`
 	WindowEnd                 types.BlockHeight `json:"windowend"`
+
+	// RenewedFrom is the id of the contract the watched contract was renewed
+	// from. It's empty if the contract wasn't formed by a renewal.
+	RenewedFrom types.FileContractID `json:"renewedfrom"`
+
+	// FeeBump is the fee paid by the watchdog to bump the fee of the
+	// contract's formation transaction set.
+	FeeBump types.Currency `json:"feebump"`
+}
+
+// ContractFeeBump describes a child transaction created by the watchdog to
+// bump the fee of a contract's unconfirmed formation transaction set.
+type ContractFeeBump struct {
+	ContractID types.FileContractID `json:"contractid"`
+	TxnID      types.TransactionID  `json:"txnid"`
+	Fee        types.Currency       `json:"fee"`
+	Height     types.BlockHeight    `json:"height"`
+	Confirmed  bool                 `json:"confirmed"`
 }
 
 // DirectoryInfo provides information about a siadir
@@ -533,9 +707,22 @@ type DownloadInfo struct {
 	Received             uint64    `json:"received"`             // Amount of data confirmed and decoded.
 	StartTime            time.Time `json:"starttime"`            // The time when the download was started.
 	StartTimeUnix        int64     `json:"starttimeunix"`        // The time when the download was started in unix format.
+	StuckChunks          uint64    `json:"stuckchunks"`          // Number of chunks waiting in the download heap for too long.
 	TotalDataTransferred uint64    `json:"totaldatatransferred"` // Total amount of data transferred, including negotiation, etc.
 }
 
+// PendingDownloadChunk is a chunk of a classic download which is waiting in
+// the download heap for memory to become available.
+type PendingDownloadChunk struct {
+	DownloadID   DownloadID    `json:"downloadid"`   // The id of the download the chunk belongs to.
+	SiaPath      SiaPath       `json:"siapath"`      // The siapath of the file used for the download.
+	ChunkIndex   uint64        `json:"chunkindex"`   // The index of the chunk within the file.
+	Priority     uint64        `json:"priority"`     // The priority of the download.
+	Age          time.Duration `json:"age"`          // The time the chunk has been waiting in the heap.
+	MemoryNeeded uint64        `json:"memoryneeded"` // The memory required to download the chunk.
+	Stuck        bool          `json:"stuck"`        // Whether the chunk was waiting in the heap for too long.
+}
+
 // FileUploadParams contains the information used by the Renter to upload a
 // file.
 type FileUploadParams struct {
@@ -553,16 +740,47 @@ type FileUploadParams struct {
 	// to create a CipherKey with the given CipherType. This value override
 	// CipherType if it is set.
 	CipherKey crypto.CipherKey
+
+	// Archive indicates that the file should only be uploaded to the
+	// renter's archive hosts. It is a shorthand for the archive profile.
+	Archive bool
+
+	// Profile is the name of the allowance profile whose hosts the file is
+	// uploaded to. If empty, the file is uploaded to the regular hosts.
+	Profile string
+
+	// AllowedHosts restricts the upload to the given hosts. The file is
+	// neither uploaded to nor repaired on any other host. If empty, the file
+	// may use any host.
+	AllowedHosts []types.SiaPublicKey
+
+	// ExpiryTime is the time after which the file is deleted by the renter.
+	// A zero value means that the file doesn't expire.
+	ExpiryTime time.Time
+}
+
+// UploadProfile returns the name of the allowance profile the file is uploaded
+// to.
+func (fup FileUploadParams) UploadProfile() (string, error) {
+	if !fup.Archive {
+		return fup.Profile, nil
+	}
+	if fup.Profile != "" && fup.Profile != ArchiveProfileName {
+		return "", errors.New("archive can't be combined with a profile other than the archive profile")
+	}
+	return ArchiveProfileName, nil
 }
 
 // FileInfo provides information about a file.
 type FileInfo struct {
 	AccessTime       time.Time         `json:"accesstime"`
+	Archive          bool              `json:"archive"`
 	Available        bool              `json:"available"`
 	ChangeTime       time.Time         `json:"changetime"`
 	CipherType       string            `json:"ciphertype"`
 	CreateTime       time.Time         `json:"createtime"`
 	Expiration       types.BlockHeight `json:"expiration"`
+	ExpiryTime       time.Time         `json:"expirytime"`
 	Filesize         uint64            `json:"filesize"`
 	Health           float64           `json:"health"`
 	LocalPath        string            `json:"localpath"`
@@ -581,9 +799,18 @@ type FileInfo struct {
 	Stuck            bool              `json:"stuck"`
 	StuckBytes       uint64            `json:"stuckbytes"`
 	StuckHealth      float64           `json:"stuckhealth"`
+	TTL              uint64            `json:"ttl"` // remaining seconds until the file expires
 	UID              uint64            `json:"uid"`
 	UploadedBytes    uint64            `json:"uploadedbytes"`
 	UploadProgress   float64           `json:"uploadprogress"`
+
+	// AllowedHosts are the hosts the file is restricted to. If empty, the
+	// file may use any host.
+	AllowedHosts []types.SiaPublicKey `json:"allowedhosts"`
+
+	// Profile is the name of the allowance profile whose hosts store the
+	// file. Empty for files stored on the regular hosts.
+	Profile string `json:"profile"`
 }
 
 // Name implements os.FileInfo.
@@ -627,6 +854,10 @@ type HostDBEntry struct {
 
 	LastHistoricUpdate types.BlockHeight `json:"lasthistoricupdate"`
 
+	// Benchmark contains the most recent results of the benchmark programs
+	// the renter's worker executed on the host.
+	Benchmark HostBenchmark `json:"benchmark"`
+
 	// Measurements related to the IP subnet mask.
 	IPNets          []string  `json:"ipnets"`
 	LastIPNetChange time.Time `json:"lastipnetchange"`
@@ -644,6 +875,52 @@ type HostDBEntry struct {
 	Filtered bool `json:"filtered"`
 }
 
+// SkykeyAutoBackupStatus contains information about the automatic backup of
+// the renter's skykeys. Every time the skykeys change, a password protected
+// bundle of the keys is uploaded and the registry entry is updated to point to
+// it.
+type SkykeyAutoBackupStatus struct {
+	// Enabled indicates whether the automatic backup is enabled.
+	Enabled bool `json:"enabled"`
+
+	// EntryName is the user-chosen name of the registry entry.
+	EntryName string `json:"entryname"`
+
+	// Skylink is the V2 skylink of the registry entry which always resolves
+	// to the latest backup.
+	Skylink string `json:"skylink"`
+
+	// LastBackup is the skylink of the latest backup and LastBackupTime the
+	// time it was created.
+	LastBackup     string    `json:"lastbackup"`
+	LastBackupTime time.Time `json:"lastbackuptime"`
+
+	// RecentErr is the error of the most recent failed backup.
+	RecentErr     string    `json:"recenterr"`
+	RecentErrTime time.Time `json:"recenterrtime"`
+}
+
+// HostBenchmark contains the results of benchmarking a host's program
+// execution. The execution latency and throughput are measured separately from
+// the network round trip time which allows for telling apart hosts with slow
+// disks from hosts which are far away.
+type HostBenchmark struct {
+	// ExecutionLatency is the time it took the host to execute a read
+	// program minus the round trip time.
+	ExecutionLatency time.Duration `json:"executionlatency"`
+
+	// RTT is the round trip time of a minimal program.
+	RTT time.Duration `json:"rtt"`
+
+	// Throughput is the read throughput of the host in bytes per second,
+	// excluding the round trip time.
+	Throughput float64 `json:"throughput"`
+
+	// Timestamp is the time of the benchmark. A zero timestamp indicates that
+	// the host was never benchmarked.
+	Timestamp time.Time `json:"timestamp"`
+}
+
 // HostDBScan represents a single scan event.
 type HostDBScan struct {
 	Timestamp time.Time `json:"timestamp"`
@@ -664,6 +941,7 @@ type HostScoreBreakdown struct {
 	AcceptContractAdjustment   float64 `json:"acceptcontractadjustment"`
 	AgeAdjustment              float64 `json:"ageadjustment"`
 	BasePriceAdjustment        float64 `json:"basepriceadjustment"`
+	BenchmarkAdjustment        float64 `json:"benchmarkadjustment"`
 	BurnAdjustment             float64 `json:"burnadjustment"`
 	CollateralAdjustment       float64 `json:"collateraladjustment"`
 	DurationAdjustment         float64 `json:"durationadjustment"`
@@ -679,12 +957,26 @@ type HostScoreBreakdown struct {
 type MemoryStatus struct {
 	MemoryManagerStatus
 
+	Budget MemoryBudgetStatus `json:"budget"`
+
 	Registry     MemoryManagerStatus `json:"registry"`
 	UserUpload   MemoryManagerStatus `json:"userupload"`
 	UserDownload MemoryManagerStatus `json:"userdownload"`
 	System       MemoryManagerStatus `json:"system"`
 }
 
+// MemoryBudgetStatus contains the status of the renter's soft memory limit
+// which is shared by the memory managers, downloads and upload buffers.
+type MemoryBudgetStatus struct {
+	// Limit is the soft memory limit. 0 means that there is no limit.
+	Limit uint64 `json:"limit"`
+	// Used is the amount of memory currently accounted for.
+	Used uint64 `json:"used"`
+	// Rejected is the number of requests that were rejected since startup
+	// because admitting them would have exceeded the limit.
+	Rejected uint64 `json:"rejected"`
+}
+
 // MemoryManagerStatus contains the memory status of a single memory manager.
 type MemoryManagerStatus struct {
 	Available uint64 `json:"available"`
@@ -735,13 +1027,32 @@ type RenterPriceEstimation struct {
 	UploadTerabyte types.Currency `json:"uploadterabyte"`
 }
 
+// DefaultPriceTableUpdateFraction is the default fraction of a price table's
+// validity after which the workers update it.
+const DefaultPriceTableUpdateFraction = 0.5
+
 // RenterSettings control the behavior of the Renter.
 type RenterSettings struct {
-	Allowance        Allowance     `json:"allowance"`
-	IPViolationCheck bool          `json:"ipviolationcheck"`
-	MaxUploadSpeed   int64         `json:"maxuploadspeed"`
-	MaxDownloadSpeed int64         `json:"maxdownloadspeed"`
-	UploadsStatus    UploadsStatus `json:"uploadsstatus"`
+	Allowance           Allowance           `json:"allowance"`
+	IPViolationCheck    bool                `json:"ipviolationcheck"`
+	MaxUploadSpeed      int64               `json:"maxuploadspeed"`
+	MaxDownloadSpeed    int64               `json:"maxdownloadspeed"`
+	MemoryLimit         uint64              `json:"memorylimit"`
+	Overdrive           OverdriveSettings   `json:"overdrive"`
+	SkyfileUploadLimits SkyfileUploadLimits `json:"skyfileuploadlimits"`
+	TrafficShares       TrafficShares       `json:"trafficshares"`
+	UploadsStatus       UploadsStatus       `json:"uploadsstatus"`
+
+	// FanoutRedundancyPolicy determines how the erasure code parameters of
+	// the fanout of uploaded skyfiles are chosen.
+	FanoutRedundancyPolicy FanoutRedundancyPolicy `json:"fanoutredundancypolicy"`
+
+	// PriceTableUpdateFraction is the fraction of a price table's validity
+	// after which the workers fetch a new price table from their hosts.
+	PriceTableUpdateFraction float64 `json:"pricetableupdatefraction"`
+
+	// BaseSectorHedging controls the hedged fetching of base sectors.
+	BaseSectorHedging HedgingSettings `json:"basesectorhedging"`
 }
 
 // UploadsStatus contains information about the Renter's Uploads
@@ -838,6 +1149,61 @@ type RecoverableContract struct {
 	TxnFee types.Currency `json:"txnfee"`
 }
 
+// ContractRecoveryState describes the state of a contract found by a recovery
+// scan.
+type ContractRecoveryState string
+
+const (
+	// ContractRecoveryStatePending indicates that a contract was found but no
+	// attempt to recover it was made yet.
+	ContractRecoveryStatePending ContractRecoveryState = "pending"
+	// ContractRecoveryStateFailed indicates that the last attempt to recover
+	// a contract failed. The recovery will be retried.
+	ContractRecoveryStateFailed ContractRecoveryState = "failed"
+	// ContractRecoveryStateRecovered indicates that a contract was recovered.
+	ContractRecoveryStateRecovered ContractRecoveryState = "recovered"
+	// ContractRecoveryStateExpired indicates that a contract was found but
+	// not recovered since it already expired.
+	ContractRecoveryStateExpired ContractRecoveryState = "expired"
+)
+
+// ContractRecoveryStatus is the recovery status of a single contract found by
+// a recovery scan.
+type ContractRecoveryStatus struct {
+	ID            types.FileContractID  `json:"id"`
+	HostPublicKey types.SiaPublicKey    `json:"hostpublickey"`
+	State         ContractRecoveryState `json:"state"`
+	// Attempts is the number of times the contractor tried to recover the
+	// contract.
+	Attempts uint64 `json:"attempts"`
+	// Error is the error of the last failed recovery attempt.
+	Error string `json:"error,omitempty"`
+}
+
+// RecoveryScanProgress describes the progress of the most recent scan for
+// recoverable contracts.
+type RecoveryScanProgress struct {
+	// ScanInProgress indicates whether a scan is currently running.
+	ScanInProgress bool `json:"scaninprogress"`
+	// Paused indicates whether the running scan is paused.
+	Paused bool `json:"paused"`
+
+	// StartHeight and EndHeight are the inclusive range of blocks scanned by
+	// the most recent manually initiated scan.
+	StartHeight types.BlockHeight `json:"startheight"`
+	EndHeight   types.BlockHeight `json:"endheight"`
+
+	// BlocksScanned is the number of blocks scanned by the most recent scan.
+	BlocksScanned uint64 `json:"blocksscanned"`
+	// ContractsFound is the number of recoverable contracts found by the
+	// most recent scan.
+	ContractsFound uint64 `json:"contractsfound"`
+
+	// Contracts contains the recovery status of all contracts found since
+	// startup.
+	Contracts []ContractRecoveryStatus `json:"contracts"`
+}
+
 // A RenterContract contains metadata about a file contract. It is read-only;
 // modifying a RenterContract does not modify the actual file contract.
 type RenterContract struct {
@@ -860,6 +1226,11 @@ type RenterContract struct {
 	StorageSpending     types.Currency
 	UploadSpending      types.Currency
 
+	// AccountSpending is the money spent from the ephemeral account with the
+	// contract's host while the contract was active. The account is funded
+	// by the contract, so it is covered by the FundAccountSpending.
+	AccountSpending ContractAccountSpending
+
 	// Utility contains utility information about the renter.
 	Utility ContractUtility
 
@@ -922,6 +1293,40 @@ func (x MaintenanceSpending) Sum() types.Currency {
 	return x.AccountBalanceCost.Add(x.FundAccountCost).Add(x.UpdatePriceTableCost)
 }
 
+// ContractAccountSpending is a helper struct that contains a breakdown of the
+// money spent from an ephemeral account, as reported by the worker of the
+// account's host.
+type ContractAccountSpending struct {
+	DownloadSpending types.Currency `json:"downloadspending"`
+	RegistrySpending types.Currency `json:"registryspending"`
+	UploadSpending   types.Currency `json:"uploadspending"`
+}
+
+// Add is a convenience function that sums the fields of the spending object
+// with the corresponding fields of the given object.
+func (x ContractAccountSpending) Add(y ContractAccountSpending) ContractAccountSpending {
+	return ContractAccountSpending{
+		DownloadSpending: x.DownloadSpending.Add(y.DownloadSpending),
+		RegistrySpending: x.RegistrySpending.Add(y.RegistrySpending),
+		UploadSpending:   x.UploadSpending.Add(y.UploadSpending),
+	}
+}
+
+// ContractSpendingBreakdown is a breakdown of the spending of a contract by
+// category. Download and upload spending include both the money paid directly
+// from the contract and from the ephemeral account with the host. Since the
+// account is funded by the contract, the account spending is also part of the
+// FundAccountSpending.
+type ContractSpendingBreakdown struct {
+	DownloadSpending    types.Currency `json:"downloadspending"`
+	FeeSpending         types.Currency `json:"feespending"`
+	FundAccountSpending types.Currency `json:"fundaccountspending"`
+	MaintenanceSpending types.Currency `json:"maintenancespending"`
+	RegistrySpending    types.Currency `json:"registryspending"`
+	StorageSpending     types.Currency `json:"storagespending"`
+	UploadSpending      types.Currency `json:"uploadspending"`
+}
+
 // Size returns the contract size
 func (rc *RenterContract) Size() uint64 {
 	var size uint64
@@ -931,6 +1336,20 @@ func (rc *RenterContract) Size() uint64 {
 	return size
 }
 
+// SpendingBreakdown returns the breakdown of the contract's spending by
+// category.
+func (rc *RenterContract) SpendingBreakdown() ContractSpendingBreakdown {
+	return ContractSpendingBreakdown{
+		DownloadSpending:    rc.DownloadSpending.Add(rc.AccountSpending.DownloadSpending),
+		FeeSpending:         rc.ContractFee.Add(rc.TxnFee).Add(rc.SiafundFee),
+		FundAccountSpending: rc.FundAccountSpending,
+		MaintenanceSpending: rc.MaintenanceSpending.Sum(),
+		RegistrySpending:    rc.AccountSpending.RegistrySpending,
+		StorageSpending:     rc.StorageSpending,
+		UploadSpending:      rc.UploadSpending.Add(rc.AccountSpending.UploadSpending),
+	}
+}
+
 // SkynetSpending return the sum of all spending of the contract that is subject to
 // skynet fees.
 func (rc *RenterContract) SkynetSpending() (spending types.Currency) {
@@ -1002,6 +1421,93 @@ func (cs ContractorSpending) SpendingBreakdown() (totalSpent, unspentAllocated,
 	return totalSpent, unspentAllocated, unspentUnallocated
 }
 
+// SpendingForecast is a projection of the contractor's spending for the
+// remainder of the current period. The projection extrapolates the spending
+// rates observed since the start of the period and adds the cost of the
+// renewals which are expected to happen before the period ends.
+type SpendingForecast struct {
+	// CurrentSpending is the money spent within the current period so far.
+	CurrentSpending types.Currency `json:"currentspending"`
+	// RemainingFunds are the allowance funds which haven't been spent yet.
+	RemainingFunds types.Currency `json:"remainingfunds"`
+
+	// The following fields are the projected additional spending until the
+	// end of the period for each spending category.
+	ProjectedDownloadSpending    types.Currency `json:"projecteddownloadspending"`
+	ProjectedFundAccountSpending types.Currency `json:"projectedfundaccountspending"`
+	ProjectedMaintenanceSpending types.Currency `json:"projectedmaintenancespending"`
+	ProjectedStorageSpending     types.Currency `json:"projectedstoragespending"`
+	ProjectedUploadSpending      types.Currency `json:"projecteduploadspending"`
+
+	// UpcomingRenewals is the number of contracts which are expected to be
+	// renewed before the end of the period.
+	UpcomingRenewals uint64 `json:"upcomingrenewals"`
+	// ProjectedRenewalSpending is the estimated cost of the upcoming
+	// renewals.
+	ProjectedRenewalSpending types.Currency `json:"projectedrenewalspending"`
+
+	// ProjectedTotalSpending is the projected total spending of the period
+	// including the current spending.
+	ProjectedTotalSpending types.Currency `json:"projectedtotalspending"`
+
+	// PeriodEnd is the height at which the current period ends.
+	PeriodEnd types.BlockHeight `json:"periodend"`
+	// DepletionHeight is the projected height at which the allowance funds
+	// run out. It is only set if InsufficientFunds is true.
+	DepletionHeight types.BlockHeight `json:"depletionheight"`
+	// InsufficientFunds indicates whether the allowance is expected to run
+	// out of funds before the end of the period.
+	InsufficientFunds bool `json:"insufficientfunds"`
+}
+
+// AutoFundSettings are the settings for automatically topping up the
+// allowance from the wallet.
+type AutoFundSettings struct {
+	// Enabled indicates whether the allowance is topped up automatically.
+	Enabled bool `json:"enabled"`
+	// Threshold is the amount of remaining allowance funds below which the
+	// allowance is topped up.
+	Threshold types.Currency `json:"threshold"`
+	// Amount is the amount the allowance is topped up by.
+	Amount types.Currency `json:"amount"`
+	// MaxPerPeriod is the maximum amount the allowance is topped up by within
+	// a single period.
+	MaxPerPeriod types.Currency `json:"maxperperiod"`
+}
+
+// AutoFundEvent is an entry of the audit log of automatic allowance top-ups.
+type AutoFundEvent struct {
+	Timestamp      time.Time         `json:"timestamp"`
+	BlockHeight    types.BlockHeight `json:"blockheight"`
+	Amount         types.Currency    `json:"amount"`
+	OldFunds       types.Currency    `json:"oldfunds"`
+	NewFunds       types.Currency    `json:"newfunds"`
+	RemainingFunds types.Currency    `json:"remainingfunds"`
+}
+
+// AutoFundStatus contains the auto-funding settings as well as the top-ups
+// of the current period and the most recent top-ups.
+type AutoFundStatus struct {
+	Settings AutoFundSettings `json:"settings"`
+	// Period is the start of the period PeriodTopUps refers to.
+	Period       types.BlockHeight `json:"period"`
+	PeriodTopUps types.Currency    `json:"periodtopups"`
+	// Events is the audit log of the most recent top-ups, oldest first.
+	Events []AutoFundEvent `json:"events"`
+}
+
+// ContractDefragReport reports the outcome of removing the sectors without
+// references from a contract.
+type ContractDefragReport struct {
+	ID types.FileContractID `json:"id"`
+	// SectorsRemoved is the number of sectors deleted from the host.
+	SectorsRemoved uint64 `json:"sectorsremoved"`
+	// ReclaimedBytes is the amount of storage freed up on the host.
+	ReclaimedBytes uint64 `json:"reclaimedbytes"`
+	// Cost is the amount spent on the revisions which removed the sectors.
+	Cost types.Currency `json:"cost"`
+}
+
 // ContractorChurnStatus contains the current churn budgets for the Contractor's
 // churnLimiter and the aggregate churn for the current period.
 type ContractorChurnStatus struct {
@@ -1010,6 +1516,27 @@ type ContractorChurnStatus struct {
 	AggregateCurrentPeriodChurn uint64 `json:"aggregatecurrentperiodchurn"`
 	// MaxPeriodChurn is the (adjustable) maximum churn allowed per period.
 	MaxPeriodChurn uint64 `json:"maxperiodchurn"`
+	// RemainingChurnBudget is the churn that is allowed at the moment. It may
+	// be negative.
+	RemainingChurnBudget int64 `json:"remainingchurnbudget"`
+	// RemainingPeriodBudget is the churn that is allowed for the rest of the
+	// period. It may be negative.
+	RemainingPeriodBudget int64 `json:"remainingperiodbudget"`
+	// DeferredChurn is the total size of files from contracts whose churn was
+	// deferred because it exceeded the churn budget.
+	DeferredChurn uint64 `json:"deferredchurn"`
+	// DeferredContracts are the contracts whose churn was deferred.
+	DeferredContracts []DeferredChurnContract `json:"deferredcontracts"`
+}
+
+// DeferredChurnContract is a contract that would have been churned if it
+// hadn't exceeded the churn budget. It remains good for renew until there is
+// enough budget to churn it.
+type DeferredChurnContract struct {
+	ID            types.FileContractID `json:"id"`
+	HostPublicKey types.SiaPublicKey   `json:"hostpublickey"`
+	Size          uint64               `json:"size"`
+	DeferredSince types.BlockHeight    `json:"deferredsince"`
 }
 
 // UploadedBackup contains metadata about an uploaded backup.
@@ -1040,31 +1567,44 @@ type (
 		HostPubKey      types.SiaPublicKey   `json:"hostpubkey"`
 
 		// Download status information
-		DownloadCoolDownError string        `json:"downloadcooldownerror"`
-		DownloadCoolDownTime  time.Duration `json:"downloadcooldowntime"`
-		DownloadOnCoolDown    bool          `json:"downloadoncooldown"`
-		DownloadQueueSize     int           `json:"downloadqueuesize"`
-		DownloadTerminated    bool          `json:"downloadterminated"`
+		DownloadConsecutiveFailures uint64        `json:"downloadconsecutivefailures"`
+		DownloadCoolDownError       string        `json:"downloadcooldownerror"`
+		DownloadCoolDownTime        time.Duration `json:"downloadcooldowntime"`
+		DownloadOnCoolDown          bool          `json:"downloadoncooldown"`
+		DownloadQueueSize           int           `json:"downloadqueuesize"`
+		DownloadTerminated          bool          `json:"downloadterminated"`
 
 		// Upload status information
-		UploadCoolDownError string        `json:"uploadcooldownerror"`
-		UploadCoolDownTime  time.Duration `json:"uploadcooldowntime"`
-		UploadOnCoolDown    bool          `json:"uploadoncooldown"`
-		UploadQueueSize     int           `json:"uploadqueuesize"`
-		UploadTerminated    bool          `json:"uploadterminated"`
+		UploadConsecutiveFailures uint64        `json:"uploadconsecutivefailures"`
+		UploadCoolDownError       string        `json:"uploadcooldownerror"`
+		UploadCoolDownTime        time.Duration `json:"uploadcooldowntime"`
+		UploadOnCoolDown          bool          `json:"uploadoncooldown"`
+		UploadQueueSize           int           `json:"uploadqueuesize"`
+		UploadTerminated          bool          `json:"uploadterminated"`
 
 		// Maintenance Cooldown information
-		MaintenanceOnCooldown    bool          `json:"maintenanceoncooldown"`
-		MaintenanceCoolDownError string        `json:"maintenancecooldownerror"`
-		MaintenanceCoolDownTime  time.Duration `json:"maintenancecooldowntime"`
+		MaintenanceOnCooldown          bool          `json:"maintenanceoncooldown"`
+		MaintenanceConsecutiveFailures uint64        `json:"maintenanceconsecutivefailures"`
+		MaintenanceCoolDownError       string        `json:"maintenancecooldownerror"`
+		MaintenanceCoolDownTime        time.Duration `json:"maintenancecooldowntime"`
 
 		// Ephemeral Account information
-		AccountBalanceTarget types.Currency      `json:"accountbalancetarget"`
-		AccountStatus        WorkerAccountStatus `json:"accountstatus"`
+		AccountBalanceTarget   types.Currency      `json:"accountbalancetarget"`
+		AccountRefillThreshold types.Currency      `json:"accountrefillthreshold"`
+		AccountStatus          WorkerAccountStatus `json:"accountstatus"`
 
 		// PriceTable information
 		PriceTableStatus WorkerPriceTableStatus `json:"pricetablestatus"`
 
+		// Benchmark information
+		BenchmarkStatus WorkerBenchmarkStatus `json:"benchmarkstatus"`
+
+		// Stream pool information
+		StreamPoolStatus WorkerStreamPoolStatus `json:"streampoolstatus"`
+
+		// Connection information
+		ConnectionStatus WorkerConnectionStatus `json:"connectionstatus"`
+
 		// Job Queues
 		DownloadSnapshotJobQueueSize int `json:"downloadsnapshotjobqueuesize"`
 		UploadSnapshotJobQueueSize   int `json:"uploadsnapshotjobqueuesize"`
@@ -1082,14 +1622,95 @@ type (
 		UpdateRegistryJobsStatus WorkerUpdateRegistryJobStatus `json:"updateregistryjobsstatus"`
 	}
 
+	// WorkerPoolDetail contains the full internal state of the renter's
+	// workers. It is meant for debugging and its fields may change between
+	// releases.
+	WorkerPoolDetail struct {
+		NumWorkers int            `json:"numworkers"`
+		Workers    []WorkerDetail `json:"workers"`
+	}
+
+	// WorkerDetail contains the full internal state of a worker.
+	WorkerDetail struct {
+		ContractID      types.FileContractID `json:"contractid"`
+		ContractUtility ContractUtility      `json:"contractutility"`
+		HostPubKey      types.SiaPublicKey   `json:"hostpubkey"`
+		HostVersion     string               `json:"hostversion"`
+
+		// Paused indicates whether the worker was paused manually.
+		// Blocklisted indicates whether the worker's host is on the worker
+		// blocklist. Neither paused nor blocklisted workers launch jobs.
+		Blocklisted bool `json:"blocklisted"`
+		Killed      bool `json:"killed"`
+		Paused      bool `json:"paused"`
+
+		// Ephemeral account information
+		AccountBalance            types.Currency      `json:"accountbalance"`
+		AccountBalanceTarget      types.Currency      `json:"accountbalancetarget"`
+		AccountPendingDeposits    types.Currency      `json:"accountpendingdeposits"`
+		AccountPendingWithdrawals types.Currency      `json:"accountpendingwithdrawals"`
+		AccountRefillThreshold    types.Currency      `json:"accountrefillthreshold"`
+		AccountStatus             WorkerAccountStatus `json:"accountstatus"`
+
+		// Loop and maintenance information
+		LoopStatus                     WorkerLoopStatus `json:"loopstatus"`
+		MaintenanceOnCooldown          bool             `json:"maintenanceoncooldown"`
+		MaintenanceConsecutiveFailures uint64           `json:"maintenanceconsecutivefailures"`
+		MaintenanceCoolDownError       string           `json:"maintenancecooldownerror"`
+		MaintenanceCoolDownTime        time.Duration    `json:"maintenancecooldowntime"`
+
+		// PriceTable information
+		PriceTableStatus WorkerPriceTableStatus `json:"pricetablestatus"`
+
+		// Queues contains the status of the worker's job queues and its upload
+		// queue indexed by their names.
+		Queues map[string]WorkerGenericJobsStatus `json:"queues"`
+
+		// RecentErrors contains the most recent error of every part of the
+		// worker, sorted from the most recent to the oldest one.
+		RecentErrors []WorkerRecentError `json:"recenterrors"`
+	}
+
+	// WorkerLoopStatus contains information about the jobs the worker loop is
+	// currently running and the limits for launching async jobs.
+	WorkerLoopStatus struct {
+		AsyncJobsRunning        uint64 `json:"asyncjobsrunning"`
+		SerialJobRunning        bool   `json:"serialjobrunning"`
+		SuspectRevisionMismatch bool   `json:"suspectrevisionmismatch"`
+
+		ReadDataLimit        uint64 `json:"readdatalimit"`
+		ReadDataOutstanding  uint64 `json:"readdataoutstanding"`
+		WriteDataLimit       uint64 `json:"writedatalimit"`
+		WriteDataOutstanding uint64 `json:"writedataoutstanding"`
+	}
+
+	// WorkerRecentError is the most recent error of a part of a worker.
+	WorkerRecentError struct {
+		Source string    `json:"source"`
+		Error  string    `json:"error"`
+		Time   time.Time `json:"time"`
+	}
+
 	// WorkerGenericJobsStatus contains the common information for worker jobs.
 	WorkerGenericJobsStatus struct {
-		ConsecutiveFailures uint64    `json:"consecutivefailures"`
-		JobQueueSize        uint64    `json:"jobqueuesize"`
-		OnCooldown          bool      `json:"oncooldown"`
-		OnCooldownUntil     time.Time `json:"oncooldownuntil"`
-		RecentErr           string    `json:"recenterr"`
-		RecentErrTime       time.Time `json:"recenterrtime"`
+		CircuitBreaker      WorkerCircuitBreakerStatus `json:"circuitbreaker"`
+		ConsecutiveFailures uint64                     `json:"consecutivefailures"`
+		JobQueueSize        uint64                     `json:"jobqueuesize"`
+		ShedJobs            uint64                     `json:"shedjobs"`
+		OnCooldown          bool                       `json:"oncooldown"`
+		OnCooldownUntil     time.Time                  `json:"oncooldownuntil"`
+		RecentErr           string                     `json:"recenterr"`
+		RecentErrTime       time.Time                  `json:"recenterrtime"`
+	}
+
+	// WorkerCircuitBreakerStatus contains information about the circuit
+	// breaker of a job category. A worker whose breaker is open is excluded
+	// from running jobs of the category until the breaker is half-open again
+	// and a probe job succeeded.
+	WorkerCircuitBreakerStatus struct {
+		State     string    `json:"state"`
+		OpenUntil time.Time `json:"openuntil"`
+		Trips     uint64    `json:"trips"`
 	}
 
 	// WorkerAccountStatus contains detailed information about the account
@@ -1100,6 +1721,117 @@ type (
 		RecentErr         string    `json:"recenterr"`
 		RecentErrTime     time.Time `json:"recenterrtime"`
 		RecentSuccessTime time.Time `json:"recentsuccesstime"`
+
+		ConsecutiveRefillFailures uint64                `json:"consecutiverefillfailures"`
+		RecentRefills             []WorkerAccountRefill `json:"recentrefills"`
+	}
+
+	// WorkerAccountRefill contains information about a single attempt to
+	// refill an ephemeral account.
+	WorkerAccountRefill struct {
+		Time    time.Time      `json:"time"`
+		Amount  types.Currency `json:"amount"`
+		Success bool           `json:"success"`
+		Error   string         `json:"error,omitempty"`
+	}
+
+	// WorkerAccountRefillSettings overwrite the default balance target and
+	// refill threshold of the ephemeral account on a host. A zero target uses
+	// the default target and a zero threshold uses half the target.
+	WorkerAccountRefillSettings struct {
+		Target    types.Currency `json:"target"`
+		Threshold types.Currency `json:"threshold"`
+	}
+
+	// WorkerBenchmarkStatus contains detailed information about the
+	// benchmarks a worker runs on its host. The round trip time and the
+	// program execution latency are tracked separately.
+	WorkerBenchmarkStatus struct {
+		ExecutionStats *DistributionTrackerStats `json:"executionstats"`
+		RTTStats       *DistributionTrackerStats `json:"rttstats"`
+
+		ExecutionLatency time.Duration `json:"executionlatency"`
+		RTT              time.Duration `json:"rtt"`
+		Throughput       float64       `json:"throughput"`
+
+		LastBenchmark time.Time `json:"lastbenchmark"`
+		NextBenchmark time.Time `json:"nextbenchmark"`
+
+		RecentErr     string    `json:"recenterr"`
+		RecentErrTime time.Time `json:"recenterrtime"`
+	}
+
+	// WorkerStreamPoolStatus contains information about the pool of idle
+	// streams a worker keeps open to its host. Hits and misses count the
+	// streams taken from the pool and the streams that had to be opened on
+	// demand.
+	WorkerStreamPoolStatus struct {
+		IdleStreams    uint64 `json:"idlestreams"`
+		MaxIdleStreams uint64 `json:"maxidlestreams"`
+
+		DialFailures uint64 `json:"dialfailures"`
+		Expired      uint64 `json:"expired"`
+		Hits         uint64 `json:"hits"`
+		Misses       uint64 `json:"misses"`
+	}
+
+	// WorkerConnectionStatus contains information about the health of a
+	// worker's connection to its host. The RTT is estimated from the time it
+	// takes to open a stream on the worker's mux. Stream errors are errors of
+	// the mux while reading from or writing to a stream and reconnects count
+	// the streams which were opened successfully after failing to open one.
+	WorkerConnectionStatus struct {
+		EstimatedRTT time.Duration `json:"estimatedrtt"`
+		LastRTT      time.Duration `json:"lastrtt"`
+
+		StreamsOpened      uint64 `json:"streamsopened"`
+		StreamOpenFailures uint64 `json:"streamopenfailures"`
+		StreamErrors       uint64 `json:"streamerrors"`
+		Reconnects         uint64 `json:"reconnects"`
+
+		RecentErr     string    `json:"recenterr"`
+		RecentErrTime time.Time `json:"recenterrtime"`
+	}
+
+	// WorkerConnectionProbe is the result of a single connectivity test
+	// against a worker's host.
+	WorkerConnectionProbe struct {
+		Duration time.Duration `json:"duration"`
+		Error    string        `json:"error,omitempty"`
+		Success  bool          `json:"success"`
+	}
+
+	// WorkerConnectionReport is the result of diagnosing the connection of a
+	// worker to its host.
+	WorkerConnectionReport struct {
+		HostPubKey types.SiaPublicKey `json:"hostpubkey"`
+		MuxAddress string             `json:"muxaddress"`
+
+		// TCPConnect is the result of opening a plain TCP connection to the
+		// host's siamux.
+		TCPConnect WorkerConnectionProbe `json:"tcpconnect"`
+
+		// StreamOpens are the results of opening streams to the host on the
+		// worker's mux.
+		StreamOpens       []WorkerConnectionProbe `json:"streamopens"`
+		MinStreamOpenTime time.Duration           `json:"minstreamopentime"`
+
+		// Reachable is true if at least one stream was opened.
+		Reachable bool `json:"reachable"`
+
+		// ConnectionStatus is the worker's connection status after the
+		// test.
+		ConnectionStatus WorkerConnectionStatus `json:"connectionstatus"`
+	}
+
+	// WorkerBlocklistEntry is a subnet of hosts which the renter's workers
+	// refuse to launch jobs to. Entries may be tagged with the autonomous
+	// system number of the network they belong to, which allows for removing
+	// all subnets of an ASN at once.
+	WorkerBlocklistEntry struct {
+		Subnet string `json:"subnet"`
+		ASN    uint32 `json:"asn,omitempty"`
+		Reason string `json:"reason,omitempty"`
 	}
 
 	// WorkerPriceTableStatus contains detailed information about the price
@@ -1108,6 +1840,9 @@ type (
 		ExpiryTime time.Time `json:"expirytime"`
 		UpdateTime time.Time `json:"updatetime"`
 
+		Age      time.Duration `json:"age"`
+		Validity time.Duration `json:"validity"`
+
 		Active bool `json:"active"`
 
 		RecentErr     string    `json:"recenterr"`
@@ -1120,9 +1855,15 @@ type (
 		AvgJobTime1m  uint64 `json:"avgjobtime1m"`  // in ms
 		AvgJobTime4m  uint64 `json:"avgjobtime4m"`  // in ms
 
-		ConsecutiveFailures uint64 `json:"consecutivefailures"`
+		CircuitBreaker      WorkerCircuitBreakerStatus `json:"circuitbreaker"`
+		ConsecutiveFailures uint64                     `json:"consecutivefailures"`
+
+		// CorruptPieces is the number of pieces served by the host which
+		// didn't match their merkle root.
+		CorruptPieces uint64 `json:"corruptpieces"`
 
 		JobQueueSize uint64 `json:"jobqueuesize"`
+		ShedJobs     uint64 `json:"shedjobs"`
 
 		RecentErr     string    `json:"recenterr"`
 		RecentErrTime time.Time `json:"recenterrtime"`
@@ -1133,9 +1874,11 @@ type (
 	WorkerHasSectorJobsStatus struct {
 		AvgJobTime uint64 `json:"avgjobtime"` // in ms
 
-		ConsecutiveFailures uint64 `json:"consecutivefailures"`
+		CircuitBreaker      WorkerCircuitBreakerStatus `json:"circuitbreaker"`
+		ConsecutiveFailures uint64                     `json:"consecutivefailures"`
 
 		JobQueueSize uint64 `json:"jobqueuesize"`
+		ShedJobs     uint64 `json:"shedjobs"`
 
 		RecentErr     string    `json:"recenterr"`
 		RecentErrTime time.Time `json:"recenterrtime"`
@@ -1196,17 +1939,33 @@ type Renter interface {
 	// CancelContract cancels a specific contract of the renter.
 	CancelContract(id types.FileContractID) error
 
+	// DefragContract removes the sectors which are no longer referenced from
+	// a specific contract of the renter.
+	DefragContract(id types.FileContractID) (ContractDefragReport, error)
+
 	// Contracts returns the staticContracts of the renter's hostContractor.
 	Contracts() []RenterContract
 
+	// ContractSetSnapshot returns a signed snapshot of the renter's current
+	// contracts for external auditing.
+	ContractSetSnapshot() (SignedContractSetSnapshot, error)
+
 	// ContractStatus returns the status of the contract with the given ID in the
 	// watchdog, and a bool indicating whether or not the watchdog is aware of it.
 	ContractStatus(fcID types.FileContractID) (ContractWatchStatus, bool)
 
+	// ContractFeeBumps returns the pending fee bumps of unconfirmed contract
+	// transactions.
+	ContractFeeBumps() []ContractFeeBump
+
 	// CreateBackup creates a backup of the renter's siafiles. If a secret is not
 	// nil, the backup will be encrypted using the provided secret.
 	CreateBackup(dst string, secret []byte) error
 
+	// CreateSkynetBackup creates an encrypted backup of the skynet folder and
+	// the renter's skykeys and uploads it as a skyfile.
+	CreateSkynetBackup(ctx context.Context, secret []byte) (Skylink, error)
+
 	// DecryptBaseSector attempts to decrypt the baseSector. If it has the
 	// necessary Skykey, it will decrypt the baseSector in-place. It returns the
 	// file-specific skykey to be used for decrypting the rest of the associated
@@ -1222,13 +1981,31 @@ type Renter interface {
 	// use.
 	LoadBackup(src string, secret []byte) error
 
+	// RestoreSkynetBackup restores the skynet folder and the skykeys of a
+	// backup created by CreateSkynetBackup.
+	RestoreSkynetBackup(src io.ReadSeeker, secret []byte) error
+
 	// InitRecoveryScan starts scanning the whole blockchain for recoverable
 	// contracts within a separate thread.
 	InitRecoveryScan() error
 
+	// InitRecoveryScanRange starts scanning the blocks between start and end
+	// (inclusive) for recoverable contracts within a separate thread.
+	InitRecoveryScanRange(start, end types.BlockHeight) error
+
+	// PauseRecoveryScan pauses the running recovery scan.
+	PauseRecoveryScan() error
+
+	// ResumeRecoveryScan resumes a paused recovery scan.
+	ResumeRecoveryScan() error
+
 	// OldContracts returns the oldContracts of the renter's hostContractor.
 	OldContracts() []RenterContract
 
+	// HostProfiles maps the hosts of the renter's allowance profiles to the
+	// names of their profiles.
+	HostProfiles() map[string]string
+
 	// ContractorChurnStatus returns contract churn stats for the current period.
 	ContractorChurnStatus() ContractorChurnStatus
 
@@ -1242,6 +2019,11 @@ type Renter interface {
 	// MemoryStatus returns the current status of the memory manager
 	MemoryStatus() (MemoryStatus, error)
 
+	// AdmitRequest returns ErrMemoryLimitExceeded if admitting a new request
+	// which requires the given amount of memory would exceed the soft memory
+	// limit.
+	AdmitRequest(memory uint64) error
+
 	// Mount mounts a FUSE filesystem at mountPoint, making the contents of sp
 	// available via the local filesystem.
 	Mount(mountPoint string, sp SiaPath, opts MountOptions) error
@@ -1259,6 +2041,18 @@ type Renter interface {
 	// billing period.
 	PeriodSpending() (ContractorSpending, error)
 
+	// SpendingForecast returns the projected spending for the remainder of
+	// the current billing period.
+	SpendingForecast() (SpendingForecast, error)
+
+	// AutoFundStatus returns the auto-funding settings and the audit log of
+	// automatic allowance top-ups.
+	AutoFundStatus() (AutoFundStatus, error)
+
+	// SetAutoFundSettings updates the settings for automatically topping up
+	// the allowance.
+	SetAutoFundSettings(AutoFundSettings) error
+
 	// RecoverableContracts returns the contracts that the contractor deems
 	// recoverable. That means they are not expired yet and also not part of the
 	// active contracts. Usually this should return an empty slice unless the host
@@ -1269,6 +2063,10 @@ type Renter interface {
 	// contracts is in progress and if it is, the current progress of the scan.
 	RecoveryScanStatus() (bool, types.BlockHeight)
 
+	// RecoveryScanProgress returns the detailed progress of the most recent
+	// recovery scan and the recovery status of the contracts found.
+	RecoveryScanProgress() RecoveryScanProgress
+
 	// RefreshedContract checks if the contract was previously refreshed
 	RefreshedContract(fcid types.FileContractID) bool
 
@@ -1296,6 +2094,10 @@ type Renter interface {
 	// DeleteFile deletes a file entry from the renter.
 	DeleteFile(siaPath SiaPath) error
 
+	// RetrieveArchivedFile starts the retrieval of an archived file. Once the
+	// retrieval completes, downloads of the file are served from disk.
+	RetrieveArchivedFile(siaPath SiaPath) error
+
 	// Download creates a download according to the parameters passed, including
 	// downloads of `offset` and `length` type. It returns a method to
 	// start the download.
@@ -1318,6 +2120,14 @@ type Renter interface {
 	// DownloadHistory lists all the files that have been scheduled for download.
 	DownloadHistory() ([]DownloadInfo, error)
 
+	// PendingDownloadChunks lists the chunks of classic downloads which are
+	// waiting in the download heap, in the order they will be downloaded.
+	PendingDownloadChunks() ([]PendingDownloadChunk, error)
+
+	// CancelPendingDownload cancels the download with the given uid and
+	// removes its pending chunks from the download heap.
+	CancelPendingDownload(uid DownloadID) error
+
 	// File returns information on specific file queried by user
 	File(siaPath SiaPath) (FileInfo, error)
 
@@ -1332,6 +2142,14 @@ type Renter interface {
 	// SetFilterMode sets the renter's hostdb filter mode
 	SetFilterMode(fm FilterMode, hosts []types.SiaPublicKey) error
 
+	// HostAllowlist returns the status of the host allow-list feed.
+	HostAllowlist() (HostAllowlistStatus, error)
+
+	// RefreshHostAllowlist fetches the signed host allow-list and applies it
+	// as the hostdb's whitelist. A non-empty source replaces the configured
+	// source and key of the feed.
+	RefreshHostAllowlist(source string, publicKey types.SiaPublicKey) error
+
 	// Host provides the DB entry and score breakdown for the requested host.
 	Host(pk types.SiaPublicKey) (HostDBEntry, bool, error)
 
@@ -1380,6 +2198,10 @@ type Renter interface {
 	// ResolveSkylinkV2 resolves a V2 skylink to a V1 skylink if possible.
 	ResolveSkylinkV2(ctx context.Context, sl Skylink) (Skylink, []RegistryEntry, error)
 
+	// ResolveSkynetName resolves a human-readable name to the skylink its
+	// TXT records point to.
+	ResolveSkynetName(ctx context.Context, name string) (SkynetNameResolution, error)
+
 	// ScoreBreakdown will return the score for a host db entry using the
 	// hostdb's weighting algorithm.
 	ScoreBreakdown(entry HostDBEntry) (HostScoreBreakdown, error)
@@ -1395,12 +2217,14 @@ type Renter interface {
 	SetFileTrackingPath(siaPath SiaPath, newPath string) error
 
 	// UpdateRegistry updates the registries on all workers with the given
-	// registry value.
-	UpdateRegistry(ctx context.Context, spk types.SiaPublicKey, srv modules.SignedRegistryValue) error
+	// registry value. It returns the proofs of the hosts which accepted the
+	// update.
+	UpdateRegistry(ctx context.Context, spk types.SiaPublicKey, srv modules.SignedRegistryValue) ([]RegistryUpdateProof, error)
 
 	// UpdateRegistryMulti updates the registries on the given workers with the
-	// corresponding registry values.
-	UpdateRegistryMulti(ctx context.Context, srvs map[string]RegistryEntry) error
+	// corresponding registry values. It returns the proofs of the hosts which
+	// accepted the update.
+	UpdateRegistryMulti(ctx context.Context, srvs map[string]RegistryEntry) ([]RegistryUpdateProof, error)
 
 	// PauseRepairsAndUploads pauses the renter's repairs and uploads for a time
 	// duration
@@ -1459,37 +2283,84 @@ type Renter interface {
 	// Skykeys returns a slice containing each Skykey being stored by the renter.
 	Skykeys() ([]skykey.Skykey, error)
 
+	// CreateSkykeyBackup uploads a password protected bundle of the renter's
+	// skykeys as a skyfile.
+	CreateSkykeyBackup(ctx context.Context, password string) (Skylink, error)
+
+	// ExportSkykeys returns a password protected bundle of the renter's
+	// skykeys.
+	ExportSkykeys(password string) ([]byte, error)
+
+	// ImportSkykeys adds the skykeys of a password protected bundle to the
+	// renter's skykey manager and returns the added keys.
+	ImportSkykeys(bundle []byte, password string) ([]skykey.Skykey, error)
+
+	// SetSkykeyAutoBackup enables the automatic backup of the renter's
+	// skykeys to the registry entry with the given name. An empty password
+	// disables the automatic backup.
+	SetSkykeyAutoBackup(ctx context.Context, password, entryName string) (SkykeyAutoBackupStatus, error)
+
+	// SkykeyAutoBackupStatus returns the status of the automatic skykey
+	// backup.
+	SkykeyAutoBackupStatus() (SkykeyAutoBackupStatus, error)
+
 	// CreateSkylinkFromSiafile will create a skylink from a siafile. This will
 	// result in some uploading - the base sector skyfile needs to be uploaded
 	// separately, and if there is a fanout expansion that needs to be uploaded
 	// separately as well.
 	CreateSkylinkFromSiafile(SkyfileUploadParameters, SiaPath) (Skylink, error)
 
+	// DeleteSkylink tombstones a skylink and starts removing its siafiles
+	// and cached data in the background.
+	DeleteSkylink(ctx context.Context, link Skylink) (SkynetDeleteJob, error)
+
 	// DownloadByRoot will fetch data using the merkle root of that data. The
 	// given timeout will make sure this call won't block for a time that
 	// exceeds the given timeout value. Passing a timeout of 0 is considered as
 	// no timeout. The pricePerMS acts as a budget to spend on faster, and thus
-	// potentially more expensive, hosts.
-	DownloadByRoot(root crypto.Hash, offset, length uint64, timeout time.Duration, pricePerMS types.Currency) ([]byte, error)
+	// potentially more expensive, hosts. Unspecified overdrive settings default
+	// to the renter's settings.
+	DownloadByRoot(root crypto.Hash, offset, length uint64, timeout time.Duration, pricePerMS types.Currency, overdrive OverdriveSettings) ([]byte, error)
 
 	// DownloadSkylink will fetch a file from the Sia network using the given
 	// skylink. The given timeout will make sure this call won't block for a
 	// time that exceeds the given timeout value. Passing a timeout of 0 is
 	// considered as no timeout. The pricePerMS acts as a budget to spend on
-	// faster, and thus potentially more expensive, hosts.
-	DownloadSkylink(link Skylink, timeout time.Duration, pricePerMS types.Currency) (SkyfileStreamer, []RegistryEntry, error)
+	// faster, and thus potentially more expensive, hosts. Unspecified overdrive
+	// settings default to the renter's settings. If access is set, the
+	// download is only allowed if the requested skylink and the skylink it
+	// resolves to may be accessed with the given credentials. A nil access is
+	// used by the renter's own downloads and skips the check.
+	DownloadSkylink(link Skylink, timeout time.Duration, pricePerMS types.Currency, overdrive OverdriveSettings, access *SkylinkAccess) (SkyfileStreamer, []RegistryEntry, error)
+
+	// DownloadSkylinkMetadata will fetch the metadata of a file from the Sia
+	// network without fetching its fanout. The returned streamer only serves
+	// the file's data if it is stored in the base sector. The access is checked
+	// like for DownloadSkylink.
+	DownloadSkylinkMetadata(link Skylink, timeout time.Duration, pricePerMS types.Currency, overdrive OverdriveSettings, access *SkylinkAccess) (SkyfileStreamer, []RegistryEntry, error)
 
 	// DownloadSkylinkBaseSector will take a link and turn it into the data of a
 	// download without any decoding of the metadata, fanout, or decryption. The
 	// given timeout will make sure this call won't block for a time that
 	// exceeds the given timeout value. Passing a timeout of 0 is considered as
 	// no timeout. The pricePerMS acts as a budget to spend on faster, and thus
-	// potentially more expensive, hosts.
-	DownloadSkylinkBaseSector(link Skylink, timeout time.Duration, pricePerMS types.Currency) (Streamer, []RegistryEntry, Skylink, error)
+	// potentially more expensive, hosts. Unspecified overdrive settings default
+	// to the renter's settings. The access is checked like for
+	// DownloadSkylink.
+	DownloadSkylinkBaseSector(link Skylink, timeout time.Duration, pricePerMS types.Currency, overdrive OverdriveSettings, access *SkylinkAccess) (Streamer, []RegistryEntry, Skylink, error)
+
+	// CachedSkylinkHealth returns the health of a skylink pinned by the node
+	// as computed by the health loop. The returned bool is false if the
+	// health of the skylink isn't known.
+	CachedSkylinkHealth(link Skylink) (CachedSkylinkHealth, bool)
 
 	// SkylinkHealth returns the health of a skylink on the network.
 	SkylinkHealth(ctx context.Context, link Skylink, ppms types.Currency) (SkylinkHealth, error)
 
+	// VerifySkylink asks the contracted hosts for every piece of every chunk
+	// of a skyfile and returns a per chunk redundancy report.
+	VerifySkylink(ctx context.Context, link Skylink, ppms types.Currency) (SkylinkVerification, error)
+
 	// UploadSkyfile will upload data to the Sia network from a reader and
 	// create a skyfile, returning the skylink that can be used to access the
 	// file.
@@ -1500,9 +2371,64 @@ type Renter interface {
 	// file.
 	UploadSkyfile(context.Context, SkyfileUploadParameters, SkyfileUploadReader) (Skylink, error)
 
+	// SkynetConvertDir starts converting all siafiles within a directory to
+	// skyfiles in the background.
+	SkynetConvertDir(job SkynetConvertDirJob) (SkynetConvertDirJob, error)
+
+	// SkynetConvertDirJob returns the directory conversion job with the given
+	// id.
+	SkynetConvertDirJob(id string) (SkynetConvertDirJob, error)
+
+	// SkynetDeleteJob returns the skylink deletion job with the given id.
+	SkynetDeleteJob(id string) (SkynetDeleteJob, error)
+
+	// Skylinks returns the skylinks known to the node which pass the filter
+	// together with the total number of skylinks passing the filter.
+	Skylinks(filter SkylinksFilter) ([]SkylinkInfo, uint64, error)
+
+	// SkynetRepairPriorities returns the repair priorities of the skylinks
+	// which are prioritized the most, sorted by priority.
+	SkynetRepairPriorities() ([]SkylinkRepairPriority, error)
+
+	// SetSkylinkRepairPriority manually overrides the repair priority of a
+	// skylink.
+	SetSkylinkRepairPriority(link Skylink, priority float64) (SkylinkRepairPriority, error)
+
+	// ResetSkylinkRepairPriority removes the manual repair priority override
+	// of a skylink.
+	ResetSkylinkRepairPriority(link Skylink) (SkylinkRepairPriority, error)
+
+	// SkynetDirUploadAbort aborts the directory upload session with the given
+	// id and removes all of its uploaded files.
+	SkynetDirUploadAbort(id string) error
+
+	// SkynetDirUploadAddFile adds a file to the directory upload session with
+	// the given id.
+	SkynetDirUploadAddFile(id string, file SkynetDirUploadFile, reader io.Reader) error
+
+	// SkynetDirUploadCreate creates a new directory upload session. The
+	// optional manifest restricts the session to the files within it.
+	SkynetDirUploadCreate(session SkynetDirUploadSession, manifest []SkynetDirUploadFile) (SkynetDirUploadSession, error)
+
+	// SkynetDirUploadFinalize uploads the files of the directory upload
+	// session with the given id as a single skyfile.
+	SkynetDirUploadFinalize(ctx context.Context, id string) (Skylink, error)
+
+	// SkynetDirUploadSession returns the directory upload session with the
+	// given id.
+	SkynetDirUploadSession(id string) (SkynetDirUploadSession, error)
+
 	// Blocklist returns the merkleroots that are blocked
 	Blocklist() ([]crypto.Hash, error)
 
+	// BlocklistReasons returns the reasons why merkleroots were blocked if
+	// known.
+	BlocklistReasons() (map[crypto.Hash]string, error)
+
+	// CheckSkynetBlocklist returns whether the given skylinks or hashes of
+	// merkleroots are blocked.
+	CheckSkynetBlocklist(ctx context.Context, candidates []string, isHash bool) ([]bool, error)
+
 	// PinSkylink re-uploads the data stored at the file under that skylink with
 	// the given parameters. Alongside the parameters we can pass a timeout and
 	// a price per millisecond. The timeout ensures fetching the base sector
@@ -1510,16 +2436,104 @@ type Renter interface {
 	// allowed to spend on faster hosts.
 	PinSkylink(link Skylink, sup SkyfileUploadParameters, timeout time.Duration, pricePerMS types.Currency) error
 
+	// PinSkylinkWithDependencies pins the skylink as well as the skylinks
+	// referenced by its content up to the given depth and returns the
+	// discovered dependency graph. If an API token ID is given, the
+	// dependencies are only pinned if they fit into the token's quota and
+	// are added to its usage.
+	PinSkylinkWithDependencies(link Skylink, sup SkyfileUploadParameters, timeout time.Duration, pricePerMS types.Currency, depth uint64, tokenID string) ([]SkylinkDependency, error)
+
 	// UnpinSkylink unpins a skylink from the renter by removing the underlying
 	// siafile.
 	UnpinSkylink(skylink Skylink) error
 
+	// SignSkylink restricts access to the skylink to signed URLs and returns
+	// a signature for a URL which is valid until expires.
+	SignSkylink(skylink Skylink, expires time.Time) ([]byte, error)
+
+	// UnrestrictSkylink lifts the restriction of a skylink to signed URLs.
+	UnrestrictSkylink(skylink Skylink) error
+
+	// VerifySkylinkAccess checks whether a skylink may be downloaded. If a
+	// signature is provided, it needs to be valid. If the skylink is
+	// restricted, a signature is required.
+	VerifySkylinkAccess(skylink Skylink, expires time.Time, signature []byte) error
+
 	// Portals returns the list of known skynet portals.
 	Portals() ([]SkynetPortal, error)
 
+	// APITokens returns the information about all API tokens.
+	APITokens() ([]APIToken, error)
+
+	// APITokenUsage returns the information about the API token with the
+	// given ID together with the usage of its quota.
+	APITokenUsage(id string) (APIToken, APITokenUsage, error)
+
+	// CommitAPITokenQuota adds the given number of reserved bytes to the
+	// usage of the API token with the given ID and attributes them to the
+	// uploaded or pinned skylink. Unpinning the skylink frees them again.
+	CommitAPITokenQuota(id string, skylink Skylink, size uint64) error
+
+	// ReleaseAPITokenQuota releases the given number of reserved bytes of
+	// the API token with the given ID.
+	ReleaseAPITokenQuota(id string, size uint64)
+
+	// ReserveAPITokenQuota reserves the given number of bytes of the quota
+	// of the API token with the given ID for an upload or pin. The bytes
+	// count towards the quota until they are committed or released.
+	ReserveAPITokenQuota(id string, size uint64) error
+
+	// CreateAPIToken creates a new API token with the given scope and quota
+	// and returns it together with its information.
+	CreateAPIToken(name string, scope APITokenScope, quota APITokenQuota) (string, APIToken, error)
+
+	// RevokeAPIToken revokes the API token with the given ID.
+	RevokeAPIToken(id string) error
+
+	// SetAPITokenQuota sets the quota of the API token with the given ID.
+	SetAPITokenQuota(id string, quota APITokenQuota) error
+
+	// ValidateAPIToken returns the information about an API token or
+	// ErrAPITokenNotFound if the token is invalid.
+	ValidateAPIToken(token string) (APIToken, error)
+
+	// MaintenanceJobs returns the status of the renter's maintenance jobs.
+	MaintenanceJobs() ([]MaintenanceJobStatus, error)
+
+	// PauseMaintenanceJob pauses the maintenance job with the given name
+	// until it is resumed.
+	PauseMaintenanceJob(name string) error
+
+	// ResumeMaintenanceJob resumes the paused maintenance job with the given
+	// name.
+	ResumeMaintenanceJob(name string) error
+
+	// SetMaintenanceJobInterval changes the interval and jitter of the
+	// maintenance job with the given name.
+	SetMaintenanceJobInterval(name string, interval time.Duration, jitter float64) error
+
+	// SetSkynetFolderQuota sets the quota of the given top-level folder of
+	// the skynet folder. A quota of 0 removes the quota.
+	SetSkynetFolderQuota(folder string, quota uint64) error
+
+	// SkynetFolderUsage returns the number of bytes stored under the given
+	// top-level folder of the skynet folder together with its quota.
+	SkynetFolderUsage(folder string) (SkynetFolderUsage, error)
+
+	// SkynetFolderUsages returns the usage of all top-level folders of the
+	// skynet folder.
+	SkynetFolderUsages() ([]SkynetFolderUsage, error)
+
 	// RestoreSkyfile restores a skyfile such that the skylink is preserved.
 	RestoreSkyfile(reader io.Reader) (Skylink, error)
 
+	// ImportSkyfile uploads a skyfile fetched from another portal such that
+	// the skylink is preserved. The data is verified against the skylink.
+	ImportSkyfile(skylink Skylink, baseSector []byte, reader io.Reader) (Skylink, error)
+
+	// SkyfileUploadLimits returns the limits imposed on skyfile uploads.
+	SkyfileUploadLimits() SkyfileUploadLimits
+
 	// UpdateSkynetBlocklist updates the list of hashed merkleroots that are
 	// blocked
 	UpdateSkynetBlocklist(ctx context.Context, additions, removals []string, isHash bool) error
@@ -1530,6 +2544,54 @@ type Renter interface {
 	// WorkerPoolStatus returns the current status of the Renter's worker pool
 	WorkerPoolStatus() (WorkerPoolStatus, error)
 
+	// SetWorkerAccountRefillSettings overwrites the balance target and refill
+	// threshold of the ephemeral account on the given host. Zero settings
+	// restore the defaults.
+	SetWorkerAccountRefillSettings(hostKey types.SiaPublicKey, settings WorkerAccountRefillSettings) error
+
+	// WorkerPoolDetail returns the full internal state of the renter's
+	// workers.
+	WorkerPoolDetail() (WorkerPoolDetail, error)
+
+	// PauseWorker stops the worker of the given host from launching jobs
+	// until it is resumed.
+	PauseWorker(hostKey types.SiaPublicKey) error
+
+	// ResumeWorker resumes a paused worker.
+	ResumeWorker(hostKey types.SiaPublicKey) error
+
+	// FlushWorkerQueues discards all jobs queued with the worker of the given
+	// host.
+	FlushWorkerQueues(hostKey types.SiaPublicKey) error
+
+	// DiagnoseWorker runs a live connectivity test against the host of the
+	// given worker.
+	DiagnoseWorker(hostKey types.SiaPublicKey) (WorkerConnectionReport, error)
+
+	// ResetWorkerCooldown takes the worker of the given host off all of its
+	// cooldowns.
+	ResetWorkerCooldown(hostKey types.SiaPublicKey) error
+
+	// WorkerBlocklist returns the subnets of the hosts which the workers
+	// refuse to launch jobs to.
+	WorkerBlocklist() []WorkerBlocklistEntry
+
+	// TrustedRegistryHosts returns the hosts whose responses are required or
+	// weighted higher when reading from the registry.
+	TrustedRegistryHosts() TrustedRegistryHosts
+
+	// SetTrustedRegistryHosts replaces the hosts whose responses are
+	// required or weighted higher when reading from the registry.
+	SetTrustedRegistryHosts(settings TrustedRegistryHosts) error
+
+	// UpdateWorkerBlocklist adds and removes entries from the worker
+	// blocklist. Removals are either subnets or ASNs in the form 'AS<number>'.
+	UpdateWorkerBlocklist(additions []WorkerBlocklistEntry, removals []string) error
+
+	// WorkerBlocklistedHosts returns the public keys of the hosts which are
+	// currently blocked by the worker blocklist.
+	WorkerBlocklistedHosts() []types.SiaPublicKey
+
 	// UpdateMetadata will ensure that the metadata of the provided directory is
 	// updated and that the updated stats are represented in the aggregate
 	// statistics of the root folder.
@@ -1553,6 +2615,40 @@ type SkyfileStreamer interface {
 	Metadata() SkyfileMetadata
 	RawMetadata() []byte
 	Skylink() Skylink
+
+	// Provenance returns which hosts served the pieces of the fanout chunks
+	// that were read from the streamer so far.
+	Provenance() []ChunkProvenance
+
+	// Bandwidth returns the number of bytes which were sent to and received
+	// from hosts to fetch the fanout chunks that were read from the streamer
+	// so far, including the overhead of overdrive.
+	Bandwidth() (up, down uint64)
+}
+
+// ChunkProvenance lists the hosts which served the pieces of a chunk of a
+// skyfile's fanout.
+type ChunkProvenance struct {
+	ChunkIndex uint64            `json:"chunkindex"`
+	Pieces     []PieceProvenance `json:"pieces"`
+}
+
+// PieceProvenance describes the host which served a piece of a chunk.
+// Attempts is the number of attempts it took to download the piece, it is
+// greater than 1 if the piece had to be retried on other hosts.
+type PieceProvenance struct {
+	PieceIndex uint64             `json:"pieceindex"`
+	HostKey    types.SiaPublicKey `json:"hostkey"`
+	Attempts   uint64             `json:"attempts"`
+}
+
+// CachedSkylinkHealth is the health of a skylink pinned by the node as
+// computed by the health loop. Like the health of a siafile, a health of 0
+// means full redundancy and a health above 1 means that the skylink might not
+// be recoverable.
+type CachedSkylinkHealth struct {
+	Health      float64   `json:"health"`
+	LastUpdated time.Time `json:"lastupdated"`
 }
 
 // SkylinkHealth describes the health of a skylink on the network.
@@ -1578,6 +2674,60 @@ type SkylinkHealth struct {
 	FanoutRedundancy []float64 `json:"fanoutredundancy,omitempty"`
 }
 
+// SkylinkVerification is a per chunk report of the redundancy of a skyfile
+// across the contracted hosts.
+type SkylinkVerification struct {
+	// Skylink is the v1 skylink of the verified skyfile.
+	Skylink Skylink `json:"skylink"`
+
+	// BaseSectorHosts are the hosts which store the base sector.
+	BaseSectorHosts []types.SiaPublicKey `json:"basesectorhosts"`
+
+	// FanoutDataPieces and FanoutParityPieces are the erasure coding
+	// settings specified in the layout of the skyfile.
+	FanoutDataPieces   uint8 `json:"fanoutdatapieces"`
+	FanoutParityPieces uint8 `json:"fanoutparitypieces"`
+
+	// AdvertisedRedundancy is the redundancy the fanout should have
+	// according to its erasure coding settings.
+	AdvertisedRedundancy float64 `json:"advertisedredundancy"`
+
+	// EffectiveRedundancy is the worst redundancy of any of the fanout's
+	// chunks.
+	EffectiveRedundancy float64 `json:"effectiveredundancy"`
+
+	// Verified is true if the base sector was found and every chunk of the
+	// fanout reaches the advertised redundancy.
+	Verified bool `json:"verified"`
+
+	// Chunks contains the report of every chunk of the fanout.
+	Chunks []ChunkVerification `json:"chunks"`
+}
+
+// ChunkVerification describes which hosts store the pieces of a chunk of a
+// skyfile's fanout.
+type ChunkVerification struct {
+	ChunkIndex uint64 `json:"chunkindex"`
+
+	// Redundancy is the redundancy of the chunk based on the pieces which
+	// were found on at least one host.
+	Redundancy float64 `json:"redundancy"`
+
+	// MissingPieces are the indices of the pieces which weren't found on
+	// any host.
+	MissingPieces []uint64 `json:"missingpieces"`
+
+	// Pieces lists the hosts which store each of the pieces.
+	Pieces []PieceVerification `json:"pieces"`
+}
+
+// PieceVerification describes which hosts store a piece of a chunk.
+type PieceVerification struct {
+	PieceIndex uint64               `json:"pieceindex"`
+	Root       crypto.Hash          `json:"root"`
+	Hosts      []types.SiaPublicKey `json:"hosts"`
+}
+
 // RenterDownloadParameters defines the parameters passed to the Renter's
 // Download method.
 type RenterDownloadParameters struct {
@@ -1666,6 +2816,9 @@ type HostDB interface {
 	// a host for a given key
 	IncrementFailedInteractions(types.SiaPublicKey) error
 
+	// UpdateHostBenchmark updates the benchmark results of a host.
+	UpdateHostBenchmark(types.SiaPublicKey, HostBenchmark) error
+
 	// initialScanComplete returns a boolean indicating if the initial scan of the
 	// hostdb is completed.
 	InitialScanComplete() (bool, error)
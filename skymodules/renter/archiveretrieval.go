@@ -0,0 +1,113 @@
+package renter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+// Archived files are stored on the archive hosts, which are selected for their
+// low storage price rather than their performance. Instead of being downloaded
+// directly, they are retrieved ahead of time. A retrieval downloads the file in
+// the background into the renter's retrieval directory. Once the download
+// completes, the retrieved copy becomes the local copy of the file and all
+// further downloads of the file are served from disk.
+
+const (
+	// archiveRetrievalDir is the directory within the renter's persist
+	// directory which holds the retrieved copies of archived files.
+	archiveRetrievalDir = "archiveretrievals"
+)
+
+var (
+	// ErrNotArchived is returned when retrieving a file which isn't archived.
+	ErrNotArchived = errors.New("file is not archived")
+)
+
+// RetrieveArchivedFile starts the retrieval of an archived file. The file is
+// downloaded in the background and its local copy is set once the download
+// completes. Retrieving a file which was already retrieved is a no-op.
+func (r *Renter) RetrieveArchivedFile(siaPath skymodules.SiaPath) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	// Check that the file is archived and hasn't been retrieved yet.
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to open siafile")
+	}
+	profile := entry.Profile()
+	localPath := entry.LocalPath()
+	dst := filepath.Join(r.persistDir, archiveRetrievalDir, string(entry.UID()))
+	err = entry.Close()
+	if err != nil {
+		return errors.AddContext(err, "unable to close siafile")
+	}
+	if profile != skymodules.ArchiveProfileName {
+		return ErrNotArchived
+	}
+	if localPath == dst {
+		if _, err := os.Stat(dst); err == nil {
+			return nil
+		}
+	}
+
+	// Download the file into a temporary file which is renamed once the
+	// download is complete. That way the local copy is never partial.
+	err = os.MkdirAll(filepath.Dir(dst), skymodules.DefaultDirPerm)
+	if err != nil {
+		return errors.AddContext(err, "unable to create retrieval directory")
+	}
+	tmp := dst + "_temp"
+	_, start, _, err := r.DownloadAsync(skymodules.RenterDownloadParameters{
+		Async:       true,
+		SiaPath:     siaPath,
+		Destination: tmp,
+	}, func(err error) error {
+		return r.managedFinishArchiveRetrieval(siaPath, tmp, dst, err)
+	})
+	if err != nil {
+		return errors.AddContext(err, "unable to create retrieval download")
+	}
+	return start()
+}
+
+// managedFinishArchiveRetrieval is called once the download of a retrieval is
+// complete. It moves the retrieved copy into place and sets it as the local
+// copy of the file.
+func (r *Renter) managedFinishArchiveRetrieval(siaPath skymodules.SiaPath, tmp, dst string, downloadErr error) error {
+	if downloadErr != nil {
+		r.staticLog.Printf("Retrieval of archived file %v failed: %v", siaPath, downloadErr)
+		return errors.Compose(downloadErr, os.Remove(tmp))
+	}
+	err := os.Rename(tmp, dst)
+	if err != nil {
+		return errors.AddContext(err, "unable to move retrieved file into place")
+	}
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		// The file was deleted during the retrieval.
+		return errors.Compose(err, os.Remove(dst))
+	}
+	err = entry.SetLocalPath(dst)
+	return errors.Compose(err, entry.Close())
+}
+
+// managedRemoveRetrievedCopy removes the retrieved copy of a file if the file's
+// local path points to one.
+func (r *Renter) managedRemoveRetrievedCopy(localPath string) error {
+	dir := filepath.Join(r.persistDir, archiveRetrievalDir)
+	if localPath == "" || !strings.HasPrefix(localPath, dir+string(filepath.Separator)) {
+		return nil
+	}
+	err := os.Remove(localPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -51,7 +51,86 @@ func (r *Renter) CreateBackup(dst string, secret []byte) error {
 
 // managedCreateBackup creates a backup of the renter's siafiles. If a secret is
 // not nil, the backup will be encrypted using the provided secret.
-func (r *Renter) managedCreateBackup(dst string, secret []byte) (err error) {
+func (r *Renter) managedCreateBackup(dst string, secret []byte) error {
+	return writeBackup(dst, secret, func(gzw io.Writer) error {
+		// Wrap the gzip writer into a tar writer.
+		tw := tar.NewWriter(gzw)
+		// Add the files to the archive.
+		if err := r.managedTarSiaFiles(tw, skymodules.UserFolder); err != nil {
+			return errors.Compose(err, tw.Close())
+		}
+		// Close tar writer to flush it before writing the allowance.
+		if err := tw.Close(); err != nil {
+			return err
+		}
+		// Write the allowance.
+		allowanceBytes, err := json.Marshal(r.staticHostContractor.Allowance())
+		if err != nil {
+			return err
+		}
+		_, err = gzw.Write(allowanceBytes)
+		return err
+	})
+}
+
+// LoadBackup loads the siafiles of a previously created backup into the
+// renter. If the backup is encrypted, secret will be used to decrypt it.
+// Otherwise the argument is ignored.
+func (r *Renter) LoadBackup(src string, secret []byte) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	// Only load a backup if there are no siafiles yet.
+	root, err := r.staticFileSystem.OpenSiaDir(skymodules.UserFolder)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, root.Close())
+	}()
+
+	// Open the gzip file.
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+	return readBackup(f, secret, func(gzr io.Reader) error {
+		// Wrap the gzip reader in a tar reader.
+		tr := tar.NewReader(gzr)
+		// Untar the files.
+		if err := r.managedUntarDir(tr, skymodules.UserFolder); err != nil {
+			return errors.AddContext(err, "failed to untar dir")
+		}
+		// Unmarshal the allowance if available. This needs to happen after
+		// adding decryption and confirming the hash but before adding
+		// decompression.
+		dec := json.NewDecoder(gzr)
+		var allowance skymodules.Allowance
+		if err := dec.Decode(&allowance); err != nil {
+			// legacy backup without allowance
+			r.staticLog.Println("WARN: Decoding the backup's allowance failed: ", err)
+		}
+		// If the backup contained a valid allowance and we currently don't have
+		// an allowance set, import it.
+		if !reflect.DeepEqual(allowance, skymodules.Allowance{}) &&
+			reflect.DeepEqual(r.staticHostContractor.Allowance(), skymodules.Allowance{}) {
+			if err := r.staticHostContractor.SetAllowance(allowance); err != nil {
+				return errors.AddContext(err, "unable to set allowance from backup")
+			}
+		}
+		return nil
+	})
+}
+
+// writeBackup creates a backup at dst. The body of the backup is written to
+// the gzip writer passed to writeBody. If a secret is not nil, the backup will
+// be encrypted using the provided secret.
+func writeBackup(dst string, secret []byte, writeBody func(io.Writer) error) (err error) {
 	// Create the gzip file.
 	f, err := os.Create(dst)
 	if err != nil {
@@ -99,65 +178,27 @@ func (r *Renter) managedCreateBackup(dst string, secret []byte) (err error) {
 	archive = io.MultiWriter(archive, h)
 	// Wrap the potentially encrypted writer into a gzip writer.
 	gzw := gzip.NewWriter(archive)
-	// Wrap the gzip writer into a tar writer.
-	tw := tar.NewWriter(gzw)
-	// Add the files to the archive.
-	if err := r.managedTarSiaFiles(tw); err != nil {
-		twErr := tw.Close()
-		gzwErr := gzw.Close()
-		return errors.Compose(err, twErr, gzwErr)
-	}
-	// Close tar writer to flush it before writing the allowance.
-	twErr := tw.Close()
-	// Write the allowance.
-	allowanceBytes, err := json.Marshal(r.staticHostContractor.Allowance())
-	if err != nil {
-		gzwErr := gzw.Close()
-		return errors.Compose(err, twErr, gzwErr)
-	}
-	_, err = gzw.Write(allowanceBytes)
-	if err != nil {
-		gzwErr := gzw.Close()
-		return errors.Compose(err, twErr, gzwErr)
+	// Write the body.
+	if err := writeBody(gzw); err != nil {
+		return errors.Compose(err, gzw.Close())
 	}
 	// Close the gzip writer to flush it.
-	gzwErr := gzw.Close()
+	if err := gzw.Close(); err != nil {
+		return err
+	}
 	// Write the hash to the beginning of the file.
 	_, err = f.WriteAt(h.Sum(nil), 0)
-	return errors.Compose(err, twErr, gzwErr)
+	return err
 }
 
-// LoadBackup loads the siafiles of a previously created backup into the
-// renter. If the backup is encrypted, secret will be used to decrypt it.
-// Otherwise the argument is ignored.
-func (r *Renter) LoadBackup(src string, secret []byte) (err error) {
-	if err := r.tg.Add(); err != nil {
-		return err
-	}
-	defer r.tg.Done()
-
-	// Only load a backup if there are no siafiles yet.
-	root, err := r.staticFileSystem.OpenSiaDir(skymodules.UserFolder)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		err = errors.Compose(err, root.Close())
-	}()
-
-	// Open the gzip file.
-	f, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		err = errors.Compose(err, f.Close())
-	}()
-	archive := io.Reader(f)
+// readBackup verifies the checksum of the backup read from src and passes a
+// reader for the decrypted and decompressed body of the backup to readBody.
+func readBackup(src io.ReadSeeker, secret []byte, readBody func(io.Reader) error) (err error) {
+	archive := io.Reader(src)
 
 	// Read the checksum.
 	var chks crypto.Hash
-	_, err = io.ReadFull(f, chks[:])
+	_, err = io.ReadFull(src, chks[:])
 	if err != nil {
 		return err
 	}
@@ -178,7 +219,7 @@ func (r *Renter) LoadBackup(src string, secret []byte) (err error) {
 	if err != nil {
 		return err
 	}
-	archive, err = wrapReaderInCipher(io.MultiReader(archive, f), bh, secret)
+	archive, err = wrapReaderInCipher(io.MultiReader(archive, src), bh, secret)
 	if err != nil {
 		return err
 	}
@@ -194,11 +235,11 @@ func (r *Renter) LoadBackup(src string, secret []byte) (err error) {
 		return errors.New("checksum doesn't match")
 	}
 	// Seek back to the beginning of the body.
-	if _, err := f.Seek(-n, io.SeekCurrent); err != nil {
+	if _, err := src.Seek(-n, io.SeekCurrent); err != nil {
 		return err
 	}
 	// Wrap the file again.
-	archive, err = wrapReaderInCipher(f, bh, secret)
+	archive, err = wrapReaderInCipher(src, bh, secret)
 	if err != nil {
 		return err
 	}
@@ -210,37 +251,14 @@ func (r *Renter) LoadBackup(src string, secret []byte) (err error) {
 	defer func() {
 		err = errors.Compose(err, gzr.Close())
 	}()
-	// Wrap the gzip reader in a tar reader.
-	tr := tar.NewReader(gzr)
-	// Untar the files.
-	if err := r.managedUntarDir(tr); err != nil {
-		return errors.AddContext(err, "failed to untar dir")
-	}
-	// Unmarshal the allowance if available. This needs to happen after adding
-	// decryption and confirming the hash but before adding decompression.
-	dec = json.NewDecoder(gzr)
-	var allowance skymodules.Allowance
-	if err := dec.Decode(&allowance); err != nil {
-		// legacy backup without allowance
-		r.staticLog.Println("WARN: Decoding the backup's allowance failed: ", err)
-	}
-	// If the backup contained a valid allowance and we currently don't have an
-	// allowance set, import it.
-	if !reflect.DeepEqual(allowance, skymodules.Allowance{}) &&
-		reflect.DeepEqual(r.staticHostContractor.Allowance(), skymodules.Allowance{}) {
-		if err := r.staticHostContractor.SetAllowance(allowance); err != nil {
-			return errors.AddContext(err, "unable to set allowance from backup")
-		}
-	}
-	return nil
+	return readBody(gzr)
 }
 
-// managedTarSiaFiles creates a tarball from the renter's siafiles and writes
-// it to dst.
-func (r *Renter) managedTarSiaFiles(tw *tar.Writer) error {
-	// Walk over all the siafiles in in the user's home and add them to the
-	// tarball.
-	return r.staticFileSystem.Walk(skymodules.UserFolder, func(path string, info os.FileInfo, statErr error) (err error) {
+// managedTarSiaFiles creates a tarball from the renter's siafiles within the
+// given folder and writes it to tw.
+func (r *Renter) managedTarSiaFiles(tw *tar.Writer, folder skymodules.SiaPath) error {
+	// Walk over all the siafiles in the folder and add them to the tarball.
+	return r.staticFileSystem.Walk(folder, func(path string, info os.FileInfo, statErr error) (err error) {
 		// This error is non-nil if filepath.Walk couldn't stat a file or
 		// folder.
 		if statErr != nil {
@@ -256,7 +274,7 @@ func (r *Renter) managedTarSiaFiles(tw *tar.Writer) error {
 		if err != nil {
 			return err
 		}
-		relPath := strings.TrimPrefix(path, r.staticFileSystem.DirPath(skymodules.UserFolder))
+		relPath := strings.TrimPrefix(path, r.staticFileSystem.DirPath(folder))
 		header.Name = relPath
 		// If the info is a dir there is nothing more to do besides writing the
 		// header.
@@ -267,7 +285,7 @@ func (r *Renter) managedTarSiaFiles(tw *tar.Writer) error {
 		var file io.Reader
 		if filepath.Ext(path) == skymodules.SiaFileExtension {
 			// Get the siafile.
-			siaPath, err := skymodules.UserFolder.Join(strings.TrimSuffix(relPath, skymodules.SiaFileExtension))
+			siaPath, err := folder.Join(strings.TrimSuffix(relPath, skymodules.SiaFileExtension))
 			if err != nil {
 				return err
 			}
@@ -299,9 +317,9 @@ func (r *Renter) managedTarSiaFiles(tw *tar.Writer) error {
 			var siaPath skymodules.SiaPath
 			siaPathStr := strings.TrimSuffix(relPath, skymodules.SiaDirExtension)
 			if siaPathStr == string(filepath.Separator) {
-				siaPath = skymodules.UserFolder
+				siaPath = folder
 			} else {
-				siaPath, err = skymodules.UserFolder.Join(siaPathStr)
+				siaPath, err = folder.Join(siaPathStr)
 				if err != nil {
 					return err
 				}
@@ -340,15 +358,15 @@ func (r *Renter) managedTarSiaFiles(tw *tar.Writer) error {
 	})
 }
 
-// managedUntarDir untars the archive from src and writes the contents to dstFolder
-// while preserving the relative paths within the archive.
-func (r *Renter) managedUntarDir(tr *tar.Reader) (err error) {
+// managedUntarDir untars the archive from src and writes the contents to the
+// given folder while preserving the relative paths within the archive.
+func (r *Renter) managedUntarDir(tr *tar.Reader, folder skymodules.SiaPath) (err error) {
 	// Flush out the health updates before returning so that the user can see a
 	// correct root metadata once the restore is complete.
 	defer r.staticDirUpdateBatcher.callFlush()
 
 	// Copy the files from the tarball to the new location.
-	dir := r.staticFileSystem.DirPath(skymodules.UserFolder)
+	dir := r.staticFileSystem.DirPath(folder)
 	for {
 		header, err := tr.Next()
 		if errors.Contains(err, io.EOF) {
@@ -400,13 +418,18 @@ func (r *Renter) managedUntarDir(tr *tar.Reader) (err error) {
 			}
 			// Try creating a new SiaDir.
 			var siaPath skymodules.SiaPath
-			if err := siaPath.LoadSysPath(r.staticFileSystem.DirPath(skymodules.UserFolder), dst); err != nil {
+			if err := siaPath.LoadSysPath(r.staticFileSystem.DirPath(folder), dst); err != nil {
 				return errors.AddContext(err, "could not load system path")
 			}
 			siaPath, err = siaPath.Dir()
 			if err != nil {
 				return errors.AddContext(err, "could not get directory")
 			}
+			if siaPath.IsRoot() {
+				siaPath = folder
+			} else if siaPath, err = folder.Join(siaPath.String()); err != nil {
+				return errors.AddContext(err, "could not join folders")
+			}
 			err := r.staticFileSystem.NewSiaDir(siaPath, skymodules.DefaultDirPerm)
 			if errors.Contains(err, filesystem.ErrExists) {
 				// .siadir exists already
@@ -431,7 +454,7 @@ func (r *Renter) managedUntarDir(tr *tar.Reader) (err error) {
 		} else if filepath.Ext(info.Name()) == skymodules.SiaFileExtension {
 			// Add the file to the SiaFileSet.
 			reader := bytes.NewReader(b)
-			siaPath, err := skymodules.UserFolder.Join(strings.TrimSuffix(header.Name, skymodules.SiaFileExtension))
+			siaPath, err := folder.Join(strings.TrimSuffix(header.Name, skymodules.SiaFileExtension))
 			if err != nil {
 				return errors.AddContext(err, "could not join folders")
 			}
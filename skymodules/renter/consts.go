@@ -6,6 +6,7 @@ import (
 
 	"gitlab.com/SkynetLabs/skyd/build"
 	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/modules"
 )
 
 // Version and system parameters.
@@ -23,12 +24,42 @@ const (
 	AlertSiafileLowRedundancyThreshold = 0.75
 )
 
+const (
+	// AlertMSGWorkerAccountRefillFailed indicates that refilling the
+	// ephemeral account on a host failed repeatedly.
+	AlertMSGWorkerAccountRefillFailed = "Refilling the ephemeral account on the host mentioned in the 'Cause' failed repeatedly"
+	// AlertMSGWorkerAccountExcessiveBalance indicates that the ephemeral
+	// account on a host holds a lot more money than its balance target.
+	AlertMSGWorkerAccountExcessiveBalance = "The ephemeral account on the host mentioned in the 'Cause' holds an excessive unspent balance"
+	// AlertMSGDownloadChunkStuck indicates that chunks of a download have
+	// been waiting in the download heap for too long.
+	AlertMSGDownloadChunkStuck = "Chunks of the download mentioned in the 'Cause' are stuck in the download heap"
+)
+
 // AlertCauseSiafileLowRedundancy creates a customized "cause" for a siafile
 // with a certain path and health.
 func AlertCauseSiafileLowRedundancy(siaPath skymodules.SiaPath, health, redundancy float64) string {
 	return fmt.Sprintf("Siafile '%v' has a health of %v and redundancy of %v", siaPath.String(), health, redundancy)
 }
 
+// alertIDWorkerAccountRefillFailed creates the alert id for repeatedly failing
+// refills of the ephemeral account on a host.
+func alertIDWorkerAccountRefillFailed(hostKey string) modules.AlertID {
+	return modules.AlertID("worker-account-refill-failed-" + hostKey)
+}
+
+// alertIDWorkerAccountExcessiveBalance creates the alert id for an excessive
+// balance in the ephemeral account on a host.
+func alertIDWorkerAccountExcessiveBalance(hostKey string) modules.AlertID {
+	return modules.AlertID("worker-account-excessive-balance-" + hostKey)
+}
+
+// alertIDDownloadChunkStuck creates the alert id for stuck chunks of a
+// download.
+func alertIDDownloadChunkStuck(uid skymodules.DownloadID) modules.AlertID {
+	return modules.AlertID("download-chunk-stuck-" + string(uid))
+}
+
 // Default redundancy parameters.
 var (
 	// syncCheckInterval is how often the repair heap checks the consensus code
@@ -232,6 +263,31 @@ var (
 		Testing:  5,
 	}).(int)
 
+	// downloadChunkStuckCheckInterval is the interval at which the download
+	// heap is checked for stuck chunks.
+	downloadChunkStuckCheckInterval = build.Select(build.Var{
+		Dev:      10 * time.Second,
+		Standard: 30 * time.Second,
+		Testing:  100 * time.Millisecond,
+	}).(time.Duration)
+
+	// downloadChunkStuckThreshold is the amount of time a chunk can wait in
+	// the download heap before it is considered stuck. Stuck chunks are
+	// prioritized over all other chunks in the heap.
+	downloadChunkStuckThreshold = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 5 * time.Minute,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// downloadChunkStarvedTimeout is the amount of time a chunk can wait in
+	// the download heap before its download is failed.
+	downloadChunkStarvedTimeout = build.Select(build.Var{
+		Dev:      5 * time.Minute,
+		Standard: 30 * time.Minute,
+		Testing:  3 * time.Second,
+	}).(time.Duration)
+
 	// offlineCheckFrequency is how long the renter will wait to check the
 	// online status if it is offline.
 	offlineCheckFrequency = build.Select(build.Var{
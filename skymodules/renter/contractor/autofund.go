@@ -0,0 +1,181 @@
+package contractor
+
+import (
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// ErrAutoFundZeroAmount is returned if auto-funding is enabled without a
+	// top-up amount.
+	ErrAutoFundZeroAmount = errors.New("auto-fund amount must be non-zero")
+	// ErrAutoFundZeroThreshold is returned if auto-funding is enabled without
+	// a threshold.
+	ErrAutoFundZeroThreshold = errors.New("auto-fund threshold must be non-zero")
+	// ErrAutoFundMaxPerPeriodTooLow is returned if the maximum top-up per
+	// period is lower than a single top-up.
+	ErrAutoFundMaxPerPeriodTooLow = errors.New("auto-fund max per period must be at least the auto-fund amount")
+
+	// errAutoFundLimitReached is returned if the allowance can't be topped up
+	// since the maximum top-up for the period was reached.
+	errAutoFundLimitReached = errors.New("the maximum automatic top-up for the current period was reached")
+	// errAutoFundInsufficientBalance is returned if the allowance can't be
+	// topped up since the wallet balance is insufficient.
+	errAutoFundInsufficientBalance = errors.New("the wallet balance is insufficient to top up the allowance")
+)
+
+var (
+	// maxAutoFundEvents is the maximum number of top-ups kept in the audit
+	// log.
+	maxAutoFundEvents = 100
+)
+
+// AutoFundStatus returns the auto-funding settings and the audit log of
+// automatic allowance top-ups.
+func (c *Contractor) AutoFundStatus() skymodules.AutoFundStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	status := c.autoFund
+	status.Events = append([]skymodules.AutoFundEvent{}, c.autoFund.Events...)
+	return status
+}
+
+// SetAutoFundSettings updates the settings for automatically topping up the
+// allowance.
+func (c *Contractor) SetAutoFundSettings(settings skymodules.AutoFundSettings) error {
+	if err := c.staticTG.Add(); err != nil {
+		return err
+	}
+	defer c.staticTG.Done()
+
+	// sanity checks
+	if settings.Enabled {
+		if settings.Amount.IsZero() {
+			return ErrAutoFundZeroAmount
+		} else if settings.Threshold.IsZero() {
+			return ErrAutoFundZeroThreshold
+		} else if settings.MaxPerPeriod.Cmp(settings.Amount) < 0 {
+			return ErrAutoFundMaxPerPeriodTooLow
+		}
+	}
+	c.staticLog.Println("INFO: setting auto-fund settings to", settings)
+
+	c.mu.Lock()
+	c.autoFund.Settings = settings
+	err := c.save()
+	c.mu.Unlock()
+	if err != nil {
+		return errors.AddContext(err, "unable to save contractor after setting auto-fund settings")
+	}
+	if !settings.Enabled {
+		c.staticAlerter.UnregisterAlert(AlertIDRenterAutoFundFailed)
+	}
+	return nil
+}
+
+// managedAutoFund tops up the allowance from the wallet if auto-funding is
+// enabled and the remaining allowance funds dropped below the threshold.
+func (c *Contractor) managedAutoFund() {
+	c.mu.Lock()
+	settings := c.autoFund.Settings
+	if c.autoFund.Period != c.currentPeriod {
+		c.autoFund.Period = c.currentPeriod
+		c.autoFund.PeriodTopUps = types.ZeroCurrency
+	}
+	periodTopUps := c.autoFund.PeriodTopUps
+	c.mu.Unlock()
+	if !settings.Enabled {
+		c.staticAlerter.UnregisterAlert(AlertIDRenterAutoFundFailed)
+		return
+	}
+
+	// Compute the remaining funds the same way contract maintenance does.
+	spending, err := c.PeriodSpending()
+	if err != nil {
+		c.staticLog.Println("WARN: unable to get period spending for auto-funding:", err)
+		return
+	}
+	allowance := c.Allowance()
+	var remaining types.Currency
+	if spending.TotalAllocated.Cmp(allowance.Funds) < 0 {
+		remaining = allowance.Funds.Sub(spending.TotalAllocated)
+	}
+	balance, _, _, err := c.staticWallet.ConfirmedBalance()
+	if err != nil {
+		c.staticLog.Println("WARN: unable to get wallet balance for auto-funding:", err)
+		return
+	}
+	amount, err := autoFundAmount(settings, periodTopUps, remaining, balance)
+	if err != nil {
+		c.staticAlerter.RegisterAlert(AlertIDRenterAutoFundFailed, AlertMSGAutoFundFailed, err.Error(), modules.SeverityWarning)
+		return
+	}
+	c.staticAlerter.UnregisterAlert(AlertIDRenterAutoFundFailed)
+	if amount.IsZero() {
+		return
+	}
+
+	// Top up the allowance. The allowance might have been changed in the
+	// meantime so we only top it up if it is still the one we checked.
+	c.mu.Lock()
+	if !c.allowance.Active() || !c.allowance.Funds.Equals(allowance.Funds) {
+		c.mu.Unlock()
+		return
+	}
+	event := skymodules.AutoFundEvent{
+		Timestamp:      time.Now(),
+		BlockHeight:    c.blockHeight,
+		Amount:         amount,
+		OldFunds:       c.allowance.Funds,
+		NewFunds:       c.allowance.Funds.Add(amount),
+		RemainingFunds: remaining,
+	}
+	c.allowance.Funds = event.NewFunds
+	c.autoFund.PeriodTopUps = c.autoFund.PeriodTopUps.Add(amount)
+	c.autoFund.Events = append(c.autoFund.Events, event)
+	if len(c.autoFund.Events) > maxAutoFundEvents {
+		c.autoFund.Events = c.autoFund.Events[len(c.autoFund.Events)-maxAutoFundEvents:]
+	}
+	allowance = c.allowance
+	err = c.save()
+	c.mu.Unlock()
+	if err != nil {
+		c.staticLog.Println("WARN: unable to save contractor after auto-funding:", err)
+	}
+	c.staticLog.Printf("INFO: automatically topped up allowance by %v from %v to %v", amount.HumanString(), event.OldFunds.HumanString(), event.NewFunds.HumanString())
+
+	// Inform the watchdog and hostdb about the allowance change.
+	c.staticWatchdog.callAllowanceUpdated(allowance)
+	err = c.staticHDB.SetAllowance(allowance)
+	if err != nil {
+		c.staticLog.Println("WARN: unable to update hostdb allowance after auto-funding:", err)
+	}
+}
+
+// autoFundAmount returns the amount the allowance should be topped up by. A
+// zero amount is returned if the remaining funds are above the threshold. The
+// top-up is bounded by the maximum top-up per period and the wallet needs to
+// be able to cover both the remaining funds and the top-up.
+func autoFundAmount(settings skymodules.AutoFundSettings, periodTopUps, remaining, balance types.Currency) (types.Currency, error) {
+	if !settings.Enabled || remaining.Cmp(settings.Threshold) >= 0 {
+		return types.ZeroCurrency, nil
+	}
+	amount := settings.Amount
+	if periodTopUps.Add(amount).Cmp(settings.MaxPerPeriod) > 0 {
+		amount = types.ZeroCurrency
+		if settings.MaxPerPeriod.Cmp(periodTopUps) > 0 {
+			amount = settings.MaxPerPeriod.Sub(periodTopUps)
+		}
+	}
+	if amount.IsZero() {
+		return types.ZeroCurrency, errAutoFundLimitReached
+	}
+	if balance.Cmp(remaining.Add(amount)) < 0 {
+		return types.ZeroCurrency, errAutoFundInsufficientBalance
+	}
+	return amount, nil
+}
@@ -0,0 +1,55 @@
+package contractor
+
+import (
+	"testing"
+
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/types"
+)
+
+// TestAutoFundAmount is a unit test for autoFundAmount.
+func TestAutoFundAmount(t *testing.T) {
+	t.Parallel()
+
+	settings := skymodules.AutoFundSettings{
+		Enabled:      true,
+		Threshold:    types.NewCurrency64(100),
+		Amount:       types.NewCurrency64(50),
+		MaxPerPeriod: types.NewCurrency64(120),
+	}
+	disabled := settings
+	disabled.Enabled = false
+
+	tests := []struct {
+		settings     skymodules.AutoFundSettings
+		periodTopUps uint64
+		remaining    uint64
+		balance      uint64
+		amount       uint64
+		err          error
+	}{
+		// Disabled.
+		{settings: disabled, remaining: 10, balance: 1000},
+		// Above threshold.
+		{settings: settings, remaining: 100, balance: 1000},
+		// Full top-up.
+		{settings: settings, remaining: 10, balance: 1000, amount: 50},
+		// Top-up bounded by the limit of the period.
+		{settings: settings, periodTopUps: 100, remaining: 10, balance: 1000, amount: 20},
+		// Limit reached.
+		{settings: settings, periodTopUps: 120, remaining: 10, balance: 1000, err: errAutoFundLimitReached},
+		// Wallet can't cover the remaining funds and the top-up.
+		{settings: settings, remaining: 10, balance: 59, err: errAutoFundInsufficientBalance},
+		// Wallet covers exactly the remaining funds and the top-up.
+		{settings: settings, remaining: 10, balance: 60, amount: 50},
+	}
+	for i, test := range tests {
+		amount, err := autoFundAmount(test.settings, types.NewCurrency64(test.periodTopUps), types.NewCurrency64(test.remaining), types.NewCurrency64(test.balance))
+		if err != test.err {
+			t.Fatalf("%v: wrong error %v != %v", i, err, test.err)
+		}
+		if !amount.Equals64(test.amount) {
+			t.Fatalf("%v: wrong amount %v != %v", i, amount, test.amount)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package contractor
 
 import (
+	"bytes"
 	"sort"
 	"sync"
 
@@ -30,14 +31,20 @@ type churnLimiter struct {
 	// churned in the current period.
 	aggregateCurrentPeriodChurn uint64
 
+	// deferredChurn are the contracts which weren't churned because they
+	// exceeded the churn budget. They are churned once there is enough
+	// budget, which might be in one of the next periods.
+	deferredChurn map[types.FileContractID]skymodules.DeferredChurnContract
+
 	mu               sync.Mutex
 	staticContractor *Contractor
 }
 
 // churnLimiterPersist is the persisted state of a churnLimiter.
 type churnLimiterPersist struct {
-	AggregateCurrentPeriodChurn uint64 `json:"aggregatecurrentperiodchurn"`
-	RemainingChurnBudget        int    `json:"remainingchurnbudget"`
+	AggregateCurrentPeriodChurn uint64                             `json:"aggregatecurrentperiodchurn"`
+	RemainingChurnBudget        int                                `json:"remainingchurnbudget"`
+	DeferredChurn               []skymodules.DeferredChurnContract `json:"deferredchurn"`
 }
 
 // managedMaxPeriodChurn returns the MaxPeriodChurn of the churnLimiter.
@@ -50,30 +57,72 @@ func (cl *churnLimiter) managedMaxPeriodChurn() uint64 {
 func (cl *churnLimiter) callPersistData() churnLimiterPersist {
 	cl.mu.Lock()
 	defer cl.mu.Unlock()
-	return churnLimiterPersist{cl.aggregateCurrentPeriodChurn, cl.remainingChurnBudget}
+	return churnLimiterPersist{
+		AggregateCurrentPeriodChurn: cl.aggregateCurrentPeriodChurn,
+		RemainingChurnBudget:        cl.remainingChurnBudget,
+		DeferredChurn:               cl.deferredContracts(),
+	}
+}
+
+// deferredContracts returns the contracts whose churn was deferred sorted by
+// the height at which they were deferred.
+func (cl *churnLimiter) deferredContracts() []skymodules.DeferredChurnContract {
+	deferred := make([]skymodules.DeferredChurnContract, 0, len(cl.deferredChurn))
+	for _, dc := range cl.deferredChurn {
+		deferred = append(deferred, dc)
+	}
+	sort.Slice(deferred, func(i, j int) bool {
+		if deferred[i].DeferredSince != deferred[j].DeferredSince {
+			return deferred[i].DeferredSince < deferred[j].DeferredSince
+		}
+		return bytes.Compare(deferred[i].ID[:], deferred[j].ID[:]) < 0
+	})
+	return deferred
 }
 
 // newChurnLimiterFromPersist creates a new churnLimiter using persisted state.
 func newChurnLimiterFromPersist(contractor *Contractor, persistData churnLimiterPersist) *churnLimiter {
-	return &churnLimiter{
+	cl := &churnLimiter{
 		staticContractor:            contractor,
 		aggregateCurrentPeriodChurn: persistData.AggregateCurrentPeriodChurn,
 		remainingChurnBudget:        persistData.RemainingChurnBudget,
+		deferredChurn:               make(map[types.FileContractID]skymodules.DeferredChurnContract),
+	}
+	for _, dc := range persistData.DeferredChurn {
+		cl.deferredChurn[dc.ID] = dc
 	}
+	return cl
 }
 
 // newChurnLimiter returns a new churnLimiter.
 func newChurnLimiter(contractor *Contractor) *churnLimiter {
-	return &churnLimiter{staticContractor: contractor}
+	return &churnLimiter{
+		staticContractor: contractor,
+		deferredChurn:    make(map[types.FileContractID]skymodules.DeferredChurnContract),
+	}
 }
 
-// ChurnStatus returns the current period's aggregate churn and the max churn
-// per period.
+// ChurnStatus returns the current period's aggregate churn, the max churn per
+// period, the remaining churn budgets and the contracts whose churn was
+// deferred.
 func (c *Contractor) ChurnStatus() skymodules.ContractorChurnStatus {
-	aggregateChurn, maxChurn := c.staticChurnLimiter.managedAggregateAndMaxChurn()
+	cl := c.staticChurnLimiter
+	aggregateChurn, maxChurn := cl.managedAggregateAndMaxChurn()
+	currentBudget, periodBudget := cl.managedChurnBudget()
+	cl.mu.Lock()
+	deferred := cl.deferredContracts()
+	cl.mu.Unlock()
+	var deferredChurn uint64
+	for _, dc := range deferred {
+		deferredChurn += dc.Size
+	}
 	return skymodules.ContractorChurnStatus{
 		AggregateCurrentPeriodChurn: aggregateChurn,
 		MaxPeriodChurn:              maxChurn,
+		RemainingChurnBudget:        int64(currentBudget),
+		RemainingPeriodBudget:       int64(periodBudget),
+		DeferredChurn:               deferredChurn,
+		DeferredContracts:           deferred,
 	}
 }
 
@@ -82,10 +131,35 @@ func (c *Contractor) ChurnStatus() skymodules.ContractorChurnStatus {
 func (cl *churnLimiter) callResetAggregateChurn() {
 	cl.mu.Lock()
 	cl.staticContractor.staticLog.Println("Aggregate Churn for last period: ", cl.aggregateCurrentPeriodChurn)
+	if len(cl.deferredChurn) > 0 {
+		cl.staticContractor.staticLog.Printf("Deferring churn of %v contracts to the new period", len(cl.deferredChurn))
+	}
 	cl.aggregateCurrentPeriodChurn = 0
 	cl.mu.Unlock()
 }
 
+// callUpdateDeferredChurn replaces the contracts whose churn is deferred with
+// the given ones. Contracts that were already deferred keep the height at which
+// they were first deferred.
+func (cl *churnLimiter) callUpdateDeferredChurn(contracts []skymodules.RenterContract, blockHeight types.BlockHeight) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	deferredChurn := make(map[types.FileContractID]skymodules.DeferredChurnContract, len(contracts))
+	for _, contract := range contracts {
+		dc, exists := cl.deferredChurn[contract.ID]
+		if !exists {
+			dc = skymodules.DeferredChurnContract{
+				ID:            contract.ID,
+				HostPublicKey: contract.HostPublicKey,
+				DeferredSince: blockHeight,
+			}
+		}
+		dc.Size = contract.Transaction.FileContractRevisions[0].NewFileSize
+		deferredChurn[contract.ID] = dc
+	}
+	cl.deferredChurn = deferredChurn
+}
+
 // callNotifyChurnedContract adds the size of this contract's files to the aggregate
 // churn in this period. Must be called when contracts are marked !GFR.
 func (cl *churnLimiter) callNotifyChurnedContract(contract skymodules.RenterContract) {
@@ -143,6 +217,7 @@ func (cl *churnLimiter) managedProcessSuggestedUpdates(queue []contractScoreAndU
 		return queue[i].score.Cmp(queue[j].score) < 0
 	})
 
+	var deferred []skymodules.RenterContract
 	var queuedContract contractScoreAndUtil
 	for len(queue) > 0 {
 		queuedContract, queue = queue[0], queue[1:]
@@ -157,6 +232,7 @@ func (cl *churnLimiter) managedProcessSuggestedUpdates(queue []contractScoreAndU
 			currentBudget, periodBudget := cl.managedChurnBudget()
 			cl.staticContractor.staticLog.Debugf("Remaining Churn Budget: %d. Remaining Period Budget: %d", currentBudget, periodBudget)
 			queuedContract.util.GoodForRenew = true
+			deferred = append(deferred, queuedContract.contract)
 		}
 
 		if churningThisContract {
@@ -169,6 +245,13 @@ func (cl *churnLimiter) managedProcessSuggestedUpdates(queue []contractScoreAndU
 			return err
 		}
 	}
+
+	// Remember the contracts whose churn was deferred.
+	c := cl.staticContractor
+	c.mu.RLock()
+	blockHeight := c.blockHeight
+	c.mu.RUnlock()
+	cl.callUpdateDeferredChurn(deferred, blockHeight)
 	return nil
 }
 
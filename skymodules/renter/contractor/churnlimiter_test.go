@@ -81,3 +81,49 @@ func TestCanChurnContract(t *testing.T) {
 		t.Fatal("Expected not to be able to churn contract")
 	}
 }
+
+// TestDeferredChurn tests the tracking of contracts whose churn was deferred.
+func TestDeferredChurn(t *testing.T) {
+	allowance := skymodules.DefaultAllowance
+	allowance.MaxPeriodChurn = 1000
+	c := &Contractor{
+		allowance: allowance,
+	}
+	cl := newChurnLimiter(c)
+	c.staticChurnLimiter = cl
+
+	// contractWithID is a helper to create a contract with an ID.
+	contractWithID := func(id byte, size uint64) skymodules.RenterContract {
+		contract := contractWithSize(size)
+		contract.ID = types.FileContractID{id}
+		return contract
+	}
+
+	// Defer the churn of two contracts.
+	cl.callUpdateDeferredChurn([]skymodules.RenterContract{contractWithID(1, 100), contractWithID(2, 200)}, 10)
+	status := c.ChurnStatus()
+	if status.DeferredChurn != 300 || len(status.DeferredContracts) != 2 {
+		t.Fatal("wrong status", status)
+	}
+
+	// One of them is still deferred, the other one was churned and a new one
+	// was deferred. The still deferred one keeps its height but its size is
+	// updated.
+	cl.callUpdateDeferredChurn([]skymodules.RenterContract{contractWithID(3, 50), contractWithID(2, 250)}, 20)
+	status = c.ChurnStatus()
+	if status.DeferredChurn != 300 || len(status.DeferredContracts) != 2 {
+		t.Fatal("wrong status", status)
+	}
+	if dc := status.DeferredContracts[0]; dc.ID != (types.FileContractID{2}) || dc.DeferredSince != 10 || dc.Size != 250 {
+		t.Fatal("wrong deferred contract", dc)
+	}
+	if dc := status.DeferredContracts[1]; dc.ID != (types.FileContractID{3}) || dc.DeferredSince != 20 || dc.Size != 50 {
+		t.Fatal("wrong deferred contract", dc)
+	}
+
+	// The deferred contracts are persisted.
+	loaded := newChurnLimiterFromPersist(c, cl.callPersistData())
+	if len(loaded.deferredChurn) != 2 || loaded.deferredChurn[types.FileContractID{2}].DeferredSince != 10 {
+		t.Fatal("wrong deferred churn after loading", loaded.deferredChurn)
+	}
+}
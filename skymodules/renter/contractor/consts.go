@@ -6,6 +6,22 @@ import (
 	"go.sia.tech/siad/types"
 )
 
+// AlertIDs of the contractor's alerts.
+const (
+	// AlertIDRenterContractRenewalUnconfirmed is the id of the alert that is
+	// registered if renewal transactions fail to confirm on-chain.
+	AlertIDRenterContractRenewalUnconfirmed modules.AlertID = "contract-renewal-unconfirmed"
+
+	// AlertIDRenterAllowanceProjectedLowFunds is the id of the alert that is
+	// registered if the allowance is projected to run out of funds before
+	// the end of the current period.
+	AlertIDRenterAllowanceProjectedLowFunds modules.AlertID = "projected-low-funds"
+
+	// AlertIDRenterAutoFundFailed is the id of the alert that is registered if
+	// the allowance needs to be topped up but the top-up isn't possible.
+	AlertIDRenterAutoFundFailed modules.AlertID = "auto-fund-failed"
+)
+
 // Constants related to the contractor's alerts.
 var (
 	// AlertCauseInsufficientAllowanceFunds indicates that the cause for the
@@ -17,9 +33,22 @@ var (
 	// funds.
 	AlertMSGAllowanceLowFunds = "At least one contract formation/renewal failed due to the allowance being low on funds"
 
+	// AlertMSGAllowanceProjectedLowFunds indicates that the projected spending
+	// of the current period exceeds the allowance funds.
+	AlertMSGAllowanceProjectedLowFunds = "The allowance is projected to run out of funds before the end of the current period"
+
+	// AlertMSGAutoFundFailed indicates that the remaining allowance funds are
+	// below the auto-funding threshold but the allowance couldn't be topped
+	// up.
+	AlertMSGAutoFundFailed = "The allowance is low on funds and couldn't be topped up automatically"
+
 	// AlertMSGFailedContractRenewal indicates that the contract renewal failed
 	AlertMSGFailedContractRenewal = "Contractor is attempting to renew/refresh contracts but failed"
 
+	// AlertMSGRenewalUnconfirmed indicates that at least one renewal
+	// transaction didn't confirm on-chain in time or was dropped.
+	AlertMSGRenewalUnconfirmed = "At least one contract renewal transaction failed to confirm on-chain"
+
 	// AlertMSGWalletLockedDuringMaintenance indicates that forming/renewing a
 	// contract during contract maintenance isn't possible due to a locked wallet.
 	AlertMSGWalletLockedDuringMaintenance = "At least one contract failed to form/renew due to the wallet being locked"
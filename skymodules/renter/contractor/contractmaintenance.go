@@ -51,6 +51,12 @@ type (
 		amount     types.Currency
 		data       uint64
 		hostPubKey types.SiaPublicKey
+
+		// allowance and endHeight are the allowance and the new end height
+		// of the contract. They differ from the renter's allowance for
+		// contracts with the hosts of an allowance profile.
+		allowance skymodules.Allowance
+		endHeight types.BlockHeight
 	}
 )
 
@@ -112,16 +118,18 @@ func hostsForPortalFormation(allowance skymodules.Allowance, allContracts []skym
 
 // hostsForRegularFormation returns the number of hosts needed for
 // non-portal contract formation plus a set of hosts to use.
-func hostsForRegularFormation(allowance skymodules.Allowance, allContracts []skymodules.RenterContract, recoverableContracts []skymodules.RecoverableContract, randomHosts func(_ int, _, _ []types.SiaPublicKey) ([]skymodules.HostDBEntry, error), l *persist.Logger) (int, []skymodules.HostDBEntry) {
+func hostsForRegularFormation(allowance skymodules.Allowance, allContracts []skymodules.RenterContract, recoverableContracts []skymodules.RecoverableContract, hostProfiles map[string]string, randomHosts func(_ int, _, _ []types.SiaPublicKey) ([]skymodules.HostDBEntry, error), l *persist.Logger) (int, []skymodules.HostDBEntry) {
 	if allowance.PortalMode() {
 		build.Critical("hostsForRegularFormation was called on a portal")
 		return 0, nil
 	}
 	// Count the number of contracts which are good for uploading, and then make
-	// more as needed to fill the gap.
+	// more as needed to fill the gap. Contracts with the hosts of allowance
+	// profiles don't count towards the regular hosts.
 	uploadContracts := 0
 	for _, c := range allContracts {
-		if c.Utility.GoodForUpload {
+		_, isProfileHost := hostProfiles[c.HostPublicKey.String()]
+		if c.Utility.GoodForUpload && !isProfileHost {
 			uploadContracts++
 		}
 	}
@@ -134,12 +142,83 @@ func hostsForRegularFormation(allowance skymodules.Allowance, allContracts []sky
 		l.Println("need more contracts:", neededContracts)
 	}
 
-	// Assemble two exclusion lists. The first one excludes all hosts that we
-	// already have contracts with and the second one excludes all hosts we have
-	// active contracts with. Then select a new batch of hosts to attempt
-	// contract formation with.
-	var blacklist []types.SiaPublicKey
-	var addressBlacklist []types.SiaPublicKey
+	blacklist, addressBlacklist := formationBlacklists(allContracts, recoverableContracts)
+	hosts, err := randomHosts(neededContracts*4+randomHostsBufferForScore, blacklist, addressBlacklist)
+	if err != nil {
+		l.Println("WARN: not forming new contracts:", err)
+		return 0, nil
+	}
+	l.Debugln("trying to form contracts with hosts, pulled this many hosts from hostdb:", len(hosts))
+	return neededContracts, hosts
+}
+
+// hostsForProfileFormation returns the number of hosts needed for the
+// allowance profile with the given name plus a set of hosts to use. The
+// provided allowance is the allowance of the profile.
+func hostsForProfileFormation(profile string, allowance skymodules.Allowance, allContracts []skymodules.RenterContract, recoverableContracts []skymodules.RecoverableContract, hostProfiles map[string]string, randomHosts func(_ int, _, _ []types.SiaPublicKey, _ skymodules.Allowance) ([]skymodules.HostDBEntry, error), l *persist.Logger) (int, []skymodules.HostDBEntry) {
+	if allowance.PortalMode() {
+		build.Critical("hostsForProfileFormation was called on a portal")
+		return 0, nil
+	}
+	if allowance.Hosts == 0 {
+		return 0, nil
+	}
+	// Count the number of contracts of the profile which are good for
+	// uploading.
+	profileContracts := 0
+	for _, c := range allContracts {
+		if c.Utility.GoodForUpload && hostProfiles[c.HostPublicKey.String()] == profile {
+			profileContracts++
+		}
+	}
+	neededContracts := int(allowance.Hosts) - profileContracts
+	if neededContracts <= 0 {
+		l.Debugf("do not seem to need more contracts for profile %v", profile)
+		return 0, nil
+	}
+	l.Printf("need more contracts for profile %v: %v", profile, neededContracts)
+
+	blacklist, addressBlacklist := formationBlacklists(allContracts, recoverableContracts)
+	candidates, err := randomHosts(neededContracts*4+randomHostsBufferForScore, blacklist, addressBlacklist, allowance)
+	if err != nil {
+		l.Printf("WARN: not forming new contracts for profile %v: %v", profile, err)
+		return 0, nil
+	}
+	// Ignore hosts that exceed the price caps of the profile.
+	var hosts []skymodules.HostDBEntry
+	for _, host := range candidates {
+		if err := checkProfilePriceCaps(allowance, host); err != nil {
+			l.Debugf("skipping host %v for profile %v: %v", host.PublicKey, profile, err)
+			continue
+		}
+		hosts = append(hosts, host)
+	}
+	l.Debugf("trying to form contracts for profile %v, pulled this many hosts from hostdb: %v", profile, len(hosts))
+	return neededContracts, hosts
+}
+
+// checkProfilePriceCaps returns an error if the host's prices exceed the price
+// caps of a profile's allowance.
+func checkProfilePriceCaps(allowance skymodules.Allowance, host skymodules.HostDBEntry) error {
+	if !allowance.MaxStoragePrice.IsZero() && host.StoragePrice.Cmp(allowance.MaxStoragePrice) > 0 {
+		return errors.New("storage price exceeds the profile's max storage price")
+	}
+	if !allowance.MaxUploadBandwidthPrice.IsZero() && host.UploadBandwidthPrice.Cmp(allowance.MaxUploadBandwidthPrice) > 0 {
+		return errors.New("upload bandwidth price exceeds the profile's max upload bandwidth price")
+	}
+	if !allowance.MaxDownloadBandwidthPrice.IsZero() && host.DownloadBandwidthPrice.Cmp(allowance.MaxDownloadBandwidthPrice) > 0 {
+		return errors.New("download bandwidth price exceeds the profile's max download bandwidth price")
+	}
+	if !allowance.MaxContractPrice.IsZero() && host.ContractPrice.Cmp(allowance.MaxContractPrice) > 0 {
+		return errors.New("contract price exceeds the profile's max contract price")
+	}
+	return nil
+}
+
+// formationBlacklists assembles two exclusion lists for contract formation.
+// The first one excludes all hosts that we already have contracts with and the
+// second one excludes all hosts we have active contracts with.
+func formationBlacklists(allContracts []skymodules.RenterContract, recoverableContracts []skymodules.RecoverableContract) (blacklist, addressBlacklist []types.SiaPublicKey) {
 	for _, contract := range allContracts {
 		blacklist = append(blacklist, contract.HostPublicKey)
 		if !contract.Utility.Locked || contract.Utility.GoodForRenew || contract.Utility.GoodForUpload {
@@ -151,14 +230,7 @@ func hostsForRegularFormation(allowance skymodules.Allowance, allContracts []sky
 	for _, contract := range recoverableContracts {
 		blacklist = append(blacklist, contract.HostPublicKey)
 	}
-
-	hosts, err := randomHosts(neededContracts*4+randomHostsBufferForScore, blacklist, addressBlacklist)
-	if err != nil {
-		l.Println("WARN: not forming new contracts:", err)
-		return 0, nil
-	}
-	l.Debugln("trying to form contracts with hosts, pulled this many hosts from hostdb:", len(hosts))
-	return neededContracts, hosts
+	return
 }
 
 // initialContractFunding computes the amount of money to put into the first
@@ -522,20 +594,25 @@ func (c *Contractor) managedFindMinAllowedHostScores() (types.Currency, types.Cu
 // managedNewContract negotiates an initial file contract with the specified
 // host, saves it, and returns it.
 func (c *Contractor) managedNewContract(host skymodules.HostDBEntry, contractFunding types.Currency, endHeight types.BlockHeight) (_ types.Currency, _ skymodules.RenterContract, err error) {
+	c.mu.RLock()
+	allowance := c.allowance
+	c.mu.RUnlock()
+	return c.managedNewContractWithAllowance(host, contractFunding, endHeight, allowance)
+}
+
+// managedNewContractWithAllowance negotiates an initial file contract with the
+// specified host using the provided allowance, saves it, and returns it.
+func (c *Contractor) managedNewContractWithAllowance(host skymodules.HostDBEntry, contractFunding types.Currency, endHeight types.BlockHeight, allowance skymodules.Allowance) (_ types.Currency, _ skymodules.RenterContract, err error) {
 	// reject hosts that are too expensive
 	if host.StoragePrice.Cmp(maxStoragePrice) > 0 {
 		return types.ZeroCurrency, skymodules.RenterContract{}, errTooExpensive
 	}
 	// Determine if host settings align with allowance period
-	c.mu.Lock()
-	if reflect.DeepEqual(c.allowance, skymodules.Allowance{}) {
-		c.mu.Unlock()
+	if reflect.DeepEqual(allowance, skymodules.Allowance{}) {
 		return types.ZeroCurrency, skymodules.RenterContract{}, errors.New("called managedNewContract but allowance wasn't set")
 	}
-	allowance := c.allowance
 	hostSettings := host.HostExternalSettings
-	period := c.allowance.Period
-	c.mu.Unlock()
+	period := allowance.Period
 
 	if host.MaxDuration < period {
 		err := errors.New("unable to form contract with host due to insufficient MaxDuration of host")
@@ -575,7 +652,7 @@ func (c *Contractor) managedNewContract(host skymodules.HostDBEntry, contractFun
 	// create contract params
 	c.mu.RLock()
 	params := skymodules.ContractParams{
-		Allowance:     c.allowance,
+		Allowance:     allowance,
 		Host:          host,
 		Funding:       contractFunding,
 		StartHeight:   c.blockHeight,
@@ -601,13 +678,12 @@ func (c *Contractor) managedNewContract(host skymodules.HostDBEntry, contractFun
 	}
 
 	monitorContractArgs := monitorContractArgs{
-		false,
-		contract.ID,
-		contract.Transaction,
-		formationTxnSet,
-		sweepTxn,
-		sweepParents,
-		params.StartHeight,
+		fcID:            contract.ID,
+		revisionTxn:     contract.Transaction,
+		formationTxnSet: formationTxnSet,
+		sweepTxn:        sweepTxn,
+		sweepParents:    sweepParents,
+		blockHeight:     params.StartHeight,
 	}
 	err = c.staticWatchdog.callMonitorContract(monitorContractArgs)
 	if err != nil {
@@ -684,6 +760,10 @@ func (c *Contractor) managedLimitGFUHosts() {
 	for hk := range c.preferredHosts {
 		preferredHosts[hk] = struct{}{}
 	}
+	profileHosts := make(map[string]struct{})
+	for hk := range c.hostProfiles {
+		profileHosts[hk] = struct{}{}
+	}
 	c.mu.Unlock()
 
 	potentialHosts := make(map[string]struct{})
@@ -692,6 +772,11 @@ func (c *Contractor) managedLimitGFUHosts() {
 		if !contract.Utility.GoodForUpload {
 			continue
 		}
+		// The hosts of allowance profiles are limited by their profiles
+		// instead.
+		if _, isProfileHost := profileHosts[contract.HostPublicKey.String()]; isProfileHost {
+			continue
+		}
 		// If it is gfu, mark the corresponding host as a potential candidate.
 		potentialHosts[contract.HostPublicKey.String()] = struct{}{}
 	}
@@ -734,6 +819,10 @@ func (c *Contractor) managedLimitGFUHosts() {
 		if isPreferred {
 			continue // nothing to do
 		}
+		_, isProfileHost := profileHosts[contract.HostPublicKey.String()]
+		if isProfileHost {
+			continue // nothing to do
+		}
 		if !contract.Utility.GoodForUpload {
 			continue // nothing to do
 		}
@@ -787,16 +876,10 @@ func staticCheckFormPaymentContractGouging(allowance skymodules.Allowance, hostS
 // checkFormContractGouging will check whether the pricing for forming
 // this contract triggers any price gouging warnings.
 func checkFormContractGouging(allowance skymodules.Allowance, hostSettings modules.HostExternalSettings) error {
-	// Check whether the RPC base price is too high.
-	if !allowance.MaxRPCPrice.IsZero() && allowance.MaxRPCPrice.Cmp(hostSettings.BaseRPCPrice) < 0 {
-		return errors.New("rpc base price of host is too high - price gouging protection enabled")
-	}
-	// Check whether the form contract price is too high.
-	if !allowance.MaxContractPrice.IsZero() && allowance.MaxContractPrice.Cmp(hostSettings.ContractPrice) < 0 {
-		return errors.New("contract price of host is too high - price gouging protection enabled")
-	}
-
-	return nil
+	// Check whether the RPC base price or the form contract price are too
+	// high.
+	report := skymodules.NewGougingChecker(allowance).CheckHostSettings(hostSettings)
+	return report.Err(skymodules.GougingFieldBaseRPCPrice, skymodules.GougingFieldContractPrice)
 }
 
 // managedRenew negotiates a new contract for data already stored with a host.
@@ -824,7 +907,7 @@ func (c *Contractor) managedRenew(id types.FileContractID, hpk types.SiaPublicKe
 	}
 
 	c.mu.Lock()
-	a := c.allowance
+	a, _ := c.hostAllowance(hpk.String())
 	c.mu.Unlock()
 	if reflect.DeepEqual(a, skymodules.Allowance{}) {
 		return skymodules.RenterContract{}, errors.New("called managedRenew but allowance isn't set")
@@ -875,7 +958,7 @@ func (c *Contractor) managedRenew(id types.FileContractID, hpk types.SiaPublicKe
 	// create contract params
 	c.mu.RLock()
 	params := skymodules.ContractParams{
-		Allowance:     c.allowance,
+		Allowance:     a,
 		Host:          host,
 		Funding:       contractFunding,
 		StartHeight:   c.blockHeight,
@@ -935,13 +1018,13 @@ func (c *Contractor) managedRenew(id types.FileContractID, hpk types.SiaPublicKe
 	}
 
 	monitorContractArgs := monitorContractArgs{
-		false,
-		newContract.ID,
-		newContract.Transaction,
-		formationTxnSet,
-		sweepTxn,
-		sweepParents,
-		params.StartHeight,
+		fcID:            newContract.ID,
+		revisionTxn:     newContract.Transaction,
+		formationTxnSet: formationTxnSet,
+		sweepTxn:        sweepTxn,
+		sweepParents:    sweepParents,
+		blockHeight:     params.StartHeight,
+		renewedFrom:     id,
 	}
 	err = c.staticWatchdog.callMonitorContract(monitorContractArgs)
 	if err != nil {
@@ -1040,13 +1123,19 @@ func (c *Contractor) managedRenewContract(renewInstructions fileContractRenewal,
 	oldUtility := oldContract.Utility()
 	if errRenew != nil {
 		// Increment the number of failed renews for the contract if it
-		// was the host's fault.
-		if skymodules.IsHostsFault(errRenew) {
+		// was the host's fault. Local and network failures don't count
+		// towards replacing the contract.
+		switch {
+		case skymodules.IsHostsFault(errRenew):
 			c.mu.Lock()
 			c.numFailedRenews[oldContract.Metadata().ID]++
 			totalFailures := c.numFailedRenews[oldContract.Metadata().ID]
 			c.mu.Unlock()
 			c.staticLog.Debugln("remote host determined to be at fault, tallying up failed renews", totalFailures, id)
+		case skymodules.IsNetworkFault(errRenew):
+			c.staticLog.Debugln("renew failed due to a network error, not tallying up failed renews", id)
+		case skymodules.IsLocalFault(errRenew):
+			c.staticLog.Debugln("renew failed due to a local error, not tallying up failed renews", id)
 		}
 
 		// Check if contract has to be replaced.
@@ -1273,6 +1362,13 @@ func (c *Contractor) threadedContractMaintenance() {
 		return
 	}
 
+	// Warn the user if the allowance is projected to run out of funds before
+	// the end of the period.
+	c.managedCheckSpendingForecast()
+
+	// Top up the allowance if it is low on funds and auto-funding is enabled.
+	c.managedAutoFund()
+
 	// The rest of this function needs to know a few of the stateful variables
 	// from the contractor, build those up under a lock so that the rest of the
 	// function can execute without lock contention.
@@ -1332,6 +1428,16 @@ func (c *Contractor) threadedContractMaintenance() {
 			continue
 		}
 
+		// Contracts with the hosts of an allowance profile are renewed using
+		// the profile's allowance and period.
+		c.mu.RLock()
+		contractAllowance, profile := c.hostAllowance(contract.HostPublicKey.String())
+		contractEndHeight := endHeight
+		if profile != "" {
+			contractEndHeight = c.profileEndHeight(profile)
+		}
+		c.mu.RUnlock()
+
 		// Skip any contracts which do not exist or are otherwise unworthy for
 		// renewal.
 		utility, ok := c.managedContractUtility(contract.ID)
@@ -1347,8 +1453,8 @@ func (c *Contractor) threadedContractMaintenance() {
 		// calculate a spending for the contract that is proportional to how
 		// much money was spend on the contract throughout this billing cycle
 		// (which is now ending).
-		if blockHeight+allowance.RenewWindow >= contract.EndHeight && !c.staticDeps.Disrupt("disableRenew") {
-			renewAmount, err := c.managedEstimateRenewFundingRequirements(contract, gfrContracts, blockHeight, allowance)
+		if blockHeight+contractAllowance.RenewWindow >= contract.EndHeight && !c.staticDeps.Disrupt("disableRenew") {
+			renewAmount, err := c.managedEstimateRenewFundingRequirements(contract, gfrContracts, blockHeight, contractAllowance)
 			if err != nil {
 				c.staticLog.Debugln("Contract skipped because there was an error estimating renew funding requirements", renewAmount, err)
 				continue
@@ -1358,6 +1464,8 @@ func (c *Contractor) threadedContractMaintenance() {
 				amount:     renewAmount,
 				data:       contract.Size(),
 				hostPubKey: contract.HostPublicKey,
+				allowance:  contractAllowance,
+				endHeight:  contractEndHeight,
 			})
 			c.staticLog.Debugln("Contract has been added to the renew set for being past the renew height")
 			continue
@@ -1367,7 +1475,7 @@ func (c *Contractor) threadedContractMaintenance() {
 		// if less than 'minContractFundRenewalThreshold' funds are remaining
 		// (3% at time of writing), or if there is less than 3 sectors worth of
 		// storage+upload+download remaining.
-		blockBytes := types.NewCurrency64(modules.SectorSize * uint64(allowance.Period))
+		blockBytes := types.NewCurrency64(modules.SectorSize * uint64(contractAllowance.Period))
 		sectorStoragePrice := host.StoragePrice.Mul(blockBytes)
 		sectorUploadBandwidthPrice := host.UploadBandwidthPrice.Mul64(modules.SectorSize)
 		sectorDownloadBandwidthPrice := host.DownloadBandwidthPrice.Mul64(modules.SectorSize)
@@ -1389,8 +1497,8 @@ func (c *Contractor) threadedContractMaintenance() {
 			// the user in the event that the user stops uploading immediately
 			// after the renew.
 			refreshAmount := contract.TotalCost.Mul64(2)
-			minInitialContractFunds := allowance.Funds.Div64(allowance.Hosts).Div64(MinInitialContractFundingDivFactor)
-			minimum := initialContractFunding(allowance, host, txnFee, minInitialContractFunds, types.ZeroCurrency)
+			minInitialContractFunds := contractAllowance.Funds.Div64(contractAllowance.Hosts).Div64(MinInitialContractFundingDivFactor)
+			minimum := initialContractFunding(contractAllowance, host, txnFee, minInitialContractFunds, types.ZeroCurrency)
 			if refreshAmount.Cmp(minimum) < 0 {
 				refreshAmount = minimum
 				c.staticLog.Printf("Contract refresh amount %v below minimum amount %v", refreshAmount, minimum)
@@ -1400,6 +1508,8 @@ func (c *Contractor) threadedContractMaintenance() {
 				amount:     refreshAmount,
 				data:       contract.Size(),
 				hostPubKey: contract.HostPublicKey,
+				allowance:  contractAllowance,
+				endHeight:  contractEndHeight,
 			})
 			c.staticLog.Debugln("Contract identified as needing to be added to refresh set", contract.RenterFunds, sectorPrice.Mul64(3), percentRemaining, MinContractFundRenewalThreshold)
 		} else {
@@ -1512,7 +1622,7 @@ func (c *Contractor) threadedContractMaintenance() {
 		// Renew one contract. The error is ignored because the renew function
 		// already will have logged the error, and in the event of an error,
 		// 'fundsSpent' will return '0'.
-		fundsSpent, err := c.managedRenewContract(renewal, currentPeriod, allowance, blockHeight, endHeight)
+		fundsSpent, err := c.managedRenewContract(renewal, currentPeriod, renewal.allowance, blockHeight, renewal.endHeight)
 		if errors.Contains(err, errContractNotGFR) {
 			// Do not add a renewal error.
 			c.staticLog.Debugln("Contract skipped because it is not good for renew", renewal.id)
@@ -1556,7 +1666,7 @@ func (c *Contractor) threadedContractMaintenance() {
 		// Renew one contract. The error is ignored because the renew function
 		// already will have logged the error, and in the event of an error,
 		// 'fundsSpent' will return '0'.
-		fundsSpent, err := c.managedRenewContract(renewal, currentPeriod, allowance, blockHeight, endHeight)
+		fundsSpent, err := c.managedRenewContract(renewal, currentPeriod, renewal.allowance, blockHeight, renewal.endHeight)
 		if err != nil {
 			c.staticLog.Println("Error refreshing a contract", renewal.id, err)
 			renewErr = errors.Compose(renewErr, err)
@@ -1578,11 +1688,42 @@ func (c *Contractor) threadedContractMaintenance() {
 	}
 
 	// Form contracts.
-	lf, wl := c.managedFormContracts(fundsRemaining, hosts, neededContracts, allowance, endHeight)
+	lf, wl := c.managedFormContracts(fundsRemaining, hosts, neededContracts, allowance, endHeight, "")
 
 	// Register alerts if necessary.
 	registerLowFundsAlert = registerLowFundsAlert || lf
 	registerWalletLockedDuringMaintenance = registerWalletLockedDuringMaintenance || wl
+	if lf || wl || allowance.PortalMode() {
+		return
+	}
+
+	// Form the contracts of the allowance profiles with the remaining funds.
+	c.managedPruneProfileHosts()
+	for _, profile := range allowance.ProfileNames() {
+		profileAllowance, _ := allowance.ProfileAllowance(profile)
+		neededContracts, hosts = c.managedHostsForProfileFormation(profile, profileAllowance)
+		if neededContracts <= 0 {
+			continue
+		}
+		spending, err = c.PeriodSpending()
+		if err != nil {
+			c.staticLog.Println("WARN: error getting period spending:", err)
+			return
+		}
+		fundsRemaining = types.ZeroCurrency
+		if spending.TotalAllocated.Cmp(allowance.Funds) < 0 {
+			fundsRemaining = allowance.Funds.Sub(spending.TotalAllocated)
+		}
+		c.mu.RLock()
+		profileEndHeight := c.profileEndHeight(profile)
+		c.mu.RUnlock()
+		lf, wl = c.managedFormContracts(fundsRemaining, hosts, neededContracts, profileAllowance, profileEndHeight, profile)
+		registerLowFundsAlert = registerLowFundsAlert || lf
+		registerWalletLockedDuringMaintenance = registerWalletLockedDuringMaintenance || wl
+		if lf || wl {
+			return
+		}
+	}
 }
 
 // managedHostsForPortalFormation returns the hosts to form contracts with for a
@@ -1599,12 +1740,41 @@ func (c *Contractor) managedHostsForPortalFormation(allowance skymodules.Allowan
 // managedHostsForRegularFormation returns the number of hosts needed for
 // non-portal contract formation plus a set of hosts to use.
 func (c *Contractor) managedHostsForRegularFormation(allowance skymodules.Allowance) (int, []skymodules.HostDBEntry) {
-	return hostsForRegularFormation(allowance, c.staticContracts.ViewAll(), c.RecoverableContracts(), c.staticHDB.RandomHosts, c.staticLog)
+	return hostsForRegularFormation(allowance, c.staticContracts.ViewAll(), c.RecoverableContracts(), c.HostProfiles(), c.staticHDB.RandomHosts, c.staticLog)
+}
+
+// managedHostsForProfileFormation returns the number of hosts needed for the
+// allowance profile with the given name plus a set of hosts to use.
+func (c *Contractor) managedHostsForProfileFormation(profile string, allowance skymodules.Allowance) (int, []skymodules.HostDBEntry) {
+	return hostsForProfileFormation(profile, allowance, c.staticContracts.ViewAll(), c.RecoverableContracts(), c.HostProfiles(), c.staticHDB.RandomHostsWithAllowance, c.staticLog)
+}
+
+// managedPruneProfileHosts removes hosts from the allowance profiles if we no
+// longer have a contract with them that is good for renewing or if their
+// profile was removed from the allowance.
+func (c *Contractor) managedPruneProfileHosts() {
+	gfr := make(map[string]struct{})
+	for _, contract := range c.staticContracts.ViewAll() {
+		if contract.Utility.GoodForRenew {
+			gfr[contract.HostPublicKey.String()] = struct{}{}
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for host, profile := range c.hostProfiles {
+		_, exists := gfr[host]
+		_, profileExists := c.allowance.Profiles[profile]
+		if !exists || !profileExists {
+			delete(c.hostProfiles, host)
+		}
+	}
 }
 
 // managedFormContracts tries to form up to neededContracts with the hosts given
-// by hosts and the provided budget, allowance and endHeight.
-func (c *Contractor) managedFormContracts(budget types.Currency, hosts []skymodules.HostDBEntry, neededContracts int, allowance skymodules.Allowance, endHeight types.BlockHeight) (lowFunds, walletLocked bool) {
+// by hosts and the provided budget, allowance and endHeight. If profile is set,
+// the hosts of the formed contracts are added to the allowance profile with
+// that name.
+func (c *Contractor) managedFormContracts(budget types.Currency, hosts []skymodules.HostDBEntry, neededContracts int, allowance skymodules.Allowance, endHeight types.BlockHeight, profile string) (lowFunds, walletLocked bool) {
 	// Calculate the anticipated transaction fee.
 	_, maxFee := c.staticTPool.FeeEstimation()
 	txnFee := maxFee.Mul64(skymodules.EstimatedFileContractTransactionSetSize)
@@ -1666,7 +1836,7 @@ func (c *Contractor) managedFormContracts(budget types.Currency, hosts []skymodu
 
 		// Attempt forming a contract with this host.
 		start := time.Now()
-		fundsSpent, newContract, err := c.managedNewContract(host, contractFunds, endHeight)
+		fundsSpent, newContract, err := c.managedNewContractWithAllowance(host, contractFunds, endHeight, allowance)
 		if err != nil {
 			c.staticLog.Printf("Attempted to form a contract with %v, time spent %v, but negotiation failed: %v\n", host.NetAddress, time.Since(start).Round(time.Millisecond), err)
 			continue
@@ -1680,6 +1850,7 @@ func (c *Contractor) managedFormContracts(budget types.Currency, hosts []skymodu
 			c.staticLog.Println("Score:    ", sb.Score)
 			c.staticLog.Println("Age Adjustment:        ", sb.AgeAdjustment)
 			c.staticLog.Println("Base Price Adjustment: ", sb.BasePriceAdjustment)
+			c.staticLog.Println("Benchmark Adjustment:  ", sb.BenchmarkAdjustment)
 			c.staticLog.Println("Burn Adjustment:       ", sb.BurnAdjustment)
 			c.staticLog.Println("Collateral Adjustment: ", sb.CollateralAdjustment)
 			c.staticLog.Println("Duration Adjustment:   ", sb.DurationAdjustment)
@@ -1700,6 +1871,9 @@ func (c *Contractor) managedFormContracts(budget types.Currency, hosts []skymodu
 			return
 		}
 		c.mu.Lock()
+		if profile != "" {
+			c.hostProfiles[host.PublicKey.String()] = profile
+		}
 		err = c.save()
 		c.mu.Unlock()
 		if err != nil {
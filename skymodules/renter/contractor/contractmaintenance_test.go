@@ -331,7 +331,7 @@ func TestHostsForRegularFormation(t *testing.T) {
 	}
 
 	// Check returned hosts and needed hosts.
-	needed, hosts := hostsForRegularFormation(a, allContracts, recoverableContracts, randomHosts, l)
+	needed, hosts := hostsForRegularFormation(a, allContracts, recoverableContracts, nil, randomHosts, l)
 	if !reflect.DeepEqual(hosts, returnedHosts) {
 		t.Fatal("wrong hosts returned")
 	}
@@ -339,3 +339,119 @@ func TestHostsForRegularFormation(t *testing.T) {
 		t.Fatal("needed not set")
 	}
 }
+
+// TestHostsForProfileFormation is a unit test for hostsForProfileFormation.
+func TestHostsForProfileFormation(t *testing.T) {
+	a := skymodules.Allowance{
+		Hosts:  5,
+		Period: 100,
+		Profiles: map[string]skymodules.AllowanceProfile{
+			"cold": {
+				Hosts:           3,
+				Period:          1000,
+				ExpectedStorage: 1 << 40,
+				MaxStoragePrice: types.NewCurrency64(100),
+			},
+			"hot": {
+				Hosts: 2,
+			},
+		},
+	}
+	profileAllowance, exists := a.ProfileAllowance("cold")
+	if !exists {
+		t.Fatal("profile doesn't exist")
+	}
+
+	// helpers
+	randomPK := func() types.SiaPublicKey {
+		var spk types.SiaPublicKey
+		spk.Key = fastrand.Bytes(crypto.PublicKeySize)
+		return spk
+	}
+
+	// Create one gfu regular contract and one gfu contract for each profile.
+	coldPK := randomPK()
+	hotPK := randomPK()
+	allContracts := []skymodules.RenterContract{
+		{
+			HostPublicKey: randomPK(),
+			Utility: skymodules.ContractUtility{
+				GoodForUpload: true,
+			},
+		},
+		{
+			HostPublicKey: coldPK,
+			Utility: skymodules.ContractUtility{
+				GoodForUpload: true,
+			},
+		},
+		{
+			HostPublicKey: hotPK,
+			Utility: skymodules.ContractUtility{
+				GoodForUpload: true,
+			},
+		},
+	}
+	hostProfiles := map[string]string{
+		coldPK.String(): "cold",
+		hotPK.String():  "hot",
+	}
+	l, err := persist.NewLogger(ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Make sure random hosts is called with the profile's allowance and
+	// return one cheap and one expensive host for each requested host.
+	var cheapHosts []skymodules.HostDBEntry
+	randomHosts := func(n int, blacklist, addressBlacklist []types.SiaPublicKey, allowance skymodules.Allowance) ([]skymodules.HostDBEntry, error) {
+		// -1 comes from the contract of the profile.
+		expectedN := (a.Profiles["cold"].Hosts-1)*4 + uint64(randomHostsBufferForScore)
+		if uint64(n) != expectedN {
+			t.Fatal("random host called with wrong n", n, expectedN)
+		}
+		if !reflect.DeepEqual(allowance, profileAllowance) {
+			t.Fatal("random hosts wasn't called with the profile's allowance")
+		}
+		if len(blacklist) != len(allContracts) {
+			t.Fatal("wrong blacklist", len(blacklist))
+		}
+		var hosts []skymodules.HostDBEntry
+		for i := 0; i < n/2; i++ {
+			cheap := skymodules.HostDBEntry{PublicKey: randomPK()}
+			cheap.StoragePrice = a.Profiles["cold"].MaxStoragePrice
+			expensive := skymodules.HostDBEntry{PublicKey: randomPK()}
+			expensive.StoragePrice = a.Profiles["cold"].MaxStoragePrice.Add64(1)
+			hosts = append(hosts, cheap, expensive)
+			cheapHosts = append(cheapHosts, cheap)
+		}
+		return hosts, nil
+	}
+
+	// Only the cheap hosts should be returned. The contract of the other
+	// profile doesn't count towards the profile.
+	needed, hosts := hostsForProfileFormation("cold", profileAllowance, allContracts, nil, hostProfiles, randomHosts, l)
+	if !reflect.DeepEqual(hosts, cheapHosts) {
+		t.Fatal("wrong hosts returned")
+	}
+	if needed != int(a.Profiles["cold"].Hosts)-1 {
+		t.Fatal("wrong number of needed hosts", needed)
+	}
+
+	// The contracts of the profiles shouldn't count towards the regular
+	// hosts.
+	regularRandomHosts := func(n int, _, _ []types.SiaPublicKey) ([]skymodules.HostDBEntry, error) {
+		return nil, nil
+	}
+	needed, _ = hostsForRegularFormation(a, allContracts, nil, hostProfiles, regularRandomHosts, l)
+	if needed != int(a.Hosts)-1 {
+		t.Fatal("wrong number of needed regular hosts", needed)
+	}
+
+	// Without hosts in the profile, no contracts are needed.
+	profileAllowance.Hosts = 0
+	needed, hosts = hostsForProfileFormation("cold", profileAllowance, allContracts, nil, hostProfiles, randomHosts, l)
+	if needed != 0 || len(hosts) != 0 {
+		t.Fatal("profile contracts shouldn't be formed", needed, len(hosts))
+	}
+}
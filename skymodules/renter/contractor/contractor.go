@@ -2,6 +2,7 @@ package contractor
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"net"
 	"os"
@@ -68,6 +69,9 @@ type Contractor struct {
 	staticInterruptMaintenance chan struct{}
 	maintenanceLock            siasync.TryMutex
 
+	// Only one thread should be defragging contracts at a time.
+	defragLock siasync.TryMutex
+
 	// Only one thread should be scanning the blockchain for recoverable
 	// contracts at a time.
 	atomicScanInProgress     uint32
@@ -85,6 +89,12 @@ type Contractor struct {
 	// is unlocked.
 	recentRecoveryChange modules.ConsensusChangeID
 
+	// recoveryScan is the progress of the most recent recovery scan and
+	// recoveryStatus contains the recovery status of all contracts found
+	// since startup.
+	recoveryScan   recoveryScanProgress
+	recoveryStatus map[types.FileContractID]*skymodules.ContractRecoveryStatus
+
 	downloaders     map[types.FileContractID]*hostDownloader
 	editors         map[types.FileContractID]*hostEditor
 	sessions        map[types.FileContractID]*hostSession
@@ -103,11 +113,16 @@ type Contractor struct {
 	staticContracts      *proto.ContractSet
 	oldContracts         map[types.FileContractID]skymodules.RenterContract
 	preferredHosts       map[string]struct{}
+	hostProfiles         map[string]string
 	doubleSpentContracts map[types.FileContractID]types.BlockHeight
 	recoverableContracts map[types.FileContractID]skymodules.RecoverableContract
 	renewedFrom          map[types.FileContractID]types.FileContractID
 	renewedTo            map[types.FileContractID]types.FileContractID
 
+	// autoFund contains the settings and audit log of the automatic
+	// allowance top-ups.
+	autoFund skymodules.AutoFundStatus
+
 	staticChurnLimiter *churnLimiter
 	staticWatchdog     *watchdog
 }
@@ -135,6 +150,19 @@ func (c *Contractor) Allowance() skymodules.Allowance {
 	return c.allowance
 }
 
+// HostProfiles maps the hosts that were selected for an allowance profile to
+// the name of the profile. Hosts which are not part of any profile are
+// regular hosts.
+func (c *Contractor) HostProfiles() map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	hosts := make(map[string]string, len(c.hostProfiles))
+	for host, profile := range c.hostProfiles {
+		hosts[host] = profile
+	}
+	return hosts
+}
+
 // ContractPublicKey returns the public key capable of verifying the renter's
 // signature on a contract.
 func (c *Contractor) ContractPublicKey(pk types.SiaPublicKey) (crypto.PublicKey, bool) {
@@ -154,7 +182,18 @@ func (c *Contractor) InitRecoveryScan() (err error) {
 		return err
 	}
 	defer c.staticTG.Done()
-	return c.callInitRecoveryScan(modules.ConsensusChangeBeginning)
+	return c.managedInitRecoveryScanRange(0, c.staticCS.Height())
+}
+
+// InitRecoveryScanRange starts scanning the blocks between start and end
+// (inclusive) for recoverable contracts within a separate thread. Limiting the
+// range speeds up the recovery of recently formed contracts.
+func (c *Contractor) InitRecoveryScanRange(start, end types.BlockHeight) (err error) {
+	if err := c.staticTG.Add(); err != nil {
+		return err
+	}
+	defer c.staticTG.Done()
+	return c.managedInitRecoveryScanRange(start, end)
 }
 
 // PeriodSpending returns the amount spent on contracts during the current
@@ -492,7 +531,9 @@ func contractorBlockingStartup(cs modules.ConsensusSet, w modules.Wallet, tp mod
 		oldContracts:         make(map[types.FileContractID]skymodules.RenterContract),
 		doubleSpentContracts: make(map[types.FileContractID]types.BlockHeight),
 		preferredHosts:       make(map[string]struct{}),
+		hostProfiles:         make(map[string]string),
 		recoverableContracts: make(map[types.FileContractID]skymodules.RecoverableContract),
+		recoveryStatus:       make(map[types.FileContractID]*skymodules.ContractRecoveryStatus),
 		renewing:             make(map[types.FileContractID]bool),
 		renewedFrom:          make(map[types.FileContractID]types.FileContractID),
 		renewedTo:            make(map[types.FileContractID]types.FileContractID),
@@ -603,7 +644,34 @@ func NewCustomContractor(cs modules.ConsensusSet, w modules.Wallet, tp modules.T
 
 // callInitRecoveryScan starts scanning the whole blockchain at a certain
 // ChangeID for recoverable contracts within a separate thread.
-func (c *Contractor) callInitRecoveryScan(scanStart modules.ConsensusChangeID) (err error) {
+func (c *Contractor) callInitRecoveryScan(scanStart modules.ConsensusChangeID) error {
+	return c.callStartRecoveryScan(recoveryScanProgress{}, func(scanner *recoveryScanner) error {
+		return scanner.threadedScan(c.staticCS, scanStart, c.staticTG.StopChan())
+	})
+}
+
+// managedInitRecoveryScanRange starts scanning the blocks between start and
+// end for recoverable contracts within a separate thread.
+func (c *Contractor) managedInitRecoveryScanRange(start, end types.BlockHeight) error {
+	if start > end {
+		return errInvalidRecoveryScanRange
+	}
+	if height := c.staticCS.Height(); end > height {
+		return errors.AddContext(errInvalidRecoveryScanRange, fmt.Sprintf("end %v is greater than the current blockheight %v", end, height))
+	}
+	progress := recoveryScanProgress{
+		ranged:      true,
+		startHeight: start,
+		endHeight:   end,
+	}
+	return c.callStartRecoveryScan(progress, func(scanner *recoveryScanner) error {
+		return scanner.threadedScanRange(c.staticCS, start, end, c.staticTG.StopChan())
+	})
+}
+
+// callStartRecoveryScan starts a scan for recoverable contracts within a
+// separate thread. The provided scan function performs the actual scan.
+func (c *Contractor) callStartRecoveryScan(progress recoveryScanProgress, scan func(*recoveryScanner) error) (err error) {
 	// Check if we are already scanning the blockchain.
 	if !atomic.CompareAndSwapUint32(&c.atomicScanInProgress, 0, 1) {
 		return errors.New("scan for recoverable contracts is already in progress")
@@ -624,6 +692,9 @@ func (c *Contractor) callInitRecoveryScan(scanStart modules.ConsensusChangeID) (
 	rs := skymodules.DeriveRenterSeed(s)
 	// Reset the scan progress before starting the scan.
 	atomic.StoreInt64(&c.atomicRecoveryScanHeight, 0)
+	c.mu.Lock()
+	c.recoveryScan = progress
+	c.mu.Unlock()
 	// Create the scanner.
 	scanner := newRecoveryScanner(c, rs)
 	// Start the scan.
@@ -634,7 +705,7 @@ func (c *Contractor) callInitRecoveryScan(scanStart modules.ConsensusChangeID) (
 		}
 		defer c.staticTG.Done()
 		// Scan blockchain.
-		if err := scanner.threadedScan(c.staticCS, scanStart, c.staticTG.StopChan()); err != nil {
+		if err := scan(scanner); err != nil {
 			c.staticLog.Println("Scan failed", err)
 		}
 		if c.staticDeps.Disrupt("disableRecoveryStatusReset") {
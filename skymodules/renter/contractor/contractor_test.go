@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -925,3 +926,95 @@ func TestPaymentMissingStorageObligation(t *testing.T) {
 		t.Fatal("Contract should not be locked")
 	}
 }
+
+// TestRecoveryScanRange tests scanning a range of blocks for recoverable
+// contracts as well as pausing and resuming the scan.
+func TestRecoveryScanRange(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// create testing trio
+	_, c, _, cf, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tryClose(cf, t)
+	height := c.staticCS.Height()
+
+	// Invalid ranges are rejected.
+	if err := c.InitRecoveryScanRange(10, 5); !errors.Contains(err, errInvalidRecoveryScanRange) {
+		t.Fatal("expected invalid range", err)
+	}
+	if err := c.InitRecoveryScanRange(0, height+1); !errors.Contains(err, errInvalidRecoveryScanRange) {
+		t.Fatal("expected invalid range", err)
+	}
+
+	// Can't pause or resume without a scan.
+	if err := c.PauseRecoveryScan(); !errors.Contains(err, errNoPausableRecoveryScan) {
+		t.Fatal("expected no pausable scan", err)
+	}
+	if err := c.ResumeRecoveryScan(); !errors.Contains(err, errNoPausableRecoveryScan) {
+		t.Fatal("expected no pausable scan", err)
+	}
+
+	// Run a paused scan manually.
+	atomic.StoreUint32(&c.atomicScanInProgress, 1)
+	c.mu.Lock()
+	c.recoveryScan = recoveryScanProgress{ranged: true}
+	c.mu.Unlock()
+	if err := c.PauseRecoveryScan(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.PauseRecoveryScan(); !errors.Contains(err, errRecoveryScanPaused) {
+		t.Fatal("expected scan to be paused already", err)
+	}
+	scanner := newRecoveryScanner(c, skymodules.RenterSeed{})
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- scanner.threadedScanRange(c.staticCS, 0, height, c.staticTG.StopChan())
+	}()
+
+	// The scan shouldn't make progress while paused.
+	time.Sleep(100 * time.Millisecond)
+	progress := c.RecoveryScanProgress()
+	if !progress.ScanInProgress || !progress.Paused || progress.BlocksScanned != 0 {
+		t.Fatal("unexpected progress", progress)
+	}
+
+	// Resume the scan. All blocks should be scanned.
+	if err := c.ResumeRecoveryScan(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	progress = c.RecoveryScanProgress()
+	if progress.Paused || progress.BlocksScanned != uint64(height)+1 {
+		t.Fatal("unexpected progress", progress)
+	}
+	if err := c.ResumeRecoveryScan(); !errors.Contains(err, errRecoveryScanNotPaused) {
+		t.Fatal("expected scan not to be paused", err)
+	}
+	atomic.StoreUint32(&c.atomicScanInProgress, 0)
+
+	// Scan a range through the API of the contractor.
+	start := height / 2
+	if err := c.InitRecoveryScanRange(start, height); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		progress := c.RecoveryScanProgress()
+		if progress.ScanInProgress {
+			return errors.New("scan still in progress")
+		}
+		if progress.StartHeight != start || progress.EndHeight != height || progress.BlocksScanned != uint64(height-start)+1 {
+			return fmt.Errorf("unexpected progress %+v", progress)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -15,6 +15,36 @@ func (c *Contractor) contractEndHeight() types.BlockHeight {
 	return c.currentPeriod + c.allowance.Period + c.allowance.RenewWindow
 }
 
+// profileEndHeight returns the height at which new contracts of the allowance
+// profile with the given name end. Profiles without their own period share the
+// period of the allowance. The contracts of a profile with its own period end
+// one period after they were formed or renewed.
+func (c *Contractor) profileEndHeight(profile string) types.BlockHeight {
+	p, exists := c.allowance.Profiles[profile]
+	if !exists || p.Period == 0 {
+		return c.contractEndHeight()
+	}
+	renewWindow := p.RenewWindow
+	if renewWindow == 0 {
+		renewWindow = c.allowance.RenewWindow
+	}
+	return c.blockHeight + p.Period + renewWindow
+}
+
+// hostAllowance returns the allowance used to form, renew and judge contracts
+// with the given host as well as the name of the host's allowance profile.
+func (c *Contractor) hostAllowance(hostKey string) (skymodules.Allowance, string) {
+	profile, exists := c.hostProfiles[hostKey]
+	if !exists {
+		return c.allowance, ""
+	}
+	allowance, exists := c.allowance.ProfileAllowance(profile)
+	if !exists {
+		return c.allowance, ""
+	}
+	return allowance, profile
+}
+
 // managedCancelContract cancels a contract by setting its utility fields to
 // false and locking the utilities. The contract can still be used for
 // downloads after this but it won't be used for uploads or renewals.
@@ -148,6 +178,18 @@ func (c *Contractor) ContractUtility(pk types.SiaPublicKey) (skymodules.Contract
 	return c.managedContractUtility(id)
 }
 
+// RecordAccountSpending attributes the given ephemeral account spending to the
+// latest contract with the host with the given public key.
+func (c *Contractor) RecordAccountSpending(pk types.SiaPublicKey, spending skymodules.ContractAccountSpending) error {
+	c.mu.RLock()
+	id, ok := c.pubKeysToContractID[pk.String()]
+	c.mu.RUnlock()
+	if !ok {
+		return errors.New("no contract with host")
+	}
+	return c.staticContracts.RecordAccountSpending(id, spending)
+}
+
 // MarkContractBad will mark a specific contract as bad.
 func (c *Contractor) MarkContractBad(id types.FileContractID) error {
 	if err := c.staticTG.Add(); err != nil {
@@ -0,0 +1,122 @@
+package contractor
+
+import (
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"gitlab.com/SkynetLabs/skyd/skymodules/renter/proto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// ErrDefragUnsupported is returned when trying to defrag a contract in a
+	// build which doesn't track the references to the sectors of contracts.
+	// Only testing builds enable the refcounter, so release builds can't tell
+	// which sectors are safe to delete.
+	ErrDefragUnsupported = errors.New("contract defragmentation is only supported by testing builds since release builds don't track the references to sectors")
+)
+
+// DefragContract deletes the sectors which are no longer referenced from the
+// contract with the given id and reports how much storage was reclaimed on the
+// host.
+func (c *Contractor) DefragContract(id types.FileContractID) (skymodules.ContractDefragReport, error) {
+	if build.Release != "testing" {
+		return skymodules.ContractDefragReport{}, ErrDefragUnsupported
+	}
+	if err := c.staticTG.Add(); err != nil {
+		return skymodules.ContractDefragReport{}, err
+	}
+	defer c.staticTG.Done()
+	return c.managedDefragContract(id)
+}
+
+// managedDefragContract deletes the sectors which are no longer referenced from
+// the contract with the given id.
+func (c *Contractor) managedDefragContract(id types.FileContractID) (skymodules.ContractDefragReport, error) {
+	report := skymodules.ContractDefragReport{ID: id}
+	contract, ok := c.staticContracts.View(id)
+	if !ok {
+		return report, errContractNotFound
+	}
+	// Avoid connecting to the host if there is nothing to delete.
+	garbage, err := c.staticContracts.GarbageSectors(id)
+	if err != nil {
+		return report, errors.AddContext(err, "failed to find unreferenced sectors")
+	}
+	if garbage == 0 {
+		return report, nil
+	}
+
+	s, err := c.Session(contract.HostPublicKey, c.staticTG.StopChan())
+	if err != nil {
+		return report, errors.AddContext(err, "failed to create session")
+	}
+	removed, err := s.Defrag()
+	err = errors.Compose(err, s.Close())
+
+	// Report what was removed even if the session failed halfway.
+	report.SectorsRemoved = removed
+	report.ReclaimedBytes = removed * modules.SectorSize
+	if updated, ok := c.staticContracts.View(id); ok && contract.RenterFunds.Cmp(updated.RenterFunds) > 0 {
+		report.Cost = contract.RenterFunds.Sub(updated.RenterFunds)
+	}
+	return report, err
+}
+
+// inDefragWindow returns whether the given time is within the window of UTC
+// hours [start, end).
+func inDefragWindow(t time.Time, start, end int) bool {
+	hour := t.UTC().Hour()
+	if start < end {
+		return hour >= start && hour < end
+	}
+	return hour >= start || hour < end
+}
+
+// threadedDefragContracts deletes the sectors which are no longer referenced
+// from the renter's active contracts if the current time is within the
+// configured defrag window.
+func (c *Contractor) threadedDefragContracts() {
+	if build.Release != "testing" {
+		return
+	}
+	start, end, ok := build.ContractDefragWindow()
+	if !ok || !inDefragWindow(time.Now(), start, end) {
+		return
+	}
+	if err := c.staticTG.Add(); err != nil {
+		return
+	}
+	defer c.staticTG.Done()
+
+	// Only one thread should be defragging contracts at a time.
+	if !c.defragLock.TryLock() {
+		return
+	}
+	defer c.defragLock.Unlock()
+
+	for _, contract := range c.staticContracts.ViewAll() {
+		if !contract.Utility.GoodForRenew {
+			continue // about to expire anyway
+		}
+		select {
+		case <-c.staticTG.StopChan():
+			return
+		default:
+		}
+		report, err := c.managedDefragContract(contract.ID)
+		if errors.Contains(err, proto.ErrNoRefCounter) {
+			c.staticLog.Debugln("Skipping contract defrag:", err)
+			return
+		}
+		if err != nil {
+			c.staticLog.Printf("WARN: failed to defrag contract %v: %v", contract.ID, err)
+		}
+		if report.SectorsRemoved > 0 {
+			c.staticLog.Printf("Removed %v unreferenced sectors from contract %v, reclaiming %v bytes for %v", report.SectorsRemoved, contract.ID, report.ReclaimedBytes, report.Cost.HumanString())
+		}
+	}
+}
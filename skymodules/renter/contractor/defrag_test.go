@@ -0,0 +1,151 @@
+package contractor
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestInDefragWindow is a unit test for inDefragWindow.
+func TestInDefragWindow(t *testing.T) {
+	t.Parallel()
+
+	at := func(hour int) time.Time {
+		return time.Date(2021, 1, 1, hour, 30, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		start, end, hour int
+		in               bool
+	}{
+		{2, 5, 1, false},
+		{2, 5, 2, true},
+		{2, 5, 4, true},
+		{2, 5, 5, false},
+		{22, 4, 21, false},
+		{22, 4, 23, true},
+		{22, 4, 0, true},
+		{22, 4, 4, false},
+	}
+	for _, test := range tests {
+		if in := inDefragWindow(at(test.hour), test.start, test.end); in != test.in {
+			t.Errorf("%v-%v at %v: expected %v but was %v", test.start, test.end, test.hour, test.in, in)
+		}
+	}
+}
+
+// TestIntegrationDefragContract tests that the contractor can delete
+// unreferenced sectors from a contract.
+func TestIntegrationDefragContract(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// create testing trio
+	h, c, _, cf, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tryClose(cf, t)
+
+	// get the host's entry from the db
+	hostEntry, ok, err := c.staticHDB.Host(h.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("no entry for host in db")
+	}
+
+	// set an allowance but don't use SetAllowance to avoid automatic contract
+	// formation.
+	c.mu.Lock()
+	c.allowance = skymodules.DefaultAllowance
+	c.mu.Unlock()
+
+	// form a contract with the host
+	_, contract, err := c.managedNewContract(hostEntry, types.SiacoinPrecision.Mul64(50), c.blockHeight+100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// upload some sectors
+	s, err := c.Session(contract.HostPublicKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sectors [][]byte
+	for i := 0; i < 10; i++ {
+		data := fastrand.Bytes(int(modules.SectorSize))
+		if _, err := s.Upload(data); err != nil {
+			t.Fatal(err)
+		}
+		sectors = append(sectors, data)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing should be removed while all sectors are referenced
+	report, err := c.DefragContract(contract.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.SectorsRemoved != 0 || report.ReclaimedBytes != 0 || !report.Cost.IsZero() {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	// drop the references to more sectors than are removed in a single batch
+	// and defrag the contract again
+	err = c.staticContracts.DecrementSectorReferences(contract.ID, 0, 2, 5, 6, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err = c.DefragContract(contract.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.SectorsRemoved != 5 || report.ReclaimedBytes != 5*modules.SectorSize {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if report.Cost.IsZero() {
+		t.Fatal("defrag should cost money")
+	}
+	updated, ok := c.staticContracts.View(contract.ID)
+	if !ok {
+		t.Fatal("contract not found")
+	}
+	if updated.Size() != 5*modules.SectorSize {
+		t.Fatal("wrong contract size", updated.Size())
+	}
+
+	// the remaining sectors should still be available
+	s, err = c.Session(contract.HostPublicKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := s.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	for _, i := range []int{1, 3, 4, 7, 9} {
+		retrieved, err := s.Download(crypto.MerkleRoot(sectors[i]), 0, uint32(modules.SectorSize))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(sectors[i], retrieved) {
+			t.Fatal("downloaded data does not match original", i)
+		}
+	}
+	// the removed ones should be gone
+	_, err = s.Download(crypto.MerkleRoot(sectors[0]), 0, uint32(modules.SectorSize))
+	if err == nil {
+		t.Fatal("removed sector shouldn't be available")
+	}
+}
@@ -54,6 +54,7 @@ type (
 		IncrementFailedInteractions(key types.SiaPublicKey) error
 		InitialScanComplete() (complete bool, err error)
 		RandomHosts(n int, blacklist, addressBlacklist []types.SiaPublicKey) ([]skymodules.HostDBEntry, error)
+		RandomHostsWithAllowance(n int, blacklist, addressBlacklist []types.SiaPublicKey, allowance skymodules.Allowance) ([]skymodules.HostDBEntry, error)
 		UpdateContracts([]skymodules.RenterContract) error
 		ScoreBreakdown(skymodules.HostDBEntry) (skymodules.HostScoreBreakdown, error)
 		SetAllowance(allowance skymodules.Allowance) error
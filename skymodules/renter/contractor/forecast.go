@@ -0,0 +1,126 @@
+package contractor
+
+import (
+	"fmt"
+
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// SpendingForecast returns the projected spending for the remainder of the
+// current billing period.
+func (c *Contractor) SpendingForecast() (skymodules.SpendingForecast, error) {
+	if err := c.staticTG.Add(); err != nil {
+		return skymodules.SpendingForecast{}, err
+	}
+	defer c.staticTG.Done()
+	return c.managedSpendingForecast()
+}
+
+// managedSpendingForecast computes the spending forecast from the current
+// state of the contractor.
+func (c *Contractor) managedSpendingForecast() (skymodules.SpendingForecast, error) {
+	spending, err := c.PeriodSpending()
+	if err != nil {
+		return skymodules.SpendingForecast{}, err
+	}
+	c.mu.RLock()
+	allowance := c.allowance
+	blockHeight := c.blockHeight
+	currentPeriod := c.currentPeriod
+	c.mu.RUnlock()
+	return forecastSpending(spending, allowance, blockHeight, currentPeriod, c.staticContracts.ViewAll()), nil
+}
+
+// managedCheckSpendingForecast registers an alert if the allowance is
+// projected to run out of funds before the end of the current period and
+// unregisters it otherwise.
+func (c *Contractor) managedCheckSpendingForecast() {
+	forecast, err := c.managedSpendingForecast()
+	if err != nil {
+		c.staticLog.Println("Unable to compute spending forecast:", err)
+		return
+	}
+	if !forecast.InsufficientFunds {
+		c.staticAlerter.UnregisterAlert(AlertIDRenterAllowanceProjectedLowFunds)
+		return
+	}
+	cause := fmt.Sprintf("Projected spending of %v exceeds the allowance funds at height %v", forecast.ProjectedTotalSpending.HumanString(), forecast.DepletionHeight)
+	c.staticAlerter.RegisterAlert(AlertIDRenterAllowanceProjectedLowFunds, AlertMSGAllowanceProjectedLowFunds, cause, modules.SeverityWarning)
+}
+
+// forecastSpending projects the spending for the remainder of the period
+// starting at currentPeriod. Every spending category is extrapolated linearly
+// using the rate observed since the start of the period. Contracts which enter
+// their renew window before the end of the period are expected to be renewed
+// at their current total cost.
+func forecastSpending(spending skymodules.ContractorSpending, allowance skymodules.Allowance, blockHeight, currentPeriod types.BlockHeight, contracts []skymodules.RenterContract) skymodules.SpendingForecast {
+	totalSpent, _, _ := spending.SpendingBreakdown()
+	forecast := skymodules.SpendingForecast{
+		CurrentSpending: totalSpent,
+		PeriodEnd:       currentPeriod + allowance.Period,
+	}
+	if allowance.Funds.Cmp(totalSpent) > 0 {
+		forecast.RemainingFunds = allowance.Funds.Sub(totalSpent)
+	}
+
+	// Extrapolate the spending of every category. If no blocks have passed
+	// within the period yet, there is no rate to extrapolate from.
+	var elapsed, remaining types.BlockHeight
+	if blockHeight > currentPeriod {
+		elapsed = blockHeight - currentPeriod
+	}
+	if forecast.PeriodEnd > blockHeight {
+		remaining = forecast.PeriodEnd - blockHeight
+	}
+	project := func(spent types.Currency) types.Currency {
+		if elapsed == 0 {
+			return types.ZeroCurrency
+		}
+		return spent.Mul64(uint64(remaining)).Div64(uint64(elapsed))
+	}
+	forecast.ProjectedDownloadSpending = project(spending.DownloadSpending)
+	forecast.ProjectedFundAccountSpending = project(spending.FundAccountSpending)
+	forecast.ProjectedMaintenanceSpending = project(spending.MaintenanceSpending.Sum())
+	forecast.ProjectedStorageSpending = project(spending.StorageSpending)
+	forecast.ProjectedUploadSpending = project(spending.UploadSpending)
+	projectedUsage := forecast.ProjectedDownloadSpending.
+		Add(forecast.ProjectedFundAccountSpending).
+		Add(forecast.ProjectedMaintenanceSpending).
+		Add(forecast.ProjectedStorageSpending).
+		Add(forecast.ProjectedUploadSpending)
+
+	// Add the cost of the contracts which are renewed within the period.
+	for _, contract := range contracts {
+		if !contract.Utility.GoodForRenew {
+			continue
+		}
+		if contract.EndHeight <= blockHeight || contract.EndHeight >= forecast.PeriodEnd+allowance.RenewWindow {
+			continue
+		}
+		forecast.UpcomingRenewals++
+		forecast.ProjectedRenewalSpending = forecast.ProjectedRenewalSpending.Add(contract.TotalCost)
+	}
+	forecast.ProjectedTotalSpending = totalSpent.Add(projectedUsage).Add(forecast.ProjectedRenewalSpending)
+
+	// Check whether the allowance is projected to run out of funds.
+	if forecast.ProjectedTotalSpending.Cmp(allowance.Funds) <= 0 {
+		return forecast
+	}
+	forecast.InsufficientFunds = true
+
+	// Renewals are paid upfront, so the funds available for the projected
+	// usage are the remaining funds minus the renewal costs.
+	if forecast.RemainingFunds.Cmp(forecast.ProjectedRenewalSpending) <= 0 || projectedUsage.IsZero() {
+		forecast.DepletionHeight = blockHeight
+		return forecast
+	}
+	available := forecast.RemainingFunds.Sub(forecast.ProjectedRenewalSpending)
+	blocks, err := available.Mul64(uint64(remaining)).Div(projectedUsage).Uint64()
+	if err != nil {
+		blocks = uint64(remaining)
+	}
+	forecast.DepletionHeight = blockHeight + types.BlockHeight(blocks)
+	return forecast
+}
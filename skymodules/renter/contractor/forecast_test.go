@@ -0,0 +1,97 @@
+package contractor
+
+import (
+	"testing"
+
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/types"
+)
+
+// TestForecastSpending is a unit test for forecastSpending.
+func TestForecastSpending(t *testing.T) {
+	t.Parallel()
+
+	// Half of the period has passed.
+	allowance := skymodules.Allowance{
+		Funds:       types.NewCurrency64(1000),
+		Period:      100,
+		RenewWindow: 20,
+	}
+	currentPeriod := types.BlockHeight(100)
+	blockHeight := types.BlockHeight(150)
+	spending := skymodules.ContractorSpending{
+		ContractFees:     types.NewCurrency64(10),
+		DownloadSpending: types.NewCurrency64(50),
+		StorageSpending:  types.NewCurrency64(50),
+		UploadSpending:   types.NewCurrency64(100),
+	}
+
+	// Only the first contract is renewed within the period. The second one
+	// enters its renew window at the end of the period and the third one is
+	// not good for renew.
+	contracts := []skymodules.RenterContract{
+		{
+			EndHeight: 210,
+			TotalCost: types.NewCurrency64(300),
+			Utility:   skymodules.ContractUtility{GoodForRenew: true},
+		},
+		{
+			EndHeight: 220,
+			TotalCost: types.NewCurrency64(300),
+			Utility:   skymodules.ContractUtility{GoodForRenew: true},
+		},
+		{
+			EndHeight: 210,
+			TotalCost: types.NewCurrency64(300),
+		},
+	}
+
+	// The spending is doubled and the renewal is added.
+	f := forecastSpending(spending, allowance, blockHeight, currentPeriod, contracts)
+	if !f.CurrentSpending.Equals64(210) {
+		t.Fatal("wrong current spending", f.CurrentSpending)
+	}
+	if !f.RemainingFunds.Equals64(790) {
+		t.Fatal("wrong remaining funds", f.RemainingFunds)
+	}
+	if !f.ProjectedUploadSpending.Equals64(100) || !f.ProjectedDownloadSpending.Equals64(50) || !f.ProjectedStorageSpending.Equals64(50) {
+		t.Fatal("wrong projected spending", f)
+	}
+	if f.UpcomingRenewals != 1 || !f.ProjectedRenewalSpending.Equals64(300) {
+		t.Fatal("wrong renewals", f.UpcomingRenewals, f.ProjectedRenewalSpending)
+	}
+	if !f.ProjectedTotalSpending.Equals64(710) {
+		t.Fatal("wrong projected total", f.ProjectedTotalSpending)
+	}
+	if f.PeriodEnd != 200 {
+		t.Fatal("wrong period end", f.PeriodEnd)
+	}
+	if f.InsufficientFunds || f.DepletionHeight != 0 {
+		t.Fatal("funds shouldn't be insufficient", f)
+	}
+
+	// Lower the funds. 390H remain of which 300H are used for the renewal.
+	// The remaining 90H last for 22 blocks at a rate of 4H per block.
+	allowance.Funds = types.NewCurrency64(600)
+	f = forecastSpending(spending, allowance, blockHeight, currentPeriod, contracts)
+	if !f.InsufficientFunds {
+		t.Fatal("funds should be insufficient")
+	}
+	if f.DepletionHeight != 172 {
+		t.Fatal("wrong depletion height", f.DepletionHeight)
+	}
+
+	// If the renewal can't be paid for, the funds are depleted right away.
+	allowance.Funds = types.NewCurrency64(400)
+	f = forecastSpending(spending, allowance, blockHeight, currentPeriod, contracts)
+	if !f.InsufficientFunds || f.DepletionHeight != blockHeight {
+		t.Fatal("funds should be depleted", f.InsufficientFunds, f.DepletionHeight)
+	}
+
+	// At the start of the period there is no rate to extrapolate.
+	allowance.Funds = types.NewCurrency64(1000)
+	f = forecastSpending(spending, allowance, currentPeriod, currentPeriod, contracts)
+	if !f.ProjectedUploadSpending.IsZero() || !f.ProjectedDownloadSpending.IsZero() || !f.ProjectedStorageSpending.IsZero() {
+		t.Fatal("spending shouldn't be projected", f)
+	}
+}
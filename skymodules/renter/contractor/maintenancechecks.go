@@ -55,6 +55,7 @@ func (c *Contractor) managedCheckHostScore(contract skymodules.RenterContract, s
 			c.staticLog.Println("Score:    ", sb.Score)
 			c.staticLog.Println("Age Adjustment:        ", sb.AgeAdjustment)
 			c.staticLog.Println("Base Price Adjustment: ", sb.BasePriceAdjustment)
+			c.staticLog.Println("Benchmark Adjustment:  ", sb.BenchmarkAdjustment)
 			c.staticLog.Println("Burn Adjustment:       ", sb.BurnAdjustment)
 			c.staticLog.Println("Collateral Adjustment: ", sb.CollateralAdjustment)
 			c.staticLog.Println("Duration Adjustment:   ", sb.DurationAdjustment)
@@ -79,6 +80,7 @@ func (c *Contractor) managedCheckHostScore(contract skymodules.RenterContract, s
 			c.staticLog.Println("Score:    ", sb.Score)
 			c.staticLog.Println("Age Adjustment:        ", sb.AgeAdjustment)
 			c.staticLog.Println("Base Price Adjustment: ", sb.BasePriceAdjustment)
+			c.staticLog.Println("Benchmark Adjustment:  ", sb.BenchmarkAdjustment)
 			c.staticLog.Println("Burn Adjustment:       ", sb.BurnAdjustment)
 			c.staticLog.Println("Collateral Adjustment: ", sb.CollateralAdjustment)
 			c.staticLog.Println("Duration Adjustment:   ", sb.DurationAdjustment)
@@ -103,10 +105,10 @@ func (c *Contractor) managedCheckHostScore(contract skymodules.RenterContract, s
 func (c *Contractor) managedUtilityChecks(contract skymodules.RenterContract, host skymodules.HostDBEntry, sb skymodules.HostScoreBreakdown, minScoreGFU, minScoreGFR types.Currency) (newUtility skymodules.ContractUtility, uus utilityUpdateStatus) {
 	revision := contract.Transaction.FileContractRevisions[0]
 	c.mu.RLock()
-	allowance := c.allowance
+	allowance, profile := c.hostAllowance(contract.HostPublicKey.String())
 	blockHeight := c.blockHeight
-	renewWindow := c.allowance.RenewWindow
-	period := c.allowance.Period
+	renewWindow := allowance.RenewWindow
+	period := allowance.Period
 	_, renewed := c.renewedTo[contract.ID]
 	c.mu.RUnlock()
 
@@ -155,6 +157,12 @@ func (c *Contractor) managedUtilityChecks(contract skymodules.RenterContract, ho
 	uus = uus.Merge(needsUpdate)
 	newUtility = newUtility.Merge(u)
 
+	// The hosts of allowance profiles are not selected by their score
+	// relative to the regular hosts.
+	if profile != "" {
+		return newUtility, uus
+	}
+
 	u, needsUpdate = c.managedCheckHostScore(contract, sb, minScoreGFR, minScoreGFU)
 	uus = uus.Merge(needsUpdate)
 	newUtility = newUtility.Merge(u)
@@ -36,10 +36,12 @@ type contractorPersist struct {
 	OldContracts         []skymodules.RenterContract      `json:"oldcontracts"`
 	DoubleSpentContracts map[string]types.BlockHeight     `json:"doublespentcontracts"`
 	PreferredHosts       []string                         `json:"preferredhosts"`
+	HostProfiles         map[string]string                `json:"hostprofiles"`
 	RecoverableContracts []skymodules.RecoverableContract `json:"recoverablecontracts"`
 	RenewedFrom          map[string]types.FileContractID  `json:"renewedfrom"`
 	RenewedTo            map[string]types.FileContractID  `json:"renewedto"`
 	Synced               bool                             `json:"synced"`
+	AutoFund             skymodules.AutoFundStatus        `json:"autofund"`
 
 	// Subsystem persistence:
 	ChurnLimiter churnLimiterPersist `json:"churnlimiter"`
@@ -64,7 +66,9 @@ func (c *Contractor) persistData() contractorPersist {
 		RenewedTo:            make(map[string]types.FileContractID),
 		DoubleSpentContracts: make(map[string]types.BlockHeight),
 		PreferredHosts:       make([]string, 0, len(c.preferredHosts)),
+		HostProfiles:         make(map[string]string, len(c.hostProfiles)),
 		Synced:               synced,
+		AutoFund:             c.autoFund,
 	}
 	for k, v := range c.renewedFrom {
 		data.RenewedFrom[k.String()] = v
@@ -84,6 +88,9 @@ func (c *Contractor) persistData() contractorPersist {
 	for host := range c.preferredHosts {
 		data.PreferredHosts = append(data.PreferredHosts, host)
 	}
+	for host, profile := range c.hostProfiles {
+		data.HostProfiles[host] = profile
+	}
 	data.ChurnLimiter = c.staticChurnLimiter.callPersistData()
 	data.WatchdogData = c.staticWatchdog.callPersistData()
 	return data
@@ -128,6 +135,7 @@ func (c *Contractor) load() error {
 		close(c.synced)
 	}
 	c.recentRecoveryChange = data.RecentRecoveryChange
+	c.autoFund = data.AutoFund
 	var fcid types.FileContractID
 	for k, v := range data.RenewedFrom {
 		if err := fcid.LoadString(k); err != nil {
@@ -156,6 +164,9 @@ func (c *Contractor) load() error {
 	for _, host := range data.PreferredHosts {
 		c.preferredHosts[host] = struct{}{}
 	}
+	for host, profile := range data.HostProfiles {
+		c.hostProfiles[host] = profile
+	}
 
 	c.staticChurnLimiter = newChurnLimiterFromPersist(c, data.ChurnLimiter)
 
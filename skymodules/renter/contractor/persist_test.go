@@ -92,6 +92,21 @@ func TestSaveLoad(t *testing.T) {
 	}
 	c.preferredHosts["host"] = struct{}{}
 	close(c.synced)
+	c.autoFund = skymodules.AutoFundStatus{
+		Settings: skymodules.AutoFundSettings{
+			Enabled:      true,
+			Threshold:    types.NewCurrency64(1),
+			Amount:       types.NewCurrency64(2),
+			MaxPerPeriod: types.NewCurrency64(3),
+		},
+		Period:       10,
+		PeriodTopUps: types.NewCurrency64(2),
+		Events: []skymodules.AutoFundEvent{{
+			BlockHeight: 15,
+			Amount:      types.NewCurrency64(2),
+		}},
+	}
+	expectedAutoFund := c.autoFund
 
 	c.staticChurnLimiter = newChurnLimiter(c)
 	c.staticChurnLimiter.aggregateCurrentPeriodChurn = 123456
@@ -105,6 +120,7 @@ func TestSaveLoad(t *testing.T) {
 	c.oldContracts = make(map[types.FileContractID]skymodules.RenterContract)
 	c.renewedFrom = make(map[types.FileContractID]types.FileContractID)
 	c.renewedTo = make(map[types.FileContractID]types.FileContractID)
+	c.autoFund = skymodules.AutoFundStatus{}
 	err = c.load()
 	if err != nil {
 		t.Fatal(err)
@@ -129,6 +145,9 @@ func TestSaveLoad(t *testing.T) {
 	if len(c.preferredHosts) != 1 {
 		t.Fatal("wrong length")
 	}
+	if !reflect.DeepEqual(c.autoFund, expectedAutoFund) {
+		t.Fatal("autoFund not restored properly:", c.autoFund)
+	}
 	select {
 	case <-c.synced:
 	default:
@@ -1,11 +1,15 @@
 package contractor
 
 import (
+	"bytes"
+	"fmt"
+	"sort"
 	"sync"
 	"sync/atomic"
 
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/NebulousLabs/threadgroup"
 
 	"gitlab.com/SkynetLabs/skyd/skymodules"
 	"go.sia.tech/siad/crypto"
@@ -24,6 +28,41 @@ import (
 // we ignore that contract and don't delete it. We might want
 // to recover it later.
 
+var (
+	// errInvalidRecoveryScanRange is returned if the range of a recovery
+	// scan is invalid.
+	errInvalidRecoveryScanRange = errors.New("invalid recovery scan range")
+
+	// errNoPausableRecoveryScan is returned when trying to pause or resume a
+	// recovery scan while no scan is running which can be paused.
+	errNoPausableRecoveryScan = errors.New("no pausable recovery scan in progress")
+
+	// errRecoveryScanPaused is returned when trying to pause a recovery scan
+	// which is already paused.
+	errRecoveryScanPaused = errors.New("recovery scan is already paused")
+
+	// errRecoveryScanNotPaused is returned when trying to resume a recovery
+	// scan which isn't paused.
+	errRecoveryScanNotPaused = errors.New("recovery scan is not paused")
+)
+
+// recoveryScanProgress tracks the progress of a recovery scan.
+type recoveryScanProgress struct {
+	// ranged indicates that the scan was initiated manually and scans a
+	// range of blocks. Only ranged scans can be paused.
+	ranged      bool
+	startHeight types.BlockHeight
+	endHeight   types.BlockHeight
+
+	blocksScanned  uint64
+	contractsFound uint64
+
+	// paused indicates whether the scan is paused. If it is, resumeChan is
+	// closed once the scan is resumed.
+	paused     bool
+	resumeChan chan struct{}
+}
+
 // recoveryScanner is a scanner that subscribes to the consensus set from the
 // beginning and searches the blockchain for recoverable contracts. Potential
 // contracts will be added to the contractor which will then periodically try
@@ -76,13 +115,49 @@ func (rs *recoveryScanner) threadedScan(cs modules.ConsensusSet, scanStart modul
 	return nil
 }
 
+// threadedScanRange scans the blocks between start and end (inclusive) for
+// filecontracts belonging to the wallet's seed. In contrast to threadedScan it
+// doesn't subscribe to the consensus set which allows for pausing the scan
+// between blocks without blocking the consensus set.
+func (rs *recoveryScanner) threadedScanRange(cs modules.ConsensusSet, start, end types.BlockHeight, cancel <-chan struct{}) error {
+	if err := rs.c.staticTG.Add(); err != nil {
+		return err
+	}
+	defer rs.c.staticTG.Done()
+	for height := start; height <= end; height++ {
+		// Wait for the scan to be resumed if it is paused.
+		if err := rs.c.managedWaitRecoveryScanResumed(cancel); err != nil {
+			return err
+		}
+		select {
+		case <-cancel:
+			return threadgroup.ErrStopped
+		default:
+		}
+		block, exists := cs.BlockAtHeight(height)
+		if !exists {
+			return fmt.Errorf("block at height %v doesn't exist", height)
+		}
+		// Find lost contracts for recovery.
+		rs.c.mu.Lock()
+		found := rs.c.findRecoverableContracts(rs.rs, block)
+		rs.c.recoveryScan.blocksScanned++
+		rs.c.recoveryScan.contractsFound += found
+		rs.c.mu.Unlock()
+		atomic.StoreInt64(&rs.c.atomicRecoveryScanHeight, int64(height))
+	}
+	return nil
+}
+
 // ProcessConsensusChange scans the blockchain for information relevant to the
 // recoveryScanner.
 func (rs *recoveryScanner) ProcessConsensusChange(cc modules.ConsensusChange) {
 	for _, block := range cc.AppliedBlocks {
 		// Find lost contracts for recovery.
 		rs.c.mu.Lock()
-		rs.c.findRecoverableContracts(rs.rs, block)
+		found := rs.c.findRecoverableContracts(rs.rs, block)
+		rs.c.recoveryScan.blocksScanned++
+		rs.c.recoveryScan.contractsFound += found
 		rs.c.mu.Unlock()
 		atomic.AddInt64(&rs.c.atomicRecoveryScanHeight, 1)
 	}
@@ -98,8 +173,9 @@ func (rs *recoveryScanner) ProcessConsensusChange(cc modules.ConsensusChange) {
 // findRecoverableContracts scans the block for contracts that could
 // potentially be recovered. We are not going to recover them right away though
 // since many of them could already be expired. Recovery happens periodically
-// in threadedContractMaintenance.
-func (c *Contractor) findRecoverableContracts(renterSeed skymodules.RenterSeed, b types.Block) {
+// in threadedContractMaintenance. It returns the number of newly found
+// contracts.
+func (c *Contractor) findRecoverableContracts(renterSeed skymodules.RenterSeed, b types.Block) (found uint64) {
 	for _, txn := range b.Transactions {
 		// Check if the arbitrary data starts with the correct prefix.
 		csi, encryptedHostKey, hasIdentifier := hasFCIdentifier(txn)
@@ -161,8 +237,15 @@ func (c *Contractor) findRecoverableContracts(renterSeed skymodules.RenterSeed,
 				TxnFee:        txnFee,
 				StartHeight:   c.blockHeight - 1, // Assume that it takes 1 block to mine the contract
 			}
+			c.recoveryStatus[fcid] = &skymodules.ContractRecoveryStatus{
+				ID:            fcid,
+				HostPublicKey: hostKey,
+				State:         skymodules.ContractRecoveryStatePending,
+			}
+			found++
 		}
 	}
+	return found
 }
 
 // managedRecoverContract recovers a single contract by contacting the host it
@@ -263,8 +346,10 @@ func (c *Contractor) callRecoverContracts() {
 	}
 	c.mu.RUnlock()
 
-	// Remember the deleted contracts.
+	// Remember the deleted contracts and the outcome of the recovery.
 	deleteContract := make([]bool, len(recoverableContracts))
+	states := make([]skymodules.ContractRecoveryState, len(recoverableContracts))
+	recoveryErrs := make([]error, len(recoverableContracts))
 
 	// Try to recover the contracts in parallel.
 	var wg sync.WaitGroup
@@ -275,6 +360,7 @@ func (c *Contractor) callRecoverContracts() {
 			if blockHeight >= rc.WindowEnd {
 				// No need to recover a contract if we are beyond the WindowEnd.
 				deleteContract[j] = true
+				states[j] = skymodules.ContractRecoveryStateExpired
 				c.staticLog.Printf("Not recovering contract since the current blockheight %v is >= the WindowEnd %v: %v",
 					blockHeight, rc.WindowEnd, rc.ID)
 				return
@@ -290,11 +376,14 @@ func (c *Contractor) callRecoverContracts() {
 			// Recover contract.
 			err := c.managedRecoverContract(rc, ers, blockHeight)
 			if err != nil {
+				states[j] = skymodules.ContractRecoveryStateFailed
+				recoveryErrs[j] = err
 				c.staticLog.Println("Failed to recover contract", rc.ID, err)
 				return
 			}
 			// Recovery was successful.
 			deleteContract[j] = true
+			states[j] = skymodules.ContractRecoveryStateRecovered
 			c.staticLog.Println("Successfully recovered contract", rc.ID)
 		}(i, recoverableContract)
 	}
@@ -302,9 +391,28 @@ func (c *Contractor) callRecoverContracts() {
 	// Wait for the recovery to be done.
 	wg.Wait()
 
-	// Delete the contracts.
+	// Delete the contracts and update their status.
 	c.mu.Lock()
 	for i, rc := range recoverableContracts {
+		if states[i] != "" {
+			// Contracts loaded from disk don't have a status yet.
+			status, exists := c.recoveryStatus[rc.ID]
+			if !exists {
+				status = &skymodules.ContractRecoveryStatus{
+					ID:            rc.ID,
+					HostPublicKey: rc.HostPublicKey,
+				}
+				c.recoveryStatus[rc.ID] = status
+			}
+			status.State = states[i]
+			status.Error = ""
+			if states[i] != skymodules.ContractRecoveryStateExpired {
+				status.Attempts++
+			}
+			if recoveryErrs[i] != nil {
+				status.Error = recoveryErrs[i].Error()
+			}
+		}
 		if deleteContract[i] {
 			delete(c.recoverableContracts, rc.ID)
 			c.staticLog.Println("Deleted contract from recoverable contracts:", rc.ID)
@@ -327,6 +435,86 @@ func (c *Contractor) removeRecoverableContracts(b types.Block) {
 			// Delete the contract from the map since we no longer need to
 			// recover it.
 			delete(c.recoverableContracts, fcid)
+			delete(c.recoveryStatus, fcid)
 		}
 	}
 }
+
+// managedWaitRecoveryScanResumed blocks while the recovery scan is paused.
+func (c *Contractor) managedWaitRecoveryScanResumed(cancel <-chan struct{}) error {
+	c.mu.RLock()
+	paused, resumeChan := c.recoveryScan.paused, c.recoveryScan.resumeChan
+	c.mu.RUnlock()
+	if !paused {
+		return nil
+	}
+	select {
+	case <-resumeChan:
+		return nil
+	case <-cancel:
+		return threadgroup.ErrStopped
+	}
+}
+
+// PauseRecoveryScan pauses the running recovery scan. Only scans initiated
+// through InitRecoveryScan or InitRecoveryScanRange can be paused.
+func (c *Contractor) PauseRecoveryScan() error {
+	if err := c.staticTG.Add(); err != nil {
+		return err
+	}
+	defer c.staticTG.Done()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if atomic.LoadUint32(&c.atomicScanInProgress) == 0 || !c.recoveryScan.ranged {
+		return errNoPausableRecoveryScan
+	}
+	if c.recoveryScan.paused {
+		return errRecoveryScanPaused
+	}
+	c.recoveryScan.paused = true
+	c.recoveryScan.resumeChan = make(chan struct{})
+	return nil
+}
+
+// ResumeRecoveryScan resumes a paused recovery scan.
+func (c *Contractor) ResumeRecoveryScan() error {
+	if err := c.staticTG.Add(); err != nil {
+		return err
+	}
+	defer c.staticTG.Done()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if atomic.LoadUint32(&c.atomicScanInProgress) == 0 || !c.recoveryScan.ranged {
+		return errNoPausableRecoveryScan
+	}
+	if !c.recoveryScan.paused {
+		return errRecoveryScanNotPaused
+	}
+	c.recoveryScan.paused = false
+	close(c.recoveryScan.resumeChan)
+	return nil
+}
+
+// RecoveryScanProgress returns the detailed progress of the most recent
+// recovery scan and the recovery status of the contracts found.
+func (c *Contractor) RecoveryScanProgress() skymodules.RecoveryScanProgress {
+	inProgress := atomic.LoadUint32(&c.atomicScanInProgress) == 1
+	c.mu.RLock()
+	progress := skymodules.RecoveryScanProgress{
+		ScanInProgress: inProgress,
+		Paused:         inProgress && c.recoveryScan.paused,
+		StartHeight:    c.recoveryScan.startHeight,
+		EndHeight:      c.recoveryScan.endHeight,
+		BlocksScanned:  c.recoveryScan.blocksScanned,
+		ContractsFound: c.recoveryScan.contractsFound,
+		Contracts:      make([]skymodules.ContractRecoveryStatus, 0, len(c.recoveryStatus)),
+	}
+	for _, status := range c.recoveryStatus {
+		progress.Contracts = append(progress.Contracts, *status)
+	}
+	c.mu.RUnlock()
+	sort.Slice(progress.Contracts, func(i, j int) bool {
+		return bytes.Compare(progress.Contracts[i].ID[:], progress.Contracts[j].ID[:]) < 0
+	})
+	return progress
+}
@@ -27,6 +27,10 @@ type Session interface {
 	// ContractID returns the FileContractID of the contract.
 	ContractID() types.FileContractID
 
+	// Defrag deletes the sectors which are no longer referenced from the
+	// contract. It returns the number of deleted sectors.
+	Defrag() (uint64, error)
+
 	// Download requests the specified sector data.
 	Download(root crypto.Hash, offset, length uint32) ([]byte, error)
 
@@ -114,6 +118,22 @@ func (hs *hostSession) Close() error {
 // ContractID returns the ID of the contract being revised.
 func (hs *hostSession) ContractID() types.FileContractID { return hs.staticID }
 
+// Defrag deletes the sectors which are no longer referenced from the contract
+// and moves the remaining sectors into the gaps.
+func (hs *hostSession) Defrag() (uint64, error) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	if hs.invalid {
+		return 0, errInvalidSession
+	}
+
+	_, removed, err := hs.staticSession.Defrag()
+	if err != nil {
+		return removed, errors.AddContext(err, "unable to perform defrag operation in session")
+	}
+	return removed, nil
+}
+
 // Download retrieves the sector with the specified Merkle root, and revises
 // the underlying contract to pay the host proportionally to the data
 // retrieved.
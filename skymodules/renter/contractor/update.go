@@ -168,4 +168,10 @@ func (c *Contractor) ProcessConsensusChange(cc modules.ConsensusChange) {
 	if cc.Synced {
 		go c.threadedContractMaintenance()
 	}
+
+	// Remove unreferenced sectors from the contracts if we are within the
+	// defrag window.
+	if cc.Synced {
+		go c.threadedDefragContracts()
+	}
 }
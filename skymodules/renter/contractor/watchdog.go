@@ -56,6 +56,15 @@ type watchdog struct {
 	renewWindow types.BlockHeight
 	blockHeight types.BlockHeight
 
+	// maxFeeBumpBudget is the maximum amount of money the watchdog is allowed
+	// to spend on pending fee bumps at any time.
+	maxFeeBumpBudget types.Currency
+
+	// droppedRenewals is the number of consecutive renewal transactions which
+	// were double-spent before they confirmed. It is reset once a renewal
+	// confirms.
+	droppedRenewals uint64
+
 	staticContractor *Contractor
 	staticDeps       modules.Dependencies
 	staticTPool      transactionPool
@@ -99,6 +108,28 @@ type fileContractStatus struct {
 	// Store the storage proof window start and end heights.
 	windowStart types.BlockHeight
 	windowEnd   types.BlockHeight
+
+	// renewedFrom is the id of the contract this contract was renewed from.
+	// It's empty for contracts which are not the result of a renewal.
+	renewedFrom types.FileContractID
+
+	// monitorHeight is the height at which the watchdog started monitoring
+	// the contract.
+	monitorHeight types.BlockHeight
+
+	// If the formation transaction set is not confirmed in time, the watchdog
+	// may bump its fee by creating a child transaction which spends one of
+	// the renter's outputs from the set and pays a higher fee for the whole
+	// set (CPFP). The feeBumpTxn is broadcast together with the
+	// formationTxnSet until the contract is found.
+	feeBumpTxn       types.Transaction
+	feeBump          types.Currency
+	feeBumpHeight    types.BlockHeight
+	feeBumpConfirmed bool
+
+	// feeBumpPending indicates that a fee bump is currently being created
+	// for the contract outside of the watchdog's lock.
+	feeBumpPending bool
 }
 
 // monitorContractArgs defines the arguments passed to callMonitorContract.
@@ -113,18 +144,23 @@ type monitorContractArgs struct {
 	sweepTxn        types.Transaction
 	sweepParents    []types.Transaction
 	blockHeight     types.BlockHeight
+
+	// renewedFrom is the id of the renewed contract if the monitored contract
+	// was formed by a renewal.
+	renewedFrom types.FileContractID
 }
 
 // newWatchdog creates a new watchdog.
 func newWatchdog(contractor *Contractor) *watchdog {
-	renewWindow := contractor.Allowance().RenewWindow
+	allowance := contractor.Allowance()
 	return &watchdog{
 		contracts:          make(map[types.FileContractID]*fileContractStatus),
 		archivedContracts:  make(map[types.FileContractID]skymodules.ContractWatchStatus),
 		outputDependencies: make(map[types.SiacoinOutputID]map[types.FileContractID]struct{}),
 
-		renewWindow: renewWindow,
-		blockHeight: contractor.blockHeight,
+		renewWindow:      allowance.RenewWindow,
+		maxFeeBumpBudget: allowance.MaxFeeBumpBudget,
+		blockHeight:      contractor.blockHeight,
 
 		staticTPool:      contractor.staticTPool,
 		staticDeps:       contractor.staticDeps,
@@ -141,13 +177,23 @@ func (c *Contractor) ContractStatus(fcID types.FileContractID) (skymodules.Contr
 	return c.staticWatchdog.managedContractStatus(fcID)
 }
 
+// ContractFeeBumps returns the pending fee bumps of the watchdog.
+func (c *Contractor) ContractFeeBumps() []skymodules.ContractFeeBump {
+	if err := c.staticTG.Add(); err != nil {
+		return nil
+	}
+	defer c.staticTG.Done()
+	return c.staticWatchdog.managedPendingFeeBumps()
+}
+
 // callAllowanceUpdated informs the watchdog of an allowance change.
 func (w *watchdog) callAllowanceUpdated(a skymodules.Allowance) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	// Set the new renewWindow.
+	// Set the new renewWindow and fee bump budget.
 	w.renewWindow = a.RenewWindow
+	w.maxFeeBumpBudget = a.MaxFeeBumpBudget
 }
 
 // callMonitorContract tells the watchdog to monitor the blockchain for data
@@ -189,6 +235,8 @@ func (w *watchdog) callMonitorContract(args monitorContractArgs) error {
 		sweepParents:         args.sweepParents,
 		windowStart:          args.revisionTxn.FileContractRevisions[0].NewWindowStart,
 		windowEnd:            args.revisionTxn.FileContractRevisions[0].NewWindowEnd,
+		renewedFrom:          args.renewedFrom,
+		monitorHeight:        args.blockHeight,
 	}
 	w.contracts[args.fcID] = fileContractStatus
 
@@ -268,6 +316,8 @@ func (w *watchdog) archiveContract(fcID types.FileContractID, doubleSpendHeight
 		DoubleSpendHeight:         doubleSpendHeight,
 		WindowStart:               contractData.windowStart,
 		WindowEnd:                 contractData.windowEnd,
+		RenewedFrom:               contractData.renewedFrom,
+		FeeBump:                   contractData.feeBump,
 	}
 	delete(w.contracts, fcID)
 }
@@ -379,6 +429,11 @@ func (w *watchdog) scanAppliedBlock(block types.Block) {
 			if contractData, ok := w.contracts[fcID]; ok {
 				contractData.contractFound = true
 				w.staticContractor.staticLog.Debugln("Found contract: ", fcID)
+
+				// A confirmed renewal resets the dropped renewals.
+				if contractData.renewedFrom != (types.FileContractID{}) {
+					w.droppedRenewals = 0
+				}
 			}
 		}
 
@@ -423,11 +478,18 @@ func (w *watchdog) findDependencySpends(txn types.Transaction) {
 			// If we found the contract already, then this output must be spent in the
 			// formation txn set. Otherwise we must check if this transaction
 			// double-spends any inputs for the formation transaction set.
-			_, ok := w.contracts[fcID]
+			contractData, ok := w.contracts[fcID]
 			if !ok {
 				w.staticContractor.staticLog.Critical("Found dependency on un-monitored formation")
 				continue
 			}
+			// The contract's own fee bump spends an output of the formation
+			// txn set. This is not a double-spend.
+			if contractData.feeBumpHeight != 0 && contractData.feeBumpTxn.ID() == txn.ID() {
+				contractData.feeBumpConfirmed = true
+				w.staticContractor.staticLog.Debugln("Found fee bump for: ", fcID)
+				continue
+			}
 			spendsMonitoredOutput = true
 			inputsSpent[fcID] = struct{}{}
 		}
@@ -450,6 +512,13 @@ func (w *watchdog) findDependencySpends(txn types.Transaction) {
 		if err != nil {
 			w.staticContractor.staticLog.Println("Error removing txn from set, inputs were double-spent:", err, fcID, len(txnSet), txn.ID())
 
+			// Keep track of dropped renewals to alert the user if renewals
+			// keep failing.
+			if contractData.renewedFrom != (types.FileContractID{}) {
+				w.droppedRenewals++
+				w.staticContractor.staticLog.Printf("Renewal of contract %v was dropped (%v consecutive dropped renewals)", contractData.renewedFrom, w.droppedRenewals)
+			}
+
 			//  Signal to the contractor that this contract's inputs were
 			//  double-spent and that it should be removed.
 			w.archiveContract(fcID, w.blockHeight)
@@ -695,6 +764,34 @@ func (w *watchdog) callCheckContracts() {
 			w.archiveContract(fcID, 0)
 		}
 	}
+	w.updateRenewalAlert()
+}
+
+// updateRenewalAlert registers an alert if a renewal transaction hasn't been
+// confirmed within the renewalConfirmationWindow or if too many consecutive
+// renewals were dropped. Otherwise the alert is unregistered.
+func (w *watchdog) updateRenewalAlert() {
+	var unconfirmed int
+	for _, contractData := range w.contracts {
+		if contractData.contractFound || contractData.renewedFrom == (types.FileContractID{}) {
+			continue
+		}
+		if w.blockHeight >= contractData.monitorHeight+renewalConfirmationWindow {
+			unconfirmed++
+		}
+	}
+
+	alerter := w.staticContractor.staticAlerter
+	switch {
+	case w.droppedRenewals >= droppedRenewalsAlertThreshold:
+		cause := fmt.Sprintf("%v consecutive renewal transactions were dropped", w.droppedRenewals)
+		alerter.RegisterAlert(AlertIDRenterContractRenewalUnconfirmed, AlertMSGRenewalUnconfirmed, cause, modules.SeverityWarning)
+	case unconfirmed > 0:
+		cause := fmt.Sprintf("%v renewal transactions remain unconfirmed after %v blocks", unconfirmed, renewalConfirmationWindow)
+		alerter.RegisterAlert(AlertIDRenterContractRenewalUnconfirmed, AlertMSGRenewalUnconfirmed, cause, modules.SeverityWarning)
+	default:
+		alerter.UnregisterAlert(AlertIDRenterContractRenewalUnconfirmed)
+	}
 }
 
 // checkUnconfirmedContract re-broadcasts the file contract formation
@@ -719,11 +816,204 @@ func (w *watchdog) checkUnconfirmedContract(fcID types.FileContractID, contractD
 		// causing this to be triggered.
 		w.sweepContractInputs(fcID, contractData)
 	} else {
+		// Bump the fee of the set if it has been unconfirmed for too long.
+		// The fee bump is created in a go-routine since it requires the
+		// wallet and the transaction pool which must not be called while
+		// holding the watchdog's lock.
+		if w.needsFeeBump(contractData) {
+			contractData.feeBumpPending = true
+			txnSet := append([]types.Transaction{}, contractData.formationTxnSet...)
+			go func() {
+				err := w.staticContractor.staticTG.Add()
+				if err != nil {
+					return
+				}
+				defer w.staticContractor.staticTG.Done()
+				w.managedBumpContractFee(fcID, txnSet)
+			}()
+		}
+
 		// Try to broadcast the transaction set again.
+		txnSet := contractData.formationTxnSet
+		if contractData.feeBumpHeight != 0 && !contractData.feeBumpConfirmed {
+			txnSet = append(append([]types.Transaction{}, txnSet...), contractData.feeBumpTxn)
+		}
 		debugStr := fmt.Sprintf("sending formation txn for contract with id: %s at h=%d wh=%d", fcID.String(), w.blockHeight, contractData.formationSweepHeight)
 		w.staticContractor.staticLog.Debugln(debugStr)
-		w.sendTxnSet(contractData.formationTxnSet, debugStr)
+		w.sendTxnSet(txnSet, debugStr)
+	}
+}
+
+// pendingFeeBumps returns the total amount of money spent on fee bumps of
+// contracts that are not confirmed yet.
+func (w *watchdog) pendingFeeBumps() types.Currency {
+	var pending types.Currency
+	for _, contractData := range w.contracts {
+		if !contractData.contractFound {
+			pending = pending.Add(contractData.feeBump)
+		}
 	}
+	return pending
+}
+
+// needsFeeBump returns whether the fee of the contract's formationTxnSet
+// should be bumped.
+func (w *watchdog) needsFeeBump(contractData *fileContractStatus) bool {
+	if w.maxFeeBumpBudget.IsZero() || contractData.feeBumpPending || contractData.feeBumpHeight != 0 {
+		return false
+	}
+	return w.blockHeight >= contractData.monitorHeight+feeBumpDelay
+}
+
+// managedBumpContractFee creates a child transaction which spends one of the
+// renter's outputs of the formationTxnSet and pays a fee high enough for the
+// whole set to be considered at the current maximum fee estimate (CPFP). The
+// fee is limited by the remaining fee bump budget. The watchdog's lock is only
+// acquired to check the budget and to update the contract, not while calling
+// the wallet or the transaction pool.
+func (w *watchdog) managedBumpContractFee(fcID types.FileContractID, txnSet []types.Transaction) {
+	// Clear the pending flag if no fee bump is created.
+	var bumped bool
+	defer func() {
+		if bumped {
+			return
+		}
+		w.mu.Lock()
+		if contractData, ok := w.contracts[fcID]; ok {
+			contractData.feeBumpPending = false
+		}
+		w.mu.Unlock()
+	}()
+
+	// Find an unspent output of the set that belongs to the wallet.
+	spent := make(map[types.SiacoinOutputID]struct{})
+	for _, txn := range txnSet {
+		for _, sci := range txn.SiacoinInputs {
+			spent[sci.ParentID] = struct{}{}
+		}
+	}
+	var parentID types.SiacoinOutputID
+	var parentOutput types.SiacoinOutput
+	var uc types.UnlockConditions
+	var found bool
+	for _, txn := range txnSet {
+		for i, sco := range txn.SiacoinOutputs {
+			oid := txn.SiacoinOutputID(uint64(i))
+			if _, ok := spent[oid]; ok {
+				continue
+			}
+			var err error
+			uc, err = w.staticContractor.staticWallet.UnlockConditions(sco.UnlockHash)
+			if err != nil {
+				continue
+			}
+			parentID, parentOutput, found = oid, sco, true
+			break
+		}
+		if found {
+			break
+		}
+	}
+	if !found {
+		w.staticContractor.staticLog.Debugln("Unable to bump fee, no wallet output in formation txn set", fcID)
+		return
+	}
+
+	// Create the child transaction.
+	refundUC, err := w.staticContractor.staticWallet.NextAddress()
+	if err != nil {
+		w.staticContractor.staticLog.Println("Unable to get address for fee bump", err)
+		return
+	}
+	child := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{
+			ParentID:         parentID,
+			UnlockConditions: uc,
+		}},
+		SiacoinOutputs: []types.SiacoinOutput{{
+			Value:      parentOutput.Value,
+			UnlockHash: refundUC.UnlockHash(),
+		}},
+		MinerFees: []types.Currency{types.ZeroCurrency},
+	}
+
+	// Compute the fee required for the whole set, including the child, to
+	// match the max fee estimate.
+	var setFees types.Currency
+	setSize := child.MarshalSiaSize()
+	for _, txn := range txnSet {
+		setSize += txn.MarshalSiaSize()
+		for _, fee := range txn.MinerFees {
+			setFees = setFees.Add(fee)
+		}
+	}
+	_, maxFee := w.staticTPool.FeeEstimation()
+	requiredFees := maxFee.Mul64(uint64(setSize))
+	if setFees.Cmp(requiredFees) >= 0 {
+		w.staticContractor.staticLog.Debugln("No fee bump required for contract", fcID)
+		return
+	}
+	fee := requiredFees.Sub(setFees)
+	if fee.Cmp(parentOutput.Value) >= 0 {
+		w.staticContractor.staticLog.Println("Unable to bump fee, output too small", fcID)
+		return
+	}
+	w.mu.Lock()
+	withinBudget := w.pendingFeeBumps().Add(fee).Cmp(w.maxFeeBumpBudget) <= 0
+	w.mu.Unlock()
+	if !withinBudget {
+		w.staticContractor.staticLog.Println("Unable to bump fee, fee bump budget exceeded", fcID)
+		return
+	}
+	child.MinerFees[0] = fee
+	child.SiacoinOutputs[0].Value = parentOutput.Value.Sub(fee)
+
+	// Sign the child.
+	builder, err := w.staticContractor.staticWallet.RegisterTransaction(child, nil)
+	if err != nil {
+		w.staticContractor.staticLog.Println("Unable to register fee bump transaction", err)
+		return
+	}
+	if !builder.MarkWalletInputs() {
+		w.staticContractor.staticLog.Println("feeBumpBuilder did not mark any owned inputs")
+		builder.Drop()
+		return
+	}
+	signedTxnSet, err := builder.Sign(true)
+	if err != nil {
+		w.staticContractor.staticLog.Println("unable to sign fee bump txn", fcID, err)
+		builder.Drop()
+		return
+	}
+	feeBumpTxn := signedTxnSet[len(signedTxnSet)-1]
+
+	// Update the contract. The contract might have been found or archived and
+	// the budget might have been spent in the meantime.
+	w.mu.Lock()
+	contractData, ok := w.contracts[fcID]
+	if !ok || contractData.contractFound || contractData.feeBumpHeight != 0 {
+		w.mu.Unlock()
+		w.staticContractor.staticLog.Debugln("Dropping fee bump, contract no longer unconfirmed", fcID)
+		builder.Drop()
+		return
+	}
+	if w.pendingFeeBumps().Add(fee).Cmp(w.maxFeeBumpBudget) > 0 {
+		w.mu.Unlock()
+		w.staticContractor.staticLog.Println("Unable to bump fee, fee bump budget exceeded", fcID)
+		builder.Drop()
+		return
+	}
+	contractData.feeBumpTxn = feeBumpTxn
+	contractData.feeBump = fee
+	contractData.feeBumpHeight = w.blockHeight
+	contractData.feeBumpPending = false
+	bumped = true
+	w.mu.Unlock()
+	w.staticContractor.staticLog.Printf("Bumped fee of contract %v by %v", fcID, fee.HumanString())
+
+	// Broadcast the child together with the formation set.
+	debugStr := fmt.Sprintf("sending fee bumped formation txn for contract with id: %s", fcID.String())
+	w.sendTxnSet(append(txnSet, feeBumpTxn), debugStr)
 }
 
 // threadedCheckMonitorRevision checks if the given FileContract has it latest
@@ -852,9 +1142,33 @@ func (w *watchdog) managedContractStatus(fcID types.FileContractID) (skymodules.
 		StorageProofFoundAtHeight: contractData.storageProofFound,
 		WindowStart:               contractData.windowStart,
 		WindowEnd:                 contractData.windowEnd,
+		RenewedFrom:               contractData.renewedFrom,
+		FeeBump:                   contractData.feeBump,
 	}, true
 }
 
+// managedPendingFeeBumps returns the fee bumps of all contracts which haven't
+// been found on-chain yet.
+func (w *watchdog) managedPendingFeeBumps() []skymodules.ContractFeeBump {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var bumps []skymodules.ContractFeeBump
+	for fcID, contractData := range w.contracts {
+		if contractData.contractFound || contractData.feeBumpHeight == 0 {
+			continue
+		}
+		bumps = append(bumps, skymodules.ContractFeeBump{
+			ContractID: fcID,
+			TxnID:      contractData.feeBumpTxn.ID(),
+			Fee:        contractData.feeBump,
+			Height:     contractData.feeBumpHeight,
+			Confirmed:  contractData.feeBumpConfirmed,
+		})
+	}
+	return bumps
+}
+
 // threadedSendMostRecentRevision sends the most recent revision transaction out.
 // Should be called whenever a contract is no longer going to be used.
 func (w *watchdog) threadedSendMostRecentRevision(metadata skymodules.RenterContract) {
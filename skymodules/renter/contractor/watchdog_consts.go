@@ -22,6 +22,31 @@ var (
 		Standard: types.BlockHeight(288),
 		Testing:  types.BlockHeight(100),
 	}).(types.BlockHeight)
+
+	// renewalConfirmationWindow is the number of blocks within which the
+	// watchdog expects a renewal transaction to be confirmed. Renewals which
+	// remain unconfirmed for longer cause an alert to be registered.
+	renewalConfirmationWindow = build.Select(build.Var{
+		Dev:      types.BlockHeight(12),
+		Standard: types.BlockHeight(36),
+		Testing:  types.BlockHeight(10),
+	}).(types.BlockHeight)
+
+	// feeBumpDelay is the number of blocks the watchdog waits for a formation
+	// transaction set to be confirmed before it attempts to bump its fee.
+	feeBumpDelay = build.Select(build.Var{
+		Dev:      types.BlockHeight(6),
+		Standard: types.BlockHeight(18),
+		Testing:  types.BlockHeight(3),
+	}).(types.BlockHeight)
+
+	// droppedRenewalsAlertThreshold is the number of consecutive renewals that
+	// need to be dropped before the watchdog registers an alert.
+	droppedRenewalsAlertThreshold = build.Select(build.Var{
+		Dev:      uint64(2),
+		Standard: uint64(2),
+		Testing:  uint64(2),
+	}).(uint64)
 )
 
 var (
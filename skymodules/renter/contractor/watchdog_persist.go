@@ -11,6 +11,7 @@ import (
 type watchdogPersist struct {
 	Contracts         map[string]fileContractStatusPersist      `json:"contracts"`
 	ArchivedContracts map[string]skymodules.ContractWatchStatus `json:"archivedcontracts"`
+	DroppedRenewals   uint64                                    `json:"droppedrenewals,omitempty"`
 }
 
 // fileContractStatusPersist defines what information from fileContractStatus is persisted.
@@ -28,6 +29,14 @@ type fileContractStatusPersist struct {
 
 	WindowStart types.BlockHeight `json:"windowstart"`
 	WindowEnd   types.BlockHeight `json:"windowend"`
+
+	RenewedFrom   types.FileContractID `json:"renewedfrom,omitempty"`
+	MonitorHeight types.BlockHeight    `json:"monitorheight,omitempty"`
+
+	FeeBumpTxn       types.Transaction `json:"feebumptxn,omitempty"`
+	FeeBump          types.Currency    `json:"feebump,omitempty"`
+	FeeBumpHeight    types.BlockHeight `json:"feebumpheight,omitempty"`
+	FeeBumpConfirmed bool              `json:"feebumpconfirmed,omitempty"`
 }
 
 // persistData returns the data that will be saved to disk for
@@ -49,6 +58,12 @@ func (d *fileContractStatus) persistData() fileContractStatusPersist {
 		SweepParents:         d.sweepParents,
 		WindowStart:          d.windowStart,
 		WindowEnd:            d.windowEnd,
+		RenewedFrom:          d.renewedFrom,
+		MonitorHeight:        d.monitorHeight,
+		FeeBumpTxn:           d.feeBumpTxn,
+		FeeBump:              d.feeBump,
+		FeeBumpHeight:        d.feeBumpHeight,
+		FeeBumpConfirmed:     d.feeBumpConfirmed,
 	}
 }
 
@@ -60,6 +75,7 @@ func (w *watchdog) callPersistData() watchdogPersist {
 	data := watchdogPersist{
 		Contracts:         make(map[string]fileContractStatusPersist),
 		ArchivedContracts: make(map[string]skymodules.ContractWatchStatus),
+		DroppedRenewals:   w.droppedRenewals,
 	}
 	for fcID, contractData := range w.contracts {
 		data.Contracts[fcID.String()] = contractData.persistData()
@@ -75,6 +91,7 @@ func (w *watchdog) callPersistData() watchdogPersist {
 // information stored in persistData.
 func newWatchdogFromPersist(contractor *Contractor, persistData watchdogPersist) (*watchdog, error) {
 	w := newWatchdog(contractor)
+	w.droppedRenewals = persistData.DroppedRenewals
 
 	var fcID types.FileContractID
 	for fcIDString, data := range persistData.Contracts {
@@ -96,6 +113,14 @@ func newWatchdogFromPersist(contractor *Contractor, persistData watchdogPersist)
 			sweepParents: data.SweepParents,
 			windowStart:  data.WindowStart,
 			windowEnd:    data.WindowEnd,
+
+			renewedFrom:   data.RenewedFrom,
+			monitorHeight: data.MonitorHeight,
+
+			feeBumpTxn:       data.FeeBumpTxn,
+			feeBump:          data.FeeBump,
+			feeBumpHeight:    data.FeeBumpHeight,
+			feeBumpConfirmed: data.FeeBumpConfirmed,
 		}
 		for _, oid := range data.ParentOutputs {
 			contractData.parentOutputs[oid] = struct{}{}
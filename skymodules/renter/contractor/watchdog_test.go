@@ -1,6 +1,7 @@
 package contractor
 
 import (
+	"fmt"
 	"math"
 	"sync"
 	"testing"
@@ -732,13 +733,11 @@ func TestWatchdogPruning(t *testing.T) {
 
 	// Signal the watchdog with this file contract and formation transaction.
 	monitorContractArgs := monitorContractArgs{
-		false,
-		fcID,
-		revisionTxn,
-		txnSet,
-		txnSet[0],
-		nil,
-		5000,
+		fcID:            fcID,
+		revisionTxn:     revisionTxn,
+		formationTxnSet: txnSet,
+		sweepTxn:        txnSet[0],
+		blockHeight:     5000,
 	}
 	err = c.staticWatchdog.callMonitorContract(monitorContractArgs)
 	if err != nil {
@@ -861,13 +860,11 @@ func TestWatchdogDependencyAdding(t *testing.T) {
 
 	// Signal the watchdog with this file contract and formation transaction.
 	monitorContractArgs := monitorContractArgs{
-		false,
-		fcID,
-		revisionTxn,
-		formationSet,
-		txnSet[0],
-		nil,
-		5000,
+		fcID:            fcID,
+		revisionTxn:     revisionTxn,
+		formationTxnSet: formationSet,
+		sweepTxn:        txnSet[0],
+		blockHeight:     5000,
 	}
 	err = c.staticWatchdog.callMonitorContract(monitorContractArgs)
 	if err != nil {
@@ -962,3 +959,270 @@ func TestWatchdogDependencyAdding(t *testing.T) {
 		t.Fatal("unexpected txn set length", len(updatedTxnSet), len(txnSet)-numRoots)
 	}
 }
+
+// TestWatchdogRenewalAlert checks that the watchdog registers an alert for
+// renewals which don't confirm in time or are dropped repeatedly.
+func TestWatchdogRenewalAlert(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// create testing trio
+	_, c, _, cf, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tryClose(cf, t)
+
+	// Give the watchdog a gated transaction pool to prevent it from rejecting
+	// the fake formation transaction sets.
+	gatedTpool := gatedTpool{
+		tpoolGate: &tpoolGate{gateClosed: true,
+			txnSets: make([][]types.Transaction, 0),
+		},
+		transactionPool: c.staticTPool,
+	}
+	w := c.staticWatchdog
+	w.mu.Lock()
+	w.staticTPool = gatedTpool
+	w.blockHeight = 5000
+	w.mu.Unlock()
+
+	// hasAlert is a helper to check whether the renewal alert is registered.
+	hasAlert := func() bool {
+		_, _, warn := c.Alerts()
+		for _, alert := range warn {
+			if alert.Module == "contractor" && alert.Msg == AlertMSGRenewalUnconfirmed {
+				return true
+			}
+		}
+		return false
+	}
+
+	// monitorRenewal is a helper that monitors a fake renewal at the
+	// watchdog's current height.
+	monitorRenewal := func() (txnSet []types.Transaction, fcTxn types.Transaction) {
+		txnSet, _, _, fcTxn, _ = createTestTransactionTree(1, 1)
+		fcID := fcTxn.FileContractID(0)
+		w.mu.Lock()
+		bh := w.blockHeight
+		w.mu.Unlock()
+		err := w.callMonitorContract(monitorContractArgs{
+			fcID:            fcID,
+			revisionTxn:     createFakeRevisionTxn(fcID, 1, 10000, 10005),
+			formationTxnSet: txnSet,
+			sweepTxn:        txnSet[0],
+			blockHeight:     bh,
+			renewedFrom:     types.FileContractID{1},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	// Monitor a renewal. The alert shouldn't be registered right away.
+	_, fcTxn := monitorRenewal()
+	w.callCheckContracts()
+	if hasAlert() {
+		t.Fatal("alert shouldn't be registered yet")
+	}
+	status, ok := c.ContractStatus(fcTxn.FileContractID(0))
+	if !ok || status.RenewedFrom != (types.FileContractID{1}) {
+		t.Fatal("unexpected status", ok, status.RenewedFrom)
+	}
+
+	// Move past the confirmation window. The alert should be registered.
+	w.mu.Lock()
+	w.blockHeight += renewalConfirmationWindow
+	w.mu.Unlock()
+	w.callCheckContracts()
+	if !hasAlert() {
+		t.Fatal("alert should be registered")
+	}
+
+	// Confirm the renewal. The alert should be unregistered again.
+	w.callScanConsensusChange(modules.ConsensusChange{
+		AppliedBlocks: []types.Block{{Transactions: []types.Transaction{fcTxn}}},
+	})
+	w.callCheckContracts()
+	if hasAlert() {
+		t.Fatal("alert should be unregistered")
+	}
+
+	// Drop renewals by double-spending their root transactions. The alert
+	// should be registered once the threshold is reached.
+	for i := uint64(0); i < droppedRenewalsAlertThreshold; i++ {
+		if hasAlert() {
+			t.Fatal("alert shouldn't be registered yet", i)
+		}
+		txnSet, _ := monitorRenewal()
+		doubleSpend := txnSet[len(txnSet)-1]
+		doubleSpend.ArbitraryData = [][]byte{fastrand.Bytes(16)}
+		w.callScanConsensusChange(modules.ConsensusChange{
+			AppliedBlocks: []types.Block{{Transactions: []types.Transaction{doubleSpend}}},
+		})
+		w.callCheckContracts()
+	}
+	if !hasAlert() {
+		t.Fatal("alert should be registered")
+	}
+	w.mu.Lock()
+	droppedRenewals := w.droppedRenewals
+	w.mu.Unlock()
+	if droppedRenewals != droppedRenewalsAlertThreshold {
+		t.Fatal("wrong number of dropped renewals", droppedRenewals)
+	}
+}
+
+// TestWatchdogFeeBump checks that the watchdog bumps the fee of unconfirmed
+// formation transaction sets within its budget.
+func TestWatchdogFeeBump(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// create testing trio
+	_, c, _, cf, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tryClose(cf, t)
+
+	// Give the watchdog a gated transaction pool to prevent it from rejecting
+	// the fake formation transaction sets.
+	gatedTpool := gatedTpool{
+		tpoolGate: &tpoolGate{gateClosed: true,
+			logTxns: true,
+			txnSets: make([][]types.Transaction, 0),
+		},
+		transactionPool: c.staticTPool,
+	}
+	w := c.staticWatchdog
+	w.mu.Lock()
+	w.staticTPool = gatedTpool
+	w.blockHeight = 5000
+	w.mu.Unlock()
+
+	// monitorContract is a helper that monitors a fake contract with an output
+	// owned by the wallet.
+	monitorContract := func() types.Transaction {
+		uc, err := c.staticWallet.NextAddress()
+		if err != nil {
+			t.Fatal(err)
+		}
+		fcTxn := types.Transaction{
+			SiacoinInputs: []types.SiacoinInput{{ParentID: types.SiacoinOutputID(crypto.HashObject(fastrand.Bytes(32)))}},
+			SiacoinOutputs: []types.SiacoinOutput{{
+				Value:      types.SiacoinPrecision.Mul64(1000),
+				UnlockHash: uc.UnlockHash(),
+			}},
+			FileContracts: []types.FileContract{{UnlockHash: types.UnlockHash(crypto.HashObject(fastrand.Bytes(32)))}},
+		}
+		fcID := fcTxn.FileContractID(0)
+		w.mu.Lock()
+		bh := w.blockHeight
+		w.mu.Unlock()
+		err = w.callMonitorContract(monitorContractArgs{
+			fcID:            fcID,
+			revisionTxn:     createFakeRevisionTxn(fcID, 1, 10000, 10005),
+			formationTxnSet: []types.Transaction{fcTxn},
+			sweepTxn:        fcTxn,
+			blockHeight:     bh,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fcTxn
+	}
+
+	// checkContracts is a helper that checks the contracts and waits for
+	// pending fee bumps to be created.
+	checkContracts := func() {
+		w.callCheckContracts()
+		err := build.Retry(100, 10*time.Millisecond, func() error {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			for fcID, contractData := range w.contracts {
+				if contractData.feeBumpPending {
+					return fmt.Errorf("fee bump of %v still pending", fcID)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Without a budget, no fee bump should be created.
+	fcTxn := monitorContract()
+	w.mu.Lock()
+	w.blockHeight += feeBumpDelay
+	w.mu.Unlock()
+	checkContracts()
+	if len(c.ContractFeeBumps()) != 0 {
+		t.Fatal("fee bump shouldn't have been created")
+	}
+
+	// Set a budget. Now the fee should be bumped.
+	w.mu.Lock()
+	w.maxFeeBumpBudget = types.SiacoinPrecision.Mul64(100)
+	w.mu.Unlock()
+	checkContracts()
+	bumps := c.ContractFeeBumps()
+	if len(bumps) != 1 {
+		t.Fatal("expected 1 fee bump", len(bumps))
+	}
+	bump := bumps[0]
+	if bump.ContractID != fcTxn.FileContractID(0) || bump.Fee.IsZero() || bump.Confirmed {
+		t.Fatal("unexpected fee bump", bump)
+	}
+
+	// The child should be broadcast together with the formation set.
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		gatedTpool.mu.Lock()
+		defer gatedTpool.mu.Unlock()
+		for _, txnSet := range gatedTpool.txnSets {
+			if len(txnSet) == 2 && txnSet[1].ID() == bump.TxnID {
+				return nil
+			}
+		}
+		return errors.New("fee bump wasn't broadcast")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Limit the budget to the pending bumps. Another contract shouldn't be
+	// bumped.
+	w.mu.Lock()
+	w.maxFeeBumpBudget = bump.Fee
+	w.mu.Unlock()
+	monitorContract()
+	w.mu.Lock()
+	w.blockHeight += feeBumpDelay
+	w.mu.Unlock()
+	checkContracts()
+	if len(c.ContractFeeBumps()) != 1 {
+		t.Fatal("budget should be exceeded")
+	}
+
+	// Confirm the first contract together with its fee bump. This shouldn't be
+	// mistaken for a double-spend.
+	w.mu.Lock()
+	child := w.contracts[bump.ContractID].feeBumpTxn
+	w.mu.Unlock()
+	w.callScanConsensusChange(modules.ConsensusChange{
+		AppliedBlocks: []types.Block{{Transactions: []types.Transaction{fcTxn, child}}},
+	})
+	status, ok := c.ContractStatus(bump.ContractID)
+	if !ok || !status.ContractFound || status.Archived || !status.FeeBump.Equals(bump.Fee) {
+		t.Fatal("unexpected status", ok, status)
+	}
+	if len(c.ContractFeeBumps()) != 0 {
+		t.Fatal("fee bump shouldn't be pending anymore")
+	}
+}
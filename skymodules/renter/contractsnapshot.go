@@ -0,0 +1,63 @@
+package renter
+
+import (
+	"sort"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/modules"
+)
+
+// ContractSetSnapshot returns a snapshot of the renter's current contracts
+// signed with the renter's contract snapshot key.
+func (r *Renter) ContractSetSnapshot() (skymodules.SignedContractSetSnapshot, error) {
+	if err := r.tg.Add(); err != nil {
+		return skymodules.SignedContractSetSnapshot{}, err
+	}
+	defer r.tg.Done()
+
+	snapshot := skymodules.ContractSetSnapshot{
+		BlockHeight: r.staticConsensusSet.Height(),
+		Timestamp:   time.Now().Unix(),
+	}
+	for _, c := range r.staticHostContractor.Contracts() {
+		var netAddress modules.NetAddress
+		hdbe, exists, _ := r.staticHostDB.Host(c.HostPublicKey)
+		if exists {
+			netAddress = hdbe.NetAddress
+		}
+		snapshot.Contracts = append(snapshot.Contracts, skymodules.ContractSnapshot{
+			ID:            c.ID,
+			HostPublicKey: c.HostPublicKey,
+			NetAddress:    netAddress,
+			Size:          c.Size(),
+			StartHeight:   c.StartHeight,
+			EndHeight:     c.EndHeight,
+
+			TotalCost:           c.TotalCost,
+			Fees:                c.TxnFee.Add(c.SiafundFee).Add(c.ContractFee),
+			RenterFunds:         c.RenterFunds,
+			DownloadSpending:    c.DownloadSpending,
+			FundAccountSpending: c.FundAccountSpending,
+			MaintenanceSpending: c.MaintenanceSpending.Sum(),
+			StorageSpending:     c.StorageSpending,
+			UploadSpending:      c.UploadSpending,
+
+			GoodForUpload: c.Utility.GoodForUpload,
+			GoodForRenew:  c.Utility.GoodForRenew,
+		})
+	}
+	sort.Slice(snapshot.Contracts, func(i, j int) bool {
+		return snapshot.Contracts[i].ID.String() < snapshot.Contracts[j].ID.String()
+	})
+
+	id := r.mu.RLock()
+	sk := r.persist.ContractSnapshotKey
+	r.mu.RUnlock(id)
+	signed, err := skymodules.SignContractSetSnapshot(snapshot, sk)
+	if err != nil {
+		return skymodules.SignedContractSetSnapshot{}, errors.AddContext(err, "failed to sign contract set snapshot")
+	}
+	return signed, nil
+}
@@ -26,7 +26,27 @@ func (r *Renter) DeleteDir(siaPath skymodules.SiaPath) error {
 		return err
 	}
 	defer r.tg.Done()
-	return r.staticFileSystem.DeleteDir(siaPath)
+	return r.managedDeleteDir(siaPath)
+}
+
+// managedDeleteDir deletes a directory including its sub directories and
+// files. The chunks of the deleted siafiles are released within the skyfile
+// chunk index.
+func (r *Renter) managedDeleteDir(siaPath skymodules.SiaPath) error {
+	// Remember the siafiles before deleting them. Files which are added
+	// while deleting the directory are released when the index is pruned.
+	files, _, err := r.managedListSiafiles(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "failed to list siafiles of directory")
+	}
+	err = r.staticFileSystem.DeleteDir(siaPath)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		r.managedReleaseDeletedSiafile(file)
+	}
+	return nil
 }
 
 // DirList lists the directories in a siadir
@@ -2,6 +2,7 @@ package renter
 
 import (
 	"fmt"
+	"math"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -109,6 +110,11 @@ func (r *Renter) managedUpdateFileMetadatasParams(dirSiaPath skymodules.SiaPath,
 					return err
 				}
 				err = sf.UpdateMetadata(offlineMap, goodForRenewMap, contracts, used)
+				if err == nil {
+					md := sf.Metadata()
+					health := math.Max(md.CachedHealth, md.CachedStuckHealth)
+					r.staticSkylinkHealthCache.callUpdate(fileSiaPath, md.Skylinks, health)
+				}
 				return errors.Compose(err, sf.Close())
 			}()
 			errMU.Lock()
@@ -205,7 +205,8 @@ func (dub *dirUpdateBatcher) newBatch(priorCompleteChan <-chan struct{}) *dirUpd
 }
 
 // threadedExecuteBatchUpdates is a permanent background thread which will
-// execute batched updates in the background.
+// execute batched updates in the background whenever a flush is requested,
+// either explicitly or periodically by the maintenance scheduler.
 func (dub *dirUpdateBatcher) threadedExecuteBatchUpdates() {
 	for {
 		select {
@@ -216,7 +217,6 @@ func (dub *dirUpdateBatcher) threadedExecuteBatchUpdates() {
 			dub.nextBatch.managedExecute()
 			return
 		case <-dub.staticFlushChan:
-		case <-time.After(maxTimeBetweenBatchExecutions):
 		}
 
 		// Rotate the current batch out for a new batch. This will block any
@@ -249,6 +249,12 @@ func (r *Renter) newDirUpdateBatcher() (*dirUpdateBatcher, error) {
 	if err != nil {
 		return nil, errors.AddContext(err, "unable to launch the batch updates backghround thread")
 	}
+
+	// Schedule a job that executes the current batch periodically.
+	err = r.registerMaintenanceJob(maintenanceJobDirUpdateBatch, maxTimeBetweenBatchExecutions, false, dub.callFlush)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to schedule the batch updates")
+	}
 	return dub, nil
 }
 
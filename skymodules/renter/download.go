@@ -27,6 +27,10 @@ type (
 		atomicDataReceived         uint64 // Incremented as data completes, will stop at 100% file progress.
 		atomicTotalDataTransferred uint64 // Incremented as data arrives, includes overdrive, contract negotiation, etc.
 
+		// atomicStuckChunks is the number of the download's chunks which
+		// have been waiting in the download heap for too long.
+		atomicStuckChunks uint64
+
 		// Other progress variables.
 		chunksRemaining uint64        // Number of chunks whose downloads are incomplete.
 		completeChan    chan struct{} // Closed once the download is complete.
@@ -36,6 +40,10 @@ type (
 		// completeChan is closed.
 		downloadCompleteFuncs []func(error) error
 
+		// stuckChunkAlert indicates whether an alert was registered for
+		// stuck chunks of the download.
+		stuckChunkAlert bool
+
 		// Timestamp information.
 		endTime         time.Time // Set immediately before closing 'completeChan'.
 		staticStartTime time.Time // Set immediately when the download object is created.
@@ -559,6 +567,7 @@ func (r *Renter) DownloadByUID(uid skymodules.DownloadID) (skymodules.DownloadIn
 		Received:             atomic.LoadUint64(&d.atomicDataReceived),
 		StartTime:            d.staticStartTime,
 		StartTimeUnix:        d.staticStartTime.UnixNano(),
+		StuckChunks:          atomic.LoadUint64(&d.atomicStuckChunks),
 		TotalDataTransferred: atomic.LoadUint64(&d.atomicTotalDataTransferred),
 	}, true
 }
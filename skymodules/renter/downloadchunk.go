@@ -75,6 +75,10 @@ type unfinishedDownloadChunk struct {
 	// Memory management variables.
 	memoryAllocated uint64
 
+	// Download heap state - need the download heap's mutex to access.
+	heapPushTime time.Time // When the chunk was pushed onto the heap.
+	heapStuck    bool      // Whether the chunk was waiting in the heap for too long.
+
 	// The staticDownload object, mostly to update staticDownload progress.
 	staticDownload *download
 	mu             sync.Mutex
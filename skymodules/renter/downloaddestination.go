@@ -29,27 +29,6 @@ import (
 	"go.sia.tech/siad/modules"
 )
 
-// skipWriter is a helper type that ignores the first 'skip' bytes written to it.
-type skipWriter struct {
-	writer io.Writer
-	skip   int
-}
-
-// Write will write bytes to the skipWriter, being sure to skip over any bytes
-// which the skipWriter was initialized to skip
-func (sw *skipWriter) Write(p []byte) (int, error) {
-	if sw.skip == 0 {
-		return sw.writer.Write(p)
-	} else if sw.skip > len(p) {
-		sw.skip -= len(p)
-		return len(p), nil
-	}
-	n, err := sw.writer.Write(p[sw.skip:])
-	n += sw.skip
-	sw.skip = 0
-	return n, err
-}
-
 // sectionWriter implements Write on a section
 // of an underlying WriterAt.
 type sectionWriter struct {
@@ -133,12 +112,8 @@ func (ddf *downloadDestinationFile) WritePieces(ec skymodules.ErasureCoder, piec
 	if ddf.deps.Disrupt("PostponeWritePiecesRecovery") {
 		time.Sleep(time.Duration(fastrand.Intn(1000)) * time.Millisecond)
 	}
-	skipWriter := &skipWriter{
-		writer: sw,
-		skip:   int(dataOffset),
-	}
-	bufioWriter := bufio.NewWriter(skipWriter)
-	err := ec.Recover(pieces, dataOffset+length, bufioWriter)
+	bufioWriter := bufio.NewWriter(sw)
+	err := ec.RecoverRange(pieces, dataOffset, length, bufioWriter)
 	err2 := bufioWriter.Flush()
 	return errors.AddContext(errors.Compose(err, err2), "unable to write pieces to destination file")
 }
@@ -234,7 +209,7 @@ func (ddw *downloadDestinationWriter) WritePieces(ec skymodules.ErasureCoder, pi
 
 		// Write the data to the stream, and the update the progress and unblock
 		// the next write.
-		err := ec.Recover(pieces, dataOffset+length, &skipWriter{writer: ddw, skip: int(dataOffset)})
+		err := ec.RecoverRange(pieces, dataOffset, length, ddw)
 		ddw.progress += int64(length)
 		ddw.unblockNextWrites()
 		return err
@@ -14,24 +14,48 @@ package renter
 import (
 	"container/heap"
 	"context"
+	"fmt"
 	"io"
 	"os"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// errDownloadChunkStarved is returned if a chunk of a download has been
+	// waiting in the download heap for too long.
+	errDownloadChunkStarved = errors.New("chunk was starved in the download heap")
+
+	// errDownloadNotFound is returned if there is no download for a uid.
+	errDownloadNotFound = errors.New("download not found")
+
+	// errDownloadAlreadyComplete is returned when cancelling a download which
+	// is already complete.
+	errDownloadAlreadyComplete = errors.New("download is already complete")
 )
 
-// downloadChunkHeap is a heap that is sorted first by file priority, then by
-// the start time of the download, and finally by the index of the chunk.  As
-// downloads are queued, they are added to the downloadChunkHeap. As resources
-// become available to execute downloads, chunks are pulled off of the heap and
-// distributed to workers.
+// downloadChunkHeap is a heap that is sorted first by whether a chunk is stuck,
+// then by file priority, then by the start time of the download, and finally
+// by the index of the chunk.  As downloads are queued, they are added to the
+// downloadChunkHeap. As resources become available to execute downloads,
+// chunks are pulled off of the heap and distributed to workers.
 type downloadChunkHeap []*unfinishedDownloadChunk
 
 // Implementation of heap.Interface for downloadChunkHeap.
 func (dch downloadChunkHeap) Len() int { return len(dch) }
 func (dch downloadChunkHeap) Less(i, j int) bool {
-	// First sort by priority.
+	// Stuck chunks go first to prevent them from being starved by chunks of
+	// a higher priority.
+	if dch[i].heapStuck != dch[j].heapStuck {
+		return dch[i].heapStuck
+	}
+	// Then sort by priority.
 	if dch[i].staticPriority != dch[j].staticPriority {
 		return dch[i].staticPriority > dch[j].staticPriority
 	}
@@ -75,6 +99,9 @@ func (dh *downloadHeap) managedPopIncomplete() *unfinishedDownloadChunk {
 			return nil
 		}
 		nextChunk := heap.Pop(&dh.heap).(*unfinishedDownloadChunk)
+		if nextChunk.heapStuck {
+			atomic.AddUint64(&nextChunk.staticDownload.atomicStuckChunks, ^uint64(0)) // subtract 1
+		}
 		if !nextChunk.staticDownload.staticComplete() {
 			return nextChunk
 		}
@@ -85,7 +112,90 @@ func (dh *downloadHeap) managedPopIncomplete() *unfinishedDownloadChunk {
 func (dh *downloadHeap) managedPush(chunk *unfinishedDownloadChunk) {
 	dh.mu.Lock()
 	defer dh.mu.Unlock()
-	dh.heap.Push(chunk)
+	chunk.heapPushTime = time.Now()
+	chunk.heapStuck = false
+	heap.Push(&dh.heap, chunk)
+}
+
+// managedPending returns the incomplete chunks in the heap in the order in
+// which they will be popped.
+func (dh *downloadHeap) managedPending(now time.Time) []skymodules.PendingDownloadChunk {
+	dh.mu.Lock()
+	chunks := make(downloadChunkHeap, 0, len(dh.heap))
+	for _, chunk := range dh.heap {
+		if !chunk.staticDownload.staticComplete() {
+			chunks = append(chunks, chunk)
+		}
+	}
+	sort.Sort(chunks)
+	pending := make([]skymodules.PendingDownloadChunk, 0, len(chunks))
+	for _, chunk := range chunks {
+		pending = append(pending, skymodules.PendingDownloadChunk{
+			DownloadID:   chunk.staticDownload.staticUID,
+			SiaPath:      chunk.staticDownload.staticSiaPath,
+			ChunkIndex:   chunk.staticChunkIndex,
+			Priority:     chunk.staticPriority,
+			Age:          now.Sub(chunk.heapPushTime),
+			MemoryNeeded: chunk.memoryRequired(),
+			Stuck:        chunk.heapStuck,
+		})
+	}
+	dh.mu.Unlock()
+	return pending
+}
+
+// managedRemoveDownload removes the chunks of the given download from the
+// heap and returns the number of removed chunks.
+func (dh *downloadHeap) managedRemoveDownload(d *download) int {
+	dh.mu.Lock()
+	defer dh.mu.Unlock()
+	remaining := dh.heap[:0]
+	removed := 0
+	for _, chunk := range dh.heap {
+		if chunk.staticDownload != d {
+			remaining = append(remaining, chunk)
+			continue
+		}
+		if chunk.heapStuck {
+			atomic.AddUint64(&d.atomicStuckChunks, ^uint64(0)) // subtract 1
+		}
+		removed++
+	}
+	// Clear the references to the removed chunks.
+	for i := len(remaining); i < len(dh.heap); i++ {
+		dh.heap[i] = nil
+	}
+	dh.heap = remaining
+	heap.Init(&dh.heap)
+	return removed
+}
+
+// managedUpdateStuckChunks marks the chunks which have been waiting in the heap
+// for longer than the stuck threshold as stuck, moving them to the front of
+// the heap. The chunks which became stuck are returned alongside the chunks
+// which have been waiting for longer than the starved timeout.
+func (dh *downloadHeap) managedUpdateStuckChunks(now time.Time, stuckThreshold, starvedTimeout time.Duration) (stuck, starved []*unfinishedDownloadChunk) {
+	dh.mu.Lock()
+	defer dh.mu.Unlock()
+	for _, chunk := range dh.heap {
+		if chunk.staticDownload.staticComplete() {
+			continue
+		}
+		age := now.Sub(chunk.heapPushTime)
+		if age >= starvedTimeout {
+			starved = append(starved, chunk)
+		}
+		if age >= stuckThreshold && !chunk.heapStuck {
+			chunk.heapStuck = true
+			atomic.AddUint64(&chunk.staticDownload.atomicStuckChunks, 1)
+			stuck = append(stuck, chunk)
+		}
+	}
+	// Restore the heap order if chunks became stuck.
+	if len(stuck) > 0 {
+		heap.Init(&dh.heap)
+	}
+	return
 }
 
 // acquireMemoryForDownloadChunk will block until memory is available for the
@@ -100,11 +210,61 @@ func (r *Renter) managedAcquireMemoryForDownloadChunk(udc *unfinishedDownloadChu
 	// need extra memory to decode a bunch of pieces, though I do not believe
 	// our erasure coding has been optimized around this yet, so we may actually
 	// go over the memory limits when we decode pieces.
-	memoryRequired := uint64(udc.staticOverdrive+udc.erasureCode.MinPieces()) * udc.staticPieceSize
+	memoryRequired := udc.memoryRequired()
 	udc.memoryAllocated = memoryRequired
 	return udc.staticMemoryManager.Request(context.Background(), memoryRequired, memoryPriorityHigh)
 }
 
+// memoryRequired returns the amount of memory required to download the chunk,
+// which is the memory for the minimum number of pieces plus the overdrive
+// amount.
+func (udc *unfinishedDownloadChunk) memoryRequired() uint64 {
+	return uint64(udc.staticOverdrive+udc.erasureCode.MinPieces()) * udc.staticPieceSize
+}
+
+// PendingDownloadChunks lists the chunks of classic downloads which are
+// waiting in the download heap, in the order they will be downloaded.
+func (r *Renter) PendingDownloadChunks() ([]skymodules.PendingDownloadChunk, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	return r.staticDownloadHeap.managedPending(time.Now()), nil
+}
+
+// CancelPendingDownload cancels the download with the given uid and removes
+// its pending chunks from the download heap. This allows clearing stuck
+// downloads without restarting the renter.
+func (r *Renter) CancelPendingDownload(uid skymodules.DownloadID) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	d, exists := r.staticDownloadHistory.callFetchDownload(uid)
+	if !exists {
+		return errDownloadNotFound
+	}
+
+	// Fail the download. Chunks which were already distributed to the
+	// workers are aborted by the workers once they notice.
+	d.mu.Lock()
+	complete := d.staticComplete()
+	if !complete {
+		d.err = skymodules.ErrDownloadCancelled
+		d.markComplete()
+	}
+	d.mu.Unlock()
+	if complete {
+		return errDownloadAlreadyComplete
+	}
+
+	// Remove the chunks which haven't been popped yet. They didn't acquire
+	// any memory yet.
+	removed := r.staticDownloadHeap.managedRemoveDownload(d)
+	r.staticLog.Printf("Cancelled download %v of '%v', removed %v pending chunks", uid, d.staticSiaPath, removed)
+	return nil
+}
+
 // managedAddChunkToDownloadHeap will add a chunk to the download heap in a
 // thread-safe way.
 func (r *Renter) managedAddChunkToDownloadHeap(udc *unfinishedDownloadChunk) {
@@ -284,6 +444,57 @@ func (r *Renter) managedTryFetchChunkFromDisk(chunk *unfinishedDownloadChunk) bo
 	return true
 }
 
+// managedCheckStuckDownloadChunks checks the download heap for stuck chunks.
+// Stuck chunks are prioritized and reported through the logs and an alert.
+// Downloads with chunks that remain stuck until the starved timeout are
+// failed.
+func (r *Renter) managedCheckStuckDownloadChunks() {
+	now := time.Now()
+	stuck, starved := r.staticDownloadHeap.managedUpdateStuckChunks(now, downloadChunkStuckThreshold, downloadChunkStarvedTimeout)
+	for _, chunk := range stuck {
+		d := chunk.staticDownload
+		cause := fmt.Sprintf("chunk %v of download %v of '%v' has been waiting in the download heap for %v", chunk.staticChunkIndex, d.staticUID, d.staticSiaPath, now.Sub(chunk.heapPushTime).Round(time.Second))
+		r.staticLog.Println("WARN:", cause)
+		r.staticAlerter.RegisterAlert(alertIDDownloadChunkStuck(d.staticUID), AlertMSGDownloadChunkStuck, cause, modules.SeverityWarning)
+
+		// Unregister the alert once the download is done.
+		d.mu.Lock()
+		if !d.stuckChunkAlert {
+			d.stuckChunkAlert = true
+			d.onComplete(func(_ error) error {
+				r.staticAlerter.UnregisterAlert(alertIDDownloadChunkStuck(d.staticUID))
+				return nil
+			})
+		}
+		d.mu.Unlock()
+	}
+	for _, chunk := range starved {
+		r.staticLog.Printf("WARN: failing download %v of '%v' since chunk %v was starved", chunk.staticDownload.staticUID, chunk.staticDownload.staticSiaPath, chunk.staticChunkIndex)
+		chunk.staticDownload.managedFail(fmt.Errorf("chunk %v failed: %v", chunk.staticChunkIndex, errDownloadChunkStarved))
+	}
+}
+
+// threadedCheckStuckDownloadChunks periodically checks the download heap for
+// stuck chunks.
+func (r *Renter) threadedCheckStuckDownloadChunks() {
+	err := r.tg.Add()
+	if err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	ticker := time.NewTicker(downloadChunkStuckCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-ticker.C:
+		}
+		r.managedCheckStuckDownloadChunks()
+	}
+}
+
 // threadedDownloadLoop utilizes the worker pool to make progress on any queued
 // downloads.
 func (r *Renter) threadedDownloadLoop() {
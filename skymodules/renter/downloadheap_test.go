@@ -0,0 +1,247 @@
+package renter
+
+import (
+	"io/ioutil"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+)
+
+// TestDownloadHeapStuckChunks is a unit test for the stuck chunk detection of
+// the download heap.
+func TestDownloadHeapStuckChunks(t *testing.T) {
+	t.Parallel()
+
+	newChunk := func(priority uint64) *unfinishedDownloadChunk {
+		return &unfinishedDownloadChunk{
+			staticPriority: priority,
+			staticDownload: &download{
+				completeChan:    make(chan struct{}),
+				staticStartTime: time.Now(),
+			},
+		}
+	}
+
+	// Push a low priority chunk and a high priority chunk.
+	var dh downloadHeap
+	low := newChunk(1)
+	high := newChunk(2)
+	dh.managedPush(low)
+	dh.managedPush(high)
+
+	// Nothing is stuck yet.
+	now := time.Now()
+	stuck, starved := dh.managedUpdateStuckChunks(now, time.Minute, time.Hour)
+	if len(stuck) != 0 || len(starved) != 0 {
+		t.Fatal("no chunks should be stuck", len(stuck), len(starved))
+	}
+
+	// Let the low priority chunk wait for longer than the threshold. It
+	// should be marked as stuck.
+	dh.mu.Lock()
+	low.heapPushTime = now.Add(-2 * time.Minute)
+	dh.mu.Unlock()
+	stuck, starved = dh.managedUpdateStuckChunks(now, time.Minute, time.Hour)
+	if len(stuck) != 1 || stuck[0] != low || len(starved) != 0 {
+		t.Fatal("low priority chunk should be stuck", len(stuck), len(starved))
+	}
+	if atomic.LoadUint64(&low.staticDownload.atomicStuckChunks) != 1 {
+		t.Fatal("stuck chunk wasn't counted")
+	}
+
+	// A chunk is only reported once.
+	stuck, _ = dh.managedUpdateStuckChunks(now, time.Minute, time.Hour)
+	if len(stuck) != 0 {
+		t.Fatal("chunk was reported twice")
+	}
+
+	// The stuck chunk is popped first despite its lower priority.
+	if dh.managedPopIncomplete() != low {
+		t.Fatal("stuck chunk should be popped first")
+	}
+	if atomic.LoadUint64(&low.staticDownload.atomicStuckChunks) != 0 {
+		t.Fatal("popped chunk should no longer be counted")
+	}
+	if dh.managedPopIncomplete() != high {
+		t.Fatal("expected high priority chunk")
+	}
+
+	// Push a chunk which is starved.
+	dh.managedPush(low)
+	dh.mu.Lock()
+	low.heapPushTime = now.Add(-2 * time.Hour)
+	dh.mu.Unlock()
+	stuck, starved = dh.managedUpdateStuckChunks(now, time.Minute, time.Hour)
+	if len(stuck) != 1 || len(starved) != 1 || starved[0] != low {
+		t.Fatal("chunk should be stuck and starved", len(stuck), len(starved))
+	}
+
+	// Chunks of completed downloads are ignored.
+	close(low.staticDownload.completeChan)
+	stuck, starved = dh.managedUpdateStuckChunks(now, time.Minute, time.Hour)
+	if len(stuck) != 0 || len(starved) != 0 {
+		t.Fatal("chunks of complete downloads should be ignored", len(stuck), len(starved))
+	}
+	if dh.managedPopIncomplete() != nil {
+		t.Fatal("heap should be empty")
+	}
+}
+
+// TestCheckStuckDownloadChunks verifies that the renter registers an alert for
+// stuck download chunks and fails downloads with starved chunks.
+func TestCheckStuckDownloadChunks(t *testing.T) {
+	t.Parallel()
+
+	logger, err := persist.NewLogger(ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := new(Renter)
+	r.staticLog = logger
+	r.staticAlerter = modules.NewAlerter("renter")
+	r.staticDownloadHeap = new(downloadHeap)
+
+	hasAlert := func() bool {
+		_, _, warn := r.staticAlerter.Alerts()
+		for _, alert := range warn {
+			if alert.Msg == AlertMSGDownloadChunkStuck && strings.Contains(alert.Cause, "stuckdownload") {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Push a chunk of a download onto the heap.
+	d := &download{
+		completeChan:    make(chan struct{}),
+		staticRenter:    r,
+		staticStartTime: time.Now(),
+		staticUID:       skymodules.DownloadID("stuckdownload"),
+	}
+	chunk := &unfinishedDownloadChunk{staticDownload: d}
+	r.staticDownloadHeap.managedPush(chunk)
+
+	// Let the chunk become stuck.
+	r.staticDownloadHeap.mu.Lock()
+	chunk.heapPushTime = time.Now().Add(-downloadChunkStuckThreshold)
+	r.staticDownloadHeap.mu.Unlock()
+	r.managedCheckStuckDownloadChunks()
+	if !hasAlert() {
+		t.Fatal("alert wasn't registered")
+	}
+	if atomic.LoadUint64(&d.atomicStuckChunks) != 1 {
+		t.Fatal("stuck chunk wasn't counted")
+	}
+
+	// Let the chunk starve.
+	r.staticDownloadHeap.mu.Lock()
+	chunk.heapPushTime = time.Now().Add(-downloadChunkStarvedTimeout)
+	r.staticDownloadHeap.mu.Unlock()
+	r.managedCheckStuckDownloadChunks()
+	if d.Err() == nil || !strings.Contains(d.Err().Error(), errDownloadChunkStarved.Error()) {
+		t.Fatal("download should have failed", d.Err())
+	}
+	if hasAlert() {
+		t.Fatal("alert should be unregistered once the download is complete")
+	}
+}
+
+// TestPendingDownloadChunks verifies that the renter lists the pending chunks
+// of the download heap and that pending downloads can be cancelled.
+func TestPendingDownloadChunks(t *testing.T) {
+	t.Parallel()
+
+	logger, err := persist.NewLogger(ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := new(Renter)
+	r.staticLog = logger
+	r.staticDownloadHeap = new(downloadHeap)
+	r.staticDownloadHistory = newDownloadHistory()
+
+	// Add two downloads with two chunks each.
+	ec := skymodules.NewRSSubCodeDefault()
+	newDownload := func(uid string, priority uint64) *download {
+		d := &download{
+			completeChan:    make(chan struct{}),
+			staticRenter:    r,
+			staticSiaPath:   skymodules.RandomSiaPath(),
+			staticStartTime: time.Now(),
+			staticUID:       skymodules.DownloadID(uid),
+		}
+		r.staticDownloadHistory.callAddDownload(d)
+		for i := uint64(0); i < 2; i++ {
+			r.staticDownloadHeap.managedPush(&unfinishedDownloadChunk{
+				erasureCode:      ec,
+				staticChunkIndex: i,
+				staticDownload:   d,
+				staticOverdrive:  1,
+				staticPieceSize:  modules.SectorSize,
+				staticPriority:   priority,
+			})
+		}
+		return d
+	}
+	low := newDownload("low", 1)
+	high := newDownload("high", 2)
+
+	// The chunks are listed in the order they will be popped.
+	pending, err := r.PendingDownloadChunks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 4 {
+		t.Fatal("wrong number of pending chunks", len(pending))
+	}
+	expected := []struct {
+		uid   skymodules.DownloadID
+		index uint64
+	}{{"high", 0}, {"high", 1}, {"low", 0}, {"low", 1}}
+	for i, chunk := range pending {
+		if chunk.DownloadID != expected[i].uid || chunk.ChunkIndex != expected[i].index {
+			t.Fatal("wrong order", i, chunk.DownloadID, chunk.ChunkIndex)
+		}
+	}
+	if pending[0].SiaPath != high.staticSiaPath || pending[0].Priority != 2 || pending[0].Age < 0 {
+		t.Fatal("wrong chunk info", pending[0])
+	}
+	if memory := uint64(ec.MinPieces()+1) * modules.SectorSize; pending[0].MemoryNeeded != memory {
+		t.Fatal("wrong memory", pending[0].MemoryNeeded, memory)
+	}
+
+	// Cancel the high priority download.
+	err = r.CancelPendingDownload(high.staticUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Contains(high.Err(), skymodules.ErrDownloadCancelled) {
+		t.Fatal("download wasn't cancelled", high.Err())
+	}
+	pending, err = r.PendingDownloadChunks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 2 || pending[0].DownloadID != low.staticUID || pending[1].DownloadID != low.staticUID {
+		t.Fatal("chunks of the cancelled download weren't removed", pending)
+	}
+
+	// Cancelling it again or cancelling an unknown download fails.
+	if err := r.CancelPendingDownload(high.staticUID); !errors.Contains(err, errDownloadAlreadyComplete) {
+		t.Fatal("wrong error", err)
+	}
+	if err := r.CancelPendingDownload("unknown"); !errors.Contains(err, errDownloadNotFound) {
+		t.Fatal("wrong error", err)
+	}
+
+	// The remaining chunks can still be popped.
+	if dh := r.staticDownloadHeap; dh.managedPopIncomplete() == nil || dh.managedPopIncomplete() == nil || dh.managedPopIncomplete() != nil {
+		t.Fatal("wrong number of chunks left in the heap")
+	}
+}
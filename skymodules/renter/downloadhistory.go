@@ -109,6 +109,7 @@ func (dh *downloadHistory) managedHistory() []skymodules.DownloadInfo {
 			Received:             atomic.LoadUint64(&d.atomicDataReceived),
 			StartTime:            d.staticStartTime,
 			StartTimeUnix:        d.staticStartTime.UnixNano(),
+			StuckChunks:          atomic.LoadUint64(&d.atomicStuckChunks),
 			TotalDataTransferred: atomic.LoadUint64(&d.atomicTotalDataTransferred),
 		}
 		// Release download lock before calling d.Err(), which will acquire the
@@ -15,8 +15,18 @@ func (r *Renter) DeleteFile(siaPath skymodules.SiaPath) error {
 	}
 	defer r.tg.Done()
 
-	// Perform the delete operation.
-	err = r.staticFileSystem.DeleteFile(siaPath)
+	// Perform the delete operation. If the file can be opened, its chunks are
+	// released from the skyfile chunk index as well.
+	fileNode, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		err = r.staticFileSystem.DeleteFile(siaPath)
+		if err == nil {
+			r.staticSkylinkHealthCache.callRemove(siaPath)
+		}
+	} else {
+		err = r.managedDeleteFileNode(siaPath, fileNode)
+		err = errors.Compose(err, fileNode.Close())
+	}
 	if err != nil {
 		return errors.AddContext(err, "unable to delete siafile from filesystem")
 	}
@@ -4,6 +4,7 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"time"
 
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/SkynetLabs/skyd/build"
@@ -139,11 +140,14 @@ func (n *FileNode) managedFileInfo(siaPath skymodules.SiaPath, offline map[strin
 	maxHealth := math.Max(health, stuckHealth)
 	fileInfo := skymodules.FileInfo{
 		AccessTime:       n.AccessTime(),
+		AllowedHosts:     n.AllowedHosts(),
+		Archive:          n.Profile() == skymodules.ArchiveProfileName,
 		Available:        redundancy >= 1,
 		ChangeTime:       n.ChangeTime(),
 		CipherType:       n.MasterKey().Type().String(),
 		CreateTime:       n.CreateTime(),
 		Expiration:       n.Expiration(contracts),
+		ExpiryTime:       n.ExpiryTime(),
 		Filesize:         n.Size(),
 		Health:           health,
 		LocalPath:        localPath,
@@ -152,6 +156,7 @@ func (n *FileNode) managedFileInfo(siaPath skymodules.SiaPath, offline map[strin
 		ModificationTime: n.ModTime(),
 		NumStuckChunks:   numStuckChunks,
 		OnDisk:           onDisk,
+		Profile:          n.Profile(),
 		Recoverable:      onDisk || redundancy >= 1,
 		Redundancy:       redundancy,
 		Renewing:         true,
@@ -161,6 +166,7 @@ func (n *FileNode) managedFileInfo(siaPath skymodules.SiaPath, offline map[strin
 		Stuck:            numStuckChunks > 0,
 		StuckHealth:      stuckHealth,
 		StuckBytes:       stuckBytes,
+		TTL:              remainingTTL(n.ExpiryTime()),
 		UID:              n.staticUID,
 		UploadedBytes:    uploadedBytes,
 		UploadProgress:   uploadProgress,
@@ -224,11 +230,14 @@ func (n *FileNode) staticCachedInfo(siaPath skymodules.SiaPath) (skymodules.File
 	maxHealth := math.Max(md.CachedHealth, md.CachedStuckHealth)
 	fileInfo := skymodules.FileInfo{
 		AccessTime:       md.AccessTime,
+		AllowedHosts:     append([]types.SiaPublicKey{}, md.AllowedHosts...),
+		Archive:          md.Profile == skymodules.ArchiveProfileName,
 		Available:        md.CachedUserRedundancy >= 1,
 		ChangeTime:       md.ChangeTime,
 		CipherType:       md.StaticMasterKeyType.String(),
 		CreateTime:       md.CreateTime,
 		Expiration:       md.CachedExpiration,
+		ExpiryTime:       md.ExpiryTime,
 		Filesize:         uint64(md.FileSize),
 		Health:           md.CachedHealth,
 		LocalPath:        localPath,
@@ -237,6 +246,7 @@ func (n *FileNode) staticCachedInfo(siaPath skymodules.SiaPath) (skymodules.File
 		ModificationTime: md.ModTime,
 		NumStuckChunks:   md.NumStuckChunks,
 		OnDisk:           onDisk,
+		Profile:          md.Profile,
 		Recoverable:      onDisk || md.CachedUserRedundancy >= 1,
 		Redundancy:       md.CachedUserRedundancy,
 		Renewing:         true,
@@ -246,9 +256,20 @@ func (n *FileNode) staticCachedInfo(siaPath skymodules.SiaPath) (skymodules.File
 		Stuck:            md.NumStuckChunks > 0,
 		StuckBytes:       md.CachedStuckBytes,
 		StuckHealth:      md.CachedStuckHealth,
+		TTL:              remainingTTL(md.ExpiryTime),
 		UID:              n.staticUID,
 		UploadedBytes:    md.CachedUploadedBytes,
 		UploadProgress:   md.CachedUploadProgress,
 	}
 	return fileInfo, nil
 }
+
+// remainingTTL returns the number of seconds until the given expiry time. Files
+// without an expiry time or which already expired have a TTL of 0.
+func remainingTTL(expiry time.Time) uint64 {
+	ttl := time.Until(expiry)
+	if expiry.IsZero() || ttl <= 0 {
+		return 0
+	}
+	return uint64(ttl.Seconds())
+}
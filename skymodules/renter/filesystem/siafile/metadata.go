@@ -152,6 +152,19 @@ type (
 		// skyfiles, those skyfiles will be listed here. It should be noted that
 		// a single siafile can be responsible for tracking many skyfiles.
 		Skylinks []string `json:"skylinks"`
+
+		// Profile is the name of the allowance profile whose hosts store the
+		// file. If empty, the file is stored on the regular hosts.
+		Profile string `json:"profile"`
+
+		// ExpiryTime is the time after which the file is deleted by the
+		// renter without being repaired anymore. A zero value means that the
+		// file doesn't expire.
+		ExpiryTime time.Time `json:"expirytime"`
+
+		// AllowedHosts restricts the hosts the file is uploaded to and
+		// repaired on. If empty, the file may use any host.
+		AllowedHosts []types.SiaPublicKey `json:"allowedhosts"`
 	}
 
 	// BubbledMetadata is the metadata of a siafile that gets bubbled
@@ -172,6 +185,36 @@ type (
 	}
 )
 
+// Profile returns the name of the allowance profile whose hosts store the file.
+func (sf *SiaFile) Profile() string {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.Profile
+}
+
+// AllowedHosts returns the hosts the file is restricted to. If it is empty, the
+// file may use any host.
+func (sf *SiaFile) AllowedHosts() []types.SiaPublicKey {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return append([]types.SiaPublicKey{}, sf.staticMetadata.AllowedHosts...)
+}
+
+// Expired returns whether the file's expiry time has passed.
+func (sf *SiaFile) Expired() bool {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return !sf.staticMetadata.ExpiryTime.IsZero() && time.Now().After(sf.staticMetadata.ExpiryTime)
+}
+
+// ExpiryTime returns the time after which the file is deleted. A zero value
+// means that the file doesn't expire.
+func (sf *SiaFile) ExpiryTime() time.Time {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.ExpiryTime
+}
+
 // AccessTime returns the AccessTime timestamp of the file.
 func (sf *SiaFile) AccessTime() time.Time {
 	sf.mu.RLock()
@@ -331,6 +374,8 @@ func (md Metadata) backup() (b Metadata) {
 	b.GroupID = md.GroupID
 	b.ChunkOffset = md.ChunkOffset
 	b.PubKeyTableOffset = md.PubKeyTableOffset
+	b.Profile = md.Profile
+	b.ExpiryTime = md.ExpiryTime
 	// Special handling for slice since reflect.DeepEqual is false when
 	// comparing empty slice to nil.
 	if md.Skylinks == nil {
@@ -340,6 +385,12 @@ func (md Metadata) backup() (b Metadata) {
 		b.Skylinks = make([]string, len(md.Skylinks), cap(md.Skylinks))
 		copy(b.Skylinks, md.Skylinks)
 	}
+	if md.AllowedHosts == nil {
+		b.AllowedHosts = nil
+	} else {
+		b.AllowedHosts = make([]types.SiaPublicKey, len(md.AllowedHosts), cap(md.AllowedHosts))
+		copy(b.AllowedHosts, md.AllowedHosts)
+	}
 	// If the backup was successful it should match the original.
 	if build.Release == "testing" && !md.equals(b) {
 		fmt.Println("md:\n", md)
@@ -381,6 +432,9 @@ func (md *Metadata) restore(b Metadata) {
 	md.ChunkOffset = b.ChunkOffset
 	md.PubKeyTableOffset = b.PubKeyTableOffset
 	md.Skylinks = b.Skylinks
+	md.Profile = b.Profile
+	md.ExpiryTime = b.ExpiryTime
+	md.AllowedHosts = b.AllowedHosts
 	// If the backup was successful it should match the backup.
 	if build.Release == "testing" && !md.equals(b) {
 		fmt.Println("md:\n", md)
@@ -519,6 +573,74 @@ func (sf *SiaFile) SetLocalPath(path string) (err error) {
 	return sf.createAndApplyTransaction(updates...)
 }
 
+// SetProfile sets the allowance profile whose hosts store the file.
+func (sf *SiaFile) SetProfile(profile string) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+
+	sf.staticMetadata.Profile = profile
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
+// SetAllowedHosts restricts the hosts the file is uploaded to and repaired
+// on. An empty set removes the restriction.
+func (sf *SiaFile) SetAllowedHosts(hosts []types.SiaPublicKey) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+
+	sf.staticMetadata.AllowedHosts = append([]types.SiaPublicKey{}, hosts...)
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
+// SetExpiryTime sets the time after which the file is deleted. A zero value
+// means that the file doesn't expire.
+func (sf *SiaFile) SetExpiryTime(expiry time.Time) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+
+	sf.staticMetadata.ExpiryTime = expiry
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
 // Size returns the file's size.
 func (sf *SiaFile) Size() uint64 {
 	sf.mu.RLock()
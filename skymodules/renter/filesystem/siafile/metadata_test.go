@@ -1,6 +1,7 @@
 package siafile
 
 import (
+	"encoding/hex"
 	"fmt"
 	"os"
 	"reflect"
@@ -76,10 +77,16 @@ func TestBackupRestoreMetadata(t *testing.T) {
 		sf.staticMetadata.GroupID = int32(fastrand.Intn(100))
 		sf.staticMetadata.ChunkOffset = int64(fastrand.Uint64n(100))
 		sf.staticMetadata.PubKeyTableOffset = int64(fastrand.Uint64n(100))
+		sf.staticMetadata.Profile = hex.EncodeToString(fastrand.Bytes(4))
+		sf.staticMetadata.ExpiryTime = time.Now()
 		sf.staticMetadata.Skylinks = nil
 		if fastrand.Intn(2) == 0 { // 50% chance to be not nil
 			sf.staticMetadata.Skylinks = make([]string, fastrand.Intn(10))
 		}
+		sf.staticMetadata.AllowedHosts = nil
+		if fastrand.Intn(2) == 0 { // 50% chance to be not nil
+			sf.staticMetadata.AllowedHosts = make([]types.SiaPublicKey, fastrand.Intn(10))
+		}
 
 		// Error occurred after changing the fields.
 		return errors.New("")
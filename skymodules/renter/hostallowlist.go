@@ -0,0 +1,267 @@
+package renter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+// The host allow-list feed pins the hostdb of a private portal to a curated
+// set of hosts. The feed is a signed allow-list document which is loaded from
+// a URL or a file at startup, periodically and on demand. Every allow-list with
+// a valid signature and a revision that is not lower than the applied one is
+// applied as the whitelist of the hostdb. The applied allow-list is persisted
+// so that an older revision isn't applied after a restart either.
+
+const (
+	// maxHostAllowlistSize is the max size of a host allow-list document.
+	maxHostAllowlistSize = 1 << 22 // 4 MiB
+
+	// hostAllowlistFetchTimeout is the timeout for fetching a host allow-list
+	// from a URL.
+	hostAllowlistFetchTimeout = time.Minute
+
+	// hostAllowlistPersistFile is the name of the persist object which
+	// contains the applied host allow-list.
+	hostAllowlistPersistFile = "hostallowlist.json"
+)
+
+var (
+	// hostAllowlistRefreshInterval is the interval at which the host
+	// allow-list is refreshed from its source.
+	hostAllowlistRefreshInterval = build.Select(build.Var{
+		Dev:      5 * time.Minute,
+		Standard: time.Hour,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+
+	// hostAllowlistMetadata is the metadata used when persisting the applied
+	// host allow-list.
+	hostAllowlistMetadata = persist.Metadata{
+		Header:  "Host Allowlist",
+		Version: "1.5.9",
+	}
+)
+
+type (
+	// hostAllowlistFeed is the source of the host allow-list and the state of
+	// the allow-list that was last applied.
+	hostAllowlistFeed struct {
+		source     string
+		publicKey  types.SiaPublicKey
+		allowlist  skymodules.HostAllowlist
+		lastUpdate time.Time
+		lastErr    error
+
+		// refreshMu ensures that only one refresh happens at a time.
+		refreshMu sync.Mutex
+		mu        sync.Mutex
+	}
+
+	// hostAllowlistPersistence is the persisted state of the host allow-list
+	// feed.
+	hostAllowlistPersistence struct {
+		PublicKey  types.SiaPublicKey       `json:"publickey"`
+		Allowlist  skymodules.HostAllowlist `json:"allowlist"`
+		LastUpdate time.Time                `json:"lastupdate"`
+	}
+)
+
+// newHostAllowlistFeed creates a feed from the given source and the string
+// representation of its public key. The feed is disabled if the source is
+// empty.
+func newHostAllowlistFeed(source, publicKey string) (*hostAllowlistFeed, error) {
+	feed := &hostAllowlistFeed{
+		source: source,
+	}
+	if source == "" {
+		return feed, nil
+	}
+	err := feed.publicKey.LoadString(publicKey)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to load host allow-list key")
+	}
+	return feed, nil
+}
+
+// managedLoadHostAllowlist loads the allow-list which was applied last. The
+// revision of the loaded allow-list is only enforced if it was signed with the
+// configured key.
+func (r *Renter) managedLoadHostAllowlist() error {
+	var p hostAllowlistPersistence
+	err := skymodules.LoadPersistJSON(r.staticPersistBackend, hostAllowlistMetadata, &p, hostAllowlistPersistFile)
+	if errors.Contains(err, skymodules.ErrPersistObjectNotFound) {
+		return nil
+	}
+	if err != nil {
+		return errors.AddContext(err, "failed to load host allow-list")
+	}
+	feed := r.staticHostAllowlist
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+	if feed.source != "" && !feed.publicKey.Equals(p.PublicKey) {
+		return nil
+	}
+	feed.publicKey = p.PublicKey
+	feed.allowlist = p.Allowlist
+	feed.lastUpdate = p.LastUpdate
+	return nil
+}
+
+// managedStatus returns the status of the feed.
+func (feed *hostAllowlistFeed) managedStatus() skymodules.HostAllowlistStatus {
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+	status := skymodules.HostAllowlistStatus{
+		Source:     feed.source,
+		PublicKey:  feed.publicKey,
+		Revision:   feed.allowlist.Revision,
+		Hosts:      append([]skymodules.HostAllowlistEntry{}, feed.allowlist.Hosts...),
+		LastUpdate: feed.lastUpdate,
+	}
+	if feed.lastErr != nil {
+		status.LastError = feed.lastErr.Error()
+	}
+	return status
+}
+
+// staticFetchHostAllowlist fetches the signed host allow-list document from a
+// URL or a file.
+func staticFetchHostAllowlist(ctx context.Context, source string) (sal skymodules.SignedHostAllowlist, err error) {
+	var r io.ReadCloser
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		ctx, cancel := context.WithTimeout(ctx, hostAllowlistFetchTimeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return skymodules.SignedHostAllowlist{}, err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return skymodules.SignedHostAllowlist{}, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return skymodules.SignedHostAllowlist{}, errors.Compose(fmt.Errorf("unexpected status code %v", resp.StatusCode), resp.Body.Close())
+		}
+		r = resp.Body
+	} else {
+		r, err = os.Open(source)
+		if err != nil {
+			return skymodules.SignedHostAllowlist{}, err
+		}
+	}
+	defer func() {
+		err = errors.Compose(err, r.Close())
+	}()
+	err = json.NewDecoder(io.LimitReader(r, maxHostAllowlistSize)).Decode(&sal)
+	if err != nil {
+		return skymodules.SignedHostAllowlist{}, errors.AddContext(err, "failed to decode host allow-list")
+	}
+	return sal, nil
+}
+
+// managedRefreshHostAllowlist fetches the host allow-list from the feed's
+// source, verifies it and applies it as the whitelist of the hostdb.
+func (r *Renter) managedRefreshHostAllowlist() (err error) {
+	feed := r.staticHostAllowlist
+	feed.refreshMu.Lock()
+	defer feed.refreshMu.Unlock()
+
+	feed.mu.Lock()
+	source, pk, revision, applied := feed.source, feed.publicKey, feed.allowlist.Revision, !feed.lastUpdate.IsZero()
+	feed.mu.Unlock()
+	if source == "" {
+		return skymodules.ErrHostAllowlistNotConfigured
+	}
+	defer func() {
+		feed.mu.Lock()
+		feed.lastErr = err
+		feed.mu.Unlock()
+	}()
+
+	// Fetch and verify the allow-list.
+	sal, err := staticFetchHostAllowlist(r.tg.StopCtx(), source)
+	if err != nil {
+		return errors.AddContext(err, "failed to fetch host allow-list")
+	}
+	al, err := sal.Verify(pk)
+	if err != nil {
+		return err
+	}
+	if applied && al.Revision < revision {
+		return skymodules.ErrHostAllowlistOutdated
+	}
+
+	// Apply it.
+	err = r.SetFilterMode(skymodules.HostDBActiveWhitelist, al.PublicKeys())
+	if err != nil {
+		return errors.AddContext(err, "failed to apply host allow-list")
+	}
+	feed.mu.Lock()
+	feed.allowlist = al
+	feed.lastUpdate = time.Now()
+	p := hostAllowlistPersistence{
+		PublicKey:  pk,
+		Allowlist:  al,
+		LastUpdate: feed.lastUpdate,
+	}
+	feed.mu.Unlock()
+	err = skymodules.SavePersistJSON(r.staticPersistBackend, hostAllowlistMetadata, p, hostAllowlistPersistFile)
+	return errors.AddContext(err, "failed to persist host allow-list")
+}
+
+// managedRefreshHostAllowlistJob refreshes the host allow-list if a source is
+// configured. It's run periodically by the maintenance scheduler.
+func (r *Renter) managedRefreshHostAllowlistJob() {
+	err := r.managedRefreshHostAllowlist()
+	if err != nil && !errors.Contains(err, skymodules.ErrHostAllowlistNotConfigured) {
+		r.staticLog.Println("WARN: failed to refresh host allow-list:", err)
+	}
+}
+
+// HostAllowlist returns the status of the host allow-list feed.
+func (r *Renter) HostAllowlist() (skymodules.HostAllowlistStatus, error) {
+	if err := r.tg.Add(); err != nil {
+		return skymodules.HostAllowlistStatus{}, err
+	}
+	defer r.tg.Done()
+	return r.staticHostAllowlist.managedStatus(), nil
+}
+
+// RefreshHostAllowlist refreshes the host allow-list. If a source is provided,
+// it replaces the configured source and key of the feed.
+func (r *Renter) RefreshHostAllowlist(source string, publicKey types.SiaPublicKey) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	// Replace the source. The revision of the applied allow-list only
+	// protects against older lists signed with the same key.
+	if source != "" {
+		feed := r.staticHostAllowlist
+		feed.refreshMu.Lock()
+		feed.mu.Lock()
+		if !feed.publicKey.Equals(publicKey) {
+			feed.allowlist = skymodules.HostAllowlist{}
+			feed.lastUpdate = time.Time{}
+		}
+		feed.source = source
+		feed.publicKey = publicKey
+		feed.mu.Unlock()
+		feed.refreshMu.Unlock()
+	}
+	return r.managedRefreshHostAllowlist()
+}
@@ -0,0 +1,158 @@
+package renter
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// TestHostAllowlist tests refreshing the host allow-list from a file and a URL.
+func TestHostAllowlist(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// Without a source, refreshing fails.
+	err = r.RefreshHostAllowlist("", types.SiaPublicKey{})
+	if !errors.Contains(err, skymodules.ErrHostAllowlistNotConfigured) {
+		t.Fatal("wrong error", err)
+	}
+
+	// writeAllowlist signs an allow-list with the given revision and number
+	// of hosts and writes it to a file.
+	sk, pk := crypto.GenerateKeyPair()
+	spk := types.Ed25519PublicKey(pk)
+	path := filepath.Join(rt.dir, "allowlist.json")
+	writeAllowlist := func(revision uint64, numHosts int) skymodules.HostAllowlist {
+		t.Helper()
+		al := skymodules.HostAllowlist{Revision: revision}
+		for i := 0; i < numHosts; i++ {
+			_, hostPK := crypto.GenerateKeyPair()
+			al.Hosts = append(al.Hosts, skymodules.HostAllowlistEntry{
+				PublicKey: types.Ed25519PublicKey(hostPK),
+			})
+		}
+		sal, err := skymodules.SignHostAllowlist(al, sk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		salBytes, err := json.Marshal(sal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, salBytes, 0600); err != nil {
+			t.Fatal(err)
+		}
+		return al
+	}
+
+	// checkApplied checks that the allow-list is applied as the whitelist.
+	checkApplied := func(al skymodules.HostAllowlist) {
+		t.Helper()
+		fm, hosts, err := r.Filter()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fm != skymodules.HostDBActiveWhitelist || len(hosts) != len(al.Hosts) {
+			t.Fatal("allow-list not applied", fm, len(hosts))
+		}
+		for _, pk := range al.PublicKeys() {
+			if _, exists := hosts[pk.String()]; !exists {
+				t.Fatal("missing host", pk)
+			}
+		}
+		status, err := r.HostAllowlist()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Revision != al.Revision || len(status.Hosts) != len(al.Hosts) || status.LastUpdate.IsZero() || status.LastError != "" {
+			t.Fatal("wrong status", status)
+		}
+	}
+
+	// Load an allow-list from the file.
+	al := writeAllowlist(1, 2)
+	if err := r.RefreshHostAllowlist(path, spk); err != nil {
+		t.Fatal(err)
+	}
+	checkApplied(al)
+
+	// An older revision is rejected.
+	writeAllowlist(0, 3)
+	err = r.RefreshHostAllowlist("", types.SiaPublicKey{})
+	if !errors.Contains(err, skymodules.ErrHostAllowlistOutdated) {
+		t.Fatal("wrong error", err)
+	}
+	status, err := r.HostAllowlist()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.LastError == "" {
+		t.Fatal("expected error in status")
+	}
+
+	// A list signed with a different key is rejected.
+	writeAllowlist(2, 3)
+	_, otherPK := crypto.GenerateKeyPair()
+	err = r.RefreshHostAllowlist(path, types.Ed25519PublicKey(otherPK))
+	if !errors.Contains(err, skymodules.ErrHostAllowlistInvalidSignature) {
+		t.Fatal("wrong error", err)
+	}
+	fm, hosts, err := r.Filter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fm != skymodules.HostDBActiveWhitelist || len(hosts) != len(al.Hosts) {
+		t.Fatal("allow-list shouldn't have changed", fm, len(hosts))
+	}
+
+	// Load a newer allow-list from a URL.
+	al = writeAllowlist(3, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.ServeFile(w, req, path)
+	}))
+	defer server.Close()
+	if err := r.RefreshHostAllowlist(server.URL, spk); err != nil {
+		t.Fatal(err)
+	}
+	checkApplied(al)
+
+	// After a restart, an older revision is still rejected.
+	r, err = rt.reloadRenter(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkApplied(al)
+	writeAllowlist(2, 3)
+	err = r.RefreshHostAllowlist(path, spk)
+	if !errors.Contains(err, skymodules.ErrHostAllowlistOutdated) {
+		t.Fatal("wrong error", err)
+	}
+	fm, hosts, err = r.Filter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fm != skymodules.HostDBActiveWhitelist || len(hosts) != len(al.Hosts) {
+		t.Fatal("allow-list shouldn't have changed", fm, len(hosts))
+	}
+}
@@ -1,9 +1,11 @@
 package hostdb
 
 import (
+	"runtime"
 	"time"
 
 	"gitlab.com/SkynetLabs/skyd/build"
+	"go.sia.tech/siad/types"
 )
 
 const (
@@ -113,6 +115,31 @@ var (
 	}).(int)
 )
 
+var (
+	// announcementBatchSize is the number of blocks of a consensus change
+	// from which a single thread extracts the host announcements. Consensus
+	// changes with more blocks, e.g. during a rescan, are split into batches
+	// which are processed in parallel.
+	announcementBatchSize = build.Select(build.Var{
+		Standard: 100,
+		Dev:      20,
+		Testing:  5,
+	}).(int)
+
+	// announcementThreads is the maximum number of threads extracting host
+	// announcements from a single consensus change.
+	announcementThreads = runtime.NumCPU()
+
+	// rescanCheckpointFrequency is the number of blocks after which the
+	// hostdb persists its progress while it is catching up with consensus,
+	// so that a crash during a rescan doesn't restart it from the beginning.
+	rescanCheckpointFrequency = build.Select(build.Var{
+		Standard: types.BlockHeight(1000),
+		Dev:      types.BlockHeight(100),
+		Testing:  types.BlockHeight(10),
+	}).(types.BlockHeight)
+)
+
 var (
 	// maxScanSleep is the maximum amount of time that the hostdb will sleep
 	// between performing scans of the hosts.
@@ -106,6 +106,13 @@ type HostDB struct {
 
 	blockHeight types.BlockHeight
 	lastChange  modules.ConsensusChangeID
+
+	// rescanning indicates that the hostdb is rescanning the blockchain from
+	// the beginning. It is persisted so that a rescan which was interrupted
+	// resumes from its last checkpoint. lastCheckpoint is the block height
+	// of the last checkpoint.
+	rescanning     bool
+	lastCheckpoint types.BlockHeight
 }
 
 // Enforce that HostDB satisfies the skymodules.HostDB interface.
@@ -325,10 +332,12 @@ func hostdbBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 	// If the block height has loaded as zero, the most recent consensus change
 	// needs to be set to perform a full rescan. This will also help the hostdb
 	// to pick up any hosts that it has incorrectly dropped in the past.
+	//
+	// A rescan which is still in progress resumes from its last checkpoint
+	// instead, the missing hosts will be found as it continues.
 	hdb.mu.Lock()
-	if hdb.blockHeight == 0 || compatV147ForceRescan {
-		hdb.lastChange = modules.ConsensusChangeBeginning
-		hdb.blockHeight = 0
+	if hdb.blockHeight == 0 || (compatV147ForceRescan && !hdb.rescanning) {
+		hdb.startRescan()
 	}
 	hdb.mu.Unlock()
 
@@ -363,8 +372,7 @@ func hostdbAsyncStartup(hdb *HostDB, cs modules.ConsensusSet) error {
 		// Subscribe again using the new ID. This will cause a triggered scan
 		// on all of the hosts, but that should be acceptable.
 		hdb.mu.Lock()
-		hdb.blockHeight = 0
-		hdb.lastChange = modules.ConsensusChangeBeginning
+		hdb.startRescan()
 		hdb.mu.Unlock()
 		err = cs.ConsensusSetSubscribe(hdb, hdb.lastChange, hdb.tg.StopChan())
 	}
@@ -634,6 +634,54 @@ func (*testCheckForIPViolationsDeps) Resolver() modules.Resolver {
 	return &testCheckForIPViolationsResolver{}
 }
 
+// TestUpdateHostBenchmark checks that updating a host's benchmark updates the
+// entry in the host tree.
+func TestUpdateHostBenchmark(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	hdbt, err := newHDBTesterDeps(t.Name(), &disableScanLoopDeps{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Updating an unknown host should fail.
+	host := makeHostDBEntry()
+	benchmark := skymodules.HostBenchmark{
+		ExecutionLatency: time.Second,
+		RTT:              time.Millisecond,
+		Throughput:       1 << 16,
+		Timestamp:        time.Now(),
+	}
+	err = hdbt.hdb.UpdateHostBenchmark(host.PublicKey, benchmark)
+	if !errors.Contains(err, errHostNotFoundInTree) {
+		t.Fatal("expected errHostNotFoundInTree", err)
+	}
+
+	// Add the host and update it.
+	err = hdbt.hdb.staticHostTree.Insert(host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scoreBefore := hdbt.hdb.weightFunc(host).Score()
+	err = hdbt.hdb.UpdateHostBenchmark(host.PublicKey, benchmark)
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, ok := hdbt.hdb.staticHostTree.Select(host.PublicKey)
+	if !ok {
+		t.Fatal("host not found")
+	}
+	if host.Benchmark.ExecutionLatency != benchmark.ExecutionLatency || host.Benchmark.RTT != benchmark.RTT || host.Benchmark.Throughput != benchmark.Throughput || !host.Benchmark.Timestamp.Equal(benchmark.Timestamp) {
+		t.Fatal("benchmark wasn't updated", host.Benchmark)
+	}
+	if hdbt.hdb.weightFunc(host).Score().Cmp(scoreBefore) >= 0 {
+		t.Fatal("slow host should have a lower score")
+	}
+}
+
 // TestCheckForIPViolations tests the hostdb's CheckForIPViolations method.
 func TestCheckForIPViolations(t *testing.T) {
 	if testing.Short() {
@@ -129,3 +129,25 @@ func (hdb *HostDB) IncrementFailedInteractions(key types.SiaPublicKey) error {
 	hdb.staticHostTree.Modify(host)
 	return nil
 }
+
+// UpdateHostBenchmark updates the benchmark results of the host with the given
+// key. The host's score is recomputed using the new results.
+func (hdb *HostDB) UpdateHostBenchmark(key types.SiaPublicKey, benchmark skymodules.HostBenchmark) error {
+	if err := hdb.tg.Add(); err != nil {
+		return errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+
+	// Fetch the host.
+	host, haveHost := hdb.staticHostTree.Select(key)
+	if !haveHost {
+		return errors.AddContext(errHostNotFoundInTree, "unable to update host benchmark:")
+	}
+
+	// Update the benchmark.
+	host.Benchmark = benchmark
+	return hdb.modify(host)
+}
@@ -20,6 +20,7 @@ type HostAdjustments struct {
 	AcceptContractAdjustment   float64
 	AgeAdjustment              float64
 	BasePriceAdjustment        float64
+	BenchmarkAdjustment        float64
 	BurnAdjustment             float64
 	CollateralAdjustment       float64
 	DurationAdjustment         float64
@@ -72,6 +73,7 @@ func (h HostAdjustments) HostScoreBreakdown(totalScore types.Currency, ignoreAge
 		AcceptContractAdjustment:   h.AcceptContractAdjustment,
 		AgeAdjustment:              h.AgeAdjustment,
 		BasePriceAdjustment:        h.BasePriceAdjustment,
+		BenchmarkAdjustment:        h.BenchmarkAdjustment,
 		BurnAdjustment:             h.BurnAdjustment,
 		CollateralAdjustment:       h.CollateralAdjustment,
 		DurationAdjustment:         h.DurationAdjustment,
@@ -90,6 +92,7 @@ func (h HostAdjustments) Score() types.Currency {
 	fullPenalty := h.AgeAdjustment *
 		h.AcceptContractAdjustment *
 		h.BasePriceAdjustment *
+		h.BenchmarkAdjustment *
 		h.BurnAdjustment *
 		h.CollateralAdjustment *
 		h.DurationAdjustment *
@@ -15,6 +15,17 @@ import (
 )
 
 const (
+	// benchmarkLatencyThreshold is the program execution latency up to which a
+	// benchmarked host doesn't receive a penalty. Only the time the host spends
+	// executing the program is considered, the network round trip time is
+	// excluded.
+	benchmarkLatencyThreshold = 250 * time.Millisecond
+
+	// benchmarkPenaltyExponentiation is the power to which we raise the ratio
+	// of the latency threshold and a host's execution latency once the host
+	// exceeds the threshold.
+	benchmarkPenaltyExponentiation = 2
+
 	// collateralExponentiation is the power to which we raise the weight
 	// during collateral adjustment when the collateral is large. This sublinear
 	// number ensures that there is not an overpreference on collateral when
@@ -105,6 +116,18 @@ func (hdb *HostDB) basePriceAdjustments(entry skymodules.HostDBEntry) float64 {
 	return 1
 }
 
+// benchmarkAdjustments will adjust the weight of the entry according to the
+// program execution latency measured by the renter's benchmarks. Hosts that
+// haven't been benchmarked yet don't receive a penalty.
+func benchmarkAdjustments(entry skymodules.HostDBEntry) float64 {
+	latency := entry.Benchmark.ExecutionLatency
+	if entry.Benchmark.Timestamp.IsZero() || latency <= benchmarkLatencyThreshold {
+		return 1
+	}
+	ratio := float64(benchmarkLatencyThreshold) / float64(latency)
+	return math.Pow(ratio, benchmarkPenaltyExponentiation)
+}
+
 // collateralAdjustments improves the host's weight according to the amount of
 // collateral that they have provided.
 func (hdb *HostDB) collateralAdjustments(entry skymodules.HostDBEntry, allowance skymodules.Allowance) float64 {
@@ -594,6 +617,7 @@ func (hdb *HostDB) managedCalculateHostWeightFn(allowance skymodules.Allowance)
 			AcceptContractAdjustment:   hdb.acceptContractAdjustments(entry),
 			AgeAdjustment:              hdb.lifetimeAdjustments(entry),
 			BasePriceAdjustment:        hdb.basePriceAdjustments(entry),
+			BenchmarkAdjustment:        benchmarkAdjustments(entry),
 			BurnAdjustment:             1,
 			CollateralAdjustment:       hdb.collateralAdjustments(entry, allowance),
 			DurationAdjustment:         hdb.durationAdjustments(entry, allowance),
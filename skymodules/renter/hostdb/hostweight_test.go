@@ -425,6 +425,50 @@ func TestHostWeightVersionDifferences(t *testing.T) {
 	}
 }
 
+// TestHostWeightBenchmarkDifferences checks that a host with a slow program
+// execution has a lower score than a host with a fast execution and that
+// hosts without a benchmark aren't penalized.
+func TestHostWeightBenchmarkDifferences(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	hdb := bareHostDB()
+
+	// A host without a benchmark receives no penalty, even if its latency is
+	// set.
+	entry := DefaultHostDBEntry
+	entry.Benchmark.ExecutionLatency = time.Hour
+	if ba := benchmarkAdjustments(entry); ba != 1 {
+		t.Fatal("host without benchmark shouldn't be penalized", ba)
+	}
+
+	// A fast host receives no penalty either.
+	entry.Benchmark = skymodules.HostBenchmark{
+		ExecutionLatency: benchmarkLatencyThreshold,
+		RTT:              time.Second,
+		Timestamp:        time.Now(),
+	}
+	if ba := benchmarkAdjustments(entry); ba != 1 {
+		t.Fatal("fast host shouldn't be penalized", ba)
+	}
+
+	// A host that takes twice as long as the threshold is penalized.
+	entry2 := entry
+	entry2.Benchmark.ExecutionLatency = 2 * benchmarkLatencyThreshold
+	entry2.Benchmark.RTT = 0
+	if ba := benchmarkAdjustments(entry2); ba != 0.25 {
+		t.Fatal("wrong adjustment for slow host", ba)
+	}
+	w1 := hdb.weightFunc(entry)
+	w2 := hdb.weightFunc(entry2)
+	if w1.Score().Cmp(w2.Score()) <= 0 {
+		t.Log(w1)
+		t.Log(w2)
+		t.Error("Faster host should have more weight")
+	}
+}
+
 // TestHostWeightLifetimeDifferences checks that a host that has been on the
 // chain for more time has a higher weight than a host that is newer.
 func TestHostWeightLifetimeDifferences(t *testing.T) {
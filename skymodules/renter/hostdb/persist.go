@@ -33,6 +33,7 @@ type hdbPersist struct {
 	LastChange               modules.ConsensusChangeID
 	FilteredHosts            map[string]types.SiaPublicKey
 	FilterMode               skymodules.FilterMode
+	Rescanning               bool
 }
 
 // persistData returns the data in the hostdb that will be saved to disk.
@@ -44,6 +45,7 @@ func (hdb *HostDB) persistData() (data hdbPersist) {
 	data.LastChange = hdb.lastChange
 	data.FilteredHosts = hdb.filteredHosts
 	data.FilterMode = hdb.filterMode
+	data.Rescanning = hdb.rescanning
 	return data
 }
 
@@ -69,6 +71,8 @@ func (hdb *HostDB) load() error {
 	hdb.knownContracts = data.KnownContracts
 	hdb.filteredHosts = data.FilteredHosts
 	hdb.filterMode = data.FilterMode
+	hdb.rescanning = data.Rescanning
+	hdb.lastCheckpoint = data.BlockHeight
 
 	if len(hdb.filteredHosts) > 0 {
 		hdb.staticFilteredTree = hosttree.New(hdb.weightFunc, modules.ProdDependencies.Resolver())
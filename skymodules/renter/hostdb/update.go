@@ -1,6 +1,7 @@
 package hostdb
 
 import (
+	"sync"
 	"time"
 
 	"gitlab.com/SkynetLabs/skyd/build"
@@ -32,6 +33,75 @@ func findHostAnnouncements(b types.Block) (announcements []skymodules.HostDBEntr
 	return
 }
 
+// findHostAnnouncementsInBlocks returns the host announcements found within
+// the given blocks in the order of the blocks. Decoding announcements requires
+// verifying their signatures, so large numbers of blocks are split into
+// batches which are processed in parallel.
+func findHostAnnouncementsInBlocks(blocks []types.Block) []skymodules.HostDBEntry {
+	announcements := make([][]skymodules.HostDBEntry, len(blocks))
+	if len(blocks) <= announcementBatchSize || announcementThreads < 2 {
+		for i, block := range blocks {
+			announcements[i] = findHostAnnouncements(block)
+		}
+	} else {
+		batches := make(chan int, (len(blocks)+announcementBatchSize-1)/announcementBatchSize)
+		for start := 0; start < len(blocks); start += announcementBatchSize {
+			batches <- start
+		}
+		close(batches)
+
+		// Every thread writes to its own blocks' entries, so no locking is
+		// required.
+		var wg sync.WaitGroup
+		for i := 0; i < announcementThreads; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for start := range batches {
+					end := start + announcementBatchSize
+					if end > len(blocks) {
+						end = len(blocks)
+					}
+					for j := start; j < end; j++ {
+						announcements[j] = findHostAnnouncements(blocks[j])
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	var all []skymodules.HostDBEntry
+	for _, blockAnnouncements := range announcements {
+		all = append(all, blockAnnouncements...)
+	}
+	return all
+}
+
+// startRescan resets the hostdb's consensus progress to rescan the blockchain
+// from the beginning.
+func (hdb *HostDB) startRescan() {
+	hdb.blockHeight = 0
+	hdb.lastChange = modules.ConsensusChangeBeginning
+	hdb.lastCheckpoint = 0
+	hdb.rescanning = true
+}
+
+// checkpoint persists the consensus progress of the hostdb if it is
+// catching up with consensus and enough blocks were processed since the last
+// checkpoint. Once the hostdb is synced, a rescan is finished.
+func (hdb *HostDB) checkpoint(synced bool) {
+	if synced && hdb.rescanning {
+		hdb.rescanning = false
+	} else if synced || hdb.blockHeight < hdb.lastCheckpoint+rescanCheckpointFrequency {
+		return
+	}
+	hdb.lastCheckpoint = hdb.blockHeight
+	if err := hdb.saveSync(); err != nil {
+		hdb.staticLog.Println("ERROR: unable to persist the hostdb's consensus progress:", err)
+	}
+}
+
 // insertBlockchainHost adds a host entry to the state. The host will be inserted
 // into the set of all hosts, and if it is online and responding to requests it
 // will be put into the list of active hosts.
@@ -118,13 +188,12 @@ func (hdb *HostDB) ProcessConsensusChange(cc modules.ConsensusChange) {
 	}
 
 	// Add hosts announced in blocks that were applied.
-	for _, block := range cc.AppliedBlocks {
-		for _, host := range findHostAnnouncements(block) {
-			hdb.staticLog.Debugln("Found a host in a host announcement:", host.NetAddress, host.PublicKey)
-			hdb.insertBlockchainHost(host)
-		}
+	for _, host := range findHostAnnouncementsInBlocks(cc.AppliedBlocks) {
+		hdb.staticLog.Debugln("Found a host in a host announcement:", host.NetAddress, host.PublicKey)
+		hdb.insertBlockchainHost(host)
 	}
 
 	hdb.synced = cc.Synced
 	hdb.lastChange = cc.ID
+	hdb.checkpoint(cc.Synced)
 }
@@ -1,10 +1,15 @@
 package hostdb
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"gitlab.com/SkynetLabs/skyd/build"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
 	"go.sia.tech/siad/types"
 )
 
@@ -52,3 +57,101 @@ func TestFindHostAnnouncements(t *testing.T) {
 		t.Error("host announcement found when there was an invalid encoding of a host announcement")
 	}
 }
+
+// TestFindHostAnnouncementsInBlocks probes the findHostAnnouncementsInBlocks
+// function.
+func TestFindHostAnnouncementsInBlocks(t *testing.T) {
+	t.Parallel()
+
+	// Create enough blocks to be processed in multiple batches. Every other
+	// block contains an announcement.
+	var blocks []types.Block
+	var expected []modules.NetAddress
+	for i := 0; i < 5*announcementBatchSize+1; i++ {
+		var b types.Block
+		b.Timestamp = types.Timestamp(i)
+		if i%2 == 0 {
+			na := modules.NetAddress(fmt.Sprintf("foo%v.com:1234", i))
+			annBytes, err := makeSignedAnnouncement(na)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b.Transactions = []types.Transaction{{ArbitraryData: [][]byte{annBytes}}}
+			expected = append(expected, na)
+		}
+		blocks = append(blocks, b)
+	}
+
+	// The announcements should be returned in the order of the blocks.
+	announcements := findHostAnnouncementsInBlocks(blocks)
+	if len(announcements) != len(expected) {
+		t.Fatalf("expected %v announcements but got %v", len(expected), len(announcements))
+	}
+	for i, host := range announcements {
+		if host.NetAddress != expected[i] {
+			t.Fatalf("%v: expected %v but got %v", i, expected[i], host.NetAddress)
+		}
+	}
+
+	// A few blocks are processed in a single batch.
+	announcements = findHostAnnouncementsInBlocks(blocks[:1])
+	if len(announcements) != 1 || announcements[0].NetAddress != expected[0] {
+		t.Fatal("wrong announcements", announcements)
+	}
+}
+
+// TestRescanCheckpoint tests that the hostdb persists its progress while
+// rescanning the blockchain.
+func TestRescanCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	hdb := bareHostDB()
+	hdb.staticDeps = modules.ProdDependencies
+	hdb.persistDir = build.TempDir("HostDB", t.Name())
+	if err := os.RemoveAll(hdb.persistDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(hdb.persistDir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	hdb.startRescan()
+
+	// loadPersist loads the persisted hostdb.
+	loadPersist := func() (data hdbPersist) {
+		err := persist.LoadJSON(persistMetadata, &data, filepath.Join(hdb.persistDir, persistFilename))
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	// Apply blocks one at a time. The progress should be persisted every
+	// rescanCheckpointFrequency blocks.
+	for i := types.BlockHeight(1); i <= 2*rescanCheckpointFrequency+1; i++ {
+		var cc modules.ConsensusChange
+		cc.ID = modules.ConsensusChangeID{byte(i)}
+		cc.AppliedBlocks = []types.Block{{Timestamp: types.Timestamp(i)}}
+		hdb.ProcessConsensusChange(cc)
+
+		data := loadPersist()
+		checkpoint := i - i%rescanCheckpointFrequency
+		if data.BlockHeight != checkpoint {
+			t.Fatalf("%v: expected checkpoint at %v but got %v", i, checkpoint, data.BlockHeight)
+		}
+		if checkpoint > 0 && (!data.Rescanning || data.LastChange != modules.ConsensusChangeID{byte(checkpoint)}) {
+			t.Fatal("wrong checkpoint", data.Rescanning, data.LastChange)
+		}
+	}
+
+	// Once synced, the rescan is finished and persisted right away.
+	var cc modules.ConsensusChange
+	cc.AppliedBlocks = []types.Block{{Timestamp: 1}}
+	cc.Synced = true
+	hdb.ProcessConsensusChange(cc)
+	if data := loadPersist(); data.Rescanning || data.BlockHeight != 2*rescanCheckpointFrequency+2 {
+		t.Fatal("rescan wasn't finished", data.Rescanning, data.BlockHeight)
+	}
+	if hdb.rescanning {
+		t.Fatal("hostdb is still rescanning")
+	}
+}
@@ -0,0 +1,81 @@
+package renter
+
+import (
+	"time"
+
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"gitlab.com/SkynetLabs/skyd/skymodules/maintenance"
+)
+
+const (
+	// maintenanceJitter is the fraction of their interval by which the runs
+	// of the renter's maintenance jobs are randomly moved.
+	maintenanceJitter = 0.1
+)
+
+// The names of the renter's maintenance jobs.
+const (
+	maintenanceJobContractUtilities = "contractutilities"
+	maintenanceJobDirUpdateBatch    = "dirupdatebatch"
+	maintenanceJobDirUploadPrune    = "diruploadprune"
+	maintenanceJobHostAllowlist     = "hostallowlist"
+	maintenanceJobRecentUploadCache = "recentuploadcache"
+	maintenanceJobSkynetFeePayout   = "skynetfeepayout"
+	maintenanceJobStatsPersist      = "statspersist"
+)
+
+// registerMaintenanceJob registers a job with the renter's maintenance
+// scheduler. The job doesn't run once the renter is shutting down.
+func (r *Renter) registerMaintenanceJob(name string, interval time.Duration, immediate bool, fn func()) error {
+	return r.staticMaintenance.Register(maintenance.Job{
+		Name:      name,
+		Interval:  interval,
+		Jitter:    maintenanceJitter,
+		Immediate: immediate,
+		Fn: func() {
+			if err := r.tg.Add(); err != nil {
+				return
+			}
+			defer r.tg.Done()
+			fn()
+		},
+	})
+}
+
+// MaintenanceJobs returns the status of the renter's maintenance jobs.
+func (r *Renter) MaintenanceJobs() ([]skymodules.MaintenanceJobStatus, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	return r.staticMaintenance.Status(), nil
+}
+
+// PauseMaintenanceJob pauses the maintenance job with the given name until it
+// is resumed.
+func (r *Renter) PauseMaintenanceJob(name string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.staticMaintenance.Pause(name)
+}
+
+// ResumeMaintenanceJob resumes the paused maintenance job with the given name.
+func (r *Renter) ResumeMaintenanceJob(name string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.staticMaintenance.Resume(name)
+}
+
+// SetMaintenanceJobInterval changes the interval and jitter of the
+// maintenance job with the given name. The change is not persisted.
+func (r *Renter) SetMaintenanceJobInterval(name string, interval time.Duration, jitter float64) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.staticMaintenance.SetInterval(name, interval, jitter)
+}
@@ -84,6 +84,10 @@ type memoryManager struct {
 	staticBlocking chan struct{}
 	staticStop     <-chan struct{}
 
+	// staticBudget is the soft memory limit shared with the other memory
+	// managers. Granted memory is accounted for in the budget.
+	staticBudget *memoryBudget
+
 	mu sync.Mutex
 }
 
@@ -143,11 +147,15 @@ func (mm *memoryManager) handleStarvation() {
 // manager will be updated to reflect the granted request.
 func (mm *memoryManager) try(amount uint64, priority bool) (success bool) {
 	// Defer a function to check whether a low priority memory request has been
-	// granted. If so, reset the starvation tracker.
+	// granted. If so, reset the starvation tracker. Granted memory is also
+	// accounted for in the memory budget.
 	defer func() {
 		if success && !priority {
 			mm.memSinceLowPriority = 0
 		}
+		if success {
+			mm.staticBudget.callAdd(amount)
+		}
 	}()
 
 	// If there is enough memory available, then the request can be granted. For
@@ -258,6 +266,7 @@ func (mm *memoryManager) Request(ctx context.Context, amount uint64, priority bo
 // Return will return memory to the manager, waking any blocking threads which
 // now have enough memory to proceed.
 func (mm *memoryManager) Return(amount uint64) {
+	mm.staticBudget.callRelease(amount)
 	mm.mu.Lock()
 	defer mm.mu.Unlock()
 
@@ -367,8 +376,9 @@ func (mm *memoryManager) callStatus() skymodules.MemoryManagerStatus {
 	}
 }
 
-// newMemoryManager will create a memoryManager and return it.
-func newMemoryManager(baseMemory uint64, priorityMemory uint64, stopChan <-chan struct{}) *memoryManager {
+// newMemoryManager will create a memoryManager and return it. The granted
+// memory is accounted for in the provided budget.
+func newMemoryManager(baseMemory uint64, priorityMemory uint64, budget *memoryBudget, stopChan <-chan struct{}) *memoryManager {
 	return &memoryManager{
 		available:       baseMemory,
 		base:            baseMemory,
@@ -379,5 +389,6 @@ func newMemoryManager(baseMemory uint64, priorityMemory uint64, stopChan <-chan
 
 		staticBlocking: make(chan struct{}, 1),
 		staticStop:     stopChan,
+		staticBudget:   budget,
 	}
 }
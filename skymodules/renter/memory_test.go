@@ -17,7 +17,7 @@ import (
 func TestMemoryManager(t *testing.T) {
 	// Mimic the default parameters.
 	stopChan := make(chan struct{})
-	mm := newMemoryManager(100, 25, stopChan)
+	mm := newMemoryManager(100, 25, newMemoryBudget(0), stopChan)
 
 	// Low priority memory should have no issues requesting up to 75 memory.
 	for i := 0; i < 75; i++ {
@@ -428,7 +428,7 @@ func TestMemoryManagerConcurrent(t *testing.T) {
 
 	// Mimic the default parameters.
 	stopChan := make(chan struct{})
-	mm := newMemoryManager(100, 25, stopChan)
+	mm := newMemoryManager(100, 25, newMemoryBudget(0), stopChan)
 
 	// Spin up a bunch of threads to all request and release memory at the same
 	// time.
@@ -508,7 +508,7 @@ func TestMemoryManagerStatus(t *testing.T) {
 	memoryDefault := repairMemoryDefault
 	memoryPriorityDefault := memoryDefault / 4
 	stopChan := make(chan struct{})
-	mm := newMemoryManager(memoryDefault, memoryPriorityDefault, stopChan)
+	mm := newMemoryManager(memoryDefault, memoryPriorityDefault, newMemoryBudget(0), stopChan)
 
 	// Check status
 	ms := mm.callStatus()
@@ -643,7 +643,7 @@ func TestMemoryManagerRequestMemoryWithContext(t *testing.T) {
 
 	// Create memory manager
 	stopChan := make(chan struct{})
-	mm := newMemoryManager(repairMemoryDefault, repairMemoryPriorityDefault, stopChan)
+	mm := newMemoryManager(repairMemoryDefault, repairMemoryPriorityDefault, newMemoryBudget(0), stopChan)
 
 	// Get the total available memory
 	mm.mu.Lock()
@@ -0,0 +1,98 @@
+package renter
+
+import (
+	"sync"
+
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+// memoryBudget is the soft memory limit shared by the memory managers, the
+// project downloads and the upload buffers. Allocations are never blocked by
+// the budget, they are only accounted for. Instead, the API calls 'callAdmit'
+// before starting a new download or upload to reject requests which would
+// exceed the limit. That way the renter sheds load before running out of
+// memory.
+type memoryBudget struct {
+	// limit is the soft memory limit. A limit of 0 means that the budget is
+	// unlimited.
+	limit uint64
+
+	// used is the amount of memory currently in use.
+	used uint64
+
+	// rejected is the number of requests that weren't admitted.
+	rejected uint64
+
+	mu sync.Mutex
+}
+
+// newMemoryBudget creates a new budget with the given limit.
+func newMemoryBudget(limit uint64) *memoryBudget {
+	return &memoryBudget{
+		limit: limit,
+	}
+}
+
+// callAdd accounts for allocated memory.
+func (mb *memoryBudget) callAdd(amount uint64) {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	mb.used += amount
+}
+
+// callRelease accounts for released memory.
+func (mb *memoryBudget) callRelease(amount uint64) {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	if amount > mb.used {
+		build.Critical("memory budget being used incorrectly, too much memory released")
+		mb.used = 0
+		return
+	}
+	mb.used -= amount
+}
+
+// callAdmit returns ErrMemoryLimitExceeded if admitting a request which
+// requires the given amount of memory would exceed the limit.
+func (mb *memoryBudget) callAdmit(amount uint64) error {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	if mb.limit == 0 || mb.used+amount <= mb.limit {
+		return nil
+	}
+	mb.rejected++
+	return skymodules.ErrMemoryLimitExceeded
+}
+
+// callLimit returns the limit of the budget.
+func (mb *memoryBudget) callLimit() uint64 {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	return mb.limit
+}
+
+// callSetLimit updates the limit of the budget.
+func (mb *memoryBudget) callSetLimit(limit uint64) {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	mb.limit = limit
+}
+
+// callStatus returns the status of the budget.
+func (mb *memoryBudget) callStatus() skymodules.MemoryBudgetStatus {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	return skymodules.MemoryBudgetStatus{
+		Limit:    mb.limit,
+		Used:     mb.used,
+		Rejected: mb.rejected,
+	}
+}
+
+// AdmitRequest returns ErrMemoryLimitExceeded if admitting a new request which
+// requires the given amount of memory would exceed the renter's soft memory
+// limit.
+func (r *Renter) AdmitRequest(memory uint64) error {
+	return r.staticMemoryBudget.callAdmit(memory)
+}
@@ -0,0 +1,57 @@
+package renter
+
+import (
+	"context"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+// TestMemoryBudget tests the accounting and admission control of the memory
+// budget.
+func TestMemoryBudget(t *testing.T) {
+	t.Parallel()
+
+	// Without a limit, every request is admitted.
+	mb := newMemoryBudget(0)
+	mb.callAdd(1000)
+	if err := mb.callAdmit(1000); err != nil {
+		t.Fatal(err)
+	}
+
+	// Set a limit. Requests which fit are admitted.
+	mb.callSetLimit(1500)
+	if err := mb.callAdmit(500); err != nil {
+		t.Fatal(err)
+	}
+	if err := mb.callAdmit(501); !errors.Contains(err, skymodules.ErrMemoryLimitExceeded) {
+		t.Fatal("expected request to be rejected", err)
+	}
+
+	// Release memory. The request should fit again.
+	mb.callRelease(1000)
+	if err := mb.callAdmit(501); err != nil {
+		t.Fatal(err)
+	}
+	status := mb.callStatus()
+	if status.Limit != 1500 || status.Used != 0 || status.Rejected != 1 {
+		t.Fatal("unexpected status", status)
+	}
+
+	// Memory granted by a memory manager is accounted for in its budget.
+	stopChan := make(chan struct{})
+	defer close(stopChan)
+	mm := newMemoryManager(100, 25, mb, stopChan)
+	if !mm.Request(context.Background(), 50, memoryPriorityLow) {
+		t.Fatal("request failed")
+	}
+	if used := mb.callStatus().Used; used != 50 {
+		t.Fatal("unexpected used memory", used)
+	}
+	mm.Return(20)
+	mm.Return(30)
+	if used := mb.callStatus().Used; used != 0 {
+		t.Fatal("unexpected used memory", used)
+	}
+}
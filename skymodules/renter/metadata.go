@@ -21,6 +21,10 @@ import (
 // during a bubble.
 var ErrSkylinkUnpinned = errors.New("skylink is unpinned")
 
+// ErrSiaFileExpired is the error returned when a siafile with an expired TTL is
+// found during a bubble.
+var ErrSiaFileExpired = errors.New("siafile is expired")
+
 // bubbledSiaDirMetadata is a wrapper for siadir.Metadata that also contains the
 // siapath for convenience.
 type bubbledSiaDirMetadata struct {
@@ -334,13 +338,19 @@ func (r *Renter) managedCachedFileMetadata(siaPath skymodules.SiaPath) (bubbledS
 	if r.managedIsFileNodeBlocked(sf) && !r.staticDeps.Disrupt("DisableDeleteBlockedFiles") {
 		// Delete the file
 		r.staticLog.Println("Deleting blocked fileNode at:", siaPath)
-		return bubbledSiaFileMetadata{}, errors.Compose(r.staticFileSystem.DeleteFile(siaPath), ErrSkylinkBlocked)
+		return bubbledSiaFileMetadata{}, errors.Compose(r.managedDeleteFileNode(siaPath, sf), ErrSkylinkBlocked)
 	}
 	// Check if there is a pending unpin request
 	if r.staticSkylinkManager.callIsUnpinned(sf) {
 		// Delete the file
 		r.staticLog.Println("Deleting unpinned fileNode at:", siaPath)
-		return bubbledSiaFileMetadata{}, errors.Compose(r.staticFileSystem.DeleteFile(siaPath), ErrSkylinkUnpinned)
+		return bubbledSiaFileMetadata{}, errors.Compose(r.managedDeleteFileNode(siaPath, sf), ErrSkylinkUnpinned)
+	}
+	// Check if the file's TTL expired
+	if sf.Expired() {
+		// Delete the file
+		r.staticLog.Println("Deleting expired fileNode at:", siaPath)
+		return bubbledSiaFileMetadata{}, errors.Compose(r.managedDeleteFileNode(siaPath, sf), ErrSiaFileExpired)
 	}
 
 	// Check if original file is on disk
@@ -400,6 +410,10 @@ func (r *Renter) managedCachedFileMetadatas(siaPaths []skymodules.SiaPath) (_ []
 				// If the fileNode is unpinned we ignore the error and continue.
 				continue
 			}
+			if errors.Contains(err, ErrSiaFileExpired) {
+				// If the fileNode is expired we ignore the error and continue.
+				continue
+			}
 			if err != nil {
 				errMu.Lock()
 				errs = errors.Compose(errs, err)
@@ -8,8 +8,10 @@ import (
 	"testing"
 	"time"
 
+	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/SkynetLabs/skyd/siatest/dependencies"
 	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"gitlab.com/SkynetLabs/skyd/skymodules/renter/filesystem"
 	"gitlab.com/SkynetLabs/skyd/skymodules/renter/filesystem/siadir"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
@@ -72,6 +74,69 @@ func TestCalculateFileMetadatas(t *testing.T) {
 	}
 }
 
+// TestCachedFileMetadataExpired verifies that files with an expired TTL are
+// deleted when their metadata is bubbled.
+func TestCachedFileMetadataExpired(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create renter
+	rt, err := newRenterTesterWithDependency(t.Name(), &dependencies.DependencyDisableRepairAndHealthLoops{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add a file that expires in the future and one that already expired.
+	sf1, err := rt.renter.newRenterTestFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sf1.SetExpiryTime(time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf2, err := rt.renter.newRenterTestFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sf2.SetExpiryTime(time.Now().Add(-time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	siaPath1 := rt.renter.staticFileSystem.FileSiaPath(sf1)
+	siaPath2 := rt.renter.staticFileSystem.FileSiaPath(sf2)
+	if err := errors.Compose(sf1.Close(), sf2.Close()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The file that didn't expire should report its TTL.
+	fi, err := rt.renter.File(siaPath1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.TTL == 0 || fi.TTL > uint64(time.Hour.Seconds()) {
+		t.Fatal("unexpected ttl", fi.TTL)
+	}
+
+	// Bubbling the metadata of the expired file should delete it.
+	_, err = rt.renter.managedCachedFileMetadata(siaPath1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = rt.renter.managedCachedFileMetadata(siaPath2)
+	if !errors.Contains(err, ErrSiaFileExpired) {
+		t.Fatal("expected ErrSiaFileExpired but got", err)
+	}
+	if _, err := rt.renter.File(siaPath1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rt.renter.File(siaPath2); !errors.Contains(err, filesystem.ErrNotExist) {
+		t.Fatal("expected expired file to be deleted", err)
+	}
+}
+
 // TestDirectoryMetadatas probes the directory metadata methods of the
 // renter.
 func TestDirectoryMetadatas(t *testing.T) {
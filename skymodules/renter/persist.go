@@ -4,15 +4,19 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
 	"gitlab.com/NebulousLabs/writeaheadlog"
 
 	"gitlab.com/SkynetLabs/skyd/build"
 	"gitlab.com/SkynetLabs/skyd/skymodules"
 	"gitlab.com/SkynetLabs/skyd/skymodules/renter/filesystem"
 	"gitlab.com/SkynetLabs/skyd/skymodules/renter/filesystem/siafile"
+	"gitlab.com/SkynetLabs/skyd/skymodules/renter/persistbackend"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/persist"
 	"go.sia.tech/siad/types"
 )
@@ -20,11 +24,12 @@ import (
 // PersistedStats contains the information about the renter's stats which is
 // persisted to disk.
 type PersistedStats struct {
-	RegistryReadStats     skymodules.PersistedDistributionTracker `json:"registryreadstats"`
-	RegistryWriteStats    skymodules.PersistedDistributionTracker `json:"registrywritestats"`
-	BaseSectorUploadStats skymodules.PersistedDistributionTracker `json:"basesectoruploadstats"`
-	ChunkUploadStats      skymodules.PersistedDistributionTracker `json:"chunkuploadstats"`
-	StreamBufferStats     skymodules.PersistedDistributionTracker `json:"streambufferstats"`
+	RegistryReadStats       skymodules.PersistedDistributionTracker `json:"registryreadstats"`
+	RegistryWriteStats      skymodules.PersistedDistributionTracker `json:"registrywritestats"`
+	BaseSectorUploadStats   skymodules.PersistedDistributionTracker `json:"basesectoruploadstats"`
+	ChunkUploadStats        skymodules.PersistedDistributionTracker `json:"chunkuploadstats"`
+	StreamBufferStats       skymodules.PersistedDistributionTracker `json:"streambufferstats"`
+	BaseSectorDownloadStats skymodules.PersistedDistributionTracker `json:"basesectordownloadstats"`
 }
 
 const (
@@ -90,60 +95,177 @@ type (
 		MaxUploadSpeed   int64
 		UploadedBackups  []skymodules.UploadedBackup
 		SyncedContracts  []types.FileContractID
+
+		// Overdrive are the default overdrive settings for downloads which
+		// don't specify their own.
+		Overdrive skymodules.OverdriveSettings
+
+		// SkyfileUploadLimits are the limits imposed on skyfile uploads.
+		SkyfileUploadLimits skymodules.SkyfileUploadLimits
+
+		// FanoutRedundancyPolicy is the policy which determines the erasure
+		// code parameters of the fanout of uploaded skyfiles.
+		FanoutRedundancyPolicy skymodules.FanoutRedundancyPolicy
+
+		// PriceTableUpdateFraction is the fraction of a price table's
+		// validity after which the workers update it. 0 means that the
+		// default is used.
+		PriceTableUpdateFraction float64
+
+		// TrafficShares are the shares of the bandwidth limits the traffic
+		// classes are allowed to use.
+		TrafficShares skymodules.TrafficShares
+
+		// MemoryLimit is the soft memory limit shared by downloads and
+		// uploads. 0 means that there is no limit.
+		MemoryLimit uint64
+
+		// RestrictedSkylinks are the skylinks which can only be downloaded
+		// using a URL signed with the SkylinkSigningKey.
+		RestrictedSkylinks map[string]struct{}
+		SkylinkSigningKey  []byte
+
+		// ContractSnapshotKey is the key which signs the exported contract
+		// set snapshots.
+		ContractSnapshotKey crypto.SecretKey
+
+		// AccountRefillSettings overwrite the default refill settings of the
+		// ephemeral accounts on the hosts they are keyed by.
+		AccountRefillSettings map[string]skymodules.WorkerAccountRefillSettings
+
+		// WorkerBlocklist are the subnets of the hosts which the workers
+		// refuse to launch jobs to.
+		WorkerBlocklist []skymodules.WorkerBlocklistEntry
+
+		// SkykeyAutoBackup contains the settings of the automatic backup of
+		// the renter's skykeys.
+		SkykeyAutoBackup skykeyAutoBackupSettings
+
+		// TrustedRegistryHosts are the hosts whose responses are required or
+		// weighted higher when reading from the registry.
+		TrustedRegistryHosts skymodules.TrustedRegistryHosts
+
+		// BaseSectorHedging controls the hedged fetching of base sectors.
+		BaseSectorHedging skymodules.HedgingSettings
+	}
+
+	// persistSnapshot is an encoded snapshot of the renter's persistence.
+	// The id increases with every snapshot.
+	persistSnapshot struct {
+		id   uint64
+		data []byte
+	}
+
+	// persistWriter serializes the writes of persistence snapshots to the
+	// backend and keeps track of the last written snapshot.
+	persistWriter struct {
+		written uint64
+		mu      sync.Mutex
 	}
 )
 
-// saveSync stores the current renter data to disk and then syncs to disk.
-func (r *Renter) saveSync() error {
-	return persist.SaveJSON(settingsMetadata, r.persist, filepath.Join(r.persistDir, PersistFilename))
+// newPersistBackend creates the backend for the renter's persistence. If an
+// S3 bucket is configured in the environment, the settings, stats and applied
+// host allow-list are stored in the bucket. Otherwise they are stored in the
+// persist dir.
+//
+// NOTE: siafiles, the wal, the skynet tokens, the skyfile chunk index and the
+// other subsystems require random access or appending to their files and
+// always remain on the local disk.
+func newPersistBackend(persistDir string) (skymodules.PersistBackend, error) {
+	settings, ok := build.PersistS3()
+	if !ok {
+		return persistbackend.NewFilesystemBackend(persistDir), nil
+	}
+	return persistbackend.NewS3Backend(persistbackend.S3Config{
+		Endpoint:        settings.Endpoint,
+		Region:          settings.Region,
+		Bucket:          settings.Bucket,
+		Prefix:          settings.Prefix,
+		AccessKeyID:     settings.AccessKeyID,
+		SecretAccessKey: settings.SecretAccessKey,
+	})
 }
 
-// threadedStatsPersister periodically persists the renter's collected stats.
-func (r *Renter) threadedStatsPersister() {
-	if err := r.tg.Add(); err != nil {
-		return
-	}
-	defer r.tg.Done()
-
-	ticker := time.NewTicker(statsPersistInterval)
-	for {
-		statsPath := filepath.Join(r.persistDir, StatsFilename)
-		err := persist.SaveJSON(statsMetadata, PersistedStats{
-			RegistryReadStats:     r.staticRegistryReadStats.Persist(),
-			RegistryWriteStats:    r.staticRegWriteStats.Persist(),
-			BaseSectorUploadStats: r.staticBaseSectorUploadStats.Persist(),
-			ChunkUploadStats:      r.staticChunkUploadStats.Persist(),
-			StreamBufferStats:     r.staticStreamBufferStats.Persist(),
-		}, statsPath)
-		if err != nil {
-			r.staticLog.Print("Failed to persist stats object:", err)
-		}
+// snapshotPersist takes a snapshot of the current renter data. The caller
+// must hold r.mu. The snapshot is written by managedWritePersist after
+// releasing the lock since writing to a remote backend can take a while.
+func (r *Renter) snapshotPersist() (persistSnapshot, error) {
+	data, err := skymodules.MarshalPersistJSON(settingsMetadata, r.persist)
+	if err != nil {
+		return persistSnapshot{}, err
+	}
+	r.persistSnapshotID++
+	return persistSnapshot{
+		id:   r.persistSnapshotID,
+		data: data,
+	}, nil
+}
 
-		// Sleep
-		select {
-		case <-r.tg.StopCtx().Done():
-			return // shutdown
-		case <-ticker.C:
-		}
+// managedWritePersist stores a snapshot of the renter data in the persistence
+// backend. Snapshots which are older than the last stored one are skipped
+// since the newer snapshot already contains their changes.
+func (r *Renter) managedWritePersist(snapshot persistSnapshot) error {
+	pw := r.staticPersistWriter
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	if snapshot.id <= pw.written {
+		return nil
+	}
+	err := r.staticPersistBackend.WriteObject(PersistFilename, snapshot.data)
+	if err != nil {
+		return err
+	}
+	pw.written = snapshot.id
+	return nil
+}
+
+// managedSaveSync stores the current renter data in the persistence backend.
+// The caller must not hold r.mu.
+func (r *Renter) managedSaveSync() error {
+	id := r.mu.Lock()
+	snapshot, err := r.snapshotPersist()
+	r.mu.Unlock(id)
+	if err != nil {
+		return err
+	}
+	return r.managedWritePersist(snapshot)
+}
+
+// managedPersistStats persists the renter's collected stats. The stats are
+// collected before writing them to avoid holding any of their locks while
+// writing to the backend.
+func (r *Renter) managedPersistStats() {
+	stats := PersistedStats{
+		RegistryReadStats:       r.staticRegistryReadStats.Persist(),
+		RegistryWriteStats:      r.staticRegWriteStats.Persist(),
+		BaseSectorUploadStats:   r.staticBaseSectorUploadStats.Persist(),
+		ChunkUploadStats:        r.staticChunkUploadStats.Persist(),
+		StreamBufferStats:       r.staticStreamBufferStats.Persist(),
+		BaseSectorDownloadStats: r.staticBaseSectorDownloadLatencyStats.Persist(),
+	}
+	err := skymodules.SavePersistJSON(r.staticPersistBackend, statsMetadata, stats, StatsFilename)
+	if err != nil {
+		r.staticLog.Print("Failed to persist stats object:", err)
 	}
 }
 
 // managedLoadSettings fetches the saved renter data from disk.
 func (r *Renter) managedLoadSettings() error {
 	r.persist = persistence{}
-	err := persist.LoadJSON(settingsMetadata, &r.persist, filepath.Join(r.persistDir, PersistFilename))
-	if os.IsNotExist(err) {
+	err := skymodules.LoadPersistJSON(r.staticPersistBackend, settingsMetadata, &r.persist, PersistFilename)
+	if errors.Contains(err, skymodules.ErrPersistObjectNotFound) {
 		// No persistence yet, set the defaults and continue.
 		r.persist.MaxDownloadSpeed = DefaultMaxDownloadSpeed
 		r.persist.MaxUploadSpeed = DefaultMaxUploadSpeed
-		id := r.mu.Lock()
-		err = r.saveSync()
-		r.mu.Unlock(id)
+		err = r.managedSaveSync()
 		if err != nil {
 			return err
 		}
-	} else if errors.Contains(err, persist.ErrBadVersion) {
-		// Outdated version, try the 040 to 133 upgrade.
+	} else if _, onDisk := r.staticPersistBackend.(*persistbackend.FilesystemBackend); onDisk && errors.Contains(err, persist.ErrBadVersion) {
+		// Outdated version, try the 040 to 133 upgrade. Outdated versions
+		// predate the persistence backends and are therefore always found
+		// on disk.
 		err = convertPersistVersionFrom040To133(filepath.Join(r.persistDir, PersistFilename))
 		if err != nil {
 			r.staticLog.Println("WARNING: 040 to 133 renter upgrade failed, trying 133 to 140 next", err)
@@ -168,9 +290,40 @@ func (r *Renter) managedLoadSettings() error {
 		return err
 	}
 
+	// Generate a key for signing skylink URLs if we don't have one yet.
+	if len(r.persist.SkylinkSigningKey) == 0 {
+		r.persist.SkylinkSigningKey = fastrand.Bytes(skymodules.SkylinkSigningKeySize)
+		err = r.managedSaveSync()
+		if err != nil {
+			return errors.AddContext(err, "failed to persist skylink signing key")
+		}
+	}
+
+	// Generate a key for signing contract set snapshots if we don't have one
+	// yet.
+	if r.persist.ContractSnapshotKey == (crypto.SecretKey{}) {
+		r.persist.ContractSnapshotKey, _ = crypto.GenerateKeyPair()
+		err = r.managedSaveSync()
+		if err != nil {
+			return errors.AddContext(err, "failed to persist contract snapshot key")
+		}
+	}
+
+	// Apply the soft memory limit.
+	r.staticMemoryBudget.callSetLimit(r.persist.MemoryLimit)
+
+	// Apply the worker blocklist.
+	err = r.staticWorkerBlocklist.callUpdate(r.persist.WorkerBlocklist, nil)
+	if err != nil {
+		return errors.AddContext(err, "failed to load worker blocklist")
+	}
+
+	// Apply the trusted registry hosts.
+	r.staticTrustedRegistryHosts.callSet(r.persist.TrustedRegistryHosts)
+
 	// Set the bandwidth limits on the contractor, which was already initialized
 	// without bandwidth limits.
-	return r.staticSetBandwidthLimits(r.persist.MaxDownloadSpeed, r.persist.MaxUploadSpeed)
+	return r.staticSetBandwidthLimits(r.persist.MaxDownloadSpeed, r.persist.MaxUploadSpeed, r.persist.TrafficShares)
 }
 
 // managedInitPersist handles all of the persistence initialization, such as creating
@@ -249,6 +402,11 @@ func (r *Renter) managedInitPersist() error {
 		return errors.AddContext(err, "failed to initialize the renter's distribution trackers")
 	}
 
+	// Load the applied host allow-list.
+	if err := r.managedLoadHostAllowlist(); err != nil {
+		return err
+	}
+
 	// Create the essential dirs in the filesystem.
 	err = fs.NewSiaDir(skymodules.HomeFolder, skymodules.DefaultDirPerm)
 	if err != nil && !errors.Contains(err, filesystem.ErrExists) {
@@ -277,18 +435,19 @@ func (r *Renter) managedInitStats() error {
 	r.staticBaseSectorUploadStats = skymodules.NewDistributionTrackerStandard()
 	r.staticChunkUploadStats = skymodules.NewDistributionTrackerStandard()
 	r.staticStreamBufferStats = skymodules.NewDistributionTrackerStandard()
+	r.staticBaseSectorDownloadLatencyStats = skymodules.NewDistributionTrackerStandard()
 
 	// Load the existing stats.
-	statsPath := filepath.Join(r.persistDir, StatsFilename)
 	var stats PersistedStats
-	err := persist.LoadJSON(statsMetadata, &stats, statsPath)
-	if os.IsNotExist(err) {
+	err := skymodules.LoadPersistJSON(r.staticPersistBackend, statsMetadata, &stats, StatsFilename)
+	if errors.Contains(err, skymodules.ErrPersistObjectNotFound) {
 		// No persistence yet. Seed the trackers.
 		r.staticRegistryReadStats.AddDataPoint(readRegistryStatsSeed) // Seed the stats so that startup doesn't say 0.
 		r.staticRegWriteStats.AddDataPoint(5 * time.Second)           // Seed the stats so that startup doesn't say 0.
 		r.staticBaseSectorUploadStats.AddDataPoint(15 * time.Second)  // Seed the stats so that startup doesn't say 0.
 		r.staticChunkUploadStats.AddDataPoint(15 * time.Second)       // Seed the stats so that startup doesn't say 0.
 		r.staticStreamBufferStats.AddDataPoint(5 * time.Second)       // Seed the stats so that startup doesn't say 0.
+		r.staticBaseSectorDownloadLatencyStats.AddDataPoint(time.Second)
 		return nil
 	} else if err != nil {
 		if build.Release == "testing" {
@@ -304,7 +463,16 @@ func (r *Renter) managedInitStats() error {
 	err3 := r.staticBaseSectorUploadStats.Load(stats.BaseSectorUploadStats)
 	err4 := r.staticChunkUploadStats.Load(stats.ChunkUploadStats)
 	err5 := r.staticStreamBufferStats.Load(stats.StreamBufferStats)
-	if err := errors.Compose(err1, err2, err3, err4, err5); err != nil {
+
+	// The base sector download stats were added later, seed them if they
+	// haven't been persisted yet.
+	var err6 error
+	if len(stats.BaseSectorDownloadStats.Distributions) == 0 {
+		r.staticBaseSectorDownloadLatencyStats.AddDataPoint(time.Second)
+	} else {
+		err6 = r.staticBaseSectorDownloadLatencyStats.Load(stats.BaseSectorDownloadStats)
+	}
+	if err := errors.Compose(err1, err2, err3, err4, err5, err6); err != nil {
 		if build.Release == "testing" {
 			build.Critical(err)
 		}
@@ -10,11 +10,14 @@ import (
 
 	"gitlab.com/NebulousLabs/fastrand"
 	"gitlab.com/NebulousLabs/ratelimit"
+	"gitlab.com/SkynetLabs/skyd/build"
 	"gitlab.com/SkynetLabs/skyd/siatest/dependencies"
 	"gitlab.com/SkynetLabs/skyd/skymodules"
 	"gitlab.com/SkynetLabs/skyd/skymodules/renter/filesystem/siafile"
+	"gitlab.com/SkynetLabs/skyd/skymodules/renter/persistbackend"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
+	siasync "go.sia.tech/siad/sync"
 )
 
 // testingFileParams generates the ErasureCoder with random dataPieces and
@@ -134,6 +137,30 @@ func TestRenterSaveLoad(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	// Update the price table update fraction. Values outside of [0, 1) are
+	// rejected.
+	newPTUpdateFraction := 0.25
+	settings.PriceTableUpdateFraction = 1
+	if err := rt.renter.SetSettings(settings); err == nil {
+		t.Fatal("expected invalid fraction to be rejected")
+	}
+	settings.PriceTableUpdateFraction = newPTUpdateFraction
+	err = rt.renter.SetSettings(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Update the fanout redundancy policy. Unknown policies are rejected.
+	settings.FanoutRedundancyPolicy = "random"
+	if err := rt.renter.SetSettings(settings); err == nil {
+		t.Fatal("expected unknown policy to be rejected")
+	}
+	settings.FanoutRedundancyPolicy = skymodules.FanoutRedundancyPolicyDynamic
+	err = rt.renter.SetSettings(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	// Add a file to the renter
 	entry, err := rt.renter.newRenterTestFile()
 	if err != nil {
@@ -153,7 +180,7 @@ func TestRenterSaveLoad(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	err = rt.renter.saveSync() // save metadata
+	err = rt.renter.managedSaveSync() // save metadata
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -183,6 +210,12 @@ func TestRenterSaveLoad(t *testing.T) {
 	if newSettings.MaxUploadSpeed != newUpSpeed {
 		t.Error("upload settings not being persisted correctly")
 	}
+	if newSettings.PriceTableUpdateFraction != newPTUpdateFraction {
+		t.Error("price table update fraction not being persisted correctly")
+	}
+	if newSettings.FanoutRedundancyPolicy != skymodules.FanoutRedundancyPolicyDynamic {
+		t.Error("fanout redundancy policy not being persisted correctly")
+	}
 
 	// Check that SiaFileSet loaded the renter's file
 	_, err = rt.renter.staticFileSystem.OpenSiaFile(siapath)
@@ -384,3 +417,65 @@ func TestSiafileCompatibility(t *testing.T) {
 		t.Fatal("invalid number of chunks in siafile:", sf.NumChunks())
 	}
 }
+
+// TestRenterPersistSnapshots tests that snapshots of the renter's persistence
+// are written outside of the renter's lock and that older snapshots never
+// overwrite newer ones.
+func TestRenterPersistSnapshots(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	r := &Renter{
+		mu:                   siasync.New(modules.SafeMutexDelay, 1),
+		staticPersistBackend: persistbackend.NewFilesystemBackend(build.TempDir("renter", t.Name())),
+		staticPersistWriter:  &persistWriter{},
+	}
+
+	// load loads the persisted memory limit.
+	load := func() uint64 {
+		var p persistence
+		err := skymodules.LoadPersistJSON(r.staticPersistBackend, settingsMetadata, &p, PersistFilename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p.MemoryLimit
+	}
+
+	// Take two snapshots.
+	id := r.mu.Lock()
+	r.persist.MemoryLimit = 1
+	older, err := r.snapshotPersist()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.persist.MemoryLimit = 2
+	newer, err := r.snapshotPersist()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.mu.Unlock(id)
+
+	// Write the newer one first. The older one should be skipped.
+	if err := r.managedWritePersist(newer); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.managedWritePersist(older); err != nil {
+		t.Fatal(err)
+	}
+	if limit := load(); limit != 2 {
+		t.Fatal("unexpected memory limit", limit)
+	}
+
+	// Saving takes a new snapshot.
+	id = r.mu.Lock()
+	r.persist.MemoryLimit = 3
+	r.mu.Unlock(id)
+	if err := r.managedSaveSync(); err != nil {
+		t.Fatal(err)
+	}
+	if limit := load(); limit != 3 {
+		t.Fatal("unexpected memory limit", limit)
+	}
+}
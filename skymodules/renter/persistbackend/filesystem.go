@@ -0,0 +1,137 @@
+package persistbackend
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+const (
+	// filePermissions are the permissions of objects written by the
+	// filesystem backend.
+	filePermissions = 0600
+)
+
+type (
+	// FilesystemBackend is a PersistBackend which stores objects as files
+	// within a directory on the local disk.
+	FilesystemBackend struct {
+		staticDir string
+	}
+)
+
+// NewFilesystemBackend creates a new backend which stores objects within the
+// given directory.
+func NewFilesystemBackend(dir string) *FilesystemBackend {
+	return &FilesystemBackend{
+		staticDir: dir,
+	}
+}
+
+// Dir returns the directory of the backend.
+func (fb *FilesystemBackend) Dir() string {
+	return fb.staticDir
+}
+
+// path returns the path of the object with the given name.
+func (fb *FilesystemBackend) path(name string) (string, error) {
+	path := filepath.Join(fb.staticDir, filepath.FromSlash(name))
+	if !strings.HasPrefix(path, filepath.Clean(fb.staticDir)+string(filepath.Separator)) {
+		return "", errors.New("object name escapes the backend's directory: " + name)
+	}
+	return path, nil
+}
+
+// DeleteObject deletes the object with the given name.
+func (fb *FilesystemBackend) DeleteObject(name string) error {
+	path, err := fb.path(name)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// ListObjects returns the names of all objects with the given prefix.
+func (fb *FilesystemBackend) ListObjects(prefix string) ([]string, error) {
+	var names []string
+	err := filepath.Walk(fb.staticDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(fb.staticDir, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return names, err
+}
+
+// ReadObject returns the data of the object with the given name.
+func (fb *FilesystemBackend) ReadObject(name string) ([]byte, error) {
+	path, err := fb.path(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, errors.Compose(err, skymodules.ErrPersistObjectNotFound)
+	}
+	return data, err
+}
+
+// WriteObject atomically replaces the object with the given name. The data is
+// written to a temporary file first which is then renamed. That way a crash
+// leaves either the old or the new object behind but never a partial one.
+func (fb *FilesystemBackend) WriteObject(name string, data []byte) (err error) {
+	path, err := fb.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), skymodules.DefaultDirPerm); err != nil {
+		return errors.AddContext(err, "failed to create object directory")
+	}
+
+	// Write the temporary file.
+	tmpPath := path + skymodules.PersistTempSuffix
+	f, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, filePermissions)
+	if err != nil {
+		return errors.AddContext(err, "failed to open temporary file")
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	err = errors.Compose(err, f.Close())
+	if err != nil {
+		return errors.AddContext(err, "failed to write temporary file")
+	}
+
+	// Rename it and sync the directory.
+	if err := os.Rename(tmpPath, path); err != nil {
+		return errors.AddContext(err, "failed to rename temporary file")
+	}
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return errors.AddContext(err, "failed to open object directory")
+	}
+	return errors.Compose(dir.Sync(), dir.Close())
+}
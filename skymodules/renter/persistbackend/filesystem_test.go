@@ -0,0 +1,173 @@
+package persistbackend
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/persist"
+)
+
+// testBackend runs the tests shared by all backends. It expects an empty
+// backend.
+func testBackend(t *testing.T, b skymodules.PersistBackend) {
+	// Reading a missing object fails.
+	_, err := b.ReadObject("foo")
+	if !errors.Contains(err, skymodules.ErrPersistObjectNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// Write some objects and read them back.
+	objects := map[string][]byte{
+		"foo":         []byte("foo"),
+		"dir/bar":     []byte("bar"),
+		"dir/baz":     []byte("baz"),
+		"other/a b~c": []byte("escaped"),
+	}
+	for name, data := range objects {
+		if err := b.WriteObject(name, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, data := range objects {
+		read, err := b.ReadObject(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(read, data) {
+			t.Fatal("wrong data", name, string(read))
+		}
+	}
+
+	// Overwrite an object.
+	if err := b.WriteObject("foo", []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	read, err := b.ReadObject("foo")
+	if err != nil || string(read) != "new" {
+		t.Fatal("object wasn't overwritten", err, string(read))
+	}
+
+	// List the objects.
+	names, err := b.ListObjects("dir/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(names)
+	if !reflect.DeepEqual(names, []string{"dir/bar", "dir/baz"}) {
+		t.Fatal("wrong names", names)
+	}
+	names, err = b.ListObjects("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != len(objects) {
+		t.Fatal("wrong number of objects", names)
+	}
+
+	// Delete an object twice.
+	for i := 0; i < 2; i++ {
+		if err := b.DeleteObject("dir/bar"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = b.ReadObject("dir/bar")
+	if !errors.Contains(err, skymodules.ErrPersistObjectNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// Save and load a json object.
+	meta := persist.Metadata{Header: "Test", Version: "1.0"}
+	type testObject struct {
+		Foo string
+		Bar []int
+	}
+	obj := testObject{Foo: "foo", Bar: []int{1, 2, 3}}
+	if err := skymodules.SavePersistJSON(b, meta, obj, "test.json"); err != nil {
+		t.Fatal(err)
+	}
+	var loaded testObject
+	if err := skymodules.LoadPersistJSON(b, meta, &loaded, "test.json"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(obj, loaded) {
+		t.Fatal("wrong object", loaded)
+	}
+	err = skymodules.LoadPersistJSON(b, persist.Metadata{Header: "Test", Version: "2.0"}, &loaded, "test.json")
+	if !errors.Contains(err, persist.ErrBadVersion) {
+		t.Fatal("unexpected error", err)
+	}
+}
+
+// TestFilesystemBackend tests the FilesystemBackend.
+func TestFilesystemBackend(t *testing.T) {
+	t.Parallel()
+
+	dir := build.TempDir("persistbackend", t.Name())
+	if err := os.MkdirAll(dir, skymodules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	fb := NewFilesystemBackend(dir)
+	testBackend(t, fb)
+
+	// Objects can't escape the directory.
+	if err := fb.WriteObject("../escape", nil); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// No temporary files should be left behind.
+	if _, err := os.Stat(filepath.Join(dir, "foo"+skymodules.PersistTempSuffix)); !os.IsNotExist(err) {
+		t.Fatal("temporary file left behind", err)
+	}
+
+	// Objects written with persist.SaveJSON can be loaded and vice versa.
+	meta := persist.Metadata{Header: "Test", Version: "1.0"}
+	obj := map[string]int{"foo": 1}
+	if err := persist.SaveJSON(meta, obj, filepath.Join(dir, "compat.json")); err != nil {
+		t.Fatal(err)
+	}
+	var loaded map[string]int
+	if err := skymodules.LoadPersistJSON(fb, meta, &loaded, "compat.json"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(obj, loaded) {
+		t.Fatal("wrong object", loaded)
+	}
+	if err := skymodules.SavePersistJSON(fb, meta, map[string]int{"bar": 2}, "compat.json"); err != nil {
+		t.Fatal(err)
+	}
+	loaded = nil
+	if err := persist.LoadJSON(meta, &loaded, filepath.Join(dir, "compat.json")); err != nil {
+		t.Fatal(err)
+	}
+	if loaded["bar"] != 2 {
+		t.Fatal("wrong object", loaded)
+	}
+
+	// A corrupted object falls back to its temporary copy.
+	good, err := ioutil.ReadFile(filepath.Join(dir, "compat.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "compat.json"+skymodules.PersistTempSuffix), good, 0600); err != nil {
+		t.Fatal(err)
+	}
+	corrupted := append([]byte{}, good...)
+	corrupted[len(corrupted)-3]++
+	if err := ioutil.WriteFile(filepath.Join(dir, "compat.json"), corrupted, 0600); err != nil {
+		t.Fatal(err)
+	}
+	loaded = nil
+	if err := skymodules.LoadPersistJSON(fb, meta, &loaded, "compat.json"); err != nil {
+		t.Fatal(err)
+	}
+	if loaded["bar"] != 2 {
+		t.Fatal("wrong object", loaded)
+	}
+}
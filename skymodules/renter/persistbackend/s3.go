@@ -0,0 +1,181 @@
+package persistbackend
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+const (
+	// s3RequestTimeout is the timeout of a single operation on the object
+	// store.
+	s3RequestTimeout = time.Minute
+
+	// s3NoSuchKey is the error code returned by the object store when
+	// accessing an object which doesn't exist.
+	s3NoSuchKey = "NoSuchKey"
+)
+
+var (
+	// errS3NoBucket is returned when creating an S3 backend without a bucket.
+	errS3NoBucket = errors.New("S3 backend requires a bucket")
+
+	// errS3NoCredentials is returned when creating an S3 backend without
+	// credentials.
+	errS3NoCredentials = errors.New("S3 backend requires an access key id and secret access key")
+)
+
+type (
+	// S3Config contains the settings of an S3Backend.
+	S3Config struct {
+		// Endpoint is the base URL of the object store, e.g.
+		// https://s3.us-east-1.amazonaws.com. Objects are addressed using
+		// path-style URLs which are supported by most S3 compatible stores.
+		Endpoint string
+
+		// Region is the region of the bucket. Defaults to us-east-1.
+		Region string
+
+		// Bucket is the name of the bucket and Prefix an optional prefix
+		// which is prepended to the names of all objects.
+		Bucket string
+		Prefix string
+
+		// AccessKeyID and SecretAccessKey are the credentials used to sign
+		// requests.
+		AccessKeyID     string
+		SecretAccessKey string
+
+		// Transport is the transport used for requests. Defaults to the
+		// default transport of the minio client.
+		Transport http.RoundTripper
+	}
+
+	// S3Backend is a PersistBackend which stores objects in an S3 compatible
+	// object store.
+	S3Backend struct {
+		staticClient *minio.Client
+		staticConfig S3Config
+	}
+)
+
+// NewS3Backend creates a new backend which stores objects in an S3 compatible
+// object store.
+func NewS3Backend(config S3Config) (*S3Backend, error) {
+	if config.Bucket == "" {
+		return nil, errS3NoBucket
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, errS3NoCredentials
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = fmt.Sprintf("https://s3.%v.amazonaws.com", config.Region)
+	}
+	config.Prefix = strings.Trim(config.Prefix, "/")
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to parse S3 endpoint")
+	}
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported S3 endpoint scheme '%v'", endpoint.Scheme)
+	}
+	if strings.Trim(endpoint.Path, "/") != "" {
+		return nil, errors.New("S3 endpoint can't contain a path")
+	}
+	client, err := minio.New(endpoint.Host, &minio.Options{
+		Creds:        credentials.NewStaticV4(config.AccessKeyID, config.SecretAccessKey, ""),
+		Secure:       endpoint.Scheme == "https",
+		Transport:    config.Transport,
+		Region:       config.Region,
+		BucketLookup: minio.BucketLookupPath,
+	})
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to create S3 client")
+	}
+	return &S3Backend{
+		staticClient: client,
+		staticConfig: config,
+	}, nil
+}
+
+// key returns the key of the object with the given name within the bucket.
+func (sb *S3Backend) key(name string) string {
+	if sb.staticConfig.Prefix == "" {
+		return name
+	}
+	return sb.staticConfig.Prefix + "/" + name
+}
+
+// DeleteObject deletes the object with the given name.
+func (sb *S3Backend) DeleteObject(name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s3RequestTimeout)
+	defer cancel()
+	err := sb.staticClient.RemoveObject(ctx, sb.staticConfig.Bucket, sb.key(name), minio.RemoveObjectOptions{})
+	if err != nil && minio.ToErrorResponse(err).Code != s3NoSuchKey {
+		return errors.AddContext(err, "failed to delete object")
+	}
+	return nil
+}
+
+// ListObjects returns the names of all objects with the given prefix.
+func (sb *S3Backend) ListObjects(prefix string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s3RequestTimeout)
+	defer cancel()
+	var names []string
+	objects := sb.staticClient.ListObjects(ctx, sb.staticConfig.Bucket, minio.ListObjectsOptions{
+		Prefix:    sb.key(prefix),
+		Recursive: true,
+	})
+	for obj := range objects {
+		if obj.Err != nil {
+			return nil, errors.AddContext(obj.Err, "failed to list objects")
+		}
+		names = append(names, strings.TrimPrefix(obj.Key, sb.key("")))
+	}
+	return names, nil
+}
+
+// ReadObject returns the data of the object with the given name.
+func (sb *S3Backend) ReadObject(name string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s3RequestTimeout)
+	defer cancel()
+	obj, err := sb.staticClient.GetObject(ctx, sb.staticConfig.Bucket, sb.key(name), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to get object")
+	}
+	defer func() {
+		_ = obj.Close()
+	}()
+	data, err := ioutil.ReadAll(obj)
+	if minio.ToErrorResponse(err).Code == s3NoSuchKey {
+		return nil, skymodules.ErrPersistObjectNotFound
+	}
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to read object")
+	}
+	return data, nil
+}
+
+// WriteObject replaces the object with the given name. Uploads to S3 are
+// atomic, so readers never see a partially written object.
+func (sb *S3Backend) WriteObject(name string, data []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s3RequestTimeout)
+	defer cancel()
+	_, err := sb.staticClient.PutObject(ctx, sb.staticConfig.Bucket, sb.key(name), bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType: "application/octet-stream",
+	})
+	return errors.AddContext(err, "failed to put object")
+}
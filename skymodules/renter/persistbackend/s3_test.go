@@ -0,0 +1,155 @@
+package persistbackend
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+// fakeS3 is a minimal in-memory S3 server for testing.
+type fakeS3 struct {
+	objects map[string][]byte
+	mu      sync.Mutex
+}
+
+// fakeS3ListBucketResult is the response of a ListObjectsV2 request.
+type fakeS3ListBucketResult struct {
+	XMLName  xml.Name `xml:"ListBucketResult"`
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// ServeHTTP implements http.Handler.
+func (fs *fakeS3) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if !strings.HasPrefix(req.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	key := strings.TrimPrefix(req.URL.Path, "/bucket/")
+	switch req.Method {
+	case http.MethodPut:
+		data, _ := ioutil.ReadAll(req.Body)
+		fs.objects[key] = data
+		w.Header().Set("ETag", `"etag"`)
+	case http.MethodDelete:
+		delete(fs.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodGet:
+		if req.URL.Query().Get("list-type") == "2" {
+			fs.list(w, req)
+			return
+		}
+		data, ok := fs.objects[key]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("<Error><Code>NoSuchKey</Code></Error>"))
+			return
+		}
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		_, _ = w.Write(data)
+	}
+}
+
+// list handles ListObjectsV2 requests returning a single key per page.
+func (fs *fakeS3) list(w http.ResponseWriter, req *http.Request) {
+	var keys []string
+	for key := range fs.objects {
+		if strings.HasPrefix(key, req.URL.Query().Get("prefix")) && key > req.URL.Query().Get("continuation-token") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	var result fakeS3ListBucketResult
+	if len(keys) > 0 {
+		result.Contents = append(result.Contents, struct {
+			Key string `xml:"Key"`
+		}{Key: keys[0]})
+	}
+	if len(keys) > 1 {
+		result.IsTruncated = true
+		result.NextContinuationToken = keys[0]
+	}
+	_ = xml.NewEncoder(w).Encode(result)
+}
+
+// TestS3Backend tests the S3Backend against a fake object store.
+func TestS3Backend(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(&fakeS3{objects: make(map[string][]byte)})
+	defer server.Close()
+	transport := server.Client().Transport
+
+	// Creating a backend without a bucket or credentials fails.
+	_, err := NewS3Backend(S3Config{Endpoint: server.URL, AccessKeyID: "key", SecretAccessKey: "secret"})
+	if err != errS3NoBucket {
+		t.Fatal("unexpected error", err)
+	}
+	_, err = NewS3Backend(S3Config{Endpoint: server.URL, Bucket: "bucket"})
+	if err != errS3NoCredentials {
+		t.Fatal("unexpected error", err)
+	}
+
+	sb, err := NewS3Backend(S3Config{
+		Endpoint:        server.URL,
+		Bucket:          "bucket",
+		Prefix:          "/portal1/",
+		AccessKeyID:     "key",
+		SecretAccessKey: "secret",
+		Transport:       transport,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testBackend(t, sb)
+
+	// The objects should be stored under the prefix.
+	noPrefix, err := NewS3Backend(S3Config{Endpoint: server.URL, Bucket: "bucket", AccessKeyID: "key", SecretAccessKey: "secret", Transport: transport})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, err := noPrefix.ListObjects("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) == 0 {
+		t.Fatal("expected objects")
+	}
+	for _, name := range names {
+		if !strings.HasPrefix(name, "portal1/") {
+			t.Fatal("object isn't stored under the prefix", name)
+		}
+	}
+
+	// Requests with wrong credentials fail.
+	wrongKey, err := NewS3Backend(S3Config{Endpoint: server.URL, Bucket: "bucket", AccessKeyID: "wrong", SecretAccessKey: "secret", Transport: transport})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wrongKey.ReadObject("foo"); err == nil || errors.Contains(err, skymodules.ErrPersistObjectNotFound) {
+		t.Fatal("expected forbidden error", err)
+	}
+
+	// Endpoints with a path are rejected.
+	_, err = NewS3Backend(S3Config{Endpoint: server.URL + "/path", Bucket: "bucket", AccessKeyID: "key", SecretAccessKey: "secret"})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
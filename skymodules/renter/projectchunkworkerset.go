@@ -406,6 +406,10 @@ func (pcws *projectChunkWorkerSet) managedLaunchWorkers(ws *pcwsWorkerState) {
 	workers := ws.staticRenter.staticWorkerPool.callWorkers()
 	responseChan := make(chan *jobHasSectorResponse, len(workers))
 	for _, w := range workers {
+		// Silently skip workers of blocklisted hosts and paused workers.
+		if w.staticJobsDisabledErr() != nil {
+			continue
+		}
 		err := pcws.managedLaunchWorker(w, responseChan, ws)
 		if err != nil && !errors.Contains(err, errEstimateAboveMax) {
 			pcws.staticRenter.staticLog.Debugf("failed to launch worker: %v", err)
@@ -492,9 +496,17 @@ func (pcws *projectChunkWorkerSet) managedTryUpdateWorkerState() error {
 // will select those workers only if the additional expense of using those
 // workers is less than 100 * pricePerMS.
 func (pcws *projectChunkWorkerSet) managedDownload(ctx context.Context, pricePerMS types.Currency, offset, length uint64, skipRecovery, lowPrio bool) (chan *downloadResponse, error) {
+	respChan, _, err := pcws.managedDownloadExcluding(ctx, pricePerMS, offset, length, skipRecovery, lowPrio, nil)
+	return respChan, err
+}
+
+// managedDownloadExcluding is like managedDownload but it won't launch any of
+// the workers of the hosts in excluded. It also returns the host keys of the
+// initial set of workers that were launched for the download.
+func (pcws *projectChunkWorkerSet) managedDownloadExcluding(ctx context.Context, pricePerMS types.Currency, offset, length uint64, skipRecovery, lowPrio bool, excluded map[string]struct{}) (chan *downloadResponse, map[string]struct{}, error) {
 	// Potentially force a timeout via a disrupt for testing.
 	if pcws.staticRenter.staticDeps.Disrupt("timeoutProjectDownloadByRoot") {
-		return nil, errors.Compose(ErrProjectTimedOut, ErrRootNotFound)
+		return nil, nil, errors.Compose(ErrProjectTimedOut, ErrRootNotFound)
 	}
 
 	// Convenience variables.
@@ -510,13 +522,13 @@ func (pcws *projectChunkWorkerSet) managedDownload(ctx context.Context, pricePer
 	// sectors were not supported when encryption schemes with overhead were
 	// being suggested.
 	if pcws.staticMasterKey.Type().Overhead() != 0 && (offset != 0 || length != modules.SectorSize*uint64(ec.MinPieces())) {
-		return nil, errors.New("invalid request performed - this chunk has encryption overhead and therefore the full chunk must be downloaded")
+		return nil, nil, errors.New("invalid request performed - this chunk has encryption overhead and therefore the full chunk must be downloaded")
 	}
 
 	// Refresh the pcws. This will only cause a refresh if one is necessary.
 	err := pcws.managedTryUpdateWorkerState()
 	if err != nil {
-		return nil, errors.AddContext(err, "unable to initiate download")
+		return nil, nil, errors.AddContext(err, "unable to initiate download")
 	}
 
 	// After refresh, grab the worker state.
@@ -566,9 +578,11 @@ func (pcws *projectChunkWorkerSet) managedDownload(ctx context.Context, pricePer
 		pieceOffset: pieceOffset,
 		pieceLength: pieceLength,
 
-		staticIsLowPrio: lowPrio,
+		staticIsLowPrio:       lowPrio,
+		staticExcludedWorkers: excluded,
 
-		pricePerMS: pricePerMS,
+		pricePerMS:      pricePerMS,
+		staticOverdrive: pcws.staticRenter.managedOverdriveSettings(overdriveSettingsFromContext(ctx)),
 
 		availablePieces:         make([][]*pieceDownload, ec.NumPieces()),
 		availablePiecesByWorker: make(map[string][]uint64),
@@ -587,17 +601,34 @@ func (pcws *projectChunkWorkerSet) managedDownload(ctx context.Context, pricePer
 	fastrand.Read(pdc.uid[:])
 	pdc.launchTime = time.Now()
 
+	// Account for the memory of the downloaded pieces in the renter's memory
+	// budget until the pdc is done.
+	budget := pcws.staticRenter.staticMemoryBudget
+	memory := pieceLength * uint64(ec.NumPieces())
+	budget.callAdd(memory)
+
 	// Launch the initial set of workers for the pdc.
 	err = pdc.launchInitialWorkers()
 	if err != nil {
-		return nil, errors.Compose(err, ErrRootNotFound)
+		budget.callRelease(memory)
+		return nil, nil, errors.Compose(err, ErrRootNotFound)
+	}
+
+	// Remember the hosts of the initial workers before the background thread
+	// takes over the pdc.
+	launched := make(map[string]struct{}, len(pdc.launchedWorkers))
+	for _, lw := range pdc.launchedWorkers {
+		launched[lw.staticWorker.staticHostPubKeyStr] = struct{}{}
 	}
 
 	// All initial workers have been launched. The function can return now,
 	// unblocking the caller. A background thread will be launched to collect
 	// the responses and launch overdrive workers when necessary.
-	go pdc.threadedCollectAndOverdrivePieces()
-	return pdc.downloadResponseChan, nil
+	go func() {
+		defer budget.callRelease(memory)
+		pdc.threadedCollectAndOverdrivePieces()
+	}()
+	return pdc.downloadResponseChan, launched, nil
 }
 
 // newPCWSByRoots will create a worker set to download a chunk given just the
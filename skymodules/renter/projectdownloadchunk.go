@@ -48,8 +48,19 @@ type (
 
 		// expectedCompleteTime indicates the time when the download is expected
 		// to complete. This is used to determine whether or not a download is late.
+		// It is re-estimated if the download is late when the piece is retried on
+		// another worker.
 		expectedCompleteTime time.Time
 
+		// attempt is the number of the attempt to download the piece which is
+		// made by this piece download. The first worker launched for a piece
+		// makes the first attempt, a worker which is launched for the same
+		// piece after that, e.g. because the previous attempt failed or is
+		// late, makes the next one. 'lastErr' is the error of the latest failed
+		// attempt of the piece at the time this attempt was launched.
+		attempt int
+		lastErr error
+
 		worker *worker
 	}
 
@@ -72,6 +83,11 @@ type (
 
 		staticIsLowPrio bool
 
+		// staticExcludedWorkers are the host keys of workers which the pdc
+		// doesn't launch. It is used by hedged downloads to fetch the data
+		// from a different set of workers.
+		staticExcludedWorkers map[string]struct{}
+
 		// pricePerMS is the amount of money we are willing to spend on faster
 		// workers. If a certain set of workers is 100ms faster, but that
 		// exceeds the pricePerMS we are willing to pay for it, we won't use
@@ -79,6 +95,17 @@ type (
 		// favor the faster and more expensive worker set.
 		pricePerMS types.Currency
 
+		// staticOverdrive are the overdrive settings of the download and
+		// latencyTargetOverdriven indicates whether the latency-target
+		// strategy already launched its overdrive worker.
+		staticOverdrive         skymodules.OverdriveSettings
+		latencyTargetOverdriven bool
+
+		// expectedCost is the cumulative expected cost of the workers launched
+		// so far. Overdrive workers are not launched if that would push it
+		// above the max cost of the overdrive settings.
+		expectedCost types.Currency
+
 		// availablePieces are pieces that resolved workers think they can
 		// fetch.
 		//
@@ -96,6 +123,11 @@ type (
 		workersConsideredIndex     int
 		unresolvedWorkersRemaining int
 
+		// overdriveCandidates indexes the workers which might be launched as
+		// overdrive workers. It is created when the available pieces are
+		// updated for the first time.
+		overdriveCandidates *overdriveCandidates
+
 		// dataPieces is the buffer that is used to place data as it comes back.
 		// There is one piece per chunk, and pieces can be nil. To know if the
 		// download is complete, the number of non-nil pieces will be counted.
@@ -130,6 +162,11 @@ type (
 		// jobErr will contain the error in case it failed.
 		jobErr error
 
+		// bandwidthUp and bandwidthDown are the number of bytes the worker
+		// sent to and received from the host to execute the job.
+		bandwidthUp   uint64
+		bandwidthDown uint64
+
 		// totalDuration is the total amount of time it took for the worker to
 		// complete the download since it was launched, or the time it took to
 		// fail.
@@ -155,6 +192,10 @@ type (
 		// `availablePieces` array on the PDC.
 		staticPieceIndex uint64
 
+		// staticAttempt is the number of the attempt to download the piece
+		// which is made by the worker.
+		staticAttempt int
+
 		staticPDC    *projectDownloadChunk
 		staticWorker *worker
 	}
@@ -171,6 +212,16 @@ type (
 		// and avoid another large allocation.
 		externLogicalChunkData [][]byte
 
+		// provenance contains the hosts which successfully served the pieces
+		// of the chunk.
+		provenance []skymodules.PieceProvenance
+
+		// bandwidthUp and bandwidthDown are the number of bytes which were
+		// sent to and received from the hosts by all launched workers,
+		// including the overdrive workers and the ones that failed.
+		bandwidthUp   uint64
+		bandwidthDown uint64
+
 		// launchedWorkers contains a list of worker information for the workers
 		// that were launched to try and complete this download. This field can
 		// be used for debugging purposes should the download time out or error
@@ -196,7 +247,7 @@ func (lwi *launchedWorkerInfo) String() string {
 	if lwi.completeTime.IsZero() {
 		duration := time.Since(lwi.staticLaunchTime).Milliseconds()
 
-		return fmt.Sprintf("%v | %v | piece %v | estimated complete %v ms | not responded after %vms", pdcId, wDescr, lwi.staticPieceIndex, estimate, duration)
+		return fmt.Sprintf("%v | %v | piece %v | attempt %v | estimated complete %v ms | not responded after %vms", pdcId, wDescr, lwi.staticPieceIndex, lwi.staticAttempt, estimate, duration)
 	}
 
 	// if download is complete
@@ -210,7 +261,7 @@ func (lwi *launchedWorkerInfo) String() string {
 	totalDur := lwi.totalDuration.Milliseconds()
 	jobDur := lwi.jobDuration.Milliseconds()
 
-	return fmt.Sprintf("%v | %v | piece %v | estimated complete %v ms | responded after %vms | read job took %vms | %v", pdcId, wDescr, lwi.staticPieceIndex, estimate, totalDur, jobDur, jDescr)
+	return fmt.Sprintf("%v | %v | piece %v | attempt %v | estimated complete %v ms | responded after %vms | read job took %vms | %v", pdcId, wDescr, lwi.staticPieceIndex, lwi.staticAttempt, estimate, totalDur, jobDur, jDescr)
 }
 
 // successful is a small helper method that returns whether the piece was
@@ -219,6 +270,45 @@ func (pd *pieceDownload) successful() bool {
 	return pd.completed && pd.downloadErr == nil
 }
 
+// running is a small helper method that returns whether the piece download was
+// launched and neither completed nor failed yet.
+func (pd *pieceDownload) running() bool {
+	return pd.launched && !pd.completed && pd.downloadErr == nil
+}
+
+// pieceAttempts returns the number of attempts which were made to download the
+// piece with the given index and the error of the latest failed attempt.
+func (pdc *projectDownloadChunk) pieceAttempts(pieceIndex uint64) (attempts int, lastErr error) {
+	latestFailed := 0
+	for _, pd := range pdc.availablePieces[pieceIndex] {
+		if !pd.launched {
+			continue
+		}
+		attempts++
+		if pd.downloadErr != nil && pd.attempt > latestFailed {
+			latestFailed = pd.attempt
+			lastErr = pd.downloadErr
+		}
+	}
+	return
+}
+
+// reestimateLateAttempts updates the expected complete time of the running
+// attempts of the piece with the given index which are late. This is called
+// when the piece is retried on another worker, so that the original estimates
+// of the late attempts neither trigger more overdrive workers nor cause the
+// piece to be considered late once the retry is running. A late attempt is
+// expected to take twice as long as it is late already.
+func (pdc *projectDownloadChunk) reestimateLateAttempts(pieceIndex uint64, retry *pieceDownload) {
+	now := time.Now()
+	for _, pd := range pdc.availablePieces[pieceIndex] {
+		if pd == retry || !pd.running() || pd.expectedCompleteTime.After(now) {
+			continue
+		}
+		pd.expectedCompleteTime = now.Add(2 * now.Sub(pd.expectedCompleteTime))
+	}
+}
+
 // updateWorkerHeap updates a worker heap by going through all workers and
 // updating them depending on whether they are resolved or not. This does not
 // apply any gouging or maintenance checks again since we don't expect a
@@ -287,26 +377,55 @@ func (pdc *projectDownloadChunk) updateWorkerHeap(h *pdcWorkerHeap) {
 }
 
 // updateAvailablePieces adds any new resolved workers to the pdc's list of
-// available pieces.
+// available pieces and updates the overdrive candidates accordingly.
 func (pdc *projectDownloadChunk) updateAvailablePieces() {
 	ws := pdc.workerState
 
+	// Index the unresolved workers as overdrive candidates on the first
+	// update. The workers which resolved already are added below.
+	if pdc.overdriveCandidates == nil {
+		pdc.overdriveCandidates = newOverdriveCandidates()
+		for _, uw := range ws.unresolvedWorkers {
+			if !pdc.isExcluded(uw.staticWorker) {
+				pdc.addUnresolvedOverdriveCandidate(uw)
+			}
+		}
+	}
+
 	// Add any new resolved workers to the pdc's list of available pieces.
 	for i := pdc.workersConsideredIndex; i < len(ws.resolvedWorkers); i++ {
 		// Add the returned worker to available pieces for each piece that the
 		// resolved worker has.
 		resp := ws.resolvedWorkers[i]
+		if pdc.isExcluded(resp.worker) {
+			continue
+		}
 		hpk := resp.worker.staticHostPubKeyStr
+		pieces := make([]overdrivePiece, 0, len(resp.pieceIndices))
 		for _, pieceIndex := range resp.pieceIndices {
 			pd := &pieceDownload{
 				worker: resp.worker,
 			}
 			pdc.availablePieces[pieceIndex] = append(pdc.availablePieces[pieceIndex], pd)
+			pieces = append(pieces, overdrivePiece{index: pieceIndex, pieceDownload: pd})
 		}
 		pdc.availablePiecesByWorker[hpk] = resp.pieceIndices
+		pdc.addResolvedOverdriveCandidate(resp.worker, pieces)
 	}
 	pdc.workersConsideredIndex = len(ws.resolvedWorkers)
-	pdc.unresolvedWorkersRemaining = len(ws.unresolvedWorkers)
+	pdc.unresolvedWorkersRemaining = 0
+	for _, uw := range ws.unresolvedWorkers {
+		if !pdc.isExcluded(uw.staticWorker) {
+			pdc.unresolvedWorkersRemaining++
+		}
+	}
+}
+
+// isExcluded returns whether the pdc is not allowed to launch the given
+// worker.
+func (pdc *projectDownloadChunk) isExcluded(w *worker) bool {
+	_, excluded := pdc.staticExcludedWorkers[w.staticHostPubKeyStr]
+	return excluded
 }
 
 // managedUnresolvedWorkers will return the set of unresolved workers from the
@@ -323,6 +442,9 @@ func (pdc *projectDownloadChunk) managedUnresolvedWorkers() ([]*pcwsUnresolvedWo
 
 	var unresolvedWorkers []*pcwsUnresolvedWorker
 	for _, uw := range ws.unresolvedWorkers {
+		if pdc.isExcluded(uw.staticWorker) {
+			continue
+		}
 		unresolvedWorkers = append(unresolvedWorkers, uw)
 	}
 
@@ -334,8 +456,27 @@ func (pdc *projectDownloadChunk) managedUnresolvedWorkers() ([]*pcwsUnresolvedWo
 	return unresolvedWorkers, ws.registerForWorkerUpdate()
 }
 
+// managedUpdateOverdriveCandidates updates the set of available pieces and the
+// overdrive candidates of the pdc to reflect any previously unresolved workers
+// that are now available workers.
+//
+// A channel will also be returned which will be closed when there are new
+// unresolved workers available.
+func (pdc *projectDownloadChunk) managedUpdateOverdriveCandidates() <-chan struct{} {
+	ws := pdc.workerState
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	pdc.updateAvailablePieces()
+	return ws.registerForWorkerUpdate()
+}
+
 // handleJobReadResponse will take a jobReadResponse from a worker job
 // and integrate it into the set of pieces.
+//
+// The piece is verified against the piece root of the chunk before it is
+// used. If the host served a corrupt piece, the host is penalized and only the
+// piece download is marked as failed, so that overdrive can launch another
+// worker for the piece.
 func (pdc *projectDownloadChunk) handleJobReadResponse(jrr *jobReadResponse) {
 	// Prevent a production panic.
 	if jrr == nil {
@@ -354,23 +495,33 @@ func (pdc *projectDownloadChunk) handleJobReadResponse(jrr *jobReadResponse) {
 	launchedWorker.completeTime = time.Now()
 	launchedWorker.jobDuration = jrr.staticJobTime
 	launchedWorker.jobErr = jrr.staticErr
+	launchedWorker.bandwidthUp = jrr.staticBandwidthUp
+	launchedWorker.bandwidthDown = jrr.staticBandwidthDown
 	launchedWorker.totalDuration = time.Since(launchedWorker.staticLaunchTime)
 
+	// The read job updated the worker's expected job time, so its position
+	// among the overdrive candidates might have changed.
+	pdc.refreshOverdriveCandidate(worker)
+
 	// Check whether the job failed.
 	if jrr.staticErr != nil {
 		// The download failed, update the pdc available pieces to reflect the
 		// failure.
-		pieceFound := false
-		for i := 0; i < len(pdc.availablePieces[pieceIndex]); i++ {
-			if pdc.availablePieces[pieceIndex][i].worker.staticHostPubKeyStr == worker.staticHostPubKeyStr {
-				if pieceFound {
-					build.Critical("The list of available pieces contains duplicates.") // sanity check
-				}
-				pieceFound = true
-				pdc.availablePieces[pieceIndex][i].completed = true
-				pdc.availablePieces[pieceIndex][i].downloadErr = jrr.staticErr
-			}
-		}
+		pdc.failPiece(pieceIndex, worker, jrr.staticErr)
+		return
+	}
+
+	// Verify the piece against the piece root of the chunk. The worker
+	// doesn't verify the proof for project downloads, so a corrupt piece is
+	// detected before it is decrypted and used to recover the chunk.
+	proofStart := int(pdc.pieceOffset / crypto.SegmentSize)
+	proofEnd := int((pdc.pieceOffset + pdc.pieceLength) / crypto.SegmentSize)
+	root := pdc.workerSet.staticPieceRoots[pieceIndex]
+	if !crypto.VerifyRangeProof(jrr.staticData, jrr.staticProof, proofStart, proofEnd, root) {
+		err := errors.AddContext(errSectorCorrupted, fmt.Sprintf("piece %v of chunk %v failed proof verification", pieceIndex, pdc.workerSet.staticChunkIndex))
+		launchedWorker.jobErr = err
+		pdc.failPiece(pieceIndex, worker, err)
+		worker.managedReportCorruption(err)
 		return
 	}
 
@@ -398,6 +549,21 @@ func (pdc *projectDownloadChunk) handleJobReadResponse(jrr *jobReadResponse) {
 	}
 }
 
+// failPiece marks the download of the piece by the given worker as failed.
+func (pdc *projectDownloadChunk) failPiece(pieceIndex uint64, w *worker, err error) {
+	pieceFound := false
+	for i := 0; i < len(pdc.availablePieces[pieceIndex]); i++ {
+		if pdc.availablePieces[pieceIndex][i].worker.staticHostPubKeyStr == w.staticHostPubKeyStr {
+			if pieceFound {
+				build.Critical("The list of available pieces contains duplicates.") // sanity check
+			}
+			pieceFound = true
+			pdc.availablePieces[pieceIndex][i].completed = true
+			pdc.availablePieces[pieceIndex][i].downloadErr = err
+		}
+	}
+}
+
 // fail will send an error down the download response channel.
 func (pdc *projectDownloadChunk) fail(err error) {
 	// Log info and finish span.
@@ -408,8 +574,11 @@ func (pdc *projectDownloadChunk) fail(err error) {
 	}
 
 	// Create and return a response
+	up, down := pdc.bandwidth()
 	dr := &downloadResponse{
-		err: err,
+		err:           err,
+		bandwidthUp:   up,
+		bandwidthDown: down,
 
 		launchedWorkers: pdc.launchedWorkers,
 	}
@@ -421,17 +590,10 @@ func (pdc *projectDownloadChunk) recoverData() ([]byte, error) {
 	// Determine the amount of bytes the EC will need to skip from the recovered
 	// data when returning the data.
 	skipLength := pdc.offsetInChunk % (crypto.SegmentSize * uint64(pdc.workerSet.staticErasureCoder.MinPieces()))
-	recoveredBytes := uint64(pdc.lengthInChunk + skipLength)
-
-	// Create a skipwriter that ensures we're recovering at the offset
-	buf := bytes.NewBuffer(make([]byte, 0, recoveredBytes))
-	skipWriter := &skipWriter{
-		writer: buf,
-		skip:   int(skipLength),
-	}
 
-	// Recover the pieces in to a single byte slice.
-	err := pdc.workerSet.staticErasureCoder.Recover(pdc.dataPieces, recoveredBytes, skipWriter)
+	// Recover the requested range of the pieces in to a single byte slice.
+	buf := bytes.NewBuffer(make([]byte, 0, pdc.lengthInChunk))
+	err := pdc.workerSet.staticErasureCoder.RecoverRange(pdc.dataPieces, skipLength, pdc.lengthInChunk, buf)
 	if err != nil {
 		pdc.fail(errors.AddContext(err, "unable to complete erasure decode of download"))
 	}
@@ -474,16 +636,57 @@ func (pdc *projectDownloadChunk) finalize() {
 	}
 
 	// Return the data to the caller.
+	up, down := pdc.bandwidth()
 	dr := &downloadResponse{
 		data:                   data,
 		externLogicalChunkData: pdc.dataPieces,
 		err:                    err,
+		provenance:             pdc.provenance(),
+		bandwidthUp:            up,
+		bandwidthDown:          down,
 
 		launchedWorkers: pdc.launchedWorkers,
 	}
 	pdc.downloadResponseChan <- dr
 }
 
+// provenance returns the hosts which successfully downloaded a piece of the
+// chunk.
+func (pdc *projectDownloadChunk) provenance() []skymodules.PieceProvenance {
+	var provenance []skymodules.PieceProvenance
+	for pieceIndex, pieceDownloads := range pdc.availablePieces {
+		for _, pd := range pieceDownloads {
+			if !pd.successful() {
+				continue
+			}
+			provenance = append(provenance, skymodules.PieceProvenance{
+				PieceIndex: uint64(pieceIndex),
+				HostKey:    pd.worker.staticHostPubKey,
+				Attempts:   uint64(pd.attempt),
+			})
+		}
+	}
+	return provenance
+}
+
+// bandwidth returns the number of bytes the launched workers sent to and
+// received from the hosts. Workers which haven't returned yet will still
+// consume bandwidth after the response was sent, so their expected bandwidth
+// is used instead.
+func (pdc *projectDownloadChunk) bandwidth() (up, down uint64) {
+	for _, lw := range pdc.launchedWorkers {
+		if lw.completeTime.IsZero() {
+			ul, dl := readSectorJobExpectedBandwidth(pdc.pieceLength)
+			up += ul
+			down += dl
+			continue
+		}
+		up += lw.bandwidthUp
+		down += lw.bandwidthDown
+	}
+	return
+}
+
 // finished returns true if the download is finished, and returns an error if
 // the download is unable to complete.
 func (pdc *projectDownloadChunk) finished() (bool, error) {
@@ -494,6 +697,7 @@ func (pdc *projectDownloadChunk) finished() (bool, error) {
 	// potential downloads.
 	completedPieces := 0
 	hopefulPieces := 0
+	capped := false
 	for _, piece := range pdc.availablePieces {
 		// Only count one piece as hopeful per set.
 		hopeful := false
@@ -505,6 +709,12 @@ func (pdc *projectDownloadChunk) finished() (bool, error) {
 				completedPieces++
 				break
 			}
+			// A worker that hasn't launched yet and can't be launched within
+			// the max cost is not hopeful.
+			if !pieceDownload.launched && !pdc.withinMaxCost(pieceDownload.worker) {
+				capped = true
+				continue
+			}
 			// If this piece has not yet failed, it is hopeful. Keep looking
 			// through the pieces in case there is a piece that was downloaded
 			// successfully.
@@ -526,12 +736,25 @@ func (pdc *projectDownloadChunk) finished() (bool, error) {
 
 	// Ensure that there are enough pieces that could potentially become
 	// completed to finish the download.
+	if hopefulPieces < ec.MinPieces() && capped {
+		return false, errors.AddContext(skymodules.ErrDownloadCostCapExceeded, fmt.Sprintf("%v with a max cost of %v", errNotEnoughPieces, pdc.staticOverdrive.MaxCost.HumanString()))
+	}
 	if hopefulPieces < ec.MinPieces() {
 		return false, errNotEnoughPieces
 	}
 	return false, nil
 }
 
+// withinMaxCost returns whether launching the given worker keeps the expected
+// cost of the download within the max cost of the overdrive settings.
+func (pdc *projectDownloadChunk) withinMaxCost(w *worker) bool {
+	if pdc.staticOverdrive.MaxCost.IsZero() {
+		return true
+	}
+	cost := w.callReadQueue(pdc.staticIsLowPrio).callExpectedJobCost(pdc.pieceLength)
+	return pdc.expectedCost.Add(cost).Cmp(pdc.staticOverdrive.MaxCost) <= 0
+}
+
 // launchWorker will launch a worker and update the corresponding available
 // piece.
 //
@@ -545,12 +768,20 @@ func (pdc *projectDownloadChunk) launchWorker(w *worker, pieceIndex uint64, isOv
 		build.Critical("pieceOffset or pieceLength is not segment aligned")
 	}
 
+	// Determine which attempt of the piece is made by the worker.
+	attempts, lastErr := pdc.pieceAttempts(pieceIndex)
+	attempt := attempts + 1
+
 	// Log the event.
 	if span := opentracing.SpanFromContext(pdc.ctx); span != nil {
 		span.LogKV(
 			"launchWorker", w.staticHostPubKeyStr,
 			"overdriveWorker", isOverdrive,
+			"attempt", attempt,
 		)
+		if lastErr != nil {
+			span.LogKV("lastAttemptErr", lastErr)
+		}
 	}
 
 	// Create the read job metadata.
@@ -562,6 +793,8 @@ func (pdc *projectDownloadChunk) launchWorker(w *worker, pieceIndex uint64, isOv
 		staticSpendingCategory:    categoryDownload,
 		staticPieceRootIndex:      pieceIndex,
 		staticLaunchedWorkerIndex: launchedWorkerIndex,
+
+		staticSkipProofVerification: true,
 	}
 
 	// Create the read sector job for the worker.
@@ -569,12 +802,15 @@ func (pdc *projectDownloadChunk) launchWorker(w *worker, pieceIndex uint64, isOv
 	jrs := w.newJobReadSector(pdc.ctx, jrq, pdc.workerResponseChan, jobMetadata, sectorRoot, pdc.pieceOffset, pdc.pieceLength)
 
 	// Submit the job.
-	expectedCompleteTime, added := jrq.callAddWithEstimate(jrs)
+	expectedCompleteTime, err := jrq.callAddWithEstimate(jrs)
+	added := err == nil
 
-	// Track the launched worker
+	// Track the launched worker and its expected cost.
 	if added {
+		pdc.expectedCost = pdc.expectedCost.Add(jrq.callExpectedJobCost(pdc.pieceLength))
 		pdc.launchedWorkers = append(pdc.launchedWorkers, &launchedWorkerInfo{
 			staticPieceIndex:        pieceIndex,
+			staticAttempt:           attempt,
 			staticIsOverdriveWorker: isOverdrive,
 
 			staticLaunchTime:           time.Now(),
@@ -595,14 +831,22 @@ func (pdc *projectDownloadChunk) launchWorker(w *worker, pieceIndex uint64, isOv
 	// match. If all is going well, each worker should appear at most once
 	// in this piece, but for the sake of defensive programming we check all
 	// elements anyway.
+	//
+	// If the piece is retried, the estimates of the earlier attempts which
+	// are late are updated.
 	for _, pieceDownload := range pdc.availablePieces[pieceIndex] {
 		if w.staticHostPubKeyStr == pieceDownload.worker.staticHostPubKeyStr {
 			pieceDownload.launched = true
+			pieceDownload.attempt = attempt
+			pieceDownload.lastErr = lastErr
 			if added {
 				pieceDownload.expectedCompleteTime = expectedCompleteTime
+				if attempt > 1 {
+					pdc.reestimateLateAttempts(pieceIndex, pieceDownload)
+				}
 			} else {
 				pieceDownload.completed = true
-				pieceDownload.downloadErr = errors.New("unable to add piece to queue")
+				pieceDownload.downloadErr = errors.AddContext(err, "unable to add piece to queue")
 			}
 		}
 	}
@@ -4,14 +4,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
-	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
 	"gitlab.com/SkynetLabs/skyd/skymodules"
 	"go.sia.tech/siad/crypto"
@@ -278,6 +279,7 @@ func TestProjectDownloadChunk_handleJobResponse(t *testing.T) {
 	pdc := new(projectDownloadChunk)
 	pdc.workerSet = pcws
 	pdc.workerSet.staticChunkIndex = 0
+	pdc.pieceLength = uint64(len(pieces[3]))
 	pdc.dataPieces = make([][]byte, ec.NumPieces())
 	pdc.availablePieces = [][]*pieceDownload{
 		{{launched: true, worker: w}},
@@ -293,10 +295,15 @@ func TestProjectDownloadChunk_handleJobResponse(t *testing.T) {
 	pdc.launchedWorkers = []*launchedWorkerInfo{&lwi}
 
 	// verify the pdc after a successful read response for piece at index 3
+	numSegments := len(pieces[3]) / crypto.SegmentSize
 	success := &jobReadResponse{
 		staticData:    pieces[3],
+		staticProof:   crypto.MerkleRangeProof(pieces[3], 0, numSegments),
 		staticErr:     nil,
 		staticJobTime: time.Duration(1),
+
+		staticBandwidthUp:   10,
+		staticBandwidthDown: 100,
 		staticMetadata: jobReadMetadata{
 			staticLaunchedWorkerIndex: 0,
 			staticPieceRootIndex:      3,
@@ -322,7 +329,9 @@ func TestProjectDownloadChunk_handleJobResponse(t *testing.T) {
 	if lwi.completeTime == (time.Time{}) ||
 		lwi.jobDuration == 0 ||
 		lwi.totalDuration == 0 ||
-		lwi.jobErr != nil {
+		lwi.jobErr != nil ||
+		lwi.bandwidthUp != 10 ||
+		lwi.bandwidthDown != 100 {
 		t.Fatal("unexpected")
 	}
 
@@ -362,6 +371,7 @@ func TestProjectDownloadChunk_handleJobResponse(t *testing.T) {
 		pdc.availablePieces[3],
 		&pieceDownload{launched: true, worker: w},
 	)
+	success.staticData = pieces[3]
 	defer func() {
 		if r := recover(); r == nil {
 			t.Fatal("Expected build.Critical", r)
@@ -370,6 +380,114 @@ func TestProjectDownloadChunk_handleJobResponse(t *testing.T) {
 	pdc.handleJobReadResponse(success)
 }
 
+// TestProjectDownloadChunk_handleJobResponseCorrupt verifies that a corrupt
+// piece only fails the piece download and penalizes the worker that served it.
+func TestProjectDownloadChunk_handleJobResponseCorrupt(t *testing.T) {
+	t.Parallel()
+
+	ec := skymodules.NewRSSubCodeDefault()
+	ptck, err := crypto.NewSiaKey(crypto.TypePlain, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pieces, err := ec.Encode(fastrand.Bytes(int(modules.SectorSize)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	renter := new(Renter)
+	renter.staticLog, err = persist.NewLogger(ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := new(worker)
+	w.staticHostPubKeyStr = "w"
+	w.staticRenter = renter
+	w.initJobReadQueue(nil)
+	w.initJobLowPrioReadQueue(nil)
+
+	pcws := new(projectChunkWorkerSet)
+	pcws.staticMasterKey = ptck
+	pcws.staticErasureCoder = ec
+	pcws.staticPieceRoots = []crypto.Hash{crypto.MerkleRoot(pieces[0])}
+	pcws.staticRenter = renter
+
+	pdc := new(projectDownloadChunk)
+	pdc.workerSet = pcws
+	pdc.pieceLength = uint64(len(pieces[0]))
+	pdc.dataPieces = make([][]byte, ec.NumPieces())
+	pdc.availablePieces = [][]*pieceDownload{{{launched: true, worker: w}}}
+	pdc.launchedWorkers = []*launchedWorkerInfo{{staticLaunchTime: time.Now()}}
+	pdc.unresolvedWorkersRemaining = ec.MinPieces()
+
+	// Serve a piece with a flipped byte.
+	corrupt := append([]byte{}, pieces[0]...)
+	corrupt[0]++
+	pdc.handleJobReadResponse(&jobReadResponse{
+		staticData:  corrupt,
+		staticProof: crypto.MerkleRangeProof(pieces[0], 0, len(pieces[0])/crypto.SegmentSize),
+		staticMetadata: jobReadMetadata{
+			staticPieceRootIndex: 0,
+			staticWorker:         w,
+		},
+	})
+
+	// The download shouldn't be aborted since other workers can still
+	// provide the missing pieces.
+	completed, err := pdc.finished()
+	if completed || err != nil {
+		t.Fatal("download should still be in progress", completed, err)
+	}
+
+	// The piece shouldn't be used and the piece download should be failed.
+	if pdc.dataPieces[0] != nil {
+		t.Fatal("corrupt piece was used")
+	}
+	if pd := pdc.availablePieces[0][0]; !pd.completed || !errors.Contains(pd.downloadErr, errSectorCorrupted) {
+		t.Fatal("piece download wasn't failed", pd.downloadErr)
+	}
+	if !errors.Contains(pdc.launchedWorkers[0].jobErr, errSectorCorrupted) {
+		t.Fatal("launched worker info wasn't updated", pdc.launchedWorkers[0].jobErr)
+	}
+
+	// The worker should be penalized.
+	if corrupt := atomic.LoadUint64(&w.atomicCorruptPieces); corrupt != 1 {
+		t.Fatal("wrong number of corrupt pieces", corrupt)
+	}
+	for _, jq := range []*jobReadQueue{w.staticJobReadQueue, w.staticJobLowPrioReadQueue} {
+		status := jq.callStatus()
+		if !jq.callOnCooldown() || status.consecutiveFailures != cooldownMaxConsecutiveFailures || status.breakerState != circuitBreakerStateOpen {
+			t.Fatal("worker wasn't penalized", status)
+		}
+	}
+}
+
+// TestProjectDownloadChunk_bandwidth is a unit test for the bandwidth
+// function on the pdc.
+func TestProjectDownloadChunk_bandwidth(t *testing.T) {
+	t.Parallel()
+
+	pdc := new(projectDownloadChunk)
+	pdc.pieceLength = 1 << 16
+
+	// A worker that completed and an overdrive worker that failed.
+	pdc.launchedWorkers = []*launchedWorkerInfo{
+		{completeTime: time.Now(), bandwidthUp: 10, bandwidthDown: 100},
+		{completeTime: time.Now(), bandwidthUp: 20, bandwidthDown: 200, staticIsOverdriveWorker: true},
+	}
+	if up, down := pdc.bandwidth(); up != 30 || down != 300 {
+		t.Fatal("unexpected bandwidth", up, down)
+	}
+
+	// A worker that is still in flight is counted using its expected
+	// bandwidth.
+	pdc.launchedWorkers = append(pdc.launchedWorkers, &launchedWorkerInfo{staticIsOverdriveWorker: true})
+	ul, dl := readSectorJobExpectedBandwidth(pdc.pieceLength)
+	if up, down := pdc.bandwidth(); up != 30+ul || down != 300+dl {
+		t.Fatal("unexpected bandwidth", up, down)
+	}
+}
+
 // TestProjectDownloadChunk_launchWorker is a unit test for the 'launchWorker'
 // function on the pdc.
 func TestProjectDownloadChunk_launchWorker(t *testing.T) {
@@ -468,6 +586,132 @@ func TestProjectDownloadChunk_launchWorker(t *testing.T) {
 	}
 }
 
+// TestProjectDownloadChunk_launchWorkerDeadline verifies that a worker isn't
+// launched if its job isn't expected to complete before the deadline of the
+// download.
+func TestProjectDownloadChunk_launchWorkerDeadline(t *testing.T) {
+	t.Parallel()
+
+	ec := skymodules.NewRSCodeDefault()
+
+	// mock a worker which takes 100ms to complete a job
+	worker := mockWorker(100 * time.Millisecond)
+
+	// mock a pdc with a deadline which is too close for the worker
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	pdc := new(projectDownloadChunk)
+	pdc.ctx = ctx
+	pdc.workerSet = new(projectChunkWorkerSet)
+	pdc.workerSet.staticPieceRoots = make([]crypto.Hash, ec.NumPieces())
+	pdc.pieceLength = 1 << 16 // 64kb
+	pdc.availablePieces = make([][]*pieceDownload, ec.NumPieces())
+	for pieceIndex := range pdc.availablePieces {
+		pdc.availablePieces[pieceIndex] = append(pdc.availablePieces[pieceIndex], &pieceDownload{
+			worker: worker,
+		})
+	}
+
+	// the job is rejected and the piece is marked as failed
+	_, added := pdc.launchWorker(worker, 0, false)
+	if added {
+		t.Fatal("worker shouldn't be launched")
+	}
+	if len(pdc.launchedWorkers) != 0 || worker.staticJobReadQueue.callLen() != 0 {
+		t.Fatal("unexpected")
+	}
+	pd := pdc.availablePieces[0][0]
+	if !pd.launched || !pd.completed || !errors.Contains(pd.downloadErr, errEstimateAboveDeadline) {
+		t.Fatal("unexpected", pd.downloadErr)
+	}
+
+	// with a later deadline the job is added
+	ctx2, cancel2 := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel2()
+	pdc.ctx = ctx2
+	if _, added := pdc.launchWorker(worker, 1, false); !added {
+		t.Fatal("worker should be launched")
+	}
+}
+
+// TestProjectDownloadChunk_launchWorkerRetry is a unit test that verifies the
+// attempts of a piece are tracked when it is retried on other workers and that
+// late attempts are re-estimated.
+func TestProjectDownloadChunk_launchWorkerRetry(t *testing.T) {
+	t.Parallel()
+
+	ec := skymodules.NewRSCodeDefault()
+
+	// mock three workers which can all download the first piece
+	workers := make([]*worker, 3)
+	for i := range workers {
+		workers[i] = mockWorker(100 * time.Millisecond)
+		workers[i].staticHostPubKey = types.SiaPublicKey{
+			Algorithm: types.SignatureEd25519,
+			Key:       fastrand.Bytes(crypto.PublicKeySize),
+		}
+		workers[i].staticHostPubKeyStr = workers[i].staticHostPubKey.String()
+	}
+	pdc := new(projectDownloadChunk)
+	pdc.ctx = context.Background()
+	pdc.workerSet = &projectChunkWorkerSet{staticErasureCoder: ec}
+	pdc.workerSet.staticPieceRoots = make([]crypto.Hash, ec.NumPieces())
+	pdc.pieceLength = 1 << 16 // 64kb
+	pdc.availablePieces = make([][]*pieceDownload, ec.NumPieces())
+	for _, w := range workers {
+		pdc.availablePieces[0] = append(pdc.availablePieces[0], &pieceDownload{
+			worker: w,
+		})
+	}
+	pd1, pd2, pd3 := pdc.availablePieces[0][0], pdc.availablePieces[0][1], pdc.availablePieces[0][2]
+
+	// the first launch makes the first attempt
+	if _, added := pdc.launchWorker(workers[0], 0, false); !added {
+		t.Fatal("worker should be launched")
+	}
+	if pd1.attempt != 1 || pd1.lastErr != nil || pdc.launchedWorkers[0].staticAttempt != 1 {
+		t.Fatal("unexpected", pd1.attempt, pd1.lastErr)
+	}
+
+	// the first attempt is late, retrying the piece re-estimates it
+	late := time.Now().Add(-time.Second)
+	pd1.expectedCompleteTime = late
+	if _, added := pdc.launchWorker(workers[1], 0, true); !added {
+		t.Fatal("worker should be launched")
+	}
+	if pd2.attempt != 2 || pd2.lastErr != nil || pdc.launchedWorkers[1].staticAttempt != 2 {
+		t.Fatal("unexpected", pd2.attempt, pd2.lastErr)
+	}
+	if !pd1.expectedCompleteTime.After(time.Now()) {
+		t.Fatal("late attempt wasn't re-estimated", pd1.expectedCompleteTime)
+	}
+
+	// the piece is expected to return with its earliest attempt
+	pd1.expectedCompleteTime = time.Now().Add(time.Hour)
+	_, latestReturn := pdc.managedOverdriveStatus()
+	if latestReturn != pd2.expectedCompleteTime {
+		t.Fatal("unexpected", latestReturn)
+	}
+
+	// the second attempt fails, the third one records its error
+	errFailed := errors.New("failed")
+	pd2.completed = true
+	pd2.downloadErr = errFailed
+	if _, added := pdc.launchWorker(workers[2], 0, true); !added {
+		t.Fatal("worker should be launched")
+	}
+	if pd3.attempt != 3 || pd3.lastErr != errFailed {
+		t.Fatal("unexpected", pd3.attempt, pd3.lastErr)
+	}
+
+	// the attempts are part of the provenance
+	pd3.completed = true
+	provenance := pdc.provenance()
+	if len(provenance) != 1 || provenance[0].Attempts != 3 || !provenance[0].HostKey.Equals(workers[2].staticHostPubKey) {
+		t.Fatal("unexpected", provenance)
+	}
+}
+
 // TestGetPieceOffsetAndLen is a unit test that probes the helper function
 // getPieceOffsetAndLength
 func TestGetPieceOffsetAndLen(t *testing.T) {
@@ -549,11 +793,7 @@ func TestGetPieceOffsetAndLenWithRecover(t *testing.T) {
 		}
 
 		buf := bytes.NewBuffer(nil)
-		skipWriter := &skipWriter{
-			writer: buf,
-			skip:   int(skipLength),
-		}
-		err = ec.Recover(sliced, length+uint64(skipLength), skipWriter)
+		err = ec.RecoverRange(sliced, skipLength, length, buf)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -606,6 +846,7 @@ func TestLaunchedWorkerInfo_String(t *testing.T) {
 
 	lwi := &launchedWorkerInfo{
 		staticPieceIndex:        1,
+		staticAttempt:           2,
 		staticIsOverdriveWorker: false,
 
 		staticLaunchTime:           time.Now().Add(-5 * time.Second),
@@ -618,7 +859,7 @@ func TestLaunchedWorkerInfo_String(t *testing.T) {
 
 	// assert output when download not complete
 	expectedWorkerInfo := "initial worker " + w.staticHostPubKey.ShortString()
-	expectedPieceInfo := "piece 1"
+	expectedPieceInfo := "piece 1 | attempt 2"
 	expectedEstInfo := "estimated complete 10000 ms"
 	expectedDurInfo := "not responded after 5000ms"
 	if !strings.Contains(lwi.String(), expectedWorkerInfo) ||
@@ -687,9 +928,13 @@ func (mec *mockErasureCoder) Identifier() skymodules.ErasureCoderIdentifier {
 	return skymodules.ErasureCoderIdentifier("mock")
 }
 func (mec *mockErasureCoder) EncodeShards(data [][]byte) ([][]byte, error)         { return nil, nil }
+func (mec *mockErasureCoder) EncodeDataShards(data [][]byte) ([][]byte, error)     { return nil, nil }
 func (mec *mockErasureCoder) Reconstruct(pieces [][]byte) error                    { return nil }
 func (mec *mockErasureCoder) Recover(pieces [][]byte, n uint64, w io.Writer) error { return nil }
-func (mec *mockErasureCoder) SupportsPartialEncoding() (uint64, bool)              { return 0, true }
+func (mec *mockErasureCoder) RecoverRange(pieces [][]byte, offset, length uint64, w io.Writer) error {
+	return nil
+}
+func (mec *mockErasureCoder) SupportsPartialEncoding() (uint64, bool) { return 0, true }
 func (mec *mockErasureCoder) Type() skymodules.ErasureCoderType {
 	return skymodules.ErasureCoderType{9, 9, 9, 9}
 }
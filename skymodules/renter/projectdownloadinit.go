@@ -515,14 +515,10 @@ func (pdc *projectDownloadChunk) launchInitialWorkers() error {
 // by the project download are reasonable in relation to the user's allowance
 // and the amount of data they intend to download
 func checkProjectDownloadGouging(pt modules.RPCPriceTable, allowance skymodules.Allowance) error {
-	// Check whether the download bandwidth price is too high.
-	if !allowance.MaxDownloadBandwidthPrice.IsZero() && allowance.MaxDownloadBandwidthPrice.Cmp(pt.DownloadBandwidthCost) < 0 {
-		return fmt.Errorf("download bandwidth price of host is %v, which is above the maximum allowed by the allowance: %v - price gouging protection enabled", pt.DownloadBandwidthCost, allowance.MaxDownloadBandwidthPrice)
-	}
-
-	// Check whether the upload bandwidth price is too high.
-	if !allowance.MaxUploadBandwidthPrice.IsZero() && allowance.MaxUploadBandwidthPrice.Cmp(pt.UploadBandwidthCost) < 0 {
-		return fmt.Errorf("upload bandwidth price of host is %v, which is above the maximum allowed by the allowance: %v - price gouging protection enabled", pt.UploadBandwidthCost, allowance.MaxUploadBandwidthPrice)
+	// Check whether the bandwidth prices are too high.
+	report := skymodules.NewGougingChecker(allowance).CheckPriceTable(pt)
+	if err := report.Err(skymodules.GougingFieldDownloadBandwidthPrice, skymodules.GougingFieldUploadBandwidthPrice); err != nil {
+		return err
 	}
 
 	// If there is no allowance, price gouging checks have to be disabled,
@@ -1,10 +1,12 @@
 package renter
 
 import (
+	"context"
 	"math"
 	"time"
 
 	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
 	"go.sia.tech/siad/types"
 )
 
@@ -23,8 +25,44 @@ const (
 	// this is set to 12 as that would induce a minimum wait of over 4s, which
 	// is higher than the current maxExpBackoffDelayMS.
 	maxExpBackoffRetryCount = 12
+
+	// aggressiveOverdriveWorkers is the number of overdrive workers the
+	// aggressive overdrive strategy launches on top of the minimum number of
+	// pieces right away.
+	aggressiveOverdriveWorkers = 2
+
+	// conservativeOverdriveDelay is the amount of time the conservative
+	// overdrive strategy waits after the slowest worker is late before it
+	// launches an overdrive worker.
+	conservativeOverdriveDelay = 50 * time.Millisecond
 )
 
+// overdriveSettingsKey is the context key for the overdrive settings of a
+// download.
+type overdriveSettingsKey struct{}
+
+// contextWithOverdriveSettings returns a context that carries the given
+// overdrive settings.
+func contextWithOverdriveSettings(ctx context.Context, ods skymodules.OverdriveSettings) context.Context {
+	return context.WithValue(ctx, overdriveSettingsKey{}, ods)
+}
+
+// overdriveSettingsFromContext returns the overdrive settings attached to the
+// context. If there are none, the unspecified settings are returned.
+func overdriveSettingsFromContext(ctx context.Context) skymodules.OverdriveSettings {
+	ods, _ := ctx.Value(overdriveSettingsKey{}).(skymodules.OverdriveSettings)
+	return ods
+}
+
+// managedOverdriveSettings returns the given overdrive settings with the
+// unspecified fields set to the renter's defaults.
+func (r *Renter) managedOverdriveSettings(ods skymodules.OverdriveSettings) skymodules.OverdriveSettings {
+	id := r.mu.RLock()
+	defaults := r.persist.Overdrive
+	r.mu.RUnlock(id)
+	return ods.Merge(defaults)
+}
+
 // TODO: Better handling of time.After
 
 // TODO: The pricing mechanism for these overdrive workers is not optimal
@@ -58,80 +96,6 @@ func (pdc *projectDownloadChunk) adjustedReadDuration(w *worker) time.Duration {
 	return addCostPenalty(jobTime, jobCost, pdc.pricePerMS)
 }
 
-// bestOverdriveUnresolvedWorker will scan through a proveded list of unresolved
-// workers and find the best one to use as an overdrive worker.
-//
-// Four values are returned.
-//
-// The first indicates whether an unresolved worker was found that beats the
-// initial duration, which is pessimistically set to math.MaxInt64. Due to job
-// cost however an unresolved worker might not beat that value.
-//
-// The second signifies whether the best worker is late. If so, any worker that
-// is resolved should be preferred over any worker that is unresolved.
-//
-// The third return value is the unresolved duration. This is a modified
-// duration based on the combination of the amount of time until the worker has
-// completed its task plus the amount of time penalty the worker incurs for
-// being expensive.
-//
-// The final return value is a wait duration, which indicates how much time
-// needs to elapse before the best unresolved worker flips over into being a
-// late worker.
-func (pdc *projectDownloadChunk) bestOverdriveUnresolvedWorker(puws []*pcwsUnresolvedWorker) (exists, late bool, duration, waitDuration time.Duration, workerIndex int) {
-	// Set the duration and late status to the most pessimistic value.
-	exists = false
-	late = true
-	duration = time.Duration(math.MaxInt64)
-	waitDuration = time.Duration(math.MaxInt64)
-	workerIndex = -1
-
-	// Loop through the unresovled workers and find the best unresolved worker.
-	for i, uw := range puws {
-		// Figure how much time is expected to remain until the worker is
-		// available. Note that no price penalty is attached to the HasSector
-		// call, because that call is being made regardless of the cost.
-		uwLate := false
-		hasSectorTime := time.Until(uw.staticExpectedResolvedTime)
-		if hasSectorTime < 0 {
-			hasSectorTime = 0
-			uwLate = true
-		}
-		// Skip this worker if the best is not late but this worker is late.
-		if uwLate && !late {
-			continue
-		}
-
-		// Figure out how much time is expected until the worker completes the
-		// download job.
-		readTime := pdc.adjustedReadDuration(uw.staticWorker)
-
-		// Ensure we don't overflow
-		var adjustedTotalDuration time.Duration
-		if readTime > math.MaxInt64-hasSectorTime {
-			adjustedTotalDuration = math.MaxInt64
-		} else {
-			adjustedTotalDuration = hasSectorTime + readTime
-		}
-
-		// Compare the total time (including price preference) to the current
-		// best time. Workers that are not late get preference over workers that
-		// are late.
-		betterLateStatus := !uwLate && late
-		betterDuration := adjustedTotalDuration < duration
-		if betterLateStatus || betterDuration {
-			exists = true
-			duration = adjustedTotalDuration
-			workerIndex = i
-			if !uwLate {
-				waitDuration = hasSectorTime
-				late = false
-			}
-		}
-	}
-	return exists, late, duration, waitDuration, workerIndex
-}
-
 // managedFindBestOverdriveWorker will search for the best worker to contribute
 // to an overdrive. The selection criteria is to select a worker that is
 // expected to be the fastest. If the fastest worker is an unresolved worker,
@@ -145,6 +109,10 @@ func (pdc *projectDownloadChunk) bestOverdriveUnresolvedWorker(puws []*pcwsUnres
 // yet and there are no other options. There is no timer in that case, only
 // blocking on workersUpdatedChan.
 func (pdc *projectDownloadChunk) managedFindBestOverdriveWorker() (*worker, uint64, <-chan struct{}, <-chan time.Time) {
+	// Update the overdrive candidates with the workers which resolved since
+	// the last call.
+	updateChan := pdc.managedUpdateOverdriveCandidates()
+
 	// Find the best unresolved worker. The return values include an 'adjusted
 	// duration', which indicates how long the worker takes accounting for
 	// pricing, and the 'wait duration', which is the max amount of time that we
@@ -155,40 +123,14 @@ func (pdc *projectDownloadChunk) managedFindBestOverdriveWorker() (*worker, uint
 	// return them explicitly.
 	//
 	// buw = bestUnresolvedWorker
-	unresolvedWorkers, updateChan := pdc.managedUnresolvedWorkers()
-	buwExists, buwLate, buwAdjustedDuration, buwWaitDuration, _ := pdc.bestOverdriveUnresolvedWorker(unresolvedWorkers)
+	buwExists, buwLate, buwAdjustedDuration, buwWaitDuration, _ := pdc.bestUnresolvedOverdriveCandidate()
 
-	// Loop through the set of pieces to find the fastest worker that can be
-	// launched. Because this function is only called for overdrive workers, we
-	// can assume that any launched piece is already late.
+	// Find the fastest resolved worker that can be launched. Because this
+	// function is only called for overdrive workers, we can assume that any
+	// launched piece is already late.
 	//
 	// baw = bestAvailableWorker
-	bawAdjustedDuration := time.Duration(math.MaxInt64)
-	bawPieceIndex := 0
-	var baw *worker
-
-	for i, activePiece := range pdc.availablePieces {
-		for _, pieceDownload := range activePiece {
-			// Don't consider any workers from this piece if the piece is
-			// completed.
-			if pieceDownload.completed {
-				break
-			}
-
-			// Skip over failed pieces or pieces that have already launched.
-			if pieceDownload.downloadErr != nil || pieceDownload.launched {
-				continue
-			}
-
-			// Determine if this worker is better than any existing worker.
-			workerAdjustedDuration := pdc.adjustedReadDuration(pieceDownload.worker)
-			if workerAdjustedDuration < bawAdjustedDuration {
-				bawAdjustedDuration = workerAdjustedDuration
-				bawPieceIndex = i
-				baw = pieceDownload.worker
-			}
-		}
-	}
+	baw, bawPieceIndex, bawAdjustedDuration := pdc.bestResolvedOverdriveCandidate()
 
 	// Return nil if there are no workers that can be launched.
 	if !buwExists && baw == nil {
@@ -207,7 +149,7 @@ func (pdc *projectDownloadChunk) managedFindBestOverdriveWorker() (*worker, uint
 	}
 
 	// Return the baw.
-	return baw, uint64(bawPieceIndex), nil, nil
+	return baw, bawPieceIndex, nil, nil
 }
 
 // managedTryLaunchOverdriveWorker will attempt to launch an overdrive worker. A worker
@@ -248,28 +190,37 @@ func (pdc *projectDownloadChunk) managedTryLaunchOverdriveWorker() (bool, time.T
 }
 
 // managedOverdriveStatus will return the number of overdrive workers that need to be
-// launched, and the expected return time of the slowest worker that has already
-// launched a download task.
+// launched, and the time at which the overdrive status should be checked again.
+// Unless the strategy demands otherwise, that is the expected return time of
+// the slowest worker that has already launched a download task.
 func (pdc *projectDownloadChunk) managedOverdriveStatus() (int, time.Time) {
 	// Go through the pieces, determining how many pieces are launched without
-	// fail, and what the latest return time is of all the workers that have
-	// already been launched.
+	// fail, and what the latest return time is of all the pieces that have
+	// already been launched. If a piece was retried and multiple attempts are
+	// running, the piece is expected to return with its earliest attempt.
 	numLWF := 0 // LWF = launchedWithoutFail
 	var latestReturn time.Time
 	for _, piece := range pdc.availablePieces {
 		launchedWithoutFail := false
+		var pieceReturn time.Time
 		for _, pieceDownload := range piece {
 			if !pieceDownload.launched || pieceDownload.downloadErr != nil {
 				continue // skip
 			}
 			launchedWithoutFail = true
-			if !pieceDownload.completed && pieceDownload.expectedCompleteTime.After(latestReturn) {
-				latestReturn = pieceDownload.expectedCompleteTime
+			if pieceDownload.completed {
+				continue
+			}
+			if pieceReturn.IsZero() || pieceDownload.expectedCompleteTime.Before(pieceReturn) {
+				pieceReturn = pieceDownload.expectedCompleteTime
 			}
 		}
 		if launchedWithoutFail {
 			numLWF++
 		}
+		if pieceReturn.After(latestReturn) {
+			latestReturn = pieceReturn
+		}
 	}
 
 	// If there are not enough LWF workers to complete the download, return the
@@ -282,14 +233,40 @@ func (pdc *projectDownloadChunk) managedOverdriveStatus() (int, time.Time) {
 	// here or there will no longer hold back the download. This might be worth
 	// revisiting in the future when workers are more stable, it may not be
 	// necessary.
+	//
+	// The conservative strategy doesn't launch any extra workers and the
+	// aggressive strategy launches a fixed number of extra workers instead.
 	workersWanted := pdc.workerSet.staticErasureCoder.MinPieces()
-	workersWanted += workersWanted / 5
+	switch pdc.staticOverdrive.Strategy {
+	case skymodules.OverdriveStrategyConservative:
+	case skymodules.OverdriveStrategyAggressive:
+		workersWanted += aggressiveOverdriveWorkers
+	default:
+		workersWanted += workersWanted / 5
+	}
 	if numLWF < workersWanted {
 		return workersWanted - numLWF, latestReturn
 	}
 
+	// If the download is expected to exceed the latency target, launch a
+	// single overdrive worker once the target is reached.
+	if pdc.staticOverdrive.Strategy == skymodules.OverdriveStrategyLatencyTarget && !pdc.latencyTargetOverdriven {
+		target := pdc.launchTime.Add(pdc.staticOverdrive.LatencyTarget)
+		if latestReturn.After(target) {
+			if time.Now().After(target) {
+				pdc.latencyTargetOverdriven = true
+				return 1, latestReturn
+			}
+			return 0, target
+		}
+	}
+
 	// If the latest worker should have already completed its job, return that
-	// an overdrive worker should be launched.
+	// an overdrive worker should be launched. The conservative strategy waits
+	// a bit longer to give the worker a chance to finish.
+	if pdc.staticOverdrive.Strategy == skymodules.OverdriveStrategyConservative {
+		latestReturn = latestReturn.Add(conservativeOverdriveDelay)
+	}
 	if time.Now().After(latestReturn) {
 		return 1, latestReturn
 	}
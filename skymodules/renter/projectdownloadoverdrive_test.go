@@ -1,12 +1,16 @@
 package renter
 
 import (
+	"context"
 	"math"
+	"strings"
 	"testing"
 	"time"
 
+	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
 	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/types"
 )
 
@@ -145,6 +149,9 @@ func TestProjectDownloadChunk_findBestOverdriveWorker(t *testing.T) {
 	pdc := new(projectDownloadChunk)
 	pdc.pieceLength = 1 << 16
 	pdc.pricePerMS = types.SiacoinPrecision.MulFloat(1e-12) // pS
+	pdc.availablePieces = make([][]*pieceDownload, ec.NumPieces())
+	pdc.availablePiecesByWorker = make(map[string][]uint64)
+	pdc.dataPieces = make([][]byte, ec.NumPieces())
 	pdc.workerState = &pcwsWorkerState{
 		unresolvedWorkers: map[string]*pcwsUnresolvedWorker{
 			"w1": {
@@ -153,39 +160,45 @@ func TestProjectDownloadChunk_findBestOverdriveWorker(t *testing.T) {
 			}, // ~250ms total dur
 			"w2": {
 				staticWorker:               w2,
-				staticExpectedResolvedTime: now.Add(10 * time.Millisecond),
-			}, // ~110ms total dur
+				staticExpectedResolvedTime: now.Add(50 * time.Millisecond),
+			}, // ~150ms total dur
 		},
 	}
 
-	// basic case with no available cases should match the 1st case of
-	// 'TestProjectDownloadChunk_bestOverdriveUnresolvedWorker'
-	pdc.availablePieces = make([][]*pieceDownload, ec.NumPieces())
+	// resolve mocks a worker resolving with the given pieces
+	resolve := func(w *worker, pieces ...uint64) {
+		ws := pdc.workerState
+		delete(ws.unresolvedWorkers, w.staticHostPubKeyStr)
+		ws.resolvedWorkers = append(ws.resolvedWorkers, &pcwsWorkerResponse{
+			worker:       w,
+			pieceIndices: pieces,
+		})
+	}
 
 	// verify the pdc currently has no good overdrive worker yet, as there are
 	// no available pieces and thus no available workers
-	worker, _, _, _ := pdc.managedFindBestOverdriveWorker()
-	if worker != nil {
+	worker, _, updateChan, lateChan := pdc.managedFindBestOverdriveWorker()
+	if worker != nil || updateChan == nil || lateChan == nil {
 		t.Fatal("unexpected", worker)
 	}
+	if pdc.overdriveCandidates.best(overdriveRankUnresolved) != pdc.overdriveCandidates.candidate(w2) {
+		t.Fatal("expected w2 to be the best unresolved candidate")
+	}
 
-	// add an available piece for worker 1 for the 2nd piece
-	pdc.availablePieces = make([][]*pieceDownload, ec.NumPieces())
-	delete(pdc.workerState.unresolvedWorkers, "w1")
-	pdc.availablePieces[2] = append(pdc.availablePieces[2], &pieceDownload{
-		worker: w1,
-	})
-
-	// expect the worker to still be nil, because we have an unresolved worker
-	// that has a better estimate
+	// resolve worker 1 with the 2nd piece, expect the worker to still be nil,
+	// because we have an unresolved worker that has a better estimate
+	resolve(w1, 2)
 	worker, _, _, _ = pdc.managedFindBestOverdriveWorker()
 	if worker != nil {
 		t.Fatal("unexpected", worker)
 	}
+	if c := pdc.overdriveCandidates.candidate(w1); c == nil || c.rank != overdriveRankResolved {
+		t.Fatal("expected w1 to be a resolved candidate")
+	}
 
-	// tweak it so the unresolved becomes slower than the first worker, for
-	// which we have an available piece
-	pdc.workerState.unresolvedWorkers["w2"].staticExpectedResolvedTime = now.Add(200 * time.Millisecond)
+	// mock a cooldown on w2's jobread queue, that makes the unresolved worker
+	// slower than the first worker, for which we have an available piece
+	w2.staticJobReadQueue.cooldownUntil = time.Now().Add(time.Minute)
 	worker, pieceIndex, _, _ := pdc.managedFindBestOverdriveWorker()
 	if worker != w1 {
 		t.Fatal("unexpected", worker)
@@ -194,12 +207,10 @@ func TestProjectDownloadChunk_findBestOverdriveWorker(t *testing.T) {
 		t.Fatal("unexpected", pieceIndex)
 	}
 
-	// now do the same for worker 2, because w2 is faster it should now become
-	// the best available worker
-	delete(pdc.workerState.unresolvedWorkers, "w2")
-	pdc.availablePieces[1] = append(pdc.availablePieces[1], &pieceDownload{
-		worker: w2,
-	})
+	// now resolve worker 2 with the 1st piece, because w2 is faster it should
+	// now become the best available worker
+	w2.staticJobReadQueue.cooldownUntil = time.Time{}
+	resolve(w2, 1)
 	worker, pieceIndex, _, _ = pdc.managedFindBestOverdriveWorker()
 	if worker != w2 {
 		t.Fatal("unexpected", worker.staticHostPubKeyStr)
@@ -208,7 +219,7 @@ func TestProjectDownloadChunk_findBestOverdriveWorker(t *testing.T) {
 		t.Fatal("unexpected", pieceIndex)
 	}
 
-	// now mock a cooldown on w2's jobread queue, it should now favor w1
+	// now mock a cooldown on w2's jobread queue again, it should now favor w1
 	w2.staticJobReadQueue.cooldownUntil = time.Now().Add(time.Minute)
 	worker, pieceIndex, _, _ = pdc.managedFindBestOverdriveWorker()
 	if worker != w1 {
@@ -218,11 +229,10 @@ func TestProjectDownloadChunk_findBestOverdriveWorker(t *testing.T) {
 		t.Fatal("unexpected", pieceIndex)
 	}
 
-	// mock worker 1 completed downloading piece at index 2, seeing as piece at
-	// index 2 is complete, it should get skipped and worker 2 should now become
+	// mock the piece at index 2 being downloaded, seeing as piece at index 2
+	// is complete, worker 1 should get dropped and worker 2 should now become
 	// the most interesting overdrive working
-	pdc.availablePieces[2][0].completed = true
-
+	pdc.dataPieces[2] = fastrand.Bytes(int(pdc.pieceLength))
 	worker, pieceIndex, _, _ = pdc.managedFindBestOverdriveWorker()
 	if worker != w2 {
 		t.Fatal("unexpected", worker.staticHostPubKeyStr)
@@ -230,67 +240,109 @@ func TestProjectDownloadChunk_findBestOverdriveWorker(t *testing.T) {
 	if pieceIndex != 1 {
 		t.Fatal("unexpected", pieceIndex)
 	}
+	if pdc.overdriveCandidates.candidate(w1) != nil {
+		t.Fatal("w1 should have been removed")
+	}
+
+	// mock worker 2 being launched for piece 1, there are no more workers
+	// that can be launched
+	pdc.availablePieces[1][0].launched = true
+	worker, _, updateChan, lateChan = pdc.managedFindBestOverdriveWorker()
+	if worker != nil || updateChan != nil || lateChan != nil {
+		t.Fatal("unexpected", worker)
+	}
 }
 
-// TestProjectDownloadChunk_bestOverdriveUnresolvedWorker is a unit test for the
-// 'bestOverdriveUnresolvedWorker' function on the pdc
-func TestProjectDownloadChunk_bestOverdriveUnresolvedWorker(t *testing.T) {
+// TestProjectDownloadChunk_bestUnresolvedOverdriveCandidate is a unit test for
+// the 'bestUnresolvedOverdriveCandidate' function on the pdc
+func TestProjectDownloadChunk_bestUnresolvedOverdriveCandidate(t *testing.T) {
 	t.Parallel()
 
 	now := time.Now()
 	max := time.Duration(math.MaxInt64)
 
-	// mock a pdc
-	pdc := new(projectDownloadChunk)
-	pdc.pieceLength = 1 << 16
-	pdc.pricePerMS = types.SiacoinPrecision.MulFloat(1e-12) // pS
+	// newPDC mocks a pdc with the given unresolved workers
+	newPDC := func(uws ...*pcwsUnresolvedWorker) *projectDownloadChunk {
+		pdc := new(projectDownloadChunk)
+		pdc.pieceLength = 1 << 16
+		pdc.pricePerMS = types.SiacoinPrecision.MulFloat(1e-12) // pS
+		pdc.workerState = &pcwsWorkerState{
+			unresolvedWorkers: make(map[string]*pcwsUnresolvedWorker),
+		}
+		for _, uw := range uws {
+			pdc.workerState.unresolvedWorkers[uw.staticWorker.staticHostPubKeyStr] = uw
+		}
+		pdc.updateAvailablePieces()
+		return pdc
+	}
 
-	// verify return params for an empty array of unresolved workers
-	uws := []*pcwsUnresolvedWorker{}
-	exists, late, dur, waitDur, wIndex := pdc.bestOverdriveUnresolvedWorker(uws)
-	if exists || !late || dur != max || waitDur != max || wIndex != -1 {
+	// verify return params for an empty set of unresolved workers
+	pdc := newPDC()
+	exists, late, dur, waitDur, w := pdc.bestUnresolvedOverdriveCandidate()
+	if exists || !late || dur != max || waitDur != max || w != nil {
 		t.Fatal("unexpected")
 	}
 
 	// mock two workers with different traits
 	w1 := mockWorker(100 * time.Millisecond) // avg 100ms job time
+	w1.staticHostPubKeyStr = "w1"
 	w2 := mockWorker(200 * time.Millisecond) // avg 200ms job time
-	uws = []*pcwsUnresolvedWorker{
-		{
-			staticWorker:               w1,
-			staticExpectedResolvedTime: now.Add(200 * time.Millisecond),
-		}, // ~300ms total dur
-		{
+	w2.staticHostPubKeyStr = "w2"
+	pdc = newPDC(&pcwsUnresolvedWorker{
+		staticWorker:               w1,
+		staticExpectedResolvedTime: now.Add(200 * time.Millisecond),
+	}, // ~300ms total dur
+		&pcwsUnresolvedWorker{
 			staticWorker:               w2,
 			staticExpectedResolvedTime: now.Add(50 * time.Millisecond),
 		}, // ~250ms total dur
-	}
+	)
 
 	// verify the best overdrive worker has the expected outcome values for w2
-	exists, late, dur, waitDur, wIndex = pdc.bestOverdriveUnresolvedWorker(uws)
-	if !exists || late || wIndex != 1 {
+	exists, late, _, waitDur, w = pdc.bestUnresolvedOverdriveCandidate()
+	if !exists || late || w != w2 || waitDur > 50*time.Millisecond {
 		t.Fatal("unexpected")
 	}
 
 	// now make w2 very expensive, the best overdrive worker should become w1
 	w2.staticPriceTable().staticPriceTable.ReadBaseCost = types.SiacoinPrecision
-	exists, late, dur, waitDur, wIndex = pdc.bestOverdriveUnresolvedWorker(uws)
-	if !exists || late || wIndex != 0 {
+	exists, late, _, _, w = pdc.bestUnresolvedOverdriveCandidate()
+	if !exists || late || w != w1 {
 		t.Fatal("unexpected")
 	}
 
-	// now alter w1 to be late, the best overdrive worker should become w2
-	uws[0].staticExpectedResolvedTime = now.Add(-50 * time.Millisecond)
-	exists, late, dur, waitDur, wIndex = pdc.bestOverdriveUnresolvedWorker(uws)
-	if !exists || late || wIndex != 1 {
+	// now make w1 late, the best overdrive worker should become w2 and w1
+	// should be moved to the late rank
+	pdc = newPDC(&pcwsUnresolvedWorker{
+		staticWorker:               w1,
+		staticExpectedResolvedTime: now.Add(-50 * time.Millisecond),
+	},
+		&pcwsUnresolvedWorker{
+			staticWorker:               w2,
+			staticExpectedResolvedTime: now.Add(50 * time.Millisecond),
+		},
+	)
+	exists, late, _, _, w = pdc.bestUnresolvedOverdriveCandidate()
+	if !exists || late || w != w2 {
 		t.Fatal("unexpected")
 	}
+	if pdc.overdriveCandidates.candidate(w1).rank != overdriveRankLate {
+		t.Fatal("w1 should be late")
+	}
 
-	// now alter w2 to be late as well, we expect the worker with the lowest
-	// read time to be the best one here
-	uws[1].staticExpectedResolvedTime = now.Add(-100 * time.Millisecond)
-	exists, late, dur, waitDur, wIndex = pdc.bestOverdriveUnresolvedWorker(uws)
-	if !exists || !late || waitDur != max || wIndex != 0 {
+	// now make w2 late as well, we expect the worker with the lowest read
+	// time to be the best one here
+	pdc = newPDC(&pcwsUnresolvedWorker{
+		staticWorker:               w1,
+		staticExpectedResolvedTime: now.Add(-50 * time.Millisecond),
+	},
+		&pcwsUnresolvedWorker{
+			staticWorker:               w2,
+			staticExpectedResolvedTime: now.Add(-100 * time.Millisecond),
+		},
+	)
+	exists, late, dur, waitDur, w = pdc.bestUnresolvedOverdriveCandidate()
+	if !exists || !late || waitDur != max || w != w1 || dur != pdc.adjustedReadDuration(w1) {
 		t.Fatal("unexpected")
 	}
 }
@@ -351,3 +403,171 @@ func TestProjectDownloadChunk_overdriveStatus(t *testing.T) {
 		t.Fatal("unexpected")
 	}
 }
+
+// TestProjectDownloadChunk_overdriveStatusStrategies is a unit test for the
+// 'overdriveStatus' function on the pdc using the various overdrive strategies.
+func TestProjectDownloadChunk_overdriveStatusStrategies(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	ec := skymodules.NewRSCodeDefault()
+	minPieces := ec.MinPieces()
+
+	// newPDC is a helper to create a pdc with the given strategy and a single
+	// launched piece that is expected to complete at the given time.
+	newPDC := func(strategy skymodules.OverdriveStrategy, completeTime time.Time) *projectDownloadChunk {
+		pcws := new(projectChunkWorkerSet)
+		pcws.staticErasureCoder = ec
+		pdc := new(projectDownloadChunk)
+		pdc.workerSet = pcws
+		pdc.launchTime = now
+		pdc.staticOverdrive = skymodules.OverdriveSettings{Strategy: strategy}.Merge(skymodules.OverdriveSettings{})
+		pdc.availablePieces = make([][]*pieceDownload, ec.NumPieces())
+		pdc.availablePieces[0] = []*pieceDownload{{launched: true, expectedCompleteTime: completeTime}}
+		return pdc
+	}
+
+	// Check the number of workers launched right away.
+	tests := map[skymodules.OverdriveStrategy]int{
+		skymodules.OverdriveStrategyConservative:  minPieces,
+		skymodules.OverdriveStrategyBalanced:      minPieces + minPieces/5,
+		skymodules.OverdriveStrategyAggressive:    minPieces + aggressiveOverdriveWorkers,
+		skymodules.OverdriveStrategyLatencyTarget: minPieces + minPieces/5,
+	}
+	for strategy, wanted := range tests {
+		pdc := newPDC(strategy, now.Add(time.Minute))
+		toLaunch, _ := pdc.managedOverdriveStatus()
+		if toLaunch != wanted-1 {
+			t.Fatalf("%v: expected %v workers but got %v", strategy, wanted-1, toLaunch)
+		}
+	}
+
+	// fillPieces marks enough pieces as launched to not require more workers.
+	fillPieces := func(pdc *projectDownloadChunk, completeTime time.Time) {
+		for i := 1; i < len(pdc.availablePieces); i++ {
+			pdc.availablePieces[i] = []*pieceDownload{{launched: true, expectedCompleteTime: completeTime}}
+		}
+	}
+
+	// The conservative strategy waits a bit longer than the latest worker.
+	late := now.Add(-conservativeOverdriveDelay / 2)
+	pdc := newPDC(skymodules.OverdriveStrategyConservative, late)
+	fillPieces(pdc, late)
+	toLaunch, checkTime := pdc.managedOverdriveStatus()
+	if toLaunch != 0 || checkTime != late.Add(conservativeOverdriveDelay) {
+		t.Fatal("unexpected", toLaunch, checkTime)
+	}
+	pdc = newPDC(skymodules.OverdriveStrategyBalanced, late)
+	fillPieces(pdc, late)
+	toLaunch, _ = pdc.managedOverdriveStatus()
+	if toLaunch != 1 {
+		t.Fatal("unexpected", toLaunch)
+	}
+
+	// The latency target strategy launches an overdrive worker once if the
+	// download is expected to miss the target.
+	pdc = newPDC(skymodules.OverdriveStrategyLatencyTarget, now.Add(time.Minute))
+	fillPieces(pdc, now.Add(time.Minute))
+	toLaunch, checkTime = pdc.managedOverdriveStatus()
+	if toLaunch != 0 || checkTime != now.Add(skymodules.DefaultOverdriveLatencyTarget) {
+		t.Fatal("unexpected", toLaunch, checkTime)
+	}
+	pdc.launchTime = now.Add(-time.Hour)
+	toLaunch, _ = pdc.managedOverdriveStatus()
+	if toLaunch != 1 {
+		t.Fatal("unexpected", toLaunch)
+	}
+	toLaunch, _ = pdc.managedOverdriveStatus()
+	if toLaunch != 0 {
+		t.Fatal("unexpected", toLaunch)
+	}
+}
+
+// TestProjectDownloadChunk_maxCost is a unit test that verifies overdrive
+// workers are not launched once they would exceed the max cost of the
+// download and that the download fails with an error naming the cap.
+func TestProjectDownloadChunk_maxCost(t *testing.T) {
+	t.Parallel()
+
+	// mock two workers and a pdc with a 64kb piece length
+	w1 := mockWorker(10 * time.Millisecond)
+	w1.staticHostPubKeyStr = "w1"
+	w2 := mockWorker(20 * time.Millisecond)
+	w2.staticHostPubKeyStr = "w2"
+
+	ec := skymodules.NewRSCodeDefault()
+	pdc := new(projectDownloadChunk)
+	pdc.pieceLength = 1 << 16
+	pdc.pricePerMS = types.SiacoinPrecision
+	pdc.workerSet = &projectChunkWorkerSet{staticErasureCoder: ec}
+	pdc.workerState = &pcwsWorkerState{
+		resolvedWorkers: []*pcwsWorkerResponse{{worker: w2, pieceIndices: []uint64{1}}},
+	}
+	pdc.availablePieces = [][]*pieceDownload{
+		{{launched: true, completed: true, downloadErr: errors.New("failed"), worker: w1}},
+		{},
+	}
+	for i := 2; i < ec.MinPieces(); i++ {
+		pdc.availablePieces = append(pdc.availablePieces, []*pieceDownload{{launched: true, completed: true}})
+	}
+	pdc.availablePiecesByWorker = make(map[string][]uint64)
+	pdc.dataPieces = make([][]byte, len(pdc.availablePieces))
+	pdc.updateAvailablePieces()
+
+	// without a max cost the worker is launched
+	cost := w2.staticJobReadQueue.callExpectedJobCost(pdc.pieceLength)
+	if cost.IsZero() {
+		t.Fatal("unexpected")
+	}
+	if !pdc.withinMaxCost(w2) {
+		t.Fatal("unexpected")
+	}
+	worker, _, _, _ := pdc.managedFindBestOverdriveWorker()
+	if worker != w2 {
+		t.Fatal("unexpected")
+	}
+
+	// mock the cost of the launched worker and set a max cost that allows for
+	// launching one more worker
+	pdc.expectedCost = cost
+	pdc.staticOverdrive.MaxCost = cost.Mul64(2)
+	if !pdc.withinMaxCost(w2) {
+		t.Fatal("unexpected")
+	}
+	worker, _, _, _ = pdc.managedFindBestOverdriveWorker()
+	if worker != w2 {
+		t.Fatal("unexpected")
+	}
+	if _, err := pdc.finished(); err != nil {
+		t.Fatal(err)
+	}
+
+	// lower the max cost, the worker can't be launched anymore and the
+	// download is no longer able to complete
+	pdc.staticOverdrive.MaxCost = cost.Mul64(2).Sub64(1)
+	if pdc.withinMaxCost(w2) {
+		t.Fatal("unexpected")
+	}
+	worker, _, _, _ = pdc.managedFindBestOverdriveWorker()
+	if worker != nil {
+		t.Fatal("unexpected")
+	}
+	_, err := pdc.finished()
+	if !errors.Contains(err, skymodules.ErrDownloadCostCapExceeded) {
+		t.Fatal("unexpected error", err)
+	}
+	if !strings.Contains(err.Error(), pdc.staticOverdrive.MaxCost.HumanString()) {
+		t.Fatal("error should name the max cost", err)
+	}
+
+	// launching the worker anyway tracks its cost
+	pdc.expectedCost = types.ZeroCurrency
+	pdc.ctx = context.Background()
+	pdc.workerSet.staticPieceRoots = make([]crypto.Hash, ec.NumPieces())
+	if _, launched := pdc.launchWorker(w2, 1, true); !launched {
+		t.Fatal("unexpected")
+	}
+	if !pdc.expectedCost.Equals(cost) {
+		t.Fatal("unexpected", pdc.expectedCost, cost)
+	}
+}
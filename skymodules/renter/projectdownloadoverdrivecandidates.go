@@ -0,0 +1,331 @@
+package renter
+
+import (
+	"container/heap"
+	"math"
+	"time"
+)
+
+// The overdrive candidates of a pdc are indexed in one heap per rank. Every
+// worker is in at most one heap and is moved between the heaps as its rank
+// changes. Resolved workers know which pieces they can download and are keyed
+// by their adjusted read duration. Unresolved workers are keyed by the time
+// at which they are expected to complete the download if they resolve on
+// time. Once they are late, they are moved to the late rank and keyed by
+// their adjusted read duration.
+//
+// The heaps are updated incrementally. Workers are added and moved when they
+// resolve and their keys are updated when one of their read jobs returns.
+// The adjusted read duration of a worker can change in the meantime, e.g.
+// when its read queue goes on a cooldown. That's why the key of the best
+// candidate is checked before it is selected. If it got worse, the candidate
+// is moved down and the next one is checked. Candidates which can't be
+// launched anymore are removed lazily the same way. Selecting the best
+// candidate therefore only takes O(log n) instead of evaluating every worker
+// for every piece.
+
+const (
+	// overdriveRankResolved contains the resolved workers which have pieces
+	// left to download.
+	overdriveRankResolved = iota
+
+	// overdriveRankUnresolved contains the unresolved workers which are
+	// expected to resolve in the future.
+	overdriveRankUnresolved
+
+	// overdriveRankLate contains the unresolved workers which should have
+	// resolved already.
+	overdriveRankLate
+
+	// numOverdriveRanks is the number of ranks.
+	numOverdriveRanks
+)
+
+type (
+	// overdriveCandidate is a worker which might be launched as an overdrive
+	// worker.
+	overdriveCandidate struct {
+		worker *worker
+
+		// key is the value the candidate is sorted by within the heap of its
+		// rank. readDuration is the adjusted read duration of the worker at
+		// the time the key was computed and resolvedTime is the expected
+		// resolve time of an unresolved worker.
+		key          time.Duration
+		readDuration time.Duration
+		resolvedTime time.Time
+
+		// pieces are the pieces a resolved worker might download, ordered by
+		// their index.
+		pieces []overdrivePiece
+
+		// rank and index are the position of the candidate within the
+		// candidate heaps.
+		rank  int
+		index int
+	}
+
+	// overdrivePiece is a piece which a resolved worker might download.
+	overdrivePiece struct {
+		index         uint64
+		pieceDownload *pieceDownload
+	}
+
+	// overdriveCandidateHeap is a heap of overdrive candidates sorted by their
+	// key.
+	overdriveCandidateHeap []*overdriveCandidate
+
+	// overdriveCandidates indexes the overdrive candidates of a pdc by rank
+	// and by the worker's host key.
+	overdriveCandidates struct {
+		heaps    [numOverdriveRanks]overdriveCandidateHeap
+		byWorker map[string]*overdriveCandidate
+
+		// staticEpoch is the reference time for the keys of unresolved
+		// workers.
+		staticEpoch time.Time
+	}
+)
+
+func (h overdriveCandidateHeap) Len() int           { return len(h) }
+func (h overdriveCandidateHeap) Less(i, j int) bool { return h[i].key < h[j].key }
+func (h overdriveCandidateHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *overdriveCandidateHeap) Push(x interface{}) {
+	c := x.(*overdriveCandidate)
+	c.index = len(*h)
+	*h = append(*h, c)
+}
+func (h *overdriveCandidateHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	c := old[n-1]
+	old[n-1] = nil
+	c.index = -1
+	*h = old[:n-1]
+	return c
+}
+
+// newOverdriveCandidates creates an empty set of overdrive candidates.
+func newOverdriveCandidates() *overdriveCandidates {
+	return &overdriveCandidates{
+		byWorker:    make(map[string]*overdriveCandidate),
+		staticEpoch: time.Now(),
+	}
+}
+
+// addDurations adds two durations and caps the result at math.MaxInt64
+// instead of overflowing.
+func addDurations(a, b time.Duration) time.Duration {
+	if a > 0 && b > math.MaxInt64-a {
+		return math.MaxInt64
+	}
+	return a + b
+}
+
+// best returns the best candidate of the given rank or nil if there is none.
+func (oc *overdriveCandidates) best(rank int) *overdriveCandidate {
+	if len(oc.heaps[rank]) == 0 {
+		return nil
+	}
+	return oc.heaps[rank][0]
+}
+
+// candidate returns the candidate for the given worker or nil if the worker
+// is not a candidate.
+func (oc *overdriveCandidates) candidate(w *worker) *overdriveCandidate {
+	return oc.byWorker[w.staticHostPubKeyStr]
+}
+
+// fix updates the key of a candidate.
+func (oc *overdriveCandidates) fix(c *overdriveCandidate, key time.Duration) {
+	c.key = key
+	heap.Fix(&oc.heaps[c.rank], c.index)
+}
+
+// push adds a candidate with the given rank.
+func (oc *overdriveCandidates) push(c *overdriveCandidate, rank int) {
+	c.rank = rank
+	heap.Push(&oc.heaps[rank], c)
+	oc.byWorker[c.worker.staticHostPubKeyStr] = c
+}
+
+// remove removes a candidate.
+func (oc *overdriveCandidates) remove(c *overdriveCandidate) {
+	heap.Remove(&oc.heaps[c.rank], c.index)
+	delete(oc.byWorker, c.worker.staticHostPubKeyStr)
+}
+
+// unresolvedKey returns the key of an unresolved worker which is the time
+// at which the worker is expected to complete the download relative to the
+// epoch of the candidates.
+func (oc *overdriveCandidates) unresolvedKey(c *overdriveCandidate) time.Duration {
+	return addDurations(c.resolvedTime.Sub(oc.staticEpoch), c.readDuration)
+}
+
+// addUnresolvedOverdriveCandidate adds an unresolved worker to the overdrive
+// candidates.
+func (pdc *projectDownloadChunk) addUnresolvedOverdriveCandidate(uw *pcwsUnresolvedWorker) {
+	oc := pdc.overdriveCandidates
+	c := &overdriveCandidate{
+		worker:       uw.staticWorker,
+		readDuration: pdc.adjustedReadDuration(uw.staticWorker),
+		resolvedTime: uw.staticExpectedResolvedTime,
+	}
+	c.key = oc.unresolvedKey(c)
+	oc.push(c, overdriveRankUnresolved)
+}
+
+// addResolvedOverdriveCandidate adds a resolved worker to the overdrive
+// candidates. If the worker was an unresolved candidate before, it is moved
+// to the resolved rank.
+func (pdc *projectDownloadChunk) addResolvedOverdriveCandidate(w *worker, pieces []overdrivePiece) {
+	oc := pdc.overdriveCandidates
+	if c := oc.candidate(w); c != nil {
+		oc.remove(c)
+	}
+	if len(pieces) == 0 {
+		return
+	}
+	readDuration := pdc.adjustedReadDuration(w)
+	oc.push(&overdriveCandidate{
+		worker:       w,
+		key:          readDuration,
+		readDuration: readDuration,
+		pieces:       pieces,
+	}, overdriveRankResolved)
+}
+
+// refreshOverdriveCandidate updates the key of the given worker after its
+// adjusted read duration might have changed.
+func (pdc *projectDownloadChunk) refreshOverdriveCandidate(w *worker) {
+	oc := pdc.overdriveCandidates
+	if oc == nil {
+		return
+	}
+	c := oc.candidate(w)
+	if c == nil {
+		return
+	}
+	pdc.updateOverdriveCandidate(c)
+}
+
+// updateOverdriveCandidate updates the key of the given candidate using the
+// current adjusted read duration of its worker. It returns true if the key got
+// worse.
+func (pdc *projectDownloadChunk) updateOverdriveCandidate(c *overdriveCandidate) bool {
+	oc := pdc.overdriveCandidates
+	readDuration := pdc.adjustedReadDuration(c.worker)
+	worse := readDuration > c.readDuration
+	c.readDuration = readDuration
+	if c.rank == overdriveRankUnresolved {
+		oc.fix(c, oc.unresolvedKey(c))
+	} else {
+		oc.fix(c, readDuration)
+	}
+	return worse
+}
+
+// launchable returns whether the worker of a resolved candidate can still be
+// launched to download the given piece. That's not the case if the piece was
+// downloaded already or if the worker was launched for the piece before.
+func (pdc *projectDownloadChunk) launchable(p overdrivePiece) bool {
+	pd := p.pieceDownload
+	return !pd.launched && pd.downloadErr == nil && pdc.dataPieces[p.index] == nil
+}
+
+// bestResolvedOverdriveCandidate returns the best resolved worker to launch as
+// an overdrive worker, the piece it should download and its adjusted read
+// duration. If there is no such worker, nil is returned.
+func (pdc *projectDownloadChunk) bestResolvedOverdriveCandidate() (*worker, uint64, time.Duration) {
+	oc := pdc.overdriveCandidates
+	for c := oc.best(overdriveRankResolved); c != nil; c = oc.best(overdriveRankResolved) {
+		// Drop the pieces which can't be launched anymore. A piece never
+		// becomes launchable again, so they are dropped for good.
+		for len(c.pieces) > 0 && !pdc.launchable(c.pieces[0]) {
+			c.pieces = c.pieces[1:]
+		}
+
+		// Remove workers without pieces and workers that can't be launched
+		// within the max cost. The expected cost of the download only grows,
+		// so they won't become launchable again.
+		if len(c.pieces) == 0 || !pdc.withinMaxCost(c.worker) {
+			oc.remove(c)
+			continue
+		}
+
+		// Check whether the key is outdated.
+		if pdc.updateOverdriveCandidate(c) {
+			continue
+		}
+		return c.worker, c.pieces[0].index, c.readDuration
+	}
+	return nil, 0, time.Duration(math.MaxInt64)
+}
+
+// bestUnresolvedOverdriveCandidate returns the best unresolved worker to
+// launch as an overdrive worker once it resolves.
+//
+// Five values are returned.
+//
+// The first indicates whether an unresolved worker was found that can be
+// launched within the max cost.
+//
+// The second signifies whether the best worker is late. If so, any worker that
+// is resolved should be preferred over any worker that is unresolved. Workers
+// that are not late get preference over workers that are late.
+//
+// The third return value is the unresolved duration. This is a modified
+// duration based on the combination of the amount of time until the worker has
+// completed its task plus the amount of time penalty the worker incurs for
+// being expensive.
+//
+// The fourth return value is a wait duration, which indicates how much time
+// needs to elapse before the best unresolved worker flips over into being a
+// late worker.
+//
+// The final return value is the best unresolved worker itself.
+func (pdc *projectDownloadChunk) bestUnresolvedOverdriveCandidate() (exists, late bool, duration, waitDuration time.Duration, w *worker) {
+	oc := pdc.overdriveCandidates
+
+	// Check the workers which are not late first.
+	for c := oc.best(overdriveRankUnresolved); c != nil; c = oc.best(overdriveRankUnresolved) {
+		// Skip workers that can't be launched within the max cost.
+		if !pdc.withinMaxCost(c.worker) {
+			oc.remove(c)
+			continue
+		}
+
+		// Move the worker to the late rank if it should have resolved
+		// already.
+		hasSectorTime := time.Until(c.resolvedTime)
+		if hasSectorTime < 0 {
+			oc.remove(c)
+			c.key = c.readDuration
+			oc.push(c, overdriveRankLate)
+			continue
+		}
+
+		// Check whether the key is outdated.
+		if pdc.updateOverdriveCandidate(c) {
+			continue
+		}
+		return true, false, addDurations(hasSectorTime, c.readDuration), hasSectorTime, c.worker
+	}
+
+	// Check the late workers.
+	for c := oc.best(overdriveRankLate); c != nil; c = oc.best(overdriveRankLate) {
+		if !pdc.withinMaxCost(c.worker) {
+			oc.remove(c)
+			continue
+		}
+		if pdc.updateOverdriveCandidate(c) {
+			continue
+		}
+		return true, true, c.readDuration, time.Duration(math.MaxInt64), c.worker
+	}
+	return false, true, time.Duration(math.MaxInt64), time.Duration(math.MaxInt64), nil
+}
@@ -0,0 +1,89 @@
+package renter
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// TestOverdriveCandidates is a unit test for the heaps of the overdrive
+// candidates.
+func TestOverdriveCandidates(t *testing.T) {
+	t.Parallel()
+
+	// mock a few candidates
+	oc := newOverdriveCandidates()
+	candidates := make([]*overdriveCandidate, 5)
+	for i := range candidates {
+		w := mockWorker(time.Millisecond)
+		w.staticHostPubKeyStr = string(rune('a' + i))
+		candidates[i] = &overdriveCandidate{
+			worker: w,
+			key:    time.Duration(len(candidates)-i) * time.Millisecond,
+		}
+		oc.push(candidates[i], overdriveRankResolved)
+	}
+
+	// the candidate with the lowest key should be the best
+	if oc.best(overdriveRankResolved) != candidates[4] {
+		t.Fatal("unexpected best candidate")
+	}
+	if oc.best(overdriveRankUnresolved) != nil || oc.best(overdriveRankLate) != nil {
+		t.Fatal("other ranks should be empty")
+	}
+
+	// increasing the key of the best candidate should move it down
+	oc.fix(candidates[4], time.Second)
+	if oc.best(overdriveRankResolved) != candidates[3] {
+		t.Fatal("unexpected best candidate")
+	}
+
+	// removing the best candidate should remove it from the index as well
+	oc.remove(candidates[3])
+	if oc.best(overdriveRankResolved) != candidates[2] {
+		t.Fatal("unexpected best candidate")
+	}
+	if oc.candidate(candidates[3].worker) != nil {
+		t.Fatal("candidate should have been removed")
+	}
+
+	// moving a candidate to another rank
+	oc.remove(candidates[0])
+	oc.push(candidates[0], overdriveRankLate)
+	if oc.best(overdriveRankLate) != candidates[0] || oc.candidate(candidates[0].worker).rank != overdriveRankLate {
+		t.Fatal("candidate should have been moved")
+	}
+
+	// the remaining candidates should be popped in order
+	expected := []*overdriveCandidate{candidates[2], candidates[1], candidates[4]}
+	for _, c := range expected {
+		if oc.best(overdriveRankResolved) != c {
+			t.Fatal("unexpected order")
+		}
+		oc.remove(c)
+	}
+	if len(oc.byWorker) != 1 {
+		t.Fatal("unexpected number of candidates", len(oc.byWorker))
+	}
+}
+
+// TestAddDurations is a unit test for addDurations.
+func TestAddDurations(t *testing.T) {
+	t.Parallel()
+
+	max := time.Duration(math.MaxInt64)
+	tests := []struct {
+		a, b, result time.Duration
+	}{
+		{time.Second, time.Second, 2 * time.Second},
+		{-time.Second, time.Second, 0},
+		{max, time.Second, max},
+		{time.Second, max, max},
+		{max, max, max},
+	}
+	for _, test := range tests {
+		if result := addDurations(test.a, test.b); result != test.result {
+			t.Fatal("unexpected result", test.a, test.b, result, test.result)
+		}
+	}
+}
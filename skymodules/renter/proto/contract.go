@@ -24,6 +24,7 @@ const (
 	updateNameInsertContract = "insertContract"
 	updateNameSetHeader      = "setHeader"
 	updateNameSetRoot        = "setRoot"
+	updateNameTruncateRoots  = "truncateRoots"
 
 	// decodeMaxSizeMultiplier is multiplied with the size of an encoded object
 	// to allocated a bit of extra space for decoding.
@@ -52,6 +53,13 @@ type updateSetRoot struct {
 	Index int
 }
 
+// updateTruncateRoots is an update which truncates the sector roots of a
+// filecontract with the specified id to the given number of roots.
+type updateTruncateRoots struct {
+	ID       types.FileContractID
+	NumRoots int
+}
+
 // contractHeader holds all the information about a contract apart from the
 // sector roots themselves.
 type contractHeader struct {
@@ -75,6 +83,7 @@ type contractHeader struct {
 	TxnFee              types.Currency
 	SiafundFee          types.Currency
 	Utility             skymodules.ContractUtility
+	AccountSpending     skymodules.ContractAccountSpending
 }
 
 // rootUpdate is a helper type that specifies an update to a root.
@@ -90,6 +99,11 @@ type rootUpdate struct {
 	// root is the new value of the root. This may be the empty hash if the root
 	// is meant to be trimmed.
 	root crypto.Hash
+	// existing indicates that root was already part of the contract at
+	// srcIndex before the update. This is used to move the reference counter
+	// of a root together with the root itself.
+	existing bool
+	srcIndex uint64
 }
 
 // newRootUpdateTrimRoot creates a trim update.
@@ -102,9 +116,10 @@ func newRootUpdateAppendRoot(root crypto.Hash) rootUpdate {
 	return rootUpdate{root: root, appended: true}
 }
 
-// newRootUpdateUpdateRoot creates a regular update.
-func newRootUpdateUpdateRoot(root crypto.Hash) rootUpdate {
-	return rootUpdate{root: root, appended: false}
+// newRootUpdateUpdateRoot creates a regular update for the existing root at
+// the given index.
+func newRootUpdateUpdateRoot(root crypto.Hash, index uint64) rootUpdate {
+	return rootUpdate{root: root, appended: false, existing: true, srcIndex: index}
 }
 
 // validate returns an error if the contractHeader is invalid.
@@ -280,6 +295,7 @@ func (c *SafeContract) metadata() skymodules.RenterContract {
 		TxnFee:              h.TxnFee,
 		SiafundFee:          h.SiafundFee,
 		Utility:             h.Utility,
+		AccountSpending:     h.AccountSpending,
 	}
 }
 
@@ -338,6 +354,39 @@ func (c *SafeContract) Sign(hash crypto.Hash) crypto.Signature {
 	return crypto.SignHash(hash, c.header.SecretKey)
 }
 
+// RecordAccountSpending adds the given spending from the ephemeral account with
+// the contract's host to the contract's header.
+func (c *SafeContract) RecordAccountSpending(spending skymodules.ContractAccountSpending) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Construct new header
+	newHeader := c.header
+	newHeader.AccountSpending = newHeader.AccountSpending.Add(spending)
+
+	// Record the intent to change the header in the wal.
+	t, err := c.newWalTxn([]writeaheadlog.Update{
+		c.makeUpdateSetHeader(newHeader),
+	})
+	if err != nil {
+		return err
+	}
+	// Signal that the setup is completed.
+	if err := <-t.SignalSetupComplete(); err != nil {
+		return err
+	}
+	// Apply the change.
+	if err := c.applySetHeader(newHeader); err != nil {
+		return err
+	}
+	// Sync the change to disk.
+	if err := c.staticHeaderFile.Sync(); err != nil {
+		return err
+	}
+	// Signal that the update has been applied.
+	return t.SignalUpdatesApplied()
+}
+
 // UpdateUtility updates the utility field of a contract.
 func (c *SafeContract) UpdateUtility(utility skymodules.ContractUtility) error {
 	c.mu.Lock()
@@ -426,6 +475,75 @@ func (c *SafeContract) makeUpdateSetRoot(root crypto.Hash, index int) writeahead
 	}
 }
 
+// makeUpdateTruncateRoots creates an update that truncates the roots to the
+// given number of roots.
+func (c *SafeContract) makeUpdateTruncateRoots(numRoots int) writeaheadlog.Update {
+	id := c.header.ID()
+	return writeaheadlog.Update{
+		Name: updateNameTruncateRoots,
+		Instructions: encoding.Marshal(updateTruncateRoots{
+			ID:       id,
+			NumRoots: numRoots,
+		}),
+	}
+}
+
+// makeUpdatesRefCounterTruncate creates the WAL updates that move the
+// reference counters of the roots that were moved by rootUpdates and drop the
+// counters of all sectors starting at numRoots. If there is no open refcounter
+// update session this method will open one. This update session will be closed
+// when we apply the updates.
+func (c *SafeContract) makeUpdatesRefCounterTruncate(rootUpdates map[uint64]rootUpdate, numRoots uint64) ([]writeaheadlog.Update, error) {
+	if build.Release != "testing" {
+		return nil, nil // no update needed
+	}
+	// Compute the change of every counter within the remaining roots. Moved
+	// roots take their counters with them and new roots start with a single
+	// reference.
+	diff := make(map[uint64]int64)
+	for rootIdx, update := range rootUpdates {
+		if update.trim || rootIdx >= numRoots {
+			continue
+		}
+		if update.existing && update.srcIndex == rootIdx {
+			continue
+		}
+		newCount := uint16(1)
+		if update.existing {
+			count, err := c.staticRC.callCount(update.srcIndex)
+			if err != nil {
+				return nil, errors.AddContext(err, "failed to get count of moved root")
+			}
+			newCount = count
+		}
+		oldCount, err := c.staticRC.callCount(rootIdx)
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to get count of replaced root")
+		}
+		diff[rootIdx] = int64(newCount) - int64(oldCount)
+	}
+	// See makeUpdateRefCounterAppend for this hidden retry.
+	updates, err := c.staticRC.callApplyDiff(diff)
+	if errors.Contains(err, ErrUpdateWithoutUpdateSession) {
+		if err = c.staticRC.callStartUpdate(); err != nil {
+			return nil, err
+		}
+		updates, err = c.staticRC.callApplyDiff(diff)
+	}
+	if err != nil {
+		return nil, err
+	}
+	numSectors := uint64(c.merkleRoots.len())
+	if numRoots < numSectors {
+		u, err := c.staticRC.callDropSectors(numSectors - numRoots)
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, u)
+	}
+	return updates, nil
+}
+
 // makeUpdateRefCounterAppend creates a WAL update that sets a given
 // refcounter value. If there is no open refcounter update session this method
 // will open one. This update session will be closed when we apply the update.
@@ -499,6 +617,12 @@ func (c *SafeContract) applySetRoot(root crypto.Hash, index int) error {
 	return c.merkleRoots.insert(index, root)
 }
 
+// applyTruncateRoots directly truncates the roots on disk to the given number
+// of roots without going through a WAL transaction.
+func (c *SafeContract) applyTruncateRoots(numRoots int) error {
+	return c.merkleRoots.truncate(numRoots)
+}
+
 // managedRecordRootUpdates creates a WAL update that applies a number of
 // updates to contract roots.
 func (c *SafeContract) managedRecordRootUpdates(rev types.FileContractRevision, rootUpdates map[uint64]rootUpdate, storageCost, bandwidthCost types.Currency) (*unappliedWalTxn, error) {
@@ -512,19 +636,36 @@ func (c *SafeContract) managedRecordRootUpdates(rev types.FileContractRevision,
 	newHeader.StorageSpending = newHeader.StorageSpending.Add(storageCost)
 	newHeader.UploadSpending = newHeader.UploadSpending.Add(bandwidthCost)
 
+	// If existing roots are trimmed, the contract's roots are truncated to
+	// the new size of the contract.
+	numRoots := c.merkleRoots.len()
+	for _, update := range rootUpdates {
+		if update.trim && !update.appended {
+			numRoots = int(rev.NewFileSize / modules.SectorSize)
+			break
+		}
+	}
+	truncate := numRoots < c.merkleRoots.len()
+
 	updates := []writeaheadlog.Update{
 		c.makeUpdateSetHeader(newHeader),
 	}
 	for rootIdx, update := range rootUpdates {
-		if !update.trim {
+		// Roots beyond the new end of the contract are truncated anyway.
+		if !update.trim && (!truncate || rootIdx < uint64(numRoots)) {
 			updates = append(updates, c.makeUpdateSetRoot(update.root, int(rootIdx)))
-		} else if update.trim && !update.appended {
-			err := errors.New("trimming from a contract is not yet supported")
-			build.Critical(err)
-			return nil, err
 		}
 	}
-	if build.Release == "testing" {
+	if truncate {
+		updates = append(updates, c.makeUpdateTruncateRoots(numRoots))
+	}
+	if build.Release == "testing" && truncate {
+		rcUpdates, err := c.makeUpdatesRefCounterTruncate(rootUpdates, uint64(numRoots))
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to create refcounter updates")
+		}
+		updates = append(updates, rcUpdates...)
+	} else if build.Release == "testing" {
 		rcUpdate, err := c.makeUpdateRefCounterAppend()
 		if err != nil {
 			return nil, errors.AddContext(err, "failed to create a refcounter update")
@@ -573,7 +714,15 @@ func (c *SafeContract) managedCommitAppend(t *unappliedWalTxn, signedTxn types.T
 			if err := c.applySetRoot(sru.Root, sru.Index); err != nil {
 				return err
 			}
-		case updateNameRCWriteAt:
+		case updateNameTruncateRoots:
+			var tru updateTruncateRoots
+			if err := encoding.Unmarshal(u.Instructions, &tru); err != nil {
+				return err
+			}
+			if err := c.applyTruncateRoots(tru.NumRoots); err != nil {
+				return err
+			}
+		case updateNameRCWriteAt, updateNameRCTruncate:
 			if err = c.applyRefCounterUpdate(u); err != nil {
 				return errors.AddContext(err, "failed to apply refcounter update")
 			}
@@ -729,7 +878,15 @@ func (c *SafeContract) managedCommitTxns() error {
 				if err := c.applySetRoot(u.Root, u.Index); err != nil {
 					return err
 				}
-			case updateNameRCWriteAt:
+			case updateNameTruncateRoots:
+				var u updateTruncateRoots
+				if err := encoding.Unmarshal(update.Instructions, &u); err != nil {
+					return err
+				}
+				if err := c.applyTruncateRoots(u.NumRoots); err != nil {
+					return err
+				}
+			case updateNameRCWriteAt, updateNameRCTruncate:
 				if err := c.applyRefCounterUpdate(update); err != nil {
 					return err
 				}
@@ -830,7 +987,15 @@ func (c *SafeContract) managedSyncRevision(rev types.FileContractRevision, sigs
 						if err := c.applySetRoot(sru.Root, sru.Index); err != nil {
 							return err
 						}
-					case updateNameRCWriteAt:
+					case updateNameTruncateRoots:
+						var tru updateTruncateRoots
+						if err := encoding.Unmarshal(u.Instructions, &tru); err != nil {
+							return err
+						}
+						if err := c.applyTruncateRoots(tru.NumRoots); err != nil {
+							return err
+						}
+					case updateNameRCWriteAt, updateNameRCTruncate:
 						if err := c.applyRefCounterUpdate(u); err != nil {
 							return errors.AddContext(err, "failed to apply refcounter update")
 						}
@@ -993,16 +1158,23 @@ func loadSafeContractHeader(f io.ReadSeeker, decodeMaxSize int) (contractHeader,
 	var header contractHeader
 	err := encoding.NewDecoder(f, decodeMaxSize).Decode(&header)
 	if err != nil {
-		// Unable to decode the old header, try a new decode. Seek the file back
-		// to the beginning.
-		var v1412DecodeErr error
+		// Unable to decode the header, try the legacy decodes. Seek the file
+		// back to the beginning before each attempt.
+		var v158DecodeErr, v1412DecodeErr error
 		_, seekErr := f.Seek(0, 0)
 		if seekErr != nil {
 			return contractHeader{}, errors.AddContext(errors.Compose(err, seekErr), "unable to reset file when attempting legacy decode")
 		}
-		header, v1412DecodeErr = contractHeaderDecodeV1412ToV1420(f, decodeMaxSize)
-		if v1412DecodeErr != nil {
-			return contractHeader{}, errors.AddContext(errors.Compose(err, v1412DecodeErr), "unable to decode contract header")
+		header, v158DecodeErr = contractHeaderDecodeV158ToV159(f, decodeMaxSize)
+		if v158DecodeErr != nil {
+			_, seekErr = f.Seek(0, 0)
+			if seekErr != nil {
+				return contractHeader{}, errors.AddContext(errors.Compose(err, v158DecodeErr, seekErr), "unable to reset file when attempting legacy decode")
+			}
+			header, v1412DecodeErr = contractHeaderDecodeV1412ToV1420(f, decodeMaxSize)
+		}
+		if v158DecodeErr != nil && v1412DecodeErr != nil {
+			return contractHeader{}, errors.AddContext(errors.Compose(err, v158DecodeErr, v1412DecodeErr), "unable to decode contract header")
 		}
 	}
 	if err := header.validate(); err != nil {
@@ -1230,10 +1402,14 @@ func (mrs *MerkleRootSet) UnmarshalJSON(b []byte) error {
 func unmarshalHeader(b []byte, u *updateSetHeader) error {
 	// Try unmarshalling the header.
 	if err := encoding.Unmarshal(b, u); err != nil {
-		// Try unmarshalling the update
+		// Try unmarshalling the update using the legacy formats.
+		v158Err := updateSetHeaderUnmarshalV158ToV159(b, u)
+		if v158Err == nil {
+			return nil
+		}
 		v132Err := updateSetHeaderUnmarshalV132ToV1420(b, u)
 		if v132Err != nil {
-			return errors.AddContext(errors.Compose(err, v132Err), "unable to unmarshal update set header")
+			return errors.AddContext(errors.Compose(err, v158Err, v132Err), "unable to unmarshal update set header")
 		}
 	}
 	return nil
@@ -12,6 +12,7 @@ import (
 	"gitlab.com/NebulousLabs/encoding"
 	"gitlab.com/NebulousLabs/fastrand"
 	"gitlab.com/NebulousLabs/ratelimit"
+	"gitlab.com/NebulousLabs/writeaheadlog"
 	"gitlab.com/SkynetLabs/skyd/build"
 	"gitlab.com/SkynetLabs/skyd/skymodules"
 	"go.sia.tech/siad/crypto"
@@ -402,6 +403,96 @@ func TestContractLargeHeader(t *testing.T) {
 	}
 }
 
+// TestContractRecordAccountSpending tests that account spending recorded in a
+// contract is added up and persisted.
+func TestContractRecordAccountSpending(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// create contract set with one contract
+	dir := build.TempDir(filepath.Join("proto", t.Name()))
+	rl := ratelimit.NewRateLimit(0, 0, 0)
+	cs, err := NewContractSet(dir, rl, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := contractHeader{
+		Transaction: types.Transaction{
+			FileContractRevisions: []types.FileContractRevision{{
+				NewRevisionNumber:    1,
+				NewValidProofOutputs: []types.SiacoinOutput{{}, {}},
+				UnlockConditions: types.UnlockConditions{
+					PublicKeys: []types.SiaPublicKey{{}, {}},
+				},
+			}},
+		},
+		DownloadSpending: types.NewCurrency64(10),
+		UploadSpending:   types.NewCurrency64(20),
+		ContractFee:      types.NewCurrency64(1),
+		TxnFee:           types.NewCurrency64(2),
+		SiafundFee:       types.NewCurrency64(3),
+	}
+	contract, err := cs.managedInsertContract(header, []crypto.Hash{{1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// recording spending for an unknown contract should fail
+	spending := skymodules.ContractAccountSpending{
+		DownloadSpending: types.NewCurrency64(1),
+		RegistrySpending: types.NewCurrency64(2),
+		UploadSpending:   types.NewCurrency64(3),
+	}
+	if err := cs.RecordAccountSpending(types.FileContractID{1}, spending); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// record spending twice
+	for i := 0; i < 2; i++ {
+		if err := cs.RecordAccountSpending(contract.ID, spending); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := spending.Add(spending)
+	metadata, ok := cs.View(contract.ID)
+	if !ok {
+		t.Fatal("contract not found")
+	}
+	if !bytes.Equal(encoding.Marshal(metadata.AccountSpending), encoding.Marshal(expected)) {
+		t.Fatal("unexpected account spending", metadata.AccountSpending)
+	}
+
+	// check the breakdown
+	breakdown := metadata.SpendingBreakdown()
+	expectedBreakdown := skymodules.ContractSpendingBreakdown{
+		DownloadSpending: types.NewCurrency64(12),
+		FeeSpending:      types.NewCurrency64(6),
+		RegistrySpending: types.NewCurrency64(4),
+		UploadSpending:   types.NewCurrency64(26),
+	}
+	if !bytes.Equal(encoding.Marshal(breakdown), encoding.Marshal(expectedBreakdown)) {
+		t.Fatal("unexpected breakdown", breakdown)
+	}
+
+	// reload the contract set, the spending should be persisted
+	if err := cs.Close(); err != nil {
+		t.Fatal(err)
+	}
+	cs, err = NewContractSet(dir, rl, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata, ok = cs.View(contract.ID)
+	if !ok {
+		t.Fatal("contract not found")
+	}
+	if !bytes.Equal(encoding.Marshal(metadata.AccountSpending), encoding.Marshal(expected)) {
+		t.Fatal("unexpected account spending after reload", metadata.AccountSpending)
+	}
+}
+
 // TestContractSetInsert checks if inserting contracts into the set is ACID.
 func TestContractSetInsertInterrupted(t *testing.T) {
 	if testing.Short() {
@@ -710,6 +801,134 @@ func TestContractRefCounter(t *testing.T) {
 	}
 }
 
+// TestContractTruncateRoots checks that moving roots and trimming existing
+// roots from a contract updates the roots and the refcounter as expected.
+func TestContractTruncateRoots(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// create a contract set
+	dir := build.TempDir(filepath.Join("proto", t.Name()))
+	rl := ratelimit.NewRateLimit(0, 0, 0)
+	cs, err := NewContractSet(dir, rl, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// add a contract with some roots
+	initialHeader := contractHeader{
+		Transaction: types.Transaction{
+			FileContractRevisions: []types.FileContractRevision{{
+				NewRevisionNumber:    1,
+				NewFileSize:          6 * modules.SectorSize,
+				NewValidProofOutputs: []types.SiacoinOutput{{}, {}},
+				UnlockConditions: types.UnlockConditions{
+					PublicKeys: []types.SiaPublicKey{{}, {}},
+				},
+			}},
+		},
+	}
+	initialRoots := []crypto.Hash{{1}, {2}, {3}, {4}, {5}, {6}}
+	c, err := cs.managedInsertContract(initialHeader, initialRoots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc := cs.managedMustAcquire(t, c.ID)
+
+	// set the reference counts
+	initialCounts := []uint16{0, 2, 0, 1, 3, 0}
+	if err := sc.staticRC.callStartUpdate(); err != nil {
+		t.Fatal(err)
+	}
+	var updates []writeaheadlog.Update
+	for i, count := range initialCounts {
+		u, err := sc.staticRC.callSetCount(uint64(i), count)
+		if err != nil {
+			t.Fatal(err)
+		}
+		updates = append(updates, u)
+	}
+	if err := sc.staticRC.callCreateAndApplyTransaction(updates...); err != nil {
+		t.Fatal(err)
+	}
+	if err := sc.staticRC.callUpdateApplied(); err != nil {
+		t.Fatal(err)
+	}
+	garbage, err := sc.managedGarbageSectors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(garbage, []uint64{0, 2, 5}) {
+		t.Fatal("wrong garbage", garbage)
+	}
+
+	// swap the roots at index 0 and 4 as well as 2 and 3 and trim the last 3
+	// roots
+	txn := types.Transaction{
+		FileContractRevisions: []types.FileContractRevision{{
+			NewRevisionNumber:    2,
+			NewFileSize:          3 * modules.SectorSize,
+			NewValidProofOutputs: []types.SiacoinOutput{{}, {}},
+			UnlockConditions: types.UnlockConditions{
+				PublicKeys: []types.SiaPublicKey{{}, {}},
+			},
+		}},
+	}
+	newRev := txn.FileContractRevisions[0]
+	trimmed := func(ru rootUpdate) rootUpdate {
+		ru.trim = true
+		return ru
+	}
+	walTxn, err := sc.managedRecordRootUpdates(newRev, map[uint64]rootUpdate{
+		0: newRootUpdateUpdateRoot(initialRoots[4], 4),
+		2: newRootUpdateUpdateRoot(initialRoots[3], 3),
+		3: trimmed(newRootUpdateUpdateRoot(initialRoots[2], 2)),
+		4: trimmed(newRootUpdateUpdateRoot(initialRoots[0], 0)),
+		5: newRootUpdateTrimRoot(),
+	}, types.ZeroCurrency, types.ZeroCurrency)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sc.managedCommitAppend(walTxn, txn, types.ZeroCurrency, types.ZeroCurrency)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// check the roots
+	roots, err := sc.merkleRoots.merkleRoots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedRoots := []crypto.Hash{{5}, {2}, {4}}
+	if !reflect.DeepEqual(roots, expectedRoots) {
+		t.Fatal("wrong roots", roots)
+	}
+	if sc.merkleRoots.root() != cachedMerkleRoot(expectedRoots) {
+		t.Fatal("wrong cached root")
+	}
+	// check the refcounter
+	if sc.staticRC.numSectors != uint64(len(expectedRoots)) {
+		t.Fatal("refcounter has wrong number of sectors", sc.staticRC.numSectors)
+	}
+	for i, expected := range []uint16{3, 2, 1} {
+		count, err := sc.staticRC.callCount(uint64(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != expected {
+			t.Fatalf("wrong count at %v: %v != %v", i, count, expected)
+		}
+	}
+	garbage, err = sc.managedGarbageSectors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(garbage) != 0 {
+		t.Fatal("contract still contains garbage", garbage)
+	}
+}
+
 // TestContractRecordCommitDownloadIntent tests recording and committing
 // downloads and makes sure they use the wal correctly.
 func TestContractRecordCommitDownloadIntent(t *testing.T) {
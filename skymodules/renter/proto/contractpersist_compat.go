@@ -41,6 +41,39 @@ type v132ContractHeader struct {
 	SiafundFee       types.Currency
 }
 
+// v158UpdateSetHeader is the update set header that was used up to and
+// including v1.5.8. It contains the legacy v158ContractHeader.
+type v158UpdateSetHeader struct {
+	ID     types.FileContractID
+	Header v158ContractHeader
+}
+
+// v158ContractHeader is a contractHeader without the AccountSpending field.
+// This field was added after v1.5.8 to break down the spending of a contract
+// by category.
+type v158ContractHeader struct {
+	// transaction is the signed transaction containing the most recent
+	// revision of the file contract.
+	Transaction types.Transaction
+
+	// secretKey is the key used by the renter to sign the file contract
+	// transaction.
+	SecretKey crypto.SecretKey
+
+	// Same as skymodules.RenterContract.
+	StartHeight         types.BlockHeight
+	DownloadSpending    types.Currency
+	FundAccountSpending types.Currency
+	MaintenanceSpending skymodules.MaintenanceSpending
+	StorageSpending     types.Currency
+	UploadSpending      types.Currency
+	TotalCost           types.Currency
+	ContractFee         types.Currency
+	TxnFee              types.Currency
+	SiafundFee          types.Currency
+	Utility             skymodules.ContractUtility
+}
+
 // v1412ContractHeader is the contract header that was used up to and including
 // v1.4.1.2
 type v1412ContractHeader struct {
@@ -68,6 +101,50 @@ type v1412ContractHeader struct {
 	Locked        bool
 }
 
+// convert converts a v158ContractHeader to a contractHeader.
+func (h v158ContractHeader) convert() contractHeader {
+	return contractHeader{
+		Transaction:         h.Transaction,
+		SecretKey:           h.SecretKey,
+		StartHeight:         h.StartHeight,
+		DownloadSpending:    h.DownloadSpending,
+		FundAccountSpending: h.FundAccountSpending,
+		MaintenanceSpending: h.MaintenanceSpending,
+		StorageSpending:     h.StorageSpending,
+		UploadSpending:      h.UploadSpending,
+		TotalCost:           h.TotalCost,
+		ContractFee:         h.ContractFee,
+		TxnFee:              h.TxnFee,
+		SiafundFee:          h.SiafundFee,
+		Utility:             h.Utility,
+	}
+}
+
+// contractHeaderDecodeV158ToV159 attempts to decode a contract header using
+// the persist struct as of v1.5.8, returning a header that has been converted
+// to the current version of the header.
+func contractHeaderDecodeV158ToV159(f io.Reader, decodeMaxSize int) (contractHeader, error) {
+	var v158Header v158ContractHeader
+	err := encoding.NewDecoder(f, decodeMaxSize).Decode(&v158Header)
+	if err != nil {
+		return contractHeader{}, errors.AddContext(err, "unable to decode header as a v158 header")
+	}
+	return v158Header.convert(), nil
+}
+
+// updateSetHeaderUnmarshalV158ToV159 attempts to unmarshal an update set
+// header using the v1.5.8 encoding scheme, returning the current version of
+// the update set header.
+func updateSetHeaderUnmarshalV158ToV159(b []byte, u *updateSetHeader) error {
+	var oldHeader v158UpdateSetHeader
+	if err := encoding.Unmarshal(b, &oldHeader); err != nil {
+		return errors.AddContext(err, "could not unmarshal update into v1.5.8 format")
+	}
+	u.ID = oldHeader.ID
+	u.Header = oldHeader.Header.convert()
+	return nil
+}
+
 // contractHeaderDecodeV1412ToV1420 attempts to decode a contract header using
 // the persist struct as of v1.4.1.2, returning a header that has been converted
 // to the v1.4.2 version of the header.
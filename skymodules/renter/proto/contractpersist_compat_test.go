@@ -1,9 +1,14 @@
 package proto
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 	"testing"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/types"
 )
 
 var (
@@ -28,3 +33,58 @@ func TestLoadV1412Contract(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestLoadV158ContractHeader tests that contract headers and update set headers
+// persisted before the account spending was added can be loaded.
+func TestLoadV158ContractHeader(t *testing.T) {
+	t.Parallel()
+
+	v158Header := v158ContractHeader{
+		Transaction: types.Transaction{
+			FileContractRevisions: []types.FileContractRevision{{
+				NewRevisionNumber:    1,
+				NewValidProofOutputs: []types.SiacoinOutput{{}, {}},
+				UnlockConditions: types.UnlockConditions{
+					PublicKeys: []types.SiaPublicKey{{}, {}},
+				},
+			}},
+		},
+		DownloadSpending:    types.NewCurrency64(1),
+		FundAccountSpending: types.NewCurrency64(2),
+		MaintenanceSpending: skymodules.MaintenanceSpending{
+			AccountBalanceCost: types.NewCurrency64(3),
+		},
+		SiafundFee: types.NewCurrency64(4),
+		Utility: skymodules.ContractUtility{
+			GoodForUpload: true,
+		},
+	}
+
+	// load the header
+	b := encoding.Marshal(v158Header)
+	header, err := loadSafeContractHeader(bytes.NewReader(b), len(b)*decodeMaxSizeMultiplier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !header.DownloadSpending.Equals(v158Header.DownloadSpending) ||
+		!header.FundAccountSpending.Equals(v158Header.FundAccountSpending) ||
+		!bytes.Equal(encoding.Marshal(header.MaintenanceSpending), encoding.Marshal(v158Header.MaintenanceSpending)) ||
+		!header.SiafundFee.Equals(v158Header.SiafundFee) ||
+		header.Utility != v158Header.Utility {
+		t.Fatal("header wasn't converted correctly", header)
+	}
+	if !bytes.Equal(encoding.Marshal(header.AccountSpending), encoding.Marshal(skymodules.ContractAccountSpending{})) {
+		t.Fatal("account spending should be empty", header.AccountSpending)
+	}
+
+	// load the update set header
+	id := types.FileContractID{1}
+	b = encoding.Marshal(v158UpdateSetHeader{ID: id, Header: v158Header})
+	var u updateSetHeader
+	if err := unmarshalHeader(b, &u); err != nil {
+		t.Fatal(err)
+	}
+	if u.ID != id || !u.Header.DownloadSpending.Equals(v158Header.DownloadSpending) || u.Header.Utility != v158Header.Utility {
+		t.Fatal("update set header wasn't converted correctly", u)
+	}
+}
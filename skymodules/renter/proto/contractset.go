@@ -160,6 +160,19 @@ func (cs *ContractSet) PublicKey(id types.FileContractID) (crypto.PublicKey, boo
 	return safeContract.PublicKey(), true
 }
 
+// RecordAccountSpending adds the given ephemeral account spending to the
+// contract with the given id. The contract doesn't need to be acquired, since
+// the spending is independent of the contract's revisions.
+func (cs *ContractSet) RecordAccountSpending(id types.FileContractID, spending skymodules.ContractAccountSpending) error {
+	cs.mu.Lock()
+	safeContract, ok := cs.contracts[id]
+	cs.mu.Unlock()
+	if !ok {
+		return errors.New("contract not found")
+	}
+	return safeContract.RecordAccountSpending(spending)
+}
+
 // ViewAll returns the metadata of each contract in the set. The contracts are
 // not locked.
 func (cs *ContractSet) ViewAll() []skymodules.RenterContract {
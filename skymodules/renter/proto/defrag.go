@@ -0,0 +1,169 @@
+package proto
+
+import (
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// defragBatchSize is the max number of sectors removed from a contract
+	// within a single Write RPC. It is kept small to keep the size of the
+	// host's Merkle proof within the bounds the renter expects for a write.
+	defragBatchSize = 4
+)
+
+var (
+	// ErrNoRefCounter is returned when trying to defrag a contract which
+	// doesn't track the references to its sectors. Only testing builds create
+	// refcounters for contracts.
+	ErrNoRefCounter = errors.New("contract doesn't track references to its sectors, which only testing builds do")
+)
+
+// Defrag removes the sectors without any references from the contract. The
+// gaps left by removed sectors are filled by swapping in sectors from the end
+// of the contract before the end is trimmed. It returns the updated contract
+// and the number of removed sectors.
+func (s *Session) Defrag() (_ skymodules.RenterContract, removed uint64, err error) {
+	sc, haveContract := s.contractSet.Acquire(s.contractID)
+	if !haveContract {
+		return skymodules.RenterContract{}, 0, errors.New("contract not present in contract set")
+	}
+	defer s.contractSet.Return(sc)
+
+	garbage, err := sc.managedGarbageSectors()
+	if err != nil {
+		return sc.Metadata(), 0, errors.AddContext(err, "failed to find unreferenced sectors")
+	}
+	// Remove the sectors in batches. The garbage is computed again for every
+	// batch since the indices change with every write.
+	toRemove := uint64(len(garbage))
+	for removed < toRemove && len(garbage) > 0 {
+		if len(garbage) > defragBatchSize {
+			garbage = garbage[:defragBatchSize]
+		}
+		numSectors := sc.header.LastRevision().NewFileSize / modules.SectorSize
+		_, err = s.write(sc, defragActions(garbage, numSectors))
+		if err != nil {
+			return sc.Metadata(), removed, errors.AddContext(err, "failed to remove unreferenced sectors")
+		}
+		removed += uint64(len(garbage))
+
+		garbage, err = sc.managedGarbageSectors()
+		if err != nil {
+			return sc.Metadata(), removed, errors.AddContext(err, "failed to find unreferenced sectors")
+		}
+	}
+	return sc.Metadata(), removed, nil
+}
+
+// DecrementSectorReferences decrements the reference counters of the sectors at
+// the given indices of the contract with the given id. Sectors without any
+// references are deleted from the host by Defrag.
+func (cs *ContractSet) DecrementSectorReferences(id types.FileContractID, secIdxs ...uint64) error {
+	sc, haveContract := cs.Acquire(id)
+	if !haveContract {
+		return errors.New("contract not present in contract set")
+	}
+	defer cs.Return(sc)
+	return sc.managedDecrementSectorReferences(secIdxs)
+}
+
+// GarbageSectors returns the number of sectors of the contract with the given
+// id which are not referenced anymore.
+func (cs *ContractSet) GarbageSectors(id types.FileContractID) (uint64, error) {
+	sc, haveContract := cs.Acquire(id)
+	if !haveContract {
+		return 0, errors.New("contract not present in contract set")
+	}
+	defer cs.Return(sc)
+	garbage, err := sc.managedGarbageSectors()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(len(garbage)), nil
+}
+
+// managedGarbageSectors returns the indices of the contract's sectors which
+// are not referenced anymore in ascending order.
+func (c *SafeContract) managedGarbageSectors() ([]uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.staticRC == nil {
+		return nil, ErrNoRefCounter
+	}
+	zeros, err := c.staticRC.callZeroCounts()
+	if err != nil {
+		return nil, err
+	}
+	// Ignore counters of sectors which are not part of the contract.
+	garbage := zeros[:0]
+	for _, secIdx := range zeros {
+		if secIdx < uint64(c.merkleRoots.len()) {
+			garbage = append(garbage, secIdx)
+		}
+	}
+	return garbage, nil
+}
+
+// managedDecrementSectorReferences decrements the reference counters of the
+// sectors at the given indices.
+func (c *SafeContract) managedDecrementSectorReferences(secIdxs []uint64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.staticRC == nil {
+		return ErrNoRefCounter
+	}
+	// See makeUpdateRefCounterAppend for this hidden retry.
+	updates, err := c.staticRC.callDecrementMany(secIdxs...)
+	if errors.Contains(err, ErrUpdateWithoutUpdateSession) {
+		if err = c.staticRC.callStartUpdate(); err != nil {
+			return err
+		}
+		updates, err = c.staticRC.callDecrementMany(secIdxs...)
+	}
+	if err != nil {
+		return errors.Compose(err, c.staticRC.callUpdateApplied())
+	}
+	err = c.staticRC.callCreateAndApplyTransaction(updates...)
+	return errors.Compose(err, c.staticRC.callUpdateApplied())
+}
+
+// defragActions returns the write actions which remove the sectors at the
+// given sorted indices from a contract with numSectors sectors. Every removed
+// sector in front of the new end of the contract is swapped with a remaining
+// sector from behind the new end. Afterwards the contract is trimmed. Every
+// trim only removes a single sector since that is what the host's Merkle proof
+// covers.
+func defragActions(garbage []uint64, numSectors uint64) []modules.LoopWriteAction {
+	isGarbage := make(map[uint64]struct{}, len(garbage))
+	for _, idx := range garbage {
+		isGarbage[idx] = struct{}{}
+	}
+	newNumSectors := numSectors - uint64(len(garbage))
+	var actions []modules.LoopWriteAction
+	end := numSectors
+	for _, idx := range garbage {
+		if idx >= newNumSectors {
+			break // trimmed anyway
+		}
+		// Find the last remaining sector.
+		end--
+		for _, ok := isGarbage[end]; ok; _, ok = isGarbage[end] {
+			end--
+		}
+		actions = append(actions, modules.LoopWriteAction{
+			Type: modules.WriteActionSwap,
+			A:    idx,
+			B:    end,
+		})
+	}
+	for range garbage {
+		actions = append(actions, modules.LoopWriteAction{
+			Type: modules.WriteActionTrim,
+			A:    1,
+		})
+	}
+	return actions
+}
@@ -0,0 +1,81 @@
+package proto
+
+import (
+	"reflect"
+	"testing"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestDefragActions is a unit test for defragActions.
+func TestDefragActions(t *testing.T) {
+	t.Parallel()
+
+	swap := func(a, b uint64) modules.LoopWriteAction {
+		return modules.LoopWriteAction{Type: modules.WriteActionSwap, A: a, B: b}
+	}
+	trim := modules.LoopWriteAction{Type: modules.WriteActionTrim, A: 1}
+
+	tests := []struct {
+		desc       string
+		garbage    []uint64
+		numSectors uint64
+		exp        []modules.LoopWriteAction
+	}{
+		{
+			desc:       "End",
+			garbage:    []uint64{4, 5},
+			numSectors: 6,
+			exp:        []modules.LoopWriteAction{trim, trim},
+		},
+		{
+			desc:       "Front",
+			garbage:    []uint64{0, 1},
+			numSectors: 6,
+			exp:        []modules.LoopWriteAction{swap(0, 5), swap(1, 4), trim, trim},
+		},
+		{
+			desc:       "Mixed",
+			garbage:    []uint64{0, 2, 5},
+			numSectors: 6,
+			exp:        []modules.LoopWriteAction{swap(0, 4), swap(2, 3), trim, trim, trim},
+		},
+		{
+			desc:       "All",
+			garbage:    []uint64{0, 1, 2},
+			numSectors: 3,
+			exp:        []modules.LoopWriteAction{trim, trim, trim},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			actions := defragActions(test.garbage, test.numSectors)
+			if !reflect.DeepEqual(actions, test.exp) {
+				t.Fatalf("wrong actions: expected %v, got %v", test.exp, actions)
+			}
+			// Applying the actions should remove all the garbage.
+			sectors := make([]uint64, test.numSectors)
+			for i := range sectors {
+				sectors[i] = uint64(i)
+			}
+			for _, action := range actions {
+				switch action.Type {
+				case modules.WriteActionSwap:
+					sectors[action.A], sectors[action.B] = sectors[action.B], sectors[action.A]
+				case modules.WriteActionTrim:
+					sectors = sectors[:len(sectors)-1]
+				}
+			}
+			for _, sector := range sectors {
+				for _, idx := range test.garbage {
+					if sector == idx {
+						t.Fatal("garbage sector wasn't removed", idx)
+					}
+				}
+			}
+			if uint64(len(sectors)) != test.numSectors-uint64(len(test.garbage)) {
+				t.Fatal("wrong number of remaining sectors", len(sectors))
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package proto
+
+import (
+	stderrors "errors"
+	"io"
+	"net"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+// Errors returned by the renewal protocols fall into three categories. Local
+// failures, e.g. failing to sign a transaction, are caused by the renter
+// itself. Network failures are caused by the connection to the host. All
+// other failures are host protocol failures. Only the latter are the host's
+// fault and penalize the host.
+
+// localFault marks an error as caused by the renter itself.
+func localFault(err error) error {
+	if err == nil {
+		return nil
+	}
+	return errors.Extend(err, skymodules.ErrLocalFault)
+}
+
+// isNetworkError returns true if the error or any of the errors it is composed
+// of was caused by the connection to the host.
+func isNetworkError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if composed, ok := err.(errors.Error); ok {
+		for _, e := range composed.ErrSet {
+			if isNetworkError(e) {
+				return true
+			}
+		}
+		return false
+	}
+	var netErr net.Error
+	return stderrors.As(err, &netErr) || stderrors.Is(err, io.EOF) || stderrors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// classifyFault classifies an error returned by an interaction with a host and
+// extends it with the corresponding fault. The returned bool indicates whether
+// the host is at fault.
+func classifyFault(err error) (bool, error) {
+	switch {
+	case err == nil:
+		return false, nil
+	case skymodules.IsLocalFault(err), skymodules.IsNetworkFault(err):
+		return false, err
+	case skymodules.IsHostsFault(err):
+		return true, err
+	case isNetworkError(err):
+		return false, errors.Extend(err, skymodules.ErrNetworkFault)
+	default:
+		return true, errors.Extend(err, skymodules.ErrHostFault)
+	}
+}
@@ -0,0 +1,62 @@
+package proto
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+// TestClassifyFault is a unit test for classifyFault.
+func TestClassifyFault(t *testing.T) {
+	t.Parallel()
+
+	// Create a network error by dialing an address nobody listens on.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	_, dialErr := net.Dial("tcp", addr)
+	if dialErr == nil {
+		t.Fatal("expected dial to fail")
+	}
+
+	tests := []struct {
+		name      string
+		err       error
+		hostFault bool
+		fault     error
+	}{
+		{"nil", nil, false, nil},
+		{"protocol", errors.New("host sent invalid signature"), true, skymodules.ErrHostFault},
+		{"local", localFault(errors.New("failed to sign transaction")), false, skymodules.ErrLocalFault},
+		{"local with context", errors.AddContext(localFault(errors.New("wal")), "context"), false, skymodules.ErrLocalFault},
+		{"dial", errors.AddContext(dialErr, "unsuccessful dial"), false, skymodules.ErrNetworkFault},
+		{"eof", io.EOF, false, skymodules.ErrNetworkFault},
+		{"wrapped eof", fmt.Errorf("failed to read response: %w", io.ErrUnexpectedEOF), false, skymodules.ErrNetworkFault},
+		{"composed eof", errors.Compose(errors.New("read failed"), io.ErrUnexpectedEOF), false, skymodules.ErrNetworkFault},
+		{"already classified", errors.Extend(io.EOF, skymodules.ErrHostFault), true, skymodules.ErrHostFault},
+	}
+	for _, test := range tests {
+		hostFault, err := classifyFault(test.err)
+		if hostFault != test.hostFault {
+			t.Errorf("%v: expected host fault %v but got %v", test.name, test.hostFault, hostFault)
+		}
+		if test.err == nil {
+			if err != nil {
+				t.Errorf("%v: expected nil error but got %v", test.name, err)
+			}
+			continue
+		}
+		if !errors.Contains(err, test.fault) {
+			t.Errorf("%v: expected %v but got %v", test.name, test.fault, err)
+		}
+	}
+}
@@ -210,6 +210,39 @@ func (mr *merkleRoots) delete(i int, lastRoot crypto.Hash, truncateSize int64) e
 	return nil
 }
 
+// truncate removes all roots starting at index numRoots from the merkleRoots.
+// Truncating to a number of roots that is greater than or equal to the current
+// number of roots is a no-op which makes the operation indempotent.
+func (mr *merkleRoots) truncate(numRoots int) error {
+	if numRoots >= mr.numMerkleRoots {
+		return nil
+	}
+	if numRoots < 0 {
+		return errors.New("can't truncate to a negative number of roots")
+	}
+	// Truncate the file.
+	if err := mr.rootsFile.Truncate(fileOffsetFromRootIndex(numRoots)); err != nil {
+		return errors.AddContext(err, "failed to truncate file")
+	}
+	mr.numMerkleRoots = numRoots
+	// If the remaining roots still contain all the cached subTrees, we only
+	// need to shorten the uncached roots.
+	numCached := numRoots / merkleRootsPerCache
+	if numCached == len(mr.cachedSubTrees) {
+		mr.uncachedRoots = mr.uncachedRoots[:numRoots-numCached*merkleRootsPerCache]
+		return nil
+	}
+	// Otherwise we drop the truncated subTrees and load the remaining roots
+	// of the last partially truncated one into mr.uncachedRoots.
+	mr.cachedSubTrees = mr.cachedSubTrees[:numCached]
+	roots, err := mr.merkleRootsFromIndexFromDisk(numCached*merkleRootsPerCache, numRoots)
+	if err != nil {
+		return errors.AddContext(err, "failed to read truncated cached tree's roots")
+	}
+	mr.uncachedRoots = append(mr.uncachedRoots[:0], roots...)
+	return nil
+}
+
 // insert inserts a root by replacing a root at an existing index.
 func (mr *merkleRoots) insert(index int, root crypto.Hash) error {
 	// If the index does point to an offset beyond the end of the file we fill
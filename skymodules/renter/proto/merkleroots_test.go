@@ -378,6 +378,67 @@ func TestDelete(t *testing.T) {
 	}
 }
 
+// TestTruncate tests truncating the merkle roots by multiple roots at once.
+func TestTruncate(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	dir := build.TempDir(t.Name())
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	filePath := path.Join(dir, "file.dat")
+	file, err := os.Create(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create many sector roots.
+	numMerkleRoots := 1000
+	merkleRoots := newMerkleRoots(file)
+	var expected []crypto.Hash
+	for i := 0; i < numMerkleRoots; i++ {
+		hash := crypto.Hash{}
+		copy(hash[:], fastrand.Bytes(crypto.HashSize)[:])
+		merkleRoots.push(hash)
+		expected = append(expected, hash)
+	}
+
+	for merkleRoots.numMerkleRoots > 0 {
+		// Truncate a random number of roots. Sometimes within the uncached
+		// roots and sometimes across multiple cached subTrees.
+		numRoots := merkleRoots.numMerkleRoots - fastrand.Intn(3*merkleRootsPerCache/2) - 1
+		if numRoots < 0 {
+			numRoots = 0
+		}
+		// Call truncate twice to make sure it's idempotent.
+		if err := merkleRoots.truncate(numRoots); err != nil {
+			t.Fatal(err)
+		}
+		if err := merkleRoots.truncate(numRoots); err != nil {
+			t.Fatal(err)
+		}
+		expected = expected[:numRoots]
+		if merkleRoots.len() != numRoots {
+			t.Fatal("wrong number of roots", merkleRoots.len(), numRoots)
+		}
+		if n, err := merkleRoots.lenFromFile(); err != nil || n != numRoots {
+			t.Fatal("wrong number of roots on disk", n, numRoots, err)
+		}
+		// The in-memory structure should match reloading the roots.
+		loadedRoots, applyTxns, err := loadExistingMerkleRootsFromSection(merkleRoots.rootsFile)
+		if err != nil || applyTxns {
+			t.Fatal("failed to load existing roots", err)
+		}
+		if err := cmpRoots(loadedRoots, merkleRoots); err != nil {
+			t.Fatal(err)
+		}
+		if merkleRoots.root() != cachedMerkleRoot(expected) {
+			t.Fatal("wrong root after truncating")
+		}
+	}
+}
+
 // TestMerkleRootsRandom creates a large number of merkle roots and runs random
 // valid operations on them that shouldn't result in any errors.
 func TestMerkleRootsRandom(t *testing.T) {
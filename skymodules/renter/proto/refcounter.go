@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"sort"
 	"sync"
 
 	siasync "go.sia.tech/siad/sync"
@@ -204,6 +205,16 @@ func (rc *refCounter) callAppend() (writeaheadlog.Update, error) {
 	return createWriteAtUpdate(rc.filepath, rc.numSectors-1, 1), nil
 }
 
+// callApplyDiff changes the reference counters of multiple sectors by the
+// given deltas. It returns a single set of updates with one update for every
+// changed sector. If any of the counters would over- or underflow, no counter is
+// changed.
+func (rc *refCounter) callApplyDiff(diff map[uint64]int64) ([]writeaheadlog.Update, error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.applyDiff(diff)
+}
+
 // callCount returns the number of references to the given sector
 func (rc *refCounter) callCount(secIdx uint64) (uint16, error) {
 	rc.mu.Lock()
@@ -211,6 +222,36 @@ func (rc *refCounter) callCount(secIdx uint64) (uint16, error) {
 	return rc.readCount(secIdx)
 }
 
+// callZeroCounts returns the indices of all the sectors without any references
+// in ascending order. It reads the whole file at once which is a lot faster
+// than calling callCount for every sector.
+func (rc *refCounter) callZeroCounts() (_ []uint64, err error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	f, err := rc.staticDeps.Open(rc.filepath)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to open the refcounter file")
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+	b := make([]byte, 2*rc.numSectors)
+	if _, err = f.ReadAt(b, refCounterHeaderSize); err != nil {
+		return nil, errors.AddContext(err, "failed to read from refcounter file")
+	}
+	var zeros []uint64
+	for secIdx := uint64(0); secIdx < rc.numSectors; secIdx++ {
+		count, ok := rc.newSectorCounts[secIdx]
+		if !ok {
+			count = binary.LittleEndian.Uint16(b[2*secIdx:])
+		}
+		if count == 0 {
+			zeros = append(zeros, secIdx)
+		}
+	}
+	return zeros, nil
+}
+
 // callCreateAndApplyTransaction is a helper method that creates a writeaheadlog
 // transaction and applies it.
 func (rc *refCounter) callCreateAndApplyTransaction(updates ...writeaheadlog.Update) error {
@@ -294,6 +335,18 @@ func (rc *refCounter) callDecrement(secIdx uint64) (writeaheadlog.Update, error)
 	return createWriteAtUpdate(rc.filepath, secIdx, count), nil
 }
 
+// callDecrementMany decrements the reference counters of the given sectors. A
+// sector which is given multiple times is decremented multiple times.
+func (rc *refCounter) callDecrementMany(secIdxs ...uint64) ([]writeaheadlog.Update, error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	diff := make(map[uint64]int64, len(secIdxs))
+	for _, secIdx := range secIdxs {
+		diff[secIdx]--
+	}
+	return rc.applyDiff(diff)
+}
+
 // callDeleteRefCounter deletes the counter's file from disk
 func (rc *refCounter) callDeleteRefCounter() (writeaheadlog.Update, error) {
 	rc.mu.Lock()
@@ -353,6 +406,18 @@ func (rc *refCounter) callIncrement(secIdx uint64) (writeaheadlog.Update, error)
 	return createWriteAtUpdate(rc.filepath, secIdx, count), nil
 }
 
+// callIncrementMany increments the reference counters of the given sectors. A
+// sector which is given multiple times is incremented multiple times.
+func (rc *refCounter) callIncrementMany(secIdxs ...uint64) ([]writeaheadlog.Update, error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	diff := make(map[uint64]int64, len(secIdxs))
+	for _, secIdx := range secIdxs {
+		diff[secIdx]++
+	}
+	return rc.applyDiff(diff)
+}
+
 // callSetCount sets the value of the reference counter of a given sector. The
 // sector is specified by its sequential number (secIdx).
 func (rc *refCounter) callSetCount(secIdx uint64, c uint16) (writeaheadlog.Update, error) {
@@ -443,6 +508,55 @@ func (rc *refCounter) managedStartUpdate() error {
 	return nil
 }
 
+// applyDiff changes the reference counters of multiple sectors by the given
+// deltas. The new counts are validated before any of them is changed so a
+// failed diff doesn't leave a partially applied diff behind.
+func (rc *refCounter) applyDiff(diff map[uint64]int64) ([]writeaheadlog.Update, error) {
+	if !rc.isUpdateInProgress {
+		return nil, ErrUpdateWithoutUpdateSession
+	}
+	if rc.isDeleted {
+		return nil, ErrUpdateAfterDelete
+	}
+	// Sort the sectors to create the updates in a deterministic order.
+	secIdxs := make([]uint64, 0, len(diff))
+	for secIdx, delta := range diff {
+		if delta == 0 {
+			continue
+		}
+		if secIdx >= rc.numSectors {
+			return nil, errors.AddContext(ErrInvalidSectorNumber, "failed to apply diff")
+		}
+		secIdxs = append(secIdxs, secIdx)
+	}
+	sort.Slice(secIdxs, func(i, j int) bool {
+		return secIdxs[i] < secIdxs[j]
+	})
+	// Compute the new counts.
+	counts := make([]uint16, len(secIdxs))
+	for i, secIdx := range secIdxs {
+		count, err := rc.readCount(secIdx)
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to read count from diff")
+		}
+		newCount := int64(count) + diff[secIdx]
+		if newCount < 0 {
+			return nil, errors.New("sector count underflow")
+		}
+		if newCount > math.MaxUint16 {
+			return nil, errors.New("sector count overflow")
+		}
+		counts[i] = uint16(newCount)
+	}
+	// Apply them.
+	updates := make([]writeaheadlog.Update, 0, len(secIdxs))
+	for i, secIdx := range secIdxs {
+		rc.newSectorCounts[secIdx] = counts[i]
+		updates = append(updates, createWriteAtUpdate(rc.filepath, secIdx, counts[i]))
+	}
+	return updates, nil
+}
+
 // readCount reads the given sector count either from disk (if there are no
 // pending updates) or from the in-memory cache (if there are).
 func (rc *refCounter) readCount(secIdx uint64) (_ uint16, err error) {
@@ -448,6 +448,100 @@ func TestRefCounterIncrement(t *testing.T) {
 	}
 }
 
+// TestRefCounterBatch tests the batch operations of the refcounter.
+func TestRefCounterBatch(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// prepare a refcounter with 4 sectors with a count of 1 each
+	rc := testPrepareRefCounter(4, t)
+
+	// the batch operations require an update session
+	_, err := rc.callIncrementMany(0)
+	if !errors.Contains(err, ErrUpdateWithoutUpdateSession) {
+		t.Fatal("Expected ErrUpdateWithoutUpdateSession, got:", err)
+	}
+	err = rc.callStartUpdate()
+	if err != nil {
+		t.Fatal("Failed to start an update session", err)
+	}
+
+	// checkCounts checks the counts of all sectors
+	checkCounts := func(expected ...uint16) {
+		t.Helper()
+		for secIdx, c := range expected {
+			val, err := rc.readCount(uint64(secIdx))
+			if err != nil {
+				t.Fatal("Failed to read value:", err)
+			}
+			if val != c {
+				t.Fatalf("read wrong value for sector %v. Expected %d, got %d", secIdx, c, val)
+			}
+		}
+	}
+
+	// increment sector 0 twice and sector 2 once. Duplicate sectors result
+	// in a single update.
+	var updates []writeaheadlog.Update
+	us, err := rc.callIncrementMany(0, 2, 0)
+	if err != nil {
+		t.Fatal("Failed to create increment updates:", err)
+	}
+	if len(us) != 2 {
+		t.Fatal("wrong number of updates", len(us))
+	}
+	updates = append(updates, us...)
+	checkCounts(3, 1, 2, 1)
+
+	// decrement sector 2 and sector 3
+	us, err = rc.callDecrementMany(3, 2)
+	if err != nil {
+		t.Fatal("Failed to create decrement updates:", err)
+	}
+	updates = append(updates, us...)
+	checkCounts(3, 1, 1, 0)
+
+	// apply a diff which changes multiple sectors at once, deltas of 0 are
+	// ignored
+	us, err = rc.callApplyDiff(map[uint64]int64{0: -3, 1: 0, 3: 1000})
+	if err != nil {
+		t.Fatal("Failed to create diff updates:", err)
+	}
+	if len(us) != 2 {
+		t.Fatal("wrong number of updates", len(us))
+	}
+	updates = append(updates, us...)
+	checkCounts(0, 1, 1, 1000)
+
+	// invalid diffs don't change any counts
+	_, err = rc.callDecrementMany(1, 0)
+	if err == nil || err.Error() != "sector count underflow" {
+		t.Fatal("Expected underflow, got:", err)
+	}
+	_, err = rc.callApplyDiff(map[uint64]int64{2: 1, 3: math.MaxUint16})
+	if err == nil || err.Error() != "sector count overflow" {
+		t.Fatal("Expected overflow, got:", err)
+	}
+	_, err = rc.callIncrementMany(1, rc.numSectors)
+	if !errors.Contains(err, ErrInvalidSectorNumber) {
+		t.Fatal("Expected ErrInvalidSectorNumber, got:", err)
+	}
+	checkCounts(0, 1, 1, 1000)
+
+	// apply the updates in a single transaction and check the values on disk
+	err = rc.callCreateAndApplyTransaction(updates...)
+	if err != nil {
+		t.Fatal("Failed to apply updates:", err)
+	}
+	err = rc.callUpdateApplied()
+	if err != nil {
+		t.Fatal("Failed to finish the update session:", err)
+	}
+	checkCounts(0, 1, 1, 1000)
+}
+
 // TestRefCounterLoad specifically tests refcounter's Load method
 func TestRefCounterLoad(t *testing.T) {
 	if testing.Short() {
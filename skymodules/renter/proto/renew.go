@@ -72,14 +72,20 @@ func (cs *ContractSet) managedNewRenewAndClear(oldContract *SafeContract, params
 		return skymodules.RenterContract{}, nil, err
 	}
 
-	// Increase Successful/Failed interactions accordingly
+	// Increase Successful/Failed interactions accordingly. Only protocol
+	// failures are the host's fault. A revision mismatch might not be the
+	// host's fault either.
 	defer func() {
-		// A revision mismatch might not be the host's fault.
-		if err != nil && !IsRevisionMismatch(err) {
-			hdb.IncrementFailedInteractions(contract.HostPublicKey())
-			err = errors.Extend(err, skymodules.ErrHostFault)
-		} else if err == nil {
+		if err == nil {
 			hdb.IncrementSuccessfulInteractions(contract.HostPublicKey())
+			return
+		} else if IsRevisionMismatch(err) {
+			return
+		}
+		var hostFault bool
+		hostFault, err = classifyFault(err)
+		if hostFault {
+			hdb.IncrementFailedInteractions(contract.HostPublicKey())
 		}
 	}()
 
@@ -104,7 +110,7 @@ func (cs *ContractSet) managedNewRenewAndClear(oldContract *SafeContract, params
 	bandwidthCost := host.BaseRPCPrice
 	finalRev, err := prepareFinalRevision(contract, bandwidthCost)
 	if err != nil {
-		return skymodules.RenterContract{}, nil, errors.AddContext(err, "Unable to create final revision")
+		return skymodules.RenterContract{}, nil, localFault(errors.AddContext(err, "Unable to create final revision"))
 	}
 
 	// Create the RenewContract request.
@@ -127,7 +133,7 @@ func (cs *ContractSet) managedNewRenewAndClear(oldContract *SafeContract, params
 	// Record the changes we are about to make to the contract.
 	walTxn, err := oldContract.managedRecordClearContractIntent(finalRev, bandwidthCost)
 	if err != nil {
-		return skymodules.RenterContract{}, nil, err
+		return skymodules.RenterContract{}, nil, localFault(err)
 	}
 
 	// Read the host's response.
@@ -171,7 +177,7 @@ func (cs *ContractSet) managedNewRenewAndClear(oldContract *SafeContract, params
 	if err != nil {
 		err = errors.New("failed to sign transaction: " + err.Error())
 		modules.WriteRPCResponse(s.conn, s.aead, nil, err)
-		return skymodules.RenterContract{}, nil, err
+		return skymodules.RenterContract{}, nil, localFault(err)
 	}
 
 	// calculate signatures added by the transaction builder
@@ -209,7 +215,7 @@ func (cs *ContractSet) managedNewRenewAndClear(oldContract *SafeContract, params
 	// Construct the final transaction.
 	txnSet, err = prepareTransactionSet(txnBuilder)
 	if err != nil {
-		return skymodules.RenterContract{}, nil, err
+		return skymodules.RenterContract{}, nil, localFault(err)
 	}
 
 	// Submit to blockchain.
@@ -249,17 +255,17 @@ func (cs *ContractSet) managedNewRenewAndClear(oldContract *SafeContract, params
 	// Get old roots
 	oldRoots, err := oldContract.merkleRoots.merkleRoots()
 	if err != nil {
-		return skymodules.RenterContract{}, nil, err
+		return skymodules.RenterContract{}, nil, localFault(err)
 	}
 
 	// Add contract to set.
 	meta, err := cs.managedInsertContract(header, oldRoots)
 	if err != nil {
-		return skymodules.RenterContract{}, nil, err
+		return skymodules.RenterContract{}, nil, localFault(err)
 	}
 	// Commit changes to old contract.
 	if err := oldContract.managedCommitClearContract(walTxn, finalRevTxn, bandwidthCost); err != nil {
-		return skymodules.RenterContract{}, nil, err
+		return skymodules.RenterContract{}, nil, localFault(err)
 	}
 	return meta, txnSet, nil
 }
@@ -463,13 +469,17 @@ func (cs *ContractSet) RenewContract(conn net.Conn, fcid types.FileContractID, p
 		return skymodules.RenterContract{}, nil, errors.AddContext(err, "failed to prepare txnSet with finalRev and new contract")
 	}
 
-	// Increase Successful/Failed interactions accordingly
+	// Increase Successful/Failed interactions accordingly. Only protocol
+	// failures are the host's fault.
 	defer func() {
-		if err != nil {
-			hdb.IncrementFailedInteractions(host.PublicKey)
-			err = errors.Compose(err, skymodules.ErrHostFault)
-		} else if err == nil {
+		if err == nil {
 			hdb.IncrementSuccessfulInteractions(host.PublicKey)
+			return
+		}
+		var hostFault bool
+		hostFault, err = classifyFault(err)
+		if hostFault {
+			hdb.IncrementFailedInteractions(host.PublicKey)
 		}
 	}()
 
@@ -530,7 +540,7 @@ func (cs *ContractSet) RenewContract(conn net.Conn, fcid types.FileContractID, p
 	_ = txnBuilder.AddTransactionSignature(finalRevHostSig)
 	signedTxnSet, err := txnBuilder.Sign(true)
 	if err != nil {
-		return skymodules.RenterContract{}, nil, errors.AddContext(err, "failed to sign transaction set")
+		return skymodules.RenterContract{}, nil, localFault(errors.AddContext(err, "failed to sign transaction set"))
 	}
 
 	// Calculate signatures added by the transaction builder
@@ -565,7 +575,7 @@ func (cs *ContractSet) RenewContract(conn net.Conn, fcid types.FileContractID, p
 	// Construct the final transaction.
 	txnSet, err = prepareTransactionSet(txnBuilder)
 	if err != nil {
-		return skymodules.RenterContract{}, nil, errors.AddContext(err, "failed to prepare txnSet with finalRev and new contract")
+		return skymodules.RenterContract{}, nil, localFault(errors.AddContext(err, "failed to prepare txnSet with finalRev and new contract"))
 	}
 
 	// Submit the txn set with the final revision and new contract to the blockchain.
@@ -597,18 +607,18 @@ func (cs *ContractSet) RenewContract(conn net.Conn, fcid types.FileContractID, p
 	// Get old roots
 	oldRoots, err := oldSC.merkleRoots.merkleRoots()
 	if err != nil {
-		return skymodules.RenterContract{}, nil, err
+		return skymodules.RenterContract{}, nil, localFault(err)
 	}
 
 	// Add contract to set.
 	newContract, err := cs.managedInsertContract(header, oldRoots)
 	if err != nil {
-		return skymodules.RenterContract{}, nil, err
+		return skymodules.RenterContract{}, nil, localFault(err)
 	}
 
 	// Commit changes to old contract.
 	if err := oldSC.managedCommitClearContract(walTxn, finalRevTxn, renewCost); err != nil {
-		return skymodules.RenterContract{}, nil, err
+		return skymodules.RenterContract{}, nil, localFault(err)
 	}
 	return newContract, txnSet, nil
 }
@@ -229,7 +229,7 @@ func (s *Session) write(sc *SafeContract, actions []modules.LoopWriteAction) (_
 				if err != nil {
 					return skymodules.RenterContract{}, err
 				}
-				ruA = newRootUpdateUpdateRoot(rootA)
+				ruA = newRootUpdateUpdateRoot(rootA, action.A)
 			}
 			ruB, existsB := rootUpdates[action.B]
 			if !existsB {
@@ -237,9 +237,11 @@ func (s *Session) write(sc *SafeContract, actions []modules.LoopWriteAction) (_
 				if err != nil {
 					return skymodules.RenterContract{}, err
 				}
-				ruB = newRootUpdateUpdateRoot(rootB)
+				ruB = newRootUpdateUpdateRoot(rootB, action.B)
 			}
 			ruA.root, ruB.root = ruB.root, ruA.root
+			ruA.existing, ruB.existing = ruB.existing, ruA.existing
+			ruA.srcIndex, ruB.srcIndex = ruB.srcIndex, ruA.srcIndex
 			rootUpdates[action.A] = ruA
 			rootUpdates[action.B] = ruB
 
@@ -920,13 +922,17 @@ func (cs *ContractSet) NewRawSession(host skymodules.HostDBEntry, currentHeight
 
 // managedNewSession initiates the RPC loop with a host and returns a Session.
 func (cs *ContractSet) managedNewSession(host skymodules.HostDBEntry, currentHeight types.BlockHeight, hdb hostDB, cancel <-chan struct{}) (_ *Session, err error) {
-	// Increase Successful/Failed interactions accordingly
+	// Increase Successful/Failed interactions accordingly. Network failures
+	// such as an unsuccessful dial are not the host's fault.
 	defer func() {
-		if err != nil {
-			hdb.IncrementFailedInteractions(host.PublicKey)
-			err = errors.Extend(err, skymodules.ErrHostFault)
-		} else {
+		if err == nil {
 			hdb.IncrementSuccessfulInteractions(host.PublicKey)
+			return
+		}
+		var hostFault bool
+		hostFault, err = classifyFault(err)
+		if hostFault {
+			hdb.IncrementFailedInteractions(host.PublicKey)
 		}
 	}()
 
@@ -1051,8 +1057,8 @@ func modifyLeaves(leafHashes []crypto.Hash, actions []modules.LoopWriteAction, n
 			numSectors++
 		case modules.WriteActionTrim:
 			for j := uint64(0); j < action.A; j++ {
-				indices = append(indices, numSectors)
 				numSectors--
+				indices = append(indices, numSectors)
 			}
 		case modules.WriteActionSwap:
 			indices = append(indices, action.A, action.B)
@@ -1062,11 +1068,11 @@ func modifyLeaves(leafHashes []crypto.Hash, actions []modules.LoopWriteAction, n
 		return indices[i] < indices[j]
 	})
 	indexMap := make(map[uint64]int, len(leafHashes))
-	for i, index := range indices {
-		if i > 0 && index == indices[i-1] {
+	for _, index := range indices {
+		if _, exists := indexMap[index]; exists {
 			continue // remove duplicates
 		}
-		indexMap[index] = i
+		indexMap[index] = len(indexMap)
 	}
 
 	for _, action := range actions {
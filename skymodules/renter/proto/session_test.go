@@ -238,6 +238,20 @@ func TestModifyLeafHashes(t *testing.T) {
 			leaves: []crypto.Hash{{1}, {2}, {3}, {4}},
 			exp:    []crypto.Hash{{4}, {3}, {2}, crypto.MerkleRoot([]byte{1, 2, 3}), crypto.MerkleRoot([]byte{4, 5, 6})},
 		},
+		{
+			desc:       "SwapSwapSwapTrimTrimTrim",
+			numSectors: 6,
+			actions: []modules.LoopWriteAction{
+				{Type: modules.WriteActionSwap, A: 0, B: 5},
+				{Type: modules.WriteActionSwap, A: 1, B: 4},
+				{Type: modules.WriteActionSwap, A: 2, B: 3},
+				{Type: modules.WriteActionTrim, A: 1},
+				{Type: modules.WriteActionTrim, A: 1},
+				{Type: modules.WriteActionTrim, A: 1},
+			},
+			leaves: []crypto.Hash{{0}, {1}, {2}, {3}, {4}, {5}},
+			exp:    []crypto.Hash{{5}, {4}, {3}},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.desc, func(t *testing.T) {
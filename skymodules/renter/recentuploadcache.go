@@ -0,0 +1,165 @@
+package renter
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"gitlab.com/SkynetLabs/skyd/skymodules/renter/filesystem"
+	"go.sia.tech/siad/crypto"
+)
+
+// The recent upload cache keeps a local copy of the base sectors of recently
+// uploaded skyfiles. Right after an upload, the base sector is only stored on
+// a few hosts and downloading it back is slow. Serving the base sector from
+// disk instead speeds up sharing a skylink right after uploading it. Since
+// small skyfiles are stored entirely within their base sector, they are served
+// without contacting any hosts.
+//
+// A base sector is evicted once its siafile reached full redundancy, once it
+// exceeds its max age or once the size of the cache exceeds its budget.
+
+const (
+	// recentUploadCacheDir is the name of the directory within the renter's
+	// persist dir which holds the recently uploaded base sectors.
+	recentUploadCacheDir = "recentuploadcache"
+)
+
+var (
+	// recentUploadCacheMaxAge is the time after which a base sector is evicted
+	// from the recent upload cache even if its siafile hasn't reached full
+	// redundancy yet.
+	recentUploadCacheMaxAge = build.Select(build.Var{
+		Dev:      10 * time.Minute,
+		Standard: time.Hour,
+		Testing:  time.Minute,
+	}).(time.Duration)
+
+	// recentUploadCachePruneInterval is the interval at which the renter
+	// evicts base sectors from the recent upload cache.
+	recentUploadCachePruneInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Minute,
+		Testing:  time.Second,
+	}).(time.Duration)
+)
+
+type (
+	// recentUploadCache is a size-bounded on-disk cache for the base sectors
+	// of recently uploaded skyfiles.
+	recentUploadCache struct {
+		// uploads maps the merkle roots of the cached base sectors to the
+		// siafiles they were uploaded to.
+		uploads map[crypto.Hash]recentUpload
+
+		staticSectors *sectorCache
+		mu            sync.Mutex
+	}
+
+	// recentUpload is a cached base sector of a recent upload.
+	recentUpload struct {
+		siaPath skymodules.SiaPath
+		added   time.Time
+	}
+)
+
+// newRecentUploadCache creates a new recent upload cache in the given dir.
+// Since the siafiles of base sectors cached by a previous run are unknown, the
+// dir is cleared first.
+func newRecentUploadCache(dir string, maxSize uint64) (*recentUploadCache, error) {
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, errors.AddContext(err, "failed to clear recent upload cache dir")
+	}
+	sc, err := newSectorCache(dir, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	return &recentUploadCache{
+		uploads:       make(map[crypto.Hash]recentUpload),
+		staticSectors: sc,
+	}, nil
+}
+
+// managedAdd adds the base sector which was uploaded to the siafile at the
+// given siapath to the cache.
+func (ruc *recentUploadCache) managedAdd(root crypto.Hash, siaPath skymodules.SiaPath, baseSector []byte) error {
+	if err := ruc.staticSectors.managedAdd(root, baseSector); err != nil {
+		return err
+	}
+	ruc.mu.Lock()
+	ruc.uploads[root] = recentUpload{
+		siaPath: siaPath,
+		added:   time.Now(),
+	}
+	ruc.mu.Unlock()
+	return nil
+}
+
+// managedGet returns the base sector with the given root from the cache.
+func (ruc *recentUploadCache) managedGet(root crypto.Hash) ([]byte, error) {
+	return ruc.staticSectors.managedGet(root)
+}
+
+// managedRemove removes a base sector from the cache.
+func (ruc *recentUploadCache) managedRemove(root crypto.Hash) {
+	ruc.mu.Lock()
+	delete(ruc.uploads, root)
+	ruc.mu.Unlock()
+	ruc.staticSectors.managedRemove(root)
+}
+
+// managedPrune evicts the base sectors which are older than the max age or of
+// which the siafile reached full redundancy. The health of a siafile is
+// fetched using the health func. Base sectors of siafiles which no longer
+// exist are evicted as well.
+func (ruc *recentUploadCache) managedPrune(maxAge time.Duration, health func(skymodules.SiaPath) (float64, error)) error {
+	ruc.mu.Lock()
+	uploads := make(map[crypto.Hash]recentUpload, len(ruc.uploads))
+	for root, upload := range ruc.uploads {
+		uploads[root] = upload
+	}
+	ruc.mu.Unlock()
+
+	var errs error
+	for root, upload := range uploads {
+		if time.Since(upload.added) > maxAge {
+			ruc.managedRemove(root)
+			continue
+		}
+		h, err := health(upload.siaPath)
+		if errors.Contains(err, filesystem.ErrNotExist) || (err == nil && h <= 0) {
+			ruc.managedRemove(root)
+			continue
+		}
+		errs = errors.Compose(errs, err)
+	}
+	return errs
+}
+
+// managedAddRecentUpload adds an uploaded base sector to the recent upload
+// cache if the cache is enabled.
+func (r *Renter) managedAddRecentUpload(root crypto.Hash, siaPath skymodules.SiaPath, baseSector []byte) {
+	ruc := r.staticRecentUploadCache
+	if ruc == nil {
+		return
+	}
+	if err := ruc.managedAdd(root, siaPath, baseSector); err != nil {
+		r.staticLog.Printf("WARN: failed to add base sector %v to recent upload cache: %v", root, err)
+	}
+}
+
+// managedPruneRecentUploadCacheJob evicts the base sectors of siafiles which
+// reached full redundancy from the recent upload cache.
+func (r *Renter) managedPruneRecentUploadCacheJob() {
+	health := func(siaPath skymodules.SiaPath) (float64, error) {
+		fi, err := r.File(siaPath)
+		return fi.Health, err
+	}
+	err := r.staticRecentUploadCache.managedPrune(recentUploadCacheMaxAge, health)
+	if err != nil {
+		r.staticLog.Println("WARN: failed to prune recent upload cache:", err)
+	}
+}
@@ -0,0 +1,105 @@
+package renter
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"gitlab.com/SkynetLabs/skyd/skymodules/renter/filesystem"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestRecentUploadCache is a unit test for the recentUploadCache.
+func TestRecentUploadCache(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	// Leave a base sector of a previous run behind which should be removed.
+	sector := fastrand.Bytes(int(modules.SectorSize))
+	root := crypto.MerkleRoot(sector)
+	if err := ioutil.WriteFile(filepath.Join(dir, root.String()), sector, 0600); err != nil {
+		t.Fatal(err)
+	}
+	ruc, err := newRecentUploadCache(dir, 10*modules.SectorSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ruc.managedGet(root); !errors.Contains(err, errSectorCacheMiss) {
+		t.Fatal("expected miss", err)
+	}
+
+	// Add base sectors for 4 siafiles.
+	sectors := make(map[skymodules.SiaPath][]byte)
+	roots := make(map[skymodules.SiaPath]crypto.Hash)
+	for _, name := range []string{"healthy", "unhealthy", "deleted", "failed"} {
+		siaPath, err := skymodules.NewSiaPath(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sectors[siaPath] = fastrand.Bytes(int(modules.SectorSize))
+		roots[siaPath] = crypto.MerkleRoot(sectors[siaPath])
+		if err := ruc.managedAdd(roots[siaPath], siaPath, sectors[siaPath]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for siaPath, root := range roots {
+		if s, err := ruc.managedGet(root); err != nil || !bytes.Equal(s, sectors[siaPath]) {
+			t.Fatal("wrong sector", siaPath, err)
+		}
+	}
+
+	// Prune the cache. Only the base sectors of the unhealthy siafile and the
+	// siafile of which the health couldn't be fetched should remain.
+	errHealth := errors.New("failed to fetch health")
+	health := func(siaPath skymodules.SiaPath) (float64, error) {
+		switch siaPath.String() {
+		case "healthy":
+			return 0, nil
+		case "unhealthy":
+			return 0.5, nil
+		case "deleted":
+			return 0, filesystem.ErrNotExist
+		default:
+			return 0, errHealth
+		}
+	}
+	if err := ruc.managedPrune(time.Hour, health); !errors.Contains(err, errHealth) {
+		t.Fatal("expected health error", err)
+	}
+	for siaPath, root := range roots {
+		_, err := ruc.managedGet(root)
+		remains := siaPath.String() == "unhealthy" || siaPath.String() == "failed"
+		if remains && err != nil {
+			t.Fatal("base sector should remain", siaPath, err)
+		} else if !remains && !errors.Contains(err, errSectorCacheMiss) {
+			t.Fatal("base sector should be evicted", siaPath, err)
+		}
+	}
+
+	// Once the max age is exceeded, all base sectors are evicted.
+	if err := ruc.managedPrune(0, health); err != nil {
+		t.Fatal(err)
+	}
+	for _, root := range roots {
+		if _, err := ruc.managedGet(root); !errors.Contains(err, errSectorCacheMiss) {
+			t.Fatal("expected miss", err)
+		}
+	}
+	if len(ruc.uploads) != 0 {
+		t.Fatal("uploads should be empty", len(ruc.uploads))
+	}
+}
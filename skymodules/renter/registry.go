@@ -212,13 +212,14 @@ func (r *Renter) ReadRegistryRID(ctx context.Context, rid modules.RegistryEntryI
 }
 
 // UpdateRegistry updates the registries on all workers with the given
-// registry value.
-func (r *Renter) UpdateRegistry(ctx context.Context, spk types.SiaPublicKey, srv modules.SignedRegistryValue) error {
+// registry value. It returns the proofs of the hosts which accepted the
+// update.
+func (r *Renter) UpdateRegistry(ctx context.Context, spk types.SiaPublicKey, srv modules.SignedRegistryValue) ([]skymodules.RegistryUpdateProof, error) {
 	// Block until there is memory available, and then ensure the memory gets
 	// returned.
 	// Since registry entries are very small we use a fairly generous multiple.
 	if !r.staticRegistryMemoryManager.Request(ctx, updateRegistryMemory, memoryPriorityHigh) {
-		return errors.New("timeout while waiting in job queue - server is busy")
+		return nil, errors.New("timeout while waiting in job queue - server is busy")
 	}
 	defer r.staticRegistryMemoryManager.Return(updateRegistryMemory)
 
@@ -227,13 +228,14 @@ func (r *Renter) UpdateRegistry(ctx context.Context, spk types.SiaPublicKey, srv
 }
 
 // UpdateRegistryMulti updates the registries on the given workers with the
-// corresponding registry values.
-func (r *Renter) UpdateRegistryMulti(ctx context.Context, srvs map[string]skymodules.RegistryEntry) error {
+// corresponding registry values. It returns the proofs of the hosts which
+// accepted the update.
+func (r *Renter) UpdateRegistryMulti(ctx context.Context, srvs map[string]skymodules.RegistryEntry) ([]skymodules.RegistryUpdateProof, error) {
 	// Block until there is memory available, and then ensure the memory gets
 	// returned.
 	// Since registry entries are very small we use a fairly generous multiple.
 	if !r.staticRegistryMemoryManager.Request(ctx, updateRegistryMemory, memoryPriorityHigh) {
-		return errors.New("timeout while waiting in job queue - server is busy")
+		return nil, errors.New("timeout while waiting in job queue - server is busy")
 	}
 	defer r.staticRegistryMemoryManager.Return(updateRegistryMemory)
 
@@ -370,6 +372,16 @@ func (r *Renter) managedReadRegistry(ctx context.Context, rid modules.RegistryEn
 		return subscribedRV, nil
 	}
 
+	// Check if the entry was read recently.
+	rc := r.staticRegistryEntryCache
+	if rc != nil {
+		cachedEntry, ok := rc.managedGet(rid)
+		span.SetTag("registrycache", ok)
+		if ok {
+			return cachedEntry, nil
+		}
+	}
+
 	// Measure the time it takes to fetch the entry.
 	startTime := time.Now()
 	defer func() {
@@ -427,7 +439,22 @@ func (r *Renter) managedReadRegistry(ctx context.Context, rid modules.RegistryEn
 		awaitedWorkers = -1
 	}
 
+	// If trusted registry hosts are configured, we also wait for all of the
+	// trusted workers.
+	trusted := r.staticTrustedRegistryHosts.callSettings()
+	trustedWorkers := make(map[string]struct{})
+	for _, host := range trusted.Hosts {
+		trustedWorkers[host.String()] = struct{}{}
+	}
+	trustedPending := 0
+	for _, w := range launchedWorkers {
+		if _, isTrusted := trustedWorkers[w.staticHostPubKeyStr]; isTrusted {
+			trustedPending++
+		}
+	}
+
 	var best *jobReadRegistryResponse
+	var successfulResps []*jobReadRegistryResponse
 	responses := 0
 	// Wait for responses until either there are no responses left or until
 	// we have waited for enough of our workersToWaitFor.
@@ -439,7 +466,7 @@ func (r *Renter) managedReadRegistry(ctx context.Context, rid modules.RegistryEn
 		}
 
 		// Check if we have waited for enough workers.
-		if awaitedWorkers >= cutoff {
+		if awaitedWorkers >= cutoff && trustedPending == 0 {
 			break // done
 		}
 
@@ -449,6 +476,9 @@ func (r *Renter) managedReadRegistry(ctx context.Context, rid modules.RegistryEn
 		if exists {
 			awaitedWorkers++
 		}
+		if _, isTrusted := trustedWorkers[resp.staticWorker.staticHostPubKeyStr]; isTrusted {
+			trustedPending--
+		}
 
 		// Increment responses.
 		responses++
@@ -457,6 +487,7 @@ func (r *Renter) managedReadRegistry(ctx context.Context, rid modules.RegistryEn
 		if resp.staticErr != nil || resp.staticSignedRegistryValue == nil {
 			continue
 		}
+		successfulResps = append(successfulResps, resp)
 
 		// Remember the best response.
 		if isBetter, _ := isBetterReadRegistryResponse(best, resp); isBetter {
@@ -464,6 +495,13 @@ func (r *Renter) managedReadRegistry(ctx context.Context, rid modules.RegistryEn
 		}
 	}
 
+	// If trusted registry hosts are configured, they decide which of the
+	// responses is accepted.
+	var agreement []skymodules.RegistryHostAgreement
+	if len(trusted.Hosts) > 0 {
+		best, agreement = pickTrustedRegistryResponse(trusted, successfulResps)
+	}
+
 	// If we don't have a successful response and also not a response for every
 	// worker, we timed out.
 	noResponse := best == nil || best.staticSignedRegistryValue == nil
@@ -476,7 +514,12 @@ func (r *Renter) managedReadRegistry(ctx context.Context, rid modules.RegistryEn
 	if noResponse {
 		return skymodules.RegistryEntry{}, ErrRegistryEntryNotFound
 	}
-	return *best.staticSignedRegistryValue, nil
+	entry := *best.staticSignedRegistryValue
+	entry.TrustedHostAgreement = agreement
+	if rc != nil {
+		rc.managedSet(rid, entry)
+	}
+	return entry, nil
 }
 
 // managedLaunchReadRegistryWorkers launches read registry jobs on all available
@@ -535,7 +578,7 @@ func (r *Renter) managedLaunchReadRegistryWorkers(ctx context.Context, span open
 // NOTE: the input ctx only unblocks the call if it fails to hit the threshold
 // before the timeout. It doesn't stop the update jobs. That's because we want
 // to always make sure we update as many hosts as possble.
-func (r *Renter) managedUpdateRegistry(ctx context.Context, spk types.SiaPublicKey, srv modules.SignedRegistryValue) (err error) {
+func (r *Renter) managedUpdateRegistry(ctx context.Context, spk types.SiaPublicKey, srv modules.SignedRegistryValue) (_ []skymodules.RegistryUpdateProof, err error) {
 	workers := r.staticWorkerPool.callWorkers()
 	srvs := make(map[string]skymodules.RegistryEntry, len(workers))
 	for _, w := range workers {
@@ -545,11 +588,12 @@ func (r *Renter) managedUpdateRegistry(ctx context.Context, spk types.SiaPublicK
 }
 
 // managedUpdateRegistry updates the registries on all workers with the given
-// registry value.
+// registry value. It returns the proofs of the hosts which accepted the update
+// before the call returned.
 // NOTE: the input ctx only unblocks the call if it fails to hit the threshold
 // before the timeout. It doesn't stop the update jobs. That's because we want
 // to always make sure we update as many hosts as possble.
-func (r *Renter) managedUpdateRegistryMulti(ctx context.Context, workers []*worker, srvs map[string]skymodules.RegistryEntry, minUpdates int) (err error) {
+func (r *Renter) managedUpdateRegistryMulti(ctx context.Context, workers []*worker, srvs map[string]skymodules.RegistryEntry, minUpdates int) (_ []skymodules.RegistryUpdateProof, err error) {
 	// Start tracing.
 	start := time.Now()
 	tracer := opentracing.GlobalTracer()
@@ -564,7 +608,14 @@ func (r *Renter) managedUpdateRegistryMulti(ctx context.Context, workers []*work
 	// Verify the signatures before updating the hosts.
 	for _, srv := range srvs {
 		if err := srv.Verify(); err != nil {
-			return errors.AddContext(err, "managedUpdateRegistry: failed to verify signature of entry")
+			return nil, errors.AddContext(err, "managedUpdateRegistry: failed to verify signature of entry")
+		}
+	}
+
+	// Invalidate cached entries which are older than the update.
+	if rc := r.staticRegistryEntryCache; rc != nil {
+		for _, srv := range srvs {
+			rc.managedInvalidate(modules.DeriveRegistryEntryID(srv.PubKey, srv.Tweak), &srv.SignedRegistryValue)
 		}
 	}
 	// Create a channel to receive all of the
@@ -611,13 +662,14 @@ func (r *Renter) managedUpdateRegistryMulti(ctx context.Context, workers []*work
 	workers = workers[:numRegistryWorkers]
 	// If there are no workers remaining, fail early.
 	if len(workers) < minUpdates {
-		return errors.AddContext(skymodules.ErrNotEnoughWorkersInWorkerPool, "cannot perform UpdateRegistry")
+		return nil, errors.AddContext(skymodules.ErrNotEnoughWorkersInWorkerPool, "cannot perform UpdateRegistry")
 	}
 
 	workersLeft := len(workers)
 	responses := 0
 	successfulResponses := 0
 
+	var proofs []skymodules.RegistryUpdateProof
 	var respErrs error
 	for successfulResponses < minUpdates && workersLeft+successfulResponses >= minUpdates {
 		// Check deadline.
@@ -625,7 +677,7 @@ func (r *Renter) managedUpdateRegistryMulti(ctx context.Context, workers []*work
 		select {
 		case <-ctx.Done():
 			// Timeout reached.
-			return ErrRegistryUpdateTimeout
+			return nil, ErrRegistryUpdateTimeout
 		case resp = <-staticResponseChan:
 		}
 
@@ -642,27 +694,31 @@ func (r *Renter) managedUpdateRegistryMulti(ctx context.Context, workers []*work
 			// update won't be able to change the consensus of the network on
 			// the latest entry.
 			if modules.IsRegistryEntryExistErr(resp.staticErr) {
-				return resp.staticErr
+				return nil, resp.staticErr
 			}
 			respErrs = errors.Compose(respErrs, resp.staticErr)
 			continue
 		}
 
-		// Increment successful responses.
+		// Increment successful responses and remember the proof.
 		successfulResponses++
+		proofs = append(proofs, skymodules.RegistryUpdateProof{
+			HostKey:       resp.staticWorker.staticHostPubKey,
+			RegistryEntry: srvs[resp.staticWorker.staticHostPubKeyStr],
+		})
 	}
 
 	// Check if we ran out of workers.
 	if successfulResponses == 0 {
 		r.staticLog.Print("RegistryUpdate failed with 0 successful responses: ", respErrs)
-		return errors.Compose(err, ErrRegistryUpdateNoSuccessfulUpdates)
+		return nil, errors.Compose(err, ErrRegistryUpdateNoSuccessfulUpdates)
 	}
 	if successfulResponses < minUpdates {
 		r.staticLog.Printf("RegistryUpdate failed with %v < %v successful responses: %v", successfulResponses, minUpdates, respErrs)
-		return errors.Compose(err, ErrRegistryUpdateInsufficientRedundancy)
+		return nil, errors.Compose(err, ErrRegistryUpdateInsufficientRedundancy)
 	}
 	r.staticRegWriteStats.AddDataPoint(time.Since(start))
-	return nil
+	return proofs, nil
 }
 
 // isBetterReadRegistryResponse returns true if resp2 is a better response than
@@ -781,7 +837,7 @@ func (r *Renter) threadedHandleRegistryRepairs(ctx context.Context, parentSpan o
 	}
 
 	// Update the registry.
-	err := r.managedUpdateRegistryMulti(ctx, workers, srvs, RegistryEntryRepairThreshold-len(upToDateHosts))
+	_, err := r.managedUpdateRegistryMulti(ctx, workers, srvs, RegistryEntryRepairThreshold-len(upToDateHosts))
 	if err != nil {
 		r.staticLog.Debugln("threadedHandleRegistryRepairs: failed to update registry", err)
 	}
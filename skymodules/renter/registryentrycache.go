@@ -0,0 +1,138 @@
+package renter
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// registryEntryCacheTTL is the amount of time an entry is served from the
+	// registry cache before it needs to be read from the hosts again.
+	registryEntryCacheTTL = build.Select(build.Var{
+		Dev:      10 * time.Second,
+		Standard: 30 * time.Second,
+		Testing:  3 * time.Second,
+	}).(time.Duration)
+)
+
+type (
+	// registryEntryCache is a size-bounded in-memory cache for recently read
+	// registry entries. It is consulted before launching read registry jobs.
+	// Entries expire after a ttl, are evicted in LRU order once the cache is
+	// full and are invalidated as soon as a more recent revision is seen,
+	// either by updating the entry or through a subscription notification.
+	registryEntryCache struct {
+		entries map[modules.RegistryEntryID]*list.Element
+		lru     *list.List
+
+		hits          uint64
+		misses        uint64
+		invalidations uint64
+
+		staticMaxEntries uint64
+		staticTTL        time.Duration
+		mu               sync.Mutex
+	}
+
+	// registryEntryCacheEntry is a cached registry entry.
+	registryEntryCacheEntry struct {
+		staticRID    modules.RegistryEntryID
+		staticEntry  skymodules.RegistryEntry
+		staticExpiry time.Time
+	}
+)
+
+// newRegistryEntryCache creates a new registry entry cache which holds up to
+// maxEntries entries for the given ttl.
+func newRegistryEntryCache(maxEntries uint64, ttl time.Duration) *registryEntryCache {
+	return &registryEntryCache{
+		entries:          make(map[modules.RegistryEntryID]*list.Element),
+		lru:              list.New(),
+		staticMaxEntries: maxEntries,
+		staticTTL:        ttl,
+	}
+}
+
+// managedGet returns the cached entry for the given entry id if it exists and
+// hasn't expired yet.
+func (rc *registryEntryCache) managedGet(rid modules.RegistryEntryID) (skymodules.RegistryEntry, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	e, exists := rc.entries[rid]
+	if !exists {
+		rc.misses++
+		return skymodules.RegistryEntry{}, false
+	}
+	entry := e.Value.(*registryEntryCacheEntry)
+	if time.Now().After(entry.staticExpiry) {
+		rc.remove(e)
+		rc.misses++
+		return skymodules.RegistryEntry{}, false
+	}
+	rc.lru.MoveToFront(e)
+	rc.hits++
+	return entry.staticEntry, true
+}
+
+// managedSet adds an entry to the cache unless the cache already contains a
+// more recent revision of it. The least recently used entries are evicted if
+// the cache is full.
+func (rc *registryEntryCache) managedSet(rid modules.RegistryEntryID, entry skymodules.RegistryEntry) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if e, exists := rc.entries[rid]; exists {
+		cached := e.Value.(*registryEntryCacheEntry)
+		if moreRecentSRV(&entry.SignedRegistryValue, &cached.staticEntry.SignedRegistryValue) {
+			return // cached entry is more recent
+		}
+		rc.remove(e)
+	}
+	rc.entries[rid] = rc.lru.PushFront(&registryEntryCacheEntry{
+		staticRID:    rid,
+		staticEntry:  entry,
+		staticExpiry: time.Now().Add(rc.staticTTL),
+	})
+	for uint64(rc.lru.Len()) > rc.staticMaxEntries {
+		rc.remove(rc.lru.Back())
+	}
+}
+
+// managedInvalidate removes the cached entry for the given entry id if srv is
+// a more recent revision of it.
+func (rc *registryEntryCache) managedInvalidate(rid modules.RegistryEntryID, srv *modules.SignedRegistryValue) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	e, exists := rc.entries[rid]
+	if !exists {
+		return
+	}
+	cached := e.Value.(*registryEntryCacheEntry)
+	if !moreRecentSRV(&cached.staticEntry.SignedRegistryValue, srv) {
+		return // cached entry is up-to-date
+	}
+	rc.remove(e)
+	rc.invalidations++
+}
+
+// managedStats returns the stats of the cache.
+func (rc *registryEntryCache) managedStats() skymodules.RegistryCacheStats {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return skymodules.RegistryCacheStats{
+		Entries:       uint64(rc.lru.Len()),
+		Hits:          rc.hits,
+		Misses:        rc.misses,
+		Invalidations: rc.invalidations,
+	}
+}
+
+// remove removes an element from the cache.
+func (rc *registryEntryCache) remove(e *list.Element) {
+	entry := rc.lru.Remove(e).(*registryEntryCacheEntry)
+	delete(rc.entries, entry.staticRID)
+}
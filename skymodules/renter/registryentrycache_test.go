@@ -0,0 +1,164 @@
+package renter
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestRegistryEntryCache is a unit test for the registryEntryCache.
+func TestRegistryEntryCache(t *testing.T) {
+	t.Parallel()
+
+	rc := newRegistryEntryCache(2, time.Hour)
+
+	// randomEntry creates a random entry and returns it with its id.
+	randomEntry := func() (modules.RegistryEntryID, skymodules.RegistryEntry, crypto.SecretKey) {
+		srv, spk, sk := randomRegistryValue()
+		return modules.DeriveRegistryEntryID(spk, srv.Tweak), skymodules.NewRegistryEntry(spk, srv), sk
+	}
+	// checkStats checks the stats of the cache.
+	checkStats := func(expected skymodules.RegistryCacheStats) {
+		t.Helper()
+		if stats := rc.managedStats(); stats != expected {
+			t.Fatalf("wrong stats %+v != %+v", stats, expected)
+		}
+	}
+
+	// Miss.
+	rid1, entry1, sk1 := randomEntry()
+	if _, ok := rc.managedGet(rid1); ok {
+		t.Fatal("entry shouldn't be cached")
+	}
+	checkStats(skymodules.RegistryCacheStats{Misses: 1})
+
+	// Hit.
+	rc.managedSet(rid1, entry1)
+	cached, ok := rc.managedGet(rid1)
+	if !ok || !reflect.DeepEqual(cached, entry1) {
+		t.Fatal("wrong cached entry", ok)
+	}
+	checkStats(skymodules.RegistryCacheStats{Entries: 1, Hits: 1, Misses: 1})
+
+	// An older revision doesn't replace the cached entry but a newer one does.
+	newer := entry1
+	newer.Revision++
+	newer.SignedRegistryValue = newer.Sign(sk1)
+	rc.managedSet(rid1, newer)
+	rc.managedSet(rid1, entry1)
+	if cached, _ := rc.managedGet(rid1); cached.Revision != newer.Revision {
+		t.Fatal("cached entry wasn't updated", cached.Revision)
+	}
+
+	// Invalidating with the same revision doesn't remove the entry, a newer
+	// revision does.
+	rc.managedInvalidate(rid1, &newer.SignedRegistryValue)
+	if _, ok := rc.managedGet(rid1); !ok {
+		t.Fatal("entry shouldn't be invalidated")
+	}
+	newest := newer
+	newest.Revision++
+	newest.SignedRegistryValue = newest.Sign(sk1)
+	rc.managedInvalidate(rid1, &newest.SignedRegistryValue)
+	if _, ok := rc.managedGet(rid1); ok {
+		t.Fatal("entry should be invalidated")
+	}
+	checkStats(skymodules.RegistryCacheStats{Hits: 3, Misses: 2, Invalidations: 1})
+
+	// Fill the cache and add another entry. The least recently used entry is
+	// evicted.
+	rid2, entry2, _ := randomEntry()
+	rid3, entry3, _ := randomEntry()
+	rid4, entry4, _ := randomEntry()
+	rc.managedSet(rid2, entry2)
+	rc.managedSet(rid3, entry3)
+	if _, ok := rc.managedGet(rid2); !ok {
+		t.Fatal("entry should be cached")
+	}
+	rc.managedSet(rid4, entry4)
+	if _, ok := rc.managedGet(rid3); ok {
+		t.Fatal("entry should be evicted")
+	}
+	if _, ok := rc.managedGet(rid2); !ok {
+		t.Fatal("entry should be cached")
+	}
+	if _, ok := rc.managedGet(rid4); !ok {
+		t.Fatal("entry should be cached")
+	}
+
+	// Entries expire after the ttl.
+	rc = newRegistryEntryCache(2, time.Millisecond)
+	rc.managedSet(rid1, entry1)
+	time.Sleep(10 * time.Millisecond)
+	if _, ok := rc.managedGet(rid1); ok {
+		t.Fatal("entry should be expired")
+	}
+	checkStats(skymodules.RegistryCacheStats{Misses: 1})
+}
+
+// TestRegistryEntryCacheRenter tests that the renter serves registry reads from
+// the registry entry cache and that subscription notifications invalidate it.
+func TestRegistryEntryCacheRenter(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+	rc := newRegistryEntryCache(10, time.Hour)
+	r.staticRegistryEntryCache = rc
+
+	srv, spk, sk := randomRegistryValue()
+	entry := skymodules.NewRegistryEntry(spk, srv)
+	rid := modules.DeriveRegistryEntryID(spk, srv.Tweak)
+
+	// The entry isn't cached and the renter doesn't have workers.
+	_, err = r.ReadRegistry(context.Background(), spk, srv.Tweak)
+	if !errors.Contains(err, skymodules.ErrNotEnoughWorkersInWorkerPool) {
+		t.Fatal("wrong error", err)
+	}
+
+	// Once it is cached, it is returned without workers.
+	rc.managedSet(rid, entry)
+	readEntry, err := r.ReadRegistry(context.Background(), spk, srv.Tweak)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(readEntry, entry) {
+		t.Fatal("wrong entry")
+	}
+	perf, err := r.Performance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := skymodules.RegistryCacheStats{Entries: 1, Hits: 1, Misses: 1}
+	if perf.RegistryCacheStats != expected {
+		t.Fatalf("wrong stats %+v", perf.RegistryCacheStats)
+	}
+
+	// A notification about a newer revision invalidates the entry.
+	srv.Revision++
+	srv = srv.Sign(sk)
+	r.staticSubscriptionManager.Notify(modules.RPCRegistrySubscriptionNotificationEntryUpdate{
+		Entry:  srv,
+		PubKey: spk,
+	})
+	if _, ok := rc.managedGet(rid); ok {
+		t.Fatal("entry should be invalidated")
+	}
+}
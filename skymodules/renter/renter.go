@@ -44,11 +44,14 @@ import (
 	"gitlab.com/SkynetLabs/skyd/build"
 	"gitlab.com/SkynetLabs/skyd/skykey"
 	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"gitlab.com/SkynetLabs/skyd/skymodules/maintenance"
 	"gitlab.com/SkynetLabs/skyd/skymodules/renter/contractor"
 	"gitlab.com/SkynetLabs/skyd/skymodules/renter/filesystem"
 	"gitlab.com/SkynetLabs/skyd/skymodules/renter/hostdb"
 	"gitlab.com/SkynetLabs/skyd/skymodules/renter/skynetblocklist"
 	"gitlab.com/SkynetLabs/skyd/skymodules/renter/skynetportals"
+	"gitlab.com/SkynetLabs/skyd/skymodules/renter/skynetquotas"
+	"gitlab.com/SkynetLabs/skyd/skymodules/renter/skynettokens"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/persist"
@@ -89,12 +92,20 @@ type hostContractor interface {
 	// Allowance returns the current allowance
 	Allowance() skymodules.Allowance
 
+	// HostProfiles maps the hosts that were selected for an allowance
+	// profile to the name of the profile.
+	HostProfiles() map[string]string
+
 	// Close closes the hostContractor.
 	Close() error
 
 	// CancelContract cancels the Renter's contract
 	CancelContract(id types.FileContractID) error
 
+	// DefragContract removes the sectors which are no longer referenced from
+	// the Renter's contract.
+	DefragContract(id types.FileContractID) (skymodules.ContractDefragReport, error)
+
 	// Contracts returns the staticContracts of the renter's hostContractor.
 	Contracts() []skymodules.RenterContract
 
@@ -116,6 +127,9 @@ type hostContractor interface {
 	// watchdog.
 	ContractStatus(fcID types.FileContractID) (skymodules.ContractWatchStatus, bool)
 
+	// ContractFeeBumps returns the pending fee bumps of the watchdog.
+	ContractFeeBumps() []skymodules.ContractFeeBump
+
 	// CurrentPeriod returns the height at which the current allowance period
 	// began.
 	CurrentPeriod() types.BlockHeight
@@ -124,15 +138,41 @@ type hostContractor interface {
 	// contracts within a separate thread.
 	InitRecoveryScan() error
 
+	// InitRecoveryScanRange starts scanning the blocks between start and end
+	// (inclusive) for recoverable contracts within a separate thread.
+	InitRecoveryScanRange(start, end types.BlockHeight) error
+
+	// PauseRecoveryScan pauses the running recovery scan.
+	PauseRecoveryScan() error
+
+	// ResumeRecoveryScan resumes a paused recovery scan.
+	ResumeRecoveryScan() error
+
 	// PeriodSpending returns the amount spent on contracts during the current
 	// billing period.
 	PeriodSpending() (skymodules.ContractorSpending, error)
 
+	// SpendingForecast returns the projected spending for the remainder of
+	// the current billing period.
+	SpendingForecast() (skymodules.SpendingForecast, error)
+
+	// AutoFundStatus returns the auto-funding settings and the audit log of
+	// automatic allowance top-ups.
+	AutoFundStatus() skymodules.AutoFundStatus
+
+	// SetAutoFundSettings updates the settings for automatically topping up
+	// the allowance.
+	SetAutoFundSettings(skymodules.AutoFundSettings) error
+
 	// ProvidePayment takes a stream and a set of payment details and handles
 	// the payment for an RPC by sending and processing payment request and
 	// response objects to the host. It returns an error in case of failure.
 	ProvidePayment(stream io.ReadWriter, pt *modules.RPCPriceTable, details contractor.PaymentDetails) error
 
+	// RecordAccountSpending attributes the given ephemeral account spending
+	// to the latest contract with the host with the given public key.
+	RecordAccountSpending(types.SiaPublicKey, skymodules.ContractAccountSpending) error
+
 	// OldContracts returns the oldContracts of the renter's hostContractor.
 	OldContracts() []skymodules.RenterContract
 
@@ -160,6 +200,10 @@ type hostContractor interface {
 	// contracts is in progress and if it is, the current progress of the scan.
 	RecoveryScanStatus() (bool, types.BlockHeight)
 
+	// RecoveryScanProgress returns the detailed progress of the most recent
+	// recovery scan and the recovery status of the contracts found.
+	RecoveryScanProgress() skymodules.RecoveryScanProgress
+
 	// RefreshedContract checks if the contract was previously refreshed
 	RefreshedContract(fcid types.FileContractID) bool
 
@@ -211,12 +255,32 @@ type Renter struct {
 	// friendly to the atomic package, but actually it's a time.Duration.
 	atomicSystemHealthScanDuration uint64
 
+	// atomicBaseSectorHedges counts the hedged base sector downloads that
+	// were launched and atomicBaseSectorHedgesWon the ones which finished
+	// before the download they were hedging.
+	atomicBaseSectorHedges    uint64
+	atomicBaseSectorHedgesWon uint64
+
 	// Skynet Management
-	staticSkylinkManager    *skylinkManager
-	staticSkynetBlocklist   *skynetblocklist.SkynetBlocklist
-	staticSkynetPortals     *skynetportals.SkynetPortals
-	staticSpendingHistory   *spendingHistory
-	staticSkynetTUSUploader *skynetTUSUploader
+	staticSkylinkManager     *skylinkManager
+	staticSkynetBlocklist    *skynetblocklist.SkynetBlocklist
+	staticSkynetPortals      *skynetportals.SkynetPortals
+	staticSkynetTokens       *skynettokens.SkynetTokens
+	staticSkynetQuotas       *skynetquotas.SkynetQuotas
+	staticMaintenance        *maintenance.Scheduler
+	staticSpendingHistory    *spendingHistory
+	staticSkyfileChunkIndex  *skyfileChunkIndex
+	staticSkylinkIndex       *skylinkIndex
+	staticSkylinkHealthCache *skylinkHealthCache
+	staticUploadSessions     *uploadSessions
+	staticRepairPriorities   *skylinkRepairPriorities
+	staticSkynetTUSUploader  *skynetTUSUploader
+	staticUploadScanner      *uploadScanner
+	staticSkynetDirUploader  *skynetDirUploader
+	staticSkynetDirConverter *skynetDirConverter
+	staticSkynetDeleter      *skynetDeleter
+	staticSkynetNameResolver *skynetNameResolver
+	staticHostAllowlist      *hostAllowlistFeed
 
 	// Download management.
 	staticDownloadHeap *downloadHeap
@@ -257,18 +321,23 @@ type Renter struct {
 	// The renter's bandwidth ratelimit.
 	staticRL *ratelimit.RateLimit
 
+	// staticTrafficRLs limit the traffic classes to their share of the
+	// renter's bandwidth.
+	staticTrafficRLs trafficClassRateLimits
+
 	// stats cache related fields.
 	statsChan chan struct{}
 	statsMu   sync.Mutex
 
 	// various performance stats
-	staticBaseSectorDownloadStats   *skymodules.DownloadOverdriveStats
-	staticBaseSectorUploadStats     *skymodules.DistributionTracker
-	staticChunkUploadStats          *skymodules.DistributionTracker
-	staticFanoutSectorDownloadStats *skymodules.DownloadOverdriveStats
-	staticRegistryReadStats         *skymodules.DistributionTracker
-	staticRegWriteStats             *skymodules.DistributionTracker
-	staticStreamBufferStats         *skymodules.DistributionTracker
+	staticBaseSectorDownloadStats        *skymodules.DownloadOverdriveStats
+	staticBaseSectorDownloadLatencyStats *skymodules.DistributionTracker
+	staticBaseSectorUploadStats          *skymodules.DistributionTracker
+	staticChunkUploadStats               *skymodules.DistributionTracker
+	staticFanoutSectorDownloadStats      *skymodules.DownloadOverdriveStats
+	staticRegistryReadStats              *skymodules.DistributionTracker
+	staticRegWriteStats                  *skymodules.DistributionTracker
+	staticStreamBufferStats              *skymodules.DistributionTracker
 
 	// Memory management
 	//
@@ -281,7 +350,11 @@ type Renter struct {
 	//
 	// staticRepairMemoryManager is used for repair work scheduled by siad
 	//
+	// staticMemoryBudget is the soft memory limit shared by the memory
+	// managers, the project downloads and the upload buffers.
+	//
 	staticMemoryManager             *memoryManager
+	staticMemoryBudget              *memoryBudget
 	staticRegistryMemoryManager     *memoryManager
 	staticRepairMemoryManager       *memoryManager
 	staticUserDownloadMemoryManager *memoryManager
@@ -297,11 +370,17 @@ type Renter struct {
 	staticGateway                      modules.Gateway
 	staticHostContractor               hostContractor
 	staticHostDB                       skymodules.HostDB
+	staticRegistryEntryCache           *registryEntryCache
+	staticSkykeyBackupState            *skykeyBackupState
 	staticSkykeyManager                *skykey.SkykeyManager
 	staticStreamBufferSet              *streamBufferSet
+	staticSectorCache                  *sectorCache
+	staticRecentUploadCache            *recentUploadCache
 	staticTPool                        modules.TransactionPool
+	staticTrustedRegistryHosts         *trustedRegistryHosts
 	staticUploadChunkDistributionQueue *uploadChunkDistributionQueue
 	staticWallet                       modules.Wallet
+	staticWorkerBlocklist              *workerBlocklist
 	staticWorkerPool                   *workerPool
 
 	// Utilities
@@ -314,6 +393,17 @@ type Renter struct {
 	staticRepairLog *persist.Logger
 	staticWAL       *writeaheadlog.WAL
 	tg              threadgroup.ThreadGroup
+
+	// staticPersistBackend stores the renter's settings, stats and applied
+	// host allow-list. It defaults to the persist dir but can be an object
+	// store.
+	staticPersistBackend skymodules.PersistBackend
+
+	// persistSnapshotID is the id of the latest snapshot of the persistence.
+	// It's protected by mu. The staticPersistWriter makes sure that older
+	// snapshots never overwrite newer ones.
+	persistSnapshotID   uint64
+	staticPersistWriter *persistWriter
 }
 
 // Close closes the Renter and its dependencies
@@ -340,6 +430,7 @@ func (r *Renter) MemoryStatus() (skymodules.MemoryStatus, error) {
 	total := repairStatus.Add(userDownloadStatus).Add(userUploadStatus).Add(registryStatus)
 	return skymodules.MemoryStatus{
 		MemoryManagerStatus: total,
+		Budget:              r.staticMemoryBudget.callStatus(),
 
 		Registry:     registryStatus,
 		System:       repairStatus,
@@ -582,13 +673,17 @@ func (r *Renter) managedUpdateRenterContractsAndUtilities() {
 	r.mu.Unlock(id)
 }
 
-// staticSetBandwidthLimits will change the bandwidth limits of the renter based
-// on the persist values for the bandwidth.
-func (r *Renter) staticSetBandwidthLimits(downloadSpeed int64, uploadSpeed int64) error {
+// staticSetBandwidthLimits will change the bandwidth limits of the renter and
+// its traffic classes based on the persist values for the bandwidth.
+func (r *Renter) staticSetBandwidthLimits(downloadSpeed int64, uploadSpeed int64, shares skymodules.TrafficShares) error {
 	// Input validation.
 	if downloadSpeed < 0 || uploadSpeed < 0 {
 		return errors.New("download/upload rate limit can't be below 0")
 	}
+	if err := shares.Validate(); err != nil {
+		return err
+	}
+	r.staticTrafficRLs.staticSetLimits(downloadSpeed, uploadSpeed, shares)
 
 	// Check for sentinel "no limits" value.
 	if downloadSpeed == 0 && uploadSpeed == 0 {
@@ -616,6 +711,21 @@ func (r *Renter) SetSettings(s skymodules.RenterSettings) error {
 	if s.MaxDownloadSpeed < 0 || s.MaxUploadSpeed < 0 {
 		return errors.New("bandwidth limits cannot be negative")
 	}
+	if err := s.Overdrive.Validate(); err != nil {
+		return errors.AddContext(err, "invalid overdrive settings")
+	}
+	if err := s.BaseSectorHedging.Validate(); err != nil {
+		return errors.AddContext(err, "invalid base sector hedging settings")
+	}
+	if err := s.TrafficShares.Validate(); err != nil {
+		return errors.AddContext(err, "invalid traffic shares")
+	}
+	if s.PriceTableUpdateFraction < 0 || s.PriceTableUpdateFraction >= 1 {
+		return fmt.Errorf("price table update fraction must be within [0, 1), got %v", s.PriceTableUpdateFraction)
+	}
+	if _, err := skymodules.ParseFanoutRedundancyPolicy(string(s.FanoutRedundancyPolicy)); err != nil {
+		return err
+	}
 
 	// Set allowance.
 	err := r.staticHostContractor.SetAllowance(s.Allowance)
@@ -627,7 +737,7 @@ func (r *Renter) SetSettings(s skymodules.RenterSettings) error {
 	r.staticHostDB.SetIPViolationCheck(s.IPViolationCheck)
 
 	// Set the bandwidth limits.
-	err = r.staticSetBandwidthLimits(s.MaxDownloadSpeed, s.MaxUploadSpeed)
+	err = r.staticSetBandwidthLimits(s.MaxDownloadSpeed, s.MaxUploadSpeed, s.TrafficShares)
 	if err != nil {
 		return err
 	}
@@ -636,11 +746,19 @@ func (r *Renter) SetSettings(s skymodules.RenterSettings) error {
 	id := r.mu.Lock()
 	r.persist.MaxDownloadSpeed = s.MaxDownloadSpeed
 	r.persist.MaxUploadSpeed = s.MaxUploadSpeed
-	err = r.saveSync()
+	r.persist.FanoutRedundancyPolicy = s.FanoutRedundancyPolicy
+	r.persist.BaseSectorHedging = s.BaseSectorHedging
+	r.persist.Overdrive = s.Overdrive
+	r.persist.PriceTableUpdateFraction = s.PriceTableUpdateFraction
+	r.persist.SkyfileUploadLimits = s.SkyfileUploadLimits
+	r.persist.TrafficShares = s.TrafficShares
+	r.persist.MemoryLimit = s.MemoryLimit
 	r.mu.Unlock(id)
+	err = r.managedSaveSync()
 	if err != nil {
 		return err
 	}
+	r.staticMemoryBudget.callSetLimit(s.MemoryLimit)
 
 	// Update the worker pool so that the changes are immediately apparent to
 	// users.
@@ -761,6 +879,12 @@ func (r *Renter) CancelContract(id types.FileContractID) error {
 	return r.staticHostContractor.CancelContract(id)
 }
 
+// DefragContract removes the sectors which are no longer referenced from a
+// specific contract of the renter.
+func (r *Renter) DefragContract(id types.FileContractID) (skymodules.ContractDefragReport, error) {
+	return r.staticHostContractor.DefragContract(id)
+}
+
 // Contracts returns an array of host contractor's staticContracts
 func (r *Renter) Contracts() []skymodules.RenterContract { return r.staticHostContractor.Contracts() }
 
@@ -779,6 +903,12 @@ func (r *Renter) ContractStatus(fcID types.FileContractID) (skymodules.ContractW
 	return r.staticHostContractor.ContractStatus(fcID)
 }
 
+// ContractFeeBumps returns the pending fee bumps of unconfirmed contract
+// transactions.
+func (r *Renter) ContractFeeBumps() []skymodules.ContractFeeBump {
+	return r.staticHostContractor.ContractFeeBumps()
+}
+
 // ContractorChurnStatus returns contract churn stats for the current period.
 func (r *Renter) ContractorChurnStatus() skymodules.ContractorChurnStatus {
 	return r.staticHostContractor.ChurnStatus()
@@ -796,25 +926,63 @@ func (r *Renter) RecoveryScanStatus() (bool, types.BlockHeight) {
 	return r.staticHostContractor.RecoveryScanStatus()
 }
 
+// InitRecoveryScanRange starts scanning the blocks between start and end
+// (inclusive) for recoverable contracts within a separate thread.
+func (r *Renter) InitRecoveryScanRange(start, end types.BlockHeight) error {
+	return r.staticHostContractor.InitRecoveryScanRange(start, end)
+}
+
+// PauseRecoveryScan pauses the running recovery scan.
+func (r *Renter) PauseRecoveryScan() error {
+	return r.staticHostContractor.PauseRecoveryScan()
+}
+
+// ResumeRecoveryScan resumes a paused recovery scan.
+func (r *Renter) ResumeRecoveryScan() error {
+	return r.staticHostContractor.ResumeRecoveryScan()
+}
+
+// RecoveryScanProgress returns the detailed progress of the most recent
+// recovery scan and the recovery status of the contracts found.
+func (r *Renter) RecoveryScanProgress() skymodules.RecoveryScanProgress {
+	return r.staticHostContractor.RecoveryScanProgress()
+}
+
 // OldContracts returns an array of host contractor's oldContracts
 func (r *Renter) OldContracts() []skymodules.RenterContract {
 	return r.staticHostContractor.OldContracts()
 }
 
+// HostProfiles maps the hosts of the renter's allowance profiles to the names
+// of their profiles.
+func (r *Renter) HostProfiles() map[string]string {
+	return r.staticHostContractor.HostProfiles()
+}
+
 // Performance is a function call that returns all of the performance
 // information about the renter.
 func (r *Renter) Performance() (skymodules.RenterPerformance, error) {
 	healthDuration := time.Duration(atomic.LoadUint64(&r.atomicSystemHealthScanDuration))
+	var registryCacheStats skymodules.RegistryCacheStats
+	if rc := r.staticRegistryEntryCache; rc != nil {
+		registryCacheStats = rc.managedStats()
+	}
 	return skymodules.RenterPerformance{
 		SystemHealthScanDuration: healthDuration,
 
 		BaseSectorDownloadOverdriveStats:   r.staticBaseSectorDownloadStats,
+		BaseSectorDownloadStats:            r.staticBaseSectorDownloadLatencyStats.Stats(),
 		BaseSectorUploadStats:              r.staticBaseSectorUploadStats.Stats(),
 		ChunkUploadStats:                   r.staticChunkUploadStats.Stats(),
 		FanoutSectorDownloadOverdriveStats: r.staticFanoutSectorDownloadStats,
 		RegistryReadStats:                  r.staticRegistryReadStats.Stats(),
 		RegistryWriteStats:                 r.staticRegWriteStats.Stats(),
 		StreamBufferReadStats:              r.staticStreamBufferStats.Stats(),
+		StreamBufferCancelledBytes:         atomic.LoadUint64(&r.staticStreamBufferSet.atomicCancelledBytes),
+		BaseSectorHedges:                   atomic.LoadUint64(&r.atomicBaseSectorHedges),
+		BaseSectorHedgesWon:                atomic.LoadUint64(&r.atomicBaseSectorHedgesWon),
+
+		RegistryCacheStats: registryCacheStats,
 	}, nil
 }
 
@@ -823,6 +991,35 @@ func (r *Renter) PeriodSpending() (skymodules.ContractorSpending, error) {
 	return r.staticHostContractor.PeriodSpending()
 }
 
+// SpendingForecast returns the host contractor's spending forecast for the
+// current period.
+func (r *Renter) SpendingForecast() (skymodules.SpendingForecast, error) {
+	if err := r.tg.Add(); err != nil {
+		return skymodules.SpendingForecast{}, err
+	}
+	defer r.tg.Done()
+	return r.staticHostContractor.SpendingForecast()
+}
+
+// AutoFundStatus returns the host contractor's auto-funding settings and
+// audit log.
+func (r *Renter) AutoFundStatus() (skymodules.AutoFundStatus, error) {
+	if err := r.tg.Add(); err != nil {
+		return skymodules.AutoFundStatus{}, err
+	}
+	defer r.tg.Done()
+	return r.staticHostContractor.AutoFundStatus(), nil
+}
+
+// SetAutoFundSettings updates the host contractor's auto-funding settings.
+func (r *Renter) SetAutoFundSettings(settings skymodules.AutoFundSettings) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.staticHostContractor.SetAutoFundSettings(settings)
+}
+
 // RecoverableContracts returns the host contractor's recoverable contracts.
 func (r *Renter) RecoverableContracts() []skymodules.RecoverableContract {
 	return r.staticHostContractor.RecoverableContracts()
@@ -846,18 +1043,55 @@ func (r *Renter) Settings() (skymodules.RenterSettings, error) {
 		return skymodules.RenterSettings{}, errors.AddContext(err, "error getting IPViolationsCheck:")
 	}
 	paused, endTime := r.staticUploadHeap.managedPauseStatus()
+	id := r.mu.RLock()
+	hedging := r.persist.BaseSectorHedging
+	overdrive := r.persist.Overdrive
+	redundancyPolicy := r.persist.FanoutRedundancyPolicy
+	uploadLimits := r.persist.SkyfileUploadLimits
+	trafficShares := r.persist.TrafficShares
+	r.mu.RUnlock(id)
+	if redundancyPolicy == skymodules.FanoutRedundancyPolicyNotSpecified {
+		redundancyPolicy = skymodules.FanoutRedundancyPolicyStatic
+	}
+	ptUpdateFraction := r.managedPriceTableUpdateFraction()
 	return skymodules.RenterSettings{
-		Allowance:        r.staticHostContractor.Allowance(),
-		IPViolationCheck: enabled,
-		MaxDownloadSpeed: download,
-		MaxUploadSpeed:   upload,
+		Allowance:           r.staticHostContractor.Allowance(),
+		IPViolationCheck:    enabled,
+		MaxDownloadSpeed:    download,
+		MaxUploadSpeed:      upload,
+		MemoryLimit:         r.staticMemoryBudget.callLimit(),
+		Overdrive:           overdrive,
+		SkyfileUploadLimits: uploadLimits,
+		TrafficShares:       trafficShares,
 		UploadsStatus: skymodules.UploadsStatus{
 			Paused:       paused,
 			PauseEndTime: endTime,
 		},
+		PriceTableUpdateFraction: ptUpdateFraction,
+		FanoutRedundancyPolicy:   redundancyPolicy,
+		BaseSectorHedging:        hedging,
 	}, nil
 }
 
+// managedPriceTableUpdateFraction returns the fraction of a price table's
+// validity after which the workers update it.
+func (r *Renter) managedPriceTableUpdateFraction() float64 {
+	id := r.mu.RLock()
+	fraction := r.persist.PriceTableUpdateFraction
+	r.mu.RUnlock(id)
+	if fraction == 0 {
+		return skymodules.DefaultPriceTableUpdateFraction
+	}
+	return fraction
+}
+
+// SkyfileUploadLimits returns the limits imposed on skyfile uploads.
+func (r *Renter) SkyfileUploadLimits() skymodules.SkyfileUploadLimits {
+	id := r.mu.RLock()
+	defer r.mu.RUnlock(id)
+	return r.persist.SkyfileUploadLimits
+}
+
 // ProcessConsensusChange returns the process consensus change
 func (r *Renter) ProcessConsensusChange(cc modules.ConsensusChange) {
 	id := r.mu.Lock()
@@ -868,26 +1102,17 @@ func (r *Renter) ProcessConsensusChange(cc modules.ConsensusChange) {
 	}
 }
 
-// threadedPaySkynetFee pays the accumulated skynet fee every 24 hours.
-func (r *Renter) threadedPaySkynetFee() {
-	// Pay periodically.
-	ticker := time.NewTicker(skymodules.SkynetFeePayoutCheckInterval)
-	for {
-		na := r.staticDeps.SkynetAddress()
+// managedPaySkynetFee pays the accumulated skynet fee if it is due.
+func (r *Renter) managedPaySkynetFee() {
+	na := r.staticDeps.SkynetAddress()
 
-		// Compute the threshold.
-		_, max := r.staticTPool.FeeEstimation()
-		threshold := max.Mul64(skynetFeePayoutMultiplier)
+	// Compute the threshold.
+	_, max := r.staticTPool.FeeEstimation()
+	threshold := max.Mul64(skynetFeePayoutMultiplier)
 
-		err := paySkynetFee(r.staticSpendingHistory, r.staticWallet, append(r.Contracts(), r.OldContracts()...), na, threshold, r.staticLog)
-		if err != nil {
-			r.staticLog.Print(err)
-		}
-		select {
-		case <-r.tg.StopChan():
-			return // shutdown
-		case <-ticker.C:
-		}
+	err := paySkynetFee(r.staticSpendingHistory, r.staticWallet, append(r.Contracts(), r.OldContracts()...), na, threshold, r.staticLog)
+	if err != nil {
+		r.staticLog.Print(err)
 	}
 }
 
@@ -971,7 +1196,12 @@ func (r *Renter) AddSkykey(sk skykey.Skykey) error {
 		return err
 	}
 	defer r.tg.Done()
-	return r.staticSkykeyManager.AddKey(sk)
+	err := r.staticSkykeyManager.AddKey(sk)
+	if err != nil {
+		return err
+	}
+	_ = r.tg.Launch(r.threadedSkykeyAutoBackup)
+	return nil
 }
 
 // DeleteSkykeyByID deletes the Skykey with the given ID from the renter's skykey
@@ -981,7 +1211,12 @@ func (r *Renter) DeleteSkykeyByID(id skykey.SkykeyID) error {
 		return err
 	}
 	defer r.tg.Done()
-	return r.staticSkykeyManager.DeleteKeyByID(id)
+	err := r.staticSkykeyManager.DeleteKeyByID(id)
+	if err != nil {
+		return err
+	}
+	_ = r.tg.Launch(r.threadedSkykeyAutoBackup)
+	return nil
 }
 
 // DeleteSkykeyByName deletes the Skykey with the given name from the renter's skykey
@@ -991,7 +1226,12 @@ func (r *Renter) DeleteSkykeyByName(name string) error {
 		return err
 	}
 	defer r.tg.Done()
-	return r.staticSkykeyManager.DeleteKeyByName(name)
+	err := r.staticSkykeyManager.DeleteKeyByName(name)
+	if err != nil {
+		return err
+	}
+	_ = r.tg.Launch(r.threadedSkykeyAutoBackup)
+	return nil
 }
 
 // SkykeyByName gets the Skykey with the given name from the renter's skykey
@@ -1010,7 +1250,12 @@ func (r *Renter) CreateSkykey(name string, skType skykey.SkykeyType) (skykey.Sky
 		return skykey.Skykey{}, err
 	}
 	defer r.tg.Done()
-	return r.staticSkykeyManager.CreateKey(name, skType)
+	sk, err := r.staticSkykeyManager.CreateKey(name, skType)
+	if err != nil {
+		return skykey.Skykey{}, err
+	}
+	_ = r.tg.Launch(r.threadedSkykeyAutoBackup)
+	return sk, nil
 }
 
 // SkykeyByID gets the Skykey with the given ID from the renter's skykey
@@ -1100,6 +1345,8 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 		},
 
 		staticDownloadHistory: newDownloadHistory(),
+		staticMaintenance:     maintenance.New(),
+		staticPersistWriter:   &persistWriter{},
 
 		ongoingRegistryRepairs: make(map[modules.RegistryEntryID]struct{}),
 
@@ -1111,11 +1358,15 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 		staticHostContractor: hc,
 		persistDir:           persistDir,
 		staticRL:             rl,
+		staticTrafficRLs:     newTrafficClassRateLimits(),
 		staticAlerter:        modules.NewAlerter("renter"),
 		staticMux:            mux,
 		mu:                   siasync.New(modules.SafeMutexDelay, 1),
 		staticTPool:          tpool,
 	}
+	if err := r.tg.OnStop(r.staticMaintenance.Close); err != nil {
+		return nil, err
+	}
 	r.staticSkynetTUSUploader = newSkynetTUSUploader(r, tus)
 	if err := r.tg.AfterStop(r.staticSkynetTUSUploader.Close); err != nil {
 		return nil, err
@@ -1130,6 +1381,49 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 	}
 	r.staticSpendingHistory = sh
 
+	// Init the skyfile chunk index.
+	ci, err := newSkyfileChunkIndex(r.persistDir, skyfileChunkIndexFilename)
+	if err != nil {
+		return nil, err
+	}
+	r.staticSkyfileChunkIndex = ci
+	if err := r.tg.AfterStop(ci.Close); err != nil {
+		return nil, err
+	}
+
+	// Init the skylink index.
+	si, err := newSkylinkIndex(r.persistDir, skylinkIndexFilename)
+	if err != nil {
+		return nil, err
+	}
+	r.staticSkylinkIndex = si
+	if err := r.tg.AfterStop(si.Close); err != nil {
+		return nil, err
+	}
+
+	// Init the upload sessions.
+	us, err := newUploadSessions(r.persistDir, uploadSessionsFilename)
+	if err != nil {
+		return nil, err
+	}
+	r.staticUploadSessions = us
+	if err := r.tg.AfterStop(us.Close); err != nil {
+		return nil, err
+	}
+
+	// Init the skylink health cache.
+	r.staticSkylinkHealthCache = newSkylinkHealthCache()
+
+	// Init the skylink repair priorities.
+	srp, err := newSkylinkRepairPriorities(r.persistDir, skylinkRepairPriorityFilename)
+	if err != nil {
+		return nil, err
+	}
+	r.staticRepairPriorities = srp
+	if err := r.tg.AfterStop(srp.Close); err != nil {
+		return nil, err
+	}
+
 	// Init the statsChan and close it right away to signal that no scan is
 	// going on.
 	r.statsChan = make(chan struct{})
@@ -1164,10 +1458,14 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 		return nil, errors.AddContext(err, "unable to create account manager")
 	}
 
-	r.staticRegistryMemoryManager = newMemoryManager(registryMemoryDefault, registryMemoryPriorityDefault, r.tg.StopChan())
-	r.staticUserUploadMemoryManager = newMemoryManager(userUploadMemoryDefault, userUploadMemoryPriorityDefault, r.tg.StopChan())
-	r.staticUserDownloadMemoryManager = newMemoryManager(userDownloadMemoryDefault, userDownloadMemoryPriorityDefault, r.tg.StopChan())
-	r.staticRepairMemoryManager = newMemoryManager(repairMemoryDefault, repairMemoryPriorityDefault, r.tg.StopChan())
+	r.staticMemoryBudget = newMemoryBudget(0)
+	r.staticWorkerBlocklist, _ = newWorkerBlocklist(nil)
+	r.staticTrustedRegistryHosts = &trustedRegistryHosts{}
+	r.staticSkykeyBackupState = &skykeyBackupState{}
+	r.staticRegistryMemoryManager = newMemoryManager(registryMemoryDefault, registryMemoryPriorityDefault, r.staticMemoryBudget, r.tg.StopChan())
+	r.staticUserUploadMemoryManager = newMemoryManager(userUploadMemoryDefault, userUploadMemoryPriorityDefault, r.staticMemoryBudget, r.tg.StopChan())
+	r.staticUserDownloadMemoryManager = newMemoryManager(userDownloadMemoryDefault, userDownloadMemoryPriorityDefault, r.staticMemoryBudget, r.tg.StopChan())
+	r.staticRepairMemoryManager = newMemoryManager(repairMemoryDefault, repairMemoryPriorityDefault, r.staticMemoryBudget, r.tg.StopChan())
 
 	r.staticFuseManager = newFuseManager(r)
 	r.staticStuckStack = callNewStuckStack()
@@ -1186,6 +1484,63 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 	}
 	r.staticSkynetPortals = sp
 
+	// Add SkynetTokens
+	st, err := skynettokens.New(r.persistDir)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create new skynet token list")
+	}
+	r.staticSkynetTokens = st
+	if err := r.tg.AfterStop(st.Close); err != nil {
+		return nil, err
+	}
+
+	// Add SkynetQuotas
+	sq, err := skynetquotas.New(r.persistDir)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create new skynet quota list")
+	}
+	r.staticSkynetQuotas = sq
+
+	// Add the directory upload sessions
+	sdu, err := newSkynetDirUploader(r, filepath.Join(r.persistDir, skynetDirUploadsDir))
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create new skynet directory uploader")
+	}
+	r.staticSkynetDirUploader = sdu
+
+	// Add the name resolver
+	r.staticSkynetNameResolver = newSkynetNameResolver(build.HNSResolver(), &r.tg)
+
+	// Add the upload scanner
+	r.staticUploadScanner = newUploadScanner(build.UploadScanner())
+
+	// Add the host allow-list feed
+	hal, err := newHostAllowlistFeed(build.HostAllowlist())
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create host allow-list feed")
+	}
+	r.staticHostAllowlist = hal
+
+	// Add the directory conversion jobs
+	sdc, err := newSkynetDirConverter(r, filepath.Join(r.persistDir, skynetConvertDirsDir))
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create new skynet directory converter")
+	}
+	r.staticSkynetDirConverter = sdc
+
+	// Add the skylink deletion jobs
+	sd, err := newSkynetDeleter(r, filepath.Join(r.persistDir, skynetDeletesDir))
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create new skynet deleter")
+	}
+	r.staticSkynetDeleter = sd
+
+	// Create the persistence backend.
+	r.staticPersistBackend, err = newPersistBackend(r.persistDir)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create persistence backend")
+	}
+
 	// Load all saved data.
 	err = r.managedInitPersist()
 	if err != nil {
@@ -1195,6 +1550,27 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 	// Init stream buffer now that the stats are initialised.
 	r.staticStreamBufferSet = newStreamBufferSet(r.staticStreamBufferStats, &r.tg)
 
+	// Initialize the sector cache if enabled.
+	if size, ok := build.SectorCacheSize(); ok && size > 0 {
+		r.staticSectorCache, err = newSectorCache(filepath.Join(r.persistDir, sectorCacheDir), size)
+		if err != nil {
+			return nil, errors.AddContext(err, "unable to create sector cache")
+		}
+	}
+
+	// Initialize the recent upload cache if enabled.
+	if size, ok := build.RecentUploadCacheSize(); ok && size > 0 {
+		r.staticRecentUploadCache, err = newRecentUploadCache(filepath.Join(r.persistDir, recentUploadCacheDir), size)
+		if err != nil {
+			return nil, errors.AddContext(err, "unable to create recent upload cache")
+		}
+	}
+
+	// Initialize the registry cache if enabled.
+	if size, ok := build.RegistryCacheSize(); ok && size > 0 {
+		r.staticRegistryEntryCache = newRegistryEntryCache(size, registryEntryCacheTTL)
+	}
+
 	// After persist is initialized, create the worker pool.
 	r.staticWorkerPool = r.newWorkerPool()
 
@@ -1212,13 +1588,19 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 		return nil, err
 	}
 
-	// Calculate the initial cached utilities and kick off a thread that updates
+	// Calculate the initial cached utilities and schedule a job that updates
 	// the utilities regularly.
 	r.managedUpdateRenterContractsAndUtilities()
-	go r.threadedUpdateRenterContractsAndUtilities()
+	err = r.registerMaintenanceJob(maintenanceJobContractUtilities, cachedUtilitiesUpdateInterval, false, r.managedUpdateRenterContractsAndUtilities)
+	if err != nil {
+		return nil, err
+	}
 
-	// Launch the stat persisting thread.
-	go r.threadedStatsPersister()
+	// Schedule the stat persisting job.
+	err = r.registerMaintenanceJob(maintenanceJobStatsPersist, statsPersistInterval, true, r.managedPersistStats)
+	if err != nil {
+		return nil, err
+	}
 
 	// Spin up background threads which are not depending on the renter being
 	// up-to-date with consensus.
@@ -1231,6 +1613,10 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 		go r.threadedHealthLoop()
 	}
 
+	// Release the chunks of siafiles which were deleted without releasing
+	// them within the skyfile chunk index.
+	go r.threadedPruneSkyfileChunkIndex()
+
 	// If the spending history didn't exist before, manually init it with the
 	// current spending. We don't want portals to pay a huge fee right after
 	// upgrading for pre-skynet license spendings.
@@ -1254,8 +1640,9 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 		return nil, err
 	}
 
-	// Spin up the skynet fee paying goroutine.
-	if err := r.tg.Launch(r.threadedPaySkynetFee); err != nil {
+	// Schedule the skynet fee paying job.
+	err = r.registerMaintenanceJob(maintenanceJobSkynetFeePayout, skymodules.SkynetFeePayoutCheckInterval, true, r.managedPaySkynetFee)
+	if err != nil {
 		return nil, err
 	}
 
@@ -1264,6 +1651,26 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 		return nil, err
 	}
 
+	// Schedule the recent upload cache pruning job.
+	if r.staticRecentUploadCache != nil {
+		err = r.registerMaintenanceJob(maintenanceJobRecentUploadCache, recentUploadCachePruneInterval, false, r.managedPruneRecentUploadCacheJob)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Schedule the pruning of abandoned directory upload sessions.
+	err = r.registerMaintenanceJob(maintenanceJobDirUploadPrune, skynetDirUploadPruneInterval, false, r.staticSkynetDirUploader.managedPruneSessions)
+	if err != nil {
+		return nil, err
+	}
+
+	// Schedule the host allow-list refresh job.
+	err = r.registerMaintenanceJob(maintenanceJobHostAllowlist, hostAllowlistRefreshInterval, true, r.managedRefreshHostAllowlistJob)
+	if err != nil {
+		return nil, err
+	}
+
 	// Unsubscribe on shutdown.
 	err = r.tg.OnStop(func() error {
 		cs.Unsubscribe(r)
@@ -1294,6 +1701,7 @@ func renterAsyncStartup(r *Renter, cs modules.ConsensusSet) error {
 	// consensus set.
 	// Spin up the workers for the work pool.
 	go r.threadedDownloadLoop()
+	go r.threadedCheckStuckDownloadChunks()
 	if !r.staticDeps.Disrupt("DisableRepairAndHealthLoops") {
 		go r.threadedUploadAndRepair()
 		go r.threadedStuckFileLoop()
@@ -1302,27 +1710,13 @@ func renterAsyncStartup(r *Renter, cs modules.ConsensusSet) error {
 	if !r.staticDeps.Disrupt("DisableSnapshotSync") {
 		go r.threadedSynchronizeSnapshots()
 	}
+	// Resume the directory conversions which were interrupted by a shutdown.
+	go r.staticSkynetDirConverter.threadedResumeJobs()
+	// Resume the skylink deletions which were interrupted by a shutdown.
+	go r.staticSkynetDeleter.threadedResumeJobs()
 	return nil
 }
 
-// threadedUpdateRenterContractsAndUtilities periodically calls
-// managedUpdateRenterContractsAndUtilities.
-func (r *Renter) threadedUpdateRenterContractsAndUtilities() {
-	err := r.tg.Add()
-	if err != nil {
-		return
-	}
-	defer r.tg.Done()
-	for {
-		select {
-		case <-r.tg.StopChan():
-			return
-		case <-time.After(cachedUtilitiesUpdateInterval):
-		}
-		r.managedUpdateRenterContractsAndUtilities()
-	}
-}
-
 // NewCustomRenter initializes a renter and returns it.
 func NewCustomRenter(g modules.Gateway, cs modules.ConsensusSet, tpool modules.TransactionPool, hdb skymodules.HostDB, w modules.Wallet, hc hostContractor, mux *siamux.SiaMux, tus skymodules.SkynetTUSUploadStore, persistDir string, rl *ratelimit.RateLimit, deps skymodules.SkydDependencies) (*Renter, <-chan error) {
 	errChan := make(chan error, 1)
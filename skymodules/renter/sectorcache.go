@@ -0,0 +1,185 @@
+package renter
+
+import (
+	"container/list"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// sectorCacheDir is the name of the directory within the renter's persist
+	// dir which holds the cached sectors.
+	sectorCacheDir = "sectorcache"
+
+	// sectorCacheTmpSuffix is the suffix of sectors which are currently being
+	// written to the cache.
+	sectorCacheTmpSuffix = ".tmp"
+)
+
+var (
+	// errSectorCacheMiss is returned if a sector is not in the cache.
+	errSectorCacheMiss = errors.New("sector not found in cache")
+)
+
+type (
+	// sectorCache is a size-bounded on-disk cache for full sectors. Sectors
+	// are stored in files named after their merkle root and are evicted in
+	// LRU order once the size of the cache exceeds its max size. Whenever a
+	// sector is read from the cache, its merkle root is verified to make sure
+	// the cache never serves corrupted data.
+	sectorCache struct {
+		entries map[crypto.Hash]*list.Element
+		lru     *list.List
+		size    uint64
+
+		staticDir     string
+		staticMaxSize uint64
+		mu            sync.Mutex
+	}
+)
+
+// newSectorCache creates a new sector cache in the given dir. Sectors which
+// are already in the dir are loaded into the cache in the order they were last
+// modified.
+func newSectorCache(dir string, maxSize uint64) (*sectorCache, error) {
+	if err := os.MkdirAll(dir, skymodules.DefaultDirPerm); err != nil {
+		return nil, errors.AddContext(err, "failed to create sector cache dir")
+	}
+	sc := &sectorCache{
+		entries:       make(map[crypto.Hash]*list.Element),
+		lru:           list.New(),
+		staticDir:     dir,
+		staticMaxSize: maxSize,
+	}
+
+	// Load the existing sectors, oldest first.
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to read sector cache dir")
+	}
+	sort.Slice(fis, func(i, j int) bool {
+		return fis[i].ModTime().Before(fis[j].ModTime())
+	})
+	for _, fi := range fis {
+		var root crypto.Hash
+		if err := root.LoadString(fi.Name()); err != nil || uint64(fi.Size()) != modules.SectorSize {
+			// Remove leftovers of interrupted writes and unknown files.
+			if err := os.Remove(filepath.Join(dir, fi.Name())); err != nil {
+				return nil, errors.AddContext(err, "failed to remove invalid file from sector cache")
+			}
+			continue
+		}
+		sc.entries[root] = sc.lru.PushFront(root)
+		sc.size += modules.SectorSize
+	}
+	if err := sc.managedEvict(); err != nil {
+		return nil, errors.AddContext(err, "failed to evict sectors from cache")
+	}
+	return sc, nil
+}
+
+// path returns the path of the file for the sector with the given root.
+func (sc *sectorCache) path(root crypto.Hash) string {
+	return filepath.Join(sc.staticDir, hex.EncodeToString(root[:]))
+}
+
+// managedAdd adds a full sector to the cache, evicting the least recently used
+// sectors if necessary.
+func (sc *sectorCache) managedAdd(root crypto.Hash, sector []byte) error {
+	if uint64(len(sector)) != modules.SectorSize {
+		return errors.New("only full sectors can be cached")
+	}
+	sc.mu.Lock()
+	_, exists := sc.entries[root]
+	sc.mu.Unlock()
+	if exists {
+		return nil
+	}
+
+	// Write the sector to a tmp file first to avoid partially written
+	// sectors.
+	path := sc.path(root)
+	tmpPath := path + sectorCacheTmpSuffix
+	if err := ioutil.WriteFile(tmpPath, sector, skymodules.DefaultFilePerm); err != nil {
+		return errors.AddContext(err, "failed to write sector to cache")
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return errors.Compose(err, os.Remove(tmpPath))
+	}
+
+	sc.mu.Lock()
+	if _, exists := sc.entries[root]; !exists {
+		sc.entries[root] = sc.lru.PushFront(root)
+		sc.size += modules.SectorSize
+	}
+	sc.mu.Unlock()
+	return sc.managedEvict()
+}
+
+// managedEvict removes the least recently used sectors until the cache no
+// longer exceeds its max size.
+func (sc *sectorCache) managedEvict() error {
+	var toRemove []crypto.Hash
+	sc.mu.Lock()
+	for sc.size > sc.staticMaxSize && sc.lru.Len() > 0 {
+		root := sc.lru.Remove(sc.lru.Back()).(crypto.Hash)
+		delete(sc.entries, root)
+		sc.size -= modules.SectorSize
+		toRemove = append(toRemove, root)
+	}
+	sc.mu.Unlock()
+
+	var errs error
+	for _, root := range toRemove {
+		if err := os.Remove(sc.path(root)); err != nil && !os.IsNotExist(err) {
+			errs = errors.Compose(errs, err)
+		}
+	}
+	return errs
+}
+
+// managedGet returns the sector with the given root from the cache. If the
+// sector on disk doesn't match its root, it is removed from the cache.
+func (sc *sectorCache) managedGet(root crypto.Hash) ([]byte, error) {
+	sc.mu.Lock()
+	e, exists := sc.entries[root]
+	if exists {
+		sc.lru.MoveToFront(e)
+	}
+	sc.mu.Unlock()
+	if !exists {
+		return nil, errSectorCacheMiss
+	}
+
+	sector, err := ioutil.ReadFile(sc.path(root))
+	if err == nil && (uint64(len(sector)) != modules.SectorSize || crypto.MerkleRoot(sector) != root) {
+		err = errors.New("cached sector is corrupted")
+	}
+	if err != nil {
+		sc.managedRemove(root)
+		return nil, errors.Compose(errSectorCacheMiss, err)
+	}
+	return sector, nil
+}
+
+// managedRemove removes a sector from the cache.
+func (sc *sectorCache) managedRemove(root crypto.Hash) {
+	sc.mu.Lock()
+	e, exists := sc.entries[root]
+	if exists {
+		sc.lru.Remove(e)
+		delete(sc.entries, root)
+		sc.size -= modules.SectorSize
+	}
+	sc.mu.Unlock()
+	_ = os.Remove(sc.path(root))
+}
@@ -0,0 +1,114 @@
+package renter
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/SkynetLabs/skyd/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestSectorCache is a unit test for the sectorCache.
+func TestSectorCache(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	// Leave an interrupted write behind which should be cleaned up.
+	tmpFile := filepath.Join(dir, "foo"+sectorCacheTmpSuffix)
+	if err := ioutil.WriteFile(tmpFile, []byte{1, 2, 3}, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a cache which can hold 2 sectors.
+	sc, err := newSectorCache(dir, 2*modules.SectorSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(tmpFile); !os.IsNotExist(err) {
+		t.Fatal("tmp file wasn't removed", err)
+	}
+
+	// newSector is a helper to create a random sector.
+	newSector := func() ([]byte, crypto.Hash) {
+		sector := fastrand.Bytes(int(modules.SectorSize))
+		return sector, crypto.MerkleRoot(sector)
+	}
+	sector1, root1 := newSector()
+	sector2, root2 := newSector()
+	sector3, root3 := newSector()
+
+	// Partial sectors can't be added.
+	if err := sc.managedAdd(root1, sector1[:100]); err == nil {
+		t.Fatal("should fail")
+	}
+
+	// Miss.
+	if _, err := sc.managedGet(root1); !errors.Contains(err, errSectorCacheMiss) {
+		t.Fatal("expected miss", err)
+	}
+
+	// Add two sectors and fetch them.
+	if err := sc.managedAdd(root1, sector1); err != nil {
+		t.Fatal(err)
+	}
+	if err := sc.managedAdd(root2, sector2); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := sc.managedGet(root1); err != nil || !bytes.Equal(s, sector1) {
+		t.Fatal("wrong sector", err)
+	}
+
+	// Add a third one. Since sector1 was used more recently, sector2 should be
+	// evicted.
+	if err := sc.managedAdd(root3, sector3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sc.managedGet(root2); !errors.Contains(err, errSectorCacheMiss) {
+		t.Fatal("expected miss", err)
+	}
+	if _, err := os.Stat(sc.path(root2)); !os.IsNotExist(err) {
+		t.Fatal("sector wasn't removed from disk", err)
+	}
+
+	// Corrupt sector3 on disk. It should be removed when fetched.
+	if err := ioutil.WriteFile(sc.path(root3), sector2, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sc.managedGet(root3); !errors.Contains(err, errSectorCacheMiss) {
+		t.Fatal("expected miss", err)
+	}
+	if sc.size != modules.SectorSize || len(sc.entries) != 1 || sc.lru.Len() != 1 {
+		t.Fatal("wrong cache state", sc.size, len(sc.entries), sc.lru.Len())
+	}
+
+	// Reload the cache with a smaller size.
+	if err := sc.managedAdd(root2, sector2); err != nil {
+		t.Fatal(err)
+	}
+	sc, err = newSectorCache(dir, modules.SectorSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sc.entries) != 1 {
+		t.Fatal("wrong number of entries", len(sc.entries))
+	}
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fis) != 1 {
+		t.Fatal("wrong number of files", len(fis))
+	}
+}
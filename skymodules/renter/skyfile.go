@@ -101,6 +101,14 @@ var (
 	// ErrInvalidSkylinkVersion is returned when an operation fails due to the
 	// skylink having the wrong version.
 	ErrInvalidSkylinkVersion = errors.New("skylink had unexpected version")
+
+	// ErrFanoutNotFetched is returned when reading from a streamer returned by
+	// DownloadSkylinkMetadata for a skyfile with a fanout.
+	ErrFanoutNotFetched = errors.New("the fanout of the skyfile wasn't fetched")
+
+	// ErrSkyfileImportVerificationFailed is returned when the data of an
+	// imported skyfile doesn't match its skylink.
+	ErrSkyfileImportVerificationFailed = errors.New("imported data doesn't match the skylink")
 )
 
 // skyfileEstablishDefaults will set any zero values in the lup to be equal to
@@ -111,6 +119,33 @@ func skyfileEstablishDefaults(lup *skymodules.SkyfileUploadParameters) {
 	}
 }
 
+// managedFanoutRedundancy returns the number of data and parity pieces to use
+// for the fanout of a skyfile according to the renter's fanout redundancy
+// policy. The dynamic policy considers the workers of hosts which are good for
+// upload and are neither on an upload cooldown nor blocklisted.
+func (r *Renter) managedFanoutRedundancy() (dataPieces, parityPieces int) {
+	id := r.mu.RLock()
+	policy := r.persist.FanoutRedundancyPolicy
+	r.mu.RUnlock(id)
+	if policy != skymodules.FanoutRedundancyPolicyDynamic {
+		return policy.Redundancy(0)
+	}
+	goodHosts := 0
+	for _, w := range r.staticWorkerPool.callWorkers() {
+		if !w.staticCache().staticContractUtility.GoodForUpload || w.staticJobsDisabledErr() != nil {
+			continue
+		}
+		w.mu.Lock()
+		onCooldown, _ := w.onUploadCooldown()
+		w.mu.Unlock()
+		if onCooldown {
+			continue
+		}
+		goodHosts++
+	}
+	return policy.Redundancy(goodHosts)
+}
+
 // fileUploadParams will create an erasure coder and return the FileUploadParams
 // to use when uploading using the provided parameters.
 func fileUploadParams(siaPath skymodules.SiaPath, dataPieces, parityPieces int, force bool, ct crypto.CipherType) (skymodules.FileUploadParams, error) {
@@ -141,7 +176,22 @@ func baseSectorUploadParamsFromSUP(sup skymodules.SkyfileUploadParameters) (skym
 	// encryption. This should cause all of the pieces to have the same Merkle
 	// root, which is critical to making the file discoverable to viewnodes and
 	// also resilient to host failures.
-	return fileUploadParams(sup.SiaPath, 1, int(sup.BaseChunkRedundancy)-1, sup.Force, crypto.TypePlain)
+	fup, err := fileUploadParams(sup.SiaPath, 1, int(sup.BaseChunkRedundancy)-1, sup.Force, crypto.TypePlain)
+	if err != nil {
+		return skymodules.FileUploadParams{}, err
+	}
+	fup.AllowedHosts = sup.AllowedHosts
+	fup.ExpiryTime = skyfileExpiryTime(sup)
+	return fup, nil
+}
+
+// skyfileExpiryTime returns the time after which a skyfile uploaded with the
+// given parameters is deleted. A zero value means that it doesn't expire.
+func skyfileExpiryTime(sup skymodules.SkyfileUploadParameters) time.Time {
+	if sup.TTL <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(sup.TTL)
 }
 
 // streamerFromReader wraps a bytes.Reader to give it a Close() method, which
@@ -150,6 +200,14 @@ type streamerFromReader struct {
 	*bytes.Reader
 }
 
+// noFanoutStreamer is a skymodules.Streamer for a skyfile whose fanout wasn't
+// fetched. It can be seeked, which allows for determining the size of the
+// skyfile, but reading from it fails.
+type noFanoutStreamer struct {
+	off  int64
+	size int64
+}
+
 // skylinkStreamerFromReader wraps a streamerFromReader to give it a Metadata()
 // method, which allows it to satisfy the modules.SkyfileStreamer interface.
 type skylinkStreamerFromReader struct {
@@ -165,6 +223,40 @@ func (sfr *streamerFromReader) Close() error {
 	return nil
 }
 
+// Close is a no-op because the noFanoutStreamer doesn't hold any resources.
+func (nfs *noFanoutStreamer) Close() error {
+	return nil
+}
+
+// Read implements the io.Reader interface. It always fails unless the end of
+// the skyfile was reached.
+func (nfs *noFanoutStreamer) Read(b []byte) (int, error) {
+	if nfs.off >= nfs.size {
+		return 0, io.EOF
+	}
+	return 0, ErrFanoutNotFetched
+}
+
+// Seek implements the io.Seeker interface.
+func (nfs *noFanoutStreamer) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = nfs.off + offset
+	case io.SeekEnd:
+		abs = nfs.size + offset
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if abs < 0 {
+		return 0, errors.New("negative position")
+	}
+	nfs.off = abs
+	return abs, nil
+}
+
 // StreamerFromSlice returns a skymodules.Streamer given a slice. This is
 // non-trivial because a bytes.Reader does not implement Close.
 func StreamerFromSlice(b []byte) skymodules.Streamer {
@@ -196,6 +288,18 @@ func (sfr *skylinkStreamerFromReader) Metadata() skymodules.SkyfileMetadata {
 	return sfr.staticMD
 }
 
+// Bandwidth implements the skymodules.SkyfileStreamer interface. The data of
+// the streamer is already in memory so no bandwidth was used.
+func (sfr *skylinkStreamerFromReader) Bandwidth() (up, down uint64) {
+	return 0, 0
+}
+
+// Provenance implements the skymodules.SkyfileStreamer interface. The data
+// of the streamer is already in memory so there is no provenance.
+func (sfr *skylinkStreamerFromReader) Provenance() []skymodules.ChunkProvenance {
+	return nil
+}
+
 // RawMetadata implements the modules.SkyfileStreamer interface.
 func (sfr *skylinkStreamerFromReader) RawMetadata() []byte {
 	return sfr.staticRawMD
@@ -230,9 +334,11 @@ func (r *Renter) CreateSkylinkFromSiafile(sup skymodules.SkyfileUploadParameters
 
 	// Override the metadata with the info from the fileNode.
 	metadata := skymodules.SkyfileMetadata{
-		Filename: siaPath.Name(),
-		Mode:     fileNode.Mode(),
-		Length:   fileNode.Size(),
+		Filename:      siaPath.Name(),
+		Mode:          fileNode.Mode(),
+		Length:        fileNode.Size(),
+		ExtraMetadata: sup.ExtraMetadata,
+		Headers:       sup.Headers,
 	}
 
 	// Generate the fanoutBytes
@@ -334,6 +440,22 @@ func (r *Renter) managedCreateSkylinkRawMD(ctx context.Context, sup skymodules.S
 		return skymodules.Skylink{}, err
 	}
 
+	// Check if the new skylink was deleted.
+	deleted, err := r.managedIsDeleted(ctx, skylink)
+	if err != nil {
+		return skymodules.Skylink{}, err
+	}
+	if deleted {
+		err = skymodules.ErrSkylinkDeleted
+		// Skylink was deleted, return error and try and delete file
+		deleteErr := r.DeleteFile(sup.SiaPath)
+		// Don't bother returning an error if the file doesn't exist
+		if !errors.Contains(deleteErr, filesystem.ErrNotExist) {
+			err = errors.Compose(err, deleteErr)
+		}
+		return skymodules.Skylink{}, err
+	}
+
 	// Upload the base sector.
 	err = r.managedUploadBaseSector(ctx, sup, baseSector, skylink)
 	if err != nil {
@@ -426,6 +548,38 @@ func (r *Renter) Blocklist() ([]crypto.Hash, error) {
 	return r.staticSkynetBlocklist.Blocklist(), nil
 }
 
+// BlocklistReasons returns the reasons why merkleroots were added to the
+// blocklist if known.
+func (r *Renter) BlocklistReasons() (map[crypto.Hash]string, error) {
+	err := r.tg.Add()
+	if err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	return r.staticSkynetBlocklist.Reasons(), nil
+}
+
+// CheckSkynetBlocklist returns whether the given skylinks or hashes of
+// merkleroots are on the blocklist. This allows for checking the content of an
+// upload before paying for it.
+func (r *Renter) CheckSkynetBlocklist(ctx context.Context, candidates []string, isHash bool) ([]bool, error) {
+	err := r.tg.Add()
+	if err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+
+	hashes, err := r.managedParseBlocklistHashes(ctx, candidates, isHash)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to parse blocklist candidates")
+	}
+	blocked := make([]bool, len(hashes))
+	for i, hash := range hashes {
+		blocked[i] = r.staticSkynetBlocklist.IsHashBlocked(hash)
+	}
+	return blocked, nil
+}
+
 // UpdateSkynetBlocklist updates the list of hashed merkleroots that are blocked
 func (r *Renter) UpdateSkynetBlocklist(ctx context.Context, additions, removals []string, isHash bool) error {
 	err := r.tg.Add()
@@ -511,13 +665,29 @@ func (r *Renter) managedUploadBaseSector(ctx context.Context, sup skymodules.Sky
 
 	// Add the skylink to the Siafile.
 	err = fileNode.AddSkylink(skylink)
-	return errors.AddContext(err, "unable to add skylink to siafile")
+	if err != nil {
+		return errors.AddContext(err, "unable to add skylink to siafile")
+	}
+
+	// Add the skylink to the skylink index.
+	err = r.managedIndexBaseSector(skylink, sup.SiaPath, baseSector)
+	if err != nil {
+		return errors.AddContext(err, "unable to add skylink to skylink index")
+	}
+
+	// Serve the base sector locally until the siafile reached full
+	// redundancy.
+	r.managedAddRecentUpload(skylink.MerkleRoot(), sup.SiaPath, baseSector)
+	return nil
 }
 
 // managedUploadSkyfile uploads a file and returns the skylink and whether or
 // not it was a large file.
 func (r *Renter) managedUploadSkyfile(ctx context.Context, sup skymodules.SkyfileUploadParameters, reader skymodules.SkyfileUploadReader) (skymodules.Skylink, error) {
-	// see if we can fit the entire upload in a single chunk
+	// see if we can fit the entire upload in a single chunk, the buffer is
+	// accounted for in the memory budget until the upload is done
+	r.staticMemoryBudget.callAdd(modules.SectorSize)
+	defer r.staticMemoryBudget.callRelease(modules.SectorSize)
 	buf := make([]byte, modules.SectorSize)
 	numBytes, err := io.ReadFull(reader, buf)
 	buf = buf[:numBytes] // truncate the buffer
@@ -606,6 +776,16 @@ func (r *Renter) managedUploadSkyfileSmallFile(ctx context.Context, sup skymodul
 		return skylink, nil
 	}
 
+	// Check if the new skylink is blocked before paying for the upload of
+	// the base sector.
+	blocked, err := r.managedIsBlocked(ctx, skylink)
+	if err != nil {
+		return skymodules.Skylink{}, err
+	}
+	if blocked {
+		return skymodules.Skylink{}, ErrSkylinkBlocked
+	}
+
 	// Upload the base sector.
 	start := time.Now()
 	err = r.managedUploadBaseSector(ctx, sup, baseSector, skylink)
@@ -638,10 +818,23 @@ func (r *Renter) managedUploadSkyfileLargeFile(ctx context.Context, sup skymodul
 		return skymodules.Skylink{}, errors.AddContext(err, "unable to create SiaPath for large skyfile extended data")
 	}
 
+	// Choose the redundancy of the fanout according to the fanout redundancy
+	// policy, it is recorded in the skyfile's layout. Resumed upload sessions
+	// keep the redundancy of their earlier attempts and archived skyfiles
+	// always use the archive redundancy.
+	dataPieces, parityPieces := r.managedFanoutRedundancy()
+	if sup.SessionID != "" && !sup.DryRun {
+		if dp, pp, exists := r.managedUploadSessionRedundancy(sup, siaPath); exists {
+			dataPieces, parityPieces = dp, pp
+		}
+	}
+	if sup.Archive {
+		dataPieces = skymodules.RenterArchiveDataPieces
+		parityPieces = skymodules.RenterArchiveParityPieces
+	}
+
 	// Disrupt and use custom redundancy if the StandardUploadRedundancy
 	// dependency is set.
-	dataPieces := skymodules.RenterDefaultDataPieces
-	parityPieces := skymodules.RenterDefaultParityPieces
 	if r.staticDeps.Disrupt("StandardUploadRedundancy") {
 		dataPieces = 10
 		parityPieces = 20
@@ -652,6 +845,14 @@ func (r *Renter) managedUploadSkyfileLargeFile(ctx context.Context, sup skymodul
 	if err != nil {
 		return skymodules.Skylink{}, errors.AddContext(err, "unable to create FileUploadParams for large file")
 	}
+	// The fanout of an archived skyfile is stored on the archive hosts and
+	// the fanout of a skyfile with a profile on the hosts of that profile. The
+	// base sector always remains on the regular hosts to keep the skylink
+	// resolvable with low latency.
+	fup.Archive = sup.Archive
+	fup.Profile = sup.Profile
+	fup.AllowedHosts = sup.AllowedHosts
+	fup.ExpiryTime = skyfileExpiryTime(sup)
 
 	// Generate a Cipher Key for the FileUploadParams.
 	err = generateCipherKey(&fup, sup)
@@ -659,8 +860,19 @@ func (r *Renter) managedUploadSkyfileLargeFile(ctx context.Context, sup skymodul
 		return skymodules.Skylink{}, errors.AddContext(err, "unable to create Cipher key for FileUploadParams")
 	}
 
-	// Check the upload params first and create a fileNode.
-	fileNode, err := r.managedInitUploadStream(fup)
+	// Check the upload params first and create a fileNode. Upload sessions
+	// reopen the fileNode of an earlier attempt if possible.
+	var fileNode *filesystem.FileNode
+	var completed map[uint64]struct{}
+	sessionID := sup.SessionID
+	if sup.DryRun {
+		sessionID = ""
+	}
+	if sessionID != "" {
+		fileNode, completed, err = r.managedOpenUploadSession(sup, fup)
+	} else {
+		fileNode, err = r.managedInitUploadStream(fup)
+	}
 	if err != nil {
 		return skymodules.Skylink{}, err
 	}
@@ -691,8 +903,9 @@ func (r *Renter) managedUploadSkyfileLargeFile(ctx context.Context, sup skymodul
 		// their merkle roots.
 		err = r.managedPopulateFileNodeFromReader(fileNode, cr)
 	} else {
-		// Upload the file using a streamer.
-		_, err = r.callUploadStreamFromReaderWithFileNode(ctx, fileNode, cr, 0)
+		// Upload the file while sharing chunks which were uploaded for
+		// other skyfiles already.
+		err = r.managedUploadDeduplicatedFromReader(ctx, fileNode, cr, sessionID, completed)
 	}
 	if err != nil {
 		return skymodules.Skylink{}, errors.AddContext(err, "failed to upload file")
@@ -722,12 +935,18 @@ func (r *Renter) managedUploadSkyfileLargeFile(ctx context.Context, sup skymodul
 	if err != nil {
 		return skymodules.Skylink{}, errors.AddContext(err, "unable to create skylink from filenode")
 	}
+
+	// The upload is finished so the session is no longer needed.
+	err = r.staticUploadSessions.managedRemove(sessionID)
+	if err != nil {
+		return skymodules.Skylink{}, errors.AddContext(err, "unable to remove upload session")
+	}
 	return skylink, nil
 }
 
 // DownloadByRoot will fetch data using the merkle root of that data. This uses
 // all of the async worker primitives to improve speed and throughput.
-func (r *Renter) DownloadByRoot(root crypto.Hash, offset, length uint64, timeout time.Duration, pricePerMS types.Currency) ([]byte, error) {
+func (r *Renter) DownloadByRoot(root crypto.Hash, offset, length uint64, timeout time.Duration, pricePerMS types.Currency, overdrive skymodules.OverdriveSettings) ([]byte, error) {
 	if err := r.tg.Add(); err != nil {
 		return nil, err
 	}
@@ -738,6 +957,11 @@ func (r *Renter) DownloadByRoot(root crypto.Hash, offset, length uint64, timeout
 		return nil, ErrSkylinkBlocked
 	}
 
+	// Check if the merkleroot was deleted
+	if r.staticSkynetDeleter.managedIsTombstoned(crypto.HashObject(root)) {
+		return nil, skymodules.ErrSkylinkDeleted
+	}
+
 	// Create the context
 	ctx := r.tg.StopCtx()
 	if timeout > 0 {
@@ -751,11 +975,12 @@ func (r *Renter) DownloadByRoot(root crypto.Hash, offset, length uint64, timeout
 	span.SetTag("root", root)
 	defer span.Finish()
 
-	// Attach the span to the ctx
+	// Attach the span and the overdrive settings to the ctx
 	ctx = opentracing.ContextWithSpan(ctx, span)
+	ctx = contextWithOverdriveSettings(ctx, overdrive)
 
 	// Fetch the data
-	data, _, err := r.managedDownloadByRoot(ctx, root, offset, length, pricePerMS)
+	data, err := r.managedDownloadByRootCached(ctx, root, offset, length, pricePerMS)
 	if errors.Contains(err, ErrProjectTimedOut) {
 		err = errors.AddContext(err, fmt.Sprintf("timed out after %vs", timeout.Seconds()))
 	}
@@ -764,7 +989,7 @@ func (r *Renter) DownloadByRoot(root crypto.Hash, offset, length uint64, timeout
 
 // DownloadSkylink will take a link and turn it into the metadata and data of a
 // download.
-func (r *Renter) DownloadSkylink(link skymodules.Skylink, timeout time.Duration, pricePerMS types.Currency) (skymodules.SkyfileStreamer, []skymodules.RegistryEntry, error) {
+func (r *Renter) DownloadSkylink(link skymodules.Skylink, timeout time.Duration, pricePerMS types.Currency, overdrive skymodules.OverdriveSettings, access *skymodules.SkylinkAccess) (skymodules.SkyfileStreamer, []skymodules.RegistryEntry, error) {
 	if err := r.tg.Add(); err != nil {
 		return nil, nil, err
 	}
@@ -782,8 +1007,10 @@ func (r *Renter) DownloadSkylink(link skymodules.Skylink, timeout time.Duration,
 	span := opentracing.StartSpan("DownloadSkylink")
 	span.SetTag("skylink", link.String())
 
-	// Attach the span to the ctx
+	// Attach the span, the overdrive settings and the access to the ctx
 	ctx = opentracing.ContextWithSpan(ctx, span)
+	ctx = contextWithOverdriveSettings(ctx, overdrive)
+	ctx = contextWithSkylinkAccess(ctx, access)
 
 	// Check if link needs to be resolved from V2 to V1.
 	link, srvs, err := r.managedTryResolveSkylinkV2(ctx, link, true)
@@ -793,6 +1020,9 @@ func (r *Renter) DownloadSkylink(link skymodules.Skylink, timeout time.Duration,
 
 	// Download the data
 	streamer, err := r.managedDownloadSkylink(ctx, link, timeout, pricePerMS)
+	if err == nil {
+		r.staticRepairPriorities.callRecordDownload(link.String())
+	}
 	if errors.Contains(err, ErrProjectTimedOut) {
 		span.LogKV("timeout", timeout)
 		span.SetTag("timeout", true)
@@ -804,7 +1034,7 @@ func (r *Renter) DownloadSkylink(link skymodules.Skylink, timeout time.Duration,
 
 // DownloadSkylinkBaseSector will take a link and turn it into the data of
 // a basesector without any decoding of the metadata, fanout, or decryption.
-func (r *Renter) DownloadSkylinkBaseSector(link skymodules.Skylink, timeout time.Duration, pricePerMS types.Currency) (skymodules.Streamer, []skymodules.RegistryEntry, skymodules.Skylink, error) {
+func (r *Renter) DownloadSkylinkBaseSector(link skymodules.Skylink, timeout time.Duration, pricePerMS types.Currency, overdrive skymodules.OverdriveSettings, access *skymodules.SkylinkAccess) (skymodules.Streamer, []skymodules.RegistryEntry, skymodules.Skylink, error) {
 	if err := r.tg.Add(); err != nil {
 		return nil, nil, link, err
 	}
@@ -823,8 +1053,10 @@ func (r *Renter) DownloadSkylinkBaseSector(link skymodules.Skylink, timeout time
 	span.SetTag("skylink", link.String())
 	defer span.Finish()
 
-	// Attach the span to the ctx
+	// Attach the span, the overdrive settings and the access to the ctx
 	ctx = opentracing.ContextWithSpan(ctx, span)
+	ctx = contextWithOverdriveSettings(ctx, overdrive)
+	ctx = contextWithSkylinkAccess(ctx, access)
 
 	// Check if link needs to be resolved from V2 to V1.
 	link, srvs, err := r.managedTryResolveSkylinkV2(ctx, link, true)
@@ -839,10 +1071,84 @@ func (r *Renter) DownloadSkylinkBaseSector(link skymodules.Skylink, timeout time
 	}
 
 	// Download the base sector
-	baseSector, _, err := r.managedDownloadByRoot(ctx, link.MerkleRoot(), offset, fetchSize, pricePerMS)
+	baseSector, err := r.managedDownloadByRootCached(ctx, link.MerkleRoot(), offset, fetchSize, pricePerMS)
 	return StreamerFromSlice(baseSector), srvs, link, err
 }
 
+// DownloadSkylinkMetadata will take a link and turn it into the metadata of a
+// download. Only the base sector is fetched and no chunk fetchers are created
+// for the fanout, which makes it a lot cheaper than DownloadSkylink. If the
+// skyfile's data is stored in the base sector, the returned streamer serves
+// it. Otherwise reading from the streamer returns ErrFanoutNotFetched, but it
+// can still be seeked to determine the size of the skyfile.
+func (r *Renter) DownloadSkylinkMetadata(link skymodules.Skylink, timeout time.Duration, pricePerMS types.Currency, overdrive skymodules.OverdriveSettings, access *skymodules.SkylinkAccess) (skymodules.SkyfileStreamer, []skymodules.RegistryEntry, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, nil, err
+	}
+	defer r.tg.Done()
+
+	// Create a context
+	ctx := r.tg.StopCtx()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(r.tg.StopCtx(), timeout)
+		defer cancel()
+	}
+
+	// Create a new span.
+	span := opentracing.StartSpan("DownloadSkylinkMetadata")
+	span.SetTag("skylink", link.String())
+	defer span.Finish()
+
+	// Attach the span, the overdrive settings and the access to the ctx
+	ctx = opentracing.ContextWithSpan(ctx, span)
+	ctx = contextWithOverdriveSettings(ctx, overdrive)
+	ctx = contextWithSkylinkAccess(ctx, access)
+
+	// Check if link needs to be resolved from V2 to V1.
+	link, srvs, err := r.managedTryResolveSkylinkV2(ctx, link, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Fetch the base sector. It is served from the sector cache if possible.
+	offset, fetchSize, err := link.OffsetAndFetchSize()
+	if err != nil {
+		return nil, nil, errors.AddContext(err, "unable to parse skylink")
+	}
+	baseSector, err := r.managedDownloadByRootCached(ctx, link.MerkleRoot(), offset, fetchSize, pricePerMS)
+	if errors.Contains(err, ErrProjectTimedOut) {
+		span.LogKV("timeout", timeout)
+		span.SetTag("timeout", true)
+		err = errors.AddContext(err, fmt.Sprintf("timed out after %vs", timeout.Seconds()))
+	}
+	if err != nil {
+		return nil, nil, errors.AddContext(err, "unable to download base sector")
+	}
+
+	// Decrypt the base sector if necessary and parse the metadata.
+	if skymodules.IsEncryptedBaseSector(baseSector) {
+		_, err = r.managedDecryptBaseSector(baseSector)
+		if err != nil {
+			return nil, nil, errors.AddContext(err, "unable to decrypt skyfile base sector")
+		}
+	}
+	layout, fanoutBytes, metadata, rawMetadata, baseSectorPayload, err := skymodules.ParseSkyfileMetadata(baseSector)
+	if err != nil {
+		return nil, nil, errors.AddContext(err, "error parsing skyfile metadata")
+	}
+	if len(fanoutBytes) == 0 {
+		return SkylinkStreamerFromSlice(baseSectorPayload, metadata, rawMetadata, link, layout), srvs, nil
+	}
+	return &skylinkStreamerFromReader{
+		Streamer:      &noFanoutStreamer{size: int64(layout.Filesize)},
+		staticLayout:  layout,
+		staticMD:      metadata,
+		staticRawMD:   rawMetadata,
+		staticSkylink: link,
+	}, srvs, nil
+}
+
 // managedDownloadSkylink will take a link and turn it into the metadata and
 // data of a download.
 func (r *Renter) managedDownloadSkylink(ctx context.Context, link skymodules.Skylink, streamReadTimeout time.Duration, pricePerMS types.Currency) (skymodules.SkyfileStreamer, error) {
@@ -918,6 +1224,15 @@ func (r *Renter) PinSkylink(skylink skymodules.Skylink, lup skymodules.SkyfileUp
 		return ErrSkylinkBlocked
 	}
 
+	// Check if link was deleted
+	deleted, err := r.managedIsDeleted(ctx, skylink)
+	if err != nil {
+		return err
+	}
+	if deleted {
+		return skymodules.ErrSkylinkDeleted
+	}
+
 	// Create a span.
 	span := opentracing.StartSpan("PinSkylink")
 	span.SetTag("skylink", skylink.String())
@@ -927,7 +1242,7 @@ func (r *Renter) PinSkylink(skylink skymodules.Skylink, lup skymodules.SkyfileUp
 	ctx = opentracing.ContextWithSpan(ctx, span)
 
 	// Fetch the leading chunk.
-	baseSector, err := r.DownloadByRoot(skylink.MerkleRoot(), 0, modules.SectorSize, timeout, pricePerMS)
+	baseSector, err := r.DownloadByRoot(skylink.MerkleRoot(), 0, modules.SectorSize, timeout, pricePerMS, skymodules.OverdriveSettings{})
 	if err != nil {
 		return errors.AddContext(err, "unable to fetch base sector of skylink")
 	}
@@ -991,9 +1306,10 @@ func (r *Renter) PinSkylink(skylink skymodules.Skylink, lup skymodules.SkyfileUp
 		return errors.AddContext(err, "unable to upload base sector")
 	}
 
-	// If there is no fanout, nothing more to do, the pin is complete.
+	// If there is no fanout, nothing more to do, the pin is complete once the
+	// content was scanned.
 	if layout.FanoutSize == 0 {
-		return nil
+		return r.managedScanUploadedSkyfile(ctx, skylink, lup.SiaPath)
 	}
 	// Create the erasure coder to use when uploading the file bulk.
 	fup.ErasureCode, err = skymodules.NewRSSubCode(int(layout.FanoutDataPieces), int(layout.FanoutParityPieces), crypto.SegmentSize)
@@ -1023,7 +1339,7 @@ func (r *Renter) PinSkylink(skylink skymodules.Skylink, lup skymodules.SkyfileUp
 	if err != nil {
 		return errors.AddContext(err, "unable to upload skyfile fanout")
 	}
-	return nil
+	return r.managedScanUploadedSkyfile(ctx, skylink, lup.SiaPath)
 }
 
 // RestoreSkyfile restores a skyfile from disk such that the skylink is
@@ -1041,7 +1357,66 @@ func (r *Renter) RestoreSkyfile(reader io.Reader) (skymodules.Skylink, error) {
 	if err != nil {
 		return skymodules.Skylink{}, errors.AddContext(err, "unable to load skylink")
 	}
+	return r.managedRestoreSkyfile(skylink, baseSector, reader, false)
+}
+
+// ImportSkyfile uploads a skyfile which was fetched from another portal such
+// that the skylink is preserved. The base sector is verified against the
+// merkle root of the skylink and the uploaded fanout against the base sector
+// before the skylink is added to the siafiles.
+func (r *Renter) ImportSkyfile(skylink skymodules.Skylink, baseSector []byte, reader io.Reader) (skymodules.Skylink, error) {
+	if err := r.tg.Add(); err != nil {
+		return skymodules.Skylink{}, err
+	}
+	defer r.tg.Done()
 
+	// Only skylinks for the whole base sector can be imported.
+	if !skylink.IsSkylinkV1() {
+		return skymodules.Skylink{}, ErrInvalidSkylinkVersion
+	}
+	offset, _, err := skylink.OffsetAndFetchSize()
+	if err != nil {
+		return skymodules.Skylink{}, errors.AddContext(err, "unable to parse skylink")
+	}
+	if offset != 0 {
+		return skymodules.Skylink{}, errors.New("skylink doesn't point to the start of a base sector")
+	}
+	if uint64(len(baseSector)) > modules.SectorSize {
+		return skymodules.Skylink{}, errors.AddContext(ErrSkyfileImportVerificationFailed, "base sector is too large")
+	}
+
+	// Pad the base sector and check its merkle root.
+	sector := make([]byte, modules.SectorSize)
+	copy(sector, baseSector)
+	if crypto.MerkleRoot(sector) != skylink.MerkleRoot() {
+		return skymodules.Skylink{}, errors.AddContext(ErrSkyfileImportVerificationFailed, "merkle root of base sector doesn't match")
+	}
+
+	// The skylink is explicitly pinned again, so a pending unpin request
+	// shouldn't delete the imported siafiles.
+	r.staticSkylinkManager.managedRemoveUnpinRequest(skylink)
+	skylink, err = r.managedRestoreSkyfile(skylink, sector, reader, true)
+	if err != nil {
+		return skymodules.Skylink{}, err
+	}
+
+	// Scan the imported content before returning the skylink.
+	siaPath, err := skymodules.SkynetFolder.Join(skylink.String())
+	if err != nil {
+		return skymodules.Skylink{}, errors.AddContext(err, "unable to create siapath")
+	}
+	err = r.managedScanUploadedSkyfile(r.tg.StopCtx(), skylink, siaPath)
+	if err != nil {
+		return skymodules.Skylink{}, err
+	}
+	return skylink, nil
+}
+
+// managedRestoreSkyfile uploads the base sector and fanout of a skyfile such
+// that the provided skylink is preserved. If verifyFanout is set, the fanout
+// of the uploaded data is compared to the fanout in the base sector and the
+// upload is deleted again if they don't match.
+func (r *Renter) managedRestoreSkyfile(skylink skymodules.Skylink, baseSector []byte, reader io.Reader, verifyFanout bool) (skymodules.Skylink, error) {
 	// Check if the new skylink is blocked
 	blocked, err := r.managedIsBlocked(r.tg.StopCtx(), skylink)
 	if err != nil {
@@ -1051,6 +1426,15 @@ func (r *Renter) RestoreSkyfile(reader io.Reader) (skymodules.Skylink, error) {
 		return skymodules.Skylink{}, ErrSkylinkBlocked
 	}
 
+	// Check if the new skylink was deleted
+	deleted, err := r.managedIsDeleted(r.tg.StopCtx(), skylink)
+	if err != nil {
+		return skymodules.Skylink{}, err
+	}
+	if deleted {
+		return skymodules.Skylink{}, skymodules.ErrSkylinkDeleted
+	}
+
 	// Check if the base sector is encrypted, and attempt to decrypt it.
 	// This will fail if we don't have the decryption key.
 	var fileSpecificSkykey skykey.Skykey
@@ -1063,13 +1447,13 @@ func (r *Renter) RestoreSkyfile(reader io.Reader) (skymodules.Skylink, error) {
 	}
 
 	// Parse the baseSector.
-	sl, _, sm, _, _, err := skymodules.ParseSkyfileMetadata(baseSector)
+	sl, fanoutBytes, sm, _, _, err := skymodules.ParseSkyfileMetadata(baseSector)
 	if err != nil {
 		return skymodules.Skylink{}, errors.AddContext(err, "error parsing the baseSector")
 	}
 
 	// Create the upload parameters
-	siaPath, err := skymodules.SkynetFolder.Join(skylinkStr)
+	siaPath, err := skymodules.SkynetFolder.Join(skylink.String())
 	if err != nil {
 		return skymodules.Skylink{}, errors.AddContext(err, "unable to create siapath")
 	}
@@ -1085,8 +1469,10 @@ func (r *Renter) RestoreSkyfile(reader io.Reader) (skymodules.Skylink, error) {
 		DefaultPath:        sm.DefaultPath,
 		DisableDefaultPath: sm.DisableDefaultPath,
 
-		TryFiles:   sm.TryFiles,
-		ErrorPages: sm.ErrorPages,
+		TryFiles:      sm.TryFiles,
+		ErrorPages:    sm.ErrorPages,
+		ExtraMetadata: sm.ExtraMetadata,
+		Headers:       sm.Headers,
 	}
 	skyfileEstablishDefaults(&sup)
 
@@ -1184,6 +1570,24 @@ func (r *Renter) RestoreSkyfile(reader io.Reader) (skymodules.Skylink, error) {
 		return skymodules.Skylink{}, err
 	}
 
+	// Check that the uploaded data matches the fanout of the base sector.
+	if verifyFanout {
+		onlyOnePieceNeeded := sl.FanoutDataPieces == 1 && sl.CipherType == crypto.TypePlain
+		fanout, err := skyfileEncodeFanoutFromFileNode(fileNode, onlyOnePieceNeeded)
+		if err == nil && !bytes.Equal(fanout, fanoutBytes) {
+			err = ErrSkyfileImportVerificationFailed
+		}
+		if err != nil {
+			err = errors.AddContext(err, "unable to verify the uploaded fanout")
+			deleteErr := errors.Compose(r.DeleteFile(sup.SiaPath), r.DeleteFile(extendedPath))
+			// Don't bother returning an error if the file doesn't exist
+			if !errors.Contains(deleteErr, filesystem.ErrNotExist) {
+				err = errors.Compose(err, deleteErr)
+			}
+			return skymodules.Skylink{}, err
+		}
+	}
+
 	// Add the skylink to the siafiles.
 	err = fileNode.AddSkylink(skylink)
 	if err != nil {
@@ -1207,6 +1611,28 @@ func (r *Renter) UploadSkyfile(ctx context.Context, sup skymodules.SkyfileUpload
 	// Set reasonable default values for any sup fields that are blank.
 	skyfileEstablishDefaults(&sup)
 
+	// Upload sessions need to recreate the same skylink when resuming which
+	// isn't possible with a random file-specific key.
+	if sup.SessionID != "" && encryptionEnabled(&sup) {
+		return skymodules.Skylink{}, skymodules.ErrUploadSessionEncrypted
+	}
+	if len(sup.SessionID) > skymodules.MaxUploadSessionIDLength {
+		return skymodules.Skylink{}, skymodules.ErrUploadSessionIDTooLong
+	}
+
+	// If the upload is restricted to a set of hosts, make sure that the set
+	// can hold all the pieces of the base sector before uploading anything.
+	// The fanout is checked once its redundancy is known.
+	if len(sup.AllowedHosts) > 0 {
+		fup, err := baseSectorUploadParamsFromSUP(sup)
+		if err != nil {
+			return skymodules.Skylink{}, errors.AddContext(err, "failed to create siafile upload parameters")
+		}
+		if err := r.managedCheckAllowedHosts(sup.AllowedHosts, fup.ErasureCode); err != nil {
+			return skymodules.Skylink{}, errors.AddContext(err, "unable to upload skyfile")
+		}
+	}
+
 	// If a skykey name or ID was specified, generate a file-specific key for
 	// this upload.
 	err = r.managedGenerateFilekey(&sup, nil)
@@ -1215,9 +1641,11 @@ func (r *Renter) UploadSkyfile(ctx context.Context, sup skymodules.SkyfileUpload
 	}
 
 	// defer a function that cleans up the siafiles after a failed upload
-	// attempt or after a dry run
+	// attempt or after a dry run. The siafiles of an unfinished upload
+	// session are kept to resume the upload later.
 	defer func() {
-		if err != nil || sup.DryRun {
+		unfinishedSession := sup.SessionID != "" && !sup.DryRun && r.staticUploadSessions.managedExists(sup.SessionID)
+		if (err != nil && !unfinishedSession) || sup.DryRun {
 			if err := r.DeleteFile(sup.SiaPath); err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
 				r.staticLog.Printf("error deleting siafile after upload error: %v", err)
 			}
@@ -1243,6 +1671,26 @@ func (r *Renter) UploadSkyfile(ctx context.Context, sup skymodules.SkyfileUpload
 		span.Finish()
 	}()
 
+	// Spool the content of the skyfile for the upload scanner if enabled.
+	var spool *uploadScanSpool
+	if r.staticUploadScanner.staticEnabled() && !sup.DryRun {
+		spool, err = newUploadScanSpool(reader)
+		if err != nil {
+			return skymodules.Skylink{}, errors.AddContext(err, "unable to prepare upload scan")
+		}
+		defer func() {
+			if closeErr := spool.Close(); closeErr != nil {
+				r.staticLog.Printf("error closing upload scan spool: %v", closeErr)
+			}
+		}()
+		reader = spool
+	}
+
+	// Compute the additional content hashes while uploading if requested.
+	if sup.ContentHashes {
+		reader = newSkyfileHashReader(reader)
+	}
+
 	// Upload the skyfile
 	skylink, err = r.managedUploadSkyfile(ctx, sup, reader)
 	if err != nil {
@@ -1252,6 +1700,15 @@ func (r *Renter) UploadSkyfile(ctx context.Context, sup skymodules.SkyfileUpload
 		return skymodules.Skylink{}, errors.New("SkyfileUploadFail")
 	}
 
+	// Scan the content of the skyfile before returning the skylink. Rejected
+	// skyfiles are deleted by the above defer func.
+	if spool != nil {
+		err = r.managedScanSkyfile(ctx, skylink, spool)
+		if err != nil {
+			return skymodules.Skylink{}, err
+		}
+	}
+
 	// Check if skylink is blocked
 	blocked, err := r.managedIsBlocked(ctx, skylink)
 	if err != nil {
@@ -1263,6 +1720,15 @@ func (r *Renter) UploadSkyfile(ctx context.Context, sup skymodules.SkyfileUpload
 		return skymodules.Skylink{}, ErrSkylinkBlocked
 	}
 
+	// Check if skylink was deleted
+	deleted, err := r.managedIsDeleted(ctx, skylink)
+	if err != nil {
+		return skymodules.Skylink{}, err
+	}
+	if deleted && !sup.DryRun {
+		return skymodules.Skylink{}, skymodules.ErrSkylinkDeleted
+	}
+
 	return skylink, nil
 }
 
@@ -1381,8 +1847,12 @@ func (r *Renter) managedResolveSkylinkV2(ctx context.Context, sl skymodules.Skyl
 // the skylink is not a V2 skylink, the input link is returned. If the V2
 // skylink is a nested V2 skylink, it will continue to try and resolve down to a
 // V1 skylink until MaxSkylinkV2ResolvingDepth is met. If the skylink is nested
-// more times than MaxSkylinkV2ResolvingDepth then an error is returned.
+// more times than MaxSkylinkV2ResolvingDepth then an error is returned. If
+// blocklistCheck is set, the resolved skylink is also checked against the
+// blocklist, the deleted skylinks and the signed URL restrictions.
 func (r *Renter) managedTryResolveSkylinkV2(ctx context.Context, link skymodules.Skylink, blocklistCheck bool) (_ skymodules.Skylink, srvs []skymodules.RegistryEntry, err error) {
+	requested := link
+
 	// Check if link needs to be resolved from V2 to V1.
 	for i := 0; i < int(MaxSkylinkV2ResolvingDepth) && link.IsSkylinkV2(); i++ {
 		var srv *skymodules.RegistryEntry
@@ -1400,7 +1870,8 @@ func (r *Renter) managedTryResolveSkylinkV2(ctx context.Context, link skymodules
 		return skymodules.Skylink{}, nil, ErrSkylinkNesting
 	}
 
-	// If we made it to a V1 link check if it is blocked.
+	// If we made it to a V1 link check if it is blocked, deleted or
+	// restricted.
 	if blocklistCheck {
 		blocked, err := r.managedIsBlocked(ctx, link)
 		if err != nil {
@@ -1409,28 +1880,148 @@ func (r *Renter) managedTryResolveSkylinkV2(ctx context.Context, link skymodules
 		if blocked {
 			return skymodules.Skylink{}, nil, ErrSkylinkBlocked
 		}
+		deleted, err := r.managedIsDeleted(ctx, link)
+		if err != nil {
+			return skymodules.Skylink{}, nil, err
+		}
+		if deleted {
+			return skymodules.Skylink{}, nil, skymodules.ErrSkylinkDeleted
+		}
+		err = r.managedVerifySkylinkAccessFromContext(ctx, requested, link)
+		if err != nil {
+			return skymodules.Skylink{}, nil, err
+		}
 	}
 	return link, srvs, nil
 }
 
 // managedSkylinkHealth returns the health of a skylink on the network.
 func (r *Renter) managedSkylinkHealth(ctx context.Context, sl skymodules.Skylink, ppms types.Currency) (skymodules.SkylinkHealth, error) {
+	// Fetch the layout and the fanout of the skyfile.
+	sl, layout, fanoutChunks, ws, err := r.managedSkyfileFanoutChunks(ctx, sl, ppms)
+	if err != nil {
+		return skymodules.SkylinkHealth{}, err
+	}
+	numPieces := int(layout.FanoutDataPieces + layout.FanoutParityPieces)
+
+	// Prepare the list of roots to ask the hosts for. If the base sector was
+	// served from a cache, no workers looked it up. In that case it is looked
+	// up together with the fanout and stored as the last root.
+	var roots []crypto.Hash
+	rootIndexToChunkIndex := make(map[int]int)
+	for chunkIndex, chunk := range fanoutChunks {
+		for _, root := range chunk {
+			rootIndexToChunkIndex[len(roots)] = chunkIndex
+			roots = append(roots, root)
+		}
+	}
+	numChunks := len(fanoutChunks)
+	if ws == nil {
+		roots = append(roots, sl.MerkleRoot())
+	}
+
+	// Ask the hosts for the roots.
+	rootHosts, err := r.managedHasSectorHosts(ctx, roots, numPieces)
+	if err != nil {
+		return skymodules.SkylinkHealth{}, err
+	}
+	var baseSectorRedundancy uint64
+	if ws == nil {
+		baseSectorRedundancy = uint64(len(rootHosts[len(rootHosts)-1]))
+		roots, rootHosts = roots[:len(roots)-1], rootHosts[:len(rootHosts)-1]
+	}
+	rootTotals := make([]uint64, len(roots))
+	for i, hosts := range rootHosts {
+		rootTotals[i] = uint64(len(hosts))
+	}
+
+	// Wait for the worker state results for the base sector.
+	if ws != nil {
+		resps := ws.WaitForResults(ctx)
+		for _, resp := range resps {
+			if resp.err != nil {
+				continue
+			}
+			// Check > 0 because base sector only has 1 piece.
+			if len(resp.pieceIndices) > 0 {
+				baseSectorRedundancy++
+			}
+		}
+	}
+
+	// Create a slice of good pieces for each chunk. A chunk has a good
+	// piece if a root belonging to the chunk exists >0 times on the
+	// network.
+	chunkGoodPieces := make([]int, numChunks)
+	onlyOnePiecePerChunk := layout.FanoutDataPieces == 1 && layout.CipherType == crypto.TypePlain
+	for i := 0; i < len(rootTotals); i++ {
+		chunkIndex := rootIndexToChunkIndex[i]
+		if onlyOnePiecePerChunk {
+			// Special Case: If we only need one piece per chunk, we
+			// count all occurrences of that piece up until
+			// numPieces.
+			chunkGoodPieces[chunkIndex] += int(rootTotals[i])
+			if chunkGoodPieces[chunkIndex] > numPieces {
+				chunkGoodPieces[chunkIndex] = numPieces
+			}
+		} else if rootTotals[i] > 0 {
+			// Otherwise every piece only counts as 1 good piece.
+			chunkGoodPieces[chunkIndex]++
+		}
+	}
+
+	// Set the base sector redundancy.
+	health := skymodules.SkylinkHealth{
+		BaseSectorRedundancy: baseSectorRedundancy,
+	}
+
+	// If the fanout datapieces are 0, there is no fanout and we are done.
+	if layout.FanoutDataPieces == 0 {
+		return health, nil
+	}
+
+	// Compute the health of all chunks and remember the worst one. That's
+	// the overall fanout health.
+	worstHealth := float64(numPieces / int(layout.FanoutDataPieces))
+	fanoutHealth := make([]float64, 0, numChunks)
+	for _, goodPieces := range chunkGoodPieces {
+		chunkHealth := float64(goodPieces) / float64(layout.FanoutDataPieces)
+		if chunkHealth < worstHealth {
+			worstHealth = chunkHealth
+		}
+		fanoutHealth = append(fanoutHealth, chunkHealth)
+	}
+	return skymodules.SkylinkHealth{
+		BaseSectorRedundancy:      baseSectorRedundancy,
+		FanoutEffectiveRedundancy: worstHealth,
+		FanoutRedundancy:          fanoutHealth,
+		FanoutDataPieces:          layout.FanoutDataPieces,
+		FanoutParityPieces:        layout.FanoutParityPieces,
+	}, nil
+}
+
+// managedSkyfileFanoutChunks resolves the skylink, downloads its base sector
+// and returns the resolved skylink, the layout and the decoded fanout of the
+// skyfile. The base sector is served from the caches if possible. The returned
+// worker state contains the results of looking up the base sector and is nil
+// if it was served from a cache.
+func (r *Renter) managedSkyfileFanoutChunks(ctx context.Context, sl skymodules.Skylink, ppms types.Currency) (skymodules.Skylink, skymodules.SkyfileLayout, [][]crypto.Hash, *pcwsWorkerState, error) {
 	// Resolve the skylink if necessary.
 	sl, _, err := r.managedTryResolveSkylinkV2(ctx, sl, true)
 	if err != nil {
-		return skymodules.SkylinkHealth{}, errors.AddContext(err, "failed to resolve skylink")
+		return skymodules.Skylink{}, skymodules.SkyfileLayout{}, nil, nil, errors.AddContext(err, "failed to resolve skylink")
 	}
 
 	// Get the offset and fetchsize from the skylink
 	offset, fetchSize, err := sl.OffsetAndFetchSize()
 	if err != nil {
-		return skymodules.SkylinkHealth{}, errors.AddContext(err, "unable to parse offset and fetchsize from skylink")
+		return skymodules.Skylink{}, skymodules.SkyfileLayout{}, nil, nil, errors.AddContext(err, "unable to parse offset and fetchsize from skylink")
 	}
 
 	// Get base sector.
-	baseSector, ws, err := r.managedDownloadByRoot(ctx, sl.MerkleRoot(), offset, fetchSize, ppms)
+	baseSector, ws, err := r.managedDownloadByRootCachedWithState(ctx, sl.MerkleRoot(), offset, fetchSize, ppms)
 	if err != nil {
-		return skymodules.SkylinkHealth{}, errors.AddContext(err, "unable to download base sector")
+		return skymodules.Skylink{}, skymodules.SkyfileLayout{}, nil, nil, errors.AddContext(err, "unable to download base sector")
 	}
 
 	// Check if the base sector is encrypted, and attempt to decrypt it.
@@ -1438,41 +2029,28 @@ func (r *Renter) managedSkylinkHealth(ctx context.Context, sl skymodules.Skylink
 	if encrypted {
 		_, err = r.managedDecryptBaseSector(baseSector)
 		if err != nil {
-			return skymodules.SkylinkHealth{}, errors.AddContext(err, "failed to decrypt base sector")
+			return skymodules.Skylink{}, skymodules.SkyfileLayout{}, nil, nil, errors.AddContext(err, "failed to decrypt base sector")
 		}
 	}
 
 	// Parse out the metadata of the skyfile.
 	layout, fanoutBytes, _, _, _, err := skymodules.ParseSkyfileMetadata(baseSector)
 	if err != nil {
-		return skymodules.SkylinkHealth{}, errors.AddContext(err, "error parsing skyfile metadata")
+		return skymodules.Skylink{}, skymodules.SkyfileLayout{}, nil, nil, errors.AddContext(err, "error parsing skyfile metadata")
 	}
-	numPieces := int(layout.FanoutDataPieces + layout.FanoutParityPieces)
 
-	// Prepare the list of roots to ask the hosts for.
-	var roots []crypto.Hash
-
-	// If the file has a fanout, ask the hosts for the fanout as well.
-	rootIndexToChunkIndex := make(map[int]int)
-	numChunks := 0
-	if len(fanoutBytes) > 0 {
-		// Create the list of chunks from the fanout. Since we want to
-		// give an overview of the health of the file on the network, we
-		// don't compress the fanout.
-		fanoutChunks, err := layout.DecodeFanoutIntoChunks(fanoutBytes)
-		if err != nil {
-			return skymodules.SkylinkHealth{}, errors.AddContext(err, "error parsing skyfile fanout")
-		}
-
-		for chunkIndex, chunk := range fanoutChunks {
-			for _, root := range chunk {
-				rootIndexToChunkIndex[len(roots)] = chunkIndex
-				roots = append(roots, root)
-			}
-		}
-		numChunks = len(fanoutChunks)
+	// Create the list of chunks from the fanout. Since we want to give an
+	// overview of the file on the network, we don't compress the fanout.
+	fanoutChunks, err := layout.DecodeFanoutIntoChunks(fanoutBytes)
+	if err != nil {
+		return skymodules.Skylink{}, skymodules.SkyfileLayout{}, nil, nil, errors.AddContext(err, "error parsing skyfile fanout")
 	}
+	return sl, layout, fanoutChunks, ws, nil
+}
 
+// managedHasSectorHosts asks all workers whether their hosts store the given
+// roots. It returns the hosts which store each of the roots.
+func (r *Renter) managedHasSectorHosts(ctx context.Context, roots []crypto.Hash, numPieces int) ([][]types.SiaPublicKey, error) {
 	// Get the workers.
 	workers := r.staticWorkerPool.callWorkers()
 
@@ -1510,15 +2088,16 @@ func (r *Renter) managedSkylinkHealth(ctx context.Context, sl skymodules.Skylink
 
 		// If a batch has 0 launched workers we are done.
 		if launchedWorkers == 0 {
-			return skymodules.SkylinkHealth{}, errors.New("no workers were launched successfully")
+			return nil, errors.New("no workers were launched successfully")
 		}
 	}
 
-	// Each batch has its own waiting goroutine.
+	// Each batch has its own waiting goroutine. The batches cover distinct
+	// ranges of the roots, so they can write to the result without locking.
 	// TODO: Once Sia has upgraded to support larger MDM programs we don't
 	// need the batching here anymore.
 	var wg sync.WaitGroup
-	rootTotals := make([]uint64, len(roots))
+	rootHosts := make([][]types.SiaPublicKey, len(roots))
 	for batchIndex, responseChan := range responseChans {
 		wg.Add(1)
 		go func(batchIndex uint64, responseChan chan *jobHasSectorResponse) {
@@ -1535,78 +2114,16 @@ func (r *Renter) managedSkylinkHealth(ctx context.Context, sl skymodules.Skylink
 				if resp.staticErr != nil {
 					continue
 				}
-				// Add the result to the totals.
+				// Add the host to the result.
 				for i, available := range resp.staticAvailables {
 					if available {
-						batchOffset := uint64(maxHasSectorBatchSize) * batchIndex
-						rootTotals[batchOffset+uint64(i)]++
+						rootIndex := uint64(maxHasSectorBatchSize)*batchIndex + uint64(i)
+						rootHosts[rootIndex] = append(rootHosts[rootIndex], resp.staticWorker.staticHostPubKey)
 					}
 				}
 			}
 		}(uint64(batchIndex), responseChan)
 	}
 	wg.Wait()
-
-	// Wait for the worker state results for the base sector.
-	resps := ws.WaitForResults(ctx)
-	var baseSectorRedundancy uint64
-	for _, resp := range resps {
-		if resp.err != nil {
-			continue
-		}
-		// Check > 0 because base sector only has 1 piece.
-		if len(resp.pieceIndices) > 0 {
-			baseSectorRedundancy++
-		}
-	}
-
-	// Create a slice of good pieces for each chunk. A chunk has a good
-	// piece if a root belonging to the chunk exists >0 times on the
-	// network.
-	chunkGoodPieces := make([]int, numChunks)
-	onlyOnePiecePerChunk := layout.FanoutDataPieces == 1 && layout.CipherType == crypto.TypePlain
-	for i := 0; i < len(rootTotals); i++ {
-		chunkIndex := rootIndexToChunkIndex[i]
-		if onlyOnePiecePerChunk {
-			// Special Case: If we only need one piece per chunk, we
-			// count all occurrences of that piece up until
-			// numPieces.
-			chunkGoodPieces[chunkIndex] += int(rootTotals[i])
-			if chunkGoodPieces[chunkIndex] > numPieces {
-				chunkGoodPieces[chunkIndex] = numPieces
-			}
-		} else if rootTotals[i] > 0 {
-			// Otherwise every piece only counts as 1 good piece.
-			chunkGoodPieces[chunkIndex]++
-		}
-	}
-
-	// Set the base sector redundancy.
-	health := skymodules.SkylinkHealth{
-		BaseSectorRedundancy: baseSectorRedundancy,
-	}
-
-	// If the fanout datapieces are 0, there is no fanout and we are done.
-	if layout.FanoutDataPieces == 0 {
-		return health, nil
-	}
-
-	// Compute the health of all chunks and remember the worst one. That's
-	// the overall fanout health.
-	worstHealth := float64(numPieces / int(layout.FanoutDataPieces))
-	fanoutHealth := make([]float64, 0, numChunks)
-	for _, goodPieces := range chunkGoodPieces {
-		chunkHealth := float64(goodPieces) / float64(layout.FanoutDataPieces)
-		if chunkHealth < worstHealth {
-			worstHealth = chunkHealth
-		}
-		fanoutHealth = append(fanoutHealth, chunkHealth)
-	}
-	return skymodules.SkylinkHealth{
-		BaseSectorRedundancy:      baseSectorRedundancy,
-		FanoutEffectiveRedundancy: worstHealth,
-		FanoutRedundancy:          fanoutHealth,
-		FanoutDataPieces:          layout.FanoutDataPieces,
-		FanoutParityPieces:        layout.FanoutParityPieces,
-	}, nil
+	return rootHosts, nil
 }
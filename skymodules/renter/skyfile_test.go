@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
 	"gitlab.com/SkynetLabs/skyd/build"
 	"gitlab.com/SkynetLabs/skyd/skymodules"
@@ -196,8 +198,98 @@ func TestShortFanoutPanic(t *testing.T) {
 	}
 
 	// Download the file. This should fail due to the short fanout.
-	_, _, err = r.DownloadSkylink(skylink, time.Hour, types.SiacoinPrecision.MulFloat(1e-7))
+	_, _, err = r.DownloadSkylink(skylink, time.Hour, types.SiacoinPrecision.MulFloat(1e-7), skymodules.OverdriveSettings{}, nil)
 	if err == nil || !strings.Contains(err.Error(), skymodules.ErrMalformedBaseSector.Error()) {
 		t.Fatal(err)
 	}
 }
+
+// TestNoFanoutStreamer is a unit test for the noFanoutStreamer.
+func TestNoFanoutStreamer(t *testing.T) {
+	t.Parallel()
+
+	nfs := &noFanoutStreamer{size: 100}
+
+	// Seeking to the end returns the size.
+	size, err := nfs.Seek(0, io.SeekEnd)
+	if err != nil || size != 100 {
+		t.Fatal("unexpected size", size, err)
+	}
+	// Reading at the end returns io.EOF.
+	if _, err := nfs.Read(make([]byte, 1)); err != io.EOF {
+		t.Fatal("expected EOF", err)
+	}
+	// Reading before the end fails.
+	off, err := nfs.Seek(-10, io.SeekCurrent)
+	if err != nil || off != 90 {
+		t.Fatal("unexpected offset", off, err)
+	}
+	if _, err := nfs.Read(make([]byte, 1)); !errors.Contains(err, ErrFanoutNotFetched) {
+		t.Fatal("expected ErrFanoutNotFetched", err)
+	}
+	// Seeking to a negative offset fails.
+	if _, err := nfs.Seek(-1, io.SeekStart); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// TestImportSkyfileVerification checks that ImportSkyfile rejects base sectors
+// which don't match the skylink before uploading anything.
+func TestImportSkyfileVerification(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// Create a base sector and a skylink for it. The merkle root is computed
+	// over the padded sector.
+	baseSector := fastrand.Bytes(100)
+	sector := make([]byte, modules.SectorSize)
+	copy(sector, baseSector)
+	skylink, err := skymodules.NewSkylinkV1(crypto.MerkleRoot(sector), 0, uint64(len(baseSector)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A base sector with different data is rejected.
+	corrupted := append([]byte{}, baseSector...)
+	corrupted[0]++
+	_, err = r.ImportSkyfile(skylink, corrupted, nil)
+	if !errors.Contains(err, ErrSkyfileImportVerificationFailed) {
+		t.Fatal("wrong error", err)
+	}
+
+	// A base sector that is too large is rejected.
+	_, err = r.ImportSkyfile(skylink, fastrand.Bytes(int(modules.SectorSize)+1), nil)
+	if !errors.Contains(err, ErrSkyfileImportVerificationFailed) {
+		t.Fatal("wrong error", err)
+	}
+
+	// A skylink with an offset is rejected.
+	skylinkOffset, err := skymodules.NewSkylinkV1(skylink.MerkleRoot(), 4096, 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.ImportSkyfile(skylinkOffset, baseSector, nil)
+	if err == nil || !strings.Contains(err.Error(), "doesn't point to the start of a base sector") {
+		t.Fatal("wrong error", err)
+	}
+
+	// The valid base sector passes verification but can't be parsed as a
+	// skyfile.
+	_, err = r.ImportSkyfile(skylink, baseSector, nil)
+	if err == nil || errors.Contains(err, ErrSkyfileImportVerificationFailed) {
+		t.Fatal("wrong error", err)
+	}
+}
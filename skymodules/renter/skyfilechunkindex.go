@@ -0,0 +1,584 @@
+package renter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"gitlab.com/SkynetLabs/skyd/skymodules/renter/filesystem"
+	"gitlab.com/SkynetLabs/skyd/skymodules/renter/filesystem/siafile"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+// The skyfile chunk index allows for deduplicating the fanout chunks of
+// skyfiles. Chunks are indexed by the erasure code settings and the merkle
+// roots of their pieces. Before uploading a chunk, the index is checked for a
+// chunk with the same piece roots. If one is found, its pieces are added to
+// the new siafile instead of uploading the chunk again. Since identical roots
+// imply identical sectors on the hosts, this is safe for encrypted chunks as
+// well. Unencrypted chunks are deterministic and therefore shared across
+// skyfiles while encrypted chunks only match if they were encrypted with the
+// same key.
+//
+// Every entry of the index is reference counted. A reference is added for
+// every siafile chunk which uses the entry's pieces and removed again when the
+// siafile is deleted. Entries without references are garbage collected. The
+// references are tracked by the siafile's UID since siafiles can be renamed
+// and their chunks might not have all of their pieces when they are deleted.
+// Every path which deletes siafiles releases their references. On startup the
+// references of siafiles which don't exist anymore are released as well, e.g.
+// those of files which were deleted during an unclean shutdown.
+//
+// Indexed pieces are only reused if they are stored on hosts which are
+// currently online and good for renew and if they cover all the pieces of
+// the chunk. Otherwise the chunk is uploaded again and the new pieces replace
+// the indexed ones.
+//
+// NOTE: the index doesn't update the reference counters of the contracts. The
+// contractor's refcounter is only enabled in testing builds and doesn't know
+// about sectors which are shared between siafiles. Shared sectors are
+// therefore only protected by the index itself and must not be removed from
+// the hosts as long as the index references them.
+
+const (
+	// skyfileChunkIndexFilename is the name of the file which persists the
+	// skyfile chunk index.
+	skyfileChunkIndexFilename = "skyfilechunkindex.dat"
+)
+
+var (
+	// skyfileChunkIndexMDHeader is the header of the metadata for the persist
+	// file.
+	skyfileChunkIndexMDHeader = types.NewSpecifier("SkyfileChunkIdx")
+)
+
+type (
+	// skyfileChunkIndex is a persisted, reference counted index of the
+	// uploaded fanout chunks of skyfiles.
+	skyfileChunkIndex struct {
+		chunks map[crypto.Hash]*skyfileChunkIndexChunk
+		files  map[siafile.SiafileUID][]crypto.Hash
+
+		staticAop *persist.AppendOnlyPersist
+		mu        sync.Mutex
+	}
+
+	// skyfileChunkIndexChunk is an indexed chunk.
+	skyfileChunkIndexChunk struct {
+		refs   int
+		pieces []skyfileChunkIndexPiece
+	}
+
+	// skyfileChunkIndexEntry is the definition of a persisted entry. An entry
+	// either adds a reference from a siafile to a chunk or releases all the
+	// references of a siafile. The pieces are only persisted when a chunk was
+	// uploaded. They replace the pieces of an existing chunk with the same key.
+	skyfileChunkIndexEntry struct {
+		File    siafile.SiafileUID       `json:"file"`
+		Key     crypto.Hash              `json:"key"`
+		Pieces  []skyfileChunkIndexPiece `json:"pieces,omitempty"`
+		Release bool                     `json:"release,omitempty"`
+	}
+
+	// skyfileChunkIndexPiece is a piece of an indexed chunk.
+	skyfileChunkIndexPiece struct {
+		PieceIndex uint64             `json:"pieceindex"`
+		HostKey    types.SiaPublicKey `json:"hostkey"`
+		MerkleRoot crypto.Hash        `json:"merkleroot"`
+	}
+)
+
+// skyfileChunkKey returns the key of a chunk within the skyfile chunk index
+// given the chunk's erasure code and the merkle roots of its pieces.
+func skyfileChunkKey(ec skymodules.ErasureCoder, roots []crypto.Hash) crypto.Hash {
+	return crypto.HashAll(ec.Identifier(), roots)
+}
+
+// newSkyfileChunkIndex creates a new skyfile chunk index or loads an existing
+// one from disk.
+func newSkyfileChunkIndex(dir, filename string) (*skyfileChunkIndex, error) {
+	aop, r, err := persist.NewAppendOnlyPersist(dir, filename, skyfileChunkIndexMDHeader, persist.MetadataVersionv156)
+	if err != nil {
+		return nil, err
+	}
+	ci := &skyfileChunkIndex{
+		chunks:    make(map[crypto.Hash]*skyfileChunkIndexChunk),
+		files:     make(map[siafile.SiafileUID][]crypto.Hash),
+		staticAop: aop,
+	}
+	decoder := json.NewDecoder(r)
+	for {
+		var entry skyfileChunkIndexEntry
+		err := decoder.Decode(&entry)
+		if errors.Contains(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, errors.Compose(err, aop.Close())
+		}
+		ci.applyEntry(entry)
+	}
+	return ci, nil
+}
+
+// applyEntry applies a persisted entry to the in-memory index.
+func (ci *skyfileChunkIndex) applyEntry(entry skyfileChunkIndexEntry) {
+	// Release all the references of the file.
+	if entry.Release {
+		for _, key := range ci.files[entry.File] {
+			chunk, exists := ci.chunks[key]
+			if !exists {
+				continue
+			}
+			chunk.refs--
+			if chunk.refs <= 0 {
+				delete(ci.chunks, key)
+			}
+		}
+		delete(ci.files, entry.File)
+		return
+	}
+
+	// Add a reference.
+	chunk, exists := ci.chunks[entry.Key]
+	if !exists {
+		if len(entry.Pieces) == 0 {
+			return // chunk was garbage collected
+		}
+		chunk = &skyfileChunkIndexChunk{}
+		ci.chunks[entry.Key] = chunk
+	}
+	if len(entry.Pieces) > 0 {
+		chunk.pieces = entry.Pieces
+	}
+	chunk.refs++
+	ci.files[entry.File] = append(ci.files[entry.File], entry.Key)
+}
+
+// managedPersistEntry persists an entry and applies it to the in-memory index.
+func (ci *skyfileChunkIndex) managedPersistEntry(entry skyfileChunkIndexEntry) error {
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+	return ci.persistEntry(entry)
+}
+
+// persistEntry persists an entry and applies it to the in-memory index.
+func (ci *skyfileChunkIndex) persistEntry(entry skyfileChunkIndexEntry) error {
+	entryBytes, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = ci.staticAop.Write(entryBytes)
+	if err != nil {
+		return err
+	}
+	ci.applyEntry(entry)
+	return nil
+}
+
+// Close closes the underlying persistence.
+func (ci *skyfileChunkIndex) Close() error {
+	return ci.staticAop.Close()
+}
+
+// managedAcquire adds a reference from the given file to the chunk with the
+// given key and returns the chunk's usable pieces. The usable pieces are
+// determined by the provided function. If the chunk is not indexed or none of
+// its pieces are usable, no reference is added and false is returned. A nil
+// function considers all the pieces usable.
+func (ci *skyfileChunkIndex) managedAcquire(uid siafile.SiafileUID, key crypto.Hash, usable func([]skyfileChunkIndexPiece) []skyfileChunkIndexPiece) ([]skyfileChunkIndexPiece, bool, error) {
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+	chunk, exists := ci.chunks[key]
+	if !exists {
+		return nil, false, nil
+	}
+	pieces := append([]skyfileChunkIndexPiece{}, chunk.pieces...)
+	if usable != nil {
+		pieces = usable(pieces)
+	}
+	if len(pieces) == 0 {
+		return nil, false, nil
+	}
+	err := ci.persistEntry(skyfileChunkIndexEntry{
+		File: uid,
+		Key:  key,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return pieces, true, nil
+}
+
+// managedAdd adds a reference from the given file to the uploaded chunk with
+// the given key. The given pieces replace the pieces of the chunk if it is
+// indexed already.
+func (ci *skyfileChunkIndex) managedAdd(uid siafile.SiafileUID, key crypto.Hash, pieces []skyfileChunkIndexPiece) error {
+	if len(pieces) == 0 {
+		return nil // nothing to share
+	}
+	return ci.managedPersistEntry(skyfileChunkIndexEntry{
+		File:   uid,
+		Key:    key,
+		Pieces: pieces,
+	})
+}
+
+// managedRelease removes all the references of the given file. Chunks are
+// garbage collected once they aren't referenced anymore.
+func (ci *skyfileChunkIndex) managedRelease(uid siafile.SiafileUID) error {
+	ci.mu.Lock()
+	_, exists := ci.files[uid]
+	ci.mu.Unlock()
+	if !exists {
+		return nil
+	}
+	return ci.managedPersistEntry(skyfileChunkIndexEntry{
+		File:    uid,
+		Release: true,
+	})
+}
+
+// managedFiles returns the UIDs of all the files which reference chunks of
+// the index.
+func (ci *skyfileChunkIndex) managedFiles() map[siafile.SiafileUID]struct{} {
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+	uids := make(map[siafile.SiafileUID]struct{}, len(ci.files))
+	for uid := range ci.files {
+		uids[uid] = struct{}{}
+	}
+	return uids
+}
+
+// managedLen returns the number of indexed chunks.
+func (ci *skyfileChunkIndex) managedLen() int {
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+	return len(ci.chunks)
+}
+
+// staticChunkIndexPieces returns the pieces of the given chunk in the format
+// of the skyfile chunk index.
+func staticChunkIndexPieces(fileNode *filesystem.FileNode, chunkIndex uint64) ([]skyfileChunkIndexPiece, error) {
+	pieces, err := fileNode.Pieces(chunkIndex)
+	if err != nil {
+		return nil, err
+	}
+	var indexPieces []skyfileChunkIndexPiece
+	for pieceIndex, pieceSet := range pieces {
+		for _, piece := range pieceSet {
+			indexPieces = append(indexPieces, skyfileChunkIndexPiece{
+				PieceIndex: uint64(pieceIndex),
+				HostKey:    piece.HostPubKey,
+				MerkleRoot: piece.MerkleRoot,
+			})
+		}
+	}
+	return indexPieces, nil
+}
+
+// staticUsableIndexPieces returns the pieces of an indexed chunk which are
+// stored on hosts that are online and good for renew. If those pieces don't
+// cover all the numPieces pieces of the chunk, nil is returned and the chunk
+// needs to be uploaded again.
+func staticUsableIndexPieces(pieces []skyfileChunkIndexPiece, numPieces int, offline, goodForRenew map[string]bool) []skyfileChunkIndexPiece {
+	var usable []skyfileChunkIndexPiece
+	covered := make(map[uint64]struct{})
+	for _, piece := range pieces {
+		hk := piece.HostKey.String()
+		if !goodForRenew[hk] || offline[hk] {
+			continue
+		}
+		usable = append(usable, piece)
+		covered[piece.PieceIndex] = struct{}{}
+	}
+	if len(covered) < numPieces {
+		return nil
+	}
+	return usable
+}
+
+// managedUploadDeduplicatedFromReader uploads the chunks read from the given
+// reader to the given fileNode. Chunks which were already uploaded for another
+// skyfile are not uploaded again as long as their pieces are stored on usable
+// hosts. Instead the pieces of the existing chunk are added to the fileNode.
+//
+// If a session ID is provided, the chunks are recorded in the upload session
+// once they are available. The chunks which were completed in an earlier
+// attempt of the session are only verified and not uploaded again.
+func (r *Renter) managedUploadDeduplicatedFromReader(ctx context.Context, fileNode *filesystem.FileNode, reader skymodules.ChunkReader, sessionID string, completed map[uint64]struct{}) (err error) {
+	ci := r.staticSkyfileChunkIndex
+	us := r.staticUploadSessions
+	ec := fileNode.ErasureCode()
+	chunkSize := fileNode.ChunkSize()
+	offline, goodForRenew, _, _ := r.callRenterContractsAndUtilities()
+	usable := func(pieces []skyfileChunkIndexPiece) []skyfileChunkIndexPiece {
+		return staticUsableIndexPieces(pieces, ec.NumPieces(), offline, goodForRenew)
+	}
+
+	// uploadedChunk is a chunk which was uploaded and needs to be added to
+	// the index once it's available.
+	type uploadedChunk struct {
+		index uint64
+		key   crypto.Hash
+	}
+	var uploaded []uploadedChunk
+
+	// Track the uploaded chunks until they are available. This also happens
+	// on error to record the progress of the upload session.
+	tracker := r.newUploadSessionTracker(sessionID)
+	defer func() {
+		err = errors.Compose(err, tracker.managedWait())
+	}()
+
+	for chunkIndex := uint64(0); reader.Peek(); chunkIndex++ {
+		if chunkIndex > 0 && r.staticDeps.Disrupt("InterruptUploadSession") {
+			return errors.New("upload session interrupted")
+		}
+		pieces, n, err := reader.ReadChunk()
+		if errors.Contains(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return errors.AddContext(err, "failed to read chunk")
+		}
+		roots := make([]crypto.Hash, len(pieces))
+		for pieceIndex, piece := range pieces {
+			roots[pieceIndex] = crypto.MerkleRoot(piece)
+		}
+		key := skyfileChunkKey(ec, roots)
+
+		// Check if the chunk was completed by an earlier attempt of the
+		// upload session.
+		if _, exists := completed[chunkIndex]; exists {
+			if err := staticVerifySessionChunk(fileNode, chunkIndex, roots); err != nil {
+				return errors.AddContext(err, "failed to verify chunk of upload session")
+			}
+			uploaded = append(uploaded, uploadedChunk{index: chunkIndex, key: key})
+			continue
+		}
+
+		// Check if the chunk was uploaded before.
+		indexPieces, exists, err := ci.managedAcquire(fileNode.UID(), key, usable)
+		if err != nil {
+			return errors.AddContext(err, "failed to check skyfile chunk index")
+		}
+		if exists {
+			err = addIndexedChunk(fileNode, chunkIndex, chunkSize, n, indexPieces)
+			if err != nil {
+				return errors.AddContext(err, "failed to add indexed chunk")
+			}
+			if err := us.managedCompleteChunk(sessionID, chunkIndex); err != nil {
+				return errors.AddContext(err, "failed to record chunk in upload session")
+			}
+			continue
+		}
+
+		// Upload the chunk.
+		cr := newPrereadChunkReader(pieces, n)
+		uucs, _, err := r.callUploadStreamFromReaderWithFileNodeNoBlock(ctx, fileNode, cr, int64(chunkIndex*chunkSize))
+		if err != nil {
+			return err
+		}
+		uploaded = append(uploaded, uploadedChunk{index: chunkIndex, key: key})
+
+		// If no chunk was returned, the chunk didn't need any work.
+		if len(uucs) == 0 {
+			if err := us.managedCompleteChunk(sessionID, chunkIndex); err != nil {
+				return errors.AddContext(err, "failed to record chunk in upload session")
+			}
+			continue
+		}
+		tracker.track(uucs...)
+	}
+
+	// Wait for the uploaded chunks to become available and add them to the
+	// index.
+	err = tracker.managedWait()
+	if err != nil {
+		return err
+	}
+	for _, uc := range uploaded {
+		indexPieces, err := staticChunkIndexPieces(fileNode, uc.index)
+		if err != nil {
+			return errors.AddContext(err, "failed to get pieces of uploaded chunk")
+		}
+		if err := ci.managedAdd(fileNode.UID(), uc.key, indexPieces); err != nil {
+			return errors.AddContext(err, "failed to add chunk to skyfile chunk index")
+		}
+	}
+	return nil
+}
+
+// addIndexedChunk adds the pieces of an indexed chunk to the fileNode.
+func addIndexedChunk(fileNode *filesystem.FileNode, chunkIndex, chunkSize, n uint64, pieces []skyfileChunkIndexPiece) error {
+	err := fileNode.SiaFile.GrowNumChunks(chunkIndex + 1)
+	if err != nil {
+		return err
+	}
+	for _, piece := range pieces {
+		err = fileNode.SiaFile.AddPiece(piece.HostKey, chunkIndex, piece.PieceIndex, piece.MerkleRoot)
+		if err != nil {
+			return errors.AddContext(err, "failed to add piece of indexed chunk")
+		}
+	}
+	if n == chunkSize {
+		return nil
+	}
+	if err := fileNode.SetFileSize(fileNode.Size() - chunkSize + n); err != nil {
+		return errors.AddContext(err, "failed to adjust FileSize")
+	}
+	return nil
+}
+
+// managedDeleteFileNode deletes the siafile at the given siaPath, releases
+// the chunks of the file within the skyfile chunk index and removes the file
+// from the skylink health cache.
+func (r *Renter) managedDeleteFileNode(siaPath skymodules.SiaPath, fileNode *filesystem.FileNode) error {
+	file := listedSiafile{
+		siaPath:   siaPath,
+		uid:       fileNode.UID(),
+		localPath: fileNode.LocalPath(),
+	}
+	err := r.staticFileSystem.DeleteFile(siaPath)
+	if err != nil {
+		return err
+	}
+	r.managedReleaseDeletedSiafile(file)
+	return nil
+}
+
+// listedSiafile is a siafile returned by managedListSiafiles.
+type listedSiafile struct {
+	siaPath   skymodules.SiaPath
+	uid       siafile.SiafileUID
+	localPath string
+}
+
+// managedListSiafiles returns all the siafiles within the directory at the
+// given siaPath and its sub directories. Files which are deleted or renamed
+// while listing them are skipped and counted.
+func (r *Renter) managedListSiafiles(siaPath skymodules.SiaPath) (files []listedSiafile, skipped int, err error) {
+	var mu sync.Mutex
+	var siaPaths []skymodules.SiaPath
+	flf := func(fi skymodules.FileInfo) {
+		mu.Lock()
+		siaPaths = append(siaPaths, fi.SiaPath)
+		mu.Unlock()
+	}
+	err = r.staticFileSystem.CachedList(siaPath, true, flf, func(skymodules.DirectoryInfo) {})
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, sp := range siaPaths {
+		fileNode, err := r.staticFileSystem.OpenSiaFile(sp)
+		if errors.Contains(err, filesystem.ErrNotExist) {
+			skipped++
+			continue
+		}
+		if err != nil {
+			return nil, 0, errors.AddContext(err, "failed to open siafile")
+		}
+		files = append(files, listedSiafile{
+			siaPath:   sp,
+			uid:       fileNode.UID(),
+			localPath: fileNode.LocalPath(),
+		})
+		if err := fileNode.Close(); err != nil {
+			return nil, 0, err
+		}
+	}
+	return files, skipped, nil
+}
+
+// managedReleaseDeletedSiafile releases the chunks of a siafile which was
+// deleted within the skyfile chunk index and removes the file from the
+// skylink health cache.
+func (r *Renter) managedReleaseDeletedSiafile(file listedSiafile) {
+	r.staticSkylinkHealthCache.callRemove(file.siaPath)
+	err := r.managedRemoveRetrievedCopy(file.localPath)
+	if err != nil {
+		r.staticLog.Printf("Unable to remove retrieved copy of deleted siafile %v: %v", file.siaPath, err)
+	}
+	err = r.staticSkyfileChunkIndex.managedRelease(file.uid)
+	if err != nil {
+		r.staticLog.Printf("Unable to release chunks of deleted siafile %v: %v", file.siaPath, err)
+	}
+}
+
+// managedPruneSkyfileChunkIndex releases the references of all the files
+// within the skyfile chunk index which don't exist in the filesystem anymore.
+func (r *Renter) managedPruneSkyfileChunkIndex() error {
+	// Grab the files of the index before listing the filesystem. Files which
+	// are added to the index afterwards already exist in the filesystem.
+	uids := r.staticSkyfileChunkIndex.managedFiles()
+	if len(uids) == 0 {
+		return nil // nothing to prune
+	}
+	files, skipped, err := r.managedListSiafiles(skymodules.RootSiaPath())
+	if err != nil {
+		return errors.AddContext(err, "failed to list siafiles")
+	}
+	// A renamed file might have been skipped, so it's not safe to release
+	// any references. They are pruned after the next restart instead.
+	if skipped > 0 {
+		return nil
+	}
+	for _, file := range files {
+		delete(uids, file.uid)
+	}
+	for uid := range uids {
+		if err := r.staticSkyfileChunkIndex.managedRelease(uid); err != nil {
+			return errors.AddContext(err, "failed to release references of deleted siafile")
+		}
+	}
+	return nil
+}
+
+// threadedPruneSkyfileChunkIndex prunes the skyfile chunk index on startup.
+func (r *Renter) threadedPruneSkyfileChunkIndex() {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+	if err := r.managedPruneSkyfileChunkIndex(); err != nil {
+		r.staticLog.Println("Failed to prune skyfile chunk index:", err)
+	}
+}
+
+// prereadChunkReader is a ChunkReader for a single chunk which was already
+// read.
+type prereadChunkReader struct {
+	chunk [][]byte
+	n     uint64
+	read  bool
+}
+
+// newPrereadChunkReader creates a new prereadChunkReader.
+func newPrereadChunkReader(chunk [][]byte, n uint64) *prereadChunkReader {
+	return &prereadChunkReader{
+		chunk: chunk,
+		n:     n,
+	}
+}
+
+// Peek implements the ChunkReader interface.
+func (cr *prereadChunkReader) Peek() bool {
+	return !cr.read
+}
+
+// ReadChunk implements the ChunkReader interface.
+func (cr *prereadChunkReader) ReadChunk() ([][]byte, uint64, error) {
+	if cr.read {
+		return nil, 0, io.EOF
+	}
+	cr.read = true
+	chunk := cr.chunk
+	cr.chunk = nil // allow for GC
+	return chunk, cr.n, nil
+}
@@ -0,0 +1,297 @@
+package renter
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"gitlab.com/SkynetLabs/skyd/skymodules/renter/filesystem/siafile"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// TestSkyfileChunkIndex tests the reference counting and persistence of the
+// skyfile chunk index.
+func TestSkyfileChunkIndex(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	testDir := build.TempDir("renter", t.Name())
+	fileName := "test"
+
+	ci, err := newSkyfileChunkIndex(testDir, fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Unknown chunks can't be acquired.
+	var key crypto.Hash
+	fastrand.Read(key[:])
+	file1, file2 := siafile.SiafileUID("file1"), siafile.SiafileUID("file2")
+	_, exists, err := ci.managedAcquire(file1, key, nil)
+	if err != nil || exists {
+		t.Fatal("unexpected result", exists, err)
+	}
+
+	// Add a chunk for the first file.
+	pieces := []skyfileChunkIndexPiece{
+		{
+			PieceIndex: 0,
+			HostKey:    types.SiaPublicKey{Key: fastrand.Bytes(32)},
+			MerkleRoot: crypto.Hash{1},
+		},
+		{
+			PieceIndex: 1,
+			HostKey:    types.SiaPublicKey{Key: fastrand.Bytes(32)},
+			MerkleRoot: crypto.Hash{2},
+		},
+	}
+	if err := ci.managedAdd(file1, key, pieces); err != nil {
+		t.Fatal(err)
+	}
+
+	// Acquire it for the second file.
+	acquired, exists, err := ci.managedAcquire(file2, key, nil)
+	if err != nil || !exists {
+		t.Fatal("unexpected result", exists, err)
+	}
+	if len(acquired) != len(pieces) || !acquired[1].HostKey.Equals(pieces[1].HostKey) || acquired[1].MerkleRoot != pieces[1].MerkleRoot {
+		t.Fatal("unexpected pieces", acquired)
+	}
+
+	// Reload the index. The chunk should still be there with 2 references.
+	if err := ci.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ci, err = newSkyfileChunkIndex(testDir, fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ci.managedLen() != 1 || ci.chunks[key].refs != 2 {
+		t.Fatal("unexpected index after reload", ci.managedLen())
+	}
+
+	// Release both files. The chunk should be garbage collected.
+	if err := ci.managedRelease(file1); err != nil {
+		t.Fatal(err)
+	}
+	if ci.managedLen() != 1 {
+		t.Fatal("chunk shouldn't be gone yet")
+	}
+	if err := ci.managedRelease(file2); err != nil {
+		t.Fatal(err)
+	}
+	if ci.managedLen() != 0 {
+		t.Fatal("chunk should be gone")
+	}
+	// Releasing an unknown file is a no-op.
+	if err := ci.managedRelease(file1); err != nil {
+		t.Fatal(err)
+	}
+	// Adding a reference to a garbage collected chunk is not possible.
+	if _, exists, err := ci.managedAcquire(file1, key, nil); err != nil || exists {
+		t.Fatal("unexpected result", exists, err)
+	}
+
+	// The chunk should still be gone after a reload.
+	if err := ci.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ci, err = newSkyfileChunkIndex(testDir, fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ci.managedLen() != 0 {
+		t.Fatal("chunk should be gone after reload")
+	}
+	if err := ci.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestSkyfileChunkIndexDeletedFiles tests that the references of deleted
+// siafiles are released when deleting their directory and when pruning the
+// skyfile chunk index.
+func TestSkyfileChunkIndexDeletedFiles(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+	ci := r.staticSkyfileChunkIndex
+
+	// addFile creates a siafile which references a chunk of the index.
+	rsc, _ := skymodules.NewRSCode(1, 1)
+	addFile := func(siaPath skymodules.SiaPath) crypto.Hash {
+		fileNode, err := r.createRenterTestFileWithParams(siaPath, rsc, crypto.TypePlain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := fileNode.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}()
+		var key crypto.Hash
+		fastrand.Read(key[:])
+		pieces := []skyfileChunkIndexPiece{{MerkleRoot: key}}
+		if err := ci.managedAdd(fileNode.UID(), key, pieces); err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+
+	// Deleting a directory releases the references of its files.
+	dir, err := skymodules.NewSiaPath("dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"file1", "sub/file2"} {
+		siaPath, err := dir.Join(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		addFile(siaPath)
+	}
+	if ci.managedLen() != 2 {
+		t.Fatal("unexpected number of chunks", ci.managedLen())
+	}
+	if err := r.DeleteDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if ci.managedLen() != 0 {
+		t.Fatal("chunks of deleted directory weren't released", ci.managedLen())
+	}
+
+	// Pruning the index releases the references of files which don't exist
+	// anymore.
+	siaPath, err := skymodules.NewSiaPath("file3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := addFile(siaPath)
+	var orphanKey crypto.Hash
+	fastrand.Read(orphanKey[:])
+	err = ci.managedAdd(siafile.SiafileUID("orphan"), orphanKey, []skyfileChunkIndexPiece{{MerkleRoot: orphanKey}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.managedPruneSkyfileChunkIndex(); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := ci.chunks[orphanKey]; exists {
+		t.Fatal("chunk of orphaned file wasn't released")
+	}
+	if _, exists := ci.chunks[key]; !exists {
+		t.Fatal("chunk of existing file was released")
+	}
+}
+
+// TestSkyfileChunkIndexUsablePieces tests that indexed pieces are only reused
+// if they are stored on usable hosts and that re-uploaded chunks replace the
+// indexed pieces.
+func TestSkyfileChunkIndexUsablePieces(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	testDir := build.TempDir("renter", t.Name())
+	ci, err := newSkyfileChunkIndex(testDir, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ci.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create 3 hosts. The first one is good, the second one is offline and
+	// the third one is not good for renew.
+	hosts := make([]types.SiaPublicKey, 3)
+	for i := range hosts {
+		hosts[i] = types.SiaPublicKey{Key: fastrand.Bytes(32)}
+	}
+	offline := map[string]bool{
+		hosts[1].String(): true,
+	}
+	goodForRenew := map[string]bool{
+		hosts[0].String(): true,
+		hosts[1].String(): true,
+	}
+	usable := func(pieces []skyfileChunkIndexPiece) []skyfileChunkIndexPiece {
+		return staticUsableIndexPieces(pieces, 2, offline, goodForRenew)
+	}
+
+	// Index a chunk with 2 pieces of which only the first one is stored on
+	// the good host.
+	var key crypto.Hash
+	fastrand.Read(key[:])
+	file1, file2 := siafile.SiafileUID("file1"), siafile.SiafileUID("file2")
+	pieces := []skyfileChunkIndexPiece{
+		{PieceIndex: 0, HostKey: hosts[0], MerkleRoot: crypto.Hash{1}},
+		{PieceIndex: 1, HostKey: hosts[1], MerkleRoot: crypto.Hash{2}},
+		{PieceIndex: 1, HostKey: hosts[2], MerkleRoot: crypto.Hash{2}},
+	}
+	if err := ci.managedAdd(file1, key, pieces); err != nil {
+		t.Fatal(err)
+	}
+
+	// The chunk can't be acquired since the second piece is not stored on a
+	// usable host. No reference should be added.
+	if _, exists, err := ci.managedAcquire(file2, key, usable); err != nil || exists {
+		t.Fatal("unexpected result", exists, err)
+	}
+	if refs := ci.chunks[key].refs; refs != 1 {
+		t.Fatal("unexpected refs", refs)
+	}
+
+	// Re-upload the chunk for the second file. The new pieces replace the
+	// indexed ones.
+	goodForRenew[hosts[2].String()] = true
+	reuploaded := []skyfileChunkIndexPiece{
+		{PieceIndex: 0, HostKey: hosts[0], MerkleRoot: crypto.Hash{1}},
+		{PieceIndex: 1, HostKey: hosts[2], MerkleRoot: crypto.Hash{2}},
+	}
+	if err := ci.managedAdd(file2, key, reuploaded); err != nil {
+		t.Fatal(err)
+	}
+	if refs := ci.chunks[key].refs; refs != 2 {
+		t.Fatal("unexpected refs", refs)
+	}
+
+	// Now the chunk can be acquired and only the usable pieces are returned.
+	acquired, exists, err := ci.managedAcquire(siafile.SiafileUID("file3"), key, usable)
+	if err != nil || !exists {
+		t.Fatal("unexpected result", exists, err)
+	}
+	if len(acquired) != len(reuploaded) || !acquired[1].HostKey.Equals(hosts[2]) {
+		t.Fatal("unexpected pieces", acquired)
+	}
+
+	// The replaced pieces should survive a reload.
+	if err := ci.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ci, err = newSkyfileChunkIndex(testDir, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chunk := ci.chunks[key]; chunk.refs != 3 || len(chunk.pieces) != len(reuploaded) {
+		t.Fatal("unexpected chunk after reload", chunk.refs, chunk.pieces)
+	}
+}
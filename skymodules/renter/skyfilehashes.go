@@ -0,0 +1,87 @@
+package renter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"lukechampine.com/blake3"
+)
+
+// skyfileHashReader wraps a skyfile upload reader and computes the
+// additional content hashes of the skyfile while it is being read.
+//
+// NOTE: just like the wrapped reader this object is not threadsafe.
+type skyfileHashReader struct {
+	skymodules.SkyfileUploadReader
+
+	// skip is the number of bytes which are read again after they were
+	// passed to SetReadBuffer and have already been hashed.
+	skip int
+
+	// eof indicates whether the wrapped reader was read entirely. The hashes
+	// are only complete after that.
+	eof bool
+
+	blake3Hasher hash.Hash
+	sha256Hasher hash.Hash
+}
+
+// newSkyfileHashReader wraps the given reader in a reader which computes the
+// skyfile's content hashes.
+func newSkyfileHashReader(reader skymodules.SkyfileUploadReader) *skyfileHashReader {
+	return &skyfileHashReader{
+		SkyfileUploadReader: reader,
+		blake3Hasher:        blake3.New(32, nil),
+		sha256Hasher:        sha256.New(),
+	}
+}
+
+// Read implements io.Reader. The read data is hashed unless it was already
+// hashed before it was passed to SetReadBuffer.
+func (hr *skyfileHashReader) Read(b []byte) (int, error) {
+	n, err := hr.SkyfileUploadReader.Read(b)
+	data := b[:n]
+	if hr.skip > 0 {
+		skipped := hr.skip
+		if skipped > len(data) {
+			skipped = len(data)
+		}
+		data = data[skipped:]
+		hr.skip -= skipped
+	}
+	_, _ = hr.blake3Hasher.Write(data)
+	_, _ = hr.sha256Hasher.Write(data)
+	if errors.Contains(err, io.EOF) {
+		hr.eof = true
+	}
+	return n, err
+}
+
+// SetReadBuffer implements skymodules.SkyfileUploadReader. The buffer is
+// expected to contain the data which was read last.
+func (hr *skyfileHashReader) SetReadBuffer(data []byte) {
+	hr.skip += len(data)
+	hr.SkyfileUploadReader.SetReadBuffer(data)
+}
+
+// SkyfileMetadata implements skymodules.SkyfileUploadReader. The content
+// hashes are added to the metadata of the wrapped reader once the content was
+// read entirely.
+func (hr *skyfileHashReader) SkyfileMetadata(ctx context.Context) (skymodules.SkyfileMetadata, error) {
+	metadata, err := hr.SkyfileUploadReader.SkyfileMetadata(ctx)
+	if err != nil {
+		return skymodules.SkyfileMetadata{}, err
+	}
+	if hr.eof {
+		metadata.Hashes = &skymodules.SkyfileHashes{
+			BLAKE3: hex.EncodeToString(hr.blake3Hasher.Sum(nil)),
+			SHA256: hex.EncodeToString(hr.sha256Hasher.Sum(nil)),
+		}
+	}
+	return metadata, nil
+}
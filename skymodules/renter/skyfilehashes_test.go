@@ -0,0 +1,62 @@
+package renter
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"lukechampine.com/blake3"
+)
+
+// TestSkyfileHashReader verifies that the hash reader hashes the read data
+// exactly once, even if parts of it are passed back to SetReadBuffer, and that
+// the hashes are only added to the metadata after the data was read entirely.
+func TestSkyfileHashReader(t *testing.T) {
+	t.Parallel()
+
+	data := fastrand.Bytes(1000)
+	reader := skymodules.NewSkyfileReader(bytes.NewReader(data), skymodules.SkyfileUploadParameters{Filename: "file"})
+	hr := newSkyfileHashReader(reader)
+
+	// Read some data and pass part of it back.
+	buf := make([]byte, 100)
+	n, err := hr.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hr.SetReadBuffer(buf[50:n])
+	if hr.eof {
+		t.Fatal("reader shouldn't be done yet")
+	}
+
+	// Read the remaining data.
+	rest, err := ioutil.ReadAll(hr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(append(buf[:50], rest...), data) {
+		t.Fatal("read data doesn't match")
+	}
+
+	// The metadata should contain the hashes.
+	metadata, err := hr.SkyfileMetadata(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.Filename != "file" || metadata.Hashes == nil {
+		t.Fatal("unexpected metadata", metadata)
+	}
+	sha256Hash := sha256.Sum256(data)
+	if metadata.Hashes.SHA256 != hex.EncodeToString(sha256Hash[:]) {
+		t.Fatal("wrong sha256 hash", metadata.Hashes.SHA256)
+	}
+	blake3Hash := blake3.Sum256(data)
+	if metadata.Hashes.BLAKE3 != hex.EncodeToString(blake3Hash[:]) {
+		t.Fatal("wrong blake3 hash", metadata.Hashes.BLAKE3)
+	}
+}
@@ -0,0 +1,225 @@
+package renter
+
+import (
+	"io"
+	"mime"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"gitlab.com/SkynetLabs/skyd/skymodules/renter/filesystem"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// maxSkylinkDependencies is the maximum number of dependencies which are
+	// pinned together with a skylink.
+	maxSkylinkDependencies = 100
+
+	// maxSkylinkDependencyScanSize is the maximum number of bytes of a
+	// skylink's content which are scanned for dependencies.
+	maxSkylinkDependencyScanSize = 1 << 22 // 4 MiB
+
+	// skylinkDependenciesSuffix is the suffix which is appended to the siapath
+	// of a pinned skylink to create the folder its dependencies are pinned
+	// in.
+	skylinkDependenciesSuffix = "-dependencies"
+)
+
+// PinSkylinkWithDependencies pins the skylink as well as all skylinks which
+// are referenced by its content up to the given depth. The dependencies are
+// pinned within a folder next to the pinned skylink. Failing to pin the
+// skylink itself returns an error while failing to pin or crawl a dependency
+// is reported within the returned dependency graph. The first node of the
+// graph is the pinned skylink.
+//
+// If an API token ID is given, every dependency reserves its size of the
+// token's quota before it is pinned. Dependencies which don't fit into the
+// quota are not pinned. The quota of the skylink itself is handled by the
+// caller.
+func (r *Renter) PinSkylinkWithDependencies(skylink skymodules.Skylink, lup skymodules.SkyfileUploadParameters, timeout time.Duration, pricePerMS types.Currency, depth uint64, tokenID string) ([]skymodules.SkylinkDependency, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+
+	// Pin the skylink itself.
+	err := r.PinSkylink(skylink, lup, timeout, pricePerMS)
+	if err != nil {
+		return nil, err
+	}
+	depsDir, err := lup.SiaPath.AddSuffixStr(skylinkDependenciesSuffix)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create siapath for dependencies")
+	}
+
+	// Crawl the dependencies breadth-first.
+	nodes := []skymodules.SkylinkDependency{{
+		Skylink: skylink.String(),
+		Pinned:  true,
+	}}
+	links := []skymodules.Skylink{skylink}
+	visited := map[skymodules.Skylink]struct{}{
+		skylink: {},
+	}
+	for i := 0; i < len(nodes); i++ {
+		if nodes[i].Depth >= depth || !nodes[i].Pinned {
+			continue
+		}
+		deps, err := r.managedSkylinkDependencies(links[i], timeout, pricePerMS)
+		if err != nil {
+			nodes[i].Error = err.Error()
+			continue
+		}
+		for _, dep := range deps {
+			nodes[i].Dependencies = append(nodes[i].Dependencies, dep.String())
+			if _, exists := visited[dep]; exists {
+				continue
+			}
+			if len(nodes) > maxSkylinkDependencies {
+				r.staticLog.Printf("Not pinning dependency %v of %v since the maximum number of dependencies was reached", dep, skylink)
+				continue
+			}
+			visited[dep] = struct{}{}
+
+			// Pin the dependency. A dependency which was pinned before
+			// already exists at its siapath.
+			node := skymodules.SkylinkDependency{
+				Skylink: dep.String(),
+				Depth:   nodes[i].Depth + 1,
+			}
+			depLup := lup
+			depLup.SiaPath, err = depsDir.Join(dep.String())
+			if err == nil {
+				err = r.managedPinDependency(dep, depLup, timeout, pricePerMS, tokenID)
+			}
+			if err != nil && !errors.Contains(err, filesystem.ErrExists) {
+				node.Error = err.Error()
+			} else {
+				node.Pinned = true
+			}
+			nodes = append(nodes, node)
+			links = append(links, dep)
+		}
+	}
+	return nodes, nil
+}
+
+// managedPinDependency pins a dependency of a skylink. If an API token ID is
+// given, the dependency's size is reserved from the token's quota and only
+// added to its usage if the dependency was pinned.
+func (r *Renter) managedPinDependency(dep skymodules.Skylink, lup skymodules.SkyfileUploadParameters, timeout time.Duration, pricePerMS types.Currency, tokenID string) error {
+	if tokenID == "" {
+		return r.PinSkylink(dep, lup, timeout, pricePerMS)
+	}
+	streamer, _, err := r.DownloadSkylinkMetadata(dep, timeout, pricePerMS, skymodules.OverdriveSettings{}, nil)
+	if err != nil {
+		return errors.AddContext(err, "unable to fetch metadata of dependency")
+	}
+	size := streamer.Metadata().Length
+	if err := streamer.Close(); err != nil {
+		return errors.AddContext(err, "unable to close streamer")
+	}
+	err = r.staticSkynetTokens.Reserve(tokenID, size)
+	if err != nil {
+		return err
+	}
+	err = r.PinSkylink(dep, lup, timeout, pricePerMS)
+	if err != nil {
+		r.staticSkynetTokens.Release(tokenID, size)
+		return err
+	}
+	return r.staticSkynetTokens.Commit(tokenID, dep, size)
+}
+
+// managedSkylinkDependencies downloads the content of a skylink and returns
+// the skylinks it references. Only text based content is scanned.
+func (r *Renter) managedSkylinkDependencies(skylink skymodules.Skylink, timeout time.Duration, pricePerMS types.Currency) (_ []skymodules.Skylink, err error) {
+	streamer, _, err := r.DownloadSkylink(skylink, timeout, pricePerMS, skymodules.OverdriveSettings{}, nil)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to download skylink")
+	}
+	defer func() {
+		err = errors.Compose(err, streamer.Close())
+	}()
+
+	// Collect the sections of the content which should be scanned.
+	type section struct {
+		offset, length uint64
+	}
+	var sections []section
+	md := streamer.Metadata()
+	if len(md.Subfiles) == 0 {
+		if isCrawlableContentType(contentTypeByFilename(md.Filename)) {
+			sections = append(sections, section{0, md.Length})
+		}
+	} else {
+		for _, sub := range md.Subfiles {
+			contentType := sub.ContentType
+			if contentType == "" {
+				contentType = contentTypeByFilename(sub.Filename)
+			}
+			if isCrawlableContentType(contentType) {
+				sections = append(sections, section{sub.Offset, sub.Len})
+			}
+		}
+		sort.Slice(sections, func(i, j int) bool {
+			return sections[i].offset < sections[j].offset
+		})
+	}
+
+	// Read the sections up to the maximum scan size. The sections are
+	// separated by a newline to avoid skylinks being formed across them.
+	var data []byte
+	for _, s := range sections {
+		remaining := uint64(maxSkylinkDependencyScanSize - len(data))
+		if s.length > remaining {
+			s.length = remaining
+		}
+		if s.length == 0 {
+			break
+		}
+		_, err = streamer.Seek(int64(s.offset), io.SeekStart)
+		if err != nil {
+			return nil, errors.AddContext(err, "unable to seek within skylink")
+		}
+		b := make([]byte, s.length)
+		_, err = io.ReadFull(streamer, b)
+		if err != nil {
+			return nil, errors.AddContext(err, "unable to read skylink")
+		}
+		data = append(data, b...)
+		data = append(data, '\n')
+	}
+
+	// Extract the skylinks and ignore references to the skylink itself.
+	var deps []skymodules.Skylink
+	for _, sl := range skymodules.ExtractSkylinks(data) {
+		if sl != skylink {
+			deps = append(deps, sl)
+		}
+	}
+	return deps, nil
+}
+
+// contentTypeByFilename returns the content type for a file based on its
+// extension.
+func contentTypeByFilename(filename string) string {
+	return mime.TypeByExtension(filepath.Ext(filename))
+}
+
+// isCrawlableContentType returns true if content of the given type might
+// reference other skylinks and should be scanned for dependencies.
+func isCrawlableContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "javascript") ||
+		strings.HasSuffix(mediaType, "json") ||
+		strings.HasSuffix(mediaType, "xml")
+}
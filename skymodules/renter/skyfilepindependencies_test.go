@@ -0,0 +1,30 @@
+package renter
+
+import "testing"
+
+// TestIsCrawlableContentType is a unit test for isCrawlableContentType.
+func TestIsCrawlableContentType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		contentType string
+		crawlable   bool
+	}{
+		{"text/html; charset=utf-8", true},
+		{"text/css", true},
+		{"application/javascript", true},
+		{"application/json", true},
+		{"image/svg+xml", true},
+		{contentTypeByFilename("index.js"), true},
+		{contentTypeByFilename("index.html"), true},
+		{"image/png", false},
+		{"application/octet-stream", false},
+		{contentTypeByFilename("file"), false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if crawlable := isCrawlableContentType(test.contentType); crawlable != test.crawlable {
+			t.Errorf("%v: expected %v but got %v", test.contentType, test.crawlable, crawlable)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package renter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skykey"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// skykeyBackupKeySpecifier is the specifier used to derive the key pair of
+	// the registry entry which points to the automatic skykey backups.
+	skykeyBackupKeySpecifier = types.NewSpecifier("SkykeyBackup")
+
+	// skykeyAutoBackupTimeout is the timeout of an automatic skykey backup
+	// which is triggered in the background.
+	skykeyAutoBackupTimeout = build.Select(build.Var{
+		Dev:      5 * time.Minute,
+		Standard: 15 * time.Minute,
+		Testing:  time.Minute,
+	}).(time.Duration)
+)
+
+var (
+	// errSkykeyAutoBackupNoEntryName is returned when enabling the automatic
+	// skykey backup without an entry name.
+	errSkykeyAutoBackupNoEntryName = errors.New("automatic skykey backup requires an entry name")
+)
+
+type (
+	// skykeyAutoBackupSettings are the persisted settings of the automatic
+	// skykey backup. Only the key derived from the password is persisted,
+	// never the password itself.
+	skykeyAutoBackupSettings struct {
+		Enabled        bool
+		EntryName      string
+		BundleKey      skykey.BundleKey
+		LastBackup     string
+		LastBackupTime time.Time
+	}
+
+	// skykeyBackupState contains the in-memory state of the automatic skykey
+	// backup.
+	skykeyBackupState struct {
+		recentErr     error
+		recentErrTime time.Time
+		mu            sync.Mutex
+
+		// staticBackupMu serializes backups to make sure that registry
+		// updates don't race each other.
+		staticBackupMu sync.Mutex
+	}
+)
+
+// skykeyBackupKeyPair derives the key pair used for the registry entry of the
+// automatic skykey backup from the renter seed.
+func (r *Renter) skykeyBackupKeyPair() (crypto.SecretKey, crypto.PublicKey, error) {
+	// Get the wallet seed.
+	ws, _, err := r.staticWallet.PrimarySeed()
+	if err != nil {
+		return crypto.SecretKey{}, crypto.PublicKey{}, errors.AddContext(err, "failed to get wallet's primary seed")
+	}
+	// Derive the renter seed and wipe the memory once we are done using it.
+	rs := skymodules.DeriveRenterSeed(ws)
+	defer fastrand.Read(rs[:])
+	// Derive the entropy and wipe it afterwards.
+	entropy := crypto.HashAll(rs, skykeyBackupKeySpecifier)
+	defer fastrand.Read(entropy[:])
+	sk, pk := crypto.GenerateKeyPairDeterministic([crypto.EntropySize]byte(entropy))
+	return sk, pk, nil
+}
+
+// skykeyBackupTweak returns the tweak of the registry entry with the given
+// name.
+func skykeyBackupTweak(entryName string) crypto.Hash {
+	return crypto.HashBytes([]byte(entryName))
+}
+
+// managedSkykeyBackupSkylink returns the V2 skylink of the registry entry with
+// the given name.
+func (r *Renter) managedSkykeyBackupSkylink(entryName string) (skymodules.Skylink, error) {
+	_, pk, err := r.skykeyBackupKeyPair()
+	if err != nil {
+		return skymodules.Skylink{}, err
+	}
+	spk := types.Ed25519PublicKey(pk)
+	return skymodules.NewSkylinkV2(spk, skykeyBackupTweak(entryName)), nil
+}
+
+// managedUploadSkykeyBundle uploads a skykey bundle as a skyfile.
+func (r *Renter) managedUploadSkykeyBundle(ctx context.Context, bundle []byte) (skymodules.Skylink, error) {
+	filename := fmt.Sprintf("skykey-backup-%v", time.Now().UnixNano())
+	siaPath, err := skymodules.SkynetBackupFolder.Join(filename)
+	if err != nil {
+		return skymodules.Skylink{}, err
+	}
+	sup := skymodules.SkyfileUploadParameters{
+		SiaPath:  siaPath,
+		Filename: filename,
+		Force:    true,
+	}
+	return r.UploadSkyfile(ctx, sup, skymodules.NewSkyfileReader(bytes.NewReader(bundle), sup))
+}
+
+// CreateSkykeyBackup uploads a password protected bundle of the renter's
+// skykeys as a skyfile.
+func (r *Renter) CreateSkykeyBackup(ctx context.Context, password string) (skymodules.Skylink, error) {
+	if err := r.tg.Add(); err != nil {
+		return skymodules.Skylink{}, err
+	}
+	defer r.tg.Done()
+	bundle, err := r.staticSkykeyManager.ExportBundle(password)
+	if err != nil {
+		return skymodules.Skylink{}, errors.AddContext(err, "failed to export skykeys")
+	}
+	return r.managedUploadSkykeyBundle(ctx, bundle)
+}
+
+// ExportSkykeys returns a password protected bundle of the renter's skykeys.
+func (r *Renter) ExportSkykeys(password string) ([]byte, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	return r.staticSkykeyManager.ExportBundle(password)
+}
+
+// ImportSkykeys adds the skykeys of a password protected bundle to the
+// renter's skykey manager and returns the added keys.
+func (r *Renter) ImportSkykeys(bundle []byte, password string) ([]skykey.Skykey, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	added, err := r.staticSkykeyManager.ImportBundle(bundle, password)
+	if len(added) > 0 {
+		_ = r.tg.Launch(r.threadedSkykeyAutoBackup)
+	}
+	return added, err
+}
+
+// SetSkykeyAutoBackup enables the automatic backup of the renter's skykeys to
+// the registry entry with the given name. An empty password disables the
+// automatic backup. Enabling the backup creates an initial backup right away.
+func (r *Renter) SetSkykeyAutoBackup(ctx context.Context, password, entryName string) (skymodules.SkykeyAutoBackupStatus, error) {
+	if err := r.tg.Add(); err != nil {
+		return skymodules.SkykeyAutoBackupStatus{}, err
+	}
+	defer r.tg.Done()
+
+	// Disable the backup.
+	if password == "" {
+		id := r.mu.Lock()
+		old := r.persist.SkykeyAutoBackup
+		r.persist.SkykeyAutoBackup = skykeyAutoBackupSettings{}
+		r.mu.Unlock(id)
+		err := r.managedSaveSync()
+		if err != nil {
+			id = r.mu.Lock()
+			r.persist.SkykeyAutoBackup = old
+			r.mu.Unlock(id)
+			return skymodules.SkykeyAutoBackupStatus{}, errors.AddContext(err, "failed to persist skykey backup settings")
+		}
+		return r.managedSkykeyAutoBackupStatus()
+	}
+	if entryName == "" {
+		return skymodules.SkykeyAutoBackupStatus{}, errSkykeyAutoBackupNoEntryName
+	}
+
+	// Derive the key and create the initial backup.
+	bk, err := skykey.NewBundleKey(password)
+	if err != nil {
+		return skymodules.SkykeyAutoBackupStatus{}, err
+	}
+	settings := skykeyAutoBackupSettings{
+		Enabled:   true,
+		EntryName: entryName,
+		BundleKey: bk,
+	}
+	if err := r.managedSkykeyAutoBackup(ctx, settings, true); err != nil {
+		return skymodules.SkykeyAutoBackupStatus{}, errors.AddContext(err, "failed to create initial skykey backup")
+	}
+	return r.managedSkykeyAutoBackupStatus()
+}
+
+// SkykeyAutoBackupStatus returns the status of the automatic skykey backup.
+func (r *Renter) SkykeyAutoBackupStatus() (skymodules.SkykeyAutoBackupStatus, error) {
+	if err := r.tg.Add(); err != nil {
+		return skymodules.SkykeyAutoBackupStatus{}, err
+	}
+	defer r.tg.Done()
+	return r.managedSkykeyAutoBackupStatus()
+}
+
+// managedSkykeyAutoBackupStatus returns the status of the automatic skykey
+// backup.
+func (r *Renter) managedSkykeyAutoBackupStatus() (skymodules.SkykeyAutoBackupStatus, error) {
+	id := r.mu.RLock()
+	settings := r.persist.SkykeyAutoBackup
+	r.mu.RUnlock(id)
+
+	state := r.staticSkykeyBackupState
+	state.mu.Lock()
+	var recentErrStr string
+	if state.recentErr != nil {
+		recentErrStr = state.recentErr.Error()
+	}
+	status := skymodules.SkykeyAutoBackupStatus{
+		Enabled:        settings.Enabled,
+		EntryName:      settings.EntryName,
+		LastBackup:     settings.LastBackup,
+		LastBackupTime: settings.LastBackupTime,
+		RecentErr:      recentErrStr,
+		RecentErrTime:  state.recentErrTime,
+	}
+	state.mu.Unlock()
+
+	if !settings.Enabled {
+		return status, nil
+	}
+	skylink, err := r.managedSkykeyBackupSkylink(settings.EntryName)
+	if err != nil {
+		return skymodules.SkykeyAutoBackupStatus{}, err
+	}
+	status.Skylink = skylink.String()
+	return status, nil
+}
+
+// threadedSkykeyAutoBackup creates a new automatic skykey backup if the
+// automatic backup is enabled.
+func (r *Renter) threadedSkykeyAutoBackup() {
+	id := r.mu.RLock()
+	settings := r.persist.SkykeyAutoBackup
+	r.mu.RUnlock(id)
+	if !settings.Enabled {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.tg.StopCtx(), skykeyAutoBackupTimeout)
+	defer cancel()
+	err := r.managedSkykeyAutoBackup(ctx, settings, false)
+	if err != nil {
+		r.staticLog.Printf("WARN: automatic skykey backup failed: %v", err)
+	}
+}
+
+// managedSkykeyAutoBackup uploads a bundle of the renter's skykeys, updates
+// the registry entry of the settings to point to it and persists the updated
+// settings. The initial backup is created when the backup is enabled and
+// always persists its settings.
+func (r *Renter) managedSkykeyAutoBackup(ctx context.Context, settings skykeyAutoBackupSettings, initial bool) (err error) {
+	state := r.staticSkykeyBackupState
+	state.staticBackupMu.Lock()
+	defer state.staticBackupMu.Unlock()
+
+	// Remember the error for the status.
+	defer func() {
+		state.mu.Lock()
+		defer state.mu.Unlock()
+		if err != nil {
+			state.recentErr = err
+			state.recentErrTime = time.Now()
+		}
+	}()
+
+	// Upload the bundle.
+	bundle, err := skykey.ExportBundleWithKey(r.staticSkykeyManager.Skykeys(), settings.BundleKey)
+	if err != nil {
+		return errors.AddContext(err, "failed to export skykeys")
+	}
+	skylink, err := r.managedUploadSkykeyBundle(ctx, bundle)
+	if err != nil {
+		return errors.AddContext(err, "failed to upload skykey bundle")
+	}
+
+	// Update the registry entry.
+	sk, pk, err := r.skykeyBackupKeyPair()
+	if err != nil {
+		return err
+	}
+	defer fastrand.Read(sk[:])
+	spk := types.Ed25519PublicKey(pk)
+	tweak := skykeyBackupTweak(settings.EntryName)
+	var revision uint64
+	entry, err := r.ReadRegistry(ctx, spk, tweak)
+	notFound := errors.Contains(err, ErrRegistryEntryNotFound) || errors.Contains(err, ErrRegistryLookupTimeout)
+	if err == nil {
+		revision = entry.Revision + 1
+	} else if !notFound {
+		return errors.AddContext(err, "failed to read skykey backup registry entry")
+	}
+	srv := modules.NewRegistryValue(tweak, skylink.Bytes(), revision, modules.RegistryTypeWithoutPubkey).Sign(sk)
+	_, err = r.UpdateRegistry(ctx, spk, srv)
+	if err != nil {
+		return errors.AddContext(err, "failed to update skykey backup registry entry")
+	}
+
+	// Persist the settings. A background backup only updates the settings if
+	// they weren't changed in the meantime.
+	id := r.mu.Lock()
+	old := r.persist.SkykeyAutoBackup
+	if !initial && (!old.Enabled || old.EntryName != settings.EntryName || old.BundleKey != settings.BundleKey) {
+		r.mu.Unlock(id)
+		return nil
+	}
+	settings.LastBackup = skylink.String()
+	settings.LastBackupTime = time.Now()
+	r.persist.SkykeyAutoBackup = settings
+	r.mu.Unlock(id)
+	err = r.managedSaveSync()
+	if err != nil {
+		id = r.mu.Lock()
+		r.persist.SkykeyAutoBackup = old
+		r.mu.Unlock(id)
+		return errors.AddContext(err, "failed to persist skykey backup settings")
+	}
+	return nil
+}
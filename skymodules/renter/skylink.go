@@ -92,6 +92,13 @@ func (sm *skylinkManager) managedAddUnpinRequest(skylink skymodules.Skylink) {
 	sm.unpinRequests[skylinkStr] = time.Now().Add(TargetHealthCheckFrequency * 2)
 }
 
+// managedRemoveUnpinRequest removes a pending unpin request for a skylink.
+func (sm *skylinkManager) managedRemoveUnpinRequest(skylink skymodules.Skylink) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	delete(sm.unpinRequests, skylink.String())
+}
+
 // UnpinSkylink unpins a skylink from the renter by removing the underlying
 // siafile.
 //
@@ -128,7 +135,9 @@ func (r *Renter) UnpinSkylink(skylink skymodules.Skylink) error {
 
 	// Add the unpin request
 	r.staticSkylinkManager.managedAddUnpinRequest(skylink)
-	return nil
+
+	// Free the skylink's bytes of the API tokens it was pinned with.
+	return errors.AddContext(r.staticSkynetTokens.Unpin(skylink), "failed to update api token usage")
 }
 
 // managedBlocklistHash returns the hash to be used in the blocklist
@@ -179,6 +179,15 @@ func testSkylinkBasic(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+
+	// Removing the unpin request should leave no requests.
+	sm.managedRemoveUnpinRequest(skylink2)
+	sm.mu.Lock()
+	numRequests := len(sm.unpinRequests)
+	sm.mu.Unlock()
+	if numRequests != 0 {
+		t.Fatal("expected no unpin requests", numRequests)
+	}
 }
 
 // TestBlocklistHash probes the BlocklistHash method of the renter.
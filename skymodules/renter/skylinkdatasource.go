@@ -2,12 +2,15 @@ package renter
 
 import (
 	"context"
+	"sync/atomic"
+	"time"
 
 	"github.com/opentracing/opentracing-go"
 	"gitlab.com/SkynetLabs/skyd/build"
 	"gitlab.com/SkynetLabs/skyd/skykey"
 	"gitlab.com/SkynetLabs/skyd/skymodules"
 	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 
 	"gitlab.com/NebulousLabs/errors"
@@ -136,6 +139,7 @@ func (sds *skylinkDataSource) ReadStream(ctx context.Context, off, fetchSize uin
 		numChunks += 1
 	}
 	downloadChans := make([]chan *downloadResponse, 0, numChunks)
+	chunkIndices := make([]uint64, 0, numChunks)
 
 	// Otherwise we are dealing with a large skyfile and have to aggregate the
 	// download responses for every chunk in the fanout. We keep reading from
@@ -180,6 +184,7 @@ func (sds *skylinkDataSource) ReadStream(ctx context.Context, off, fetchSize uin
 			return responseChan
 		}
 		downloadChans = append(downloadChans, respChan)
+		chunkIndices = append(chunkIndices, chunkIndex)
 
 		off += downloadSize
 		n += downloadSize
@@ -191,12 +196,20 @@ func (sds *skylinkDataSource) ReadStream(ctx context.Context, off, fetchSize uin
 		data := make([]byte, fetchSize)
 		offset := 0
 		failed := false
+		provenance := make([]skymodules.ChunkProvenance, 0, len(downloadChans))
+		var bandwidthUp, bandwidthDown uint64
 
-		for _, respChan := range downloadChans {
+		for i, respChan := range downloadChans {
 			resp := <-respChan
+			bandwidthUp += resp.bandwidthUp
+			bandwidthDown += resp.bandwidthDown
 			if resp.err == nil {
 				n := copy(data[offset:], resp.data)
 				offset += n
+				provenance = append(provenance, skymodules.ChunkProvenance{
+					ChunkIndex: chunkIndices[i],
+					Pieces:     resp.provenance,
+				})
 				continue
 			}
 			if !failed {
@@ -207,7 +220,12 @@ func (sds *skylinkDataSource) ReadStream(ctx context.Context, off, fetchSize uin
 		}
 
 		if !failed {
-			responseChan <- &readResponse{staticData: data}
+			responseChan <- &readResponse{
+				staticData:          data,
+				staticProvenance:    provenance,
+				staticBandwidthUp:   bandwidthUp,
+				staticBandwidthDown: bandwidthDown,
+			}
 			close(responseChan)
 		}
 	})
@@ -246,18 +264,32 @@ func (r *Renter) managedDownloadByRoot(ctx context.Context, root crypto.Hash, of
 	}
 
 	// Download the base sector. The base sector contains the metadata, without
-	// it we can't provide a completed data source.
+	// it we can't provide a completed data source. The download is hedged by a
+	// second download from a different set of workers if it is slow or fails.
 	//
 	// NOTE: we pass in the provided context here, if the user imposed a timeout
 	// on the download request, this will fire if it takes too long.
-	respChan, err := pcws.managedDownload(ctx, pricePerMS, offset, length, false, false)
+	start := time.Now()
+	hedgeDelay, hedge := r.managedBaseSectorHedgeDelay()
+	result, err := hedgedDownload(ctx, hedgeDelay, hedge, func(ctx context.Context, excluded map[string]struct{}) (chan *downloadResponse, map[string]struct{}, error) {
+		return pcws.managedDownloadExcluding(ctx, pricePerMS, offset, length, false, false, excluded)
+	})
 	if err != nil {
-		return nil, nil, errors.AddContext(err, "unable to start download")
+		return nil, nil, err
+	}
+	if result.hedged {
+		atomic.AddUint64(&r.atomicBaseSectorHedges, 1)
+		span.SetTag("hedged", true)
+	}
+	if result.hedgeWon {
+		atomic.AddUint64(&r.atomicBaseSectorHedgesWon, 1)
+		span.SetTag("hedgewon", true)
 	}
-	resp := <-respChan
+	resp := result.resp
 	if resp.err != nil {
 		return nil, nil, errors.AddContext(resp.err, "base sector download did not succeed")
 	}
+	r.staticBaseSectorDownloadLatencyStats.AddDataPoint(time.Since(start))
 	baseSector := resp.data
 	if len(baseSector) < skymodules.SkyfileLayoutSize {
 		return nil, nil, errors.New("download did not fetch enough data, layout cannot be decoded")
@@ -266,6 +298,59 @@ func (r *Renter) managedDownloadByRoot(ctx context.Context, root crypto.Hash, of
 	return baseSector, pcws.managedWorkerState(), nil
 }
 
+// managedDownloadByRootCached will fetch data using the merkle root of that
+// data. If the recent upload cache or the sector cache are enabled, they are
+// consulted before launching any worker jobs. On a sector cache miss, the full
+// sector is downloaded and added to the sector cache.
+func (r *Renter) managedDownloadByRootCached(ctx context.Context, root crypto.Hash, offset, length uint64, pricePerMS types.Currency) ([]byte, error) {
+	data, _, err := r.managedDownloadByRootCachedWithState(ctx, root, offset, length, pricePerMS)
+	return data, err
+}
+
+// managedDownloadByRootCachedWithState works like managedDownloadByRootCached
+// but also returns the state of the workers which looked up the root if the
+// data was downloaded. If the data was served from a cache, no workers were
+// involved and the returned worker state is nil.
+func (r *Renter) managedDownloadByRootCachedWithState(ctx context.Context, root crypto.Hash, offset, length uint64, pricePerMS types.Currency) ([]byte, *pcwsWorkerState, error) {
+	// Check the recently uploaded base sectors.
+	if ruc := r.staticRecentUploadCache; ruc != nil && offset+length <= modules.SectorSize {
+		sector, err := ruc.managedGet(root)
+		if err == nil {
+			if span := opentracing.SpanFromContext(ctx); span != nil {
+				span.SetTag("recentuploadcache", true)
+			}
+			return sector[offset : offset+length], nil, nil
+		}
+	}
+
+	sc := r.staticSectorCache
+	if sc == nil || offset+length > modules.SectorSize {
+		return r.managedDownloadByRoot(ctx, root, offset, length, pricePerMS)
+	}
+
+	// Check the cache.
+	sector, err := sc.managedGet(root)
+	if err != nil && !errors.Contains(err, errSectorCacheMiss) {
+		r.staticLog.Printf("WARN: failed to fetch sector %v from cache: %v", root, err)
+	}
+	if err == nil {
+		if span := opentracing.SpanFromContext(ctx); span != nil {
+			span.SetTag("sectorcache", true)
+		}
+		return sector[offset : offset+length], nil, nil
+	}
+
+	// Download the full sector and add it to the cache.
+	sector, ws, err := r.managedDownloadByRoot(ctx, root, 0, modules.SectorSize, pricePerMS)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := sc.managedAdd(root, sector); err != nil {
+		r.staticLog.Printf("WARN: failed to add sector %v to cache: %v", root, err)
+	}
+	return sector[offset : offset+length], ws, nil
+}
+
 // managedSkylinkDataSource will create a streamBufferDataSource for the data
 // contained inside of a Skylink. The function will not return until the base
 // sector and all skyfile metadata has been retrieved.
@@ -288,7 +373,7 @@ func (r *Renter) managedSkylinkDataSource(ctx context.Context, skylink skymodule
 	//
 	// NOTE: we pass in the provided context here, if the user imposed a timeout
 	// on the download request, this will fire if it takes too long.
-	baseSector, _, err := r.managedDownloadByRoot(ctx, skylink.MerkleRoot(), offset, fetchSize, pricePerMS)
+	baseSector, err := r.managedDownloadByRootCached(ctx, skylink.MerkleRoot(), offset, fetchSize, pricePerMS)
 	if err != nil {
 		return nil, errors.AddContext(err, "unable to download base sector")
 	}
@@ -0,0 +1,141 @@
+package renter
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+// The skylink health cache holds the health of the skylinks pinned by the
+// node. Computing the health of a skylink on demand requires asking the hosts
+// for all of its sectors. Instead, the health loop records the health of every
+// siafile it updates for the skylinks of that file. The health of a skylink is
+// the worst health of its siafiles, e.g. the base sector and the fanout of a
+// large skyfile.
+//
+// Entries which weren't refreshed by the health loop for a while are ignored
+// since the file might have been renamed or the health loop might be stuck.
+
+var (
+	// skylinkHealthCacheTTL is the time after which a cached health is
+	// considered stale.
+	skylinkHealthCacheTTL = 2 * TargetHealthCheckFrequency
+)
+
+type (
+	// skylinkHealthCache caches the health of the siafiles of pinned
+	// skylinks.
+	skylinkHealthCache struct {
+		// files maps the siapaths of siafiles to their most recent health.
+		// skylinks maps skylinks to the siapaths of their siafiles.
+		files    map[skymodules.SiaPath]skylinkHealthCacheEntry
+		skylinks map[string]map[skymodules.SiaPath]struct{}
+
+		mu sync.Mutex
+	}
+
+	// skylinkHealthCacheEntry is the cached health of a siafile.
+	skylinkHealthCacheEntry struct {
+		skylinks []string
+		health   float64
+		updated  time.Time
+	}
+)
+
+// newSkylinkHealthCache creates a new skylink health cache.
+func newSkylinkHealthCache() *skylinkHealthCache {
+	return &skylinkHealthCache{
+		files:    make(map[skymodules.SiaPath]skylinkHealthCacheEntry),
+		skylinks: make(map[string]map[skymodules.SiaPath]struct{}),
+	}
+}
+
+// callHealth returns the cached health of a skylink and when it was last
+// updated. The returned bool is false if the health of the skylink isn't
+// cached.
+func (shc *skylinkHealthCache) callHealth(skylink string) (float64, time.Time, bool) {
+	shc.mu.Lock()
+	defer shc.mu.Unlock()
+	health := -math.MaxFloat64
+	var updated time.Time
+	var found bool
+	for siaPath := range shc.skylinks[skylink] {
+		entry := shc.files[siaPath]
+		if time.Since(entry.updated) > skylinkHealthCacheTTL {
+			continue
+		}
+		health = math.Max(health, entry.health)
+		// Report the time of the least recent update.
+		if !found || entry.updated.Before(updated) {
+			updated = entry.updated
+		}
+		found = true
+	}
+	if !found {
+		return 0, time.Time{}, false
+	}
+	return health, updated, true
+}
+
+// callRemove removes the siafile at the given siapath from the cache.
+func (shc *skylinkHealthCache) callRemove(siaPath skymodules.SiaPath) {
+	shc.mu.Lock()
+	defer shc.mu.Unlock()
+	shc.remove(siaPath)
+}
+
+// callUpdate updates the health of the siafile at the given siapath. Siafiles
+// without skylinks are not cached.
+func (shc *skylinkHealthCache) callUpdate(siaPath skymodules.SiaPath, skylinks []string, health float64) {
+	shc.mu.Lock()
+	defer shc.mu.Unlock()
+	shc.remove(siaPath)
+	if len(skylinks) == 0 {
+		return
+	}
+	shc.files[siaPath] = skylinkHealthCacheEntry{
+		skylinks: append([]string{}, skylinks...),
+		health:   health,
+		updated:  time.Now(),
+	}
+	for _, skylink := range skylinks {
+		siaPaths, exists := shc.skylinks[skylink]
+		if !exists {
+			siaPaths = make(map[skymodules.SiaPath]struct{})
+			shc.skylinks[skylink] = siaPaths
+		}
+		siaPaths[siaPath] = struct{}{}
+	}
+}
+
+// remove removes the siafile at the given siapath from the cache.
+func (shc *skylinkHealthCache) remove(siaPath skymodules.SiaPath) {
+	entry, exists := shc.files[siaPath]
+	if !exists {
+		return
+	}
+	delete(shc.files, siaPath)
+	for _, skylink := range entry.skylinks {
+		delete(shc.skylinks[skylink], siaPath)
+		if len(shc.skylinks[skylink]) == 0 {
+			delete(shc.skylinks, skylink)
+		}
+	}
+}
+
+// CachedSkylinkHealth returns the health of a skylink pinned by the node as
+// computed by the most recent run of the health loop. The returned bool is
+// false if the health of the skylink isn't known, e.g. because it isn't pinned
+// by the node.
+func (r *Renter) CachedSkylinkHealth(sl skymodules.Skylink) (skymodules.CachedSkylinkHealth, bool) {
+	health, updated, ok := r.staticSkylinkHealthCache.callHealth(sl.String())
+	if !ok {
+		return skymodules.CachedSkylinkHealth{}, false
+	}
+	return skymodules.CachedSkylinkHealth{
+		Health:      health,
+		LastUpdated: updated,
+	}, true
+}
@@ -0,0 +1,70 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+// TestSkylinkHealthCache is a unit test for the skylinkHealthCache.
+func TestSkylinkHealthCache(t *testing.T) {
+	t.Parallel()
+
+	shc := newSkylinkHealthCache()
+	base := skymodules.RandomSiaPath()
+	extended, err := base.AddSuffixStr(skymodules.ExtendedSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Unknown skylinks have no health.
+	if _, _, ok := shc.callHealth("a"); ok {
+		t.Fatal("health shouldn't be cached")
+	}
+
+	// Files without skylinks aren't cached.
+	shc.callUpdate(skymodules.RandomSiaPath(), nil, 1)
+	if len(shc.files) != 0 {
+		t.Fatal("file without skylinks was cached")
+	}
+
+	// The worst health of a skylink's files is its health.
+	shc.callUpdate(base, []string{"a", "b"}, 0.25)
+	shc.callUpdate(extended, []string{"a"}, 0.5)
+	if health, _, ok := shc.callHealth("a"); !ok || health != 0.5 {
+		t.Fatal("wrong health", ok, health)
+	}
+	if health, _, ok := shc.callHealth("b"); !ok || health != 0.25 {
+		t.Fatal("wrong health", ok, health)
+	}
+
+	// Updating a file replaces its health and skylinks.
+	shc.callUpdate(base, []string{"a"}, 0.75)
+	if health, _, ok := shc.callHealth("a"); !ok || health != 0.75 {
+		t.Fatal("wrong health", ok, health)
+	}
+	if _, _, ok := shc.callHealth("b"); ok {
+		t.Fatal("health shouldn't be cached")
+	}
+
+	// Stale entries are ignored.
+	shc.mu.Lock()
+	entry := shc.files[base]
+	entry.updated = time.Now().Add(-2 * skylinkHealthCacheTTL)
+	shc.files[base] = entry
+	shc.mu.Unlock()
+	if health, _, ok := shc.callHealth("a"); !ok || health != 0.5 {
+		t.Fatal("wrong health", ok, health)
+	}
+
+	// Removing the files removes the skylink.
+	shc.callRemove(base)
+	shc.callRemove(extended)
+	if _, _, ok := shc.callHealth("a"); ok {
+		t.Fatal("health shouldn't be cached")
+	}
+	if len(shc.files) != 0 || len(shc.skylinks) != 0 {
+		t.Fatal("cache should be empty", len(shc.files), len(shc.skylinks))
+	}
+}
@@ -0,0 +1,118 @@
+package renter
+
+import (
+	"context"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+type (
+	// hedgedDownloadFunc launches a download which doesn't use any of the
+	// workers of the hosts in excluded. It returns the channel the response is
+	// sent on and the hosts of the workers it launched.
+	hedgedDownloadFunc func(ctx context.Context, excluded map[string]struct{}) (chan *downloadResponse, map[string]struct{}, error)
+
+	// hedgedDownloadResult is the result of a hedgedDownload.
+	hedgedDownloadResult struct {
+		resp *downloadResponse
+
+		// hedged indicates whether a hedged download was launched and
+		// hedgeWon whether its response was used.
+		hedged   bool
+		hedgeWon bool
+	}
+)
+
+// managedBaseSectorHedgeDelay returns how long a base sector download is given
+// before a hedged download is launched. If hedging is disabled, false is
+// returned.
+func (r *Renter) managedBaseSectorHedgeDelay() (time.Duration, bool) {
+	id := r.mu.RLock()
+	settings := r.persist.BaseSectorHedging
+	r.mu.RUnlock(id)
+	if settings.Disabled {
+		return 0, false
+	}
+	return r.staticBaseSectorDownloadLatencyStats.Distribution(0).PStat(settings.PercentileOrDefault()), true
+}
+
+// hedgedDownload launches a download using the download func. If hedge is true
+// and the download doesn't return within the delay, or if it fails, a second
+// download is launched which doesn't use the initial workers of the first one.
+// The response of the download which succeeds first is returned and the other
+// download is cancelled. The downloads are expected to respond once the ctx is
+// closed.
+func hedgedDownload(ctx context.Context, delay time.Duration, hedge bool, download hedgedDownloadFunc) (hedgedDownloadResult, error) {
+	// Launch the primary download.
+	primaryCtx, primaryCancel := context.WithCancel(ctx)
+	defer primaryCancel()
+	primaryChan, launched, err := download(primaryCtx, nil)
+	if err != nil {
+		return hedgedDownloadResult{}, errors.AddContext(err, "unable to start download")
+	}
+
+	// Start the timer for the hedged download.
+	var hedgeTimer <-chan time.Time
+	if hedge {
+		t := time.NewTimer(delay)
+		defer t.Stop()
+		hedgeTimer = t.C
+	}
+
+	// launchHedge launches the hedged download in a separate goroutine since
+	// launching the initial workers might block until the worker set has
+	// resolved enough workers.
+	var result hedgedDownloadResult
+	var hedgeChan chan *downloadResponse
+	hedgeCtx, hedgeCancel := context.WithCancel(ctx)
+	defer hedgeCancel()
+	launchHedge := func() {
+		hedgeTimer = nil
+		hedgeChan = make(chan *downloadResponse, 1)
+		result.hedged = true
+		go func() {
+			respChan, _, err := download(hedgeCtx, launched)
+			if err != nil {
+				hedgeChan <- &downloadResponse{err: errors.AddContext(err, "unable to start hedged download")}
+				return
+			}
+			hedgeChan <- <-respChan
+		}()
+	}
+
+	// Wait for the first successful response.
+	var primaryErr, hedgeErr error
+	for {
+		select {
+		case <-hedgeTimer:
+			launchHedge()
+		case resp := <-primaryChan:
+			primaryChan = nil
+			if resp.err == nil {
+				result.resp = resp
+				return result, nil
+			}
+			primaryErr = resp.err
+
+			// Retry with a hedged download right away if there is none yet.
+			if hedge && !result.hedged {
+				launchHedge()
+			}
+		case resp := <-hedgeChan:
+			hedgeChan = nil
+			if resp.err == nil {
+				result.resp = resp
+				result.hedgeWon = true
+				return result, nil
+			}
+			hedgeErr = resp.err
+		}
+
+		// If both downloads are done, they failed.
+		if primaryChan == nil && hedgeChan == nil {
+			result.resp = &downloadResponse{err: errors.Compose(primaryErr, hedgeErr)}
+			return result, nil
+		}
+	}
+}
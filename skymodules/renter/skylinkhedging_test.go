@@ -0,0 +1,117 @@
+package renter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+// TestHedgedDownload is a unit test for hedgedDownload.
+func TestHedgedDownload(t *testing.T) {
+	t.Parallel()
+
+	errDownload := errors.New("download failed")
+
+	// mockDownload returns a download func which responds after the given
+	// durations with the given errors. The first download reports to have
+	// launched host "primary" and the second one host "hedge". If a download
+	// is cancelled before it responds, it fails with the ctx error. The
+	// excluded hosts of each download are recorded.
+	mockDownload := func(durations []time.Duration, errs []error, excludedHosts *[]map[string]struct{}) hedgedDownloadFunc {
+		var i int
+		return func(ctx context.Context, excluded map[string]struct{}) (chan *downloadResponse, map[string]struct{}, error) {
+			n := i
+			i++
+			*excludedHosts = append(*excludedHosts, excluded)
+			host := "primary"
+			if n > 0 {
+				host = "hedge"
+			}
+			respChan := make(chan *downloadResponse, 1)
+			go func() {
+				select {
+				case <-time.After(durations[n]):
+					respChan <- &downloadResponse{data: []byte(host), err: errs[n]}
+				case <-ctx.Done():
+					respChan <- &downloadResponse{err: ctx.Err()}
+				}
+			}()
+			return respChan, map[string]struct{}{host: {}}, nil
+		}
+	}
+
+	// A fast download isn't hedged.
+	var excluded []map[string]struct{}
+	download := mockDownload([]time.Duration{0}, []error{nil}, &excluded)
+	result, err := hedgedDownload(context.Background(), time.Second, true, download)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.hedged || result.hedgeWon || string(result.resp.data) != "primary" {
+		t.Fatal("unexpected result", result)
+	}
+
+	// A slow download is hedged and the hedge wins. It excludes the workers
+	// of the primary download.
+	excluded = nil
+	download = mockDownload([]time.Duration{time.Minute, 0}, []error{nil, nil}, &excluded)
+	result, err = hedgedDownload(context.Background(), 10*time.Millisecond, true, download)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.hedged || !result.hedgeWon || string(result.resp.data) != "hedge" {
+		t.Fatal("unexpected result", result)
+	}
+	if len(excluded) != 2 || excluded[0] != nil {
+		t.Fatal("unexpected exclusions", excluded)
+	}
+	if _, ok := excluded[1]["primary"]; !ok || len(excluded[1]) != 1 {
+		t.Fatal("hedge should exclude the primary workers", excluded[1])
+	}
+
+	// A slow download which still finishes before the hedge wins.
+	excluded = nil
+	download = mockDownload([]time.Duration{50 * time.Millisecond, time.Minute}, []error{nil, nil}, &excluded)
+	result, err = hedgedDownload(context.Background(), 10*time.Millisecond, true, download)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.hedged || result.hedgeWon || string(result.resp.data) != "primary" {
+		t.Fatal("unexpected result", result)
+	}
+
+	// A failed download is retried right away.
+	excluded = nil
+	download = mockDownload([]time.Duration{0, 0}, []error{errDownload, nil}, &excluded)
+	result, err = hedgedDownload(context.Background(), time.Minute, true, download)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.hedged || !result.hedgeWon || string(result.resp.data) != "hedge" {
+		t.Fatal("unexpected result", result)
+	}
+
+	// If both downloads fail, the errors are returned.
+	excluded = nil
+	download = mockDownload([]time.Duration{0, 0}, []error{errDownload, errDownload}, &excluded)
+	result, err = hedgedDownload(context.Background(), time.Minute, true, download)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.hedged || result.hedgeWon || !errors.Contains(result.resp.err, errDownload) {
+		t.Fatal("unexpected result", result)
+	}
+
+	// Without hedging, a failed download isn't retried.
+	excluded = nil
+	download = mockDownload([]time.Duration{0}, []error{errDownload}, &excluded)
+	result, err = hedgedDownload(context.Background(), 0, false, download)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.hedged || !errors.Contains(result.resp.err, errDownload) || len(excluded) != 1 {
+		t.Fatal("unexpected result", result)
+	}
+}
@@ -0,0 +1,277 @@
+package renter
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+// The skylink index records the skylinks uploaded by the node together with
+// the information needed to list and search them without fetching their
+// metadata from the network. An entry is added whenever a base sector is
+// uploaded and removed when the skylink is deleted.
+//
+// When listing skylinks, the index is merged with the skylinks of the
+// siafiles. Skylinks without a siafile are unpinned and skylinks which are
+// missing from the index, e.g. because they were uploaded before the index
+// existed, are described using their siafiles.
+
+const (
+	// skylinkIndexFilename is the name of the file which persists the skylink
+	// index.
+	skylinkIndexFilename = "skylinkindex.dat"
+)
+
+var (
+	// skylinkIndexMDHeader is the header of the metadata for the persist
+	// file.
+	skylinkIndexMDHeader = types.NewSpecifier("SkylinkIndex")
+)
+
+type (
+	// skylinkIndex is a persisted index of the skylinks uploaded by the node.
+	skylinkIndex struct {
+		skylinks map[string]skylinkIndexEntry
+
+		staticAop *persist.AppendOnlyPersist
+		mu        sync.Mutex
+	}
+
+	// skylinkIndexEntry is the definition of a persisted entry. An entry
+	// either adds a skylink to the index or removes it.
+	skylinkIndexEntry struct {
+		Skylink    string             `json:"skylink"`
+		SiaPath    skymodules.SiaPath `json:"siapath,omitempty"`
+		Filename   string             `json:"filename,omitempty"`
+		Size       uint64             `json:"size,omitempty"`
+		UploadTime time.Time          `json:"uploadtime,omitempty"`
+		Encrypted  bool               `json:"encrypted,omitempty"`
+		Remove     bool               `json:"remove,omitempty"`
+	}
+)
+
+// newSkylinkIndex creates a new skylink index or loads an existing one from
+// disk.
+func newSkylinkIndex(dir, filename string) (*skylinkIndex, error) {
+	aop, r, err := persist.NewAppendOnlyPersist(dir, filename, skylinkIndexMDHeader, persist.MetadataVersionv156)
+	if err != nil {
+		return nil, err
+	}
+	si := &skylinkIndex{
+		skylinks:  make(map[string]skylinkIndexEntry),
+		staticAop: aop,
+	}
+	decoder := json.NewDecoder(r)
+	for {
+		var entry skylinkIndexEntry
+		err := decoder.Decode(&entry)
+		if errors.Contains(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, errors.Compose(err, aop.Close())
+		}
+		si.applyEntry(entry)
+	}
+	return si, nil
+}
+
+// applyEntry applies a persisted entry to the in-memory index.
+func (si *skylinkIndex) applyEntry(entry skylinkIndexEntry) {
+	if entry.Remove {
+		delete(si.skylinks, entry.Skylink)
+		return
+	}
+	si.skylinks[entry.Skylink] = entry
+}
+
+// Close closes the underlying persistence.
+func (si *skylinkIndex) Close() error {
+	return si.staticAop.Close()
+}
+
+// callEntries returns a copy of the entries of the index.
+func (si *skylinkIndex) callEntries() map[string]skylinkIndexEntry {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	entries := make(map[string]skylinkIndexEntry, len(si.skylinks))
+	for skylink, entry := range si.skylinks {
+		entries[skylink] = entry
+	}
+	return entries
+}
+
+// managedPersistEntry persists an entry and applies it.
+func (si *skylinkIndex) managedPersistEntry(entry skylinkIndexEntry) error {
+	entryBytes, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	_, err = si.staticAop.Write(entryBytes)
+	if err != nil {
+		return err
+	}
+	si.applyEntry(entry)
+	return nil
+}
+
+// managedAdd adds a skylink to the index.
+func (si *skylinkIndex) managedAdd(skylink skymodules.Skylink, siaPath skymodules.SiaPath, filename string, size uint64, encrypted bool) error {
+	return si.managedPersistEntry(skylinkIndexEntry{
+		Skylink:    skylink.String(),
+		SiaPath:    siaPath,
+		Filename:   filename,
+		Size:       size,
+		UploadTime: time.Now(),
+		Encrypted:  encrypted,
+	})
+}
+
+// managedRemove removes a skylink from the index.
+func (si *skylinkIndex) managedRemove(skylink skymodules.Skylink) error {
+	si.mu.Lock()
+	_, exists := si.skylinks[skylink.String()]
+	si.mu.Unlock()
+	if !exists {
+		return nil
+	}
+	return si.managedPersistEntry(skylinkIndexEntry{
+		Skylink: skylink.String(),
+		Remove:  true,
+	})
+}
+
+// managedIndexBaseSector adds the skylink of an uploaded base sector to the
+// skylink index. Encrypted base sectors are decrypted with the renter's
+// skykeys to read their metadata. If the metadata can't be read, the skylink
+// is indexed with the name of its siafile instead.
+func (r *Renter) managedIndexBaseSector(skylink skymodules.Skylink, siaPath skymodules.SiaPath, baseSector []byte) error {
+	filename := siaPath.Name()
+	var size uint64
+	encrypted := skymodules.IsEncryptedBaseSector(baseSector)
+	bs := append([]byte(nil), baseSector...)
+	var err error
+	if encrypted {
+		_, err = r.managedDecryptBaseSector(bs)
+	}
+	if err == nil {
+		var sm skymodules.SkyfileMetadata
+		_, _, sm, _, _, err = skymodules.ParseSkyfileMetadata(bs)
+		if err == nil {
+			filename = sm.Filename
+			size = sm.Length
+		}
+	}
+	if err != nil {
+		r.staticLog.Debugf("failed to read metadata of %v for the skylink index: %v", skylink, err)
+	}
+	return r.staticSkylinkIndex.managedAdd(skylink, siaPath, filename, size, encrypted)
+}
+
+// Skylinks returns the skylinks known to the node which pass the filter
+// together with the total number of skylinks passing the filter. The
+// skylinks are sorted by upload time, newest first.
+func (r *Renter) Skylinks(filter skymodules.SkylinksFilter) ([]skymodules.SkylinkInfo, uint64, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, 0, err
+	}
+	defer r.tg.Done()
+
+	// Apply the default limit.
+	if filter.Limit == 0 {
+		filter.Limit = skymodules.DefaultSkylinksLimit
+	}
+	if filter.Limit > skymodules.MaxSkylinksLimit {
+		return nil, 0, skymodules.ErrSkylinksLimitTooHigh
+	}
+
+	// Collect the skylinks of the siafiles. The extended siafiles of large
+	// skyfiles share the skylink of the skyfile's siafile and only contribute
+	// to its size.
+	var mu sync.Mutex
+	pinned := make(map[string]skymodules.SkylinkInfo)
+	flf := func(fi skymodules.FileInfo) {
+		extended := strings.HasSuffix(fi.SiaPath.String(), skymodules.ExtendedSuffix)
+		mu.Lock()
+		defer mu.Unlock()
+		for _, skylink := range fi.Skylinks {
+			info, exists := pinned[skylink]
+			if !exists {
+				info = skymodules.SkylinkInfo{
+					Skylink:    skylink,
+					UploadTime: fi.CreateTime,
+					Pinned:     true,
+					Encrypted:  fi.CipherType != crypto.TypePlain.String(),
+				}
+			}
+			if !extended {
+				info.SiaPath = fi.SiaPath
+				info.Filename = fi.SiaPath.Name()
+			}
+			if fi.Filesize > info.Size {
+				info.Size = fi.Filesize
+			}
+			pinned[skylink] = info
+		}
+	}
+	err := r.staticFileSystem.CachedList(skymodules.RootSiaPath(), true, flf, func(skymodules.DirectoryInfo) {})
+	if err != nil {
+		return nil, 0, errors.AddContext(err, "failed to list files")
+	}
+
+	// Merge them with the index.
+	entries := r.staticSkylinkIndex.callEntries()
+	infos := make([]skymodules.SkylinkInfo, 0, len(entries)+len(pinned))
+	for skylink, entry := range entries {
+		info := skymodules.SkylinkInfo{
+			Skylink:    skylink,
+			SiaPath:    entry.SiaPath,
+			Filename:   entry.Filename,
+			Size:       entry.Size,
+			UploadTime: entry.UploadTime,
+			Encrypted:  entry.Encrypted,
+		}
+		if pinnedInfo, exists := pinned[skylink]; exists {
+			info.Pinned = true
+			if !pinnedInfo.SiaPath.IsRoot() {
+				info.SiaPath = pinnedInfo.SiaPath
+			}
+			delete(pinned, skylink)
+		}
+		if filter.Matches(info) {
+			infos = append(infos, info)
+		}
+	}
+	for _, info := range pinned {
+		if filter.Matches(info) {
+			infos = append(infos, info)
+		}
+	}
+
+	// Sort and paginate them.
+	sort.Slice(infos, func(i, j int) bool {
+		if !infos[i].UploadTime.Equal(infos[j].UploadTime) {
+			return infos[i].UploadTime.After(infos[j].UploadTime)
+		}
+		return infos[i].Skylink < infos[j].Skylink
+	})
+	total := uint64(len(infos))
+	if filter.Offset >= total {
+		return []skymodules.SkylinkInfo{}, total, nil
+	}
+	infos = infos[filter.Offset:]
+	if uint64(len(infos)) > filter.Limit {
+		infos = infos[:filter.Limit]
+	}
+	return infos, total, nil
+}
@@ -0,0 +1,214 @@
+package renter
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/crypto"
+)
+
+// randomSkylink returns a random V1 skylink.
+func randomSkylink(t *testing.T) skymodules.Skylink {
+	var root crypto.Hash
+	fastrand.Read(root[:])
+	skylink, err := skymodules.NewSkylinkV1(root, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return skylink
+}
+
+// TestSkylinkIndexPersist tests that the entries of the skylink index are
+// persisted.
+func TestSkylinkIndexPersist(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	testDir := build.TempDir("renter", t.Name())
+	fileName := "test"
+
+	si, err := newSkylinkIndex(testDir, fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add two skylinks and remove one of them again.
+	sl1, sl2 := randomSkylink(t), randomSkylink(t)
+	err = si.managedAdd(sl1, skymodules.RandomSiaPath(), "file1", 10, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = si.managedAdd(sl2, skymodules.RandomSiaPath(), "file2", 20, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := si.managedRemove(sl1); err != nil {
+		t.Fatal(err)
+	}
+	// Removing an unknown skylink is a no-op.
+	if err := si.managedRemove(randomSkylink(t)); err != nil {
+		t.Fatal(err)
+	}
+	entries := si.callEntries()
+
+	// Reload.
+	if err := si.Close(); err != nil {
+		t.Fatal(err)
+	}
+	si, err = newSkylinkIndex(testDir, fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded := si.callEntries()
+	if len(reloaded) != 1 || len(entries) != 1 {
+		t.Fatal("wrong number of entries", len(entries), len(reloaded))
+	}
+	entry, exists := reloaded[sl2.String()]
+	if !exists {
+		t.Fatal("skylink missing after reload")
+	}
+	expected := entries[sl2.String()]
+	if !entry.UploadTime.Equal(expected.UploadTime) || !entry.SiaPath.Equals(expected.SiaPath) {
+		t.Fatal("entry changed after reload", entry, expected)
+	}
+	entry.UploadTime = expected.UploadTime
+	if entry != expected {
+		t.Fatal("entry changed after reload", entry, expected)
+	}
+	if err := si.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestRenterSkylinks tests listing the skylinks of the renter.
+func TestRenterSkylinks(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// Create a plaintext siafile with an indexed skylink, an encrypted
+	// siafile with a skylink which is not indexed and index a skylink without
+	// a siafile.
+	addSiafile := func(skylink skymodules.Skylink, ct crypto.CipherType) skymodules.SiaPath {
+		siaPath, rsc := testingFileParams()
+		fileNode, err := r.createRenterTestFileWithParams(siaPath, rsc, ct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := fileNode.AddSkylink(skylink); err != nil {
+			t.Fatal(err)
+		}
+		if err := fileNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return siaPath
+	}
+	slPinned, slNotIndexed, slUnpinned := randomSkylink(t), randomSkylink(t), randomSkylink(t)
+	pinnedPath := addSiafile(slPinned, crypto.TypePlain)
+	notIndexedPath := addSiafile(slNotIndexed, crypto.TypeThreefish)
+	err = r.staticSkylinkIndex.managedAdd(slPinned, pinnedPath, "Pinned.txt", 10, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.staticSkylinkIndex.managedAdd(slUnpinned, skymodules.RandomSiaPath(), "unpinned.txt", 5000, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// list lists the skylinks and returns them by skylink.
+	list := func(filter skymodules.SkylinksFilter) (map[string]skymodules.SkylinkInfo, uint64) {
+		t.Helper()
+		infos, total, err := r.Skylinks(filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := make(map[string]skymodules.SkylinkInfo)
+		for _, info := range infos {
+			m[info.Skylink] = info
+		}
+		return m, total
+	}
+
+	// Without a filter all skylinks are listed.
+	infos, total := list(skymodules.SkylinksFilter{})
+	if total != 3 || len(infos) != 3 {
+		t.Fatal("wrong number of skylinks", total, len(infos))
+	}
+	if info := infos[slPinned.String()]; !info.Pinned || info.Encrypted || info.Filename != "Pinned.txt" || info.Size != 10 || !info.SiaPath.Equals(pinnedPath) {
+		t.Fatal("wrong info", info)
+	}
+	if info := infos[slNotIndexed.String()]; !info.Pinned || !info.Encrypted || info.Filename != notIndexedPath.Name() || !info.SiaPath.Equals(notIndexedPath) {
+		t.Fatal("wrong info", info)
+	}
+	if info := infos[slUnpinned.String()]; info.Pinned || !info.Encrypted || info.Filename != "unpinned.txt" {
+		t.Fatal("wrong info", info)
+	}
+
+	// Filter the skylinks.
+	f := false
+	tr := true
+	tests := []struct {
+		filter   skymodules.SkylinksFilter
+		skylinks []skymodules.Skylink
+	}{
+		{filter: skymodules.SkylinksFilter{Pinned: &f}, skylinks: []skymodules.Skylink{slUnpinned}},
+		{filter: skymodules.SkylinksFilter{Pinned: &tr}, skylinks: []skymodules.Skylink{slPinned, slNotIndexed}},
+		{filter: skymodules.SkylinksFilter{Encrypted: &f}, skylinks: []skymodules.Skylink{slPinned}},
+		{filter: skymodules.SkylinksFilter{Search: "PINNED"}, skylinks: []skymodules.Skylink{slPinned, slUnpinned}},
+		{filter: skymodules.SkylinksFilter{MinSize: 5000}, skylinks: []skymodules.Skylink{slUnpinned}},
+		{filter: skymodules.SkylinksFilter{MaxSize: 10, Encrypted: &f}, skylinks: []skymodules.Skylink{slPinned}},
+	}
+	for i, test := range tests {
+		infos, total := list(test.filter)
+		if total != uint64(len(test.skylinks)) || len(infos) != len(test.skylinks) {
+			t.Fatalf("%v: wrong number of skylinks %v %v", i, total, len(infos))
+		}
+		for _, skylink := range test.skylinks {
+			if _, exists := infos[skylink.String()]; !exists {
+				t.Fatalf("%v: missing skylink", i)
+			}
+		}
+	}
+
+	// Paginate the skylinks.
+	var paginated []skymodules.SkylinkInfo
+	for offset := uint64(0); offset < 4; offset++ {
+		infos, total, err := r.Skylinks(skymodules.SkylinksFilter{Offset: offset, Limit: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != 3 {
+			t.Fatal("wrong total", total)
+		}
+		paginated = append(paginated, infos...)
+	}
+	if len(paginated) != 3 {
+		t.Fatal("wrong number of skylinks", len(paginated))
+	}
+	for i := 1; i < len(paginated); i++ {
+		if paginated[i].UploadTime.After(paginated[i-1].UploadTime) {
+			t.Fatal("skylinks not sorted by upload time")
+		}
+	}
+	_, _, err = r.Skylinks(skymodules.SkylinksFilter{Limit: skymodules.MaxSkylinksLimit + 1})
+	if !errors.Contains(err, skymodules.ErrSkylinksLimitTooHigh) {
+		t.Fatal("wrong error", err)
+	}
+}
@@ -0,0 +1,123 @@
+package renter
+
+import (
+	"context"
+	"sort"
+
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// VerifySkylink asks the contracted hosts for every piece of every chunk of a
+// skyfile and returns a per chunk redundancy report. This allows for
+// confirming that an upload actually reached the redundancy its erasure
+// coding settings advertise.
+func (r *Renter) VerifySkylink(ctx context.Context, sl skymodules.Skylink, ppms types.Currency) (skymodules.SkylinkVerification, error) {
+	if err := r.tg.Add(); err != nil {
+		return skymodules.SkylinkVerification{}, err
+	}
+	defer r.tg.Done()
+
+	// Verifying a skylink requires admin access which is why restricted
+	// skylinks can be verified without a signature.
+	ctx = contextWithUnrestrictedSkylinkAccess(ctx)
+	return r.managedVerifySkylink(ctx, sl, ppms)
+}
+
+// managedVerifySkylink returns the redundancy report of a skylink.
+func (r *Renter) managedVerifySkylink(ctx context.Context, sl skymodules.Skylink, ppms types.Currency) (skymodules.SkylinkVerification, error) {
+	// Fetch the layout and the fanout of the skyfile.
+	sl, layout, fanoutChunks, _, err := r.managedSkyfileFanoutChunks(ctx, sl, ppms)
+	if err != nil {
+		return skymodules.SkylinkVerification{}, err
+	}
+
+	// Ask the hosts for the base sector and all the roots of the fanout.
+	roots := []crypto.Hash{sl.MerkleRoot()}
+	for _, chunk := range fanoutChunks {
+		roots = append(roots, chunk...)
+	}
+	numPieces := int(layout.FanoutDataPieces) + int(layout.FanoutParityPieces)
+	if numPieces == 0 {
+		numPieces = 1
+	}
+	rootHosts, err := r.managedHasSectorHosts(ctx, roots, numPieces)
+	if err != nil {
+		return skymodules.SkylinkVerification{}, err
+	}
+	return buildSkylinkVerification(sl, layout, fanoutChunks, rootHosts), nil
+}
+
+// buildSkylinkVerification creates the redundancy report of a skyfile from the
+// hosts which store the roots of the skyfile. The first root is expected to be
+// the base sector, followed by the roots of the fanout chunks.
+func buildSkylinkVerification(sl skymodules.Skylink, layout skymodules.SkyfileLayout, fanoutChunks [][]crypto.Hash, rootHosts [][]types.SiaPublicKey) skymodules.SkylinkVerification {
+	sv := skymodules.SkylinkVerification{
+		Skylink:            sl,
+		BaseSectorHosts:    sortedHosts(rootHosts[0]),
+		FanoutDataPieces:   layout.FanoutDataPieces,
+		FanoutParityPieces: layout.FanoutParityPieces,
+		Chunks:             make([]skymodules.ChunkVerification, 0, len(fanoutChunks)),
+	}
+	sv.Verified = len(sv.BaseSectorHosts) > 0
+
+	// If the fanout datapieces are 0, there is no fanout and we are done.
+	if layout.FanoutDataPieces == 0 {
+		return sv
+	}
+	numPieces := int(layout.FanoutDataPieces) + int(layout.FanoutParityPieces)
+	sv.AdvertisedRedundancy = float64(numPieces) / float64(layout.FanoutDataPieces)
+	sv.EffectiveRedundancy = sv.AdvertisedRedundancy
+
+	// Special Case: if the data of the file is using 1-of-N erasure coding,
+	// each piece is identical and the fanout only contains a single root per
+	// chunk. Every host storing that root counts as one piece.
+	onlyOnePiecePerChunk := layout.FanoutDataPieces == 1 && layout.CipherType == crypto.TypePlain
+
+	rootIndex := 1
+	for chunkIndex, chunk := range fanoutChunks {
+		cv := skymodules.ChunkVerification{
+			ChunkIndex: uint64(chunkIndex),
+			Pieces:     make([]skymodules.PieceVerification, 0, len(chunk)),
+		}
+		goodPieces := 0
+		for pieceIndex, root := range chunk {
+			hosts := sortedHosts(rootHosts[rootIndex])
+			rootIndex++
+			cv.Pieces = append(cv.Pieces, skymodules.PieceVerification{
+				PieceIndex: uint64(pieceIndex),
+				Root:       root,
+				Hosts:      hosts,
+			})
+			if onlyOnePiecePerChunk {
+				goodPieces += len(hosts)
+			} else if len(hosts) > 0 {
+				goodPieces++
+			} else {
+				cv.MissingPieces = append(cv.MissingPieces, uint64(pieceIndex))
+			}
+		}
+		if goodPieces > numPieces {
+			goodPieces = numPieces
+		}
+		cv.Redundancy = float64(goodPieces) / float64(layout.FanoutDataPieces)
+		if cv.Redundancy < sv.EffectiveRedundancy {
+			sv.EffectiveRedundancy = cv.Redundancy
+		}
+		if goodPieces < numPieces {
+			sv.Verified = false
+		}
+		sv.Chunks = append(sv.Chunks, cv)
+	}
+	return sv
+}
+
+// sortedHosts returns a sorted copy of the given hosts.
+func sortedHosts(hosts []types.SiaPublicKey) []types.SiaPublicKey {
+	sorted := append([]types.SiaPublicKey{}, hosts...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].String() < sorted[j].String()
+	})
+	return sorted
+}
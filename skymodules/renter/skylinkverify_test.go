@@ -0,0 +1,107 @@
+package renter
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// TestBuildSkylinkVerification is a unit test for buildSkylinkVerification.
+func TestBuildSkylinkVerification(t *testing.T) {
+	t.Parallel()
+
+	hk := func() types.SiaPublicKey {
+		return types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: fastrand.Bytes(32)}
+	}
+	hk1, hk2, hk3 := hk(), hk(), hk()
+	root := func() crypto.Hash {
+		var h crypto.Hash
+		fastrand.Read(h[:])
+		return h
+	}
+
+	// Create a 2-of-4 encrypted skyfile with 2 chunks.
+	layout := skymodules.SkyfileLayout{
+		FanoutDataPieces:   2,
+		FanoutParityPieces: 2,
+		CipherType:         crypto.TypeThreefish,
+	}
+	chunks := [][]crypto.Hash{
+		{root(), root(), root(), root()},
+		{root(), root(), root(), root()},
+	}
+
+	// The first chunk is fully available, the second one is missing pieces
+	// 1 and 3.
+	rootHosts := [][]types.SiaPublicKey{
+		{hk1, hk2},
+		{hk1}, {hk2}, {hk3}, {hk1, hk2},
+		{hk1}, nil, {hk3}, nil,
+	}
+	sv := buildSkylinkVerification(skymodules.Skylink{}, layout, chunks, rootHosts)
+	if len(sv.BaseSectorHosts) != 2 {
+		t.Fatal("wrong base sector hosts", sv.BaseSectorHosts)
+	}
+	if sv.AdvertisedRedundancy != 2 || sv.EffectiveRedundancy != 1 || sv.Verified {
+		t.Fatal("unexpected verification", sv.AdvertisedRedundancy, sv.EffectiveRedundancy, sv.Verified)
+	}
+	if len(sv.Chunks) != 2 {
+		t.Fatal("wrong number of chunks", len(sv.Chunks))
+	}
+	if cv := sv.Chunks[0]; cv.Redundancy != 2 || len(cv.MissingPieces) != 0 || len(cv.Pieces) != 4 {
+		t.Fatal("unexpected first chunk", cv)
+	}
+	cv := sv.Chunks[1]
+	if cv.ChunkIndex != 1 || cv.Redundancy != 1 {
+		t.Fatal("unexpected second chunk", cv)
+	}
+	if len(cv.MissingPieces) != 2 || cv.MissingPieces[0] != 1 || cv.MissingPieces[1] != 3 {
+		t.Fatal("wrong missing pieces", cv.MissingPieces)
+	}
+	if cv.Pieces[2].Root != chunks[1][2] || len(cv.Pieces[2].Hosts) != 1 || !cv.Pieces[2].Hosts[0].Equals(hk3) {
+		t.Fatal("unexpected piece", cv.Pieces[2])
+	}
+
+	// Once all pieces are available the skyfile is verified.
+	rootHosts[6] = []types.SiaPublicKey{hk2}
+	rootHosts[8] = []types.SiaPublicKey{hk3}
+	sv = buildSkylinkVerification(skymodules.Skylink{}, layout, chunks, rootHosts)
+	if !sv.Verified || sv.EffectiveRedundancy != 2 {
+		t.Fatal("skyfile should be verified", sv.EffectiveRedundancy)
+	}
+
+	// Without the base sector it isn't.
+	rootHosts[0] = nil
+	sv = buildSkylinkVerification(skymodules.Skylink{}, layout, chunks, rootHosts)
+	if sv.Verified {
+		t.Fatal("skyfile shouldn't be verified without base sector")
+	}
+
+	// For 1-of-N skyfiles every host storing the single root of a chunk
+	// counts as a piece.
+	layout = skymodules.SkyfileLayout{
+		FanoutDataPieces:   1,
+		FanoutParityPieces: 2,
+		CipherType:         crypto.TypePlain,
+	}
+	chunks = [][]crypto.Hash{{root()}}
+	rootHosts = [][]types.SiaPublicKey{{hk1}, {hk1, hk2}}
+	sv = buildSkylinkVerification(skymodules.Skylink{}, layout, chunks, rootHosts)
+	if sv.AdvertisedRedundancy != 3 || sv.EffectiveRedundancy != 2 || sv.Verified {
+		t.Fatal("unexpected verification", sv.AdvertisedRedundancy, sv.EffectiveRedundancy, sv.Verified)
+	}
+	rootHosts[1] = append(rootHosts[1], hk3)
+	sv = buildSkylinkVerification(skymodules.Skylink{}, layout, chunks, rootHosts)
+	if !sv.Verified || sv.EffectiveRedundancy != 3 {
+		t.Fatal("skyfile should be verified", sv.EffectiveRedundancy)
+	}
+
+	// Skyfiles without a fanout only need the base sector.
+	sv = buildSkylinkVerification(skymodules.Skylink{}, skymodules.SkyfileLayout{}, nil, [][]types.SiaPublicKey{{hk1}})
+	if !sv.Verified || len(sv.Chunks) != 0 || sv.AdvertisedRedundancy != 0 {
+		t.Fatal("unexpected verification", sv)
+	}
+}
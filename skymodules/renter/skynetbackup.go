@@ -0,0 +1,124 @@
+package renter
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skykey"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+type (
+	// skynetBackupMetadata is the metadata which follows the siafiles within
+	// the body of a skynet backup.
+	skynetBackupMetadata struct {
+		// Skykeys contains the renter's skykeys which are required to
+		// download encrypted skyfiles.
+		Skykeys []string `json:"skykeys"`
+	}
+)
+
+// CreateSkynetBackup creates an encrypted backup of the skynet folder and
+// uploads it as a skyfile. The backup contains the siafiles of the skynet
+// folder and the renter's skykeys which allows for restoring the skyfiles on
+// another node without reuploading them.
+func (r *Renter) CreateSkynetBackup(ctx context.Context, secret []byte) (_ skymodules.Skylink, err error) {
+	if err := r.tg.Add(); err != nil {
+		return skymodules.Skylink{}, err
+	}
+	defer r.tg.Done()
+
+	// Write the backup to a temporary file.
+	f, err := ioutil.TempFile(r.persistDir, "skynetbackup")
+	if err != nil {
+		return skymodules.Skylink{}, errors.AddContext(err, "failed to create temporary backup file")
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close(), os.Remove(f.Name()))
+	}()
+	if err := r.managedWriteSkynetBackup(f.Name(), secret); err != nil {
+		return skymodules.Skylink{}, errors.AddContext(err, "failed to create backup")
+	}
+
+	// Upload the backup.
+	filename := fmt.Sprintf("skynet-backup-%v", time.Now().Unix())
+	siaPath, err := skymodules.SkynetBackupFolder.Join(filename)
+	if err != nil {
+		return skymodules.Skylink{}, err
+	}
+	sup := skymodules.SkyfileUploadParameters{
+		SiaPath:  siaPath,
+		Filename: filename,
+		Force:    true,
+	}
+	return r.UploadSkyfile(ctx, sup, skymodules.NewSkyfileReader(f, sup))
+}
+
+// managedWriteSkynetBackup writes a backup of the skynet folder and the
+// renter's skykeys to dst.
+func (r *Renter) managedWriteSkynetBackup(dst string, secret []byte) error {
+	// Collect the skykeys.
+	var md skynetBackupMetadata
+	for _, sk := range r.staticSkykeyManager.Skykeys() {
+		skStr, err := sk.ToString()
+		if err != nil {
+			return errors.AddContext(err, "failed to encode skykey")
+		}
+		md.Skykeys = append(md.Skykeys, skStr)
+	}
+	return writeBackup(dst, secret, func(gzw io.Writer) error {
+		tw := tar.NewWriter(gzw)
+		if err := r.managedTarSiaFiles(tw, skymodules.SkynetFolder); err != nil {
+			return errors.Compose(err, tw.Close())
+		}
+		// Close tar writer to flush it before writing the metadata.
+		if err := tw.Close(); err != nil {
+			return err
+		}
+		return json.NewEncoder(gzw).Encode(md)
+	})
+}
+
+// RestoreSkynetBackup restores the skynet folder and the skykeys from a backup
+// created by CreateSkynetBackup. Skyfiles and skykeys which exist already are
+// not overwritten.
+func (r *Renter) RestoreSkynetBackup(src io.ReadSeeker, secret []byte) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return readBackup(src, secret, func(gzr io.Reader) error {
+		// Untar the siafiles.
+		tr := tar.NewReader(gzr)
+		if err := r.managedUntarDir(tr, skymodules.SkynetFolder); err != nil {
+			return errors.AddContext(err, "failed to untar dir")
+		}
+		// Import the skykeys.
+		var md skynetBackupMetadata
+		if err := json.NewDecoder(gzr).Decode(&md); err != nil {
+			return errors.AddContext(err, "failed to decode backup metadata")
+		}
+		for _, skStr := range md.Skykeys {
+			var sk skykey.Skykey
+			if err := sk.FromString(skStr); err != nil {
+				return errors.AddContext(err, "failed to decode skykey")
+			}
+			err := r.staticSkykeyManager.AddKey(sk)
+			if errors.Contains(err, skykey.ErrSkykeyWithIDAlreadyExists) || errors.Contains(err, skykey.ErrSkykeyWithNameAlreadyExists) {
+				r.staticLog.Printf("skipping skykey %v from skynet backup: %v", sk.Name, err)
+				continue
+			}
+			if err != nil {
+				return errors.AddContext(err, "failed to add skykey")
+			}
+		}
+		return nil
+	})
+}
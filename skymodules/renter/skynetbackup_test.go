@@ -0,0 +1,106 @@
+package renter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/SkynetLabs/skyd/skykey"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/crypto"
+)
+
+// TestSkynetBackup tests creating a backup of the skynet folder and restoring
+// it on another renter.
+func TestSkynetBackup(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create a skyfile, a siafile outside of the skynet folder and a skykey.
+	skyfilePath, err := skymodules.SkynetFolder.Join("foo/bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	userFilePath, err := skymodules.UserFolder.Join("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, siaPath := range []skymodules.SiaPath{skyfilePath, userFilePath} {
+		rsc, _ := skymodules.NewRSCode(1, 1)
+		f, err := rt.renter.createRenterTestFileWithParamsAndSize(siaPath, rsc, crypto.TypePlain, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sk, err := rt.renter.CreateSkykey("key", skykey.TypePrivateID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Write the backup.
+	secret := fastrand.Bytes(32)
+	backupPath := filepath.Join(rt.dir, "skynetbackup")
+	if err := rt.renter.managedWriteSkynetBackup(backupPath, secret); err != nil {
+		t.Fatal(err)
+	}
+
+	// Restore it on a new renter.
+	rt2, err := newRenterTester(t.Name() + "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt2.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	f, err := os.Open(backupPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if err := rt2.renter.RestoreSkynetBackup(f, fastrand.Bytes(32)); err == nil {
+		t.Fatal("restoring with the wrong secret should fail")
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt2.renter.RestoreSkynetBackup(f, secret); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the skyfile should be restored.
+	if exists, err := rt2.renter.staticFileSystem.FileExists(skyfilePath); err != nil || !exists {
+		t.Fatal("skyfile wasn't restored", exists, err)
+	}
+	if exists, err := rt2.renter.staticFileSystem.FileExists(userFilePath); err != nil || exists {
+		t.Fatal("siafile outside of the skynet folder was restored", exists, err)
+	}
+	restored, err := rt2.renter.SkykeyByName("key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.ID() != sk.ID() {
+		t.Fatal("wrong skykey restored")
+	}
+}
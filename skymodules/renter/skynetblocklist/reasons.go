@@ -0,0 +1,145 @@
+package skynetblocklist
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// reasonsPersistFile is the name of the file which persists the reasons
+	// why hashes were added to the blocklist.
+	reasonsPersistFile string = "skynetblocklistreasons.dat"
+
+	// maxReasonLength is the max length of a persisted reason. Longer reasons
+	// are truncated.
+	maxReasonLength = 256
+)
+
+var (
+	// reasonsMetadataHeader is the header of the metadata for the reasons
+	// persist file
+	reasonsMetadataHeader = types.NewSpecifier("BlocklistReasons")
+
+	// reasonsMetadataVersion is the version of the reasons persist file
+	reasonsMetadataVersion = types.NewSpecifier("v1.0.0\n")
+)
+
+// reasonEntry contains a blocked hash and the reason why it was blocked. An
+// empty reason removes the hash's reason.
+type reasonEntry struct {
+	Hash   crypto.Hash
+	Reason string
+}
+
+// BlockWithReason adds the given hashes to the blocklist and records the
+// reason why they were blocked.
+func (sb *SkynetBlocklist) BlockWithReason(hashes []crypto.Hash, reason string) error {
+	if reason == "" {
+		return errors.New("reason can't be empty")
+	}
+	if len(reason) > maxReasonLength {
+		reason = reason[:maxReasonLength]
+	}
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	// Record the reasons first, a reason without a blocked hash is ignored.
+	var entries []reasonEntry
+	for _, hash := range hashes {
+		entries = append(entries, reasonEntry{Hash: hash, Reason: reason})
+	}
+	err := sb.persistReasons(entries)
+	if err != nil {
+		return err
+	}
+
+	buf, err := sb.marshalObjects(hashes, nil)
+	if err != nil {
+		return errors.AddContext(err, fmt.Sprintf("unable to update skynet blocklist persistence at '%v'", sb.staticAop.FilePath()))
+	}
+	_, err = sb.staticAop.Write(buf.Bytes())
+	return errors.AddContext(err, fmt.Sprintf("unable to update skynet blocklist persistence at '%v'", sb.staticAop.FilePath()))
+}
+
+// Reasons returns the reasons why hashes were added to the blocklist. Hashes
+// which were blocked without a reason are not included.
+func (sb *SkynetBlocklist) Reasons() map[crypto.Hash]string {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	reasons := make(map[crypto.Hash]string)
+	for hash, reason := range sb.reasons {
+		if _, blocked := sb.hashes[hash]; blocked {
+			reasons[hash] = reason
+		}
+	}
+	return reasons
+}
+
+// persistReasons applies the given reason entries and persists them.
+func (sb *SkynetBlocklist) persistReasons(entries []reasonEntry) error {
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		if sb.reasons[entry.Hash] == entry.Reason {
+			continue
+		}
+		if entry.Reason == "" {
+			delete(sb.reasons, entry.Hash)
+		} else {
+			sb.reasons[entry.Hash] = entry.Reason
+		}
+		_, err := buf.Write(encoding.Marshal(entry))
+		if err != nil {
+			return errors.AddContext(err, "unable to write reason to the buffer")
+		}
+	}
+	if buf.Len() == 0 {
+		return nil
+	}
+	_, err := sb.staticReasonsAop.Write(buf.Bytes())
+	return errors.AddContext(err, fmt.Sprintf("unable to update skynet blocklist reasons persistence at '%v'", sb.staticReasonsAop.FilePath()))
+}
+
+// loadReasons loads the persisted reasons from the given directory.
+func loadReasons(persistDir string) (*persist.AppendOnlyPersist, map[crypto.Hash]string, error) {
+	aop, reader, err := persist.NewAppendOnlyPersist(persistDir, reasonsPersistFile, reasonsMetadataHeader, reasonsMetadataVersion)
+	if err != nil {
+		return nil, nil, errors.AddContext(err, "unable to initialize the skynet blocklist reasons persistence")
+	}
+	reasons, err := unmarshalReasons(reader)
+	if err != nil {
+		return nil, nil, errors.Compose(errors.AddContext(err, "unable to unmarshal reasons"), aop.Close())
+	}
+	return aop, reasons, nil
+}
+
+// unmarshalReasons unmarshals the sia encoded reason entries.
+func unmarshalReasons(reader io.Reader) (map[crypto.Hash]string, error) {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	reasons := make(map[crypto.Hash]string)
+	r := bytes.NewReader(data)
+	dec := encoding.NewDecoder(r, encoding.DefaultAllocLimit)
+	for r.Len() > 0 {
+		var entry reasonEntry
+		err := dec.Decode(&entry)
+		if err != nil {
+			return nil, err
+		}
+		if entry.Reason == "" {
+			delete(reasons, entry.Hash)
+			continue
+		}
+		reasons[entry.Hash] = entry.Reason
+	}
+	return reasons, nil
+}
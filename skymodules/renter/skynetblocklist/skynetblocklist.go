@@ -36,11 +36,15 @@ type (
 	// SkynetBlocklist manages a set of blocked skylinks by tracking the
 	// merkleroots and persists the list to disk.
 	SkynetBlocklist struct {
-		staticAop *persist.AppendOnlyPersist
+		staticAop        *persist.AppendOnlyPersist
+		staticReasonsAop *persist.AppendOnlyPersist
 
 		// hashes is a set of hashed blocked merkleroots.
 		hashes map[crypto.Hash]struct{}
 
+		// reasons contains the reasons why hashes were blocked if known.
+		reasons map[crypto.Hash]string
+
 		mu sync.Mutex
 	}
 
@@ -70,6 +74,14 @@ func New(persistDir string) (*SkynetBlocklist, error) {
 	}
 	sb.hashes = hashes
 
+	// Load the reasons of the blocked hashes.
+	reasonsAop, reasons, err := loadReasons(persistDir)
+	if err != nil {
+		return nil, errors.Compose(err, aop.Close())
+	}
+	sb.staticReasonsAop = reasonsAop
+	sb.reasons = reasons
+
 	return sb, nil
 }
 
@@ -87,7 +99,7 @@ func (sb *SkynetBlocklist) Blocklist() []crypto.Hash {
 
 // Close closes and frees associated resources.
 func (sb *SkynetBlocklist) Close() error {
-	return sb.staticAop.Close()
+	return errors.Compose(sb.staticAop.Close(), sb.staticReasonsAop.Close())
 }
 
 // IsBlocked indicates if a skylink is currently blocked
@@ -118,7 +130,16 @@ func (sb *SkynetBlocklist) UpdateBlocklist(additions, removals []crypto.Hash) er
 		return errors.AddContext(err, fmt.Sprintf("unable to update skynet blocklist persistence at '%v'", sb.staticAop.FilePath()))
 	}
 	_, err = sb.staticAop.Write(buf.Bytes())
-	return errors.AddContext(err, fmt.Sprintf("unable to update skynet blocklist persistence at '%v'", sb.staticAop.FilePath()))
+	if err != nil {
+		return errors.AddContext(err, fmt.Sprintf("unable to update skynet blocklist persistence at '%v'", sb.staticAop.FilePath()))
+	}
+
+	// Forget the reasons of removed hashes.
+	var entries []reasonEntry
+	for _, hash := range removals {
+		entries = append(entries, reasonEntry{Hash: hash})
+	}
+	return sb.persistReasons(entries)
 }
 
 // marshalObjects marshals the given objects into a byte buffer.
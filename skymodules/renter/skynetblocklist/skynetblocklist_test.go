@@ -376,3 +376,70 @@ func TestMarshalSia(t *testing.T) {
 		t.Fatal("merkleroot not found in blocklist")
 	}
 }
+
+// TestBlockWithReason tests that the reasons of blocked hashes are persisted
+// and forgotten once the hashes are removed from the blocklist.
+func TestBlockWithReason(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	testdir := testDir(t.Name())
+	sb, err := New(testdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Block two hashes with a reason and one without.
+	var hash1, hash2, hash3 crypto.Hash
+	fastrand.Read(hash1[:])
+	fastrand.Read(hash2[:])
+	fastrand.Read(hash3[:])
+	err = sb.BlockWithReason([]crypto.Hash{hash1, hash2}, "malware")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sb.UpdateBlocklist([]crypto.Hash{hash3}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sb.BlockWithReason([]crypto.Hash{hash3}, ""); err == nil {
+		t.Fatal("blocking without a reason should fail")
+	}
+	if !sb.IsHashBlocked(hash1) || !sb.IsHashBlocked(hash2) {
+		t.Fatal("hashes should be blocked")
+	}
+
+	// Remove one of the hashes and reload the blocklist.
+	err = sb.UpdateBlocklist(nil, []crypto.Hash{hash2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sb.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sb, err = New(testdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := sb.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	reasons := sb.Reasons()
+	if len(reasons) != 1 || reasons[hash1] != "malware" {
+		t.Fatal("unexpected reasons", reasons)
+	}
+
+	// Blocking the removed hash again without a reason shouldn't restore its
+	// old reason.
+	err = sb.UpdateBlocklist([]crypto.Hash{hash2}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := sb.Reasons()[hash2]; exists {
+		t.Fatal("reason of removed hash shouldn't be restored")
+	}
+}
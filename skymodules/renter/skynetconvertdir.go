@@ -0,0 +1,370 @@
+package renter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"gitlab.com/SkynetLabs/skyd/skymodules/renter/filesystem"
+	"go.sia.tech/siad/persist"
+)
+
+const (
+	// skynetConvertDirsDir is the name of the directory within the renter's
+	// persist directory which contains the directory conversion jobs.
+	skynetConvertDirsDir = "skynetconvertdirs"
+
+	// skynetConvertDirJobExtension is the extension of the files which
+	// contain the persisted conversion jobs.
+	skynetConvertDirJobExtension = ".json"
+)
+
+var (
+	// skynetConvertDirPersistInterval is the number of processed files after
+	// which the progress of a conversion job is persisted.
+	skynetConvertDirPersistInterval = build.Select(build.Var{
+		Dev:      uint64(20),
+		Standard: uint64(100),
+		Testing:  uint64(2),
+	}).(uint64)
+
+	// skynetConvertDirMetadata is the metadata used when persisting a
+	// directory conversion job.
+	skynetConvertDirMetadata = persist.Metadata{
+		Header:  "Skynet Convert Dir Job",
+		Version: "1.5.7",
+	}
+)
+
+type (
+	// skynetDirConverter manages the directory conversion jobs of the renter.
+	// Every job is persisted in its own file which allows unfinished jobs to
+	// resume after a restart of the renter.
+	skynetDirConverter struct {
+		jobs map[string]*skynetConvertDirJob
+
+		staticDir    string
+		staticRenter *Renter
+		mu           sync.Mutex
+	}
+
+	// skynetConvertDirJob is a single directory conversion job.
+	skynetConvertDirJob struct {
+		job skymodules.SkynetConvertDirJob
+
+		staticPath string
+		mu         sync.Mutex
+	}
+)
+
+// newSkynetDirConverter creates a new converter and loads the persisted jobs
+// from disk.
+func newSkynetDirConverter(r *Renter, dir string) (*skynetDirConverter, error) {
+	sdc := &skynetDirConverter{
+		jobs:         make(map[string]*skynetConvertDirJob),
+		staticDir:    dir,
+		staticRenter: r,
+	}
+	err := os.MkdirAll(dir, skymodules.DefaultDirPerm)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to create directory conversion dir")
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to read directory conversion dir")
+	}
+	for _, info := range infos {
+		if info.IsDir() || !strings.HasSuffix(info.Name(), skynetConvertDirJobExtension) {
+			continue
+		}
+		j := &skynetConvertDirJob{
+			staticPath: filepath.Join(dir, info.Name()),
+		}
+		err := persist.LoadJSON(skynetConvertDirMetadata, &j.job, j.staticPath)
+		if err != nil {
+			r.staticLog.Printf("WARN: dropping corrupted directory conversion job %v: %v", info.Name(), err)
+			if err := os.Remove(j.staticPath); err != nil {
+				return nil, errors.AddContext(err, "failed to remove directory conversion job")
+			}
+			continue
+		}
+		if j.job.Files == nil {
+			j.job.Files = make(map[string]skymodules.SkynetConvertDirFile)
+		}
+		sdc.jobs[j.job.ID] = j
+	}
+	return sdc, nil
+}
+
+// copyJob returns a deep copy of the job's state. The caller must hold the
+// job's lock.
+func (j *skynetConvertDirJob) copyJob() skymodules.SkynetConvertDirJob {
+	job := j.job
+	job.Files = make(map[string]skymodules.SkynetConvertDirFile, len(j.job.Files))
+	for siaPath, file := range j.job.Files {
+		job.Files[siaPath] = file
+	}
+	return job
+}
+
+// saveSync persists the job. The caller must hold the job's lock.
+func (j *skynetConvertDirJob) saveSync() error {
+	return persist.SaveJSON(skynetConvertDirMetadata, j.job, j.staticPath)
+}
+
+// managedJob returns the job with the given id.
+func (sdc *skynetDirConverter) managedJob(id string) (*skynetConvertDirJob, error) {
+	sdc.mu.Lock()
+	defer sdc.mu.Unlock()
+	j, exists := sdc.jobs[id]
+	if !exists {
+		return nil, skymodules.ErrConvertDirJobNotFound
+	}
+	return j, nil
+}
+
+// threadedResumeJobs resumes all jobs which were still running when the renter
+// was shut down.
+func (sdc *skynetDirConverter) threadedResumeJobs() {
+	sdc.mu.Lock()
+	var toResume []*skynetConvertDirJob
+	for _, j := range sdc.jobs {
+		j.mu.Lock()
+		running := j.job.Status == skymodules.SkynetConvertDirStatusRunning
+		j.mu.Unlock()
+		if running {
+			toResume = append(toResume, j)
+		}
+	}
+	sdc.mu.Unlock()
+
+	for _, j := range toResume {
+		job := j
+		err := sdc.staticRenter.tg.Launch(func() {
+			sdc.threadedConvert(job)
+		})
+		if err != nil {
+			return // renter is shutting down
+		}
+	}
+}
+
+// threadedConvert converts all siafiles of the job which haven't been
+// processed yet. If the renter shuts down before all files were processed, the
+// job remains running and is resumed on the next startup.
+func (sdc *skynetDirConverter) threadedConvert(j *skynetConvertDirJob) {
+	r := sdc.staticRenter
+	j.mu.Lock()
+	job := j.copyJob()
+	j.mu.Unlock()
+
+	// fail marks the job as failed.
+	fail := func(err error) {
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		j.job.Status = skymodules.SkynetConvertDirStatusFailed
+		j.job.Error = err.Error()
+		j.job.LastUpdate = time.Now()
+		if err := j.saveSync(); err != nil {
+			r.staticLog.Printf("failed to persist directory conversion job %v: %v", job.ID, err)
+		}
+	}
+
+	// Collect the files of the directory.
+	var mu sync.Mutex
+	var files []skymodules.FileInfo
+	flf := func(fi skymodules.FileInfo) {
+		mu.Lock()
+		files = append(files, fi)
+		mu.Unlock()
+	}
+	err := r.staticFileSystem.CachedList(job.SiaPath, job.Recursive, flf, func(skymodules.DirectoryInfo) {})
+	if err != nil {
+		fail(errors.AddContext(err, "failed to list directory"))
+		return
+	}
+	sort.Slice(files, func(i, k int) bool {
+		return files[i].SiaPath.String() < files[k].SiaPath.String()
+	})
+
+	// Queue the files which haven't been processed yet.
+	var toConvert []skymodules.FileInfo
+	for _, fi := range files {
+		if _, processed := job.Files[fi.SiaPath.String()]; !processed {
+			toConvert = append(toConvert, fi)
+		}
+	}
+	j.mu.Lock()
+	j.job.Total = uint64(len(files))
+	j.job.LastUpdate = time.Now()
+	err = j.saveSync()
+	j.mu.Unlock()
+	if err != nil {
+		r.staticLog.Printf("failed to persist directory conversion job %v: %v", job.ID, err)
+	}
+
+	// Convert the files using a limited number of workers.
+	fileChan := make(chan skymodules.FileInfo)
+	var wg sync.WaitGroup
+	for i := 0; i < job.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for fi := range fileChan {
+				sdc.managedConvertFile(j, fi)
+			}
+		}()
+	}
+	stopped := false
+LOOP:
+	for _, fi := range toConvert {
+		select {
+		case <-r.tg.StopChan():
+			stopped = true
+			break LOOP
+		case fileChan <- fi:
+		}
+	}
+	close(fileChan)
+	wg.Wait()
+
+	// Persist the final state. A job which was interrupted by a shutdown
+	// remains running.
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if !stopped {
+		j.job.Status = skymodules.SkynetConvertDirStatusComplete
+	}
+	j.job.LastUpdate = time.Now()
+	if err := j.saveSync(); err != nil {
+		r.staticLog.Printf("failed to persist directory conversion job %v: %v", job.ID, err)
+	}
+}
+
+// managedConvertFile converts a single siafile of a job and records the
+// result.
+func (sdc *skynetDirConverter) managedConvertFile(j *skynetConvertDirJob, fi skymodules.FileInfo) {
+	r := sdc.staticRenter
+	j.mu.Lock()
+	srcDir, destDir := j.job.SiaPath, j.job.DestSiaPath
+	j.mu.Unlock()
+
+	var file skymodules.SkynetConvertDirFile
+	skipped := len(fi.Skylinks) > 0
+	if skipped {
+		file.Skylink = fi.Skylinks[0]
+	} else {
+		skylink, err := func() (skymodules.Skylink, error) {
+			destSiaPath, err := fi.SiaPath.Rebase(srcDir, destDir)
+			if err != nil {
+				return skymodules.Skylink{}, err
+			}
+			sup := skymodules.SkyfileUploadParameters{
+				SiaPath: destSiaPath,
+			}
+			return r.CreateSkylinkFromSiafile(sup, fi.SiaPath)
+		}()
+		if err != nil {
+			file.Error = err.Error()
+		} else {
+			file.Skylink = skylink.String()
+		}
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	switch {
+	case skipped:
+		j.job.Skipped++
+	case file.Error != "":
+		j.job.Failed++
+	default:
+		j.job.Converted++
+	}
+	j.job.Files[fi.SiaPath.String()] = file
+	j.job.LastUpdate = time.Now()
+	if j.job.Processed()%skynetConvertDirPersistInterval == 0 {
+		if err := j.saveSync(); err != nil {
+			r.staticLog.Printf("failed to persist directory conversion job %v: %v", j.job.ID, err)
+		}
+	}
+}
+
+// SkynetConvertDir starts a background job which converts all siafiles within
+// the job's SiaPath to skyfiles. The progress of the job can be retrieved
+// using SkynetConvertDirJob.
+func (r *Renter) SkynetConvertDir(job skymodules.SkynetConvertDirJob) (skymodules.SkynetConvertDirJob, error) {
+	if err := r.tg.Add(); err != nil {
+		return skymodules.SkynetConvertDirJob{}, err
+	}
+	defer r.tg.Done()
+	sdc := r.staticSkynetDirConverter
+
+	// Validate the job.
+	if job.Concurrency == 0 {
+		job.Concurrency = skymodules.DefaultSkynetConvertDirConcurrency
+	}
+	if job.Concurrency < 0 || job.Concurrency > skymodules.MaxSkynetConvertDirConcurrency {
+		return skymodules.SkynetConvertDirJob{}, errors.New("invalid concurrency")
+	}
+	if job.DestSiaPath.IsEmpty() {
+		job.DestSiaPath = skymodules.RandomSkynetFilePath()
+	}
+	exists, err := r.staticFileSystem.DirExists(job.SiaPath)
+	if err != nil {
+		return skymodules.SkynetConvertDirJob{}, errors.AddContext(err, "failed to check for directory")
+	}
+	if !exists {
+		return skymodules.SkynetConvertDirJob{}, errors.AddContext(filesystem.ErrNotExist, "unable to convert directory")
+	}
+
+	job.ID = persist.UID()
+	job.Status = skymodules.SkynetConvertDirStatusRunning
+	job.Error = ""
+	job.Total, job.Converted, job.Failed, job.Skipped = 0, 0, 0, 0
+	job.Files = make(map[string]skymodules.SkynetConvertDirFile)
+	job.CreatedAt = time.Now()
+	job.LastUpdate = job.CreatedAt
+
+	j := &skynetConvertDirJob{
+		job:        job,
+		staticPath: filepath.Join(sdc.staticDir, job.ID+skynetConvertDirJobExtension),
+	}
+	if err := j.saveSync(); err != nil {
+		return skymodules.SkynetConvertDirJob{}, errors.AddContext(err, "failed to persist directory conversion job")
+	}
+	job = j.copyJob()
+	sdc.mu.Lock()
+	sdc.jobs[job.ID] = j
+	sdc.mu.Unlock()
+
+	err = r.tg.Launch(func() {
+		sdc.threadedConvert(j)
+	})
+	if err != nil {
+		return skymodules.SkynetConvertDirJob{}, err
+	}
+	return job, nil
+}
+
+// SkynetConvertDirJob returns the directory conversion job with the given id.
+func (r *Renter) SkynetConvertDirJob(id string) (skymodules.SkynetConvertDirJob, error) {
+	if err := r.tg.Add(); err != nil {
+		return skymodules.SkynetConvertDirJob{}, err
+	}
+	defer r.tg.Done()
+	j, err := r.staticSkynetDirConverter.managedJob(id)
+	if err != nil {
+		return skymodules.SkynetConvertDirJob{}, err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.copyJob(), nil
+}
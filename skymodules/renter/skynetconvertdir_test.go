@@ -0,0 +1,88 @@
+package renter
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+// TestSkynetConvertDirPersistence tests that the progress of directory
+// conversion jobs survives a restart.
+func TestSkynetConvertDirPersistence(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	r := &Renter{}
+	sdc, err := newSkynetDirConverter(r, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.staticSkynetDirConverter = sdc
+
+	// Unknown jobs can't be fetched.
+	if _, err := r.SkynetConvertDirJob("foo"); !errors.Contains(err, skymodules.ErrConvertDirJobNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// Add a job manually.
+	j := &skynetConvertDirJob{
+		job: skymodules.SkynetConvertDirJob{
+			ID:          "job",
+			SiaPath:     skymodules.UserFolder,
+			DestSiaPath: skymodules.SkynetFolder,
+			Concurrency: 1,
+			Status:      skymodules.SkynetConvertDirStatusRunning,
+			Total:       3,
+			Files:       make(map[string]skymodules.SkynetConvertDirFile),
+			CreatedAt:   time.Now(),
+		},
+		staticPath: filepath.Join(dir, "job"+skynetConvertDirJobExtension),
+	}
+	sdc.jobs[j.job.ID] = j
+
+	// Process two files which are skyfiles already. The progress is persisted
+	// after every second file.
+	for _, name := range []string{"a", "b"} {
+		siaPath, err := skymodules.UserFolder.Join(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sdc.managedConvertFile(j, skymodules.FileInfo{
+			SiaPath:  siaPath,
+			Skylinks: []string{name},
+		})
+	}
+	job, err := r.SkynetConvertDirJob(j.job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Skipped != 2 || job.Processed() != 2 || len(job.Files) != 2 {
+		t.Fatal("unexpected job", job)
+	}
+
+	// Reload the converter.
+	r = &Renter{}
+	sdc, err = newSkynetDirConverter(r, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.staticSkynetDirConverter = sdc
+	reloaded, err := r.SkynetConvertDirJob(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Status != skymodules.SkynetConvertDirStatusRunning || reloaded.Skipped != 2 || reloaded.Total != 3 {
+		t.Fatal("unexpected job", reloaded)
+	}
+	a := reloaded.Files[skymodules.UserFolder.String()+"/a"]
+	if a.Skylink != "a" || a.Error != "" {
+		t.Fatal("unexpected file", a)
+	}
+}
@@ -0,0 +1,327 @@
+package renter
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"gitlab.com/SkynetLabs/skyd/skymodules/renter/filesystem"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/persist"
+)
+
+const (
+	// skynetDeletesDir is the name of the directory within the renter's
+	// persist directory which contains the skylink deletion jobs.
+	skynetDeletesDir = "skynetdeletes"
+
+	// skynetDeleteJobExtension is the extension of the files which contain
+	// the persisted deletion jobs.
+	skynetDeleteJobExtension = ".json"
+)
+
+var (
+	// skynetDeleteMetadata is the metadata used when persisting a skylink
+	// deletion job.
+	skynetDeleteMetadata = persist.Metadata{
+		Header:  "Skynet Delete Job",
+		Version: "1.5.9",
+	}
+)
+
+type (
+	// skynetDeleter manages the skylink deletion jobs of the renter. Every job
+	// is persisted in its own file which allows unfinished jobs to resume
+	// after a restart of the renter. The persisted jobs also act as the
+	// tombstones of the deleted skylinks.
+	skynetDeleter struct {
+		jobs map[string]*skynetDeleteJob
+
+		// tombstones contains the hashes of the merkle roots of all deleted
+		// skylinks. They are computed the same way as the blocklist hashes.
+		tombstones map[crypto.Hash]struct{}
+
+		staticDir    string
+		staticRenter *Renter
+		mu           sync.Mutex
+	}
+
+	// skynetDeleteJob is a single skylink deletion job.
+	skynetDeleteJob struct {
+		job skymodules.SkynetDeleteJob
+
+		staticPath string
+		mu         sync.Mutex
+	}
+)
+
+// newSkynetDeleter creates a new deleter and loads the persisted jobs from
+// disk.
+func newSkynetDeleter(r *Renter, dir string) (*skynetDeleter, error) {
+	sd := &skynetDeleter{
+		jobs:         make(map[string]*skynetDeleteJob),
+		tombstones:   make(map[crypto.Hash]struct{}),
+		staticDir:    dir,
+		staticRenter: r,
+	}
+	err := os.MkdirAll(dir, skymodules.DefaultDirPerm)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to create skylink deletion dir")
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to read skylink deletion dir")
+	}
+	for _, info := range infos {
+		if info.IsDir() || !strings.HasSuffix(info.Name(), skynetDeleteJobExtension) {
+			continue
+		}
+		j := &skynetDeleteJob{
+			staticPath: filepath.Join(dir, info.Name()),
+		}
+		// Corrupted jobs are kept on disk since they might be the only
+		// tombstone of a skylink.
+		var sl skymodules.Skylink
+		err := persist.LoadJSON(skynetDeleteMetadata, &j.job, j.staticPath)
+		if err == nil {
+			err = sl.LoadString(j.job.Skylink)
+		}
+		if err != nil {
+			r.staticLog.Printf("WARN: ignoring corrupted skylink deletion job %v: %v", info.Name(), err)
+			continue
+		}
+		sd.jobs[j.job.ID] = j
+		sd.tombstones[crypto.HashObject(sl.MerkleRoot())] = struct{}{}
+	}
+	return sd, nil
+}
+
+// copyJob returns a deep copy of the job's state. The caller must hold the
+// job's lock.
+func (j *skynetDeleteJob) copyJob() skymodules.SkynetDeleteJob {
+	job := j.job
+	job.DeletedFiles = append([]skymodules.SiaPath{}, j.job.DeletedFiles...)
+	return job
+}
+
+// saveSync persists the job. The caller must hold the job's lock.
+func (j *skynetDeleteJob) saveSync() error {
+	return persist.SaveJSON(skynetDeleteMetadata, j.job, j.staticPath)
+}
+
+// managedIsTombstoned returns whether the skylink with the given blocklist
+// hash was deleted.
+func (sd *skynetDeleter) managedIsTombstoned(hash crypto.Hash) bool {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	_, deleted := sd.tombstones[hash]
+	return deleted
+}
+
+// managedJob returns the job with the given id.
+func (sd *skynetDeleter) managedJob(id string) (*skynetDeleteJob, error) {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	j, exists := sd.jobs[id]
+	if !exists {
+		return nil, skymodules.ErrSkynetDeleteJobNotFound
+	}
+	return j, nil
+}
+
+// threadedResumeJobs resumes all jobs which were still running when the renter
+// was shut down.
+func (sd *skynetDeleter) threadedResumeJobs() {
+	sd.mu.Lock()
+	var toResume []*skynetDeleteJob
+	for _, j := range sd.jobs {
+		j.mu.Lock()
+		running := j.job.Status == skymodules.SkynetDeleteStatusRunning
+		j.mu.Unlock()
+		if running {
+			toResume = append(toResume, j)
+		}
+	}
+	sd.mu.Unlock()
+
+	for _, j := range toResume {
+		job := j
+		err := sd.staticRenter.tg.Launch(func() {
+			sd.threadedDelete(job)
+		})
+		if err != nil {
+			return // renter is shutting down
+		}
+	}
+}
+
+// threadedDelete removes all siafiles of the job's skylink and purges the
+// skylink's cached data. Deleting is idempotent which is why an interrupted
+// job simply starts over on the next startup.
+func (sd *skynetDeleter) threadedDelete(j *skynetDeleteJob) {
+	r := sd.staticRenter
+	j.mu.Lock()
+	job := j.copyJob()
+	j.mu.Unlock()
+
+	var sl skymodules.Skylink
+	err := sl.LoadString(job.Skylink)
+	if err == nil {
+		// Purge the cached base sector.
+		if sc := r.staticSectorCache; sc != nil {
+			sc.managedRemove(sl.MerkleRoot())
+		}
+		if ruc := r.staticRecentUploadCache; ruc != nil {
+			ruc.managedRemove(sl.MerkleRoot())
+		}
+		// Remove the siafiles.
+		var deleted []skymodules.SiaPath
+		deleted, err = r.managedDeleteSkylinkFiles(job.Skylink)
+		if err == nil {
+			// Free the skylink's bytes of the API tokens it was pinned
+			// with.
+			err = errors.AddContext(r.staticSkynetTokens.Unpin(sl), "failed to update api token usage")
+		}
+		j.mu.Lock()
+		j.job.DeletedFiles = append(j.job.DeletedFiles, deleted...)
+		j.mu.Unlock()
+	}
+
+	// Persist the final state. A job which was interrupted by a shutdown
+	// remains running.
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	select {
+	case <-r.tg.StopChan():
+	default:
+		if err != nil {
+			j.job.Status = skymodules.SkynetDeleteStatusFailed
+			j.job.Error = err.Error()
+		} else {
+			j.job.Status = skymodules.SkynetDeleteStatusComplete
+		}
+	}
+	j.job.LastUpdate = time.Now()
+	if err := j.saveSync(); err != nil {
+		r.staticLog.Printf("failed to persist skylink deletion job %v: %v", job.ID, err)
+	}
+}
+
+// managedDeleteSkylinkFiles removes all siafiles which contain the given
+// skylink, including the extended siafiles of large skyfiles.
+//
+// NOTE: Since the SiaPath is not stored in the SkyfileLayout or the
+// SkyfileMetadata, we have to iterate over the entire filesystem to find the
+// siafiles.
+func (r *Renter) managedDeleteSkylinkFiles(skylink string) ([]skymodules.SiaPath, error) {
+	var mu sync.Mutex
+	var toDelete []skymodules.SiaPath
+	flf := func(fi skymodules.FileInfo) {
+		for _, sl := range fi.Skylinks {
+			if sl == skylink {
+				mu.Lock()
+				toDelete = append(toDelete, fi.SiaPath)
+				mu.Unlock()
+				return
+			}
+		}
+	}
+	err := r.staticFileSystem.CachedList(skymodules.RootSiaPath(), true, flf, func(skymodules.DirectoryInfo) {})
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to list files")
+	}
+
+	var deleted []skymodules.SiaPath
+	var errs error
+	for _, siaPath := range toDelete {
+		err := r.DeleteFile(siaPath)
+		if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+			errs = errors.Compose(errs, errors.AddContext(err, "failed to delete "+siaPath.String()))
+			continue
+		}
+		deleted = append(deleted, siaPath)
+	}
+	return deleted, errs
+}
+
+// managedIsDeleted returns whether or not a skylink was deleted. This method
+// can be used for both V1 and V2 skylinks.
+func (r *Renter) managedIsDeleted(ctx context.Context, sl skymodules.Skylink) (bool, error) {
+	hash, err := r.managedBlocklistHash(ctx, sl)
+	if err != nil {
+		return false, errors.AddContext(err, "unable to get blocklist hash")
+	}
+	return r.staticSkynetDeleter.managedIsTombstoned(hash), nil
+}
+
+// DeleteSkylink deletes a skylink from the renter. The skylink is tombstoned
+// right away which prevents it from being pinned or served again. Its
+// siafiles are removed and its cached data is purged in the background. The
+// progress of the job can be retrieved using SkynetDeleteJob.
+func (r *Renter) DeleteSkylink(ctx context.Context, skylink skymodules.Skylink) (skymodules.SkynetDeleteJob, error) {
+	if err := r.tg.Add(); err != nil {
+		return skymodules.SkynetDeleteJob{}, err
+	}
+	defer r.tg.Done()
+	sd := r.staticSkynetDeleter
+
+	// Resolve the skylink to the V1 skylink that is deleted.
+	skylink, _, err := r.managedTryResolveSkylinkV2(ctx, skylink, false)
+	if err != nil {
+		return skymodules.SkynetDeleteJob{}, errors.AddContext(err, "failed to resolve skylink")
+	}
+
+	// Remove the skylink from the skylink index.
+	if err := r.staticSkylinkIndex.managedRemove(skylink); err != nil {
+		return skymodules.SkynetDeleteJob{}, errors.AddContext(err, "failed to remove skylink from skylink index")
+	}
+
+	now := time.Now()
+	j := &skynetDeleteJob{
+		job: skymodules.SkynetDeleteJob{
+			ID:         persist.UID(),
+			Skylink:    skylink.String(),
+			Status:     skymodules.SkynetDeleteStatusRunning,
+			CreatedAt:  now,
+			LastUpdate: now,
+		},
+	}
+	j.staticPath = filepath.Join(sd.staticDir, j.job.ID+skynetDeleteJobExtension)
+	if err := j.saveSync(); err != nil {
+		return skymodules.SkynetDeleteJob{}, errors.AddContext(err, "failed to persist skylink deletion job")
+	}
+	job := j.copyJob()
+	sd.mu.Lock()
+	sd.jobs[job.ID] = j
+	sd.tombstones[crypto.HashObject(skylink.MerkleRoot())] = struct{}{}
+	sd.mu.Unlock()
+
+	err = r.tg.Launch(func() {
+		sd.threadedDelete(j)
+	})
+	if err != nil {
+		return skymodules.SkynetDeleteJob{}, err
+	}
+	return job, nil
+}
+
+// SkynetDeleteJob returns the skylink deletion job with the given id.
+func (r *Renter) SkynetDeleteJob(id string) (skymodules.SkynetDeleteJob, error) {
+	if err := r.tg.Add(); err != nil {
+		return skymodules.SkynetDeleteJob{}, err
+	}
+	defer r.tg.Done()
+	j, err := r.staticSkynetDeleter.managedJob(id)
+	if err != nil {
+		return skymodules.SkynetDeleteJob{}, err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.copyJob(), nil
+}
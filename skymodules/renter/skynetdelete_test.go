@@ -0,0 +1,78 @@
+package renter
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/crypto"
+)
+
+// TestSkynetDeletePersistence tests that skylink deletion jobs and their
+// tombstones survive a restart.
+func TestSkynetDeletePersistence(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	r := &Renter{}
+	sd, err := newSkynetDeleter(r, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.staticSkynetDeleter = sd
+
+	// Unknown jobs can't be fetched.
+	if _, err := r.SkynetDeleteJob("foo"); !errors.Contains(err, skymodules.ErrSkynetDeleteJobNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// Persist a job manually.
+	sl, err := skymodules.NewSkylinkV1(crypto.Hash{1}, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j := &skynetDeleteJob{
+		job: skymodules.SkynetDeleteJob{
+			ID:        "job",
+			Skylink:   sl.String(),
+			Status:    skymodules.SkynetDeleteStatusRunning,
+			CreatedAt: time.Now(),
+		},
+		staticPath: filepath.Join(dir, "job"+skynetDeleteJobExtension),
+	}
+	if err := j.saveSync(); err != nil {
+		t.Fatal(err)
+	}
+	hash := crypto.HashObject(sl.MerkleRoot())
+	if sd.managedIsTombstoned(hash) {
+		t.Fatal("skylink shouldn't be tombstoned yet")
+	}
+
+	// Reload the deleter. The job should be loaded and its skylink should be
+	// tombstoned.
+	r = &Renter{}
+	sd, err = newSkynetDeleter(r, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.staticSkynetDeleter = sd
+	job, err := r.SkynetDeleteJob(j.job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Skylink != sl.String() || job.Status != skymodules.SkynetDeleteStatusRunning {
+		t.Fatal("unexpected job", job)
+	}
+	if !sd.managedIsTombstoned(hash) {
+		t.Fatal("skylink should be tombstoned")
+	}
+	if sd.managedIsTombstoned(crypto.HashObject(crypto.Hash{2})) {
+		t.Fatal("other skylinks shouldn't be tombstoned")
+	}
+}
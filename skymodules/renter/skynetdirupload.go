@@ -0,0 +1,515 @@
+package renter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/persist"
+)
+
+const (
+	// skynetDirUploadsDir is the name of the directory within the renter's
+	// persist directory which contains the directory upload sessions.
+	skynetDirUploadsDir = "skynetdiruploads"
+
+	// skynetDirUploadSessionFile is the name of the file which contains the
+	// persisted session within the session's directory.
+	skynetDirUploadSessionFile = "session.json"
+
+	// skynetDirUploadTmpSuffix is the suffix of files which are still being
+	// written to a session's directory.
+	skynetDirUploadTmpSuffix = ".tmp"
+)
+
+var (
+	// skynetDirUploadSessionTimeout is the time of inactivity after which a
+	// directory upload session is pruned.
+	skynetDirUploadSessionTimeout = build.Select(build.Var{
+		Dev:      time.Hour,
+		Standard: 24 * time.Hour,
+		Testing:  time.Minute,
+	}).(time.Duration)
+
+	// skynetDirUploadPruneInterval is the interval at which expired
+	// directory upload sessions are pruned.
+	skynetDirUploadPruneInterval = build.Select(build.Var{
+		Dev:      10 * time.Minute,
+		Standard: time.Hour,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// skynetDirUploadMetadata is the metadata used when persisting a directory
+	// upload session.
+	skynetDirUploadMetadata = persist.Metadata{
+		Header:  "Skynet Dir Upload Session",
+		Version: "1.5.7",
+	}
+)
+
+type (
+	// skynetDirUploader manages the directory upload sessions of the renter.
+	// Every session is persisted in its own directory together with the data
+	// of the files that were uploaded to it, which allows sessions to survive
+	// a restart of the renter.
+	skynetDirUploader struct {
+		sessions map[string]*skynetDirUploadSession
+
+		staticDir    string
+		staticRenter *Renter
+		mu           sync.Mutex
+	}
+
+	// skynetDirUploadSession is a single directory upload session.
+	skynetDirUploadSession struct {
+		finalizing bool
+		session    skymodules.SkynetDirUploadSession
+
+		staticDir string
+		mu        sync.Mutex
+	}
+)
+
+// newSkynetDirUploader creates a new uploader and loads the persisted sessions
+// from disk.
+func newSkynetDirUploader(r *Renter, dir string) (*skynetDirUploader, error) {
+	sdu := &skynetDirUploader{
+		sessions:     make(map[string]*skynetDirUploadSession),
+		staticDir:    dir,
+		staticRenter: r,
+	}
+	err := os.MkdirAll(dir, skymodules.DefaultDirPerm)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to create directory upload dir")
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to read directory upload dir")
+	}
+	for _, info := range infos {
+		if !info.IsDir() {
+			continue
+		}
+		sessionDir := filepath.Join(dir, info.Name())
+		s, err := loadSkynetDirUploadSession(sessionDir)
+		if err != nil || time.Since(s.session.LastUpdate) > skynetDirUploadSessionTimeout {
+			// Drop sessions which are corrupted or expired.
+			if err := os.RemoveAll(sessionDir); err != nil {
+				return nil, errors.AddContext(err, "failed to remove directory upload session")
+			}
+			continue
+		}
+		sdu.sessions[s.session.ID] = s
+	}
+	return sdu, nil
+}
+
+// loadSkynetDirUploadSession loads a persisted session from the given directory
+// and removes any files which were not completely written before the session
+// was persisted.
+func loadSkynetDirUploadSession(dir string) (*skynetDirUploadSession, error) {
+	s := &skynetDirUploadSession{
+		staticDir: dir,
+	}
+	err := persist.LoadJSON(skynetDirUploadMetadata, &s.session, filepath.Join(dir, skynetDirUploadSessionFile))
+	if err != nil {
+		return nil, err
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		if strings.HasSuffix(info.Name(), skynetDirUploadTmpSuffix) {
+			if err := os.Remove(filepath.Join(dir, info.Name())); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return s, nil
+}
+
+// dataPath returns the path of the file which contains the data of the
+// session's file with the given name.
+func (s *skynetDirUploadSession) dataPath(filename string) string {
+	return filepath.Join(s.staticDir, crypto.HashBytes([]byte(filename)).String())
+}
+
+// copySession returns a deep copy of the session's state. The caller must hold
+// the session's lock.
+func (s *skynetDirUploadSession) copySession() skymodules.SkynetDirUploadSession {
+	session := s.session
+	session.Files = make(map[string]skymodules.SkynetDirUploadFile, len(s.session.Files))
+	for filename, file := range s.session.Files {
+		session.Files[filename] = file
+	}
+	return session
+}
+
+// saveSync persists the session.
+func (s *skynetDirUploadSession) saveSync() error {
+	return persist.SaveJSON(skynetDirUploadMetadata, s.session, filepath.Join(s.staticDir, skynetDirUploadSessionFile))
+}
+
+// managedSession returns the session with the given id.
+func (sdu *skynetDirUploader) managedSession(id string) (*skynetDirUploadSession, error) {
+	sdu.mu.Lock()
+	defer sdu.mu.Unlock()
+	s, exists := sdu.sessions[id]
+	if !exists {
+		return nil, skymodules.ErrDirUploadSessionNotFound
+	}
+	return s, nil
+}
+
+// managedPruneSessions removes all sessions which haven't been updated within
+// the session timeout.
+func (sdu *skynetDirUploader) managedPruneSessions() {
+	sdu.mu.Lock()
+	var toPrune []*skynetDirUploadSession
+	for id, s := range sdu.sessions {
+		s.mu.Lock()
+		expired := !s.finalizing && time.Since(s.session.LastUpdate) > skynetDirUploadSessionTimeout
+		s.mu.Unlock()
+		if expired {
+			toPrune = append(toPrune, s)
+			delete(sdu.sessions, id)
+		}
+	}
+	sdu.mu.Unlock()
+
+	for _, s := range toPrune {
+		if err := os.RemoveAll(s.staticDir); err != nil {
+			sdu.staticRenter.staticLog.Printf("failed to prune directory upload session %v: %v", s.session.ID, err)
+		}
+	}
+}
+
+// dirUploadSize returns the combined size of the files of the session except
+// for the file with the given name.
+func dirUploadSize(session skymodules.SkynetDirUploadSession, except string) uint64 {
+	var size uint64
+	for name, f := range session.Files {
+		if name != except {
+			size += f.Len
+		}
+	}
+	return size
+}
+
+// checkDirUploadLimits checks the files of a directory upload session against
+// the given skyfile upload limits. The size of the file with the given name is
+// not known yet and therefore not checked. The size of the metadata is only
+// known once the session is finalized.
+func checkDirUploadLimits(limits skymodules.SkyfileUploadLimits, session skymodules.SkynetDirUploadSession, uploading string) error {
+	if err := limits.CheckUploadSize(dirUploadSize(session, uploading)); err != nil {
+		return err
+	}
+	if limits.MaxSubfiles > 0 && uint64(len(session.Files)) > limits.MaxSubfiles {
+		return errors.AddContext(skymodules.ErrSkyfileTooManySubfiles, fmt.Sprintf("%v > %v subfiles", len(session.Files), limits.MaxSubfiles))
+	}
+	return nil
+}
+
+// SkynetDirUploadCreate creates a new directory upload session. If files are
+// provided, they act as a manifest for the session and only the files within
+// the manifest are accepted by the session.
+func (r *Renter) SkynetDirUploadCreate(session skymodules.SkynetDirUploadSession, manifest []skymodules.SkynetDirUploadFile) (skymodules.SkynetDirUploadSession, error) {
+	if err := r.tg.Add(); err != nil {
+		return skymodules.SkynetDirUploadSession{}, err
+	}
+	defer r.tg.Done()
+	sdu := r.staticSkynetDirUploader
+
+	// Opportunistically prune expired sessions.
+	sdu.managedPruneSessions()
+
+	// Validate the manifest.
+	session.Files = make(map[string]skymodules.SkynetDirUploadFile, len(manifest))
+	for _, f := range manifest {
+		if err := skymodules.ValidatePathString(f.Filename, false); err != nil {
+			return skymodules.SkynetDirUploadSession{}, errors.AddContext(err, "invalid filename in manifest")
+		}
+		if _, exists := session.Files[f.Filename]; exists {
+			return skymodules.SkynetDirUploadSession{}, errors.New("manifest contains duplicate filename " + f.Filename)
+		}
+		f.Complete = false
+		session.Files[f.Filename] = f
+	}
+	session.Manifest = len(manifest) > 0
+	if session.Manifest {
+		if err := checkDirUploadLimits(r.SkyfileUploadLimits(), session, ""); err != nil {
+			return skymodules.SkynetDirUploadSession{}, err
+		}
+	}
+	session.ID = persist.UID()
+	session.CreatedAt = time.Now()
+	session.LastUpdate = session.CreatedAt
+	if session.SiaPath.IsEmpty() {
+		session.SiaPath = skymodules.RandomSkynetFilePath()
+	}
+
+	s := &skynetDirUploadSession{
+		session:   session,
+		staticDir: filepath.Join(sdu.staticDir, session.ID),
+	}
+	err := os.MkdirAll(s.staticDir, skymodules.DefaultDirPerm)
+	if err != nil {
+		return skymodules.SkynetDirUploadSession{}, errors.AddContext(err, "failed to create session dir")
+	}
+	err = s.saveSync()
+	if err != nil {
+		return skymodules.SkynetDirUploadSession{}, errors.Compose(errors.AddContext(err, "failed to persist session"), os.RemoveAll(s.staticDir))
+	}
+
+	session = s.copySession()
+	sdu.mu.Lock()
+	sdu.sessions[session.ID] = s
+	sdu.mu.Unlock()
+	return session, nil
+}
+
+// SkynetDirUploadSession returns the directory upload session with the given
+// id.
+func (r *Renter) SkynetDirUploadSession(id string) (skymodules.SkynetDirUploadSession, error) {
+	if err := r.tg.Add(); err != nil {
+		return skymodules.SkynetDirUploadSession{}, err
+	}
+	defer r.tg.Done()
+	s, err := r.staticSkynetDirUploader.managedSession(id)
+	if err != nil {
+		return skymodules.SkynetDirUploadSession{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.copySession(), nil
+}
+
+// SkynetDirUploadAddFile adds a file to the directory upload session with the
+// given id. Files can be added to the same session in parallel. Uploading a
+// file with the same name twice overwrites the previous upload.
+func (r *Renter) SkynetDirUploadAddFile(id string, file skymodules.SkynetDirUploadFile, reader io.Reader) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	s, err := r.staticSkynetDirUploader.managedSession(id)
+	if err != nil {
+		return err
+	}
+	if err := skymodules.ValidatePathString(file.Filename, false); err != nil {
+		return errors.AddContext(err, "invalid filename")
+	}
+
+	// Check the file against the manifest.
+	s.mu.Lock()
+	expected, inManifest := s.session.Files[file.Filename]
+	finalizing := s.finalizing
+	manifest := s.session.Manifest
+	s.mu.Unlock()
+	if finalizing {
+		return skymodules.ErrDirUploadSessionFinalizing
+	}
+	if manifest && !inManifest {
+		return skymodules.ErrDirUploadFileNotInManifest
+	}
+	if manifest {
+		// Fill in the fields that weren't specified with the upload.
+		if file.ContentType == "" {
+			file.ContentType = expected.ContentType
+		}
+		if file.Mode == 0 {
+			file.Mode = expected.Mode
+		}
+	}
+
+	// Check the upload limits before accepting the file. The file may not be
+	// larger than what's left of the max upload size after the session's
+	// other files.
+	limits := r.SkyfileUploadLimits()
+	s.mu.Lock()
+	session := s.copySession()
+	s.mu.Unlock()
+	if _, exists := session.Files[file.Filename]; !exists {
+		session.Files[file.Filename] = file
+	}
+	err = checkDirUploadLimits(limits, session, file.Filename)
+	if err != nil {
+		return err
+	}
+	if limits.MaxUploadSize > 0 {
+		reader = io.LimitReader(reader, int64(limits.MaxUploadSize-dirUploadSize(session, file.Filename))+1)
+	}
+
+	// Write the data to a temporary file first to allow for parallel uploads
+	// of the same file without corrupting an already uploaded file.
+	dataPath := s.dataPath(file.Filename)
+	tmpPath := dataPath + "_" + persist.UID() + skynetDirUploadTmpSuffix
+	f, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_EXCL, skymodules.DefaultFilePerm)
+	if err != nil {
+		return errors.AddContext(err, "failed to create file")
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		if rmErr := os.Remove(tmpPath); rmErr != nil && !os.IsNotExist(rmErr) {
+			err = errors.Compose(err, rmErr)
+		}
+	}()
+	// Keep the beginning of the file around to sniff its content type.
+	sniff := make([]byte, skymodules.ContentTypeSniffLen)
+	sn, err := io.ReadFull(reader, sniff)
+	if err != nil && !errors.Contains(err, io.EOF) && !errors.Contains(err, io.ErrUnexpectedEOF) {
+		return errors.Compose(errors.AddContext(err, "failed to read file"), f.Close())
+	}
+	sniff = sniff[:sn]
+	n, err := io.Copy(f, io.MultiReader(bytes.NewReader(sniff), reader))
+	if err != nil {
+		return errors.Compose(errors.AddContext(err, "failed to write file"), f.Close())
+	}
+	file.ContentType = skymodules.SniffContentType(file.ContentType, sniff)
+	err = errors.Compose(f.Sync(), f.Close())
+	if err != nil {
+		return errors.AddContext(err, "failed to sync file")
+	}
+	if err := limits.CheckUploadSize(dirUploadSize(session, file.Filename) + uint64(n)); err != nil {
+		return err
+	}
+	if manifest && uint64(n) != expected.Len {
+		return errors.New("uploaded file length doesn't match manifest")
+	}
+	file.Len = uint64(n)
+	file.Complete = true
+
+	// Move the file into place and update the session.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.finalizing {
+		return skymodules.ErrDirUploadSessionFinalizing
+	}
+	err = os.Rename(tmpPath, dataPath)
+	if err != nil {
+		return errors.AddContext(err, "failed to rename file")
+	}
+	s.session.Files[file.Filename] = file
+	s.session.LastUpdate = time.Now()
+	return s.saveSync()
+}
+
+// SkynetDirUploadFinalize uploads the files of the directory upload session
+// with the given id as a single skyfile. Upon success, the session is removed.
+func (r *Renter) SkynetDirUploadFinalize(ctx context.Context, id string) (skymodules.Skylink, error) {
+	if err := r.tg.Add(); err != nil {
+		return skymodules.Skylink{}, err
+	}
+	defer r.tg.Done()
+	sdu := r.staticSkynetDirUploader
+	s, err := sdu.managedSession(id)
+	if err != nil {
+		return skymodules.Skylink{}, err
+	}
+
+	// Mark the session as finalizing to prevent further changes.
+	s.mu.Lock()
+	if s.finalizing {
+		s.mu.Unlock()
+		return skymodules.Skylink{}, skymodules.ErrDirUploadSessionFinalizing
+	}
+	session := s.copySession()
+	md, err := session.Metadata()
+	if err != nil {
+		s.mu.Unlock()
+		return skymodules.Skylink{}, err
+	}
+	if md.Filename == "" {
+		md.Filename = session.SiaPath.Name()
+	}
+	s.finalizing = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.finalizing = false
+		s.mu.Unlock()
+	}()
+
+	// Open the files in the order of the metadata's layout.
+	var readers []io.Reader
+	for _, file := range session.SortedFiles() {
+		f, err := os.Open(s.dataPath(file.Filename))
+		if err != nil {
+			return skymodules.Skylink{}, errors.AddContext(err, "failed to open file "+file.Filename)
+		}
+		defer func() {
+			if err := f.Close(); err != nil {
+				r.staticLog.Printf("failed to close file of directory upload session %v: %v", id, err)
+			}
+		}()
+		readers = append(readers, f)
+	}
+
+	sup := skymodules.SkyfileUploadParameters{
+		SiaPath:             session.SiaPath,
+		Force:               session.Force,
+		BaseChunkRedundancy: session.BaseChunkRedundancy,
+		Filename:            md.Filename,
+		DefaultPath:         session.DefaultPath,
+		DisableDefaultPath:  session.DisableDefaultPath,
+		TryFiles:            session.TryFiles,
+		ErrorPages:          session.ErrorPages,
+	}
+	ulr := skymodules.NewSkyfileUploadLimitReader(skymodules.NewSkyfileReaderWithMetadata(io.MultiReader(readers...), md), r.SkyfileUploadLimits())
+	skylink, err := r.UploadSkyfile(ctx, sup, ulr)
+	if ulr.Err() != nil {
+		return skymodules.Skylink{}, ulr.Err()
+	}
+	if err != nil {
+		return skymodules.Skylink{}, err
+	}
+
+	// The upload succeeded, remove the session.
+	sdu.mu.Lock()
+	delete(sdu.sessions, id)
+	sdu.mu.Unlock()
+	if err := os.RemoveAll(s.staticDir); err != nil {
+		r.staticLog.Printf("failed to remove finalized directory upload session %v: %v", id, err)
+	}
+	return skylink, nil
+}
+
+// SkynetDirUploadAbort aborts the directory upload session with the given id
+// and removes all of its uploaded files.
+func (r *Renter) SkynetDirUploadAbort(id string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	sdu := r.staticSkynetDirUploader
+	s, err := sdu.managedSession(id)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	if s.finalizing {
+		s.mu.Unlock()
+		return skymodules.ErrDirUploadSessionFinalizing
+	}
+	s.finalizing = true
+	s.mu.Unlock()
+
+	sdu.mu.Lock()
+	delete(sdu.sessions, id)
+	sdu.mu.Unlock()
+	return os.RemoveAll(s.staticDir)
+}
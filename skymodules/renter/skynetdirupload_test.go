@@ -0,0 +1,174 @@
+package renter
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/modules"
+	siasync "go.sia.tech/siad/sync"
+)
+
+// TestSkynetDirUploadPersistence tests that directory upload sessions and
+// their uploaded files survive a restart.
+func TestSkynetDirUploadPersistence(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	r := &Renter{mu: siasync.New(modules.SafeMutexDelay, 1)}
+	sdu, err := newSkynetDirUploader(r, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.staticSkynetDirUploader = sdu
+
+	// Create a session with a manifest.
+	manifest := []skymodules.SkynetDirUploadFile{
+		{Filename: "index.html", Len: 10, ContentType: "text/html"},
+		{Filename: "a/b.txt", Len: 20},
+	}
+	session, err := r.SkynetDirUploadCreate(skymodules.SkynetDirUploadSession{Filename: "dir"}, manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !session.Manifest || len(session.Files) != 2 {
+		t.Fatal("unexpected session", session)
+	}
+
+	// Uploading a file that's not part of the manifest should fail.
+	err = r.SkynetDirUploadAddFile(session.ID, skymodules.SkynetDirUploadFile{Filename: "c.txt"}, bytes.NewReader(fastrand.Bytes(1)))
+	if !errors.Contains(err, skymodules.ErrDirUploadFileNotInManifest) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// Uploading a file with the wrong length should fail.
+	err = r.SkynetDirUploadAddFile(session.ID, skymodules.SkynetDirUploadFile{Filename: "index.html"}, bytes.NewReader(fastrand.Bytes(11)))
+	if err == nil {
+		t.Fatal("expected upload with wrong length to fail")
+	}
+
+	// Upload one of the files.
+	err = r.SkynetDirUploadAddFile(session.ID, skymodules.SkynetDirUploadFile{Filename: "index.html"}, bytes.NewReader(fastrand.Bytes(10)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err = r.SkynetDirUploadSession(session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := session.Files["index.html"]
+	if !file.Complete || file.Len != 10 || file.ContentType != "text/html" {
+		t.Fatal("unexpected file", file)
+	}
+	if _, err := session.Metadata(); !errors.Contains(err, skymodules.ErrDirUploadIncomplete) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// Reload the uploader.
+	r = &Renter{mu: siasync.New(modules.SafeMutexDelay, 1)}
+	sdu, err = newSkynetDirUploader(r, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.staticSkynetDirUploader = sdu
+	reloaded, err := r.SkynetDirUploadSession(session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded.Files["index.html"].Complete || reloaded.Files["a/b.txt"].Complete {
+		t.Fatal("unexpected files after reload", reloaded.Files)
+	}
+
+	// Complete the session.
+	err = r.SkynetDirUploadAddFile(session.ID, skymodules.SkynetDirUploadFile{Filename: "a/b.txt"}, bytes.NewReader(fastrand.Bytes(20)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err = r.SkynetDirUploadSession(session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.Metadata(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Abort the session.
+	err = r.SkynetDirUploadAbort(session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.SkynetDirUploadSession(session.ID)
+	if !errors.Contains(err, skymodules.ErrDirUploadSessionNotFound) {
+		t.Fatal("unexpected error", err)
+	}
+}
+
+// TestSkynetDirUploadPruneSessions tests that abandoned directory upload
+// sessions are pruned in the background without being accessed.
+func TestSkynetDirUploadPruneSessions(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+	sdu := r.staticSkynetDirUploader
+
+	// Create two sessions.
+	expired, err := r.SkynetDirUploadCreate(skymodules.SkynetDirUploadSession{Filename: "expired"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	active, err := r.SkynetDirUploadCreate(skymodules.SkynetDirUploadSession{Filename: "active"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Backdate the first session.
+	s, err := sdu.managedSession(expired.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.mu.Lock()
+	s.session.LastUpdate = time.Now().Add(-2 * skynetDirUploadSessionTimeout)
+	expiredDir := s.staticDir
+	s.mu.Unlock()
+
+	// The expired session should be pruned by the maintenance job.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		_, err := r.SkynetDirUploadSession(expired.ID)
+		if !errors.Contains(err, skymodules.ErrDirUploadSessionNotFound) {
+			return fmt.Errorf("expected session to be pruned, got %v", err)
+		}
+		if _, err := os.Stat(expiredDir); !os.IsNotExist(err) {
+			return fmt.Errorf("expected session dir to be removed, got %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The active session should still exist.
+	if _, err := r.SkynetDirUploadSession(active.ID); err != nil {
+		t.Fatal(err)
+	}
+}
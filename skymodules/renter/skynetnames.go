@@ -0,0 +1,300 @@
+package renter
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/threadgroup"
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+var (
+	// skynetNameCacheTTL is the amount of time a resolved name is cached.
+	skynetNameCacheTTL = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 5 * time.Minute,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+
+	// skynetNameNegativeCacheTTL is the amount of time a name which doesn't
+	// point to a skylink is cached.
+	skynetNameNegativeCacheTTL = build.Select(build.Var{
+		Dev:      10 * time.Second,
+		Standard: 30 * time.Second,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// skynetNameErrorCacheTTL is the amount of time a failed lookup is
+	// cached. It's short since the failure might be temporary.
+	skynetNameErrorCacheTTL = build.Select(build.Var{
+		Dev:      5 * time.Second,
+		Standard: 5 * time.Second,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// skynetNameLookupTimeout is the maximum amount of time a lookup of a
+	// name may take.
+	skynetNameLookupTimeout = build.Select(build.Var{
+		Dev:      10 * time.Second,
+		Standard: 10 * time.Second,
+		Testing:  2 * time.Second,
+	}).(time.Duration)
+
+	// maxSkynetNameCacheEntries is the maximum number of names the resolver
+	// caches.
+	maxSkynetNameCacheEntries = 10000
+
+	// maxSkynetNameLookupsPerSecond is the maximum number of names the
+	// resolver looks up per second. Resolutions served from the cache don't
+	// count towards the limit.
+	maxSkynetNameLookupsPerSecond = build.Select(build.Var{
+		Dev:      50,
+		Standard: 50,
+		Testing:  1000,
+	}).(int)
+)
+
+var (
+	// errNoHNSResolver is returned when a Handshake name is resolved without
+	// a Handshake resolver being configured.
+	errNoHNSResolver = errors.New("unable to resolve handshake name, no handshake resolver configured")
+)
+
+type (
+	// skynetNameResolver resolves human-readable names to skylinks using the
+	// TXT records of the names and caches the results.
+	skynetNameResolver struct {
+		cache   map[string]skynetNameCacheEntry
+		lookups map[string]*skynetNameLookup
+
+		// lookupWindowStart is the start of the current one second window
+		// and windowLookups the number of lookups started within it.
+		lookupWindowStart time.Time
+		windowLookups     int
+
+		staticMaxLookupsPerSecond int
+		staticTG                  *threadgroup.ThreadGroup
+		mu                        sync.Mutex
+
+		// staticLookupTXT looks up the TXT records of a name, either using
+		// the DNS or a Handshake resolver.
+		staticLookupTXT func(ctx context.Context, name string, hns bool) ([]string, error)
+	}
+
+	// skynetNameCacheEntry is a cached resolution of a name. If the name
+	// doesn't point to a skylink or the lookup failed, err is set.
+	skynetNameCacheEntry struct {
+		resolution skymodules.SkynetNameResolution
+		err        error
+		expires    time.Time
+	}
+
+	// skynetNameLookup is an ongoing lookup of a name. Concurrent resolutions
+	// of the same name wait for the ongoing lookup instead of starting their
+	// own. The lookup isn't bound to the context of any of them. Once done is
+	// closed, resolution and err are set.
+	skynetNameLookup struct {
+		done       chan struct{}
+		resolution skymodules.SkynetNameResolution
+		err        error
+	}
+)
+
+// newSkynetNameResolver creates a new resolver which resolves Handshake names
+// using the DNS server at the given address. If no address is provided,
+// Handshake names can't be resolved. The lookups are run within the provided
+// thread group.
+func newSkynetNameResolver(hnsResolverAddr string, tg *threadgroup.ThreadGroup) *skynetNameResolver {
+	var hnsResolver *net.Resolver
+	if hnsResolverAddr != "" {
+		hnsResolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, hnsResolverAddr)
+			},
+		}
+	}
+	return &skynetNameResolver{
+		cache:                     make(map[string]skynetNameCacheEntry),
+		lookups:                   make(map[string]*skynetNameLookup),
+		staticMaxLookupsPerSecond: maxSkynetNameLookupsPerSecond,
+		staticTG:                  tg,
+		staticLookupTXT: func(ctx context.Context, name string, hns bool) ([]string, error) {
+			if !hns {
+				return net.DefaultResolver.LookupTXT(ctx, name)
+			}
+			if hnsResolver == nil {
+				return nil, errNoHNSResolver
+			}
+			return hnsResolver.LookupTXT(ctx, name)
+		},
+	}
+}
+
+// ResolveSkynetName resolves a human-readable name to a skylink. The TXT
+// records of the name's _dnslink subdomain take precedence over the records of
+// the name itself. Names ending in .hns are resolved using the Handshake
+// resolver.
+func (r *Renter) ResolveSkynetName(ctx context.Context, name string) (skymodules.SkynetNameResolution, error) {
+	if err := r.tg.Add(); err != nil {
+		return skymodules.SkynetNameResolution{}, err
+	}
+	defer r.tg.Done()
+	return r.staticSkynetNameResolver.managedResolve(ctx, name)
+}
+
+// managedResolve resolves a name to a skylink, using the cache if possible.
+func (snr *skynetNameResolver) managedResolve(ctx context.Context, name string) (skymodules.SkynetNameResolution, error) {
+	normalized, hns, err := skymodules.ParseSkynetName(name)
+	if err != nil {
+		return skymodules.SkynetNameResolution{}, err
+	}
+	key := normalized
+	if hns {
+		key += skymodules.HNSNameSuffix
+	}
+
+	// Check the cache. If the name is being looked up already, wait for that
+	// lookup to finish instead.
+	snr.mu.Lock()
+	entry, exists := snr.cache[key]
+	if exists && time.Now().Before(entry.expires) {
+		snr.mu.Unlock()
+		return entry.resolution, entry.err
+	}
+	lookup, ongoing := snr.lookups[key]
+	if !ongoing {
+		if !snr.tryStartLookup() {
+			snr.mu.Unlock()
+			return skymodules.SkynetNameResolution{}, skymodules.ErrSkynetNameLookupsLimited
+		}
+		lookup = &skynetNameLookup{done: make(chan struct{})}
+		snr.lookups[key] = lookup
+	}
+	snr.mu.Unlock()
+
+	// Start the lookup in the background. It is shared by all callers which
+	// resolve the name concurrently, which is why it is only limited by the
+	// lookup timeout and not by the caller's context.
+	if !ongoing {
+		err = snr.staticTG.Launch(func() {
+			snr.threadedLookup(key, normalized, hns, lookup)
+		})
+		if err != nil {
+			snr.mu.Lock()
+			delete(snr.lookups, key)
+			snr.mu.Unlock()
+			lookup.err = err
+			close(lookup.done)
+			return skymodules.SkynetNameResolution{}, err
+		}
+	}
+
+	// Wait for the lookup.
+	select {
+	case <-lookup.done:
+	case <-ctx.Done():
+		return skymodules.SkynetNameResolution{}, ctx.Err()
+	}
+	return lookup.resolution, lookup.err
+}
+
+// threadedLookup looks up a name and caches the result.
+func (snr *skynetNameResolver) threadedLookup(key, name string, hns bool, lookup *skynetNameLookup) {
+	ctx, cancel := context.WithTimeout(snr.staticTG.StopCtx(), skynetNameLookupTimeout)
+	defer cancel()
+
+	// Look up the records.
+	resolution, err := snr.managedLookup(ctx, key, name, hns)
+
+	// Cache the result. Names without a skylink are cached for longer than
+	// failed lookups since the failure might be temporary. Lookups which
+	// failed because of a shutdown aren't cached at all.
+	entry := skynetNameCacheEntry{
+		resolution: resolution,
+		err:        err,
+		expires:    resolution.Expires,
+	}
+	if errors.Contains(err, skymodules.ErrSkynetNameNotFound) {
+		entry.expires = time.Now().Add(skynetNameNegativeCacheTTL)
+	} else if err != nil {
+		entry.expires = time.Now().Add(skynetNameErrorCacheTTL)
+	}
+	snr.mu.Lock()
+	delete(snr.lookups, key)
+	if snr.staticTG.StopCtx().Err() == nil {
+		snr.addToCache(key, entry)
+	}
+	snr.mu.Unlock()
+	lookup.resolution, lookup.err = resolution, err
+	close(lookup.done)
+}
+
+// tryStartLookup returns whether another lookup may be started without
+// exceeding the max number of lookups per second.
+func (snr *skynetNameResolver) tryStartLookup() bool {
+	now := time.Now()
+	if now.Sub(snr.lookupWindowStart) >= time.Second {
+		snr.lookupWindowStart = now
+		snr.windowLookups = 0
+	}
+	if snr.windowLookups >= snr.staticMaxLookupsPerSecond {
+		return false
+	}
+	snr.windowLookups++
+	return true
+}
+
+// managedLookup looks up the TXT records of the name and its _dnslink
+// subdomain and returns the skylink the records point to.
+func (snr *skynetNameResolver) managedLookup(ctx context.Context, key, name string, hns bool) (skymodules.SkynetNameResolution, error) {
+	source := skymodules.SkynetNameSourceDNS
+	if hns {
+		source = skymodules.SkynetNameSourceHNS
+	}
+	for _, query := range []string{skymodules.DNSLinkSubdomain + name, name} {
+		records, err := snr.staticLookupTXT(ctx, query, hns)
+		if dnsErr, ok := err.(*net.DNSError); ok && dnsErr.IsNotFound {
+			continue
+		}
+		if err != nil {
+			return skymodules.SkynetNameResolution{}, errors.AddContext(err, "failed to look up TXT records of "+query)
+		}
+		sl, err := skymodules.SkylinkFromTXTRecords(records)
+		if errors.Contains(err, skymodules.ErrSkynetNameNotFound) {
+			continue
+		}
+		if err != nil {
+			return skymodules.SkynetNameResolution{}, errors.AddContext(err, "invalid TXT record of "+query)
+		}
+		return skymodules.SkynetNameResolution{
+			Name:    key,
+			Skylink: sl,
+			Source:  source,
+			Expires: time.Now().Add(skynetNameCacheTTL),
+		}, nil
+	}
+	return skymodules.SkynetNameResolution{}, skymodules.ErrSkynetNameNotFound
+}
+
+// addToCache adds an entry to the cache. If the cache is full, expired entries
+// are evicted first. If that doesn't free up space, the entry isn't cached.
+func (snr *skynetNameResolver) addToCache(key string, entry skynetNameCacheEntry) {
+	if _, exists := snr.cache[key]; !exists && len(snr.cache) >= maxSkynetNameCacheEntries {
+		now := time.Now()
+		for k, e := range snr.cache {
+			if now.After(e.expires) {
+				delete(snr.cache, k)
+			}
+		}
+		if len(snr.cache) >= maxSkynetNameCacheEntries {
+			return
+		}
+	}
+	snr.cache[key] = entry
+}
@@ -0,0 +1,316 @@
+package renter
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/threadgroup"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/crypto"
+)
+
+// TestSkynetNameResolver is a unit test for the skynetNameResolver.
+func TestSkynetNameResolver(t *testing.T) {
+	t.Parallel()
+
+	sl, err := skymodules.NewSkylinkV1(crypto.Hash{1}, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sl2, err := skymodules.NewSkylinkV1(crypto.Hash{2}, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a resolver with fake records.
+	records := map[string][]string{
+		"_dnslink.example.com": {skymodules.DNSLinkSkynetPrefix + sl.String()},
+		"example.com":          {skymodules.SkylinkTXTRecordPrefix + sl2.String()},
+		"other.com":            {skymodules.SkylinkTXTRecordPrefix + sl2.String()},
+		"nolink.com":           {"v=spf1 -all"},
+		"broken.com":           {skymodules.SkylinkTXTRecordPrefix + "notaskylink"},
+	}
+	var mu sync.Mutex
+	lookups := make(map[string]int)
+	snr := newSkynetNameResolver("", &threadgroup.ThreadGroup{})
+	lookupDNS := snr.staticLookupTXT
+	snr.staticLookupTXT = func(ctx context.Context, name string, hns bool) ([]string, error) {
+		if hns {
+			return lookupDNS(ctx, name, hns)
+		}
+		mu.Lock()
+		lookups[name]++
+		mu.Unlock()
+		if name == "_dnslink.fail.com" {
+			return nil, errors.New("temporary failure")
+		}
+		r, exists := records[name]
+		if !exists {
+			return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+		}
+		return r, nil
+	}
+	numLookups := func(name string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return lookups[name]
+	}
+
+	// The _dnslink subdomain takes precedence.
+	res, err := snr.managedResolve(context.Background(), "Example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Skylink != sl || res.Name != "example.com" || res.Source != skymodules.SkynetNameSourceDNS {
+		t.Fatal("wrong resolution", res)
+	}
+	if time.Until(res.Expires) <= 0 || time.Until(res.Expires) > skynetNameCacheTTL {
+		t.Fatal("wrong expiry", res.Expires)
+	}
+
+	// The name itself is used if the subdomain has no records.
+	res, err = snr.managedResolve(context.Background(), "other.com")
+	if err != nil || res.Skylink != sl2 {
+		t.Fatal("wrong resolution", res, err)
+	}
+
+	// Resolving again uses the cache.
+	_, err = snr.managedResolve(context.Background(), "example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := numLookups("_dnslink.example.com"); n != 1 {
+		t.Fatal("expected a single lookup", n)
+	}
+
+	// Names without a skylink are cached negatively.
+	for i := 0; i < 2; i++ {
+		_, err = snr.managedResolve(context.Background(), "nolink.com")
+		if !errors.Contains(err, skymodules.ErrSkynetNameNotFound) {
+			t.Fatal("wrong error", err)
+		}
+	}
+	if n := numLookups("nolink.com"); n != 1 {
+		t.Fatal("expected a single lookup", n)
+	}
+	time.Sleep(skynetNameNegativeCacheTTL)
+	_, err = snr.managedResolve(context.Background(), "nolink.com")
+	if !errors.Contains(err, skymodules.ErrSkynetNameNotFound) {
+		t.Fatal("wrong error", err)
+	}
+	if n := numLookups("nolink.com"); n != 2 {
+		t.Fatal("expected another lookup", n)
+	}
+
+	// Other errors are cached briefly.
+	for i := 0; i < 2; i++ {
+		_, err = snr.managedResolve(context.Background(), "fail.com")
+		if err == nil || errors.Contains(err, skymodules.ErrSkynetNameNotFound) {
+			t.Fatal("wrong error", err)
+		}
+	}
+	if n := numLookups("_dnslink.fail.com"); n != 1 {
+		t.Fatal("expected a single lookup", n)
+	}
+	time.Sleep(skynetNameErrorCacheTTL)
+	_, err = snr.managedResolve(context.Background(), "fail.com")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if n := numLookups("_dnslink.fail.com"); n != 2 {
+		t.Fatal("expected another lookup", n)
+	}
+	if _, err = snr.managedResolve(context.Background(), "broken.com"); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// Invalid names are rejected.
+	if _, err = snr.managedResolve(context.Background(), "localhost"); !errors.Contains(err, skymodules.ErrInvalidSkynetName) {
+		t.Fatal("wrong error", err)
+	}
+
+	// Handshake names require a handshake resolver.
+	if _, err = snr.managedResolve(context.Background(), "skyapp.hns"); !errors.Contains(err, errNoHNSResolver) {
+		t.Fatal("wrong error", err)
+	}
+}
+
+// TestSkynetNameResolverLimits tests that the skynetNameResolver deduplicates
+// concurrent lookups of the same name and limits the number of lookups.
+func TestSkynetNameResolverLimits(t *testing.T) {
+	t.Parallel()
+
+	sl, err := skymodules.NewSkylinkV1(crypto.Hash{1}, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a resolver which blocks lookups until they are released.
+	var mu sync.Mutex
+	lookups := make(map[string]int)
+	release := make(chan struct{})
+	snr := newSkynetNameResolver("", &threadgroup.ThreadGroup{})
+	snr.staticMaxLookupsPerSecond = 3
+	snr.staticLookupTXT = func(ctx context.Context, name string, hns bool) ([]string, error) {
+		mu.Lock()
+		lookups[name]++
+		mu.Unlock()
+		<-release
+		return []string{skymodules.SkylinkTXTRecordPrefix + sl.String()}, nil
+	}
+	numLookups := func(name string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return lookups[name]
+	}
+
+	// Resolve the same name concurrently. Only one lookup is performed.
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := snr.managedResolve(context.Background(), "example.com")
+			if err == nil && res.Skylink != sl {
+				err = errors.New("wrong skylink")
+			}
+			errs <- err
+		}()
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := numLookups("_dnslink.example.com"); n != 1 {
+		t.Fatal("expected a single lookup", n)
+	}
+
+	// Resolving other names is limited to 3 lookups per second. The cached
+	// name can still be resolved.
+	for i := 0; i < 2; i++ {
+		if _, err := snr.managedResolve(context.Background(), fmt.Sprintf("example%v.com", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := snr.managedResolve(context.Background(), "example2.com"); !errors.Contains(err, skymodules.ErrSkynetNameLookupsLimited) {
+		t.Fatal("wrong error", err)
+	}
+	if _, err := snr.managedResolve(context.Background(), "example.com"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Second)
+	if _, err := snr.managedResolve(context.Background(), "example2.com"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestSkynetNameResolverCanceledCaller tests that a caller which started a
+// lookup and gave up on it neither cancels the lookup for other callers nor
+// prevents the result from being cached.
+func TestSkynetNameResolverCanceledCaller(t *testing.T) {
+	t.Parallel()
+
+	sl, err := skymodules.NewSkylinkV1(crypto.Hash{1}, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a resolver which blocks lookups until they are released.
+	var mu sync.Mutex
+	var lookups int
+	release := make(chan struct{})
+	var tg threadgroup.ThreadGroup
+	defer func() {
+		if err := tg.Stop(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	snr := newSkynetNameResolver("", &tg)
+	snr.staticLookupTXT = func(ctx context.Context, name string, hns bool) ([]string, error) {
+		mu.Lock()
+		lookups++
+		mu.Unlock()
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return []string{skymodules.SkylinkTXTRecordPrefix + sl.String()}, nil
+	}
+
+	// Start a lookup and cancel it.
+	ctx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error)
+	go func() {
+		_, err := snr.managedResolve(ctx, "example.com")
+		leaderErr <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	if err := <-leaderErr; !errors.Contains(err, context.Canceled) {
+		t.Fatal("wrong error", err)
+	}
+
+	// Another caller waiting for the same name receives the result.
+	waiterErr := make(chan error)
+	go func() {
+		res, err := snr.managedResolve(context.Background(), "example.com")
+		if err == nil && res.Skylink != sl {
+			err = errors.New("wrong skylink")
+		}
+		waiterErr <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	if err := <-waiterErr; err != nil {
+		t.Fatal(err)
+	}
+
+	// The result is cached.
+	res, err := snr.managedResolve(context.Background(), "example.com")
+	if err != nil || res.Skylink != sl {
+		t.Fatal("wrong resolution", res, err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if lookups != 1 {
+		t.Fatal("expected a single lookup", lookups)
+	}
+}
+
+// TestSkynetNameResolverTimeout tests that lookups time out after the lookup
+// timeout.
+func TestSkynetNameResolverTimeout(t *testing.T) {
+	t.Parallel()
+
+	var tg threadgroup.ThreadGroup
+	defer func() {
+		if err := tg.Stop(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	snr := newSkynetNameResolver("", &tg)
+	snr.staticLookupTXT = func(ctx context.Context, name string, hns bool) ([]string, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	start := time.Now()
+	_, err := snr.managedResolve(context.Background(), "example.com")
+	if !errors.Contains(err, context.DeadlineExceeded) {
+		t.Fatal("wrong error", err)
+	}
+	if elapsed := time.Since(start); elapsed < skynetNameLookupTimeout || elapsed > 2*skynetNameLookupTimeout {
+		t.Fatal("lookup didn't time out after the lookup timeout", elapsed)
+	}
+}
@@ -0,0 +1,90 @@
+package renter
+
+import (
+	"sort"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"gitlab.com/SkynetLabs/skyd/skymodules/renter/filesystem"
+)
+
+// SetSkynetFolderQuota sets the quota of the given top-level folder of the
+// skynet folder. A quota of 0 removes the quota.
+func (r *Renter) SetSkynetFolderQuota(folder string, quota uint64) error {
+	err := r.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.staticSkynetQuotas.SetQuota(folder, quota)
+}
+
+// SkynetFolderUsage returns the number of bytes stored under the given
+// top-level folder of the skynet folder together with its quota. The usage is
+// taken from the folder's directory metadata and is updated whenever the
+// folder is bubbled.
+func (r *Renter) SkynetFolderUsage(folder string) (skymodules.SkynetFolderUsage, error) {
+	err := r.tg.Add()
+	if err != nil {
+		return skymodules.SkynetFolderUsage{}, err
+	}
+	defer r.tg.Done()
+	if err := skymodules.ValidateSkynetFolder(folder); err != nil {
+		return skymodules.SkynetFolderUsage{}, err
+	}
+	siaPath, err := skymodules.SkynetFolder.Join(folder)
+	if err != nil {
+		return skymodules.SkynetFolderUsage{}, err
+	}
+	di, err := r.staticFileSystem.DirInfo(siaPath)
+	if err != nil {
+		return skymodules.SkynetFolderUsage{}, errors.AddContext(err, "unable to get folder info")
+	}
+	return skymodules.SkynetFolderUsage{
+		Folder:   folder,
+		NumFiles: di.AggregateNumFiles,
+		Size:     di.AggregateSize,
+		Quota:    r.staticSkynetQuotas.Quota(folder),
+	}, nil
+}
+
+// SkynetFolderUsages returns the usage of all top-level folders of the skynet
+// folder as well as of the folders which have a quota but don't exist yet. The
+// usages are sorted by folder.
+func (r *Renter) SkynetFolderUsages() ([]skymodules.SkynetFolderUsage, error) {
+	err := r.tg.Add()
+	if err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	quotas := r.staticSkynetQuotas.Quotas()
+	dis, err := r.managedDirList(skymodules.SkynetFolder)
+	if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		return nil, errors.AddContext(err, "unable to list skynet folder")
+	}
+	var usages []skymodules.SkynetFolderUsage
+	for _, di := range dis {
+		dir, err := di.SiaPath.Dir()
+		if err != nil || !dir.Equals(skymodules.SkynetFolder) {
+			continue // skip the skynet folder itself
+		}
+		folder := di.SiaPath.Name()
+		usages = append(usages, skymodules.SkynetFolderUsage{
+			Folder:   folder,
+			NumFiles: di.AggregateNumFiles,
+			Size:     di.AggregateSize,
+			Quota:    quotas[folder],
+		})
+		delete(quotas, folder)
+	}
+	for folder, quota := range quotas {
+		usages = append(usages, skymodules.SkynetFolderUsage{
+			Folder: folder,
+			Quota:  quota,
+		})
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].Folder < usages[j].Folder
+	})
+	return usages, nil
+}
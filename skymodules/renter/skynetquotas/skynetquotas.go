@@ -0,0 +1,92 @@
+package skynetquotas
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/persist"
+)
+
+const (
+	// persistFile is the name of the persist file
+	persistFile string = "skynetquotas.json"
+)
+
+var (
+	// persistMetadata is the metadata of the persist file
+	persistMetadata = persist.Metadata{
+		Header:  "Skynet Quotas",
+		Version: "1.5.9",
+	}
+)
+
+// SkynetQuotas manages the quotas of the top-level folders of the skynet
+// folder.
+type SkynetQuotas struct {
+	// quotas maps the folder names to their quota in bytes.
+	quotas map[string]uint64
+
+	staticPersistPath string
+	mu                sync.Mutex
+}
+
+// New returns an initialized SkynetQuotas.
+func New(persistDir string) (*SkynetQuotas, error) {
+	sq := &SkynetQuotas{
+		quotas:            make(map[string]uint64),
+		staticPersistPath: filepath.Join(persistDir, persistFile),
+	}
+	err := persist.LoadJSON(persistMetadata, &sq.quotas, sq.staticPersistPath)
+	if os.IsNotExist(err) {
+		return sq, nil
+	}
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to load skynet quotas")
+	}
+	return sq, nil
+}
+
+// Quota returns the quota of the given folder. A quota of 0 means unlimited.
+func (sq *SkynetQuotas) Quota(folder string) uint64 {
+	sq.mu.Lock()
+	defer sq.mu.Unlock()
+	return sq.quotas[folder]
+}
+
+// Quotas returns the quotas of all folders which have one.
+func (sq *SkynetQuotas) Quotas() map[string]uint64 {
+	sq.mu.Lock()
+	defer sq.mu.Unlock()
+	quotas := make(map[string]uint64, len(sq.quotas))
+	for folder, quota := range sq.quotas {
+		quotas[folder] = quota
+	}
+	return quotas
+}
+
+// SetQuota sets the quota of the given folder. A quota of 0 removes the quota.
+func (sq *SkynetQuotas) SetQuota(folder string, quota uint64) error {
+	if err := skymodules.ValidateSkynetFolder(folder); err != nil {
+		return err
+	}
+	sq.mu.Lock()
+	defer sq.mu.Unlock()
+	old, exists := sq.quotas[folder]
+	if quota == 0 {
+		delete(sq.quotas, folder)
+	} else {
+		sq.quotas[folder] = quota
+	}
+	if err := persist.SaveJSON(persistMetadata, sq.quotas, sq.staticPersistPath); err != nil {
+		if exists {
+			sq.quotas[folder] = old
+		} else {
+			delete(sq.quotas, folder)
+		}
+		return errors.AddContext(err, "unable to persist skynet quota")
+	}
+	return nil
+}
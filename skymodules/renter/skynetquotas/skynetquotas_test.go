@@ -0,0 +1,68 @@
+package skynetquotas
+
+import (
+	"os"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+// testDir is a helper function for creating the testing directory
+func testDir(name string) string {
+	dir := build.TempDir("skynetquotas", name)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		panic(err)
+	}
+	return dir
+}
+
+// TestSkynetQuotas tests setting and removing quotas as well as their
+// persistence.
+func TestSkynetQuotas(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	testdir := testDir(t.Name())
+	sq, err := New(testdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sq.Quotas()) != 0 {
+		t.Fatal("expected no quotas")
+	}
+
+	// Invalid folders are rejected.
+	for _, folder := range []string{"", "a/b", ".."} {
+		if err := sq.SetQuota(folder, 100); !errors.Contains(err, skymodules.ErrInvalidSkynetFolder) {
+			t.Fatalf("expected invalid folder error for '%v': %v", folder, err)
+		}
+	}
+
+	// Set two quotas.
+	if err := sq.SetQuota("tenant1", 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := sq.SetQuota("tenant2", 200); err != nil {
+		t.Fatal(err)
+	}
+	if sq.Quota("tenant1") != 100 || sq.Quota("tenant2") != 200 || sq.Quota("tenant3") != 0 {
+		t.Fatal("unexpected quotas", sq.Quotas())
+	}
+
+	// Remove one of them and reload.
+	if err := sq.SetQuota("tenant2", 0); err != nil {
+		t.Fatal(err)
+	}
+	sq, err = New(testdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quotas := sq.Quotas()
+	if len(quotas) != 1 || quotas["tenant1"] != 100 {
+		t.Fatal("unexpected quotas after reload", quotas)
+	}
+}
@@ -0,0 +1,279 @@
+package renter
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+// The skylink repair priorities feed the popularity of skylinks into the
+// repair loop. Every download of a skylink increases its popularity which
+// decays exponentially over time. When the chunks of a siafile are added to
+// the upload heap, they are assigned the highest priority of the file's
+// skylinks and chunks with a higher priority are repaired first.
+//
+// The popularity is only tracked in memory while manual overrides are
+// persisted.
+
+const (
+	// skylinkRepairPriorityFilename is the name of the file which persists
+	// the manual repair priority overrides.
+	skylinkRepairPriorityFilename = "skylinkrepairpriority.dat"
+
+	// skylinkRepairPriorityQueueLen is the maximum number of skylinks
+	// returned by SkynetRepairPriorities.
+	skylinkRepairPriorityQueueLen = 1000
+)
+
+var (
+	// skylinkRepairPriorityMDHeader is the header of the metadata for the
+	// persist file.
+	skylinkRepairPriorityMDHeader = types.NewSpecifier("RepairPriority")
+
+	// skylinkPopularityHalfLife is the time after which the popularity of a
+	// skylink has decayed to half its value.
+	skylinkPopularityHalfLife = build.Select(build.Var{
+		Dev:      time.Hour,
+		Standard: 24 * time.Hour,
+		Testing:  time.Minute,
+	}).(time.Duration)
+
+	// maxTrackedSkylinkPopularity is the maximum number of skylinks for
+	// which the popularity is tracked. Once it is reached, the least popular
+	// skylink is evicted.
+	maxTrackedSkylinkPopularity = build.Select(build.Var{
+		Dev:      1000,
+		Standard: 100000,
+		Testing:  10,
+	}).(int)
+)
+
+type (
+	// skylinkRepairPriorities tracks the popularity and repair priority
+	// overrides of skylinks.
+	skylinkRepairPriorities struct {
+		popularity map[string]*skylinkPopularity
+		overrides  map[string]float64
+
+		staticAop *persist.AppendOnlyPersist
+		mu        sync.Mutex
+	}
+
+	// skylinkPopularity is the decaying popularity of a skylink.
+	skylinkPopularity struct {
+		score      float64
+		lastUpdate time.Time
+	}
+
+	// skylinkRepairPriorityEntry is the definition of a persisted override.
+	skylinkRepairPriorityEntry struct {
+		Skylink  string  `json:"skylink"`
+		Priority float64 `json:"priority"`
+		Reset    bool    `json:"reset,omitempty"`
+	}
+)
+
+// newSkylinkRepairPriorities creates new skylink repair priorities or loads the
+// persisted overrides from disk.
+func newSkylinkRepairPriorities(dir, filename string) (*skylinkRepairPriorities, error) {
+	aop, r, err := persist.NewAppendOnlyPersist(dir, filename, skylinkRepairPriorityMDHeader, persist.MetadataVersionv156)
+	if err != nil {
+		return nil, err
+	}
+	srp := &skylinkRepairPriorities{
+		popularity: make(map[string]*skylinkPopularity),
+		overrides:  make(map[string]float64),
+		staticAop:  aop,
+	}
+	decoder := json.NewDecoder(r)
+	for {
+		var entry skylinkRepairPriorityEntry
+		err := decoder.Decode(&entry)
+		if errors.Contains(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, errors.Compose(err, aop.Close())
+		}
+		srp.applyEntry(entry)
+	}
+	return srp, nil
+}
+
+// applyEntry applies a persisted override.
+func (srp *skylinkRepairPriorities) applyEntry(entry skylinkRepairPriorityEntry) {
+	if entry.Reset {
+		delete(srp.overrides, entry.Skylink)
+		return
+	}
+	srp.overrides[entry.Skylink] = entry.Priority
+}
+
+// Close closes the underlying persistence.
+func (srp *skylinkRepairPriorities) Close() error {
+	return srp.staticAop.Close()
+}
+
+// decayedScore returns the popularity's score at the given time.
+func (sp *skylinkPopularity) decayedScore(now time.Time) float64 {
+	elapsed := now.Sub(sp.lastUpdate)
+	if elapsed <= 0 {
+		return sp.score
+	}
+	return sp.score * math.Pow(0.5, float64(elapsed)/float64(skylinkPopularityHalfLife))
+}
+
+// callRecordDownload increases the popularity of a skylink.
+func (srp *skylinkRepairPriorities) callRecordDownload(skylink string) {
+	now := time.Now()
+	srp.mu.Lock()
+	defer srp.mu.Unlock()
+
+	sp, exists := srp.popularity[skylink]
+	if !exists {
+		if len(srp.popularity) >= maxTrackedSkylinkPopularity {
+			srp.evictLeastPopular(now)
+		}
+		sp = &skylinkPopularity{lastUpdate: now}
+		srp.popularity[skylink] = sp
+	}
+	sp.score = sp.decayedScore(now) + 1
+	sp.lastUpdate = now
+}
+
+// evictLeastPopular removes the least popular skylink.
+func (srp *skylinkRepairPriorities) evictLeastPopular(now time.Time) {
+	var leastPopular string
+	minScore := math.MaxFloat64
+	for skylink, sp := range srp.popularity {
+		if score := sp.decayedScore(now); score < minScore {
+			leastPopular = skylink
+			minScore = score
+		}
+	}
+	delete(srp.popularity, leastPopular)
+}
+
+// priority returns the repair priority of a skylink.
+func (srp *skylinkRepairPriorities) priority(skylink string, now time.Time) skymodules.SkylinkRepairPriority {
+	p := skymodules.SkylinkRepairPriority{
+		Skylink: skylink,
+	}
+	if sp, exists := srp.popularity[skylink]; exists {
+		p.Popularity = sp.decayedScore(now)
+	}
+	p.Priority, p.Override = srp.overrides[skylink]
+	if !p.Override {
+		p.Priority = p.Popularity
+	}
+	return p
+}
+
+// callPriority returns the highest repair priority of the given skylinks.
+func (srp *skylinkRepairPriorities) callPriority(skylinks []string) float64 {
+	now := time.Now()
+	srp.mu.Lock()
+	defer srp.mu.Unlock()
+	var priority float64
+	for _, skylink := range skylinks {
+		priority = math.Max(priority, srp.priority(skylink, now).Priority)
+	}
+	return priority
+}
+
+// callPriorities returns the repair priorities of the tracked skylinks sorted
+// by priority in descending order.
+func (srp *skylinkRepairPriorities) callPriorities() []skymodules.SkylinkRepairPriority {
+	now := time.Now()
+	srp.mu.Lock()
+	priorities := make([]skymodules.SkylinkRepairPriority, 0, len(srp.popularity)+len(srp.overrides))
+	for skylink := range srp.popularity {
+		priorities = append(priorities, srp.priority(skylink, now))
+	}
+	for skylink := range srp.overrides {
+		if _, exists := srp.popularity[skylink]; !exists {
+			priorities = append(priorities, srp.priority(skylink, now))
+		}
+	}
+	srp.mu.Unlock()
+
+	sort.Slice(priorities, func(i, j int) bool {
+		if priorities[i].Priority != priorities[j].Priority {
+			return priorities[i].Priority > priorities[j].Priority
+		}
+		return priorities[i].Skylink < priorities[j].Skylink
+	})
+	if len(priorities) > skylinkRepairPriorityQueueLen {
+		priorities = priorities[:skylinkRepairPriorityQueueLen]
+	}
+	return priorities
+}
+
+// managedPersistEntry persists an override and applies it.
+func (srp *skylinkRepairPriorities) managedPersistEntry(entry skylinkRepairPriorityEntry) (skymodules.SkylinkRepairPriority, error) {
+	entryBytes, err := json.Marshal(entry)
+	if err != nil {
+		return skymodules.SkylinkRepairPriority{}, err
+	}
+	srp.mu.Lock()
+	defer srp.mu.Unlock()
+	_, err = srp.staticAop.Write(entryBytes)
+	if err != nil {
+		return skymodules.SkylinkRepairPriority{}, err
+	}
+	srp.applyEntry(entry)
+	return srp.priority(entry.Skylink, time.Now()), nil
+}
+
+// SkynetRepairPriorities returns the repair priorities of the skylinks which
+// are prioritized the most.
+func (r *Renter) SkynetRepairPriorities() ([]skymodules.SkylinkRepairPriority, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	return r.staticRepairPriorities.callPriorities(), nil
+}
+
+// SetSkylinkRepairPriority manually overrides the repair priority of a
+// skylink.
+func (r *Renter) SetSkylinkRepairPriority(link skymodules.Skylink, priority float64) (skymodules.SkylinkRepairPriority, error) {
+	if err := r.tg.Add(); err != nil {
+		return skymodules.SkylinkRepairPriority{}, err
+	}
+	defer r.tg.Done()
+	if !link.IsSkylinkV1() {
+		return skymodules.SkylinkRepairPriority{}, errors.New("can't set repair priority of version 2 skylink")
+	}
+	if math.IsNaN(priority) || math.IsInf(priority, 0) || priority < 0 {
+		return skymodules.SkylinkRepairPriority{}, skymodules.ErrInvalidRepairPriority
+	}
+	return r.staticRepairPriorities.managedPersistEntry(skylinkRepairPriorityEntry{
+		Skylink:  link.String(),
+		Priority: priority,
+	})
+}
+
+// ResetSkylinkRepairPriority removes the manual repair priority override of a
+// skylink.
+func (r *Renter) ResetSkylinkRepairPriority(link skymodules.Skylink) (skymodules.SkylinkRepairPriority, error) {
+	if err := r.tg.Add(); err != nil {
+		return skymodules.SkylinkRepairPriority{}, err
+	}
+	defer r.tg.Done()
+	if !link.IsSkylinkV1() {
+		return skymodules.SkylinkRepairPriority{}, errors.New("can't reset repair priority of version 2 skylink")
+	}
+	return r.staticRepairPriorities.managedPersistEntry(skylinkRepairPriorityEntry{
+		Skylink: link.String(),
+		Reset:   true,
+	})
+}
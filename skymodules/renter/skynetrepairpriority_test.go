@@ -0,0 +1,119 @@
+package renter
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"gitlab.com/SkynetLabs/skyd/build"
+)
+
+// TestSkylinkRepairPriorities tests the popularity tracking and the persisted
+// overrides of the skylink repair priorities.
+func TestSkylinkRepairPriorities(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	testDir := build.TempDir("renter", t.Name())
+	fileName := "test"
+
+	srp, err := newSkylinkRepairPriorities(testDir, fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Unknown skylinks have no priority.
+	if p := srp.callPriority([]string{"a", "b"}); p != 0 {
+		t.Fatal("unexpected priority", p)
+	}
+
+	// Download a twice and b once.
+	srp.callRecordDownload("a")
+	srp.callRecordDownload("a")
+	srp.callRecordDownload("b")
+	if p := srp.callPriority([]string{"a"}); math.Abs(p-2) > 0.01 {
+		t.Fatal("unexpected priority", p)
+	}
+	// The highest priority of a file's skylinks is used.
+	if p := srp.callPriority([]string{"b", "a"}); math.Abs(p-2) > 0.01 {
+		t.Fatal("unexpected priority", p)
+	}
+
+	// The popularity decays over time.
+	srp.mu.Lock()
+	srp.popularity["a"].lastUpdate = srp.popularity["a"].lastUpdate.Add(-skylinkPopularityHalfLife)
+	srp.mu.Unlock()
+	if p := srp.callPriority([]string{"a"}); math.Abs(p-1) > 0.01 {
+		t.Fatal("unexpected priority", p)
+	}
+
+	// Override the priority of b and of an unknown skylink c.
+	p, err := srp.managedPersistEntry(skylinkRepairPriorityEntry{Skylink: "b", Priority: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Override || p.Priority != 10 || math.Abs(p.Popularity-1) > 0.01 {
+		t.Fatal("unexpected priority", p)
+	}
+	_, err = srp.managedPersistEntry(skylinkRepairPriorityEntry{Skylink: "c", Priority: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The queue should be sorted by priority.
+	priorities := srp.callPriorities()
+	if len(priorities) != 3 || priorities[0].Skylink != "b" || priorities[1].Skylink != "c" || priorities[2].Skylink != "a" {
+		t.Fatal("unexpected priorities", priorities)
+	}
+
+	// Reload. Only the overrides should be persisted.
+	if err := srp.Close(); err != nil {
+		t.Fatal(err)
+	}
+	srp, err = newSkylinkRepairPriorities(testDir, fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priorities = srp.callPriorities()
+	if len(priorities) != 2 || priorities[0].Skylink != "b" || priorities[0].Priority != 10 || priorities[0].Popularity != 0 {
+		t.Fatal("unexpected priorities", priorities)
+	}
+
+	// Reset the override of b.
+	p, err = srp.managedPersistEntry(skylinkRepairPriorityEntry{Skylink: "b", Reset: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Override || p.Priority != 0 {
+		t.Fatal("unexpected priority", p)
+	}
+	if err := srp.Close(); err != nil {
+		t.Fatal(err)
+	}
+	srp, err = newSkylinkRepairPriorities(testDir, fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if priorities = srp.callPriorities(); len(priorities) != 1 || priorities[0].Skylink != "c" {
+		t.Fatal("unexpected priorities", priorities)
+	}
+
+	// Track more skylinks than allowed. The least popular one is evicted.
+	srp.callRecordDownload("popular")
+	srp.callRecordDownload("popular")
+	for i := 0; i < maxTrackedSkylinkPopularity; i++ {
+		srp.callRecordDownload(fmt.Sprint(i))
+	}
+	srp.mu.Lock()
+	_, popularExists := srp.popularity["popular"]
+	numTracked := len(srp.popularity)
+	srp.mu.Unlock()
+	if !popularExists || numTracked != maxTrackedSkylinkPopularity {
+		t.Fatal("unexpected popularity tracking", popularExists, numTracked)
+	}
+	if err := srp.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,124 @@
+package renter
+
+import (
+	"context"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+type (
+	// skylinkAccessKey is the context key for the signed URL credentials of a
+	// download.
+	skylinkAccessKey struct{}
+
+	// skylinkAccessUnrestrictedKey is the context key which marks the
+	// renter's own downloads that may access restricted skylinks.
+	skylinkAccessUnrestrictedKey struct{}
+)
+
+// contextWithSkylinkAccess returns a context that carries the given access. A
+// nil access marks the context as one of the renter's own downloads.
+func contextWithSkylinkAccess(ctx context.Context, access *skymodules.SkylinkAccess) context.Context {
+	if access == nil {
+		return contextWithUnrestrictedSkylinkAccess(ctx)
+	}
+	return context.WithValue(ctx, skylinkAccessKey{}, *access)
+}
+
+// contextWithUnrestrictedSkylinkAccess returns a context that may access
+// restricted skylinks without a signature.
+func contextWithUnrestrictedSkylinkAccess(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skylinkAccessUnrestrictedKey{}, struct{}{})
+}
+
+// SignSkylink restricts access to the skylink to signed URLs and returns a
+// signature for a URL which is valid until expires.
+func (r *Renter) SignSkylink(skylink skymodules.Skylink, expires time.Time) ([]byte, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+
+	id := r.mu.Lock()
+	key := r.persist.SkylinkSigningKey
+	_, restricted := r.persist.RestrictedSkylinks[skylink.String()]
+	if !restricted {
+		if r.persist.RestrictedSkylinks == nil {
+			r.persist.RestrictedSkylinks = make(map[string]struct{})
+		}
+		r.persist.RestrictedSkylinks[skylink.String()] = struct{}{}
+	}
+	r.mu.Unlock(id)
+	if !restricted {
+		err := r.managedSaveSync()
+		if err != nil {
+			id = r.mu.Lock()
+			delete(r.persist.RestrictedSkylinks, skylink.String())
+			r.mu.Unlock(id)
+			return nil, errors.AddContext(err, "failed to persist restricted skylink")
+		}
+	}
+	return skymodules.SkylinkURLSignature(key, skylink, expires), nil
+}
+
+// UnrestrictSkylink lifts the restriction of a skylink to signed URLs.
+func (r *Renter) UnrestrictSkylink(skylink skymodules.Skylink) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	id := r.mu.Lock()
+	_, restricted := r.persist.RestrictedSkylinks[skylink.String()]
+	delete(r.persist.RestrictedSkylinks, skylink.String())
+	r.mu.Unlock(id)
+	if !restricted {
+		return nil
+	}
+	err := r.managedSaveSync()
+	if err != nil {
+		id = r.mu.Lock()
+		r.persist.RestrictedSkylinks[skylink.String()] = struct{}{}
+		r.mu.Unlock(id)
+		return errors.AddContext(err, "failed to persist unrestricted skylink")
+	}
+	return nil
+}
+
+// VerifySkylinkAccess checks whether a skylink may be downloaded. If a
+// signature is provided, it needs to be valid. If the skylink is restricted, a
+// signature is required.
+func (r *Renter) VerifySkylinkAccess(skylink skymodules.Skylink, expires time.Time, signature []byte) error {
+	id := r.mu.RLock()
+	key := r.persist.SkylinkSigningKey
+	_, restricted := r.persist.RestrictedSkylinks[skylink.String()]
+	r.mu.RUnlock(id)
+
+	if len(signature) == 0 {
+		if restricted {
+			return skymodules.ErrSkylinkSignatureRequired
+		}
+		return nil
+	}
+	return skymodules.VerifySkylinkURLSignature(key, skylink, expires, signature)
+}
+
+// managedVerifySkylinkAccessFromContext checks the access attached to the
+// context against the requested skylink and the V1 skylink it resolved to.
+// Contexts marked as unrestricted belong to the renter's own downloads and are
+// not checked. A signature needs to be valid for the requested skylink and
+// grants access to the skylink it resolves to. Without a signature, including
+// contexts without any access, neither skylink may be restricted.
+func (r *Renter) managedVerifySkylinkAccessFromContext(ctx context.Context, requested, resolved skymodules.Skylink) error {
+	if ctx.Value(skylinkAccessUnrestrictedKey{}) != nil {
+		return nil
+	}
+	access, _ := ctx.Value(skylinkAccessKey{}).(skymodules.SkylinkAccess)
+	err := r.VerifySkylinkAccess(requested, access.Expires, access.Signature)
+	if err != nil || len(access.Signature) > 0 || requested == resolved {
+		return err
+	}
+	return r.VerifySkylinkAccess(resolved, time.Time{}, nil)
+}
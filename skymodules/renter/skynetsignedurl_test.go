@@ -0,0 +1,156 @@
+package renter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// TestSkylinkAccess tests restricting skylinks to signed URLs and verifying
+// access to them.
+func TestSkylinkAccess(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	skylink, err := skymodules.NewSkylinkV1(crypto.Hash{1}, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expires := time.Now().Add(time.Hour)
+
+	// An unrestricted skylink can be accessed without a signature.
+	if err := r.VerifySkylinkAccess(skylink, time.Time{}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sign the skylink. It should no longer be accessible without a
+	// signature.
+	sig, err := r.SignSkylink(skylink, expires)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.VerifySkylinkAccess(skylink, time.Time{}, nil)
+	if !errors.Contains(err, skymodules.ErrSkylinkSignatureRequired) {
+		t.Fatal("wrong error", err)
+	}
+	if err := r.VerifySkylinkAccess(skylink, expires, sig); err != nil {
+		t.Fatal(err)
+	}
+
+	// The restriction and key should survive a restart.
+	r, err = rt.reloadRenter(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.VerifySkylinkAccess(skylink, expires, sig); err != nil {
+		t.Fatal(err)
+	}
+	err = r.VerifySkylinkAccess(skylink, time.Time{}, nil)
+	if !errors.Contains(err, skymodules.ErrSkylinkSignatureRequired) {
+		t.Fatal("wrong error", err)
+	}
+
+	// Lift the restriction again.
+	if err := r.UnrestrictSkylink(skylink); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.VerifySkylinkAccess(skylink, time.Time{}, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestVerifySkylinkAccessFromContext tests that the access attached to a
+// download's context is checked against both the requested and the resolved
+// skylink.
+func TestVerifySkylinkAccessFromContext(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	slV1, err := skymodules.NewSkylinkV1(crypto.Hash{1}, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slV2 := skymodules.NewSkylinkV2(types.SiaPublicKey{}, crypto.Hash{2})
+	expires := time.Now().Add(time.Hour)
+	sigV1, err := r.SignSkylink(slV1, expires)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigV2, err := r.SignSkylink(slV2, expires)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.UnrestrictSkylink(slV2); err != nil {
+		t.Fatal(err)
+	}
+
+	verify := func(access *skymodules.SkylinkAccess, requested skymodules.Skylink) error {
+		ctx := contextWithSkylinkAccess(context.Background(), access)
+		return r.managedVerifySkylinkAccessFromContext(ctx, requested, slV1)
+	}
+
+	// The renter's own downloads aren't checked.
+	if err := verify(nil, slV2); err != nil {
+		t.Fatal(err)
+	}
+	// A context without any access may not access restricted skylinks.
+	err = r.managedVerifySkylinkAccessFromContext(context.Background(), slV2, slV1)
+	if !errors.Contains(err, skymodules.ErrSkylinkSignatureRequired) {
+		t.Fatal("wrong error", err)
+	}
+	err = r.managedVerifySkylinkAccessFromContext(context.Background(), slV1, slV1)
+	if !errors.Contains(err, skymodules.ErrSkylinkSignatureRequired) {
+		t.Fatal("wrong error", err)
+	}
+	if err := r.managedVerifySkylinkAccessFromContext(context.Background(), slV2, slV2); err != nil {
+		t.Fatal(err)
+	}
+	// Resolving an unrestricted V2 skylink to a restricted V1 skylink
+	// requires a signature.
+	err = verify(&skymodules.SkylinkAccess{}, slV2)
+	if !errors.Contains(err, skymodules.ErrSkylinkSignatureRequired) {
+		t.Fatal("wrong error", err)
+	}
+	// A signature for the V2 skylink grants access to the V1 skylink.
+	if err := verify(&skymodules.SkylinkAccess{Expires: expires, Signature: sigV2}, slV2); err != nil {
+		t.Fatal(err)
+	}
+	// A signature for the V1 skylink doesn't match the requested V2 skylink.
+	err = verify(&skymodules.SkylinkAccess{Expires: expires, Signature: sigV1}, slV2)
+	if !errors.Contains(err, skymodules.ErrSkylinkSignatureInvalid) {
+		t.Fatal("wrong error", err)
+	}
+	// The V1 skylink can be accessed directly with its signature.
+	if err := verify(&skymodules.SkylinkAccess{Expires: expires, Signature: sigV1}, slV1); err != nil {
+		t.Fatal(err)
+	}
+}
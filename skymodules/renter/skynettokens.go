@@ -0,0 +1,98 @@
+package renter
+
+import (
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+// APITokens returns the information about all API tokens.
+func (r *Renter) APITokens() ([]skymodules.APIToken, error) {
+	err := r.tg.Add()
+	if err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	return r.staticSkynetTokens.Tokens(), nil
+}
+
+// APITokenUsage returns the information about the API token with the given ID
+// together with the usage of its quota.
+func (r *Renter) APITokenUsage(id string) (skymodules.APIToken, skymodules.APITokenUsage, error) {
+	err := r.tg.Add()
+	if err != nil {
+		return skymodules.APIToken{}, skymodules.APITokenUsage{}, err
+	}
+	defer r.tg.Done()
+	return r.staticSkynetTokens.Usage(id)
+}
+
+// CommitAPITokenQuota adds the given number of reserved bytes to the usage of
+// the API token with the given ID and attributes them to the uploaded or pinned
+// skylink.
+func (r *Renter) CommitAPITokenQuota(id string, skylink skymodules.Skylink, size uint64) error {
+	err := r.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.staticSkynetTokens.Commit(id, skylink, size)
+}
+
+// CreateAPIToken creates a new API token with the given scope and quota and
+// returns it together with its information.
+func (r *Renter) CreateAPIToken(name string, scope skymodules.APITokenScope, quota skymodules.APITokenQuota) (string, skymodules.APIToken, error) {
+	err := r.tg.Add()
+	if err != nil {
+		return "", skymodules.APIToken{}, err
+	}
+	defer r.tg.Done()
+	return r.staticSkynetTokens.Create(name, scope, quota)
+}
+
+// ReleaseAPITokenQuota releases the given number of reserved bytes of the API
+// token with the given ID.
+func (r *Renter) ReleaseAPITokenQuota(id string, size uint64) {
+	r.staticSkynetTokens.Release(id, size)
+}
+
+// ReserveAPITokenQuota reserves the given number of bytes of the quota of the
+// API token with the given ID for an upload or pin. ErrAPITokenQuotaExceeded is
+// returned if the bytes don't fit into the quota.
+func (r *Renter) ReserveAPITokenQuota(id string, size uint64) error {
+	err := r.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.staticSkynetTokens.Reserve(id, size)
+}
+
+// RevokeAPIToken revokes the API token with the given ID.
+func (r *Renter) RevokeAPIToken(id string) error {
+	err := r.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.staticSkynetTokens.Revoke(id)
+}
+
+// SetAPITokenQuota sets the quota of the API token with the given ID.
+func (r *Renter) SetAPITokenQuota(id string, quota skymodules.APITokenQuota) error {
+	err := r.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.staticSkynetTokens.SetQuota(id, quota)
+}
+
+// ValidateAPIToken returns the information about an API token or
+// ErrAPITokenNotFound if the token is invalid.
+func (r *Renter) ValidateAPIToken(token string) (skymodules.APIToken, error) {
+	err := r.tg.Add()
+	if err != nil {
+		return skymodules.APIToken{}, err
+	}
+	defer r.tg.Done()
+	return r.staticSkynetTokens.Validate(token)
+}
@@ -0,0 +1,395 @@
+package skynettokens
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/persist"
+)
+
+const (
+	// persistFile is the name of the persist file
+	persistFile string = "skynettokens.json"
+
+	// tokenIDSize is the number of random bytes of a token ID.
+	tokenIDSize = 8
+
+	// tokenSize is the number of random bytes of a token.
+	tokenSize = 32
+)
+
+var (
+	// persistMetadata is the metadata of the persist file
+	persistMetadata = persist.Metadata{
+		Header:  "Skynet Tokens",
+		Version: "1.5.9",
+	}
+
+	// usagePersistInterval is the amount of time changes to the usage of the
+	// tokens are batched before they are persisted.
+	usagePersistInterval = build.Select(build.Var{
+		Dev:      5 * time.Second,
+		Standard: 10 * time.Second,
+		Testing:  100 * time.Millisecond,
+	}).(time.Duration)
+)
+
+type (
+	// SkynetTokens manages the API tokens of a portal by persisting their
+	// hashes to disk. The tokens themselves are never stored.
+	SkynetTokens struct {
+		// tokens maps the token IDs to the persisted tokens.
+		tokens map[string]persistToken
+
+		// hashes maps the token hashes to the token IDs.
+		hashes map[crypto.Hash]string
+
+		// reserved maps the token IDs to the number of bytes reserved by
+		// uploads and pins which are still in progress. Reservations are not
+		// persisted.
+		reserved map[string]uint64
+
+		// persistTimer is set while changes to the usage of the tokens are
+		// waiting to be persisted. closed is set once the tokens were closed
+		// and no more persists are scheduled.
+		persistTimer *time.Timer
+		closed       bool
+
+		// persistMu serializes persisting the tokens. It is acquired before
+		// mu to make sure that an older snapshot of the tokens never
+		// overwrites a newer one.
+		persistMu sync.Mutex
+
+		staticPersistPath string
+		mu                sync.Mutex
+	}
+
+	// persistToken is the persisted form of a token.
+	persistToken struct {
+		skymodules.APIToken
+		Hash  crypto.Hash              `json:"hash"`
+		Usage skymodules.APITokenUsage `json:"usage"`
+
+		// Pins maps the skylinks uploaded or pinned using the token to the
+		// number of bytes they count towards the token's pinned bytes.
+		Pins map[string]uint64 `json:"pins,omitempty"`
+	}
+)
+
+// New returns an initialized SkynetTokens.
+func New(persistDir string) (*SkynetTokens, error) {
+	st := &SkynetTokens{
+		tokens:            make(map[string]persistToken),
+		hashes:            make(map[crypto.Hash]string),
+		reserved:          make(map[string]uint64),
+		staticPersistPath: filepath.Join(persistDir, persistFile),
+	}
+	var tokens []persistToken
+	err := persist.LoadJSON(persistMetadata, &tokens, st.staticPersistPath)
+	if os.IsNotExist(err) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to load skynet tokens")
+	}
+	for _, pt := range tokens {
+		st.tokens[pt.ID] = pt
+		st.hashes[pt.Hash] = pt.ID
+	}
+	return st, nil
+}
+
+// Create creates a new token with the given name, scope and quota. It returns
+// the token which is required for authentication together with its
+// information.
+func (st *SkynetTokens) Create(name string, scope skymodules.APITokenScope, quota skymodules.APITokenQuota) (string, skymodules.APIToken, error) {
+	if err := scope.Validate(); err != nil {
+		return "", skymodules.APIToken{}, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(fastrand.Bytes(tokenSize))
+	pt := persistToken{
+		APIToken: skymodules.APIToken{
+			ID:        hex.EncodeToString(fastrand.Bytes(tokenIDSize)),
+			Name:      name,
+			Scope:     scope,
+			Quota:     quota,
+			CreatedAt: time.Now(),
+		},
+		Hash: crypto.HashBytes([]byte(token)),
+	}
+
+	st.mu.Lock()
+	st.tokens[pt.ID] = pt
+	st.hashes[pt.Hash] = pt.ID
+	st.mu.Unlock()
+	if err := st.managedSave(); err != nil {
+		st.mu.Lock()
+		delete(st.tokens, pt.ID)
+		delete(st.hashes, pt.Hash)
+		st.mu.Unlock()
+		return "", skymodules.APIToken{}, errors.AddContext(err, "unable to persist token")
+	}
+	return token, pt.APIToken, nil
+}
+
+// Revoke revokes the token with the given ID.
+func (st *SkynetTokens) Revoke(id string) error {
+	st.mu.Lock()
+	pt, exists := st.tokens[id]
+	if !exists {
+		st.mu.Unlock()
+		return skymodules.ErrAPITokenNotFound
+	}
+	delete(st.tokens, id)
+	delete(st.hashes, pt.Hash)
+	st.mu.Unlock()
+	if err := st.managedSave(); err != nil {
+		st.mu.Lock()
+		st.tokens[id] = pt
+		st.hashes[pt.Hash] = id
+		st.mu.Unlock()
+		return errors.AddContext(err, "unable to persist token revocation")
+	}
+	return nil
+}
+
+// Reserve reserves the given number of bytes of the quota of the token with
+// the given ID for an upload or pin. The check and the reservation are a single
+// step, so concurrent uploads can't exceed the quota together. The bytes count
+// towards the quota until they are either committed or released.
+func (st *SkynetTokens) Reserve(id string, size uint64) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	pt, exists := st.tokens[id]
+	if !exists {
+		return skymodules.ErrAPITokenNotFound
+	}
+	usage := currentUsage(pt.Usage, time.Now())
+	reserved := st.reserved[id] + size
+	quota := pt.Quota
+	if quota.MaxUploadBytesPerDay > 0 && usage.UploadedBytesToday+reserved > quota.MaxUploadBytesPerDay {
+		return skymodules.ErrAPITokenQuotaExceeded
+	}
+	if quota.MaxPinnedBytes > 0 && usage.PinnedBytes+reserved > quota.MaxPinnedBytes {
+		return skymodules.ErrAPITokenQuotaExceeded
+	}
+	st.reserved[id] = reserved
+	return nil
+}
+
+// Release releases the given number of reserved bytes of the token with the
+// given ID without adding them to its usage.
+func (st *SkynetTokens) Release(id string, size uint64) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.release(id, size)
+}
+
+// Commit adds the given number of reserved bytes to the usage of the token
+// with the given ID. The bytes are attributed to the skylink, so they are
+// freed once the skylink is unpinned. If the skylink is already pinned using
+// the token, the bytes are only released since they were charged before. The
+// usage is persisted in the background.
+func (st *SkynetTokens) Commit(id string, skylink skymodules.Skylink, size uint64) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.release(id, size)
+	pt, exists := st.tokens[id]
+	if !exists {
+		return skymodules.ErrAPITokenNotFound
+	}
+	sl := skylink.String()
+	if _, pinned := pt.Pins[sl]; pinned {
+		return nil
+	}
+	usage := currentUsage(pt.Usage, time.Now())
+	usage.UploadedBytesToday += size
+	usage.PinnedBytes += size
+	updated := pt
+	updated.Usage = usage
+	updated.Pins = make(map[string]uint64, len(pt.Pins)+1)
+	for s, n := range pt.Pins {
+		updated.Pins[s] = n
+	}
+	updated.Pins[sl] = size
+	st.tokens[id] = updated
+	st.schedulePersist()
+	return nil
+}
+
+// Unpin frees the bytes the skylink counts towards the pinned bytes of all
+// tokens it was uploaded or pinned with. The usage is persisted in the
+// background.
+func (st *SkynetTokens) Unpin(skylink skymodules.Skylink) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	sl := skylink.String()
+	var unpinned bool
+	for id, pt := range st.tokens {
+		size, pinned := pt.Pins[sl]
+		if !pinned {
+			continue
+		}
+		unpinned = true
+		updated := pt
+		if size > updated.Usage.PinnedBytes {
+			size = updated.Usage.PinnedBytes
+		}
+		updated.Usage.PinnedBytes -= size
+		updated.Pins = make(map[string]uint64, len(pt.Pins))
+		for s, n := range pt.Pins {
+			if s != sl {
+				updated.Pins[s] = n
+			}
+		}
+		st.tokens[id] = updated
+	}
+	if unpinned {
+		st.schedulePersist()
+	}
+	return nil
+}
+
+// SetQuota sets the quota of the token with the given ID.
+func (st *SkynetTokens) SetQuota(id string, quota skymodules.APITokenQuota) error {
+	st.mu.Lock()
+	pt, exists := st.tokens[id]
+	if !exists {
+		st.mu.Unlock()
+		return skymodules.ErrAPITokenNotFound
+	}
+	updated := pt
+	updated.Quota = quota
+	st.tokens[id] = updated
+	st.mu.Unlock()
+	if err := st.managedSave(); err != nil {
+		st.mu.Lock()
+		if current, exists := st.tokens[id]; exists {
+			current.Quota = pt.Quota
+			st.tokens[id] = current
+		}
+		st.mu.Unlock()
+		return errors.AddContext(err, "unable to persist token quota")
+	}
+	return nil
+}
+
+// Usage returns the information about the token with the given ID together
+// with the usage of its quota.
+func (st *SkynetTokens) Usage(id string) (skymodules.APIToken, skymodules.APITokenUsage, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	pt, exists := st.tokens[id]
+	if !exists {
+		return skymodules.APIToken{}, skymodules.APITokenUsage{}, skymodules.ErrAPITokenNotFound
+	}
+	return pt.APIToken, currentUsage(pt.Usage, time.Now()), nil
+}
+
+// Tokens returns the information about all tokens sorted by their creation
+// time.
+func (st *SkynetTokens) Tokens() []skymodules.APIToken {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	tokens := make([]skymodules.APIToken, 0, len(st.tokens))
+	for _, pt := range st.tokens {
+		tokens = append(tokens, pt.APIToken)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].CreatedAt.Before(tokens[j].CreatedAt)
+	})
+	return tokens
+}
+
+// Validate returns the information about the given token. If the token
+// doesn't exist, ErrAPITokenNotFound is returned.
+func (st *SkynetTokens) Validate(token string) (skymodules.APIToken, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	id, exists := st.hashes[crypto.HashBytes([]byte(token))]
+	if !exists {
+		return skymodules.APIToken{}, skymodules.ErrAPITokenNotFound
+	}
+	return st.tokens[id].APIToken, nil
+}
+
+// release releases the given number of reserved bytes of the token with the
+// given ID.
+func (st *SkynetTokens) release(id string, size uint64) {
+	if size >= st.reserved[id] {
+		delete(st.reserved, id)
+		return
+	}
+	st.reserved[id] -= size
+}
+
+// Close persists any pending changes to the usage of the tokens.
+func (st *SkynetTokens) Close() error {
+	st.mu.Lock()
+	st.closed = true
+	if st.persistTimer != nil {
+		st.persistTimer.Stop()
+		st.persistTimer = nil
+	}
+	st.mu.Unlock()
+	return st.managedSave()
+}
+
+// managedSave persists a snapshot of the tokens. The tokens are only locked
+// while taking the snapshot, not while writing it to disk.
+func (st *SkynetTokens) managedSave() error {
+	st.persistMu.Lock()
+	defer st.persistMu.Unlock()
+
+	st.mu.Lock()
+	tokens := make([]persistToken, 0, len(st.tokens))
+	for _, pt := range st.tokens {
+		tokens = append(tokens, pt)
+	}
+	st.mu.Unlock()
+	return persist.SaveJSON(persistMetadata, tokens, st.staticPersistPath)
+}
+
+// schedulePersist schedules persisting the tokens after the usage persist
+// interval unless a persist is scheduled already. This batches the frequent
+// changes to the usage of the tokens into a single write.
+func (st *SkynetTokens) schedulePersist() {
+	if st.persistTimer != nil || st.closed {
+		return
+	}
+	st.persistTimer = time.AfterFunc(usagePersistInterval, st.threadedPersist)
+}
+
+// threadedPersist persists the tokens after a scheduled persist. Failures are
+// retried with the next scheduled persist.
+func (st *SkynetTokens) threadedPersist() {
+	st.mu.Lock()
+	st.persistTimer = nil
+	st.mu.Unlock()
+	if err := st.managedSave(); err != nil {
+		st.mu.Lock()
+		st.schedulePersist()
+		st.mu.Unlock()
+	}
+}
+
+// currentUsage resets the uploaded bytes of the usage if they refer to a day
+// before the given time.
+func currentUsage(usage skymodules.APITokenUsage, now time.Time) skymodules.APITokenUsage {
+	day := now.UTC().Truncate(24 * time.Hour)
+	if !usage.Day.Equal(day) {
+		usage.Day = day
+		usage.UploadedBytesToday = 0
+	}
+	return usage
+}
@@ -0,0 +1,297 @@
+package skynettokens
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/crypto"
+)
+
+// testDir is a helper function for creating the testing directory
+func testDir(name string) string {
+	dir := build.TempDir("skynettokens", name)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		panic(err)
+	}
+	return dir
+}
+
+// TestSkynetTokens tests creating, validating and revoking tokens as well as
+// their persistence.
+func TestSkynetTokens(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	testdir := testDir(t.Name())
+	st, err := New(testdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Tokens()) != 0 {
+		t.Fatal("expected no tokens")
+	}
+
+	// Invalid scopes are rejected.
+	if _, _, err := st.Create("invalid", "superuser", skymodules.APITokenQuota{}); !errors.Contains(err, skymodules.ErrInvalidAPITokenScope) {
+		t.Fatal("expected invalid scope error", err)
+	}
+
+	// Create two tokens.
+	uploadToken, upload, err := st.Create("uploader", skymodules.APITokenScopeUpload, skymodules.APITokenQuota{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	adminToken, admin, err := st.Create("admin", skymodules.APITokenScopeAdmin, skymodules.APITokenQuota{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uploadToken == adminToken || upload.ID == admin.ID {
+		t.Fatal("tokens should be unique")
+	}
+
+	// Validate them.
+	if token, err := st.Validate(uploadToken); err != nil || token != upload {
+		t.Fatal("unexpected validation result", token, err)
+	}
+	if _, err := st.Validate("notatoken"); !errors.Contains(err, skymodules.ErrAPITokenNotFound) {
+		t.Fatal("expected token not found error", err)
+	}
+
+	// Revoke the upload token.
+	if err := st.Revoke(upload.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Revoke(upload.ID); !errors.Contains(err, skymodules.ErrAPITokenNotFound) {
+		t.Fatal("expected token not found error", err)
+	}
+	if _, err := st.Validate(uploadToken); !errors.Contains(err, skymodules.ErrAPITokenNotFound) {
+		t.Fatal("revoked token shouldn't be valid", err)
+	}
+
+	// Reload the tokens. Only the admin token should remain.
+	st, err = New(testdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens := st.Tokens()
+	if len(tokens) != 1 || tokens[0].ID != admin.ID || tokens[0].Scope != skymodules.APITokenScopeAdmin {
+		t.Fatal("unexpected tokens", tokens)
+	}
+	if _, err := st.Validate(adminToken); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestSkynetTokensUsage tests setting quotas and tracking the usage of tokens.
+func TestSkynetTokensUsage(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	testdir := testDir(t.Name())
+	st, err := New(testdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quota := skymodules.APITokenQuota{MaxUploadBytesPerDay: 1000}
+	_, token, err := st.Create("uploader", skymodules.APITokenScopeUpload, quota)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add some uploads.
+	sl1, err := skymodules.NewSkylinkV1(crypto.Hash{1}, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sl2, err := skymodules.NewSkylinkV1(crypto.Hash{2}, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, upload := range []struct {
+		skylink skymodules.Skylink
+		size    uint64
+	}{{sl1, 100}, {sl2, 50}} {
+		if err := st.Reserve(token.ID, upload.size); err != nil {
+			t.Fatal(err)
+		}
+		if err := st.Commit(token.ID, upload.skylink, upload.size); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Pinning a skylink again doesn't charge it twice.
+	if err := st.Reserve(token.ID, 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Commit(token.ID, sl1, 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Reserve("unknown", 50); !errors.Contains(err, skymodules.ErrAPITokenNotFound) {
+		t.Fatal("expected token not found error", err)
+	}
+	if len(st.reserved) != 0 {
+		t.Fatal("reservations weren't committed", st.reserved)
+	}
+
+	// Update the quota.
+	quota.MaxSkyfileSize = 10
+	if err := st.SetQuota(token.ID, quota); err != nil {
+		t.Fatal(err)
+	}
+
+	// Check the usage after reloading.
+	st, err = New(testdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, usage, err := st.Usage(token.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Quota != quota {
+		t.Fatal("wrong quota", info.Quota)
+	}
+	if usage.UploadedBytesToday != 150 || usage.PinnedBytes != 150 {
+		t.Fatal("wrong usage", usage)
+	}
+
+	// The uploaded bytes are reset on the next day but the pinned bytes
+	// aren't.
+	usage = currentUsage(usage, time.Now().Add(24*time.Hour))
+	if usage.UploadedBytesToday != 0 || usage.PinnedBytes != 150 {
+		t.Fatal("wrong usage", usage)
+	}
+
+	// Unpinning a skylink frees its pinned bytes.
+	if err := st.Unpin(sl1); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Unpin(sl1); err != nil {
+		t.Fatal(err)
+	}
+	_, usage, err = st.Usage(token.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.UploadedBytesToday != 150 || usage.PinnedBytes != 50 {
+		t.Fatal("wrong usage", usage)
+	}
+
+	// The usage is persisted in the background.
+	err = build.Retry(100, 10*time.Millisecond, func() error {
+		reloaded, err := New(testdir)
+		if err != nil {
+			return err
+		}
+		_, usage, err := reloaded.Usage(token.ID)
+		if err != nil {
+			return err
+		}
+		if usage.PinnedBytes != 50 {
+			return fmt.Errorf("wrong usage %v", usage)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pending changes are persisted when closing the tokens.
+	if err := st.Unpin(sl2); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+	st, err = New(testdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, usage, err = st.Usage(token.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.PinnedBytes != 0 {
+		t.Fatal("wrong usage", usage)
+	}
+}
+
+// TestSkynetTokensReserve tests that reservations count towards the quota of a
+// token until they are committed or released.
+func TestSkynetTokensReserve(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	st, err := New(testDir(t.Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	quota := skymodules.APITokenQuota{MaxUploadBytesPerDay: 1000, MaxPinnedBytes: 500}
+	_, token, err := st.Create("uploader", skymodules.APITokenScopeUpload, quota)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sl, err := skymodules.NewSkylinkV1(crypto.Hash{1}, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Concurrent reservations can't exceed the pinned bytes together.
+	var wg sync.WaitGroup
+	var succeeded uint64
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if st.Reserve(token.ID, 100) == nil {
+				atomic.AddUint64(&succeeded, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if succeeded != 5 {
+		t.Fatal("wrong number of reservations", succeeded)
+	}
+
+	// Releasing a reservation frees its bytes.
+	st.Release(token.ID, 100)
+	if err := st.Reserve(token.ID, 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Reserve(token.ID, 1); !errors.Contains(err, skymodules.ErrAPITokenQuotaExceeded) {
+		t.Fatal("expected quota exceeded error", err)
+	}
+
+	// Committing moves the bytes from the reservation to the usage. Freeing
+	// them by unpinning the skylink makes room for new reservations.
+	if err := st.Commit(token.ID, sl, 500); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Reserve(token.ID, 1); !errors.Contains(err, skymodules.ErrAPITokenQuotaExceeded) {
+		t.Fatal("expected quota exceeded error", err)
+	}
+	if err := st.Unpin(sl); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Reserve(token.ID, 500); err != nil {
+		t.Fatal(err)
+	}
+
+	// The uploaded bytes aren't freed though.
+	if err := st.Reserve(token.ID, 1); !errors.Contains(err, skymodules.ErrAPITokenQuotaExceeded) {
+		t.Fatal("expected quota exceeded error", err)
+	}
+}
@@ -270,17 +270,27 @@ func (r *Renter) managedSnapshotExists(name string) bool {
 // managedSaveSnapshot saves snapshot metadata to disk.
 func (r *Renter) managedSaveSnapshot(meta skymodules.UploadedBackup) error {
 	id := r.mu.Lock()
-	defer r.mu.Unlock(id)
+	changed := r.updateUploadedBackups(meta)
+	r.mu.Unlock(id)
+	if !changed {
+		return nil
+	}
+	return r.managedSaveSync()
+}
+
+// updateUploadedBackups adds or updates the metadata of a snapshot within the
+// renter's persistence. It returns whether the persistence changed.
+func (r *Renter) updateUploadedBackups(meta skymodules.UploadedBackup) bool {
 	// Check whether we've already saved this snapshot.
 	for i, ub := range r.persist.UploadedBackups {
 		if ub.UID == meta.UID {
 			if ub == meta {
 				// nothing changed
-				return nil
+				return false
 			}
 			// something changed; overwrite existing entry
 			r.persist.UploadedBackups[i] = meta
-			return r.saveSync()
+			return true
 		}
 	}
 	// Append the new snapshot.
@@ -299,11 +309,7 @@ func (r *Renter) managedSaveSnapshot(meta skymodules.UploadedBackup) error {
 		return r.persist.UploadedBackups[i].CreationDate > r.persist.UploadedBackups[j].CreationDate
 	})
 	r.persist.UploadedBackups = r.persist.UploadedBackups[:len(entryTable)]
-	// Save the result.
-	if err := r.saveSync(); err != nil {
-		return err
-	}
-	return nil
+	return true
 }
 
 // managedDownloadSnapshotTable will fetch the snapshot table from the host.
@@ -373,7 +379,7 @@ func (r *Renter) managedUploadSnapshot(meta skymodules.UploadedBackup, dotSia []
 	// Submit a job to each worker. Make sure the response channel has enough
 	// room in the buffer for all results, this way workers are not being
 	// blocked when returning their results.
-	maxWait, cancel := context.WithTimeout(r.tg.StopCtx(), maxSnapshotUploadTime)
+	maxWait, cancel := context.WithTimeout(contextWithTrafficClass(r.tg.StopCtx(), skymodules.TrafficClassBackup), maxSnapshotUploadTime)
 	defer cancel()
 	responseChan := make(chan *jobUploadSnapshotResponse, len(workers))
 	queued := 0
@@ -661,17 +667,9 @@ func (r *Renter) threadedSynchronizeSnapshots() {
 					return errors.Compose(err, entry.Close())
 				}
 
-				// Close out the entry.
-				err = entry.Close()
-				if err != nil {
-					return err
-				}
-
-				// Delete the local siafile.
-				if err := r.staticFileSystem.DeleteFile(info.SiaPath); err != nil {
-					return err
-				}
-				return nil
+				// Delete the local siafile and close out the entry.
+				err = r.managedDeleteFileNode(info.SiaPath, entry)
+				return errors.Compose(err, entry.Close())
 			}()
 			if err != nil {
 				r.staticLog.Println("Failed to upload snapshot .sia:", err)
@@ -806,9 +804,9 @@ func (r *Renter) threadedSynchronizeSnapshots() {
 		for fcid := range syncedContracts {
 			r.persist.SyncedContracts = append(r.persist.SyncedContracts, fcid)
 		}
-		if err := r.saveSync(); err != nil {
+		r.mu.Unlock(id)
+		if err := r.managedSaveSync(); err != nil {
 			r.staticLog.Println("Failed to update set of synced hosts:", err)
 		}
-		r.mu.Unlock(id)
 	}
 }
@@ -3,18 +3,18 @@ package renter
 // NOTE: This stream buffer is uninfished in a couple of ways. The first way is
 // that it's not possible to cancel fetches. The second way is that fetches are
 // not prioritized, there should be a higher priority on data that is closer to
-// the current stream offset. The third is that the amount of data which gets
-// fetched is not dynamically adjusted. The streamer really should be monitoring
-// the total amount of time it takes for a call to the data source to return
-// some data, and should buffer accordingly. If auto-adjusting the lookahead
-// size, care needs to be taken to ensure not to exceed the
-// bytesBufferedPerStream size, as exceeding that will cause issues with the
-// lru, and cause data fetches to be evicted before they become useful.
+// the current stream offset. The amount of data which gets fetched is adjusted
+// to the rate at which the consumer reads from the stream. Care is taken to
+// never exceed the bytesBufferedPerStream size with the lookahead, as exceeding
+// that will cause issues with the lru, and cause data fetches to be evicted
+// before they become useful.
 
 import (
 	"context"
 	"io"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
@@ -51,6 +51,10 @@ var (
 	// available.
 	errTimeout = errors.New("could not get data from data section, context timed out")
 
+	// errDataSectionCancelled is returned when the fetch of a data section was
+	// cancelled because no stream needed the data anymore.
+	errDataSectionCancelled = errors.New("data section fetch was cancelled")
+
 	// bytesBufferedPerStream is the total amount of data that gets allocated
 	// per stream. If the RequestSize of a stream buffer is less than three
 	// times the bytesBufferedPerStream, that much data will be allocated
@@ -99,6 +103,26 @@ var (
 		Standard: uint64(1 << 23), // 8 MiB
 		Testing:  uint64(1 << 6),  // 64 bytes
 	}).(uint64)
+
+	// readAheadDuration is the amount of time worth of data, at the rate the
+	// consumer of a stream is reading, that the stream tries to fetch ahead of
+	// the current offset. The resulting lookahead is never smaller than the
+	// minimumLookahead and never exceeds the bytesBufferedPerStream.
+	readAheadDuration = build.Select(build.Var{
+		Dev:      time.Second * 10,
+		Standard: time.Second * 10,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// readRateMinSampleDuration is the minimum amount of time a stream needs
+	// to be read from sequentially before its read rate is used to adjust the
+	// lookahead. This prevents quick seeks from causing a lot of data to be
+	// fetched which will never be read.
+	readRateMinSampleDuration = build.Select(build.Var{
+		Dev:      time.Second * 2,
+		Standard: time.Second * 2,
+		Testing:  time.Millisecond * 100,
+	}).(time.Duration)
 )
 
 // streamBufferDataSource is an interface that the stream buffer uses to fetch
@@ -156,8 +180,14 @@ type streamBufferDataSource interface {
 // source. It contains the data being downloaded and an error in case of
 // failure.
 type readResponse struct {
-	staticData []byte
-	staticErr  error
+	staticData       []byte
+	staticErr        error
+	staticProvenance []skymodules.ChunkProvenance
+
+	// staticBandwidthUp and staticBandwidthDown are the number of bytes
+	// which were sent to and received from hosts to fetch the data.
+	staticBandwidthUp   uint64
+	staticBandwidthDown uint64
 }
 
 // dataSection represents a section of data from a data source. The data section
@@ -169,12 +199,21 @@ type dataSection struct {
 	// dataAvailable, externData, externDuration, and externErr work together.
 	// The data and error are not allowed to be accessed by external threads
 	// until the data available channel has been closed. Once the dataAvailable
-	// channel has been closed, externData, externDuration and externErr are to
-	// be treated like static fields.
-	dataAvailable  chan struct{}
-	externDuration time.Duration
-	externData     []byte
-	externErr      error
+	// channel has been closed, externData, externDuration, externErr,
+	// externProvenance and the extern bandwidth fields are to be treated
+	// like static fields.
+	dataAvailable       chan struct{}
+	externDuration      time.Duration
+	externData          []byte
+	externErr           error
+	externProvenance    []skymodules.ChunkProvenance
+	externBandwidthUp   uint64
+	externBandwidthDown uint64
+
+	// staticCancel cancels the fetch of the data section and staticFetchSize
+	// is the number of bytes being fetched.
+	staticCancel    context.CancelFunc
+	staticFetchSize uint64
 
 	refCount uint64
 }
@@ -190,6 +229,29 @@ type stream struct {
 	lru    *leastRecentlyUsedCache
 	offset uint64
 
+	// readStart and bytesRead are used to measure the rate at which the
+	// consumer reads from the stream. The measurement is restarted whenever
+	// the stream seeks to a different offset.
+	readStart time.Time
+	bytesRead uint64
+
+	// provenance contains the hosts which served the pieces of the chunks
+	// that were read from the stream, indexed by the chunk index.
+	provenance map[uint64][]skymodules.PieceProvenance
+
+	// bandwidthUp and bandwidthDown are the number of bytes which were sent
+	// to and received from hosts to fetch the data sections the stream read
+	// from. bandwidthSections contains the indices of the data sections
+	// which were already accounted for.
+	bandwidthUp       uint64
+	bandwidthDown     uint64
+	bandwidthSections map[uint64]struct{}
+
+	// staticMaxLookahead is the maximum amount of data the stream will fetch
+	// ahead of the current offset. It is bounded by the number of data
+	// sections the lru can hold.
+	staticMaxLookahead uint64
+
 	mu                 sync.Mutex
 	staticStreamBuffer *streamBuffer
 
@@ -218,6 +280,8 @@ type streamBuffer struct {
 	staticStreamBufferSet *streamBufferSet
 	staticStreamID        skymodules.DataSourceID
 	staticPricePerMS      types.Currency
+	staticOverdrive       skymodules.OverdriveSettings
+	staticTrafficClass    skymodules.TrafficClass
 	staticWallet          modules.SiacoinSenderMulti
 	staticSpan            opentracing.Span
 }
@@ -226,6 +290,11 @@ type streamBuffer struct {
 // When a new stream is created, the stream buffer set is referenced to check
 // whether another stream using the same data source already exists.
 type streamBufferSet struct {
+	// atomicCancelledBytes is the number of bytes of data sections whose
+	// fetch was cancelled before it completed because the streams that
+	// requested them seeked elsewhere.
+	atomicCancelledBytes uint64
+
 	streams map[skymodules.DataSourceID]*streamBuffer
 
 	staticStatsCollector *skymodules.DistributionTracker
@@ -257,6 +326,11 @@ func newStreamBufferSet(statsCollector *skymodules.DistributionTracker, tg *thre
 // Each stream has a separate LRU for determining what data to buffer. Because
 // the LRU is distinct to the stream, the shared cache feature will not result
 // in one stream evicting data from another stream's LRU.
+//
+// If a new stream buffer is created, the overdrive settings and traffic class
+// attached to the context are used for all data the stream buffer fetches.
+// Data that is fetched ahead of the streams' offsets uses the streaming class
+// unless the context's class has an even lower priority.
 func (sbs *streamBufferSet) callNewStream(ctx context.Context, dataSource streamBufferDataSource, initialOffset uint64, timeout time.Duration, pricePerMS types.Currency) *stream {
 	// Grab the streamBuffer for the provided sourceID. If no streamBuffer for
 	// the sourceID exists, create a new one.
@@ -271,6 +345,8 @@ func (sbs *streamBufferSet) callNewStream(ctx context.Context, dataSource stream
 			staticDataSource:      dataSource,
 			staticDataSectionSize: dataSource.RequestSize(),
 			staticPricePerMS:      pricePerMS,
+			staticOverdrive:       overdriveSettingsFromContext(ctx),
+			staticTrafficClass:    trafficClassFromContext(ctx),
 			staticStreamBufferSet: sbs,
 			staticStreamID:        sourceID,
 			staticSpan:            opentracing.SpanFromContext(ctx),
@@ -334,6 +410,16 @@ func (ds *dataSection) managedData(ctx context.Context) (data []byte, err error)
 	return
 }
 
+// available returns whether the fetch of the data section has completed.
+func (ds *dataSection) available() bool {
+	select {
+	case <-ds.dataAvailable:
+		return true
+	default:
+		return false
+	}
+}
+
 // Close will release all of the resources held by a stream.
 //
 // Before removing the stream, this function will sleep for some time. This is
@@ -456,15 +542,77 @@ func (s *stream) Read(b []byte) (int, error) {
 	if err != nil {
 		return 0, errors.AddContext(err, "read call failed because data section fetch failed")
 	}
+	s.addProvenance(dataSection.externProvenance)
+	s.addBandwidth(currentSection, dataSection)
+
 	// Copy the data into the read request.
 	n := copy(b, data[offsetInSection:offsetInSection+bytesToRead])
 	s.offset += uint64(n)
+	s.bytesRead += uint64(n)
 
 	// Send the call to prepare the next data section.
 	s.prepareOffset()
 	return n, nil
 }
 
+// Provenance returns the hosts which served the pieces of the chunks that were
+// read from the stream so far, sorted by chunk index.
+func (s *stream) Provenance() []skymodules.ChunkProvenance {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	provenance := make([]skymodules.ChunkProvenance, 0, len(s.provenance))
+	for chunkIndex, pieces := range s.provenance {
+		provenance = append(provenance, skymodules.ChunkProvenance{
+			ChunkIndex: chunkIndex,
+			Pieces:     append([]skymodules.PieceProvenance{}, pieces...),
+		})
+	}
+	sort.Slice(provenance, func(i, j int) bool {
+		return provenance[i].ChunkIndex < provenance[j].ChunkIndex
+	})
+	return provenance
+}
+
+// addProvenance adds the provenance of a data section to the stream. Pieces
+// which were served by the same host for multiple data sections are only
+// added once.
+func (s *stream) addProvenance(provenance []skymodules.ChunkProvenance) {
+	for _, cp := range provenance {
+		pieces := s.provenance[cp.ChunkIndex]
+	LOOP:
+		for _, pp := range cp.Pieces {
+			for _, existing := range pieces {
+				if existing.PieceIndex == pp.PieceIndex && existing.HostKey.Equals(pp.HostKey) {
+					continue LOOP
+				}
+			}
+			pieces = append(pieces, pp)
+		}
+		s.provenance[cp.ChunkIndex] = pieces
+	}
+}
+
+// Bandwidth returns the number of bytes which were sent to and received from
+// hosts to fetch the data that was read from the stream so far. This includes
+// the overhead of overdrive workers and failed jobs.
+func (s *stream) Bandwidth() (up, down uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bandwidthUp, s.bandwidthDown
+}
+
+// addBandwidth adds the bandwidth that was used to fetch a data section to
+// the stream. Every data section is only accounted for once, even if the
+// stream reads from it multiple times.
+func (s *stream) addBandwidth(index uint64, ds *dataSection) {
+	if _, exists := s.bandwidthSections[index]; exists {
+		return
+	}
+	s.bandwidthSections[index] = struct{}{}
+	s.bandwidthUp += ds.externBandwidthUp
+	s.bandwidthDown += ds.externBandwidthDown
+}
+
 // Seek will move the read head of the stream to the provided offset.
 func (s *stream) Seek(offset int64, whence int) (int64, error) {
 	// Input checking.
@@ -476,6 +624,7 @@ func (s *stream) Seek(offset int64, whence int) (int64, error) {
 
 	// Update the offset of the stream according to the inputs.
 	dataSize := s.staticStreamBuffer.staticDataSize
+	oldOffset := s.offset
 	switch whence {
 	case io.SeekStart:
 		s.offset = uint64(offset)
@@ -494,66 +643,142 @@ func (s *stream) Seek(offset int64, whence int) (int64, error) {
 		return int64(s.offset), errors.New("invalid value for 'whence' in call to seek")
 	}
 
+	// If the offset changed, the consumer is no longer reading sequentially.
+	// Restart the read rate measurement to avoid prefetching a lot of data
+	// after a quick seek.
+	if s.offset != oldOffset {
+		s.readStart = time.Now()
+		s.bytesRead = 0
+		s.cancelStalePrefetches()
+	}
+
 	// Prepare the fetch of the updated offset.
 	s.prepareOffset()
 	return int64(s.offset), nil
 }
 
-// prepareOffset will ensure that the dataSection containing the offset is made
-// available in the LRU, and that the following dataSection is also available.
-func (s *stream) prepareOffset() {
+// lookahead returns the amount of data that should be buffered ahead of the
+// current offset. It is proportional to the rate at which the consumer reads
+// from the stream, bounded by the minimumLookahead and the maximum lookahead
+// of the stream.
+func (s *stream) lookahead() uint64 {
+	lookahead := minimumLookahead
+	elapsed := time.Since(s.readStart)
+	if elapsed >= readRateMinSampleDuration {
+		readRate := float64(s.bytesRead) / elapsed.Seconds()
+		adaptiveLookahead := uint64(readRate * readAheadDuration.Seconds())
+		if adaptiveLookahead > lookahead {
+			lookahead = adaptiveLookahead
+		}
+	}
+	if lookahead > s.staticMaxLookahead {
+		lookahead = s.staticMaxLookahead
+	}
+	return lookahead
+}
+
+// prefetchWindow returns the indices of the first and last data section the
+// stream should buffer for its current offset. The window starts with the data
+// section containing the offset and always includes the following data
+// section, more sections are added until the lookahead is covered or the end
+// of the stream is reached. If the offset is at the end of the data, ok is
+// false.
+func (s *stream) prefetchWindow() (first, last uint64, ok bool) {
 	// Convenience variables.
 	dataSize := s.staticStreamBuffer.staticDataSize
 	dataSectionSize := s.staticStreamBuffer.staticDataSectionSize
 
-	// If the offset is already at the end of the data, there is nothing to do.
+	// If the offset is already at the end of the data, there is nothing to
+	// buffer.
 	if s.offset == dataSize {
+		return 0, 0, false
+	}
+	first = s.offset / dataSectionSize
+	last = first
+
+	// If there is a following data section, add that as well. This is done
+	// regardless of the minimumLookahead, we always want to buffer at least
+	// one more piece than the current piece.
+	if (first+1)*dataSectionSize < dataSize {
+		last = first + 1
+	}
+
+	// Keep adding more pieces to the buffer until we have buffered at least
+	// the lookahead total data or have reached the end of the stream.
+	nextIndex := first + 2
+	lookahead := s.lookahead()
+	for i := dataSectionSize * 2; i < lookahead && nextIndex*dataSectionSize < dataSize; i += dataSectionSize {
+		last = nextIndex
+		nextIndex++
+	}
+	return first, last, true
+}
+
+// prepareOffset will ensure that the dataSection containing the offset is made
+// available in the LRU, and that the following dataSections within the
+// prefetch window are also available.
+func (s *stream) prepareOffset() {
+	first, last, ok := s.prefetchWindow()
+	if !ok {
 		return
 	}
 
 	// Update the current data section. The update call will trigger the
 	// streamBuffer to fetch the dataSection if the dataSection is not already
 	// in the streamBuffer cache.
-	index := s.offset / dataSectionSize
-	s.lru.callUpdate(index)
+	s.lru.callUpdate(first, s.staticStreamBuffer.staticTrafficClass)
 
-	// If there is a following data section, update that as well. This update is
-	// done regardless of the minimumLookahead, we always want to buffer at
-	// least one more piece than the current piece.
-	nextIndex := index + 1
-	if nextIndex*dataSectionSize < dataSize {
-		s.lru.callUpdate(nextIndex)
+	// Update the remaining data sections of the window using a lower priority
+	// traffic class.
+	lookaheadClass := lowerPriorityTrafficClass(s.staticStreamBuffer.staticTrafficClass, skymodules.TrafficClassStreaming)
+	for index := first + 1; index <= last; index++ {
+		s.lru.callUpdate(index, lookaheadClass)
 	}
+}
 
-	// Keep adding more pieces to the buffer until we have buffered at least
-	// minimumLookahead total data or have reached the end of the stream.
-	nextIndex++
-	for i := dataSectionSize * 2; i < minimumLookahead && nextIndex*dataSectionSize < dataSize; i += dataSectionSize {
-		s.lru.callUpdate(nextIndex)
-		nextIndex++
-	}
+// cancelStalePrefetches releases all data sections of the stream which are
+// still being fetched but fall outside of the prefetch window of the current
+// offset. Data sections that are not used by any other stream have their fetch
+// cancelled. This is called after seeking to stop spending bandwidth and money
+// on data for the old position.
+func (s *stream) cancelStalePrefetches() uint64 {
+	first, last, ok := s.prefetchWindow()
+	return s.lru.callEvictPending(func(index uint64) bool {
+		return !ok || index < first || index > last
+	})
 }
 
 // callFetchDataSection will increment the refcount of a dataSection in the
 // stream buffer. If the dataSection is not currently available in the stream
-// buffer, the data section will be fetched from the dataSource.
-func (sb *streamBuffer) callFetchDataSection(index uint64) {
+// buffer, the data section will be fetched from the dataSource using the
+// provided traffic class.
+func (sb *streamBuffer) callFetchDataSection(index uint64, class skymodules.TrafficClass) {
 	sb.mu.Lock()
 	defer sb.mu.Unlock()
 
 	// Fetch the relevant dataSection, creating a new one if necessary.
 	dataSection, exists := sb.dataSections[index]
 	if !exists {
-		dataSection = sb.newDataSection(index)
+		dataSection = sb.newDataSection(index, class)
 	}
 	// Increment the refcount of the dataSection.
 	dataSection.refCount++
 }
 
+// callDataSectionPending returns whether the data section with the given index
+// exists in the stream buffer and is still being fetched.
+func (sb *streamBuffer) callDataSectionPending(index uint64) bool {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	dataSection, exists := sb.dataSections[index]
+	return exists && !dataSection.available()
+}
+
 // callRemoveDataSection will decrement the refcount of a data section in the
 // stream buffer. If the refcount reaches zero, the data section will be deleted
-// from the stream buffer.
-func (sb *streamBuffer) callRemoveDataSection(index uint64) {
+// from the stream buffer. If the data section was still being fetched at that
+// point, the fetch is cancelled and the number of cancelled bytes is returned.
+func (sb *streamBuffer) callRemoveDataSection(index uint64) uint64 {
 	sb.mu.Lock()
 	defer sb.mu.Unlock()
 
@@ -561,14 +786,22 @@ func (sb *streamBuffer) callRemoveDataSection(index uint64) {
 	dataSection, exists := sb.dataSections[index]
 	if !exists {
 		build.Critical("remove called on data section that does not exist")
-		return
+		return 0
 	}
 	// Decrement the refcount.
 	dataSection.refCount--
-	// Delete the data section if the refcount has fallen to zero.
-	if dataSection.refCount == 0 {
-		delete(sb.dataSections, index)
+	if dataSection.refCount > 0 {
+		return 0
+	}
+	// Delete the data section since the refcount has fallen to zero and cancel
+	// the fetch if it is still ongoing.
+	delete(sb.dataSections, index)
+	if dataSection.available() {
+		return 0
 	}
+	dataSection.staticCancel()
+	atomic.AddUint64(&sb.staticStreamBufferSet.atomicCancelledBytes, dataSection.staticFetchSize)
+	return dataSection.staticFetchSize
 }
 
 // managedPrepareNewStream creates a new stream from an existing stream buffer.
@@ -581,10 +814,23 @@ func (sb *streamBuffer) managedPrepareNewStream(ctx context.Context, initialOffs
 		dataSectionsToCache = minimumDataSections
 	}
 
+	// The stream never looks further ahead than the lru can hold, leaving room
+	// for the current data section.
+	maxLookahead := (dataSectionsToCache - 1) * sb.staticDataSectionSize
+	if maxLookahead < minimumLookahead {
+		maxLookahead = minimumLookahead
+	}
+
 	// Create a stream that points to the stream buffer.
 	stream := &stream{
-		lru:    newLeastRecentlyUsedCache(dataSectionsToCache, sb),
-		offset: initialOffset,
+		lru:        newLeastRecentlyUsedCache(dataSectionsToCache, sb),
+		offset:     initialOffset,
+		readStart:  time.Now(),
+		provenance: make(map[uint64][]skymodules.PieceProvenance),
+
+		bandwidthSections: make(map[uint64]struct{}),
+
+		staticMaxLookahead: maxLookahead,
 
 		staticContext:      sb.staticTG.StopCtx(),
 		staticReadTimeout:  timeout,
@@ -597,7 +843,7 @@ func (sb *streamBuffer) managedPrepareNewStream(ctx context.Context, initialOffs
 
 // newDataSection will create a new data section for the streamBuffer and spin
 // up a goroutine to pull the data from the data source.
-func (sb *streamBuffer) newDataSection(index uint64) *dataSection {
+func (sb *streamBuffer) newDataSection(index uint64, class skymodules.TrafficClass) *dataSection {
 	// Convenience variables.
 	dataSize := sb.staticDataSize
 	dataSectionSize := sb.staticDataSectionSize
@@ -615,9 +861,13 @@ func (sb *streamBuffer) newDataSection(index uint64) *dataSection {
 
 	// Create the data section, allocating the right number of bytes for the
 	// ReadAt call to fill out.
+	ctx, cancel := context.WithCancel(sb.staticTG.StopCtx())
 	ds := &dataSection{
 		dataAvailable: make(chan struct{}),
 		externData:    make([]byte, fetchSize),
+
+		staticCancel:    cancel,
+		staticFetchSize: fetchSize,
 	}
 	sb.dataSections[index] = ds
 
@@ -625,6 +875,7 @@ func (sb *streamBuffer) newDataSection(index uint64) *dataSection {
 	// closed when the data is available.
 	go func() {
 		defer close(ds.dataAvailable)
+		defer cancel()
 
 		// Create a child span for the data section
 		spanRef := opentracing.ChildOf(sb.staticSpan.Context())
@@ -647,8 +898,11 @@ func (sb *streamBuffer) newDataSection(index uint64) *dataSection {
 		}
 		defer sb.staticTG.Done()
 
-		// Create a context from our span
-		ctx := opentracing.ContextWithSpan(sb.staticTG.StopCtx(), span)
+		// Create a context from our span, the overdrive settings of the
+		// stream buffer and the traffic class of the section.
+		ctx = opentracing.ContextWithSpan(ctx, span)
+		ctx = contextWithOverdriveSettings(ctx, sb.staticOverdrive)
+		ctx = contextWithTrafficClass(ctx, class)
 
 		// Grab the data from the data source.
 		start := time.Now()
@@ -659,11 +913,21 @@ func (sb *streamBuffer) newDataSection(index uint64) *dataSection {
 			ds.externErr = errors.AddContext(response.staticErr, "data section ReadStream failed")
 			ds.externDuration = time.Since(start)
 			ds.externData = response.staticData
+			ds.externProvenance = response.staticProvenance
+			ds.externBandwidthUp = response.staticBandwidthUp
+			ds.externBandwidthDown = response.staticBandwidthDown
 			if ds.externErr == nil {
 				sb.staticStreamBufferSet.staticStatsCollector.AddDataPoint(ds.externDuration)
 			}
-		case <-sb.staticTG.StopChan():
-			ds.externErr = errors.AddContext(errTimeout, "failed to read response from ReadStream")
+		case <-ctx.Done():
+			// The context is closed either because the stream buffer was
+			// shut down or because the fetch was cancelled.
+			select {
+			case <-sb.staticTG.StopChan():
+				ds.externErr = errors.AddContext(errTimeout, "failed to read response from ReadStream")
+			default:
+				ds.externErr = errDataSectionCancelled
+			}
 		}
 	}()
 	return ds
@@ -7,10 +7,12 @@ import (
 	"io"
 	"io/ioutil"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
+	"gitlab.com/SkynetLabs/skyd/build"
 	"gitlab.com/SkynetLabs/skyd/skymodules"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/types"
@@ -501,3 +503,207 @@ func TestStreamSmoke(t *testing.T) {
 		t.Fatal("bad")
 	}
 }
+
+// TestStreamLookahead is a unit test for the adaptive lookahead of a stream.
+func TestStreamLookahead(t *testing.T) {
+	t.Parallel()
+
+	maxLookahead := 100 * minimumLookahead
+	s := &stream{
+		readStart:          time.Now(),
+		staticMaxLookahead: maxLookahead,
+	}
+
+	// Without a long enough sample, the minimum lookahead is used.
+	s.bytesRead = maxLookahead
+	if la := s.lookahead(); la != minimumLookahead {
+		t.Fatal("wrong lookahead", la)
+	}
+
+	// A slow consumer should result in the minimum lookahead.
+	s.readStart = time.Now().Add(-readAheadDuration)
+	s.bytesRead = minimumLookahead / 2
+	if la := s.lookahead(); la != minimumLookahead {
+		t.Fatal("wrong lookahead", la)
+	}
+
+	// A faster consumer should result in a proportionally larger lookahead.
+	s.bytesRead = 10 * minimumLookahead
+	if la := s.lookahead(); la < 9*minimumLookahead || la > 10*minimumLookahead {
+		t.Fatal("wrong lookahead", la)
+	}
+
+	// A very fast consumer is capped by the max lookahead.
+	s.bytesRead = 1000 * minimumLookahead
+	if la := s.lookahead(); la != maxLookahead {
+		t.Fatal("wrong lookahead", la)
+	}
+}
+
+// TestStreamProvenance is a unit test for the provenance tracking of a stream.
+func TestStreamProvenance(t *testing.T) {
+	t.Parallel()
+
+	s := &stream{
+		provenance: make(map[uint64][]skymodules.PieceProvenance),
+	}
+	hk1 := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: fastrand.Bytes(32)}
+	hk2 := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: fastrand.Bytes(32)}
+
+	// Add the provenance of two data sections which share chunk 1.
+	s.addProvenance([]skymodules.ChunkProvenance{
+		{ChunkIndex: 1, Pieces: []skymodules.PieceProvenance{{PieceIndex: 0, HostKey: hk1}}},
+	})
+	s.addProvenance([]skymodules.ChunkProvenance{
+		{ChunkIndex: 1, Pieces: []skymodules.PieceProvenance{{PieceIndex: 0, HostKey: hk1}, {PieceIndex: 1, HostKey: hk2}}},
+		{ChunkIndex: 0, Pieces: []skymodules.PieceProvenance{{PieceIndex: 2, HostKey: hk2}}},
+	})
+
+	// The chunks should be sorted and the duplicate piece only listed once.
+	provenance := s.Provenance()
+	if len(provenance) != 2 || provenance[0].ChunkIndex != 0 || provenance[1].ChunkIndex != 1 {
+		t.Fatal("unexpected provenance", provenance)
+	}
+	if len(provenance[0].Pieces) != 1 || len(provenance[1].Pieces) != 2 {
+		t.Fatal("unexpected pieces", provenance)
+	}
+	if provenance[1].Pieces[1].PieceIndex != 1 || !provenance[1].Pieces[1].HostKey.Equals(hk2) {
+		t.Fatal("unexpected piece", provenance[1].Pieces[1])
+	}
+}
+
+// TestStreamBandwidth is a unit test for the bandwidth tracking of a stream.
+func TestStreamBandwidth(t *testing.T) {
+	t.Parallel()
+
+	s := &stream{
+		bandwidthSections: make(map[uint64]struct{}),
+	}
+	ds1 := &dataSection{externBandwidthUp: 10, externBandwidthDown: 100}
+	ds2 := &dataSection{externBandwidthUp: 20, externBandwidthDown: 200}
+
+	// Reading from the same section multiple times only counts once.
+	s.addBandwidth(0, ds1)
+	s.addBandwidth(0, ds1)
+	s.addBandwidth(1, ds2)
+	if up, down := s.Bandwidth(); up != 30 || down != 300 {
+		t.Fatal("unexpected bandwidth", up, down)
+	}
+}
+
+// blockingDataSource is a data source which never returns any data. It counts
+// the reads which were cancelled through their context.
+type blockingDataSource struct {
+	*mockDataSource
+	atomicCancelled uint64
+}
+
+// ReadStream implements streamBufferDataSource.
+func (bds *blockingDataSource) ReadStream(ctx context.Context, offset, fetchSize uint64, pricePerMS types.Currency) chan *readResponse {
+	go func() {
+		<-ctx.Done()
+		atomic.AddUint64(&bds.atomicCancelled, 1)
+	}()
+	return make(chan *readResponse)
+}
+
+// TestStreamSeekCancelsPrefetches checks that seeking a stream cancels the
+// fetches of data sections outside of the new prefetch window, unless they are
+// still needed by another stream.
+func TestStreamSeekCancelsPrefetches(t *testing.T) {
+	t.Parallel()
+
+	ctx := opentracing.ContextWithSpan(context.Background(), testSpan())
+	var tg threadgroup.ThreadGroup
+	defer func() {
+		if err := tg.Stop(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	dataSectionSize := uint64(16)
+	dataSource := &blockingDataSource{
+		mockDataSource: newMockDataSource(fastrand.Bytes(1000), dataSectionSize),
+	}
+	sbs := newStreamBufferSet(skymodules.NewDistributionTrackerStandard(), &tg)
+
+	// Create two streams at offset 0. With the minimum lookahead, both should
+	// be buffering the first 4 data sections.
+	stream1 := sbs.callNewStream(ctx, dataSource, 0, 0, types.ZeroCurrency)
+	stream2, exists := sbs.callNewStreamFromID(ctx, dataSource.ID(), 0, 0)
+	if !exists {
+		t.Fatal("stream buffer should exist")
+	}
+	sb := stream1.staticStreamBuffer
+	numSections := func() int {
+		sb.mu.Lock()
+		defer sb.mu.Unlock()
+		return len(sb.dataSections)
+	}
+	if n := numSections(); n != 4 {
+		t.Fatal("wrong number of data sections", n)
+	}
+
+	// Seek the first stream. The old sections are still used by the second
+	// stream so nothing should be cancelled.
+	_, err := stream1.Seek(800, io.SeekStart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := numSections(); n != 8 {
+		t.Fatal("wrong number of data sections", n)
+	}
+	if cancelled := atomic.LoadUint64(&sbs.atomicCancelledBytes); cancelled != 0 {
+		t.Fatal("nothing should have been cancelled", cancelled)
+	}
+
+	// Seek the second stream by 2 sections. The first 2 sections are no
+	// longer needed and should be cancelled while the other 2 are kept.
+	_, err = stream2.Seek(int64(2*dataSectionSize), io.SeekStart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := numSections(); n != 8 {
+		t.Fatal("wrong number of data sections", n)
+	}
+	sb.mu.Lock()
+	_, exists0 := sb.dataSections[0]
+	_, exists1 := sb.dataSections[1]
+	_, exists2 := sb.dataSections[2]
+	sb.mu.Unlock()
+	if exists0 || exists1 || !exists2 {
+		t.Fatal("wrong data sections were removed")
+	}
+	if cancelled := atomic.LoadUint64(&sbs.atomicCancelledBytes); cancelled != 2*dataSectionSize {
+		t.Fatal("wrong number of cancelled bytes", cancelled)
+	}
+
+	// The reads of the data source should be cancelled.
+	err = build.Retry(100, 10*time.Millisecond, func() error {
+		if cancelled := atomic.LoadUint64(&dataSource.atomicCancelled); cancelled != 2 {
+			return fmt.Errorf("expected 2 cancelled reads but got %v", cancelled)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sections within the window of the stream are never cancelled.
+	cancelled := stream2.cancelStalePrefetches()
+	if cancelled != 0 {
+		t.Fatal("sections within the window shouldn't be cancelled", cancelled)
+	}
+
+	// Seeking to the end of the data cancels all pending sections of the
+	// stream.
+	_, err = stream2.Seek(0, io.SeekEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelled := atomic.LoadUint64(&sbs.atomicCancelledBytes); cancelled != 6*dataSectionSize {
+		t.Fatal("wrong number of cancelled bytes", cancelled)
+	}
+	if n := numSections(); n != 4 {
+		t.Fatal("wrong number of data sections", n)
+	}
+}
@@ -7,6 +7,8 @@ package renter
 
 import (
 	"sync"
+
+	"gitlab.com/SkynetLabs/skyd/skymodules"
 )
 
 // leastRecentlyUsedCacheNode is a node in the leastRecentlyUsedCache. It has an
@@ -61,11 +63,45 @@ func (lru *leastRecentlyUsedCache) callEvictAll() {
 	}
 }
 
+// callEvictPending will remove all nodes from the lru for which stale returns
+// true and whose data sections are still being fetched. The corresponding data
+// sections are released on the stream buffer. The number of bytes whose fetch
+// was cancelled as a result is returned.
+func (lru *leastRecentlyUsedCache) callEvictPending(stale func(index uint64) bool) uint64 {
+	// Collect the stale nodes.
+	lru.mu.Lock()
+	var indices []uint64
+	for index := range lru.nodes {
+		if stale(index) {
+			indices = append(indices, index)
+		}
+	}
+	lru.mu.Unlock()
+
+	// Evict the nodes which are still pending.
+	var cancelled uint64
+	for _, index := range indices {
+		if !lru.staticStreamBuffer.callDataSectionPending(index) {
+			continue
+		}
+		lru.mu.Lock()
+		node, exists := lru.nodes[index]
+		if exists {
+			lru.remove(node)
+		}
+		lru.mu.Unlock()
+		if exists {
+			cancelled += lru.staticStreamBuffer.callRemoveDataSection(index)
+		}
+	}
+	return cancelled
+}
+
 // callUpdate is called when a node in the LRU is accessed. This will cause that
 // node to be placed at the most recent point of the LRU. If the node is not
 // currently in the LRU and the LRU is full, the least recently used node of the
-// LRU will be evicted.
-func (lru *leastRecentlyUsedCache) callUpdate(index uint64) {
+// LRU will be evicted. New nodes are fetched using the provided traffic class.
+func (lru *leastRecentlyUsedCache) callUpdate(index uint64, class skymodules.TrafficClass) {
 	lru.mu.Lock()
 	// Check if the node is already in the LRU. If so, move that node to the
 	// front of the list.
@@ -82,7 +118,7 @@ func (lru *leastRecentlyUsedCache) callUpdate(index uint64) {
 	}
 	lru.insertHead(node)
 	lru.mu.Unlock()
-	lru.staticStreamBuffer.callFetchDataSection(index)
+	lru.staticStreamBuffer.callFetchDataSection(index, class)
 
 	// Eviction needs to straddle the consistency domain of the lru and the
 	// consistency domain of the stream buffer, so it has to be a managed call.
@@ -129,6 +165,23 @@ func (lru *leastRecentlyUsedCache) insertHead(node *leastRecentlyUsedCacheNode)
 	lru.head = node
 }
 
+// remove removes a node from the cache.
+func (lru *leastRecentlyUsedCache) remove(node *leastRecentlyUsedCacheNode) {
+	delete(lru.nodes, node.index)
+	if node.prev != nil {
+		node.prev.next = node.next
+	} else {
+		lru.head = node.next
+	}
+	if node.next != nil {
+		node.next.prev = node.prev
+	} else {
+		lru.tail = node.prev
+	}
+	node.prev = nil
+	node.next = nil
+}
+
 // moveToFront accepts a node that is already in the LRU and then moves that
 // node to the front of the LRU.
 func (lru *leastRecentlyUsedCache) moveToFront(node *leastRecentlyUsedCacheNode) {
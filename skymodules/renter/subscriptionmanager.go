@@ -71,6 +71,11 @@ func (sm *registrySubscriptionManager) Notify(notifications ...modules.RPCRegist
 	for _, notification := range notifications {
 		eid := modules.DeriveRegistryEntryID(notification.PubKey, notification.Entry.Tweak)
 
+		// Invalidate the cached entry if the notification is more recent.
+		if rc := sm.staticRenter.staticRegistryEntryCache; rc != nil {
+			rc.managedInvalidate(eid, &notification.Entry)
+		}
+
 		sub, exists := sm.subscriptions[eid]
 		if !exists {
 			continue
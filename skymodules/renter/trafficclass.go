@@ -0,0 +1,88 @@
+package renter
+
+import (
+	"context"
+
+	"gitlab.com/NebulousLabs/ratelimit"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+// trafficClassKey is the context key for the traffic class of a request.
+type trafficClassKey struct{}
+
+// trafficClassRateLimits are the ratelimits of the traffic classes. They are
+// applied on top of the renter's ratelimit.
+type trafficClassRateLimits map[skymodules.TrafficClass]*ratelimit.RateLimit
+
+// contextWithTrafficClass returns a context that carries the given traffic
+// class.
+func contextWithTrafficClass(ctx context.Context, tc skymodules.TrafficClass) context.Context {
+	return context.WithValue(ctx, trafficClassKey{}, tc)
+}
+
+// trafficClassFromContext returns the traffic class attached to the context.
+// If there is none, the traffic is considered interactive.
+func trafficClassFromContext(ctx context.Context) skymodules.TrafficClass {
+	tc, ok := ctx.Value(trafficClassKey{}).(skymodules.TrafficClass)
+	if !ok {
+		return skymodules.TrafficClassInteractive
+	}
+	return tc
+}
+
+// lowerPriorityTrafficClass returns the class with the lower priority of the
+// two.
+func lowerPriorityTrafficClass(a, b skymodules.TrafficClass) skymodules.TrafficClass {
+	if a.Priority() > b.Priority() {
+		return a
+	}
+	return b
+}
+
+// newTrafficClassRateLimits creates unlimited ratelimits for all traffic
+// classes.
+func newTrafficClassRateLimits() trafficClassRateLimits {
+	rls := make(trafficClassRateLimits)
+	for _, tc := range skymodules.TrafficClasses {
+		rls[tc] = ratelimit.NewRateLimit(0, 0, 0)
+	}
+	return rls
+}
+
+// staticSetLimits limits every traffic class to its share of the given
+// bandwidth limits. Classes without a share are not limited.
+func (rls trafficClassRateLimits) staticSetLimits(downloadSpeed, uploadSpeed int64, shares skymodules.TrafficShares) {
+	for _, tc := range skymodules.TrafficClasses {
+		share := int64(shares.Share(tc))
+		download := trafficClassLimit(downloadSpeed, share)
+		upload := trafficClassLimit(uploadSpeed, share)
+		if download == 0 && upload == 0 {
+			rls[tc].SetLimits(0, 0, 0)
+		} else {
+			rls[tc].SetLimits(download, upload, 4*4096)
+		}
+	}
+}
+
+// staticRateLimit returns the ratelimit of the given traffic class.
+func (rls trafficClassRateLimits) staticRateLimit(tc skymodules.TrafficClass) *ratelimit.RateLimit {
+	rl, exists := rls[tc]
+	if !exists {
+		return rls[skymodules.TrafficClassInteractive]
+	}
+	return rl
+}
+
+// trafficClassLimit returns the share of the given limit in bytes per second.
+// A limit of 0 means unlimited, so the share of an unlimited limit is
+// unlimited as well. A non-zero limit never results in a share of 0.
+func trafficClassLimit(limit, share int64) int64 {
+	if limit == 0 || share == 0 {
+		return 0
+	}
+	limit = limit * share / skymodules.MaxTrafficShare
+	if limit == 0 {
+		limit = 1
+	}
+	return limit
+}
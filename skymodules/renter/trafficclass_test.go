@@ -0,0 +1,115 @@
+package renter
+
+import (
+	"context"
+	"testing"
+
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+// TestTrafficClassContext is a unit test for attaching traffic classes to a
+// context.
+func TestTrafficClassContext(t *testing.T) {
+	t.Parallel()
+
+	// Without a class the traffic is interactive.
+	ctx := context.Background()
+	if tc := trafficClassFromContext(ctx); tc != skymodules.TrafficClassInteractive {
+		t.Fatal("wrong class", tc)
+	}
+	ctx = contextWithTrafficClass(ctx, skymodules.TrafficClassRepair)
+	if tc := trafficClassFromContext(ctx); tc != skymodules.TrafficClassRepair {
+		t.Fatal("wrong class", tc)
+	}
+
+	// Check lowerPriorityTrafficClass.
+	if tc := lowerPriorityTrafficClass(skymodules.TrafficClassInteractive, skymodules.TrafficClassStreaming); tc != skymodules.TrafficClassStreaming {
+		t.Fatal("wrong class", tc)
+	}
+	if tc := lowerPriorityTrafficClass(skymodules.TrafficClassBackup, skymodules.TrafficClassStreaming); tc != skymodules.TrafficClassBackup {
+		t.Fatal("wrong class", tc)
+	}
+}
+
+// TestTrafficClassRateLimits is a unit test for the ratelimits of the traffic
+// classes.
+func TestTrafficClassRateLimits(t *testing.T) {
+	t.Parallel()
+
+	rls := newTrafficClassRateLimits()
+	for _, tc := range skymodules.TrafficClasses {
+		if download, upload, _ := rls.staticRateLimit(tc).Limits(); download != 0 || upload != 0 {
+			t.Fatal("new ratelimits should be unlimited", tc, download, upload)
+		}
+	}
+
+	// Limit the repair class to a quarter of the bandwidth and the backup
+	// class to a tiny share.
+	shares := skymodules.TrafficShares{Repair: 25, Backup: 1}
+	rls.staticSetLimits(1000, 50, shares)
+	if download, upload, _ := rls.staticRateLimit(skymodules.TrafficClassRepair).Limits(); download != 250 || upload != 12 {
+		t.Fatal("wrong limits", download, upload)
+	}
+	if download, upload, _ := rls.staticRateLimit(skymodules.TrafficClassBackup).Limits(); download != 10 || upload != 1 {
+		t.Fatal("wrong limits", download, upload)
+	}
+	for _, tc := range []skymodules.TrafficClass{skymodules.TrafficClassInteractive, skymodules.TrafficClassStreaming} {
+		if download, upload, _ := rls.staticRateLimit(tc).Limits(); download != 0 || upload != 0 {
+			t.Fatal("classes without share should be unlimited", tc, download, upload)
+		}
+	}
+
+	// Without renter limits the classes are unlimited.
+	rls.staticSetLimits(0, 0, shares)
+	for _, tc := range skymodules.TrafficClasses {
+		if download, upload, _ := rls.staticRateLimit(tc).Limits(); download != 0 || upload != 0 {
+			t.Fatal("ratelimits should be unlimited", tc, download, upload)
+		}
+	}
+
+	// Unknown classes use the interactive limit.
+	if rls.staticRateLimit("bulk") != rls.staticRateLimit(skymodules.TrafficClassInteractive) {
+		t.Fatal("wrong ratelimit for unknown class")
+	}
+}
+
+// TestJobQueueTrafficClassPriority makes sure that jobs are ordered by the
+// priority of their traffic class and in FIFO order within a class.
+func TestJobQueueTrafficClassPriority(t *testing.T) {
+	t.Parallel()
+
+	w := new(worker)
+	w.staticRenter = new(Renter)
+	jq := newJobGenericQueue(w)
+
+	// Add jobs in an order that requires reordering.
+	classes := []skymodules.TrafficClass{
+		skymodules.TrafficClassRepair,
+		skymodules.TrafficClassBackup,
+		skymodules.TrafficClassInteractive,
+		skymodules.TrafficClassRepair,
+		skymodules.TrafficClassStreaming,
+		skymodules.TrafficClassInteractive,
+	}
+	jobs := make([]*jobTest, len(classes))
+	for i, tc := range classes {
+		jobs[i] = &jobTest{
+			jobGeneric: newJobGeneric(contextWithTrafficClass(context.Background(), tc), jq, nil),
+		}
+		if !jq.callAdd(jobs[i]) {
+			t.Fatal("failed to add job")
+		}
+	}
+
+	// Check the order.
+	expected := []*jobTest{jobs[2], jobs[5], jobs[4], jobs[0], jobs[3], jobs[1]}
+	for i, j := range expected {
+		next := jq.callNext()
+		if next != j {
+			t.Fatalf("job %v: expected %v job but got %v job", i, j.staticTrafficClass(), next.staticTrafficClass())
+		}
+	}
+	if jq.callNext() != nil {
+		t.Fatal("queue should be empty")
+	}
+}
@@ -0,0 +1,141 @@
+package renter
+
+import (
+	"sync"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/crypto"
+)
+
+type (
+	// trustedRegistryHosts holds the hosts whose responses are required or
+	// weighted higher when reading from the registry.
+	trustedRegistryHosts struct {
+		settings skymodules.TrustedRegistryHosts
+		mu       sync.Mutex
+	}
+)
+
+// callSettings returns the current trusted registry hosts settings.
+func (trh *trustedRegistryHosts) callSettings() skymodules.TrustedRegistryHosts {
+	trh.mu.Lock()
+	defer trh.mu.Unlock()
+	settings := trh.settings
+	settings.Hosts = append(settings.Hosts[:0:0], trh.settings.Hosts...)
+	return settings
+}
+
+// callSet replaces the trusted registry hosts settings.
+func (trh *trustedRegistryHosts) callSet(settings skymodules.TrustedRegistryHosts) {
+	trh.mu.Lock()
+	defer trh.mu.Unlock()
+	trh.settings = settings
+}
+
+// registryValueID identifies the value of a registry response. Responses
+// with the same ID agree on the entry.
+func registryValueID(srv *skymodules.RegistryEntry) crypto.Hash {
+	return crypto.HashAll(srv.Tweak, srv.Data, srv.Revision, srv.Type)
+}
+
+// pickTrustedRegistryResponse picks the best of the successful responses to a
+// registry read taking the trusted registry hosts into account. It also
+// returns the agreement of the trusted hosts with the picked response. In
+// required mode, nil is returned if no trusted host returned a value.
+func pickTrustedRegistryResponse(trusted skymodules.TrustedRegistryHosts, resps []*jobReadRegistryResponse) (*jobReadRegistryResponse, []skymodules.RegistryHostAgreement) {
+	trustedSet := make(map[string]struct{}, len(trusted.Hosts))
+	for _, host := range trusted.Hosts {
+		trustedSet[host.String()] = struct{}{}
+	}
+
+	// Sum up the weights and the number of trusted hosts per value.
+	weights := make(map[crypto.Hash]uint64)
+	trustedCounts := make(map[crypto.Hash]int)
+	trustedResps := make(map[string]*jobReadRegistryResponse)
+	for _, resp := range resps {
+		id := registryValueID(resp.staticSignedRegistryValue)
+		hostKey := resp.staticWorker.staticHostPubKeyStr
+		if _, isTrusted := trustedSet[hostKey]; isTrusted {
+			weights[id] += trusted.Weight
+			trustedCounts[id]++
+			trustedResps[hostKey] = resp
+		} else {
+			weights[id]++
+		}
+	}
+
+	// Pick the best eligible response.
+	eligible := func(resp *jobReadRegistryResponse) bool {
+		id := registryValueID(resp.staticSignedRegistryValue)
+		if trusted.Mode == skymodules.TrustedRegistryHostsModeWeighted {
+			return weights[id] >= trusted.Weight
+		}
+		return trustedCounts[id] > 0
+	}
+	var best *jobReadRegistryResponse
+	for _, resp := range resps {
+		if !eligible(resp) {
+			continue
+		}
+		if isBetter, _ := isBetterReadRegistryResponse(best, resp); isBetter {
+			best = resp
+		}
+	}
+	// In weighted mode, fall back to the best response.
+	if best == nil && trusted.Mode == skymodules.TrustedRegistryHostsModeWeighted {
+		for _, resp := range resps {
+			if isBetter, _ := isBetterReadRegistryResponse(best, resp); isBetter {
+				best = resp
+			}
+		}
+	}
+
+	// Compute the agreement of the trusted hosts.
+	agreement := make([]skymodules.RegistryHostAgreement, 0, len(trusted.Hosts))
+	for _, host := range trusted.Hosts {
+		ha := skymodules.RegistryHostAgreement{
+			HostKey: host,
+		}
+		if resp, ok := trustedResps[host.String()]; ok {
+			ha.Responded = true
+			ha.Revision = resp.staticSignedRegistryValue.Revision
+			ha.Agrees = best != nil && registryValueID(resp.staticSignedRegistryValue) == registryValueID(best.staticSignedRegistryValue)
+		}
+		agreement = append(agreement, ha)
+	}
+	return best, agreement
+}
+
+// TrustedRegistryHosts returns the hosts whose responses are required or
+// weighted higher when reading from the registry.
+func (r *Renter) TrustedRegistryHosts() skymodules.TrustedRegistryHosts {
+	return r.staticTrustedRegistryHosts.callSettings()
+}
+
+// SetTrustedRegistryHosts replaces the hosts whose responses are required or
+// weighted higher when reading from the registry. The changes take effect for
+// the next registry read.
+func (r *Renter) SetTrustedRegistryHosts(settings skymodules.TrustedRegistryHosts) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if err := settings.Validate(); err != nil {
+		return errors.AddContext(err, "invalid trusted registry hosts")
+	}
+
+	id := r.mu.Lock()
+	old := r.persist.TrustedRegistryHosts
+	r.persist.TrustedRegistryHosts = settings
+	r.mu.Unlock(id)
+	err := r.managedSaveSync()
+	if err != nil {
+		id = r.mu.Lock()
+		r.persist.TrustedRegistryHosts = old
+		r.mu.Unlock(id)
+		return errors.AddContext(err, "failed to persist trusted registry hosts")
+	}
+	r.staticTrustedRegistryHosts.callSet(settings)
+	return nil
+}
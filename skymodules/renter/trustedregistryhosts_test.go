@@ -0,0 +1,106 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestPickTrustedRegistryResponse is a unit test for
+// pickTrustedRegistryResponse.
+func TestPickTrustedRegistryResponse(t *testing.T) {
+	t.Parallel()
+
+	// Create 4 hosts. The first two are trusted.
+	var hosts []types.SiaPublicKey
+	var workers []*worker
+	for i := 0; i < 4; i++ {
+		hpk := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: fastrand.Bytes(crypto.PublicKeySize)}
+		hosts = append(hosts, hpk)
+		workers = append(workers, &worker{staticHostPubKeyStr: hpk.String()})
+	}
+	var tweak crypto.Hash
+	fastrand.Read(tweak[:])
+	response := func(w *worker, revision uint64) *jobReadRegistryResponse {
+		srv := skymodules.NewRegistryEntry(types.SiaPublicKey{}, modules.SignedRegistryValue{
+			RegistryValue: modules.NewRegistryValue(tweak, []byte{byte(revision)}, revision, modules.RegistryTypeWithoutPubkey),
+		})
+		return &jobReadRegistryResponse{
+			staticCompleteTime:        time.Now(),
+			staticSignedRegistryValue: &srv,
+			staticWorker:              w,
+		}
+	}
+
+	// The untrusted hosts return a higher revision than the trusted ones.
+	// Only one of the trusted hosts responded.
+	resps := []*jobReadRegistryResponse{
+		response(workers[2], 5),
+		response(workers[3], 5),
+		response(workers[0], 3),
+	}
+
+	// In required mode, the value of the trusted host is accepted.
+	required := skymodules.TrustedRegistryHosts{
+		Hosts: hosts[:2],
+		Mode:  skymodules.TrustedRegistryHostsModeRequired,
+	}
+	best, agreement := pickTrustedRegistryResponse(required, resps)
+	if best == nil || best.staticSignedRegistryValue.Revision != 3 {
+		t.Fatal("wrong response", best)
+	}
+	if len(agreement) != 2 {
+		t.Fatal("wrong agreement", agreement)
+	}
+	if a := agreement[0]; !a.HostKey.Equals(hosts[0]) || !a.Responded || !a.Agrees || a.Revision != 3 {
+		t.Fatal("wrong agreement", a)
+	}
+	if a := agreement[1]; !a.HostKey.Equals(hosts[1]) || a.Responded || a.Agrees {
+		t.Fatal("wrong agreement", a)
+	}
+
+	// Without a trusted response, nothing is accepted.
+	best, agreement = pickTrustedRegistryResponse(required, resps[:2])
+	if best != nil || agreement[0].Responded || agreement[1].Responded {
+		t.Fatal("expected no response", best, agreement)
+	}
+
+	// In weighted mode, the two untrusted hosts outweigh the trusted one if
+	// its weight is 2.
+	weighted := skymodules.TrustedRegistryHosts{
+		Hosts:  hosts[:2],
+		Mode:   skymodules.TrustedRegistryHostsModeWeighted,
+		Weight: 2,
+	}
+	best, agreement = pickTrustedRegistryResponse(weighted, resps)
+	if best == nil || best.staticSignedRegistryValue.Revision != 5 {
+		t.Fatal("wrong response", best)
+	}
+	if agreement[0].Agrees {
+		t.Fatal("trusted host shouldn't agree")
+	}
+
+	// With a weight of 3, they don't.
+	weighted.Weight = 3
+	best, agreement = pickTrustedRegistryResponse(weighted, resps)
+	if best == nil || best.staticSignedRegistryValue.Revision != 3 || !agreement[0].Agrees {
+		t.Fatal("wrong response", best)
+	}
+
+	// A single fast untrusted host can't win against the trusted one.
+	best, _ = pickTrustedRegistryResponse(weighted, []*jobReadRegistryResponse{resps[0], resps[2]})
+	if best == nil || best.staticSignedRegistryValue.Revision != 3 {
+		t.Fatal("wrong response", best)
+	}
+
+	// Without any value reaching the weight, the best value is accepted.
+	best, _ = pickTrustedRegistryResponse(weighted, resps[:1])
+	if best == nil || best.staticSignedRegistryValue.Revision != 5 {
+		t.Fatal("wrong response", best)
+	}
+}
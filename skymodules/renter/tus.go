@@ -141,10 +141,19 @@ func (stu *skynetTUSUploader) NewUpload(ctx context.Context, info handler.FileIn
 		return nil, errors.AddContext(err, "invalid metadata")
 	}
 
+	// Check the upload against the upload limits of the portal. The size of
+	// TUS uploads is never deferred, so it's known upfront.
+	limits := stu.staticRenter.SkyfileUploadLimits()
+	err = errors.Compose(limits.CheckUploadSize(uint64(info.Size)), limits.CheckMetadata(sm))
+	if err != nil {
+		return nil, handler.NewHTTPError(err, http.StatusRequestEntityTooLarge)
+	}
+
 	// Set the upload params to 'force' to allow overwriting the fileNode.
 	sup.Force = true
 
-	// Create the upload.
+	// Create the upload. TUS uploads ignore the fanout redundancy policy
+	// since clients align their chunks with the default chunk size.
 	upload, err := stu.managedCreateUpload(info, sp, fileName, SkyfileDefaultBaseChunkRedundancy, skymodules.RenterDefaultDataPieces, skymodules.RenterDefaultParityPieces, sm, true, crypto.TypePlain)
 	if err != nil {
 		return nil, errors.AddContext(err, "failed to save new upload")
@@ -378,13 +387,29 @@ func (u *ongoingTUSUpload) GetReader(ctx context.Context) (io.Reader, error) {
 // finishUploadLarge handles finishing up a large upload.
 func (u *ongoingTUSUpload) finishUploadLarge(ctx context.Context, fanout []byte, sup skymodules.SkyfileUploadParameters, fi handler.FileInfo, masterKey crypto.CipherKey, ec skymodules.ErasureCoder, smBytes []byte) (skylink skymodules.Skylink, err error) {
 	r := u.staticUploader.staticRenter
-	return r.managedCreateSkylinkRawMD(ctx, sup, smBytes, fanout, uint64(fi.Size), masterKey, ec)
+	skylink, err = r.managedCreateSkylinkRawMD(ctx, sup, smBytes, fanout, uint64(fi.Size), masterKey, ec)
+	if err != nil {
+		return skymodules.Skylink{}, err
+	}
+	err = r.managedScanUploadedSkyfile(ctx, skylink, sup.SiaPath)
+	if err != nil {
+		return skymodules.Skylink{}, err
+	}
+	return skylink, nil
 }
 
 // finishUploadSmall handles finishing up a small upload.
 func (u *ongoingTUSUpload) finishUploadSmall(ctx context.Context, sup skymodules.SkyfileUploadParameters, smBytes, smallUploadData []byte) (skylink skymodules.Skylink, err error) {
 	r := u.staticUploader.staticRenter
-	return r.managedUploadSkyfileSmallFile(ctx, sup, smBytes, smallUploadData)
+	skylink, err = r.managedUploadSkyfileSmallFile(ctx, sup, smBytes, smallUploadData)
+	if err != nil {
+		return skymodules.Skylink{}, err
+	}
+	err = r.managedScanUploadedSkyfile(ctx, skylink, sup.SiaPath)
+	if err != nil {
+		return skymodules.Skylink{}, err
+	}
+	return skylink, nil
 }
 
 // FinishUpload is called when the upload is done.
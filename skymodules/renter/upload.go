@@ -18,9 +18,14 @@ import (
 	"gitlab.com/SkynetLabs/skyd/skymodules"
 	"gitlab.com/SkynetLabs/skyd/skymodules/renter/filesystem"
 	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
 )
 
 var (
+	// ErrNotEnoughAllowedHosts is returned if an upload is restricted to a
+	// set of hosts which can't hold all the pieces of the upload's chunks.
+	ErrNotEnoughAllowedHosts = errors.New("not enough of the allowed hosts are available to upload at full redundancy")
+
 	// ErrUploadDirectory is returned if the user tries to upload a directory.
 	ErrUploadDirectory = errors.New("cannot upload directory")
 )
@@ -52,6 +57,21 @@ func (r *Renter) Upload(up skymodules.FileUploadParams) error {
 		return errors.AddContext(err, "unable to close file after checking permissions")
 	}
 
+	// Fill in any missing upload params with sensible defaults.
+	if up.ErasureCode == nil {
+		up.ErasureCode = skymodules.NewRSSubCodeDefault()
+	}
+
+	// If the upload is restricted to a set of hosts, make sure that the set
+	// can hold all the pieces of a chunk before touching any existing file.
+	if err := r.managedCheckAllowedHosts(up.AllowedHosts, up.ErasureCode); err != nil {
+		return err
+	}
+	profile, err := up.UploadProfile()
+	if err != nil {
+		return err
+	}
+
 	// Delete existing file if overwrite flag is set. Ignore ErrUnknownPath.
 	if up.Force {
 		err := r.DeleteFile(up.SiaPath)
@@ -60,11 +80,6 @@ func (r *Renter) Upload(up skymodules.FileUploadParams) error {
 		}
 	}
 
-	// Fill in any missing upload params with sensible defaults.
-	if up.ErasureCode == nil {
-		up.ErasureCode = skymodules.NewRSSubCodeDefault()
-	}
-
 	// Check that we have contracts to upload to. We need at least data +
 	// parity/2 contracts. NumPieces is equal to data+parity, and min pieces is
 	// equal to parity. Therefore (NumPieces+MinPieces)/2 = (data+data+parity)/2
@@ -100,6 +115,24 @@ func (r *Renter) Upload(up skymodules.FileUploadParams) error {
 	if err != nil {
 		return errors.AddContext(err, "could not open the new sia file")
 	}
+	if profile != "" {
+		err = entry.SetProfile(profile)
+		if err != nil {
+			return errors.Compose(errors.AddContext(err, "could not set the allowance profile of the sia file"), entry.Close())
+		}
+	}
+	if !up.ExpiryTime.IsZero() {
+		err = entry.SetExpiryTime(up.ExpiryTime)
+		if err != nil {
+			return errors.Compose(errors.AddContext(err, "could not set the expiry time of the sia file"), entry.Close())
+		}
+	}
+	if len(up.AllowedHosts) > 0 {
+		err = entry.SetAllowedHosts(up.AllowedHosts)
+		if err != nil {
+			return errors.Compose(errors.AddContext(err, "could not set the allowed hosts of the sia file"), entry.Close())
+		}
+	}
 
 	// No need to upload zero-byte files.
 	if sourceInfo.Size() == 0 {
@@ -128,3 +161,54 @@ func (r *Renter) Upload(up skymodules.FileUploadParams) error {
 	}
 	return nil
 }
+
+// managedCheckAllowedHosts returns an error if the given set of allowed hosts
+// doesn't contain enough hosts with a contract that is good for uploading to
+// hold all the pieces of a chunk with the given erasure coding settings. An
+// empty set doesn't restrict the upload.
+func (r *Renter) managedCheckAllowedHosts(allowedHosts []types.SiaPublicKey, ec skymodules.ErasureCoder) error {
+	if len(allowedHosts) == 0 {
+		return nil
+	}
+	gfu := make(map[string]struct{})
+	for _, c := range r.staticHostContractor.Contracts() {
+		if c.Utility.GoodForUpload {
+			gfu[c.HostPublicKey.String()] = struct{}{}
+		}
+	}
+	usable := make(map[string]struct{})
+	for _, host := range allowedHosts {
+		if _, exists := gfu[host.String()]; exists {
+			usable[host.String()] = struct{}{}
+		}
+	}
+	if len(usable) < ec.NumPieces() {
+		return errors.AddContext(ErrNotEnoughAllowedHosts, fmt.Sprintf("%v of the %v allowed hosts are usable but %v are needed", len(usable), len(allowedHosts), ec.NumPieces()))
+	}
+	return nil
+}
+
+// isAllowedHost returns whether the host with the given public key is within
+// the set of allowed hosts.
+func isAllowedHost(allowedHosts []types.SiaPublicKey, host string) bool {
+	for _, allowed := range allowedHosts {
+		if allowed.String() == host {
+			return true
+		}
+	}
+	return false
+}
+
+// hasProfileHosts returns whether any host belongs to the allowance profile
+// with the given name. The regular hosts always exist.
+func hasProfileHosts(hostProfiles map[string]string, profile string) bool {
+	if profile == "" {
+		return true
+	}
+	for _, p := range hostProfiles {
+		if p == profile {
+			return true
+		}
+	}
+	return false
+}
@@ -40,6 +40,7 @@ type unfinishedUploadChunk struct {
 	// Information about the chunk, namely where it exists within the file.
 	fileRecentlySuccessful bool // indicates if the file the chunk is from had a recent successful repair
 	health                 float64
+	repairPriority         float64
 	length                 uint64
 	staticMemoryNeeded     uint64 // memory needed in bytes
 	memoryReleased         uint64 // memory that has been returned of memoryNeeded
@@ -64,6 +65,12 @@ type unfinishedUploadChunk struct {
 	logicalChunkData  [][]byte
 	physicalChunkData [][]byte
 
+	// piecesReady is only set for chunks whose upload is pipelined with the
+	// erasure coding. It contains one channel per piece which is closed once
+	// the physical data of the piece is available. It is set before the chunk
+	// is distributed to the workers and not modified afterwards.
+	piecesReady []chan struct{}
+
 	// staticExpectedPieceRoots is a list of piece roots that are known for the
 	// chunk. If the roots are blank, it means there is no expectation for the
 	// root. This field is used to prevent file corruption when repairing from
@@ -118,6 +125,7 @@ type unfinishedUploadChunk struct {
 	//	+ the worker should increment the number of pieces completed
 	//	+ the worker should decrement the number of pieces registered
 	//	+ the worker should release the memory for the completed piece
+	encodingPending  bool // whether the physical data of some pieces is still being computed.
 	err              error
 	mu               sync.Mutex
 	pieceUsage       []bool              // 'true' if a piece is either uploaded, or a worker is attempting to upload that piece.
@@ -243,6 +251,10 @@ func (uc *unfinishedUploadChunk) managedNotifyStandbyWorkers() {
 // completed. This can either mean that it ran out of workers or that it was
 // uploaded successfully.
 func (uc *unfinishedUploadChunk) chunkComplete() bool {
+	// The chunk is not complete while it's still being erasure coded.
+	if uc.encodingPending {
+		return false
+	}
 	// The whole chunk was uploaded successfully.
 	if uc.piecesCompleted == uc.staticPiecesNeeded && uc.piecesRegistered == 0 {
 		return true
@@ -490,8 +502,14 @@ func (r *Renter) threadedFetchAndRepairChunk(chunk *unfinishedUploadChunk) {
 		}
 	}
 
-	// Fetch the logical data for the chunk.
-	err = r.managedFetchLogicalChunkData(chunk)
+	// Fetch the logical data for the chunk. If the chunk's upload can be
+	// pipelined with its erasure coding, only the data pieces are fetched.
+	var dataPieces, dataShards [][]byte
+	if chunk.staticCanPipeline() {
+		dataPieces, dataShards, err = r.managedFetchDataPieces(chunk)
+	} else {
+		err = r.managedFetchLogicalChunkData(chunk)
+	}
 	if err != nil {
 		// Return the erasure coding memory. This is not handled by the cleanup
 		// code.
@@ -532,6 +550,12 @@ func (r *Renter) threadedFetchAndRepairChunk(chunk *unfinishedUploadChunk) {
 		}
 		return
 	}
+	// If the data pieces were fetched, distribute the chunk while it is being
+	// erasure coded.
+	if dataPieces != nil {
+		r.managedPipelineChunk(chunk, dataPieces, dataShards, erasureCodingMemory+pieceCompletedMemory)
+		return
+	}
 	// Return the erasure coding memory. This is not handled by the data
 	// fetching, where the erasure coding occurs.
 	chunk.staticMemoryManager.Return(erasureCodingMemory + pieceCompletedMemory)
@@ -613,7 +637,12 @@ func (r *Renter) staticFetchLogicalDataFromReader(uc *unfinishedUploadChunk) err
 	if err != nil {
 		return errors.AddContext(err, "source data does not match previously uploaded data - blocking corrupt repair")
 	}
+	return uc.staticAdjustFileSize(n)
+}
 
+// staticAdjustFileSize adjusts the size of the chunk's file after n bytes were
+// read from the chunk's source reader.
+func (uc *unfinishedUploadChunk) staticAdjustFileSize(n uint64) error {
 	// If we read a full chunk, we are done. No need to adjust the file size.
 	if n == uc.fileEntry.ChunkSize() {
 		return nil
@@ -654,18 +683,9 @@ func (r *Renter) managedFetchLogicalChunkData(uc *unfinishedUploadChunk) error {
 
 	//  Try to fetch the file from the local path and upload there.
 	err := func() error {
-		osFile, err := os.Open(uc.fileEntry.LocalPath())
-		if os.IsNotExist(err) {
-			// The file doesn't exist on disk anymore, drop the local path.
-			//
-			// NOTE: we are removing the localpath here to avoid potential
-			// future corruption by a different file with the same filename
-			// being added at the localpath location.
-			r.staticLog.Println("WARN: local file not found on disk, setting localpath to '' to avoid corruption for", uc.fileEntry.SiaFilePath())
-			err = errors.Compose(err, uc.fileEntry.SetLocalPath(""))
-		}
+		osFile, err := r.managedOpenLocalFile(uc)
 		if err != nil {
-			return errors.AddContext(err, "unable to open file locally")
+			return err
 		}
 		defer func() {
 			err = errors.Compose(err, osFile.Close())
@@ -689,6 +709,25 @@ func (r *Renter) managedFetchLogicalChunkData(uc *unfinishedUploadChunk) error {
 	return nil
 }
 
+// managedOpenLocalFile opens the local file of the chunk. If the file doesn't
+// exist anymore, the local path of the chunk's file is dropped.
+func (r *Renter) managedOpenLocalFile(uc *unfinishedUploadChunk) (*os.File, error) {
+	osFile, err := os.Open(uc.fileEntry.LocalPath())
+	if os.IsNotExist(err) {
+		// The file doesn't exist on disk anymore, drop the local path.
+		//
+		// NOTE: we are removing the localpath here to avoid potential
+		// future corruption by a different file with the same filename
+		// being added at the localpath location.
+		r.staticLog.Println("WARN: local file not found on disk, setting localpath to '' to avoid corruption for", uc.fileEntry.SiaFilePath())
+		err = errors.Compose(err, uc.fileEntry.SetLocalPath(""))
+	}
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to open file locally")
+	}
+	return osFile, nil
+}
+
 // managedCleanUpUploadChunk will check the state of the chunk and perform any
 // cleanup required. This can include returning reserved memory and releasing
 // the chunk from the map of active chunks in the chunk heap.
@@ -707,6 +746,11 @@ func (r *Renter) managedCleanUpUploadChunk(uc *unfinishedUploadChunk) {
 		if uc.pieceUsage[i] {
 			continue
 		}
+		// Skip the piece if it's still being computed. It will be released
+		// once the chunk is fully erasure coded.
+		if uc.piecePending(i) {
+			continue
+		}
 
 		// If we have all the available pieces we need, release this piece.
 		// Otherwise, mark that there's another piece available. This algorithm
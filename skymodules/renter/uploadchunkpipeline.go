@@ -0,0 +1,235 @@
+package renter
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+)
+
+// Upload Pipelining Overview:
+// The upload of a chunk can be pipelined with its erasure coding. Instead of
+// waiting for all the pieces of a chunk to be erasure coded and encrypted, the
+// chunk is distributed to the workers as soon as its data pieces are
+// encrypted. The data pieces are uploaded while the parity pieces are
+// computed and each parity piece is handed to the workers as soon as it's
+// ready. Workers which are assigned a piece that is not ready yet block until
+// it is. Since the data of the chunk is fully read before it is distributed,
+// workers only ever wait for the cpu bound encoding of the chunk.
+//
+// The memory of the chunk is tracked the same way as for chunks which are
+// not pipelined. The plaintext data pieces are covered by the erasure coding
+// memory, which is returned once the parity pieces are computed, while the
+// encrypted pieces are covered by the memory for the physical pieces, which
+// is returned as the pieces are uploaded or released.
+//
+// Only new uploads are pipelined. Repairs require all pieces to pass an
+// integrity check before any of them is uploaded.
+
+var (
+	// errUploadPieceUnavailable is returned if a worker was assigned a piece
+	// which couldn't be computed.
+	errUploadPieceUnavailable = errors.New("physical data of the piece is not available")
+)
+
+// managedPiece returns the physical data of the piece at the given index. If
+// the piece is still being computed, it blocks until the piece is available.
+func (uc *unfinishedUploadChunk) managedPiece(pieceIndex uint64, stopChan <-chan struct{}) ([]byte, error) {
+	if uc.piecesReady != nil {
+		select {
+		case <-uc.piecesReady[pieceIndex]:
+		case <-stopChan:
+			return nil, errors.New("interrupted by shutdown")
+		}
+	}
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	piece := uc.physicalChunkData[pieceIndex]
+	if piece == nil {
+		return nil, errUploadPieceUnavailable
+	}
+	return piece, nil
+}
+
+// piecePending returns whether the physical data of the piece at the given
+// index is still being computed.
+func (uc *unfinishedUploadChunk) piecePending(pieceIndex int) bool {
+	if uc.piecesReady == nil {
+		return false
+	}
+	select {
+	case <-uc.piecesReady[pieceIndex]:
+		return false
+	default:
+		return true
+	}
+}
+
+// staticCanPipeline returns whether the upload of the chunk can be pipelined
+// with its erasure coding.
+func (uc *unfinishedUploadChunk) staticCanPipeline() bool {
+	// Chunks with expected roots are repairs.
+	var zeroHash crypto.Hash
+	for _, root := range uc.staticExpectedPieceRoots {
+		if root != zeroHash {
+			return false
+		}
+	}
+	// Stream shards can only be pipelined if their reader can hand out data
+	// pieces.
+	if uc.sourceReader != nil {
+		ss, ok := uc.sourceReader.(*StreamShard)
+		return ok && ss.staticCanPipeline()
+	}
+	return uc.fileEntry.LocalPath() != ""
+}
+
+// managedFetchDataPieces fetches the data pieces of a chunk without erasure
+// coding or encrypting them. Apart from the plaintext data pieces, which are
+// needed to compute the parity pieces, it also returns the data shards of the
+// encoded chunk. If the data can't be read from the chunk's local file, the
+// logical data of the chunk is downloaded from the network instead, in which
+// case no data pieces are returned.
+func (r *Renter) managedFetchDataPieces(uc *unfinishedUploadChunk) (dataPieces, dataShards [][]byte, err error) {
+	// Use the sourceReader if one is available.
+	if uc.sourceReader != nil {
+		pr, ok := uc.sourceReader.(pipelinedChunkReader)
+		if !ok {
+			return nil, nil, errors.New("source reader doesn't support pipelining")
+		}
+		var n uint64
+		dataPieces, n, err = pr.ReadDataPieces()
+		if err != nil {
+			return nil, nil, errors.AddContext(err, "unable to read the data pieces from the source reader")
+		}
+		err = uc.staticAdjustFileSize(n)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		// Try to fetch the data pieces from the local file.
+		dataPieces, err = func() (_ [][]byte, err error) {
+			osFile, err := r.managedOpenLocalFile(uc)
+			if err != nil {
+				return nil, err
+			}
+			defer func() {
+				err = errors.Compose(err, osFile.Close())
+			}()
+			sr := io.NewSectionReader(osFile, uc.offset, int64(uc.length))
+			dataPieces, n, err := readDataPieces(sr, uc.fileEntry.ErasureCode(), uc.fileEntry.PieceSize())
+			if err != nil {
+				return nil, errors.AddContext(err, "unable to read the data from the local file")
+			}
+			if n == 0 {
+				return nil, errors.AddContext(io.EOF, "unable to read the data from the local file")
+			}
+			return dataPieces, nil
+		}()
+		if err != nil {
+			r.staticLog.Printf("falling back to remote download for repair: fetch from local file %v failed: %v", uc.fileEntry.LocalPath(), err)
+			return nil, nil, r.managedDownloadLogicalChunkData(uc)
+		}
+	}
+
+	// Get the data shards. Depending on the erasure coder, they might not be
+	// identical to the data pieces.
+	dataShards, err = uc.fileEntry.ErasureCode().EncodeDataShards(dataPieces)
+	if err != nil {
+		return nil, nil, errors.AddContext(err, "unable to get the data shards")
+	}
+	return dataPieces, dataShards, nil
+}
+
+// managedPipelineChunk distributes a chunk to the workers as soon as its data
+// shards are encrypted and computes the parity pieces while the data shards
+// are being uploaded. The erasure coding memory is returned once all pieces
+// are computed.
+func (r *Renter) managedPipelineChunk(chunk *unfinishedUploadChunk, dataPieces, dataShards [][]byte, erasureCodingMemory uint64) {
+	mk := chunk.fileEntry.MasterKey()
+
+	// Sanity check - we should have at least as many physical data pieces as we
+	// do elements in our piece usage.
+	if len(chunk.physicalChunkData) < len(chunk.pieceUsage) {
+		r.staticLog.Critical("not enough physical pieces to match the upload settings of the file")
+		return
+	}
+
+	// Determine which pieces need to be uploaded. The chunk hasn't been
+	// distributed yet, so the piece usage can't change.
+	needed := make([]bool, len(chunk.physicalChunkData))
+	for i := range chunk.pieceUsage {
+		needed[i] = !chunk.pieceUsage[i]
+	}
+	chunk.piecesReady = make([]chan struct{}, len(chunk.physicalChunkData))
+	for i := range chunk.piecesReady {
+		chunk.piecesReady[i] = make(chan struct{})
+	}
+
+	// Pad and encrypt the data shards. Encryption doesn't modify the shards,
+	// which might be the plaintext data pieces that are still needed to
+	// compute the parity pieces.
+	var wg sync.WaitGroup
+	for i := range dataShards {
+		if !needed[i] {
+			continue
+		}
+		chunk.physicalChunkData[i] = dataShards[i]
+		wg.Add(1)
+		go func(i int) {
+			padAndEncryptPiece(chunk.staticIndex, uint64(i), chunk.physicalChunkData, mk)
+			wg.Done()
+		}(i)
+	}
+	wg.Wait()
+	for i := range dataShards {
+		close(chunk.piecesReady[i])
+	}
+	chunk.encodingPending = true
+
+	// Distribute the chunk to the workers.
+	chunk.chunkLogicalDataReceivedTime = time.Now()
+	r.staticUploadChunkDistributionQueue.callAddUploadChunk(chunk)
+
+	// Compute the parity pieces.
+	logicalChunkData, err := chunk.fileEntry.ErasureCode().EncodeShards(dataPieces)
+	if err != nil {
+		r.staticLog.Critical("failed to compute the parity pieces of chunk", chunk.staticIndex, chunk.staticSiaPath, err)
+	}
+
+	// Pad and encrypt the parity pieces and hand them to the workers as soon
+	// as they are ready. If the erasure coding failed, the workers waiting for
+	// the pieces will fail their uploads.
+	for i := len(dataShards); i < len(chunk.piecesReady); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var piece []byte
+			if err == nil && needed[i] {
+				padAndEncryptPiece(chunk.staticIndex, uint64(i), logicalChunkData, mk)
+				piece = logicalChunkData[i]
+			}
+			chunk.mu.Lock()
+			chunk.physicalChunkData[i] = piece
+			close(chunk.piecesReady[i])
+			chunk.mu.Unlock()
+		}(i)
+	}
+	wg.Wait()
+
+	// Return the erasure coding memory.
+	chunk.staticMemoryManager.Return(erasureCodingMemory)
+	chunk.mu.Lock()
+	chunk.memoryReleased += erasureCodingMemory
+	chunk.encodingPending = false
+	distributed := !chunk.chunkDistributionTime.IsZero()
+	chunk.mu.Unlock()
+
+	// Release any pieces that are not needed anymore. If the chunk hasn't
+	// been distributed yet, this happens after the distribution.
+	if distributed {
+		r.managedCleanUpUploadChunk(chunk)
+	}
+}
@@ -0,0 +1,132 @@
+package renter
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestChunkReaderReadDataPieces makes sure that encoding and encrypting the
+// data pieces returned by ReadDataPieces the way a pipelined upload does
+// results in the same chunk as ReadChunk.
+func TestChunkReaderReadDataPieces(t *testing.T) {
+	t.Parallel()
+
+	ec, err := skymodules.NewRSSubCode(10, 20, crypto.SegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mk := crypto.GenerateSiaKey(crypto.TypeDefaultRenter)
+	pieceSize := modules.SectorSize - mk.Type().Overhead()
+	data := fastrand.Bytes(int(pieceSize)*ec.MinPieces() + 1)
+
+	// Read the chunks the regular way.
+	cr := NewChunkReaderWithChunkIndex(bytes.NewReader(data), ec, mk, 1)
+	chunk1, n1, err := cr.ReadChunk()
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunk2, n2, err := cr.ReadChunk()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Read the data pieces and encode them like a pipelined upload would.
+	pr, ok := NewChunkReaderWithChunkIndex(bytes.NewReader(data), ec, mk, 1).(pipelinedChunkReader)
+	if !ok {
+		t.Fatal("chunk reader doesn't support pipelining")
+	}
+	for chunkIndex, expected := range [][][]byte{chunk1, chunk2} {
+		dataPieces, n, err := pr.ReadDataPieces()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expectedN := []uint64{n1, n2}[chunkIndex]; n != expectedN {
+			t.Fatal("wrong n", n, expectedN)
+		}
+		physical := make([][]byte, ec.NumPieces())
+		dataShards, err := ec.EncodeDataShards(dataPieces)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range dataShards {
+			physical[i] = dataShards[i]
+			padAndEncryptPiece(uint64(chunkIndex+1), uint64(i), physical, mk)
+		}
+		logical, err := ec.EncodeShards(dataPieces)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := ec.MinPieces(); i < ec.NumPieces(); i++ {
+			padAndEncryptPiece(uint64(chunkIndex+1), uint64(i), logical, mk)
+			physical[i] = logical[i]
+		}
+		for i := range expected {
+			if !bytes.Equal(physical[i], expected[i]) {
+				t.Fatalf("chunk %v: piece %v doesn't match", chunkIndex, i)
+			}
+		}
+	}
+
+	// The reader should be drained.
+	if _, _, err := pr.ReadDataPieces(); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, _, err := cr.ReadChunk(); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// TestUploadChunkManagedPiece is a unit test for managedPiece.
+func TestUploadChunkManagedPiece(t *testing.T) {
+	t.Parallel()
+
+	// Chunks which are not pipelined return the piece right away.
+	uc := &unfinishedUploadChunk{
+		physicalChunkData: [][]byte{{1}, nil},
+	}
+	piece, err := uc.managedPiece(0, nil)
+	if err != nil || !bytes.Equal(piece, []byte{1}) {
+		t.Fatal("unexpected result", piece, err)
+	}
+	_, err = uc.managedPiece(1, nil)
+	if !errors.Contains(err, errUploadPieceUnavailable) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// Pipelined chunks block until the piece is ready.
+	uc = &unfinishedUploadChunk{
+		physicalChunkData: make([][]byte, 2),
+		piecesReady:       []chan struct{}{make(chan struct{}), make(chan struct{})},
+	}
+	if !uc.piecePending(0) {
+		t.Fatal("piece should be pending")
+	}
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		uc.mu.Lock()
+		uc.physicalChunkData[0] = []byte{2}
+		close(uc.piecesReady[0])
+		uc.mu.Unlock()
+	}()
+	piece, err = uc.managedPiece(0, nil)
+	if err != nil || !bytes.Equal(piece, []byte{2}) {
+		t.Fatal("unexpected result", piece, err)
+	}
+	if uc.piecePending(0) {
+		t.Fatal("piece shouldn't be pending")
+	}
+
+	// Waiting for a piece can be interrupted.
+	stopChan := make(chan struct{})
+	close(stopChan)
+	if _, err := uc.managedPiece(1, stopChan); err == nil {
+		t.Fatal("expected error")
+	}
+}
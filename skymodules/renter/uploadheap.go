@@ -89,7 +89,11 @@ func (uch uploadChunkHeap) Less(i, j int) bool {
 	//    - These are chunks of a siafile that do not have a local file to repair
 	//    from
 	//
-	//  5) Worst Health Chunk
+	//  5) Popular Chunks
+	//    - These are chunks of skyfiles with a higher repair priority, which
+	//      is based on the popularity of their skylinks
+	//
+	//  6) Worst Health Chunk
 	//    - The base priority of chunks in the heap is by the worst health
 
 	// Check for Priority chunks
@@ -135,6 +139,11 @@ func (uch uploadChunkHeap) Less(i, j int) bool {
 		return false
 	}
 
+	// Check for Popular Chunks
+	if uch[i].repairPriority != uch[j].repairPriority {
+		return uch[i].repairPriority > uch[j].repairPriority
+	}
+
 	// Base case, Check for worst health
 	return uch[i].health > uch[j].health
 }
@@ -509,8 +518,10 @@ func (r *Renter) managedBuildUnfinishedChunk(ctx context.Context, entry *filesys
 		span = opentracing.StartSpan("unfinishedUploadChunk", spanRef)
 	}
 
+	// Any data the chunk needs to download from the network is repair
+	// traffic.
 	uuc := &unfinishedUploadChunk{
-		ctx:          ctx,
+		ctx:          contextWithTrafficClass(ctx, skymodules.TrafficClassRepair),
 		fileEntry:    entryCopy,
 		staticRenter: r,
 
@@ -552,8 +563,25 @@ func (r *Renter) managedBuildUnfinishedChunk(ctx context.Context, entry *filesys
 		staticSpan: span,
 	}
 
-	// Every chunk can have a different set of unused hosts.
+	// Every chunk can have a different set of unused hosts. Files which are
+	// restricted to a set of hosts only use those hosts. Otherwise files of
+	// an allowance profile only use the hosts of that profile while regular
+	// files only use the hosts without a profile. If the profile doesn't have
+	// any hosts yet, its files fall back to the regular hosts.
+	allowedHosts := entry.AllowedHosts()
+	hostProfiles := r.staticHostContractor.HostProfiles()
+	profile := entry.Profile()
+	if !hasProfileHosts(hostProfiles, profile) {
+		profile = ""
+	}
 	for host := range hosts {
+		if len(allowedHosts) > 0 {
+			if !isAllowedHost(allowedHosts, host) {
+				continue
+			}
+		} else if hostProfiles[host] != profile {
+			continue
+		}
 		uuc.unusedHosts[host] = struct{}{}
 	}
 
@@ -623,6 +651,11 @@ func (r *Renter) managedBuildUnfinishedChunk(ctx context.Context, entry *filesys
 // finish would then close the Entry and consequentially impact the remaining
 // chunks.
 func (r *Renter) managedBuildUnfinishedChunks(entry *filesystem.FileNode, hosts map[string]struct{}, target repairTarget, offline, goodForRenew map[string]bool, mm *memoryManager) []*unfinishedUploadChunk {
+	// Expired files are deleted by the next bubble, don't repair them anymore.
+	if entry.Expired() {
+		return nil
+	}
+
 	// If we don't have enough workers for the file, don't repair it right now.
 	minPieces := entry.ErasureCode().MinPieces()
 	r.staticWorkerPool.mu.RLock()
@@ -674,6 +707,9 @@ func (r *Renter) managedBuildUnfinishedChunks(entry *filesystem.FileNode, hosts
 		pks[string(pk.Key)] = pk
 	}
 
+	// Get the repair priority of the file's skylinks.
+	repairPriority := r.staticRepairPriorities.callPriority(entry.Metadata().Skylinks)
+
 	// Assemble the set of chunks.
 	newUnfinishedChunks := make([]*unfinishedUploadChunk, 0, len(chunkIndexes))
 	for _, index := range chunkIndexes {
@@ -692,6 +728,7 @@ func (r *Renter) managedBuildUnfinishedChunks(entry *filesystem.FileNode, hosts
 		if exists {
 			continue
 		}
+		chunk.repairPriority = repairPriority
 		newUnfinishedChunks = append(newUnfinishedChunks, chunk)
 	}
 
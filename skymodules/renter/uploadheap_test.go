@@ -2,6 +2,7 @@ package renter
 
 import (
 	"bytes"
+	"container/heap"
 	"fmt"
 	"os"
 	"strings"
@@ -43,6 +44,7 @@ func TestUploadHeap(t *testing.T) {
 	t.Run("HeapMaps", testUploadHeapMaps)
 	t.Run("PauseChan", testUploadHeapPauseChan)
 	t.Run("RemoteChunks", testAddRemoteChunksToHeap)
+	t.Run("RepairPriority", testUploadHeapRepairPriority)
 
 	// Regression Tests
 	t.Run("Regression_SwitchStuckStatus", testChunkSwitchStuckStatus)
@@ -1356,3 +1358,28 @@ func testManagedPruneIncompleteChunks(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// testUploadHeapRepairPriority verifies that chunks with a higher repair
+// priority are popped before chunks with a worse health.
+func testUploadHeapRepairPriority(t *testing.T) {
+	var uch uploadChunkHeap
+	chunks := []*unfinishedUploadChunk{
+		{health: 1.5, onDisk: true},
+		{health: 0.5, onDisk: true, repairPriority: 1},
+		{health: 0.8, onDisk: true, repairPriority: 10},
+		{health: 1.0, onDisk: true, repairPriority: 1},
+		{health: 0.1, stuck: true},
+	}
+	for _, chunk := range chunks {
+		heap.Push(&uch, chunk)
+	}
+
+	// The stuck chunk should come first, followed by the chunks sorted by
+	// their repair priority and health.
+	expected := []*unfinishedUploadChunk{chunks[4], chunks[2], chunks[3], chunks[1], chunks[0]}
+	for i, chunk := range expected {
+		if popped := heap.Pop(&uch).(*unfinishedUploadChunk); popped != chunk {
+			t.Fatalf("%v: unexpected chunk %v", i, popped)
+		}
+	}
+}
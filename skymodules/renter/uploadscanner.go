@@ -0,0 +1,350 @@
+package renter
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"gitlab.com/SkynetLabs/skyd/skymodules/renter/filesystem"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// The upload scanner is an external scanner, e.g. a virus scanner, which is
+// configured by the portal operator. The content of every uploaded skyfile is
+// spooled to a temporary file while it is uploaded. Before the skylink is
+// returned to the uploader, the content is passed to the scanner which can
+// reject it. Rejected skyfiles are deleted and their skylinks are added to the
+// blocklist together with the scanner's reason. Results are cached by the
+// SHA-256 hash of the content.
+//
+// The scanner is either an HTTP callback or a local command. The HTTP callback
+// receives the content as the body of a POST request and responds with a JSON
+// encoded uploadScanResult. The command is executed with the hex encoded hash
+// of the content as its only argument and receives the content on stdin. It
+// exits with code 0 to accept the content and with code 1 to reject it, in
+// which case its output is the reason.
+//
+// Skyfiles which are uploaded using TUS, pinned or imported don't pass through
+// a single reader which could be spooled. Their content is downloaded again
+// once the base sector and fanout are uploaded and scanned before the skylink
+// is returned.
+
+const (
+	// uploadScanCacheSize is the max number of scan results which are cached.
+	uploadScanCacheSize = 10000
+
+	// uploadScanTimeout is the max time the upload scanner has to scan the
+	// content of a skyfile.
+	uploadScanTimeout = 10 * time.Minute
+
+	// uploadScanCommandRejectCode is the exit code of a scanner command which
+	// rejects the content.
+	uploadScanCommandRejectCode = 1
+
+	// uploadScanDefaultReason is the reason recorded in the blocklist if the
+	// scanner didn't provide one.
+	uploadScanDefaultReason = "rejected by upload scanner"
+
+	// uploadScanContentHashHeader is the header which contains the hash of
+	// the content sent to an HTTP scanner.
+	uploadScanContentHashHeader = "Skynet-Content-Hash"
+)
+
+var (
+	// uploadScanPricePerMS is the price per millisecond the renter is willing
+	// to pay when downloading the content of an uploaded skyfile for the
+	// scanner.
+	uploadScanPricePerMS = types.SiacoinPrecision.MulFloat(1e-7) // 100 nS
+
+	// ErrSkyfileRejected is returned when the upload scanner rejects the
+	// content of a skyfile.
+	ErrSkyfileRejected = errors.New("skyfile was rejected by the upload scanner")
+)
+
+type (
+	// uploadScanner scans the content of uploaded skyfiles using an HTTP
+	// callback or a local command.
+	uploadScanner struct {
+		staticSource string
+
+		// results caches the scan results by content hash. order is used to
+		// evict the oldest results.
+		results map[crypto.Hash]uploadScanResult
+		order   []crypto.Hash
+		mu      sync.Mutex
+	}
+
+	// uploadScanResult is the result of scanning the content of a skyfile.
+	uploadScanResult struct {
+		Rejected bool   `json:"rejected"`
+		Reason   string `json:"reason"`
+	}
+
+	// uploadScanSpool is a SkyfileUploadReader which spools the data read
+	// from the wrapped reader to a temporary file while hashing it.
+	uploadScanSpool struct {
+		skymodules.SkyfileUploadReader
+
+		staticFile *os.File
+		hasher     hash.Hash
+		size       int64
+
+		// skip is the number of bytes which are read again after they were
+		// passed to SetReadBuffer and therefore are already spooled.
+		skip int
+	}
+)
+
+// newUploadScanner creates a scanner for the given source. Uploads aren't
+// scanned if the source is empty.
+func newUploadScanner(source string) *uploadScanner {
+	return &uploadScanner{
+		staticSource: source,
+		results:      make(map[crypto.Hash]uploadScanResult),
+	}
+}
+
+// staticEnabled returns whether uploads should be scanned.
+func (us *uploadScanner) staticEnabled() bool {
+	return us.staticSource != ""
+}
+
+// managedScan scans the spooled content and returns the result. Cached results
+// are returned without scanning the content again.
+func (us *uploadScanner) managedScan(ctx context.Context, spool *uploadScanSpool) (uploadScanResult, error) {
+	contentHash := spool.ContentHash()
+	us.mu.Lock()
+	result, cached := us.results[contentHash]
+	us.mu.Unlock()
+	if cached {
+		return result, nil
+	}
+
+	content, err := spool.Content()
+	if err != nil {
+		return uploadScanResult{}, errors.AddContext(err, "failed to read spooled content")
+	}
+	ctx, cancel := context.WithTimeout(ctx, uploadScanTimeout)
+	defer cancel()
+	if strings.HasPrefix(us.staticSource, "http://") || strings.HasPrefix(us.staticSource, "https://") {
+		result, err = staticScanHTTP(ctx, us.staticSource, contentHash, content, spool.size)
+	} else {
+		result, err = staticScanCommand(ctx, us.staticSource, contentHash, content)
+	}
+	if err != nil {
+		return uploadScanResult{}, err
+	}
+	if result.Rejected && result.Reason == "" {
+		result.Reason = uploadScanDefaultReason
+	}
+
+	// Cache the result.
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	if _, exists := us.results[contentHash]; !exists {
+		us.results[contentHash] = result
+		us.order = append(us.order, contentHash)
+	}
+	for len(us.order) > uploadScanCacheSize {
+		delete(us.results, us.order[0])
+		us.order = us.order[1:]
+	}
+	return result, nil
+}
+
+// staticScanHTTP scans the content using the HTTP callback at the given URL.
+func staticScanHTTP(ctx context.Context, url string, contentHash crypto.Hash, content io.Reader, size int64) (uploadScanResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, content)
+	if err != nil {
+		return uploadScanResult{}, err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set(uploadScanContentHashHeader, "sha256:"+hex.EncodeToString(contentHash[:]))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return uploadScanResult{}, errors.AddContext(err, "failed to reach upload scanner")
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return uploadScanResult{}, fmt.Errorf("upload scanner responded with unexpected status code %v", resp.StatusCode)
+	}
+	var result uploadScanResult
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return uploadScanResult{}, errors.AddContext(err, "failed to decode upload scanner response")
+	}
+	return result, nil
+}
+
+// staticScanCommand scans the content using the command at the given path.
+func staticScanCommand(ctx context.Context, path string, contentHash crypto.Hash, content io.Reader) (uploadScanResult, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, hex.EncodeToString(contentHash[:]))
+	cmd.Stdin = content
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == uploadScanCommandRejectCode {
+		return uploadScanResult{
+			Rejected: true,
+			Reason:   strings.TrimSpace(stdout.String()),
+		}, nil
+	}
+	if err != nil {
+		return uploadScanResult{}, errors.AddContext(err, fmt.Sprintf("upload scanner failed: %v", strings.TrimSpace(stderr.String())))
+	}
+	return uploadScanResult{}, nil
+}
+
+// newUploadScanSpool wraps the given reader in a spool which writes the read
+// data to a temporary file.
+func newUploadScanSpool(reader skymodules.SkyfileUploadReader) (*uploadScanSpool, error) {
+	f, err := ioutil.TempFile("", "skyd-upload-scan-")
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to create spool file")
+	}
+	return &uploadScanSpool{
+		SkyfileUploadReader: reader,
+		staticFile:          f,
+		hasher:              sha256.New(),
+	}, nil
+}
+
+// Read implements io.Reader. The read data is spooled unless it was already
+// spooled before it was passed to SetReadBuffer.
+func (s *uploadScanSpool) Read(b []byte) (int, error) {
+	n, err := s.SkyfileUploadReader.Read(b)
+	data := b[:n]
+	if s.skip > 0 {
+		skipped := s.skip
+		if skipped > len(data) {
+			skipped = len(data)
+		}
+		data = data[skipped:]
+		s.skip -= skipped
+	}
+	if len(data) > 0 {
+		if _, writeErr := s.staticFile.Write(data); writeErr != nil {
+			return n, errors.Compose(err, errors.AddContext(writeErr, "failed to spool upload"))
+		}
+		_, _ = s.hasher.Write(data)
+		s.size += int64(len(data))
+	}
+	return n, err
+}
+
+// SetReadBuffer implements skymodules.SkyfileUploadReader. The buffer is
+// expected to contain the data which was read last.
+func (s *uploadScanSpool) SetReadBuffer(data []byte) {
+	s.skip += len(data)
+	s.SkyfileUploadReader.SetReadBuffer(data)
+}
+
+// ContentHash returns the SHA-256 hash of the spooled content.
+func (s *uploadScanSpool) ContentHash() (h crypto.Hash) {
+	copy(h[:], s.hasher.Sum(nil))
+	return
+}
+
+// Content returns a reader for the spooled content.
+func (s *uploadScanSpool) Content() (io.Reader, error) {
+	if _, err := s.staticFile.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return io.LimitReader(s.staticFile, s.size), nil
+}
+
+// Close closes and removes the spool file.
+func (s *uploadScanSpool) Close() error {
+	return errors.Compose(s.staticFile.Close(), os.Remove(s.staticFile.Name()))
+}
+
+// managedScanSkyfile scans the spooled content of the skyfile with the given
+// skylink. If the content is rejected, the skylink is blocked with the
+// scanner's reason and ErrSkyfileRejected is returned.
+func (r *Renter) managedScanSkyfile(ctx context.Context, skylink skymodules.Skylink, spool *uploadScanSpool) error {
+	result, err := r.staticUploadScanner.managedScan(ctx, spool)
+	if err != nil {
+		return errors.AddContext(err, "failed to scan skyfile")
+	}
+	if !result.Rejected {
+		return nil
+	}
+	r.staticLog.Printf("Upload scanner rejected skylink %v: %v", skylink, result.Reason)
+	hash := crypto.HashObject(skylink.MerkleRoot())
+	err = r.staticSkynetBlocklist.BlockWithReason([]crypto.Hash{hash}, result.Reason)
+	if err != nil {
+		return errors.Compose(ErrSkyfileRejected, errors.AddContext(err, "failed to block rejected skylink"))
+	}
+	return errors.AddContext(ErrSkyfileRejected, result.Reason)
+}
+
+// managedScanUploadedSkyfile scans the content of a skyfile which was uploaded
+// to the given siapath without being spooled. The content is downloaded using
+// the skylink. If the content is rejected, the skyfile's siafiles are deleted,
+// the skylink is blocked and ErrSkyfileRejected is returned.
+func (r *Renter) managedScanUploadedSkyfile(ctx context.Context, skylink skymodules.Skylink, siaPath skymodules.SiaPath) (err error) {
+	if !r.staticUploadScanner.staticEnabled() {
+		return nil
+	}
+	span, ctx := opentracing.StartSpanFromContext(ctx, "managedScanUploadedSkyfile")
+	defer span.Finish()
+
+	// Spool the content of the skyfile.
+	streamer, err := r.managedDownloadSkylink(ctx, skylink, uploadScanTimeout, uploadScanPricePerMS)
+	if err != nil {
+		return errors.AddContext(err, "failed to download skyfile for upload scan")
+	}
+	defer func() {
+		err = errors.Compose(err, streamer.Close())
+	}()
+	spool, err := newUploadScanSpool(skymodules.NewSkyfileReader(streamer, skymodules.SkyfileUploadParameters{}))
+	if err != nil {
+		return errors.AddContext(err, "unable to prepare upload scan")
+	}
+	defer func() {
+		if closeErr := spool.Close(); closeErr != nil {
+			r.staticLog.Printf("error closing upload scan spool: %v", closeErr)
+		}
+	}()
+	_, err = io.Copy(ioutil.Discard, spool)
+	if err != nil {
+		return errors.AddContext(err, "failed to spool skyfile for upload scan")
+	}
+
+	// Scan it and delete the skyfile if it was rejected.
+	err = r.managedScanSkyfile(ctx, skylink, spool)
+	if !errors.Contains(err, ErrSkyfileRejected) {
+		return err
+	}
+	extendedSiaPath, spErr := siaPath.AddSuffixStr(skymodules.ExtendedSuffix)
+	if spErr != nil {
+		return errors.Compose(err, spErr)
+	}
+	for _, sp := range []skymodules.SiaPath{siaPath, extendedSiaPath} {
+		deleteErr := r.DeleteFile(sp)
+		if deleteErr != nil && !errors.Contains(deleteErr, filesystem.ErrNotExist) {
+			err = errors.Compose(err, deleteErr)
+		}
+	}
+	return err
+}
@@ -0,0 +1,359 @@
+package renter
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"gitlab.com/SkynetLabs/skyd/skymodules/renter/filesystem"
+	"go.sia.tech/siad/crypto"
+)
+
+// newTestUploadScanSpool returns a spool which has read all of the given data.
+func newTestUploadScanSpool(t *testing.T, data []byte) *uploadScanSpool {
+	reader := skymodules.NewSkyfileReader(bytes.NewReader(data), skymodules.SkyfileUploadParameters{})
+	spool, err := newUploadScanSpool(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ioutil.ReadAll(spool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spool
+}
+
+// newTestScannerCommand writes a shell script with the given name and body to
+// a test directory and returns its path.
+func newTestScannerCommand(t *testing.T, name, body string) string {
+	dir := build.TempDir("renter", "uploadscanner", t.Name(), name)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "scanner.sh")
+	err := ioutil.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestUploadScanSpool verifies that the spool records the read data exactly
+// once, even if parts of it are passed back to SetReadBuffer.
+func TestUploadScanSpool(t *testing.T) {
+	t.Parallel()
+
+	data := fastrand.Bytes(1000)
+	reader := skymodules.NewSkyfileReader(bytes.NewReader(data), skymodules.SkyfileUploadParameters{})
+	spool, err := newUploadScanSpool(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := spool.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Read some data and pass part of it back.
+	buf := make([]byte, 100)
+	n, err := spool.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spool.SetReadBuffer(buf[50:n])
+
+	// Read the remaining data.
+	rest, err := ioutil.ReadAll(spool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(append(buf[:50], rest...), data) {
+		t.Fatal("read data doesn't match")
+	}
+
+	// The spool should contain the data once.
+	content, err := spool.Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	spooled, err := ioutil.ReadAll(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(spooled, data) {
+		t.Fatal("spooled data doesn't match")
+	}
+	if spool.ContentHash() != crypto.Hash(sha256.Sum256(data)) {
+		t.Fatal("wrong content hash")
+	}
+}
+
+// TestUploadScannerHTTP verifies that an HTTP scanner receives the content and
+// that its results are cached.
+func TestUploadScannerHTTP(t *testing.T) {
+	t.Parallel()
+
+	var calls uint64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddUint64(&calls, 1)
+		content, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(uploadScanResult{
+			Rejected: bytes.Contains(content, []byte("malware")),
+		})
+	}))
+	defer srv.Close()
+	us := newUploadScanner(srv.URL)
+	if !us.staticEnabled() {
+		t.Fatal("scanner should be enabled")
+	}
+
+	accepted := newTestUploadScanSpool(t, []byte("harmless"))
+	defer func() {
+		if err := accepted.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	rejected := newTestUploadScanSpool(t, []byte("some malware"))
+	defer func() {
+		if err := rejected.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	for i := 0; i < 2; i++ {
+		result, err := us.managedScan(context.Background(), accepted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Rejected {
+			t.Fatal("content shouldn't be rejected")
+		}
+		result, err = us.managedScan(context.Background(), rejected)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Rejected || result.Reason != uploadScanDefaultReason {
+			t.Fatal("content should be rejected", result)
+		}
+	}
+	// The second scans should have been served from the cache.
+	if n := atomic.LoadUint64(&calls); n != 2 {
+		t.Fatal("expected 2 calls but got", n)
+	}
+
+	// A failing scanner should fail the scan.
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	_, err := newUploadScanner(failing.URL).managedScan(context.Background(), accepted)
+	if err == nil {
+		t.Fatal("scan should fail")
+	}
+}
+
+// TestUploadScannerCommand verifies that a command scanner receives the
+// content and can reject it.
+func TestUploadScannerCommand(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	cmd := newTestScannerCommand(t, "reject", `if grep -q malware; then echo "found malware"; exit 1; fi`)
+	us := newUploadScanner(cmd)
+
+	accepted := newTestUploadScanSpool(t, []byte("harmless"))
+	defer func() {
+		if err := accepted.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	result, err := us.managedScan(context.Background(), accepted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Rejected {
+		t.Fatal("content shouldn't be rejected")
+	}
+
+	rejected := newTestUploadScanSpool(t, []byte("some malware"))
+	defer func() {
+		if err := rejected.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	result, err = us.managedScan(context.Background(), rejected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Rejected || result.Reason != "found malware" {
+		t.Fatal("content should be rejected", result)
+	}
+
+	// Any other exit code is an error.
+	cmd = newTestScannerCommand(t, "fail", "exit 2")
+	_, err = newUploadScanner(cmd).managedScan(context.Background(), accepted)
+	if err == nil {
+		t.Fatal("scan should fail")
+	}
+}
+
+// TestManagedScanSkyfile verifies that rejected skylinks are blocked together
+// with the scanner's reason.
+func TestManagedScanSkyfile(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+	cmd := newTestScannerCommand(t, "reject", `if grep -q malware; then echo "found malware"; exit 1; fi`)
+	r.staticUploadScanner = newUploadScanner(cmd)
+
+	skylink, err := skymodules.NewSkylinkV1(crypto.HashBytes(fastrand.Bytes(32)), 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Accepted content isn't blocked.
+	accepted := newTestUploadScanSpool(t, []byte("harmless"))
+	defer func() {
+		if err := accepted.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	err = r.managedScanSkyfile(context.Background(), skylink, accepted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocked, err := r.managedIsBlocked(context.Background(), skylink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blocked {
+		t.Fatal("skylink shouldn't be blocked")
+	}
+
+	// Rejected content is blocked with the reason.
+	rejected := newTestUploadScanSpool(t, []byte("some malware"))
+	defer func() {
+		if err := rejected.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	err = r.managedScanSkyfile(context.Background(), skylink, rejected)
+	if !errors.Contains(err, ErrSkyfileRejected) {
+		t.Fatal("expected rejection", err)
+	}
+	blocked, err = r.managedIsBlocked(context.Background(), skylink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !blocked {
+		t.Fatal("skylink should be blocked")
+	}
+	reasons, err := r.BlocklistReasons()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reason := reasons[crypto.HashObject(skylink.MerkleRoot())]; reason != "found malware" {
+		t.Fatal("wrong reason", reason)
+	}
+}
+
+// TestManagedScanUploadedSkyfile verifies that skyfiles which weren't spooled
+// during the upload are downloaded and scanned and deleted if rejected.
+func TestManagedScanUploadedSkyfile(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	wt, err := newWorkerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := wt.rt.renter
+	cmd := newTestScannerCommand(t, "reject", `if grep -q malware; then echo "found malware"; exit 1; fi`)
+	r.staticUploadScanner = newUploadScanner(cmd)
+
+	// upload uploads a small skyfile with the given content without scanning
+	// it.
+	upload := func(data []byte) (skymodules.Skylink, skymodules.SiaPath) {
+		sup := skymodules.SkyfileUploadParameters{
+			SiaPath:             skymodules.RandomSkynetFilePath(),
+			BaseChunkRedundancy: 2,
+		}
+		metadataBytes, err := skymodules.SkyfileMetadataBytes(skymodules.SkyfileMetadata{
+			Filename: "file",
+			Length:   uint64(len(data)),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		skylink, err := r.managedUploadSkyfileSmallFile(context.Background(), sup, metadataBytes, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return skylink, sup.SiaPath
+	}
+
+	// Accepted content is kept.
+	skylink, siaPath := upload([]byte("harmless"))
+	err = r.managedScanUploadedSkyfile(context.Background(), skylink, siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.File(siaPath); err != nil {
+		t.Fatal("accepted skyfile should exist", err)
+	}
+
+	// Rejected content is deleted and blocked.
+	skylink, siaPath = upload([]byte("some malware"))
+	err = r.managedScanUploadedSkyfile(context.Background(), skylink, siaPath)
+	if !errors.Contains(err, ErrSkyfileRejected) {
+		t.Fatal("expected rejection", err)
+	}
+	if _, err := r.File(siaPath); !errors.Contains(err, filesystem.ErrNotExist) {
+		t.Fatal("rejected skyfile should be deleted", err)
+	}
+	blocked, err := r.managedIsBlocked(context.Background(), skylink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !blocked {
+		t.Fatal("skylink should be blocked")
+	}
+}
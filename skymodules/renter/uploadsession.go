@@ -0,0 +1,323 @@
+package renter
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"gitlab.com/SkynetLabs/skyd/skymodules/renter/filesystem"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+// The upload sessions record which chunks of a large skyfile's extended
+// siafile are available on the network. When an upload with a session ID is
+// interrupted, the extended siafile is kept. Resuming the upload reopens it,
+// skips the chunks recorded in the session after verifying that they match the
+// new content and uploads the remaining chunks. Once the skylink is created,
+// the session is removed.
+
+const (
+	// uploadSessionsFilename is the name of the file which persists the
+	// upload sessions.
+	uploadSessionsFilename = "uploadsessions.dat"
+)
+
+var (
+	// uploadSessionsMDHeader is the header of the metadata for the persist
+	// file.
+	uploadSessionsMDHeader = types.NewSpecifier("UploadSessions")
+)
+
+type (
+	// uploadSessions is the persisted set of unfinished upload sessions.
+	uploadSessions struct {
+		sessions map[string]*uploadSession
+
+		staticAop *persist.AppendOnlyPersist
+		mu        sync.Mutex
+	}
+
+	// uploadSession is an unfinished upload session.
+	uploadSession struct {
+		siaPath   skymodules.SiaPath
+		completed map[uint64]struct{}
+	}
+
+	// uploadSessionEntry is the definition of a persisted entry. An entry
+	// either starts a session, records a completed chunk of a session or
+	// removes a session.
+	uploadSessionEntry struct {
+		ID      string             `json:"id"`
+		SiaPath skymodules.SiaPath `json:"siapath,omitempty"`
+		Chunk   *uint64            `json:"chunk,omitempty"`
+		Remove  bool               `json:"remove,omitempty"`
+	}
+
+	// uploadSessionTracker records the chunks of an upload session once they
+	// become available.
+	uploadSessionTracker struct {
+		staticChunks    chan *unfinishedUploadChunk
+		staticDone      chan struct{}
+		staticCloseOnce sync.Once
+		err             error
+	}
+)
+
+// newUploadSessions creates a new set of upload sessions or loads an existing
+// one from disk.
+func newUploadSessions(dir, filename string) (*uploadSessions, error) {
+	aop, r, err := persist.NewAppendOnlyPersist(dir, filename, uploadSessionsMDHeader, persist.MetadataVersionv156)
+	if err != nil {
+		return nil, err
+	}
+	us := &uploadSessions{
+		sessions:  make(map[string]*uploadSession),
+		staticAop: aop,
+	}
+	decoder := json.NewDecoder(r)
+	for {
+		var entry uploadSessionEntry
+		err := decoder.Decode(&entry)
+		if errors.Contains(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, errors.Compose(err, aop.Close())
+		}
+		us.applyEntry(entry)
+	}
+	return us, nil
+}
+
+// applyEntry applies a persisted entry to the in-memory sessions.
+func (us *uploadSessions) applyEntry(entry uploadSessionEntry) {
+	if entry.Remove {
+		delete(us.sessions, entry.ID)
+		return
+	}
+	session, exists := us.sessions[entry.ID]
+	if entry.Chunk == nil {
+		us.sessions[entry.ID] = &uploadSession{
+			siaPath:   entry.SiaPath,
+			completed: make(map[uint64]struct{}),
+		}
+		return
+	}
+	if !exists {
+		return // session was removed
+	}
+	session.completed[*entry.Chunk] = struct{}{}
+}
+
+// Close closes the underlying persistence.
+func (us *uploadSessions) Close() error {
+	return us.staticAop.Close()
+}
+
+// managedPersistEntry persists an entry and applies it.
+func (us *uploadSessions) managedPersistEntry(entry uploadSessionEntry) error {
+	entryBytes, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	_, err = us.staticAop.Write(entryBytes)
+	if err != nil {
+		return err
+	}
+	us.applyEntry(entry)
+	return nil
+}
+
+// managedSession returns the siapath and the completed chunks of a session.
+func (us *uploadSessions) managedSession(id string) (skymodules.SiaPath, map[uint64]struct{}, bool) {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	session, exists := us.sessions[id]
+	if !exists {
+		return skymodules.SiaPath{}, nil, false
+	}
+	completed := make(map[uint64]struct{}, len(session.completed))
+	for chunkIndex := range session.completed {
+		completed[chunkIndex] = struct{}{}
+	}
+	return session.siaPath, completed, true
+}
+
+// managedStart starts a new session for the skyfile at the given siapath,
+// replacing any existing session with the same ID.
+func (us *uploadSessions) managedStart(id string, siaPath skymodules.SiaPath) error {
+	return us.managedPersistEntry(uploadSessionEntry{
+		ID:      id,
+		SiaPath: siaPath,
+	})
+}
+
+// managedCompleteChunk records a chunk of a session as completed. Chunks of
+// unknown sessions are ignored.
+func (us *uploadSessions) managedCompleteChunk(id string, chunkIndex uint64) error {
+	us.mu.Lock()
+	session, exists := us.sessions[id]
+	completed := exists
+	if exists {
+		_, completed = session.completed[chunkIndex]
+	}
+	us.mu.Unlock()
+	if !exists || completed {
+		return nil
+	}
+	return us.managedPersistEntry(uploadSessionEntry{
+		ID:    id,
+		Chunk: &chunkIndex,
+	})
+}
+
+// managedRemove removes a session.
+func (us *uploadSessions) managedRemove(id string) error {
+	us.mu.Lock()
+	_, exists := us.sessions[id]
+	us.mu.Unlock()
+	if !exists {
+		return nil
+	}
+	return us.managedPersistEntry(uploadSessionEntry{
+		ID:     id,
+		Remove: true,
+	})
+}
+
+// managedExists returns whether a session with the given ID exists.
+func (us *uploadSessions) managedExists(id string) bool {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	_, exists := us.sessions[id]
+	return exists
+}
+
+// managedOpenUploadSession opens the extended siafile of the upload session
+// of the given skyfile upload. If the session doesn't exist yet, or its
+// siafile was deleted, a new session is started and the siafile is created
+// from the given upload params. The completed chunks of the session are
+// returned as well.
+func (r *Renter) managedOpenUploadSession(sup skymodules.SkyfileUploadParameters, fup skymodules.FileUploadParams) (*filesystem.FileNode, map[uint64]struct{}, error) {
+	siaPath, completed, exists := r.staticUploadSessions.managedSession(sup.SessionID)
+	if exists && !siaPath.Equals(sup.SiaPath) {
+		return nil, nil, skymodules.ErrUploadSessionSiaPathMismatch
+	}
+	if exists {
+		fileNode, err := r.staticFileSystem.OpenSiaFile(fup.SiaPath)
+		if err == nil && fileNode.ErasureCode().Identifier() != fup.ErasureCode.Identifier() {
+			err = errors.Compose(fileNode.Close(), errors.New("erasure code of resumed upload session doesn't match"))
+			return nil, nil, err
+		}
+		if err == nil {
+			return fileNode, completed, nil
+		}
+		if !errors.Contains(err, filesystem.ErrNotExist) {
+			return nil, nil, errors.AddContext(err, "failed to open siafile of upload session")
+		}
+	}
+
+	// Start a new session.
+	fileNode, err := r.managedInitUploadStream(fup)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = r.staticUploadSessions.managedStart(sup.SessionID, sup.SiaPath)
+	if err != nil {
+		return nil, nil, errors.Compose(err, fileNode.Close())
+	}
+	return fileNode, make(map[uint64]struct{}), nil
+}
+
+// managedUploadSessionRedundancy returns the number of data and parity pieces
+// of the extended siafile of an earlier attempt of the upload session. A
+// resumed upload keeps the redundancy of its earlier attempts, even if the
+// fanout redundancy policy would choose a different one by now.
+func (r *Renter) managedUploadSessionRedundancy(sup skymodules.SkyfileUploadParameters, extendedPath skymodules.SiaPath) (dataPieces, parityPieces int, exists bool) {
+	siaPath, _, exists := r.staticUploadSessions.managedSession(sup.SessionID)
+	if !exists || !siaPath.Equals(sup.SiaPath) {
+		return 0, 0, false
+	}
+	fileNode, err := r.staticFileSystem.OpenSiaFile(extendedPath)
+	if err != nil {
+		return 0, 0, false
+	}
+	ec := fileNode.ErasureCode()
+	if err := fileNode.Close(); err != nil {
+		r.staticLog.Println("failed to close siafile of upload session:", err)
+	}
+	return ec.MinPieces(), ec.NumPieces() - ec.MinPieces(), true
+}
+
+// staticVerifySessionChunk checks that the pieces of a chunk completed in an
+// earlier attempt of an upload session match the given piece roots.
+func staticVerifySessionChunk(fileNode *filesystem.FileNode, chunkIndex uint64, roots []crypto.Hash) error {
+	pieces, err := fileNode.Pieces(chunkIndex)
+	if err != nil {
+		return err
+	}
+	for pieceIndex, pieceSet := range pieces {
+		for _, piece := range pieceSet {
+			if pieceIndex >= len(roots) || piece.MerkleRoot != roots[pieceIndex] {
+				return skymodules.ErrUploadSessionContentMismatch
+			}
+		}
+	}
+	return nil
+}
+
+// newUploadSessionTracker creates a tracker which records the chunks passed to
+// it in the upload session with the given ID once they become available. The
+// chunks are tracked in the background to record them as soon as possible.
+func (r *Renter) newUploadSessionTracker(id string) *uploadSessionTracker {
+	t := &uploadSessionTracker{
+		staticChunks: make(chan *unfinishedUploadChunk, 100),
+		staticDone:   make(chan struct{}),
+	}
+	go func() {
+		defer close(t.staticDone)
+		for chunk := range t.staticChunks {
+			if t.err != nil {
+				continue
+			}
+			select {
+			case <-r.tg.StopChan():
+				t.err = errors.New("upload timed out, renter has shutdown")
+				continue
+			case <-chunk.staticAvailableChan:
+			}
+			chunk.mu.Lock()
+			err := chunk.err
+			chunk.mu.Unlock()
+			if err != nil {
+				t.err = errors.AddContext(err, "upload streamer failed to get all data available")
+				continue
+			}
+			err = r.staticUploadSessions.managedCompleteChunk(id, chunk.staticIndex)
+			if err != nil {
+				t.err = errors.AddContext(err, "failed to record chunk in upload session")
+			}
+		}
+	}()
+	return t
+}
+
+// track adds a chunk to the tracker.
+func (t *uploadSessionTracker) track(chunks ...*unfinishedUploadChunk) {
+	for _, chunk := range chunks {
+		t.staticChunks <- chunk
+	}
+}
+
+// managedWait stops accepting new chunks and waits for the tracked chunks to
+// become available. It can be called multiple times.
+func (t *uploadSessionTracker) managedWait() error {
+	t.staticCloseOnce.Do(func() { close(t.staticChunks) })
+	<-t.staticDone
+	return t.err
+}
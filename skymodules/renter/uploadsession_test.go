@@ -0,0 +1,87 @@
+package renter
+
+import (
+	"testing"
+
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+// TestUploadSessionsPersist tests that the upload sessions are persisted.
+func TestUploadSessionsPersist(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	testDir := build.TempDir("renter", t.Name())
+	fileName := "test"
+
+	us, err := newUploadSessions(testDir, fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Start two sessions, complete a few chunks and remove one of them.
+	siaPath1, siaPath2 := skymodules.RandomSiaPath(), skymodules.RandomSiaPath()
+	if err := us.managedStart("1", siaPath1); err != nil {
+		t.Fatal(err)
+	}
+	if err := us.managedStart("2", siaPath2); err != nil {
+		t.Fatal(err)
+	}
+	for _, chunkIndex := range []uint64{0, 2, 2} {
+		if err := us.managedCompleteChunk("1", chunkIndex); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := us.managedCompleteChunk("2", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := us.managedRemove("2"); err != nil {
+		t.Fatal(err)
+	}
+	// Completing chunks of unknown sessions is a no-op.
+	if err := us.managedCompleteChunk("3", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// check checks the sessions.
+	check := func(us *uploadSessions) {
+		t.Helper()
+		siaPath, completed, exists := us.managedSession("1")
+		if !exists || !siaPath.Equals(siaPath1) {
+			t.Fatal("wrong session", exists, siaPath)
+		}
+		_, completed0 := completed[0]
+		_, completed2 := completed[2]
+		if len(completed) != 2 || !completed0 || !completed2 {
+			t.Fatal("wrong completed chunks", completed)
+		}
+		if us.managedExists("2") || us.managedExists("3") {
+			t.Fatal("session shouldn't exist")
+		}
+	}
+	check(us)
+
+	// Reload.
+	if err := us.Close(); err != nil {
+		t.Fatal(err)
+	}
+	us, err = newUploadSessions(testDir, fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(us)
+
+	// Restarting a session resets its completed chunks.
+	if err := us.managedStart("1", siaPath1); err != nil {
+		t.Fatal(err)
+	}
+	if _, completed, _ := us.managedSession("1"); len(completed) != 0 {
+		t.Fatal("expected no completed chunks", completed)
+	}
+	if err := us.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
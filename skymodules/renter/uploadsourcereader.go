@@ -21,6 +21,14 @@ type chunkReader struct {
 	peek       []byte
 }
 
+// pipelinedChunkReader is a ChunkReader which can also return the data pieces
+// of a chunk before they are erasure coded. That way the data pieces can be
+// uploaded while the parity pieces are still being computed.
+type pipelinedChunkReader interface {
+	skymodules.ChunkReader
+	ReadDataPieces() ([][]byte, uint64, error)
+}
+
 // fanoutChunkReader implements the FanoutChunkReader interface by wrapping a
 // ChunkReader.
 type fanoutChunkReader struct {
@@ -76,24 +84,39 @@ func (cr *chunkReader) Peek() bool {
 // that this chunk was created from which is useful because the last chunk might
 // be padded.
 func (cr *chunkReader) ReadChunk() ([][]byte, uint64, error) {
-	r := io.MultiReader(bytes.NewReader(cr.peek), cr.staticReader)
-	dataPieces, n, err := readDataPieces(r, cr.staticEC, cr.staticPieceSize)
+	chunkIndex := cr.chunkIndex
+	dataPieces, n, err := cr.ReadDataPieces()
+	if errors.Contains(err, io.EOF) {
+		return nil, 0, io.EOF
+	}
 	if err != nil {
 		return nil, 0, errors.AddContext(err, "ReadChunk: failed to read data pieces")
 	}
-	if n == 0 {
-		return nil, 0, io.EOF
-	}
 	logicalChunkData, err := cr.staticEC.EncodeShards(dataPieces)
 	if err != nil {
 		return nil, 0, errors.AddContext(err, "ReadChunk: failed to encode logical chunk data")
 	}
 	for pieceIndex := range logicalChunkData {
-		padAndEncryptPiece(cr.chunkIndex, uint64(pieceIndex), logicalChunkData, cr.staticMasterKey)
+		padAndEncryptPiece(chunkIndex, uint64(pieceIndex), logicalChunkData, cr.staticMasterKey)
+	}
+	return logicalChunkData, n, nil
+}
+
+// ReadDataPieces reads the data pieces of the next chunk from the reader
+// without erasure coding or encrypting them. It also returns the number of
+// bytes that the pieces were created from.
+func (cr *chunkReader) ReadDataPieces() ([][]byte, uint64, error) {
+	r := io.MultiReader(bytes.NewReader(cr.peek), cr.staticReader)
+	dataPieces, n, err := readDataPieces(r, cr.staticEC, cr.staticPieceSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	if n == 0 {
+		return nil, 0, io.EOF
 	}
 	cr.peek = nil
 	cr.chunkIndex++
-	return logicalChunkData, n, nil
+	return dataPieces, n, nil
 }
 
 // Fanout returns the current fanout.
@@ -85,6 +85,36 @@ func (ss *StreamShard) ReadChunk() ([][]byte, uint64, error) {
 	return chunk, ss.n, ss.err
 }
 
+// ReadDataPieces implements the pipelinedChunkReader interface. It can only be
+// called if the shard's reader supports pipelining.
+func (ss *StreamShard) ReadDataPieces() ([][]byte, uint64, error) {
+	if ss.closed {
+		return nil, 0, errors.New("StreamShard already closed")
+	}
+	pr, ok := ss.r.(pipelinedChunkReader)
+	if !ok {
+		return nil, 0, errors.New("StreamShard reader doesn't support pipelining")
+	}
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	// Read data pieces.
+	var dataPieces [][]byte
+	dataPieces, ss.n, ss.err = pr.ReadDataPieces()
+
+	// The chunk is read. Mark the shard as closed.
+	ss.closed = true
+	close(ss.signalChan)
+
+	return dataPieces, ss.n, ss.err
+}
+
+// staticCanPipeline returns whether the shard's reader supports pipelining.
+func (ss *StreamShard) staticCanPipeline() bool {
+	_, ok := ss.r.(pipelinedChunkReader)
+	return ok
+}
+
 // UploadStreamFromReader reads from the provided reader until io.EOF is reached
 // and upload the data to the Sia network.
 func (r *Renter) UploadStreamFromReader(up skymodules.FileUploadParams, reader io.Reader) error {
@@ -119,6 +149,18 @@ func (r *Renter) managedInitUploadStream(up skymodules.FileUploadParams) (*files
 		return nil, errors.New("'force' and 'repair' can't both be set")
 	}
 
+	// If the upload is restricted to a set of hosts, make sure that the set
+	// can hold all the pieces of a chunk before touching any existing file.
+	if !repair {
+		if err := r.managedCheckAllowedHosts(up.AllowedHosts, ec); err != nil {
+			return nil, err
+		}
+	}
+	profile, err := up.UploadProfile()
+	if err != nil {
+		return nil, err
+	}
+
 	// Delete existing file if overwrite flag is set. Ignore ErrUnknownPath.
 	if force {
 		err := r.DeleteFile(siaPath)
@@ -156,7 +198,32 @@ func (r *Renter) managedInitUploadStream(up skymodules.FileUploadParams) (*files
 	if err != nil {
 		return nil, err
 	}
-	return r.staticFileSystem.OpenSiaFile(siaPath)
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return nil, err
+	}
+	// Assign the file to the requested allowance profile.
+	if profile != "" {
+		err = entry.SetProfile(profile)
+		if err != nil {
+			return nil, errors.Compose(err, entry.Close())
+		}
+	}
+	// Set the expiry time if the file should expire.
+	if !up.ExpiryTime.IsZero() {
+		err = entry.SetExpiryTime(up.ExpiryTime)
+		if err != nil {
+			return nil, errors.Compose(err, entry.Close())
+		}
+	}
+	// Restrict the file to the allowed hosts.
+	if len(up.AllowedHosts) > 0 {
+		err = entry.SetAllowedHosts(up.AllowedHosts)
+		if err != nil {
+			return nil, errors.Compose(err, entry.Close())
+		}
+	}
+	return entry, nil
 }
 
 // callUploadStreamFromReaderWithFileNodeNoBlock reads from the provided reader until
@@ -307,6 +374,20 @@ func (r *Renter) callUploadStreamFromReaderWithFileNode(ctx context.Context, fil
 		return n, err
 	}
 	// Wait for all chunks to become available.
+	if err := r.managedWaitForChunksAvailable(chunks); err != nil {
+		return n, err
+	}
+	// Disrupt to force an error and ensure the fileNode is being closed
+	// correctly.
+	if r.staticDeps.Disrupt("failUploadStreamFromReader") {
+		return n, errors.New("disrupted by failUploadStreamFromReader")
+	}
+	return n, nil
+}
+
+// managedWaitForChunksAvailable blocks until all of the provided chunks are
+// available on the network or until one of them failed.
+func (r *Renter) managedWaitForChunksAvailable(chunks []*unfinishedUploadChunk) (err error) {
 	for _, chunk := range chunks {
 		select {
 		case <-r.tg.StopChan():
@@ -317,15 +398,10 @@ func (r *Renter) callUploadStreamFromReaderWithFileNode(ctx context.Context, fil
 			chunk.mu.Unlock()
 		}
 		if err != nil {
-			return n, errors.AddContext(err, "upload streamer failed to get all data available")
+			return errors.AddContext(err, "upload streamer failed to get all data available")
 		}
 	}
-	// Disrupt to force an error and ensure the fileNode is being closed
-	// correctly.
-	if r.staticDeps.Disrupt("failUploadStreamFromReader") {
-		return n, errors.New("disrupted by failUploadStreamFromReader")
-	}
-	return n, nil
+	return nil
 }
 
 // callUploadStreamFromReader reads from the provided reader until io.EOF is
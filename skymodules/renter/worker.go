@@ -66,6 +66,8 @@ type (
 		atomicAccountBalanceCheckRunning uint64         // used for a sanity check
 		atomicCache                      unsafe.Pointer // points to a workerCache object
 		atomicCacheUpdating              uint64         // ensures only one cache update happens at a time
+		atomicCorruptPieces              uint64         // number of corrupt pieces served by the host
+		atomicPaused                     uint64         // set if the worker was paused manually
 		atomicPriceTable                 unsafe.Pointer // points to a workerPriceTable object
 		atomicPriceTableUpdateRunning    uint64         // used for a sanity check
 
@@ -106,6 +108,18 @@ type (
 		// launching of async jobs.
 		staticLoopState *workerLoopState
 
+		// The benchmark state contains the results of the benchmarks the
+		// worker periodically runs on its host.
+		staticBenchmarkState *workerBenchmarkState
+
+		// The stream pool keeps idle streams to the host open for the
+		// worker's RPCs.
+		staticStreamPool *workerStreamPool
+
+		// The connection state tracks the health of the worker's connection
+		// to its host.
+		staticConnectionState *workerConnectionState
+
 		// The maintenance state contains information about the worker's RHP3
 		// related state. It is used to determine whether or not the worker's
 		// maintenance cooldown can be reset.
@@ -221,11 +235,9 @@ func (r *Renter) newWorker(hostPubKey types.SiaPublicKey) (*worker, error) {
 		return nil, errors.AddContext(err, "could not open account")
 	}
 
-	// set the balance target to 1SC
-	//
-	// TODO: check that the balance target  makes sense in function of the
-	// amount of MDM programs it can run with that amount of money
-	balanceTarget := types.SiacoinPrecision
+	// set the balance target, the refill settings of the host are applied on
+	// top of it
+	balanceTarget := defaultAccountBalanceTarget
 	if r.staticDeps.Disrupt("DisableFunding") {
 		balanceTarget = types.ZeroCurrency
 	}
@@ -259,7 +271,7 @@ func (r *Renter) newWorker(hostPubKey types.SiaPublicKey) (*worker, error) {
 	}
 	// Share the read stats between the read queues. That way a repair
 	// download will contribute to user download estimations and vice versa.
-	jrs := &jobReadStats{}
+	jrs := newJobReadStats()
 
 	// staticJobReadRegistryDT will be seeded when the first price table is
 	// fetched.
@@ -267,6 +279,9 @@ func (r *Renter) newWorker(hostPubKey types.SiaPublicKey) (*worker, error) {
 
 	w.newPriceTable()
 	w.newMaintenanceState()
+	w.newBenchmarkState()
+	w.newStreamPool()
+	w.newConnectionState()
 	w.initJobHasSectorQueue()
 	w.initJobReadQueue(jrs)
 	w.initJobLowPrioReadQueue(jrs)
@@ -276,6 +291,12 @@ func (r *Renter) newWorker(hostPubKey types.SiaPublicKey) (*worker, error) {
 	w.initJobUpdateRegistryQueue()
 	w.initJobUploadSnapshotQueue()
 
+	// Close the idle streams of the stream pool when the worker is killed.
+	err = w.staticTG.OnStop(w.staticStreamPool.managedClose)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to register OnStop for worker stream pool")
+	}
+
 	// Close the worker when the renter is stopped.
 	err = r.tg.OnStop(func() error {
 		w.managedKill()
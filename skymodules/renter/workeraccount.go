@@ -130,6 +130,10 @@ type (
 		recentErrTime     time.Time
 		recentSuccessTime time.Time
 
+		// Refill tracking.
+		consecutiveRefillFailures uint64
+		recentRefills             []skymodules.WorkerAccountRefill
+
 		// syncAt defines what time the renter should be syncing the account to
 		// the host.
 		syncAt time.Time
@@ -208,6 +212,23 @@ func (s *spendingDetails) update(category spendingCategory, amount types.Currenc
 	}
 }
 
+// contractAccountSpending returns the breakdown of account spending that is
+// tracked in the contract with the account's host for a spend of the given
+// amount within the category.
+func (category spendingCategory) contractAccountSpending(amount types.Currency) (spending skymodules.ContractAccountSpending) {
+	switch category {
+	case categoryDownload, categoryRepairDownload, categorySnapshotDownload:
+		spending.DownloadSpending = amount
+	case categoryRegistryRead, categoryRegistryWrite, categorySubscription:
+		spending.RegistrySpending = amount
+	case categoryRepairUpload, categorySnapshotUpload, categoryUpload:
+		spending.UploadSpending = amount
+	default:
+		build.Critical("category is not handled, developer error")
+	}
+	return
+}
+
 // ProvidePayment takes a stream and various payment details and handles the
 // payment by sending and processing payment request and response objects.
 // Returns an error in case of failure.
@@ -356,7 +377,15 @@ func (a *account) managedCommitDeposit(amount types.Currency, success bool) {
 // got refunded by the host already.
 func (a *account) managedCommitWithdrawal(category spendingCategory, withdrawal, refund types.Currency, success bool) {
 	a.mu.Lock()
-	defer a.mu.Unlock()
+	defer func() {
+		a.mu.Unlock()
+
+		// report the spend to the contractor after releasing the lock to
+		// attribute it to the contract with the host
+		if success {
+			a.managedReportSpending(category, withdrawal)
+		}
+	}()
 
 	// (no need to sanity check - the implementation of 'Sub' does this for us)
 	a.pendingWithdrawals = a.pendingWithdrawals.Sub(withdrawal.Add(refund))
@@ -376,6 +405,15 @@ func (a *account) managedCommitWithdrawal(category spendingCategory, withdrawal,
 	}
 }
 
+// managedReportSpending reports a spend from the account to the contractor,
+// which tracks it in the contract with the account's host.
+func (a *account) managedReportSpending(category spendingCategory, amount types.Currency) {
+	err := a.staticRenter.staticHostContractor.RecordAccountSpending(a.staticHostKey, category.contractAccountSpending(amount))
+	if err != nil {
+		a.staticRenter.staticLog.Debugf("failed to record account spending for host %v: %v", a.staticHostKey, err)
+	}
+}
+
 // managedNeedsToRefill returns whether or not the account needs to be refilled.
 func (a *account) managedNeedsToRefill(target types.Currency) bool {
 	a.mu.Lock()
@@ -447,6 +485,9 @@ func (a *account) managedStatus() skymodules.WorkerAccountStatus {
 		RecentErr:         recentErrStr,
 		RecentErrTime:     a.recentErrTime,
 		RecentSuccessTime: a.recentSuccessTime,
+
+		ConsecutiveRefillFailures: a.consecutiveRefillFailures,
+		RecentRefills:             append([]skymodules.WorkerAccountRefill{}, a.recentRefills...),
 	}
 }
 
@@ -584,6 +625,7 @@ func (w *worker) externSyncAccountBalanceToHost() {
 	// our balance in case the host tells us we actually have more money, and it
 	// will keep track of drift in both directions.
 	w.staticAccount.managedSyncBalance(balance)
+	w.managedCheckAccountBalance()
 
 	// TODO perform a thorough balance comparison to decide whether the drift in
 	// the account balance is warranted. If not the host needs to be penalized
@@ -604,7 +646,8 @@ func (w *worker) managedNeedsToRefillAccount() bool {
 		return false
 	}
 
-	return w.staticAccount.managedNeedsToRefill(w.staticBalanceTarget.Div64(2))
+	_, threshold := w.managedAccountRefillTargets()
+	return w.staticAccount.managedNeedsToRefill(threshold)
 }
 
 // managedNeedsToSyncAccountBalanceToHost returns true if the renter needs to
@@ -628,13 +671,14 @@ func (w *worker) managedRefillAccount() {
 	if w.staticRenter.staticDeps.Disrupt("DisableFunding") {
 		return // don't refill account
 	}
-	// The account balance dropped to below half the balance target, refill. Use
+	// The account balance dropped to below the refill threshold, refill. Use
 	// the max expected balance when refilling to avoid exceeding any host
 	// maximums.
+	target, _ := w.managedAccountRefillTargets()
 	balance := w.staticAccount.managedMaxExpectedBalance()
 	amount := types.ZeroCurrency
-	if w.staticBalanceTarget.Cmp(balance) > 0 {
-		amount = w.staticBalanceTarget.Sub(balance)
+	if target.Cmp(balance) > 0 {
+		amount = target.Sub(balance)
 	}
 	pt := w.staticPriceTable().staticPriceTable
 
@@ -666,11 +710,16 @@ func (w *worker) managedRefillAccount() {
 		// working of the maintenance cooldown mechanism.
 		cd := w.managedTrackAccountRefillErr(err)
 
+		// Add the refill to the account's history and alert the user if
+		// refills keep failing.
+		w.managedTrackAccountRefill(amount, err)
+
 		// If the error is nil, return.
 		if err == nil {
 			w.staticAccount.mu.Lock()
 			w.staticAccount.recentSuccessTime = time.Now()
 			w.staticAccount.mu.Unlock()
+			w.managedCheckAccountBalance()
 			return
 		}
 
@@ -695,7 +744,7 @@ func (w *worker) managedRefillAccount() {
 	}()
 
 	// check the current price table for gouging errors
-	err = checkFundAccountGouging(w.staticPriceTable().staticPriceTable, w.staticCache().staticRenterAllowance, w.staticBalanceTarget)
+	err = checkFundAccountGouging(w.staticPriceTable().staticPriceTable, w.staticCache().staticRenterAllowance, target)
 	if err != nil {
 		return
 	}
@@ -710,7 +759,7 @@ func (w *worker) managedRefillAccount() {
 
 	// create a new stream
 	var stream net.Conn
-	stream, err = w.staticNewStream()
+	stream, err = w.staticNewStream(skymodules.TrafficClassInteractive)
 	if err != nil {
 		err = errors.AddContext(err, "Unable to create a new stream")
 		return
@@ -824,7 +873,7 @@ func (w *worker) staticHostAccountBalance() (_ types.Currency, err error) {
 	}()
 
 	// Get a stream.
-	stream, err := w.staticNewStream()
+	stream, err := w.staticNewStream(skymodules.TrafficClassInteractive)
 	if err != nil {
 		return types.ZeroCurrency, err
 	}
@@ -94,11 +94,129 @@ func TestWorkerAccount(t *testing.T) {
 		testWorkerAccountSyncAccountBalanceToHostCritical(t, wt)
 	})
 
+	t.Run("RefillSettings", func(t *testing.T) {
+		testWorkerAccountRefillSettings(t, wt)
+	})
+
 	t.Run("SpendingDetails", func(t *testing.T) {
 		testWorkerAccountSpendingDetails(t, wt)
 	})
 }
 
+// testWorkerAccountRefillSettings verifies the per-host refill settings, the
+// refill history and the account alerts.
+func testWorkerAccountRefillSettings(t *testing.T, wt *workerTester) {
+	w := wt.worker
+	r := w.staticRenter
+
+	// hasAlert is a helper that checks whether the alert with the given
+	// message is registered for the worker's host.
+	hasAlert := func(msg string) bool {
+		_, _, warn := r.staticAlerter.Alerts()
+		for _, alert := range warn {
+			if alert.Msg == msg && strings.Contains(alert.Cause, w.staticHostPubKeyStr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// wait until the worker is done with its maintenance tasks to make sure the
+	// account is funded
+	if err := build.Retry(100, 100*time.Millisecond, func() error {
+		if !w.managedMaintenanceSucceeded() {
+			return errors.New("worker not ready with maintenance")
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// without custom settings the defaults apply
+	target, threshold := w.managedAccountRefillTargets()
+	if !target.Equals(w.staticBalanceTarget) || !threshold.Equals(w.staticBalanceTarget.Div64(2)) {
+		t.Fatal("unexpected defaults", target, threshold)
+	}
+
+	// a threshold which isn't below the target is rejected
+	settings := skymodules.WorkerAccountRefillSettings{
+		Target:    types.SiacoinPrecision,
+		Threshold: types.SiacoinPrecision,
+	}
+	err := r.SetWorkerAccountRefillSettings(wt.staticHostPubKey, settings)
+	if !errors.Contains(err, errAccountRefillThresholdTooHigh) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// lowering the target below half the balance registers an alert
+	settings = skymodules.WorkerAccountRefillSettings{
+		Target: w.staticBalanceTarget.Div64(4),
+	}
+	err = r.SetWorkerAccountRefillSettings(wt.staticHostPubKey, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, threshold = w.managedAccountRefillTargets()
+	if !target.Equals(settings.Target) || !threshold.Equals(settings.Target.Div64(2)) {
+		t.Fatal("settings not applied", target, threshold)
+	}
+	status := w.callStatus()
+	if !status.AccountBalanceTarget.Equals(target) || !status.AccountRefillThreshold.Equals(threshold) {
+		t.Fatal("settings not reported", status.AccountBalanceTarget, status.AccountRefillThreshold)
+	}
+	if !hasAlert(AlertMSGWorkerAccountExcessiveBalance) {
+		t.Fatal("excessive balance alert not registered")
+	}
+
+	// restoring the defaults unregisters it again
+	err = r.SetWorkerAccountRefillSettings(wt.staticHostPubKey, skymodules.WorkerAccountRefillSettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hasAlert(AlertMSGWorkerAccountExcessiveBalance) {
+		t.Fatal("excessive balance alert not unregistered")
+	}
+	if _, exists := r.persist.AccountRefillSettings[w.staticHostPubKeyStr]; exists {
+		t.Fatal("settings should have been removed")
+	}
+
+	// repeated refill failures register an alert
+	refillErr := errors.New("refill failed")
+	for i := uint64(0); i < accountRefillFailureAlertThreshold; i++ {
+		if hasAlert(AlertMSGWorkerAccountRefillFailed) {
+			t.Fatal("alert registered too early", i)
+		}
+		w.managedTrackAccountRefill(types.SiacoinPrecision, refillErr)
+	}
+	if !hasAlert(AlertMSGWorkerAccountRefillFailed) {
+		t.Fatal("refill alert not registered")
+	}
+	accStatus := w.staticAccount.managedStatus()
+	if accStatus.ConsecutiveRefillFailures != accountRefillFailureAlertThreshold {
+		t.Fatal("wrong number of failures", accStatus.ConsecutiveRefillFailures)
+	}
+	last := accStatus.RecentRefills[len(accStatus.RecentRefills)-1]
+	if last.Success || last.Error != refillErr.Error() || !last.Amount.Equals(types.SiacoinPrecision) {
+		t.Fatal("unexpected refill", last)
+	}
+
+	// a successful refill resets the failures and unregisters the alert
+	w.managedTrackAccountRefill(types.SiacoinPrecision, nil)
+	if hasAlert(AlertMSGWorkerAccountRefillFailed) {
+		t.Fatal("refill alert not unregistered")
+	}
+	accStatus = w.staticAccount.managedStatus()
+	if accStatus.ConsecutiveRefillFailures != 0 {
+		t.Fatal("failures not reset", accStatus.ConsecutiveRefillFailures)
+	}
+	if len(accStatus.RecentRefills) > maxRecentAccountRefills {
+		t.Fatal("too many refills in history", len(accStatus.RecentRefills))
+	}
+	if last := accStatus.RecentRefills[len(accStatus.RecentRefills)-1]; !last.Success || last.Error != "" {
+		t.Fatal("unexpected refill", last)
+	}
+}
+
 // testAccountCheckFundAccountGouging checks that `checkFundAccountGouging` is
 // correctly detecting price gouging from a host.
 func testAccountCheckFundAccountGouging(t *testing.T) {
@@ -630,7 +748,7 @@ func testWorkerAccountSpendingDetails(t *testing.T, wt *workerTester) {
 	cost = cost.Add(bandwidthCost)
 
 	// execute it
-	_, _, err = w.managedExecuteProgram(p, data, types.FileContractID{}, categoryDownload, cost)
+	_, _, err = w.managedExecuteProgram(p, data, types.FileContractID{}, categoryDownload, skymodules.TrafficClassInteractive, cost)
 	if err != nil {
 		t.Fatal(err)
 	}
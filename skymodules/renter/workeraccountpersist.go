@@ -234,6 +234,7 @@ func (am *accountManager) managedOpenAccount(hostKey types.SiaPublicKey) (acc *a
 
 		staticFile:   am.staticFile,
 		staticOffset: int64(offset),
+		staticRenter: am.staticRenter,
 
 		staticReady: make(chan struct{}),
 	}
@@ -574,6 +575,7 @@ func (am *accountManager) readAccountAt(offset int64) (*account, error) {
 
 		staticOffset: offset,
 		staticFile:   am.staticFile,
+		staticRenter: am.staticRenter,
 	}
 	close(acc.staticReady)
 	return acc, nil
@@ -0,0 +1,168 @@
+package renter
+
+import (
+	"fmt"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// defaultAccountBalanceTarget is the balance target of an ephemeral
+	// account on a host without custom refill settings.
+	//
+	// TODO: check that the balance target  makes sense in function of the
+	// amount of MDM programs it can run with that amount of money
+	defaultAccountBalanceTarget = types.SiacoinPrecision
+
+	// accountRefillFailureAlertThreshold is the number of consecutive failed
+	// refills after which an alert is registered for the account.
+	accountRefillFailureAlertThreshold = uint64(5)
+
+	// accountExcessiveBalanceMultiplier defines when an account holds an
+	// excessive balance. That is the case if the balance exceeds the balance
+	// target times the multiplier.
+	accountExcessiveBalanceMultiplier = uint64(2)
+
+	// maxRecentAccountRefills is the number of refills kept in an account's
+	// refill history.
+	maxRecentAccountRefills = 10
+)
+
+var (
+	// errAccountRefillThresholdTooHigh is returned if the refill threshold of
+	// an account is not below its balance target.
+	errAccountRefillThresholdTooHigh = errors.New("account refill threshold must be lower than the balance target")
+)
+
+// SetWorkerAccountRefillSettings overwrites the balance target and refill
+// threshold of the ephemeral account on the given host. Zero settings restore
+// the defaults.
+func (r *Renter) SetWorkerAccountRefillSettings(hostKey types.SiaPublicKey, settings skymodules.WorkerAccountRefillSettings) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	// sanity check the settings
+	target, threshold := accountRefillTargets(defaultAccountBalanceTarget, settings)
+	if threshold.Cmp(target) >= 0 {
+		return errAccountRefillThresholdTooHigh
+	}
+
+	id := r.mu.Lock()
+	old, exists := r.persist.AccountRefillSettings[hostKey.String()]
+	if settings.Target.IsZero() && settings.Threshold.IsZero() {
+		delete(r.persist.AccountRefillSettings, hostKey.String())
+	} else {
+		if r.persist.AccountRefillSettings == nil {
+			r.persist.AccountRefillSettings = make(map[string]skymodules.WorkerAccountRefillSettings)
+		}
+		r.persist.AccountRefillSettings[hostKey.String()] = settings
+	}
+	r.mu.Unlock(id)
+	err := r.managedSaveSync()
+	if err != nil {
+		id = r.mu.Lock()
+		if exists {
+			r.persist.AccountRefillSettings[hostKey.String()] = old
+		} else {
+			delete(r.persist.AccountRefillSettings, hostKey.String())
+		}
+		r.mu.Unlock(id)
+		return errors.AddContext(err, "failed to persist account refill settings")
+	}
+
+	// Wake the worker to apply the new settings right away.
+	w, err := r.staticWorkerPool.callWorker(hostKey)
+	if err == nil {
+		w.managedCheckAccountBalance()
+		w.staticWake()
+	}
+	return nil
+}
+
+// managedAccountRefillTargets returns the balance target and refill threshold
+// of the worker's account.
+func (w *worker) managedAccountRefillTargets() (target, threshold types.Currency) {
+	r := w.staticRenter
+	id := r.mu.RLock()
+	settings := r.persist.AccountRefillSettings[w.staticHostPubKeyStr]
+	r.mu.RUnlock(id)
+
+	// Funding might be disabled in which case the settings are ignored.
+	if w.staticBalanceTarget.IsZero() {
+		return types.ZeroCurrency, types.ZeroCurrency
+	}
+	return accountRefillTargets(w.staticBalanceTarget, settings)
+}
+
+// managedCheckAccountBalance registers an alert if the worker's account holds
+// an excessive unspent balance.
+func (w *worker) managedCheckAccountBalance() {
+	alertID := alertIDWorkerAccountExcessiveBalance(w.staticHostPubKeyStr)
+	target, _ := w.managedAccountRefillTargets()
+	maxBalance := target.Mul64(accountExcessiveBalanceMultiplier)
+	balance := w.staticAccount.managedAvailableBalance()
+	if target.IsZero() || balance.Cmp(maxBalance) <= 0 {
+		w.staticRenter.staticAlerter.UnregisterAlert(alertID)
+		return
+	}
+	cause := fmt.Sprintf("host %v: balance of %v exceeds %v times the balance target of %v", w.staticHostPubKeyStr, balance.HumanString(), accountExcessiveBalanceMultiplier, target.HumanString())
+	w.staticRenter.staticAlerter.RegisterAlert(alertID, AlertMSGWorkerAccountExcessiveBalance, cause, modules.SeverityWarning)
+}
+
+// managedTrackAccountRefill adds a refill to the history of the worker's
+// account and registers an alert if refilling failed too many times in a row.
+func (w *worker) managedTrackAccountRefill(amount types.Currency, err error) {
+	refill := skymodules.WorkerAccountRefill{
+		Time:    time.Now(),
+		Amount:  amount,
+		Success: err == nil,
+	}
+	if err != nil {
+		refill.Error = err.Error()
+	}
+
+	a := w.staticAccount
+	a.mu.Lock()
+	if err == nil {
+		a.consecutiveRefillFailures = 0
+	} else {
+		a.consecutiveRefillFailures++
+	}
+	failures := a.consecutiveRefillFailures
+	a.recentRefills = append(a.recentRefills, refill)
+	if len(a.recentRefills) > maxRecentAccountRefills {
+		a.recentRefills = a.recentRefills[len(a.recentRefills)-maxRecentAccountRefills:]
+	}
+	a.mu.Unlock()
+
+	alertID := alertIDWorkerAccountRefillFailed(w.staticHostPubKeyStr)
+	if failures < accountRefillFailureAlertThreshold {
+		w.staticRenter.staticAlerter.UnregisterAlert(alertID)
+		return
+	}
+	if failures == accountRefillFailureAlertThreshold {
+		w.staticRenter.staticLog.Printf("WARN: refilling the account on host %v failed %v times in a row: %v", w.staticHostPubKeyStr, failures, err)
+	}
+	cause := fmt.Sprintf("host %v: %v consecutive failures, most recent error: %v", w.staticHostPubKeyStr, failures, err)
+	w.staticRenter.staticAlerter.RegisterAlert(alertID, AlertMSGWorkerAccountRefillFailed, cause, modules.SeverityWarning)
+}
+
+// accountRefillTargets applies the refill settings to the default balance
+// target and returns the resulting balance target and refill threshold.
+func accountRefillTargets(defaultTarget types.Currency, settings skymodules.WorkerAccountRefillSettings) (target, threshold types.Currency) {
+	target = defaultTarget
+	if !settings.Target.IsZero() {
+		target = settings.Target
+	}
+	threshold = target.Div64(2)
+	if !settings.Threshold.IsZero() {
+		threshold = settings.Threshold
+	}
+	return target, threshold
+}
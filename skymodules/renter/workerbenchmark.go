@@ -0,0 +1,247 @@
+package renter
+
+import (
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// workerBenchmarkReadLength is the amount of data the worker reads from
+	// the host when benchmarking the host's program execution. It is capped at
+	// the sector size.
+	workerBenchmarkReadLength = 1 << 16 // 64 KiB
+)
+
+var (
+	// workerBenchmarkInterval is the amount of time between two benchmarks of
+	// a worker's host.
+	workerBenchmarkInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 10 * time.Minute,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+)
+
+type (
+	// workerBenchmarkState contains information about the benchmarks a worker
+	// runs on its host. A benchmark consists of two programs. A minimal
+	// HasSector program which measures the round trip time to the host and a
+	// small ReadOffset program which measures how long it takes the host to
+	// read data from disk. Subtracting the former from the latter gives us the
+	// host's execution latency independent of its network latency.
+	workerBenchmarkState struct {
+		lastBenchmark time.Time
+		nextBenchmark time.Time
+		recentErr     error
+		recentErrTime time.Time
+
+		staticExecutionDT *skymodules.DistributionTracker
+		staticRTTDT       *skymodules.DistributionTracker
+
+		mu sync.Mutex
+	}
+)
+
+// newBenchmarkState initializes the worker's benchmark state.
+func (w *worker) newBenchmarkState() {
+	w.staticBenchmarkState = &workerBenchmarkState{
+		nextBenchmark:     time.Now(),
+		staticExecutionDT: skymodules.NewDistributionTrackerStandard(),
+		staticRTTDT:       skymodules.NewDistributionTrackerStandard(),
+	}
+}
+
+// benchmarkReadLength returns the number of bytes read by a benchmark.
+func benchmarkReadLength() uint64 {
+	if modules.SectorSize < workerBenchmarkReadLength {
+		return modules.SectorSize
+	}
+	return workerBenchmarkReadLength
+}
+
+// benchmarkReadOffset returns a random offset for a read of the given length
+// within a contract of the given size. The read never crosses a sector
+// boundary and is segment aligned.
+func benchmarkReadOffset(contractSize, length uint64) uint64 {
+	numSectors := contractSize / modules.SectorSize
+	numSegments := (modules.SectorSize-length)/crypto.SegmentSize + 1
+	sectorOffset := fastrand.Uint64n(numSectors) * modules.SectorSize
+	return sectorOffset + fastrand.Uint64n(numSegments)*crypto.SegmentSize
+}
+
+// managedNeedsToBenchmark returns true if the worker should benchmark its
+// host.
+func (w *worker) managedNeedsToBenchmark() bool {
+	if w.staticRenter.staticDeps.Disrupt("DisableWorkerBenchmark") {
+		return false
+	}
+	// No need to benchmark if the worker is on maintenance cooldown.
+	if w.managedOnMaintenanceCooldown() {
+		return false
+	}
+	// The benchmark requires a valid price table and a funded account.
+	if !w.staticPriceTable().staticValid() {
+		return false
+	}
+	if w.staticAccount.managedAvailableBalance().IsZero() {
+		return false
+	}
+	// The benchmark reads from the contract, so it needs data to read.
+	if w.staticCache().staticContractSize < modules.SectorSize {
+		return false
+	}
+
+	bs := w.staticBenchmarkState
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	return time.Now().After(bs.nextBenchmark)
+}
+
+// managedBenchmark benchmarks the worker's host and reports the results to
+// the hostdb.
+func (w *worker) managedBenchmark() {
+	bs := w.staticBenchmarkState
+	bs.mu.Lock()
+	bs.nextBenchmark = time.Now().Add(workerBenchmarkInterval)
+	bs.mu.Unlock()
+
+	rtt, execution, err := w.managedExecuteBenchmark()
+	if err != nil {
+		bs.mu.Lock()
+		bs.recentErr = err
+		bs.recentErrTime = time.Now()
+		bs.mu.Unlock()
+		w.staticRenter.staticLog.Debugf("Worker %v failed to benchmark host: %v", w.staticHostPubKeyStr, err)
+		return
+	}
+	bs.staticRTTDT.AddDataPoint(rtt)
+	bs.staticExecutionDT.AddDataPoint(execution)
+
+	bs.mu.Lock()
+	bs.lastBenchmark = time.Now()
+	benchmark := bs.hostBenchmark()
+	bs.mu.Unlock()
+
+	err = w.staticRenter.staticHostDB.UpdateHostBenchmark(w.staticHostPubKey, benchmark)
+	if err != nil {
+		w.staticRenter.staticLog.Debugf("Worker %v failed to update host benchmark: %v", w.staticHostPubKeyStr, err)
+	}
+}
+
+// managedExecuteBenchmark executes the benchmark programs on the host and
+// returns the round trip time and the execution latency of the host.
+func (w *worker) managedExecuteBenchmark() (rtt, execution time.Duration, err error) {
+	pt := w.staticPriceTable().staticPriceTable
+
+	// Measure the round trip time using a HasSector program for a random
+	// root. The host only has to perform a lookup to execute it.
+	var root crypto.Hash
+	fastrand.Read(root[:])
+	pb := modules.NewProgramBuilder(&pt, 0) // 0 duration since HasSector doesn't depend on it.
+	pb.AddHasSectorInstruction(root)
+	program, programData := pb.Program()
+	cost, _, _ := pb.Cost(true)
+	ul, dl := hasSectorJobExpectedBandwidth(1)
+	cost = cost.Add(modules.MDMBandwidthCost(pt, ul, dl))
+
+	start := time.Now()
+	_, _, err = w.managedExecuteProgram(program, programData, types.FileContractID{}, categoryRepairDownload, skymodules.TrafficClassRepair, cost)
+	if err != nil {
+		return 0, 0, errors.AddContext(err, "failed to execute rtt benchmark")
+	}
+	rtt = time.Since(start)
+
+	// Measure the execution latency using a ReadOffset program without a
+	// proof for a random offset within the contract.
+	length := benchmarkReadLength()
+	offset := benchmarkReadOffset(w.staticCache().staticContractSize, length)
+	pb = modules.NewProgramBuilder(&pt, 0) // 0 duration since Read doesn't depend on it.
+	pb.AddReadOffsetInstruction(length, offset, false)
+	program, programData = pb.Program()
+	cost, _, _ = pb.Cost(true)
+	ul, dl = readSectorJobExpectedBandwidth(length)
+	cost = cost.Add(modules.MDMBandwidthCost(pt, ul, dl))
+
+	start = time.Now()
+	responses, _, err := w.managedExecuteProgram(program, programData, w.staticCache().staticContractID, categoryRepairDownload, skymodules.TrafficClassRepair, cost)
+	if err != nil {
+		return 0, 0, errors.AddContext(err, "failed to execute read benchmark")
+	}
+	readTime := time.Since(start)
+	if len(responses) != len(program) {
+		return 0, 0, errors.New("read benchmark returned the wrong number of responses")
+	}
+	if responses[0].Error != nil {
+		return 0, 0, errors.AddContext(responses[0].Error, "read benchmark failed")
+	}
+	if uint64(len(responses[0].Output)) != length {
+		return 0, 0, errors.New("read benchmark returned the wrong amount of data")
+	}
+
+	// The execution latency is the part of the read that isn't explained by
+	// the round trip time.
+	execution = readTime - rtt
+	if execution < 0 {
+		execution = 0
+	}
+	return rtt, execution, nil
+}
+
+// hostBenchmark returns the benchmark results which are reported to the
+// hostdb. The results are based on the distributions with a 24 hour half life
+// to avoid a single slow benchmark from heavily impacting the host's score.
+func (bs *workerBenchmarkState) hostBenchmark() skymodules.HostBenchmark {
+	execution := bs.staticExecutionDT.Distribution(1).ExpectedDuration()
+	return skymodules.HostBenchmark{
+		ExecutionLatency: execution,
+		RTT:              bs.staticRTTDT.Distribution(1).ExpectedDuration(),
+		Throughput:       benchmarkThroughput(benchmarkReadLength(), execution),
+		Timestamp:        bs.lastBenchmark,
+	}
+}
+
+// benchmarkThroughput returns the throughput in bytes per second for reading
+// the given number of bytes within the given execution latency.
+func benchmarkThroughput(length uint64, execution time.Duration) float64 {
+	if execution <= 0 {
+		execution = time.Millisecond
+	}
+	return float64(length) / execution.Seconds()
+}
+
+// managedStatus returns the status of the worker's benchmarks.
+func (bs *workerBenchmarkState) managedStatus() skymodules.WorkerBenchmarkStatus {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	var recentErrStr string
+	if bs.recentErr != nil {
+		recentErrStr = bs.recentErr.Error()
+	}
+	var benchmark skymodules.HostBenchmark
+	if !bs.lastBenchmark.IsZero() {
+		benchmark = bs.hostBenchmark()
+	}
+	return skymodules.WorkerBenchmarkStatus{
+		ExecutionStats: bs.staticExecutionDT.Stats(),
+		RTTStats:       bs.staticRTTDT.Stats(),
+
+		ExecutionLatency: benchmark.ExecutionLatency,
+		RTT:              benchmark.RTT,
+		Throughput:       benchmark.Throughput,
+
+		LastBenchmark: bs.lastBenchmark,
+		NextBenchmark: bs.nextBenchmark,
+
+		RecentErr:     recentErrStr,
+		RecentErrTime: bs.recentErrTime,
+	}
+}
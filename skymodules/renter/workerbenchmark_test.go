@@ -0,0 +1,110 @@
+package renter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestBenchmarkReadOffset is a unit test for benchmarkReadOffset.
+func TestBenchmarkReadOffset(t *testing.T) {
+	t.Parallel()
+
+	length := benchmarkReadLength()
+	contractSize := 3 * modules.SectorSize
+	for i := 0; i < 1000; i++ {
+		offset := benchmarkReadOffset(contractSize, length)
+		if offset%crypto.SegmentSize != 0 {
+			t.Fatal("offset isn't segment aligned", offset)
+		}
+		if offset+length > contractSize {
+			t.Fatal("read exceeds contract", offset)
+		}
+		if offset/modules.SectorSize != (offset+length-1)/modules.SectorSize {
+			t.Fatal("read crosses sector boundary", offset)
+		}
+	}
+}
+
+// TestWorkerBenchmark verifies that a worker periodically benchmarks its host
+// and reports the results to the hostdb.
+func TestWorkerBenchmark(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	wt, err := newWorkerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// The worker shouldn't benchmark an empty contract.
+	if wt.staticCache().staticContractSize != 0 || wt.managedNeedsToBenchmark() {
+		t.Fatal("worker shouldn't benchmark an empty contract")
+	}
+
+	// Upload a snapshot to fill the first sector of the contract.
+	backup := skymodules.UploadedBackup{
+		Name:         "foo",
+		CreationDate: types.CurrentTimestamp(),
+		Size:         10,
+	}
+	err = wt.UploadSnapshot(context.Background(), backup, fastrand.Bytes(int(backup.Size)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Wait for the worker to benchmark the host.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		status := wt.staticBenchmarkState.managedStatus()
+		if status.LastBenchmark.IsZero() {
+			return fmt.Errorf("host wasn't benchmarked yet, recent err: %v", status.RecentErr)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check the worker status.
+	status := wt.callStatus().BenchmarkStatus
+	if status.Throughput <= 0 || status.RecentErr != "" {
+		t.Fatal("unexpected status", status)
+	}
+	if !status.NextBenchmark.After(status.LastBenchmark) {
+		t.Fatal("next benchmark should be after the last one", status.NextBenchmark, status.LastBenchmark)
+	}
+	if status.RTTStats.DataPoints[0] == 0 || status.ExecutionStats.DataPoints[0] == 0 {
+		t.Fatal("distributions should contain data points")
+	}
+
+	// Check the hostdb.
+	host, ok, err := wt.staticRenter.staticHostDB.Host(wt.staticHostPubKey)
+	if err != nil || !ok {
+		t.Fatal("host not found", ok, err)
+	}
+	if host.Benchmark.Timestamp.IsZero() || host.Benchmark.RTT != status.RTT || host.Benchmark.ExecutionLatency != status.ExecutionLatency {
+		t.Fatal("unexpected host benchmark", host.Benchmark, status)
+	}
+	sb, err := wt.staticRenter.staticHostDB.ScoreBreakdown(host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sb.BenchmarkAdjustment <= 0 || sb.BenchmarkAdjustment > 1 {
+		t.Fatal("unexpected benchmark adjustment", sb.BenchmarkAdjustment)
+	}
+}
@@ -0,0 +1,248 @@
+package renter
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errHostBlocklisted is returned when a job is submitted to a worker
+	// whose host is on the worker blocklist.
+	errHostBlocklisted = errors.New("host is on the worker blocklist")
+
+	// errInvalidWorkerBlocklistRemoval is returned when a removal is neither a
+	// subnet nor an ASN.
+	errInvalidWorkerBlocklistRemoval = errors.New("removal must be a subnet in CIDR notation or an ASN of the form 'AS<number>'")
+)
+
+type (
+	// workerBlocklist is the runtime enforcement layer for blocking hosts by
+	// subnet. Contrary to the hostdb filter, which only affects the hosts that
+	// are picked for new contracts, the blocklist is checked by the workers
+	// every time they are about to launch a job. That way hosts can be cut
+	// off immediately, even mid-contract.
+	workerBlocklist struct {
+		entries []workerBlocklistEntry
+		mu      sync.RWMutex
+	}
+
+	// workerBlocklistEntry is a parsed skymodules.WorkerBlocklistEntry.
+	workerBlocklistEntry struct {
+		staticEntry  skymodules.WorkerBlocklistEntry
+		staticSubnet *net.IPNet
+	}
+)
+
+// newWorkerBlocklist creates a new blocklist from the given entries.
+func newWorkerBlocklist(entries []skymodules.WorkerBlocklistEntry) (*workerBlocklist, error) {
+	wb := &workerBlocklist{}
+	for _, entry := range entries {
+		parsed, err := parseWorkerBlocklistEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		wb.entries = append(wb.entries, parsed)
+	}
+	return wb, nil
+}
+
+// parseWorkerBlocklistEntry parses the subnet of the entry and normalizes it.
+func parseWorkerBlocklistEntry(entry skymodules.WorkerBlocklistEntry) (workerBlocklistEntry, error) {
+	_, subnet, err := net.ParseCIDR(entry.Subnet)
+	if err != nil {
+		return workerBlocklistEntry{}, errors.AddContext(err, "invalid subnet")
+	}
+	entry.Subnet = subnet.String()
+	return workerBlocklistEntry{
+		staticEntry:  entry,
+		staticSubnet: subnet,
+	}, nil
+}
+
+// parseASN parses an ASN of the form 'AS<number>'.
+func parseASN(str string) (uint32, bool) {
+	if len(str) < 3 || !strings.EqualFold(str[:2], "AS") {
+		return 0, false
+	}
+	asn, err := strconv.ParseUint(str[2:], 10, 32)
+	if err != nil || asn == 0 {
+		return 0, false
+	}
+	return uint32(asn), true
+}
+
+// callBlocked returns true if any of the given IPs is blocked.
+func (wb *workerBlocklist) callBlocked(ips []net.IP) bool {
+	// The workers of a renter created for testing might not have a
+	// blocklist.
+	if wb == nil {
+		return false
+	}
+	wb.mu.RLock()
+	defer wb.mu.RUnlock()
+	for _, entry := range wb.entries {
+		for _, ip := range ips {
+			if entry.staticSubnet.Contains(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// callEntries returns the entries of the blocklist sorted by subnet.
+func (wb *workerBlocklist) callEntries() []skymodules.WorkerBlocklistEntry {
+	wb.mu.RLock()
+	defer wb.mu.RUnlock()
+	entries := make([]skymodules.WorkerBlocklistEntry, 0, len(wb.entries))
+	for _, entry := range wb.entries {
+		entries = append(entries, entry.staticEntry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Subnet < entries[j].Subnet
+	})
+	return entries
+}
+
+// callUpdate applies the additions and removals to the blocklist. Additions
+// overwrite existing entries for the same subnet. Nothing is changed if any
+// of the inputs is invalid.
+func (wb *workerBlocklist) callUpdate(additions []skymodules.WorkerBlocklistEntry, removals []string) error {
+	// Parse the input before touching the blocklist.
+	parsedAdditions := make([]workerBlocklistEntry, 0, len(additions))
+	for _, entry := range additions {
+		parsed, err := parseWorkerBlocklistEntry(entry)
+		if err != nil {
+			return errors.AddContext(err, fmt.Sprintf("unable to add %v", entry.Subnet))
+		}
+		parsedAdditions = append(parsedAdditions, parsed)
+	}
+	removedSubnets := make(map[string]struct{})
+	removedASNs := make(map[uint32]struct{})
+	for _, removal := range removals {
+		if asn, ok := parseASN(removal); ok {
+			removedASNs[asn] = struct{}{}
+			continue
+		}
+		_, subnet, err := net.ParseCIDR(removal)
+		if err != nil {
+			return errors.AddContext(errInvalidWorkerBlocklistRemoval, fmt.Sprintf("unable to remove %v", removal))
+		}
+		removedSubnets[subnet.String()] = struct{}{}
+	}
+
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+
+	// Apply the removals.
+	var entries []workerBlocklistEntry
+	for _, entry := range wb.entries {
+		_, subnetRemoved := removedSubnets[entry.staticEntry.Subnet]
+		_, asnRemoved := removedASNs[entry.staticEntry.ASN]
+		if subnetRemoved || (entry.staticEntry.ASN != 0 && asnRemoved) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	// Apply the additions.
+	for _, addition := range parsedAdditions {
+		replaced := false
+		for i := range entries {
+			if entries[i].staticEntry.Subnet == addition.staticEntry.Subnet {
+				entries[i] = addition
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			entries = append(entries, addition)
+		}
+	}
+	wb.entries = entries
+	return nil
+}
+
+// staticBlocklisted returns true if the worker's host is on the worker
+// blocklist. The check uses the IPs which were resolved during the last cache
+// update.
+func (w *worker) staticBlocklisted() bool {
+	cache := w.staticCache()
+	if cache == nil || w.staticRenter == nil {
+		return false
+	}
+	return w.staticRenter.staticWorkerBlocklist.callBlocked(cache.staticHostIPs)
+}
+
+// WorkerBlocklist returns the subnets of the hosts which the workers refuse
+// to launch jobs to.
+func (r *Renter) WorkerBlocklist() []skymodules.WorkerBlocklistEntry {
+	return r.staticWorkerBlocklist.callEntries()
+}
+
+// WorkerBlocklistedHosts returns the public keys of the hosts which are
+// currently blocked by the worker blocklist.
+func (r *Renter) WorkerBlocklistedHosts() []types.SiaPublicKey {
+	var hosts []types.SiaPublicKey
+	for _, w := range r.staticWorkerPool.callWorkers() {
+		if w.staticBlocklisted() {
+			hosts = append(hosts, w.staticHostPubKey)
+		}
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		return hosts[i].String() < hosts[j].String()
+	})
+	return hosts
+}
+
+// UpdateWorkerBlocklist adds and removes entries from the worker blocklist.
+// Removals are either subnets or ASNs in the form 'AS<number>'. The changes
+// take effect immediately.
+func (r *Renter) UpdateWorkerBlocklist(additions []skymodules.WorkerBlocklistEntry, removals []string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	// Hold the renter lock while updating the blocklist and taking the
+	// snapshot of the persistence to make sure that the persisted entries
+	// match the blocklist. The snapshot is written after releasing the lock.
+	id := r.mu.Lock()
+	old := r.staticWorkerBlocklist.callEntries()
+	err := r.staticWorkerBlocklist.callUpdate(additions, removals)
+	if err != nil {
+		r.mu.Unlock(id)
+		return err
+	}
+	r.persist.WorkerBlocklist = r.staticWorkerBlocklist.callEntries()
+	snapshot, err := r.snapshotPersist()
+	r.mu.Unlock(id)
+	if err == nil {
+		err = r.managedWritePersist(snapshot)
+	}
+	if err != nil {
+		// Restore the old blocklist.
+		id = r.mu.Lock()
+		r.persist.WorkerBlocklist = old
+		r.staticWorkerBlocklist.mu.Lock()
+		restored, _ := newWorkerBlocklist(old)
+		r.staticWorkerBlocklist.entries = restored.entries
+		r.staticWorkerBlocklist.mu.Unlock()
+		r.mu.Unlock(id)
+		return errors.AddContext(err, "failed to persist worker blocklist")
+	}
+
+	// Wake the workers to drop the jobs of newly blocked hosts right away.
+	for _, w := range r.staticWorkerPool.callWorkers() {
+		w.staticWake()
+	}
+	return nil
+}
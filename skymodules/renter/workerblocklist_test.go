@@ -0,0 +1,189 @@
+package renter
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/crypto"
+)
+
+// TestWorkerBlocklist is a unit test for the worker blocklist.
+func TestWorkerBlocklist(t *testing.T) {
+	t.Parallel()
+
+	wb, err := newWorkerBlocklist(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ip1 := net.ParseIP("10.0.1.1")
+	ip2 := net.ParseIP("192.168.5.5")
+	ip3 := net.ParseIP("2001:db8::1")
+	if wb.callBlocked([]net.IP{ip1, ip2, ip3}) {
+		t.Fatal("empty blocklist shouldn't block")
+	}
+
+	// A nil blocklist doesn't block anything.
+	var nilBlocklist *workerBlocklist
+	if nilBlocklist.callBlocked([]net.IP{ip1}) {
+		t.Fatal("nil blocklist shouldn't block")
+	}
+
+	// Invalid additions and removals are rejected without changing the
+	// blocklist.
+	err = wb.callUpdate([]skymodules.WorkerBlocklistEntry{{Subnet: "10.0.0.0/8"}, {Subnet: "foo"}}, nil)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	err = wb.callUpdate([]skymodules.WorkerBlocklistEntry{{Subnet: "10.0.0.0/8"}}, []string{"AS"})
+	if !errors.Contains(err, errInvalidWorkerBlocklistRemoval) {
+		t.Fatal("wrong error", err)
+	}
+	if len(wb.callEntries()) != 0 {
+		t.Fatal("blocklist shouldn't have changed")
+	}
+
+	// Block two subnets of an ASN and a single IPv6 subnet. The subnets are
+	// normalized.
+	err = wb.callUpdate([]skymodules.WorkerBlocklistEntry{
+		{Subnet: "10.0.1.7/24", ASN: 64500, Reason: "abuse"},
+		{Subnet: "192.168.0.0/16", ASN: 64500},
+		{Subnet: "2001:db8::/32"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []skymodules.WorkerBlocklistEntry{
+		{Subnet: "10.0.1.0/24", ASN: 64500, Reason: "abuse"},
+		{Subnet: "192.168.0.0/16", ASN: 64500},
+		{Subnet: "2001:db8::/32"},
+	}
+	if entries := wb.callEntries(); !reflect.DeepEqual(entries, expected) {
+		t.Fatal("wrong entries", entries)
+	}
+	for _, ip := range []net.IP{ip1, ip2, ip3} {
+		if !wb.callBlocked([]net.IP{ip}) {
+			t.Fatal("ip should be blocked", ip)
+		}
+	}
+	if wb.callBlocked([]net.IP{net.ParseIP("10.0.2.1")}) {
+		t.Fatal("ip shouldn't be blocked")
+	}
+
+	// Overwrite an entry.
+	err = wb.callUpdate([]skymodules.WorkerBlocklistEntry{{Subnet: "2001:db8::/32", Reason: "spam"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries := wb.callEntries(); len(entries) != 3 || entries[2].Reason != "spam" {
+		t.Fatal("entry wasn't overwritten", entries)
+	}
+
+	// Remove the ASN.
+	err = wb.callUpdate(nil, []string{"as64500"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wb.callBlocked([]net.IP{ip1, ip2}) {
+		t.Fatal("ips shouldn't be blocked anymore")
+	}
+	if !wb.callBlocked([]net.IP{ip1, ip3}) {
+		t.Fatal("ip should still be blocked")
+	}
+
+	// Remove the subnet.
+	err = wb.callUpdate(nil, []string{"2001:db8::1/32"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wb.callEntries()) != 0 {
+		t.Fatal("blocklist should be empty")
+	}
+}
+
+// TestWorkerBlocklistJobs verifies that a worker refuses jobs once its host is
+// blocklisted and accepts them again once it is removed from the blocklist.
+func TestWorkerBlocklistJobs(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	wt, err := newWorkerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	w := wt.worker
+	r := w.staticRenter
+
+	// The IPs of the host should have been resolved.
+	hostIPs := w.staticCache().staticHostIPs
+	if len(hostIPs) == 0 {
+		t.Fatal("host IPs weren't resolved")
+	}
+	if w.staticBlocklisted() {
+		t.Fatal("host shouldn't be blocklisted")
+	}
+	newJob := func() *jobHasSector {
+		return w.newJobHasSector(context.Background(), make(chan *jobHasSectorResponse, 1), 1, crypto.Hash{})
+	}
+	if !w.staticJobHasSectorQueue.callAdd(newJob()) {
+		t.Fatal("job should be added")
+	}
+
+	// Block the host.
+	hostIP := hostIPs[0]
+	if ip4 := hostIP.To4(); ip4 != nil {
+		hostIP = ip4
+	}
+	subnet := net.IPNet{IP: hostIP, Mask: net.CIDRMask(8*len(hostIP), 8*len(hostIP))}
+	entries := []skymodules.WorkerBlocklistEntry{{Subnet: subnet.String(), ASN: 64500}}
+	err = r.UpdateWorkerBlocklist(entries, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !w.staticBlocklisted() {
+		t.Fatal("host should be blocklisted")
+	}
+	if w.staticJobHasSectorQueue.callAdd(newJob()) {
+		t.Fatal("job shouldn't be added")
+	}
+	hosts := r.WorkerBlocklistedHosts()
+	if len(hosts) != 1 || !hosts[0].Equals(w.staticHostPubKey) {
+		t.Fatal("wrong blocklisted hosts", hosts)
+	}
+	if !reflect.DeepEqual(r.WorkerBlocklist(), entries) {
+		t.Fatal("wrong blocklist", r.WorkerBlocklist())
+	}
+
+	// The blocklist should be persisted.
+	id := r.mu.RLock()
+	persisted := r.persist.WorkerBlocklist
+	r.mu.RUnlock(id)
+	if !reflect.DeepEqual(persisted, entries) {
+		t.Fatal("blocklist wasn't persisted", persisted)
+	}
+
+	// Unblock the host again.
+	err = r.UpdateWorkerBlocklist(nil, []string{"AS64500"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.staticBlocklisted() {
+		t.Fatal("host shouldn't be blocklisted")
+	}
+	if !w.staticJobHasSectorQueue.callAdd(newJob()) {
+		t.Fatal("job should be added")
+	}
+	if len(r.WorkerBlocklistedHosts()) != 0 {
+		t.Fatal("no hosts should be blocklisted")
+	}
+}
@@ -1,6 +1,7 @@
 package renter
 
 import (
+	"net"
 	"sync/atomic"
 	"time"
 	"unsafe"
@@ -27,9 +28,11 @@ type (
 	workerCache struct {
 		staticBlockHeight     types.BlockHeight
 		staticContractID      types.FileContractID
+		staticContractSize    uint64
 		staticContractUtility skymodules.ContractUtility
 		staticHostVersion     string
 		staticRenterAllowance skymodules.Allowance
+		staticHostIPs         []net.IP
 		staticHostMuxAddress  string
 		staticMaliciousHost   bool
 		staticSynced          bool
@@ -79,11 +82,26 @@ func (w *worker) managedUpdateCache() {
 		return
 	}
 
+	// Resolve the host's IPs for the worker blocklist. If the lookup fails,
+	// the IPs of the previous cache are kept.
+	var hostIPs []net.IP
+	if oldCache := w.staticCache(); oldCache != nil {
+		hostIPs = oldCache.staticHostIPs
+	}
+	ips, err := w.staticRenter.staticDeps.LookupIP(host.NetAddress.Host())
+	if err != nil {
+		w.staticRenter.staticLog.Debugf("Worker %v failed to resolve the host's IPs: %v", w.staticHostPubKeyStr, err)
+	} else {
+		hostIPs = ips
+	}
+
 	// Create the cache object.
 	newCache := &workerCache{
 		staticBlockHeight:     w.staticRenter.staticConsensusSet.Height(),
 		staticContractID:      renterContract.ID,
+		staticContractSize:    renterContract.Size(),
 		staticContractUtility: renterContract.Utility,
+		staticHostIPs:         hostIPs,
 		staticHostMuxAddress:  host.SiaMuxAddress(),
 		staticMaliciousHost:   malicious,
 		staticHostVersion:     host.Version,
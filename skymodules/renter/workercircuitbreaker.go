@@ -0,0 +1,157 @@
+package renter
+
+import (
+	"time"
+
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+const (
+	// circuitBreakerFailureThreshold is the number of consecutive failures of
+	// a job category after which the circuit breaker of the category opens.
+	circuitBreakerFailureThreshold = 5
+
+	// circuitBreakerMaxTrips defines the maximum number of consecutive trips
+	// that will be considered when determining how long the circuit breaker
+	// stays open.
+	circuitBreakerMaxTrips = 6
+)
+
+const (
+	// circuitBreakerStateClosed indicates that the breaker is closed and jobs
+	// are accepted.
+	circuitBreakerStateClosed = "closed"
+
+	// circuitBreakerStateOpen indicates that the breaker is open and no jobs
+	// are accepted until the backoff window passed.
+	circuitBreakerStateOpen = "open"
+
+	// circuitBreakerStateHalfOpen indicates that the backoff window passed and
+	// a single probe job is accepted to check whether the host recovered.
+	circuitBreakerStateHalfOpen = "half-open"
+)
+
+var (
+	// circuitBreakerBaseBackoff is the backoff window of a breaker that
+	// tripped for the first time. It doubles with every consecutive trip.
+	circuitBreakerBaseBackoff = build.Select(build.Var{
+		Dev:      30 * time.Second,
+		Standard: 5 * time.Minute,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// circuitBreakerProbeTimeout is the amount of time after which a probe
+	// job that didn't report its outcome is considered lost and another probe
+	// is accepted.
+	circuitBreakerProbeTimeout = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 5 * time.Minute,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+)
+
+type (
+	// circuitBreaker excludes a job category of a worker from being used after
+	// it failed too many times in a row. Once the breaker trips it stays open
+	// for a backoff window. After that a single probe job is let through which
+	// either closes the breaker or trips it again with a longer backoff. The
+	// breaker is not thread-safe and is protected by the mutex of the job
+	// queue it belongs to.
+	circuitBreaker struct {
+		openUntil  time.Time
+		probeStart time.Time
+		trips      uint64
+	}
+)
+
+// blocking returns whether the breaker currently refuses new jobs.
+func (cb *circuitBreaker) blocking() bool {
+	switch cb.state() {
+	case circuitBreakerStateOpen:
+		return true
+	case circuitBreakerStateHalfOpen:
+		return time.Since(cb.probeStart) < circuitBreakerProbeTimeout
+	default:
+		return false
+	}
+}
+
+// reportCorruption lets the breaker know that a job received corrupt data. The
+// breaker trips right away unless it is open already.
+func (cb *circuitBreaker) reportCorruption() {
+	if cb.state() != circuitBreakerStateOpen {
+		cb.trip()
+	}
+}
+
+// reportFailure lets the breaker know about a failed job. A closed breaker
+// trips once the number of consecutive failures reaches the threshold and a
+// half-open breaker trips if its probe fails. Failures of jobs which were
+// launched before the breaker opened don't trip it again, otherwise a batch
+// of concurrently failing jobs would multiply the backoff.
+func (cb *circuitBreaker) reportFailure(consecutiveFailures uint64) {
+	switch cb.state() {
+	case circuitBreakerStateClosed:
+		if consecutiveFailures < circuitBreakerFailureThreshold {
+			return
+		}
+	case circuitBreakerStateHalfOpen:
+		if cb.probeStart.IsZero() {
+			return
+		}
+	default:
+		return
+	}
+	cb.trip()
+}
+
+// trip opens the breaker for a backoff window which doubles with every
+// consecutive trip.
+func (cb *circuitBreaker) trip() {
+	cb.trips++
+	trips := cb.trips
+	if trips > circuitBreakerMaxTrips {
+		trips = circuitBreakerMaxTrips
+	}
+	backoff := circuitBreakerBaseBackoff
+	for i := uint64(1); i < trips; i++ {
+		backoff *= 2
+	}
+	cb.openUntil = time.Now().Add(backoff)
+	cb.probeStart = time.Time{}
+}
+
+// reportSuccess lets the breaker know about a successful job, which closes the
+// breaker.
+func (cb *circuitBreaker) reportSuccess() {
+	*cb = circuitBreaker{}
+}
+
+// state returns the state of the breaker.
+func (cb *circuitBreaker) state() string {
+	if cb.trips == 0 {
+		return circuitBreakerStateClosed
+	}
+	if time.Now().Before(cb.openUntil) {
+		return circuitBreakerStateOpen
+	}
+	return circuitBreakerStateHalfOpen
+}
+
+// trackJob lets the breaker know that a job was accepted. If the breaker is
+// half-open, the job is the probe.
+func (cb *circuitBreaker) trackJob() {
+	if cb.state() == circuitBreakerStateHalfOpen {
+		cb.probeStart = time.Now()
+	}
+}
+
+// circuitBreakerStatus returns the status of the circuit breaker of the queue.
+func (status workerJobQueueStatus) circuitBreakerStatus() skymodules.WorkerCircuitBreakerStatus {
+	return skymodules.WorkerCircuitBreakerStatus{
+		State:     status.breakerState,
+		OpenUntil: status.breakerOpenUntil,
+		Trips:     status.breakerTrips,
+	}
+}
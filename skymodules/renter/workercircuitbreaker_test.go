@@ -0,0 +1,154 @@
+package renter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestCircuitBreaker is a unit test for the circuit breaker.
+func TestCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	var cb circuitBreaker
+	if cb.state() != circuitBreakerStateClosed || cb.blocking() {
+		t.Fatal("new breaker should be closed")
+	}
+
+	// Failures below the threshold don't trip the breaker.
+	for i := uint64(1); i < circuitBreakerFailureThreshold; i++ {
+		cb.reportFailure(i)
+	}
+	if cb.state() != circuitBreakerStateClosed || cb.blocking() {
+		t.Fatal("breaker shouldn't have tripped")
+	}
+
+	// Reaching the threshold trips it.
+	cb.reportFailure(circuitBreakerFailureThreshold)
+	if cb.state() != circuitBreakerStateOpen || !cb.blocking() {
+		t.Fatal("breaker should be open", cb.state())
+	}
+	if cb.trips != 1 {
+		t.Fatal("wrong number of trips", cb.trips)
+	}
+	backoff := time.Until(cb.openUntil)
+	if backoff <= 0 || backoff > circuitBreakerBaseBackoff {
+		t.Fatal("wrong backoff", backoff)
+	}
+
+	// Once the backoff window passed, the breaker is half-open and accepts a
+	// single probe.
+	cb.openUntil = time.Now().Add(-time.Second)
+	if cb.state() != circuitBreakerStateHalfOpen || cb.blocking() {
+		t.Fatal("breaker should be half-open", cb.state())
+	}
+	cb.trackJob()
+	if !cb.blocking() {
+		t.Fatal("breaker should block while probing")
+	}
+
+	// A lost probe is replaced after the probe timeout.
+	cb.probeStart = time.Now().Add(-circuitBreakerProbeTimeout)
+	if cb.blocking() {
+		t.Fatal("breaker should accept a new probe")
+	}
+
+	// A failed probe trips the breaker again with a longer backoff.
+	cb.trackJob()
+	cb.reportFailure(circuitBreakerFailureThreshold + 1)
+	if cb.state() != circuitBreakerStateOpen || cb.trips != 2 {
+		t.Fatal("breaker should be open again", cb.state(), cb.trips)
+	}
+	backoff = time.Until(cb.openUntil)
+	if backoff <= circuitBreakerBaseBackoff || backoff > 2*circuitBreakerBaseBackoff {
+		t.Fatal("wrong backoff", backoff)
+	}
+
+	// Failures while the breaker is open don't trip it again.
+	openUntil := cb.openUntil
+	for i := uint64(0); i < 10; i++ {
+		cb.reportFailure(circuitBreakerFailureThreshold + 2 + i)
+	}
+	if cb.trips != 2 || cb.openUntil != openUntil {
+		t.Fatal("breaker shouldn't trip while open", cb.trips)
+	}
+
+	// Neither do failures while half-open without a probe.
+	cb.openUntil = time.Now().Add(-time.Second)
+	cb.reportFailure(circuitBreakerFailureThreshold + 20)
+	if cb.state() != circuitBreakerStateHalfOpen || cb.trips != 2 {
+		t.Fatal("breaker should still be half-open", cb.state(), cb.trips)
+	}
+
+	// Corruption trips a half-open breaker right away.
+	cb.reportCorruption()
+	if cb.state() != circuitBreakerStateOpen || cb.trips != 3 {
+		t.Fatal("breaker should be open", cb.state(), cb.trips)
+	}
+
+	// The backoff is capped.
+	for i := 0; i < 2*circuitBreakerMaxTrips; i++ {
+		cb.openUntil = time.Now().Add(-time.Second)
+		cb.trackJob()
+		cb.reportFailure(circuitBreakerFailureThreshold)
+	}
+	maxBackoff := circuitBreakerBaseBackoff << (circuitBreakerMaxTrips - 1)
+	if backoff = time.Until(cb.openUntil); backoff > maxBackoff {
+		t.Fatal("backoff should be capped", backoff, maxBackoff)
+	}
+
+	// A successful probe closes the breaker.
+	cb.openUntil = time.Now().Add(-time.Second)
+	cb.trackJob()
+	cb.reportSuccess()
+	if cb.state() != circuitBreakerStateClosed || cb.blocking() || cb.trips != 0 {
+		t.Fatal("breaker should be closed", cb.state())
+	}
+}
+
+// TestCircuitBreakerConcurrentFailures tests that a batch of jobs that were
+// launched at the same time and fail together only trips the breaker once.
+func TestCircuitBreakerConcurrentFailures(t *testing.T) {
+	t.Parallel()
+
+	// Create a job queue.
+	w := new(worker)
+	w.staticRenter = new(Renter)
+	jq := newJobGenericQueue(w)
+
+	// Queue more failing jobs than the failure threshold and launch them all
+	// at once.
+	numJobs := 3 * circuitBreakerFailureThreshold
+	var jobs []workerJob
+	for i := 0; i < numJobs; i++ {
+		j := &jobTest{
+			jobGeneric:       newJobGeneric(context.Background(), jq, nil),
+			resultChan:       make(chan *jobTestResult, 1),
+			staticShouldFail: true,
+		}
+		if !jq.callAdd(j) {
+			t.Fatal("failed to add job")
+		}
+	}
+	for i := 0; i < numJobs; i++ {
+		job := jq.callNext()
+		if job == nil {
+			t.Fatal("expected job")
+		}
+		jobs = append(jobs, job)
+	}
+
+	// Let them all fail.
+	for _, job := range jobs {
+		job.callExecute()
+	}
+
+	// The breaker should have tripped exactly once.
+	status := jq.callStatus()
+	if status.breakerState != circuitBreakerStateOpen || status.breakerTrips != 1 {
+		t.Fatal("breaker should have tripped once", status.breakerState, status.breakerTrips)
+	}
+	if backoff := time.Until(status.breakerOpenUntil); backoff > circuitBreakerBaseBackoff {
+		t.Fatal("wrong backoff", backoff)
+	}
+}
@@ -0,0 +1,209 @@
+package renter
+
+import (
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/siamux"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// workerConnectionRTTWeight is the weight of a new sample when updating
+	// the estimated round trip time of a worker's connection. Same as TCP's
+	// smoothed RTT, every new sample contributes an eighth to the estimate.
+	workerConnectionRTTWeight = 0.125
+
+	// workerDiagnoseStreamSamples is the number of streams a worker opens to
+	// its host when diagnosing its connection.
+	workerDiagnoseStreamSamples = 3
+)
+
+type (
+	// workerConnectionState tracks the health of the worker's connection to
+	// its host. Opening a stream requires a round trip to the host's siamux
+	// once the mux is established, which makes the time it takes to open a
+	// stream a cheap estimate of the round trip time.
+	workerConnectionState struct {
+		estimatedRTT time.Duration
+		lastRTT      time.Duration
+
+		streamsOpened      uint64
+		streamOpenFailures uint64
+		streamErrors       uint64
+		reconnects         uint64
+
+		// disconnected is set if the most recent attempt to open a stream
+		// failed. The next successfully opened stream counts as a reconnect.
+		disconnected bool
+
+		recentErr     error
+		recentErrTime time.Time
+
+		mu sync.Mutex
+	}
+
+	// diagnosticStream wraps a stream to the worker's host and reports read
+	// and write errors of the underlying mux to the worker's connection
+	// state. Errors returned by the host as part of an RPC response are read
+	// successfully from the stream and aren't counted.
+	diagnosticStream struct {
+		siamux.Stream
+		staticState *workerConnectionState
+	}
+)
+
+// newConnectionState initializes the worker's connection state.
+func (w *worker) newConnectionState() {
+	w.staticConnectionState = &workerConnectionState{}
+}
+
+// managedTrackStreamOpen updates the connection state after trying to open a
+// stream which took the provided duration.
+func (cs *workerConnectionState) managedTrackStreamOpen(d time.Duration, err error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if err != nil {
+		cs.streamOpenFailures++
+		cs.disconnected = true
+		cs.recentErr = err
+		cs.recentErrTime = time.Now()
+		return
+	}
+	cs.streamsOpened++
+	if cs.disconnected {
+		cs.reconnects++
+		cs.disconnected = false
+	}
+	cs.lastRTT = d
+	if cs.estimatedRTT == 0 {
+		cs.estimatedRTT = d
+	} else {
+		cs.estimatedRTT = time.Duration((1-workerConnectionRTTWeight)*float64(cs.estimatedRTT) + workerConnectionRTTWeight*float64(d))
+	}
+}
+
+// managedTrackStreamErr updates the connection state after reading from or
+// writing to a stream failed.
+func (cs *workerConnectionState) managedTrackStreamErr(err error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.streamErrors++
+	cs.recentErr = err
+	cs.recentErrTime = time.Now()
+}
+
+// managedStatus returns the status of the worker's connection.
+func (cs *workerConnectionState) managedStatus() skymodules.WorkerConnectionStatus {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	var recentErrStr string
+	if cs.recentErr != nil {
+		recentErrStr = cs.recentErr.Error()
+	}
+	return skymodules.WorkerConnectionStatus{
+		EstimatedRTT: cs.estimatedRTT,
+		LastRTT:      cs.lastRTT,
+
+		StreamsOpened:      cs.streamsOpened,
+		StreamOpenFailures: cs.streamOpenFailures,
+		StreamErrors:       cs.streamErrors,
+		Reconnects:         cs.reconnects,
+
+		RecentErr:     recentErrStr,
+		RecentErrTime: cs.recentErrTime,
+	}
+}
+
+// Read implements io.Reader.
+func (ds *diagnosticStream) Read(b []byte) (int, error) {
+	n, err := ds.Stream.Read(b)
+	if err != nil && !errors.Contains(err, io.EOF) {
+		ds.staticState.managedTrackStreamErr(err)
+	}
+	return n, err
+}
+
+// Write implements io.Writer.
+func (ds *diagnosticStream) Write(b []byte) (int, error) {
+	n, err := ds.Stream.Write(b)
+	if err != nil {
+		ds.staticState.managedTrackStreamErr(err)
+	}
+	return n, err
+}
+
+// newDiagnosticStream wraps a stream to the worker's host.
+func (w *worker) newDiagnosticStream(stream siamux.Stream) siamux.Stream {
+	return &diagnosticStream{
+		Stream:      stream,
+		staticState: w.staticConnectionState,
+	}
+}
+
+// managedDiagnose runs a live connectivity test against the worker's host. It
+// first opens a plain TCP connection to the host's siamux and then opens a few
+// streams on the worker's mux, timing each of them.
+func (w *worker) managedDiagnose() skymodules.WorkerConnectionReport {
+	address := w.staticCache().staticHostMuxAddress
+	report := skymodules.WorkerConnectionReport{
+		HostPubKey: w.staticHostPubKey,
+		MuxAddress: address,
+	}
+
+	// Connect to the host without the mux.
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", address, defaultNewStreamTimeout)
+	report.TCPConnect = newWorkerConnectionProbe(time.Since(start), err)
+	if err == nil {
+		_ = conn.Close()
+	}
+
+	// Open the streams one after another to not measure them competing with
+	// each other.
+	for i := 0; i < workerDiagnoseStreamSamples && !w.staticKilled(); i++ {
+		start := time.Now()
+		stream, err := w.staticDialStream(address)
+		probe := newWorkerConnectionProbe(time.Since(start), err)
+		if err == nil {
+			_ = stream.Close()
+			report.Reachable = true
+			if report.MinStreamOpenTime == 0 || probe.Duration < report.MinStreamOpenTime {
+				report.MinStreamOpenTime = probe.Duration
+			}
+		}
+		report.StreamOpens = append(report.StreamOpens, probe)
+	}
+	report.ConnectionStatus = w.staticConnectionState.managedStatus()
+	return report
+}
+
+// newWorkerConnectionProbe creates the result of a single connectivity test.
+func newWorkerConnectionProbe(d time.Duration, err error) skymodules.WorkerConnectionProbe {
+	probe := skymodules.WorkerConnectionProbe{
+		Duration: d,
+		Success:  err == nil,
+	}
+	if err != nil {
+		probe.Error = err.Error()
+	}
+	return probe
+}
+
+// DiagnoseWorker runs a live connectivity test against the host of the given
+// worker and returns a report of the results.
+func (r *Renter) DiagnoseWorker(hostKey types.SiaPublicKey) (skymodules.WorkerConnectionReport, error) {
+	if err := r.tg.Add(); err != nil {
+		return skymodules.WorkerConnectionReport{}, err
+	}
+	defer r.tg.Done()
+	w, err := r.staticWorkerPool.callWorker(hostKey)
+	if err != nil {
+		return skymodules.WorkerConnectionReport{}, errors.AddContext(err, "unable to diagnose worker")
+	}
+	return w.managedDiagnose(), nil
+}
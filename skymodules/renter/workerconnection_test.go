@@ -0,0 +1,109 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+// TestWorkerConnectionState is a unit test for tracking the state of a
+// worker's connection.
+func TestWorkerConnectionState(t *testing.T) {
+	t.Parallel()
+
+	cs := &workerConnectionState{}
+
+	// The first sample is used as the estimate.
+	cs.managedTrackStreamOpen(80*time.Millisecond, nil)
+	status := cs.managedStatus()
+	if status.EstimatedRTT != 80*time.Millisecond || status.LastRTT != 80*time.Millisecond {
+		t.Fatal("wrong rtt", status.EstimatedRTT, status.LastRTT)
+	}
+
+	// Following samples are smoothed.
+	cs.managedTrackStreamOpen(160*time.Millisecond, nil)
+	status = cs.managedStatus()
+	if status.EstimatedRTT != 90*time.Millisecond {
+		t.Fatal("wrong estimated rtt", status.EstimatedRTT)
+	}
+	if status.LastRTT != 160*time.Millisecond {
+		t.Fatal("wrong last rtt", status.LastRTT)
+	}
+	if status.StreamsOpened != 2 || status.Reconnects != 0 {
+		t.Fatal("wrong counts", status.StreamsOpened, status.Reconnects)
+	}
+
+	// Failures don't affect the estimate. Only the first stream opened after
+	// a failure counts as a reconnect.
+	err := errors.New("failed")
+	cs.managedTrackStreamOpen(time.Second, err)
+	cs.managedTrackStreamOpen(time.Second, err)
+	cs.managedTrackStreamOpen(90*time.Millisecond, nil)
+	cs.managedTrackStreamOpen(90*time.Millisecond, nil)
+	status = cs.managedStatus()
+	if status.EstimatedRTT != 90*time.Millisecond {
+		t.Fatal("wrong estimated rtt", status.EstimatedRTT)
+	}
+	if status.StreamOpenFailures != 2 || status.StreamsOpened != 4 || status.Reconnects != 1 {
+		t.Fatal("wrong counts", status.StreamOpenFailures, status.StreamsOpened, status.Reconnects)
+	}
+	if status.RecentErr != err.Error() || status.RecentErrTime.IsZero() {
+		t.Fatal("wrong recent error", status.RecentErr, status.RecentErrTime)
+	}
+
+	// Track a stream error.
+	cs.managedTrackStreamErr(errors.New("stream error"))
+	status = cs.managedStatus()
+	if status.StreamErrors != 1 || status.RecentErr != "stream error" {
+		t.Fatal("wrong stream errors", status.StreamErrors, status.RecentErr)
+	}
+}
+
+// TestWorkerDiagnose verifies that a worker can diagnose its connection to
+// its host.
+func TestWorkerDiagnose(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	wt, err := newWorkerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// The worker already opened streams to its host.
+	status := wt.staticConnectionState.managedStatus()
+	if status.StreamsOpened == 0 || status.EstimatedRTT == 0 {
+		t.Fatal("connection wasn't tracked", status.StreamsOpened, status.EstimatedRTT)
+	}
+
+	// Diagnose the connection.
+	report, err := wt.staticRenter.DiagnoseWorker(wt.staticHostPubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Reachable || !report.TCPConnect.Success {
+		t.Fatal("host should be reachable", report)
+	}
+	if len(report.StreamOpens) != workerDiagnoseStreamSamples {
+		t.Fatal("wrong number of stream opens", len(report.StreamOpens))
+	}
+	for _, probe := range report.StreamOpens {
+		if !probe.Success || probe.Duration < report.MinStreamOpenTime {
+			t.Fatal("invalid probe", probe, report.MinStreamOpenTime)
+		}
+	}
+	if report.ConnectionStatus.StreamsOpened < status.StreamsOpened+workerDiagnoseStreamSamples {
+		t.Fatal("streams of the diagnosis weren't tracked", report.ConnectionStatus.StreamsOpened, status.StreamsOpened)
+	}
+	if report.MuxAddress != wt.staticCache().staticHostMuxAddress {
+		t.Fatal("wrong mux address", report.MuxAddress)
+	}
+}
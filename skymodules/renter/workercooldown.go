@@ -3,7 +3,9 @@ package renter
 import (
 	"time"
 
+	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/types"
 )
 
 const (
@@ -43,3 +45,47 @@ func cooldownUntil(consecutiveFailures uint64) time.Time {
 	}
 	return time.Now().Add(randCooldown)
 }
+
+// managedResetCooldowns takes the worker off all of its cooldowns. This
+// includes the cooldowns of the job queues, the upload cooldown and the
+// maintenance cooldown. The worker is woken up afterwards to resume its work
+// right away.
+func (w *worker) managedResetCooldowns() {
+	queues := []*jobGenericQueue{
+		w.staticJobDownloadSnapshotQueue.jobGenericQueue,
+		w.staticJobHasSectorQueue.jobGenericQueue,
+		w.staticJobReadQueue.jobGenericQueue,
+		w.staticJobLowPrioReadQueue.jobGenericQueue,
+		w.staticJobReadRegistryQueue.jobGenericQueue,
+		w.staticJobRenewQueue.jobGenericQueue,
+		w.staticJobUpdateRegistryQueue.jobGenericQueue,
+		w.staticJobUploadSnapshotQueue.jobGenericQueue,
+	}
+	for _, jq := range queues {
+		jq.callResetCooldown()
+	}
+
+	w.mu.Lock()
+	w.uploadConsecutiveFailures = 0
+	w.uploadRecentFailure = time.Time{}
+	w.mu.Unlock()
+
+	w.staticMaintenanceState.managedForceResetMaintenanceCooldown()
+	w.staticWake()
+}
+
+// ResetWorkerCooldown takes the worker of the host with the given public key
+// off all of its cooldowns. This is useful after fixing the issue that caused
+// the worker to fail, since the cooldowns can last for hours.
+func (r *Renter) ResetWorkerCooldown(hostKey types.SiaPublicKey) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	w, err := r.staticWorkerPool.callWorker(hostKey)
+	if err != nil {
+		return errors.AddContext(err, "unable to reset worker cooldown")
+	}
+	w.managedResetCooldowns()
+	return nil
+}
@@ -3,6 +3,8 @@ package renter
 import (
 	"testing"
 	"time"
+
+	"gitlab.com/NebulousLabs/errors"
 )
 
 // TestCooldownUntil checks that the cooldownUntil function is working as
@@ -74,3 +76,76 @@ func TestCooldownUntil(t *testing.T) {
 		}
 	}
 }
+
+// TestResetWorkerCooldown verifies that a worker's cooldowns can be reset
+// manually and that the cooldown state is reported in the worker's status.
+func TestResetWorkerCooldown(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	wt, err := newWorkerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := wt.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	w := wt.worker
+
+	// Put the download queue, the uploads and the maintenance on cooldown.
+	failure := errors.New("failure")
+	for i := 0; i < 3; i++ {
+		w.staticJobLowPrioReadQueue.callReportFailure(failure)
+	}
+	w.mu.Lock()
+	w.uploadRecentFailure = time.Now()
+	w.uploadRecentFailureErr = failure
+	w.uploadConsecutiveFailures = 2
+	w.mu.Unlock()
+	w.managedTrackPriceTableUpdateErr(failure)
+
+	// The status should reflect the cooldowns.
+	status := w.callStatus()
+	if !status.DownloadOnCoolDown || status.DownloadConsecutiveFailures != 3 || status.DownloadCoolDownTime <= 0 {
+		t.Fatal("unexpected download cooldown", status.DownloadOnCoolDown, status.DownloadConsecutiveFailures, status.DownloadCoolDownTime)
+	}
+	if !status.UploadOnCoolDown || status.UploadConsecutiveFailures != 2 || status.UploadCoolDownTime <= 0 {
+		t.Fatal("unexpected upload cooldown", status.UploadOnCoolDown, status.UploadConsecutiveFailures, status.UploadCoolDownTime)
+	}
+	if !status.MaintenanceOnCooldown || status.MaintenanceConsecutiveFailures == 0 || status.MaintenanceCoolDownError == "" {
+		t.Fatal("unexpected maintenance cooldown", status.MaintenanceOnCooldown, status.MaintenanceConsecutiveFailures, status.MaintenanceCoolDownError)
+	}
+
+	// Reset the cooldowns.
+	err = wt.rt.renter.ResetWorkerCooldown(w.staticHostPubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status = w.callStatus()
+	if status.DownloadOnCoolDown || status.DownloadConsecutiveFailures != 0 || status.DownloadCoolDownTime > 0 {
+		t.Fatal("download still on cooldown", status.DownloadOnCoolDown, status.DownloadConsecutiveFailures, status.DownloadCoolDownTime)
+	}
+	if status.UploadOnCoolDown || status.UploadConsecutiveFailures != 0 || status.UploadCoolDownTime > 0 {
+		t.Fatal("upload still on cooldown", status.UploadOnCoolDown, status.UploadConsecutiveFailures, status.UploadCoolDownTime)
+	}
+	if status.MaintenanceOnCooldown || status.MaintenanceConsecutiveFailures != 0 {
+		t.Fatal("maintenance still on cooldown", status.MaintenanceOnCooldown, status.MaintenanceConsecutiveFailures)
+	}
+	// The recent error is kept for debugging.
+	if status.DownloadCoolDownError == "" {
+		t.Fatal("recent error should be kept")
+	}
+
+	// Resetting the cooldown of an unknown host fails.
+	hpk := w.staticHostPubKey
+	hpk.Key = append([]byte{}, hpk.Key...)
+	hpk.Key[0]++
+	if err := wt.rt.renter.ResetWorkerCooldown(hpk); err == nil {
+		t.Fatal("expected error for unknown host")
+	}
+}
@@ -0,0 +1,242 @@
+package renter
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errWorkerPaused is returned when a job is submitted to a worker which
+	// was paused.
+	errWorkerPaused = errors.New("worker is paused")
+
+	// errWorkerQueuesFlushed is returned to the jobs which were discarded
+	// because the queues of their worker were flushed.
+	errWorkerQueuesFlushed = errors.New("worker queues were flushed")
+)
+
+// staticPaused returns true if the worker was paused.
+func (w *worker) staticPaused() bool {
+	return atomic.LoadUint64(&w.atomicPaused) == 1
+}
+
+// staticJobsDisabledErr returns the reason why the worker must not launch any
+// jobs or nil if it may launch jobs. Workers that were paused or whose host is
+// on the worker blocklist don't launch jobs.
+func (w *worker) staticJobsDisabledErr() error {
+	if w.staticPaused() {
+		return errWorkerPaused
+	}
+	if w.staticBlocklisted() {
+		return errHostBlocklisted
+	}
+	return nil
+}
+
+// staticJobQueues returns the generic job queues of the worker indexed by their
+// names.
+func (w *worker) staticJobQueues() map[string]*jobGenericQueue {
+	return map[string]*jobGenericQueue{
+		"downloadsnapshot": w.staticJobDownloadSnapshotQueue.jobGenericQueue,
+		"hassector":        w.staticJobHasSectorQueue.jobGenericQueue,
+		"read":             w.staticJobReadQueue.jobGenericQueue,
+		"lowprioread":      w.staticJobLowPrioReadQueue.jobGenericQueue,
+		"readregistry":     w.staticJobReadRegistryQueue.jobGenericQueue,
+		"renew":            w.staticJobRenewQueue.jobGenericQueue,
+		"updateregistry":   w.staticJobUpdateRegistryQueue.jobGenericQueue,
+		"uploadsnapshot":   w.staticJobUploadSnapshotQueue.jobGenericQueue,
+	}
+}
+
+// managedFlushQueues discards all jobs and upload chunks queued with the
+// worker. Jobs which are already running are not affected.
+func (w *worker) managedFlushQueues() {
+	w.managedDiscardAsyncJobs(errWorkerQueuesFlushed)
+	w.managedDiscardSerialJobs(errWorkerQueuesFlushed)
+}
+
+// callDetail returns the full internal state of the worker.
+func (w *worker) callDetail() skymodules.WorkerDetail {
+	// Collect the status of the queues.
+	queues := make(map[string]skymodules.WorkerGenericJobsStatus)
+	for name, jq := range w.staticJobQueues() {
+		queues[name] = callGenericWorkerJobStatus(jq)
+	}
+
+	balanceTarget, refillThreshold := w.managedAccountRefillTargets()
+	accountStatus := w.staticAccount.managedStatus()
+	w.staticAccount.mu.Lock()
+	balance := w.staticAccount.balance
+	pendingDeposits := w.staticAccount.pendingDeposits
+	pendingWithdrawals := w.staticAccount.pendingWithdrawals
+	w.staticAccount.mu.Unlock()
+
+	maintenanceOnCooldown, maintenanceCoolDownTime, maintenanceConsecutiveFailures, maintenanceCoolDownErr := w.staticMaintenanceState.managedMaintenanceCooldownStatus()
+	var mcdErr string
+	if maintenanceCoolDownErr != nil {
+		mcdErr = maintenanceCoolDownErr.Error()
+	}
+
+	// The upload queue isn't a generic queue, its status is reported the
+	// same way nonetheless.
+	w.mu.Lock()
+	uploadOnCooldown, uploadCooldownTime := w.onUploadCooldown()
+	uploadStatus := skymodules.WorkerGenericJobsStatus{
+		ConsecutiveFailures: uint64(w.uploadConsecutiveFailures),
+		JobQueueSize:        uint64(w.unprocessedChunks.Len()),
+		OnCooldown:          uploadOnCooldown,
+		RecentErrTime:       w.uploadRecentFailure,
+	}
+	if uploadOnCooldown {
+		uploadStatus.OnCooldownUntil = time.Now().Add(uploadCooldownTime)
+	}
+	if w.uploadRecentFailureErr != nil {
+		uploadStatus.RecentErr = w.uploadRecentFailureErr.Error()
+	}
+	w.mu.Unlock()
+	queues["upload"] = uploadStatus
+
+	// Collect the most recent errors.
+	priceTableStatus := w.staticPriceTableStatus()
+	benchmarkStatus := w.staticBenchmarkState.managedStatus()
+	var recentErrs []skymodules.WorkerRecentError
+	addErr := func(source, err string, t time.Time) {
+		if err == "" {
+			return
+		}
+		recentErrs = append(recentErrs, skymodules.WorkerRecentError{
+			Source: source,
+			Error:  err,
+			Time:   t,
+		})
+	}
+	for name, status := range queues {
+		addErr(name, status.RecentErr, status.RecentErrTime)
+	}
+	addErr("account", accountStatus.RecentErr, accountStatus.RecentErrTime)
+	addErr("benchmark", benchmarkStatus.RecentErr, benchmarkStatus.RecentErrTime)
+	addErr("maintenance", mcdErr, time.Time{})
+	addErr("pricetable", priceTableStatus.RecentErr, priceTableStatus.RecentErrTime)
+	sort.Slice(recentErrs, func(i, j int) bool {
+		if !recentErrs[i].Time.Equal(recentErrs[j].Time) {
+			return recentErrs[i].Time.After(recentErrs[j].Time)
+		}
+		return recentErrs[i].Source < recentErrs[j].Source
+	})
+
+	ls := w.staticLoopState
+	cache := w.staticCache()
+	return skymodules.WorkerDetail{
+		ContractID:      cache.staticContractID,
+		ContractUtility: cache.staticContractUtility,
+		HostPubKey:      w.staticHostPubKey,
+		HostVersion:     cache.staticHostVersion,
+
+		Blocklisted: w.staticBlocklisted(),
+		Killed:      w.staticKilled(),
+		Paused:      w.staticPaused(),
+
+		AccountBalance:            balance,
+		AccountBalanceTarget:      balanceTarget,
+		AccountPendingDeposits:    pendingDeposits,
+		AccountPendingWithdrawals: pendingWithdrawals,
+		AccountRefillThreshold:    refillThreshold,
+		AccountStatus:             accountStatus,
+
+		LoopStatus: skymodules.WorkerLoopStatus{
+			AsyncJobsRunning:        atomic.LoadUint64(&ls.atomicAsyncJobsRunning),
+			SerialJobRunning:        ls.staticSerialJobRunning(),
+			SuspectRevisionMismatch: w.staticSuspectRevisionMismatch(),
+
+			ReadDataLimit:        atomic.LoadUint64(&ls.atomicReadDataLimit),
+			ReadDataOutstanding:  atomic.LoadUint64(&ls.atomicReadDataOutstanding),
+			WriteDataLimit:       atomic.LoadUint64(&ls.atomicWriteDataLimit),
+			WriteDataOutstanding: atomic.LoadUint64(&ls.atomicWriteDataOutstanding),
+		},
+		MaintenanceOnCooldown:          maintenanceOnCooldown,
+		MaintenanceConsecutiveFailures: maintenanceConsecutiveFailures,
+		MaintenanceCoolDownError:       mcdErr,
+		MaintenanceCoolDownTime:        maintenanceCoolDownTime,
+
+		PriceTableStatus: priceTableStatus,
+
+		Queues:       queues,
+		RecentErrors: recentErrs,
+	}
+}
+
+// WorkerPoolDetail returns the full internal state of the renter's workers
+// sorted by their host's public key.
+func (r *Renter) WorkerPoolDetail() (skymodules.WorkerPoolDetail, error) {
+	if err := r.tg.Add(); err != nil {
+		return skymodules.WorkerPoolDetail{}, err
+	}
+	defer r.tg.Done()
+
+	workers := r.staticWorkerPool.callWorkers()
+	details := make([]skymodules.WorkerDetail, 0, len(workers))
+	for _, w := range workers {
+		details = append(details, w.callDetail())
+	}
+	sort.Slice(details, func(i, j int) bool {
+		return details[i].HostPubKey.String() < details[j].HostPubKey.String()
+	})
+	return skymodules.WorkerPoolDetail{
+		NumWorkers: len(details),
+		Workers:    details,
+	}, nil
+}
+
+// PauseWorker stops the worker of the host with the given public key from
+// launching jobs. Queued jobs are discarded and new jobs are refused until the
+// worker is resumed. Workers are not paused anymore after a restart.
+func (r *Renter) PauseWorker(hostKey types.SiaPublicKey) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	w, err := r.staticWorkerPool.callWorker(hostKey)
+	if err != nil {
+		return errors.AddContext(err, "unable to pause worker")
+	}
+	atomic.StoreUint64(&w.atomicPaused, 1)
+	w.managedDiscardAsyncJobs(errWorkerPaused)
+	w.managedDiscardSerialJobs(errWorkerPaused)
+	return nil
+}
+
+// ResumeWorker resumes the paused worker of the host with the given public
+// key.
+func (r *Renter) ResumeWorker(hostKey types.SiaPublicKey) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	w, err := r.staticWorkerPool.callWorker(hostKey)
+	if err != nil {
+		return errors.AddContext(err, "unable to resume worker")
+	}
+	atomic.StoreUint64(&w.atomicPaused, 0)
+	w.staticWake()
+	return nil
+}
+
+// FlushWorkerQueues discards all jobs and upload chunks queued with the worker
+// of the host with the given public key.
+func (r *Renter) FlushWorkerQueues(hostKey types.SiaPublicKey) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	w, err := r.staticWorkerPool.callWorker(hostKey)
+	if err != nil {
+		return errors.AddContext(err, "unable to flush worker queues")
+	}
+	w.managedFlushQueues()
+	return nil
+}
@@ -0,0 +1,95 @@
+package renter
+
+import (
+	"context"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+)
+
+// TestWorkerPauseFlush tests pausing, resuming and flushing a worker and that
+// the worker's detail reflects its state.
+func TestWorkerPauseFlush(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	wt, err := newWorkerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	w := wt.worker
+	r := w.staticRenter
+	hpk := w.staticHostPubKey
+
+	newJob := func() *jobHasSector {
+		return w.newJobHasSector(context.Background(), make(chan *jobHasSectorResponse, 1), 1, crypto.Hash{})
+	}
+
+	// The worker should be part of the detail.
+	wpd, err := r.WorkerPoolDetail()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wpd.NumWorkers != 1 || len(wpd.Workers) != 1 {
+		t.Fatal("wrong number of workers", wpd.NumWorkers)
+	}
+	wd := wpd.Workers[0]
+	if !wd.HostPubKey.Equals(hpk) || wd.Paused || wd.Blocklisted || wd.Killed {
+		t.Fatal("wrong detail", wd)
+	}
+	for _, name := range []string{"hassector", "read", "upload", "renew"} {
+		if _, exists := wd.Queues[name]; !exists {
+			t.Fatal("missing queue", name)
+		}
+	}
+
+	// Flush the queues after adding some jobs.
+	for i := 0; i < 10; i++ {
+		if !w.staticJobHasSectorQueue.callAdd(newJob()) {
+			t.Fatal("job should be added")
+		}
+	}
+	if err := r.FlushWorkerQueues(hpk); err != nil {
+		t.Fatal(err)
+	}
+	if size := w.staticJobHasSectorQueue.callStatus().size; size != 0 {
+		t.Fatal("queue should be empty", size)
+	}
+
+	// Pause the worker. Jobs should be refused.
+	if err := r.PauseWorker(hpk); err != nil {
+		t.Fatal(err)
+	}
+	if !w.staticPaused() || !errors.Contains(w.staticJobsDisabledErr(), errWorkerPaused) {
+		t.Fatal("worker should be paused")
+	}
+	if w.staticJobHasSectorQueue.callAdd(newJob()) {
+		t.Fatal("job shouldn't be added")
+	}
+	wpd, err = r.WorkerPoolDetail()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !wpd.Workers[0].Paused {
+		t.Fatal("detail should show the worker as paused")
+	}
+
+	// Resume the worker.
+	if err := r.ResumeWorker(hpk); err != nil {
+		t.Fatal(err)
+	}
+	if w.staticPaused() || w.staticJobsDisabledErr() != nil {
+		t.Fatal("worker shouldn't be paused")
+	}
+	if !w.staticJobHasSectorQueue.callAdd(newJob()) {
+		t.Fatal("job should be added")
+	}
+}
@@ -69,9 +69,9 @@ func checkDownloadGouging(allowance skymodules.Allowance, pt *modules.RPCPriceTa
 		return errors.New(errStr)
 	}
 	// Check whether the download bandwidth price is too high.
-	if !allowance.MaxDownloadBandwidthPrice.IsZero() && allowance.MaxDownloadBandwidthPrice.Cmp(pt.DownloadBandwidthCost) < 0 {
-		errStr := fmt.Sprintf("download bandwidth price of host is %v, which is above the maximum allowed by the allowance: %v", pt.DownloadBandwidthCost, allowance.MaxDownloadBandwidthPrice)
-		return errors.New(errStr)
+	report := skymodules.NewGougingChecker(allowance).CheckPriceTable(*pt)
+	if err := report.Err(skymodules.GougingFieldDownloadBandwidthPrice); err != nil {
+		return err
 	}
 
 	// If there is no allowance, general price gouging checks have to be
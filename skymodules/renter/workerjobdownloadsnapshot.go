@@ -45,9 +45,9 @@ type (
 // halted due to price gouging.
 func checkDownloadSnapshotGouging(allowance skymodules.Allowance, pt modules.RPCPriceTable) error {
 	// Check whether the download bandwidth price is too high.
-	if !allowance.MaxDownloadBandwidthPrice.IsZero() && allowance.MaxDownloadBandwidthPrice.Cmp(pt.DownloadBandwidthCost) < 0 {
-		errStr := fmt.Sprintf("download bandwidth price of host is %v, which is above the maximum allowed by the allowance: %v", pt.DownloadBandwidthCost, allowance.MaxDownloadBandwidthPrice)
-		return errors.New(errStr)
+	report := skymodules.NewGougingChecker(allowance).CheckPriceTable(pt)
+	if err := report.Err(skymodules.GougingFieldDownloadBandwidthPrice); err != nil {
+		return err
 	}
 
 	// If there is no allowance, general price gouging checks have to be
@@ -168,7 +168,7 @@ func (w *worker) DownloadSnapshotTable(ctx context.Context) ([]snapshotEntry, er
 	jus := &jobDownloadSnapshot{
 		staticResponseChan: downloadSnapshotRespChan,
 
-		jobGeneric: newJobGeneric(ctx, w.staticJobDownloadSnapshotQueue, nil),
+		jobGeneric: newJobGeneric(contextWithTrafficClass(ctx, skymodules.TrafficClassBackup), w.staticJobDownloadSnapshotQueue, nil),
 	}
 
 	// Add the job to the queue.
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
 )
 
 var (
@@ -16,6 +17,16 @@ var (
 	// account refill is not being met. The error may or may not be extended to
 	// provide a reason.
 	ErrJobDiscarded = errors.New("job is being discarded")
+
+	// errEstimateAboveDeadline is returned if a job wasn't added to a queue
+	// because it isn't expected to complete before the deadline of its
+	// context.
+	errEstimateAboveDeadline = errors.New("can't add job since estimate is beyond the deadline")
+
+	// errJobShed is returned by a job which was discarded when it was taken
+	// from the queue because it wasn't expected to complete before the
+	// deadline of its context anymore.
+	errJobShed = errors.New("job was shed since it can't complete before its deadline")
 )
 
 type (
@@ -31,7 +42,8 @@ type (
 
 		// These fields are set when the job is added to the job queue and used
 		// after execution to log the delta between the estimated job time and
-		// the actual job time.
+		// the actual job time. The estimate is also used to shed the job if
+		// it can't complete before its deadline anymore once it is dequeued.
 		externJobStartTime         time.Time
 		externEstimatedJobDuration time.Duration
 	}
@@ -45,11 +57,19 @@ type (
 
 		killed bool
 
+		// shedJobs is the number of jobs which were discarded when they were
+		// dequeued because they couldn't meet their deadline anymore.
+		shedJobs uint64
+
 		cooldownUntil       time.Time
 		consecutiveFailures uint64
 		recentErr           error
 		recentErrTime       time.Time
 
+		// breaker excludes the queue from being used after too many
+		// consecutive failures.
+		breaker circuitBreaker
+
 		staticWorkerObj *worker // name conflict with staticWorker method
 		mu              sync.Mutex
 	}
@@ -74,6 +94,13 @@ type (
 		// staticCanceled returns true if the job has been canceled, false
 		// otherwise.
 		staticCanceled() bool
+
+		// staticMissesDeadline returns true if the job, when executed at the
+		// given time, isn't expected to complete before its deadline.
+		staticMissesDeadline(time.Time) bool
+
+		// staticTrafficClass returns the traffic class of the job.
+		staticTrafficClass() skymodules.TrafficClass
 	}
 
 	// workerJobQueue defines an interface to create a worker job queue.
@@ -100,10 +127,15 @@ type (
 	// workerJobQueueStatus is a struct that reflects the status of the queue
 	workerJobQueueStatus struct {
 		size                uint64
+		shedJobs            uint64
 		cooldownUntil       time.Time
 		consecutiveFailures uint64
 		recentErr           error
 		recentErrTime       time.Time
+
+		breakerState     string
+		breakerOpenUntil time.Time
+		breakerTrips     uint64
 	}
 )
 
@@ -136,17 +168,50 @@ func (j *jobGeneric) staticCanceled() bool {
 	}
 }
 
+// staticWithinDeadline returns whether a job which starts at the given time and
+// takes the estimated duration completes before the deadline of its context.
+// Jobs without a deadline are always within it.
+func (j *jobGeneric) staticWithinDeadline(start time.Time, estimate time.Duration) bool {
+	deadline, ok := j.staticCtx.Deadline()
+	return !ok || !start.Add(estimate).After(deadline)
+}
+
+// staticMissesDeadline returns whether the job, when executed at the given
+// time, is not expected to complete before the deadline of its context. The
+// estimated duration of the job is the one recorded when it was added to the
+// queue. Jobs without an estimate or a deadline never miss it.
+func (j *jobGeneric) staticMissesDeadline(now time.Time) bool {
+	return !j.staticWithinDeadline(now, j.externEstimatedJobDuration)
+}
+
 // staticGetMetadata returns the job's metadata.
 func (j *jobGeneric) staticGetMetadata() interface{} {
 	return j.staticMetadata
 }
 
-// add will add a job to the queue.
+// staticTrafficClass returns the traffic class attached to the job's context.
+func (j *jobGeneric) staticTrafficClass() skymodules.TrafficClass {
+	return trafficClassFromContext(j.staticCtx)
+}
+
+// add will add a job to the queue. The job is queued behind all jobs of the
+// same or a higher priority traffic class, but before the jobs of classes with
+// a lower priority.
 func (jq *jobGenericQueue) add(j workerJob) bool {
-	if jq.killed || jq.onCooldown() {
+	if jq.killed || jq.onCooldown() || jq.staticWorkerObj.staticJobsDisabledErr() != nil {
 		return false
 	}
-	jq.jobs.PushBack(j)
+	priority := j.staticTrafficClass().Priority()
+	e := jq.jobs.Back()
+	for e != nil && e.Value.(workerJob).staticTrafficClass().Priority() > priority {
+		e = e.Prev()
+	}
+	if e == nil {
+		jq.jobs.PushFront(j)
+	} else {
+		jq.jobs.InsertAfter(j, e)
+	}
+	jq.breaker.trackJob()
 	jq.staticWorkerObj.staticWake()
 	return true
 }
@@ -192,13 +257,21 @@ func (jq *jobGenericQueue) callLen() int {
 
 // callNext returns the next job in the worker queue. If there is no job in the
 // queue, 'nil' will be returned.
+//
+// Jobs which are not expected to complete before their deadline anymore are
+// shed. When a worker is overloaded, these jobs would only take up capacity
+// that viable jobs further back in the queue could use.
 func (jq *jobGenericQueue) callNext() workerJob {
 	jq.mu.Lock()
 	defer jq.mu.Unlock()
 
 	// Loop through the jobs, looking for the first job that hasn't yet been
-	// canceled. Remove jobs from the queue along the way.
-	for job := jq.jobs.Front(); job != nil; job = job.Next() {
+	// canceled and can still meet its deadline. Remove jobs from the queue
+	// along the way.
+	// NOTE: Removing an element from the list clears its next pointer, which
+	// is why the loop always continues with the new front of the list.
+	now := time.Now()
+	for job := jq.jobs.Front(); job != nil; job = jq.jobs.Front() {
 		// Remove the job from the list.
 		jq.jobs.Remove(job)
 
@@ -208,6 +281,13 @@ func (jq *jobGenericQueue) callNext() workerJob {
 			wj.callDiscard(errors.New("callNext: skipping and discarding already canceled job"))
 			continue
 		}
+
+		// Check if the job can still meet its deadline.
+		if wj.staticMissesDeadline(now) {
+			jq.shedJobs++
+			wj.callDiscard(errJobShed)
+			continue
+		}
 		return wj
 	}
 
@@ -235,6 +315,26 @@ func (jq *jobGenericQueue) callReportFailure(err error) {
 	jq.consecutiveFailures++
 	jq.recentErr = err
 	jq.recentErrTime = time.Now()
+	jq.breaker.reportFailure(jq.consecutiveFailures)
+}
+
+// callReportCorruption lets the job queue know that the host served corrupt
+// data. Unlike a regular failure, which only results in a long cooldown after
+// many consecutive failures, corruption puts the queue on the max cooldown and
+// trips the circuit breaker right away.
+func (jq *jobGenericQueue) callReportCorruption(err error) {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+
+	err = errors.AddContext(err, "host served corrupt data, discarding all jobs in this queue and going on cooldown")
+	jq.discardAll(err)
+	if jq.consecutiveFailures < cooldownMaxConsecutiveFailures {
+		jq.consecutiveFailures = cooldownMaxConsecutiveFailures
+	}
+	jq.cooldownUntil = cooldownUntil(jq.consecutiveFailures)
+	jq.recentErr = err
+	jq.recentErrTime = time.Now()
+	jq.breaker.reportCorruption()
 }
 
 // callReportSuccess lets the job queue know that there was a successsful job.
@@ -245,6 +345,17 @@ func (jq *jobGenericQueue) callReportFailure(err error) {
 func (jq *jobGenericQueue) callReportSuccess() {
 	jq.mu.Lock()
 	jq.consecutiveFailures = 0
+	jq.breaker.reportSuccess()
+	jq.mu.Unlock()
+}
+
+// callResetCooldown takes the queue off cooldown and resets its consecutive
+// failures and circuit breaker. The recentErr value is kept for debugging.
+func (jq *jobGenericQueue) callResetCooldown() {
+	jq.mu.Lock()
+	jq.cooldownUntil = time.Time{}
+	jq.consecutiveFailures = 0
+	jq.breaker = circuitBreaker{}
 	jq.mu.Unlock()
 }
 
@@ -254,10 +365,15 @@ func (jq *jobGenericQueue) callStatus() workerJobQueueStatus {
 	defer jq.mu.Unlock()
 	return workerJobQueueStatus{
 		size:                uint64(jq.jobs.Len()),
+		shedJobs:            jq.shedJobs,
 		cooldownUntil:       jq.cooldownUntil,
 		consecutiveFailures: jq.consecutiveFailures,
 		recentErr:           jq.recentErr,
 		recentErrTime:       jq.recentErrTime,
+
+		breakerState:     jq.breaker.state(),
+		breakerOpenUntil: jq.breaker.openUntil,
+		breakerTrips:     jq.breaker.trips,
 	}
 }
 
@@ -275,7 +391,8 @@ func (jq *jobGenericQueue) staticWorker() *worker {
 	return jq.staticWorkerObj
 }
 
-// onCooldown returns whether the queue is on cooldown.
+// onCooldown returns whether the queue is on cooldown. A queue with a blocking
+// circuit breaker is considered to be on cooldown as well.
 func (jq *jobGenericQueue) onCooldown() bool {
-	return time.Now().Before(jq.cooldownUntil)
+	return time.Now().Before(jq.cooldownUntil) || jq.breaker.blocking()
 }
@@ -529,3 +529,67 @@ func TestQueueMemoryLeak(t *testing.T) {
 	runtime.ReadMemStats(&ms)
 	t.Log("after gc", ms.HeapObjects, ms.HeapAlloc)
 }
+
+// TestJobQueueShedding checks that jobs which can't complete before their
+// deadline anymore are shed when they are dequeued.
+func TestJobQueueShedding(t *testing.T) {
+	t.Parallel()
+
+	// Create a job queue.
+	w := new(worker)
+	w.staticRenter = new(Renter)
+	jq := newJobGenericQueue(w)
+
+	// Helper to add a job with a deadline and estimate.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	addJob := func(ctx context.Context, estimate time.Duration) (*jobTest, chan *jobTestResult) {
+		resultChan := make(chan *jobTestResult, 1)
+		j := &jobTest{
+			jobGeneric: newJobGeneric(ctx, jq, nil),
+			resultChan: resultChan,
+		}
+		j.externEstimatedJobDuration = estimate
+		if !jq.callAdd(j) {
+			t.Fatal("failed to add job")
+		}
+		return j, resultChan
+	}
+
+	// Add a job which is doomed, a job which can still make its deadline and
+	// a job without a deadline.
+	doomed, doomedChan := addJob(ctx, time.Hour)
+	viable, _ := addJob(ctx, time.Second)
+	noDeadline, _ := addJob(context.Background(), time.Hour)
+
+	// The doomed job should be shed and the other jobs returned in order.
+	if job := jq.callNext(); job != viable {
+		t.Fatal("expected the viable job")
+	}
+	if job := jq.callNext(); job != noDeadline {
+		t.Fatal("expected the job without deadline")
+	}
+	if job := jq.callNext(); job != nil {
+		t.Fatal("queue should be empty")
+	}
+	doomed.mu.Lock()
+	discarded := doomed.discarded
+	doomed.mu.Unlock()
+	if !discarded {
+		t.Fatal("doomed job should have been discarded")
+	}
+	select {
+	case res := <-doomedChan:
+		if !errors.Contains(res.staticErr, errJobShed) {
+			t.Fatal("wrong error", res.staticErr)
+		}
+	case <-time.After(time.Minute):
+		t.Fatal("no result for the shed job")
+	}
+
+	// Shedding a job isn't a failure of the queue.
+	status := jq.callStatus()
+	if status.shedJobs != 1 || status.consecutiveFailures != 0 || jq.callOnCooldown() {
+		t.Fatal("unexpected status", status.shedJobs, status.consecutiveFailures)
+	}
+}
@@ -89,6 +89,11 @@ type (
 		// worker's recent performance for jobHasSectorQueue.
 		weightedJobTime float64
 
+		// recentJobTimes tracks the job times over a sliding window. It is
+		// preferred over the weighted average once it contains enough data
+		// points.
+		recentJobTimes *skymodules.WindowedDistribution
+
 		// availabilityMetrics keeps track of how often a sector was available
 		// on this host, we keep track of this in a way that we take the
 		// redundancy with which the sector was uploaded into account
@@ -288,6 +293,23 @@ func (j jobHasSectorBatch) staticCanceled() bool {
 	return false
 }
 
+// staticMissesDeadline always returns false. A batched job never resides in
+// the queue, the jobs it contains were already checked when they were
+// dequeued.
+func (j jobHasSectorBatch) staticMissesDeadline(time.Time) bool {
+	return false
+}
+
+// staticTrafficClass returns the traffic class of the first job in the batch.
+// Since the queue is ordered by priority, it's the class with the highest
+// priority within the batch.
+func (j jobHasSectorBatch) staticTrafficClass() skymodules.TrafficClass {
+	if len(j.staticJobs) == 0 {
+		return skymodules.TrafficClassInteractive
+	}
+	return j.staticJobs[0].staticTrafficClass()
+}
+
 // staticGetMetadata return an empty struct. A batched has sector job doesn't
 // contain any metadata.
 func (j jobHasSectorBatch) staticGetMetadata() interface{} {
@@ -419,7 +441,7 @@ func (j *jobHasSectorBatch) managedHasSector() (results [][]bool, err error) {
 	// Execute the program and parse the responses.
 	hasSectors := make([]bool, 0, len(program))
 	var responses []programResponse
-	responses, _, err = w.managedExecuteProgram(program, programData, types.FileContractID{}, categoryDownload, cost)
+	responses, _, err = w.managedExecuteProgram(program, programData, types.FileContractID{}, categoryDownload, j.staticTrafficClass(), cost)
 	if err != nil {
 		return nil, errors.AddContext(err, "unable to execute program for has sector job")
 	}
@@ -511,12 +533,13 @@ func (jq *jobHasSectorQueue) callUpdateJobTimeMetrics(jobTime time.Duration) {
 	jq.mu.Lock()
 	defer jq.mu.Unlock()
 	jq.weightedJobTime = expMovingAvgHotStart(jq.weightedJobTime, float64(jobTime), jobHasSectorPerformanceDecay)
+	addWindowedJobTime(jq.recentJobTimes, jobTime)
 }
 
 // expectedJobTime will return the amount of time that a job is expected to
 // take, given the current conditions of the queue.
 func (jq *jobHasSectorQueue) expectedJobTime() time.Duration {
-	return time.Duration(jq.weightedJobTime)
+	return windowedJobTime(jq.recentJobTimes, jq.weightedJobTime)
 }
 
 // initJobHasSectorQueue will init the queue for the has sector jobs.
@@ -528,6 +551,7 @@ func (w *worker) initJobHasSectorQueue() {
 	}
 
 	w.staticJobHasSectorQueue = &jobHasSectorQueue{
+		recentJobTimes:      skymodules.NewWindowedDistribution(jobTimeWindow),
 		availabilityMetrics: newAvailabilityMetrics(availabilityMetricsDefaultHalfLife),
 		jobGenericQueue:     newJobGenericQueue(w),
 	}
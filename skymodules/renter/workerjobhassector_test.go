@@ -213,7 +213,7 @@ func TestHasSectorJobExpectedBandwidth(t *testing.T) {
 		}
 
 		// execute the program
-		_, limit, err := w.managedExecuteProgram(p, data, types.FileContractID{}, categoryDownload, cost)
+		_, limit, err := w.managedExecuteProgram(p, data, types.FileContractID{}, categoryDownload, skymodules.TrafficClassInteractive, cost)
 		if err != nil {
 			t.Fatal(err)
 		}
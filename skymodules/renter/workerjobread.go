@@ -2,10 +2,12 @@ package renter
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
 	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
@@ -22,6 +24,21 @@ const (
 	// predictor tends to be more accurate over time, but is less responsive to
 	// things like network load.
 	jobReadPerformanceDecay = 0.9
+
+	// jobTimeWindow is the size of the sliding window over which the recent
+	// job times of read and HasSector jobs are tracked.
+	jobTimeWindow = 15 * time.Minute
+
+	// jobTimeWindowMinDataPoints is the weighted number of data points the
+	// window needs to contain before its estimate is preferred over the
+	// exponential weighted average.
+	jobTimeWindowMinDataPoints = 3
+)
+
+var (
+	// errSectorCorrupted is returned if the data served by a host doesn't
+	// match the merkle root of the sector it was requested from.
+	errSectorCorrupted = errors.New("host served data that doesn't match the sector root")
 )
 
 type (
@@ -35,6 +52,17 @@ type (
 		// tracing. By allowing it to be nil we avoid the extra overhead.
 		staticSpan opentracing.Span
 
+		// bandwidthUp and bandwidthDown are the number of bytes which were
+		// actually sent to and received from the host while executing the
+		// job. They are only accessed by the thread executing the job.
+		bandwidthUp   uint64
+		bandwidthDown uint64
+
+		// proof is the merkle proof of the data read by the job. It is
+		// passed along with the response so that callers which skip the
+		// verification of the proof in the worker can verify it themselves.
+		proof []crypto.Hash
+
 		*jobGeneric
 	}
 
@@ -56,20 +84,33 @@ type (
 		weightedJobTime1m  float64
 		weightedJobTime4m  float64
 
+		// The recent job times are tracked over a sliding window. The
+		// estimates are based on the window as long as it contains enough
+		// data points, so they adapt to the current network conditions.
+		recentJobTimes64k *skymodules.WindowedDistribution
+		recentJobTimes1m  *skymodules.WindowedDistribution
+		recentJobTimes4m  *skymodules.WindowedDistribution
+
 		mu sync.Mutex
 	}
 
 	// jobReadResponse contains the result of a Read query.
 	jobReadResponse struct {
-		// The response data.
-		staticData []byte
-		staticErr  error
+		// The response data and its merkle proof.
+		staticData  []byte
+		staticProof []crypto.Hash
+		staticErr   error
 
 		// Metadata related to the job.
 		staticMetadata jobReadMetadata
 
 		// The time it took for this job to complete.
 		staticJobTime time.Duration
+
+		// The number of bytes which were sent to and received from the host
+		// to execute the job, regardless of whether it succeeded.
+		staticBandwidthUp   uint64
+		staticBandwidthDown uint64
 	}
 
 	// jobReadMetadata contains meta information about a read job.
@@ -84,6 +125,12 @@ type (
 		// but might also be used for snapshots for example
 		staticSpendingCategory spendingCategory
 
+		// staticSkipProofVerification indicates that the caller verifies the
+		// merkle proof of the response itself. Project downloads do that to
+		// detect corrupt pieces as they arrive, against the piece roots of
+		// the chunk rather than the root the worker was asked for.
+		staticSkipProofVerification bool
+
 		staticWorker *worker
 	}
 )
@@ -142,11 +189,15 @@ func (j *jobRead) managedFinishExecute(readData []byte, readErr error, readJobTi
 	// released faster. Need to check if the job was canceled so that the
 	// goroutine will exit.
 	response := &jobReadResponse{
-		staticData: readData,
-		staticErr:  readErr,
+		staticData:  readData,
+		staticProof: j.proof,
+		staticErr:   readErr,
 
 		staticMetadata: j.staticJobReadMetadata(),
 		staticJobTime:  readJobTime,
+
+		staticBandwidthUp:   j.bandwidthUp,
+		staticBandwidthDown: j.bandwidthDown,
 	}
 	w := j.staticQueue.staticWorker()
 	err := w.staticRenter.tg.Launch(func() {
@@ -160,7 +211,12 @@ func (j *jobRead) managedFinishExecute(readData []byte, readErr error, readJobTi
 		j.staticQueue.staticWorker().staticRenter.staticLog.Print("managedFinishExecute: launch failed", err)
 	}
 
-	// Report success or failure to the queue.
+	// Report success or failure to the queue. Corrupt data is reported to
+	// the worker which penalizes the host right away.
+	if errors.Contains(readErr, errSectorCorrupted) {
+		w.managedReportCorruption(readErr)
+		return
+	}
 	if readErr != nil {
 		j.staticQueue.callReportFailure(readErr)
 		return
@@ -189,7 +245,14 @@ func (j *jobRead) callExpectedBandwidth() (ul, dl uint64) {
 // proof.
 func (j *jobRead) managedRead(w *worker, program modules.Program, programData []byte, cost types.Currency) ([]programResponse, error) {
 	// execute it
-	responses, _, err := w.managedExecuteProgram(program, programData, w.staticCache().staticContractID, j.staticJobReadMetadata().staticSpendingCategory, cost)
+	responses, limit, err := w.managedExecuteProgram(program, programData, w.staticCache().staticContractID, j.staticJobReadMetadata().staticSpendingCategory, j.staticTrafficClass(), cost)
+
+	// Record the bandwidth that was used, failed programs consume bandwidth
+	// as well.
+	if limit != nil {
+		j.bandwidthUp = limit.Uploaded()
+		j.bandwidthDown = limit.Downloaded()
+	}
 	if err != nil {
 		return []programResponse{}, err
 	}
@@ -223,17 +286,26 @@ func (j *jobRead) managedRead(w *worker, program modules.Program, programData []
 }
 
 // callAddWithEstimate will add a job to the job read queue while providing an
-// estimate for when the job is expected to return.
-func (jq *jobReadQueue) callAddWithEstimate(j *jobReadSector) (time.Time, bool) {
+// estimate for when the job is expected to return. Jobs which are not expected
+// to return before the deadline of their context are rejected, so the caller
+// can fail over to a faster worker.
+func (jq *jobReadQueue) callAddWithEstimate(j *jobReadSector) (time.Time, error) {
 	estimate := jq.staticStats.callExpectedJobTime(j.staticLength)
+	now := time.Now()
+	if !j.staticWithinDeadline(now, estimate) {
+		return time.Time{}, errEstimateAboveDeadline
+	}
 
 	jq.mu.Lock()
 	defer jq.mu.Unlock()
 
+	j.externJobStartTime = now
+	j.externEstimatedJobDuration = estimate
+
 	if !jq.add(j) {
-		return time.Time{}, false
+		return time.Time{}, errors.New("unable to add job to queue")
 	}
-	return time.Now().Add(estimate), true
+	return now.Add(estimate), nil
 }
 
 // callExpectedJobTime will return the recent performance of the worker
@@ -256,11 +328,33 @@ func (jrs *jobReadStats) callExpectedJobTime(length uint64) time.Duration {
 // for the given read length.
 func (jrs *jobReadStats) expectedJobTime(length uint64) time.Duration {
 	if length <= 1<<16 {
-		return time.Duration(jrs.weightedJobTime64k)
+		return windowedJobTime(jrs.recentJobTimes64k, jrs.weightedJobTime64k)
 	} else if length <= 1<<20 {
-		return time.Duration(jrs.weightedJobTime1m)
+		return windowedJobTime(jrs.recentJobTimes1m, jrs.weightedJobTime1m)
 	} else {
-		return time.Duration(jrs.weightedJobTime4m)
+		return windowedJobTime(jrs.recentJobTimes4m, jrs.weightedJobTime4m)
+	}
+}
+
+// windowedJobTime returns the expected job time of the window if it contains
+// enough data points. Otherwise the weighted average is returned.
+func windowedJobTime(window *skymodules.WindowedDistribution, weightedJobTime float64) time.Duration {
+	if window == nil {
+		return time.Duration(weightedJobTime)
+	}
+	d := window.Distribution()
+	if d.DataPoints() < jobTimeWindowMinDataPoints {
+		return time.Duration(weightedJobTime)
+	}
+	return d.ExpectedDuration()
+}
+
+// newJobReadStats creates a new jobReadStats object.
+func newJobReadStats() *jobReadStats {
+	return &jobReadStats{
+		recentJobTimes64k: skymodules.NewWindowedDistribution(jobTimeWindow),
+		recentJobTimes1m:  skymodules.NewWindowedDistribution(jobTimeWindow),
+		recentJobTimes4m:  skymodules.NewWindowedDistribution(jobTimeWindow),
 	}
 }
 
@@ -294,13 +388,33 @@ func (jrs *jobReadStats) callUpdateJobTimeMetrics(length uint64, jobTime time.Du
 	defer jrs.mu.Unlock()
 	if length <= 1<<16 {
 		jrs.weightedJobTime64k = expMovingAvgHotStart(jrs.weightedJobTime64k, float64(jobTime), jobReadPerformanceDecay)
+		addWindowedJobTime(jrs.recentJobTimes64k, jobTime)
 	} else if length <= 1<<20 {
 		jrs.weightedJobTime1m = expMovingAvgHotStart(jrs.weightedJobTime1m, float64(jobTime), jobReadPerformanceDecay)
+		addWindowedJobTime(jrs.recentJobTimes1m, jobTime)
 	} else {
 		jrs.weightedJobTime4m = expMovingAvgHotStart(jrs.weightedJobTime4m, float64(jobTime), jobReadPerformanceDecay)
+		addWindowedJobTime(jrs.recentJobTimes4m, jobTime)
 	}
 }
 
+// addWindowedJobTime adds a job time to the window if there is one.
+func addWindowedJobTime(window *skymodules.WindowedDistribution, jobTime time.Duration) {
+	if window != nil {
+		window.AddDataPoint(jobTime)
+	}
+}
+
+// managedReportCorruption counts a corrupt piece served by the worker's host
+// and puts both read queues on the max cooldown. A host serving corrupt data
+// is not just slow or unreachable, so it shouldn't be retried anytime soon.
+func (w *worker) managedReportCorruption(err error) {
+	atomic.AddUint64(&w.atomicCorruptPieces, 1)
+	w.staticJobReadQueue.callReportCorruption(err)
+	w.staticJobLowPrioReadQueue.callReportCorruption(err)
+	w.staticRenter.staticLog.Printf("Worker %v: %v", w.staticHostPubKeyStr, err)
+}
+
 // initJobReadQueue will initialize a queue for downloading sectors by
 // their root for the worker. This is only meant to be run once at startup.
 func (w *worker) initJobReadQueue(jrs *jobReadStats) {
@@ -27,7 +27,7 @@ func TestJobExpectedJobTime(t *testing.T) {
 	}
 
 	w := new(worker)
-	w.initJobReadQueue(&jobReadStats{})
+	w.initJobReadQueue(newJobReadStats())
 	jrq := w.staticJobReadQueue
 	for _, readLength := range []uint64{1 << 16, 1 << 20, 1 << 24} {
 		// update metrics couple of times, due to the decay the estimate might
@@ -49,6 +49,43 @@ func TestJobExpectedJobTime(t *testing.T) {
 	}
 }
 
+// TestJobReadStatsWindow verifies that the expected job time is based on the
+// recent window once it contains enough data points.
+func TestJobReadStatsWindow(t *testing.T) {
+	t.Parallel()
+
+	// Without a window, the weighted average is used.
+	jrs := &jobReadStats{weightedJobTime64k: float64(time.Second)}
+	if ejt := jrs.callExpectedJobTime(1 << 16); ejt != time.Second {
+		t.Fatal("unexpected", ejt)
+	}
+
+	// With too few data points in the window, the weighted average is used.
+	jrs = newJobReadStats()
+	jrs.weightedJobTime64k = float64(time.Second)
+	for i := 0; i < jobTimeWindowMinDataPoints-1; i++ {
+		jrs.recentJobTimes64k.AddDataPoint(100 * time.Millisecond)
+	}
+	if ejt := jrs.callExpectedJobTime(1 << 16); ejt != time.Second {
+		t.Fatal("unexpected", ejt)
+	}
+
+	// Once there are enough data points, the window is used.
+	jrs.recentJobTimes64k.AddDataPoint(100 * time.Millisecond)
+	if ejt := jrs.callExpectedJobTime(1 << 16); ejt != 100*time.Millisecond {
+		t.Fatal("unexpected", ejt)
+	}
+
+	// The other lengths are tracked separately.
+	if ejt := jrs.callExpectedJobTime(1 << 20); ejt != 0 {
+		t.Fatal("unexpected", ejt)
+	}
+	jrs.callUpdateJobTimeMetrics(1<<20, 200*time.Millisecond)
+	if jrs.recentJobTimes1m.DataPoints() == 0 || jrs.recentJobTimes64k.DataPoints() < jobTimeWindowMinDataPoints {
+		t.Fatal("data point wasn't added to the right window")
+	}
+}
+
 // TestJobReadMetadata verifies the job metadata is set on the job read response
 func TestJobReadMetadata(t *testing.T) {
 	if testing.Short() {
@@ -59,12 +59,16 @@ func (j *jobReadSector) managedReadSector() ([]byte, error) {
 	}
 	data := responses[0].Output
 	proof := responses[0].Proof
+	j.proof = proof
 
-	// verify proof
+	// verify proof unless the caller verifies it
+	if j.staticJobReadMetadata().staticSkipProofVerification {
+		return data, nil
+	}
 	proofStart := int(j.staticOffset) / crypto.SegmentSize
 	proofEnd := int(j.staticOffset+j.staticLength) / crypto.SegmentSize
 	if !crypto.VerifyRangeProof(data, proof, proofStart, proofEnd, j.staticSector) {
-		return nil, errors.New("proof verification failed")
+		return nil, errors.AddContext(errSectorCorrupted, "proof verification failed")
 	}
 	return data, nil
 }
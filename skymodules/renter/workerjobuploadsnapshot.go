@@ -52,20 +52,12 @@ type (
 // active settings for a host and determines whether a snapshot upload should be
 // halted due to price gouging.
 func checkUploadSnapshotGouging(allowance skymodules.Allowance, hostSettings modules.HostExternalSettings) error {
-	// Check whether the base RPC price is too high.
-	if !allowance.MaxRPCPrice.IsZero() && allowance.MaxRPCPrice.Cmp(hostSettings.BaseRPCPrice) < 0 {
-		errStr := fmt.Sprintf("rpc price of host is %v, which is above the maximum allowed by the allowance: %v", hostSettings.BaseRPCPrice, allowance.MaxRPCPrice)
-		return errors.New(errStr)
-	}
-	// Check whether the upload bandwidth price is too high.
-	if !allowance.MaxUploadBandwidthPrice.IsZero() && allowance.MaxUploadBandwidthPrice.Cmp(hostSettings.UploadBandwidthPrice) < 0 {
-		errStr := fmt.Sprintf("upload bandwidth price of host is %v, which is above the maximum allowed by the allowance: %v", hostSettings.UploadBandwidthPrice, allowance.MaxUploadBandwidthPrice)
-		return errors.New(errStr)
-	}
-	// Check whether the storage price is too high.
-	if !allowance.MaxStoragePrice.IsZero() && allowance.MaxStoragePrice.Cmp(hostSettings.StoragePrice) < 0 {
-		errStr := fmt.Sprintf("storage price of host is %v, which is above the maximum allowed by the allowance: %v", hostSettings.StoragePrice, allowance.MaxStoragePrice)
-		return errors.New(errStr)
+	// Check whether any of the prices relevant for uploading a snapshot
+	// exceed the limits of the allowance.
+	report := skymodules.NewGougingChecker(allowance).CheckHostSettings(hostSettings)
+	err := report.Err(skymodules.GougingFieldBaseRPCPrice, skymodules.GougingFieldUploadBandwidthPrice, skymodules.GougingFieldStoragePrice)
+	if err != nil {
+		return err
 	}
 
 	// If there is no allowance, general price gouging checks have to be
@@ -305,7 +297,7 @@ func (w *worker) UploadSnapshot(ctx context.Context, meta skymodules.UploadedBac
 		staticSiaFileData:  dotSia,
 		staticResponseChan: uploadSnapshotRespChan,
 
-		jobGeneric: newJobGeneric(ctx, w.staticJobUploadSnapshotQueue, meta),
+		jobGeneric: newJobGeneric(contextWithTrafficClass(ctx, skymodules.TrafficClassBackup), w.staticJobUploadSnapshotQueue, meta),
 	}
 
 	// Add the job to the queue.
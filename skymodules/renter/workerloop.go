@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"gitlab.com/SkynetLabs/skyd/build"
+	"go.sia.tech/siad/modules"
 
 	"gitlab.com/NebulousLabs/errors"
 )
@@ -102,6 +103,13 @@ func (w *worker) externTryLaunchSerialJob() {
 		return
 	}
 
+	// Don't launch any serial jobs to blocklisted hosts or while the worker
+	// is paused. Any queued jobs are dropped.
+	if err := w.staticJobsDisabledErr(); err != nil {
+		w.managedDiscardSerialJobs(err)
+		return
+	}
+
 	// Check every potential serial job that the worker may be required to
 	// perform. This scheduling allows a flood of jobs earlier in the list to
 	// starve out jobs later in the list. At some point we will probably
@@ -119,6 +127,10 @@ func (w *worker) externTryLaunchSerialJob() {
 		w.externLaunchSerialJob(w.managedRefillAccount)
 		return
 	}
+	if w.managedNeedsToBenchmark() {
+		w.externLaunchSerialJob(w.managedBenchmark)
+		return
+	}
 	job = w.staticJobUploadSnapshotQueue.callNext()
 	if job != nil {
 		w.externLaunchSerialJob(job.callExecute)
@@ -189,6 +201,13 @@ func (w *worker) managedAsyncReady() bool {
 		w.managedDiscardAsyncJobs(errors.New("the worker account is on cooldown"))
 		return false
 	}
+
+	// The host must not be on the worker blocklist and the worker must not
+	// be paused.
+	if err := w.staticJobsDisabledErr(); err != nil {
+		w.managedDiscardAsyncJobs(err)
+		return false
+	}
 	return true
 }
 
@@ -227,16 +246,21 @@ func (w *worker) externTryLaunchAsyncJob() bool {
 		return true
 	}
 
-	// Check every potential async job that can be launched.
-	job := w.staticJobHasSectorQueue.callNext()
-	if job != nil {
-		w.externLaunchAsyncJob(job)
-		return true
+	// Check every potential async job that can be launched. Jobs of a queue
+	// are only launched if the price table doesn't expire before they are
+	// expected to finish. Otherwise they remain queued until the price table
+	// was updated.
+	if w.staticPriceTableValidForJob(w.staticJobHasSectorQueue.callExpectedJobTime()) {
+		job := w.staticJobHasSectorQueue.callNext()
+		if job != nil {
+			w.externLaunchAsyncJob(job)
+			return true
+		}
 	}
 	// Check if registry jobs are supported.
 	cache := w.staticCache()
-	if build.VersionCmp(cache.staticHostVersion, minRegistryVersion) >= 0 {
-		job = w.staticJobUpdateRegistryQueue.callNext()
+	if build.VersionCmp(cache.staticHostVersion, minRegistryVersion) >= 0 && w.staticPriceTableValidForJob(w.ReadRegCutoffEstimate()) {
+		job := w.staticJobUpdateRegistryQueue.callNext()
 		if job != nil {
 			w.externLaunchAsyncJob(job)
 			return true
@@ -247,15 +271,19 @@ func (w *worker) externTryLaunchAsyncJob() bool {
 			return true
 		}
 	}
-	job = w.staticJobReadQueue.callNext()
-	if job != nil {
-		w.externLaunchAsyncJob(job)
-		return true
-	}
-	job = w.staticJobLowPrioReadQueue.callNext()
-	if job != nil {
-		w.externLaunchAsyncJob(job)
-		return true
+	// The read queues share their stats. Use the estimate for a full sector
+	// since the length of the next job is unknown.
+	if w.staticPriceTableValidForJob(w.staticJobReadQueue.staticStats.callExpectedJobTime(modules.SectorSize)) {
+		job := w.staticJobReadQueue.callNext()
+		if job != nil {
+			w.externLaunchAsyncJob(job)
+			return true
+		}
+		job = w.staticJobLowPrioReadQueue.callNext()
+		if job != nil {
+			w.externLaunchAsyncJob(job)
+			return true
+		}
 	}
 	return false
 }
@@ -287,6 +315,15 @@ func (w *worker) managedDiscardAsyncJobs(err error) {
 	w.staticJobLowPrioReadQueue.callDiscardAll(err)
 }
 
+// managedDiscardSerialJobs will drop all of the worker's serial jobs because
+// the worker is not allowed to perform them.
+func (w *worker) managedDiscardSerialJobs(err error) {
+	w.staticJobRenewQueue.callDiscardAll(err)
+	w.staticJobDownloadSnapshotQueue.callDiscardAll(err)
+	w.staticJobUploadSnapshotQueue.callDiscardAll(err)
+	w.managedDropUploadChunks()
+}
+
 // threadedWorkLoop is a perpetual loop run by the worker that accepts new jobs
 // and performs them. Work is divided into two types of work, serial work and
 // async work. Serial work requires exclusive access to the worker's contract,
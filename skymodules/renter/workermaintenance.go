@@ -55,8 +55,9 @@ func (wms *workerMaintenanceState) maintenanceSucceeded() bool {
 
 // managedMaintenanceCooldownStatus is a helper function that returns
 // information about the maintenance cooldown. It returns whether the worker is
-// on cooldown, the recent error string and the cooldown duration.
-func (wms *workerMaintenanceState) managedMaintenanceCooldownStatus() (bool, time.Duration, error) {
+// on cooldown, the cooldown duration, the number of consecutive failures and the
+// recent error.
+func (wms *workerMaintenanceState) managedMaintenanceCooldownStatus() (bool, time.Duration, uint64, error) {
 	wms.mu.Lock()
 	defer wms.mu.Unlock()
 
@@ -66,7 +67,7 @@ func (wms *workerMaintenanceState) managedMaintenanceCooldownStatus() (bool, tim
 		cdDuration = wms.cooldownUntil.Sub(time.Now())
 	}
 
-	return onCooldown, cdDuration, wms.recentErr
+	return onCooldown, cdDuration, wms.consecutiveFailures, wms.recentErr
 }
 
 // managedResetMaintenanceCooldown resets the worker's cooldown after a
@@ -79,6 +80,16 @@ func (wms *workerMaintenanceState) tryResetMaintenanceCooldown() time.Time {
 	return wms.cooldownUntil
 }
 
+// managedForceResetMaintenanceCooldown takes the worker off maintenance
+// cooldown regardless of whether the maintenance tasks succeeded. A task that
+// keeps failing will put the worker back on cooldown.
+func (wms *workerMaintenanceState) managedForceResetMaintenanceCooldown() {
+	wms.mu.Lock()
+	defer wms.mu.Unlock()
+	wms.consecutiveFailures = 0
+	wms.cooldownUntil = time.Time{}
+}
+
 // managedMaintenanceRecentError is a helper function that returns the recent
 // maintenance error
 func (w *worker) managedMaintenanceRecentError() error {
@@ -152,6 +152,10 @@ func (wp *workerPool) callUpdate() {
 		setChanged = true
 		delete(wp.workers, id)
 
+		// Unregister the account alerts of the worker.
+		wp.staticRenter.staticAlerter.UnregisterAlert(alertIDWorkerAccountRefillFailed(worker.staticHostPubKeyStr))
+		wp.staticRenter.staticAlerter.UnregisterAlert(alertIDWorkerAccountExcessiveBalance(worker.staticHostPubKeyStr))
+
 		// Kill the worker in a goroutine. This avoids locking issues, as
 		// wp.mu is currently locked.
 		go worker.managedKill()
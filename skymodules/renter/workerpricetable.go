@@ -172,6 +172,15 @@ func (wpt *workerPriceTable) staticValidFor(duration time.Duration) bool {
 	return minExpiry.Before(wpt.staticExpiryTime)
 }
 
+// staticAge returns the amount of time since the price table was fetched from
+// the host. A price table which was never fetched has an age of 0.
+func (wpt *workerPriceTable) staticAge() time.Duration {
+	if wpt.staticExpiryTime.IsZero() {
+		return 0
+	}
+	return time.Since(wpt.staticExpiryTime.Add(-wpt.staticPriceTable.Validity))
+}
+
 // staticNeedsToUpdate returns whether or not the price table needs to be
 // updated.
 func (wpt *workerPriceTable) staticNeedsToUpdate() bool {
@@ -271,7 +280,7 @@ func (w *worker) staticUpdatePriceTable() {
 	}()
 
 	// Get a stream.
-	stream, err := w.staticNewStream()
+	stream, err := w.staticNewStream(skymodules.TrafficClassInteractive)
 	if err != nil {
 		err = errors.AddContext(err, "unable to create new stream")
 		return
@@ -357,13 +366,12 @@ func (w *worker) staticUpdatePriceTable() {
 		return
 	}
 
-	// Calculate the expiry time and set the update time to be half of the
-	// expiry window to ensure we update the PT before it expires
+	// Calculate the expiry time and set the update time to be the configured
+	// fraction of the expiry window to ensure we update the PT before it
+	// expires.
 	now := time.Now()
 	expiryTime := now.Add(pt.Validity)
-	expiryHalfTimeInS := (expiryTime.Unix() - now.Unix()) / 2
-	expiryHalfTime := time.Duration(expiryHalfTimeInS) * time.Second
-	newUpdateTime := time.Now().Add(expiryHalfTime)
+	newUpdateTime := now.Add(priceTableUpdateDelay(pt.Validity, w.staticRenter.managedPriceTableUpdateFraction()))
 
 	// Update the price table. We preserve the recent error even though there
 	// has not been an error for debugging purposes, if there has been an error
@@ -379,6 +387,29 @@ func (w *worker) staticUpdatePriceTable() {
 	w.staticSetPriceTable(wpt)
 }
 
+// priceTableUpdateDelay returns the amount of time after which a price table
+// with the given validity should be updated. The delay is truncated to seconds.
+func priceTableUpdateDelay(validity time.Duration, fraction float64) time.Duration {
+	delay := time.Duration(float64(validity) * fraction)
+	return delay.Truncate(time.Second)
+}
+
+// staticPriceTableValidForJob returns true if the price table remains valid
+// for the expected duration of a job. If it doesn't, an update of the price
+// table is scheduled so that the job can be launched after the update. Jobs
+// which are expected to take longer than the host's price table validity are
+// allowed since updating the price table wouldn't help.
+func (w *worker) staticPriceTableValidForJob(expectedDuration time.Duration) bool {
+	wpt := w.staticPriceTable()
+	if wpt.staticValidFor(expectedDuration) || expectedDuration >= wpt.staticPriceTable.Validity {
+		return true
+	}
+	if !wpt.staticNeedsToUpdate() {
+		w.staticSchedulePriceTableUpdate(false)
+	}
+	return false
+}
+
 // checkUpdatePriceTableGouging verifies the cost of updating the price table is
 // reasonable, if deemed unreasonable we will reject it and this worker will be
 // put into cooldown.
@@ -217,7 +217,7 @@ func TestSchedulePriceTableUpdate(t *testing.T) {
 	cost = cost.Add(bandwidthCost)
 
 	// execute it
-	_, _, err = w.managedExecuteProgram(p, data, types.FileContractID{}, categoryDownload, cost)
+	_, _, err = w.managedExecuteProgram(p, data, types.FileContractID{}, categoryDownload, skymodules.TrafficClassInteractive, cost)
 	if !modules.IsPriceTableInvalidErr(err) {
 		t.Fatal("unexpected")
 	}
@@ -242,7 +242,7 @@ func TestSchedulePriceTableUpdate(t *testing.T) {
 	deps.Disable()
 
 	// execute the same program
-	_, _, err = w.managedExecuteProgram(p, data, types.FileContractID{}, categoryDownload, cost)
+	_, _, err = w.managedExecuteProgram(p, data, types.FileContractID{}, categoryDownload, skymodules.TrafficClassInteractive, cost)
 	if err != nil {
 		t.Fatal("unexpected")
 	}
@@ -324,3 +324,77 @@ func newDefaultPriceTable() modules.RPCPriceTable {
 		WriteStoreCost:  oneCurrency,
 	}
 }
+
+// TestPriceTableUpdateDelay is a unit test for priceTableUpdateDelay.
+func TestPriceTableUpdateDelay(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		validity time.Duration
+		fraction float64
+		delay    time.Duration
+	}{
+		{validity: 10 * time.Minute, fraction: 0.5, delay: 5 * time.Minute},
+		{validity: 10 * time.Minute, fraction: 0.25, delay: 150 * time.Second},
+		{validity: 10 * time.Minute, fraction: 0, delay: 0},
+		{validity: 3 * time.Second, fraction: 0.5, delay: time.Second},
+		{validity: 1500 * time.Millisecond, fraction: 0.5, delay: 0},
+	}
+	for _, test := range tests {
+		delay := priceTableUpdateDelay(test.validity, test.fraction)
+		if delay != test.delay {
+			t.Errorf("validity %v fraction %v: expected %v but got %v", test.validity, test.fraction, test.delay, delay)
+		}
+	}
+}
+
+// TestPriceTableValidForJob is a unit test for staticPriceTableValidForJob.
+func TestPriceTableValidForJob(t *testing.T) {
+	t.Parallel()
+
+	// Create a worker with a price table that was fetched 30 seconds ago and
+	// which remains valid for another 30 seconds.
+	w := &worker{wakeChan: make(chan struct{}, 1)}
+	wpt := &workerPriceTable{
+		staticExpiryTime: time.Now().Add(30 * time.Second),
+		staticUpdateTime: time.Now().Add(time.Hour),
+	}
+	wpt.staticPriceTable.Validity = time.Minute
+	w.staticSetPriceTable(wpt)
+
+	// Check the age.
+	if age := w.staticPriceTable().staticAge(); age < 30*time.Second || age > 31*time.Second {
+		t.Fatal("unexpected age", age)
+	}
+	if age := (&workerPriceTable{}).staticAge(); age != 0 {
+		t.Fatal("unexpected age", age)
+	}
+
+	// A short job is allowed and doesn't schedule an update.
+	if !w.staticPriceTableValidForJob(time.Second) {
+		t.Fatal("expected short job to be allowed")
+	}
+	if w.staticPriceTable().staticNeedsToUpdate() {
+		t.Fatal("expected no update to be scheduled")
+	}
+
+	// A job which would outlive the price table is held back and schedules
+	// an update.
+	if w.staticPriceTableValidForJob(45 * time.Second) {
+		t.Fatal("expected job to be held back")
+	}
+	if !w.staticPriceTable().staticNeedsToUpdate() {
+		t.Fatal("expected an update to be scheduled")
+	}
+	select {
+	case <-w.wakeChan:
+	default:
+		t.Fatal("expected worker to be woken up")
+	}
+
+	// A job which takes longer than the validity of any price table is
+	// allowed.
+	if !w.staticPriceTableValidForJob(2 * time.Minute) {
+		t.Fatal("expected long job to be allowed")
+	}
+}
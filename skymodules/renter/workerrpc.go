@@ -45,8 +45,9 @@ type programResponse struct {
 	Output []byte
 }
 
-// managedExecuteProgram performs the ExecuteProgramRPC on the host
-func (w *worker) managedExecuteProgram(p modules.Program, data []byte, fcid types.FileContractID, category spendingCategory, cost types.Currency) (responses []programResponse, limit mux.BandwidthLimit, err error) {
+// managedExecuteProgram performs the ExecuteProgramRPC on the host. The traffic
+// of the program is ratelimited according to the given traffic class.
+func (w *worker) managedExecuteProgram(p modules.Program, data []byte, fcid types.FileContractID, category spendingCategory, class skymodules.TrafficClass, cost types.Currency) (responses []programResponse, limit mux.BandwidthLimit, err error) {
 	// Defer a function that schedules a price table update in case we received
 	// an error that indicates the host deems our price table invalid.
 	defer func() {
@@ -64,7 +65,7 @@ func (w *worker) managedExecuteProgram(p modules.Program, data []byte, fcid type
 	}()
 
 	// create a new stream
-	stream, err := w.staticNewStream()
+	stream, err := w.staticNewStream(class)
 	if err != nil {
 		err = errors.AddContext(err, "Unable to create a new stream")
 		return
@@ -161,8 +162,9 @@ func (w *worker) managedExecuteProgram(p modules.Program, data []byte, fcid type
 	return
 }
 
-// staticNewStream returns a new stream to the worker's host
-func (w *worker) staticNewStream() (siamux.Stream, error) {
+// staticNewStream returns a new stream to the worker's host. The stream is
+// ratelimited according to the given traffic class.
+func (w *worker) staticNewStream(class skymodules.TrafficClass) (siamux.Stream, error) {
 	// If disrupt is called we sleep for the specified 'defaultNewStreamTimeout'
 	// simulating how an unreachable host would behave in production.
 	timeout := defaultNewStreamTimeout
@@ -171,22 +173,36 @@ func (w *worker) staticNewStream() (siamux.Stream, error) {
 		return nil, errors.New("InterruptNewStreamTimeout")
 	}
 
-	// Create a stream with a reasonable dial up timeout.
-	stream, err := w.staticRenter.staticMux.NewStreamTimeout(modules.HostSiaMuxSubscriberName, w.staticCache().staticHostMuxAddress, timeout, modules.SiaPKToMuxPK(w.staticHostPubKey))
-	if err != nil {
-		return nil, err
+	// Take an idle stream from the pool or create a new one with a reasonable
+	// dial up timeout.
+	address := w.staticCache().staticHostMuxAddress
+	stream := w.staticStreamPool.managedTake(address)
+	if stream == nil {
+		var err error
+		stream, err = w.staticDialStream(address)
+		if err != nil {
+			return nil, err
+		}
 	}
+	// Replace the stream we took in the background.
+	w.staticTryRefillStreamPool(address)
+
 	// Set deadline on the stream.
-	err = stream.SetDeadline(time.Now().Add(defaultRPCDeadline))
+	err := stream.SetDeadline(time.Now().Add(defaultRPCDeadline))
 	if err != nil {
 		return nil, err
 	}
 
-	// Wrap the stream in the renter's ratelimit
+	// Track errors of the underlying mux in the worker's connection state.
+	stream = w.newDiagnosticStream(stream)
+
+	// Wrap the stream in the ratelimit of its traffic class and the renter's
+	// ratelimit.
 	//
 	// NOTE: this only ratelimits the data going over the stream and not the raw
 	// bytes going over the wire, so the ratelimit might be off by a few bytes.
-	rlStream := ratelimit.NewRLStream(stream, w.staticRenter.staticRL, w.staticRenter.tg.StopChan())
+	rlStream := ratelimit.NewRLStream(stream, w.staticRenter.staticTrafficRLs.staticRateLimit(class), w.staticRenter.tg.StopChan())
+	rlStream = ratelimit.NewRLStream(rlStream, w.staticRenter.staticRL, w.staticRenter.tg.StopChan())
 
 	// Wrap the stream in global ratelimit.
 	return ratelimit.NewRLStream(rlStream, skymodules.GlobalRateLimits, w.staticRenter.tg.StopChan()), nil
@@ -203,7 +219,7 @@ func (w *worker) managedRenew(fcid types.FileContractID, params skymodules.Contr
 	}()
 
 	// create a new stream
-	stream, err := w.staticNewStream()
+	stream, err := w.staticNewStream(skymodules.TrafficClassInteractive)
 	if err != nil {
 		return skymodules.RenterContract{}, nil, errors.AddContext(err, "managedRenew: unable to create a new stream")
 	}
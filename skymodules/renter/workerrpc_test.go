@@ -7,6 +7,7 @@ import (
 
 	"gitlab.com/NebulousLabs/encoding"
 	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
@@ -60,7 +61,7 @@ func TestUseHostBlockHeight(t *testing.T) {
 	cost = cost.Add(bandwidthCost)
 
 	// execute the program
-	_, _, err = w.managedExecuteProgram(p, data, types.FileContractID{}, categoryDownload, cost)
+	_, _, err = w.managedExecuteProgram(p, data, types.FileContractID{}, categoryDownload, skymodules.TrafficClassInteractive, cost)
 	if err == nil || !strings.Contains(err.Error(), "ephemeral account withdrawal message expires too far into the future") {
 		t.Fatal("Unexpected error", err)
 	}
@@ -80,7 +81,7 @@ func TestUseHostBlockHeight(t *testing.T) {
 	w.staticSetPriceTable(wptc)
 
 	// execute the program
-	_, _, err = w.managedExecuteProgram(p, data, types.FileContractID{}, categoryDownload, cost)
+	_, _, err = w.managedExecuteProgram(p, data, types.FileContractID{}, categoryDownload, skymodules.TrafficClassInteractive, cost)
 	if err != nil {
 		t.Fatal("Unexpected error", err)
 	}
@@ -135,7 +136,7 @@ func testExecuteProgramUsedBandwidthHasSector(t *testing.T, wt *workerTester) {
 	cost = cost.Add(bandwidthCost)
 
 	// execute it
-	_, limit, err := w.managedExecuteProgram(p, data, types.FileContractID{}, categoryDownload, cost)
+	_, limit, err := w.managedExecuteProgram(p, data, types.FileContractID{}, categoryDownload, skymodules.TrafficClassInteractive, cost)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -189,7 +190,7 @@ func testExecuteProgramUsedBandwidthReadSector(t *testing.T, wt *workerTester) {
 	cost = cost.Add(bandwidthCost)
 
 	// execute it
-	_, limit, err := w.managedExecuteProgram(p, data, types.FileContractID{}, categoryDownload, cost)
+	_, limit, err := w.managedExecuteProgram(p, data, types.FileContractID{}, categoryDownload, skymodules.TrafficClassInteractive, cost)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1,6 +1,7 @@
 package renter
 
 import (
+	"sync/atomic"
 	"time"
 
 	"gitlab.com/SkynetLabs/skyd/skymodules"
@@ -13,6 +14,7 @@ func (w *worker) callStatus() skymodules.WorkerStatus {
 	downloadOnCoolDown := downloadQueue.onCooldown()
 	downloadTerminated := downloadQueue.killed
 	downloadQueueSize := downloadQueue.jobs.Len()
+	downloadConsecutiveFailures := downloadQueue.consecutiveFailures
 	downloadCoolDownTime := downloadQueue.cooldownUntil.Sub(time.Now())
 
 	var downloadCoolDownErr string
@@ -21,6 +23,8 @@ func (w *worker) callStatus() skymodules.WorkerStatus {
 	}
 	downloadQueue.mu.Unlock()
 
+	balanceTarget, refillThreshold := w.managedAccountRefillTargets()
+
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
@@ -30,7 +34,7 @@ func (w *worker) callStatus() skymodules.WorkerStatus {
 		uploadCoolDownErr = w.uploadRecentFailureErr.Error()
 	}
 
-	maintenanceOnCooldown, maintenanceCoolDownTime, maintenanceCoolDownErr := w.staticMaintenanceState.managedMaintenanceCooldownStatus()
+	maintenanceOnCooldown, maintenanceCoolDownTime, maintenanceConsecutiveFailures, maintenanceCoolDownErr := w.staticMaintenanceState.managedMaintenanceCooldownStatus()
 	var mcdErr string
 	if maintenanceCoolDownErr != nil {
 		mcdErr = maintenanceCoolDownErr.Error()
@@ -46,35 +50,48 @@ func (w *worker) callStatus() skymodules.WorkerStatus {
 		HostPubKey:      w.staticHostPubKey,
 
 		// Download information
-		DownloadCoolDownError: downloadCoolDownErr,
-		DownloadCoolDownTime:  downloadCoolDownTime,
-		DownloadOnCoolDown:    downloadOnCoolDown,
-		DownloadQueueSize:     downloadQueueSize,
-		DownloadTerminated:    downloadTerminated,
+		DownloadConsecutiveFailures: downloadConsecutiveFailures,
+		DownloadCoolDownError:       downloadCoolDownErr,
+		DownloadCoolDownTime:        downloadCoolDownTime,
+		DownloadOnCoolDown:          downloadOnCoolDown,
+		DownloadQueueSize:           downloadQueueSize,
+		DownloadTerminated:          downloadTerminated,
 
 		// Upload information
-		UploadCoolDownError: uploadCoolDownErr,
-		UploadCoolDownTime:  uploadCoolDownTime,
-		UploadOnCoolDown:    uploadOnCoolDown,
-		UploadQueueSize:     w.unprocessedChunks.Len(),
-		UploadTerminated:    w.uploadTerminated,
+		UploadConsecutiveFailures: uint64(w.uploadConsecutiveFailures),
+		UploadCoolDownError:       uploadCoolDownErr,
+		UploadCoolDownTime:        uploadCoolDownTime,
+		UploadOnCoolDown:          uploadOnCoolDown,
+		UploadQueueSize:           w.unprocessedChunks.Len(),
+		UploadTerminated:          w.uploadTerminated,
 
 		// Job Queues
 		DownloadSnapshotJobQueueSize: int(w.staticJobDownloadSnapshotQueue.callStatus().size),
 		UploadSnapshotJobQueueSize:   int(w.staticJobUploadSnapshotQueue.callStatus().size),
 
 		// Maintenance Cooldown Information
-		MaintenanceOnCooldown:    maintenanceOnCooldown,
-		MaintenanceCoolDownError: mcdErr,
-		MaintenanceCoolDownTime:  maintenanceCoolDownTime,
+		MaintenanceOnCooldown:          maintenanceOnCooldown,
+		MaintenanceConsecutiveFailures: maintenanceConsecutiveFailures,
+		MaintenanceCoolDownError:       mcdErr,
+		MaintenanceCoolDownTime:        maintenanceCoolDownTime,
 
 		// Account Information
-		AccountBalanceTarget: w.staticBalanceTarget,
-		AccountStatus:        w.staticAccount.managedStatus(),
+		AccountBalanceTarget:   balanceTarget,
+		AccountRefillThreshold: refillThreshold,
+		AccountStatus:          w.staticAccount.managedStatus(),
 
 		// Price Table Information
 		PriceTableStatus: w.staticPriceTableStatus(),
 
+		// Benchmark Information
+		BenchmarkStatus: w.staticBenchmarkState.managedStatus(),
+
+		// Stream Pool Information
+		StreamPoolStatus: w.staticStreamPool.managedStatus(),
+
+		// Connection Information
+		ConnectionStatus: w.staticConnectionState.managedStatus(),
+
 		// Read Job Information
 		ReadJobsStatus: w.callReadJobStatus(),
 
@@ -102,6 +119,9 @@ func (w *worker) staticPriceTableStatus() skymodules.WorkerPriceTableStatus {
 		ExpiryTime: pt.staticExpiryTime,
 		UpdateTime: pt.staticUpdateTime,
 
+		Age:      pt.staticAge(),
+		Validity: pt.staticPriceTable.Validity,
+
 		Active: time.Now().Before(pt.staticExpiryTime),
 
 		RecentErr:     recentErrStr,
@@ -130,8 +150,11 @@ func (w *worker) callReadJobStatus() skymodules.WorkerReadJobsStatus {
 		AvgJobTime64k:       avgJobTimeInMs(1 << 16),
 		AvgJobTime1m:        avgJobTimeInMs(1 << 20),
 		AvgJobTime4m:        avgJobTimeInMs(1 << 22),
+		CircuitBreaker:      status.circuitBreakerStatus(),
 		ConsecutiveFailures: status.consecutiveFailures,
+		CorruptPieces:       atomic.LoadUint64(&w.atomicCorruptPieces),
 		JobQueueSize:        status.size,
+		ShedJobs:            status.shedJobs,
 		RecentErr:           recentErrString,
 		RecentErrTime:       status.recentErrTime,
 	}
@@ -151,8 +174,10 @@ func (w *worker) callHasSectorJobStatus() skymodules.WorkerHasSectorJobsStatus {
 
 	return skymodules.WorkerHasSectorJobsStatus{
 		AvgJobTime:          avgJobTimeInMs,
+		CircuitBreaker:      status.circuitBreakerStatus(),
 		ConsecutiveFailures: status.consecutiveFailures,
 		JobQueueSize:        status.size,
+		ShedJobs:            status.shedJobs,
 		RecentErr:           recentErrStr,
 		RecentErrTime:       status.recentErrTime,
 	}
@@ -168,8 +193,10 @@ func callGenericWorkerJobStatus(queue *jobGenericQueue) skymodules.WorkerGeneric
 	}
 
 	return skymodules.WorkerGenericJobsStatus{
+		CircuitBreaker:      status.circuitBreakerStatus(),
 		ConsecutiveFailures: status.consecutiveFailures,
 		JobQueueSize:        status.size,
+		ShedJobs:            status.shedJobs,
 		OnCooldown:          time.Now().Before(status.cooldownUntil),
 		OnCooldownUntil:     status.cooldownUntil,
 		RecentErr:           recentErrStr,
@@ -0,0 +1,213 @@
+package renter
+
+import (
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/siamux"
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// workerStreamPoolMaxIdle is the maximum number of idle streams a worker
+	// keeps open to its host.
+	workerStreamPoolMaxIdle = 4
+)
+
+var (
+	// workerStreamPoolIdleTimeout is the amount of time an idle stream is
+	// kept in the pool before it is closed. It needs to be shorter than
+	// defaultNewStreamTimeout since the subscriber handshake of a stream
+	// times out after that.
+	workerStreamPoolIdleTimeout = build.Select(build.Var{
+		Dev:      30 * time.Second,
+		Standard: 2 * time.Minute,
+		Testing:  3 * time.Second,
+	}).(time.Duration)
+)
+
+type (
+	// workerStreamPool is a pool of idle streams to the worker's host. The
+	// host closes a stream after handling a single RPC so streams can't be
+	// reused across jobs. Instead the pool opens streams ahead of time on the
+	// worker's mux. That way jobs don't need to wait for a stream to be
+	// opened and the mux to the host is kept alive while the worker is in
+	// use, which avoids the handshake of dialing the host again.
+	workerStreamPool struct {
+		idle      []pooledStream
+		refilling bool
+
+		dialFailures uint64
+		expired      uint64
+		hits         uint64
+		misses       uint64
+
+		mu sync.Mutex
+	}
+
+	// pooledStream is an idle stream within the workerStreamPool.
+	pooledStream struct {
+		address string
+		created time.Time
+		stream  siamux.Stream
+	}
+)
+
+// newStreamPool initializes the worker's stream pool.
+func (w *worker) newStreamPool() {
+	w.staticStreamPool = &workerStreamPool{
+		idle: make([]pooledStream, 0, workerStreamPoolMaxIdle),
+	}
+}
+
+// callPrune removes streams from the pool which expired or were opened to a
+// different address than the provided one. It returns the removed streams
+// which need to be closed by the caller.
+func (p *workerStreamPool) callPrune(address string) []siamux.Stream {
+	var removed []siamux.Stream
+	idle := p.idle[:0]
+	for _, ps := range p.idle {
+		if ps.address != address || time.Since(ps.created) > workerStreamPoolIdleTimeout {
+			removed = append(removed, ps.stream)
+			p.expired++
+			continue
+		}
+		idle = append(idle, ps)
+	}
+	p.idle = idle
+	return removed
+}
+
+// managedClose closes all idle streams of the pool.
+func (p *workerStreamPool) managedClose() error {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	var err error
+	for _, ps := range idle {
+		err = errors.Compose(err, ps.stream.Close())
+	}
+	return err
+}
+
+// managedStatus returns the status of the stream pool.
+func (p *workerStreamPool) managedStatus() skymodules.WorkerStreamPoolStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return skymodules.WorkerStreamPoolStatus{
+		IdleStreams:    uint64(len(p.idle)),
+		MaxIdleStreams: workerStreamPoolMaxIdle,
+
+		DialFailures: p.dialFailures,
+		Expired:      p.expired,
+		Hits:         p.hits,
+		Misses:       p.misses,
+	}
+}
+
+// managedTake takes an idle stream to the given address from the pool. If
+// there is none, nil is returned.
+func (p *workerStreamPool) managedTake(address string) siamux.Stream {
+	p.mu.Lock()
+	removed := p.callPrune(address)
+	var stream siamux.Stream
+	if n := len(p.idle); n > 0 {
+		stream = p.idle[n-1].stream
+		p.idle = p.idle[:n-1]
+		p.hits++
+	} else {
+		p.misses++
+	}
+	p.mu.Unlock()
+
+	closeStreams(removed)
+	return stream
+}
+
+// closeStreams closes the given streams, ignoring any errors.
+func closeStreams(streams []siamux.Stream) {
+	for _, stream := range streams {
+		_ = stream.Close()
+	}
+}
+
+// staticDialStream opens a new stream to the given address of the worker's
+// host.
+func (w *worker) staticDialStream(address string) (siamux.Stream, error) {
+	start := time.Now()
+	stream, err := w.staticRenter.staticMux.NewStreamTimeout(modules.HostSiaMuxSubscriberName, address, defaultNewStreamTimeout, modules.SiaPKToMuxPK(w.staticHostPubKey))
+	w.staticConnectionState.managedTrackStreamOpen(time.Since(start), err)
+	if err != nil {
+		p := w.staticStreamPool
+		p.mu.Lock()
+		p.dialFailures++
+		p.mu.Unlock()
+		return nil, err
+	}
+	return stream, nil
+}
+
+// staticTryRefillStreamPool refills the stream pool in the background if it
+// isn't full and not already being refilled.
+func (w *worker) staticTryRefillStreamPool(address string) {
+	p := w.staticStreamPool
+	p.mu.Lock()
+	if p.refilling || len(p.idle) >= workerStreamPoolMaxIdle {
+		p.mu.Unlock()
+		return
+	}
+	p.refilling = true
+	p.mu.Unlock()
+
+	// NOTE: The refill isn't part of the worker's threadgroup since dialing
+	// an unreachable host can take up to defaultNewStreamTimeout, which would
+	// block killing the worker for as long. Streams opened after the worker
+	// was killed are closed right away instead.
+	go w.threadedRefillStreamPool(address)
+}
+
+// threadedRefillStreamPool opens new streams to the given address until the
+// stream pool is full.
+func (w *worker) threadedRefillStreamPool(address string) {
+	p := w.staticStreamPool
+	defer func() {
+		p.mu.Lock()
+		p.refilling = false
+		p.mu.Unlock()
+	}()
+
+	for {
+		p.mu.Lock()
+		removed := p.callPrune(address)
+		full := len(p.idle) >= workerStreamPoolMaxIdle
+		p.mu.Unlock()
+		closeStreams(removed)
+		if full || w.staticKilled() {
+			return
+		}
+
+		stream, err := w.staticDialStream(address)
+		if err != nil {
+			return
+		}
+
+		// Add the stream unless the worker was killed in the meantime.
+		p.mu.Lock()
+		if w.staticKilled() {
+			p.mu.Unlock()
+			_ = stream.Close()
+			return
+		}
+		p.idle = append(p.idle, pooledStream{
+			address: address,
+			created: time.Now(),
+			stream:  stream,
+		})
+		p.mu.Unlock()
+	}
+}
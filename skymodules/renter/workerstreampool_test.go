@@ -0,0 +1,119 @@
+package renter
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
+)
+
+// TestWorkerStreamPool verifies that a worker keeps idle streams to its host
+// around and uses them for its RPCs.
+func TestWorkerStreamPool(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	wt, err := newWorkerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	p := wt.staticStreamPool
+	address := wt.staticCache().staticHostMuxAddress
+
+	// The worker already performed RPCs so the pool should fill up.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		status := p.managedStatus()
+		if status.IdleStreams != workerStreamPoolMaxIdle {
+			return fmt.Errorf("pool not full %v < %v", status.IdleStreams, workerStreamPoolMaxIdle)
+		}
+		if status.Misses == 0 {
+			return fmt.Errorf("expected misses for the first RPCs")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Opening a new stream should use a stream from the pool.
+	before := p.managedStatus()
+	stream, err := wt.staticNewStream(skymodules.TrafficClassInteractive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	after := p.managedStatus()
+	if after.Hits <= before.Hits {
+		t.Fatal("expected a hit", before.Hits, after.Hits)
+	}
+	if after.DialFailures != 0 {
+		t.Fatal("unexpected dial failures", after.DialFailures)
+	}
+
+	// Expire the idle streams. Taking a stream should prune them and miss.
+	p.mu.Lock()
+	for i := range p.idle {
+		p.idle[i].created = time.Now().Add(-2 * workerStreamPoolIdleTimeout)
+	}
+	numIdle := uint64(len(p.idle))
+	p.mu.Unlock()
+	before = p.managedStatus()
+	if stream := p.managedTake(address); stream != nil {
+		t.Fatal("expected no stream")
+	}
+	after = p.managedStatus()
+	if after.Expired != before.Expired+numIdle {
+		t.Fatal("wrong number of expired streams", after.Expired, before.Expired+numIdle)
+	}
+	if after.Misses != before.Misses+1 {
+		t.Fatal("expected a miss", after.Misses, before.Misses)
+	}
+
+	// Streams to a different address are pruned as well.
+	wt.staticTryRefillStreamPool(address)
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if n := p.managedStatus().IdleStreams; n != workerStreamPoolMaxIdle {
+			return fmt.Errorf("pool not full %v < %v", n, workerStreamPoolMaxIdle)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stream := p.managedTake("otheraddress"); stream != nil {
+		t.Fatal("expected no stream")
+	}
+	if n := p.managedStatus().IdleStreams; n != 0 {
+		t.Fatal("expected pool to be empty", n)
+	}
+
+	// Killing the worker closes the pool.
+	wt.staticTryRefillStreamPool(address)
+	wt.managedKill()
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		p.mu.Lock()
+		refilling := p.refilling
+		p.mu.Unlock()
+		if refilling {
+			return fmt.Errorf("pool is still refilling")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := p.managedStatus().IdleStreams; n != 0 {
+		t.Fatal("expected pool to be empty", n)
+	}
+}
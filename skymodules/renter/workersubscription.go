@@ -15,6 +15,7 @@ import (
 	"gitlab.com/NebulousLabs/siamux"
 	"gitlab.com/NebulousLabs/threadgroup"
 	"gitlab.com/SkynetLabs/skyd/build"
+	"gitlab.com/SkynetLabs/skyd/skymodules"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
@@ -719,7 +720,7 @@ func (w *worker) managedPriceTableForSubscription(duration time.Duration) *modul
 // managedBeginSubscription begins a subscription on a new stream and returns
 // it.
 func (w *worker) managedBeginSubscription(initialBudget types.Currency, fundAcc modules.AccountID, subscriber types.Specifier) (_ siamux.Stream, err error) {
-	stream, err := w.staticNewStream()
+	stream, err := w.staticNewStream(skymodules.TrafficClassInteractive)
 	if err != nil {
 		return nil, errors.AddContext(err, "managedBeginSubscription: failed to create stream")
 	}
@@ -11,7 +11,6 @@ import (
 
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/modules"
-	"go.sia.tech/siad/types"
 )
 
 const (
@@ -30,30 +29,12 @@ const (
 // worker gains more modification actions on the host, this check can be split
 // into different checks that vary based on the operation being performed.
 func checkUploadGouging(allowance skymodules.Allowance, hostSettings modules.HostExternalSettings) error {
-	// Check whether the base RPC price is too high.
-	if !allowance.MaxRPCPrice.IsZero() && allowance.MaxRPCPrice.Cmp(hostSettings.BaseRPCPrice) < 0 {
-		errStr := fmt.Sprintf("rpc price of host is %v, which is above the maximum allowed by the allowance: %v", hostSettings.BaseRPCPrice, allowance.MaxRPCPrice)
-		return errors.New(errStr)
-	}
-	// Check whether the sector access price is too high.
-	if !allowance.MaxSectorAccessPrice.IsZero() && allowance.MaxSectorAccessPrice.Cmp(hostSettings.SectorAccessPrice) < 0 {
-		errStr := fmt.Sprintf("sector access price of host is %v, which is above the maximum allowed by the allowance: %v", hostSettings.SectorAccessPrice, allowance.MaxSectorAccessPrice)
-		return errors.New(errStr)
-	}
-	// Check whether the storage price is too high.
-	if !allowance.MaxStoragePrice.IsZero() && allowance.MaxStoragePrice.Cmp(hostSettings.StoragePrice) < 0 {
-		errStr := fmt.Sprintf("storage price of host is %v, which is above the maximum allowed by the allowance: %v", hostSettings.StoragePrice, allowance.MaxStoragePrice)
-		return errors.New(errStr)
-	}
-	// Check whether the upload bandwidth price is too high.
-	if !allowance.MaxUploadBandwidthPrice.IsZero() && allowance.MaxUploadBandwidthPrice.Cmp(hostSettings.UploadBandwidthPrice) < 0 {
-		errStr := fmt.Sprintf("upload bandwidth price of host is %v, which is above the maximum allowed by the allowance: %v", hostSettings.UploadBandwidthPrice, allowance.MaxUploadBandwidthPrice)
-		return errors.New(errStr)
-	}
-	// Check whether the download bandwidth price is too high.
-	if !allowance.MaxDownloadBandwidthPrice.IsZero() && allowance.MaxDownloadBandwidthPrice.Cmp(hostSettings.DownloadBandwidthPrice) < 0 {
-		errStr := fmt.Sprintf("download bandwidth price of host is %v, which is above the maximum allowed by the allowance: %v", hostSettings.UploadBandwidthPrice, allowance.MaxDownloadBandwidthPrice)
-		return errors.New(errStr)
+	// Check whether any of the prices relevant for uploading exceed the
+	// limits of the allowance.
+	report := skymodules.NewGougingChecker(allowance).CheckHostSettings(hostSettings)
+	err := report.Err(skymodules.GougingFieldBaseRPCPrice, skymodules.GougingFieldSectorAccessPrice, skymodules.GougingFieldStoragePrice, skymodules.GougingFieldUploadBandwidthPrice, skymodules.GougingFieldDownloadBandwidthPrice)
+	if err != nil {
+		return err
 	}
 
 	// If there is no allowance, general price gouging checks have to be
@@ -86,40 +67,13 @@ func checkUploadGouging(allowance skymodules.Allowance, hostSettings modules.Hos
 // table for a host and determines whether an upload should be halted due to
 // price gouging.
 func checkUploadGougingPT(pt modules.RPCPriceTable, allowance skymodules.Allowance) error {
-	// Check MemoryTimeCost. This is unused by hosts right now and should be set to 1H.
-	if types.NewCurrency64(1).Cmp(pt.MemoryTimeCost) < 0 {
-		errStr := fmt.Sprintf("MemoryTimeCost of host is %v, which is above the maximum allowed by the allowance: %v", pt.MemoryTimeCost, types.NewCurrency64(1))
-		return errors.New(errStr)
-	}
-	// Check WriteLengthCost. This is unused by hosts right now and should be set to 1H.
-	if types.NewCurrency64(1).Cmp(pt.WriteLengthCost) < 0 {
-		errStr := fmt.Sprintf("WriteLengthCost of host is %v, which is above the maximum allowed by the allowance: %v", pt.WriteLengthCost, types.NewCurrency64(1))
-		return errors.New(errStr)
-	}
-	// Check InitBaseCost. This equals the BaseRPCPrice in the host settings.
-	if !allowance.MaxRPCPrice.IsZero() && allowance.MaxRPCPrice.Cmp(pt.InitBaseCost) < 0 {
-		errStr := fmt.Sprintf("InitBaseCost of host is %v, which is above the maximum allowed by the allowance: %v", pt.InitBaseCost, allowance.MaxRPCPrice)
-		return errors.New(errStr)
-	}
-	// Check WriteBaseCost. This equals the SectorAccessPrice in the host settings.
-	if !allowance.MaxSectorAccessPrice.IsZero() && allowance.MaxSectorAccessPrice.Cmp(pt.WriteBaseCost) < 0 {
-		errStr := fmt.Sprintf("WriteBaseCost of host is %v, which is above the maximum allowed by the allowance: %v", pt.WriteBaseCost, allowance.MaxSectorAccessPrice)
-		return errors.New(errStr)
-	}
-	// Check WriteStoreCost. This equals the StoragePrice in the host settings.
-	if !allowance.MaxStoragePrice.IsZero() && allowance.MaxStoragePrice.Cmp(pt.WriteStoreCost) < 0 {
-		errStr := fmt.Sprintf("WriteStoreCost of host is %v, which is above the maximum allowed by the allowance: %v", pt.WriteStoreCost, allowance.MaxStoragePrice)
-		return errors.New(errStr)
-	}
-	// Check whether the upload bandwidth price is too high.
-	if !allowance.MaxUploadBandwidthPrice.IsZero() && allowance.MaxUploadBandwidthPrice.Cmp(pt.UploadBandwidthCost) < 0 {
-		errStr := fmt.Sprintf("UploadBandwidthPrice price of host is %v, which is above the maximum allowed by the allowance: %v", pt.UploadBandwidthCost, allowance.MaxUploadBandwidthPrice)
-		return errors.New(errStr)
-	}
-	// Check whether the download bandwidth price is too high.
-	if !allowance.MaxDownloadBandwidthPrice.IsZero() && allowance.MaxDownloadBandwidthPrice.Cmp(pt.DownloadBandwidthCost) < 0 {
-		errStr := fmt.Sprintf("DownloadBandwidthPrice price of host is %v, which is above the maximum allowed by the allowance: %v", pt.DownloadBandwidthCost, allowance.MaxDownloadBandwidthPrice)
-		return errors.New(errStr)
+	// Check whether any of the prices relevant for uploading exceed the
+	// limits of the allowance. MemoryTimeCost and WriteLengthCost are unused
+	// by hosts right now and should be set to 1H.
+	report := skymodules.NewGougingChecker(allowance).CheckPriceTable(pt)
+	err := report.Err(skymodules.GougingFieldMemoryTimeCost, skymodules.GougingFieldWriteLengthCost, skymodules.GougingFieldBaseRPCPrice, skymodules.GougingFieldSectorAccessPrice, skymodules.GougingFieldStoragePrice, skymodules.GougingFieldUploadBandwidthPrice, skymodules.GougingFieldDownloadBandwidthPrice)
+	if err != nil {
+		return err
 	}
 
 	// If there is no allowance, general price gouging checks have to be
@@ -204,7 +158,8 @@ func (w *worker) callQueueUploadChunk(uc *unfinishedUploadChunk) bool {
 	w.mu.Lock()
 	onCooldown, _ := w.onUploadCooldown()
 	uploadTerminated := w.uploadTerminated
-	if !goodForUpload || uploadTerminated || onCooldown || !candidateHost {
+	disabled := w.staticJobsDisabledErr() != nil
+	if !goodForUpload || uploadTerminated || onCooldown || !candidateHost || disabled {
 		// The worker should not be uploading, remove the chunk.
 		w.mu.Unlock()
 		w.managedDropChunk(uc)
@@ -280,12 +235,21 @@ func (w *worker) managedPerformUploadChunkJob() {
 		return
 	}
 
+	// Fetch the piece. If the chunk is still being erasure coded, this blocks
+	// until the piece is available.
+	piece, err := uc.managedPiece(pieceIndex, w.staticRenter.tg.StopChan())
+	if err != nil {
+		failureErr := errors.AddContext(err, "worker failed to fetch the piece to upload")
+		w.managedUploadFailed(uc, pieceIndex, failureErr)
+		return
+	}
+
 	// Perform the upload, and update the failure stats based on the success of
 	// the upload attempt.
 	//
 	// Ignore the error if it's a ErrMaxVirtualSectors coming from a pre-1.5.5
 	// host.
-	root, err := s.Upload(uc.physicalChunkData[pieceIndex])
+	root, err := s.Upload(piece)
 	ignoreErr := build.VersionCmp(hostSettings.Version, "1.5.5") < 0 && err != nil && strings.Contains(err.Error(), modules.ErrMaxVirtualSectors.Error())
 	if err != nil && !ignoreErr {
 		failureErr := fmt.Errorf("Worker failed to upload root %v via the editor: %v", root, err)
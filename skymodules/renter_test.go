@@ -570,3 +570,100 @@ func TestNumChunks(t *testing.T) {
 		})
 	}
 }
+
+// TestAllowanceProfileAllowance is a unit test for the Allowance type's
+// ProfileAllowance method.
+func TestAllowanceProfileAllowance(t *testing.T) {
+	a := DefaultAllowance
+	a.MaxStoragePrice = types.NewCurrency64(1000)
+	a.MaxDownloadBandwidthPrice = types.NewCurrency64(100)
+	a.SetArchiveProfile(AllowanceProfile{
+		Hosts:           20,
+		ExpectedStorage: uint64(a.Period) * 1000,
+		MaxStoragePrice: types.NewCurrency64(500),
+	})
+	a.Profiles["cold"] = AllowanceProfile{
+		Hosts:       10,
+		Period:      a.Period * 4,
+		RenewWindow: a.RenewWindow * 2,
+	}
+
+	// Compute the expected archive profile.
+	expected := a
+	expected.Profiles = nil
+	expected.Hosts = 20
+	expected.ExpectedStorage = uint64(a.Period) * 1000
+	expected.ExpectedUpload = 1000
+	expected.ExpectedDownload = 0
+	expected.MaxStoragePrice = types.NewCurrency64(500)
+	profile, exists := a.ProfileAllowance(ArchiveProfileName)
+	if !exists {
+		t.Fatal("archive profile doesn't exist")
+	}
+	if !reflect.DeepEqual(profile, expected) {
+		t.Log(profile)
+		t.Log(expected)
+		t.Fatal("wrong archive profile")
+	}
+
+	// The cold profile has its own period and renew window.
+	expected = a
+	expected.Profiles = nil
+	expected.Hosts = 10
+	expected.Period = a.Period * 4
+	expected.RenewWindow = a.RenewWindow * 2
+	expected.ExpectedStorage = 0
+	expected.ExpectedUpload = 0
+	expected.ExpectedDownload = 0
+	profile, exists = a.ProfileAllowance("cold")
+	if !exists {
+		t.Fatal("cold profile doesn't exist")
+	}
+	if !reflect.DeepEqual(profile, expected) {
+		t.Log(profile)
+		t.Log(expected)
+		t.Fatal("wrong cold profile")
+	}
+
+	// Unknown profiles don't exist.
+	if _, exists := a.ProfileAllowance("unknown"); exists {
+		t.Fatal("unknown profile shouldn't exist")
+	}
+
+	// The names are sorted.
+	if names := a.ProfileNames(); !reflect.DeepEqual(names, []string{ArchiveProfileName, "cold"}) {
+		t.Fatal("wrong names", names)
+	}
+
+	// The original allowance shouldn't be modified.
+	if a.Hosts != DefaultAllowance.Hosts || a.Period != DefaultAllowance.Period || a.ArchiveProfile().Hosts != 20 {
+		t.Fatal("allowance was modified")
+	}
+}
+
+// TestFileUploadParamsUploadProfile is a unit test for the FileUploadParams
+// type's UploadProfile method.
+func TestFileUploadParamsUploadProfile(t *testing.T) {
+	tests := []struct {
+		archive  bool
+		profile  string
+		expected string
+		err      bool
+	}{
+		{false, "", "", false},
+		{false, "cold", "cold", false},
+		{true, "", ArchiveProfileName, false},
+		{true, ArchiveProfileName, ArchiveProfileName, false},
+		{true, "cold", "", true},
+	}
+	for _, test := range tests {
+		fup := FileUploadParams{Archive: test.archive, Profile: test.profile}
+		profile, err := fup.UploadProfile()
+		if (err != nil) != test.err {
+			t.Fatal("unexpected error", test, err)
+		}
+		if profile != test.expected {
+			t.Fatal("wrong profile", test, profile)
+		}
+	}
+}
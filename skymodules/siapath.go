@@ -54,6 +54,11 @@ var (
 	// default.
 	SkynetFolder = NewGlobalSiaPath("/var/skynet")
 
+	// SkynetBackupFolder is the Sia folder where the skyfiles containing
+	// backups of the skynet folder are stored. It's outside of the skynet
+	// folder to avoid including previous backups in new ones.
+	SkynetBackupFolder = NewGlobalSiaPath("/var/skynetbackups")
+
 	// UserFolder is the Sia folder that is used to store the renter's siafiles.
 	UserFolder = NewGlobalSiaPath("/home/user")
 
@@ -0,0 +1,441 @@
+package skymodules
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/SkynetLabs/skyd/build"
+)
+
+var (
+	// SkyfileExtractMaxEntries is the maximum number of files an extracted
+	// archive may contain.
+	SkyfileExtractMaxEntries = build.Select(build.Var{
+		Dev:      10000,
+		Standard: 10000,
+		Testing:  10,
+	}).(int)
+
+	// SkyfileExtractMaxSize is the maximum total size of the files of an
+	// extracted archive as well as the maximum size of an uploaded zip
+	// archive.
+	SkyfileExtractMaxSize = build.Select(build.Var{
+		Dev:      int64(1 << 30),
+		Standard: int64(1 << 30),
+		Testing:  int64(1 << 16),
+	}).(int64)
+)
+
+var (
+	// ErrExtractTooManyEntries is returned if an extracted archive contains
+	// too many files.
+	ErrExtractTooManyEntries = errors.New("archive contains too many files")
+
+	// ErrExtractTooLarge is returned if an extracted archive is too large.
+	ErrExtractTooLarge = errors.New("archive is too large")
+
+	// ErrExtractUnsupportedFormat is returned if the uploaded data is not a
+	// supported archive.
+	ErrExtractUnsupportedFormat = errors.New("data is not a tar, tar.gz or zip archive")
+
+	// ErrExtractIllegalPath is returned if a file within an archive has a path
+	// which would escape the skyfile.
+	ErrExtractIllegalPath = errors.New("archive contains illegal path")
+
+	// ErrExtractDuplicatePath is returned if an archive contains the same file
+	// more than once.
+	ErrExtractDuplicatePath = errors.New("archive contains duplicate path")
+)
+
+var (
+	// gzipMagic are the first bytes of a gzip stream.
+	gzipMagic = []byte{0x1f, 0x8b}
+
+	// tarMagic is the magic within the header of a ustar or gnu tar archive.
+	tarMagic = []byte("ustar")
+
+	// tarMagicOffset is the offset of the magic within a tar header.
+	tarMagicOffset = 257
+
+	// zipMagic are the first bytes of a zip archive with at least one file.
+	zipMagic = []byte("PK\x03\x04")
+
+	// zipEmptyMagic are the first bytes of an empty zip archive.
+	zipEmptyMagic = []byte("PK\x05\x06")
+)
+
+type (
+	// SkyfileExtractReader is a SkyfileUploadReader which unpacks an archive
+	// and reads the archived files as the subfiles of a skyfile. It needs to
+	// be closed to release the resources used during extraction.
+	SkyfileExtractReader interface {
+		SkyfileUploadReader
+		io.Closer
+	}
+
+	// skyfileExtractReader is a helper struct that implements the
+	// SkyfileExtractReader interface.
+	//
+	// NOTE: reading from this object is not threadsafe and thus should not be
+	// done from more than one thread if you want the reads to be deterministic.
+	skyfileExtractReader struct {
+		readBuf []byte
+
+		// nextEntry returns the next regular file of the archive or io.EOF
+		// if there are no more files.
+		nextEntry func() (archiveEntry, error)
+		closeFn   func() error
+
+		currEntry *archiveEntry
+		currLen   uint64
+		currOff   uint64
+		currSniff []byte
+
+		numEntries int
+		totalLen   int64
+
+		// err is the error which aborted the extraction. Once set, all
+		// following reads return it since the archive can't be read further.
+		err error
+
+		metadata      SkyfileMetadata
+		metadataAvail chan struct{}
+	}
+
+	// archiveEntry is a regular file within an archive.
+	archiveEntry struct {
+		name    string
+		mode    os.FileMode
+		modTime time.Time
+		reader  io.Reader
+	}
+)
+
+// NewSkyfileExtractReader detects the format of the archive read from the
+// given reader and returns a reader which reads the files within the archive
+// as the subfiles of a skyfile. Tar and zip archives as well as gzipped tar
+// archives are supported.
+func NewSkyfileExtractReader(reader io.Reader, sup SkyfileUploadParameters) (SkyfileExtractReader, error) {
+	sr := &skyfileExtractReader{
+		closeFn: func() error { return nil },
+		metadata: SkyfileMetadata{
+			Filename:           sup.Filename,
+			Mode:               sup.Mode,
+			DefaultPath:        sup.DefaultPath,
+			DisableDefaultPath: sup.DisableDefaultPath,
+			TryFiles:           sup.TryFiles,
+			ErrorPages:         sup.ErrorPages,
+			ExtraMetadata:      sup.ExtraMetadata,
+			Headers:            sup.Headers,
+			Subfiles:           make(SkyfileSubfiles),
+		},
+		metadataAvail: make(chan struct{}),
+	}
+
+	// Peek at the beginning of the data to detect the archive format.
+	br := bufio.NewReaderSize(reader, tarMagicOffset+len(tarMagic))
+	magic, err := br.Peek(tarMagicOffset + len(tarMagic))
+	if err != nil && !errors.Contains(err, io.EOF) {
+		return nil, errors.AddContext(err, "failed to read archive")
+	}
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gzr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to open gzip stream")
+		}
+		sr.nextEntry = tarEntries(tar.NewReader(gzr))
+	case len(magic) > tarMagicOffset && bytes.HasPrefix(magic[tarMagicOffset:], tarMagic):
+		sr.nextEntry = tarEntries(tar.NewReader(br))
+	case bytes.HasPrefix(magic, zipMagic) || bytes.HasPrefix(magic, zipEmptyMagic):
+		err = sr.openZip(br)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrExtractUnsupportedFormat
+	}
+	return sr, nil
+}
+
+// openZip spools the zip archive read from the given reader into a temporary
+// file since zip archives can't be read sequentially.
+func (sr *skyfileExtractReader) openZip(reader io.Reader) (err error) {
+	f, err := ioutil.TempFile("", "skyd-extract-*.zip")
+	if err != nil {
+		return errors.AddContext(err, "failed to create temporary file")
+	}
+	sr.closeFn = func() error {
+		return errors.Compose(f.Close(), os.Remove(f.Name()))
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Compose(err, sr.closeFn())
+		}
+	}()
+	n, err := io.Copy(f, io.LimitReader(reader, SkyfileExtractMaxSize+1))
+	if err != nil {
+		return errors.AddContext(err, "failed to read zip archive")
+	}
+	if n > SkyfileExtractMaxSize {
+		return ErrExtractTooLarge
+	}
+	zr, err := zip.NewReader(f, n)
+	if err != nil {
+		return errors.AddContext(err, "failed to open zip archive")
+	}
+	sr.nextEntry = zipEntries(zr)
+	return nil
+}
+
+// tarEntries returns a function which iterates over the regular files of a tar
+// archive.
+func tarEntries(tr *tar.Reader) func() (archiveEntry, error) {
+	return func() (archiveEntry, error) {
+		for {
+			header, err := tr.Next()
+			if err != nil {
+				return archiveEntry{}, err
+			}
+			if header.Typeflag != tar.TypeReg {
+				continue // skip directories, links and other special files
+			}
+			return archiveEntry{
+				name:    header.Name,
+				mode:    header.FileInfo().Mode(),
+				modTime: header.ModTime,
+				reader:  tr,
+			}, nil
+		}
+	}
+}
+
+// zipEntries returns a function which iterates over the regular files of a zip
+// archive.
+func zipEntries(zr *zip.Reader) func() (archiveEntry, error) {
+	files := zr.File
+	var curr io.Closer
+	return func() (archiveEntry, error) {
+		if curr != nil {
+			if err := curr.Close(); err != nil {
+				return archiveEntry{}, errors.AddContext(err, "failed to close zipped file")
+			}
+			curr = nil
+		}
+		for len(files) > 0 {
+			f := files[0]
+			files = files[1:]
+			if !f.Mode().IsRegular() {
+				continue // skip directories, links and other special files
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return archiveEntry{}, errors.AddContext(err, "failed to open zipped file")
+			}
+			curr = rc
+			// A zero MS-DOS date means that the file has no modification
+			// time.
+			var modTime time.Time
+			if f.ModifiedDate != 0 || f.ModifiedTime != 0 {
+				modTime = f.Modified
+			}
+			return archiveEntry{
+				name:    f.Name,
+				mode:    f.Mode(),
+				modTime: modTime,
+				reader:  rc,
+			}, nil
+		}
+		return archiveEntry{}, io.EOF
+	}
+}
+
+// extractPath returns the path of an archived file within the skyfile. It
+// rejects paths which are absolute or which would escape the skyfile.
+func extractPath(name string) (string, error) {
+	name = strings.TrimPrefix(name, "./")
+	if strings.Contains(name, "\\") || strings.HasPrefix(name, "/") {
+		return "", errors.AddContext(ErrExtractIllegalPath, name)
+	}
+	for _, elem := range strings.Split(name, "/") {
+		if elem == ".." {
+			return "", errors.AddContext(ErrExtractIllegalPath, name)
+		}
+	}
+	if path.Clean(name) != name {
+		return "", errors.AddContext(ErrExtractIllegalPath, name)
+	}
+	if err := ValidatePathString(name, false); err != nil {
+		return "", errors.Compose(errors.AddContext(ErrExtractIllegalPath, name), err)
+	}
+	return name, nil
+}
+
+// Close releases the resources used during extraction.
+func (sr *skyfileExtractReader) Close() error {
+	return sr.closeFn()
+}
+
+// SetReadBuffer sets the given bytes as the read buffer. The next reads will
+// read from this buffer until it is entirely consumed, after which we continue
+// reading from the underlying archive.
+func (sr *skyfileExtractReader) SetReadBuffer(b []byte) {
+	sr.readBuf = b
+}
+
+// SkyfileMetadata returns the SkyfileMetadata associated with this reader.
+func (sr *skyfileExtractReader) SkyfileMetadata(ctx context.Context) (SkyfileMetadata, error) {
+	// Wait for the metadata to become available, that will be the case when
+	// the reader returned an EOF, or until the context is cancelled.
+	select {
+	case <-ctx.Done():
+		return SkyfileMetadata{}, errors.AddContext(ErrSkyfileMetadataUnavailable, "context cancelled")
+	case <-sr.metadataAvail:
+	}
+	if sr.err != nil {
+		return SkyfileMetadata{}, errors.AddContext(sr.err, "failed to extract archive")
+	}
+
+	// Check whether we found any files
+	if len(sr.metadata.Subfiles) == 0 {
+		return SkyfileMetadata{}, errors.New("could not find any file in archive")
+	}
+
+	// Use the filename of the only subfile if it's not passed as query
+	// string parameter.
+	if sr.metadata.Filename == "" && len(sr.metadata.Subfiles) == 1 {
+		for _, sf := range sr.metadata.Subfiles {
+			sr.metadata.Filename = sf.Filename
+		}
+	}
+	sr.metadata.Length = sr.currOff
+	return sr.metadata, nil
+}
+
+// Read implements the io.Reader part of the interface and reads the data of
+// the archived files one after another. While the data is being read, the
+// metadata is being constructed.
+func (sr *skyfileExtractReader) Read(p []byte) (n int, err error) {
+	if len(sr.readBuf) > 0 {
+		n = copy(p, sr.readBuf)
+		sr.readBuf = sr.readBuf[n:]
+		if len(sr.readBuf) == 0 {
+			sr.readBuf = nil // reset for GC
+		}
+	}
+
+	// check if the extraction failed or if we've already read until EOF,
+	// that will be the case if `metadataAvail` is closed.
+	if sr.err != nil {
+		return n, sr.err
+	}
+	select {
+	case <-sr.metadataAvail:
+		return n, io.EOF
+	default:
+	}
+	defer func() {
+		// make the error sticky and release anyone waiting for the metadata
+		if err != nil && !errors.Contains(err, io.EOF) {
+			sr.err = err
+			close(sr.metadataAvail)
+		}
+	}()
+
+	for n < len(p) && err == nil {
+		// only open the next entry if the current entry is not set
+		if sr.currEntry == nil {
+			var entry archiveEntry
+			entry, err = sr.nextEntry()
+			if errors.Contains(err, io.EOF) {
+				close(sr.metadataAvail)
+				break
+			}
+			if err != nil {
+				err = errors.AddContext(err, "failed to read archive")
+				break
+			}
+			entry.name, err = extractPath(entry.name)
+			if err != nil {
+				break
+			}
+			if _, exists := sr.metadata.Subfiles[entry.name]; exists {
+				err = errors.AddContext(ErrExtractDuplicatePath, entry.name)
+				break
+			}
+			sr.numEntries++
+			if sr.numEntries > SkyfileExtractMaxEntries {
+				err = ErrExtractTooManyEntries
+				break
+			}
+			sr.currEntry = &entry
+			sr.currLen = 0
+			sr.currSniff = nil
+		}
+
+		// read data from the entry
+		var nn int
+		nn, err = sr.currEntry.reader.Read(p[n:])
+		n += nn
+		sr.currLen += uint64(nn)
+		sr.totalLen += int64(nn)
+		if sr.totalLen > SkyfileExtractMaxSize {
+			err = ErrExtractTooLarge
+			break
+		}
+
+		// remember the beginning of the entry for sniffing its content type
+		if missing := ContentTypeSniffLen - len(sr.currSniff); missing > 0 {
+			chunk := p[n-nn : n]
+			if len(chunk) > missing {
+				chunk = chunk[:missing]
+			}
+			sr.currSniff = append(sr.currSniff, chunk...)
+		}
+
+		// ignore the EOF to continue reading from the next entry if
+		// necessary
+		if errors.Contains(err, io.EOF) {
+			err = nil
+			sr.createSubfileFromCurrEntry()
+			sr.currEntry = nil
+		}
+	}
+	return
+}
+
+// createSubfileFromCurrEntry adds a subfile for the current entry.
+func (sr *skyfileExtractReader) createSubfileFromCurrEntry() {
+	mode := sr.currEntry.mode.Perm()
+	if mode == 0 {
+		mode = DefaultFilePerm
+	}
+	// Only record modification times after the epoch. Entries without a
+	// timestamp report the zero time.
+	var modified int64
+	if sr.currEntry.modTime.Unix() > 0 {
+		modified = sr.currEntry.modTime.Unix()
+	}
+	contentType := mime.TypeByExtension(path.Ext(sr.currEntry.name))
+	sr.metadata.Subfiles[sr.currEntry.name] = SkyfileSubfileMetadata{
+		FileMode:    mode,
+		Filename:    sr.currEntry.name,
+		ContentType: SniffContentType(contentType, sr.currSniff),
+		Offset:      sr.currOff,
+		Len:         sr.currLen,
+		Modified:    modified,
+	}
+	sr.currOff += sr.currLen
+}
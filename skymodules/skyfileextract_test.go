@@ -0,0 +1,203 @@
+package skymodules
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// testArchiveFile is a file within a test archive.
+type testArchiveFile struct {
+	name    string
+	data    []byte
+	modTime time.Time
+}
+
+// newTestTar creates a tar archive from the given files.
+func newTestTar(t *testing.T, files []testArchiveFile) []byte {
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	// Add a directory which should be skipped.
+	err := tw.WriteHeader(&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0755})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		err := tw.WriteHeader(&tar.Header{Name: f.name, Typeflag: tar.TypeReg, Mode: 0640, Size: int64(len(f.data)), ModTime: f.modTime})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// newTestZip creates a zip archive from the given files.
+func newTestZip(t *testing.T, files []testArchiveFile) []byte {
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	for _, f := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: f.modTime})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(f.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// extractTestArchive extracts the given archive using a SkyfileExtractReader.
+func extractTestArchive(archive []byte) ([]byte, SkyfileMetadata, error) {
+	sr, err := NewSkyfileExtractReader(bytes.NewReader(archive), SkyfileUploadParameters{Filename: "archive"})
+	if err != nil {
+		return nil, SkyfileMetadata{}, err
+	}
+	defer func() {
+		_ = sr.Close()
+	}()
+	data, err := ioutil.ReadAll(sr)
+	if err != nil {
+		return nil, SkyfileMetadata{}, err
+	}
+	md, err := sr.SkyfileMetadata(context.Background())
+	return data, md, err
+}
+
+// TestSkyfileExtractReader verifies the functionality of the
+// SkyfileExtractReader.
+func TestSkyfileExtractReader(t *testing.T) {
+	t.Run("Formats", testSkyfileExtractReaderFormats)
+	t.Run("Limits", testSkyfileExtractReaderLimits)
+	t.Run("IllegalPaths", testSkyfileExtractReaderIllegalPaths)
+}
+
+// testSkyfileExtractReaderFormats verifies that tar, tar.gz and zip archives
+// are extracted correctly.
+func testSkyfileExtractReaderFormats(t *testing.T) {
+	t.Parallel()
+
+	files := []testArchiveFile{
+		{"index.html", []byte("<html></html>"), time.Time{}},
+		{"./dir/data", fastrand.Bytes(100), time.Unix(1600000000, 0)},
+		{"empty", nil, time.Time{}},
+	}
+	tarArchive := newTestTar(t, files)
+	gzBuf := new(bytes.Buffer)
+	gzw := gzip.NewWriter(gzBuf)
+	if _, err := gzw.Write(tarArchive); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	archives := map[string][]byte{
+		"tar":   tarArchive,
+		"targz": gzBuf.Bytes(),
+		"zip":   newTestZip(t, files),
+	}
+
+	for format, archive := range archives {
+		data, md, err := extractTestArchive(archive)
+		if err != nil {
+			t.Fatal(format, err)
+		}
+		expectedData := append(append([]byte{}, files[0].data...), files[1].data...)
+		if !bytes.Equal(data, expectedData) {
+			t.Fatal(format, "unexpected data")
+		}
+		if md.Filename != "archive" || md.Length != uint64(len(expectedData)) || len(md.Subfiles) != 3 {
+			t.Fatal(format, "unexpected metadata", md)
+		}
+		index := md.Subfiles["index.html"]
+		if index.Offset != 0 || index.Len != 13 || index.ContentType != "text/html; charset=utf-8" {
+			t.Fatal(format, "unexpected subfile", index)
+		}
+		dir := md.Subfiles["dir/data"]
+		if dir.Offset != 13 || dir.Len != 100 || dir.Filename != "dir/data" || dir.Modified != 1600000000 {
+			t.Fatal(format, "unexpected subfile", dir)
+		}
+		if index.Modified != 0 || !index.ModTime().Equal(time.Unix(0, 0)) {
+			t.Fatal(format, "unexpected modification time", index.Modified)
+		}
+		if format != "zip" && dir.FileMode != 0640 {
+			t.Fatal(format, "unexpected mode", dir.FileMode)
+		}
+		empty := md.Subfiles["empty"]
+		if empty.Offset != 113 || empty.Len != 0 {
+			t.Fatal(format, "unexpected subfile", empty)
+		}
+	}
+
+	// Data which isn't an archive is rejected.
+	_, _, err := extractTestArchive(fastrand.Bytes(1000))
+	if !errors.Contains(err, ErrExtractUnsupportedFormat) {
+		t.Fatal("unexpected error", err)
+	}
+}
+
+// testSkyfileExtractReaderLimits verifies that the number of files and the
+// size of extracted archives are limited.
+func testSkyfileExtractReaderLimits(t *testing.T) {
+	t.Parallel()
+
+	// Too many files.
+	var files []testArchiveFile
+	for i := 0; i <= SkyfileExtractMaxEntries; i++ {
+		files = append(files, testArchiveFile{fmt.Sprint(i), []byte{byte(i)}, time.Time{}})
+	}
+	for _, archive := range [][]byte{newTestTar(t, files), newTestZip(t, files)} {
+		if _, _, err := extractTestArchive(archive); !errors.Contains(err, ErrExtractTooManyEntries) {
+			t.Fatal("unexpected error", err)
+		}
+	}
+
+	// Too large. The zip archive compresses well which makes sure that the
+	// extracted size is limited rather than the size of the archive.
+	files = []testArchiveFile{{"file", make([]byte, SkyfileExtractMaxSize+1), time.Time{}}}
+	for _, archive := range [][]byte{newTestTar(t, files), newTestZip(t, files)} {
+		if _, _, err := extractTestArchive(archive); !errors.Contains(err, ErrExtractTooLarge) {
+			t.Fatal("unexpected error", err)
+		}
+	}
+}
+
+// testSkyfileExtractReaderIllegalPaths verifies that archives with paths that
+// would escape the skyfile are rejected.
+func testSkyfileExtractReaderIllegalPaths(t *testing.T) {
+	t.Parallel()
+
+	illegal := []string{"../file", "dir/../../file", "/etc/passwd", "dir//file", "dir\\..\\file", "dir/./file"}
+	for _, name := range illegal {
+		files := []testArchiveFile{{name, []byte("data"), time.Time{}}}
+		for _, archive := range [][]byte{newTestTar(t, files), newTestZip(t, files)} {
+			if _, _, err := extractTestArchive(archive); !errors.Contains(err, ErrExtractIllegalPath) {
+				t.Fatal("unexpected error", name, err)
+			}
+		}
+	}
+
+	// Duplicate paths are rejected as well.
+	files := []testArchiveFile{{"file", []byte("data"), time.Time{}}, {"./file", []byte("data"), time.Time{}}}
+	if _, _, err := extractTestArchive(newTestTar(t, files)); !errors.Contains(err, ErrExtractDuplicatePath) {
+		t.Fatal("unexpected error", err)
+	}
+}
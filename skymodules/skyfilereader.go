@@ -52,6 +52,10 @@ type (
 		currOff  uint64
 		currPart *multipart.Part
 
+		// currSniff contains the first bytes of the current part which are
+		// used to sniff its content type.
+		currSniff []byte
+
 		metadata      SkyfileMetadata
 		metadataAvail chan struct{}
 	}
@@ -70,6 +74,16 @@ type (
 		metadata      SkyfileMetadata
 		metadataAvail chan struct{}
 	}
+
+	// skyfileMetadataReader is a helper struct that implements the
+	// SkyfileUploadReader interface for an upload of which the metadata is
+	// known before the data is read.
+	skyfileMetadataReader struct {
+		reader  io.Reader
+		readBuf []byte
+
+		metadata SkyfileMetadata
+	}
 )
 
 // NewSkyfileReader wraps the given reader and metadata and returns a
@@ -79,8 +93,10 @@ func NewSkyfileReader(reader io.Reader, sup SkyfileUploadParameters) SkyfileUplo
 	return &skyfileReader{
 		reader: reader,
 		metadata: SkyfileMetadata{
-			Filename: sup.Filename,
-			Mode:     sup.Mode,
+			Filename:      sup.Filename,
+			Mode:          sup.Mode,
+			ExtraMetadata: sup.ExtraMetadata,
+			Headers:       sup.Headers,
 		},
 		metadataAvail: make(chan struct{}),
 	}
@@ -144,6 +160,43 @@ func (sr *skyfileReader) Read(p []byte) (n int, err error) {
 	return
 }
 
+// NewSkyfileReaderWithMetadata wraps the given reader and returns a
+// SkyfileUploadReader which returns the given metadata. This is useful if the
+// metadata of the skyfile is known upfront, e.g. because the layout of the
+// subfiles was already determined by the caller.
+func NewSkyfileReaderWithMetadata(reader io.Reader, md SkyfileMetadata) SkyfileUploadReader {
+	return &skyfileMetadataReader{
+		reader:   reader,
+		metadata: md,
+	}
+}
+
+// SetReadBuffer sets the given bytes as the read buffer. The next reads will
+// read from this buffer until it is entirely consumed, after which we continue
+// reading from the underlying reader.
+func (sr *skyfileMetadataReader) SetReadBuffer(b []byte) {
+	sr.readBuf = b
+}
+
+// SkyfileMetadata returns the SkyfileMetadata associated with this reader.
+func (sr *skyfileMetadataReader) SkyfileMetadata(_ context.Context) (SkyfileMetadata, error) {
+	return sr.metadata, nil
+}
+
+// Read implements the io.Reader part of the interface and reads data from the
+// read buffer before reading from the underlying reader.
+func (sr *skyfileMetadataReader) Read(p []byte) (int, error) {
+	if len(sr.readBuf) > 0 {
+		n := copy(p, sr.readBuf)
+		sr.readBuf = sr.readBuf[n:]
+		if len(sr.readBuf) == 0 {
+			sr.readBuf = nil // reset for GC
+		}
+		return n, nil
+	}
+	return sr.reader.Read(p)
+}
+
 // NewMultipartReader creates a multipart.Reader from an io.Reader and the
 // provided subfiles. This reader can then be used to create
 // a NewSkyfileMultipartReader.
@@ -235,6 +288,8 @@ func newSkyfileMultipartReader(reader *multipart.Reader, sup SkyfileUploadParame
 			DisableDefaultPath: sup.DisableDefaultPath,
 			TryFiles:           sup.TryFiles,
 			ErrorPages:         sup.ErrorPages,
+			ExtraMetadata:      sup.ExtraMetadata,
+			Headers:            sup.Headers,
 			Subfiles:           make(SkyfileSubfiles),
 		},
 		metadataAvail: make(chan struct{}),
@@ -314,6 +369,7 @@ func (sr *skyfileMultipartReader) Read(p []byte) (n int, err error) {
 			}
 			sr.currOff += sr.currLen
 			sr.currLen = 0
+			sr.currSniff = nil
 
 			// verify the multipart file is submitted under the expected name
 			if !isLegalFormName(sr.currPart.FormName()) {
@@ -330,6 +386,15 @@ func (sr *skyfileMultipartReader) Read(p []byte) (n int, err error) {
 		// update the length
 		sr.currLen += uint64(nn)
 
+		// remember the beginning of the part for sniffing its content type
+		if missing := ContentTypeSniffLen - len(sr.currSniff); missing > 0 {
+			chunk := p[n-nn : n]
+			if len(chunk) > missing {
+				chunk = chunk[:missing]
+			}
+			sr.currSniff = append(sr.currSniff, chunk...)
+		}
+
 		// ignore the EOF to continue reading from the next part if necessary,
 		if err == io.EOF {
 			err = nil
@@ -378,7 +443,7 @@ func (sr *skyfileMultipartReader) createSubfileFromCurrPart() error {
 	sr.metadata.Subfiles[filename] = SkyfileSubfileMetadata{
 		FileMode:    mode,
 		Filename:    filename,
-		ContentType: sr.currPart.Header.Get("Content-Type"),
+		ContentType: SniffContentType(sr.currPart.Header.Get("Content-Type"), sr.currSniff),
 		Offset:      sr.currOff,
 		Len:         sr.currLen,
 	}
@@ -208,6 +208,7 @@ func TestSkyfileMultipartReader(t *testing.T) {
 	t.Run("RandomReadSize", testSkyfileMultipartReaderRandomReadSize)
 	t.Run("ReadBuffer", testSkyfileMultipartReaderReadBuffer)
 	t.Run("MetadataTimeout", testSkyfileMultipartReaderMetadataTimeout)
+	t.Run("SniffContentType", testSkyfileMultipartReaderSniffContentType)
 }
 
 // testSkyfileMultipartReaderBasic verifies the basic use case of a skyfile
@@ -597,3 +598,75 @@ func testSkyfileMultipartReaderMetadataTimeout(t *testing.T) {
 		t.Fatal("unexpected metadata", metadata)
 	}
 }
+
+// testSkyfileMultipartReaderSniffContentType verifies that the content type of
+// parts without a specific content type is sniffed from their data.
+func testSkyfileMultipartReaderSniffContentType(t *testing.T) {
+	t.Parallel()
+
+	// create a multipart writer
+	buffer := new(bytes.Buffer)
+	writer := multipart.NewWriter(buffer)
+
+	// add a png and a html file with the default content type as well as a
+	// html file with an explicit content type
+	png := append([]byte("\x89PNG\x0D\x0A\x1A\x0A"), fastrand.Bytes(100)...)
+	html := []byte("<!DOCTYPE html><html><body>hello</body></html>")
+	files := []struct {
+		name        string
+		contentType string
+		data        []byte
+		expected    string
+	}{
+		{"image", "", png, "image/png"},
+		{"page", "", html, "text/html; charset=utf-8"},
+		{"page.txt", "text/plain", html, "text/plain"},
+	}
+	for _, f := range files {
+		var part io.Writer
+		var err error
+		if f.contentType == "" {
+			part, err = writer.CreateFormFile("files[]", f.name)
+		} else {
+			header, herr := createFormFileHeaders("files[]", f.name, "600", f.contentType)
+			if herr != nil {
+				t.Fatal(herr)
+			}
+			part, err = writer.CreatePart(header)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := part.Write(f.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// read the parts using small reads to make sure the sniffed data is
+	// collected across reads
+	multipartReader := multipart.NewReader(bytes.NewReader(buffer.Bytes()), writer.Boundary())
+	sfReader := NewSkyfileMultipartReader(multipartReader, SkyfileUploadParameters{Filename: t.Name()})
+	_, err := ioutil.ReadAll(io.LimitReader(sfReader, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, 7)
+	for err == nil {
+		_, err = sfReader.Read(b)
+	}
+	if !errors.Contains(err, io.EOF) {
+		t.Fatal(err)
+	}
+	md, err := sfReader.SkyfileMetadata(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		if ct := md.Subfiles[f.name].ContentType; ct != f.expected {
+			t.Fatalf("expected content type %v for %v but got %v", f.expected, f.name, ct)
+		}
+	}
+}
@@ -101,6 +101,33 @@ func NewSkylinkV2(spk types.SiaPublicKey, tweak crypto.Hash) Skylink {
 	return sl
 }
 
+// ExtractSkylinks returns all valid skylinks which are embedded within the
+// given data. Skylinks are recognized in both their base64 and base32 encoding
+// as long as they are not part of a longer run of skylink characters. Every
+// skylink is only returned once, in the order of its first occurrence.
+func ExtractSkylinks(data []byte) []Skylink {
+	isSkylinkChar := func(r rune) bool {
+		return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_'
+	}
+	var skylinks []Skylink
+	seen := make(map[Skylink]struct{})
+	for _, candidate := range strings.FieldsFunc(string(data), func(r rune) bool { return !isSkylinkChar(r) }) {
+		if len(candidate) != base64EncodedSkylinkSize && len(candidate) != base32EncodedSkylinkSize {
+			continue
+		}
+		var sl Skylink
+		if err := sl.LoadString(candidate); err != nil {
+			continue
+		}
+		if _, exists := seen[sl]; exists {
+			continue
+		}
+		seen[sl] = struct{}{}
+		skylinks = append(skylinks, sl)
+	}
+	return skylinks
+}
+
 // validateAndParseV1Bitfield is a helper method which validates that a bitfield
 // is valid and also parses the offset and fetch size from the bitfield. These
 // two actions are performed at once because performing full validation requires
@@ -494,13 +521,22 @@ func (sl *Skylink) setOffsetAndFetchSize(offset, fetchSize uint64) error {
 // decodeSkylink is a helper function that decodes the given string
 // representation of a skylink  into raw bytes. It either performs a base32
 // decoding, or base64 decoding, depending on the length.
-func decodeSkylink(encoded string) ([]byte, error) {
+func decodeSkylink(encoded string) (raw []byte, err error) {
 	switch len(encoded) {
 	case base32EncodedSkylinkSize:
-		return base32.HexEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(encoded))
+		raw, err = base32.HexEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(encoded))
 	case base64EncodedSkylinkSize:
-		return base64.RawURLEncoding.DecodeString(encoded)
+		raw, err = base64.RawURLEncoding.DecodeString(encoded)
 	default:
 		return nil, ErrSkylinkIncorrectSize
 	}
+	if err != nil {
+		return nil, err
+	}
+	// The decoders ignore newline characters so the decoded data might be
+	// shorter than expected.
+	if len(raw) != rawSkylinkSize {
+		return nil, ErrSkylinkIncorrectSize
+	}
+	return raw, nil
 }
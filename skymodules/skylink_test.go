@@ -1,6 +1,7 @@
 package skymodules
 
 import (
+	"fmt"
 	"path/filepath"
 	"testing"
 
@@ -204,6 +205,17 @@ func TestSkylink(t *testing.T) {
 		t.Error("expecting error when loading a string containing an illegal character")
 	}
 
+	// Try loading base32 and base64 encoded strings of the right length
+	// which contain newline characters. The decoders skip them.
+	err = slMaxB32Decoded.LoadString("\r" + b32[1:])
+	if !errors.Contains(err, ErrSkylinkIncorrectSize) {
+		t.Error("expecting 'ErrSkylinkIncorrectSize' when loading a string containing a newline", err)
+	}
+	err = slMaxB32Decoded.LoadString("\r\n" + str[2:])
+	if !errors.Contains(err, ErrSkylinkIncorrectSize) {
+		t.Error("expecting 'ErrSkylinkIncorrectSize' when loading a string containing a newline", err)
+	}
+
 	// Try loading a base32 encoded string with invalid bitfield
 	var slInvalidBitfield Skylink
 	slInvalidBitfield.bitfield = 2
@@ -439,3 +451,43 @@ func TestNewSkylinkV2(t *testing.T) {
 		t.Fatal("skylink has wrong version")
 	}
 }
+
+// TestExtractSkylinks is a unit test for ExtractSkylinks.
+func TestExtractSkylinks(t *testing.T) {
+	t.Parallel()
+
+	var mr1, mr2, mr3 crypto.Hash
+	fastrand.Read(mr1[:])
+	fastrand.Read(mr2[:])
+	fastrand.Read(mr3[:])
+	sl1, err := NewSkylinkV1(mr1, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sl2, err := NewSkylinkV1(mr2, 4096, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sl3, err := NewSkylinkV1(mr3, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Embed the skylinks in some html. The first one is referenced twice,
+	// once in its base32 encoding. The last reference is part of a longer
+	// string and therefore not a skylink.
+	html := fmt.Sprintf(`<html><script src="sia://%v/index.js"></script><img src="/%v"><a href="https://%v.siasky.net">%v</a><p>x%v</p></html>`,
+		sl1.String(), sl2.String(), sl1.Base32EncodedString(), sl1.String(), sl3.String())
+	skylinks := ExtractSkylinks([]byte(html))
+	if len(skylinks) != 2 {
+		t.Fatal("wrong number of skylinks", len(skylinks))
+	}
+	if skylinks[0] != sl1 || skylinks[1] != sl2 {
+		t.Fatal("wrong skylinks", skylinks)
+	}
+
+	// No skylinks.
+	if skylinks := ExtractSkylinks([]byte("no skylinks in here")); len(skylinks) != 0 {
+		t.Fatal("expected no skylinks", skylinks)
+	}
+}
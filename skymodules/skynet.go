@@ -3,6 +3,7 @@ package skymodules
 import (
 	"context"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
@@ -47,6 +48,24 @@ const (
 	monetizationLotteryEntropy = 32
 )
 
+const (
+	// TryFilesPathPlaceholder is replaced with the requested path when a
+	// tryfile is resolved, e.g. `$path.html` serves `/about.html` for a
+	// request to `/about`.
+	TryFilesPathPlaceholder = "$path"
+
+	// TryFilesWildcard is a tryfile path segment which is replaced with the
+	// requested path and then each of its parent directories, nearest first.
+	// E.g. `/*/index.html` serves the closest `index.html` of a request to a
+	// nested route of a single-page app.
+	TryFilesWildcard = "*"
+
+	// tryFilesMaxCandidates is the maximum number of paths a single tryfile
+	// pattern is resolved to. It bounds the work done for requests with deeply
+	// nested paths.
+	tryFilesMaxCandidates = 32
+)
+
 var (
 	// DefaultTryFilesValue is the value of tryfiles we set on each skyfile,
 	// if none is specified and defaultpath and disabledefaultpath are also
@@ -186,6 +205,43 @@ type (
 
 		// ErrorPages overrides the content we serve for some error codes.
 		ErrorPages map[int]string
+
+		// ExtraMetadata is an application-specific JSON object which is
+		// stored in the skyfile's metadata.
+		ExtraMetadata json.RawMessage
+
+		// Headers are custom response headers which are stored in the
+		// skyfile's metadata and set when the skyfile is downloaded. Only
+		// the headers in SkyfileAllowedHeaders are allowed.
+		Headers map[string]string
+
+		// Archive indicates that the skyfile should be uploaded to the
+		// renter's archive hosts using the archive erasure coding settings.
+		Archive bool
+
+		// Profile is the name of the allowance profile whose hosts the
+		// fanout of the skyfile is uploaded to. The base sector is always
+		// uploaded to the regular hosts.
+		Profile string
+
+		// AllowedHosts restricts the upload of both the base sector and the
+		// fanout to the given hosts. If empty, the skyfile may use any host.
+		AllowedHosts []types.SiaPublicKey
+
+		// SessionID identifies the upload session of a large skyfile. If the
+		// upload is interrupted, uploading the same content with the same
+		// session ID resumes the upload.
+		SessionID string
+
+		// ContentHashes indicates that the SHA-256 and BLAKE3 hashes of the
+		// skyfile's content should be computed during the upload and stored
+		// in the skyfile's metadata.
+		ContentHashes bool
+
+		// TTL is the duration after which the skyfile expires. Expired
+		// skyfiles are deleted by the renter instead of being repaired. A
+		// zero TTL means that the skyfile doesn't expire.
+		TTL time.Duration
 	}
 
 	// SkyfileMultipartUploadParameters defines the parameters specific to
@@ -218,18 +274,46 @@ type (
 		// codes.
 		ErrorPages map[int]string
 
+		// ExtraMetadata is an application-specific JSON object which is
+		// stored in the skyfile's metadata.
+		ExtraMetadata json.RawMessage
+
+		// Headers are custom response headers which are set when the
+		// skyfile is downloaded.
+		Headers map[string]string
+
 		// ContentType indicates the media of the data supplied by the reader.
 		ContentType string
+
+		// SessionID identifies the upload session of a large skyfile.
+		SessionID string
 	}
 
 	// SkyfilePinParameters defines the parameters specific to pinning a
 	// skylink. See SkyfileUploadParameters for a detailed description of the
-	// fields.
+	// fields. DependencyDepth is the number of levels of skylinks referenced
+	// by the pinned content which are pinned as well.
 	SkyfilePinParameters struct {
 		SiaPath             SiaPath `json:"siapath"`
 		Force               bool    `json:"force"`
 		Root                bool    `json:"root"`
 		BaseChunkRedundancy uint8   `json:"basechunkredundancy"`
+		DependencyDepth     uint64  `json:"dependencydepth"`
+	}
+
+	// SkylinkDependency is a node of the dependency graph which is discovered
+	// when pinning a skylink together with the skylinks it references.
+	SkylinkDependency struct {
+		// Skylink is the skylink of the node.
+		Skylink string `json:"skylink"`
+		// Depth is the distance of the node from the pinned skylink.
+		Depth uint64 `json:"depth"`
+		// Dependencies are the skylinks referenced by the node's content.
+		Dependencies []string `json:"dependencies"`
+		// Pinned indicates whether the skylink is pinned.
+		Pinned bool `json:"pinned"`
+		// Error is set if pinning or crawling the skylink failed.
+		Error string `json:"error,omitempty"`
 	}
 
 	// SkyfileMetadata is all of the metadata that gets placed into the first
@@ -238,14 +322,27 @@ type (
 	// into the leading bytes of the skyfile, meaning that this struct can be
 	// extended without breaking compatibility.
 	SkyfileMetadata struct {
-		Filename           string          `json:"filename"`
-		Length             uint64          `json:"length"`
-		Mode               os.FileMode     `json:"mode,omitempty"`
-		Subfiles           SkyfileSubfiles `json:"subfiles,omitempty"`
-		DefaultPath        string          `json:"defaultpath,omitempty"`
-		DisableDefaultPath bool            `json:"disabledefaultpath,omitempty"`
-		TryFiles           []string        `json:"tryfiles,omitempty"`
-		ErrorPages         map[int]string  `json:"errorpages,omitempty"`
+		Filename           string            `json:"filename"`
+		Length             uint64            `json:"length"`
+		Mode               os.FileMode       `json:"mode,omitempty"`
+		Subfiles           SkyfileSubfiles   `json:"subfiles,omitempty"`
+		DefaultPath        string            `json:"defaultpath,omitempty"`
+		DisableDefaultPath bool              `json:"disabledefaultpath,omitempty"`
+		TryFiles           []string          `json:"tryfiles,omitempty"`
+		ErrorPages         map[int]string    `json:"errorpages,omitempty"`
+		ExtraMetadata      json.RawMessage   `json:"extrametadata,omitempty"`
+		Headers            map[string]string `json:"headers,omitempty"`
+		Hashes             *SkyfileHashes    `json:"hashes,omitempty"`
+	}
+
+	// SkyfileHashes are additional hashes of a skyfile's content which are
+	// computed during the upload. They allow for cross-referencing a skylink
+	// with other content-addressed systems without downloading the content.
+	// The hashes are hex encoded and cover the content of all subfiles in the
+	// order they were uploaded in.
+	SkyfileHashes struct {
+		BLAKE3 string `json:"blake3"`
+		SHA256 string `json:"sha256"`
 	}
 
 	// SkynetPortal contains information identifying a Skynet portal.
@@ -362,10 +459,12 @@ func (sm SkyfileMetadata) ForPath(path string) (SkyfileMetadata, bool, uint64, u
 	// All paths must be absolute.
 	path = EnsurePrefix(path, "/")
 	metadata := SkyfileMetadata{
-		Filename:   path,
-		Subfiles:   make(SkyfileSubfiles),
-		TryFiles:   sm.TryFiles,
-		ErrorPages: sm.ErrorPages,
+		Filename:      path,
+		Subfiles:      make(SkyfileSubfiles),
+		TryFiles:      sm.TryFiles,
+		ErrorPages:    sm.ErrorPages,
+		ExtraMetadata: sm.ExtraMetadata,
+		Headers:       sm.Headers,
 	}
 
 	// Try to find an exact match
@@ -399,6 +498,10 @@ func (sm SkyfileMetadata) ForPath(path string) (SkyfileMetadata, bool, uint64, u
 	for _, file := range metadata.Subfiles {
 		metadata.Length += file.Len
 	}
+	// The hashes still apply if the path covers the whole content.
+	if offset == 0 && metadata.Length == sm.Length {
+		metadata.Hashes = sm.Hashes
+	}
 	return metadata, isFile, offset, metadata.size()
 }
 
@@ -507,7 +610,10 @@ func (sm SkyfileMetadata) offset() uint64 {
 }
 
 // determinePathBasedOnTryfiles determines if we should serve a different path
-// based on the given metadata.
+// based on the given metadata. The tryfiles are checked in order and the first
+// one which resolves to an existing subfile is served. Tryfile patterns, see
+// TryFilesPathPlaceholder and TryFilesWildcard, are always resolved relative to
+// the root of the skyfile.
 func (sm SkyfileMetadata) determinePathBasedOnTryfiles(path string) string {
 	if sm.Subfiles == nil {
 		return path
@@ -515,6 +621,14 @@ func (sm SkyfileMetadata) determinePathBasedOnTryfiles(path string) string {
 	file := strings.Trim(path, "/")
 	if _, exists := sm.Subfiles[file]; !exists {
 		for _, tf := range sm.TryFiles {
+			if IsTryFilesPattern(tf) {
+				for _, candidate := range tryFilesPatternCandidates(tf, file) {
+					if _, exists = sm.Subfiles[candidate]; exists {
+						return EnsurePrefix(candidate, "/")
+					}
+				}
+				continue
+			}
 			// If we encounter an absolute-path tryfile, and it exists, we stop
 			// searching.
 			_, exists = sm.Subfiles[strings.Trim(tf, "/")]
@@ -532,6 +646,75 @@ func (sm SkyfileMetadata) determinePathBasedOnTryfiles(path string) string {
 	return path
 }
 
+// IsTryFilesPattern returns true if the tryfile contains the path placeholder
+// or a wildcard.
+func IsTryFilesPattern(tf string) bool {
+	if strings.Contains(tf, TryFilesPathPlaceholder) {
+		return true
+	}
+	for _, segment := range strings.Split(tf, "/") {
+		if segment == TryFilesWildcard {
+			return true
+		}
+	}
+	return false
+}
+
+// tryFilesPatternCandidates returns the subfile paths the tryfile pattern
+// resolves to for the requested file, in the order in which they should be
+// tried. The placeholder is replaced once and the replacement isn't expanded
+// again, so a requested path containing a pattern can't cause a loop. Invalid
+// patterns don't resolve to any path.
+func tryFilesPatternCandidates(pattern, file string) []string {
+	if validateTryFilesPattern(pattern) != nil {
+		return nil
+	}
+	pattern = strings.Trim(pattern, "/")
+
+	// Without a wildcard the pattern resolves to a single path.
+	segments := strings.Split(pattern, "/")
+	wildcard := -1
+	for i, segment := range segments {
+		if segment == TryFilesWildcard {
+			wildcard = i
+		}
+	}
+	if wildcard == -1 {
+		return []string{cleanTryFilesCandidate(strings.Replace(pattern, TryFilesPathPlaceholder, file, 1))}
+	}
+
+	// Replace the wildcard with the requested path and each of its parent
+	// directories, nearest first, down to the root.
+	prefix := strings.Join(segments[:wildcard], "/")
+	suffix := strings.Join(segments[wildcard+1:], "/")
+	dir := file
+	var candidates []string
+	for len(candidates) < tryFilesMaxCandidates {
+		candidates = append(candidates, cleanTryFilesCandidate(prefix+"/"+dir+"/"+suffix))
+		if dir == "" {
+			break
+		}
+		if i := strings.LastIndex(dir, "/"); i >= 0 {
+			dir = dir[:i]
+		} else {
+			dir = ""
+		}
+	}
+	return candidates
+}
+
+// cleanTryFilesCandidate removes the empty segments of a resolved tryfile
+// pattern, e.g. the ones left by an empty requested path.
+func cleanTryFilesCandidate(candidate string) string {
+	var segments []string
+	for _, segment := range strings.Split(candidate, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
 // SkyfileLayout explains the layout information that is used for storing data
 // inside of the skyfile. The SkyfileLayout always appears as the first bytes
 // of the leading chunk.
@@ -656,12 +839,15 @@ func (sl SkyfileLayout) Encode() []byte {
 // written and its length. Its filename can potentially include a '/' character
 // as nested files and directories are allowed within a single Skyfile, but it
 // is not allowed to contain ./, ../, be empty, or start with a forward slash.
+// Modified is the optional modification time of the subfile as a unix
+// timestamp.
 type SkyfileSubfileMetadata struct {
 	FileMode    os.FileMode `json:"mode,omitempty,siamismatch"` // different json name for compat reasons
 	Filename    string      `json:"filename,omitempty"`
 	ContentType string      `json:"contenttype,omitempty"`
 	Offset      uint64      `json:"offset,omitempty"`
 	Len         uint64      `json:"len,omitempty"`
+	Modified    int64       `json:"modified,omitempty"`
 }
 
 // IsDir implements the os.FileInfo interface for SkyfileSubfileMetadata.
@@ -674,9 +860,11 @@ func (sm SkyfileSubfileMetadata) Mode() os.FileMode {
 	return sm.FileMode
 }
 
-// ModTime implements the os.FileInfo interface for SkyfileSubfileMetadata.
+// ModTime implements the os.FileInfo interface for SkyfileSubfileMetadata. If
+// the subfile has no modification time, the unix epoch is returned to keep
+// archives of the subfile reproducible.
 func (sm SkyfileSubfileMetadata) ModTime() time.Time {
-	return time.Time{} // no modtime available
+	return time.Unix(sm.Modified, 0).UTC()
 }
 
 // Name implements the os.FileInfo interface for SkyfileSubfileMetadata.
@@ -785,6 +973,84 @@ func computeMonetizationPayout(amt, base types.Currency, rand io.Reader) (types.
 type RegistryEntry struct {
 	modules.SignedRegistryValue
 	PubKey types.SiaPublicKey
+
+	// TrustedHostAgreement contains which of the trusted registry hosts
+	// returned the entry when it was read. It's only set if trusted registry
+	// hosts are configured.
+	TrustedHostAgreement []RegistryHostAgreement
+}
+
+// RegistryHostAgreement describes the response of a trusted registry host to
+// a registry read.
+type RegistryHostAgreement struct {
+	HostKey types.SiaPublicKey `json:"hostkey"`
+
+	// Responded is true if the host returned an entry before the read
+	// finished and Revision is the revision of that entry.
+	Responded bool   `json:"responded"`
+	Revision  uint64 `json:"revision"`
+
+	// Agrees is true if the host returned the accepted entry.
+	Agrees bool `json:"agrees"`
+}
+
+// TrustedRegistryHostsMode determines how the responses of trusted registry
+// hosts are treated.
+type TrustedRegistryHostsMode string
+
+// TrustedRegistryHosts are hosts whose registry responses are required or
+// weighted higher than the ones of other hosts before a registry value is
+// accepted. That prevents a single malicious host which responds fast from
+// skewing the results of registry reads.
+type TrustedRegistryHosts struct {
+	Hosts []types.SiaPublicKey     `json:"hosts"`
+	Mode  TrustedRegistryHostsMode `json:"mode"`
+
+	// Weight is the weight of a response from a trusted host in weighted
+	// mode. Responses from other hosts have a weight of 1.
+	Weight uint64 `json:"weight"`
+}
+
+const (
+	// TrustedRegistryHostsModeRequired only accepts registry values which were
+	// returned by at least one trusted host.
+	TrustedRegistryHostsModeRequired TrustedRegistryHostsMode = "required"
+
+	// TrustedRegistryHostsModeWeighted prefers registry values which were
+	// returned by hosts with a total weight of at least the weight of a
+	// trusted host. If there is no such value, the best value is accepted.
+	TrustedRegistryHostsModeWeighted TrustedRegistryHostsMode = "weighted"
+)
+
+// Validate validates the trusted registry hosts.
+func (trh TrustedRegistryHosts) Validate() error {
+	if len(trh.Hosts) == 0 {
+		return nil
+	}
+	switch trh.Mode {
+	case TrustedRegistryHostsModeRequired:
+	case TrustedRegistryHostsModeWeighted:
+		if trh.Weight < 2 {
+			return errors.New("weight of trusted registry hosts must be at least 2")
+		}
+	default:
+		return fmt.Errorf("unknown trusted registry hosts mode '%v'", trh.Mode)
+	}
+	seen := make(map[string]struct{})
+	for _, host := range trh.Hosts {
+		if _, exists := seen[host.String()]; exists {
+			return fmt.Errorf("duplicate trusted registry host %v", host)
+		}
+		seen[host.String()] = struct{}{}
+	}
+	return nil
+}
+
+// RegistryUpdateProof proves that a host accepted a registry update. It
+// contains the host's public key and the signed entry the host accepted.
+type RegistryUpdateProof struct {
+	HostKey types.SiaPublicKey
+	RegistryEntry
 }
 
 // Verify verifies the entry.
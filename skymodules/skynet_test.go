@@ -291,6 +291,41 @@ func TestSkyfileMetadata_ForPath(t *testing.T) {
 	}
 }
 
+// TestSkyfileMetadata_ForPathHashes verifies that ForPath only keeps the
+// content hashes if the path covers the whole content of the skyfile.
+func TestSkyfileMetadata_ForPathHashes(t *testing.T) {
+	t.Parallel()
+
+	hashes := &SkyfileHashes{BLAKE3: "blake3", SHA256: "sha256"}
+	metadata := SkyfileMetadata{
+		Filename: "dir",
+		Length:   3,
+		Subfiles: SkyfileSubfiles{
+			"dir/file1": SkyfileSubfileMetadata{Filename: "dir/file1", Offset: 0, Len: 1},
+			"dir/file2": SkyfileSubfileMetadata{Filename: "dir/file2", Offset: 1, Len: 2},
+		},
+		Hashes: hashes,
+	}
+	if md, _, _, _ := metadata.ForPath("/dir"); md.Hashes != hashes {
+		t.Fatal("hashes should be kept for the whole content", md.Hashes)
+	}
+	if md, _, _, _ := metadata.ForPath("/dir/file1"); md.Hashes != nil {
+		t.Fatal("hashes shouldn't be kept for a subfile", md.Hashes)
+	}
+	if md, _, _, _ := metadata.ForPath("/dir/file2"); md.Hashes != nil {
+		t.Fatal("hashes shouldn't be kept for a subfile", md.Hashes)
+	}
+
+	// A single file covers the whole content.
+	metadata.Length = 1
+	metadata.Subfiles = SkyfileSubfiles{
+		"file": SkyfileSubfileMetadata{Filename: "file", Offset: 0, Len: 1},
+	}
+	if md, _, _, _ := metadata.ForPath("/file"); md.Hashes != hashes {
+		t.Fatal("hashes should be kept for a single file", md.Hashes)
+	}
+}
+
 // TestSkyfileMetadata_IsDirectory is a table test for the IsDirectory method.
 func TestSkyfileMetadata_IsDirectory(t *testing.T) {
 	tests := []struct {
@@ -730,3 +765,139 @@ func TestDeterminePathBasedOnTryFiles(t *testing.T) {
 		})
 	}
 }
+
+// TestDeterminePathBasedOnTryFilesPatterns makes sure that tryfile patterns
+// are resolved in the right order.
+func TestDeterminePathBasedOnTryFilesPatterns(t *testing.T) {
+	t.Parallel()
+
+	subfiles := SkyfileSubfiles{
+		"index.html":               SkyfileSubfileMetadata{Filename: "index.html"},
+		"about.html":               SkyfileSubfileMetadata{Filename: "about.html"},
+		"app/index.html":           SkyfileSubfileMetadata{Filename: "app/index.html"},
+		"app/settings/index.html":  SkyfileSubfileMetadata{Filename: "app/settings/index.html"},
+		"app/settings/export.html": SkyfileSubfileMetadata{Filename: "app/settings/export.html"},
+	}
+
+	tests := []struct {
+		name         string
+		tryfiles     []string
+		requestPath  string
+		expectedPath string
+	}{
+		{
+			name:         "placeholder",
+			tryfiles:     []string{"$path.html"},
+			requestPath:  "/about",
+			expectedPath: "/about.html",
+		},
+		{
+			name:         "placeholder, nested",
+			tryfiles:     []string{"$path.html"},
+			requestPath:  "/app/settings/export",
+			expectedPath: "/app/settings/export.html",
+		},
+		{
+			name:         "placeholder, no match",
+			tryfiles:     []string{"$path.html"},
+			requestPath:  "/contact",
+			expectedPath: "/contact",
+		},
+		{
+			name:         "placeholder directory",
+			tryfiles:     []string{"/$path/index.html"},
+			requestPath:  "/app/",
+			expectedPath: "/app/index.html",
+		},
+		{
+			name:         "wildcard, nearest index",
+			tryfiles:     []string{"/*/index.html"},
+			requestPath:  "/app/settings/profile/edit",
+			expectedPath: "/app/settings/index.html",
+		},
+		{
+			name:         "wildcard, parent index",
+			tryfiles:     []string{"/*/index.html"},
+			requestPath:  "/app/users/1",
+			expectedPath: "/app/index.html",
+		},
+		{
+			name:         "wildcard, root index",
+			tryfiles:     []string{"/*/index.html"},
+			requestPath:  "/blog/post",
+			expectedPath: "/index.html",
+		},
+		{
+			name:         "existing file wins",
+			tryfiles:     []string{"/*/index.html"},
+			requestPath:  "/about.html",
+			expectedPath: "/about.html",
+		},
+		{
+			name:         "tryfiles are checked in order",
+			tryfiles:     []string{"$path.html", "/*/index.html"},
+			requestPath:  "/app/settings/export",
+			expectedPath: "/app/settings/export.html",
+		},
+		{
+			name:         "tryfiles are checked in order, fallback",
+			tryfiles:     []string{"$path.html", "/*/index.html"},
+			requestPath:  "/app/settings/import",
+			expectedPath: "/app/settings/index.html",
+		},
+		{
+			name:         "requested path isn't expanded",
+			tryfiles:     []string{"$path.html"},
+			requestPath:  "/$path",
+			expectedPath: "/$path",
+		},
+		{
+			name:         "invalid pattern",
+			tryfiles:     []string{"/*/*/index.html"},
+			requestPath:  "/app/settings/profile",
+			expectedPath: "/app/settings/profile",
+		},
+	}
+	for _, tt := range tests {
+		meta := SkyfileMetadata{
+			Subfiles: subfiles,
+			TryFiles: tt.tryfiles,
+		}
+		path := meta.determinePathBasedOnTryfiles(tt.requestPath)
+		if path != tt.expectedPath {
+			t.Fatalf("%v: expected path to be '%s', got '%s'", tt.name, tt.expectedPath, path)
+		}
+	}
+
+	// The number of candidates of deeply nested paths is limited.
+	deep := strings.Repeat("/a", 2*tryFilesMaxCandidates)
+	candidates := tryFilesPatternCandidates("/*/index.html", strings.Trim(deep, "/"))
+	if len(candidates) != tryFilesMaxCandidates {
+		t.Fatal("wrong number of candidates", len(candidates))
+	}
+}
+
+// TestTrustedRegistryHostsValidate tests validating the trusted registry
+// hosts.
+func TestTrustedRegistryHostsValidate(t *testing.T) {
+	t.Parallel()
+
+	hpk1 := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: fastrand.Bytes(crypto.PublicKeySize)}
+	hpk2 := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: fastrand.Bytes(crypto.PublicKeySize)}
+	tests := []struct {
+		trh   TrustedRegistryHosts
+		valid bool
+	}{
+		{TrustedRegistryHosts{}, true},
+		{TrustedRegistryHosts{Hosts: []types.SiaPublicKey{hpk1, hpk2}, Mode: TrustedRegistryHostsModeRequired}, true},
+		{TrustedRegistryHosts{Hosts: []types.SiaPublicKey{hpk1}, Mode: TrustedRegistryHostsModeWeighted, Weight: 2}, true},
+		{TrustedRegistryHosts{Hosts: []types.SiaPublicKey{hpk1}, Mode: TrustedRegistryHostsModeWeighted, Weight: 1}, false},
+		{TrustedRegistryHosts{Hosts: []types.SiaPublicKey{hpk1}, Mode: "unknown"}, false},
+		{TrustedRegistryHosts{Hosts: []types.SiaPublicKey{hpk1, hpk1}, Mode: TrustedRegistryHostsModeRequired}, false},
+	}
+	for i, test := range tests {
+		if err := test.trh.Validate(); (err == nil) != test.valid {
+			t.Errorf("%v: expected valid %v but got %v", i, test.valid, err)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package skymodules
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+const (
+	// ContentTypeSniffLen is the number of bytes at the beginning of a file
+	// which are used to sniff its content type.
+	ContentTypeSniffLen = 512
+
+	// contentTypeOctetStream is the generic content type for binary data. It
+	// is the default content type of files within multipart uploads.
+	contentTypeOctetStream = "application/octet-stream"
+)
+
+type (
+	// ContentTypeOverrides maps lower-case file extensions, including the
+	// leading dot, to the content type files with that extension are served
+	// with. The overrides take precedence over the content types stored in a
+	// skyfile's metadata.
+	ContentTypeOverrides map[string]string
+)
+
+// ContentType returns the content type a file with the given name should be
+// served with. If there is no override for the file's extension, the given
+// content type is returned.
+func (cto ContentTypeOverrides) ContentType(filename, contentType string) string {
+	if ct, exists := cto[strings.ToLower(path.Ext(filename))]; exists {
+		return ct
+	}
+	return contentType
+}
+
+// MetadataContentType returns the content type the given metadata should be
+// served with after applying the overrides.
+func (cto ContentTypeOverrides) MetadataContentType(md SkyfileMetadata) string {
+	filename := md.Filename
+	if len(md.Subfiles) == 1 {
+		for _, sf := range md.Subfiles {
+			filename = sf.Filename
+		}
+	}
+	return cto.ContentType(filename, md.ContentType())
+}
+
+// SniffContentType returns the content type of a file given the content type
+// provided by the uploader and the first bytes of the file's data. The data is
+// only sniffed if the uploader didn't provide a specific content type. If the
+// data doesn't match any known signature, the provided content type is
+// returned.
+func SniffContentType(contentType string, data []byte) string {
+	if contentType != "" && contentType != contentTypeOctetStream {
+		return contentType
+	}
+	if len(data) == 0 {
+		return contentType
+	}
+	if len(data) > ContentTypeSniffLen {
+		data = data[:ContentTypeSniffLen]
+	}
+	sniffed := http.DetectContentType(data)
+	if sniffed == contentTypeOctetStream {
+		return contentType
+	}
+	return sniffed
+}
@@ -0,0 +1,74 @@
+package skymodules
+
+import "testing"
+
+// TestSniffContentType is a unit test for SniffContentType.
+func TestSniffContentType(t *testing.T) {
+	t.Parallel()
+
+	html := []byte("<html><body>hello</body></html>")
+	tests := []struct {
+		contentType string
+		data        []byte
+		expected    string
+	}{
+		// Sniff unknown and generic content types.
+		{"", html, "text/html; charset=utf-8"},
+		{"application/octet-stream", html, "text/html; charset=utf-8"},
+		// Keep specific content types.
+		{"text/plain", html, "text/plain"},
+		// Keep the content type if there is no data.
+		{"", nil, ""},
+		{"application/octet-stream", nil, "application/octet-stream"},
+		// Keep the content type if the data is unknown.
+		{"application/octet-stream", []byte{0, 1, 2, 3}, "application/octet-stream"},
+		{"", []byte{0, 1, 2, 3}, ""},
+	}
+	for i, test := range tests {
+		if ct := SniffContentType(test.contentType, test.data); ct != test.expected {
+			t.Errorf("%v: expected %v but got %v", i, test.expected, ct)
+		}
+	}
+}
+
+// TestContentTypeOverrides is a unit test for the ContentTypeOverrides.
+func TestContentTypeOverrides(t *testing.T) {
+	t.Parallel()
+
+	cto := ContentTypeOverrides{
+		".wasm": "application/wasm",
+	}
+
+	// Overrides are applied based on the extension regardless of its case.
+	if ct := cto.ContentType("dir/file.WASM", "application/octet-stream"); ct != "application/wasm" {
+		t.Fatal("unexpected content type", ct)
+	}
+	if ct := cto.ContentType("file.txt", "text/plain"); ct != "text/plain" {
+		t.Fatal("unexpected content type", ct)
+	}
+	if ct := ContentTypeOverrides(nil).ContentType("file.wasm", "text/plain"); ct != "text/plain" {
+		t.Fatal("unexpected content type", ct)
+	}
+
+	// The metadata's content type is overridden based on the filename of its
+	// only subfile.
+	md := SkyfileMetadata{
+		Filename: "skyfile",
+		Subfiles: SkyfileSubfiles{
+			"app.wasm": SkyfileSubfileMetadata{Filename: "app.wasm", ContentType: "application/octet-stream"},
+		},
+	}
+	if ct := cto.MetadataContentType(md); ct != "application/wasm" {
+		t.Fatal("unexpected content type", ct)
+	}
+	md.Subfiles["index.html"] = SkyfileSubfileMetadata{Filename: "index.html", ContentType: "text/html"}
+	if ct := cto.MetadataContentType(md); ct != "" {
+		t.Fatal("unexpected content type", ct)
+	}
+
+	// Single files without subfiles use the metadata's filename.
+	md = SkyfileMetadata{Filename: "app.wasm"}
+	if ct := cto.MetadataContentType(md); ct != "application/wasm" {
+		t.Fatal("unexpected content type", ct)
+	}
+}
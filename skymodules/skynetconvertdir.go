@@ -0,0 +1,83 @@
+package skymodules
+
+// The Skynet bulk conversion subsystem converts all siafiles within a
+// directory to skyfiles in the background. The progress of a conversion is
+// persisted which allows it to resume after a restart of the renter without
+// converting any file twice.
+
+import (
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+const (
+	// SkynetConvertDirStatusRunning indicates that a conversion is in
+	// progress.
+	SkynetConvertDirStatusRunning = SkynetConvertDirStatus("running")
+	// SkynetConvertDirStatusComplete indicates that all files of a conversion
+	// were processed.
+	SkynetConvertDirStatusComplete = SkynetConvertDirStatus("complete")
+	// SkynetConvertDirStatusFailed indicates that a conversion failed before
+	// all files were processed.
+	SkynetConvertDirStatusFailed = SkynetConvertDirStatus("failed")
+)
+
+const (
+	// DefaultSkynetConvertDirConcurrency is the default number of files which
+	// are converted in parallel.
+	DefaultSkynetConvertDirConcurrency = 4
+
+	// MaxSkynetConvertDirConcurrency is the max number of files which can be
+	// converted in parallel.
+	MaxSkynetConvertDirConcurrency = 32
+)
+
+var (
+	// ErrConvertDirJobNotFound is returned if a conversion can't be found.
+	ErrConvertDirJobNotFound = errors.New("directory conversion not found")
+)
+
+type (
+	// SkynetConvertDirStatus is the status of a directory conversion.
+	SkynetConvertDirStatus string
+
+	// SkynetConvertDirFile is the result of converting a single siafile.
+	SkynetConvertDirFile struct {
+		Skylink string `json:"skylink,omitempty"`
+		Error   string `json:"error,omitempty"`
+	}
+
+	// SkynetConvertDirJob describes the conversion of all siafiles within a
+	// directory to skyfiles. The base sector of every converted file is
+	// stored at the file's path relative to SiaPath within DestSiaPath.
+	SkynetConvertDirJob struct {
+		ID          string                 `json:"id"`
+		SiaPath     SiaPath                `json:"siapath"`
+		DestSiaPath SiaPath                `json:"destsiapath"`
+		Recursive   bool                   `json:"recursive"`
+		Concurrency int                    `json:"concurrency"`
+		Status      SkynetConvertDirStatus `json:"status"`
+		Error       string                 `json:"error,omitempty"`
+
+		// Total is the number of siafiles which are converted by the job.
+		// Converted, Failed and Skipped are the number of processed files.
+		// Files which are skyfiles already are skipped.
+		Total     uint64 `json:"total"`
+		Converted uint64 `json:"converted"`
+		Failed    uint64 `json:"failed"`
+		Skipped   uint64 `json:"skipped"`
+
+		// Files contains the results of the processed files by their
+		// siapath.
+		Files map[string]SkynetConvertDirFile `json:"files"`
+
+		CreatedAt  time.Time `json:"createdat"`
+		LastUpdate time.Time `json:"lastupdate"`
+	}
+)
+
+// Processed returns the number of files which were processed.
+func (j SkynetConvertDirJob) Processed() uint64 {
+	return j.Converted + j.Failed + j.Skipped
+}
@@ -0,0 +1,51 @@
+package skymodules
+
+// The Skynet deletion subsystem removes a skylink from the portal. Deleting a
+// skylink writes a tombstone which prevents the node from pinning or serving
+// the skylink again before its siafiles are removed and its cached data is
+// purged in the background.
+
+import (
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+const (
+	// SkynetDeleteStatusRunning indicates that a deletion is in progress.
+	SkynetDeleteStatusRunning = SkynetDeleteStatus("running")
+	// SkynetDeleteStatusComplete indicates that all siafiles of a skylink
+	// were removed and its cached data was purged.
+	SkynetDeleteStatusComplete = SkynetDeleteStatus("complete")
+	// SkynetDeleteStatusFailed indicates that a deletion failed before all
+	// siafiles were removed.
+	SkynetDeleteStatusFailed = SkynetDeleteStatus("failed")
+)
+
+var (
+	// ErrSkylinkDeleted is returned if a skylink was deleted from the node.
+	ErrSkylinkDeleted = errors.New("skylink was deleted")
+
+	// ErrSkynetDeleteJobNotFound is returned if a deletion can't be found.
+	ErrSkynetDeleteJobNotFound = errors.New("skylink deletion not found")
+)
+
+type (
+	// SkynetDeleteStatus is the status of a skylink deletion.
+	SkynetDeleteStatus string
+
+	// SkynetDeleteJob describes the deletion of a skylink. Skylink is always
+	// the V1 skylink the deleted skylink resolved to.
+	SkynetDeleteJob struct {
+		ID      string             `json:"id"`
+		Skylink string             `json:"skylink"`
+		Status  SkynetDeleteStatus `json:"status"`
+		Error   string             `json:"error,omitempty"`
+
+		// DeletedFiles contains the siapaths of the removed siafiles.
+		DeletedFiles []SiaPath `json:"deletedfiles"`
+
+		CreatedAt  time.Time `json:"createdat"`
+		LastUpdate time.Time `json:"lastupdate"`
+	}
+)
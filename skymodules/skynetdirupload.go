@@ -0,0 +1,125 @@
+package skymodules
+
+// The Skynet directory upload subsystem allows a directory to be uploaded as a
+// single skyfile by uploading its files individually, and potentially in
+// parallel, before finalizing them into one skyfile with the correct subfile
+// metadata.
+
+import (
+	"os"
+	"sort"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+var (
+	// ErrDirUploadSessionNotFound is returned if a directory upload session
+	// can't be found.
+	ErrDirUploadSessionNotFound = errors.New("directory upload session not found")
+
+	// ErrDirUploadSessionFinalizing is returned if a directory upload session
+	// is modified while it is being finalized.
+	ErrDirUploadSessionFinalizing = errors.New("directory upload session is being finalized")
+
+	// ErrDirUploadFileNotInManifest is returned if a file is uploaded to a
+	// directory upload session with a manifest that doesn't contain the file.
+	ErrDirUploadFileNotInManifest = errors.New("file is not part of the directory upload manifest")
+
+	// ErrDirUploadIncomplete is returned if a directory upload session is
+	// finalized before all of the files in its manifest were uploaded.
+	ErrDirUploadIncomplete = errors.New("directory upload session is missing files")
+)
+
+type (
+	// SkynetDirUploadFile describes a single file of a directory upload
+	// session. When used as part of a manifest, Len is the expected length of
+	// the file.
+	SkynetDirUploadFile struct {
+		Filename    string      `json:"filename"`
+		ContentType string      `json:"contenttype,omitempty"`
+		Mode        os.FileMode `json:"mode,omitempty"`
+		Len         uint64      `json:"len"`
+		Complete    bool        `json:"complete"`
+	}
+
+	// SkynetDirUploadSession describes an ongoing directory upload. The
+	// session collects the uploaded files until it is finalized into a single
+	// skyfile.
+	SkynetDirUploadSession struct {
+		ID      string  `json:"id"`
+		SiaPath SiaPath `json:"siapath"`
+
+		// The following fields are used for the skyfile's metadata and upload
+		// parameters once the session is finalized. See
+		// SkyfileUploadParameters for a detailed description of the fields.
+		BaseChunkRedundancy uint8          `json:"basechunkredundancy"`
+		DefaultPath         string         `json:"defaultpath,omitempty"`
+		DisableDefaultPath  bool           `json:"disabledefaultpath,omitempty"`
+		ErrorPages          map[int]string `json:"errorpages,omitempty"`
+		Filename            string         `json:"filename"`
+		Force               bool           `json:"force"`
+		TryFiles            []string       `json:"tryfiles,omitempty"`
+
+		// Manifest indicates whether the files of the session were declared
+		// upfront. If so, only declared files are accepted and the session can
+		// only be finalized once all of them were uploaded.
+		Manifest bool                           `json:"manifest"`
+		Files    map[string]SkynetDirUploadFile `json:"files"`
+
+		CreatedAt  time.Time `json:"createdat"`
+		LastUpdate time.Time `json:"lastupdate"`
+	}
+)
+
+// Metadata returns the SkyfileMetadata for the uploaded files of the session.
+// The subfiles are laid out sorted by filename, which makes the resulting
+// skyfile independent of the order in which the files were uploaded.
+func (s SkynetDirUploadSession) Metadata() (SkyfileMetadata, error) {
+	files := s.SortedFiles()
+	if s.Manifest && len(files) != len(s.Files) {
+		return SkyfileMetadata{}, ErrDirUploadIncomplete
+	}
+	if len(files) == 0 {
+		return SkyfileMetadata{}, errors.AddContext(ErrDirUploadIncomplete, "no files were uploaded")
+	}
+	md := SkyfileMetadata{
+		Filename:           s.Filename,
+		DefaultPath:        s.DefaultPath,
+		DisableDefaultPath: s.DisableDefaultPath,
+		TryFiles:           s.TryFiles,
+		ErrorPages:         s.ErrorPages,
+		Subfiles:           make(SkyfileSubfiles, len(files)),
+	}
+	for _, f := range files {
+		md.Subfiles[f.Filename] = SkyfileSubfileMetadata{
+			FileMode:    f.Mode,
+			Filename:    f.Filename,
+			ContentType: f.ContentType,
+			Offset:      md.Length,
+			Len:         f.Len,
+		}
+		md.Length += f.Len
+	}
+	// Use the filename of the only file if no filename was set, like it is
+	// done for multipart uploads.
+	if md.Filename == "" && len(files) == 1 {
+		md.Filename = files[0].Filename
+	}
+	return md, nil
+}
+
+// SortedFiles returns the completely uploaded files of the session sorted by
+// filename.
+func (s SkynetDirUploadSession) SortedFiles() []SkynetDirUploadFile {
+	files := make([]SkynetDirUploadFile, 0, len(s.Files))
+	for _, f := range s.Files {
+		if f.Complete {
+			files = append(files, f)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Filename < files[j].Filename
+	})
+	return files
+}
@@ -0,0 +1,84 @@
+package skymodules
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+// TestSkynetDirUploadSessionMetadata is a unit test for the Metadata method of
+// the SkynetDirUploadSession.
+func TestSkynetDirUploadSessionMetadata(t *testing.T) {
+	t.Parallel()
+
+	// An empty session has no metadata.
+	session := SkynetDirUploadSession{
+		Filename: "dir",
+		Files:    make(map[string]SkynetDirUploadFile),
+	}
+	_, err := session.Metadata()
+	if !errors.Contains(err, ErrDirUploadIncomplete) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// Add files out of order. The incomplete one should be ignored.
+	session.Files["b.txt"] = SkynetDirUploadFile{Filename: "b.txt", Len: 20, Complete: true, ContentType: "text/plain"}
+	session.Files["a/a.txt"] = SkynetDirUploadFile{Filename: "a/a.txt", Len: 10, Complete: true}
+	session.Files["c.txt"] = SkynetDirUploadFile{Filename: "c.txt", Len: 5}
+
+	md, err := session.Metadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.Length != 30 {
+		t.Fatal("wrong length", md.Length)
+	}
+	if len(md.Subfiles) != 2 {
+		t.Fatal("wrong number of subfiles", len(md.Subfiles))
+	}
+	a := md.Subfiles["a/a.txt"]
+	b := md.Subfiles["b.txt"]
+	if a.Offset != 0 || a.Len != 10 {
+		t.Fatal("wrong layout", a)
+	}
+	if b.Offset != 10 || b.Len != 20 || b.ContentType != "text/plain" {
+		t.Fatal("wrong layout", b)
+	}
+	if err := ValidateSkyfileMetadata(md); err != nil {
+		t.Fatal(err)
+	}
+
+	// With a manifest the incomplete file prevents the metadata from being
+	// created.
+	session.Manifest = true
+	_, err = session.Metadata()
+	if !errors.Contains(err, ErrDirUploadIncomplete) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// Complete the file.
+	c := session.Files["c.txt"]
+	c.Complete = true
+	session.Files["c.txt"] = c
+	md, err = session.Metadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.Length != 35 || md.Subfiles["c.txt"].Offset != 30 {
+		t.Fatal("wrong layout", md)
+	}
+
+	// A session with a single file uses that file's name.
+	session = SkynetDirUploadSession{
+		Files: map[string]SkynetDirUploadFile{
+			"file": {Filename: "file", Len: 1, Complete: true},
+		},
+	}
+	md, err = session.Metadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.Filename != "file" {
+		t.Fatal("wrong filename", md.Filename)
+	}
+}
@@ -0,0 +1,77 @@
+package skymodules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+const (
+	// SkyfileMaxExtraMetadataSize is the maximum size of the extra metadata
+	// of a skyfile. The extra metadata is stored in the base sector so it is
+	// kept small to leave room for the skyfile's data.
+	SkyfileMaxExtraMetadataSize = 1 << 10
+)
+
+var (
+	// ErrInvalidExtraMetadata is returned if the extra metadata of a skyfile
+	// is not a JSON object.
+	ErrInvalidExtraMetadata = errors.New("extra metadata must be a JSON object")
+
+	// ErrSkyfileExtraMetadataTooLarge is returned if the extra metadata of a
+	// skyfile exceeds SkyfileMaxExtraMetadataSize.
+	ErrSkyfileExtraMetadataTooLarge = errors.New("extra metadata exceeds the max extra metadata size")
+)
+
+// ParseExtraMetadata parses the extra metadata of a skyfile. The extra
+// metadata needs to be a JSON object which doesn't exceed
+// SkyfileMaxExtraMetadataSize after being compacted. The compacted extra
+// metadata is returned.
+func ParseExtraMetadata(b []byte) (json.RawMessage, error) {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, b); err != nil {
+		return nil, errors.Compose(ErrInvalidExtraMetadata, err)
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(buf.Bytes(), &obj); err != nil || obj == nil {
+		return nil, errors.Compose(ErrInvalidExtraMetadata, err)
+	}
+	if buf.Len() > SkyfileMaxExtraMetadataSize {
+		return nil, errors.AddContext(ErrSkyfileExtraMetadataTooLarge, fmt.Sprintf("%v > %v bytes", buf.Len(), SkyfileMaxExtraMetadataSize))
+	}
+	return buf.Bytes(), nil
+}
+
+// ParseExtraMetadataFilter parses a filter for the extra metadata of
+// skyfiles. The filter is a JSON object and matches all skyfiles with extra
+// metadata containing the same values for all of the filter's keys.
+func ParseExtraMetadataFilter(b []byte) (map[string]interface{}, error) {
+	var filter map[string]interface{}
+	if err := json.Unmarshal(b, &filter); err != nil || filter == nil {
+		return nil, errors.Compose(ErrInvalidExtraMetadata, err)
+	}
+	return filter, nil
+}
+
+// MatchesExtraMetadata returns true if the extra metadata of the skyfile
+// contains all keys of the filter with the same values. An empty filter
+// matches all skyfiles.
+func (sm SkyfileMetadata) MatchesExtraMetadata(filter map[string]interface{}) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	var extra map[string]interface{}
+	if err := json.Unmarshal(sm.ExtraMetadata, &extra); err != nil {
+		return false
+	}
+	for key, value := range filter {
+		v, exists := extra[key]
+		if !exists || !reflect.DeepEqual(v, value) {
+			return false
+		}
+	}
+	return true
+}
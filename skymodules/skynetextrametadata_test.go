@@ -0,0 +1,92 @@
+package skymodules
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+// TestParseExtraMetadata is a unit test for ParseExtraMetadata.
+func TestParseExtraMetadata(t *testing.T) {
+	t.Parallel()
+
+	// Valid extra metadata is compacted.
+	em, err := ParseExtraMetadata([]byte(`{ "app": "foo",  "n": 1 }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(em) != `{"app":"foo","n":1}` {
+		t.Fatal("wrong extra metadata", string(em))
+	}
+
+	// Anything but an object is invalid.
+	for _, invalid := range []string{"", "null", "1", `"app"`, `["app"]`, `{"app"`} {
+		_, err := ParseExtraMetadata([]byte(invalid))
+		if !errors.Contains(err, ErrInvalidExtraMetadata) {
+			t.Fatalf("expected %q to be invalid, got %v", invalid, err)
+		}
+	}
+
+	// The size is checked after compacting.
+	data := strings.Repeat("a", SkyfileMaxExtraMetadataSize-len(`{"d":""}`))
+	if _, err := ParseExtraMetadata([]byte(fmt.Sprintf(`{ "d": "%v" }`, data))); err != nil {
+		t.Fatal(err)
+	}
+	_, err = ParseExtraMetadata([]byte(fmt.Sprintf(`{"d":"%va"}`, data)))
+	if !errors.Contains(err, ErrSkyfileExtraMetadataTooLarge) {
+		t.Fatal("wrong error", err)
+	}
+}
+
+// TestMatchesExtraMetadata is a unit test for
+// SkyfileMetadata.MatchesExtraMetadata.
+func TestMatchesExtraMetadata(t *testing.T) {
+	t.Parallel()
+
+	sm := SkyfileMetadata{
+		ExtraMetadata: []byte(`{"app":"foo","n":1,"tags":["x","y"],"obj":{"a":true}}`),
+	}
+	tests := []struct {
+		filter  string
+		matches bool
+	}{
+		{filter: `{}`, matches: true},
+		{filter: `{"app":"foo"}`, matches: true},
+		{filter: `{"app":"foo","n":1}`, matches: true},
+		{filter: `{"n":1.0}`, matches: true},
+		{filter: `{"tags":["x","y"]}`, matches: true},
+		{filter: `{"obj":{"a":true}}`, matches: true},
+		{filter: `{"app":"bar"}`, matches: false},
+		{filter: `{"n":"1"}`, matches: false},
+		{filter: `{"tags":["y","x"]}`, matches: false},
+		{filter: `{"missing":null}`, matches: false},
+	}
+	for _, test := range tests {
+		filter, err := ParseExtraMetadataFilter([]byte(test.filter))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matches := sm.MatchesExtraMetadata(filter); matches != test.matches {
+			t.Errorf("filter %v: expected %v but got %v", test.filter, test.matches, matches)
+		}
+	}
+
+	// Skyfiles without extra metadata only match an empty filter.
+	filter, err := ParseExtraMetadataFilter([]byte(`{"app":"foo"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (SkyfileMetadata{}).MatchesExtraMetadata(filter) {
+		t.Fatal("expected no match")
+	}
+	if !(SkyfileMetadata{}).MatchesExtraMetadata(nil) {
+		t.Fatal("expected match")
+	}
+
+	// Invalid filters are rejected.
+	if _, err := ParseExtraMetadataFilter([]byte(`["app"]`)); !errors.Contains(err, ErrInvalidExtraMetadata) {
+		t.Fatal("wrong error", err)
+	}
+}
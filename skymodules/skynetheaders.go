@@ -0,0 +1,107 @@
+package skymodules
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/textproto"
+	"strings"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+const (
+	// SkyfileMaxHeadersSize is the maximum size of the JSON encoded custom
+	// response headers of a skyfile. Like the extra metadata, the headers are
+	// stored in the base sector so they are kept small.
+	SkyfileMaxHeadersSize = 1 << 10
+)
+
+var (
+	// SkyfileAllowedHeaders are the response headers which can be set by the
+	// uploader of a skyfile. They allow static sites to control caching and
+	// CORS without a proxy in front of the portal. Headers which are set by
+	// the portal itself, e.g. Content-Type or the Skynet headers, are not
+	// allowed.
+	SkyfileAllowedHeaders = map[string]struct{}{
+		"Access-Control-Allow-Credentials": {},
+		"Access-Control-Allow-Headers":     {},
+		"Access-Control-Allow-Methods":     {},
+		"Access-Control-Allow-Origin":      {},
+		"Access-Control-Expose-Headers":    {},
+		"Access-Control-Max-Age":           {},
+		"Cache-Control":                    {},
+		"Content-Language":                 {},
+		"Content-Security-Policy":          {},
+		"Expires":                          {},
+		"Referrer-Policy":                  {},
+		"Vary":                             {},
+		"X-Content-Type-Options":           {},
+		"X-Frame-Options":                  {},
+	}
+)
+
+var (
+	// ErrInvalidSkyfileHeaders is returned if the custom response headers of
+	// a skyfile are not a JSON object of strings.
+	ErrInvalidSkyfileHeaders = errors.New("headers must be a JSON object of strings")
+
+	// ErrSkyfileHeaderNotAllowed is returned if a custom response header of a
+	// skyfile is not in SkyfileAllowedHeaders.
+	ErrSkyfileHeaderNotAllowed = errors.New("header is not allowed")
+
+	// ErrSkyfileHeadersTooLarge is returned if the custom response headers of
+	// a skyfile exceed SkyfileMaxHeadersSize.
+	ErrSkyfileHeadersTooLarge = errors.New("headers exceed the max headers size")
+)
+
+// ParseSkyfileHeaders parses the custom response headers of a skyfile. The
+// headers are a JSON object which maps header names to values. The names are
+// canonicalized and need to be in SkyfileAllowedHeaders.
+func ParseSkyfileHeaders(b []byte) (map[string]string, error) {
+	var headers map[string]string
+	if err := json.Unmarshal(b, &headers); err != nil || headers == nil {
+		return nil, errors.Compose(ErrInvalidSkyfileHeaders, err)
+	}
+	canonical := make(map[string]string, len(headers))
+	for name, value := range headers {
+		name = textproto.CanonicalMIMEHeaderKey(name)
+		if err := validateSkyfileHeader(name, value); err != nil {
+			return nil, err
+		}
+		canonical[name] = value
+	}
+	encoded, err := json.Marshal(canonical)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to encode headers")
+	}
+	if len(encoded) > SkyfileMaxHeadersSize {
+		return nil, errors.AddContext(ErrSkyfileHeadersTooLarge, fmt.Sprintf("%v > %v bytes", len(encoded), SkyfileMaxHeadersSize))
+	}
+	return canonical, nil
+}
+
+// AllowedHeaders returns the custom response headers of the skyfile which are
+// allowed to be set. Skyfiles uploaded through the API only contain allowed
+// headers but the metadata of other skyfiles might contain any headers.
+func (sm SkyfileMetadata) AllowedHeaders() map[string]string {
+	headers := make(map[string]string, len(sm.Headers))
+	for name, value := range sm.Headers {
+		name = textproto.CanonicalMIMEHeaderKey(name)
+		if validateSkyfileHeader(name, value) == nil {
+			headers[name] = value
+		}
+	}
+	return headers
+}
+
+// validateSkyfileHeader returns an error if the header with the given
+// canonical name can't be set by the uploader of a skyfile.
+func validateSkyfileHeader(name, value string) error {
+	if _, allowed := SkyfileAllowedHeaders[name]; !allowed {
+		return errors.AddContext(ErrSkyfileHeaderNotAllowed, name)
+	}
+	if strings.ContainsAny(value, "\r\n\x00") {
+		return errors.AddContext(ErrInvalidSkyfileHeaders, fmt.Sprintf("invalid value for header %v", name))
+	}
+	return nil
+}
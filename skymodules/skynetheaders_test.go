@@ -0,0 +1,70 @@
+package skymodules
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+// TestParseSkyfileHeaders is a unit test for ParseSkyfileHeaders.
+func TestParseSkyfileHeaders(t *testing.T) {
+	t.Parallel()
+
+	// Valid headers are canonicalized.
+	headers, err := ParseSkyfileHeaders([]byte(`{"cache-control": "no-cache", "ACCESS-CONTROL-ALLOW-ORIGIN": "*"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(headers) != 2 || headers["Cache-Control"] != "no-cache" || headers["Access-Control-Allow-Origin"] != "*" {
+		t.Fatal("wrong headers", headers)
+	}
+
+	// Anything but an object of strings is invalid.
+	for _, invalid := range []string{"", "null", "1", `["Cache-Control"]`, `{"Cache-Control": 1}`} {
+		_, err := ParseSkyfileHeaders([]byte(invalid))
+		if !errors.Contains(err, ErrInvalidSkyfileHeaders) {
+			t.Fatalf("expected %q to be invalid, got %v", invalid, err)
+		}
+	}
+
+	// Values can't contain line breaks.
+	_, err = ParseSkyfileHeaders([]byte(`{"Cache-Control": "no-cache\r\nSet-Cookie: a=b"}`))
+	if !errors.Contains(err, ErrInvalidSkyfileHeaders) {
+		t.Fatal("wrong error", err)
+	}
+
+	// Only allowed headers can be set.
+	for _, name := range []string{"Content-Type", "Set-Cookie", "Skynet-Skylink", "Location"} {
+		_, err := ParseSkyfileHeaders([]byte(fmt.Sprintf(`{%q: "x"}`, name)))
+		if !errors.Contains(err, ErrSkyfileHeaderNotAllowed) {
+			t.Fatalf("expected %v to be rejected, got %v", name, err)
+		}
+	}
+
+	// The size is limited.
+	data := strings.Repeat("a", SkyfileMaxHeadersSize)
+	_, err = ParseSkyfileHeaders([]byte(fmt.Sprintf(`{"Cache-Control": "%v"}`, data)))
+	if !errors.Contains(err, ErrSkyfileHeadersTooLarge) {
+		t.Fatal("wrong error", err)
+	}
+}
+
+// TestSkyfileMetadataAllowedHeaders is a unit test for
+// SkyfileMetadata.AllowedHeaders.
+func TestSkyfileMetadataAllowedHeaders(t *testing.T) {
+	t.Parallel()
+
+	sm := SkyfileMetadata{
+		Headers: map[string]string{
+			"cache-control": "no-cache",
+			"Content-Type":  "text/html",
+			"Vary":          "Origin\nSet-Cookie: a=b",
+		},
+	}
+	headers := sm.AllowedHeaders()
+	if len(headers) != 1 || headers["Cache-Control"] != "no-cache" {
+		t.Fatal("wrong headers", headers)
+	}
+}
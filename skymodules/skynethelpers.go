@@ -382,16 +382,19 @@ func fileContentType(filename string, file io.Reader) (string, error) {
 		return contentType, nil
 	}
 	// Only the first 512 bytes are used to sniff the content type. Ignore EOF
-	// so we properly fall back to the fallback defined in the http library for
-	// empty file uploads.
-	buffer := make([]byte, 512)
-	_, err := file.Read(buffer)
+	// so we properly fall back to the generic content type for empty file
+	// uploads.
+	buffer := make([]byte, ContentTypeSniffLen)
+	n, err := file.Read(buffer)
 	if err != nil && !errors.Contains(err, io.EOF) {
 		return "", err
 	}
+	if n == 0 {
+		return contentTypeOctetStream, nil
+	}
 	// Always returns a valid content-type by returning
 	// "application/octet-stream" if no others seemed to match.
-	return http.DetectContentType(buffer), nil
+	return http.DetectContentType(buffer[:n]), nil
 }
 
 // validateDefaultPath ensures the given default path makes sense in relation to
@@ -424,6 +427,29 @@ func validateDefaultPath(defaultPath string, subfiles SkyfileSubfiles) (string,
 	return defaultPath, nil
 }
 
+// validateTryFilesPattern ensures the given tryfile pattern is valid. A
+// pattern contains either the path placeholder or a wildcard segment, both at
+// most once, and no path traversal segments.
+func validateTryFilesPattern(pattern string) error {
+	placeholders := strings.Count(pattern, TryFilesPathPlaceholder)
+	wildcards := 0
+	for _, segment := range strings.Split(pattern, "/") {
+		switch segment {
+		case TryFilesWildcard:
+			wildcards++
+		case ".", "..":
+			return fmt.Errorf("tryfile '%v' can't contain path traversal segments", pattern)
+		}
+	}
+	if placeholders > 1 || wildcards > 1 {
+		return fmt.Errorf("tryfile '%v' can contain '%v' or '%v' at most once", pattern, TryFilesPathPlaceholder, TryFilesWildcard)
+	}
+	if placeholders > 0 && wildcards > 0 {
+		return fmt.Errorf("tryfile '%v' can't contain both '%v' and '%v'", pattern, TryFilesPathPlaceholder, TryFilesWildcard)
+	}
+	return nil
+}
+
 // ValidateErrorPages ensures the given errorpages configuration is valid.
 func ValidateErrorPages(ep map[int]string, subfiles SkyfileSubfiles) error {
 	for code, fname := range ep {
@@ -447,12 +473,19 @@ func ValidateErrorPages(ep map[int]string, subfiles SkyfileSubfiles) error {
 }
 
 // ValidateTryFiles ensures the given tryfiles configuration is valid.
+// Tryfile patterns are resolved per request, so they don't need to exist.
 func ValidateTryFiles(tf []string, subfiles SkyfileSubfiles) error {
 	anotherAbsPathFileExists := false
 	for _, fname := range tf {
 		if fname == "" {
 			return errors.New("a tryfile cannot be an empty string, it needs to be a valid file name")
 		}
+		if IsTryFilesPattern(fname) {
+			if err := validateTryFilesPattern(fname); err != nil {
+				return err
+			}
+			continue
+		}
 		if strings.HasPrefix(fname, "/") {
 			_, exists := subfiles[strings.TrimPrefix(fname, "/")]
 			if !exists {
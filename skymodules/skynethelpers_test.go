@@ -502,6 +502,38 @@ func TestValidateTryFiles(t *testing.T) {
 			sub:  SkyfileSubfiles{},
 			err:  "",
 		},
+		{
+			name: "test non-existent absolute path patterns",
+			tf:   []string{"/$path.html", "/*/index.html", "/index.html"},
+			sub: SkyfileSubfiles{
+				"index.html": SkyfileSubfileMetadata{},
+			},
+			err: "",
+		},
+		{
+			name: "test multiple wildcards",
+			tf:   []string{"/*/*/index.html"},
+			sub:  SkyfileSubfiles{},
+			err:  "at most once",
+		},
+		{
+			name: "test multiple placeholders",
+			tf:   []string{"$path/$path.html"},
+			sub:  SkyfileSubfiles{},
+			err:  "at most once",
+		},
+		{
+			name: "test placeholder and wildcard",
+			tf:   []string{"/*/$path.html"},
+			sub:  SkyfileSubfiles{},
+			err:  "can't contain both",
+		},
+		{
+			name: "test path traversal",
+			tf:   []string{"$path/../index.html"},
+			sub:  SkyfileSubfiles{},
+			err:  "path traversal",
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,131 @@
+package skymodules
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+const (
+	// SkynetNameSourceDNS indicates that a name was resolved using the DNS.
+	SkynetNameSourceDNS = "dns"
+
+	// SkynetNameSourceHNS indicates that a name was resolved using a
+	// Handshake resolver.
+	SkynetNameSourceHNS = "hns"
+)
+
+const (
+	// DNSLinkSubdomain is the subdomain which is queried for TXT records
+	// before the name itself.
+	DNSLinkSubdomain = "_dnslink."
+
+	// DNSLinkSkynetPrefix is the prefix of a DNSLink TXT record pointing to a
+	// skylink.
+	DNSLinkSkynetPrefix = "dnslink=/skynet-ns/"
+
+	// HNSNameSuffix is the suffix of names that are resolved using a
+	// Handshake resolver. The suffix is not part of the name that is looked
+	// up.
+	HNSNameSuffix = ".hns"
+
+	// SkylinkTXTRecordPrefix is the prefix of a TXT record pointing to a
+	// skylink.
+	SkylinkTXTRecordPrefix = "skynet-skylink="
+
+	// maxSkynetNameLength is the maximum length of a name according to RFC
+	// 1035.
+	maxSkynetNameLength = 253
+)
+
+var (
+	// ErrInvalidSkynetName is returned if a name is not a valid domain name.
+	ErrInvalidSkynetName = errors.New("invalid name")
+
+	// ErrSkynetNameNotFound is returned if a name doesn't point to a skylink.
+	ErrSkynetNameNotFound = errors.New("name doesn't point to a skylink")
+
+	// ErrSkynetNameLookupsLimited is returned if a name can't be resolved
+	// because too many names were looked up recently.
+	ErrSkynetNameLookupsLimited = errors.New("too many name lookups, try again later")
+)
+
+type (
+	// SkynetNameResolution is the result of resolving a human-readable name to
+	// a skylink.
+	SkynetNameResolution struct {
+		Name    string    `json:"name"`
+		Skylink Skylink   `json:"skylink"`
+		Source  string    `json:"source"`
+		Expires time.Time `json:"expires"`
+	}
+)
+
+// ParseSkynetName normalizes a human-readable name and checks whether it's a
+// valid domain name with at least two labels. It returns the normalized name
+// and whether the name needs to be resolved using a Handshake resolver.
+func ParseSkynetName(name string) (_ string, hns bool, err error) {
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	if strings.HasSuffix(name, HNSNameSuffix) {
+		name = strings.TrimSuffix(name, HNSNameSuffix)
+		hns = true
+	}
+	if name == "" || len(name) > maxSkynetNameLength || net.ParseIP(name) != nil {
+		return "", false, ErrInvalidSkynetName
+	}
+	labels := strings.Split(name, ".")
+	// Handshake names may be top-level names without any dots.
+	if len(labels) < 2 && !hns {
+		return "", false, ErrInvalidSkynetName
+	}
+	for _, label := range labels {
+		if !validSkynetNameLabel(label) {
+			return "", false, ErrInvalidSkynetName
+		}
+	}
+	return name, hns, nil
+}
+
+// SkylinkFromTXTRecords returns the skylink the given TXT records point to.
+// Both 'skynet-skylink=<skylink>' and 'dnslink=/skynet-ns/<skylink>' records
+// are supported. If multiple records point to a skylink, the first one is
+// used.
+func SkylinkFromTXTRecords(records []string) (Skylink, error) {
+	for _, record := range records {
+		record = strings.TrimSpace(record)
+		var link string
+		switch {
+		case strings.HasPrefix(record, SkylinkTXTRecordPrefix):
+			link = strings.TrimPrefix(record, SkylinkTXTRecordPrefix)
+		case strings.HasPrefix(record, DNSLinkSkynetPrefix):
+			link = strings.TrimPrefix(record, DNSLinkSkynetPrefix)
+		default:
+			continue
+		}
+		var sl Skylink
+		if err := sl.LoadString(strings.Trim(link, "/")); err != nil {
+			return Skylink{}, errors.AddContext(err, "record contains an invalid skylink")
+		}
+		return sl, nil
+	}
+	return Skylink{}, ErrSkynetNameNotFound
+}
+
+// validSkynetNameLabel checks whether a label of a name only consists of
+// letters, digits and hyphens and neither starts nor ends with a hyphen.
+func validSkynetNameLabel(label string) bool {
+	if label == "" || len(label) > 63 {
+		return false
+	}
+	if strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+		return false
+	}
+	for _, c := range label {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			return false
+		}
+	}
+	return true
+}
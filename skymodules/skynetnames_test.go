@@ -0,0 +1,87 @@
+package skymodules
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/crypto"
+)
+
+// TestParseSkynetName is a unit test for ParseSkynetName.
+func TestParseSkynetName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		normalized string
+		hns        bool
+		valid      bool
+	}{
+		{name: "example.com", normalized: "example.com", valid: true},
+		{name: "Sub.Example.COM.", normalized: "sub.example.com", valid: true},
+		{name: "my-app.example.com", normalized: "my-app.example.com", valid: true},
+		{name: "skyapp.hns", normalized: "skyapp", hns: true, valid: true},
+		{name: "sub.skyapp.hns", normalized: "sub.skyapp", hns: true, valid: true},
+		{name: "localhost"},
+		{name: ""},
+		{name: ".hns"},
+		{name: "127.0.0.1"},
+		{name: "-bad.example.com"},
+		{name: "bad-.example.com"},
+		{name: "under_score.example.com"},
+		{name: "double..dot.com"},
+		{name: "example.com:9980"},
+	}
+	for _, test := range tests {
+		normalized, hns, err := ParseSkynetName(test.name)
+		if !test.valid {
+			if err != ErrInvalidSkynetName {
+				t.Fatalf("%v: expected invalid name but got %v", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(test.name, err)
+		}
+		if normalized != test.normalized || hns != test.hns {
+			t.Fatal("wrong result", test.name, normalized, hns)
+		}
+	}
+}
+
+// TestSkylinkFromTXTRecords is a unit test for SkylinkFromTXTRecords.
+func TestSkylinkFromTXTRecords(t *testing.T) {
+	t.Parallel()
+
+	sl, err := NewSkylinkV1(crypto.Hash{1}, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sl2, err := NewSkylinkV1(crypto.Hash{2}, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// No records.
+	if _, err := SkylinkFromTXTRecords(nil); err != ErrSkynetNameNotFound {
+		t.Fatal("wrong error", err)
+	}
+	// Unrelated records.
+	if _, err := SkylinkFromTXTRecords([]string{"v=spf1 -all"}); err != ErrSkynetNameNotFound {
+		t.Fatal("wrong error", err)
+	}
+	// The first matching record is used.
+	records := []string{"v=spf1 -all", SkylinkTXTRecordPrefix + sl.String(), SkylinkTXTRecordPrefix + sl2.String()}
+	if resolved, err := SkylinkFromTXTRecords(records); err != nil || resolved != sl {
+		t.Fatal("wrong result", resolved, err)
+	}
+	// DNSLink records are supported.
+	records = []string{DNSLinkSkynetPrefix + sl2.String() + "/"}
+	if resolved, err := SkylinkFromTXTRecords(records); err != nil || resolved != sl2 {
+		t.Fatal("wrong result", resolved, err)
+	}
+	// Invalid skylinks are rejected.
+	records = []string{SkylinkTXTRecordPrefix + "notaskylink"}
+	if _, err := SkylinkFromTXTRecords(records); err == nil || err == ErrSkynetNameNotFound {
+		t.Fatal("wrong error", err)
+	}
+}
@@ -0,0 +1,68 @@
+package skymodules
+
+import (
+	"math"
+	"strings"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+var (
+	// ErrSkynetFolderQuotaExceeded is returned if an upload or pin would
+	// exceed the quota of the top-level skynet folder it is stored in.
+	ErrSkynetFolderQuotaExceeded = errors.New("skynet folder quota exceeded")
+
+	// ErrInvalidSkynetFolder is returned if a folder isn't a valid top-level
+	// folder of the skynet folder.
+	ErrInvalidSkynetFolder = errors.New("invalid skynet folder")
+)
+
+// SkynetFolderUsage contains the number of bytes stored under a top-level
+// folder of the skynet folder together with the folder's quota. A quota of 0
+// means unlimited.
+type SkynetFolderUsage struct {
+	Folder   string `json:"folder"`
+	NumFiles uint64 `json:"numfiles"`
+	Size     uint64 `json:"size"`
+	Quota    uint64 `json:"quota"`
+}
+
+// UploadLimit returns the number of bytes which may still be stored under the
+// folder and the error that applies once the limit is exceeded. Without a
+// quota the limit is math.MaxUint64.
+func (u SkynetFolderUsage) UploadLimit() (uint64, error) {
+	if u.Quota == 0 {
+		return math.MaxUint64, nil
+	}
+	if u.Size >= u.Quota {
+		return 0, ErrSkynetFolderQuotaExceeded
+	}
+	return u.Quota - u.Size, ErrSkynetFolderQuotaExceeded
+}
+
+// SkynetTopLevelFolder returns the name of the top-level folder of the skynet
+// folder the given siapath is stored under. If the siapath is not stored
+// within a folder of the skynet folder, false is returned.
+func SkynetTopLevelFolder(siaPath SiaPath) (string, bool) {
+	prefix := SkynetFolder.Path + "/"
+	if !strings.HasPrefix(siaPath.Path, prefix) {
+		return "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(siaPath.Path, prefix), "/", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", false
+	}
+	return parts[0], true
+}
+
+// ValidateSkynetFolder returns an error if the given name is not a valid
+// top-level folder of the skynet folder.
+func ValidateSkynetFolder(folder string) error {
+	if folder == "" || strings.Contains(folder, "/") {
+		return errors.AddContext(ErrInvalidSkynetFolder, folder)
+	}
+	if _, err := SkynetFolder.Join(folder); err != nil {
+		return errors.Compose(errors.AddContext(ErrInvalidSkynetFolder, folder), err)
+	}
+	return nil
+}
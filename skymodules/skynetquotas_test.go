@@ -0,0 +1,52 @@
+package skymodules
+
+import (
+	"math"
+	"testing"
+)
+
+// TestSkynetTopLevelFolder is a unit test for SkynetTopLevelFolder.
+func TestSkynetTopLevelFolder(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path   string
+		folder string
+		ok     bool
+	}{
+		{"var/skynet/tenant/file", "tenant", true},
+		{"var/skynet/tenant/dir/file", "tenant", true},
+		{"var/skynet/file", "", false},
+		{"var/skynet", "", false},
+		{"var/skynetfoo/tenant/file", "", false},
+		{"home/user/file", "", false},
+	}
+	for _, test := range tests {
+		folder, ok := SkynetTopLevelFolder(SiaPath{Path: test.path})
+		if folder != test.folder || ok != test.ok {
+			t.Errorf("%v: expected (%v, %v) but got (%v, %v)", test.path, test.folder, test.ok, folder, ok)
+		}
+	}
+}
+
+// TestSkynetFolderUsageUploadLimit is a unit test for UploadLimit.
+func TestSkynetFolderUsageUploadLimit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		usage SkynetFolderUsage
+		limit uint64
+		err   error
+	}{
+		{usage: SkynetFolderUsage{Size: 100}, limit: math.MaxUint64},
+		{usage: SkynetFolderUsage{Size: 100, Quota: 150}, limit: 50, err: ErrSkynetFolderQuotaExceeded},
+		{usage: SkynetFolderUsage{Size: 150, Quota: 150}, limit: 0, err: ErrSkynetFolderQuotaExceeded},
+		{usage: SkynetFolderUsage{Size: 200, Quota: 150}, limit: 0, err: ErrSkynetFolderQuotaExceeded},
+	}
+	for _, test := range tests {
+		limit, err := test.usage.UploadLimit()
+		if limit != test.limit || err != test.err {
+			t.Errorf("%+v: expected (%v, %v) but got (%v, %v)", test.usage, test.limit, test.err, limit, err)
+		}
+	}
+}
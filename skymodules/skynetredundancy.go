@@ -0,0 +1,97 @@
+package skymodules
+
+import (
+	"fmt"
+	"strings"
+
+	"gitlab.com/SkynetLabs/skyd/build"
+)
+
+const (
+	// FanoutRedundancyPolicyNotSpecified indicates that no policy was
+	// specified and the static policy should be used.
+	FanoutRedundancyPolicyNotSpecified = FanoutRedundancyPolicy("")
+	// FanoutRedundancyPolicyStatic always uploads the fanout of skyfiles with
+	// the renter's default erasure code parameters.
+	FanoutRedundancyPolicyStatic = FanoutRedundancyPolicy("static")
+	// FanoutRedundancyPolicyDynamic chooses the erasure code parameters of the
+	// fanout based on the number of good hosts available for uploading. Wide
+	// stripes with less redundancy are used if there are many good hosts and
+	// fewer pieces with more parity if the pool of hosts is shallow.
+	FanoutRedundancyPolicyDynamic = FanoutRedundancyPolicy("dynamic")
+)
+
+var (
+	// ErrUnknownFanoutRedundancyPolicy is returned when an unknown fanout
+	// redundancy policy is specified.
+	ErrUnknownFanoutRedundancyPolicy = fmt.Errorf("unknown fanout redundancy policy, allowed values are: '%v' and '%v'", FanoutRedundancyPolicyStatic, FanoutRedundancyPolicyDynamic)
+
+	// DynamicFanoutRedundancyTiers are the erasure code parameters the
+	// dynamic fanout redundancy policy chooses from, ordered from the widest
+	// to the narrowest stripes. The first tier whose MinHosts are available
+	// is used, the last tier is used if no other tier qualifies. MinHosts
+	// leaves headroom for hosts which fail during the upload.
+	DynamicFanoutRedundancyTiers = build.Select(build.Var{
+		Dev: []FanoutRedundancyTier{
+			{DataPieces: 2, ParityPieces: 2, MinHosts: 6},
+			{DataPieces: 1, ParityPieces: 1, MinHosts: 3},
+			{DataPieces: 1, ParityPieces: 2, MinHosts: 0},
+		},
+		Standard: []FanoutRedundancyTier{
+			{DataPieces: 20, ParityPieces: 20, MinHosts: 60},
+			{DataPieces: 10, ParityPieces: 20, MinHosts: 45},
+			{DataPieces: 4, ParityPieces: 12, MinHosts: 0},
+		},
+		Testing: []FanoutRedundancyTier{
+			{DataPieces: 2, ParityPieces: 2, MinHosts: 5},
+			{DataPieces: 1, ParityPieces: 2, MinHosts: 3},
+			{DataPieces: 1, ParityPieces: 1, MinHosts: 0},
+		},
+	}).([]FanoutRedundancyTier)
+)
+
+type (
+	// FanoutRedundancyPolicy describes how the erasure code parameters of the
+	// fanout of uploaded skyfiles are chosen. The chosen parameters are
+	// recorded in the skyfile's layout.
+	FanoutRedundancyPolicy string
+
+	// FanoutRedundancyTier is a set of erasure code parameters which the
+	// dynamic fanout redundancy policy uses if at least MinHosts good hosts
+	// are available.
+	FanoutRedundancyTier struct {
+		DataPieces   int
+		ParityPieces int
+		MinHosts     int
+	}
+)
+
+// ParseFanoutRedundancyPolicy parses a fanout redundancy policy from a string.
+// An empty string results in FanoutRedundancyPolicyNotSpecified.
+func ParseFanoutRedundancyPolicy(s string) (FanoutRedundancyPolicy, error) {
+	policy := FanoutRedundancyPolicy(strings.ToLower(s))
+	switch policy {
+	case FanoutRedundancyPolicyNotSpecified:
+	case FanoutRedundancyPolicyStatic:
+	case FanoutRedundancyPolicyDynamic:
+	default:
+		return "", ErrUnknownFanoutRedundancyPolicy
+	}
+	return policy, nil
+}
+
+// Redundancy returns the number of data and parity pieces of a fanout which is
+// uploaded while the given number of good hosts are available.
+func (p FanoutRedundancyPolicy) Redundancy(goodHosts int) (dataPieces, parityPieces int) {
+	if p != FanoutRedundancyPolicyDynamic {
+		return RenterDefaultDataPieces, RenterDefaultParityPieces
+	}
+	tiers := DynamicFanoutRedundancyTiers
+	for _, tier := range tiers {
+		if goodHosts >= tier.MinHosts {
+			return tier.DataPieces, tier.ParityPieces
+		}
+	}
+	last := tiers[len(tiers)-1]
+	return last.DataPieces, last.ParityPieces
+}
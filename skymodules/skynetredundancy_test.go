@@ -0,0 +1,52 @@
+package skymodules
+
+import (
+	"testing"
+)
+
+// TestFanoutRedundancyPolicy is a unit test for the FanoutRedundancyPolicy.
+func TestFanoutRedundancyPolicy(t *testing.T) {
+	t.Parallel()
+
+	// Parse the policies.
+	for _, s := range []string{"", "static", "Dynamic"} {
+		if _, err := ParseFanoutRedundancyPolicy(s); err != nil {
+			t.Fatal(s, err)
+		}
+	}
+	if _, err := ParseFanoutRedundancyPolicy("random"); err != ErrUnknownFanoutRedundancyPolicy {
+		t.Fatal("wrong error", err)
+	}
+
+	// The static policy and an unspecified policy always use the defaults.
+	for _, p := range []FanoutRedundancyPolicy{FanoutRedundancyPolicyNotSpecified, FanoutRedundancyPolicyStatic} {
+		for _, hosts := range []int{0, 1000} {
+			dp, pp := p.Redundancy(hosts)
+			if dp != RenterDefaultDataPieces || pp != RenterDefaultParityPieces {
+				t.Fatal("wrong redundancy", p, hosts, dp, pp)
+			}
+		}
+	}
+
+	// The dynamic policy chooses wider stripes with more hosts.
+	tiers := DynamicFanoutRedundancyTiers
+	for i, tier := range tiers {
+		dp, pp := FanoutRedundancyPolicyDynamic.Redundancy(tier.MinHosts)
+		if dp != tier.DataPieces || pp != tier.ParityPieces {
+			t.Fatal("wrong redundancy", i, dp, pp)
+		}
+		// Every tier must fit on the hosts it requires.
+		if tier.MinHosts > 0 && tier.DataPieces+tier.ParityPieces > tier.MinHosts {
+			t.Fatal("tier doesn't fit its hosts", i)
+		}
+	}
+	dp, pp := FanoutRedundancyPolicyDynamic.Redundancy(tiers[0].MinHosts - 1)
+	if dp != tiers[1].DataPieces || pp != tiers[1].ParityPieces {
+		t.Fatal("wrong redundancy", dp, pp)
+	}
+	dp, pp = FanoutRedundancyPolicyDynamic.Redundancy(0)
+	last := tiers[len(tiers)-1]
+	if dp != last.DataPieces || pp != last.ParityPieces {
+		t.Fatal("wrong redundancy", dp, pp)
+	}
+}
@@ -0,0 +1,34 @@
+package skymodules
+
+// Skylink repair priorities determine the order in which the chunks of skyfiles
+// are repaired once their redundancy drops. By default a skylink's priority is
+// its popularity, a decaying count of its downloads. Operators can override the
+// priority of a skylink manually.
+
+import (
+	"gitlab.com/NebulousLabs/errors"
+)
+
+var (
+	// ErrInvalidRepairPriority is returned if a repair priority override is
+	// negative or not a number.
+	ErrInvalidRepairPriority = errors.New("repair priority must be a non-negative number")
+)
+
+type (
+	// SkylinkRepairPriority describes the repair priority of a skylink.
+	SkylinkRepairPriority struct {
+		Skylink string `json:"skylink"`
+
+		// Popularity is the number of downloads of the skylink, decayed over
+		// time.
+		Popularity float64 `json:"popularity"`
+
+		// Override indicates whether the priority was set manually.
+		Override bool `json:"override"`
+
+		// Priority is the priority the skylink's chunks are repaired with.
+		// Chunks with a higher priority are repaired first.
+		Priority float64 `json:"priority"`
+	}
+)
@@ -0,0 +1,63 @@
+package skymodules
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+const (
+	// SkylinkSigningKeySize is the size of the secret key used to sign
+	// skylink download URLs.
+	SkylinkSigningKeySize = 32
+)
+
+var (
+	// ErrSkylinkSignatureExpired is returned if a signed skylink URL is used
+	// after its expiry.
+	ErrSkylinkSignatureExpired = errors.New("signed skylink url has expired")
+
+	// ErrSkylinkSignatureInvalid is returned if the signature of a signed
+	// skylink URL doesn't match the skylink and expiry.
+	ErrSkylinkSignatureInvalid = errors.New("invalid skylink url signature")
+
+	// ErrSkylinkSignatureRequired is returned if a restricted skylink is
+	// accessed without a signature.
+	ErrSkylinkSignatureRequired = errors.New("skylink can only be accessed using a signed url")
+)
+
+// SkylinkAccess holds the credentials of a signed skylink URL which a download
+// presents to access a restricted skylink.
+type SkylinkAccess struct {
+	Expires   time.Time
+	Signature []byte
+}
+
+// SkylinkURLSignature computes the HMAC of a skylink and the expiry of the
+// signed URL using the provided key.
+func SkylinkURLSignature(key []byte, skylink Skylink, expires time.Time) []byte {
+	var expiresBytes [8]byte
+	binary.LittleEndian.PutUint64(expiresBytes[:], uint64(expires.Unix()))
+
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(skylink.String()))
+	_, _ = mac.Write(expiresBytes[:])
+	return mac.Sum(nil)
+}
+
+// VerifySkylinkURLSignature verifies that the signature was created for the
+// skylink and expiry with the provided key and that the expiry hasn't passed
+// yet.
+func VerifySkylinkURLSignature(key []byte, skylink Skylink, expires time.Time, signature []byte) error {
+	expected := SkylinkURLSignature(key, skylink, expires)
+	if !hmac.Equal(expected, signature) {
+		return ErrSkylinkSignatureInvalid
+	}
+	if time.Now().After(expires) {
+		return ErrSkylinkSignatureExpired
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package skymodules
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+)
+
+// TestSkylinkURLSignature is a unit test for SkylinkURLSignature and
+// VerifySkylinkURLSignature.
+func TestSkylinkURLSignature(t *testing.T) {
+	t.Parallel()
+
+	key := fastrand.Bytes(SkylinkSigningKeySize)
+	skylink, err := NewSkylinkV1(crypto.Hash{1}, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expires := time.Now().Add(time.Hour)
+
+	// Valid signature.
+	sig := SkylinkURLSignature(key, skylink, expires)
+	if err := VerifySkylinkURLSignature(key, skylink, expires, sig); err != nil {
+		t.Fatal(err)
+	}
+
+	// Tampered expiry.
+	err = VerifySkylinkURLSignature(key, skylink, expires.Add(time.Hour), sig)
+	if !errors.Contains(err, ErrSkylinkSignatureInvalid) {
+		t.Fatal("wrong error", err)
+	}
+
+	// Different skylink.
+	skylink2, err := NewSkylinkV1(crypto.Hash{2}, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = VerifySkylinkURLSignature(key, skylink2, expires, sig)
+	if !errors.Contains(err, ErrSkylinkSignatureInvalid) {
+		t.Fatal("wrong error", err)
+	}
+
+	// Different key.
+	err = VerifySkylinkURLSignature(fastrand.Bytes(SkylinkSigningKeySize), skylink, expires, sig)
+	if !errors.Contains(err, ErrSkylinkSignatureInvalid) {
+		t.Fatal("wrong error", err)
+	}
+
+	// Expired signature.
+	expired := time.Now().Add(-time.Second)
+	sig = SkylinkURLSignature(key, skylink, expired)
+	err = VerifySkylinkURLSignature(key, skylink, expired, sig)
+	if !errors.Contains(err, ErrSkylinkSignatureExpired) {
+		t.Fatal("wrong error", err)
+	}
+}
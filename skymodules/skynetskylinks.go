@@ -0,0 +1,97 @@
+package skymodules
+
+// The skylinks of a node are the skylinks of its siafiles together with the
+// skylinks it uploaded in the past. Skylinks which are no longer referenced by
+// a siafile are considered unpinned.
+
+import (
+	"strings"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+const (
+	// DefaultSkylinksLimit is the default number of skylinks returned when
+	// listing skylinks.
+	DefaultSkylinksLimit = 100
+
+	// MaxSkylinksLimit is the maximum number of skylinks returned when
+	// listing skylinks.
+	MaxSkylinksLimit = 1000
+)
+
+var (
+	// ErrSkylinksLimitTooHigh is returned if more than MaxSkylinksLimit
+	// skylinks are requested.
+	ErrSkylinksLimitTooHigh = errors.New("skylinks limit exceeds the max limit")
+)
+
+type (
+	// SkylinkInfo describes a skylink known to the node.
+	SkylinkInfo struct {
+		Skylink string `json:"skylink"`
+
+		// SiaPath is the siapath of the skyfile. For unpinned skylinks it is
+		// the siapath the skyfile was uploaded to.
+		SiaPath SiaPath `json:"siapath"`
+
+		// Filename is the filename from the skyfile's metadata.
+		Filename string `json:"filename"`
+
+		// Size is the length of the skyfile's data.
+		Size uint64 `json:"size"`
+
+		// UploadTime is the time the skyfile was uploaded by the node.
+		UploadTime time.Time `json:"uploadtime"`
+
+		// Pinned indicates whether the skylink is referenced by a siafile.
+		Pinned bool `json:"pinned"`
+
+		// Encrypted indicates whether the skyfile's base sector is encrypted.
+		Encrypted bool `json:"encrypted"`
+	}
+
+	// SkylinksFilter filters and paginates the skylinks listed by the node.
+	// Zero values don't filter.
+	SkylinksFilter struct {
+		MinSize uint64
+		MaxSize uint64
+
+		UploadedAfter  time.Time
+		UploadedBefore time.Time
+
+		Pinned    *bool
+		Encrypted *bool
+
+		// Search is matched case-insensitively against the filenames of the
+		// skyfiles.
+		Search string
+
+		Offset uint64
+		Limit  uint64
+	}
+)
+
+// Matches returns true if the skylink passes the filter.
+func (f SkylinksFilter) Matches(si SkylinkInfo) bool {
+	if si.Size < f.MinSize || (f.MaxSize > 0 && si.Size > f.MaxSize) {
+		return false
+	}
+	if !f.UploadedAfter.IsZero() && !si.UploadTime.After(f.UploadedAfter) {
+		return false
+	}
+	if !f.UploadedBefore.IsZero() && !si.UploadTime.Before(f.UploadedBefore) {
+		return false
+	}
+	if f.Pinned != nil && si.Pinned != *f.Pinned {
+		return false
+	}
+	if f.Encrypted != nil && si.Encrypted != *f.Encrypted {
+		return false
+	}
+	if f.Search != "" && !strings.Contains(strings.ToLower(si.Filename), strings.ToLower(f.Search)) {
+		return false
+	}
+	return true
+}
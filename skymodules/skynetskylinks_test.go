@@ -0,0 +1,43 @@
+package skymodules
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSkylinksFilter is a unit test for SkylinksFilter.Matches.
+func TestSkylinksFilter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	si := SkylinkInfo{
+		Filename:   "Image.PNG",
+		Size:       100,
+		UploadTime: now,
+		Pinned:     true,
+	}
+	yes, no := true, false
+	tests := []struct {
+		filter  SkylinksFilter
+		matches bool
+	}{
+		{filter: SkylinksFilter{}, matches: true},
+		{filter: SkylinksFilter{MinSize: 100, MaxSize: 100}, matches: true},
+		{filter: SkylinksFilter{MinSize: 101}, matches: false},
+		{filter: SkylinksFilter{MaxSize: 99}, matches: false},
+		{filter: SkylinksFilter{UploadedAfter: now.Add(-time.Second), UploadedBefore: now.Add(time.Second)}, matches: true},
+		{filter: SkylinksFilter{UploadedAfter: now}, matches: false},
+		{filter: SkylinksFilter{UploadedBefore: now}, matches: false},
+		{filter: SkylinksFilter{Pinned: &yes, Encrypted: &no}, matches: true},
+		{filter: SkylinksFilter{Pinned: &no}, matches: false},
+		{filter: SkylinksFilter{Encrypted: &yes}, matches: false},
+		{filter: SkylinksFilter{Search: "image.png"}, matches: true},
+		{filter: SkylinksFilter{Search: "age"}, matches: true},
+		{filter: SkylinksFilter{Search: "jpg"}, matches: false},
+	}
+	for i, test := range tests {
+		if matches := test.filter.Matches(si); matches != test.matches {
+			t.Errorf("%v: expected %v but got %v", i, test.matches, matches)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package skymodules
+
+import (
+	"context"
+	"fmt"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+var (
+	// ErrSkyfileUploadTooLarge is returned if an upload exceeds the max
+	// upload size of the portal.
+	ErrSkyfileUploadTooLarge = errors.New("upload exceeds the max upload size")
+
+	// ErrSkyfileTooManySubfiles is returned if an upload contains more
+	// subfiles than the portal allows.
+	ErrSkyfileTooManySubfiles = errors.New("upload contains too many subfiles")
+
+	// ErrSkyfileMetadataTooLarge is returned if the metadata of an upload
+	// exceeds the max metadata size of the portal.
+	ErrSkyfileMetadataTooLarge = errors.New("skyfile metadata exceeds the max metadata size")
+)
+
+type (
+	// SkyfileUploadLimits are the limits the portal operator imposes on
+	// skyfile uploads. A limit of 0 means that there is no limit.
+	SkyfileUploadLimits struct {
+		MaxUploadSize   uint64 `json:"maxuploadsize"`
+		MaxSubfiles     uint64 `json:"maxsubfiles"`
+		MaxMetadataSize uint64 `json:"maxmetadatasize"`
+	}
+
+	// SkyfileUploadLimitReader is a SkyfileUploadReader which enforces the
+	// skyfile upload limits of the portal while the upload is read.
+	SkyfileUploadLimitReader struct {
+		SkyfileUploadReader
+		n      uint64
+		limits SkyfileUploadLimits
+		err    error
+	}
+)
+
+// NewSkyfileUploadLimitReader wraps the given reader in a reader which fails
+// once the upload exceeds the given limits.
+func NewSkyfileUploadLimitReader(reader SkyfileUploadReader, limits SkyfileUploadLimits) *SkyfileUploadLimitReader {
+	return &SkyfileUploadLimitReader{
+		SkyfileUploadReader: reader,
+		limits:              limits,
+	}
+}
+
+// CheckUploadSize returns ErrSkyfileUploadTooLarge if an upload of the given
+// size exceeds the max upload size.
+func (sul SkyfileUploadLimits) CheckUploadSize(size uint64) error {
+	if sul.MaxUploadSize > 0 && size > sul.MaxUploadSize {
+		return errors.AddContext(ErrSkyfileUploadTooLarge, fmt.Sprintf("%v > %v bytes", size, sul.MaxUploadSize))
+	}
+	return nil
+}
+
+// CheckMetadata returns an error if the given metadata contains too many
+// subfiles or exceeds the max metadata size.
+func (sul SkyfileUploadLimits) CheckMetadata(sm SkyfileMetadata) error {
+	if sul.MaxSubfiles > 0 && uint64(len(sm.Subfiles)) > sul.MaxSubfiles {
+		return errors.AddContext(ErrSkyfileTooManySubfiles, fmt.Sprintf("%v > %v subfiles", len(sm.Subfiles), sul.MaxSubfiles))
+	}
+	if sul.MaxMetadataSize == 0 {
+		return nil
+	}
+	metadataBytes, err := SkyfileMetadataBytes(sm)
+	if err != nil {
+		return err
+	}
+	if uint64(len(metadataBytes)) > sul.MaxMetadataSize {
+		return errors.AddContext(ErrSkyfileMetadataTooLarge, fmt.Sprintf("%v > %v bytes", len(metadataBytes), sul.MaxMetadataSize))
+	}
+	return nil
+}
+
+// Err returns the error of the exceeded limit if the upload exceeded one of
+// the limits.
+func (ulr *SkyfileUploadLimitReader) Err() error {
+	return ulr.err
+}
+
+// Read implements io.Reader.
+func (ulr *SkyfileUploadLimitReader) Read(b []byte) (int, error) {
+	n, err := ulr.SkyfileUploadReader.Read(b)
+	ulr.n += uint64(n)
+	if limitErr := ulr.limits.CheckUploadSize(ulr.n); limitErr != nil {
+		ulr.err = limitErr
+		return n, limitErr
+	}
+	return n, err
+}
+
+// SetReadBuffer implements SkyfileUploadReader. The data of the buffer is read
+// again, so it's not counted twice.
+func (ulr *SkyfileUploadLimitReader) SetReadBuffer(data []byte) {
+	ulr.n -= uint64(len(data))
+	ulr.SkyfileUploadReader.SetReadBuffer(data)
+}
+
+// SkyfileMetadata implements SkyfileUploadReader. It fails if the metadata
+// exceeds the limits.
+func (ulr *SkyfileUploadLimitReader) SkyfileMetadata(ctx context.Context) (SkyfileMetadata, error) {
+	sm, err := ulr.SkyfileUploadReader.SkyfileMetadata(ctx)
+	if err != nil {
+		return SkyfileMetadata{}, err
+	}
+	if err := ulr.limits.CheckMetadata(sm); err != nil {
+		ulr.err = err
+		return SkyfileMetadata{}, err
+	}
+	return sm, nil
+}
@@ -0,0 +1,128 @@
+package skymodules
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+// TestSkyfileUploadLimits is a unit test for the SkyfileUploadLimits.
+func TestSkyfileUploadLimits(t *testing.T) {
+	t.Parallel()
+
+	sm := SkyfileMetadata{
+		Filename: "dir",
+		Subfiles: SkyfileSubfiles{
+			"a": {Filename: "a", Len: 1},
+			"b": {Filename: "b", Len: 2, Offset: 1},
+		},
+	}
+	metadataBytes, err := SkyfileMetadataBytes(sm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without limits, everything is allowed.
+	var sul SkyfileUploadLimits
+	if err := sul.CheckUploadSize(1 << 40); err != nil {
+		t.Fatal(err)
+	}
+	if err := sul.CheckMetadata(sm); err != nil {
+		t.Fatal(err)
+	}
+
+	// Check the upload size.
+	sul.MaxUploadSize = 100
+	if err := sul.CheckUploadSize(100); err != nil {
+		t.Fatal(err)
+	}
+	if err := sul.CheckUploadSize(101); !errors.Contains(err, ErrSkyfileUploadTooLarge) {
+		t.Fatal("wrong error", err)
+	}
+
+	// Check the number of subfiles.
+	sul.MaxSubfiles = 2
+	if err := sul.CheckMetadata(sm); err != nil {
+		t.Fatal(err)
+	}
+	sul.MaxSubfiles = 1
+	if err := sul.CheckMetadata(sm); !errors.Contains(err, ErrSkyfileTooManySubfiles) {
+		t.Fatal("wrong error", err)
+	}
+
+	// Check the metadata size.
+	sul.MaxSubfiles = 0
+	sul.MaxMetadataSize = uint64(len(metadataBytes))
+	if err := sul.CheckMetadata(sm); err != nil {
+		t.Fatal(err)
+	}
+	sul.MaxMetadataSize--
+	if err := sul.CheckMetadata(sm); !errors.Contains(err, ErrSkyfileMetadataTooLarge) {
+		t.Fatal("wrong error", err)
+	}
+}
+
+// TestSkyfileUploadLimitReader is a unit test for the
+// SkyfileUploadLimitReader.
+func TestSkyfileUploadLimitReader(t *testing.T) {
+	t.Parallel()
+
+	data := []byte("abcdefghij")
+	sm := SkyfileMetadata{
+		Filename: "dir",
+		Subfiles: SkyfileSubfiles{
+			"a": {Filename: "a", Len: 4},
+			"b": {Filename: "b", Len: 6, Offset: 4},
+		},
+	}
+
+	// An upload within the limits can be read entirely, even if part of it
+	// is read twice.
+	limits := SkyfileUploadLimits{MaxUploadSize: uint64(len(data)), MaxSubfiles: 2}
+	ulr := NewSkyfileUploadLimitReader(NewSkyfileReaderWithMetadata(bytes.NewReader(data), sm), limits)
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(ulr, buf); err != nil {
+		t.Fatal(err)
+	}
+	ulr.SetReadBuffer(buf)
+	read, err := ioutil.ReadAll(ulr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(read, data) {
+		t.Fatal("wrong data", string(read))
+	}
+	if _, err := ulr.SkyfileMetadata(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if ulr.Err() != nil {
+		t.Fatal("unexpected error", ulr.Err())
+	}
+
+	// An upload exceeding the max upload size fails while it's read.
+	limits.MaxUploadSize--
+	ulr = NewSkyfileUploadLimitReader(NewSkyfileReaderWithMetadata(bytes.NewReader(data), sm), limits)
+	if _, err := ioutil.ReadAll(ulr); !errors.Contains(err, ErrSkyfileUploadTooLarge) {
+		t.Fatal("wrong error", err)
+	}
+	if !errors.Contains(ulr.Err(), ErrSkyfileUploadTooLarge) {
+		t.Fatal("wrong error", ulr.Err())
+	}
+
+	// An upload with too many subfiles fails when its metadata is fetched.
+	limits = SkyfileUploadLimits{MaxSubfiles: 1}
+	ulr = NewSkyfileUploadLimitReader(NewSkyfileReaderWithMetadata(bytes.NewReader(data), sm), limits)
+	if _, err := ioutil.ReadAll(ulr); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ulr.SkyfileMetadata(context.Background()); !errors.Contains(err, ErrSkyfileTooManySubfiles) {
+		t.Fatal("wrong error", err)
+	}
+	if !errors.Contains(ulr.Err(), ErrSkyfileTooManySubfiles) {
+		t.Fatal("wrong error", ulr.Err())
+	}
+}
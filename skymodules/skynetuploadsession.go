@@ -0,0 +1,37 @@
+package skymodules
+
+import (
+	"gitlab.com/NebulousLabs/errors"
+)
+
+// An upload session allows for resuming the upload of a large skyfile after it
+// was interrupted, e.g. by a restart of the renter. The session is identified
+// by an ID chosen by the uploader. The chunks of the skyfile's fanout are
+// recorded in the session once they are available on the network. Uploading
+// the same content with the same session ID again only uploads the chunks
+// which are not yet recorded and then creates the skylink.
+
+const (
+	// MaxUploadSessionIDLength is the maximum length of the ID of an upload
+	// session.
+	MaxUploadSessionIDLength = 64
+)
+
+var (
+	// ErrUploadSessionIDTooLong is returned if the ID of an upload session
+	// exceeds MaxUploadSessionIDLength.
+	ErrUploadSessionIDTooLong = errors.New("upload session id exceeds the max length")
+
+	// ErrUploadSessionEncrypted is returned if an upload session is used for
+	// an encrypted skyfile. The file-specific key of an encrypted skyfile is
+	// random which prevents the skylink from being recreated when resuming.
+	ErrUploadSessionEncrypted = errors.New("upload sessions are not supported for encrypted skyfiles")
+
+	// ErrUploadSessionSiaPathMismatch is returned if an upload session is
+	// resumed with a different siapath.
+	ErrUploadSessionSiaPathMismatch = errors.New("upload session was started with a different siapath")
+
+	// ErrUploadSessionContentMismatch is returned if the content of a resumed
+	// upload session doesn't match the already uploaded content.
+	ErrUploadSessionContentMismatch = errors.New("content doesn't match the content uploaded in the upload session")
+)
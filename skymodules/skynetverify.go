@@ -0,0 +1,149 @@
+package skymodules
+
+import (
+	"fmt"
+	"hash"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// ErrSkyfileVerificationFailed is returned if the data of a skyfile
+	// doesn't match the data committed to by its base sector.
+	ErrSkyfileVerificationFailed = errors.New("skyfile data doesn't match the skylink")
+
+	// ErrSkyfileVerificationUnsupported is returned when trying to verify an
+	// encrypted skyfile.
+	ErrSkyfileVerificationUnsupported = errors.New("verification of encrypted skyfiles is not supported")
+)
+
+// SkyfileVerifier verifies the data of a skyfile against its base sector. The
+// data is written to the verifier in order and is verified either against the
+// payload of the base sector or against the piece roots of the fanout. Since
+// the data pieces of an unencrypted skyfile are stored unmodified, the verifier
+// doesn't need to erasure code the data to verify it.
+type SkyfileVerifier struct {
+	staticLayout         SkyfileLayout
+	staticFanout         []byte
+	staticPayloadHash    crypto.Hash
+	staticPiecesPerChunk uint64
+
+	// payloadHasher is used for skyfiles without a fanout.
+	payloadHasher hash.Hash
+
+	// piece is the partially written data piece of the current chunk and
+	// pieceIndex is the index of that piece within the whole fanout.
+	piece      []byte
+	pieceIndex uint64
+
+	written uint64
+}
+
+// NewSkyfileVerifier creates a verifier for the skyfile with the given base
+// sector. The base sector is expected to have been downloaded by its merkle
+// root.
+func NewSkyfileVerifier(baseSector []byte) (*SkyfileVerifier, error) {
+	if IsEncryptedBaseSector(baseSector) {
+		return nil, ErrSkyfileVerificationUnsupported
+	}
+	layout, fanoutBytes, _, _, payload, err := ParseSkyfileMetadata(baseSector)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to parse base sector")
+	}
+	sv := &SkyfileVerifier{
+		staticLayout: layout,
+		staticFanout: fanoutBytes,
+	}
+	if layout.FanoutSize == 0 {
+		sv.staticPayloadHash = crypto.HashBytes(payload)
+		sv.payloadHasher = crypto.NewHash()
+		return sv, nil
+	}
+	piecesPerChunk, _, _, err := DecodeFanout(layout, fanoutBytes)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to decode fanout")
+	}
+	sv.staticPiecesPerChunk = piecesPerChunk
+	sv.piece = make([]byte, 0, modules.SectorSize)
+	return sv, nil
+}
+
+// Write implements io.Writer. It verifies every data piece as soon as it is
+// complete.
+func (sv *SkyfileVerifier) Write(b []byte) (int, error) {
+	n := len(b)
+	sv.written += uint64(n)
+	if sv.written > sv.staticLayout.Filesize {
+		return 0, errors.AddContext(ErrSkyfileVerificationFailed, "more data than the file contains")
+	}
+	if sv.payloadHasher != nil {
+		return sv.payloadHasher.Write(b)
+	}
+	for len(b) > 0 {
+		toCopy := int(modules.SectorSize) - len(sv.piece)
+		if toCopy > len(b) {
+			toCopy = len(b)
+		}
+		sv.piece = append(sv.piece, b[:toCopy]...)
+		b = b[toCopy:]
+		if uint64(len(sv.piece)) == modules.SectorSize {
+			if err := sv.verifyPiece(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Verify verifies the remaining data and makes sure that the whole file was
+// written.
+func (sv *SkyfileVerifier) Verify() error {
+	if sv.written != sv.staticLayout.Filesize {
+		return errors.AddContext(ErrSkyfileVerificationFailed, fmt.Sprintf("expected %v bytes but got %v", sv.staticLayout.Filesize, sv.written))
+	}
+	if sv.payloadHasher != nil {
+		var payloadHash crypto.Hash
+		copy(payloadHash[:], sv.payloadHasher.Sum(nil))
+		if payloadHash != sv.staticPayloadHash {
+			return ErrSkyfileVerificationFailed
+		}
+		return nil
+	}
+	if len(sv.piece) > 0 {
+		return sv.verifyPiece()
+	}
+	return nil
+}
+
+// verifyPiece pads the current piece, computes its root and compares it to the
+// corresponding root in the fanout.
+func (sv *SkyfileVerifier) verifyPiece() error {
+	dataPieces := uint64(sv.staticLayout.FanoutDataPieces)
+	chunkIndex := sv.pieceIndex / dataPieces
+	pieceInChunk := sv.pieceIndex % dataPieces
+
+	// 1-of-N fanouts only contain a single root per chunk.
+	rootIndex := chunkIndex*sv.staticPiecesPerChunk + pieceInChunk
+	if sv.staticPiecesPerChunk == 1 {
+		rootIndex = chunkIndex
+	}
+	offset := rootIndex * crypto.HashSize
+	if offset+crypto.HashSize > uint64(len(sv.staticFanout)) {
+		return errors.AddContext(ErrSkyfileVerificationFailed, "fanout doesn't contain enough roots")
+	}
+	var expected crypto.Hash
+	copy(expected[:], sv.staticFanout[offset:])
+
+	piece := sv.piece
+	if uint64(len(piece)) < modules.SectorSize {
+		piece = append(piece, make([]byte, modules.SectorSize-uint64(len(piece)))...)
+	}
+	if crypto.MerkleRoot(piece) != expected {
+		return errors.AddContext(ErrSkyfileVerificationFailed, fmt.Sprintf("root mismatch for piece %v of chunk %v", pieceInChunk, chunkIndex))
+	}
+	sv.piece = sv.piece[:0]
+	sv.pieceIndex++
+	return nil
+}
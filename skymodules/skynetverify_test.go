@@ -0,0 +1,122 @@
+package skymodules
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestSkyfileVerifier is a unit test for the SkyfileVerifier.
+func TestSkyfileVerifier(t *testing.T) {
+	t.Parallel()
+
+	// buildBaseSector is a helper to create a base sector for the layout,
+	// fanout and payload.
+	buildBaseSector := func(sl SkyfileLayout, fanout, payload []byte) []byte {
+		smBytes, err := SkyfileMetadataBytes(SkyfileMetadata{
+			Filename: "file",
+			Length:   sl.Filesize,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sl.Version = SkyfileVersion
+		sl.MetadataSize = uint64(len(smBytes))
+		sl.FanoutSize = uint64(len(fanout))
+		bs, _ := BuildBaseSector(sl.Encode(), fanout, smBytes, payload)
+		return bs
+	}
+	// verify is a helper which writes the data to a new verifier in small
+	// writes and verifies it.
+	verify := func(baseSector, data []byte) error {
+		sv, err := NewSkyfileVerifier(baseSector)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for len(data) > 0 {
+			n := fastrand.Intn(len(data)) + 1
+			if _, err := sv.Write(data[:n]); err != nil {
+				return err
+			}
+			data = data[n:]
+		}
+		return sv.Verify()
+	}
+
+	// Small file.
+	data := fastrand.Bytes(100)
+	bs := buildBaseSector(SkyfileLayout{Filesize: uint64(len(data))}, nil, data)
+	if err := verify(bs, data); err != nil {
+		t.Fatal(err)
+	}
+	tampered := append([]byte{}, data...)
+	tampered[0]++
+	if err := verify(bs, tampered); !errors.Contains(err, ErrSkyfileVerificationFailed) {
+		t.Fatal("wrong error", err)
+	}
+	if err := verify(bs, data[:50]); !errors.Contains(err, ErrSkyfileVerificationFailed) {
+		t.Fatal("wrong error", err)
+	}
+
+	// Large file with 2-of-3 erasure coding. The file spans 2 chunks.
+	data = fastrand.Bytes(int(3*modules.SectorSize + 100))
+	var fanout []byte
+	for chunk := 0; chunk < 2; chunk++ {
+		for piece := 0; piece < 2; piece++ {
+			start := (chunk*2 + piece) * int(modules.SectorSize)
+			pieceData := make([]byte, modules.SectorSize)
+			if start < len(data) {
+				copy(pieceData, data[start:])
+			}
+			root := crypto.MerkleRoot(pieceData)
+			fanout = append(fanout, root[:]...)
+		}
+		// Random parity root.
+		fanout = append(fanout, fastrand.Bytes(crypto.HashSize)...)
+	}
+	sl := SkyfileLayout{
+		Filesize:           uint64(len(data)),
+		FanoutDataPieces:   2,
+		FanoutParityPieces: 1,
+		CipherType:         crypto.TypePlain,
+	}
+	bs = buildBaseSector(sl, fanout, nil)
+	if err := verify(bs, data); err != nil {
+		t.Fatal(err)
+	}
+	tampered = append([]byte{}, data...)
+	tampered[len(tampered)-1]++
+	if err := verify(bs, tampered); !errors.Contains(err, ErrSkyfileVerificationFailed) {
+		t.Fatal("wrong error", err)
+	}
+	tampered = append([]byte{}, data...)
+	tampered[modules.SectorSize]++
+	if err := verify(bs, tampered); !errors.Contains(err, ErrSkyfileVerificationFailed) {
+		t.Fatal("wrong error", err)
+	}
+
+	// Large file with 1-of-N erasure coding.
+	data = fastrand.Bytes(int(modules.SectorSize + 100))
+	fanout = nil
+	for chunk := 0; chunk < 2; chunk++ {
+		pieceData := make([]byte, modules.SectorSize)
+		copy(pieceData, data[chunk*int(modules.SectorSize):])
+		root := crypto.MerkleRoot(pieceData)
+		fanout = append(fanout, root[:]...)
+	}
+	sl.Filesize = uint64(len(data))
+	sl.FanoutDataPieces = 1
+	sl.FanoutParityPieces = 9
+	bs = buildBaseSector(sl, fanout, nil)
+	if err := verify(bs, data); err != nil {
+		t.Fatal(err)
+	}
+	tampered = append([]byte{}, data...)
+	tampered[10]++
+	if err := verify(bs, tampered); !errors.Contains(err, ErrSkyfileVerificationFailed) {
+		t.Fatal("wrong error", err)
+	}
+}
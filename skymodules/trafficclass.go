@@ -0,0 +1,105 @@
+package skymodules
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// TrafficClassInteractive is the class of traffic a user is actively
+	// waiting for, e.g. the first bytes of a download or a registry lookup.
+	TrafficClassInteractive = TrafficClass("interactive")
+	// TrafficClassStreaming is the class of traffic that is fetched ahead of
+	// a consumer reading a stream.
+	TrafficClassStreaming = TrafficClass("streaming")
+	// TrafficClassRepair is the class of traffic caused by repairing files.
+	TrafficClassRepair = TrafficClass("repair")
+	// TrafficClassBackup is the class of traffic caused by creating and
+	// fetching backups.
+	TrafficClassBackup = TrafficClass("backup")
+)
+
+const (
+	// MaxTrafficShare is the maximum share of the renter's bandwidth that can
+	// be assigned to a traffic class.
+	MaxTrafficShare = 100
+)
+
+var (
+	// TrafficClasses are all traffic classes ordered by priority, starting
+	// with the class with the highest priority.
+	TrafficClasses = []TrafficClass{
+		TrafficClassInteractive,
+		TrafficClassStreaming,
+		TrafficClassRepair,
+		TrafficClassBackup,
+	}
+
+	// ErrUnknownTrafficClass is returned when an unknown traffic class is
+	// specified.
+	ErrUnknownTrafficClass = fmt.Errorf("unknown traffic class, allowed values are: '%v', '%v', '%v' and '%v'", TrafficClassInteractive, TrafficClassStreaming, TrafficClassRepair, TrafficClassBackup)
+)
+
+type (
+	// TrafficClass describes the kind of traffic caused by a request to a
+	// host. Jobs of a class with a higher priority are executed before jobs of
+	// a class with a lower priority and every class can be limited to a share
+	// of the renter's bandwidth.
+	TrafficClass string
+
+	// TrafficShares are the shares of the renter's bandwidth limits that the
+	// traffic classes are allowed to use, in percent. A share of 0 means that
+	// the class is only limited by the renter's bandwidth limits.
+	TrafficShares struct {
+		Interactive uint64 `json:"interactive"`
+		Streaming   uint64 `json:"streaming"`
+		Repair      uint64 `json:"repair"`
+		Backup      uint64 `json:"backup"`
+	}
+)
+
+// ParseTrafficClass parses a traffic class from a string.
+func ParseTrafficClass(s string) (TrafficClass, error) {
+	class := TrafficClass(strings.ToLower(s))
+	if class.Priority() < 0 {
+		return "", ErrUnknownTrafficClass
+	}
+	return class, nil
+}
+
+// Priority returns the priority of the class. Lower values indicate a higher
+// priority. Unknown classes have a priority of -1.
+func (tc TrafficClass) Priority() int {
+	for i, class := range TrafficClasses {
+		if class == tc {
+			return i
+		}
+	}
+	return -1
+}
+
+// Share returns the share of the given traffic class.
+func (ts TrafficShares) Share(tc TrafficClass) uint64 {
+	switch tc {
+	case TrafficClassInteractive:
+		return ts.Interactive
+	case TrafficClassStreaming:
+		return ts.Streaming
+	case TrafficClassRepair:
+		return ts.Repair
+	case TrafficClassBackup:
+		return ts.Backup
+	default:
+		return 0
+	}
+}
+
+// Validate checks the traffic shares for validity.
+func (ts TrafficShares) Validate() error {
+	for _, class := range TrafficClasses {
+		if share := ts.Share(class); share > MaxTrafficShare {
+			return fmt.Errorf("share of traffic class '%v' can't exceed %v%%, was %v%%", class, MaxTrafficShare, share)
+		}
+	}
+	return nil
+}
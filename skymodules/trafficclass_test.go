@@ -0,0 +1,47 @@
+package skymodules
+
+import (
+	"testing"
+)
+
+// TestTrafficClasses is a unit test for the traffic classes and shares.
+func TestTrafficClasses(t *testing.T) {
+	t.Parallel()
+
+	// Parse the classes.
+	for _, s := range []string{"interactive", "Streaming", "REPAIR", "backup"} {
+		if _, err := ParseTrafficClass(s); err != nil {
+			t.Fatal(s, err)
+		}
+	}
+	for _, s := range []string{"", "bulk"} {
+		if _, err := ParseTrafficClass(s); err != ErrUnknownTrafficClass {
+			t.Fatal("wrong error", s, err)
+		}
+	}
+
+	// Check the priorities.
+	if TrafficClassInteractive.Priority() >= TrafficClassStreaming.Priority() ||
+		TrafficClassStreaming.Priority() >= TrafficClassRepair.Priority() ||
+		TrafficClassRepair.Priority() >= TrafficClassBackup.Priority() {
+		t.Fatal("wrong priorities")
+	}
+	if TrafficClass("bulk").Priority() != -1 {
+		t.Fatal("unknown class should have no priority")
+	}
+
+	// Check the shares.
+	ts := TrafficShares{Interactive: 1, Streaming: 2, Repair: 3, Backup: 4}
+	for i, class := range TrafficClasses {
+		if ts.Share(class) != uint64(i+1) {
+			t.Fatal("wrong share", class, ts.Share(class))
+		}
+	}
+	if err := ts.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	ts.Repair = MaxTrafficShare + 1
+	if err := ts.Validate(); err == nil {
+		t.Fatal("should fail")
+	}
+}